@@ -89,12 +89,27 @@ func newRunCmd() *cobra.Command {
 	// ResourcePool flags.
 	cmd.Flags().StringVar(&opts.ResourcePool, "resource-pool", "",
 		"Resource Pool the agent belongs to")
+	cmd.Flags().StringVar(&opts.RegistrationToken, "registration-token", "",
+		"Token that lets the agent join the resource pool when the master requires one")
+	cmd.Flags().IntVar(&opts.MaxZeroSlotContainers, "max-zero-slot-containers", 0,
+		"Maximum number of zero-slot containers that the agent runs at once, below the limit of "+
+			"its resource pool (0 for the pool's limit)")
+
+	// Interruption flags.
+	cmd.Flags().StringVar(&opts.InterruptionWatcher, "interruption-watcher", "",
+		"Cloud provider whose metadata service announces interruptions of the agent's spot or "+
+			"preemptible instance (aws or gcp)")
 
 	// Container flags.
 	cmd.Flags().StringVar(&opts.ContainerMasterHost, "container-master-host", "",
 		"Master hostname that containers started by this agent will connect to")
 	cmd.Flags().IntVar(&opts.ContainerMasterPort, "container-master-port", 0,
 		"Master port that containers started by this agent will connect to")
+	cmd.Flags().StringVar(&opts.ContainerRuntime, "container-runtime", "docker",
+		"Container runtime to run tasks with (docker, containerd, singularity or apptainer)")
+	cmd.Flags().StringVar(&opts.ContainerRuntimeDir, "container-runtime-dir",
+		"/var/tmp/determined-agent",
+		"Directory for images and task files of the containerd, Singularity and Apptainer runtimes")
 
 	// Device flags.
 	cmd.Flags().StringVar(&opts.SlotType, "slot-type", "auto", "slot type to expose")
@@ -116,6 +131,18 @@ func newRunCmd() *cobra.Command {
 		&opts.Security.TLS.MasterCertName, "security-tls-master-cert-name", "",
 		"expected address in the master TLS certificate (if different than the one used for connecting)",
 	)
+	cmd.Flags().StringVar(
+		&opts.Security.TLS.ClientCert, "security-tls-client-cert", "",
+		"certificate file that the master issued to the agent, for mTLS",
+	)
+	cmd.Flags().StringVar(
+		&opts.Security.TLS.ClientKey, "security-tls-client-key", "",
+		"private key file of the certificate that the master issued to the agent",
+	)
+	cmd.Flags().StringVar(
+		&opts.Security.UsernsRemapUser, "security-userns-remap-user", "",
+		"user whose subordinate ID ranges Docker uses for user namespaces (if not the default)",
+	)
 
 	// Debug flags.
 	cmd.Flags().IntVar(&opts.ArtificialSlots, "artificial-slots", 0, "")
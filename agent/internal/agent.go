@@ -27,6 +27,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
 	proto "github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -43,7 +44,8 @@ type agent struct {
 	Version               string
 	Options               `json:"options"`
 	MasterSetAgentOptions *proto.MasterSetAgentOptions
-	Devices               []device.Device `json:"devices"`
+	Devices               []device.Device  `json:"devices"`
+	Topology              *device.Topology `json:"topology"`
 
 	socket *actor.Ref
 	cm     *actor.Ref
@@ -51,6 +53,8 @@ type agent struct {
 
 	masterProto  string
 	masterClient *http.Client
+
+	interruptions interruptionWatcher
 }
 
 func newAgent(version string, options Options) *agent {
@@ -81,6 +85,16 @@ func (a *agent) Receive(ctx *actor.Context) error {
 			ctx.Tell(a.cm, *msg.StartContainer)
 		case msg.SignalContainer != nil:
 			ctx.Tell(a.cm, *msg.SignalContainer)
+		case msg.PullImages != nil:
+			ctx.Tell(a.cm, *msg.PullImages)
+		case msg.ReattachContainers != nil:
+			ctx.Tell(a.cm, *msg.ReattachContainers)
+		case msg.ConfigureMIG != nil:
+			a.applyMIGLayouts(ctx, *msg.ConfigureMIG)
+		case msg.ShutdownAgent != nil:
+			ctx.Log().Infof("shutting down agent at the request of the master: %s",
+				msg.ShutdownAgent.Reason)
+			ctx.Self().Stop()
 		default:
 			panic(fmt.Sprintf("unknown message received: %+v", msg))
 		}
@@ -95,10 +109,23 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		if a.socket != nil {
 			ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{ContainerLog: &msg}})
 		}
+	case proto.ContainerProfile:
+		if a.socket != nil {
+			ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{ContainerProfile: &msg}})
+		}
 
 	case model.TrialLog:
 		return a.postTrialLog(msg)
 
+	case heartbeatTick:
+		a.heartbeat(ctx)
+
+	case profileTick:
+		a.profile(ctx)
+
+	case interruptionCheckTick:
+		a.checkInterruption(ctx)
+
 	case actor.ChildFailed:
 		switch msg.Child {
 		case a.socket:
@@ -240,6 +267,8 @@ func (a *agent) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 			}
 		case msg.SignalContainer != nil:
 			ctx.Tell(a.cm, *msg.SignalContainer)
+		case msg.PullImages != nil:
+			ctx.Tell(a.cm, *msg.PullImages)
 		default:
 			ctx.Respond(errors.Errorf("unknown message received"))
 		}
@@ -266,11 +295,21 @@ func (a *agent) tlsConfig() (*tls.Config, error) {
 		}
 	}
 
+	var certs []tls.Certificate
+	if certFile := a.Options.Security.TLS.ClientCert; certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, a.Options.Security.TLS.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read client certificate")
+		}
+		certs = append(certs, cert)
+	}
+
 	return &tls.Config{
 		InsecureSkipVerify: a.Options.Security.TLS.SkipVerify, //nolint:gosec
 		MinVersion:         tls.VersionTLS12,
 		RootCAs:            pool,
 		ServerName:         a.Options.Security.TLS.MasterCertName,
+		Certificates:       certs,
 	}, nil
 }
 
@@ -312,7 +351,11 @@ func (a *agent) makeMasterWebsocket(ctx *actor.Context) error {
 	masterAddr := fmt.Sprintf("%s://%s:%d/agents?id=%s&resource_pool=%s",
 		masterProto, a.MasterHost, a.MasterPort, a.AgentID, a.ResourcePool)
 	ctx.Log().Infof("connecting to master at: %s", masterAddr)
-	conn, resp, err := dialer.Dial(masterAddr, nil)
+	header := http.Header{}
+	if a.RegistrationToken != "" {
+		header.Set(proto.RegistrationTokenHeader, a.RegistrationToken)
+	}
+	conn, resp, err := dialer.Dial(masterAddr, header)
 	if err != nil {
 		return errors.Wrap(err, "error connecting to master")
 	} else if err = resp.Body.Close(); err != nil {
@@ -343,19 +386,29 @@ func (a *agent) connect(ctx *actor.Context) error {
 }
 
 func (a *agent) setup(ctx *actor.Context) error {
-	fluentActor, err := newFluentActor(a.Options, *a.MasterSetAgentOptions)
-	if err != nil {
-		return errors.Wrap(err, "failed to start Fluent daemon")
+	// Only Docker can ship container output to Fluent Bit; other runtimes send it to the agent.
+	var fluentPort int
+	if usesFluentBit(a.Options) {
+		fluentActor, err := newFluentActor(a.Options, *a.MasterSetAgentOptions)
+		if err != nil {
+			return errors.Wrap(err, "failed to start Fluent daemon")
+		}
+		a.fluent, _ = ctx.ActorOf("fluent", fluentActor)
+		fluentPort = fluentActor.port
 	}
-	a.fluent, _ = ctx.ActorOf("fluent", fluentActor)
 
-	if err = a.detect(); err != nil {
+	if err := a.detect(); err != nil {
 		return err
 	}
 	ctx.Log().Info("detected compute devices:")
 	for _, d := range a.Devices {
 		ctx.Log().Infof("\t%s", d.String())
 	}
+	topology, err := detectTopology(a.Devices)
+	if err != nil {
+		ctx.Log().WithError(err).Warn("error detecting GPU topology")
+	}
+	a.Topology = topology
 
 	v, err := getNvidiaVersion()
 	if err != nil {
@@ -372,17 +425,39 @@ func (a *agent) setup(ctx *actor.Context) error {
 		}
 	}
 
-	cm, err := newContainerManager(a, fluentActor.port)
+	cm, err := newContainerManager(a, fluentPort)
 	if err != nil {
 		return errors.Wrap(err, "error initializing container manager")
 	}
 	a.cm, _ = ctx.ActorOf("containers", cm)
+	// The master reattaches the tasks that it knows of to the containers that are still running
+	// and has the agent kill the others.
+	containers, _ := ctx.Ask(a.cm, findContainers{}).Get().([]cproto.Container)
 
 	ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{AgentStarted: &proto.AgentStarted{
-		Version: a.Version,
-		Devices: a.Devices,
-		Label:   a.Label,
+		Version:    a.Version,
+		Devices:    a.Devices,
+		Label:      a.Label,
+		Platform:   runtime.GOOS + "/" + runtime.GOARCH,
+		Topology:   a.Topology,
+		Containers: containers,
+
+		MaxZeroSlotContainers: a.MaxZeroSlotContainers,
 	}}})
+
+	if a.MasterSetAgentOptions.HeartbeatPeriod > 0 {
+		actors.NotifyAfter(ctx, a.MasterSetAgentOptions.HeartbeatPeriod, heartbeatTick{})
+	}
+	if a.MasterSetAgentOptions.ProfilingPeriod > 0 {
+		actors.NotifyAfter(ctx, a.MasterSetAgentOptions.ProfilingPeriod, profileTick{})
+	}
+
+	if a.InterruptionWatcher != "" {
+		if a.interruptions, err = newInterruptionWatcher(a.InterruptionWatcher); err != nil {
+			return err
+		}
+		actors.NotifyAfter(ctx, interruptionCheckPeriod, interruptionCheckTick{})
+	}
 	return nil
 }
 
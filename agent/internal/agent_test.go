@@ -99,6 +99,7 @@ func defaultAgentConfig() Options {
 		MasterHost:          "localhost",
 		MasterPort:          8080,
 		ContainerMasterHost: defaultContainerMasterHost(),
+		ContainerRuntime:    "docker",
 		SlotType:            "auto",
 		BindIP:              "0.0.0.0",
 		BindPort:            9090,
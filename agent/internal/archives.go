@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+// archiveFetcher downloads the remote archives of containers from the master.
+type archiveFetcher struct {
+	client    *http.Client
+	masterURL string
+}
+
+// newArchiveFetcher returns a fetcher that downloads archives from the master the agent is
+// connected to, or nil if it runs in standalone mode.
+func newArchiveFetcher(a *agent) *archiveFetcher {
+	if a.masterClient == nil {
+		return nil
+	}
+	return &archiveFetcher{
+		client:    a.masterClient,
+		masterURL: fmt.Sprintf("%s://%s:%d", a.masterProto, a.MasterHost, a.MasterPort),
+	}
+}
+
+// fetch downloads remote archives and returns them as archives to copy into the container.
+func (f *archiveFetcher) fetch(
+	ctx context.Context, remote []cproto.RemoteArchive,
+) ([]cproto.RunArchive, error) {
+	if len(remote) == 0 {
+		return nil, nil
+	}
+	if f == nil {
+		return nil, errors.New("cannot download archives without a master")
+	}
+	var archives []cproto.RunArchive
+	for _, r := range remote {
+		data, err := f.download(ctx, r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error downloading artifact %s", r.ArtifactID)
+		}
+		files, err := archive.FromTarGz(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error extracting artifact %s", r.ArtifactID)
+		}
+		for i := range files {
+			files[i].UserID, files[i].GroupID = r.UserID, r.GroupID
+		}
+		archives = append(archives, cproto.RunArchive{Path: r.Path, Archive: files})
+	}
+	return archives, nil
+}
+
+// downloadMessage is a message of the stream of the contents of an artifact that the master sends.
+type downloadMessage struct {
+	Result *struct {
+		Data []byte `json:"data"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// download downloads the contents of the artifact of a remote archive, authenticated as its task.
+func (f *archiveFetcher) download(ctx context.Context, r cproto.RemoteArchive) ([]byte, error) {
+	client, err := f.taskClient(r)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/api/v1/artifacts/%s/download", f.masterURL, r.ArtifactID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Grpc-Metadata-x-task-token", "Bearer "+r.TaskToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.Errorf("the master responded with %s: %s", resp.Status, body)
+	}
+
+	var data bytes.Buffer
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var msg downloadMessage
+		if err := decoder.Decode(&msg); err != nil {
+			return nil, err
+		}
+		switch {
+		case msg.Error != nil:
+			return nil, errors.New(msg.Error.Message)
+		case msg.Result != nil:
+			data.Write(msg.Result.Data)
+		}
+	}
+	return data.Bytes(), nil
+}
+
+// taskClient returns the client to download the archive of a task with, which presents the
+// certificate of the task if the master requires it.
+func (f *archiveFetcher) taskClient(r cproto.RemoteArchive) (*http.Client, error) {
+	transport, ok := f.client.Transport.(*http.Transport)
+	if len(r.TaskCert) == 0 || !ok || transport.TLSClientConfig == nil {
+		return f.client, nil
+	}
+	cert, err := tls.X509KeyPair(r.TaskCert, r.TaskKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the certificate of the task")
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	return &http.Client{Transport: transport}, nil
+}
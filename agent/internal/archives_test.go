@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+func newArtifactServer(t *testing.T, data []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Grpc-Metadata-x-task-token") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/api/v1/artifacts/context/download":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			// Stream the contents in two messages, as the master streams chunks.
+			encoder := json.NewEncoder(w)
+			for _, part := range [][]byte{data[:len(data)/2], data[len(data)/2:]} {
+				msg := map[string]interface{}{"result": map[string]interface{}{"data": part}}
+				if err := encoder.Encode(msg); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}))
+}
+
+func TestArchiveFetcher(t *testing.T) {
+	data, err := archive.ToTarGz(archive.Archive{
+		archive.RootItem("run.py", []byte("print('hello')"), 0o644, tar.TypeReg),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newArtifactServer(t, data)
+	defer server.Close()
+
+	f := &archiveFetcher{client: server.Client(), masterURL: server.URL}
+	archives, err := f.fetch(context.Background(), []cproto.RemoteArchive{{
+		Path: "/run/determined/workdir", ArtifactID: "context", TaskToken: "token",
+		UserID: 1000, GroupID: 1000,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 1 || len(archives[0].Archive) != 1 {
+		t.Fatalf("expected one archive with one file, got %v", archives)
+	}
+	item := archives[0].Archive[0]
+	if item.Path != "run.py" || string(item.Content) != "print('hello')" {
+		t.Errorf("unexpected file %s: %q", item.Path, item.Content)
+	}
+	if item.UserID != 1000 || item.GroupID != 1000 {
+		t.Errorf("expected the file to be owned by 1000:1000, got %d:%d", item.UserID, item.GroupID)
+	}
+
+	_, err = f.fetch(context.Background(), []cproto.RemoteArchive{{
+		ArtifactID: "context", TaskToken: "other",
+	}})
+	if err == nil {
+		t.Error("expected an error downloading with another token")
+	}
+}
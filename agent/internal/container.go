@@ -10,11 +10,11 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -29,11 +29,22 @@ const (
 type containerActor struct {
 	cproto.Container
 	spec          *cproto.Spec
-	client        *client.Client
-	docker        *actor.Ref
+	runtime       containerRuntime
+	fetcher       *archiveFetcher
+	hooks         model.TaskHooksConfig
+	hookEvent     hookEvent
+	runtimeActor  *actor.Ref
 	containerInfo *types.ContainerJSON
+	// reattachTo is the runtime ID of the running container that the actor watches again after
+	// the agent restarted, rather than starting one.
+	reattachTo string
 
 	baseTrialLog model.TrialLog
+
+	// lastSample is the previous sample of the resources the container used, which the next is
+	// compared to, and profileGPUs the utilization of its GPUs when it was asked for the next.
+	lastSample  *containerSampled
+	profileGPUs []aproto.DeviceUtilization
 }
 
 type (
@@ -41,8 +52,24 @@ type (
 	containerReady      struct{}
 )
 
-func newContainerActor(msg aproto.StartContainer, client *client.Client) actor.Actor {
-	return &containerActor{Container: msg.Container, spec: &msg.Spec, client: client}
+func newContainerActor(
+	msg aproto.StartContainer, runtime containerRuntime, fetcher *archiveFetcher,
+	hooks model.TaskHooksConfig, agentID string,
+) actor.Actor {
+	return &containerActor{
+		Container: msg.Container, spec: &msg.Spec, runtime: runtime, fetcher: fetcher, hooks: hooks,
+		hookEvent: newHookEvent(msg, agentID),
+	}
+}
+
+// newReattachedContainerActor returns an actor for a container that the agent found running when
+// it started. The actor has no spec, since the container was started by an earlier run of the
+// agent.
+func newReattachedContainerActor(
+	cont cproto.Container, runtimeID string, runtime containerRuntime,
+) actor.Actor {
+	cont.State = cproto.Starting
+	return &containerActor{Container: cont, runtime: runtime, reattachTo: runtimeID}
 }
 
 // getExtraFluentValues computes the container-specific extra fields to be injected into each Fluent
@@ -73,10 +100,17 @@ func getBaseTrialLog(spec *cproto.Spec) model.TrialLog {
 func (c *containerActor) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		c.docker, _ = ctx.ActorOf("docker", &dockerActor{Client: c.client, spec: c.spec})
+		c.runtimeActor, _ = ctx.ActorOf("runtime", &runtimeActor{
+			runtime: c.runtime, spec: c.spec, fetcher: c.fetcher, hooks: c.hooks,
+			hookEvent: c.hookEvent,
+		})
+		if c.reattachTo != "" {
+			ctx.Tell(c.runtimeActor, reattachContainer{runtimeID: c.reattachTo})
+			return nil
+		}
 		c.transition(ctx, cproto.Pulling)
 		pull := pullImage{PullSpec: c.spec.PullSpec, Name: c.spec.RunSpec.ContainerConfig.Image}
-		ctx.Tell(c.docker, pull)
+		ctx.Tell(c.runtimeActor, pull)
 		c.baseTrialLog = getBaseTrialLog(c.spec)
 
 	case getContainerSummary:
@@ -84,12 +118,12 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 
 	case imagePulled:
 		c.transition(ctx, cproto.Starting)
-		ctx.Tell(c.docker, c.spec.RunSpec)
+		ctx.Tell(c.runtimeActor, c.spec.RunSpec)
 
 	case containerStarted:
 		c.containerInfo = &msg.containerInfo
 
-		if len(c.spec.RunSpec.ChecksConfig.Checks) == 0 {
+		if c.spec == nil || len(c.spec.RunSpec.ChecksConfig.Checks) == 0 {
 			ctx.Tell(ctx.Self(), containerReady{})
 			return nil
 		}
@@ -113,8 +147,10 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 	case aproto.SignalContainer:
 		switch c.State {
 		case cproto.Assigned, cproto.Pulling:
-			switch msg.Signal {
-			case syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL:
+			// Signals with a grace period stop the container, which has no process to signal yet.
+			switch {
+			case msg.GracePeriod > 0, msg.Signal == syscall.SIGINT, msg.Signal == syscall.SIGTERM,
+				msg.Signal == syscall.SIGKILL:
 				ctx.Log().Infof("attempting to stop container while in [%s] state", c.State)
 				ctx.Self().Stop()
 				c.containerStopped(ctx, aproto.ContainerStopped{})
@@ -127,7 +163,14 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 			ctx.Tell(ctx.Self(), msg)
 		case cproto.Running:
 			ctx.Log().Infof("sending signal to container: %s", msg.Signal)
-			ctx.Tell(c.docker, signalContainer{dockerID: c.containerInfo.ID, signal: msg.Signal})
+			ctx.Tell(c.runtimeActor, signalContainer{
+				runtimeID: c.containerInfo.ID, signal: msg.Signal,
+			})
+			if msg.GracePeriod > 0 && msg.Signal != syscall.SIGKILL {
+				actors.NotifyAfter(ctx, msg.GracePeriod, aproto.SignalContainer{
+					ContainerID: c.ID, Signal: syscall.SIGKILL,
+				})
+			}
 		case cproto.Terminated:
 			ctx.Log().Warnf("ignoring signal, container already terminated: %s", msg.Signal)
 		}
@@ -140,13 +183,19 @@ func (c *containerActor) Receive(ctx *actor.Context) error {
 		} else {
 			ctx.Tell(ctx.Self().Parent(), msg)
 		}
+	case profileContainers:
+		c.profile(ctx, msg)
+
+	case containerSampled:
+		c.sampled(ctx, msg)
+
 	case actor.ChildStopped:
 
 	case actor.ChildFailed:
 		c.containerStopped(ctx, aproto.ContainerError(aproto.ContainerFailed, msg.Error))
 		return msg.Error
 
-	case dockerErr:
+	case runtimeErr:
 		c.containerStopped(ctx, aproto.ContainerError(aproto.ContainerFailed, msg.Error))
 		return msg.Error
 
@@ -0,0 +1,345 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Container runtimes that an agent can be configured to launch task containers with.
+const (
+	dockerRuntimeName      = "docker"
+	containerdRuntimeName  = "containerd"
+	singularityRuntimeName = "singularity"
+	apptainerRuntimeName   = "apptainer"
+)
+
+var containerRuntimeNames = []string{
+	dockerRuntimeName, containerdRuntimeName, singularityRuntimeName, apptainerRuntimeName,
+}
+
+// containerRuntime is the interface between the agent and the engine that runs its task
+// containers. Methods block until the operation completes; runtimeActor calls them from
+// goroutines so that container actors stay responsive.
+type containerRuntime interface {
+	// PullImage makes the image available locally, reporting progress to the logger.
+	PullImage(ctx context.Context, req pullImage, logger containerLogger) error
+	// CreateContainer prepares, but does not start, a container with the spec's archives in place
+	// and returns the runtime's ID for it.
+	CreateContainer(
+		ctx context.Context, spec container.RunSpec, logger containerLogger,
+	) (string, error)
+	// StartContainer starts a created container. It returns the container's details and a channel
+	// that receives the container's exit once all of its output has been sent to the logger.
+	StartContainer(
+		ctx context.Context, id string, spec container.RunSpec, logger containerLogger,
+	) (types.ContainerJSON, <-chan containerExit, error)
+	// SignalContainer sends a signal to the main process of a running container.
+	SignalContainer(ctx context.Context, id string, signal syscall.Signal) error
+	// RemoveContainer cleans up a container that has exited.
+	RemoveContainer(ctx context.Context, id string) error
+	// PinImage keeps pruning of unused images from removing a pulled image.
+	PinImage(ctx context.Context, name string) error
+	// RunningContainers returns the running task containers of an agent, e.g. those that it left
+	// running when it restarted.
+	RunningContainers(ctx context.Context, agentID string) ([]runningContainer, error)
+	// ReattachContainer watches a running container again, returning what StartContainer does for
+	// a container that it starts.
+	ReattachContainer(
+		ctx context.Context, id string,
+	) (types.ContainerJSON, <-chan containerExit, error)
+	// ContainerStats returns the resources that a running container has used so far.
+	ContainerStats(ctx context.Context, id string) (containerStats, error)
+}
+
+// containerStats are the cumulative resource usage of a container, from which the agent computes
+// the utilization between two samples.
+type containerStats struct {
+	cpuSeconds  float64
+	memoryBytes uint64
+	// The network counters are zero for containers that share the network of the host.
+	networkRxBytes uint64
+	networkTxBytes uint64
+}
+
+// runningContainer is a task container that a runtime found running.
+type runningContainer struct {
+	id     string
+	labels map[string]string
+}
+
+var unsafeImageNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// sanitizedImageName returns the image name with only the characters that container and file
+// names allow.
+func sanitizedImageName(name string) string {
+	return unsafeImageNameChars.ReplaceAllString(name, "_")
+}
+
+// containerExit is the outcome of a container run: its exit code, or an error if waiting on the
+// container failed.
+type containerExit struct {
+	code int64
+	err  error
+}
+
+// newContainerRuntime returns the container runtime that the agent is configured to use.
+func newContainerRuntime(opts Options) (containerRuntime, error) {
+	switch opts.ContainerRuntime {
+	case "", dockerRuntimeName:
+		return newDockerRuntime(opts)
+	case containerdRuntimeName:
+		return newContainerdRuntime(opts)
+	case singularityRuntimeName, apptainerRuntimeName:
+		return newSingularityRuntime(opts)
+	default:
+		return nil, errors.Errorf("unknown container runtime: %s", opts.ContainerRuntime)
+	}
+}
+
+// usesFluentBit returns true if the runtime ships task logs through the Fluent Bit daemon that the
+// agent runs alongside it, rather than sending container output to the agent.
+func usesFluentBit(opts Options) bool {
+	return opts.ContainerRuntime == "" || opts.ContainerRuntime == dockerRuntimeName
+}
+
+type (
+	reattachContainer struct {
+		runtimeID string
+	}
+	signalContainer struct {
+		runtimeID string
+		signal    syscall.Signal
+	}
+	sampleContainer struct {
+		runtimeID string
+	}
+	containerSampled struct {
+		stats containerStats
+		time  time.Time
+		err   error
+	}
+	pullImage struct {
+		container.PullSpec
+		Name string
+	}
+	imagePulled      struct{}
+	containerStarted struct {
+		runtimeID     string
+		containerInfo types.ContainerJSON
+	}
+	containerTerminated struct {
+		ExitCode int64
+	}
+	runtimeErr struct{ Error error }
+)
+
+// runtimeActor drives a single container through a containerRuntime on behalf of its container
+// actor.
+type runtimeActor struct {
+	runtime containerRuntime
+	spec    *container.Spec
+	fetcher *archiveFetcher
+	hooks   model.TaskHooksConfig
+	// hookEvent is the event of the hooks of the container, without the hook set.
+	hookEvent hookEvent
+}
+
+func (r *runtimeActor) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+
+	case pullImage:
+		go r.pullImage(ctx, msg)
+
+	case container.RunSpec:
+		go r.runContainer(ctx, msg)
+
+	case reattachContainer:
+		go r.reattachContainer(ctx, msg)
+
+	case signalContainer:
+		go r.signalContainer(ctx, msg)
+
+	case sampleContainer:
+		go r.sampleContainer(ctx, msg)
+
+	case actor.PostStop:
+	}
+	return nil
+}
+
+func (r *runtimeActor) pullImage(ctx *actor.Context, msg pullImage) {
+	if err := r.runtime.PullImage(context.Background(), msg, newContainerLogger(ctx)); err != nil {
+		sendErr(ctx, err)
+		return
+	}
+	ctx.Tell(ctx.Sender(), imagePulled{})
+}
+
+func (r *runtimeActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
+	if !r.spec.RunSpec.UseFluentLogging {
+		msg.HostConfig.AutoRemove = false
+	}
+
+	logger := newContainerLogger(ctx)
+	if len(msg.RemoteArchives) > 0 {
+		logger.aux("downloading files from the master")
+		remote, err := r.fetcher.fetch(context.Background(), msg.RemoteArchives)
+		if err != nil {
+			sendErr(ctx, err)
+			return
+		}
+		msg.Archives = append(append([]container.RunArchive{}, msg.Archives...), remote...)
+	}
+
+	// Post-stop hooks run once the container is removed, for every container whose pre-start
+	// hooks succeeded, whether it ran or not.
+	if len(r.hooks.PreStart) > 0 {
+		logger.aux("running pre-start hooks")
+		event := r.hookEvent
+		event.Hook = preStartHook
+		err := runTaskHooks(context.Background(), r.hooks.PreStart, event, logger)
+		if err != nil {
+			sendErr(ctx, err)
+			return
+		}
+	}
+	if len(r.hooks.PostStop) > 0 {
+		defer func() {
+			event := r.hookEvent
+			event.Hook = postStopHook
+			err := runTaskHooks(context.Background(), r.hooks.PostStop, event, logger)
+			if err != nil {
+				ctx.Log().WithError(err).Warn("error running post-stop hooks")
+			}
+		}()
+	}
+
+	id, err := r.runtime.CreateContainer(context.Background(), msg, logger)
+	if err != nil {
+		sendErr(ctx, err)
+		return
+	}
+	if !msg.HostConfig.AutoRemove {
+		defer func() {
+			if err = r.runtime.RemoveContainer(context.Background(), id); err != nil {
+				sendErr(ctx, errors.Wrap(err, "error removing container"))
+			}
+		}()
+	}
+
+	info, exits, err := r.runtime.StartContainer(context.Background(), id, msg, logger)
+	if err != nil {
+		sendErr(ctx, err)
+		return
+	}
+	ctx.Tell(ctx.Sender(), containerStarted{runtimeID: id, containerInfo: info})
+
+	exit := <-exits
+	if exit.err != nil {
+		sendErr(ctx, errors.Wrap(exit.err, "error while waiting for container to exit"))
+		return
+	}
+	ctx.Tell(ctx.Sender(), containerTerminated{ExitCode: exit.code})
+}
+
+func (r *runtimeActor) reattachContainer(ctx *actor.Context, msg reattachContainer) {
+	info, exits, err := r.runtime.ReattachContainer(context.Background(), msg.runtimeID)
+	if err != nil {
+		sendErr(ctx, err)
+		return
+	}
+	ctx.Tell(ctx.Sender(), containerStarted{runtimeID: msg.runtimeID, containerInfo: info})
+
+	exit := <-exits
+	if exit.err != nil {
+		sendErr(ctx, errors.Wrap(exit.err, "error while waiting for container to exit"))
+		return
+	}
+	ctx.Tell(ctx.Sender(), containerTerminated{ExitCode: exit.code})
+}
+
+func (r *runtimeActor) signalContainer(ctx *actor.Context, msg signalContainer) {
+	if err := r.runtime.SignalContainer(context.Background(), msg.runtimeID, msg.signal); err != nil {
+		sendErr(ctx, errors.Wrap(err, "error while killing container"))
+	}
+}
+
+func (r *runtimeActor) sampleContainer(ctx *actor.Context, msg sampleContainer) {
+	stats, err := r.runtime.ContainerStats(context.Background(), msg.runtimeID)
+	ctx.Tell(ctx.Sender(), containerSampled{stats: stats, time: time.Now().UTC(), err: err})
+}
+
+func sendErr(ctx *actor.Context, err error) {
+	ctx.Tell(ctx.Sender(), runtimeErr{Error: err})
+}
+
+// containerLogger forwards progress and output from a container runtime to the container actor
+// that requested the operation. A logger without a recipient writes to the agent's log instead.
+type containerLogger struct {
+	ctx       *actor.Context
+	recipient *actor.Ref
+}
+
+func newContainerLogger(ctx *actor.Context) containerLogger {
+	return containerLogger{ctx: ctx, recipient: ctx.Sender()}
+}
+
+// aux sends a message from the agent about the container.
+func (l containerLogger) aux(msg string) {
+	if l.recipient == nil {
+		l.ctx.Log().Info(msg)
+		return
+	}
+	l.ctx.Tell(l.recipient, aproto.ContainerLog{
+		Timestamp:  time.Now().UTC(),
+		AuxMessage: &msg,
+	})
+}
+
+// pull sends image pull progress.
+func (l containerLogger) pull(msg jsonmessage.JSONMessage) {
+	if l.recipient == nil {
+		l.ctx.Log().Debugf("%s %s", msg.ID, msg.Status)
+		return
+	}
+	l.ctx.Tell(l.recipient, aproto.ContainerLog{
+		Timestamp:   time.Now().UTC(),
+		PullMessage: &msg,
+	})
+}
+
+// output returns a writer for one of the container's output streams.
+func (l containerLogger) output(stdType stdcopy.StdType) io.Writer {
+	return demultiplexer{ctx: l.ctx, stdType: stdType, recipient: l.recipient}
+}
+
+type demultiplexer struct {
+	ctx       *actor.Context
+	stdType   stdcopy.StdType
+	recipient *actor.Ref
+}
+
+func (d demultiplexer) Write(p []byte) (n int, err error) {
+	d.ctx.Tell(d.recipient, aproto.ContainerLog{
+		Timestamp: time.Now().UTC(),
+		RunMessage: &aproto.RunMessage{
+			Value:   string(p),
+			StdType: d.stdType,
+		},
+	})
+	return len(p), nil
+}
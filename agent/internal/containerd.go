@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// containerdRuntime runs task containers on containerd through nerdctl, its Docker-compatible CLI.
+// Containers always share the host's network.
+type containerdRuntime struct {
+	*processRuntime
+	nerdctl string
+}
+
+func newContainerdRuntime(opts Options) (*containerdRuntime, error) {
+	nerdctl, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, errors.Wrap(err, "the containerd runtime requires nerdctl")
+	}
+	p, err := newProcessRuntime(opts.ContainerRuntimeDir)
+	if err != nil {
+		return nil, err
+	}
+	return &containerdRuntime{processRuntime: p, nerdctl: nerdctl}, nil
+}
+
+// PullImage implements containerRuntime.
+func (c *containerdRuntime) PullImage(
+	ctx context.Context, req pullImage, logger containerLogger,
+) error {
+	if !req.ForcePull {
+		args := []string{"image", "inspect"}
+		if req.Platform != "" {
+			args = append(args, "--platform", req.Platform)
+		}
+		// #nosec G204
+		if err := exec.CommandContext(ctx, c.nerdctl, append(args, req.Name)...).Run(); err == nil {
+			logger.aux(fmt.Sprintf("image already found, skipping pull phase: %s", req.Name))
+			return nil
+		}
+	}
+
+	logger.aux(fmt.Sprintf("pulling image: %s", req.Name))
+	args := []string{"pull"}
+	if req.Platform != "" {
+		args = append(args, "--platform", req.Platform)
+	}
+	cmd := exec.CommandContext(ctx, c.nerdctl, append(args, req.Name)...) // #nosec G204
+	if req.Registry != nil {
+		configDir, err := writeDockerConfig(c.dir, *req.Registry)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.RemoveAll(configDir)
+		}()
+		cmd.Env = append(os.Environ(), "DOCKER_CONFIG="+configDir)
+	}
+	return runLogged(cmd, logger)
+}
+
+// writeDockerConfig writes the registry credentials to a Docker client configuration in a new
+// temporary directory, which the caller must remove.
+func writeDockerConfig(dir string, reg types.AuthConfig) (string, error) {
+	auth := reg.Auth
+	if auth == "" {
+		auth = base64.StdEncoding.EncodeToString([]byte(reg.Username + ":" + reg.Password))
+	}
+	bs, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			reg.ServerAddress: map[string]string{"auth": auth},
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error encoding registry credentials")
+	}
+	configDir, err := ioutil.TempDir(dir, "docker-config-")
+	if err != nil {
+		return "", errors.Wrap(err, "error creating registry credentials directory")
+	}
+	if err = ioutil.WriteFile(filepath.Join(configDir, "config.json"), bs, 0600); err != nil {
+		_ = os.RemoveAll(configDir)
+		return "", errors.Wrap(err, "error writing registry credentials")
+	}
+	return configDir, nil
+}
+
+// runLogged runs a command to completion, sending its output to the logger as agent messages.
+func runLogged(cmd *exec.Cmd, logger containerLogger) error {
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			logger.aux(line)
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error running %s", strings.Join(cmd.Args, " "))
+	}
+	return nil
+}
+
+// StartContainer implements containerRuntime.
+func (c *containerdRuntime) StartContainer(
+	ctx context.Context, id string, spec container.RunSpec, logger containerLogger,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	binds, err := c.stagedBinds(id)
+	if err != nil {
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error reading staged files")
+	}
+	args, env := nerdctlRunArgs(id, spec, append(binds, userBinds(spec)...))
+	// The container must outlive the request that started it, so it is not bound to ctx.
+	cmd := exec.Command(c.nerdctl, args...) // #nosec G204
+	cmd.Env = append(os.Environ(), env...)
+	return c.start(id, spec, cmd, logger)
+}
+
+// nerdctlRunArgs returns the arguments to `nerdctl` that run a container in the foreground and the
+// environment variables to run it with. The values of the container's environment variables are
+// passed through nerdctl's environment rather than its arguments, which any user can read.
+func nerdctlRunArgs(
+	id string, spec container.RunSpec, binds []bindMount,
+) ([]string, []string) {
+	config, host := spec.ContainerConfig, spec.HostConfig
+	args := []string{"run", "--name", id, "--net", "host"}
+	if config.User != "" {
+		args = append(args, "--user", config.User)
+	}
+	if config.WorkingDir != "" {
+		args = append(args, "--workdir", config.WorkingDir)
+	}
+	var env []string
+	for _, e := range config.Env {
+		args = append(args, "--env", strings.SplitN(e, "=", 2)[0])
+		env = append(env, e)
+	}
+	var labels []string
+	for key, value := range config.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+	for _, b := range binds {
+		volume := b.source + ":" + b.target
+		if b.readOnly {
+			volume += ":ro"
+		}
+		args = append(args, "--volume", volume)
+	}
+	if host.ShmSize > 0 {
+		args = append(args, "--shm-size", strconv.FormatInt(host.ShmSize, 10))
+	}
+	if host.NanoCPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(float64(host.NanoCPUs)/1e9, 'f', -1, 64))
+	}
+	if host.Memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(host.Memory, 10))
+	}
+	for _, capability := range host.CapAdd {
+		args = append(args, "--cap-add", capability)
+	}
+	for _, capability := range host.CapDrop {
+		args = append(args, "--cap-drop", capability)
+	}
+	for _, d := range host.Devices {
+		device := d.PathOnHost + ":" + d.PathInContainer
+		if d.CgroupPermissions != "" {
+			device += ":" + d.CgroupPermissions
+		}
+		args = append(args, "--device", device)
+	}
+	if uuids := gpuUUIDs(spec); len(uuids) > 0 {
+		args = append(args, "--gpus", fmt.Sprintf(`"device=%s"`, strings.Join(uuids, ",")))
+	}
+	cmd := []string(config.Cmd)
+	if len(config.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", config.Entrypoint[0])
+		cmd = append(append([]string{}, config.Entrypoint[1:]...), cmd...)
+	}
+	args = append(args, config.Image)
+	return append(args, cmd...), env
+}
+
+// SignalContainer implements containerRuntime.
+func (c *containerdRuntime) SignalContainer(
+	ctx context.Context, id string, signal syscall.Signal,
+) error {
+	// #nosec G204
+	out, err := exec.CommandContext(
+		ctx, c.nerdctl, "kill", "--signal", unix.SignalName(signal), id,
+	).CombinedOutput()
+	return errors.Wrap(err, strings.TrimSpace(string(out)))
+}
+
+// ContainerStats implements containerRuntime. The processes of a container are started by
+// containerd rather than by nerdctl, so they are found from the process of the container.
+func (c *containerdRuntime) ContainerStats(ctx context.Context, id string) (containerStats, error) {
+	// #nosec G204
+	out, err := exec.CommandContext(
+		ctx, c.nerdctl, "inspect", "--format", "{{.State.Pid}}", id,
+	).CombinedOutput()
+	if err != nil {
+		return containerStats{}, errors.Wrap(err, strings.TrimSpace(string(out)))
+	}
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 32)
+	if err != nil {
+		return containerStats{}, errors.Wrapf(err, "unexpected process of container %s", id)
+	}
+	return processTreeStats(int32(pid))
+}
+
+// RemoveContainer implements containerRuntime.
+func (c *containerdRuntime) RemoveContainer(ctx context.Context, id string) error {
+	// #nosec G204
+	out, err := exec.CommandContext(ctx, c.nerdctl, "rm", "--force", id).CombinedOutput()
+	if err != nil {
+		return errors.Wrap(err, strings.TrimSpace(string(out)))
+	}
+	return c.removeStaged(id)
+}
+
+// PinImage implements containerRuntime. nerdctl does not prune images that containers use, so the
+// image is pinned by a container that is created from it but never started.
+func (c *containerdRuntime) PinImage(ctx context.Context, name string) error {
+	holder := pinnedImageContainerPrefix + sanitizedImageName(name)
+	// Replace any previous pin, which may hold an older version of the image.
+	_ = exec.CommandContext(ctx, c.nerdctl, "rm", "--force", holder).Run() // #nosec G204
+	// #nosec G204
+	out, err := exec.CommandContext(
+		ctx, c.nerdctl, "create", "--name", holder,
+		"--label", dockerContainerTypeLabel+"="+pinnedImageTypeValue, name, "true",
+	).CombinedOutput()
+	return errors.Wrap(err, strings.TrimSpace(string(out)))
+}
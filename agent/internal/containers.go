@@ -1,13 +1,14 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"syscall"
 
 	dcontainer "github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
@@ -27,6 +28,9 @@ const (
 	dockerAgentLabel            = "ai.determined.container.agent"
 	dockerClusterLabel          = "ai.determined.container.cluster"
 	dockerMasterLabel           = "ai.determined.container.master"
+	pinnedImageTypeValue        = "pinned-image"
+	taskNetworkTypeValue        = "task-network"
+	pinnedImageContainerPrefix  = "determined-pinned-"
 )
 
 type containerManager struct {
@@ -37,26 +41,44 @@ type containerManager struct {
 	Devices       []device.Device   `json:"devices"`
 
 	fluentPort int
-	docker     *client.Client
+	runtime    containerRuntime
+	fetcher    *archiveFetcher
+	// hooks are what the agent runs before task containers start and after they stop.
+	hooks model.TaskHooksConfig
+	// found are the task containers that were running when the agent started, until the master
+	// tells the agent which of them to reattach to.
+	found map[cproto.ID]foundContainer
 }
 
+// foundContainer is a task container that was running when the agent started.
+type foundContainer struct {
+	cproto.Container
+	runtimeID string
+}
+
+// findContainers asks the container manager for the task containers that were running when the
+// agent started.
+type findContainers struct{}
+
 func newContainerManager(a *agent, fluentPort int) (*containerManager, error) {
 	return &containerManager{
 		MasterInfo: a.MasterSetAgentOptions.MasterInfo,
 		Options:    a.Options,
 		Devices:    a.Devices,
 		fluentPort: fluentPort,
+		fetcher:    newArchiveFetcher(a),
+		hooks:      a.MasterSetAgentOptions.TaskHooks,
 	}, nil
 }
 
 func (c *containerManager) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
-		d, err := client.NewClientWithOpts(client.FromEnv)
+		r, err := newContainerRuntime(c.Options)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "error initializing %s container runtime", c.Options.ContainerRuntime)
 		}
-		c.docker = d
+		c.runtime = r
 
 		masterScheme := httpInsecureScheme
 		if c.Options.Security.TLS.Enabled {
@@ -94,12 +116,18 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 			dockerMasterLabel:        c.MasterInfo.MasterID,
 		}
 
-	case proto.ContainerLog, proto.ContainerStateChanged, model.TrialLog:
+	case proto.ContainerLog, proto.ContainerStateChanged, proto.ContainerProfile, model.TrialLog:
 		ctx.Tell(ctx.Self().Parent(), msg)
 
+	case profileContainers:
+		for _, child := range ctx.Children() {
+			ctx.Tell(child, msg)
+		}
+
 	case proto.StartContainer:
 		msg.Spec = c.overwriteSpec(msg.Container, msg.Spec)
-		if ref, ok := ctx.ActorOf(msg.Container.ID, newContainerActor(msg, c.docker)); !ok {
+		cont := newContainerActor(msg, c.runtime, c.fetcher, c.hooks, c.Options.AgentID)
+		if ref, ok := ctx.ActorOf(msg.Container.ID, cont); !ok {
 			ctx.Log().Warnf("container already created: %s", msg.Container.ID)
 			if ctx.ExpectingResponse() {
 				ctx.Respond(errors.Errorf("container already created: %s", msg.Container.ID))
@@ -116,6 +144,17 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 				msg.Signal, msg.ContainerID)
 		}
 
+	case findContainers:
+		ctx.Respond(c.findContainers(ctx))
+
+	case proto.ReattachContainers:
+		c.reattachContainers(ctx, msg)
+
+	case proto.PullImages:
+		for _, image := range msg.Images {
+			go c.pullImage(ctx, image, msg.Pin)
+		}
+
 	case echo.Context:
 		c.handleAPIRequest(ctx, msg)
 
@@ -128,6 +167,108 @@ func (c *containerManager) Receive(ctx *actor.Context) error {
 	return nil
 }
 
+// findContainers returns the task containers of the agent that were running when it started, e.g.
+// those that it left running when it restarted along with the master.
+func (c *containerManager) findContainers(ctx *actor.Context) []cproto.Container {
+	running, err := c.runtime.RunningContainers(context.Background(), c.Options.AgentID)
+	if err != nil {
+		ctx.Log().WithError(err).Error("error finding the running containers of the agent")
+		return nil
+	}
+	c.found = make(map[cproto.ID]foundContainer, len(running))
+	containers := make([]cproto.Container, 0, len(running))
+	for _, r := range running {
+		cont, err := c.parseContainer(r.labels)
+		if err != nil {
+			ctx.Log().WithError(err).Warnf("killing unknown container %s", r.id)
+			go c.killContainer(ctx, r.id)
+			continue
+		}
+		ctx.Log().Infof("found running container %s of %s", cont.ID, cont.Parent)
+		c.found[cont.ID] = foundContainer{Container: cont, runtimeID: r.id}
+		containers = append(containers, cont)
+	}
+	return containers
+}
+
+// parseContainer returns the container that the labels, which overwriteSpec set, describe.
+func (c *containerManager) parseContainer(labels map[string]string) (cproto.Container, error) {
+	cont := cproto.Container{
+		ID:    cproto.ID(labels[dockerContainerIDLabel]),
+		State: cproto.Running,
+	}
+	if cont.ID == "" {
+		return cproto.Container{}, errors.New("container has no ID")
+	}
+	if err := cont.Parent.UnmarshalText([]byte(labels[dockerContainerParentLabel])); err != nil {
+		return cproto.Container{}, err
+	}
+	devices := make(map[int]device.Device, len(c.Devices))
+	for _, d := range c.Devices {
+		devices[d.ID] = d
+	}
+	for _, slotID := range strings.Split(labels[dockerContainerDevicesLabel], ",") {
+		if slotID == "" {
+			continue
+		}
+		id, err := strconv.Atoi(slotID)
+		if err != nil {
+			return cproto.Container{}, errors.Wrapf(err, "invalid device %s", slotID)
+		}
+		d, ok := devices[id]
+		if !ok {
+			return cproto.Container{}, errors.Errorf("device %d not found on agent", id)
+		}
+		cont.Devices = append(cont.Devices, d)
+	}
+	return cont, nil
+}
+
+// reattachContainers reattaches to the containers that the master claims of those found running
+// when the agent started and kills the others.
+func (c *containerManager) reattachContainers(ctx *actor.Context, msg proto.ReattachContainers) {
+	reattach := make(map[cproto.ID]bool, len(msg.ContainerIDs))
+	for _, id := range msg.ContainerIDs {
+		reattach[id] = true
+	}
+	for id, found := range c.found {
+		if !reattach[id] {
+			ctx.Log().Infof("killing container %s, which no task claims", id)
+			go c.killContainer(ctx, found.runtimeID)
+			continue
+		}
+		ctx.Log().Infof("reattaching to container %s", id)
+		ctx.ActorOf(id, newReattachedContainerActor(found.Container, found.runtimeID, c.runtime))
+	}
+	c.found = nil
+}
+
+func (c *containerManager) killContainer(ctx *actor.Context, runtimeID string) {
+	if err := c.runtime.SignalContainer(
+		context.Background(), runtimeID, syscall.SIGKILL,
+	); err != nil {
+		ctx.Log().WithError(err).Errorf("error killing container %s", runtimeID)
+	}
+}
+
+// pullImage pulls an image ahead of the tasks that use it and optionally pins it. Since no task
+// is waiting on the pull, its progress goes to the agent's log.
+func (c *containerManager) pullImage(ctx *actor.Context, image string, pin bool) {
+	if err := c.runtime.PullImage(
+		context.Background(), pullImage{Name: image}, containerLogger{ctx: ctx},
+	); err != nil {
+		ctx.Log().WithError(err).Errorf("error pulling image %s", image)
+		return
+	}
+	if pin {
+		if err := c.runtime.PinImage(context.Background(), image); err != nil {
+			ctx.Log().WithError(err).Errorf("error pinning image %s", image)
+			return
+		}
+		ctx.Log().Infof("pinned image %s", image)
+	}
+}
+
 func (c *containerManager) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
@@ -139,6 +280,9 @@ func (c *containerManager) handleAPIRequest(ctx *actor.Context, apiCtx echo.Cont
 }
 
 func (c *containerManager) overwriteSpec(cont cproto.Container, spec cproto.Spec) cproto.Spec {
+	if !usesFluentBit(c.Options) {
+		spec.RunSpec.UseFluentLogging = false
+	}
 	return overwriteSpec(cont, spec, c.GlobalEnvVars, c.Labels, c.fluentPort)
 }
 
@@ -68,7 +68,8 @@ func detectCPUs() ([]device.Device, error) {
 var detectGPUsArgs = []string{"nvidia-smi", "--query-gpu=index,name,uuid", "--format=csv,noheader"}
 var detectGPUsIDFlagTpl = "--id=%v"
 
-// detectGPUs returns the list of available Nvidia GPUs.
+// detectGPUs returns the list of available Nvidia GPUs, with the GPUs that are partitioned into MIG
+// instances replaced by their instances.
 func detectGPUs(visibleGPUs string) ([]device.Device, error) {
 	flags := detectGPUsArgs[1:]
 	if visibleGPUs != "" {
@@ -93,7 +94,7 @@ func detectGPUs(visibleGPUs string) ([]device.Device, error) {
 		record, err := r.Read()
 		switch {
 		case err == io.EOF:
-			return devices, nil
+			return detectMIGInstances(devices), nil
 		case err != nil:
 			return nil, errors.Wrap(err, "error parsing output of nvidia-smi as CSV")
 		case len(record) != 3:
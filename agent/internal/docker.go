@@ -8,48 +8,55 @@ import (
 	"fmt"
 	"io"
 	"syscall"
-	"time"
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
-	"github.com/determined-ai/determined/master/pkg/actor"
-	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/container"
 )
 
-type dockerActor struct {
+// dockerRuntime runs task containers with the Docker daemon.
+type dockerRuntime struct {
 	*client.Client
 	credentialStores map[string]*credentialStore
-	spec             *container.Spec
+	userns           *userNamespace
 }
 
-type (
-	signalContainer struct {
-		dockerID string
-		signal   syscall.Signal
+func newDockerRuntime(opts Options) (*dockerRuntime, error) {
+	d, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return nil, err
 	}
-	pullImage struct {
-		container.PullSpec
-		Name string
+
+	stores, err := getAllCredentialStores()
+	if err != nil {
+		logrus.Infof("can't find any docker credential stores, continuing without them %v", err)
 	}
-	imagePulled      struct{}
-	containerStarted struct {
-		dockerID      string
-		containerInfo types.ContainerJSON
+
+	info, err := d.Info(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting Docker daemon info")
 	}
-	containerTerminated struct {
-		ExitCode int64
+	userns, err := detectUserNamespace(info, opts.Security.UsernsRemapUser)
+	if err != nil {
+		return nil, err
 	}
-	dockerErr struct{ Error error }
-)
+	if userns != nil {
+		logrus.Infof("Docker is running in %s mode, mapping uids below %d and gids below %d",
+			userns.mode, userns.uidSize, userns.gidSize)
+	}
+
+	return &dockerRuntime{Client: d, credentialStores: stores, userns: userns}, nil
+}
 
 // registryToString converts the Registry struct to a base64 encoding for json strings.
 func registryToString(reg types.AuthConfig) (string, error) {
@@ -61,246 +68,323 @@ func registryToString(reg types.AuthConfig) (string, error) {
 	return base64.URLEncoding.EncodeToString(bs), nil
 }
 
-func (d *dockerActor) Receive(ctx *actor.Context) error {
-	switch msg := ctx.Message().(type) {
-	case actor.PreStart:
-		stores, err := getAllCredentialStores()
-		if err != nil {
-			ctx.Log().Infof(
-				"can't find any docker credential stores, continuing without them %v", err)
-		}
-		d.credentialStores = stores
-
-	case pullImage:
-		go d.pullImage(ctx, msg)
-
-	case container.RunSpec:
-		go d.runContainer(ctx, msg)
-
-	case signalContainer:
-		go d.signalContainer(ctx, msg)
-
-	case actor.PostStop:
-	}
-	return nil
+// imagePlatform returns the "os/arch" platform a local image was built for.
+func imagePlatform(inspect types.ImageInspect) string {
+	return inspect.Os + "/" + inspect.Architecture
 }
 
-func (d *dockerActor) pullImage(ctx *actor.Context, msg pullImage) {
+// PullImage implements containerRuntime.
+func (d *dockerRuntime) PullImage(
+	ctx context.Context, msg pullImage, logger containerLogger,
+) error {
 	ref, err := reference.ParseNormalizedNamed(msg.Name)
 	if err != nil {
-		sendErr(ctx, errors.Wrapf(err, "error parsing image name: %s", msg.Name))
-		return
+		return errors.Wrapf(err, "error parsing image name: %s", msg.Name)
 	}
 	ref = reference.TagNameOnly(ref)
 
-	_, _, err = d.ImageInspectWithRaw(context.Background(), ref.String())
+	inspect, _, err := d.ImageInspectWithRaw(ctx, ref.String())
 	switch {
+	case err == nil && msg.Platform != "" && imagePlatform(inspect) != msg.Platform:
+		logger.aux(fmt.Sprintf(
+			"cached image is for %s, pulling image for %s: %s",
+			imagePlatform(inspect), msg.Platform, ref.String()))
 	case err == nil && msg.ForcePull:
-		d.sendAuxLog(ctx, fmt.Sprintf("attempting to remove cached image: %s", ref.String()))
+		logger.aux(fmt.Sprintf("attempting to remove cached image: %s", ref.String()))
 		opts := types.ImageRemoveOptions{Force: true, PruneChildren: false}
-		removed, rerr := d.ImageRemove(context.Background(), ref.String(), opts)
+		removed, rerr := d.ImageRemove(ctx, ref.String(), opts)
 		if rerr != nil {
-			sendErr(ctx, errors.Wrapf(rerr, "error removing image: %s", ref.String()))
-			return
+			return errors.Wrapf(rerr, "error removing image: %s", ref.String())
 		}
 		for _, r := range removed {
 			switch {
 			case r.Untagged != "":
-				d.sendAuxLog(ctx, fmt.Sprintf("untagged image: %s", r.Untagged))
+				logger.aux(fmt.Sprintf("untagged image: %s", r.Untagged))
 			case r.Deleted != "":
-				d.sendAuxLog(ctx, fmt.Sprintf("deleted image: %s", r.Deleted))
+				logger.aux(fmt.Sprintf("deleted image: %s", r.Deleted))
 			}
 		}
 	case err == nil:
-		d.sendAuxLog(ctx, fmt.Sprintf("image already found, skipping pull phase: %s", ref.String()))
-		ctx.Tell(ctx.Sender(), imagePulled{})
-		return
+		logger.aux(fmt.Sprintf("image already found, skipping pull phase: %s", ref.String()))
+		return nil
 	case client.IsErrNotFound(err):
-		d.sendAuxLog(ctx, fmt.Sprintf("image not found, pulling image: %s", ref.String()))
+		logger.aux(fmt.Sprintf("image not found, pulling image: %s", ref.String()))
 	default:
-		sendErr(ctx, errors.Wrapf(err, "error checking if image exists: %s", ref.String()))
-		return
+		return errors.Wrapf(err, "error checking if image exists: %s", ref.String())
 	}
 
 	// TODO: replace with command.EncodeAuthToBase64
 	reg := ""
 	if msg.Registry != nil {
 		if reg, err = registryToString(*msg.Registry); err != nil {
-			sendErr(ctx, errors.Wrap(err, "error encoding registry credentials"))
-			return
+			return errors.Wrap(err, "error encoding registry credentials")
 		}
 	} else if store, ok := d.credentialStores[reference.Domain(ref)]; ok {
 		var creds types.AuthConfig
 		creds, err = store.get()
 		if err != nil {
-			sendErr(ctx, errors.Wrap(err, "unable to get credentials from helper"))
-			return
+			return errors.Wrap(err, "unable to get credentials from helper")
 		}
 		reg, err = registryToString(creds)
 		if err != nil {
-			sendErr(ctx, errors.Wrap(err, "error encoding registry credentials from helper"))
-			return
+			return errors.Wrap(err, "error encoding registry credentials from helper")
 		}
 	}
 
 	opts := types.ImagePullOptions{
 		All:          false,
 		RegistryAuth: reg,
+		Platform:     msg.Platform,
 	}
 
-	logs, err := d.ImagePull(context.Background(), ref.String(), opts)
+	logs, err := d.ImagePull(ctx, ref.String(), opts)
 	if err != nil {
-		sendErr(ctx, errors.Wrapf(err, "error pulling image: %s", ref.String()))
-		return
+		return errors.Wrapf(err, "error pulling image: %s", ref.String())
 	}
 
-	if err = d.sendPullLogs(ctx, logs); err != nil {
-		sendErr(ctx, errors.Wrap(err, "error parsing log stream"))
-		return
+	if err = sendPullLogs(logger, logs); err != nil {
+		return errors.Wrap(err, "error parsing log stream")
 	}
 	if err = logs.Close(); err != nil {
-		sendErr(ctx, errors.Wrap(err, "error closing log stream"))
-		return
+		return errors.Wrap(err, "error closing log stream")
 	}
-	ctx.Tell(ctx.Sender(), imagePulled{})
+	return nil
 }
 
-func (d *dockerActor) runContainer(ctx *actor.Context, msg container.RunSpec) {
-	if !d.spec.RunSpec.UseFluentLogging {
-		msg.HostConfig.AutoRemove = false
+// CreateContainer implements containerRuntime.
+func (d *dockerRuntime) CreateContainer(
+	ctx context.Context, msg container.RunSpec, logger containerLogger,
+) (string, error) {
+	if err := d.userns.validate(msg.ContainerConfig.User); err != nil {
+		return "", err
+	}
+	if msg.TaskNetworkDriver != "" {
+		network := string(msg.HostConfig.NetworkMode)
+		if err := d.ensureTaskNetwork(ctx, network, msg.TaskNetworkDriver); err != nil {
+			return "", err
+		}
+		logger.aux(fmt.Sprintf("joining task network: %s", network))
 	}
 
 	response, err := d.ContainerCreate(
-		context.Background(), &msg.ContainerConfig, &msg.HostConfig, &msg.NetworkingConfig, "")
+		ctx, &msg.ContainerConfig, &msg.HostConfig, &msg.NetworkingConfig, "")
 	if err != nil {
-		sendErr(ctx, errors.Wrap(err, "error creating container"))
-		return
+		return "", errors.Wrap(err, "error creating container")
 	}
 	containerID := response.ID
 	for _, w := range response.Warnings {
-		d.sendAuxLog(ctx, fmt.Sprintf("warning when creating container: %s", w))
-	}
-
-	if !d.spec.RunSpec.UseFluentLogging {
-		defer func() {
-			if err = d.Client.ContainerRemove(
-				context.Background(), containerID, types.ContainerRemoveOptions{},
-			); err != nil {
-				sendErr(ctx, errors.Wrap(err, "error removing container"))
-			}
-		}()
+		logger.aux(fmt.Sprintf("warning when creating container: %s", w))
 	}
 
 	for _, copyArx := range msg.Archives {
-		d.sendAuxLog(ctx, fmt.Sprintf("copying files to container: %s", copyArx.Path))
+		logger.aux(fmt.Sprintf("copying files to container: %s", copyArx.Path))
 		files, aerr := archive.ToIOReader(copyArx.Archive)
 		if aerr != nil {
-			sendErr(ctx, errors.Wrap(aerr, "error converting RunSpec Archive files to io.Reader"))
-			return
+			return containerID, errors.Wrap(
+				aerr, "error converting RunSpec Archive files to io.Reader")
 		}
 		if cerr := d.CopyToContainer(
-			context.Background(),
+			ctx,
 			containerID,
 			copyArx.Path,
 			files,
 			copyArx.CopyOptions,
 		); cerr != nil {
-			sendErr(ctx, errors.Wrap(cerr, "error copying files to container"))
-			return
+			return containerID, errors.Wrap(cerr, "error copying files to container")
 		}
 	}
+	return containerID, nil
+}
 
-	exit, eerr := d.ContainerWait(
-		context.Background(), containerID, dcontainer.WaitConditionNextExit)
+// StartContainer implements containerRuntime.
+func (d *dockerRuntime) StartContainer(
+	ctx context.Context, containerID string, msg container.RunSpec, logger containerLogger,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	exit, eerr := d.ContainerWait(ctx, containerID, dcontainer.WaitConditionNextExit)
 
-	if err = d.ContainerStart(context.Background(), containerID,
-		types.ContainerStartOptions{}); err != nil {
-		sendErr(ctx, errors.Wrap(err, "error starting container"))
-		return
+	if err := d.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error starting container")
 	}
 
 	// If we specified a port to expose but not the host port to bind, Docker assigns an arbitrary host
 	// port, which we ask for here. (If we did specify a host port, this gives the same one back.)
-	containerInfo, err := d.ContainerInspect(context.Background(), containerID)
+	containerInfo, err := d.ContainerInspect(ctx, containerID)
 	if err != nil {
-		sendErr(ctx, errors.Wrap(err, "error inspecting container"))
-		return
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error inspecting container")
 	}
 
-	ctx.Tell(
-		ctx.Sender(),
-		containerStarted{dockerID: response.ID, containerInfo: containerInfo},
-	)
-
-	if !d.spec.RunSpec.UseFluentLogging {
-		if lerr := trackLogs(ctx, d.Client, containerID, ctx.Sender()); lerr != nil {
-			sendErr(ctx, lerr)
+	exits := make(chan containerExit, 1)
+	go func() {
+		// Logs of containers that use Fluent logging are shipped by the Docker daemon itself.
+		if !msg.UseFluentLogging {
+			if lerr := trackLogs(ctx, d.Client, containerID, logger); lerr != nil {
+				exits <- containerExit{err: lerr}
+				return
+			}
+		}
+		select {
+		case err := <-eerr:
+			exits <- containerExit{err: err}
+		case exit := <-exit:
+			if msg.TaskNetworkDriver != "" {
+				d.removeTaskNetwork(ctx, string(msg.HostConfig.NetworkMode))
+			}
+			exits <- containerExit{code: exit.StatusCode}
 		}
+	}()
+	return containerInfo, exits, nil
+}
+
+// RunningContainers implements containerRuntime.
+func (d *dockerRuntime) RunningContainers(
+	ctx context.Context, agentID string,
+) ([]runningContainer, error) {
+	containers, err := d.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", dockerContainerTypeLabel+"="+dockerContainerTypeValue),
+			filters.Arg("label", dockerAgentLabel+"="+agentID),
+		),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing containers")
 	}
-	select {
-	case err = <-eerr:
-		sendErr(ctx, errors.Wrap(err, "error while waiting for container to exit"))
-	case exit := <-exit:
-		ctx.Tell(ctx.Sender(), containerTerminated{ExitCode: exit.StatusCode})
+	running := make([]runningContainer, 0, len(containers))
+	for _, c := range containers {
+		running = append(running, runningContainer{id: c.ID, labels: c.Labels})
 	}
+	return running, nil
 }
 
-func (d *dockerActor) signalContainer(ctx *actor.Context, msg signalContainer) {
-	err := d.ContainerKill(context.Background(), msg.dockerID, unix.SignalName(msg.signal))
+// ReattachContainer implements containerRuntime. Docker ships the logs of task containers to
+// Fluent Bit itself, so only their exit is watched.
+func (d *dockerRuntime) ReattachContainer(
+	ctx context.Context, containerID string,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	exit, eerr := d.ContainerWait(ctx, containerID, dcontainer.WaitConditionNotRunning)
+
+	containerInfo, err := d.ContainerInspect(ctx, containerID)
 	if err != nil {
-		sendErr(ctx, errors.Wrap(err, "error while killing container"))
-		return
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error inspecting container")
 	}
+
+	exits := make(chan containerExit, 1)
+	go func() {
+		select {
+		case err := <-eerr:
+			exits <- containerExit{err: err}
+		case exit := <-exit:
+			exits <- containerExit{code: exit.StatusCode}
+		}
+	}()
+	return containerInfo, exits, nil
 }
 
-func sendErr(ctx *actor.Context, err error) {
-	ctx.Tell(ctx.Sender(), dockerErr{Error: err})
+// ContainerStats implements containerRuntime.
+func (d *dockerRuntime) ContainerStats(ctx context.Context, id string) (containerStats, error) {
+	resp, err := d.Client.ContainerStats(ctx, id, false)
+	if err != nil {
+		return containerStats{}, errors.Wrap(err, "error fetching container stats")
+	}
+	defer resp.Body.Close()
+
+	var raw types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return containerStats{}, errors.Wrap(err, "error decoding container stats")
+	}
+	stats := containerStats{
+		cpuSeconds:  float64(raw.CPUStats.CPUUsage.TotalUsage) / 1e9,
+		memoryBytes: raw.MemoryStats.Usage,
+	}
+	for _, network := range raw.Networks {
+		stats.networkRxBytes += network.RxBytes
+		stats.networkTxBytes += network.TxBytes
+	}
+	return stats, nil
 }
 
-func (d *dockerActor) sendAuxLog(ctx *actor.Context, msg string) {
-	ctx.Tell(ctx.Sender(), aproto.ContainerLog{
-		Timestamp:  time.Now().UTC(),
-		AuxMessage: &msg,
+// ensureTaskNetwork creates the network private to a multi-container task, unless another
+// container of the task already created it.
+func (d *dockerRuntime) ensureTaskNetwork(ctx context.Context, name, driver string) error {
+	_, err := d.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	switch {
+	case err == nil:
+		return nil
+	case !client.IsErrNotFound(err):
+		return errors.Wrap(err, "error inspecting task network")
+	}
+
+	_, err = d.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         driver,
+		// Containers started outside of swarm services can only join attachable overlay networks.
+		Attachable: driver == "overlay",
+		Labels:     map[string]string{dockerContainerTypeLabel: taskNetworkTypeValue},
 	})
+	if err != nil {
+		// Another container of the task may have created the network in the meantime.
+		if _, ierr := d.NetworkInspect(ctx, name, types.NetworkInspectOptions{}); ierr == nil {
+			return nil
+		}
+		return errors.Wrap(err, "error creating task network")
+	}
+	return nil
 }
 
-func (d *dockerActor) sendPullLogs(ctx *actor.Context, r io.Reader) error {
+// removeTaskNetwork removes the network private to a task once a container of the task exits.
+// Removing it fails while other containers of the task are still attached, in which case the last
+// of them to exit removes it.
+func (d *dockerRuntime) removeTaskNetwork(ctx context.Context, name string) {
+	if err := d.NetworkRemove(ctx, name); err != nil && !client.IsErrNotFound(err) {
+		logrus.WithError(err).Debugf("not removing task network %s", name)
+	}
+}
+
+// SignalContainer implements containerRuntime.
+func (d *dockerRuntime) SignalContainer(
+	ctx context.Context, containerID string, signal syscall.Signal,
+) error {
+	return d.ContainerKill(ctx, containerID, unix.SignalName(signal))
+}
+
+// RemoveContainer implements containerRuntime.
+func (d *dockerRuntime) RemoveContainer(ctx context.Context, containerID string) error {
+	return d.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{})
+}
+
+// PinImage implements containerRuntime. Docker does not prune images that containers use, so the
+// image is pinned by a container that is created from it but never started.
+func (d *dockerRuntime) PinImage(ctx context.Context, name string) error {
+	holder := pinnedImageContainerPrefix + sanitizedImageName(name)
+	// Replace any previous pin, which may hold an older version of the image.
+	err := d.ContainerRemove(ctx, holder, types.ContainerRemoveOptions{Force: true})
+	if err != nil && !client.IsErrNotFound(err) {
+		return errors.Wrap(err, "error removing previous pin")
+	}
+	_, err = d.ContainerCreate(ctx, &dcontainer.Config{
+		Image:  name,
+		Cmd:    []string{"true"},
+		Labels: map[string]string{dockerContainerTypeLabel: pinnedImageTypeValue},
+	}, nil, nil, holder)
+	return errors.Wrap(err, "error creating container to pin image")
+}
+
+func sendPullLogs(logger containerLogger, r io.Reader) error {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		log := jsonmessage.JSONMessage{}
 		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
 			return errors.Wrapf(err, "error parsing log message: %#v", log)
 		}
-		ctx.Tell(ctx.Sender(), aproto.ContainerLog{
-			Timestamp:   time.Now().UTC(),
-			PullMessage: &log,
-		})
+		logger.pull(log)
 	}
 	return scanner.Err()
 }
 
-type demultiplexer struct {
-	ctx       *actor.Context
-	stdType   stdcopy.StdType
-	recipient *actor.Ref
-}
-
-func (d demultiplexer) Write(p []byte) (n int, err error) {
-	d.ctx.Tell(d.recipient, aproto.ContainerLog{
-		Timestamp: time.Now().UTC(),
-		RunMessage: &aproto.RunMessage{
-			Value:   string(p),
-			StdType: d.stdType,
-		},
-	})
-	return len(p), nil
-}
-
 func trackLogs(
-	ctx *actor.Context, docker *client.Client, containerID string, recipient *actor.Ref,
+	ctx context.Context, docker *client.Client, containerID string, logger containerLogger,
 ) error {
 	logs, lErr := docker.ContainerLogs(
-		context.Background(),
+		ctx,
 		containerID,
 		types.ContainerLogsOptions{
 			ShowStdout: true,
@@ -316,8 +400,8 @@ func trackLogs(
 		return errors.Wrap(lErr, "error grabbing container logs")
 	}
 
-	stdout := demultiplexer{ctx: ctx, stdType: stdcopy.Stdout, recipient: recipient}
-	stderr := demultiplexer{ctx: ctx, stdType: stdcopy.Stderr, recipient: recipient}
+	stdout := logger.output(stdcopy.Stdout)
+	stderr := logger.output(stdcopy.Stderr)
 	if _, lErr = stdcopy.StdCopy(stdout, stderr, logs); lErr != nil {
 		return errors.Wrap(lErr, "error scanning logs")
 	}
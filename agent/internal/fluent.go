@@ -302,7 +302,8 @@ func (f *fluentActor) trackLogs(ctx *actor.Context) {
 	exitChan, errChan := f.docker.ContainerWait(
 		context.Background(), f.containerID, container.WaitConditionNotRunning)
 
-	if err := trackLogs(ctx, f.docker, f.containerID, ctx.Self()); err != nil {
+	logger := containerLogger{ctx: ctx, recipient: ctx.Self()}
+	if err := trackLogs(context.Background(), f.docker, f.containerID, logger); err != nil {
 		ctx.Log().Errorf("error tracking Fluent Bit logs: %s", err)
 	}
 	// This message also allows us to synchronize with the buffer before dumping logs.
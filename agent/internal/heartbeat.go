@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/actor/api"
+	proto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+// heartbeatTick is an internal message that triggers the agent to send a heartbeat to the master.
+type heartbeatTick struct{}
+
+// heartbeat sends a heartbeat describing the health of the host to the master and schedules the
+// next one.
+func (a *agent) heartbeat(ctx *actor.Context) {
+	msg := proto.AgentHeartbeat{SentAt: time.Now().UTC()}
+	msg.Devices, msg.Utilization = a.gpuHealth(ctx)
+
+	switch usage, err := disk.Usage("/"); {
+	case err != nil:
+		ctx.Log().WithError(err).Warn("error gathering disk usage")
+	default:
+		msg.DiskUsedPercent = usage.UsedPercent
+	}
+
+	if a.socket != nil {
+		ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{AgentHeartbeat: &msg}})
+	}
+	actors.NotifyAfter(ctx, a.MasterSetAgentOptions.HeartbeatPeriod, heartbeatTick{})
+}
+
+// gpuHealth returns the health and utilization of each GPU on the agent. If the GPUs cannot be
+// queried, every GPU is reported as failed so that the master stops scheduling onto them.
+func (a *agent) gpuHealth(ctx *actor.Context) ([]proto.DeviceHealth, []proto.DeviceUtilization) {
+	var gpus []device.Device
+	for _, d := range a.Devices {
+		if d.Type == device.GPU {
+			gpus = append(gpus, d)
+		}
+	}
+	if len(gpus) == 0 {
+		return nil, nil
+	}
+
+	health := make([]proto.DeviceHealth, 0, len(gpus))
+	stats, err := getGPUStats(a.Options.VisibleGPUs)
+	if err != nil {
+		ctx.Log().WithError(err).Warn("error gathering GPU health")
+		for _, d := range gpus {
+			health = append(health, proto.DeviceHealth{ID: d.ID, Error: err.Error()})
+		}
+		return health, nil
+	}
+
+	xids, err := getXIDErrors()
+	if err != nil {
+		// Reading the kernel log commonly requires privileges the agent does not have.
+		ctx.Log().WithError(err).Debug("error gathering XID errors")
+	}
+
+	utilization := make([]proto.DeviceUtilization, 0, len(gpus))
+	for _, d := range gpus {
+		// MIG instances report the health and utilization of the GPU that they partition.
+		s, ok := stats[d.PhysicalID()]
+		if !ok {
+			health = append(health, proto.DeviceHealth{ID: d.ID, Error: "GPU not reported by nvidia-smi"})
+			continue
+		}
+		health = append(health, proto.DeviceHealth{
+			ID:        d.ID,
+			ECCErrors: s.eccErrors,
+			XIDErrors: xids[s.busID],
+		})
+		utilization = append(utilization, proto.DeviceUtilization{
+			ID:                 d.ID,
+			UtilizationPercent: s.utilization,
+			MemoryUsedMiB:      s.memoryUsed,
+			MemoryTotalMiB:     s.memoryTotal,
+		})
+	}
+	return health, utilization
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Task hooks run at these points of the lifecycle of a container.
+const (
+	preStartHook = "pre_start"
+	postStopHook = "post_stop"
+)
+
+// hookEvent describes the container that hooks run for. Commands receive it in environment
+// variables and HTTP endpoints as the JSON body of a POST.
+type hookEvent struct {
+	Hook        string `json:"hook"`
+	TaskID      string `json:"task_id"`
+	ContainerID string `json:"container_id"`
+	AgentID     string `json:"agent_id"`
+}
+
+// newHookEvent returns the event of the hooks of a container that the master started on the agent,
+// without the hook set. The IDs come from the master's message and the agent's options rather than
+// from the environment of the container, which users may override.
+func newHookEvent(msg aproto.StartContainer, agentID string) hookEvent {
+	return hookEvent{
+		TaskID:      msg.TaskID,
+		ContainerID: string(msg.Container.ID),
+		AgentID:     agentID,
+	}
+}
+
+// runTaskHooks runs hooks in order, stopping at the first that fails.
+func runTaskHooks(
+	ctx context.Context, hooks []model.TaskHook, event hookEvent, logger containerLogger,
+) error {
+	for i, hook := range hooks {
+		if err := runTaskHook(ctx, hook, event, logger); err != nil {
+			return errors.Wrapf(err, "%s hook %d failed", event.Hook, i)
+		}
+	}
+	return nil
+}
+
+func runTaskHook(
+	ctx context.Context, hook model.TaskHook, event hookEvent, logger containerLogger,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.TimeoutDuration())
+	defer cancel()
+
+	if hook.URL != "" {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.Errorf("%s responded with %s", hook.URL, resp.Status)
+		}
+		return nil
+	}
+
+	// Admins configure the commands of hooks.
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...) //nolint:gosec
+	cmd.Env = append(os.Environ(),
+		"DET_HOOK="+event.Hook,
+		"DET_TASK_ID="+event.TaskID,
+		fmt.Sprintf("%s=%s", containerIDEnvVar, event.ContainerID),
+		fmt.Sprintf("%s=%s", agentIDEnvVar, event.AgentID),
+	)
+	out, err := cmd.CombinedOutput()
+	if output := strings.TrimSpace(string(out)); output != "" {
+		logger.aux(fmt.Sprintf("%s hook: %s", event.Hook, output))
+	}
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "%s timed out", hook.Command[0])
+	}
+	return err
+}
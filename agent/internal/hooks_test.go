@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestNewHookEvent(t *testing.T) {
+	// The IDs that users set in the environment of the container are ignored.
+	msg := aproto.StartContainer{
+		Container: cproto.Container{ID: "container"},
+		Spec: cproto.Spec{RunSpec: cproto.RunSpec{ContainerConfig: container.Config{
+			Env: []string{"DET_TASK_ID=spoofed", "DET_CONTAINER_ID=spoofed", "DET_AGENT_ID=spoofed"},
+		}}},
+		TaskID: "task",
+	}
+	event := newHookEvent(msg, "agent")
+	expected := hookEvent{TaskID: "task", ContainerID: "container", AgentID: "agent"}
+	if event != expected {
+		t.Fatalf("expected %v, got %v", expected, event)
+	}
+}
+
+func TestRunTaskHooks(t *testing.T) {
+	var received hookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if received.TaskID != "task" {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer server.Close()
+
+	event := hookEvent{Hook: postStopHook, TaskID: "task", ContainerID: "c", AgentID: "a"}
+	hooks := []model.TaskHook{
+		{Command: []string{"sh", "-c", `test "$DET_HOOK" = post_stop -a "$DET_TASK_ID" = task`}},
+		{URL: server.URL},
+	}
+	if err := runTaskHooks(context.Background(), hooks, event, containerLogger{}); err != nil {
+		t.Fatalf("expected hooks to succeed, got %v", err)
+	}
+	if received != event {
+		t.Fatalf("expected %v to be posted, got %v", event, received)
+	}
+
+	event.TaskID = "other"
+	if err := runTaskHooks(context.Background(), hooks, event, containerLogger{}); err == nil {
+		t.Fatal("expected hooks to fail")
+	}
+
+	hooks = []model.TaskHook{{Command: []string{"sleep", "5"}, Timeout: 1}}
+	if err := runTaskHooks(context.Background(), hooks, event, containerLogger{}); err == nil {
+		t.Fatal("expected hook to time out")
+	}
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/actor/api"
+	proto "github.com/determined-ai/determined/master/pkg/agent"
+)
+
+const (
+	awsInterruptionWatcherName = "aws"
+	gcpInterruptionWatcherName = "gcp"
+
+	awsMetadataEndpoint = "http://169.254.169.254"
+	gcpMetadataEndpoint = "http://metadata.google.internal"
+
+	// gcpPreemptionNotice is how long GCP waits between preempting an instance and stopping it.
+	gcpPreemptionNotice = 30 * time.Second
+
+	interruptionCheckPeriod  = 5 * time.Second
+	interruptionCheckTimeout = 2 * time.Second
+)
+
+var interruptionWatcherNames = []string{"", awsInterruptionWatcherName, gcpInterruptionWatcherName}
+
+// interruptionCheckTick is an internal message that triggers the agent to check whether the cloud
+// provider is about to reclaim its instance.
+type interruptionCheckTick struct{}
+
+// interruptionWatcher asks the instance metadata service of a cloud provider whether the spot or
+// preemptible instance the agent runs on is about to be reclaimed.
+type interruptionWatcher interface {
+	// interruption returns the time at which the instance will be reclaimed and whether an
+	// interruption is scheduled at all.
+	interruption(ctx context.Context) (time.Time, bool, error)
+}
+
+func newInterruptionWatcher(name string) (interruptionWatcher, error) {
+	client := &http.Client{Timeout: interruptionCheckTimeout}
+	switch name {
+	case awsInterruptionWatcherName:
+		return &awsInterruptionWatcher{client: client, endpoint: awsMetadataEndpoint}, nil
+	case gcpInterruptionWatcherName:
+		return &gcpInterruptionWatcher{client: client, endpoint: gcpMetadataEndpoint}, nil
+	default:
+		return nil, errors.Errorf("unknown interruption watcher: %s", name)
+	}
+}
+
+// awsInterruptionWatcher reads the spot instance action from the EC2 instance metadata service,
+// which AWS publishes two minutes before it stops or terminates a spot instance.
+type awsInterruptionWatcher struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (w *awsInterruptionWatcher) interruption(ctx context.Context) (time.Time, bool, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, w.endpoint+"/latest/meta-data/spot/instance-action", nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	// Instances that require IMDSv2 reject requests without a session token; instances that
+	// don't accept them either way, so failing to get one is not an error.
+	if token, err := w.token(ctx); err == nil {
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return time.Time{}, false, errors.Wrap(err, "error querying instance metadata")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return time.Time{}, false, nil
+	default:
+		return time.Time{}, false, errors.Errorf(
+			"unexpected status querying instance metadata: %s", resp.Status)
+	}
+
+	var action struct {
+		Action string    `json:"action"`
+		Time   time.Time `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&action); err != nil {
+		return time.Time{}, false, errors.Wrap(err, "error parsing spot instance action")
+	}
+	return action.Time, true, nil
+}
+
+func (w *awsInterruptionWatcher) token(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, w.endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status requesting metadata token: %s", resp.Status)
+	}
+	token, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// gcpInterruptionWatcher reads the preempted flag from the GCE metadata server. GCP does not
+// publish a deadline, so the interruption is taken to happen when the preemption notice ends.
+type gcpInterruptionWatcher struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (w *gcpInterruptionWatcher) interruption(ctx context.Context) (time.Time, bool, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, w.endpoint+"/computeMetadata/v1/instance/preempted", nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return time.Time{}, false, errors.Wrap(err, "error querying instance metadata")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, false, errors.Errorf(
+			"unexpected status querying instance metadata: %s", resp.Status)
+	}
+
+	preempted, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return time.Time{}, false, errors.Wrap(err, "error reading instance metadata")
+	}
+	if strings.TrimSpace(string(preempted)) != "TRUE" {
+		return time.Time{}, false, nil
+	}
+	return time.Now().Add(gcpPreemptionNotice), true, nil
+}
+
+// checkInterruption tells the master once the agent's instance is about to be reclaimed, so that
+// its tasks can be checkpointed and rescheduled in time; until then it schedules the next check.
+func (a *agent) checkInterruption(ctx *actor.Context) {
+	checkCtx, cancel := context.WithTimeout(context.Background(), interruptionCheckTimeout)
+	defer cancel()
+
+	deadline, interrupted, err := a.interruptions.interruption(checkCtx)
+	switch {
+	case err != nil:
+		ctx.Log().WithError(err).Debug("error checking for instance interruption")
+	case interrupted:
+		ctx.Log().Warnf("instance will be reclaimed by the cloud provider at %s", deadline)
+		if a.socket != nil {
+			ctx.Ask(a.socket, api.WriteMessage{Message: proto.MasterMessage{
+				AgentInterrupted: &proto.AgentInterrupted{Deadline: deadline.UTC()},
+			}})
+		}
+		return
+	}
+	actors.NotifyAfter(ctx, interruptionCheckPeriod, interruptionCheckTick{})
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newAWSMetadataServer(action string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("token"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/spot/instance-action" && action != "":
+			_, _ = w.Write([]byte(action))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newGCPMetadataServer(preempted string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(preempted))
+	}))
+}
+
+func TestAWSInterruptionWatcher(t *testing.T) {
+	server := newAWSMetadataServer("")
+	defer server.Close()
+	w := &awsInterruptionWatcher{client: server.Client(), endpoint: server.URL}
+	_, interrupted, err := w.interruption(context.Background())
+	if err != nil || interrupted {
+		t.Fatalf("expected no interruption, got %t (%v)", interrupted, err)
+	}
+
+	expected := time.Date(2021, 6, 1, 12, 2, 0, 0, time.UTC)
+	server = newAWSMetadataServer(
+		fmt.Sprintf(`{"action": "terminate", "time": "%s"}`, expected.Format(time.RFC3339)))
+	defer server.Close()
+	w = &awsInterruptionWatcher{client: server.Client(), endpoint: server.URL}
+	deadline, interrupted, err := w.interruption(context.Background())
+	if err != nil || !interrupted {
+		t.Fatalf("expected an interruption, got %t (%v)", interrupted, err)
+	}
+	if !deadline.Equal(expected) {
+		t.Errorf("expected deadline %s, got %s", expected, deadline)
+	}
+}
+
+func TestGCPInterruptionWatcher(t *testing.T) {
+	server := newGCPMetadataServer("FALSE")
+	defer server.Close()
+	w := &gcpInterruptionWatcher{client: server.Client(), endpoint: server.URL}
+	_, interrupted, err := w.interruption(context.Background())
+	if err != nil || interrupted {
+		t.Fatalf("expected no interruption, got %t (%v)", interrupted, err)
+	}
+
+	server = newGCPMetadataServer("TRUE")
+	defer server.Close()
+	w = &gcpInterruptionWatcher{client: server.Client(), endpoint: server.URL}
+	deadline, interrupted, err := w.interruption(context.Background())
+	if err != nil || !interrupted {
+		t.Fatalf("expected an interruption, got %t (%v)", interrupted, err)
+	}
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > gcpPreemptionNotice {
+		t.Errorf("expected deadline within the preemption notice, got %s", deadline)
+	}
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"bufio"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/api"
+	proto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+var (
+	detectMIGArgs = []string{"nvidia-smi", "-L"}
+
+	// nvidia-smi -L lists each GPU followed by its MIG instances, e.g.
+	//   GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-e86cb44c-6756-fd30-cd4a-1e6da3caf9b0)
+	//     MIG 3g.20gb     Device  0: (UUID: MIG-c7384736-a75d-5afc-978f-d2f1294409fd)
+	migGPUPattern      = regexp.MustCompile(`^GPU (\d+): .* \(UUID: (\S+)\)$`)
+	migInstancePattern = regexp.MustCompile(`^MIG (\S+)\s+Device\s+\d+: \(UUID: (\S+)\)$`)
+)
+
+// detectMIGInstances replaces the GPUs that are partitioned into MIG instances by their instances,
+// each of which is a slot of its own. The GPUs are returned as they are if nvidia-smi cannot tell.
+func detectMIGInstances(gpus []device.Device) []device.Device {
+	// #nosec G204
+	out, err := exec.Command(detectMIGArgs[0], detectMIGArgs[1:]...).Output()
+	if err != nil {
+		log.WithError(err).WithField("output", string(out)).Warn("error detecting MIG instances")
+		return gpus
+	}
+	return parseMIGInstances(string(out), gpus)
+}
+
+// parseMIGInstances replaces the GPUs that nvidia-smi -L lists MIG instances of by the instances.
+// The instances are numbered after the GPUs.
+func parseMIGInstances(out string, gpus []device.Device) []device.Device {
+	nextID := 0
+	byUUID := make(map[string]device.Device)
+	for _, d := range gpus {
+		byUUID[d.UUID] = d
+		if d.ID >= nextID {
+			nextID = d.ID + 1
+		}
+	}
+
+	instances := make(map[string][]device.Device)
+	var parent *device.Device
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := migGPUPattern.FindStringSubmatch(line); match != nil {
+			parent = nil
+			if d, ok := byUUID[match[2]]; ok {
+				parent = &d
+			}
+			continue
+		}
+		match := migInstancePattern.FindStringSubmatch(line)
+		if match == nil || parent == nil {
+			continue
+		}
+		instances[parent.UUID] = append(instances[parent.UUID], device.Device{
+			ID:         nextID,
+			Brand:      parent.Brand + " MIG " + match[1],
+			UUID:       match[2],
+			Type:       device.GPU,
+			MIGProfile: match[1],
+			ParentID:   parent.ID,
+		})
+		nextID++
+	}
+
+	devices := make([]device.Device, 0, len(gpus))
+	for _, d := range gpus {
+		if len(instances[d.UUID]) > 0 {
+			devices = append(devices, instances[d.UUID]...)
+		} else {
+			devices = append(devices, d)
+		}
+	}
+	return devices
+}
+
+// applyMIGLayouts repartitions the GPUs of the agent at the request of the master, which drained
+// it first. Once the GPUs are repartitioned, the agent shuts down so that its supervisor restarts
+// it and it rejoins with the new instances as its slots.
+func (a *agent) applyMIGLayouts(ctx *actor.Context, msg proto.ConfigureMIG) {
+	ctx.Log().Infof("repartitioning GPUs into MIG instances: %v", msg.Layouts)
+	configured := proto.MIGConfigured{}
+	if err := a.configureMIG(msg.Layouts); err != nil {
+		ctx.Log().WithError(err).Error("error repartitioning GPUs")
+		configured.Error = err.Error()
+	}
+	if err := api.WriteSocketJSON(
+		ctx, a.socket, proto.MasterMessage{MIGConfigured: &configured},
+	); err != nil {
+		ctx.Log().WithError(err).Warn("error reporting the MIG layout to the master")
+	}
+	if configured.Error == "" {
+		ctx.Log().Info("shutting down agent to rejoin with the new MIG instances")
+		ctx.Self().Stop()
+	}
+}
+
+// configureMIG partitions the GPUs of the agent into the MIG instances of the layouts, destroying
+// the instances that they had. GPUs whose layouts have no profiles have MIG disabled.
+func (a *agent) configureMIG(layouts []proto.MIGLayout) error {
+	gpus := make(map[int]bool)
+	for _, d := range a.Devices {
+		if d.Type == device.GPU {
+			gpus[d.PhysicalID()] = true
+		}
+	}
+	for _, l := range layouts {
+		if !gpus[l.GPU] {
+			return errors.Errorf("GPU %d is not one of the GPUs of the agent", l.GPU)
+		}
+	}
+
+	for _, l := range layouts {
+		gpu := strconv.Itoa(l.GPU)
+		// Destroying fails if the GPU has no instances, which is fine.
+		for _, flag := range []string{"-dci", "-dgi"} {
+			if out, err := nvidiaSMI("mig", "-i", gpu, flag); err != nil {
+				log.WithError(err).Debugf("nvidia-smi mig %s: %s", flag, out)
+			}
+		}
+		if len(l.Profiles) == 0 {
+			if out, err := nvidiaSMI("-i", gpu, "-mig", "0"); err != nil {
+				return errors.Wrapf(err, "error disabling MIG on GPU %d: %s", l.GPU, out)
+			}
+			continue
+		}
+		if out, err := nvidiaSMI("-i", gpu, "-mig", "1"); err != nil {
+			return errors.Wrapf(err, "error enabling MIG on GPU %d: %s", l.GPU, out)
+		}
+		profiles := strings.Join(l.Profiles, ",")
+		if out, err := nvidiaSMI("mig", "-i", gpu, "-cgi", profiles, "-C"); err != nil {
+			return errors.Wrapf(err, "error creating MIG instances %s on GPU %d: %s",
+				profiles, l.GPU, out)
+		}
+	}
+	return nil
+}
+
+func nvidiaSMI(args ...string) (string, error) {
+	// #nosec G204
+	out, err := exec.Command("nvidia-smi", args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
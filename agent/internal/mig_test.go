@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+const migList = "" +
+	"GPU 0: NVIDIA A100-SXM4-40GB (UUID: GPU-0)\n" +
+	"  MIG 3g.20gb     Device  0: (UUID: MIG-0)\n" +
+	"  MIG 2g.10gb     Device  1: (UUID: MIG-1)\n" +
+	"GPU 1: NVIDIA A100-SXM4-40GB (UUID: GPU-1)\n"
+
+func TestParseMIGInstances(t *testing.T) {
+	brand := "NVIDIA A100-SXM4-40GB"
+	gpus := []device.Device{
+		{ID: 0, Brand: brand, UUID: "GPU-0", Type: device.GPU},
+		{ID: 1, Brand: brand, UUID: "GPU-1", Type: device.GPU},
+	}
+
+	expected := []device.Device{
+		{
+			ID: 2, Brand: brand + " MIG 3g.20gb", UUID: "MIG-0", Type: device.GPU,
+			MIGProfile: "3g.20gb", ParentID: 0,
+		},
+		{
+			ID: 3, Brand: brand + " MIG 2g.10gb", UUID: "MIG-1", Type: device.GPU,
+			MIGProfile: "2g.10gb", ParentID: 0,
+		},
+		gpus[1],
+	}
+	if devices := parseMIGInstances(migList, gpus); !reflect.DeepEqual(devices, expected) {
+		t.Errorf("expected %v, got %v", expected, devices)
+	}
+
+	if devices := parseMIGInstances("", gpus); !reflect.DeepEqual(devices, gpus) {
+		t.Errorf("expected GPUs without MIG instances to stay, got %v", devices)
+	}
+}
@@ -2,8 +2,11 @@ package internal
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -39,3 +42,121 @@ func getNvidiaVersion() (string, error) {
 	}
 	return record[0], nil
 }
+
+// gpuStats holds the health and utilization information nvidia-smi reports for a single GPU.
+type gpuStats struct {
+	// busID is the PCI bus ID of the GPU in the form used by the driver's XID messages.
+	busID       string
+	eccErrors   int
+	utilization float64
+	memoryUsed  int
+	memoryTotal int
+}
+
+// getGPUStats returns the health and utilization information nvidia-smi reports for each GPU,
+// keyed by GPU index.
+func getGPUStats(visibleGPUs string) (map[int]gpuStats, error) {
+	flags := []string{
+		"--query-gpu=index,pci.bus_id,ecc.errors.uncorrected.volatile.total," +
+			"utilization.gpu,memory.used,memory.total",
+		"--format=csv,noheader,nounits",
+	}
+	if visibleGPUs != "" {
+		flags = append(flags, fmt.Sprintf(detectGPUsIDFlagTpl, visibleGPUs))
+	}
+
+	// #nosec G204
+	out, err := exec.Command("nvidia-smi", flags...).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while executing nvidia-smi: %s", string(out))
+	}
+
+	stats := make(map[int]gpuStats)
+	r := csv.NewReader(strings.NewReader(string(out)))
+	for {
+		record, err := r.Read()
+		switch {
+		case err == io.EOF:
+			return stats, nil
+		case err != nil:
+			return nil, errors.Wrap(err, "error parsing output of nvidia-smi as CSV")
+		case len(record) != 6:
+			return nil, errors.New(
+				"error parsing output of nvidia-smi; GPU record should have exactly 6 fields")
+		}
+
+		index, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, errors.Wrap(
+				err, "error parsing output of nvidia-smi; index of GPU cannot be converted to int")
+		}
+
+		// nvidia-smi reports "[N/A]" for values the GPU does not support (e.g., ECC); those are
+		// reported as zero.
+		stats[index] = gpuStats{
+			busID:       normalizeBusID(record[1]),
+			eccErrors:   parseIntOrZero(record[2]),
+			utilization: float64(parseIntOrZero(record[3])),
+			memoryUsed:  parseIntOrZero(record[4]),
+			memoryTotal: parseIntOrZero(record[5]),
+		}
+	}
+}
+
+func parseIntOrZero(s string) int {
+	i, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// xidPattern matches the XID error messages the Nvidia driver writes to the kernel log, e.g.
+// "NVRM: Xid (PCI:0000:3b:00): 79, pid=1234, GPU has fallen off the bus.".
+var xidPattern = regexp.MustCompile(`NVRM: Xid \(PCI:([0-9a-fA-F:.]+)\): (\d+)`)
+
+// fatalXIDs are the XID codes that point to a fault of the GPU rather than of the application that
+// used it, e.g. Xid 13 and 31 for illegal memory accesses or Xid 43 for a stopped context:
+// double-bit ECC errors (48), halted microcontrollers (61, 62), failed page retirements (64),
+// NVLink errors (74), GPUs that fell off the bus (79), high single-bit ECC error rates (92),
+// contained and uncontained ECC errors (94, 95) and GSP errors (119, 120).
+var fatalXIDs = map[int]bool{
+	48: true, 61: true, 62: true, 64: true, 74: true, 79: true, 92: true, 94: true, 95: true,
+	119: true, 120: true,
+}
+
+// getXIDErrors returns the number of fatal XID errors in the kernel log, keyed by normalized PCI
+// bus ID.
+func getXIDErrors() (map[string]int, error) {
+	out, err := exec.Command("dmesg").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "error while executing dmesg")
+	}
+	return countFatalXIDs(string(out)), nil
+}
+
+// countFatalXIDs returns the number of fatal XID errors in a kernel log, keyed by normalized PCI
+// bus ID.
+func countFatalXIDs(log string) map[string]int {
+	xids := make(map[string]int)
+	for _, match := range xidPattern.FindAllStringSubmatch(log, -1) {
+		if code, err := strconv.Atoi(match[2]); err == nil && fatalXIDs[code] {
+			xids[normalizeBusID(match[1])]++
+		}
+	}
+	return xids
+}
+
+// normalizeBusID converts PCI bus IDs as reported by nvidia-smi ("00000000:3B:00.0") and by the
+// kernel log ("0000:3b:00") into a common "bus:device" form ("3b:00").
+func normalizeBusID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if i := strings.LastIndex(id, "."); i != -1 {
+		id = id[:i]
+	}
+	parts := strings.Split(id, ":")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, ":")
+}
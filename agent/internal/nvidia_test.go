@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestNormalizeBusID(t *testing.T) {
+	smi := normalizeBusID("00000000:3B:00.0")
+	match := xidPattern.FindStringSubmatch(
+		"NVRM: Xid (PCI:0000:3b:00): 79, pid=1234, GPU has fallen off the bus.")
+	if len(match) != 3 {
+		t.Fatalf("expected XID message to match, got %v", match)
+	}
+	if xid := normalizeBusID(match[1]); smi != xid || smi != "3b:00" {
+		t.Errorf("expected bus IDs to normalize to 3b:00, got %q and %q", smi, xid)
+	}
+}
+
+func TestCountFatalXIDs(t *testing.T) {
+	log := "NVRM: Xid (PCI:0000:3b:00): 79, pid=1234, GPU has fallen off the bus.\n" +
+		"NVRM: Xid (PCI:0000:3b:00): 13, pid=1234, Graphics Exception\n" +
+		"NVRM: Xid (PCI:0000:5e:00): 31, pid=1234, MMU Fault\n" +
+		"NVRM: Xid (PCI:0000:5e:00): 48, pid=1234, DBE (Double Bit Error) ECC Error\n" +
+		"NVRM: Xid (PCI:0000:5e:00): 43, pid=1234, Ch 00000010\n"
+	xids := countFatalXIDs(log)
+	if len(xids) != 2 || xids["3b:00"] != 1 || xids["5e:00"] != 1 {
+		t.Errorf("expected one fatal XID error on each GPU, got %v", xids)
+	}
+}
@@ -20,9 +20,18 @@ type Options struct {
 
 	ContainerMasterHost string `json:"container_master_host"`
 	ContainerMasterPort int    `json:"container_master_port"`
+	ContainerRuntime    string `json:"container_runtime"`
+	ContainerRuntimeDir string `json:"container_runtime_dir"`
 
 	Label        string `json:"label"`
 	ResourcePool string `json:"resource_pool"`
+	// MaxZeroSlotContainers limits the zero-slot containers that the agent runs at once, below
+	// the limit of its resource pool. Zero leaves it to the pool.
+	MaxZeroSlotContainers int `json:"max_zero_slot_containers"`
+	// RegistrationToken is presented to the master when it requires agents to have one to join.
+	RegistrationToken string `json:"registration_token"`
+
+	InterruptionWatcher string `json:"interruption_watcher"`
 
 	APIEnabled bool   `json:"api_enabled"`
 	BindIP     string `json:"bind_ip"`
@@ -49,6 +58,10 @@ func (o Options) Validate() []error {
 	return []error{
 		o.validateTLS(),
 		check.In(o.SlotType, []string{"gpu", "auto", "none"}),
+		check.In(o.ContainerRuntime, containerRuntimeNames),
+		check.In(o.InterruptionWatcher, interruptionWatcherNames),
+		check.GreaterThanOrEqualTo(o.MaxZeroSlotContainers, 0,
+			"max_zero_slot_containers must not be negative"),
 	}
 }
 
@@ -67,6 +80,9 @@ func (o Options) validateTLS() error {
 
 // Printable returns a printable string.
 func (o Options) Printable() ([]byte, error) {
+	if o.RegistrationToken != "" {
+		o.RegistrationToken = "********"
+	}
 	optJSON, err := json.Marshal(o)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to convert config to JSON")
@@ -77,6 +93,10 @@ func (o Options) Printable() ([]byte, error) {
 // SecurityOptions stores configurable security-related options.
 type SecurityOptions struct {
 	TLS TLSOptions `json:"tls"`
+	// UsernsRemapUser is the user whose /etc/subuid and /etc/subgid ranges the Docker daemon uses
+	// when it runs rootless or with user namespace remapping. Defaults to "dockremap" for remapping
+	// and to the agent's user for rootless Docker.
+	UsernsRemapUser string `json:"userns_remap_user"`
 }
 
 // TLSOptions is the TLS connection configuration for the agent.
@@ -85,6 +105,10 @@ type TLSOptions struct {
 	SkipVerify     bool   `json:"skip_verify"`
 	MasterCert     string `json:"master_cert"`
 	MasterCertName string `json:"master_cert_name"`
+	// ClientCert and ClientKey are the certificate and private key that the master issued to the
+	// agent, which it must connect with when the master requires mTLS.
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -93,6 +117,9 @@ func (t TLSOptions) Validate() []error {
 	if t.MasterCert != "" && t.SkipVerify {
 		errs = append(errs, errors.New("cannot specify a master cert file with verification off"))
 	}
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		errs = append(errs, errors.New("a client cert file and key file must be specified together"))
+	}
 	return errs
 }
 
@@ -0,0 +1,321 @@
+package internal
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// stagedDirs are the directories of task files that are bound into containers as a whole. Files
+// staged anywhere else are bound one at a time so that they do not hide the rest of the image.
+var stagedDirs = []string{"/run/determined", "/opt/determined"}
+
+// bindMount is a path on the agent host made available at a path in a container.
+type bindMount struct {
+	source   string
+	target   string
+	readOnly bool
+}
+
+// processRuntime holds the parts of a containerRuntime that are shared by the runtimes that run
+// each container in the foreground of a CLI process started by the agent. Files that Docker would
+// copy into a container are staged in a directory per container and bound in when it starts.
+type processRuntime struct {
+	dir string
+
+	mu        sync.Mutex
+	processes map[string]*exec.Cmd
+}
+
+func newProcessRuntime(dir string) (*processRuntime, error) {
+	if dir == "" {
+		return nil, errors.New("a container runtime directory must be configured")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "containers"), 0700); err != nil {
+		return nil, errors.Wrapf(err, "error creating container runtime directory %s", dir)
+	}
+	return &processRuntime{dir: dir, processes: map[string]*exec.Cmd{}}, nil
+}
+
+func (p *processRuntime) stagingDir(id string) string {
+	return filepath.Join(p.dir, "containers", id)
+}
+
+// CreateContainer implements containerRuntime. It stages the spec's archives on the host; the
+// container itself is only created by the runtime's CLI when it starts.
+func (p *processRuntime) CreateContainer(
+	ctx context.Context, spec container.RunSpec, logger containerLogger,
+) (string, error) {
+	id := uuid.New().String()
+	root := p.stagingDir(id)
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return "", errors.Wrap(err, "error creating container staging directory")
+	}
+	for _, arx := range spec.Archives {
+		logger.aux("copying files to container: " + arx.Path)
+		if err := stageArchive(filepath.Join(root, arx.Path), arx.Archive); err != nil {
+			return id, errors.Wrap(err, "error staging files for container")
+		}
+	}
+	return id, nil
+}
+
+// stageArchive writes the items of an archive under dir, keeping their modes and, when the agent
+// is able to, their owners. Items may not be written outside of dir, neither through ".." nor
+// through symbolic links that earlier items created.
+func stageArchive(dir string, arx archive.Archive) error {
+	for _, item := range arx {
+		path, err := stagedPath(dir, item.Path)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		switch item.Type {
+		case tar.TypeDir:
+			if err = os.MkdirAll(path, item.FileMode.Perm()); err != nil {
+				return err
+			}
+			if err = os.Chmod(path, item.FileMode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = writeStagedFile(path, item.Content, item.FileMode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = os.Symlink(string(item.Content), path); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		if os.Geteuid() == 0 {
+			if err = os.Lchown(path, item.UserID, item.GroupID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stagedPath returns the path under dir of an archive item, or an error if the path leaves dir or
+// passes through a symbolic link, which could point anywhere on the agent host.
+func stagedPath(dir, itemPath string) (string, error) {
+	path := filepath.Join(dir, itemPath)
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("archive item %s is outside of the staging directory", itemPath)
+	}
+	current := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		switch {
+		case os.IsNotExist(err):
+			return path, nil
+		case err != nil:
+			return "", err
+		case info.Mode()&os.ModeSymlink != 0:
+			return "", errors.Errorf("archive item %s passes through a symbolic link", itemPath)
+		}
+	}
+	return path, nil
+}
+
+// writeStagedFile writes a file without following a symbolic link at its path.
+func writeStagedFile(path string, content []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// stagedBinds returns the bind mounts that make a container's staged files visible inside it.
+func (p *processRuntime) stagedBinds(id string) ([]bindMount, error) {
+	root := p.stagingDir(id)
+	var binds []bindMount
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		target := "/" + strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(path, root)), "/")
+		for _, dir := range stagedDirs {
+			if target == dir {
+				binds = append(binds, bindMount{source: path, target: target})
+				return filepath.SkipDir
+			}
+		}
+		// Binding a symbolic link binds what it points to on the agent host, so staged links are
+		// only usable within the directories that are bound as a whole.
+		if !info.IsDir() && info.Mode()&os.ModeSymlink == 0 {
+			binds = append(binds, bindMount{source: path, target: target})
+		}
+		return nil
+	})
+	sort.Slice(binds, func(i, j int) bool { return binds[i].target < binds[j].target })
+	return binds, err
+}
+
+// userBinds returns the bind mounts that the spec requests.
+func userBinds(spec container.RunSpec) []bindMount {
+	var binds []bindMount
+	for _, m := range spec.HostConfig.Mounts {
+		binds = append(binds, bindMount{source: m.Source, target: m.Target, readOnly: m.ReadOnly})
+	}
+	return binds
+}
+
+// start runs the command for a container and returns the channel that receives its exit.
+func (p *processRuntime) start(
+	id string, spec container.RunSpec, cmd *exec.Cmd, logger containerLogger,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	cmd.Stdout = logger.output(stdcopy.Stdout)
+	cmd.Stderr = logger.output(stdcopy.Stderr)
+	logger.aux("starting container: " + strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error starting container")
+	}
+
+	p.mu.Lock()
+	p.processes[id] = cmd
+	p.mu.Unlock()
+
+	exits := make(chan containerExit, 1)
+	go func() {
+		err := cmd.Wait()
+		p.mu.Lock()
+		delete(p.processes, id)
+		p.mu.Unlock()
+
+		var exitErr *exec.ExitError
+		switch {
+		case err == nil:
+			exits <- containerExit{}
+		case errors.As(err, &exitErr):
+			exits <- containerExit{code: int64(exitErr.ExitCode())}
+		default:
+			exits <- containerExit{err: err}
+		}
+	}()
+	return hostContainerInfo(id, spec), exits, nil
+}
+
+// process returns the running process for a container.
+func (p *processRuntime) process(id string) (*os.Process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cmd, ok := p.processes[id]
+	if !ok {
+		return nil, errors.Errorf("container is not running: %s", id)
+	}
+	return cmd.Process, nil
+}
+
+// ContainerStats implements containerRuntime. Containers share the network of the host, so their
+// network traffic is not counted.
+func (p *processRuntime) ContainerStats(ctx context.Context, id string) (containerStats, error) {
+	proc, err := p.process(id)
+	if err != nil {
+		return containerStats{}, err
+	}
+	return processTreeStats(int32(proc.Pid))
+}
+
+// processTreeStats returns the resources that a process and its descendants have used so far.
+func processTreeStats(pid int32) (containerStats, error) {
+	root, err := process.NewProcess(pid)
+	if err != nil {
+		return containerStats{}, errors.Wrapf(err, "error finding process %d", pid)
+	}
+	var stats containerStats
+	procs := []*process.Process{root}
+	for len(procs) > 0 {
+		proc := procs[0]
+		procs = procs[1:]
+		// Processes may exit while they are walked, so only the root must be readable.
+		times, err := proc.Times()
+		if err != nil {
+			if proc == root {
+				return containerStats{}, errors.Wrapf(err, "error reading process %d", pid)
+			}
+			continue
+		}
+		stats.cpuSeconds += times.User + times.System
+		if mem, err := proc.MemoryInfo(); err == nil {
+			stats.memoryBytes += mem.RSS
+		}
+		if children, err := proc.Children(); err == nil {
+			procs = append(procs, children...)
+		}
+	}
+	return stats, nil
+}
+
+// RunningContainers implements containerRuntime. Containers run in the foreground of processes
+// that the agent starts, which it cannot find again once it restarts.
+func (p *processRuntime) RunningContainers(
+	ctx context.Context, agentID string,
+) ([]runningContainer, error) {
+	return nil, nil
+}
+
+// ReattachContainer implements containerRuntime.
+func (p *processRuntime) ReattachContainer(
+	ctx context.Context, id string,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	return types.ContainerJSON{}, nil, errors.Errorf("cannot reattach to container: %s", id)
+}
+
+// removeStaged removes the files that were staged for a container.
+func (p *processRuntime) removeStaged(id string) error {
+	return os.RemoveAll(p.stagingDir(id))
+}
+
+// hostContainerInfo describes a container that shares the host's network in the form that Docker
+// reports containers, which is what the rest of the agent and the master expect.
+func hostContainerInfo(id string, spec container.RunSpec) types.ContainerJSON {
+	config := spec.ContainerConfig
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         id,
+			HostConfig: &dcontainer.HostConfig{NetworkMode: "host"},
+		},
+		Config: &config,
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"host": {}},
+		},
+	}
+}
+
+// gpuUUIDs returns the GPUs that the spec requests.
+func gpuUUIDs(spec container.RunSpec) []string {
+	var uuids []string
+	for _, r := range spec.HostConfig.DeviceRequests {
+		uuids = append(uuids, r.DeviceIDs...)
+	}
+	return uuids
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	dcontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+func testRunSpec() container.RunSpec {
+	return container.RunSpec{
+		ContainerConfig: dcontainer.Config{
+			Image:      "determinedai/environments:py-3.7",
+			Cmd:        []string{"/run/determined/train/entrypoint.sh"},
+			Env:        []string{"DET_TASK_ID=1"},
+			User:       "1000:1000",
+			WorkingDir: "/run/determined/workdir",
+		},
+		HostConfig: dcontainer.HostConfig{
+			Mounts: []mount.Mount{{Source: "/data", Target: "/data", ReadOnly: true}},
+			Resources: dcontainer.Resources{
+				NanoCPUs: 1.5e9,
+				Memory:   1 << 30,
+				DeviceRequests: []dcontainer.DeviceRequest{
+					{Driver: "nvidia", DeviceIDs: []string{"GPU-1", "GPU-2"}},
+				},
+			},
+		},
+	}
+}
+
+func TestStagedBinds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "process-runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	p, err := newProcessRuntime(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := p.stagingDir("c1")
+	if err = stageArchive(filepath.Join(root, "/"), archive.Archive{
+		archive.RootItem("/run/determined/etc/passwd", []byte("user"), 0644, tar.TypeReg),
+		archive.RootItem("/opt/determined/wheels/a.whl", []byte("wheel"), 0644, tar.TypeReg),
+		archive.RootItem("/etc/extra.conf", []byte("extra"), 0644, tar.TypeReg),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	binds, err := p.stagedBinds("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []bindMount{
+		{source: filepath.Join(root, "etc/extra.conf"), target: "/etc/extra.conf"},
+		{source: filepath.Join(root, "opt/determined"), target: "/opt/determined"},
+		{source: filepath.Join(root, "run/determined"), target: "/run/determined"},
+	}
+	if !reflect.DeepEqual(binds, expected) {
+		t.Errorf("expected binds %v, got %v", expected, binds)
+	}
+
+	if err = p.removeStaged("c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("expected staged files to be removed, got %v", err)
+	}
+}
+
+func TestStageArchiveEscapes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "process-runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	if err = os.MkdirAll(outside, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, arx := range map[string]archive.Archive{
+		"dot-dot": {
+			archive.RootItem("../outside/escaped", []byte("x"), 0644, tar.TypeReg),
+		},
+		"symlink-parent": {
+			archive.RootItem("/link", []byte(outside), 0777, tar.TypeSymlink),
+			archive.RootItem("/link/escaped", []byte("x"), 0644, tar.TypeReg),
+		},
+		"symlink-file": {
+			archive.RootItem("/link", []byte(filepath.Join(outside, "escaped")), 0777,
+				tar.TypeSymlink),
+			archive.RootItem("/link", []byte("x"), 0644, tar.TypeReg),
+		},
+	} {
+		_ = os.RemoveAll(root)
+		if err = stageArchive(root, arx); err == nil {
+			t.Errorf("%s: expected staging to fail", name)
+		}
+		if _, err = os.Stat(filepath.Join(outside, "escaped")); !os.IsNotExist(err) {
+			t.Errorf("%s: expected no file outside of the staging directory, got %v", name, err)
+		}
+	}
+}
+
+func TestNerdctlRunArgs(t *testing.T) {
+	spec := testRunSpec()
+	args, env := nerdctlRunArgs("c1", spec, userBinds(spec))
+	expected := []string{
+		"run", "--name", "c1", "--net", "host",
+		"--user", "1000:1000",
+		"--workdir", "/run/determined/workdir",
+		"--env", "DET_TASK_ID",
+		"--volume", "/data:/data:ro",
+		"--cpus", "1.5",
+		"--memory", "1073741824",
+		"--gpus", `"device=GPU-1,GPU-2"`,
+		"determinedai/environments:py-3.7", "/run/determined/train/entrypoint.sh",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected args %v, got %v", expected, args)
+	}
+	if expectedEnv := []string{"DET_TASK_ID=1"}; !reflect.DeepEqual(env, expectedEnv) {
+		t.Errorf("expected env %v, got %v", expectedEnv, env)
+	}
+}
+
+func TestSingularityExecArgs(t *testing.T) {
+	s := &singularityRuntime{envPrefix: "APPTAINER"}
+	spec := testRunSpec()
+	args, env := s.execArgs("/images/env.sif", spec, userBinds(spec))
+	expectedArgs := []string{
+		"exec", "--cleanenv", "--pwd", "/run/determined/workdir",
+		"--bind", "/data:/data:ro", "--nv",
+		"/images/env.sif", "/run/determined/train/entrypoint.sh",
+	}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+	expectedEnv := []string{
+		"APPTAINERENV_DET_TASK_ID=1", "APPTAINERENV_CUDA_VISIBLE_DEVICES=GPU-1,GPU-2",
+	}
+	if !reflect.DeepEqual(env, expectedEnv) {
+		t.Errorf("expected env %v, got %v", expectedEnv, env)
+	}
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"math"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+type (
+	// profileTick is an internal message that triggers the agent to sample the utilization of its
+	// task containers.
+	profileTick struct{}
+	// profileContainers asks the running task containers to sample their utilization, along with
+	// the utilization of the GPUs of the agent, keyed by device ID.
+	profileContainers struct {
+		gpus map[int]aproto.DeviceUtilization
+	}
+)
+
+// profile has the task containers sample their utilization and schedules the next samples. The
+// GPUs are queried once for all containers, since nvidia-smi reports every GPU at once.
+func (a *agent) profile(ctx *actor.Context) {
+	msg := profileContainers{gpus: map[int]aproto.DeviceUtilization{}}
+	if hasGPUs(a.Devices) {
+		stats, err := getGPUStats(a.Options.VisibleGPUs)
+		if err != nil {
+			ctx.Log().WithError(err).Debug("error gathering GPU utilization")
+		}
+		for id, s := range stats {
+			msg.gpus[id] = aproto.DeviceUtilization{
+				ID:                 id,
+				UtilizationPercent: s.utilization,
+				MemoryUsedMiB:      s.memoryUsed,
+				MemoryTotalMiB:     s.memoryTotal,
+			}
+		}
+	}
+	ctx.Tell(a.cm, msg)
+	actors.NotifyAfter(ctx, a.MasterSetAgentOptions.ProfilingPeriod, profileTick{})
+}
+
+func hasGPUs(devices []device.Device) bool {
+	for _, d := range devices {
+		if d.Type == device.GPU {
+			return true
+		}
+	}
+	return false
+}
+
+// profile asks the runtime for the resources that the container has used so far, if it is
+// running.
+func (c *containerActor) profile(ctx *actor.Context, msg profileContainers) {
+	if c.State != cproto.Running || c.containerInfo == nil {
+		return
+	}
+	c.profileGPUs = nil
+	for _, d := range c.Devices {
+		if u, ok := msg.gpus[d.PhysicalID()]; ok && d.Type == device.GPU {
+			u.ID = d.ID
+			c.profileGPUs = append(c.profileGPUs, u)
+		}
+	}
+	ctx.Tell(c.runtimeActor, sampleContainer{runtimeID: c.containerInfo.ID})
+}
+
+// sampled reports the utilization of the container since its previous sample to the master. The
+// first sample of a container only sets the baseline of the next.
+func (c *containerActor) sampled(ctx *actor.Context, msg containerSampled) {
+	if msg.err != nil {
+		ctx.Log().WithError(msg.err).Debug("error sampling container")
+		return
+	}
+	prev := c.lastSample
+	c.lastSample = &msg
+	if prev == nil || c.State != cproto.Running {
+		return
+	}
+	elapsed := msg.time.Sub(prev.time).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	cpuSeconds := math.Max(0, msg.stats.cpuSeconds-prev.stats.cpuSeconds)
+	ctx.Tell(ctx.Self().Parent(), aproto.ContainerProfile{
+		Container:               c.Container,
+		Time:                    msg.time,
+		CPUUtilizationPercent:   100 * cpuSeconds / elapsed,
+		MemoryUsedBytes:         msg.stats.memoryBytes,
+		NetworkRxBytesPerSecond: rate(prev.stats.networkRxBytes, msg.stats.networkRxBytes, elapsed),
+		NetworkTxBytesPerSecond: rate(prev.stats.networkTxBytes, msg.stats.networkTxBytes, elapsed),
+		GPUs:                    c.profileGPUs,
+	})
+}
+
+// rate returns how fast a counter grew, or zero if it was reset in between.
+func rate(prev, cur uint64, elapsed float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / elapsed
+}
@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestProcessTreeStats(t *testing.T) {
+	stats, err := processTreeStats(int32(os.Getpid()))
+	assert.NilError(t, err)
+	assert.Assert(t, stats.memoryBytes > 0)
+	assert.Equal(t, stats.networkRxBytes, uint64(0))
+}
+
+func TestRate(t *testing.T) {
+	assert.Equal(t, rate(100, 300, 2), 100.0)
+	// Counters that were reset do not report a negative rate.
+	assert.Equal(t, rate(300, 100, 2), 0.0)
+}
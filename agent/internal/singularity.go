@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// singularityRuntime runs task containers with Singularity or its successor, Apptainer. Images are
+// converted from Docker images into SIF files that are cached in the container runtime directory.
+// Containers run as the agent's user and share the host's network; the capabilities, shared memory
+// size and CPU and memory limits of a spec are not supported.
+type singularityRuntime struct {
+	*processRuntime
+	binary string
+	// envPrefix is the prefix of the environment variables that the binary reads, e.g.
+	// SINGULARITY_DOCKER_USERNAME or APPTAINERENV_FOO.
+	envPrefix string
+
+	// images maps the names of pulled images to the files that they were converted to.
+	images map[string]string
+}
+
+func newSingularityRuntime(opts Options) (*singularityRuntime, error) {
+	name := opts.ContainerRuntime
+	binary, err := exec.LookPath(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the %s runtime requires the %s command", name, name)
+	}
+	p, err := newProcessRuntime(opts.ContainerRuntimeDir)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Join(p.dir, "images"), 0700); err != nil {
+		return nil, errors.Wrap(err, "error creating image cache directory")
+	}
+	return &singularityRuntime{
+		processRuntime: p,
+		binary:         binary,
+		envPrefix:      strings.ToUpper(name),
+		images:         map[string]string{},
+	}, nil
+}
+
+// imagePath returns the path of the SIF file that an image is converted to.
+func (s *singularityRuntime) imagePath(name, platform string) string {
+	file := sanitizedImageName(name)
+	if platform != "" {
+		file += "_" + sanitizedImageName(platform)
+	}
+	return filepath.Join(s.dir, "images", file+".sif")
+}
+
+// PullImage implements containerRuntime.
+func (s *singularityRuntime) PullImage(
+	ctx context.Context, req pullImage, logger containerLogger,
+) error {
+	path := s.imagePath(req.Name, req.Platform)
+	if _, err := os.Stat(path); err == nil && !req.ForcePull {
+		logger.aux(fmt.Sprintf("image already found, skipping pull phase: %s", req.Name))
+		s.setImage(req.Name, path)
+		return nil
+	}
+
+	logger.aux(fmt.Sprintf("pulling image: %s", req.Name))
+	args := []string{"pull", "--force"}
+	if req.Platform != "" {
+		args = append(args, "--arch", strings.SplitN(req.Platform, "/", 2)[1])
+	}
+	args = append(args, path, "docker://"+req.Name)
+	cmd := exec.CommandContext(ctx, s.binary, args...) // #nosec G204
+	cmd.Env = os.Environ()
+	if req.Registry != nil {
+		cmd.Env = append(cmd.Env,
+			s.envPrefix+"_DOCKER_USERNAME="+req.Registry.Username,
+			s.envPrefix+"_DOCKER_PASSWORD="+req.Registry.Password,
+		)
+	}
+	if err := runLogged(cmd, logger); err != nil {
+		return err
+	}
+	s.setImage(req.Name, path)
+	return nil
+}
+
+func (s *singularityRuntime) setImage(name, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[name] = path
+}
+
+func (s *singularityRuntime) image(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path, ok := s.images[name]; ok {
+		return path
+	}
+	return s.imagePath(name, "")
+}
+
+// StartContainer implements containerRuntime.
+func (s *singularityRuntime) StartContainer(
+	ctx context.Context, id string, spec container.RunSpec, logger containerLogger,
+) (types.ContainerJSON, <-chan containerExit, error) {
+	if err := checkContainerUser(spec.ContainerConfig.User, os.Getuid(), os.Getgid()); err != nil {
+		return types.ContainerJSON{}, nil, errors.Wrapf(err, "cannot run %s container", s.binary)
+	}
+
+	binds, err := s.stagedBinds(id)
+	if err != nil {
+		return types.ContainerJSON{}, nil, errors.Wrap(err, "error reading staged files")
+	}
+	image := s.image(spec.ContainerConfig.Image)
+	args, env := s.execArgs(image, spec, append(binds, userBinds(spec)...))
+	// The container must outlive the request that started it, so it is not bound to ctx.
+	cmd := exec.Command(s.binary, args...) // #nosec G204
+	cmd.Env = append(os.Environ(), env...)
+	return s.start(id, spec, cmd, logger)
+}
+
+// checkContainerUser returns an error unless a container configured to run as the "uid:gid" user
+// would run as the agent's uid and gid. Singularity containers always run as the user that starts
+// them, so running a task as any other user would bypass its agent user group.
+func checkContainerUser(containerUser string, uid, gid int) error {
+	if containerUser == "" {
+		return nil
+	}
+	ids := strings.SplitN(containerUser, ":", 2)
+	if ids[0] != strconv.Itoa(uid) {
+		return errors.Errorf(
+			"containers run as the agent's uid %d, but the task must run as %s; start the agent "+
+				"as the task's user", uid, containerUser)
+	}
+	if len(ids) == 2 && ids[1] != strconv.Itoa(gid) {
+		return errors.Errorf(
+			"containers run as the agent's gid %d, but the task must run as %s; start the agent "+
+				"as the task's user", gid, containerUser)
+	}
+	return nil
+}
+
+// execArgs returns the arguments and additional environment variables that run a container from
+// the image.
+func (s *singularityRuntime) execArgs(
+	image string, spec container.RunSpec, binds []bindMount,
+) ([]string, []string) {
+	config := spec.ContainerConfig
+	args := []string{"exec", "--cleanenv"}
+	if config.WorkingDir != "" {
+		args = append(args, "--pwd", config.WorkingDir)
+	}
+	for _, b := range binds {
+		bind := b.source + ":" + b.target
+		if b.readOnly {
+			bind += ":ro"
+		}
+		args = append(args, "--bind", bind)
+	}
+	for _, d := range spec.HostConfig.Devices {
+		args = append(args, "--bind", d.PathOnHost+":"+d.PathInContainer)
+	}
+
+	// Variables with the runtime's ENV prefix are passed through --cleanenv into the container.
+	var env []string
+	for _, e := range config.Env {
+		env = append(env, s.envPrefix+"ENV_"+e)
+	}
+	if uuids := gpuUUIDs(spec); len(uuids) > 0 {
+		args = append(args, "--nv")
+		env = append(env, s.envPrefix+"ENV_CUDA_VISIBLE_DEVICES="+strings.Join(uuids, ","))
+	}
+
+	args = append(args, image)
+	args = append(args, config.Entrypoint...)
+	return append(args, config.Cmd...), env
+}
+
+// SignalContainer implements containerRuntime.
+func (s *singularityRuntime) SignalContainer(
+	ctx context.Context, id string, signal syscall.Signal,
+) error {
+	process, err := s.process(id)
+	if err != nil {
+		return err
+	}
+	return process.Signal(signal)
+}
+
+// RemoveContainer implements containerRuntime.
+func (s *singularityRuntime) RemoveContainer(ctx context.Context, id string) error {
+	return s.removeStaged(id)
+}
+
+// PinImage implements containerRuntime. Converted images are only ever removed by hand, so they
+// are always pinned.
+func (s *singularityRuntime) PinImage(ctx context.Context, name string) error {
+	return nil
+}
@@ -0,0 +1,22 @@
+package internal
+
+import "testing"
+
+func TestCheckContainerUser(t *testing.T) {
+	for user, valid := range map[string]bool{
+		"":         true,
+		"1000":     true,
+		"1000:100": true,
+		"0:0":      false,
+		"1000:0":   false,
+		"2000:100": false,
+		"someone":  false,
+	} {
+		err := checkContainerUser(user, 1000, 100)
+		if valid && err != nil {
+			t.Errorf("expected user %q to be allowed, got %v", user, err)
+		} else if !valid && err == nil {
+			t.Errorf("expected user %q to be rejected", user)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"bufio"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+var (
+	detectTopologyArgs = []string{"nvidia-smi", "topo", "--matrix"}
+
+	// nvidia-smi underlines the header of the matrix when writing to a terminal.
+	ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	nicLegendPattern  = regexp.MustCompile(`^\s*(NIC\d+):\s*(\S+)\s*$`)
+)
+
+// nicLocalConnections are the connection types in the topology matrix between a GPU and a
+// network interface that do not cross a PCIe host bridge.
+var nicLocalConnections = map[string]bool{"PIX": true, "PXB": true}
+
+// detectTopology returns how the given GPUs are connected to each other and to the network
+// interfaces of the host, or nil if nvidia-smi cannot tell.
+func detectTopology(devices []device.Device) (*device.Topology, error) {
+	gpus := make(map[int]bool)
+	for _, d := range devices {
+		if d.Type == device.GPU && d.MIGProfile == "" {
+			gpus[d.ID] = true
+		}
+	}
+	if len(gpus) == 0 {
+		return nil, nil
+	}
+
+	// #nosec G204
+	cmd := exec.Command(detectTopologyArgs[0], detectTopologyArgs[1:]...)
+	out, err := cmd.Output()
+	if execError, ok := err.(*exec.Error); ok && execError.Err == exec.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		log.WithError(err).WithField("output", string(out)).Warnf("error while executing nvidia-smi")
+		return nil, nil
+	}
+
+	topology, err := parseTopology(string(out))
+	if err != nil {
+		return nil, err
+	}
+
+	// The matrix covers every GPU on the host, including ones hidden from the agent.
+	for gpu, peers := range topology.NVLinks {
+		if !gpus[gpu] {
+			delete(topology.NVLinks, gpu)
+			continue
+		}
+		visible := peers[:0]
+		for _, peer := range peers {
+			if gpus[peer] {
+				visible = append(visible, peer)
+			}
+		}
+		topology.NVLinks[gpu] = visible
+	}
+	for gpu := range topology.NICs {
+		if !gpus[gpu] {
+			delete(topology.NICs, gpu)
+		}
+	}
+	return topology, nil
+}
+
+// parseTopology parses the topology matrix printed by `nvidia-smi topo --matrix`. The matrix has
+// a row and a column for every GPU and network interface, and each cell names the kind of
+// connection between the two, e.g. "NV12" for twelve bonded NVLinks or "PIX" for a single PCIe
+// bridge. Newer drivers name network interfaces NIC0, NIC1, ... in the matrix and list their
+// device names in a legend below it.
+func parseTopology(out string) (*device.Topology, error) {
+	var header []string
+	var rows [][]string
+	nicNames := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(ansiEscapePattern.ReplaceAllString(out, "")))
+	inMatrix := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := nicLegendPattern.FindStringSubmatch(line); match != nil {
+			nicNames[match[1]] = match[2]
+			continue
+		}
+		if !inMatrix {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// The matrix ends at the first blank line after it starts.
+			inMatrix = header == nil
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if header == nil {
+			header = fields
+		} else {
+			rows = append(rows, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading output of nvidia-smi topo")
+	}
+	if header == nil {
+		return nil, errors.New("error parsing output of nvidia-smi topo; no topology matrix")
+	}
+
+	topology := &device.Topology{NVLinks: make(map[int][]int), NICs: make(map[int][]string)}
+	for _, row := range rows {
+		gpu, ok := parseGPUName(row[0])
+		if !ok {
+			continue
+		}
+		topology.NVLinks[gpu] = []int{}
+		for i := 1; i < len(row) && i < len(header); i++ {
+			column := header[i]
+			if peer, ok := parseGPUName(column); ok {
+				if strings.HasPrefix(row[i], "NV") && peer != gpu {
+					topology.NVLinks[gpu] = append(topology.NVLinks[gpu], peer)
+				}
+				continue
+			}
+			if !nicLocalConnections[row[i]] {
+				continue
+			}
+			if name, ok := nicNames[column]; ok {
+				column = name
+			}
+			topology.NICs[gpu] = append(topology.NICs[gpu], column)
+		}
+	}
+	return topology, nil
+}
+
+func parseGPUName(name string) (int, bool) {
+	if !strings.HasPrefix(name, "GPU") {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(name, "GPU"))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+const topologyMatrix = "" +
+	"\t\x1b[4mGPU0\tGPU1\tGPU2\tGPU3\tNIC0\tNIC1\tCPU Affinity\tNUMA Affinity\x1b[0m\n" +
+	"\x1b[4mGPU0\x1b[0m\t X \tNV12\tSYS\tSYS\tPXB\tSYS\t0-23\t0\n" +
+	"\x1b[4mGPU1\x1b[0m\tNV12\t X \tSYS\tSYS\tPXB\tSYS\t0-23\t0\n" +
+	"\x1b[4mGPU2\x1b[0m\tSYS\tSYS\t X \tNV12\tSYS\tPIX\t24-47\t1\n" +
+	"\x1b[4mGPU3\x1b[0m\tSYS\tSYS\tNV12\t X \tSYS\tPHB\t24-47\t1\n" +
+	"\x1b[4mNIC0\x1b[0m\tPXB\tPXB\tSYS\tSYS\t X \tSYS\t\t\n" +
+	"\x1b[4mNIC1\x1b[0m\tSYS\tSYS\tPIX\tPHB\tSYS\t X \t\t\n" +
+	"\n" +
+	"Legend:\n" +
+	"\n" +
+	"  X    = Self\n" +
+	"  SYS  = Connection traversing PCIe as well as the SMP interconnect between NUMA nodes\n" +
+	"  NV#  = Connection traversing a bonded set of # NVLinks\n" +
+	"\n" +
+	"NIC Legend:\n" +
+	"\n" +
+	"  NIC0: mlx5_0\n" +
+	"  NIC1: mlx5_1\n"
+
+func TestParseTopology(t *testing.T) {
+	topology, err := parseTopology(topologyMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &device.Topology{
+		NVLinks: map[int][]int{0: {1}, 1: {0}, 2: {3}, 3: {2}},
+		NICs:    map[int][]string{0: {"mlx5_0"}, 1: {"mlx5_0"}, 2: {"mlx5_1"}},
+	}
+	if !reflect.DeepEqual(topology, expected) {
+		t.Errorf("expected topology %v, got %v", expected, topology)
+	}
+	if rails := topology.Rails([]int{0, 1, 2}); rails != "0=mlx5_0,1=mlx5_0,2=mlx5_1" {
+		t.Errorf("unexpected rails %q", rails)
+	}
+	if rails := topology.Rails([]int{2, 3}); rails != "" {
+		t.Errorf("expected no rails for a GPU without a local NIC, got %q", rails)
+	}
+}
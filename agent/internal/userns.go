@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	usernsModeRemap    = "userns"
+	usernsModeRootless = "rootless"
+	// defaultRemapUser is the user Docker creates for `--userns-remap=default`.
+	defaultRemapUser = "dockremap"
+	subUIDPath       = "/etc/subuid"
+	subGIDPath       = "/etc/subgid"
+)
+
+// userNamespace describes how the Docker daemon maps container user and group IDs onto host IDs
+// when it runs rootless or with user namespace remapping. Containers that run as an ID outside of
+// the mapping fail to start with an opaque error, so the agent checks task users up front.
+type userNamespace struct {
+	mode    string
+	uidSize int
+	gidSize int
+}
+
+// detectUserNamespace returns the ID mapping the Docker daemon uses, or nil if the daemon maps
+// container IDs directly onto host IDs. remapUser overrides the user whose subordinate ID ranges
+// the daemon uses.
+func detectUserNamespace(info types.Info, remapUser string) (*userNamespace, error) {
+	var mode string
+	for _, opt := range info.SecurityOptions {
+		for _, field := range strings.Split(opt, ",") {
+			switch field {
+			case "name=" + usernsModeRemap:
+				mode = usernsModeRemap
+			case "name=" + usernsModeRootless:
+				mode = usernsModeRootless
+			}
+		}
+	}
+	if mode == "" {
+		return nil, nil
+	}
+
+	if remapUser == "" {
+		remapUser = defaultRemapUser
+		if mode == usernsModeRootless {
+			current, err := user.Current()
+			if err != nil {
+				return nil, errors.Wrap(err, "error looking up the rootless Docker user")
+			}
+			remapUser = current.Username
+		}
+	}
+	owners := []string{remapUser}
+	if u, err := user.Lookup(remapUser); err == nil {
+		owners = append(owners, u.Uid)
+	}
+
+	uidSize, err := subIDRangeSizeFromFile(subUIDPath, owners)
+	if err != nil {
+		return nil, err
+	}
+	gidSize, err := subIDRangeSizeFromFile(subGIDPath, owners)
+	if err != nil {
+		return nil, err
+	}
+	if mode == usernsModeRootless {
+		// Rootless Docker maps container root onto the daemon's own user, in addition to the
+		// subordinate ranges.
+		uidSize++
+		gidSize++
+	}
+	return &userNamespace{mode: mode, uidSize: uidSize, gidSize: gidSize}, nil
+}
+
+func subIDRangeSizeFromFile(path string, owners []string) (int, error) {
+	f, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return 0, errors.Wrapf(err, "error reading subordinate ID ranges from %s", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return subIDRangeSize(f, owners)
+}
+
+// subIDRangeSize returns the total number of subordinate IDs granted to any of the owners in a
+// subuid(5) or subgid(5) formatted file.
+func subIDRangeSize(r io.Reader, owners []string) (int, error) {
+	size := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) != 3 || !containsString(owners, fields[0]) {
+			continue
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, errors.Wrapf(err, "malformed subordinate ID range: %s", scanner.Text())
+		}
+		size += count
+	}
+	return size, scanner.Err()
+}
+
+// validate checks that the "uid:gid" user a container is configured to run as is mapped by the
+// user namespace. A nil userNamespace accepts every user.
+func (u *userNamespace) validate(containerUser string) error {
+	if u == nil || containerUser == "" {
+		return nil
+	}
+	ids := strings.SplitN(containerUser, ":", 2)
+	if uid, err := strconv.Atoi(ids[0]); err == nil && uid >= u.uidSize {
+		return errors.Errorf(
+			"container uid %d is not mapped by the Docker daemon's %s user namespace, which maps "+
+				"uids below %d; grant a larger range in %s", uid, u.mode, u.uidSize, subUIDPath)
+	}
+	if len(ids) == 2 {
+		if gid, err := strconv.Atoi(ids[1]); err == nil && gid >= u.gidSize {
+			return errors.Errorf(
+				"container gid %d is not mapped by the Docker daemon's %s user namespace, which "+
+					"maps gids below %d; grant a larger range in %s", gid, u.mode, u.gidSize, subGIDPath)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestSubIDRangeSize(t *testing.T) {
+	subuid := "dockremap:100000:65536\nother:200000:65536\n1000:300000:1000\n"
+	size, err := subIDRangeSize(strings.NewReader(subuid), []string{"dockremap", "1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 66536 {
+		t.Errorf("expected 66536 subordinate IDs, got %d", size)
+	}
+
+	if _, err = subIDRangeSize(strings.NewReader("dockremap:100000:lots\n"),
+		[]string{"dockremap"}); err == nil {
+		t.Error("expected an error for a malformed range")
+	}
+}
+
+func TestUserNamespaceValidate(t *testing.T) {
+	var unmapped *userNamespace
+	if err := unmapped.validate("70000:70000"); err != nil {
+		t.Errorf("expected any user to be valid without a user namespace, got %v", err)
+	}
+
+	userns := &userNamespace{mode: usernsModeRemap, uidSize: 65536, gidSize: 1000}
+	for user, valid := range map[string]bool{
+		"":            true,
+		"0:0":         true,
+		"1000:999":    true,
+		"65536:0":     false,
+		"1000:1000":   false,
+		"someone":     true,
+		"someone:100": true,
+	} {
+		if err := userns.validate(user); (err == nil) != valid {
+			t.Errorf("validate(%q): expected valid=%t, got %v", user, valid, err)
+		}
+	}
+}
+
+func TestDetectUserNamespaceDisabled(t *testing.T) {
+	info := types.Info{SecurityOptions: []string{"name=seccomp,profile=default"}}
+	userns, err := detectUserNamespace(info, "")
+	if err != nil || userns != nil {
+		t.Errorf("expected no user namespace, got %v, %v", userns, err)
+	}
+}
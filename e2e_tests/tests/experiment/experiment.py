@@ -206,7 +206,7 @@ def cancel_single(experiment_id: int, should_have_trial: bool = False) -> None:
 
 
 def is_terminal_state(state: str) -> bool:
-    return state in ("CANCELED", "COMPLETED", "ERROR")
+    return state in ("CANCELED", "COMPLETED", "ERROR", "TIMEOUT")
 
 
 def trial_metrics(trial_id: int) -> Dict[str, Any]:
@@ -36,6 +36,8 @@ class Determinedexperimentv1State(object):
     CANCELED = "STATE_CANCELED"
     ERROR = "STATE_ERROR"
     DELETED = "STATE_DELETED"
+    STOPPING_TIMEOUT = "STATE_STOPPING_TIMEOUT"
+    TIMEOUT = "STATE_TIMEOUT"
 
     """
     Attributes:
@@ -3,7 +3,7 @@ import json
 import os
 import sys
 from collections import OrderedDict
-from typing import Any, Callable, List
+from typing import Any, Callable, Dict, List
 
 from determined.cli import render
 from determined.common import api
@@ -30,6 +30,10 @@ def list_agents(args: argparse.Namespace) -> None:
                 ("num_containers", agent["num_containers"]),
                 ("resource_pool", agent["resource_pool"]),
                 ("label", agent["label"]),
+                ("platform", agent.get("platform", "")),
+                ("version", agent.get("version", "")),
+                ("draining", agent.get("draining", False)),
+                ("awaiting_approval", agent.get("awaiting_approval", False)),
             ]
         )
         for agent_id, agent in sorted(agents.items())
@@ -39,25 +43,37 @@ def list_agents(args: argparse.Namespace) -> None:
         print(json.dumps(agents, indent=4))
         return
 
-    headers = ["Agent ID", "Registered Time", "Slots", "Containers", "Resource Pool", "Label"]
+    headers = [
+        "Agent ID",
+        "Registered Time",
+        "Slots",
+        "Containers",
+        "Resource Pool",
+        "Label",
+        "Platform",
+        "Version",
+        "Draining",
+        "Awaiting Approval",
+    ]
     values = [a.values() for a in agents]
 
     render.tabulate_or_csv(headers, values, args.csv)
 
 
-@authentication_required
-def list_slots(args: argparse.Namespace) -> None:
-    task_res = api.get(args.master, "tasks")
-    agent_res = api.get(args.master, "agents")
+def format_utilization(samples: List[Dict[str, Any]]) -> str:
+    if not samples:
+        return ""
+    latest = samples[-1]
+    return "{:.0f}% ({}/{} MiB)".format(
+        latest["utilization_percent"], latest["memory_used_mib"], latest["memory_total_mib"]
+    )
 
-    agents = agent_res.json()
-    tasks = task_res.json()
 
-    c_names = {}
-    for task in tasks.values():
-        for cont in task["containers"]:
-            c_names[cont["id"]] = {"name": task["name"], "id": task["id"]}
+@authentication_required
+def list_slots(args: argparse.Namespace) -> None:
+    r = api.get(args.master, "agents")
 
+    agents = r.json()
     slots = [
         OrderedDict(
             [
@@ -67,14 +83,15 @@ def list_slots(args: argparse.Namespace) -> None:
                 ("enabled", slot["enabled"]),
                 (
                     "task_id",
-                    c_names[slot["container"]["id"]]["id"] if slot["container"] else "FREE",
+                    slot["allocation"]["task_id"] if slot.get("allocation") else "FREE",
                 ),
                 (
                     "task_name",
-                    c_names[slot["container"]["id"]]["name"] if slot["container"] else "None",
+                    slot["allocation"]["task_name"] if slot.get("allocation") else "None",
                 ),
                 ("type", slot["device"]["type"]),
                 ("device", slot["device"]["brand"]),
+                ("utilization", format_utilization(slot.get("utilization") or [])),
             ]
         )
         for agent_id, agent in sorted(agents.items())
@@ -94,6 +111,7 @@ def list_slots(args: argparse.Namespace) -> None:
         "Task Name",
         "Type",
         "Device",
+        "Utilization",
     ]
     values = [s.values() for s in slots]
 
@@ -141,6 +159,127 @@ def patch_slot(enabled: bool) -> Callable[[argparse.Namespace], None]:
     return patch
 
 
+@authentication_required
+def approve_agent(args: argparse.Namespace) -> None:
+    api.post(args.master, "api/v1/agents/{}/approve".format(args.agent_id))
+    print("Approved agent {}".format(args.agent_id))
+
+
+@authentication_required
+def revoke_agent(args: argparse.Namespace) -> None:
+    api.delete(args.master, "api/v1/agents/{}/approve".format(args.agent_id))
+    print("Revoked the approval of agent {}".format(args.agent_id))
+
+
+@authentication_required
+def list_tokens(args: argparse.Namespace) -> None:
+    r = api.get(args.master, "api/v1/agent-tokens")
+    tokens = [
+        OrderedDict(
+            [
+                ("id", token["id"]),
+                ("resource_pool", token.get("resourcePool", "")),
+                ("description", token.get("description", "")),
+                ("created_at", render.format_time(token["createdAt"])),
+            ]
+        )
+        for token in r.json().get("agentTokens", [])
+    ]
+
+    if args.json:
+        print(json.dumps(tokens, indent=4))
+        return
+
+    headers = ["ID", "Resource Pool", "Description", "Created At"]
+    values = [t.values() for t in tokens]
+
+    render.tabulate_or_csv(headers, values, args.csv)
+
+
+@authentication_required
+def create_token(args: argparse.Namespace) -> None:
+    body = {"resource_pool": args.resource_pool or "", "description": args.description or ""}
+    r = api.post(args.master, "api/v1/agent-tokens", body=body).json()
+    print("Created agent registration token {}.".format(r["agentToken"]["id"]))
+    print("Agents present it with --registration-token; it cannot be shown again:")
+    print(r["token"])
+
+
+@authentication_required
+def revoke_token(args: argparse.Namespace) -> None:
+    api.delete(args.master, "api/v1/agent-tokens/{}".format(args.token_id))
+    print("Revoked agent registration token {}".format(args.token_id))
+
+
+def print_upgrade(upgrade: Dict[str, Any]) -> None:
+    print("Version: {}".format(upgrade["version"]))
+    print("State: {}".format(upgrade["state"].replace("STATE_", "")))
+    print("Resource pools: {}".format(", ".join(upgrade.get("resourcePools", []))))
+    if upgrade.get("currentResourcePool"):
+        print("Current resource pool: {}".format(upgrade["currentResourcePool"]))
+    print("Upgrading agents: {}".format(", ".join(upgrade.get("upgradingAgents", []))))
+    print("Upgraded agents: {}".format(", ".join(upgrade.get("upgradedAgents", []))))
+    if upgrade.get("error"):
+        print("Error: {}".format(upgrade["error"]))
+
+
+@authentication_required
+def start_upgrade(args: argparse.Namespace) -> None:
+    body = {
+        "version": args.version or "",
+        "resource_pools": args.resource_pool or [],
+        "max_unavailable": args.max_unavailable,
+        "rejoin_timeout_seconds": args.rejoin_timeout,
+    }
+    r = api.post(args.master, "api/v1/agent-upgrade", body=body).json()
+    print_upgrade(r["upgrade"])
+
+
+@authentication_required
+def describe_upgrade(args: argparse.Namespace) -> None:
+    r = api.get(args.master, "api/v1/agent-upgrade").json()
+    print_upgrade(r["upgrade"])
+
+
+@authentication_required
+def cancel_upgrade(args: argparse.Namespace) -> None:
+    r = api.delete(args.master, "api/v1/agent-upgrade").json()
+    print_upgrade(r["upgrade"])
+
+
+def print_mig_reconfiguration(reconfiguration: Dict[str, Any]) -> None:
+    print("Agent: {}".format(reconfiguration["agentId"]))
+    print("State: {}".format(reconfiguration["state"].replace("STATE_", "")))
+    for layout in reconfiguration.get("layouts", []):
+        profiles = ", ".join(layout.get("profiles", [])) or "not partitioned"
+        print("GPU {}: {}".format(layout.get("gpu", 0), profiles))
+    if reconfiguration.get("error"):
+        print("Error: {}".format(reconfiguration["error"]))
+
+
+def parse_mig_layout(layout: str) -> Dict[str, Any]:
+    gpu, _, profiles = layout.partition("=")
+    try:
+        return {"gpu": int(gpu), "profiles": [p for p in profiles.split(",") if p]}
+    except ValueError:
+        raise argparse.ArgumentTypeError(
+            "layout must be of the form GPU=PROFILE,..., like 0=3g.20gb,3g.20gb"
+        )
+
+
+@authentication_required
+def configure_mig(args: argparse.Namespace) -> None:
+    body = {"layouts": args.layout, "rejoin_timeout_seconds": args.rejoin_timeout}
+    r = api.post(args.master, "api/v1/agents/{}/mig".format(args.agent_id), body=body).json()
+    print_mig_reconfiguration(r["reconfiguration"])
+
+
+@authentication_required
+def describe_mig(args: argparse.Namespace) -> None:
+    r = api.get(args.master, "api/v1/agents/{}/mig".format(args.agent_id)).json()
+    print_mig_reconfiguration(r["reconfiguration"])
+
+
 def agent_id_completer(_1: str, parsed_args: argparse.Namespace, _2: Any) -> List[str]:
     r = api.get(parsed_args.master, "agents")
     return list(r.json().keys())
@@ -168,6 +307,59 @@ args_description = [
                 Arg("--all", action="store_true", help="disable all agents"),
             )
         ]),
+        Cmd("approve", approve_agent, "approve agent to have tasks scheduled onto it", [
+            Arg("agent_id", help="agent ID"),
+        ]),
+        Cmd("revoke", revoke_agent, "revoke the approval of agent and disconnect it", [
+            Arg("agent_id", help="agent ID"),
+        ]),
+        Cmd("upgrade", None, "manage rolling upgrades of agents", [
+            Cmd("describe", describe_upgrade, "describe the last upgrade of agents", [],
+                is_default=True),
+            Cmd("start", start_upgrade, "drain, restart and wait for agents to rejoin with a "
+                "new version, one resource pool at a time", [
+                Arg("--version", help="version to upgrade to; defaults to the master's"),
+                Arg("--resource-pool", action="append",
+                    help="resource pool whose agents to upgrade, in order; may be repeated; "
+                    "defaults to every pool"),
+                Arg("--max-unavailable", type=int, default=1,
+                    help="number of agents of a pool to upgrade at once"),
+                Arg("--rejoin-timeout", type=int, default=600,
+                    help="seconds to wait for a restarted agent to rejoin"),
+            ]),
+            Cmd("cancel", cancel_upgrade, "cancel the running upgrade of agents", []),
+        ]),
+        Cmd("mig", None, "manage the MIG instances of the GPUs of agents", [
+            Cmd("describe", describe_mig, "describe the last MIG reconfiguration of an agent", [
+                Arg("agent_id", help="agent ID", completer=agent_id_completer),
+            ], is_default=True),
+            Cmd("configure", configure_mig, "drain an agent, repartition its GPUs into MIG "
+                "instances and wait for it to rejoin", [
+                Arg("agent_id", help="agent ID", completer=agent_id_completer),
+                Arg("--layout", type=parse_mig_layout, action="append", required=True,
+                    help="MIG profiles to partition a GPU into, as GPU=PROFILE,..., like "
+                    "0=3g.20gb,3g.20gb; a GPU without profiles is not partitioned; may be "
+                    "repeated"),
+                Arg("--rejoin-timeout", type=int, default=600,
+                    help="seconds to wait for the restarted agent to rejoin"),
+            ]),
+        ]),
+        Cmd("token", None, "manage agent registration tokens", [
+            Cmd("list", list_tokens, "list agent registration tokens", [
+                Group(
+                    Arg("--csv", action="store_true", help="print as CSV"),
+                    Arg("--json", action="store_true", help="print as JSON"),
+                ),
+            ], is_default=True),
+            Cmd("create", create_token, "create agent registration token", [
+                Arg("--resource-pool", help="resource pool that the token lets agents join; "
+                    "any pool if unset"),
+                Arg("--description", help="description of the token"),
+            ]),
+            Cmd("revoke", revoke_token, "revoke agent registration token", [
+                Arg("token_id", type=int, help="token ID"),
+            ]),
+        ]),
     ]),
     Cmd("s|lot", None, "manage slots", [
         Cmd("list", list_slots, "list slots in cluster", [
@@ -39,6 +39,12 @@ def format_checkpoint(checkpoint: Dict[str, Any]) -> List[Any]:
         raise AssertionError("Invalid checkpoint state: {}".format(checkpoint["state"]))
 
 
+def format_integrity(integrity: Optional[str]) -> Optional[str]:
+    if integrity is None:
+        return None
+    return integrity.replace("INTEGRITY_", "")
+
+
 def render_checkpoint(checkpoint: experimental.Checkpoint, path: Optional[str] = None) -> None:
     if path:
         print("Local checkpoint path:")
@@ -50,6 +56,7 @@ def render_checkpoint(checkpoint: experimental.Checkpoint, path: Optional[str] =
         ["Start Time", render.format_time(checkpoint.start_time)],
         ["End Time", render.format_time(checkpoint.end_time)],
         ["Checkpoint UUID", checkpoint.uuid],
+        ["Integrity", format_integrity(checkpoint.integrity)],
         ["Validation Metrics", json.dumps(checkpoint.validation["metrics"], indent=4)],
         ["Metadata", json.dumps(checkpoint.metadata or {}, indent=4)],
     ]
@@ -113,6 +120,48 @@ def describe(args: Namespace) -> None:
     render_checkpoint(checkpoint)
 
 
+@authentication_required
+def export(args: Namespace) -> None:
+    r = api.get(args.master, "/api/v1/checkpoints/{}/export".format(args.uuid))
+    bundle = json.dumps(r.json()["bundle"], indent=2)
+    if args.output:
+        with open(args.output, "w") as f:
+            f.write(bundle)
+    else:
+        print(bundle)
+
+
+@authentication_required
+def import_checkpoint(args: Namespace) -> None:
+    with open(args.bundle) as f:
+        bundle = json.load(f)
+    storage = {}  # type: Dict[str, Any]
+    for field in args.storage or []:
+        key, sep, value = field.partition("=")
+        if not sep:
+            raise ValueError("--storage must be given as key=value, not {}".format(field))
+        storage[key] = value
+    r = api.post(
+        args.master,
+        "/api/v1/checkpoints/import",
+        body={"bundle": bundle, "checkpoint_storage": storage},
+    )
+    print("Imported checkpoint {}".format(r.json()["checkpoint"]["uuid"]))
+
+
+@authentication_required
+def verify(args: Namespace) -> None:
+    r = api.post(
+        args.master,
+        "/api/v1/experiments/{}/checkpoints/verify".format(args.experiment_id),
+        body={"checkpoint_uuids": args.uuid or []},
+    ).json()
+    print(
+        "Verifying {} checkpoints in task {}; see the results with "
+        "`det checkpoint describe`".format(r["numCheckpoints"], r["taskId"])
+    )
+
+
 args_description = Cmd(
     "c|heckpoint",
     None,
@@ -144,5 +193,43 @@ args_description = Cmd(
             "describe checkpoint",
             [Arg("uuid", type=str, help="checkpoint uuid to describe")],
         ),
+        Cmd(
+            "export",
+            export,
+            "export a checkpoint as a bundle to import into another cluster",
+            [
+                Arg("uuid", type=str, help="checkpoint uuid to export"),
+                Arg("-o", "--output", type=str, help="file to write the bundle to"),
+            ],
+        ),
+        Cmd(
+            "import",
+            import_checkpoint,
+            "import a checkpoint exported from another cluster",
+            [
+                Arg("bundle", type=str, help="file of the exported bundle"),
+                Arg(
+                    "--storage",
+                    action="append",
+                    help="field of the checkpoint storage configuration to replace as key=value, "
+                    "e.g. bucket=production, which may be given more than once",
+                ),
+            ],
+        ),
+        Cmd(
+            "verify",
+            verify,
+            "verify the files of the completed checkpoints of an experiment against the sizes "
+            "and digests recorded when they were saved",
+            [
+                Arg("experiment_id", type=int, help="experiment whose checkpoints to verify"),
+                Arg(
+                    "--uuid",
+                    action="append",
+                    help="checkpoint to verify, which may be given more than once; all completed "
+                    "checkpoints of the experiment are verified if none are given",
+                ),
+            ],
+        ),
     ],
 )
@@ -1,15 +1,18 @@
+import base64
 import hashlib
 import os
 import socket
 import ssl
 import sys
 from argparse import ArgumentDefaultsHelpFormatter, ArgumentParser, FileType, Namespace
+from datetime import datetime, timedelta, timezone
 from typing import Any, Dict, List, Union, cast
 
 import argcomplete
 import argcomplete.completers
 import OpenSSL
 import requests
+import simplejson
 import tabulate
 from termcolor import colored
 
@@ -18,15 +21,20 @@ import determined.cli
 import determined.common.api.authentication as auth
 from determined.cli import checkpoint, experiment, render
 from determined.cli.agent import args_description as agent_args_description
+from determined.cli.code import args_description as code_args_description
+from determined.cli.inference import args_description as inference_args_description
 from determined.cli.master import args_description as master_args_description
 from determined.cli.model import args_description as model_args_description
 from determined.cli.notebook import args_description as notebook_args_description
 from determined.cli.oauth import args_description as oauth_args_description
+from determined.cli.ray import args_description as ray_args_description
 from determined.cli.remote import args_description as remote_args_description
 from determined.cli.resources import args_description as resources_args_description
+from determined.cli.serving import args_description as serving_args_description
 from determined.cli.shell import args_description as shell_args_description
 from determined.cli.sso import args_description as auth_args_description
 from determined.cli.template import args_description as template_args_description
+from determined.cli.tenant import args_description as tenant_args_description
 from determined.cli.tensorboard import args_description as tensorboard_args_description
 from determined.cli.trial import args_description as trial_args_description
 from determined.cli.user import args_description as user_args_description
@@ -35,8 +43,8 @@ from determined.cli.version import check_version
 from determined.common import api, yaml
 from determined.common.api.authentication import authentication_required
 from determined.common.check import check_not_none
-from determined.common.declarative_argparse import Arg, Cmd, add_args
-from determined.common.util import chunks, debug_mode, get_default_master_address
+from determined.common.declarative_argparse import Arg, Cmd, Group, add_args
+from determined.common.util import chunks, debug_mode, get_default_master_address, sizeof_fmt
 from determined.deploy.cli import DEPLOY_CMD_NAME
 from determined.deploy.cli import args_description as deploy_args_description
 
@@ -85,6 +93,129 @@ def list_tasks(args: Namespace) -> None:
     render.tabulate_or_csv(headers, values, args.csv)
 
 
+@authentication_required
+def watch_tasks(args: Namespace) -> None:
+    params = {
+        "task_types": ["TASK_TYPE_" + t for t in args.type or []],
+        "users": args.user or [],
+        "include_current": args.include_current,
+    }
+    if args.project_id:
+        params["project_id"] = args.project_id
+
+    try:
+        with api.get(args.master, "api/v1/tasks/state-changes", params=params, stream=True) as r:
+            for line in r.iter_lines():
+                result = simplejson.loads(line)
+                if "error" in result:
+                    raise api.errors.BadResponseException(result["error"].get("message"))
+                t = result["result"]["task"]
+                print(
+                    "{} {} {} {} {}".format(
+                        t["id"],
+                        t["type"].replace("TASK_TYPE_", ""),
+                        t["state"].replace("STATE_", ""),
+                        t.get("username", ""),
+                        t.get("resourcePool", ""),
+                    ),
+                    flush=True,
+                )
+    except KeyboardInterrupt:
+        pass
+
+
+@authentication_required
+def list_artifacts(args: Namespace) -> None:
+    params = {"task_id": args.task_id} if args.task_id else {}
+    artifacts = api.get(args.master, "api/v1/artifacts", params=params).json()["artifacts"]
+
+    headers = ["ID", "Name", "Type", "State", "Task ID", "Size", "Creation Time"]
+    values = [
+        [
+            a["id"],
+            a["name"],
+            a["type"].replace("TYPE_", ""),
+            a["state"].replace("STATE_", ""),
+            a.get("taskId", ""),
+            sizeof_fmt(int(a.get("sizeBytes", 0))),
+            render.format_time(a["creationTime"]),
+        ]
+        for a in artifacts
+    ]
+    render.tabulate_or_csv(headers, values, args.csv)
+
+
+@authentication_required
+def download_artifact(args: Namespace) -> None:
+    with open(args.output, "wb") as f:
+        api.download_artifact(args.master, args.artifact_id, f)
+    print("Downloaded artifact {} to {}".format(args.artifact_id, args.output))
+
+
+@authentication_required
+def delete_artifact(args: Namespace) -> None:
+    api.delete(args.master, "api/v1/artifacts/{}".format(args.artifact_id))
+    print("Deleted artifact {}".format(args.artifact_id))
+
+
+@authentication_required
+def download_support_bundle(args: Namespace) -> None:
+    if args.experiment_id is not None:
+        params = {"experiment_id": args.experiment_id}  # type: Dict[str, Any]
+        name = "experiment {}".format(args.experiment_id)
+    else:
+        params = {"task_id": args.task_id}
+        name = "task {}".format(args.task_id)
+    with api.get(args.master, "api/v1/support-bundle", params=params, stream=True) as r:
+        with open(args.output, "wb") as f:
+            for line in r.iter_lines():
+                result = simplejson.loads(line)
+                if "error" in result:
+                    raise api.errors.BadResponseException(result["error"].get("message"))
+                f.write(base64.b64decode(result["result"]["data"]))
+    print("Downloaded the support bundle of {} to {}".format(name, args.output))
+
+
+@authentication_required
+def search(args: Namespace) -> None:
+    params = {"query": " ".join(args.query), "limit": args.limit}  # type: Dict[str, Any]
+    if args.owner:
+        params["users"] = [args.owner]
+    r = api.get(args.master, "api/v1/search", params=params).json()
+
+    headers = ["Kind", "ID", "Description", "Owner", "Score"]
+    values = [
+        [
+            result["kind"][len("KIND_") :].lower(),
+            result["id"],
+            result["description"],
+            result.get("username", ""),
+            "{:.3f}".format(result["score"]),
+        ]
+        for result in r["experiments"] + r["tasks"]
+    ]
+    render.tabulate_or_csv(headers, values, args.csv)
+
+
+@authentication_required
+def bulk_archive(args: Namespace) -> None:
+    ended_before = datetime.now(timezone.utc) - timedelta(days=args.older_than)
+    body = {"endedBefore": ended_before.isoformat()}  # type: Dict[str, Any]
+    if args.state:
+        body["states"] = ["STATE_" + state for state in args.state]
+    if args.owner:
+        body["users"] = [args.owner]
+    r = api.post(args.master, "api/v1/archive", body=body).json()
+
+    print(
+        "Archived {} experiment(s) and {} task(s).".format(
+            r.get("archivedExperiments", 0), r.get("archivedTasks", 0)
+        )
+    )
+    if r.get("skipped"):
+        print(colored("Skipped {} that you may not change.".format(r["skipped"]), "yellow"))
+
+
 @authentication_required
 def preview_search(args: Namespace) -> None:
     experiment_config = yaml.safe_load(args.config_file.read())
@@ -159,6 +290,57 @@ args_description = [
         Cmd("list", list_tasks, "list tasks in cluster", [
             Arg("--csv", action="store_true", help="print as CSV"),
         ], is_default=True),
+        Cmd("watch", watch_tasks, "print the state changes of tasks as they happen", [
+            Arg("--type", action="append", type=str.upper,
+                choices=["TRIAL", "COMMAND", "NOTEBOOK", "SHELL", "TENSORBOARD", "CHECKPOINT_GC",
+                         "CHECKPOINT_VERIFICATION", "SERVING", "BATCH_INFERENCE", "CODE_SERVER",
+                         "RAY_CLUSTER"],
+                help="only watch tasks of this type (can be repeated)"),
+            Arg("--user", action="append", help="only watch tasks of this user (can be repeated)"),
+            Arg("--project-id", type=int, help="only watch tasks in this project"),
+            Arg("--include-current", action="store_true",
+                help="first print the current state of the matching tasks"),
+        ]),
+        Cmd("artifacts", list_artifacts, "list the uploaded contexts and task outputs", [
+            Arg("task_id", nargs="?", help="only list the outputs of this task"),
+            Arg("--csv", action="store_true", help="print as CSV"),
+        ]),
+        Cmd("download-artifact", download_artifact, "download an artifact", [
+            Arg("artifact_id", help="artifact ID"),
+            Arg("output", help="file to download the artifact to"),
+        ]),
+        Cmd("delete-artifact", delete_artifact, "delete an artifact", [
+            Arg("artifact_id", help="artifact ID"),
+        ]),
+        Cmd("support-bundle", download_support_bundle,
+            "download the configuration, task history and logs of a task or an experiment to "
+            "attach to support tickets", [
+            Group(
+                Arg("task_id", nargs="?", help="task ID"),
+                Arg("--experiment-id", type=int, help="download the bundle of this experiment"),
+                required=True,
+            ),
+            Arg("-o", "--output", default="support-bundle.tar.gz",
+                help="file to download the bundle to"),
+        ]),
+    ]),
+
+    Cmd("search", search, "search experiments and tasks by their descriptions, labels and "
+        "hyperparameters", [
+        Arg("query", nargs="+", help="words to search for"),
+        Arg("--owner", help="only show results owned by the given user"),
+        Arg("--limit", type=int, default=20,
+            help="maximum number of experiments and of tasks to show"),
+        Arg("--csv", action="store_true", help="print as CSV"),
+    ]),
+
+    Cmd("archive", bulk_archive, "archive the experiments, commands, notebooks and "
+        "TensorBoards that ended a number of days ago", [
+        Arg("older_than", type=int, metavar="days",
+            help="archive those that ended at least this many days ago"),
+        Arg("--state", action="append", choices=["COMPLETED", "CANCELED", "ERROR", "TIMEOUT"],
+            help="only archive experiments in this state (can be repeated)"),
+        Arg("--owner", help="only archive experiments and tasks owned by the given user"),
     ]),
 
     Cmd("preview-search", preview_search, "preview search", [
@@ -176,11 +358,16 @@ all_args_description = (
     args_description
     + master_args_description
     + model_args_description
+    + inference_args_description
     + agent_args_description
+    + code_args_description
     + notebook_args_description
+    + ray_args_description
     + resources_args_description
+    + serving_args_description
     + shell_args_description
     + template_args_description
+    + tenant_args_description
     + tensorboard_args_description
     + trial_args_description
     + remote_args_description
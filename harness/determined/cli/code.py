@@ -0,0 +1,114 @@
+from argparse import ONE_OR_MORE, FileType, Namespace
+from pathlib import Path
+from typing import Any, Dict, List
+
+from termcolor import colored
+
+from determined.cli import command
+from determined.common import api
+from determined.common.api.authentication import authentication_required
+from determined.common.check import check_eq
+from determined.common.declarative_argparse import Arg, Cmd
+
+from .command import (
+    CONFIG_DESC,
+    CONTEXT_DESC,
+    VOLUME_DESC,
+    launch_command,
+    parse_config,
+    render_event_stream,
+)
+
+
+def _code_server_path(code_server: Dict[str, Any]) -> str:
+    # openvscode-server reads the token from the URL, while code-server asks for it as a password.
+    return "{}?tkn={}".format(code_server["serviceAddress"], code_server.get("token", ""))
+
+
+def _show_code_server(master: str, code_server: Dict[str, Any], no_browser: bool) -> None:
+    path = _code_server_path(code_server)
+    url = api.make_url(master, path) if no_browser else api.open(master, path)
+    print(colored("Code server is running at: {}".format(url), "green"))
+    if code_server.get("token"):
+        print(colored("Its password is: {}".format(code_server["token"]), "green"))
+
+
+@authentication_required
+def start_code_server(args: Namespace) -> None:
+    config = parse_config(args.config_file, None, args.config, args.volume)
+
+    resp = launch_command(
+        args.master,
+        "api/v1/code-servers",
+        config,
+        args.template,
+        context_path=args.context,
+    )["codeServer"]
+
+    if args.detach:
+        print(resp["id"])
+        return
+
+    with api.ws(args.master, "code-servers/{}/events".format(resp["id"])) as ws:
+        for msg in ws:
+            if msg["service_ready_event"]:
+                _show_code_server(args.master, resp, args.no_browser)
+            render_event_stream(msg)
+
+
+@authentication_required
+def open_code_server(args: Namespace) -> None:
+    resp = api.get(
+        args.master, "api/v1/code-servers/{}".format(args.code_server_id)
+    ).json()["codeServer"]
+    check_eq(resp["state"], "STATE_RUNNING", "Code server must be in a running state")
+    _show_code_server(args.master, resp, False)
+
+
+# fmt: off
+
+args_description = [
+    Cmd("code", None, "manage VS Code servers", [
+        Cmd("list ls", command.list, "list code servers", [
+            Arg("-q", "--quiet", action="store_true",
+                help="only display the IDs"),
+            Arg("--all", "-a", action="store_true",
+                help="show all code servers (including other users')"),
+        ], is_default=True),
+        Cmd("config", command.config,
+            "display code server config", [
+                Arg("id", type=str, help="code server ID"),
+            ]),
+        Cmd("start", start_code_server, "start a new code server", [
+            Arg("--config-file", default=None, type=FileType("r"),
+                help="command config file (.yaml)"),
+            Arg("-v", "--volume", action="append", default=[],
+                help=VOLUME_DESC),
+            Arg("-c", "--context", default=None, type=Path, help=CONTEXT_DESC),
+            Arg("--config", action="append", default=[], help=CONFIG_DESC),
+            Arg("--template", type=str,
+                help="name of template to apply to the code server configuration"),
+            Arg("--no-browser", action="store_true",
+                help="don't open the code server in a browser after startup"),
+            Arg("-d", "--detach", action="store_true",
+                help="run in the background and print the ID"),
+        ]),
+        Cmd("open", open_code_server, "open an existing code server", [
+            Arg("code_server_id", help="code server ID")
+        ]),
+        Cmd("logs", command.tail_logs, "fetch code server logs", [
+            Arg("code_server_id", help="code server ID"),
+            Arg("-f", "--follow", action="store_true",
+                help="follow the logs of a code server, similar to tail -f"),
+            Arg("--tail", type=int, default=200,
+                help="number of lines to show, counting from the end "
+                     "of the log")
+        ]),
+        Cmd("kill", command.kill, "kill a code server", [
+            Arg("code_server_id", help="code server ID", nargs=ONE_OR_MORE),
+            Arg("-f", "--force", action="store_true", help="ignore errors"),
+        ]),
+    ])
+]  # type: List[Any]
+
+# fmt: on
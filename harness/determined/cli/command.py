@@ -1,5 +1,6 @@
 import base64
 import json
+import sys
 from argparse import Namespace
 from collections import OrderedDict, namedtuple
 from pathlib import Path
@@ -8,7 +9,8 @@ from typing import IO, Any, Dict, Iterable, List, Optional, Tuple
 from termcolor import colored
 
 from determined.cli import render
-from determined.common import api, context, yaml
+from determined.common import api, constants, context, yaml
+from determined.common.api import artifact
 from determined.common.api.authentication import authentication_required
 
 CONFIG_DESC = """
@@ -21,9 +23,11 @@ List values can be specified by comma-separated values.
 CONTEXT_DESC = """
 The filepath to a directory that contains the set of files used to
 execute the command. All files under this directory will be packaged,
-maintaining the existing directory structure. The total byte contents
-of the directory must not exceed 96 MB. By default, the context
-directory will be empty.
+maintaining the existing directory structure. Directories larger than
+96 MB are uploaded as artifacts, in chunks of which only those that
+changed since an earlier upload are sent, which requires the master to
+be configured to store artifacts. By default, the context directory
+will be empty.
 """
 
 VOLUME_DESC = """
@@ -43,6 +47,10 @@ CommandTableHeader = OrderedDict(
         ("username", "username"),
         ("description", "description"),
         ("state", "state"),
+        ("reportedStatus", "reportedStatus"),
+        ("cpu", "cpu"),
+        ("gpu", "gpu"),
+        ("gpuMemory", "gpuMemory"),
         ("exitStatus", "exitStatus"),
         ("resourcePool", "resourcePool"),
     ]
@@ -56,6 +64,27 @@ TensorboardTableHeader = OrderedDict(
         ("state", "state"),
         ("experimentIds", "experimentIds"),
         ("trialIds", "trialIds"),
+        ("logPaths", "logPaths"),
+        ("reportedStatus", "reportedStatus"),
+        ("cpu", "cpu"),
+        ("gpu", "gpu"),
+        ("gpuMemory", "gpuMemory"),
+        ("exitStatus", "exitStatus"),
+        ("resourcePool", "resourcePool"),
+    ]
+)
+
+RayClusterTableHeader = OrderedDict(
+    [
+        ("id", "id"),
+        ("username", "username"),
+        ("description", "description"),
+        ("state", "state"),
+        ("workers", "workers"),
+        ("reportedStatus", "reportedStatus"),
+        ("cpu", "cpu"),
+        ("gpu", "gpu"),
+        ("gpuMemory", "gpuMemory"),
         ("exitStatus", "exitStatus"),
         ("resourcePool", "resourcePool"),
     ]
@@ -66,6 +95,8 @@ RemoteTaskName = {
     "command cmd": "command",
     "shell": "shell",
     "tensorboard": "tensorboard",
+    "code": "codeServer",
+    "ray": "rayCluster",
 }
 
 RemoteTaskLogName = {
@@ -73,6 +104,8 @@ RemoteTaskLogName = {
     "command cmd": "Command",
     "shell": "Shell",
     "tensorboard": "TensorBoard",
+    "code": "Code server",
+    "ray": "Ray cluster",
 }
 
 RemoteTaskNewAPIs = {
@@ -80,6 +113,17 @@ RemoteTaskNewAPIs = {
     "command cmd": "commands",
     "shell": "shells",
     "tensorboard": "tensorboards",
+    "code": "code-servers",
+    "ray": "ray-clusters",
+}
+
+RemoteTaskListKeys = {
+    "notebook": "notebooks",
+    "command cmd": "commands",
+    "shell": "shells",
+    "tensorboard": "tensorboards",
+    "code": "codeServers",
+    "ray": "rayClusters",
 }
 
 RemoteTaskOldAPIs = {
@@ -87,6 +131,8 @@ RemoteTaskOldAPIs = {
     "command cmd": "commands",
     "shell": "shells",
     "tensorboard": "tensorboard",
+    "code": "code-servers",
+    "ray": "ray-clusters",
 }
 
 RemoteTaskListTableHeaders = {
@@ -94,6 +140,8 @@ RemoteTaskListTableHeaders = {
     "command cmd": CommandTableHeader,
     "shell": CommandTableHeader,
     "tensorboard": TensorboardTableHeader,
+    "code": CommandTableHeader,
+    "ray": RayClusterTableHeader,
 }
 
 RemoteTaskGetIDsFunc = {
@@ -101,6 +149,8 @@ RemoteTaskGetIDsFunc = {
     "command cmd": lambda args: args.command_id,
     "shell": lambda args: args.shell_id,
     "tensorboard": lambda args: args.tensorboard_id,
+    "code": lambda args: args.code_server_id,
+    "ray": lambda args: args.ray_cluster_id,
 }
 
 
@@ -130,8 +180,18 @@ def list(args: Namespace) -> None:
         params = {}  # type: Dict[str, Any]
     else:
         params = {"users": [api.Authentication.instance().get_session_user()]}
-
-    res = api.get(args.master, api_full_path, params=params).json()[api_path]
+    if getattr(args, "archived", False):
+        params["archived"] = True
+
+    # Page through the tasks so that the master only gathers the state of a page at a time.
+    params["limit"] = 200
+    res = []  # type: List[Dict[str, Any]]
+    while True:
+        page = api.get(args.master, api_full_path, params=params).json()
+        res.extend(page[RemoteTaskListKeys[args._command]])
+        if not page.get("nextCursor"):
+            break
+        params["cursor"] = page["nextCursor"]
 
     if args.quiet:
         for command in res:
@@ -141,9 +201,36 @@ def list(args: Namespace) -> None:
     for item in res:
         if item["state"].startswith("STATE_"):
             item["state"] = item["state"][6:]
+        item["reportedStatus"] = format_reported_status(item.get("reportedStatus"))
+        item.update(format_resource_usage(item.get("resourceUsage")))
     render.render_table(res, table_header)
 
 
+def format_reported_status(reported_status: Optional[Dict[str, Any]]) -> str:
+    if not reported_status:
+        return ""
+    return "{} ({:.0f}%)".format(
+        reported_status.get("status", ""), reported_status.get("progress", 0)
+    )
+
+
+def format_resource_usage(resource_usage: Optional[Dict[str, Any]]) -> Dict[str, str]:
+    if not resource_usage:
+        return {"cpu": "", "gpu": "", "gpuMemory": ""}
+    usage = {
+        "cpu": "{:.0f}%".format(resource_usage.get("cpuUtilizationPercent", 0)),
+        "gpu": "",
+        "gpuMemory": "",
+    }
+    # Tasks without GPUs leave the GPU columns blank rather than show 0%.
+    if resource_usage.get("gpuMemoryTotalMib"):
+        usage["gpu"] = "{:.0f}%".format(resource_usage.get("gpuUtilizationPercent", 0))
+        usage["gpuMemory"] = "{}/{} MiB".format(
+            resource_usage.get("gpuMemoryUsedMib", 0), resource_usage["gpuMemoryTotalMib"]
+        )
+    return usage
+
+
 @authentication_required
 def kill(args: Namespace) -> None:
     ids = RemoteTaskGetIDsFunc[args._command](args)  # type: ignore
@@ -162,6 +249,26 @@ def kill(args: Namespace) -> None:
             print(colored("Skipping: {} ({})".format(e, type(e).__name__), "red"))
 
 
+@authentication_required
+def archive(args: Namespace) -> None:
+    set_archived(args, "archive")
+
+
+@authentication_required
+def unarchive(args: Namespace) -> None:
+    set_archived(args, "unarchive")
+
+
+def set_archived(args: Namespace, action: str) -> None:
+    ids = RemoteTaskGetIDsFunc[args._command](args)  # type: ignore
+    name = RemoteTaskName[args._command]
+
+    for id in ids:
+        api_full_path = "api/v1/{}/{}/{}".format(RemoteTaskNewAPIs[args._command], id, action)
+        api.post(args.master, api_full_path)
+        print(colored("{}d {} {}".format(action.capitalize(), name, id), "green"))
+
+
 @authentication_required
 def config(args: Namespace) -> None:
     name = RemoteTaskName[args._command]
@@ -246,6 +353,22 @@ def parse_config(
     return config
 
 
+def add_context(master: str, body: Dict[str, Any], context_path: Path) -> None:
+    """
+    Add a context directory to the body of a request to launch a command: as files if it fits in
+    the request and as an uploaded artifact otherwise.
+    """
+    ctx = context.Context.from_local(context_path, sys.maxsize)
+    if ctx.size <= constants.MAX_CONTEXT_SIZE:
+        if len(ctx) > 0:
+            body["files"] = [e.dict() for e in ctx.entries]
+        return
+
+    print("Uploading the context ({}) as an artifact...".format(context_path))
+    uploaded = artifact.upload_context(master, ctx, context_path.name)
+    body["context_artifact_id"] = uploaded["id"]
+
+
 def launch_command(
     master: str,
     endpoint: str,
@@ -254,17 +377,13 @@ def launch_command(
     context_path: Optional[Path] = None,
     data: Optional[Dict[str, Any]] = None,
 ) -> Any:
-    user_files = []  # type: List[Dict[str, Any]]
-    if context_path:
-        user_files, _ = context.read_context(context_path)
-
     body = {"config": config}  # type: Dict[str, Any]
 
     if template:
         body["template_name"] = template
 
-    if len(user_files) > 0:
-        body["files"] = user_files
+    if context_path:
+        add_context(master, body, context_path)
 
     if data is not None:
         message_bytes = json.dumps(data).encode("utf-8")
@@ -185,6 +185,39 @@ def create(args: Namespace) -> None:
         submit_experiment(args)
 
 
+@authentication_required
+def validate_config(args: Namespace) -> None:
+    body = {"config": args.config_file.read()}  # type: Dict[str, Any]
+    args.config_file.close()
+    if args.template:
+        body["template"] = args.template
+    resp = api.post(args.master, "api/v1/experiments/validate-config", body=body).json()
+    errors = resp.get("errors", [])
+    if not errors:
+        yaml.safe_dump(resp["config"], stream=sys.stdout, default_flow_style=False)
+        return
+    for error in errors:
+        if error.get("path"):
+            print("{}: {}".format(error["path"], error["message"]))
+        else:
+            print(error["message"])
+    sys.exit(1)
+
+
+@authentication_required
+def fork(args: Namespace) -> None:
+    body = {}  # type: Dict[str, Any]
+    if args.config_patch:
+        body["config_patch"] = _parse_config_file_or_exit(args.config_patch)
+    if args.checkpoint:
+        body["checkpoint_uuid"] = args.checkpoint
+    r = api.post(args.master, "api/v1/experiments/{}/fork".format(args.experiment_id), body=body)
+    experiment_id = r.json()["experiment"]["id"]
+    print("Forked experiment {} into experiment {}".format(args.experiment_id, experiment_id))
+    if not args.paused:
+        api.activate_experiment(args.master, experiment_id)
+
+
 @authentication_required
 def delete_experiment(args: Namespace) -> None:
     if args.yes or render.yes_or_no(
@@ -200,6 +233,18 @@ def delete_experiment(args: Namespace) -> None:
         print("Aborting experiment deletion.")
 
 
+@authentication_required
+def delete_logs(args: Namespace) -> None:
+    if args.yes or render.yes_or_no(
+        "Deleting the logs of an experiment is unrecoverable. Do you \n"
+        "still wish to proceed?"
+    ):
+        api.delete(args.master, "/api/v1/experiments/{}/logs".format(args.experiment_id))
+        print("Successfully deleted the logs of experiment {}".format(args.experiment_id))
+    else:
+        print("Aborting deletion of experiment logs.")
+
+
 @authentication_required
 def describe(args: Namespace) -> None:
     docs = []
@@ -221,6 +266,7 @@ def describe(args: Namespace) -> None:
         "Progress",
         "Start Time",
         "End Time",
+        "Estimated End Time",
         "Description",
         "Archived",
         "Resource Pool",
@@ -233,6 +279,7 @@ def describe(args: Namespace) -> None:
             render.format_percent(doc["progress"]),
             render.format_time(doc.get("start_time")),
             render.format_time(doc.get("end_time")),
+            render.format_time(doc.get("estimated_end_time")),
             doc["config"].get("description"),
             doc["archived"],
             doc["config"]["resources"].get("resource_pool"),
@@ -759,6 +806,40 @@ args_description = Cmd(
                 ),
             ],
         ),
+        Cmd(
+            "validate-config",
+            validate_config,
+            "check an experiment config against the cluster and print it with all defaults "
+            "applied, without creating an experiment",
+            [
+                Arg("config_file", type=FileType("r"), help="experiment config file (.yaml)"),
+                Arg(
+                    "--template",
+                    type=str,
+                    help="name of template to apply to the experiment configuration",
+                ),
+            ],
+        ),
+        Cmd(
+            "fork",
+            fork,
+            "create an experiment from an existing one",
+            [
+                experiment_id_arg("experiment ID to fork"),
+                Arg(
+                    "--config-patch",
+                    type=FileType("r"),
+                    help="JSON merge patch of the experiment config (.yaml) to apply to the "
+                    "config of the new experiment",
+                ),
+                Arg(
+                    "--checkpoint",
+                    type=str,
+                    help="UUID of a checkpoint of the experiment to warm start the new one from",
+                ),
+                Arg("--paused", action="store_true", help="do not activate the experiment"),
+            ],
+        ),
         # Lifecycle management commands.
         Cmd(
             "activate",
@@ -794,6 +875,20 @@ args_description = Cmd(
                 ),
             ],
         ),
+        Cmd(
+            "delete-logs",
+            delete_logs,
+            "delete the logs of the trials of an experiment",
+            [
+                experiment_id_arg("experiment ID whose logs to delete"),
+                Arg(
+                    "--yes",
+                    action="store_true",
+                    default=False,
+                    help="automatically answer yes to prompts",
+                ),
+            ],
+        ),
         Cmd(
             "download",
             download,
@@ -0,0 +1,170 @@
+from argparse import ONE_OR_MORE, Namespace
+from collections import OrderedDict
+from pathlib import Path
+from typing import Any, Dict, List
+
+from termcolor import colored
+
+from determined.cli import render
+from determined.common import api, context
+from determined.common.api.authentication import authentication_required
+from determined.common.declarative_argparse import Arg, Cmd
+
+BatchInferenceJobTableHeader = OrderedDict(
+    [
+        ("taskId", "task id"),
+        ("username", "username"),
+        ("modelName", "model"),
+        ("modelVersion", "version"),
+        ("checkpointUuid", "checkpoint"),
+        ("state", "state"),
+        ("progress", "progress"),
+        ("recordsProcessed", "records"),
+        ("outputPath", "output path"),
+    ]
+)
+
+
+def _render_job(job: Dict[str, Any]) -> Dict[str, Any]:
+    job["state"] = job["state"].replace("STATE_", "")
+    job["progress"] = "{:.0f}%".format(job.get("progress", 0))
+    if not job.get("modelName"):
+        job["modelName"], job["modelVersion"] = "", ""
+    return job
+
+
+@authentication_required
+def list_jobs(args: Namespace) -> None:
+    params = {"limit": -1}  # type: Dict[str, Any]
+    if not args.all:
+        params["users"] = [api.Authentication.instance().get_session_user()]
+    if args.model_name:
+        params["model_name"] = args.model_name
+    if args.version:
+        params["model_version"] = args.version
+    if args.checkpoint:
+        params["checkpoint_uuid"] = args.checkpoint
+    jobs = api.get(args.master, "api/v1/batch-inference-jobs", params=params).json()["jobs"]
+
+    if args.quiet:
+        for job in jobs:
+            print(job["taskId"])
+        return
+
+    render.render_table([_render_job(job) for job in jobs], BatchInferenceJobTableHeader)
+
+
+@authentication_required
+def start_job(args: Namespace) -> None:
+    if bool(args.checkpoint) == bool(args.model_name):
+        raise ValueError("give either --model-name and --version or --checkpoint")
+    if args.model_name and args.version is None:
+        raise ValueError("give the --version of the model")
+
+    body = {
+        "predictor": args.predictor,
+        "input_path": args.input_path,
+        "output_path": args.output_path,
+        "slots": args.slots,
+    }  # type: Dict[str, Any]
+    if args.checkpoint:
+        body["checkpoint_uuid"] = args.checkpoint
+    else:
+        body["model_name"] = args.model_name
+        body["model_version"] = args.version
+    if args.resource_pool:
+        body["resource_pool"] = args.resource_pool
+    if args.project_id:
+        body["project_id"] = args.project_id
+    if args.context:
+        body["files"], _ = context.read_context(args.context)
+
+    job = api.post(args.master, "api/v1/batch-inference-jobs", body=body).json()["job"]
+    print(
+        colored(
+            "Launched batch inference job {} of checkpoint {}, writing predictions to {}".format(
+                job["taskId"], job["checkpointUuid"], job["outputPath"]
+            ),
+            "green",
+        )
+    )
+
+
+@authentication_required
+def describe_job(args: Namespace) -> None:
+    job = api.get(args.master, "api/v1/batch-inference-jobs/{}".format(args.task_id)).json()["job"]
+    model = ""
+    if job.get("modelName"):
+        model = "{} version {}".format(job["modelName"], job["modelVersion"])
+    table = [
+        ["Task ID", job["taskId"]],
+        ["Model", model],
+        ["Checkpoint UUID", job["checkpointUuid"]],
+        ["Experiment ID", job["experimentId"]],
+        ["Trial ID", job["trialId"]],
+        ["Predictor", job["predictor"]],
+        ["Input Path", job["inputPath"]],
+        ["Output Path", job["outputPath"]],
+        ["State", job["state"].replace("STATE_", "")],
+        ["Progress", "{:.0f}%".format(job.get("progress", 0))],
+        ["Records Processed", job.get("recordsProcessed", 0)],
+        ["Start Time", render.format_time(job.get("startTime"))],
+        ["End Time", render.format_time(job.get("endTime"))],
+        ["Exit Status", job.get("exitStatus", "")],
+    ]
+    headers, values = zip(*table)  # type: ignore
+    render.tabulate_or_csv(headers, [values], False)
+
+
+@authentication_required
+def kill_job(args: Namespace) -> None:
+    for task_id in args.task_id:
+        api.post(args.master, "api/v1/batch-inference-jobs/{}/kill".format(task_id))
+        print(colored("Killed batch inference job {}".format(task_id), "green"))
+
+
+# fmt: off
+
+args_description = [
+    Cmd("inference", None, "manage batch inference jobs", [
+        Cmd("list ls", list_jobs, "list batch inference jobs", [
+            Arg("-q", "--quiet", action="store_true",
+                help="only display the task IDs"),
+            Arg("--all", "-a", action="store_true",
+                help="show all jobs (including other users')"),
+            Arg("--model-name", type=str, help="only show the jobs of this model"),
+            Arg("--version", type=int, help="only show the jobs of this version of the model"),
+            Arg("--checkpoint", type=str, help="only show the jobs of this checkpoint"),
+        ], is_default=True),
+        Cmd("start", start_job,
+            "make the predictions of a model version or checkpoint on the files under a path", [
+                Arg("predictor", type=str,
+                    help="module:function that is called with the path of the checkpoint and "
+                         "those of an input file and its output file"),
+                Arg("input_path", type=str,
+                    help="path of the input file or directory in the container"),
+                Arg("output_path", type=str,
+                    help="path in the container to write the predictions under"),
+                Arg("--model-name", type=str, help="name of the model"),
+                Arg("--version", type=int, help="version of the model"),
+                Arg("--checkpoint", type=str,
+                    help="UUID of the checkpoint, instead of a model version"),
+                Arg("-c", "--context", default=None, type=Path,
+                    help="directory with the module of the predictor, which defaults to the "
+                         "model definition of the experiment of the checkpoint"),
+                Arg("--slots", type=int, default=1, help="number of slots of the job"),
+                Arg("--resource-pool", type=str,
+                    help="resource pool to run the job in, which defaults to that of the "
+                         "experiment of the checkpoint"),
+                Arg("--project-id", type=int, help="project to launch the job in"),
+            ]),
+        Cmd("describe", describe_job, "describe a batch inference job", [
+            Arg("task_id", type=str, help="task ID of the job"),
+        ]),
+        Cmd("kill", kill_job, "kill a batch inference job", [
+            Arg("task_id", type=str, help="task ID of the job", nargs=ONE_OR_MORE),
+        ]),
+    ])
+]  # type: List[Any]
+
+# fmt: on
@@ -1,7 +1,7 @@
 import json
 import time
 from argparse import Namespace
-from typing import Any, List
+from typing import Any, Dict, List
 
 from requests import Response
 
@@ -10,9 +10,16 @@ from determined.common.api.authentication import authentication_required
 from determined.common.check import check_gt
 from determined.common.declarative_argparse import Arg, Cmd
 
+from . import render
+
 
 @authentication_required
 def config(args: Namespace) -> None:
+    if args.sources:
+        sources = api.get(args.master, "api/v1/master/config").json().get("sources", {})
+        for path in sorted(sources):
+            print("{}: {}".format(path, sources[path].replace("CONFIG_SOURCE_", "").lower()))
+        return
     response = api.get(args.master, "config")
     print(json.dumps(response.json(), indent=4))
 
@@ -52,11 +59,86 @@ def logs(args: Namespace) -> None:
                 break
 
 
+@authentication_required
+def drain(args: Namespace) -> None:
+    body = {}  # type: Dict[str, Any]
+    if args.grace_period:
+        body["grace_period_seconds"] = args.grace_period
+    api.post(args.master, "api/v1/master/drain", body=body)
+    print(
+        "The master stopped launching tasks and exits once API calls finish. Tasks that wait "
+        "for resources are launched again when it restarts."
+    )
+
+
+@authentication_required
+def reload_config(args: Namespace) -> None:
+    changed = api.post(args.master, "api/v1/master/config/reload").json().get("changed", [])
+    if changed:
+        print("Reloaded the master config, which changed: {}".format(", ".join(changed)))
+    else:
+        print("The master config did not change.")
+
+
+def render_backup(backup: Dict[str, Any]) -> List[Any]:
+    rows = backup.get("rows", {})
+    return [
+        backup["name"],
+        render.format_time(backup["createdAt"]),
+        backup["masterVersion"],
+        backup["schemaVersion"],
+        sum(rows.values()),
+    ]
+
+
+@authentication_required
+def list_backups(args: Namespace) -> None:
+    backups = api.get(args.master, "api/v1/backups").json().get("backups", [])
+    if args.json:
+        print(json.dumps(backups, indent=4))
+        return
+    headers = ["Name", "Created At", "Master Version", "Schema Version", "Rows"]
+    render.tabulate_or_csv(headers, [render_backup(b) for b in backups], False)
+
+
+@authentication_required
+def create_backup(args: Namespace) -> None:
+    backup = api.post(args.master, "api/v1/backups").json()["backup"]
+    print("Took backup {} of {} rows.".format(backup["name"], sum(backup.get("rows", {}).values())))
+
+
+@authentication_required
+def restore_backup(args: Namespace) -> None:
+    if not (
+        args.dry_run
+        or args.yes
+        or render.yes_or_no(
+            "Restoring backup {} replaces the users, experiments, model registry \n"
+            "and other metadata of the cluster. Do you still wish to proceed?".format(args.name)
+        )
+    ):
+        print("Aborting restore.")
+        return
+    body = {"dry_run": args.dry_run}
+    r = api.post(args.master, "api/v1/backups/{}/restore".format(args.name), body=body)
+    rows = r.json()["backup"].get("rows", {})
+    for table in sorted(rows):
+        print("{}: {} rows".format(table, rows[table]))
+    if args.dry_run:
+        print("Backup {} can be restored.".format(args.name))
+    else:
+        print("Restored backup {}; restart the master to reload its state.".format(args.name))
+
+
 # fmt: off
 
 args_description = [
     Cmd("m|aster", None, "manage master", [
-        Cmd("config", config, "fetch master config as JSON", []),
+        Cmd("config", config, "fetch master config as JSON", [
+            Arg("--sources", action="store_true",
+                help="print where each setting came from: its default, the config file, "
+                "an environment variable or a flag")
+        ]),
         Cmd("logs", logs, "fetch master logs", [
             Arg("-f", "--follow", action="store_true",
                 help="follow the logs of master, similar to tail -f"),
@@ -64,6 +146,27 @@ args_description = [
                 help="number of lines to show, counting from the end "
                 "of the log (default is all)")
         ]),
+        Cmd("reload-config", reload_config,
+            "apply changes to the log retention, scheduler and resource pools "
+            "in the master config file", []),
+        Cmd("backup", None, "manage backups of the metadata of the cluster", [
+            Cmd("list ls", list_backups, "list backups", [
+                Arg("--json", action="store_true", help="print as JSON"),
+            ], is_default=True),
+            Cmd("create", create_backup, "take a backup", []),
+            Cmd("restore", restore_backup, "replace the metadata of the cluster with a backup", [
+                Arg("name", type=str, help="name of the backup"),
+                Arg("--dry-run", action="store_true",
+                    help="only check that the backup can be restored"),
+                Arg("--yes", action="store_true", default=False,
+                    help="automatically answer yes to prompts"),
+            ]),
+        ]),
+        Cmd("drain", drain, "stop launching tasks and exit, before an upgrade", [
+            Arg("--grace-period", type=int,
+                help="seconds that API calls, such as those that follow logs, "
+                "may take to finish (default is 60)")
+        ]),
     ])
 ]  # type: List[Any]
 
@@ -129,18 +129,26 @@ def register_version(args: Namespace) -> None:
         resp = api.post(
             args.master,
             "/api/v1/models/{}/versions".format(args.name),
-            body={"checkpoint_uuid": args.uuid},
+            body={"checkpoint_uuid": args.uuid, "labels": args.label or []},
         )
 
         print(json.dumps(resp.json(), indent=2))
     else:
         model = Determined(args.master, None).get_model(args.name)
-        checkpoint = model.register_version(args.uuid)
+        checkpoint = model.register_version(args.uuid, args.label)
         render_model(model)
         print("\n")
         render_model_version(checkpoint)
 
 
+
+@authentication_required
+def transition_version(args: Namespace) -> None:
+    model = Determined(args.master, None).get_model(args.name)
+    model.transition_version(args.version, args.stage, args.archive_existing)
+    print("Moved version {} of model {} to {}".format(args.version, args.name, args.stage))
+
+
 args_description = [
     Cmd(
         "m|odel",
@@ -177,9 +185,35 @@ args_description = [
                 [
                     Arg("name", type=str, help="name of the model"),
                     Arg("uuid", type=str, help="uuid to register as the next version of the model"),
+                    Arg(
+                        "--label",
+                        action="append",
+                        help="label of the new version, which may be given more than once",
+                    ),
                     Arg("--json", action="store_true", help="print as JSON"),
                 ],
             ),
+            Cmd(
+                "transition-version",
+                transition_version,
+                "move a version of a model to another stage",
+                [
+                    Arg("name", type=str, help="name of the model"),
+                    Arg("version", type=int, help="version number"),
+                    Arg(
+                        "stage",
+                        type=str,
+                        choices=["none", "staging", "production", "archived"],
+                        help="stage to move the version to",
+                    ),
+                    Arg(
+                        "--archive-existing",
+                        action="store_true",
+                        help="archive the other versions in the stage when moving the version to "
+                        "staging or production",
+                    ),
+                ],
+            ),
             Cmd(
                 "describe",
                 describe,
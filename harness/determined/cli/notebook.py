@@ -59,7 +59,9 @@ args_description = [
             Arg("-q", "--quiet", action="store_true",
                 help="only display the IDs"),
             Arg("--all", "-a", action="store_true",
-                help="show all notebooks (including other users')")
+                help="show all notebooks (including other users')"),
+            Arg("--archived", action="store_true",
+                help="only show archived notebooks"),
         ], is_default=True),
         Cmd("config", command.config,
             "display notebook config", [
@@ -94,6 +96,12 @@ args_description = [
             Arg("notebook_id", help="notebook ID", nargs=ONE_OR_MORE),
             Arg("-f", "--force", action="store_true", help="ignore errors"),
         ]),
+        Cmd("archive", command.archive, "archive an exited notebook", [
+            Arg("notebook_id", help="notebook ID", nargs=ONE_OR_MORE),
+        ]),
+        Cmd("unarchive", command.unarchive, "unarchive a notebook", [
+            Arg("notebook_id", help="notebook ID", nargs=ONE_OR_MORE),
+        ]),
     ])
 ]  # type: List[Any]
 
@@ -0,0 +1,103 @@
+from argparse import ONE_OR_MORE, FileType, Namespace
+from pathlib import Path
+from typing import Any, Dict, List
+
+from termcolor import colored
+
+from determined.cli import command
+from determined.common import api
+from determined.common.api.authentication import authentication_required
+from determined.common.check import check_eq
+from determined.common.declarative_argparse import Arg, Cmd
+
+from .command import CONFIG_DESC, CONTEXT_DESC, VOLUME_DESC, parse_config, render_event_stream
+
+
+def _show_dashboard(master: str, ray_cluster: Dict[str, Any], no_browser: bool) -> None:
+    path = ray_cluster["serviceAddress"]
+    url = api.make_url(master, path) if no_browser else api.open(master, path)
+    print(colored("Ray dashboard is running at: {}".format(url), "green"))
+
+
+@authentication_required
+def start_ray_cluster(args: Namespace) -> None:
+    config = parse_config(args.config_file, None, args.config, args.volume)
+    req_body = {"config": config, "workers": args.workers}  # type: Dict[str, Any]
+
+    if args.template:
+        req_body["template_name"] = args.template
+    if args.context is not None:
+        command.add_context(args.master, req_body, args.context)
+
+    resp = api.post(args.master, "api/v1/ray-clusters", body=req_body).json()["rayCluster"]
+
+    if args.detach:
+        print(resp["id"])
+        return
+
+    with api.ws(args.master, "ray-clusters/{}/events".format(resp["id"])) as ws:
+        for msg in ws:
+            if msg["service_ready_event"]:
+                _show_dashboard(args.master, resp, args.no_browser)
+            render_event_stream(msg)
+
+
+@authentication_required
+def open_ray_dashboard(args: Namespace) -> None:
+    resp = api.get(
+        args.master, "api/v1/ray-clusters/{}".format(args.ray_cluster_id)
+    ).json()["rayCluster"]
+    check_eq(resp["state"], "STATE_RUNNING", "Ray cluster must be in a running state")
+    _show_dashboard(args.master, resp, False)
+
+
+# fmt: off
+
+args_description = [
+    Cmd("ray", None, "manage Ray clusters", [
+        Cmd("list ls", command.list, "list Ray clusters", [
+            Arg("-q", "--quiet", action="store_true",
+                help="only display the IDs"),
+            Arg("--all", "-a", action="store_true",
+                help="show all Ray clusters (including other users')"),
+        ], is_default=True),
+        Cmd("config", command.config,
+            "display Ray cluster config", [
+                Arg("id", type=str, help="Ray cluster ID"),
+            ]),
+        Cmd("start", start_ray_cluster, "start a new Ray cluster", [
+            Arg("-w", "--workers", type=int, default=0,
+                help="number of workers to start besides the head, each in its own container; "
+                     "the slots of the cluster are split evenly between them and the head"),
+            Arg("--config-file", default=None, type=FileType("r"),
+                help="command config file (.yaml)"),
+            Arg("-v", "--volume", action="append", default=[],
+                help=VOLUME_DESC),
+            Arg("-c", "--context", default=None, type=Path, help=CONTEXT_DESC),
+            Arg("--config", action="append", default=[], help=CONFIG_DESC),
+            Arg("--template", type=str,
+                help="name of template to apply to the Ray cluster configuration"),
+            Arg("--no-browser", action="store_true",
+                help="don't open the Ray dashboard in a browser after startup"),
+            Arg("-d", "--detach", action="store_true",
+                help="run in the background and print the ID"),
+        ]),
+        Cmd("open", open_ray_dashboard, "open the dashboard of an existing Ray cluster", [
+            Arg("ray_cluster_id", help="Ray cluster ID")
+        ]),
+        Cmd("logs", command.tail_logs, "fetch Ray cluster logs", [
+            Arg("ray_cluster_id", help="Ray cluster ID"),
+            Arg("-f", "--follow", action="store_true",
+                help="follow the logs of a Ray cluster, similar to tail -f"),
+            Arg("--tail", type=int, default=200,
+                help="number of lines to show, counting from the end "
+                     "of the log")
+        ]),
+        Cmd("kill", command.kill, "kill a Ray cluster", [
+            Arg("ray_cluster_id", help="Ray cluster ID", nargs=ONE_OR_MORE),
+            Arg("-f", "--force", action="store_true", help="ignore errors"),
+        ]),
+    ])
+]  # type: List[Any]
+
+# fmt: on
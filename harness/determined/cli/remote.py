@@ -48,6 +48,8 @@ args_description = [
                 help="only display the IDs"),
             Arg("--all", "-a", action="store_true",
                 help="show all commands (including other users')"),
+            Arg("--archived", action="store_true",
+                help="only show archived commands"),
         ], is_default=True),
         Cmd("config", command.config,
             "display command config", [
@@ -79,6 +81,12 @@ args_description = [
             Arg("command_id", help="command ID", nargs=ONE_OR_MORE),
             Arg("-f", "--force", action="store_true", help="ignore errors"),
         ]),
+        Cmd("archive", command.archive, "archive an exited command", [
+            Arg("command_id", help="command ID", nargs=ONE_OR_MORE),
+        ]),
+        Cmd("unarchive", command.unarchive, "unarchive a command", [
+            Arg("command_id", help="command ID", nargs=ONE_OR_MORE),
+        ]),
     ])
 ]  # type: List[Any]
 
@@ -1,6 +1,6 @@
 import sys
 from argparse import Namespace
-from typing import Any, List
+from typing import Any, Dict, List
 
 import requests
 
@@ -37,6 +37,25 @@ def aggregated(args: Namespace) -> None:
     print_response(api.get(args.master, path, params=params))
 
 
+@authentication_required
+def usage(args: Namespace) -> None:
+    params = {
+        "start_date": args.start_date,
+        "end_date": args.end_date,
+        "period": "RESOURCE_ALLOCATION_AGGREGATION_PERIOD_MONTHLY"
+        if args.monthly
+        else "RESOURCE_ALLOCATION_AGGREGATION_PERIOD_DAILY",
+    }  # type: Dict[str, Any]
+    if args.owner:
+        params["users"] = args.owner
+    if args.project_id:
+        params["project_id"] = args.project_id
+    if args.experiment_id:
+        params["experiment_id"] = args.experiment_id
+    path = "api/v1/resources/usage" if args.json else "resources/usage"
+    print_response(api.get(args.master, path, params=params))
+
+
 args_description = [
     Cmd(
         "res|ources",
@@ -68,6 +87,28 @@ args_description = [
                     ),
                 ],
             ),
+            Cmd(
+                "usage",
+                usage,
+                "get slot-hours and costs by user, project, experiment, pool and task type",
+                [
+                    Arg("start_date", help="first date to include"),
+                    Arg("end_date", help="last date to include"),
+                    Arg("--owner", help="only include tasks owned by the given user"),
+                    Arg("--project-id", type=int, help="only include tasks in the given project"),
+                    Arg(
+                        "--experiment-id",
+                        type=int,
+                        help="only include the trials of the given experiment",
+                    ),
+                    Arg("--json", action="store_true", help="output JSON rather than CSV"),
+                    Arg(
+                        "--monthly",
+                        action="store_true",
+                        help="aggregate by month rather than by day",
+                    ),
+                ],
+            ),
         ],
     )
 ]  # type: List[Any]
@@ -0,0 +1,196 @@
+from argparse import ONE_OR_MORE, FileType, Namespace
+from collections import OrderedDict
+from pathlib import Path
+from typing import Any, Dict, List
+
+from termcolor import colored
+
+from determined.cli import render
+from determined.common import api, context
+from determined.common.api.authentication import authentication_required
+from determined.common.declarative_argparse import Arg, Cmd
+
+from .command import CONFIG_DESC, CONTEXT_DESC, VOLUME_DESC, parse_config
+
+ServingTableHeader = OrderedDict(
+    [
+        ("name", "name"),
+        ("username", "username"),
+        ("modelName", "model"),
+        ("modelVersion", "version"),
+        ("state", "state"),
+        ("healthy", "healthy"),
+        ("restarts", "restarts"),
+        ("updatingReplicaId", "updating to"),
+        ("serviceAddress", "address"),
+    ]
+)
+
+
+def _launch_body(args: Namespace) -> Dict[str, Any]:
+    body = {}  # type: Dict[str, Any]
+    config = parse_config(args.config_file, None, args.config, args.volume)
+    if config:
+        body["config"] = config
+    if args.template:
+        body["template_name"] = args.template
+    if args.context:
+        body["files"], _ = context.read_context(args.context)
+    return body
+
+
+@authentication_required
+def list_servings(args: Namespace) -> None:
+    if args.all:
+        params = {}  # type: Dict[str, Any]
+    else:
+        params = {"users": [api.Authentication.instance().get_session_user()]}
+
+    params["limit"] = 200
+    servings = []  # type: List[Dict[str, Any]]
+    while True:
+        page = api.get(args.master, "api/v1/servings", params=params).json()
+        servings.extend(page["servings"])
+        if not page.get("nextCursor"):
+            break
+        params["cursor"] = page["nextCursor"]
+
+    if args.quiet:
+        for serving in servings:
+            print(serving["name"])
+        return
+
+    for serving in servings:
+        serving["state"] = serving["state"].replace("STATE_", "")
+    render.render_table(servings, ServingTableHeader)
+
+
+@authentication_required
+def start_serving(args: Namespace) -> None:
+    body = _launch_body(args)
+    body.update(
+        {
+            "name": args.name,
+            "model_name": args.model_name,
+            "model_version": args.version,
+            "health_path": args.health_path,
+            "max_restarts": args.max_restarts,
+        }
+    )
+    serving = api.post(args.master, "api/v1/servings", body=body).json()["serving"]
+    print(
+        colored(
+            "Started serving {} of model {} version {}, which will be available at {}".format(
+                serving["name"],
+                serving["modelName"],
+                serving["modelVersion"],
+                api.make_url(args.master, serving["serviceAddress"]),
+            ),
+            "green",
+        )
+    )
+
+
+@authentication_required
+def describe_serving(args: Namespace) -> None:
+    serving = api.get(args.master, "api/v1/servings/{}".format(args.name)).json()["serving"]
+    table = [
+        ["Name", serving["name"]],
+        ["Description", serving.get("description", "")],
+        ["Model", "{} version {}".format(serving["modelName"], serving["modelVersion"])],
+        ["Checkpoint UUID", serving["checkpointUuid"]],
+        ["State", serving["state"].replace("STATE_", "")],
+        ["Replica", serving.get("replicaId", "")],
+        ["Healthy", serving.get("healthy", False)],
+        ["Restarts", serving.get("restarts", 0)],
+        ["Updating To", serving.get("updatingReplicaId", "")],
+        ["Health Path", serving.get("healthPath", "")],
+        ["Address", api.make_url(args.master, serving["serviceAddress"])],
+    ]
+    headers, values = zip(*table)  # type: ignore
+    render.tabulate_or_csv(headers, [values], False)
+
+
+@authentication_required
+def serving_config(args: Namespace) -> None:
+    resp = api.get(args.master, "api/v1/servings/{}".format(args.name)).json()
+    print(render.format_object_as_yaml(resp["config"]))
+
+
+@authentication_required
+def update_serving(args: Namespace) -> None:
+    body = _launch_body(args)
+    if args.version is not None:
+        body["model_version"] = args.version
+    resp = api.patch(args.master, "api/v1/servings/{}".format(args.name), body=body)
+    serving = resp.json()["serving"]
+    print(
+        colored(
+            "Started replica {} of serving {}, which replaces the current replica once it "
+            "passes its health checks".format(serving["updatingReplicaId"], serving["name"]),
+            "green",
+        )
+    )
+
+
+@authentication_required
+def kill_serving(args: Namespace) -> None:
+    for name in args.name:
+        api.post(args.master, "api/v1/servings/{}/kill".format(name))
+        print(colored("Killed serving {}".format(name), "green"))
+
+
+# fmt: off
+
+_config_args = [
+    Arg("--config-file", default=None, type=FileType("r"),
+        help="command config file (.yaml), whose entrypoint runs the model server"),
+    Arg("-v", "--volume", action="append", default=[],
+        help=VOLUME_DESC),
+    Arg("-c", "--context", default=None, type=Path, help=CONTEXT_DESC),
+    Arg("--config", action="append", default=[], help=CONFIG_DESC),
+    Arg("--template", type=str,
+        help="name of template to apply to the serving configuration"),
+]
+
+args_description = [
+    Cmd("serving", None, "manage servings of model versions", [
+        Cmd("list ls", list_servings, "list servings", [
+            Arg("-q", "--quiet", action="store_true",
+                help="only display the names"),
+            Arg("--all", "-a", action="store_true",
+                help="show all servings (including other users')"),
+        ], is_default=True),
+        Cmd("start", start_serving, "serve a model version", [
+            Arg("name", type=str,
+                help="name of the serving, under which the master proxies it"),
+            Arg("model_name", type=str, help="name of the model"),
+            Arg("version", type=int, help="version of the model"),
+            *_config_args,
+            Arg("--health-path", type=str, default="/",
+                help="path of the model server that its health is checked at"),
+            Arg("--max-restarts", type=int, default=5,
+                help="how many times in a row to restart the model server before it passes "
+                     "its health checks"),
+        ]),
+        Cmd("describe", describe_serving, "describe a serving", [
+            Arg("name", type=str, help="name of the serving"),
+        ]),
+        Cmd("config", serving_config, "display the config of a serving", [
+            Arg("name", type=str, help="name of the serving"),
+        ]),
+        Cmd("update", update_serving,
+            "update a serving to another model version or config, replacing its replica once "
+            "the new one passes its health checks", [
+                Arg("name", type=str, help="name of the serving"),
+                Arg("--version", type=int, default=None,
+                    help="version of the model to serve instead"),
+                *_config_args,
+            ]),
+        Cmd("kill", kill_serving, "kill a serving", [
+            Arg("name", type=str, help="name of the serving", nargs=ONE_OR_MORE),
+        ]),
+    ])
+]  # type: List[Any]
+
+# fmt: on
@@ -8,7 +8,7 @@ from typing import Any, Dict, List
 
 from termcolor import colored
 
-from determined.cli import command
+from determined.cli import command, tunnel
 from determined.common import api
 from determined.common.api import request
 from determined.common.api.authentication import authentication_required
@@ -64,10 +64,47 @@ def open_shell(args: Namespace) -> None:
     _open_shell(args.master, shell, args.ssh_opts)
 
 
+@authentication_required
+def forward_port(args: Namespace) -> None:
+    resp = api.post(
+        args.master,
+        "api/v1/shells/{}/ports".format(args.shell_id),
+        body={"port": args.port},
+    ).json()
+    local_port = args.local_port or args.port
+    print(
+        colored(
+            "Forwarding localhost:{} to port {} of shell {}; press Ctrl-C to stop".format(
+                local_port, args.port, args.shell_id
+            ),
+            "green",
+        )
+    )
+    # The tunnel takes the certificate bundle as it would on its command line.
+    cert_bundle = request.get_master_cert_bundle()
+    cert_file = str(cert_bundle) if cert_bundle is not None else None
+    try:
+        tunnel.listen(
+            args.master, resp["serviceId"], local_port, cert_file, request.get_master_cert_name()
+        )
+    except KeyboardInterrupt:
+        pass
+
+
+@authentication_required
+def unforward_port(args: Namespace) -> None:
+    api.delete(args.master, "api/v1/shells/{}/ports/{}".format(args.shell_id, args.port))
+    print("Stopped forwarding port {} of shell {}".format(args.port, args.shell_id))
+
+
 def _open_shell(master: str, shell: Dict[str, Any], additional_opts: List[str]) -> None:
     with tempfile.NamedTemporaryFile("w") as fp:
-        fp.write(shell["privateKey"])
-        fp.flush()
+        # Shells of users who registered SSH keys authorize those keys instead of a generated one.
+        identity_opts = []  # type: List[str]
+        if shell.get("privateKey"):
+            fp.write(shell["privateKey"])
+            fp.flush()
+            identity_opts = ["-o", "IdentitiesOnly=yes", "-i", str(fp.name)]
         check_len(shell["addresses"], 1, "Cannot find address for shell")
         _, port = shell["addresses"][0]["host_ip"], shell["addresses"][0]["host_port"]
 
@@ -89,10 +126,7 @@ def _open_shell(master: str, shell: Dict[str, Any], additional_opts: List[str])
             "-o",
             "StrictHostKeyChecking=no",
             "-tt",
-            "-o",
-            "IdentitiesOnly=yes",
-            "-i",
-            str(fp.name),
+            *identity_opts,
             "-p",
             str(port),
             "{}@{}".format(username, shell["id"]),
@@ -137,6 +171,17 @@ args_description = [
             Arg("shell_id", help="shell ID"),
             Arg("ssh_opts", nargs="*", help="additional SSH options when connecting to the shell"),
         ]),
+        Cmd("forward", forward_port,
+            "forward a port of a running shell to a local port", [
+                Arg("shell_id", help="shell ID"),
+                Arg("port", type=int, help="port in the shell to forward"),
+                Arg("--local-port", type=int, default=None,
+                    help="local port to listen on (defaults to the same port)"),
+            ]),
+        Cmd("unforward", unforward_port, "stop forwarding a port of a shell", [
+            Arg("shell_id", help="shell ID"),
+            Arg("port", type=int, help="forwarded port in the shell"),
+        ]),
         Cmd("logs", command.tail_logs, "fetch shell logs", [
             Arg("shell_id", help="shell ID"),
             Arg("-f", "--follow", action="store_true",
@@ -0,0 +1,105 @@
+import json
+from argparse import Namespace
+from collections import OrderedDict
+from typing import Any, Dict, List
+
+from determined.cli import render
+from determined.common import api
+from determined.common.api.authentication import authentication_required
+from determined.common.declarative_argparse import Arg, Cmd, Group
+
+
+def render_tenant(tenant: Dict[str, Any]) -> Dict[str, Any]:
+    return OrderedDict(
+        [
+            ("name", tenant["name"]),
+            ("users", ", ".join(tenant.get("usernames", []))),
+            ("workspaces", ", ".join(tenant.get("workspaceNames", []))),
+            ("resource_pools", ", ".join(tenant.get("resourcePools", []))),
+        ]
+    )
+
+
+@authentication_required
+def list_tenants(args: Namespace) -> None:
+    tenants = [render_tenant(t) for t in api.get(args.master, "api/v1/tenants").json()["tenants"]]
+    if args.json:
+        print(json.dumps(tenants, indent=4))
+        return
+    headers = ["Name", "Users", "Workspaces", "Resource Pools"]
+    render.tabulate_or_csv(headers, [t.values() for t in tenants], args.csv)
+
+
+@authentication_required
+def set_tenant(args: Namespace) -> None:
+    body = {
+        "name": args.name,
+        "usernames": args.user or [],
+        "workspace_names": args.workspace or [],
+        "resource_pools": args.resource_pool or [],
+    }
+    api.put(args.master, "api/v1/tenants/{}".format(args.name), body=body)
+    print("Set tenant {}".format(args.name))
+
+
+@authentication_required
+def delete_tenant(args: Namespace) -> None:
+    api.delete(args.master, "api/v1/tenants/{}".format(args.name))
+    print("Deleted tenant {}".format(args.name))
+
+
+@authentication_required
+def tenant_usage(args: Namespace) -> None:
+    params = {}  # type: Dict[str, Any]
+    if args.start_date:
+        params["start_time"] = "{}T00:00:00Z".format(args.start_date)
+    if args.end_date:
+        params["end_time"] = "{}T00:00:00Z".format(args.end_date)
+    r = api.get(args.master, "api/v1/tenants/{}/usage".format(args.name), params=params).json()
+    if args.json:
+        print(json.dumps(r, indent=4))
+        return
+    headers = ["Resource Pool", "Slot Hours", "Cost"]
+    values = [
+        [u["resourcePool"], "{:.2f}".format(u.get("slotHours", 0)), u.get("cost", "")]
+        for u in r.get("usage", [])
+    ]
+    values.append(["total", "{:.2f}".format(r.get("totalSlotHours", 0)), r.get("totalCost", "")])
+    render.tabulate_or_csv(headers, values, args.csv)
+
+
+# fmt: off
+
+args_description = [
+    Cmd("tenant", None, "manage tenants", [
+        Cmd("list ls", list_tenants, "list tenants", [
+            Group(
+                Arg("--csv", action="store_true", help="print as CSV"),
+                Arg("--json", action="store_true", help="print as JSON"),
+            ),
+        ], is_default=True),
+        Cmd("set", set_tenant, "create a tenant, or replace the members of a tenant", [
+            Arg("name", help="name of the tenant"),
+            Arg("--user", action="append", help="user of the tenant; may be repeated"),
+            Arg("--workspace", action="append",
+                help="workspace of the tenant; may be repeated"),
+            Arg("--resource-pool", action="append",
+                help="resource pool of the tenant; may be repeated"),
+        ]),
+        Cmd("delete", delete_tenant, "delete a tenant that has no users or workspaces", [
+            Arg("name", help="name of the tenant"),
+        ]),
+        Cmd("usage", tenant_usage, "get the slot-hours and costs of the tasks of a tenant", [
+            Arg("name", help="name of the tenant"),
+            Arg("--start-date", help="first date to include, as YYYY-MM-DD "
+                "(default is the start of this month)"),
+            Arg("--end-date", help="date to stop before, as YYYY-MM-DD (default is now)"),
+            Group(
+                Arg("--csv", action="store_true", help="print as CSV"),
+                Arg("--json", action="store_true", help="print as JSON"),
+            ),
+        ]),
+    ])
+]  # type: List[Any]
+
+# fmt: on
@@ -6,7 +6,7 @@ from typing import Any, List
 from termcolor import colored
 
 from determined.cli import command
-from determined.common import api, constants, context
+from determined.common import api
 from determined.common.api.authentication import authentication_required
 from determined.common.check import check_eq
 from determined.common.declarative_argparse import Arg, Cmd
@@ -16,8 +16,8 @@ from .command import CONTEXT_DESC, parse_config, render_event_stream
 
 @authentication_required
 def start_tensorboard(args: Namespace) -> None:
-    if args.trial_ids is None and args.experiment_ids is None:
-        print("Either experiment_ids or trial_ids must be specified.")
+    if not (args.trial_ids or args.experiment_ids or args.log_path):
+        print("Either experiment_ids, trial_ids or log paths must be specified.")
         sys.exit(1)
 
     config = parse_config(args.config_file, None, [], [])
@@ -25,10 +25,12 @@ def start_tensorboard(args: Namespace) -> None:
         "config": config,
         "trial_ids": args.trial_ids,
         "experiment_ids": args.experiment_ids,
+        "log_paths": args.log_path,
+        "reuse": args.reuse,
     }
 
     if args.context is not None:
-        req_body["files"], _ = context.read_context(args.context, constants.MAX_CONTEXT_SIZE)
+        command.add_context(args.master, req_body, args.context)
 
     resp = api.post(args.master, "api/v1/tensorboards", body=req_body).json()["tensorboard"]
 
@@ -74,7 +76,9 @@ args_description = [
             Arg("-q", "--quiet", action="store_true",
                 help="only display the IDs"),
             Arg("--all", "-a", action="store_true",
-                help="show all TensorBoards (including other users')")
+                help="show all TensorBoards (including other users')"),
+            Arg("--archived", action="store_true",
+                help="only show archived TensorBoards"),
         ], is_default=True),
         Cmd("start", start_tensorboard, "start new TensorBoard instance", [
             Arg("experiment_ids", type=int, nargs="*",
@@ -87,6 +91,12 @@ args_description = [
             Arg("-t", "--trial-ids", nargs=ONE_OR_MORE, type=int,
                 help="trial IDs to load into TensorBoard; at most 100 trials are "
                      "allowed per TensorBoard instance"),
+            Arg("--log-path", action="append",
+                help="storage path of logs to load into TensorBoard, such as "
+                     "s3://bucket/prefix or gs://bucket/prefix; may be repeated"),
+            Arg("--reuse", action="store_true",
+                help="reuse a running TensorBoard that was started with --reuse for the "
+                     "same logs and config instead of starting another one"),
             Arg("--no-browser", action="store_true",
                 help="don't open TensorBoard in a browser after startup"),
             Arg("-c", "--context", default=None, type=Path, help=CONTEXT_DESC),
@@ -114,6 +124,12 @@ args_description = [
             Arg("tensorboard_id", help="TensorBoard ID", nargs=ONE_OR_MORE),
             Arg("-f", "--force", action="store_true", help="ignore errors"),
         ]),
+        Cmd("archive", command.archive, "archive an exited TensorBoard instance", [
+            Arg("tensorboard_id", help="TensorBoard ID", nargs=ONE_OR_MORE),
+        ]),
+        Cmd("unarchive", command.unarchive, "unarchive a TensorBoard instance", [
+            Arg("tensorboard_id", help="TensorBoard ID", nargs=ONE_OR_MORE),
+        ]),
     ])
 ]  # type: List[Any]
 
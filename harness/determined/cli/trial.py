@@ -3,6 +3,8 @@ import json
 from argparse import Namespace
 from typing import Any, List
 
+from termcolor import colored
+
 from determined.cli import render
 from determined.common import api
 from determined.common.api.authentication import authentication_required
@@ -123,6 +125,27 @@ def trial_logs(args: Namespace) -> None:
     )
 
 
+@authentication_required
+def search_trial_logs(args: Namespace) -> None:
+    matches = api.experiment.search_trial_logs(
+        args.master,
+        args.trial_id,
+        args.pattern,
+        context=args.context,
+        limit=args.limit,
+        timestamp_before=args.timestamp_before,
+        timestamp_after=args.timestamp_after,
+    )
+    for i, match in enumerate(matches):
+        if args.context and i > 0:
+            print("--")
+        for log in match.get("before", []):
+            print(log["message"], end="")
+        print(colored(match["match"]["message"], "yellow"), end="")
+        for log in match.get("after", []):
+            print(log["message"], end="")
+
+
 args_description = [
     Cmd(
         "t|rial",
@@ -271,6 +294,35 @@ args_description = [
                     ),
                 ],
             ),
+            Cmd(
+                "search-logs",
+                search_trial_logs,
+                "search trial logs with a regular expression",
+                [
+                    Arg("trial_id", type=int, help="trial ID"),
+                    Arg("pattern", help="regular expression (RE2 syntax) to search for"),
+                    Arg(
+                        "-C",
+                        "--context",
+                        type=int,
+                        default=0,
+                        help="number of lines to show before and after each match",
+                    ),
+                    Arg(
+                        "--limit",
+                        type=int,
+                        help="maximum number of matches to show (default is all)",
+                    ),
+                    Arg(
+                        "--timestamp-before",
+                        help="search logs only from before (RFC 3339 format)",
+                    ),
+                    Arg(
+                        "--timestamp-after",
+                        help="search logs only from after (RFC 3339 format)",
+                    ),
+                ],
+            ),
             Cmd(
                 "kill", kill_trial, "forcibly terminate a trial", [Arg("trial_id", help="trial ID")]
             ),
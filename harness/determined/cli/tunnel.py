@@ -110,6 +110,53 @@ def http_connect_tunnel(
     c2.join()
 
 
+def _tunnel_connection(
+    url: str, conn: socket.socket, cert_file: Optional[str], cert_name: Optional[str]
+) -> None:
+    ws = lomond.WebSocket(url)
+    ready_sem = threading.Semaphore(0)
+    reader = conn.makefile("rb", buffering=0)
+    writer = conn.makefile("wb", buffering=0)
+
+    c1 = threading.Thread(target=copy_to_websocket, args=(ws, reader, ready_sem))
+    c2 = threading.Thread(
+        target=copy_from_websocket, args=(writer, ws, ready_sem, cert_file, cert_name)
+    )
+    c1.start()
+    c2.start()
+    c2.join()
+    # Unblock the sending thread if the service closed the connection first.
+    try:
+        conn.shutdown(socket.SHUT_RDWR)
+    except OSError:
+        pass
+    c1.join()
+    conn.close()
+
+
+def listen(
+    master: str,
+    service: str,
+    local_port: int,
+    cert_file: Optional[str],
+    cert_name: Optional[str],
+) -> None:
+    """
+    Accept TCP connections on local_port and tunnel each of them to the service over its own
+    WebSocket connection, until interrupted.
+    """
+    url = request.maybe_upgrade_ws_scheme(request.make_url(master, "proxy/{}/".format(service)))
+    with socket.socket(socket.AF_INET, socket.SOCK_STREAM) as server:
+        server.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+        server.bind(("127.0.0.1", local_port))
+        server.listen()
+        while True:
+            conn, _ = server.accept()
+            threading.Thread(
+                target=_tunnel_connection, args=(url, conn, cert_file, cert_name), daemon=True
+            ).start()
+
+
 if __name__ == "__main__":
     parser = argparse.ArgumentParser(description="Tunnel through a Determined master")
     parser.add_argument("master_addr")
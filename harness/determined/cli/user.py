@@ -1,6 +1,6 @@
 import getpass
-from argparse import Namespace
-from collections import namedtuple
+from argparse import FileType, Namespace
+from collections import OrderedDict, namedtuple
 from functools import wraps
 from typing import Any, Callable, Dict, List, Optional
 
@@ -199,6 +199,40 @@ def whoami(parsed_args: Namespace) -> None:
     print("You are logged in as user '{}'".format(user["username"]))
 
 
+SSHKeyTableHeader = OrderedDict(
+    [
+        ("id", "ID"),
+        ("name", "Name"),
+        ("fingerprint", "Fingerprint"),
+        ("createdAt", "Registered"),
+    ]
+)
+
+
+def _ssh_keys_path(parsed_args: Namespace) -> str:
+    username = parsed_args.user or api.Authentication.instance().get_session_user()
+    return "api/v1/users/{}/ssh-keys".format(username)
+
+
+@authentication_required
+def list_ssh_keys(parsed_args: Namespace) -> None:
+    keys = api.get(parsed_args.master, _ssh_keys_path(parsed_args)).json()["sshKeys"]
+    render.render_table(keys, SSHKeyTableHeader)
+
+
+@authentication_required
+def add_ssh_key(parsed_args: Namespace) -> None:
+    body = {"public_key": parsed_args.public_key_file.read(), "name": parsed_args.name or ""}
+    key = api.post(parsed_args.master, _ssh_keys_path(parsed_args), body=body).json()["sshKey"]
+    print("Registered SSH key {} ({})".format(key["id"], key["fingerprint"]))
+
+
+@authentication_required
+def remove_ssh_key(parsed_args: Namespace) -> None:
+    api.delete(parsed_args.master, "{}/{}".format(_ssh_keys_path(parsed_args), parsed_args.id))
+    print("Removed SSH key {}".format(parsed_args.id))
+
+
 # fmt: off
 
 args_description = [
@@ -232,7 +266,23 @@ args_description = [
             Arg("--agent-gid", type=int, help="GID on agent to run tasks as"),
             Arg("--agent-group", help="group on the agent to run tasks as"),
         ]),
-        Cmd("whoami", whoami, "print the active user", [])
+        Cmd("whoami", whoami, "print the active user", []),
+        Cmd("ssh-key", None, "manage the SSH keys that log in to shells", [
+            Cmd("list", list_ssh_keys, "list registered SSH keys", [
+                Arg("--user", default=None, help="user to list the keys of"),
+            ], is_default=True),
+            Cmd("add", add_ssh_key, "register an SSH public key", [
+                Arg("public_key_file", type=FileType("r"),
+                    help="public key file, e.g. ~/.ssh/id_ed25519.pub"),
+                Arg("--name", default=None,
+                    help="name of the key (defaults to the comment of the key)"),
+                Arg("--user", default=None, help="user to register the key for"),
+            ]),
+            Cmd("remove", remove_ssh_key, "remove a registered SSH key", [
+                Arg("id", type=int, help="ID of the key"),
+                Arg("--user", default=None, help="user to remove the key of"),
+            ]),
+        ]),
     ])
 ]  # type: List[Any]
 
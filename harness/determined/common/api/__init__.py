@@ -30,3 +30,11 @@ from determined.common.api.profiler import (
     post_trial_profiler_metrics_batches,
     TrialProfilerMetricsBatch,
 )
+from determined.common.api.task import report_task_status
+from determined.common.api.artifact import (
+    download_artifact,
+    upload_artifact,
+    upload_context,
+    upload_deduplicated_artifact,
+    upload_task_output,
+)
@@ -0,0 +1,156 @@
+import base64
+import hashlib
+import pathlib
+from typing import IO, Any, Dict, List, Optional
+
+import simplejson
+
+from determined.common import api, context, util
+
+# The largest chunk that the master accepts, which it also reports when an upload starts.
+CHUNK_SIZE = 2 * 1024 * 1024
+
+
+def upload_artifact(
+    master_url: str,
+    name: str,
+    artifact_type: str,
+    f: IO[bytes],
+    headers: Optional[Dict[str, str]] = None,
+    authenticated: bool = True,
+) -> Dict[str, Any]:
+    """
+    Upload the contents of a file to the master as an artifact, in chunks of the size that the
+    master asks for, and return the completed artifact.
+    """
+    resp = api.post(
+        master_url,
+        "/api/v1/artifacts",
+        body={"name": name, "type": artifact_type},
+        headers=headers,
+        authenticated=authenticated,
+    ).json()
+    artifact_id = resp["artifact"]["id"]
+    chunk_size = resp["chunkSize"]
+
+    index = 0
+    while True:
+        chunk = f.read(chunk_size)
+        if not chunk:
+            break
+        api.post(
+            master_url,
+            "/api/v1/artifacts/{}/chunks".format(artifact_id),
+            body={"index": index, "data": base64.b64encode(chunk).decode("utf-8")},
+            headers=headers,
+            authenticated=authenticated,
+        )
+        index += 1
+
+    artifact = api.post(
+        master_url,
+        "/api/v1/artifacts/{}/complete".format(artifact_id),
+        body={},
+        headers=headers,
+        authenticated=authenticated,
+    ).json()["artifact"]  # type: Dict[str, Any]
+    return artifact
+
+
+def upload_deduplicated_artifact(
+    master_url: str,
+    name: str,
+    artifact_type: str,
+    chunks: List[bytes],
+    headers: Optional[Dict[str, str]] = None,
+    authenticated: bool = True,
+) -> Dict[str, Any]:
+    """
+    Upload an artifact that is split into chunks by the hashes of its chunks, so that only the
+    chunks that the master does not store yet, e.g. for an earlier upload of the same context, are
+    uploaded, and return the completed artifact.
+    """
+    artifact_id = api.post(
+        master_url,
+        "/api/v1/artifacts",
+        body={"name": name, "type": artifact_type},
+        headers=headers,
+        authenticated=authenticated,
+    ).json()["artifact"]["id"]
+
+    by_hash = {hashlib.sha256(chunk).hexdigest(): chunk for chunk in chunks}
+    manifest = {"chunkHashes": [hashlib.sha256(chunk).hexdigest() for chunk in chunks]}
+    # The master may evict a chunk that no artifact uses after it reports that it has it, in which
+    # case it is missing when the manifest is posted again.
+    for _ in range(3):
+        missing = api.post(
+            master_url,
+            "/api/v1/artifacts/{}/manifest".format(artifact_id),
+            body=manifest,
+            headers=headers,
+            authenticated=authenticated,
+        ).json().get("missingHashes", [])
+        if not missing:
+            break
+        print("Uploading {} of {} chunks...".format(len(missing), len(by_hash)))
+        for h in missing:
+            api.post(
+                master_url,
+                "/api/v1/artifacts/{}/blobs".format(artifact_id),
+                body={"data": base64.b64encode(by_hash[h]).decode("utf-8")},
+                headers=headers,
+                authenticated=authenticated,
+            )
+
+    artifact = api.post(
+        master_url,
+        "/api/v1/artifacts/{}/complete".format(artifact_id),
+        body={},
+        headers=headers,
+        authenticated=authenticated,
+    ).json()["artifact"]  # type: Dict[str, Any]
+    return artifact
+
+
+def upload_context(master_url: str, ctx: context.Context, name: str) -> Dict[str, Any]:
+    """
+    Upload a context as a .tar.gz context artifact in deduplicated chunks, so that uploading it
+    again with few changes only uploads the chunks that changed.
+    """
+    # Chunks are at most half of the largest chunk before compression, which leaves room for the
+    # overhead of compressing data that does not compress.
+    max_size = CHUNK_SIZE // 2
+    return upload_deduplicated_artifact(
+        master_url, name, "TYPE_CONTEXT", ctx.tar_gz_chunks(max_size)
+    )
+
+
+def download_artifact(master_url: str, artifact_id: str, f: IO[bytes]) -> None:
+    """
+    Download the contents of an artifact from the master into a file.
+    """
+    path = "/api/v1/artifacts/{}/download".format(artifact_id)
+    with api.get(master_url, path, stream=True) as r:
+        for line in r.iter_lines():
+            f.write(base64.b64decode(simplejson.loads(line)["result"]["data"]))
+
+
+def upload_task_output(
+    path: pathlib.Path, name: Optional[str] = None, master_url: Optional[str] = None
+) -> Dict[str, Any]:
+    """
+    Upload a file as an output artifact of the task this code is running in, which its owner can
+    then download, e.g. with `det task download-artifact`.
+    """
+    if master_url is None:
+        master_url = util.get_default_master_address()
+    task_token = api.Authentication.instance().get_task_token()
+    with path.open("rb") as f:
+        return upload_artifact(
+            master_url,
+            name or path.name,
+            "TYPE_OUTPUT",
+            f,
+            headers={"Grpc-Metadata-x-task-token": "Bearer {}".format(task_token)},
+            authenticated=False,
+        )
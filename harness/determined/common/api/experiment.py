@@ -11,6 +11,7 @@ import simplejson
 from termcolor import colored
 
 from determined.common import api, constants, context, yaml
+from determined.common.api import artifact, errors
 from determined.common.api import request as req
 
 
@@ -105,6 +106,30 @@ def print_trial_logs(master_url: str, trial_id: int, **kwargs: Any) -> None:
         )
 
 
+def search_trial_logs(
+    master_url: str,
+    trial_id: int,
+    pattern: str,
+    context: int = 0,
+    limit: Optional[int] = None,
+    timestamp_before: Optional[str] = None,
+    timestamp_after: Optional[str] = None,
+) -> collections.abc.Iterable:
+    query = {"pattern": pattern, "context": context}  # type: Dict[str, Any]
+    for key, val in [
+        ("limit", limit),
+        ("timestamp_before", timestamp_before),
+        ("timestamp_after", timestamp_after),
+    ]:
+        if val is not None:
+            query[key] = val
+
+    path = "/api/v1/trials/{}/logs/search?{}".format(trial_id, urlencode(query))
+    with api.get(master_url, path, stream=True) as r:
+        for line in r.iter_lines():
+            yield simplejson.loads(line)["result"]
+
+
 def follow_experiment_logs(master_url: str, exp_id: int) -> None:
     # Get the ID of this experiment's first trial (i.e., the one with the lowest ID).
     print("Waiting for first trial to begin...")
@@ -210,9 +235,8 @@ def create_experiment(
 ) -> int:
     body = {
         "experiment_config": yaml.safe_dump(config),
-        "model_definition": [e.dict() for e in model_context.entries],
         "validate_only": validate_only,
-    }
+    }  # type: Dict[str, Any]
     if template:
         body["template"] = template
     if archived:
@@ -220,7 +244,25 @@ def create_experiment(
     if additional_body_fields:
         body.update(additional_body_fields)
 
-    r = req.post(master_url, "experiments", body=body)
+    uploaded = None
+    if model_context.size > constants.MIN_UPLOADED_CONTEXT_SIZE and not validate_only:
+        try:
+            print("Uploading the model definition as an artifact...")
+            uploaded = artifact.upload_context(master_url, model_context, "model_definition")
+        except errors.APIException as e:
+            print("Sending the model definition in the request instead: {}".format(e))
+    if uploaded:
+        body["context_artifact_id"] = uploaded["id"]
+    else:
+        body["model_definition"] = [e.dict() for e in model_context.entries]
+
+    try:
+        r = req.post(master_url, "experiments", body=body)
+    finally:
+        if uploaded:
+            # The experiment stores its model definition itself. The chunks of the artifact stay
+            # cached on the master, so that submitting it again only uploads those that changed.
+            req.delete(master_url, "/api/v1/artifacts/{}".format(uploaded["id"]))
     if not hasattr(r, "headers"):
         raise Exception(r)
 
@@ -4,7 +4,7 @@ import os
 import tempfile
 import webbrowser
 from types import TracebackType
-from typing import Any, Dict, Iterator, Optional, Union
+from typing import Any, Dict, Iterator, Optional, Tuple, Union
 from urllib import parse
 
 import certifi
@@ -68,6 +68,11 @@ del f
 # Set the master servername from the environment.
 set_master_cert_name(os.environ.get("DET_MASTER_CERT_NAME"))
 
+# The client certificate and key that the master issued to this task, if it requires mTLS.
+_task_cert = None  # type: Optional[Tuple[str, str]]
+if os.environ.get("DET_TASK_CERT_FILE") and os.environ.get("DET_TASK_KEY_FILE"):
+    _task_cert = (os.environ["DET_TASK_CERT_FILE"], os.environ["DET_TASK_KEY_FILE"])
+
 
 def get_master_cert_bundle() -> Optional[Union[str, bool]]:
     return _master_cert_bundle
@@ -149,6 +154,7 @@ def do_request(
             json=body,
             headers=h,
             verify=_master_cert_bundle,
+            cert=_task_cert,
             stream=stream,
             server_hostname=_master_cert_name,
         )
@@ -0,0 +1,21 @@
+from typing import Optional
+
+from determined.common import api, util
+
+
+def report_task_status(status: str, progress: float = 0, master_url: Optional[str] = None) -> None:
+    """
+    Report a short status message and a progress percentage between 0 and 100 for the task this
+    code is running in. The status is shown when listing commands, notebooks, shells and
+    TensorBoards, e.g. with `det cmd list`.
+    """
+    if master_url is None:
+        master_url = util.get_default_master_address()
+    task_token = api.Authentication.instance().get_task_token()
+    api.post(
+        master_url,
+        "/api/v1/tasks/status",
+        body={"status": status, "progress": progress},
+        headers={"Grpc-Metadata-x-task-token": "Bearer {}".format(task_token)},
+        authenticated=False,
+    )
@@ -17,6 +17,11 @@ MAX_ENCODED_SIZE = (min(MAX_WEBSOCKET_MSG_SIZE, MAX_HTTP_REQUEST_SIZE) // 8) * 6
 # We subtract one megabyte to account for any message envelope size we may have.
 MAX_CONTEXT_SIZE = MAX_ENCODED_SIZE - (1 * 1024 * 1024)
 
+# Model definitions larger than this are uploaded as artifacts in deduplicated chunks, if the
+# master stores artifacts, so that submitting an experiment again only uploads the chunks that
+# changed.
+MIN_UPLOADED_CONTEXT_SIZE = 8 * 1024 * 1024
+
 # The maximum size of a workload metrics object is capped at 100MB. Metrics
 # are specified by the model definition and persisted after each workload
 # training step is completed.
@@ -32,8 +37,9 @@ CANCELED = "CANCELED"
 COMPLETED = "COMPLETED"
 DELETED = "DELETED"
 ERROR = "ERROR"
+TIMEOUT = "TIMEOUT"
 
-TERMINAL_STATES = {COMPLETED, CANCELED, ERROR}
+TERMINAL_STATES = {COMPLETED, CANCELED, ERROR, TIMEOUT}
 
 SHARED_FS_CONTAINER_PATH = "/determined_shared_fs"
 
@@ -1,5 +1,8 @@
 import base64
 import collections
+import gzip
+import hashlib
+import io
 import os
 import pathlib
 import tarfile
@@ -92,6 +95,62 @@ class Context:
         self._items[entry.path] = entry
         self._size += entry.size
 
+    def tar_gz_chunks(self, max_size: int) -> List[bytes]:
+        """
+        Return the files and directories of the context as a .tar.gz archive that is split into
+        chunks of at most max_size bytes before compression, each compressed on its own, which
+        concatenate to a valid archive. Chunks end at files that are chosen by their contents and
+        large files are split at fixed offsets, so that most chunks of a context that is uploaded
+        again with few changes are the same and need not be uploaded again.
+        """
+        chunks = []  # type: List[bytes]
+        pending = io.BytesIO()
+
+        def compress(data: bytes) -> None:
+            out = io.BytesIO()
+            # Leave out the modification time so that the same data always compresses the same.
+            with gzip.GzipFile(fileobj=out, mode="wb", mtime=0) as f:
+                f.write(data)
+            chunks.append(out.getvalue())
+
+        def flush() -> None:
+            if pending.tell() > 0:
+                compress(pending.getvalue())
+                pending.seek(0)
+                pending.truncate()
+
+        for entry in sorted(self.entries, key=lambda e: e.path):
+            info = tarfile.TarInfo(entry.path)
+            info.type = bytes([entry.type])
+            info.uid = entry.uid
+            info.gid = entry.gid
+            if entry.mtime != -1:
+                info.mtime = entry.mtime
+            if entry.mode != -1:
+                info.mode = entry.mode & 0o7777
+            content = b""
+            if entry.type == ord(tarfile.REGTYPE):
+                content = base64.b64decode(entry.content)
+                info.size = len(content)
+            padding = -len(content) % tarfile.BLOCKSIZE
+            data = info.tobuf(tarfile.PAX_FORMAT) + content + tarfile.NUL * padding
+
+            if len(data) > max_size:
+                flush()
+                for i in range(0, len(data), max_size):
+                    compress(data[i : i + max_size])
+                continue
+            if pending.tell() + len(data) > max_size:
+                flush()
+            pending.write(data)
+            # End the chunk after about one in 16 files.
+            if hashlib.sha256(data).digest()[0] < 16:
+                flush()
+
+        pending.write(tarfile.NUL * 2 * tarfile.BLOCKSIZE)
+        flush()
+        return chunks
+
     @classmethod
     def from_local(
         cls,
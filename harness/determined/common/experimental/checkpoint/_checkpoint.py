@@ -51,6 +51,7 @@ class Checkpoint(object):
         model_version: Optional[int] = None,
         model_name: Optional[str] = None,
         master: Optional[str] = None,
+        integrity: Optional[str] = None,
     ):
         self.uuid = uuid
         self.experiment_config = experiment_config
@@ -69,6 +70,7 @@ class Checkpoint(object):
         self.model_name = model_name
         self.metadata = metadata
         self._master = master
+        self.integrity = integrity
 
     def _find_shared_fs_path(self) -> pathlib.Path:
         """Attempt to find the path of the checkpoint if being configured to shared fs.
@@ -319,4 +321,5 @@ class Checkpoint(object):
             model_version=data.get("model_version"),
             model_name=data.get("model_name"),
             master=master,
+            integrity=data.get("integrity"),
         )
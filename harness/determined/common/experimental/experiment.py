@@ -66,6 +66,7 @@ class ExperimentReference:
                 Determinedexperimentv1State.CANCELED,
                 Determinedexperimentv1State.DELETED,
                 Determinedexperimentv1State.ERROR,
+                Determinedexperimentv1State.TIMEOUT,
             ):
                 break
             elif exp_resp.experiment.state == Determinedexperimentv1State.PAUSED:
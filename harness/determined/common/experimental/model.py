@@ -140,7 +140,9 @@ class Model:
             for version in data["modelVersions"]
         ]
 
-    def register_version(self, checkpoint_uuid: str) -> Checkpoint:
+    def register_version(
+        self, checkpoint_uuid: str, labels: Optional[List[str]] = None
+    ) -> Checkpoint:
         """
         Creates a new model version and returns the
         :class:`~determined.experimental.Checkpoint` corresponding to the
@@ -148,11 +150,12 @@ class Model:
 
         Arguments:
             checkpoint_uuid: The UUID of the checkpoint to register.
+            labels (List[string], optional): The labels of the new version.
         """
         resp = api.post(
             self._master,
             "/api/v1/models/{}/versions".format(self.name),
-            body={"checkpoint_uuid": checkpoint_uuid},
+            body={"checkpoint_uuid": checkpoint_uuid, "labels": labels or []},
         )
 
         data = resp.json()
@@ -166,6 +169,23 @@ class Model:
             self._master,
         )
 
+    def transition_version(self, version: int, stage: str, archive_existing: bool = False) -> None:
+        """
+        Moves a version of the model to another stage: ``none``, ``staging``,
+        ``production`` or ``archived``.
+
+        Arguments:
+            version (int): The version number.
+            stage (string): The stage to move the version to.
+            archive_existing (bool, optional): Whether to archive the other versions in the
+                stage when moving the version to staging or production.
+        """
+        api.post(
+            self._master,
+            "/api/v1/models/{}/versions/{}/transition".format(self.name, version),
+            body={"stage": "STAGE_" + stage.upper(), "archive_existing": archive_existing},
+        )
+
     def add_metadata(self, metadata: Dict[str, Any]) -> None:
         """
         Adds user-defined metadata to the model. The ``metadata`` argument must be a
@@ -3,6 +3,103 @@
 import json
 
 schemas = {
+    "http://determined.ai/schemas/expconf/v0/alert-rule.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/alert-rule.json",
+    "title": "AlertRule",
+    "additionalProperties": false,
+    "required": [
+        "metric"
+    ],
+    "type": "object",
+    "properties": {
+        "name": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "metric": {
+            "type": "string"
+        },
+        "above": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "below": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "for_reports": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": 1
+        },
+        "action": {
+            "enum": [
+                null,
+                "notify",
+                "pause"
+            ],
+            "default": "notify"
+        }
+    },
+    "checks": {
+        "alert rules must set above or below": {
+            "anyOf": [
+                {
+                    "required": [
+                        "above"
+                    ],
+                    "properties": {
+                        "above": {
+                            "type": "number"
+                        }
+                    }
+                },
+                {
+                    "required": [
+                        "below"
+                    ],
+                    "properties": {
+                        "below": {
+                            "type": "number"
+                        }
+                    }
+                }
+            ]
+        }
+    }
+}
+
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/alerts.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/alerts.json",
+    "title": "AlertsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/alert-rule.json"
+    }
+}
+
+"""
+    ),
     "http://determined.ai/schemas/expconf/v0/bind-mount.json": json.loads(
         r"""
 {
@@ -41,6 +138,13 @@ schemas = {
             ],
             "default": false
         },
+        "platform": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": ""
+        },
         "propagation": {
             "type": [
                 "string",
@@ -618,6 +722,44 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/dataset.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/dataset.json",
+    "title": "Dataset",
+    "additionalProperties": false,
+    "required": [
+        "name",
+        "version"
+    ],
+    "type": "object",
+    "properties": {
+        "name": {
+            "type": "string"
+        },
+        "version": {
+            "type": "string"
+        }
+    }
+}
+
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/datasets.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/datasets.json",
+    "title": "DatasetsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/dataset.json"
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/device.json": json.loads(
@@ -663,6 +805,149 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json",
+    "title": "EarlyStoppingRule",
+    "additionalProperties": false,
+    "required": [
+        "type"
+    ],
+    "type": "object",
+    "properties": {
+        "type": {
+            "enum": [
+                "nan",
+                "threshold",
+                "plateau"
+            ]
+        },
+        "metric": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "min": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "max": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "patience": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": 5
+        },
+        "min_delta": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "minimum": 0,
+            "default": 0
+        },
+        "smaller_is_better": {
+            "type": [
+                "boolean",
+                "null"
+            ],
+            "default": true
+        }
+    },
+    "checks": {
+        "threshold and plateau rules must set a metric": {
+            "conditional": {
+                "when": {
+                    "properties": {
+                        "type": {
+                            "enum": [
+                                "threshold",
+                                "plateau"
+                            ]
+                        }
+                    }
+                },
+                "enforce": {
+                    "required": [
+                        "metric"
+                    ],
+                    "properties": {
+                        "metric": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "threshold rules must set a min or a max": {
+            "conditional": {
+                "when": {
+                    "properties": {
+                        "type": {
+                            "const": "threshold"
+                        }
+                    }
+                },
+                "enforce": {
+                    "anyOf": [
+                        {
+                            "required": [
+                                "min"
+                            ],
+                            "properties": {
+                                "min": {
+                                    "type": "number"
+                                }
+                            }
+                        },
+                        {
+                            "required": [
+                                "max"
+                            ],
+                            "properties": {
+                                "max": {
+                                    "type": "number"
+                                }
+                            }
+                        }
+                    ]
+                }
+            }
+        }
+    }
+}
+
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/early-stopping.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/early-stopping.json",
+    "title": "EarlyStoppingConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json"
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/environment-image-map.json": json.loads(
@@ -857,6 +1142,14 @@ schemas = {
                 "type": "string"
             }
         },
+        "kubernetes": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": {},
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/kubernetes.json"
+        },
         "pod_spec": {
             "type": [
                 "object",
@@ -927,6 +1220,14 @@ schemas = {
         "searcher"
     ],
     "properties": {
+        "alerts": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/alerts.json"
+        },
         "bind_mounts": {
             "type": [
                 "array",
@@ -969,6 +1270,14 @@ schemas = {
             },
             "optionalRef": "http://determined.ai/schemas/expconf/v0/data-layer.json"
         },
+        "datasets": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/datasets.json"
+        },
         "debug": {
             "type": [
                 "boolean",
@@ -983,6 +1292,14 @@ schemas = {
             ],
             "default": null
         },
+        "early_stopping": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/early-stopping.json"
+        },
         "entrypoint": {
             "type": [
                 "string",
@@ -1029,6 +1346,14 @@ schemas = {
                 "type": "string"
             }
         },
+        "log_retention": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": {},
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/log-retention.json"
+        },
         "max_restarts": {
             "type": [
                 "integer",
@@ -1037,6 +1362,14 @@ schemas = {
             "minimum": 0,
             "default": 5
         },
+        "max_runtime": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": null
+        },
         "min_checkpoint_period": {
             "type": [
                 "object",
@@ -1111,6 +1444,14 @@ schemas = {
             "minimum": 1,
             "default": 100
         },
+        "scheduling_windows": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/scheduling-windows.json"
+        },
         "searcher": {
             "type": [
                 "object",
@@ -1127,6 +1468,22 @@ schemas = {
             "default": null,
             "optionalRef": "http://determined.ai/schemas/expconf/v0/security.json"
         },
+        "stop_grace_period": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        },
+        "stop_signal": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "pattern": "^SIG(HUP|INT|QUIT|TERM|USR1|USR2)$",
+            "default": null
+        },
         "tensorboard_storage": {
             "type": [
                 "object",
@@ -1644,6 +2001,45 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/kubernetes.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/kubernetes.json",
+    "title": "KubernetesConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [],
+    "properties": {
+        "namespace": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "service_account_name": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "tolerations": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "items": {
+                "type": "object"
+            }
+        }
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/length.json": json.loads(
@@ -1701,6 +2097,37 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/log-retention.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/log-retention.json",
+    "title": "LogRetentionConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [],
+    "properties": {
+        "days": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        },
+        "max_lines_per_trial": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        }
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/native.json": json.loads(
@@ -1991,6 +2418,14 @@ schemas = {
             ],
             "default": ""
         },
+        "cpu_limit": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "exclusiveMinimum": 0,
+            "default": null
+        },
         "devices": {
             "type": [
                 "array",
@@ -1999,6 +2434,13 @@ schemas = {
             "default": [],
             "optionalRef": "http://determined.ai/schemas/expconf/v0/devices.json"
         },
+        "gpu_type": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": ""
+        },
         "max_slots": {
             "type": [
                 "integer",
@@ -2006,6 +2448,22 @@ schemas = {
             ],
             "default": null
         },
+        "memory_limit": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 6291456,
+            "default": null
+        },
+        "min_slots_per_trial": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": null
+        },
         "native_parallel": {
             "type": [
                 "boolean",
@@ -2013,6 +2471,18 @@ schemas = {
             ],
             "default": false
         },
+        "platform": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "checks": {
+                "platform must be of the form os/arch, like linux/arm64": {
+                    "pattern": "^([a-z0-9_]+/[a-z0-9_]+)?$"
+                }
+            },
+            "default": ""
+        },
         "priority": {
             "type": [
                 "integer",
@@ -2058,6 +2528,15 @@ schemas = {
             ],
             "default": 1
         }
+    },
+    "checks": {
+        "min_slots_per_trial must be less than or equal to slots_per_trial": {
+            "compareProperties": {
+                "type": "a<=b",
+                "a": "min_slots_per_trial",
+                "b": "slots_per_trial"
+            }
+        }
     }
 }
 
@@ -2136,6 +2615,79 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/scheduling-window.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/scheduling-window.json",
+    "title": "SchedulingWindow",
+    "additionalProperties": false,
+    "required": [
+        "start",
+        "end"
+    ],
+    "type": "object",
+    "properties": {
+        "days": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "items": {
+                "enum": [
+                    "monday",
+                    "tuesday",
+                    "wednesday",
+                    "thursday",
+                    "friday",
+                    "saturday",
+                    "sunday"
+                ]
+            }
+        },
+        "start": {
+            "type": "string",
+            "checks": {
+                "start must be a time of the form HH:MM": {
+                    "pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+                }
+            }
+        },
+        "end": {
+            "type": "string",
+            "checks": {
+                "end must be a time of the form HH:MM": {
+                    "pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+                }
+            }
+        },
+        "time_zone": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": "UTC"
+        }
+    }
+}
+
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/scheduling-windows.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/scheduling-windows.json",
+    "title": "SchedulingWindowsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/scheduling-window.json"
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/searcher-adaptive-asha.json": json.loads(
@@ -2356,6 +2908,66 @@ schemas = {
     }
 }
 
+"""
+    ),
+    "http://determined.ai/schemas/expconf/v0/searcher-custom.json": json.loads(
+        r"""
+{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/searcher-custom.json",
+    "title": "CustomConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [
+        "name"
+    ],
+    "eventuallyRequired": [
+        "metric"
+    ],
+    "properties": {
+        "name": {
+            "const": "custom"
+        },
+        "unit": {
+            "enum": [
+                null,
+                "records",
+                "batches",
+                "epochs"
+            ],
+            "default": "batches"
+        },
+        "metric": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "smaller_is_better": {
+            "type": [
+                "boolean",
+                "null"
+            ],
+            "default": true
+        },
+        "source_trial_id": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "default": null
+        },
+        "source_checkpoint_uuid": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        }
+    }
+}
+
 """
     ),
     "http://determined.ai/schemas/expconf/v0/searcher-grid.json": json.loads(
@@ -2724,7 +3336,7 @@ schemas = {
         },
         "enforce": {
             "union": {
-                "defaultMessage": "is not an object where object[\"name\"] is one of 'single', 'random', 'grid', 'adaptive_asha', or 'pbt'",
+                "defaultMessage": "is not an object where object[\"name\"] is one of 'single', 'random', 'grid', 'adaptive_asha', 'pbt', or 'custom'",
                 "items": [
                     {
                         "unionKey": "const:name=single",
@@ -2749,6 +3361,10 @@ schemas = {
                     {
                         "unionKey": "const:name=async_halving",
                         "$ref": "http://determined.ai/schemas/expconf/v0/searcher-async-halving.json"
+                    },
+                    {
+                        "unionKey": "const:name=custom",
+                        "$ref": "http://determined.ai/schemas/expconf/v0/searcher-custom.json"
                     }
                 ]
             }
@@ -2775,6 +3391,7 @@ schemas = {
         "population_size": true,
         "replace_function": true,
         "stop_once": true,
+        "unit": true,
         "metric": {
             "type": [
                 "string",
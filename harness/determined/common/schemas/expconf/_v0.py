@@ -24,9 +24,14 @@ class DeviceV0(schemas.SchemaBase):
 class ResourcesConfigV0(schemas.SchemaBase):
     _id = "http://determined.ai/schemas/expconf/v0/resources.json"
     agent_label: Optional[str] = None
+    cpu_limit: Optional[float] = None
     devices: Optional[List[DeviceV0]] = None
+    gpu_type: Optional[str] = None
     max_slots: Optional[int] = None
+    memory_limit: Optional[int] = None
+    min_slots_per_trial: Optional[int] = None
     native_parallel: Optional[bool] = None
+    platform: Optional[str] = None
     priority: Optional[int] = None
     resource_pool: Optional[str] = None
     shm_size: Optional[int] = None
@@ -37,9 +42,14 @@ class ResourcesConfigV0(schemas.SchemaBase):
     def __init__(
         self,
         agent_label: Optional[str] = None,
+        cpu_limit: Optional[float] = None,
         devices: Optional[List[DeviceV0]] = None,
+        gpu_type: Optional[str] = None,
         max_slots: Optional[int] = None,
+        memory_limit: Optional[int] = None,
+        min_slots_per_trial: Optional[int] = None,
         native_parallel: Optional[bool] = None,
+        platform: Optional[str] = None,
         priority: Optional[int] = None,
         resource_pool: Optional[str] = None,
         shm_size: Optional[int] = None,
@@ -81,6 +91,7 @@ class BindMountV0(schemas.SchemaBase):
     _id = "http://determined.ai/schemas/expconf/v0/bind-mount.json"
     container_path: str
     host_path: str
+    platform: Optional[str] = None
     propagation: Optional[str] = None
     read_only: Optional[bool] = None
 
@@ -89,12 +100,91 @@ class BindMountV0(schemas.SchemaBase):
         self,
         container_path: str,
         host_path: str,
+        platform: Optional[str] = None,
         propagation: Optional[str] = None,
         read_only: Optional[bool] = None,
     ) -> None:
         pass
 
 
+class DatasetV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/dataset.json"
+    name: str
+    version: str
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        name: str,
+        version: str,
+    ) -> None:
+        pass
+
+
+class AlertRuleV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/alert-rule.json"
+    metric: str
+    above: Optional[float] = None
+    action: Optional[str] = None
+    below: Optional[float] = None
+    for_reports: Optional[int] = None
+    name: Optional[str] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        metric: str,
+        above: Optional[float] = None,
+        action: Optional[str] = None,
+        below: Optional[float] = None,
+        for_reports: Optional[int] = None,
+        name: Optional[str] = None,
+    ) -> None:
+        pass
+
+
+class EarlyStoppingRuleV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json"
+    type: str
+    max: Optional[float] = None
+    metric: Optional[str] = None
+    min: Optional[float] = None
+    min_delta: Optional[float] = None
+    patience: Optional[int] = None
+    smaller_is_better: Optional[bool] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        type: str,
+        max: Optional[float] = None,
+        metric: Optional[str] = None,
+        min: Optional[float] = None,
+        min_delta: Optional[float] = None,
+        patience: Optional[int] = None,
+        smaller_is_better: Optional[bool] = None,
+    ) -> None:
+        pass
+
+
+class SchedulingWindowV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/scheduling-window.json"
+    end: str
+    start: str
+    days: Optional[List[str]] = None
+    time_zone: Optional[str] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        end: str,
+        start: str,
+        days: Optional[List[str]] = None,
+        time_zone: Optional[str] = None,
+    ) -> None:
+        pass
+
+
 class ReproducibilityConfigV0(schemas.SchemaBase):
     _id = "http://determined.ai/schemas/expconf/v0/reproducibility.json"
     experiment_seed: Optional[int] = None
@@ -127,6 +217,20 @@ class ProfilingConfigV0(schemas.SchemaBase):
         pass
 
 
+class LogRetentionConfigV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/log-retention.json"
+    days: Optional[int] = None
+    max_lines_per_trial: Optional[int] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        days: Optional[int] = None,
+        max_lines_per_trial: Optional[int] = None,
+    ) -> None:
+        pass
+
+
 class LengthV0(schemas.SchemaBase):
     _id = "http://determined.ai/schemas/expconf/v0/length.json"
     batches: Optional[int] = None
@@ -297,6 +401,22 @@ class RegistryAuthConfigV0(schemas.SchemaBase):
         return super().to_dict(explicit_nones=False)
 
 
+class KubernetesConfigV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/kubernetes.json"
+    namespace: Optional[str] = None
+    service_account_name: Optional[str] = None
+    tolerations: Optional[List[Dict[str, Any]]] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        namespace: Optional[str] = None,
+        service_account_name: Optional[str] = None,
+        tolerations: Optional[List[Dict[str, Any]]] = None,
+    ) -> None:
+        pass
+
+
 class EnvironmentConfigV0(schemas.SchemaBase):
     _id = "http://determined.ai/schemas/expconf/v0/environment.json"
     add_capabilities: Optional[List[str]] = None
@@ -304,6 +424,7 @@ class EnvironmentConfigV0(schemas.SchemaBase):
     environment_variables: Optional[EnvironmentVariablesV0] = None
     force_pull_image: Optional[bool] = None
     image: Optional[EnvironmentImageV0] = None
+    kubernetes: Optional[KubernetesConfigV0] = None
     pod_spec: Optional[Dict[str, Any]] = None
     ports: Optional[Dict[str, int]] = None
     registry_auth: Optional[RegistryAuthConfigV0] = None
@@ -316,6 +437,7 @@ class EnvironmentConfigV0(schemas.SchemaBase):
         environment_variables: Optional[EnvironmentVariablesV0] = None,
         force_pull_image: Optional[bool] = None,
         image: Optional[EnvironmentImageV0] = None,
+        kubernetes: Optional[KubernetesConfigV0] = None,
         pod_spec: Optional[Dict[str, Any]] = None,
         ports: Optional[Dict[str, int]] = None,
         registry_auth: Optional[RegistryAuthConfigV0] = None,
@@ -627,6 +749,27 @@ class PBTConfigV0(schemas.SchemaBase):
         pass
 
 
+@SearcherConfigV0.member("custom")
+class CustomConfigV0(schemas.SchemaBase):
+    _id = "http://determined.ai/schemas/expconf/v0/searcher-custom.json"
+    metric: str
+    unit: Optional[str] = None
+    smaller_is_better: Optional[bool] = None
+    source_checkpoint_uuid: Optional[str] = None
+    source_trial_id: Optional[int] = None
+
+    @schemas.auto_init
+    def __init__(
+        self,
+        metric: str,
+        unit: Optional[str] = None,
+        smaller_is_better: Optional[bool] = None,
+        source_checkpoint_uuid: Optional[str] = None,
+        source_trial_id: Optional[int] = None,
+    ) -> None:
+        pass
+
+
 SearcherConfigV0_Type = Union[
     SingleConfigV0,
     RandomConfigV0,
@@ -634,6 +777,7 @@ SearcherConfigV0_Type = Union[
     AsyncHalvingConfigV0,
     AdaptiveASHAConfigV0,
     PBTConfigV0,
+    CustomConfigV0,
 ]
 SearcherConfigV0.finalize(SearcherConfigV0_Type)
 
@@ -756,18 +900,23 @@ class ExperimentConfigV0(schemas.SchemaBase):
     searcher: SearcherConfigV0
 
     # Fields which can be omitted or defined at the cluster level.
+    alerts: Optional[List[AlertRuleV0]] = None
     bind_mounts: Optional[List[BindMountV0]] = None
     checkpoint_policy: Optional[str] = None
     checkpoint_storage: Optional[CheckpointStorageConfigV0_Type] = None
     data_layer: Optional[DataLayerConfigV0_Type] = None
     data: Optional[Dict[str, Any]] = None
+    datasets: Optional[List[DatasetV0]] = None
     debug: Optional[bool] = None
     description: Optional[str] = None
+    early_stopping: Optional[List[EarlyStoppingRuleV0]] = None
     entrypoint: Optional[str] = None
     environment: Optional[EnvironmentConfigV0] = None
     # internal: Optional[InternalConfigV0] = None
     labels: Optional[str] = None
+    log_retention: Optional[LogRetentionConfigV0] = None
     max_restarts: Optional[int] = None
+    max_runtime: Optional[int] = None
     min_checkpoint_period: Optional[LengthV0] = None
     min_validation_period: Optional[LengthV0] = None
     optimizations: Optional[OptimizationsConfigV0] = None
@@ -777,7 +926,10 @@ class ExperimentConfigV0(schemas.SchemaBase):
     reproducibility: Optional[ReproducibilityConfigV0] = None
     resources: Optional[ResourcesConfigV0] = None
     scheduling_unit: Optional[int] = None
+    scheduling_windows: Optional[List[SchedulingWindowV0]] = None
     # security: Optional[SecurityConfigV0] = None
+    stop_grace_period: Optional[int] = None
+    stop_signal: Optional[str] = None
     # tensorboard_storage: Optional[TensorboardStorageConfigV0_Type] = None
 
     @schemas.auto_init
@@ -785,18 +937,23 @@ class ExperimentConfigV0(schemas.SchemaBase):
         self,
         hyperparameters: Dict[str, HyperparameterV0_Type],
         searcher: SearcherConfigV0,
+        alerts: Optional[List[AlertRuleV0]] = None,
         bind_mounts: Optional[List[BindMountV0]] = None,
         checkpoint_policy: Optional[str] = None,
         checkpoint_storage: Optional[CheckpointStorageConfigV0_Type] = None,
         data_layer: Optional[DataLayerConfigV0_Type] = None,
         data: Optional[Dict[str, Any]] = None,
+        datasets: Optional[List[DatasetV0]] = None,
         debug: Optional[bool] = None,
         description: Optional[str] = None,
+        early_stopping: Optional[List[EarlyStoppingRuleV0]] = None,
         entrypoint: Optional[str] = None,
         environment: Optional[EnvironmentConfigV0] = None,
         # internal: Optional[InternalConfigV0] = None,
         labels: Optional[str] = None,
+        log_retention: Optional[LogRetentionConfigV0] = None,
         max_restarts: Optional[int] = None,
+        max_runtime: Optional[int] = None,
         min_checkpoint_period: Optional[LengthV0] = None,
         min_validation_period: Optional[LengthV0] = None,
         optimizations: Optional[OptimizationsConfigV0] = None,
@@ -806,7 +963,10 @@ class ExperimentConfigV0(schemas.SchemaBase):
         reproducibility: Optional[ReproducibilityConfigV0] = None,
         resources: Optional[ResourcesConfigV0] = None,
         scheduling_unit: Optional[int] = None,
+        scheduling_windows: Optional[List[SchedulingWindowV0]] = None,
         # security: Optional[SecurityConfigV0] = None,
+        stop_grace_period: Optional[int] = None,
+        stop_signal: Optional[str] = None,
         # tensorboard_storage: Optional[TensorboardStorageConfigV0_Type] = None,
     ) -> None:
         pass
@@ -1,5 +1,6 @@
 import abc
 import contextlib
+import hashlib
 import os
 import shutil
 import uuid
@@ -15,17 +16,20 @@ class StorageMetadata:
         resources: Dict[str, int],
         framework: Optional[str] = None,
         format: Optional[str] = None,
+        digests: Optional[Dict[str, str]] = None,
     ) -> None:
         check_gt(len(storage_id), 0, "Invalid storage ID")
         self.storage_id = storage_id
         self.resources = resources
         self.framework = framework
         self.format = format
+        self.digests = digests
 
     def __json__(self) -> Dict[str, Any]:
         return {
             "uuid": self.storage_id,
             "resources": self.resources,
+            "digests": self.digests,
             "framework": self.framework,
             "format": self.format,
         }
@@ -45,7 +49,11 @@ class StorageMetadata:
         check_not_none(record["uuid"], "Storage ID is undefined")
         check_not_none(record["resources"], "Resources are undefined")
         return StorageMetadata(
-            record["uuid"], record["resources"], record.get("framework"), record.get("format")
+            record["uuid"],
+            record["resources"],
+            record.get("framework"),
+            record.get("format"),
+            record.get("digests"),
         )
 
 
@@ -105,7 +113,11 @@ class StorageManager:
         yield (storage_id, storage_dir)
         check_true(os.path.exists(storage_dir), "Checkpoint did not create a storage directory")
 
-        metadata = StorageMetadata(storage_id, StorageManager._list_directory(storage_dir))
+        metadata = StorageMetadata(
+            storage_id,
+            StorageManager._list_directory(storage_dir),
+            digests=StorageManager._digest_directory(storage_dir),
+        )
         self.post_store_path(storage_id, storage_dir, metadata)
 
     @abc.abstractmethod
@@ -165,3 +177,32 @@ class StorageManager:
                 result[rel_path] = os.path.getsize(abs_path)
 
         return result
+
+    @staticmethod
+    def _digest_directory(root: str) -> Dict[str, str]:
+        """
+        Returns a dict mapping path names to the hex SHA-256 digests of all
+        files in the directory `root`. Returned path names are relative to
+        `root`, like those of `_list_directory`; directories are omitted.
+        """
+        check_true(os.path.isdir(root), "{} must be an extant directory".format(root))
+        result = {}
+        for cur_path, _, files in os.walk(root):
+            for f in files:
+                abs_path = os.path.join(cur_path, f)
+                rel_path = os.path.relpath(abs_path, root)
+                result[rel_path] = StorageManager._digest_file(abs_path)
+
+        return result
+
+    @staticmethod
+    def _digest_file(path: str) -> str:
+        """
+        Returns the hex SHA-256 digest of the file at `path`, reading it in chunks so that large
+        files are not loaded into memory at once.
+        """
+        digest = hashlib.sha256()
+        with open(path, "rb") as f:
+            for chunk in iter(lambda: f.read(1024 * 1024), b""):
+                digest.update(chunk)
+        return digest.hexdigest()
@@ -0,0 +1,147 @@
+"""
+The entrypoint for the batch inference job container.
+"""
+import argparse
+import importlib
+import json
+import logging
+import os
+import sys
+from typing import Any, Callable, Dict, List, Optional
+
+import determined as det
+from determined.common import api, constants, storage, util
+
+Predictor = Callable[[str, str, str], Optional[int]]
+
+
+def load_predictor(predictor: str) -> Predictor:
+    """
+    Import the "module:function" that makes the predictions from the model definition, which is
+    in the working directory of the container.
+    """
+    module_name, function_name = predictor.split(":")
+    sys.path.insert(0, os.getcwd())
+    module = importlib.import_module(module_name)
+    fn = getattr(module, function_name, None)
+    if not callable(fn):
+        raise ValueError("{} is not a function of module {}".format(function_name, module_name))
+    return fn  # type: ignore
+
+
+def list_inputs(input_path: str) -> List[str]:
+    """
+    Return the paths of the input files relative to the input path, which is either a file or a
+    directory whose files are all read.
+    """
+    if os.path.isfile(input_path):
+        return [os.path.basename(input_path)]
+    if not os.path.isdir(input_path):
+        raise FileNotFoundError("input path {} does not exist".format(input_path))
+    inputs = []
+    for root, _, files in os.walk(input_path):
+        for name in files:
+            inputs.append(os.path.relpath(os.path.join(root, name), input_path))
+    return sorted(inputs)
+
+
+def report_progress(task_id: str, progress: float, records_processed: int) -> None:
+    task_token = api.Authentication.instance().get_task_token()
+    api.post(
+        util.get_default_master_address(),
+        "/api/v1/batch-inference-jobs/{}/progress".format(task_id),
+        body={"progress": progress, "records_processed": records_processed},
+        headers={"Grpc-Metadata-x-task-token": "Bearer {}".format(task_token)},
+        authenticated=False,
+    )
+
+
+def run_batch_inference(manager: storage.StorageManager, spec: Dict[str, Any]) -> None:
+    """
+    Call the predictor with the path of the checkpoint and those of each input file and its output
+    file, then write the lineage of the predictions under the output path.
+    """
+    lineage = spec["lineage"]
+    task_id = lineage["task_id"]
+    predict = load_predictor(spec["predictor"])
+
+    input_path, output_path = spec["input_path"], spec["output_path"]
+    inputs = list_inputs(input_path)
+    single_file = os.path.isfile(input_path)
+    logging.info("Making predictions for {} input files".format(len(inputs)))
+    os.makedirs(output_path, exist_ok=True)
+
+    records_processed = 0
+    metadata = storage.StorageMetadata.from_json(spec["checkpoint"])
+    with manager.restore_path(metadata) as checkpoint_path:
+        for i, rel_path in enumerate(inputs):
+            input_file = input_path if single_file else os.path.join(input_path, rel_path)
+            output_file = os.path.join(output_path, rel_path)
+            os.makedirs(os.path.dirname(output_file), exist_ok=True)
+
+            records = predict(checkpoint_path, input_file, output_file)
+            records_processed += records or 0
+            logging.info("Made predictions for {} ({}/{})".format(rel_path, i + 1, len(inputs)))
+            report_progress(task_id, 100 * (i + 1) / len(inputs), records_processed)
+
+    if not inputs:
+        report_progress(task_id, 100, 0)
+
+    lineage["records_processed"] = records_processed
+    with open(os.path.join(output_path, "lineage.json"), "w") as f:
+        json.dump(lineage, f, indent=2)
+    logging.info(
+        "Wrote the predictions for {} records to {}".format(records_processed, output_path)
+    )
+
+
+def json_file_arg(val: str) -> Any:
+    with open(val) as f:
+        return json.load(f)
+
+
+def main(argv: List[str]) -> None:
+    parser = argparse.ArgumentParser(description="Determined batch inference")
+
+    parser.add_argument(
+        "--version",
+        action="version",
+        version="Determined batch inference, version {}".format(det.__version__),
+    )
+    parser.add_argument(
+        "--log-level",
+        default=os.getenv("DET_LOG_LEVEL", "INFO"),
+        choices=["DEBUG", "INFO", "WARNING", "ERROR"],
+        help="Set the logging level",
+    )
+    parser.add_argument(
+        "--experiment-config",
+        type=json_file_arg,
+        default=os.getenv("DET_EXPERIMENT_CONFIG", {}),
+        help="Experiment config (JSON-formatted file)",
+    )
+    parser.add_argument(
+        "--spec",
+        type=json_file_arg,
+        default=os.getenv("DET_BATCH_INFERENCE", {}),
+        help="Batch inference job (JSON-formatted file)",
+    )
+
+    args = parser.parse_args(argv)
+
+    logging.basicConfig(
+        level=args.log_level, format="%(asctime)s:%(module)s:%(levelname)s: %(message)s"
+    )
+
+    logging.info("Determined batch inference, version {}".format(det.__version__))
+
+    storage_config = args.experiment_config["checkpoint_storage"]
+    logging.info("Using checkpoint storage: {}".format(storage_config))
+
+    manager = storage.build(storage_config, container_path=constants.SHARED_FS_CONTAINER_PATH)
+
+    run_batch_inference(manager, args.spec)
+
+
+if __name__ == "__main__":
+    main(sys.argv[1:])
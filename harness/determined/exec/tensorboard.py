@@ -63,11 +63,16 @@ def wait_for_tensorboard(max_seconds: float, url: str, still_alive_fn: Callable[
 
 
 def main(args: List[str]) -> int:
-    with open("/run/determined/workdir/experiment_config.json") as f:
-        exp_conf = json.load(f)
-
-    if exp_conf["checkpoint_storage"]["type"] == "s3":
-        set_s3_region(exp_conf["checkpoint_storage"]["bucket"])
+    exp_conf_path = "/run/determined/workdir/experiment_config.json"
+    if os.path.exists(exp_conf_path):
+        with open(exp_conf_path) as f:
+            exp_conf = json.load(f)
+
+        if exp_conf["checkpoint_storage"]["type"] == "s3":
+            set_s3_region(exp_conf["checkpoint_storage"]["bucket"])
+    elif "AWS_BUCKET" in os.environ:
+        # TensorBoards of only log paths have no experiment config.
+        set_s3_region(os.environ["AWS_BUCKET"])
 
     task_id = os.environ["DET_TASK_ID"]
     port = os.environ["TENSORBOARD_PORT"]
@@ -0,0 +1,134 @@
+"""
+The entrypoint for the checkpoint verification job container.
+"""
+import argparse
+import json
+import logging
+import os
+import sys
+from typing import Any, Dict, List, Tuple
+
+import determined as det
+from determined.common import api, constants, storage, util
+
+
+def verify_checkpoint(
+    manager: storage.StorageManager, record: Dict[str, Any]
+) -> Tuple[str, List[str]]:
+    """
+    Verify the files of a checkpoint against the sizes and digests recorded when it was saved.
+    `record` is a dict with the "uuid", "resources" and "digests" of the checkpoint. Returns the
+    integrity of the checkpoint, i.e. "VERIFIED", "CORRUPTED" or "MISSING", and the problems found.
+    """
+    metadata = storage.StorageMetadata.from_json(record)
+    digests = metadata.digests or {}
+    files = [p for p in metadata.resources if not p.endswith("/")]
+
+    problems = []
+    found = 0
+    try:
+        with manager.restore_path(metadata) as path:
+            for rel_path in files:
+                abs_path = os.path.join(path, rel_path)
+                if not os.path.isfile(abs_path):
+                    problems.append("{}: missing".format(rel_path))
+                    continue
+                found += 1
+                size = os.path.getsize(abs_path)
+                if size != metadata.resources[rel_path]:
+                    problems.append(
+                        "{}: expected {} bytes, found {}".format(
+                            rel_path, metadata.resources[rel_path], size
+                        )
+                    )
+                elif rel_path in digests:
+                    if storage.StorageManager._digest_file(abs_path) != digests[rel_path]:
+                        problems.append("{}: digest mismatch".format(rel_path))
+    except Exception as e:
+        logging.warning("Cannot restore checkpoint {}: {}".format(metadata.storage_id, e))
+        return "MISSING", ["cannot restore checkpoint: {}".format(e)]
+
+    if files and found == 0:
+        return "MISSING", problems
+    if problems:
+        return "CORRUPTED", problems
+    return "VERIFIED", problems
+
+
+def report_integrity(uuid: str, integrity: str, problems: List[str]) -> None:
+    task_token = api.Authentication.instance().get_task_token()
+    api.post(
+        util.get_default_master_address(),
+        "/api/v1/checkpoints/{}/integrity".format(uuid),
+        body={"integrity": "INTEGRITY_" + integrity, "problems": problems},
+        headers={"Grpc-Metadata-x-task-token": "Bearer {}".format(task_token)},
+        authenticated=False,
+    )
+
+
+def verify_checkpoints(manager: storage.StorageManager, to_verify: List[Dict[str, Any]]) -> None:
+    """
+    Verify the checkpoints associated with a single experiment and report what was found for each
+    of them to the master.
+    """
+    logging.info("Verifying {} checkpoints".format(len(to_verify)))
+
+    for record in to_verify:
+        integrity, problems = verify_checkpoint(manager, record)
+        logging.info("Checkpoint {} is {}".format(record["uuid"], integrity.lower()))
+        for problem in problems:
+            logging.info("  {}".format(problem))
+        report_integrity(record["uuid"], integrity, problems)
+
+
+def json_file_arg(val: str) -> Any:
+    with open(val) as f:
+        return json.load(f)
+
+
+def main(argv: List[str]) -> None:
+    parser = argparse.ArgumentParser(description="Determined checkpoint verification")
+
+    parser.add_argument(
+        "--version",
+        action="version",
+        version="Determined checkpoint verification, version {}".format(det.__version__),
+    )
+    parser.add_argument("--experiment-id", help="The experiment ID to verify checkpoints of")
+    parser.add_argument(
+        "--log-level",
+        default=os.getenv("DET_LOG_LEVEL", "INFO"),
+        choices=["DEBUG", "INFO", "WARNING", "ERROR"],
+        help="Set the logging level",
+    )
+    parser.add_argument(
+        "--experiment-config",
+        type=json_file_arg,
+        default=os.getenv("DET_EXPERIMENT_CONFIG", {}),
+        help="Experiment config (JSON-formatted file)",
+    )
+    parser.add_argument(
+        "--verify",
+        type=json_file_arg,
+        default=os.getenv("DET_VERIFY", []),
+        help="Checkpoints to verify (JSON-formatted file)",
+    )
+
+    args = parser.parse_args(argv)
+
+    logging.basicConfig(
+        level=args.log_level, format="%(asctime)s:%(module)s:%(levelname)s: %(message)s"
+    )
+
+    logging.info("Determined checkpoint verification, version {}".format(det.__version__))
+
+    storage_config = args.experiment_config["checkpoint_storage"]
+    logging.info("Using checkpoint storage: {}".format(storage_config))
+
+    manager = storage.build(storage_config, container_path=constants.SHARED_FS_CONTAINER_PATH)
+
+    verify_checkpoints(manager, args.verify["checkpoints"])
+
+
+if __name__ == "__main__":
+    main(sys.argv[1:])
@@ -0,0 +1,198 @@
+"""
+Custom searchers, which drive the hyperparameter search of an experiment from outside of the
+master, such as to search with Optuna or Ax.
+
+An experiment is configured with ``searcher: {name: custom, metric: ...}``, and a process that
+subclasses :class:`SearchMethod` and calls :func:`run` makes its operations::
+
+    class MySearchMethod(searcher.SearchMethod):
+        def initial_operations(self):
+            create = searcher.Create({"lr": 0.1})
+            return [create, searcher.ValidateAfter(create.request_id, 100),
+                    searcher.Close(create.request_id)]
+
+    searcher.run(MySearchMethod(), experiment_id)
+"""
+import logging
+import time
+import uuid
+from typing import Any, Dict, List, Optional, Union
+
+import determined.common.api.authentication as auth
+from determined.common import api, util
+
+
+class Create:
+    """
+    Create a trial with the hyperparameters. Constant hyperparameters of the experiment that are
+    left out take their configured value.
+    """
+
+    def __init__(self, hparams: Dict[str, Any], request_id: Optional[str] = None) -> None:
+        self.hparams = hparams
+        self.request_id = request_id or str(uuid.uuid4())
+
+    def _to_json(self, unit: str) -> Dict[str, Any]:
+        return {"create_trial": {"request_id": self.request_id, "hyperparams": self.hparams}}
+
+
+class ValidateAfter:
+    """
+    Train the trial until it has trained the length in total, in the unit of the searcher, and
+    then validate it.
+    """
+
+    def __init__(self, request_id: str, length: int) -> None:
+        self.request_id = request_id
+        self.length = length
+
+    def _to_json(self, unit: str) -> Dict[str, Any]:
+        return {
+            "validate_after": {
+                "request_id": self.request_id,
+                "length": {"units": "UNITS_" + unit.upper(), "length": self.length},
+            }
+        }
+
+
+class Close:
+    """
+    Close the trial once it has finished its operations.
+    """
+
+    def __init__(self, request_id: str) -> None:
+        self.request_id = request_id
+
+    def _to_json(self, unit: str) -> Dict[str, Any]:
+        return {"close_trial": {"request_id": self.request_id}}
+
+
+class Shutdown:
+    """
+    End the search.
+    """
+
+    def __init__(self, failure: bool = False) -> None:
+        self.failure = failure
+
+    def _to_json(self, unit: str) -> Dict[str, Any]:
+        return {"shutdown": {"failure": self.failure}}
+
+
+Operation = Union[Create, ValidateAfter, Close, Shutdown]
+
+
+class SearchMethod:
+    """
+    The base class of custom search methods, whose methods are called with the events of the
+    search and return the operations to make in answer to them.
+
+    The validations of a trial complete in the order of its ValidateAfter operations.
+    """
+
+    def initial_operations(self) -> List[Operation]:
+        raise NotImplementedError()
+
+    def on_trial_created(self, request_id: str) -> List[Operation]:
+        return []
+
+    def on_validation_completed(self, request_id: str, metric: float) -> List[Operation]:
+        return []
+
+    def on_trial_closed(self, request_id: str) -> List[Operation]:
+        return []
+
+    def on_trial_exited_early(self, request_id: str, exited_reason: str) -> List[Operation]:
+        """
+        Called when a trial exits before it is closed, where the exited reason is one of
+        EXITED_REASON_UNSPECIFIED (the trial errored), EXITED_REASON_INVALID_HP, or
+        EXITED_REASON_USER_REQUESTED_STOP.
+        """
+        return []
+
+    def progress(self) -> float:
+        """
+        Return the progress of the search between 0 and 1.
+        """
+        return 0.0
+
+
+_TERMINAL_STATES = {
+    "STATE_COMPLETED",
+    "STATE_CANCELED",
+    "STATE_ERROR",
+    "STATE_TIMEOUT",
+    "STATE_DELETED",
+}
+
+
+def _handle_event(search_method: SearchMethod, event: Dict[str, Any]) -> List[Operation]:
+    if "initialOperations" in event:
+        return search_method.initial_operations()
+    if "trialCreated" in event:
+        return search_method.on_trial_created(event["trialCreated"]["requestId"])
+    if "validationCompleted" in event:
+        completed = event["validationCompleted"]
+        return search_method.on_validation_completed(
+            completed["requestId"], completed.get("metric", 0.0)
+        )
+    if "trialClosed" in event:
+        return search_method.on_trial_closed(event["trialClosed"]["requestId"])
+    if "trialExitedEarly" in event:
+        exited = event["trialExitedEarly"]
+        return search_method.on_trial_exited_early(
+            exited["requestId"], exited.get("exitedReason", "EXITED_REASON_UNSPECIFIED")
+        )
+    raise ValueError("unknown searcher event: {}".format(event))
+
+
+def run(
+    search_method: SearchMethod,
+    experiment_id: int,
+    master_url: Optional[str] = None,
+    interval: float = 1.0,
+) -> None:
+    """
+    Drive the custom search of the experiment with the search method until the experiment ends,
+    answering the events of the search with the operations of the search method.
+
+    Arguments:
+        search_method: The search method that makes the operations.
+        experiment_id: The ID of the experiment, which must use the custom searcher.
+        master_url: The address of the master, which defaults to that of the environment.
+        interval: The time in seconds between checks for new events.
+    """
+    master_url = master_url or util.get_default_master_address()
+    auth.initialize_session(master_url, try_reauth=True)
+
+    path = "api/v1/experiments/{}".format(experiment_id)
+    config = api.get(master_url, path).json()["config"]
+    unit = config["searcher"].get("unit") or "batches"
+
+    while True:
+        experiment = api.get(master_url, path).json()["experiment"]
+        if experiment["state"] in _TERMINAL_STATES:
+            logging.info("Experiment {} ended".format(experiment_id))
+            return
+        if experiment["state"].startswith("STATE_STOPPING"):
+            time.sleep(interval)
+            continue
+
+        events = api.get(master_url, path + "/searcher_events").json().get("searcherEvents", [])
+        if not events:
+            time.sleep(interval)
+            continue
+
+        operations = []  # type: List[Operation]
+        for event in events:
+            logging.debug("Handling searcher event {}".format(event))
+            operations += _handle_event(search_method, event)
+        api.post(
+            master_url,
+            path + "/searcher_operations",
+            body={
+                "searcher_operations": [op._to_json(unit) for op in operations],
+                "triggered_by_event_id": events[-1]["id"],
+                "progress": search_method.progress(),
+            },
+        )
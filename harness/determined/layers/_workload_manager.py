@@ -314,6 +314,7 @@ class _TrialWorkloadManager(WorkloadManager):
                 storage.StorageManager._list_directory(path),
                 checkpoint_info.get("framework", ""),
                 checkpoint_info.get("format", ""),
+                storage.StorageManager._digest_directory(path),
             )
 
             logging.info("Saved trial to checkpoint {}".format(metadata.storage_id))
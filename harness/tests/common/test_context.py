@@ -0,0 +1,36 @@
+import io
+import tarfile
+from typing import Dict, List
+
+from determined.common import context
+
+
+def make_context(files: Dict[str, str]) -> context.Context:
+    ctx = context.Context()
+    for path, content in files.items():
+        ctx.add_item(context.ContextItem.from_content_str(path, content))
+    return ctx
+
+
+def read_chunks(chunks: List[bytes]) -> Dict[str, str]:
+    files = {}
+    with tarfile.open(fileobj=io.BytesIO(b"".join(chunks)), mode="r:gz") as tar:
+        for member in tar.getmembers():
+            f = tar.extractfile(member)
+            assert f is not None
+            files[member.name] = f.read().decode("utf-8")
+    return files
+
+
+def test_tar_gz_chunks() -> None:
+    files = {"file{:03d}.py".format(i): "print({})\n".format(i) * 50 for i in range(200)}
+    files["large.txt"] = "".join("line {}\n".format(i) for i in range(1000))
+    chunks = make_context(files).tar_gz_chunks(4096)
+    assert len(chunks) > 1
+    assert read_chunks(chunks) == files
+
+    # Changing one file only changes the chunks around it.
+    files["file100.py"] = "print('changed')\n"
+    changed = make_context(files).tar_gz_chunks(4096)
+    assert read_chunks(changed) == files
+    assert len(set(changed) - set(chunks)) <= 2
@@ -0,0 +1,61 @@
+import os
+import shutil
+from pathlib import Path
+from typing import Any, Dict
+
+import pytest
+import simplejson
+
+from determined import util
+from determined.common import storage
+from determined.exec.verify_checkpoints import verify_checkpoint
+from tests.storage import util as storage_util
+
+
+@pytest.fixture()
+def manager(tmp_path: Path) -> storage.StorageManager:
+    return storage.SharedFSStorageManager(str(tmp_path))
+
+
+@pytest.fixture()
+def record(manager: storage.StorageManager) -> Dict[str, Any]:
+    with manager.store_path() as (storage_id, path):
+        storage_util.create_checkpoint(path)
+        metadata = storage.StorageMetadata(
+            storage_id,
+            manager._list_directory(path),
+            digests=manager._digest_directory(path),
+        )
+    return simplejson.loads(util.json_encode(metadata))
+
+
+def test_verify_intact_checkpoint(manager: storage.StorageManager, record: Dict[str, Any]) -> None:
+    assert verify_checkpoint(manager, record) == ("VERIFIED", [])
+
+
+def test_verify_checkpoint_without_digests(
+    manager: storage.StorageManager, record: Dict[str, Any]
+) -> None:
+    record["digests"] = None
+    assert verify_checkpoint(manager, record) == ("VERIFIED", [])
+
+
+def test_verify_corrupted_checkpoint(
+    manager: storage.StorageManager, record: Dict[str, Any]
+) -> None:
+    path = os.path.join(manager._base_path, record["uuid"])
+    # Same size, different content: only the digest tells.
+    with open(os.path.join(path, "root.txt"), "w") as f:
+        f.write("root File")
+    os.remove(os.path.join(path, "subdir", "file.txt"))
+
+    integrity, problems = verify_checkpoint(manager, record)
+    assert integrity == "CORRUPTED"
+    assert sorted(problems) == ["root.txt: digest mismatch", "subdir/file.txt: missing"]
+
+
+def test_verify_missing_checkpoint(manager: storage.StorageManager, record: Dict[str, Any]) -> None:
+    shutil.rmtree(os.path.join(manager._base_path, record["uuid"]))
+
+    integrity, _ = verify_checkpoint(manager, record)
+    assert integrity == "MISSING"
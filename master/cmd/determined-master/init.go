@@ -32,6 +32,13 @@ func init() {
 	registerConfig()
 }
 
+// registeredKeys are the keys of the settings that environment variables or command line flags
+// can set, and configFlags are the flags.
+var (
+	registeredKeys []configKey
+	configFlags    *pflag.FlagSet
+)
+
 type configKey []string
 
 func (c configKey) EnvName() string {
@@ -46,11 +53,18 @@ func (c configKey) FlagName() string {
 	return strings.Join(c, "-")
 }
 
+// SettingPath returns the dotted path of the setting, e.g. "db.ssl_mode".
+func (c configKey) SettingPath() string {
+	return strings.ReplaceAll(strings.Join(c, "."), "-", "_")
+}
+
 func registerEnv(name configKey) {
+	registeredKeys = append(registeredKeys, name)
 	_ = v.BindEnv(name.AccessPath(), name.EnvName())
 }
 
 func registerString(flags *pflag.FlagSet, name configKey, value string, usage string) {
+	registeredKeys = append(registeredKeys, name)
 	flags.String(name.FlagName(), value, usage)
 	_ = v.BindEnv(name.AccessPath(), name.EnvName())
 	_ = v.BindPFlag(name.AccessPath(), flags.Lookup(name.FlagName()))
@@ -58,6 +72,7 @@ func registerString(flags *pflag.FlagSet, name configKey, value string, usage st
 }
 
 func registerBool(flags *pflag.FlagSet, name configKey, value bool, usage string) {
+	registeredKeys = append(registeredKeys, name)
 	flags.Bool(name.FlagName(), value, usage)
 	_ = v.BindEnv(name.AccessPath(), name.EnvName())
 	_ = v.BindPFlag(name.AccessPath(), flags.Lookup(name.FlagName()))
@@ -65,6 +80,7 @@ func registerBool(flags *pflag.FlagSet, name configKey, value bool, usage string
 }
 
 func registerInt(flags *pflag.FlagSet, name configKey, value int, usage string) {
+	registeredKeys = append(registeredKeys, name)
 	flags.Int(name.FlagName(), value, usage)
 	_ = v.BindEnv(name.AccessPath(), name.EnvName())
 	_ = v.BindPFlag(name.AccessPath(), flags.Lookup(name.FlagName()))
@@ -82,6 +98,7 @@ func registerConfig() {
 
 	// Register flags and environment variables, and set default values for the flags.
 	flags := rootCmd.Flags()
+	configFlags = flags
 	name := func(components ...string) configKey { return components }
 
 	registerString(flags, name("config-file"),
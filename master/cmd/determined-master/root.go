@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -49,6 +50,7 @@ func runRoot() error {
 	log.Infof("master configuration: %s", printableConfig)
 
 	m := internal.New(version.Version, logStore, config)
+	m.LoadConfig = reloadConfig
 	return m.Run(context.TODO())
 }
 
@@ -80,7 +82,44 @@ func initializeConfig() (*internal.Config, error) {
 	if err := check.Validate(config); err != nil {
 		return nil, err
 	}
+	if config.Sources, err = configSources(config, bs); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// reloadConfig returns the validated configuration after reading the configuration file again,
+// which replaces the settings that were read from it before. The values of environment variables
+// and command line flags do not change while the master runs.
+func reloadConfig() (*internal.Config, error) {
+	bs, err := readConfigFile(v.GetString(configKey{"config-file"}.AccessPath()))
+	if err != nil {
+		return nil, err
+	}
+	var configMap map[string]interface{}
+	if err = yaml.Unmarshal(bs, &configMap); err != nil {
+		return nil, errors.Wrap(err, "error unmarshal yaml configuration file")
+	}
+	configJSON, err := json.Marshal(configMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal configuration map into json bytes")
+	}
+	v.SetConfigType("json")
+	if err = v.ReadConfig(bytes.NewReader(configJSON)); err != nil {
+		return nil, errors.Wrap(err, "error replace configuration in viper")
+	}
 
+	config, err := getConfig(v.AllSettings())
+	if err != nil {
+		return nil, err
+	}
+	if err := check.Validate(config); err != nil {
+		return nil, err
+	}
+	if config.Sources, err = configSources(config, bs); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
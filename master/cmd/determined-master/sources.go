@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal"
+)
+
+// configSources returns where each setting of the configuration came from, given the contents of
+// the configuration file that it was read from.
+func configSources(
+	config *internal.Config, fileBytes []byte,
+) (map[string]internal.ConfigSource, error) {
+	var fileMap map[string]interface{}
+	if err := yaml.Unmarshal(fileBytes, &fileMap); err != nil {
+		return nil, errors.Wrap(err, "error unmarshal yaml configuration file")
+	}
+	// Files in the old schema set the settings that they are converted to.
+	_, schedulerExisted := fileMap["scheduler"]
+	_, provisionerExisted := fileMap["provisioner"]
+	if schedulerExisted || provisionerExisted {
+		var err error
+		if fileMap, err = applyBackwardsCompatibility(fileMap); err != nil {
+			return nil, errors.Wrap(err, "cannot apply backwards compatibility")
+		}
+	}
+
+	bs, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal configuration into json bytes")
+	}
+	var configMap map[string]interface{}
+	if err = json.Unmarshal(bs, &configMap); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal configuration into a map")
+	}
+
+	overrides := make(map[string]internal.ConfigSource)
+	for _, key := range registeredKeys {
+		switch {
+		case configFlags.Changed(key.FlagName()):
+			overrides[key.SettingPath()] = internal.ConfigSourceFlag
+		case os.Getenv(key.EnvName()) != "":
+			overrides[key.SettingPath()] = internal.ConfigSourceEnv
+		}
+	}
+
+	sources := make(map[string]internal.ConfigSource)
+	addConfigSources(sources, overrides, "", configMap, fileMap)
+	return sources, nil
+}
+
+// addConfigSources adds the sources of the settings in a section of the configuration, given the
+// same section of the configuration file, which is nil if the file does not have it.
+func addConfigSources(
+	sources, overrides map[string]internal.ConfigSource,
+	prefix string, section, fileSection map[string]interface{},
+) {
+	for name, value := range section {
+		path := prefix + name
+		_, inFile := fileSection[name]
+		if settings, ok := value.(map[string]interface{}); ok && len(settings) > 0 {
+			fileSettings, _ := fileSection[name].(map[string]interface{})
+			addConfigSources(sources, overrides, path+".", settings, fileSettings)
+			continue
+		}
+		switch source, ok := overrides[path]; {
+		case ok:
+			sources[path] = source
+		case inFile:
+			sources[path] = internal.ConfigSourceFile
+		default:
+			sources[path] = internal.ConfigSourceDefault
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal"
+)
+
+func TestConfigSources(t *testing.T) {
+	assert.NilError(t, os.Setenv("DET_DB_PORT", "5433"))
+	defer func() {
+		assert.NilError(t, os.Unsetenv("DET_DB_PORT"))
+	}()
+
+	raw := `
+db:
+  host: db.example.com
+  port: "5432"
+resource_pools:
+  - pool_name: gpu
+`
+	sources, err := configSources(internal.DefaultConfig(), []byte(raw))
+	assert.NilError(t, err)
+	assert.Equal(t, sources["db.host"], internal.ConfigSourceFile)
+	// Environment variables take precedence over the file.
+	assert.Equal(t, sources["db.port"], internal.ConfigSourceEnv)
+	assert.Equal(t, sources["db.user"], internal.ConfigSourceDefault)
+	assert.Equal(t, sources["resource_pools"], internal.ConfigSourceFile)
+	assert.Equal(t, sources["port"], internal.ConfigSourceDefault)
+}
@@ -1,23 +1,27 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
 	"sort"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	ws "github.com/determined-ai/determined/master/pkg/actor/api"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/agentv1"
 	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
 )
@@ -28,8 +32,36 @@ type agent struct {
 	socket           *actor.Ref
 	slots            *actor.Ref
 	containers       map[container.ID]*actor.Ref
+	taskIDs          map[container.ID]sproto.TaskID
 	resourcePoolName string
 	label            string
+	platform         string
+	version          string
+	topology         *device.Topology
+	// maxZeroSlotContainers is the limit of zero-slot containers that the agent sets, if any.
+	maxZeroSlotContainers int
+	// started is set once the agent reports that it started and joins its resource pool.
+	started bool
+
+	// requestedPoolName is the resource pool that the agent asked to join, and labelPools maps the
+	// labels of agents to the resource pools that claim them instead. tokenPool is the pool that
+	// the registration token of the agent restricts it to, if any.
+	requestedPoolName string
+	labelPools        map[string]string
+	tokenPool         string
+
+	// identity is what the master knows of the agent beyond its ID, which its approval is bound to.
+	identity model.AgentIdentity
+	// awaitingApproval is set until an admin approves the agent, which it joins its resource pool
+	// after. pendingStart is the start of the agent that waits for the approval.
+	awaitingApproval bool
+	pendingStart     *aproto.AgentStarted
+
+	// versionSkew is what the master does if the version of the agent is incompatible with its own.
+	versionSkew VersionSkewPolicy
+	// draining is set while a rolling upgrade or a MIG reconfiguration waits for the tasks of the
+	// agent to finish, during which no new tasks are scheduled onto it.
+	draining bool
 
 	// uuid is an anonymous ID that is used when reporting telemetry
 	// information to allow agent connection and disconnection events
@@ -38,16 +70,37 @@ type agent struct {
 
 	// opts are additional agent options the master sends to the agent.
 	opts *aproto.MasterSetAgentOptions
+
+	health *agentHealth
+	// healthy is the health status last reported to the resource pool.
+	healthy bool
+	// devices maps device IDs to the devices of the agent.
+	devices map[int]device.Device
+	// excludedDevices are the devices last reported to the resource pool as unhealthy.
+	excludedDevices map[int]bool
+
+	db *db.PgDB
+	// allocations persists the allocations of the containers of the agent.
+	allocations allocationStore
 }
 
 // AgentSummary summarizes the state on an agent.
 type AgentSummary struct {
-	ID             string       `json:"id"`
-	RegisteredTime time.Time    `json:"registered_time"`
-	Slots          SlotsSummary `json:"slots"`
-	NumContainers  int          `json:"num_containers"`
-	ResourcePool   string       `json:"resource_pool"`
-	Label          string       `json:"label"`
+	ID             string        `json:"id"`
+	RegisteredTime time.Time     `json:"registered_time"`
+	Slots          SlotsSummary  `json:"slots"`
+	NumContainers  int           `json:"num_containers"`
+	ResourcePool   string        `json:"resource_pool"`
+	Label          string        `json:"label"`
+	Platform       string        `json:"platform"`
+	Health         HealthSummary `json:"health"`
+	// AwaitingApproval is set while no tasks are scheduled onto the agent until an admin approves
+	// it.
+	AwaitingApproval bool   `json:"awaiting_approval"`
+	Version          string `json:"version"`
+	// Draining is set while no new tasks are scheduled onto the agent so that it can be upgraded
+	// or its GPUs repartitioned.
+	Draining bool `json:"draining"`
 }
 
 func (a *agent) Receive(ctx *actor.Context) error {
@@ -56,6 +109,13 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		a.uuid = uuid.New()
 		a.slots, _ = ctx.ActorOf("slots", &slots{resourcePool: a.resourcePool})
 		a.containers = make(map[container.ID]*actor.Ref)
+		a.taskIDs = make(map[container.ID]sproto.TaskID)
+		a.healthy = true
+		a.devices = make(map[int]device.Device)
+		a.excludedDevices = make(map[int]bool)
+		if a.health.config.HeartbeatPeriod > 0 {
+			actors.NotifyAfter(ctx, a.health.config.Period(), healthCheckTick{})
+		}
 	case AgentSummary:
 		ctx.Respond(a.summarize(ctx))
 	case ws.WebSocketConnected:
@@ -73,11 +133,16 @@ func (a *agent) Receive(ctx *actor.Context) error {
 	case sproto.KillTaskContainer:
 		ctx.Log().Infof("killing container id: %s", msg.ContainerID)
 		killMsg := aproto.SignalContainer{
-			ContainerID: msg.ContainerID, Signal: syscall.SIGKILL,
+			ContainerID: msg.ContainerID,
+			Signal:      msg.Stop.StopSignal(),
+			GracePeriod: msg.Stop.GracePeriodDuration(),
 		}
 		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{SignalContainer: &killMsg}})
 	case aproto.SignalContainer:
 		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{SignalContainer: &msg}})
+	case aproto.PullImages:
+		ctx.Log().Infof("pulling images: %s", strings.Join(msg.Images, ", "))
+		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{PullImages: &msg}})
 	case sproto.StartTaskContainer:
 		ctx.Log().Infof("starting container id: %s slots: %d task handler: %s",
 			msg.StartContainer.Container.ID, len(msg.StartContainer.Container.Devices),
@@ -86,10 +151,18 @@ func (a *agent) Receive(ctx *actor.Context) error {
 		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{
 			StartContainer: &msg.StartContainer,
 		}})
-		ctx.Tell(a.slots, msg.StartContainer)
+		ctx.Tell(a.slots, msg)
 		a.containers[msg.Container.ID] = msg.TaskActor
+		a.taskIDs[msg.Container.ID] = msg.TaskID
 	case aproto.MasterMessage:
 		a.handleIncomingWSMessage(ctx, msg)
+	case sproto.SetAgentLabelPools:
+		a.labelPools = msg.Pools
+		a.moveToClaimingPool(ctx)
+	case healthCheckTick:
+		a.health.check(time.Now())
+		a.updateHealth(ctx)
+		actors.NotifyAfter(ctx, a.health.config.Period(), healthCheckTick{})
 	case *proto.GetAgentRequest:
 		ctx.Respond(&proto.GetAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
 	case *proto.GetSlotsRequest:
@@ -105,6 +178,41 @@ func (a *agent) Receive(ctx *actor.Context) error {
 	case *proto.DisableAgentRequest:
 		ctx.Tell(a.slots, patchSlot{Enabled: false})
 		ctx.Respond(&proto.DisableAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
+	case *proto.ApproveAgentRequest:
+		if err := a.db.ApproveAgent(ctx.Self().Address().Local(), a.identity); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		if a.awaitingApproval {
+			ctx.Log().Info("agent approved")
+			a.awaitingApproval = false
+			if a.pendingStart != nil {
+				a.agentStarted(ctx, *a.pendingStart)
+				a.pendingStart = nil
+			}
+		}
+		ctx.Respond(&proto.ApproveAgentResponse{Agent: ToProtoAgent(a.summarize(ctx))})
+	case *proto.RevokeAgentRequest:
+		// The agent must be approved again once it reconnects.
+		ctx.Log().Info("approval of agent revoked; disconnecting it")
+		ctx.Respond(&proto.RevokeAgentResponse{})
+		ctx.Self().Stop()
+	case retryAgentStarted:
+		a.agentStarted(ctx, msg.started)
+	case drainAgent:
+		if a.draining != msg.drain {
+			a.draining = msg.drain
+			a.updateHealth(ctx)
+		}
+	case configureMIG:
+		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{
+			ConfigureMIG: &aproto.ConfigureMIG{Layouts: msg.layouts},
+		}})
+	case shutdownAgent:
+		ctx.Log().Infof("shutting down agent: %s", msg.reason)
+		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{
+			ShutdownAgent: &aproto.ShutdownAgent{Reason: msg.reason},
+		}})
 	case echo.Context:
 		a.handleAPIRequest(ctx, msg)
 	case actor.ChildFailed:
@@ -146,14 +254,23 @@ func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMes
 	switch {
 	case msg.AgentStarted != nil:
 		telemetry.ReportAgentConnected(ctx.Self().System(), a.uuid, msg.AgentStarted.Devices)
-		ctx.Log().Infof("agent connected ip: %v resource pool: %s slots: %d",
-			a.address, a.resourcePoolName, len(msg.AgentStarted.Devices))
-
-		ctx.Tell(a.resourcePool, sproto.AddAgent{Agent: ctx.Self(), Label: msg.AgentStarted.Label})
-		ctx.Tell(a.slots, *msg.AgentStarted)
 		a.label = msg.AgentStarted.Label
+		a.platform = model.PlatformOrDefault(msg.AgentStarted.Platform)
+		a.topology = msg.AgentStarted.Topology
+		a.maxZeroSlotContainers = msg.AgentStarted.MaxZeroSlotContainers
+		a.version = msg.AgentStarted.Version
+		if !a.checkVersion(ctx) {
+			return
+		}
+		if a.awaitingApproval {
+			ctx.Log().Warnf("agent connected ip: %v, awaiting approval by an admin", a.address)
+			a.pendingStart = msg.AgentStarted
+			return
+		}
+		a.agentStarted(ctx, *msg.AgentStarted)
 	case msg.ContainerStateChanged != nil:
 		a.containerStateChanged(ctx, *msg.ContainerStateChanged)
+		a.moveToClaimingPool(ctx)
 	case msg.ContainerLog != nil:
 		ref, ok := a.containers[msg.ContainerLog.Container.ID]
 		check.Panic(check.True(ok,
@@ -165,6 +282,27 @@ func (a *agent) handleIncomingWSMessage(ctx *actor.Context, msg aproto.MasterMes
 			RunMessage:  msg.ContainerLog.RunMessage,
 			AuxMessage:  msg.ContainerLog.AuxMessage,
 		})
+	case msg.AgentHeartbeat != nil:
+		a.health.heartbeat(*msg.AgentHeartbeat, time.Now())
+		a.updateHealth(ctx)
+		ctx.Tell(a.slots, slotUtilization{
+			Time: msg.AgentHeartbeat.SentAt, Samples: msg.AgentHeartbeat.Utilization,
+		})
+	case msg.ContainerProfile != nil:
+		a.recordProfile(ctx, *msg.ContainerProfile)
+		if ref, ok := a.containers[msg.ContainerProfile.Container.ID]; ok {
+			ctx.Tell(ref, sproto.TaskContainerProfile{ContainerProfile: *msg.ContainerProfile})
+		}
+	case msg.AgentInterrupted != nil:
+		ctx.Log().Warnf("agent instance will be reclaimed at %s, releasing its tasks",
+			msg.AgentInterrupted.Deadline)
+		ctx.Tell(a.resourcePool, sproto.AgentInterrupted{
+			Agent: ctx.Self(), Deadline: msg.AgentInterrupted.Deadline,
+		})
+	case msg.MIGConfigured != nil:
+		ctx.Tell(ctx.Self().Parent(), migConfigured{
+			agentID: ctx.Self().Address().Local(), err: msg.MIGConfigured.Error,
+		})
 	default:
 		check.Panic(errors.Errorf("error parsing incoming message"))
 	}
@@ -182,10 +320,16 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 		}
 		rsc.ContainerStarted = &sproto.TaskContainerStarted{
 			Addresses: sc.ContainerStarted.Addresses(),
+			// Requests from containers on other hosts reach the master from the address of their
+			// agent, while requests from containers on the same host as the master may keep the
+			// address of the container.
+			SourceAddresses: append(
+				[]string{a.address}, sc.ContainerStarted.NetworkIPs()...),
 		}
 	case container.Terminated:
 		ctx.Log().Infof("stopped container id: %s", sc.Container.ID)
 		delete(a.containers, sc.Container.ID)
+		delete(a.taskIDs, sc.Container.ID)
 		rsc.ContainerStopped = &sproto.TaskContainerStopped{
 			ContainerStopped: *sc.ContainerStopped,
 		}
@@ -195,13 +339,173 @@ func (a *agent) containerStateChanged(ctx *actor.Context, sc aproto.ContainerSta
 	ctx.Tell(a.slots, sc)
 }
 
+// agentStarted adds the agent that started, its devices and the containers that it still runs to
+// its resource pool.
+func (a *agent) agentStarted(ctx *actor.Context, started aproto.AgentStarted) {
+	// Without its allocations, every container of the agent would look orphaned and be killed, so
+	// the agent waits to join its resource pool until they can be fetched.
+	allocations, err := a.allocations.AgentAllocations(ctx.Self().Address().Local())
+	if err != nil {
+		ctx.Log().WithError(err).Errorf(
+			"cannot fetch the allocations of the agent, retrying in %s", reconcileRetryPeriod)
+		actors.NotifyAfter(ctx, reconcileRetryPeriod, retryAgentStarted{started: started})
+		return
+	}
+
+	if pool := a.claimingPool(); pool != a.resourcePoolName {
+		a.setResourcePool(ctx, pool)
+	}
+	ctx.Log().Infof("agent connected ip: %v resource pool: %s slots: %d",
+		a.address, a.resourcePoolName, len(started.Devices))
+
+	a.started = true
+	a.addToResourcePool(ctx)
+	reattached := a.reconcile(ctx, started.Containers, allocations)
+	ctx.Tell(a.slots, started)
+	for _, start := range reattached {
+		ctx.Tell(a.slots, start)
+	}
+	for _, d := range started.Devices {
+		a.devices[d.ID] = d
+	}
+}
+
+// checkVersion returns whether the agent may join the cluster with its version, after warning
+// about or refusing agents of incompatible versions. Refused agents are told to shut down.
+func (a *agent) checkVersion(ctx *actor.Context) bool {
+	masterVersion := a.opts.MasterInfo.Version
+	if a.versionSkew == VersionSkewIgnore || compatibleVersions(masterVersion, a.version) {
+		return true
+	}
+	if a.versionSkew != VersionSkewRefuse {
+		ctx.Log().Warnf("agent version %s is incompatible with master version %s",
+			a.version, masterVersion)
+		return true
+	}
+	reason := fmt.Sprintf("agent version %s is incompatible with master version %s",
+		a.version, masterVersion)
+	ctx.Log().Errorf("refusing agent: %s", reason)
+	ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{
+		ShutdownAgent: &aproto.ShutdownAgent{Reason: reason},
+	}})
+	return false
+}
+
+// claimingPool returns the resource pool that the agent belongs in: the one that claims its label,
+// or else the one that it asked to join. Agents whose registration token is for a pool stay in it.
+func (a *agent) claimingPool() string {
+	pool, ok := a.labelPools[a.label]
+	if ok && a.label != "" && (a.tokenPool == "" || pool == a.tokenPool) {
+		return pool
+	}
+	return a.requestedPoolName
+}
+
+// setResourcePool points the agent and its slots at a resource pool. It returns false if the pool
+// does not exist.
+func (a *agent) setResourcePool(ctx *actor.Context, name string) bool {
+	ref := sproto.GetRP(ctx.Self().System(), name)
+	if ref == nil {
+		ctx.Log().Warnf("cannot find resource pool %s, staying in %s", name, a.resourcePoolName)
+		return false
+	}
+	a.resourcePool = ref
+	a.resourcePoolName = name
+	return true
+}
+
+// addToResourcePool adds the agent to its resource pool. The slots of the agent add its devices to
+// the pool after the agent is added.
+func (a *agent) addToResourcePool(ctx *actor.Context) {
+	ctx.Tell(a.resourcePool, sproto.AddAgent{
+		Agent:    ctx.Self(),
+		Label:    a.label,
+		Platform: a.platform,
+		Topology: a.topology,
+
+		MaxZeroSlotContainers: a.maxZeroSlotContainers,
+	})
+	ctx.Tell(a.slots, setResourcePool{resourcePool: a.resourcePool})
+}
+
+// moveToClaimingPool moves the agent from its resource pool to the one that it belongs in, once it
+// runs no containers.
+func (a *agent) moveToClaimingPool(ctx *actor.Context) {
+	pool := a.claimingPool()
+	if !a.started || pool == a.resourcePoolName || len(a.containers) > 0 {
+		return
+	}
+	oldPool, oldPoolName := a.resourcePool, a.resourcePoolName
+	if !a.setResourcePool(ctx, pool) {
+		return
+	}
+	ctx.Log().Infof("moving agent from resource pool %s to %s", oldPoolName, pool)
+	ctx.Tell(oldPool, sproto.RemoveAgent{Agent: ctx.Self()})
+	a.addToResourcePool(ctx)
+
+	// The new resource pool takes the agent and its devices to be healthy.
+	a.healthy = true
+	a.excludedDevices = make(map[int]bool)
+	a.updateHealth(ctx)
+}
+
+// updateHealth notifies the resource pool if the health of the agent or any of its devices has
+// changed so that it can stop (or resume) scheduling tasks onto them.
+func (a *agent) updateHealth(ctx *actor.Context) {
+	// The resource pool takes agents to be healthy when they join it.
+	if !a.started {
+		return
+	}
+	a.updateDeviceHealth(ctx)
+
+	summary := a.health.summarize()
+	healthy := summary.Healthy && !a.draining
+	if healthy == a.healthy {
+		return
+	}
+	switch {
+	case healthy:
+		ctx.Log().Infof("agent is healthy again, resuming scheduling")
+	case summary.Healthy:
+		ctx.Log().Infof("agent is draining, no longer scheduling tasks onto it")
+	default:
+		ctx.Log().Warnf("agent is %s, no longer scheduling tasks onto it", summary)
+	}
+	a.healthy = healthy
+	ctx.Tell(a.resourcePool, sproto.UpdateAgentHealth{Agent: ctx.Self(), Healthy: a.healthy})
+}
+
+func (a *agent) updateDeviceHealth(ctx *actor.Context) {
+	excluded := a.health.excludedDevices()
+	for id, d := range a.devices {
+		if excluded[id] == a.excludedDevices[id] {
+			continue
+		}
+		if excluded[id] {
+			ctx.Log().Warnf("excluding device %s from scheduling: %s", d, a.health.devices[id].reason)
+		} else {
+			ctx.Log().Infof("device %s passed probation, resuming scheduling", d)
+		}
+		ctx.Tell(a.resourcePool, sproto.UpdateDeviceHealth{
+			DeviceID: sproto.DeviceID{Agent: ctx.Self(), Device: d},
+			Healthy:  !excluded[id],
+		})
+	}
+	a.excludedDevices = excluded
+}
+
 func (a *agent) summarize(ctx *actor.Context) AgentSummary {
 	return AgentSummary{
-		ID:             ctx.Self().Address().Local(),
-		RegisteredTime: ctx.Self().RegisteredTime(),
-		Slots:          ctx.Ask(a.slots, SlotsSummary{}).Get().(SlotsSummary),
-		NumContainers:  len(a.containers),
-		ResourcePool:   a.resourcePoolName,
-		Label:          a.label,
+		ID:               ctx.Self().Address().Local(),
+		RegisteredTime:   ctx.Self().RegisteredTime(),
+		Slots:            ctx.Ask(a.slots, SlotsSummary{}).Get().(SlotsSummary),
+		NumContainers:    len(a.containers),
+		ResourcePool:     a.resourcePoolName,
+		Label:            a.label,
+		Platform:         a.platform,
+		Health:           a.health.summarize(),
+		AwaitingApproval: a.awaitingApproval,
+		Version:          a.version,
+		Draining:         a.draining,
 	}
 }
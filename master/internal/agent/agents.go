@@ -1,31 +1,64 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 // Initialize creates a new global agent actor.
+// Agents must connect with the certificates issued to them if requireCerts is set. labelPools maps
+// the labels of agents to the resource pools that claim them.
 func Initialize(
-	system *actor.System, e *echo.Echo, opts *aproto.MasterSetAgentOptions,
+	system *actor.System, e *echo.Echo, opts *aproto.MasterSetAgentOptions, health HealthConfig,
+	profiling ProfilingConfig, registration RegistrationConfig, versionSkew VersionSkewPolicy,
+	requireCerts bool, pgDB *db.PgDB, labelPools map[string]string,
 ) {
-	_, ok := system.ActorOf(sproto.AgentsAddr, &agents{opts: opts})
+	agentOpts := *opts
+	agentOpts.HeartbeatPeriod = health.Period()
+	agentOpts.ProfilingPeriod = profiling.Period()
+	ref, ok := system.ActorOf(sproto.AgentsAddr, &agents{
+		opts: &agentOpts, health: health, registration: registration, requireCerts: requireCerts,
+		db: pgDB, labelPools: labelPools, versionSkew: versionSkew,
+		migReconfigurations: make(map[string]*migReconfiguration),
+	})
 	check.Panic(check.True(ok, "agents address already taken"))
 	// Route /agents and /agents/<agent id>/slots to the agents actor and slots actors.
 	e.Any("/agents*", api.Route(system, nil))
+	prom.Register("agents", healthCollector(system, ref))
 }
 
 type agents struct {
-	opts *aproto.MasterSetAgentOptions
+	opts         *aproto.MasterSetAgentOptions
+	health       HealthConfig
+	registration RegistrationConfig
+	requireCerts bool
+	db           *db.PgDB
+	// labelPools maps the labels of agents to the resource pools that claim them.
+	labelPools map[string]string
+	// versionSkew is what the master does with agents of versions incompatible with its own.
+	versionSkew VersionSkewPolicy
+	// upgrade is the last rolling upgrade of agents.
+	upgrade *agentUpgrade
+	// migReconfigurations are the last MIG reconfigurations of agents, by agent ID.
+	migReconfigurations map[string]*migReconfiguration
 }
 
 type agentsSummary map[string]AgentSummary
@@ -34,17 +67,60 @@ func (a *agents) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case api.WebSocketConnected:
 		id, resourcePool := msg.Ctx.QueryParam("id"), msg.Ctx.QueryParam("resource_pool")
-		if ref, err := a.createAgentActor(ctx, id, resourcePool, a.opts); err != nil {
+		if a.requireCerts && ca.ClientID(ca.RequestState(msg.Ctx.Request()), ca.AgentClient) != id {
+			ctx.Respond(echo.NewHTTPError(http.StatusForbidden,
+				fmt.Sprintf("agent %s must connect with the certificate issued to it", id)))
+		} else if ref, err := a.createAgentActor(
+			ctx, id, resourcePool, msg.Ctx.Request().Header.Get(aproto.RegistrationTokenHeader),
+			model.AgentIdentity{Address: remoteHost(msg.Ctx.Request()), Certified: a.requireCerts},
+			a.opts,
+		); err != nil {
 			ctx.Respond(err)
 		} else {
 			ctx.Respond(ctx.Ask(ref, msg).Get())
 		}
+	case agentsSummary:
+		ctx.Respond(a.summarize(ctx))
 	case *apiv1.GetAgentsRequest:
 		response := &apiv1.GetAgentsResponse{}
 		for _, a := range a.summarize(ctx) {
 			response.Agents = append(response.Agents, ToProtoAgent(a))
 		}
 		ctx.Respond(response)
+	case *apiv1.PullImagesRequest:
+		a.pullImages(ctx, msg)
+	case *apiv1.PostAgentUpgradeRequest:
+		a.startUpgrade(ctx, msg)
+	case *apiv1.GetAgentUpgradeRequest:
+		if a.upgrade == nil {
+			ctx.Respond(status.Error(codes.NotFound, "no upgrade of agents has run"))
+		} else {
+			ctx.Respond(&apiv1.GetAgentUpgradeResponse{Upgrade: a.upgrade.proto()})
+		}
+	case *apiv1.DeleteAgentUpgradeRequest:
+		a.cancelUpgrade(ctx)
+	case upgradeTick:
+		if msg.upgrade == a.upgrade {
+			a.checkUpgrade(ctx)
+		}
+	case *apiv1.PostAgentMIGLayoutRequest:
+		a.startMIGReconfiguration(ctx, msg)
+	case *apiv1.GetAgentMIGLayoutRequest:
+		if r := a.migReconfigurations[msg.AgentId]; r == nil {
+			ctx.Respond(status.Errorf(
+				codes.NotFound, "the GPUs of agent %s have not been repartitioned", msg.AgentId))
+		} else {
+			ctx.Respond(&apiv1.GetAgentMIGLayoutResponse{Reconfiguration: r.proto()})
+		}
+	case migTick:
+		if r := msg.reconfiguration; r == a.migReconfigurations[r.agentID] {
+			a.checkMIGReconfiguration(ctx, r)
+		}
+	case migConfigured:
+		a.migConfigured(ctx, msg)
+	case sproto.SetAgentLabelPools:
+		a.labelPools = msg.Pools
+		ctx.TellAll(msg, ctx.Children()...)
 	case echo.Context:
 		a.handleAPIRequest(ctx, msg)
 	case actor.PreStart, actor.PostStop:
@@ -55,7 +131,8 @@ func (a *agents) Receive(ctx *actor.Context) error {
 }
 
 func (a *agents) createAgentActor(
-	ctx *actor.Context, id, resourcePool string, opts *aproto.MasterSetAgentOptions,
+	ctx *actor.Context, id, resourcePool, token string, identity model.AgentIdentity,
+	opts *aproto.MasterSetAgentOptions,
 ) (*actor.Ref, error) {
 	if id == "" {
 		return nil, errors.Errorf("invalid agent id specified: %s", id)
@@ -64,13 +141,38 @@ func (a *agents) createAgentActor(
 		ctx.Log().Info("resource pool is empty; using default resource pool: default")
 		resourcePool = "default"
 	}
-	if err := sproto.ValidateRP(ctx.Self().System(), resourcePool); err != nil {
-		return nil, errors.Wrapf(err, "cannot find specified resource pool for agent %s", id)
+	// Agents may only join the pools of the agent resource manager.
+	rp := sproto.GetRP(ctx.Self().System(), resourcePool)
+	if rp == nil {
+		return nil, errors.Errorf(
+			"cannot find specified resource pool for agent %s: %s", id, resourcePool)
+	}
+	t, err := a.checkToken(id, resourcePool, token)
+	if err != nil {
+		return nil, err
+	}
+	var tokenPool string
+	if t != nil {
+		identity.TokenID = &t.ID
+		tokenPool = t.ResourcePool
+	}
+	awaitingApproval, err := a.awaitingApproval(id, identity)
+	if err != nil {
+		return nil, err
 	}
 	ref, ok := ctx.ActorOf(id, &agent{
-		resourcePool:     sproto.GetRP(ctx.Self().System(), resourcePool),
-		resourcePoolName: resourcePool,
-		opts:             opts,
+		resourcePool:      rp,
+		resourcePoolName:  resourcePool,
+		requestedPoolName: resourcePool,
+		tokenPool:         tokenPool,
+		labelPools:        a.labelPools,
+		identity:          identity,
+		awaitingApproval:  awaitingApproval,
+		versionSkew:       a.versionSkew,
+		opts:              opts,
+		health:            newAgentHealth(a.health, time.Now()),
+		db:                a.db,
+		allocations:       a.db,
 	})
 	if !ok {
 		return nil, errors.Errorf("agent already connected: %s", id)
@@ -78,6 +180,30 @@ func (a *agents) createAgentActor(
 	return ref, nil
 }
 
+// pullImages fans a request to pull images out to the requested agents, or to all of them.
+func (a *agents) pullImages(ctx *actor.Context, req *apiv1.PullImagesRequest) {
+	targets := ctx.Children()
+	if len(req.AgentIds) > 0 {
+		targets = nil
+		for _, id := range req.AgentIds {
+			ref := ctx.Child(id)
+			if ref == nil {
+				ctx.Respond(status.Errorf(codes.NotFound, "agent not found: %s", id))
+				return
+			}
+			targets = append(targets, ref)
+		}
+	}
+
+	response := &apiv1.PullImagesResponse{}
+	for _, ref := range targets {
+		ctx.Tell(ref, aproto.PullImages{Images: req.Images, Pin: req.Pin})
+		response.AgentIds = append(response.AgentIds, ref.Address().Local())
+	}
+	sort.Strings(response.AgentIds)
+	ctx.Respond(response)
+}
+
 func (a *agents) handleAPIRequest(ctx *actor.Context, apiCtx echo.Context) {
 	switch apiCtx.Request().Method {
 	case echo.GET:
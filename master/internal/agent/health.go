@@ -0,0 +1,317 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	proto "github.com/determined-ai/determined/proto/pkg/agentv1"
+)
+
+const (
+	defaultHeartbeatPeriod        = 10
+	defaultMaxMissedHeartbeats    = 3
+	defaultDiskPressureThreshold  = 95.0
+	defaultMaxGPUErrors           = 0
+	defaultDeviceProbationPeriod  = 600
+	defaultMaxDeviceQueryFailures = 3
+)
+
+// HealthConfig configures how the master decides whether an agent is healthy. Unhealthy agents
+// stay connected, but no new tasks are scheduled onto them.
+type HealthConfig struct {
+	// HeartbeatPeriod is the number of seconds between agent heartbeats.
+	HeartbeatPeriod int `json:"heartbeat_period"`
+	// MaxMissedHeartbeats is the number of consecutive heartbeats that may be missed before the
+	// agent is considered unhealthy.
+	MaxMissedHeartbeats int `json:"max_missed_heartbeats"`
+	// DiskPressureThreshold is the disk usage percentage above which the agent is considered to
+	// be under disk pressure.
+	DiskPressureThreshold float64 `json:"disk_pressure_threshold"`
+	// MaxGPUErrors is the number of uncorrectable ECC errors a GPU may report before it is
+	// excluded from scheduling.
+	MaxGPUErrors int `json:"max_gpu_errors"`
+	// DeviceProbationPeriod is the number of seconds an excluded device must go without new
+	// errors before it is scheduled onto again.
+	DeviceProbationPeriod int `json:"device_probation_period"`
+	// MaxDeviceQueryFailures is the number of consecutive heartbeats in which the health of a
+	// device may fail to be queried before it is excluded from scheduling.
+	MaxDeviceQueryFailures int `json:"max_device_query_failures"`
+}
+
+// DefaultHealthConfig returns the default agent health configuration.
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		HeartbeatPeriod:        defaultHeartbeatPeriod,
+		MaxMissedHeartbeats:    defaultMaxMissedHeartbeats,
+		DiskPressureThreshold:  defaultDiskPressureThreshold,
+		MaxGPUErrors:           defaultMaxGPUErrors,
+		DeviceProbationPeriod:  defaultDeviceProbationPeriod,
+		MaxDeviceQueryFailures: defaultMaxDeviceQueryFailures,
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *HealthConfig) UnmarshalJSON(data []byte) error {
+	*h = DefaultHealthConfig()
+	type DefaultParser *HealthConfig
+	return json.Unmarshal(data, DefaultParser(h))
+}
+
+// Validate implements the check.Validatable interface.
+func (h HealthConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(h.HeartbeatPeriod, 0, "heartbeat_period must be >= 0"),
+		check.GreaterThanOrEqualTo(h.MaxMissedHeartbeats, 1, "max_missed_heartbeats must be >= 1"),
+		check.True(h.DiskPressureThreshold > 0 && h.DiskPressureThreshold <= 100,
+			"disk_pressure_threshold must be in (0, 100]"),
+		check.GreaterThanOrEqualTo(h.MaxGPUErrors, 0, "max_gpu_errors must be >= 0"),
+		check.GreaterThanOrEqualTo(h.DeviceProbationPeriod, 0,
+			"device_probation_period must be >= 0"),
+		check.GreaterThanOrEqualTo(h.MaxDeviceQueryFailures, 1,
+			"max_device_query_failures must be >= 1"),
+	}
+}
+
+// Period returns the heartbeat period as a duration.
+func (h HealthConfig) Period() time.Duration {
+	return time.Duration(h.HeartbeatPeriod) * time.Second
+}
+
+// ProbationPeriod returns the device probation period as a duration.
+func (h HealthConfig) ProbationPeriod() time.Duration {
+	return time.Duration(h.DeviceProbationPeriod) * time.Second
+}
+
+// healthCheckTick is an internal message that triggers the agent to re-evaluate its health.
+type healthCheckTick struct{}
+
+// agentHealth tracks the health of a single agent as reported through heartbeats.
+type agentHealth struct {
+	config HealthConfig
+
+	// registeredAt is when the agent connected, from which heartbeats are missed until the first.
+	registeredAt     time.Time
+	lastHeartbeat    *time.Time
+	latency          time.Duration
+	missedHeartbeats int
+	diskUsedPercent  float64
+	devices          map[int]*deviceHealth
+}
+
+// deviceHealth tracks the health of a single device on the agent.
+type deviceHealth struct {
+	eccErrors int
+	xidErrors int
+	// reported is set once the device reported its error counters, which later reports are
+	// compared to.
+	reported bool
+	// queryFailures is the number of consecutive heartbeats in which the health of the device
+	// could not be queried.
+	queryFailures int
+	// excludedUntil is set while the device is excluded from scheduling.
+	excludedUntil *time.Time
+	reason        string
+}
+
+// HealthSummary summarizes the health of an agent.
+type HealthSummary struct {
+	Healthy          bool                        `json:"healthy"`
+	Reasons          []string                    `json:"reasons"`
+	LastHeartbeat    *time.Time                  `json:"last_heartbeat"`
+	HeartbeatLatency time.Duration               `json:"heartbeat_latency"`
+	MissedHeartbeats int                         `json:"missed_heartbeats"`
+	DiskUsedPercent  float64                     `json:"disk_used_percent"`
+	DiskPressure     bool                        `json:"disk_pressure"`
+	Devices          map[int]DeviceHealthSummary `json:"devices"`
+}
+
+// DeviceHealthSummary summarizes the health of a single device.
+type DeviceHealthSummary struct {
+	ECCErrors     int        `json:"ecc_errors"`
+	XIDErrors     int        `json:"xid_errors"`
+	Excluded      bool       `json:"excluded"`
+	ExcludedUntil *time.Time `json:"excluded_until"`
+	Reason        string     `json:"reason"`
+}
+
+func newAgentHealth(config HealthConfig, registeredAt time.Time) *agentHealth {
+	return &agentHealth{
+		config: config, registeredAt: registeredAt, devices: make(map[int]*deviceHealth),
+	}
+}
+
+// heartbeat records a heartbeat received at the given time.
+func (h *agentHealth) heartbeat(msg aproto.AgentHeartbeat, now time.Time) {
+	h.lastHeartbeat = &now
+	h.latency = now.Sub(msg.SentAt)
+	if h.latency < 0 {
+		// The agent clock is ahead of ours; the latency is unknowable, so do not report garbage.
+		h.latency = 0
+	}
+	h.missedHeartbeats = 0
+	h.diskUsedPercent = msg.DiskUsedPercent
+	for _, d := range msg.Devices {
+		h.deviceHeartbeat(d, now)
+	}
+}
+
+// deviceHeartbeat records the health reported for a device, excluding the device for the
+// probation period if it reported new errors.
+func (h *agentHealth) deviceHeartbeat(msg aproto.DeviceHealth, now time.Time) {
+	d, ok := h.devices[msg.ID]
+	if !ok {
+		d = &deviceHealth{}
+		h.devices[msg.ID] = d
+	}
+
+	// XID counters only reset when the host reboots, so only errors that are new since the first
+	// report of the device, which may follow a reconnect of the agent or a restart of the master,
+	// count against it. A single failed query may be a hiccup of nvidia-smi rather than of the
+	// device.
+	var reason string
+	switch {
+	case msg.Error != "":
+		d.queryFailures++
+		if d.queryFailures >= h.config.MaxDeviceQueryFailures {
+			reason = fmt.Sprintf(
+				"health query failed %d times in a row: %s", d.queryFailures, msg.Error)
+		}
+	case d.reported && msg.XIDErrors > d.xidErrors:
+		reason = fmt.Sprintf("reported %d new fatal XID errors", msg.XIDErrors-d.xidErrors)
+	case msg.ECCErrors > d.eccErrors && msg.ECCErrors > h.config.MaxGPUErrors:
+		reason = fmt.Sprintf("reported %d uncorrectable ECC errors", msg.ECCErrors)
+	}
+	if msg.Error == "" {
+		d.eccErrors = msg.ECCErrors
+		d.xidErrors = msg.XIDErrors
+		d.reported = true
+		d.queryFailures = 0
+	}
+
+	if reason != "" {
+		until := now.Add(h.config.ProbationPeriod())
+		d.excludedUntil = &until
+		d.reason = reason
+	}
+}
+
+// check updates the number of missed heartbeats as of the given time.
+func (h *agentHealth) check(now time.Time) {
+	if h.config.HeartbeatPeriod == 0 {
+		return
+	}
+	// Agents that never send a heartbeat, e.g. because they hang while starting, miss them from
+	// when they connected.
+	since := h.registeredAt
+	if h.lastHeartbeat != nil {
+		since = *h.lastHeartbeat
+	}
+	h.missedHeartbeats = int(now.Sub(since) / h.config.Period())
+
+	for _, d := range h.devices {
+		if d.excludedUntil != nil && !now.Before(*d.excludedUntil) {
+			d.excludedUntil = nil
+			d.reason = ""
+		}
+	}
+}
+
+// excludedDevices returns the IDs of the devices that are currently excluded from scheduling.
+func (h *agentHealth) excludedDevices() map[int]bool {
+	excluded := make(map[int]bool)
+	for id, d := range h.devices {
+		if d.excludedUntil != nil {
+			excluded[id] = true
+		}
+	}
+	return excluded
+}
+
+func (h *agentHealth) diskPressure() bool {
+	return h.diskUsedPercent >= h.config.DiskPressureThreshold
+}
+
+// reasons returns why the agent is unhealthy; an empty list means the agent is healthy.
+func (h *agentHealth) reasons() []string {
+	var reasons []string
+	if h.missedHeartbeats >= h.config.MaxMissedHeartbeats {
+		reasons = append(reasons, fmt.Sprintf("missed %d heartbeats", h.missedHeartbeats))
+	}
+	if h.diskPressure() {
+		reasons = append(reasons, fmt.Sprintf("disk usage at %.1f%%", h.diskUsedPercent))
+	}
+	return reasons
+}
+
+func (h *agentHealth) summarize() HealthSummary {
+	devices := make(map[int]DeviceHealthSummary, len(h.devices))
+	for id, d := range h.devices {
+		devices[id] = DeviceHealthSummary{
+			ECCErrors:     d.eccErrors,
+			XIDErrors:     d.xidErrors,
+			Excluded:      d.excludedUntil != nil,
+			ExcludedUntil: d.excludedUntil,
+			Reason:        d.reason,
+		}
+	}
+	reasons := h.reasons()
+	return HealthSummary{
+		Healthy:          len(reasons) == 0,
+		Reasons:          reasons,
+		LastHeartbeat:    h.lastHeartbeat,
+		HeartbeatLatency: h.latency,
+		MissedHeartbeats: h.missedHeartbeats,
+		DiskUsedPercent:  h.diskUsedPercent,
+		DiskPressure:     h.diskPressure(),
+		Devices:          devices,
+	}
+}
+
+func (h HealthSummary) String() string {
+	if h.Healthy {
+		return "healthy"
+	}
+	return "unhealthy: " + strings.Join(h.Reasons, ", ")
+}
+
+func toProtoHealth(h HealthSummary) *proto.AgentHealth {
+	var ids []int
+	for id := range h.Devices {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	devices := make([]*proto.DeviceHealth, 0, len(ids))
+	for _, id := range ids {
+		d := h.Devices[id]
+		pd := &proto.DeviceHealth{
+			Id:        int32(id),
+			EccErrors: int32(d.ECCErrors),
+			XidErrors: int32(d.XIDErrors),
+			Excluded:  d.Excluded,
+			Reason:    d.Reason,
+		}
+		if d.ExcludedUntil != nil {
+			pd.ExcludedUntil = protoutils.ToTimestamp(*d.ExcludedUntil)
+		}
+		devices = append(devices, pd)
+	}
+	health := &proto.AgentHealth{
+		Healthy:            h.Healthy,
+		Reasons:            h.Reasons,
+		HeartbeatLatencyMs: float64(h.HeartbeatLatency) / float64(time.Millisecond),
+		MissedHeartbeats:   int32(h.MissedHeartbeats),
+		DiskUsedPercent:    h.DiskUsedPercent,
+		DiskPressure:       h.DiskPressure,
+		Devices:            devices,
+	}
+	if h.LastHeartbeat != nil {
+		health.LastHeartbeat = protoutils.ToTimestamp(*h.LastHeartbeat)
+	}
+	return health
+}
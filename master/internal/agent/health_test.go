@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+)
+
+func TestDeviceHeartbeat(t *testing.T) {
+	config := DefaultHealthConfig()
+	for _, tc := range []struct {
+		name     string
+		reports  []aproto.DeviceHealth
+		excluded bool
+	}{
+		{
+			name:    "healthy",
+			reports: []aproto.DeviceHealth{{}, {}},
+		},
+		{
+			name:    "XID errors before the first report",
+			reports: []aproto.DeviceHealth{{XIDErrors: 5}, {XIDErrors: 5}},
+		},
+		{
+			name:     "new XID errors",
+			reports:  []aproto.DeviceHealth{{XIDErrors: 5}, {XIDErrors: 6}},
+			excluded: true,
+		},
+		{
+			name:     "uncorrectable ECC errors",
+			reports:  []aproto.DeviceHealth{{}, {ECCErrors: 1}},
+			excluded: true,
+		},
+		{
+			name:    "single failed query",
+			reports: []aproto.DeviceHealth{{Error: "nvidia-smi failed"}, {}},
+		},
+		{
+			name: "interrupted failed queries",
+			reports: []aproto.DeviceHealth{
+				{Error: "nvidia-smi failed"}, {Error: "nvidia-smi failed"}, {},
+				{Error: "nvidia-smi failed"}, {Error: "nvidia-smi failed"},
+			},
+		},
+		{
+			name: "consecutive failed queries",
+			reports: []aproto.DeviceHealth{
+				{Error: "nvidia-smi failed"}, {Error: "nvidia-smi failed"},
+				{Error: "nvidia-smi failed"},
+			},
+			excluded: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newAgentHealth(config, time.Now())
+			now := time.Now()
+			for _, report := range tc.reports {
+				h.deviceHeartbeat(report, now)
+			}
+			assert.Equal(t, h.excludedDevices()[0], tc.excluded)
+		})
+	}
+}
+
+func TestDeviceProbation(t *testing.T) {
+	h := newAgentHealth(DefaultHealthConfig(), time.Now())
+	now := time.Now()
+	h.heartbeat(aproto.AgentHeartbeat{SentAt: now, Devices: []aproto.DeviceHealth{{ID: 0}}}, now)
+	h.heartbeat(aproto.AgentHeartbeat{
+		SentAt: now, Devices: []aproto.DeviceHealth{{ID: 0, XIDErrors: 1}},
+	}, now)
+	assert.Assert(t, h.excludedDevices()[0])
+
+	h.check(now.Add(h.config.ProbationPeriod() - time.Second))
+	assert.Assert(t, h.excludedDevices()[0])
+	h.check(now.Add(h.config.ProbationPeriod()))
+	assert.Assert(t, !h.excludedDevices()[0])
+}
+
+func TestAgentHealth(t *testing.T) {
+	config := DefaultHealthConfig()
+	registered := time.Now()
+	period := config.Period()
+	for _, tc := range []struct {
+		name       string
+		heartbeats []time.Duration
+		disk       float64
+		checkAt    time.Duration
+		missed     int
+		healthy    bool
+	}{
+		{
+			name:    "just registered",
+			checkAt: period / 2,
+			healthy: true,
+		},
+		{
+			name:    "never sent a heartbeat",
+			checkAt: time.Duration(config.MaxMissedHeartbeats) * period,
+			missed:  config.MaxMissedHeartbeats,
+		},
+		{
+			name:       "recent heartbeat",
+			heartbeats: []time.Duration{10 * period},
+			checkAt:    10*period + period/2,
+			healthy:    true,
+		},
+		{
+			name:       "missed heartbeats",
+			heartbeats: []time.Duration{period, 2 * period},
+			checkAt:    (2 + time.Duration(config.MaxMissedHeartbeats)) * period,
+			missed:     config.MaxMissedHeartbeats,
+		},
+		{
+			name:       "fewer missed heartbeats than allowed",
+			heartbeats: []time.Duration{period},
+			checkAt:    time.Duration(config.MaxMissedHeartbeats) * period,
+			missed:     config.MaxMissedHeartbeats - 1,
+			healthy:    true,
+		},
+		{
+			name:       "disk pressure",
+			heartbeats: []time.Duration{period},
+			disk:       config.DiskPressureThreshold,
+			checkAt:    period,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newAgentHealth(config, registered)
+			for _, at := range tc.heartbeats {
+				now := registered.Add(at)
+				h.heartbeat(aproto.AgentHeartbeat{SentAt: now, DiskUsedPercent: tc.disk}, now)
+			}
+			h.check(registered.Add(tc.checkAt))
+			summary := h.summarize()
+			assert.Equal(t, summary.MissedHeartbeats, tc.missed)
+			assert.Equal(t, summary.Healthy, tc.healthy, "reasons: %v", summary.Reasons)
+		})
+	}
+}
+
+func TestAgentHealthWithoutHeartbeats(t *testing.T) {
+	config := DefaultHealthConfig()
+	config.HeartbeatPeriod = 0
+	registered := time.Now()
+	h := newAgentHealth(config, registered)
+	h.check(registered.Add(time.Hour))
+	assert.Assert(t, h.summarize().Healthy)
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"strconv"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// healthCollector returns a Prometheus collector reporting the health of every connected agent.
+func healthCollector(system *actor.System, agentsRef *actor.Ref) prom.Collector {
+	return func() []prom.Metric {
+		summaries, ok := system.Ask(agentsRef, agentsSummary{}).Get().(agentsSummary)
+		if !ok {
+			return nil
+		}
+
+		healthy := prom.Metric{
+			Name: "det_agent_healthy",
+			Help: "Whether the agent is healthy and accepting new tasks.",
+			Type: prom.Gauge,
+		}
+		latency := prom.Metric{
+			Name: "det_agent_heartbeat_latency_seconds",
+			Help: "Latency of the most recent heartbeat received from the agent.",
+			Type: prom.Gauge,
+		}
+		missed := prom.Metric{
+			Name: "det_agent_missed_heartbeats",
+			Help: "Number of consecutive heartbeats the agent has missed.",
+			Type: prom.Gauge,
+		}
+		disk := prom.Metric{
+			Name: "det_agent_disk_used_percent",
+			Help: "Disk usage reported by the agent, in percent.",
+			Type: prom.Gauge,
+		}
+		eccErrors := prom.Metric{
+			Name: "det_agent_device_ecc_errors",
+			Help: "Uncorrectable ECC errors reported by each device on the agent.",
+			Type: prom.Gauge,
+		}
+		xidErrors := prom.Metric{
+			Name: "det_agent_device_xid_errors",
+			Help: "XID errors logged by the driver for each device on the agent.",
+			Type: prom.Gauge,
+		}
+		excluded := prom.Metric{
+			Name: "det_agent_device_excluded",
+			Help: "Whether the device is excluded from scheduling because it reported errors.",
+			Type: prom.Gauge,
+		}
+
+		for _, s := range summaries {
+			labels := map[string]string{"agent_id": s.ID, "resource_pool": s.ResourcePool}
+			healthy.Samples = append(healthy.Samples,
+				prom.Sample{Labels: labels, Value: prom.BoolValue(s.Health.Healthy)})
+			latency.Samples = append(latency.Samples,
+				prom.Sample{Labels: labels, Value: s.Health.HeartbeatLatency.Seconds()})
+			missed.Samples = append(missed.Samples,
+				prom.Sample{Labels: labels, Value: float64(s.Health.MissedHeartbeats)})
+			disk.Samples = append(disk.Samples,
+				prom.Sample{Labels: labels, Value: s.Health.DiskUsedPercent})
+			for id, d := range s.Health.Devices {
+				labels := map[string]string{
+					"agent_id":      s.ID,
+					"resource_pool": s.ResourcePool,
+					"device_id":     strconv.Itoa(id),
+				}
+				eccErrors.Samples = append(eccErrors.Samples,
+					prom.Sample{Labels: labels, Value: float64(d.ECCErrors)})
+				xidErrors.Samples = append(xidErrors.Samples,
+					prom.Sample{Labels: labels, Value: float64(d.XIDErrors)})
+				excluded.Samples = append(excluded.Samples,
+					prom.Sample{Labels: labels, Value: prom.BoolValue(d.Excluded)})
+			}
+		}
+		return []prom.Metric{healthy, latency, missed, disk, eccErrors, xidErrors, excluded}
+	}
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/proto/pkg/agentv1"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+type (
+	// migTick checks on a MIG reconfiguration, unless another one of the agent replaced it.
+	migTick struct {
+		reconfiguration *migReconfiguration
+	}
+	// configureMIG tells a drained agent to repartition its GPUs into MIG instances.
+	configureMIG struct {
+		layouts []aproto.MIGLayout
+	}
+	// migConfigured tells the agents that an agent repartitioned its GPUs, or why it could not.
+	migConfigured struct {
+		agentID string
+		err     string
+	}
+)
+
+// migReconfiguration repartitions the GPUs of an agent into MIG instances. It drains the agent,
+// has it repartition its GPUs once it runs no tasks and waits for it to restart and rejoin with the
+// new instances as its slots.
+type migReconfiguration struct {
+	agentID       string
+	layouts       []aproto.MIGLayout
+	rejoinTimeout time.Duration
+	startedAt     time.Time
+	// configuredAt is when the agent was told to repartition its GPUs; it rejoins after.
+	configuredAt time.Time
+
+	state agentv1.MIGReconfiguration_State
+	err   string
+}
+
+func (r *migReconfiguration) running() bool {
+	switch r.state {
+	case agentv1.MIGReconfiguration_STATE_DRAINING, agentv1.MIGReconfiguration_STATE_CONFIGURING,
+		agentv1.MIGReconfiguration_STATE_RESTARTING:
+		return true
+	default:
+		return false
+	}
+}
+
+// startMIGReconfiguration starts repartitioning the GPUs of an agent, unless it is already being
+// repartitioned or upgraded.
+func (a *agents) startMIGReconfiguration(
+	ctx *actor.Context, req *apiv1.PostAgentMIGLayoutRequest,
+) {
+	switch r := a.migReconfigurations[req.AgentId]; {
+	case ctx.Child(req.AgentId) == nil:
+		ctx.Respond(status.Errorf(codes.NotFound, "agent %s not found", req.AgentId))
+		return
+	case r != nil && r.running():
+		ctx.Respond(status.Errorf(codes.FailedPrecondition,
+			"the GPUs of agent %s are already being repartitioned", req.AgentId))
+		return
+	case a.upgrade != nil && a.upgrade.state == agentv1.AgentUpgrade_STATE_RUNNING:
+		ctx.Respond(status.Error(codes.FailedPrecondition, "an upgrade of agents is running"))
+		return
+	}
+
+	r := &migReconfiguration{
+		agentID:       req.AgentId,
+		rejoinTimeout: time.Duration(req.RejoinTimeoutSeconds) * time.Second,
+		startedAt:     time.Now().UTC(),
+		state:         agentv1.MIGReconfiguration_STATE_DRAINING,
+	}
+	for _, l := range req.Layouts {
+		r.layouts = append(r.layouts, aproto.MIGLayout{GPU: int(l.Gpu), Profiles: l.Profiles})
+	}
+	if r.rejoinTimeout == 0 {
+		r.rejoinTimeout = defaultRejoinTimeout
+	}
+
+	ctx.Log().Infof("draining agent %s to repartition its GPUs into MIG instances", r.agentID)
+	a.migReconfigurations[r.agentID] = r
+	ctx.Tell(ctx.Child(r.agentID), drainAgent{drain: true})
+	a.checkMIGReconfiguration(ctx, r)
+	ctx.Respond(&apiv1.PostAgentMIGLayoutResponse{Reconfiguration: r.proto()})
+}
+
+// checkMIGReconfiguration advances a running MIG reconfiguration.
+func (a *agents) checkMIGReconfiguration(ctx *actor.Context, r *migReconfiguration) {
+	if !r.running() {
+		return
+	}
+	var summary *AgentSummary
+	if ref := ctx.Child(r.agentID); ref != nil {
+		s := ctx.Ask(ref, AgentSummary{}).Get().(AgentSummary)
+		summary = &s
+	}
+
+	switch r.state {
+	case agentv1.MIGReconfiguration_STATE_DRAINING:
+		if summary == nil {
+			a.stopMIGReconfiguration(ctx, r, "the agent disconnected while it was drained")
+			return
+		}
+		if summary.NumContainers == 0 {
+			ctx.Log().Infof("repartitioning the GPUs of agent %s", r.agentID)
+			ctx.Tell(ctx.Child(r.agentID), configureMIG{layouts: r.layouts})
+			r.state = agentv1.MIGReconfiguration_STATE_CONFIGURING
+			r.configuredAt = time.Now()
+		}
+	case agentv1.MIGReconfiguration_STATE_CONFIGURING, agentv1.MIGReconfiguration_STATE_RESTARTING:
+		switch {
+		case summary != nil && summary.Version != "" &&
+			summary.RegisteredTime.After(r.configuredAt):
+			ctx.Log().Infof("agent %s rejoined with its GPUs repartitioned", r.agentID)
+			r.state = agentv1.MIGReconfiguration_STATE_COMPLETED
+			return
+		case time.Since(r.configuredAt) > r.rejoinTimeout:
+			a.stopMIGReconfiguration(ctx, r, fmt.Sprintf(
+				"agent %s did not rejoin within %s", r.agentID, r.rejoinTimeout))
+			return
+		}
+	}
+	actors.NotifyAfter(ctx, upgradeCheckPeriod, migTick{reconfiguration: r})
+}
+
+// migConfigured advances the MIG reconfiguration of an agent that repartitioned its GPUs, which
+// then restarts, or fails it with why the agent could not.
+func (a *agents) migConfigured(ctx *actor.Context, msg migConfigured) {
+	r := a.migReconfigurations[msg.agentID]
+	if r == nil || r.state != agentv1.MIGReconfiguration_STATE_CONFIGURING {
+		return
+	}
+	if msg.err != "" {
+		a.stopMIGReconfiguration(ctx, r, msg.err)
+		return
+	}
+	r.state = agentv1.MIGReconfiguration_STATE_RESTARTING
+}
+
+// stopMIGReconfiguration fails a MIG reconfiguration and stops draining its agent.
+func (a *agents) stopMIGReconfiguration(ctx *actor.Context, r *migReconfiguration, err string) {
+	if ref := ctx.Child(r.agentID); ref != nil {
+		ctx.Tell(ref, drainAgent{drain: false})
+	}
+	r.state, r.err = agentv1.MIGReconfiguration_STATE_FAILED, err
+	ctx.Log().Errorf("repartitioning the GPUs of agent %s failed: %s", r.agentID, err)
+}
+
+// migReconfiguring returns whether the GPUs of any agent are being repartitioned.
+func (a *agents) migReconfiguring() bool {
+	for _, r := range a.migReconfigurations {
+		if r.running() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *migReconfiguration) proto() *agentv1.MIGReconfiguration {
+	pb := &agentv1.MIGReconfiguration{
+		AgentId:   r.agentID,
+		State:     r.state,
+		Error:     r.err,
+		StartedAt: timestamppb.New(r.startedAt),
+	}
+	for _, l := range r.layouts {
+		pb.Layouts = append(pb.Layouts, &agentv1.MIGLayout{
+			Gpu: int32(l.GPU), Profiles: append([]string(nil), l.Profiles...),
+		})
+	}
+	return pb
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const defaultProfilingSamplingPeriod = 10
+
+// ProfilingConfig configures how agents sample the utilization of the containers of tasks, which
+// the master stores as the profile of each task.
+type ProfilingConfig struct {
+	// SamplingPeriod is the number of seconds between samples of each container. Zero disables
+	// profiling.
+	SamplingPeriod int `json:"sampling_period"`
+}
+
+// DefaultProfilingConfig returns the default task profiling configuration.
+func DefaultProfilingConfig() ProfilingConfig {
+	return ProfilingConfig{SamplingPeriod: defaultProfilingSamplingPeriod}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *ProfilingConfig) UnmarshalJSON(data []byte) error {
+	*p = DefaultProfilingConfig()
+	type DefaultParser *ProfilingConfig
+	return json.Unmarshal(data, DefaultParser(p))
+}
+
+// Validate implements the check.Validatable interface.
+func (p ProfilingConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(p.SamplingPeriod, 0, "sampling_period must be >= 0"),
+	}
+}
+
+// Period returns the sampling period as a duration.
+func (p ProfilingConfig) Period() time.Duration {
+	return time.Duration(p.SamplingPeriod) * time.Second
+}
+
+// recordProfile stores a sample of the utilization of a container as part of the profile of its
+// task.
+func (a *agent) recordProfile(ctx *actor.Context, profile aproto.ContainerProfile) {
+	taskID, ok := a.taskIDs[profile.Container.ID]
+	if !ok {
+		return
+	}
+	sample := &model.TaskProfilerSample{
+		TaskID:                  string(taskID),
+		ContainerID:             profile.Container.ID.String(),
+		AgentID:                 ctx.Self().Address().Local(),
+		Time:                    profile.Time,
+		CPUUtilizationPercent:   profile.CPUUtilizationPercent,
+		MemoryUsedBytes:         int64(profile.MemoryUsedBytes),
+		NetworkRxBytesPerSecond: profile.NetworkRxBytesPerSecond,
+		NetworkTxBytesPerSecond: profile.NetworkTxBytesPerSecond,
+	}
+	for _, gpu := range profile.GPUs {
+		sample.GPUs = append(sample.GPUs, model.TaskProfilerGPU{
+			DeviceID:           gpu.ID,
+			UtilizationPercent: gpu.UtilizationPercent,
+			MemoryUsedMiB:      gpu.MemoryUsedMiB,
+			MemoryTotalMiB:     gpu.MemoryTotalMiB,
+		})
+	}
+	if err := a.db.AddTaskProfilerSample(sample); err != nil {
+		ctx.Log().WithError(err).Warnf("failed to record the profile of container %s",
+			profile.Container.ID)
+	}
+}
@@ -12,20 +12,44 @@ func ToProtoAgent(a AgentSummary) *proto.Agent {
 		slots[s.ID] = toProtoSlot(s)
 	}
 	return &proto.Agent{
-		Id:             a.ID,
-		RegisteredTime: protoutils.ToTimestamp(a.RegisteredTime),
-		Slots:          slots,
-		Containers:     nil,
-		Label:          a.Label,
-		ResourcePool:   a.ResourcePool,
+		Id:               a.ID,
+		RegisteredTime:   protoutils.ToTimestamp(a.RegisteredTime),
+		Slots:            slots,
+		Containers:       nil,
+		Label:            a.Label,
+		Platform:         a.Platform,
+		ResourcePool:     a.ResourcePool,
+		Health:           toProtoHealth(a.Health),
+		AwaitingApproval: a.AwaitingApproval,
+		Version:          a.Version,
+		Draining:         a.Draining,
 	}
 }
 
 func toProtoSlot(s SlotSummary) *proto.Slot {
+	var allocation *proto.SlotAllocation
+	if s.Allocation != nil {
+		allocation = &proto.SlotAllocation{
+			TaskId:      string(s.Allocation.TaskID),
+			TaskName:    s.Allocation.TaskName,
+			ContainerId: s.Allocation.ContainerID.String(),
+		}
+	}
+	utilization := make([]*proto.UtilizationSample, 0, len(s.Utilization))
+	for _, u := range s.Utilization {
+		utilization = append(utilization, &proto.UtilizationSample{
+			Time:               protoutils.ToTimestamp(u.Time),
+			UtilizationPercent: u.UtilizationPercent,
+			MemoryUsedMib:      int32(u.MemoryUsedMiB),
+			MemoryTotalMib:     int32(u.MemoryTotalMiB),
+		})
+	}
 	return &proto.Slot{
-		Id:        s.ID,
-		Device:    s.Device.Proto(),
-		Enabled:   s.Enabled,
-		Container: s.Container.Proto(),
+		Id:          s.ID,
+		Device:      s.Device.Proto(),
+		Enabled:     s.Enabled,
+		Container:   s.Container.Proto(),
+		Allocation:  allocation,
+		Utilization: utilization,
 	}
 }
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	ws "github.com/determined-ai/determined/master/pkg/actor/api"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// reconcileRetryPeriod is how long an agent waits to retry joining its resource pool after the
+// allocations to reconcile its containers against could not be fetched.
+const reconcileRetryPeriod = 10 * time.Second
+
+// allocationStore persists the allocations of the containers of agents.
+type allocationStore interface {
+	AgentAllocations(agentID string) ([]db.Allocation, error)
+	DeleteAllocation(containerID string) error
+}
+
+// retryAgentStarted is an internal message that retries the start of an agent whose containers
+// could not be reconciled.
+type retryAgentStarted struct {
+	started aproto.AgentStarted
+}
+
+// reconcile reconciles the containers that the agent found running when it started against the
+// persisted allocations of the agent. It reattaches the containers whose tasks claim them, which
+// it returns, and tells the agent to kill the others, so that they do not hold on to devices that
+// nothing accounts for. The allocations whose containers are gone are deleted and their tasks told
+// that the containers stopped.
+func (a *agent) reconcile(
+	ctx *actor.Context, containers []container.Container, allocations []db.Allocation,
+) []sproto.StartTaskContainer {
+	byContainer := make(map[container.ID]db.Allocation, len(allocations))
+	for _, allocation := range allocations {
+		byContainer[container.ID(allocation.ContainerID)] = allocation
+	}
+
+	var reattached []sproto.StartTaskContainer
+	reply := aproto.ReattachContainers{}
+	for _, c := range containers {
+		allocation, ok := byContainer[c.ID]
+		delete(byContainer, c.ID)
+		if ok {
+			if start, ok := a.reattach(ctx, allocation, c); ok {
+				ctx.Log().Infof("reattached container %s to %s", c.ID, allocation.TaskActor)
+				reattached = append(reattached, start)
+				reply.ContainerIDs = append(reply.ContainerIDs, c.ID)
+				continue
+			}
+			a.deleteAllocation(ctx, allocation)
+		}
+		ctx.Log().Warnf("killing orphaned container %s of %s", c.ID, c.Parent)
+	}
+
+	for _, allocation := range byContainer {
+		a.deleteAllocation(ctx, allocation)
+		if taskActor := allocationTaskActor(ctx, allocation); taskActor != nil {
+			stopped := aproto.ContainerError(
+				aproto.AgentFailed, errors.New("container stopped while the agent was disconnected"))
+			ctx.Tell(taskActor, sproto.TaskContainerStateChanged{
+				Container: container.Container{
+					Parent: taskActor.Address(),
+					ID:     container.ID(allocation.ContainerID),
+					State:  container.Terminated,
+				},
+				ContainerStopped: &sproto.TaskContainerStopped{ContainerStopped: stopped},
+			})
+		}
+	}
+
+	if len(containers) > 0 {
+		ctx.Ask(a.socket, ws.WriteMessage{Message: aproto.AgentMessage{ReattachContainers: &reply}})
+	}
+	return reattached
+}
+
+// reattach asks the task of an allocation to reattach to its container and, if it does, allocates
+// the container to it.
+func (a *agent) reattach(
+	ctx *actor.Context, allocation db.Allocation, c container.Container,
+) (sproto.StartTaskContainer, bool) {
+	taskActor := allocationTaskActor(ctx, allocation)
+	if taskActor == nil {
+		return sproto.StartTaskContainer{}, false
+	}
+	req, ok := ctx.Ask(taskActor, sproto.ReattachContainer{
+		Agent:        ctx.Self(),
+		ResourcePool: a.resourcePoolName,
+		Container:    c,
+	}).Get().(sproto.AllocateRequest)
+	if !ok {
+		return sproto.StartTaskContainer{}, false
+	}
+
+	// The resource pool learns of the allocation before the slots report their devices, so that
+	// it does not schedule other tasks onto them.
+	ctx.Tell(a.resourcePool, req)
+	a.containers[c.ID] = taskActor
+	a.taskIDs[c.ID] = req.ID
+	return sproto.StartTaskContainer{
+		TaskActor:      taskActor,
+		TaskID:         req.ID,
+		TaskName:       req.Name,
+		StartContainer: aproto.StartContainer{Container: c},
+	}, true
+}
+
+func (a *agent) deleteAllocation(ctx *actor.Context, allocation db.Allocation) {
+	if err := a.allocations.DeleteAllocation(allocation.ContainerID); err != nil {
+		ctx.Log().WithError(err).Errorf("cannot delete the allocation of %s", allocation.ContainerID)
+	}
+}
+
+// allocationTaskActor returns the actor of the task of an allocation, or nil if it no longer
+// exists.
+func allocationTaskActor(ctx *actor.Context, allocation db.Allocation) *actor.Ref {
+	var address actor.Address
+	if err := address.UnmarshalText([]byte(allocation.TaskActor)); err != nil {
+		return nil
+	}
+	return ctx.Self().System().Get(address)
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	ws "github.com/determined-ai/determined/master/pkg/actor/api"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// failingAllocationStore is an allocation store that has no allocations, and that fails to fetch
+// them while err is set.
+type failingAllocationStore struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (s *failingAllocationStore) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *failingAllocationStore) AgentAllocations(agentID string) ([]db.Allocation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nil, s.err
+}
+
+func (s *failingAllocationStore) DeleteAllocation(containerID string) error {
+	return nil
+}
+
+// recordedMessages asks a messageRecorder for the messages that it received.
+type recordedMessages struct{}
+
+// messageRecorder is an actor that records the messages that it receives.
+type messageRecorder struct {
+	messages []actor.Message
+}
+
+func (r *messageRecorder) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case recordedMessages:
+		ctx.Respond(append([]actor.Message(nil), r.messages...))
+	default:
+		r.messages = append(r.messages, ctx.Message())
+	}
+	return nil
+}
+
+func TestReconcileWaitsForAllocations(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	socket, _ := system.ActorOf(actor.Addr("socket"), &messageRecorder{})
+	pool, _ := system.ActorOf(actor.Addr("pool"), &messageRecorder{})
+	store := &failingAllocationStore{err: errors.New("connection refused")}
+	ref, _ := system.ActorOf(actor.Addr("agent"), &agent{
+		resourcePool: pool,
+		socket:       socket,
+		opts:         &aproto.MasterSetAgentOptions{},
+		versionSkew:  VersionSkewIgnore,
+		health:       newAgentHealth(HealthConfig{}, time.Now()),
+		allocations:  store,
+	})
+	started := aproto.AgentStarted{Containers: []container.Container{{ID: "orphan"}}}
+
+	// Without its allocations, the agent neither joins its pool nor has any container killed.
+	system.Ask(ref, aproto.MasterMessage{AgentStarted: &started}).Get()
+	assert.Equal(t, len(system.Ask(socket, recordedMessages{}).Get().([]actor.Message)), 0)
+	assert.Equal(t, len(system.Ask(pool, recordedMessages{}).Get().([]actor.Message)), 0)
+
+	// Once the allocations can be fetched, the agent joins its pool and kills its orphans.
+	store.setErr(nil)
+	system.Ask(ref, retryAgentStarted{started: started}).Get()
+	written := system.Ask(socket, recordedMessages{}).Get().([]actor.Message)
+	assert.Equal(t, len(written), 1)
+	reattach := written[0].(ws.WriteMessage).Message.(aproto.AgentMessage).ReattachContainers
+	assert.Assert(t, reattach != nil)
+	assert.Equal(t, len(reattach.ContainerIDs), 0)
+	var added bool
+	for _, msg := range system.Ask(pool, recordedMessages{}).Get().([]actor.Message) {
+		if _, ok := msg.(sproto.AddAgent); ok {
+			added = true
+		}
+	}
+	assert.Assert(t, added, "expected the agent to join its resource pool")
+}
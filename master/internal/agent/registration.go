@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// RegistrationConfig configures which agents may join the cluster and have tasks scheduled onto
+// them, so that hosts that can reach the master cannot join it on their own.
+type RegistrationConfig struct {
+	// RequireToken makes agents present a registration token that an admin created, either for
+	// the resource pool that they join or for any pool.
+	RequireToken bool `json:"require_token"`
+	// RequireApproval keeps tasks from being scheduled onto agents that connect for the first time
+	// until an admin approves them.
+	RequireApproval bool `json:"require_approval"`
+}
+
+// checkToken returns the registration token of an agent, if the master requires one, or an error if
+// the token does not let the agent join the resource pool.
+func (a *agents) checkToken(id, resourcePool, token string) (*model.AgentToken, error) {
+	if !a.registration.RequireToken {
+		return nil, nil
+	}
+	forbidden := func(format string, args ...interface{}) error {
+		return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf(format, args...))
+	}
+	if token == "" {
+		return nil, forbidden("agent %s must present a registration token", id)
+	}
+	t, err := a.db.AgentTokenByHash(model.HashAgentToken(token))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, forbidden("agent %s presented an unknown registration token", id)
+	case err != nil:
+		return nil, err
+	case t.ResourcePool != "" && t.ResourcePool != resourcePool:
+		return nil, forbidden("the registration token of agent %s is for resource pool %s, not %s",
+			id, t.ResourcePool, resourcePool)
+	}
+	return t, nil
+}
+
+// awaitingApproval returns whether an agent must be approved before tasks are scheduled onto it.
+// An approval only holds for the identity that the agent was approved with, so that other hosts
+// cannot claim the ID of an approved agent.
+func (a *agents) awaitingApproval(id string, identity model.AgentIdentity) (bool, error) {
+	if !a.registration.RequireApproval {
+		return false, nil
+	}
+	approved, err := a.db.AgentApproved(id, identity)
+	return !approved, err
+}
+
+// remoteHost returns the host that a request came from. It does not trust forwarding headers,
+// which the client sets.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
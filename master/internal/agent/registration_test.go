@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestRemoteHostIgnoresForwardingHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/agents", nil)
+	r.RemoteAddr = "10.0.0.1:34567"
+	r.Header.Set("X-Forwarded-For", "10.0.0.2")
+	r.Header.Set("X-Real-IP", "10.0.0.2")
+	assert.Equal(t, remoteHost(r), "10.0.0.1")
+}
+
+func TestRevokeDisconnectsAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	socket, _ := system.ActorOf(actor.Addr("socket"), &messageRecorder{})
+	pool, _ := system.ActorOf(actor.Addr("pool"), &messageRecorder{})
+	ref, _ := system.ActorOf(actor.Addr("agent"), &agent{
+		resourcePool: pool,
+		socket:       socket,
+		opts:         &aproto.MasterSetAgentOptions{},
+		versionSkew:  VersionSkewIgnore,
+		health:       newAgentHealth(HealthConfig{}, time.Now()),
+	})
+
+	resp := system.Ask(ref, &apiv1.RevokeAgentRequest{AgentId: "agent"}).Get()
+	_, ok := resp.(*apiv1.RevokeAgentResponse)
+	assert.Assert(t, ok, "unexpected response: %v", resp)
+
+	// The agent leaves its resource pool and must be approved again once it reconnects.
+	assert.NilError(t, ref.AwaitTermination())
+	var removed bool
+	for _, msg := range system.Ask(pool, recordedMessages{}).Get().([]actor.Message) {
+		if _, ok := msg.(sproto.RemoveAgent); ok {
+			removed = true
+		}
+	}
+	assert.Assert(t, removed, "expected the agent to leave its resource pool")
+}
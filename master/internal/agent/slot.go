@@ -2,6 +2,7 @@ package agent
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -16,11 +17,16 @@ import (
 	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
+// maxUtilizationSamples is the number of utilization samples each slot keeps.
+const maxUtilizationSamples = 10
+
 type slot struct {
 	resourcePool *actor.Ref
 	device       device.Device
 	enabled      slotEnabled
 	container    *container.Container
+	allocation   *SlotAllocation
+	utilization  []UtilizationSample
 }
 
 type slotEnabled struct {
@@ -35,16 +41,38 @@ func (s slotEnabled) Enabled() bool {
 
 // SlotSummary summarizes the state of a slot.
 type SlotSummary struct {
-	ID        string               `json:"id"`
-	Device    device.Device        `json:"device"`
-	Enabled   bool                 `json:"enabled"`
-	Container *container.Container `json:"container"`
+	ID          string               `json:"id"`
+	Device      device.Device        `json:"device"`
+	Enabled     bool                 `json:"enabled"`
+	Container   *container.Container `json:"container"`
+	Allocation  *SlotAllocation      `json:"allocation"`
+	Utilization []UtilizationSample  `json:"utilization"`
+}
+
+// SlotAllocation describes the task occupying a slot.
+type SlotAllocation struct {
+	TaskID      sproto.TaskID `json:"task_id"`
+	TaskName    string        `json:"task_name"`
+	ContainerID container.ID  `json:"container_id"`
+}
+
+// UtilizationSample is a sample of how heavily the device of a slot is being used.
+type UtilizationSample struct {
+	Time               time.Time `json:"time"`
+	UtilizationPercent float64   `json:"utilization_percent"`
+	MemoryUsedMiB      int       `json:"memory_used_mib"`
+	MemoryTotalMiB     int       `json:"memory_total_mib"`
 }
 
 type (
 	patchSlot struct {
 		Enabled bool `json:"enabled"`
 	}
+	// slotUtilization forwards utilization samples reported by the agent to the slots.
+	slotUtilization struct {
+		Time    time.Time
+		Samples []aproto.DeviceUtilization
+	}
 )
 
 func (s *slot) Receive(ctx *actor.Context) error {
@@ -56,15 +84,39 @@ func (s *slot) Receive(ctx *actor.Context) error {
 	case patchSlot:
 		s.enabled.userEnabled = msg.Enabled
 		s.patch(ctx)
-	case aproto.StartContainer:
+	case setResourcePool:
+		s.resourcePool = msg.resourcePool
+		if s.enabled.deviceAdded {
+			s.enabled.deviceAdded = false
+			s.patch(ctx)
+		}
+	case sproto.StartTaskContainer:
 		check.Panic(check.True(s.enabled.Enabled(), "container allocated but slot is not enabled"))
 		check.Panic(check.True(s.container == nil, "container already allocated to slot"))
 		s.container = &msg.Container
+		s.allocation = &SlotAllocation{
+			TaskID:      msg.TaskID,
+			TaskName:    msg.TaskName,
+			ContainerID: msg.Container.ID,
+		}
 	case aproto.ContainerStateChanged:
 		check.Panic(check.Equal(s.container.ID, msg.Container.ID, "Invalid container id sent to slot"))
 		s.container = &msg.Container
 		if msg.Container.State == container.Terminated {
 			s.container = nil
+			s.allocation = nil
+		}
+	case slotUtilization:
+		for _, sample := range msg.Samples {
+			s.utilization = append(s.utilization, UtilizationSample{
+				Time:               msg.Time,
+				UtilizationPercent: sample.UtilizationPercent,
+				MemoryUsedMiB:      sample.MemoryUsedMiB,
+				MemoryTotalMiB:     sample.MemoryTotalMiB,
+			})
+		}
+		if n := len(s.utilization); n > maxUtilizationSamples {
+			s.utilization = s.utilization[n-maxUtilizationSamples:]
 		}
 	case *proto.GetSlotRequest:
 		ctx.Respond(&proto.GetSlotResponse{Slot: toProtoSlot(s.summarize(ctx))})
@@ -128,10 +180,14 @@ func (s *slot) deviceID(ctx *actor.Context) sproto.DeviceID {
 }
 
 func (s *slot) summarize(ctx *actor.Context) SlotSummary {
+	utilization := make([]UtilizationSample, len(s.utilization))
+	copy(utilization, s.utilization)
 	return SlotSummary{
-		ID:        ctx.Self().Address().Local(),
-		Device:    s.device,
-		Enabled:   s.enabled.Enabled(),
-		Container: s.container,
+		ID:          ctx.Self().Address().Local(),
+		Device:      s.device,
+		Enabled:     s.enabled.Enabled(),
+		Container:   s.container,
+		Allocation:  s.allocation,
+		Utilization: utilization,
 	}
 }
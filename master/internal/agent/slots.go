@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
@@ -17,6 +18,12 @@ type slots struct {
 	resourcePool *actor.Ref
 }
 
+// setResourcePool moves the slots to the resource pool that their agent was added to. Slots whose
+// devices are enabled add them to the pool.
+type setResourcePool struct {
+	resourcePool *actor.Ref
+}
+
 // SlotsSummary contains a summary for a number of slots.
 type SlotsSummary map[string]SlotSummary
 
@@ -33,12 +40,21 @@ func (s *slots) Receive(ctx *actor.Context) error {
 			_, ok := ctx.ActorOf(d.ID, &slot{resourcePool: s.resourcePool, enabled: enabled, device: d})
 			check.Panic(check.True(ok, "error registering slot, slot %s already created", d.ID))
 		}
-	case aproto.StartContainer:
+	case sproto.StartTaskContainer:
 		s.sendToSlots(ctx, msg.Container, msg)
+	case slotUtilization:
+		for _, sample := range msg.Samples {
+			if child := ctx.Child(sample.ID); child != nil {
+				ctx.Tell(child, slotUtilization{Time: msg.Time, Samples: []aproto.DeviceUtilization{sample}})
+			}
+		}
 	case patchSlot:
 		for _, child := range ctx.Children() {
 			ctx.Tell(child, msg)
 		}
+	case setResourcePool:
+		s.resourcePool = msg.resourcePool
+		ctx.TellAll(msg, ctx.Children()...)
 	case aproto.ContainerStateChanged:
 		s.sendToSlots(ctx, msg.Container, msg)
 	case echo.Context:
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/proto/pkg/agentv1"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+const (
+	// upgradeCheckPeriod is how often a rolling upgrade checks on the agents that it upgrades.
+	upgradeCheckPeriod = 5 * time.Second
+	// defaultRejoinTimeout is how long a rolling upgrade waits for a shut down agent to rejoin.
+	defaultRejoinTimeout = 10 * time.Minute
+)
+
+type (
+	// upgradeTick checks on the agents of a rolling upgrade, unless another upgrade replaced it.
+	upgradeTick struct {
+		upgrade *agentUpgrade
+	}
+	// drainAgent starts or stops draining an agent: no new tasks are scheduled onto a draining
+	// agent, while the tasks that it runs keep running.
+	drainAgent struct {
+		drain bool
+	}
+	// shutdownAgent tells an agent to shut down, so that its supervisor restarts it.
+	shutdownAgent struct {
+		reason string
+	}
+)
+
+// agentUpgrade is a rolling upgrade of the agents of resource pools to a version, one pool at a
+// time. It drains up to maxUnavailable agents of the pool at once, shuts each down once it runs no
+// tasks so that its supervisor restarts it with the new version, and waits for it to rejoin with
+// that version.
+type agentUpgrade struct {
+	version        string
+	pools          []string
+	maxUnavailable int
+	rejoinTimeout  time.Duration
+	startedAt      time.Time
+
+	// pool is the index of the resource pool being upgraded.
+	pool int
+	// draining are the agents that are drained, and shutDown are the times when agents were shut
+	// down that have not rejoined yet.
+	draining map[string]bool
+	shutDown map[string]time.Time
+	upgraded []string
+
+	state agentv1.AgentUpgrade_State
+	err   string
+}
+
+// startUpgrade starts a rolling upgrade of agents, unless one is already running.
+func (a *agents) startUpgrade(ctx *actor.Context, req *apiv1.PostAgentUpgradeRequest) {
+	if a.upgrade != nil && a.upgrade.state == agentv1.AgentUpgrade_STATE_RUNNING {
+		ctx.Respond(status.Error(codes.FailedPrecondition, "an upgrade of agents is already running"))
+		return
+	}
+	if a.migReconfiguring() {
+		ctx.Respond(status.Error(codes.FailedPrecondition,
+			"the GPUs of an agent are being repartitioned into MIG instances"))
+		return
+	}
+	u := &agentUpgrade{
+		version:        req.Version,
+		pools:          req.ResourcePools,
+		maxUnavailable: int(req.MaxUnavailable),
+		rejoinTimeout:  time.Duration(req.RejoinTimeoutSeconds) * time.Second,
+		startedAt:      time.Now().UTC(),
+		draining:       make(map[string]bool),
+		shutDown:       make(map[string]time.Time),
+		state:          agentv1.AgentUpgrade_STATE_RUNNING,
+	}
+	if u.version == "" {
+		u.version = a.opts.MasterInfo.Version
+	}
+	if u.maxUnavailable == 0 {
+		u.maxUnavailable = 1
+	}
+	if u.rejoinTimeout == 0 {
+		u.rejoinTimeout = defaultRejoinTimeout
+	}
+	if len(u.pools) == 0 {
+		pools := make(map[string]bool)
+		for _, s := range a.summarize(ctx) {
+			pools[s.ResourcePool] = true
+		}
+		for pool := range pools {
+			u.pools = append(u.pools, pool)
+		}
+		sort.Strings(u.pools)
+	}
+
+	ctx.Log().Infof("upgrading agents of resource pools %v to version %s", u.pools, u.version)
+	a.upgrade = u
+	a.checkUpgrade(ctx)
+	ctx.Respond(&apiv1.PostAgentUpgradeResponse{Upgrade: u.proto()})
+}
+
+// cancelUpgrade cancels the running rolling upgrade of agents. The agents that it drains resume
+// running tasks, and the ones that it shut down are left to rejoin.
+func (a *agents) cancelUpgrade(ctx *actor.Context) {
+	if a.upgrade == nil || a.upgrade.state != agentv1.AgentUpgrade_STATE_RUNNING {
+		ctx.Respond(status.Error(codes.FailedPrecondition, "no upgrade of agents is running"))
+		return
+	}
+	ctx.Log().Infof("canceling the upgrade of agents to version %s", a.upgrade.version)
+	a.stopUpgrade(ctx, agentv1.AgentUpgrade_STATE_CANCELED, "")
+	ctx.Respond(&apiv1.DeleteAgentUpgradeResponse{Upgrade: a.upgrade.proto()})
+}
+
+// stopUpgrade stops the running rolling upgrade of agents in a state, and stops draining agents.
+func (a *agents) stopUpgrade(ctx *actor.Context, state agentv1.AgentUpgrade_State, err string) {
+	u := a.upgrade
+	for id := range u.draining {
+		if ref := ctx.Child(id); ref != nil {
+			ctx.Tell(ref, drainAgent{drain: false})
+		}
+	}
+	u.draining = make(map[string]bool)
+	u.state, u.err = state, err
+	if err != "" {
+		ctx.Log().Errorf("the upgrade of agents to version %s failed: %s", u.version, err)
+	}
+}
+
+// checkUpgrade advances the running rolling upgrade of agents.
+func (a *agents) checkUpgrade(ctx *actor.Context) {
+	u := a.upgrade
+	if u == nil || u.state != agentv1.AgentUpgrade_STATE_RUNNING {
+		return
+	}
+	agents := make(map[string]AgentSummary)
+	for _, s := range a.summarize(ctx) {
+		agents[s.ID] = s
+	}
+	now := time.Now()
+
+	// Agents that were shut down are upgraded once they rejoin with the new version.
+	for id, at := range u.shutDown {
+		s, ok := agents[id]
+		switch {
+		case ok && s.Version == u.version:
+			delete(u.shutDown, id)
+			u.upgraded = append(u.upgraded, id)
+		case ok && s.Version != "":
+			a.stopUpgrade(ctx, agentv1.AgentUpgrade_STATE_FAILED, fmt.Sprintf(
+				"agent %s rejoined with version %s instead of %s", id, s.Version, u.version))
+			return
+		case !ok && now.Sub(at) > u.rejoinTimeout:
+			a.stopUpgrade(ctx, agentv1.AgentUpgrade_STATE_FAILED, fmt.Sprintf(
+				"agent %s did not rejoin within %s", id, u.rejoinTimeout))
+			return
+		}
+	}
+
+	// Drained agents are shut down once they run no tasks.
+	for id := range u.draining {
+		s, ok := agents[id]
+		if ok && s.NumContainers > 0 {
+			continue
+		}
+		if ok {
+			ctx.Tell(ctx.Child(id), shutdownAgent{
+				reason: fmt.Sprintf("restarting to upgrade to version %s", u.version),
+			})
+		}
+		delete(u.draining, id)
+		u.shutDown[id] = now
+	}
+
+	for ; u.pool < len(u.pools); u.pool++ {
+		var pending []string
+		for id, s := range agents {
+			_, shutDown := u.shutDown[id]
+			if s.ResourcePool == u.pools[u.pool] && s.Version != u.version && s.Version != "" &&
+				!u.draining[id] && !shutDown {
+				pending = append(pending, id)
+			}
+		}
+		sort.Strings(pending)
+		for _, id := range pending {
+			if len(u.draining)+len(u.shutDown) >= u.maxUnavailable {
+				break
+			}
+			ctx.Log().Infof("draining agent %s to upgrade it to version %s", id, u.version)
+			ctx.Tell(ctx.Child(id), drainAgent{drain: true})
+			u.draining[id] = true
+		}
+		if len(pending) > 0 || len(u.draining) > 0 || len(u.shutDown) > 0 {
+			actors.NotifyAfter(ctx, upgradeCheckPeriod, upgradeTick{upgrade: u})
+			return
+		}
+		ctx.Log().Infof("upgraded the agents of resource pool %s to version %s",
+			u.pools[u.pool], u.version)
+	}
+	a.stopUpgrade(ctx, agentv1.AgentUpgrade_STATE_COMPLETED, "")
+}
+
+func (u *agentUpgrade) proto() *agentv1.AgentUpgrade {
+	pb := &agentv1.AgentUpgrade{
+		Version:        u.version,
+		ResourcePools:  u.pools,
+		MaxUnavailable: int32(u.maxUnavailable),
+		StartedAt:      timestamppb.New(u.startedAt),
+		UpgradedAgents: append([]string(nil), u.upgraded...),
+		State:          u.state,
+		Error:          u.err,
+	}
+	if u.pool < len(u.pools) {
+		pb.CurrentResourcePool = u.pools[u.pool]
+	}
+	for id := range u.draining {
+		pb.UpgradingAgents = append(pb.UpgradingAgents, id)
+	}
+	for id := range u.shutDown {
+		pb.UpgradingAgents = append(pb.UpgradingAgents, id)
+	}
+	sort.Strings(pb.UpgradingAgents)
+	return pb
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionSkewPolicy is what the master does with agents whose major or minor version differs from
+// its own.
+type VersionSkewPolicy string
+
+const (
+	// VersionSkewIgnore lets agents of any version join the cluster.
+	VersionSkewIgnore VersionSkewPolicy = "ignore"
+	// VersionSkewWarn lets agents of incompatible versions join the cluster with a warning. It is
+	// the default.
+	VersionSkewWarn VersionSkewPolicy = "warn"
+	// VersionSkewRefuse disconnects agents of incompatible versions.
+	VersionSkewRefuse VersionSkewPolicy = "refuse"
+)
+
+// VersionSkewPolicies are the valid version skew policies.
+var VersionSkewPolicies = []string{
+	"", string(VersionSkewIgnore), string(VersionSkewWarn), string(VersionSkewRefuse),
+}
+
+// majorMinor returns the major and minor version of a version like "0.16.3" or "0.16.3-dev0", or
+// false if the version has neither.
+func majorMinor(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// compatibleVersions returns whether an agent of a version can work with a master of another: both
+// must have the same major and minor version. Versions that cannot be parsed, such as those of
+// development builds, are taken to be compatible.
+func compatibleVersions(master, agent string) bool {
+	masterMajor, masterMinor, ok := majorMinor(master)
+	if !ok {
+		return true
+	}
+	agentMajor, agentMinor, ok := majorMinor(agent)
+	if !ok {
+		return true
+	}
+	return masterMajor == agentMajor && masterMinor == agentMinor
+}
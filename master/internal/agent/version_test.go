@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCompatibleVersions(t *testing.T) {
+	for _, tc := range []struct {
+		master, agent string
+		compatible    bool
+	}{
+		{"0.16.3", "0.16.3", true},
+		{"0.16.3", "0.16.0", true},
+		{"0.16.3-dev0", "0.16.2", true},
+		{"0.16.3", "0.15.3", false},
+		{"1.0.0", "0.16.3", false},
+		{"0.16.3", "", true},
+		{"dev", "0.15.3", true},
+	} {
+		assert.Equal(t, compatibleVersions(tc.master, tc.agent), tc.compatible,
+			"master %s, agent %s", tc.master, tc.agent)
+	}
+}
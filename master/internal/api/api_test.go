@@ -53,3 +53,36 @@ func TestPaginate(t *testing.T) {
 		}
 	}
 }
+
+func TestCursor(t *testing.T) {
+	key := "description"
+	for _, c := range []Cursor{{Key: &key, ID: "1"}, {ID: "2"}} {
+		decoded, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("failed to decode cursor %v: %s", c, err)
+		}
+		if decoded.ID != c.ID || (decoded.Key == nil) != (c.Key == nil) ||
+			(c.Key != nil && *decoded.Key != *c.Key) {
+			t.Errorf("decoded cursor %v is not %v", *decoded, c)
+		}
+	}
+	if c, err := DecodeCursor(""); c != nil || err != nil {
+		t.Errorf("empty cursor decoded as %v, %v", c, err)
+	}
+	if _, err := DecodeCursor("not a cursor"); err == nil {
+		t.Error("invalid cursor decoded")
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	terms := SearchTerms("  MNIST-pytorch lr_0.1 ")
+	expected := []string{"mnist", "pytorch", "lr", "0", "1"}
+	if len(terms) != len(expected) {
+		t.Fatalf("unexpected terms %v", terms)
+	}
+	for i := range terms {
+		if terms[i] != expected[i] {
+			t.Errorf("unexpected terms %v", terms)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Cursor is the position after the last record of a page of records that are ordered by a sort
+// key and then by ID, from which the next page continues. Since it holds the values of the last
+// record rather than its index, pages stay consistent while records are added and removed.
+type Cursor struct {
+	// Key is the sort key of the last record as text, or nil if the record has no value for it.
+	Key *string `json:"key,omitempty"`
+	// ID is the ID of the last record.
+	ID string `json:"id"`
+}
+
+// Encode encodes the cursor as the opaque token that clients pass back for the next page.
+func (c Cursor) Encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor from its token. It returns nil if the token is empty.
+func DecodeCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
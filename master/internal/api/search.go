@@ -0,0 +1,13 @@
+package api
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a search query into its lowercase words of letters and digits.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/agentv1"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func toProtoAgentToken(token model.AgentToken) *agentv1.AgentToken {
+	return &agentv1.AgentToken{
+		Id:           int32(token.ID),
+		ResourcePool: token.ResourcePool,
+		Description:  token.Description,
+		CreatedAt:    timestamppb.New(token.CreatedAt),
+	}
+}
+
+func (a *apiServer) ApproveAgent(
+	_ context.Context, req *apiv1.ApproveAgentRequest,
+) (*apiv1.ApproveAgentResponse, error) {
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "an agent id must be specified")
+	}
+	// The approval is bound to the identity that the connected agent has, which the agent records.
+	addr := sproto.AgentsAddr.Child(req.AgentId)
+	if a.m.system.Get(addr) == nil {
+		return nil, status.Errorf(codes.NotFound, "agent not connected: %s", req.AgentId)
+	}
+	var resp *apiv1.ApproveAgentResponse
+	err := a.actorRequest(addr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) RevokeAgent(
+	_ context.Context, req *apiv1.RevokeAgentRequest,
+) (*apiv1.RevokeAgentResponse, error) {
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "an agent id must be specified")
+	}
+	revokeErr := a.m.db.RevokeAgentApproval(req.AgentId)
+	if revokeErr != nil && errors.Cause(revokeErr) != db.ErrNotFound {
+		return nil, revokeErr
+	}
+	// Disconnect the agent, which must be approved again once it reconnects.
+	addr := sproto.AgentsAddr.Child(req.AgentId)
+	if a.m.system.Get(addr) == nil {
+		if revokeErr != nil {
+			return nil, status.Errorf(codes.NotFound, "agent not found: %s", req.AgentId)
+		}
+		return &apiv1.RevokeAgentResponse{}, nil
+	}
+	var resp *apiv1.RevokeAgentResponse
+	err := a.actorRequest(addr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) GetAgentTokens(
+	_ context.Context, _ *apiv1.GetAgentTokensRequest,
+) (*apiv1.GetAgentTokensResponse, error) {
+	tokens, err := a.m.db.AgentTokens()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetAgentTokensResponse{}
+	for _, token := range tokens {
+		resp.AgentTokens = append(resp.AgentTokens, toProtoAgentToken(token))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostAgentToken(
+	_ context.Context, req *apiv1.PostAgentTokenRequest,
+) (*apiv1.PostAgentTokenResponse, error) {
+	token := &model.AgentToken{
+		ResourcePool: req.ResourcePool,
+		Description:  req.Description,
+	}
+	raw, err := a.m.db.AddAgentToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostAgentTokenResponse{AgentToken: toProtoAgentToken(*token), Token: raw}, nil
+}
+
+func (a *apiServer) DeleteAgentToken(
+	_ context.Context, req *apiv1.DeleteAgentTokenRequest,
+) (*apiv1.DeleteAgentTokenResponse, error) {
+	switch err := a.m.db.DeleteAgentToken(int(req.AgentTokenId)); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(
+			codes.NotFound, "agent registration token not found: %d", req.AgentTokenId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteAgentTokenResponse{}, nil
+}
@@ -3,50 +3,96 @@ package internal
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/proto/pkg/agentv1"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 func (a *apiServer) GetAgents(
-	_ context.Context, req *apiv1.GetAgentsRequest,
+	ctx context.Context, req *apiv1.GetAgentsRequest,
 ) (resp *apiv1.GetAgentsResponse, err error) {
-	switch {
-	case sproto.UseAgentRM(a.m.system):
-		err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
-	case sproto.UseK8sRM(a.m.system):
-		err = a.actorRequest(sproto.PodsAddr.String(), req, &resp)
-	default:
-		err = status.Error(codes.NotFound, "cannot find agents or pods actor")
+	var addrs []string
+	if sproto.UseAgentRM(a.m.system) {
+		addrs = append(addrs, sproto.AgentsAddr.String())
 	}
+	if sproto.UseK8sRM(a.m.system) {
+		addrs = append(addrs, sproto.PodsAddr.String())
+	}
+	// The nodes that HPC jobs run on belong to the workload manager, which Determined does not
+	// track as agents.
+	if len(addrs) == 0 && !sproto.UseHPCRM(a.m.system) {
+		return nil, status.Error(codes.NotFound, "cannot find agents or pods actor")
+	}
+
+	resp = &apiv1.GetAgentsResponse{}
+	for _, addr := range addrs {
+		var rmResp *apiv1.GetAgentsResponse
+		if err = a.actorRequest(addr, req, &rmResp); err != nil {
+			return nil, err
+		}
+		resp.Agents = append(resp.Agents, rmResp.Agents...)
+	}
+	visible, err := a.tenantResourcePools(ctx)
 	if err != nil {
 		return nil, err
 	}
 	a.filter(&resp.Agents, func(i int) bool {
 		v := resp.Agents[i]
-		return req.Label == "" || v.Label == req.Label
+		return (req.Label == "" || v.Label == req.Label) &&
+			(visible == nil || visible(v.ResourcePool))
 	})
 	a.sort(resp.Agents, req.OrderBy, req.SortBy, apiv1.GetAgentsRequest_SORT_BY_ID)
 	return resp, a.paginate(&resp.Pagination, &resp.Agents, req.Offset, req.Limit)
 }
 
+// checkTenantAgent returns a NotFound error unless the caller of a request may see the resource
+// pool of an agent.
+func (a *apiServer) checkTenantAgent(ctx context.Context, agentID string) error {
+	visible, err := a.tenantResourcePools(ctx)
+	if err != nil || visible == nil {
+		return err
+	}
+	var resp *apiv1.GetAgentResponse
+	if err = a.actorRequest(
+		fmt.Sprintf("/agents/%s", agentID), &apiv1.GetAgentRequest{AgentId: agentID}, &resp,
+	); err != nil {
+		return err
+	}
+	if !visible(resp.Agent.ResourcePool) {
+		return status.Errorf(codes.NotFound, "agent not found: %s", agentID)
+	}
+	return nil
+}
+
 func (a *apiServer) GetAgent(
-	_ context.Context, req *apiv1.GetAgentRequest) (resp *apiv1.GetAgentResponse, err error) {
+	ctx context.Context, req *apiv1.GetAgentRequest) (resp *apiv1.GetAgentResponse, err error) {
+	if err = a.checkTenantAgent(ctx, req.AgentId); err != nil {
+		return nil, err
+	}
 	err = a.actorRequest(fmt.Sprintf("/agents/%s", req.AgentId), req, &resp)
 	return resp, err
 }
 
 func (a *apiServer) GetSlots(
-	_ context.Context, req *apiv1.GetSlotsRequest) (resp *apiv1.GetSlotsResponse, err error) {
+	ctx context.Context, req *apiv1.GetSlotsRequest) (resp *apiv1.GetSlotsResponse, err error) {
+	if err = a.checkTenantAgent(ctx, req.AgentId); err != nil {
+		return nil, err
+	}
 	err = a.actorRequest(fmt.Sprintf("/agents/%s", req.AgentId), req, &resp)
 	return resp, err
 }
 
 func (a *apiServer) GetSlot(
-	_ context.Context, req *apiv1.GetSlotRequest) (resp *apiv1.GetSlotResponse, err error) {
+	ctx context.Context, req *apiv1.GetSlotRequest) (resp *apiv1.GetSlotResponse, err error) {
+	if err = a.checkTenantAgent(ctx, req.AgentId); err != nil {
+		return nil, err
+	}
 	err = a.actorRequest(fmt.Sprintf("/agents/%s/slots/%s", req.AgentId, req.SlotId), req, &resp)
 	return resp, err
 }
@@ -74,3 +120,134 @@ func (a *apiServer) DisableSlot(
 	err = a.actorRequest(fmt.Sprintf("/agents/%s/slots/%s", req.AgentId, req.SlotId), req, &resp)
 	return resp, err
 }
+
+func (a *apiServer) PullImages(
+	_ context.Context, req *apiv1.PullImagesRequest) (resp *apiv1.PullImagesResponse, err error) {
+	if len(req.Images) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one image must be specified")
+	}
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(
+			codes.Unimplemented, "pulling images is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) IssueAgentCertificate(
+	_ context.Context, req *apiv1.IssueAgentCertificateRequest,
+) (*apiv1.IssueAgentCertificateResponse, error) {
+	if a.m.ca == nil {
+		return nil, status.Error(codes.FailedPrecondition, "security.mtls is not enabled")
+	}
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "an agent id must be specified")
+	}
+	cert, key, err := a.m.ca.IssueAgentCert(req.AgentId)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.IssueAgentCertificateResponse{
+		Certificate:   string(cert),
+		PrivateKey:    string(key),
+		CaCertificate: string(a.m.ca.CertPEM()),
+	}, nil
+}
+
+func (a *apiServer) PostAgentUpgrade(
+	_ context.Context, req *apiv1.PostAgentUpgradeRequest,
+) (resp *apiv1.PostAgentUpgradeResponse, err error) {
+	if err = grpcutil.ValidateRequest(
+		func() (bool, string) {
+			return req.MaxUnavailable >= 0, "max_unavailable must not be negative"
+		},
+		func() (bool, string) {
+			return req.RejoinTimeoutSeconds >= 0, "rejoin_timeout_seconds must not be negative"
+		},
+	); err != nil {
+		return nil, err
+	}
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(
+			codes.Unimplemented, "upgrading agents is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) GetAgentUpgrade(
+	_ context.Context, req *apiv1.GetAgentUpgradeRequest,
+) (resp *apiv1.GetAgentUpgradeResponse, err error) {
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(
+			codes.Unimplemented, "upgrading agents is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) DeleteAgentUpgrade(
+	_ context.Context, req *apiv1.DeleteAgentUpgradeRequest,
+) (resp *apiv1.DeleteAgentUpgradeResponse, err error) {
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(
+			codes.Unimplemented, "upgrading agents is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) PostAgentMIGLayout(
+	_ context.Context, req *apiv1.PostAgentMIGLayoutRequest,
+) (resp *apiv1.PostAgentMIGLayoutResponse, err error) {
+	if err = grpcutil.ValidateRequest(
+		func() (bool, string) { return req.AgentId != "", "an agent id must be specified" },
+		func() (bool, string) { return len(req.Layouts) > 0, "layouts must be specified" },
+		func() (bool, string) {
+			return req.RejoinTimeoutSeconds >= 0, "rejoin_timeout_seconds must not be negative"
+		},
+		func() (bool, string) { return validMIGLayouts(req.Layouts) },
+	); err != nil {
+		return nil, err
+	}
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(codes.Unimplemented,
+			"repartitioning GPUs is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+func (a *apiServer) GetAgentMIGLayout(
+	_ context.Context, req *apiv1.GetAgentMIGLayoutRequest,
+) (resp *apiv1.GetAgentMIGLayoutResponse, err error) {
+	if !sproto.UseAgentRM(a.m.system) {
+		return nil, status.Error(codes.Unimplemented,
+			"repartitioning GPUs is only supported by the agent resource manager")
+	}
+	err = a.actorRequest(sproto.AgentsAddr.String(), req, &resp)
+	return resp, err
+}
+
+// migProfilePattern matches the profiles of MIG instances, like 3g.20gb or 1g.10gb+me.
+var migProfilePattern = regexp.MustCompile(`^\d+g\.\d+gb(\+me)?$`)
+
+// validMIGLayouts checks that MIG layouts are of distinct GPUs and have valid profiles.
+func validMIGLayouts(layouts []*agentv1.MIGLayout) (bool, string) {
+	gpus := make(map[int32]bool)
+	for _, l := range layouts {
+		if l.Gpu < 0 {
+			return false, "the GPUs of layouts must not be negative"
+		}
+		if gpus[l.Gpu] {
+			return false, fmt.Sprintf("GPU %d has more than one layout", l.Gpu)
+		}
+		gpus[l.Gpu] = true
+		for _, p := range l.Profiles {
+			if !migProfilePattern.MatchString(p) {
+				return false, fmt.Sprintf("%q is not a MIG profile, like 3g.20gb", p)
+			}
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func (a *apiServer) BulkArchive(
+	ctx context.Context, req *apiv1.BulkArchiveRequest,
+) (*apiv1.BulkArchiveResponse, error) {
+	if req.EndedBefore == nil {
+		return nil, status.Error(codes.InvalidArgument, "ended_before is required")
+	}
+	endedBefore := req.EndedBefore.AsTime()
+	var states []model.State
+	for _, s := range req.States {
+		state := model.State(strings.TrimPrefix(s.String(), "STATE_"))
+		if !model.TerminalStates[state] {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"cannot archive experiments in non terminal state %v", state)
+		}
+		states = append(states, state)
+	}
+	if len(states) == 0 {
+		for state := range model.TerminalStates {
+			states = append(states, state)
+		}
+	}
+
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	// Whether the user may change an experiment or task only depends on whether they own it and on
+	// its project, so each of those is checked once.
+	type scope struct {
+		permission model.Permission
+		projectID  int
+	}
+	allowed := make(map[scope]bool)
+	mayArchive := func(owner string, projectID int) bool {
+		s := scope{editPermission(user.Username == owner), projectID}
+		ok, checked := allowed[s]
+		if !checked {
+			_, err := a.m.checkPermission(user, projectID, s.permission)
+			ok = err == nil
+			allowed[s] = ok
+		}
+		return ok
+	}
+
+	resp := &apiv1.BulkArchiveResponse{}
+	exps, err := a.m.db.ArchivableExperiments(
+		endedBefore, states, req.Users, int(req.ProjectId))
+	if err != nil {
+		return nil, err
+	}
+	var expIDs []int
+	for _, exp := range exps {
+		if mayArchive(exp.Username, exp.ProjectID) {
+			expIDs = append(expIDs, exp.ID)
+		} else {
+			resp.Skipped++
+		}
+	}
+	archived, err := a.m.db.ArchiveExperiments(expIDs)
+	if err != nil {
+		return nil, err
+	}
+	resp.ArchivedExperiments = int32(archived)
+
+	var tasks []command.ArchivableTask
+	for _, task := range command.ArchivableTasks(
+		a.m.system, endedBefore, req.Users, req.ProjectId,
+	) {
+		if mayArchive(task.Username, int(task.ProjectID)) {
+			tasks = append(tasks, task)
+		} else {
+			resp.Skipped++
+		}
+	}
+	resp.ArchivedTasks = int32(command.ArchiveTasks(a.m.system, tasks))
+	return resp, nil
+}
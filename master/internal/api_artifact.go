@@ -0,0 +1,363 @@
+package internal
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/artifactv1"
+)
+
+var errArtifactsDisabled = status.Error(codes.FailedPrecondition,
+	"the master does not store artifacts; set artifacts.type in its configuration")
+
+func artifactToProto(a model.Artifact) *artifactv1.Artifact {
+	pb := &artifactv1.Artifact{
+		Id:           a.ID,
+		Name:         a.Name,
+		Type:         artifactv1.Type(artifactv1.Type_value["TYPE_"+string(a.Type)]),
+		State:        artifactv1.State(artifactv1.State_value["STATE_"+string(a.State)]),
+		SizeBytes:    a.SizeBytes,
+		NumChunks:    int32(a.NumChunks),
+		CreationTime: protoutils.ToTimestamp(a.CreationTime),
+	}
+	if a.TaskID != nil {
+		pb.TaskId = *a.TaskID
+	}
+	if a.CompletionTime != nil {
+		pb.CompletionTime = protoutils.ToTimestamp(*a.CompletionTime)
+	}
+	return pb
+}
+
+// artifactStatus converts an error of the artifact service to an API error.
+func artifactStatus(err error) error {
+	switch errors.Cause(err) {
+	case db.ErrNotFound:
+		return status.Error(codes.NotFound, "artifact not found")
+	case artifacts.ErrChunkSize, artifacts.ErrInvalidHash, artifacts.ErrNotInManifest:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case artifacts.ErrNotUploading, artifacts.ErrNotCompleted, artifacts.ErrChunkOrder,
+		artifacts.ErrUploadMode, artifacts.ErrMissingChunks:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case artifacts.ErrTooLarge:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return err
+	}
+}
+
+// artifactCaller is who called an artifact API: a task, with its task token, or a user.
+type artifactCaller struct {
+	// taskID is the calling task, if a task called.
+	taskID string
+	// userID is the calling user, or the owner of the calling task.
+	userID model.UserID
+	admin  bool
+}
+
+func (a *apiServer) artifactCaller(ctx context.Context) (*artifactCaller, error) {
+	session, err := grpcutil.GetTaskSession(ctx, a.m.db)
+	switch err {
+	case nil:
+		owner, oErr := a.m.db.TaskOwner(session.TaskID)
+		switch {
+		case oErr != nil:
+			return nil, oErr
+		case owner == nil:
+			return nil, status.Errorf(codes.PermissionDenied,
+				"task %s has no owner to upload artifacts as", session.TaskID)
+		}
+		return &artifactCaller{taskID: session.TaskID, userID: *owner}, nil
+	case grpcutil.ErrTokenMissing:
+		user, _, uErr := grpcutil.GetUser(ctx, a.m.db)
+		if uErr != nil {
+			return nil, uErr
+		}
+		return &artifactCaller{userID: user.ID, admin: user.Admin}, nil
+	default:
+		return nil, err
+	}
+}
+
+// checkArtifactAccess returns an artifact if the caller may access it: tasks may only access the
+// artifacts that they upload and read the contexts of their owners, which their agents download,
+// and users those that they or their tasks uploaded, unless they are admins.
+func (a *apiServer) checkArtifactAccess(
+	ctx context.Context, id string, read bool,
+) (*model.Artifact, error) {
+	if a.m.artifacts == nil {
+		return nil, errArtifactsDisabled
+	}
+	caller, err := a.artifactCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := a.m.db.ArtifactByID(id)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	switch {
+	case caller.taskID != "":
+		ownerContext := read && artifact.Type == model.ArtifactTypeContext &&
+			artifact.OwnerID == caller.userID
+		if !ownerContext && (artifact.TaskID == nil || *artifact.TaskID != caller.taskID) {
+			return nil, status.Errorf(codes.PermissionDenied,
+				"task %s did not upload artifact %s", caller.taskID, id)
+		}
+	case artifact.OwnerID != caller.userID && !caller.admin:
+		return nil, status.Error(codes.PermissionDenied,
+			"only admins may access the artifacts of other users")
+	}
+	return artifact, nil
+}
+
+func (a *apiServer) PostArtifact(
+	ctx context.Context, req *apiv1.PostArtifactRequest,
+) (*apiv1.PostArtifactResponse, error) {
+	if a.m.artifacts == nil {
+		return nil, errArtifactsDisabled
+	}
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.Name != "", "name must be specified" },
+	); err != nil {
+		return nil, err
+	}
+	caller, err := a.artifactCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artifact := model.Artifact{Name: req.Name, OwnerID: caller.userID}
+	switch {
+	case caller.taskID == "" && req.Type == artifactv1.Type_TYPE_CONTEXT:
+		artifact.Type = model.ArtifactTypeContext
+	case caller.taskID != "" && req.Type == artifactv1.Type_TYPE_OUTPUT:
+		artifact.Type = model.ArtifactTypeOutput
+		artifact.TaskID = &caller.taskID
+	default:
+		return nil, status.Error(codes.InvalidArgument,
+			"users upload context artifacts and tasks upload output artifacts")
+	}
+	if err = a.m.artifacts.Start(&artifact); err != nil {
+		return nil, err
+	}
+	return &apiv1.PostArtifactResponse{
+		Artifact: artifactToProto(artifact), ChunkSize: artifacts.ChunkSize,
+	}, nil
+}
+
+func (a *apiServer) PostArtifactChunk(
+	ctx context.Context, req *apiv1.PostArtifactChunkRequest,
+) (*apiv1.PostArtifactChunkResponse, error) {
+	if _, err := a.checkArtifactAccess(ctx, req.ArtifactId, false); err != nil {
+		return nil, err
+	}
+	artifact, err := a.m.artifacts.Append(ctx, req.ArtifactId, int(req.Index), req.Data)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	return &apiv1.PostArtifactChunkResponse{Artifact: artifactToProto(*artifact)}, nil
+}
+
+func (a *apiServer) PostArtifactManifest(
+	ctx context.Context, req *apiv1.PostArtifactManifestRequest,
+) (*apiv1.PostArtifactManifestResponse, error) {
+	if _, err := a.checkArtifactAccess(ctx, req.ArtifactId, false); err != nil {
+		return nil, err
+	}
+	artifact, missing, err := a.m.artifacts.PutManifest(req.ArtifactId, req.ChunkHashes)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	return &apiv1.PostArtifactManifestResponse{
+		Artifact: artifactToProto(*artifact), MissingHashes: missing,
+	}, nil
+}
+
+func (a *apiServer) PostArtifactBlob(
+	ctx context.Context, req *apiv1.PostArtifactBlobRequest,
+) (*apiv1.PostArtifactBlobResponse, error) {
+	if _, err := a.checkArtifactAccess(ctx, req.ArtifactId, false); err != nil {
+		return nil, err
+	}
+	hash, err := a.m.artifacts.AppendBlob(ctx, req.ArtifactId, req.Data)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	return &apiv1.PostArtifactBlobResponse{Hash: hash}, nil
+}
+
+func (a *apiServer) CompleteArtifact(
+	ctx context.Context, req *apiv1.CompleteArtifactRequest,
+) (*apiv1.CompleteArtifactResponse, error) {
+	if _, err := a.checkArtifactAccess(ctx, req.ArtifactId, false); err != nil {
+		return nil, err
+	}
+	artifact, err := a.m.artifacts.Complete(req.ArtifactId)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	return &apiv1.CompleteArtifactResponse{Artifact: artifactToProto(*artifact)}, nil
+}
+
+func (a *apiServer) GetArtifacts(
+	ctx context.Context, req *apiv1.GetArtifactsRequest,
+) (*apiv1.GetArtifactsResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	ownerID := user.ID
+	if req.TaskId != "" && user.Admin {
+		ownerID = 0
+	}
+	found, err := a.m.db.Artifacts(req.TaskId, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetArtifactsResponse{}
+	for _, artifact := range found {
+		resp.Artifacts = append(resp.Artifacts, artifactToProto(artifact))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) GetArtifact(
+	ctx context.Context, req *apiv1.GetArtifactRequest,
+) (*apiv1.GetArtifactResponse, error) {
+	artifact, err := a.checkArtifactAccess(ctx, req.ArtifactId, true)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetArtifactResponse{Artifact: artifactToProto(*artifact)}, nil
+}
+
+func (a *apiServer) DownloadArtifact(
+	req *apiv1.DownloadArtifactRequest, resp apiv1.Determined_DownloadArtifactServer,
+) error {
+	ctx := resp.Context()
+	artifact, err := a.checkArtifactAccess(ctx, req.ArtifactId, true)
+	if err != nil {
+		return err
+	}
+	r, err := a.m.artifacts.Open(ctx, *artifact)
+	if err != nil {
+		return artifactStatus(err)
+	}
+	defer r.Close()
+	buf := make([]byte, artifacts.ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if sErr := resp.Send(&apiv1.DownloadArtifactResponse{Data: buf[:n]}); sErr != nil {
+				return sErr
+			}
+		}
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+func (a *apiServer) DeleteArtifact(
+	ctx context.Context, req *apiv1.DeleteArtifactRequest,
+) (*apiv1.DeleteArtifactResponse, error) {
+	if _, err := a.checkArtifactAccess(ctx, req.ArtifactId, false); err != nil {
+		return nil, err
+	}
+	if err := a.m.artifacts.Delete(ctx, req.ArtifactId); err != nil {
+		return nil, artifactStatus(err)
+	}
+	return &apiv1.DeleteArtifactResponse{}, nil
+}
+
+// checkContextArtifact returns an error unless a user may launch commands or create experiments
+// with an artifact as their context.
+func (m *Master) checkContextArtifact(user *model.User, id string) error {
+	if m.artifacts == nil {
+		return errArtifactsDisabled
+	}
+	artifact, err := m.db.ArtifactByID(id)
+	switch {
+	case err != nil:
+		return artifactStatus(err)
+	case artifact.OwnerID != user.ID && !user.Admin:
+		return status.Error(codes.PermissionDenied,
+			"only admins may launch commands with the artifacts of other users")
+	case artifact.Type != model.ArtifactTypeContext:
+		return status.Errorf(codes.InvalidArgument, "artifact %s is not a context", id)
+	case artifact.State != model.ArtifactStateCompleted:
+		return status.Errorf(codes.FailedPrecondition, "artifact %s is still uploading", id)
+	}
+	return nil
+}
+
+// readContextArtifact returns the files of a context artifact, which experiments store as their
+// model definitions.
+func (m *Master) readContextArtifact(
+	ctx context.Context, user *model.User, id string,
+) (archive.Archive, error) {
+	if err := m.checkContextArtifact(user, id); err != nil {
+		return nil, err
+	}
+	data, err := m.artifacts.ReadAll(ctx, id)
+	if err != nil {
+		return nil, artifactStatus(err)
+	}
+	files, err := archive.FromTarGz(data)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot extract artifact %s: %s", id, err)
+	}
+	return files, nil
+}
+
+// storeContext stores the files of the context of a command as a temporary context artifact, so
+// that the master does not hold them in memory until the command starts and its agent downloads
+// them. Servings keep their files instead, since they start replicas with them again.
+func (a *apiServer) storeContext(ctx context.Context, params *command.CommandParams) error {
+	if a.m.artifacts == nil || len(params.UserFiles) == 0 {
+		return nil
+	}
+	data, err := archive.ToTarGz(params.UserFiles)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "cannot archive the context: %s", err)
+	}
+	artifact := model.Artifact{
+		Name:    "context",
+		Type:    model.ArtifactTypeContext,
+		OwnerID: params.User.ID,
+	}
+	if err = a.m.artifacts.Store(ctx, &artifact, data); err != nil {
+		return artifactStatus(err)
+	}
+	params.UserFiles = nil
+	params.ContextArtifact = artifact.ID
+	params.TempContext = true
+	return nil
+}
+
+// deleteTempContext deletes the temporary context artifact of a command that failed to launch.
+func (a *apiServer) deleteTempContext(ctx context.Context, params *command.CommandParams) {
+	if !params.TempContext {
+		return
+	}
+	if err := a.m.artifacts.Delete(ctx, params.ContextArtifact); err != nil {
+		log.WithError(err).Errorf("cannot delete context artifact %s", params.ContextArtifact)
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/auditv1"
+)
+
+const (
+	auditLogBatchSize     = 1000
+	auditLogBatchWaitTime = 100 * time.Millisecond
+)
+
+type auditEntriesBatch []*model.AuditEntry
+
+func (b auditEntriesBatch) Size() int {
+	return len(b)
+}
+
+func (b auditEntriesBatch) ForEach(f func(interface{}) error) error {
+	for _, e := range b {
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *apiServer) GetAuditLogs(
+	req *apiv1.GetAuditLogsRequest, resp apiv1.Determined_GetAuditLogsServer,
+) error {
+	if err := grpcutil.ValidateRequest(
+		grpcutil.ValidateLimit(req.Limit),
+		grpcutil.ValidateFollow(req.Limit, req.Follow),
+	); err != nil {
+		return err
+	}
+
+	onBatch := func(b api.Batch) error {
+		return b.ForEach(func(r interface{}) error {
+			e := r.(*model.AuditEntry)
+			return resp.Send(&apiv1.GetAuditLogsResponse{Entry: &auditv1.AuditEntry{
+				Id:       e.ID,
+				Time:     timestamppb.New(e.Time),
+				Username: e.Username,
+				TaskId:   e.TaskID,
+				Address:  e.Address,
+				Method:   e.Method,
+				Request:  e.Request,
+				Outcome:  e.Outcome,
+			}})
+		})
+	}
+
+	fetch := func(r api.BatchRequest) (api.Batch, error) {
+		limit := auditLogBatchSize
+		if !r.Follow && r.Limit >= 0 && r.Limit < limit {
+			limit = r.Limit
+		}
+		entries, err := a.m.db.AuditEntries(r.Offset, limit)
+		return auditEntriesBatch(entries), err
+	}
+
+	total, err := a.m.db.AuditEntryCount()
+	if err != nil {
+		return err
+	}
+	offset, limit := api.EffectiveOffsetNLimit(int(req.Offset), int(req.Limit), total)
+	lReq := api.BatchRequest{Offset: offset, Limit: limit, Follow: req.Follow}
+
+	return api.NewBatchStreamProcessor(
+		lReq,
+		fetch,
+		onBatch,
+		nil,
+		auditLogBatchWaitTime,
+	).Run(resp.Context())
+}
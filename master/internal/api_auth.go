@@ -80,6 +80,10 @@ func (a *apiServer) Logout(
 	if err != nil {
 		return nil, err
 	}
+	if userSession == nil {
+		return nil, status.Error(
+			codes.InvalidArgument, "API tokens cannot log out; revoke the token instead")
+	}
 	err = a.m.db.DeleteUserSessionByID(userSession.ID)
 	return &apiv1.LogoutResponse{}, err
 }
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/backup"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/backupv1"
+)
+
+var errBackupsDisabled = status.Error(codes.FailedPrecondition,
+	"the master does not take backups; set backup.type in its configuration")
+
+func backupToProto(m backup.Manifest) *backupv1.Backup {
+	pb := &backupv1.Backup{
+		Name:          m.Name,
+		SchemaVersion: m.SchemaVersion,
+		MasterVersion: m.MasterVersion,
+		ClusterId:     m.ClusterID,
+		CreatedAt:     protoutils.ToTimestamp(m.CreatedAt),
+		Rows:          make(map[string]int32, len(m.Rows)),
+	}
+	for table, rows := range m.Rows {
+		pb.Rows[table] = int32(rows)
+	}
+	return pb
+}
+
+func (a *apiServer) GetBackups(
+	ctx context.Context, _ *apiv1.GetBackupsRequest,
+) (*apiv1.GetBackupsResponse, error) {
+	if a.m.backups == nil {
+		return nil, errBackupsDisabled
+	}
+	manifests, err := a.m.backups.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetBackupsResponse{}
+	for _, m := range manifests {
+		resp.Backups = append(resp.Backups, backupToProto(m))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostBackup(
+	ctx context.Context, _ *apiv1.PostBackupRequest,
+) (*apiv1.PostBackupResponse, error) {
+	if a.m.backups == nil {
+		return nil, errBackupsDisabled
+	}
+	m, err := a.m.backups.Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostBackupResponse{Backup: backupToProto(*m)}, nil
+}
+
+func (a *apiServer) RestoreBackup(
+	ctx context.Context, req *apiv1.RestoreBackupRequest,
+) (*apiv1.RestoreBackupResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.BackupName != "", "no backup specified" },
+	); err != nil {
+		return nil, err
+	}
+	if a.m.backups == nil {
+		return nil, errBackupsDisabled
+	}
+	if !req.DryRun {
+		// The master keeps the state of active experiments in memory, which a restore would not
+		// replace.
+		active, err := a.m.db.NonTerminalExperiments()
+		if err != nil {
+			return nil, err
+		}
+		if len(active) > 0 {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"%d experiments are active; pause or kill them before restoring a backup",
+				len(active))
+		}
+	}
+
+	m, err := a.m.backups.Restore(ctx, req.BackupName, req.DryRun)
+	switch cause := errors.Cause(err); {
+	case cause == backup.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "backup %s not found", req.BackupName)
+	case cause == backup.ErrSchemaVersion:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.RestoreBackupResponse{Backup: backupToProto(*m)}, nil
+}
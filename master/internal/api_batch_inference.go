@@ -0,0 +1,347 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
+	"github.com/determined-ai/determined/proto/pkg/inferencev1"
+)
+
+// predictorPattern matches the "module:function" of the predictor of a batch inference job.
+var predictorPattern = regexp.MustCompile(`^[A-Za-z_][\w.]*:[A-Za-z_]\w*$`)
+
+func (a *apiServer) GetBatchInferenceJobs(
+	ctx context.Context, req *apiv1.GetBatchInferenceJobsRequest,
+) (*apiv1.GetBatchInferenceJobsResponse, error) {
+	projectsExpr, err := a.tenantProjectsExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetBatchInferenceJobsResponse{}
+	return resp, a.m.db.QueryProto("get_batch_inference_jobs", resp,
+		req.ModelName,
+		req.ModelVersion,
+		req.CheckpointUuid,
+		strings.Join(req.Users, ","),
+		req.ProjectId,
+		req.Offset,
+		req.Limit,
+		projectsExpr,
+	)
+}
+
+func (a *apiServer) GetBatchInferenceJob(
+	ctx context.Context, req *apiv1.GetBatchInferenceJobRequest,
+) (*apiv1.GetBatchInferenceJobResponse, error) {
+	resp := &apiv1.GetBatchInferenceJobResponse{Job: &inferencev1.BatchInferenceJob{}}
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "get_batch_inference_job", resp.Job, req.TaskId,
+	); err {
+	case db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "batch inference job %s not found", req.TaskId)
+	default:
+		return resp, errors.Wrapf(err, "error fetching batch inference job %s", req.TaskId)
+	}
+}
+
+func (a *apiServer) KillBatchInferenceJob(
+	ctx context.Context, req *apiv1.KillBatchInferenceJobRequest,
+) (*apiv1.KillBatchInferenceJobResponse, error) {
+	getResp, err := a.GetBatchInferenceJob(
+		ctx, &apiv1.GetBatchInferenceJobRequest{TaskId: req.TaskId})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(ctx, getResp.Job.Username, int(getResp.Job.ProjectId)); err != nil {
+		return nil, err
+	}
+
+	// Jobs that have already ended are left as they are.
+	if addr := batchInferenceAddr(req.TaskId); a.m.system.Get(addr) != nil {
+		var resp *apiv1.KillBatchInferenceJobResponse
+		if err = a.askAtDefaultSystem(addr, req, &resp); err != nil {
+			return nil, err
+		}
+	}
+	if getResp, err = a.GetBatchInferenceJob(
+		ctx, &apiv1.GetBatchInferenceJobRequest{TaskId: req.TaskId},
+	); err != nil {
+		return nil, err
+	}
+	return &apiv1.KillBatchInferenceJobResponse{Job: getResp.Job}, nil
+}
+
+func (a *apiServer) LaunchBatchInferenceJob(
+	ctx context.Context, req *apiv1.LaunchBatchInferenceJobRequest,
+) (*apiv1.LaunchBatchInferenceJobResponse, error) {
+	if err := validateBatchInferenceRequest(req); err != nil {
+		return nil, err
+	}
+
+	// The checkpoint is either that of the model version or given directly.
+	checkpointUUID := req.CheckpointUuid
+	if req.ModelName != "" {
+		version, err := a.servingModel(ctx, req.ModelName, req.ModelVersion)
+		if err != nil {
+			return nil, err
+		}
+		checkpointUUID = version.CheckpointUUID
+	}
+	getResp, err := a.GetCheckpoint(
+		ctx, &apiv1.GetCheckpointRequest{CheckpointUuid: checkpointUUID})
+	if err != nil {
+		return nil, err
+	}
+	if err = checkBatchInferenceCheckpoint(getResp.Checkpoint); err != nil {
+		return nil, err
+	}
+	checkpoint, err := a.m.db.CheckpointByUUID(uuid.MustParse(checkpointUUID))
+	switch {
+	case err != nil:
+		return nil, err
+	case checkpoint == nil:
+		return nil, status.Errorf(codes.NotFound, "checkpoint %s not found", checkpointUUID)
+	}
+	exp, err := a.m.db.ExperimentByID(int(getResp.Checkpoint.ExperimentId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve experiment %d",
+			getResp.Checkpoint.ExperimentId)
+	}
+
+	// The job runs as the user that launches it, who needs to be able to create tasks in the
+	// project.
+	user, project, err := a.checkPermission(ctx, int(req.ProjectId), model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+	agentUserGroup, err := a.m.db.AgentUserGroup(user.ID)
+	switch {
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument,
+			"cannot find user and group information for user %s: %s", user.Username, err)
+	case agentUserGroup == nil:
+		agentUserGroup = &a.m.config.Security.DefaultTask
+	}
+	if err = a.m.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	var modelDefinition archive.Archive
+	if len(req.Files) > 0 {
+		modelDefinition = filesToArchive(req.Files)
+	} else {
+		modelDefBytes, mErr := a.m.db.ExperimentModelDefinitionRaw(exp.ID)
+		if mErr != nil {
+			return nil, errors.Wrapf(mErr,
+				"failed to retrieve the model definition of experiment %d", exp.ID)
+		}
+		if modelDefinition, err = archive.FromTarGz(modelDefBytes); err != nil {
+			return nil, errors.Wrapf(err,
+				"failed to read the model definition of experiment %d", exp.ID)
+		}
+	}
+
+	workspace, err := a.m.db.WorkspaceByID(project.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	slots := int(req.Slots)
+	resourcePool, err := a.batchInferenceResourcePool(
+		user, workspace, exp.Config, req.ResourcePool, slots)
+	if err != nil {
+		return nil, err
+	}
+
+	taskID := string(sproto.NewTaskID())
+	projectID := project.ID
+	record := &model.Task{
+		TaskID:       taskID,
+		TaskType:     model.TaskTypeBatchInference,
+		State:        model.TaskStatePending,
+		Description:  fmt.Sprintf("Batch Inference (Checkpoint %s)", checkpointUUID),
+		OwnerID:      &user.ID,
+		ProjectID:    &projectID,
+		ResourcePool: resourcePool,
+		Slots:        slots,
+		StartTime:    time.Now().UTC(),
+	}
+	job := &model.BatchInferenceJob{
+		TaskID:         taskID,
+		CheckpointUUID: checkpointUUID,
+		Predictor:      req.Predictor,
+		InputPath:      req.InputPath,
+		OutputPath:     req.OutputPath,
+	}
+	if req.ModelName != "" {
+		modelVersion := int(req.ModelVersion)
+		job.ModelName, job.ModelVersion = &req.ModelName, &modelVersion
+	}
+	spec, err := json.Marshal(batchInferenceSpec{
+		Checkpoint: checkpoint,
+		Predictor:  req.Predictor,
+		InputPath:  req.InputPath,
+		OutputPath: req.OutputPath,
+		Lineage: batchInferenceLineage{
+			TaskID:         taskID,
+			ModelName:      req.ModelName,
+			ModelVersion:   int(req.ModelVersion),
+			CheckpointUUID: checkpointUUID,
+			ExperimentID:   exp.ID,
+			TrialID:        checkpoint.TrialID,
+			Predictor:      req.Predictor,
+			InputPath:      req.InputPath,
+			Username:       user.Username,
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode the batch inference job")
+	}
+	if err = a.m.db.AddBatchInferenceJob(record, job); err != nil {
+		return nil, err
+	}
+
+	taskSpec := a.m.makeTaskSpec(resourcePool, slots)
+	if _, ok := a.m.system.ActorOf(batchInferenceAddr(taskID), &batchInferenceTask{
+		rm:              a.m.rm,
+		db:              a.m.db,
+		experiment:      exp,
+		record:          record,
+		job:             job,
+		spec:            spec,
+		modelDefinition: modelDefinition,
+		agentUserGroup:  agentUserGroup,
+		taskSpec:        &taskSpec,
+		vault:           a.m.vault,
+		ca:              a.m.ca,
+	}); !ok {
+		return nil, status.Errorf(codes.Internal, "failed to start the batch inference job")
+	}
+
+	jobResp, err := a.GetBatchInferenceJob(ctx, &apiv1.GetBatchInferenceJobRequest{TaskId: taskID})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.LaunchBatchInferenceJobResponse{Job: jobResp.Job}, nil
+}
+
+// batchInferenceResourcePool returns the resource pool of a batch inference job that a user
+// launches in a workspace, from the experiment config of its checkpoint, or a PermissionDenied
+// error if the config policies, the tenancy of the workspace or pool routing forbid the job. Like
+// experiments, jobs go to the pool that the policies force them into, else to the one they ask for
+// or that experiments go to by default, and else to that of the experiment.
+func (a *apiServer) batchInferenceResourcePool(
+	user *model.User, workspace *model.Workspace, config expconf.ExperimentConfig, pool string,
+	slots int,
+) (string, error) {
+	policies, err := a.m.configPolicies(user.ID, workspace.ID)
+	if err != nil {
+		return "", err
+	}
+	routedPool := a.m.config.PoolRouting.BatchResourcePool
+	defaultPool := policies.experimentResourcePool(defaultResourcePool(workspace, routedPool))
+	switch forced := policies.experimentResourcePool(""); {
+	case forced != "":
+		pool = forced
+	case pool == "" && defaultPool != "":
+		pool = defaultPool
+	case pool == "":
+		pool = config.Resources().ResourcePool()
+	}
+
+	image := config.Environment().Image().CPU()
+	if slots > 0 {
+		image = config.Environment().Image().GPU()
+	}
+	if errs := policies.checkConstraints("slots", slots, image); len(errs) > 0 {
+		return "", status.Error(codes.PermissionDenied, errs[0].Error())
+	}
+	if err = a.m.checkResourcePoolTenant(workspace, pool, slots); err != nil {
+		return "", status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err = a.m.checkPoolRouting(user, workspace.ID, routedPool, defaultPool, pool); err != nil {
+		return "", status.Error(codes.PermissionDenied, err.Error())
+	}
+	return pool, nil
+}
+
+// validateBatchInferenceRequest returns an InvalidArgument error if a request to launch a batch
+// inference job is malformed.
+func validateBatchInferenceRequest(req *apiv1.LaunchBatchInferenceJobRequest) error {
+	switch {
+	case (req.ModelName == "") == (req.CheckpointUuid == ""):
+		return status.Error(codes.InvalidArgument,
+			"exactly one of a model version and a checkpoint must be given")
+	case req.ModelName != "" && req.ModelVersion <= 0:
+		return status.Error(codes.InvalidArgument, "the version of the model must be given")
+	case !predictorPattern.MatchString(req.Predictor):
+		return status.Errorf(codes.InvalidArgument,
+			"predictor %q must be the module and name of a function, as in module:function",
+			req.Predictor)
+	case !filepath.IsAbs(req.InputPath) || !filepath.IsAbs(req.OutputPath):
+		return status.Error(codes.InvalidArgument,
+			"the input and output paths must be absolute paths in the container")
+	case filepath.Clean(req.InputPath) == filepath.Clean(req.OutputPath):
+		return status.Error(codes.InvalidArgument,
+			"the input and output paths must be different")
+	case req.Slots < 0:
+		return status.Error(codes.InvalidArgument, "the number of slots cannot be negative")
+	}
+	if req.CheckpointUuid != "" {
+		if _, err := uuid.Parse(req.CheckpointUuid); err != nil {
+			return status.Errorf(codes.InvalidArgument,
+				"checkpoint %q is not a valid UUID", req.CheckpointUuid)
+		}
+	}
+	return nil
+}
+
+// checkBatchInferenceCheckpoint returns a FailedPrecondition error unless the checkpoint can make
+// batch predictions: it must be a completed checkpoint of an experiment of this cluster, whose
+// environment the predictions are made in, and not be known to be corrupted or missing.
+func checkBatchInferenceCheckpoint(c *checkpointv1.Checkpoint) error {
+	switch {
+	case c.ExperimentId == 0:
+		return status.Errorf(codes.FailedPrecondition,
+			"checkpoint %s was imported from another cluster, where its experiment ran", c.Uuid)
+	case c.State != checkpointv1.State_STATE_COMPLETED:
+		return status.Errorf(codes.FailedPrecondition, "checkpoint %s is not completed", c.Uuid)
+	case c.Integrity == checkpointv1.Integrity_INTEGRITY_CORRUPTED,
+		c.Integrity == checkpointv1.Integrity_INTEGRITY_MISSING:
+		return status.Errorf(codes.FailedPrecondition, "checkpoint %s is %s", c.Uuid,
+			strings.ToLower(strings.TrimPrefix(c.Integrity.String(), "INTEGRITY_")))
+	}
+	return nil
+}
+
+func (a *apiServer) ReportBatchInferenceProgress(
+	ctx context.Context, req *apiv1.ReportBatchInferenceProgressRequest,
+) (resp *apiv1.ReportBatchInferenceProgressResponse, err error) {
+	session, err := grpcutil.GetTaskSession(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	addr := batchInferenceAddr(session.TaskID)
+	if a.m.system.Get(addr) == nil {
+		return nil, status.Errorf(codes.NotFound,
+			"task %s is not a running batch inference job", session.TaskID)
+	}
+	return resp, a.askAtDefaultSystem(addr, req, &resp)
+}
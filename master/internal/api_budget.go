@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/budgetv1"
+)
+
+var budgetUnits = map[model.BudgetUnit]budgetv1.Budget_Unit{
+	model.BudgetUnitSlotHours: budgetv1.Budget_UNIT_SLOT_HOURS,
+	model.BudgetUnitCost:      budgetv1.Budget_UNIT_COST,
+}
+
+var budgetModes = map[model.BudgetMode]budgetv1.Budget_Mode{
+	model.BudgetModeSoft: budgetv1.Budget_MODE_SOFT,
+	model.BudgetModeHard: budgetv1.Budget_MODE_HARD,
+}
+
+// budgetToProto converts a budget to its proto representation, with its usage this month.
+func (a *apiServer) budgetToProto(b model.Budget) (*budgetv1.Budget, error) {
+	pb := &budgetv1.Budget{
+		Id:      int32(b.ID),
+		Unit:    budgetUnits[b.Unit],
+		Limit:   b.Limit,
+		Mode:    budgetModes[b.Mode],
+		Preempt: b.Preempt,
+	}
+	if b.UserID != nil {
+		user, err := a.m.db.UserByID(*b.UserID)
+		if err != nil {
+			return nil, err
+		}
+		pb.Username = user.Username
+	}
+	if b.ProjectID != nil {
+		pb.ProjectId = int32(*b.ProjectID)
+	}
+	slotHours, err := a.m.db.BudgetSlotHours(b, model.BudgetPeriodStart(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	pb.Usage = b.Usage(slotHours, a.m.config.SlotHourPrices())
+	return pb, nil
+}
+
+func (a *apiServer) GetBudgets(
+	_ context.Context, _ *apiv1.GetBudgetsRequest,
+) (*apiv1.GetBudgetsResponse, error) {
+	budgets, err := a.m.db.Budgets()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetBudgetsResponse{}
+	for _, b := range budgets {
+		pb, err := a.budgetToProto(b)
+		if err != nil {
+			return nil, err
+		}
+		resp.Budgets = append(resp.Budgets, pb)
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostBudget(
+	_ context.Context, req *apiv1.PostBudgetRequest,
+) (*apiv1.PostBudgetResponse, error) {
+	pb := req.Budget
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return pb != nil, "no budget specified" },
+		func() (bool, string) {
+			return (pb.Username == "") != (pb.ProjectId == 0),
+				"exactly one of username and project_id must be specified"
+		},
+		func() (bool, string) { return pb.Limit >= 0, "limit must be non-negative" },
+		func() (bool, string) {
+			return pb.Unit != budgetv1.Budget_UNIT_UNSPECIFIED, "unit must be specified"
+		},
+		func() (bool, string) {
+			return pb.Mode != budgetv1.Budget_MODE_UNSPECIFIED, "mode must be specified"
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	b := model.Budget{Limit: pb.Limit, Preempt: pb.Preempt}
+	for unit, pbUnit := range budgetUnits {
+		if pbUnit == pb.Unit {
+			b.Unit = unit
+		}
+	}
+	for mode, pbMode := range budgetModes {
+		if pbMode == pb.Mode {
+			b.Mode = mode
+		}
+	}
+	if pb.Username != "" {
+		switch user, err := a.m.db.UserByUsername(pb.Username); {
+		case err == db.ErrNotFound:
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", pb.Username)
+		case err != nil:
+			return nil, err
+		default:
+			b.UserID = &user.ID
+		}
+	} else {
+		switch _, err := a.m.db.ProjectByID(int(pb.ProjectId)); {
+		case errors.Cause(err) == db.ErrNotFound:
+			return nil, status.Errorf(codes.NotFound, "project not found: %d", pb.ProjectId)
+		case err != nil:
+			return nil, err
+		}
+		projectID := int(pb.ProjectId)
+		b.ProjectID = &projectID
+	}
+
+	if err := a.m.db.AddBudget(&b); err != nil {
+		return nil, err
+	}
+	created, err := a.budgetToProto(b)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostBudgetResponse{Budget: created}, nil
+}
+
+func (a *apiServer) DeleteBudget(
+	_ context.Context, req *apiv1.DeleteBudgetRequest,
+) (*apiv1.DeleteBudgetResponse, error) {
+	switch err := a.m.db.DeleteBudget(int(req.BudgetId)); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "budget not found: %d", req.BudgetId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteBudgetResponse{}, nil
+}
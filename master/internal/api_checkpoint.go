@@ -2,7 +2,9 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
@@ -10,15 +12,20 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
 )
 
 func (a *apiServer) GetCheckpoint(
-	_ context.Context, req *apiv1.GetCheckpointRequest) (*apiv1.GetCheckpointResponse, error) {
+	ctx context.Context, req *apiv1.GetCheckpointRequest) (*apiv1.GetCheckpointResponse, error) {
 	resp := &apiv1.GetCheckpointResponse{}
 	resp.Checkpoint = &checkpointv1.Checkpoint{}
-	switch err := a.m.db.QueryProto("get_checkpoint", resp.Checkpoint, req.CheckpointUuid); err {
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "get_checkpoint", resp.Checkpoint, req.CheckpointUuid,
+	); err {
 	case db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "checkpoint %s not found", req.CheckpointUuid)
@@ -52,9 +59,215 @@ func (a *apiServer) PostCheckpointMetadata(
 	currCheckpoint.Metadata = req.Checkpoint.Metadata
 	log.Infof("checkpoint (%s) metadata changing from %s to %s",
 		req.Checkpoint.Uuid, currMeta, newMeta)
-	err = a.m.db.QueryProto("update_checkpoint_metadata",
+	err = a.m.db.QueryProtoContext(ctx, "update_checkpoint_metadata",
 		&checkpointv1.Checkpoint{}, req.Checkpoint.Uuid, newMeta)
 
 	return &apiv1.PostCheckpointMetadataResponse{Checkpoint: currCheckpoint},
 		errors.Wrapf(err, "error updating checkpoint %s in database", req.Checkpoint.Uuid)
 }
+
+func (a *apiServer) ExportCheckpoint(
+	ctx context.Context, req *apiv1.ExportCheckpointRequest,
+) (*apiv1.ExportCheckpointResponse, error) {
+	getResp, err := a.GetCheckpoint(ctx,
+		&apiv1.GetCheckpointRequest{CheckpointUuid: req.CheckpointUuid})
+	if err != nil {
+		return nil, err
+	}
+	if getResp.Checkpoint.State != checkpointv1.State_STATE_COMPLETED {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"checkpoint %s is in %s state, only completed checkpoints can be exported",
+			req.CheckpointUuid, getResp.Checkpoint.State)
+	}
+	return &apiv1.ExportCheckpointResponse{Bundle: &checkpointv1.CheckpointBundle{
+		ClusterId:  a.m.ClusterID,
+		Checkpoint: getResp.Checkpoint,
+	}}, nil
+}
+
+// importedCheckpointStorage returns the checkpoint storage configuration of an imported checkpoint:
+// that of its bundle, without the credentials that the exporting cluster redacted, and with the
+// fields of the request replacing those of the bundle.
+func importedCheckpointStorage(
+	bundled interface{}, replaced map[string]interface{},
+) (map[string]interface{}, error) {
+	storage := map[string]interface{}{}
+	if bundled, ok := bundled.(map[string]interface{}); ok {
+		for key, value := range bundled {
+			if value != model.RedactedCredential {
+				storage[key] = value
+			}
+		}
+	}
+	for key, value := range replaced {
+		for _, path := range model.StorageCredentialPaths {
+			if path == "checkpoint_storage."+key {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"imported checkpoints are read with the credentials of the environment, "+
+						"%s cannot be given", key)
+			}
+		}
+		storage[key] = value
+	}
+	return storage, nil
+}
+
+func (a *apiServer) ImportCheckpoint(
+	ctx context.Context, req *apiv1.ImportCheckpointRequest,
+) (*apiv1.ImportCheckpointResponse, error) {
+	var c *checkpointv1.Checkpoint
+	if req.Bundle != nil {
+		c = req.Bundle.Checkpoint
+	}
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return c != nil, "no checkpoint bundle specified" },
+		func() (bool, string) {
+			_, err := uuid.Parse(c.Uuid)
+			return err == nil, "the checkpoint of the bundle must have a valid uuid"
+		},
+		func() (bool, string) {
+			return c.State == checkpointv1.State_STATE_COMPLETED,
+				"only completed checkpoints can be imported"
+		},
+		func() (bool, string) {
+			return c.ExperimentConfig != nil && c.StartTime != nil,
+				"the checkpoint of the bundle must have an experiment config and a start time"
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	config := c.ExperimentConfig.AsMap()
+	storage, err := importedCheckpointStorage(
+		config["checkpoint_storage"], req.CheckpointStorage.AsMap())
+	if err != nil {
+		return nil, err
+	}
+	config["checkpoint_storage"] = storage
+
+	var metrics model.JSONObj
+	if c.Metrics != nil {
+		b, mErr := protojson.MarshalOptions{UseProtoNames: true}.Marshal(c.Metrics)
+		if mErr != nil {
+			return nil, errors.Wrap(mErr, "error marshaling checkpoint metrics")
+		}
+		if err = json.Unmarshal(b, &metrics); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling checkpoint metrics")
+		}
+	}
+	source := model.JSONObj{
+		"cluster_id":        req.Bundle.ClusterId,
+		"experiment_id":     c.ExperimentId,
+		"trial_id":          c.TrialId,
+		"experiment_config": config,
+		"hparams":           c.Hparams.AsMap(),
+		"metrics":           metrics,
+	}
+	if c.ValidationState != checkpointv1.State_STATE_UNSPECIFIED {
+		source["validation_state"] = c.ValidationState.String()
+	}
+
+	checkpoint := model.Checkpoint{
+		TotalBatches:      int(c.BatchNumber),
+		State:             model.CompletedState,
+		StartTime:         c.StartTime.AsTime(),
+		UUID:              &c.Uuid,
+		Resources:         model.JSONObjFromMapStringInt64(c.Resources),
+		Metadata:          model.JSONObj(c.Metadata.AsMap()),
+		Framework:         c.Framework,
+		Format:            c.Format,
+		DeterminedVersion: c.DeterminedVersion,
+	}
+	if c.EndTime != nil {
+		endTime := c.EndTime.AsTime()
+		checkpoint.EndTime = &endTime
+	}
+	switch err = a.m.db.AddImportedCheckpoint(&checkpoint, source); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(codes.AlreadyExists, "checkpoint %s already exists", c.Uuid)
+	case err != nil:
+		return nil, err
+	}
+	log.Infof("imported checkpoint %s from cluster %s", c.Uuid, req.Bundle.ClusterId)
+
+	getResp, err := a.GetCheckpoint(ctx, &apiv1.GetCheckpointRequest{CheckpointUuid: c.Uuid})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ImportCheckpointResponse{Checkpoint: getResp.Checkpoint}, nil
+}
+
+func (a *apiServer) VerifyExperimentCheckpoints(
+	ctx context.Context, req *apiv1.VerifyExperimentCheckpointsRequest,
+) (*apiv1.VerifyExperimentCheckpointsResponse, error) {
+	if err := a.checkExperimentOwner(ctx, int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
+	exp, err := a.m.db.ExperimentByID(int(req.ExperimentId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve experiment %d", req.ExperimentId)
+	}
+
+	toVerify, err := a.m.db.ExperimentCheckpointsToVerifyRaw(exp.ID, req.CheckpointUuids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the checkpoints to verify")
+	}
+	var found checkpointsToVerify
+	if err = json.Unmarshal(toVerify, &found); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the checkpoints to verify")
+	}
+	switch {
+	case len(found.Checkpoints) == 0:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"experiment %d has no completed checkpoints to verify", exp.ID)
+	case len(req.CheckpointUuids) > 0 && len(found.Checkpoints) < len(req.CheckpointUuids):
+		return nil, status.Errorf(codes.NotFound,
+			"some of the checkpoints are not completed checkpoints of experiment %d", exp.ID)
+	}
+
+	agentUserGroup, err := a.m.db.AgentUserGroup(*exp.OwnerID)
+	switch {
+	case err != nil:
+		return nil, errors.Errorf("cannot find user and group for experiment")
+	case agentUserGroup == nil:
+		agentUserGroup = &a.m.config.Security.DefaultTask
+	}
+	if err = a.m.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	taskID := sproto.NewTaskID()
+	if _, ok := a.m.system.ActorOf(checkpointVerificationAddr(string(taskID)),
+		&checkpointVerificationTask{
+			rm:             a.m.rm,
+			db:             a.m.db,
+			experiment:     exp,
+			taskID:         taskID,
+			toVerify:       toVerify,
+			agentUserGroup: agentUserGroup,
+			taskSpec:       a.m.taskSpec,
+			vault:          a.m.vault,
+			ca:             a.m.ca,
+		}); !ok {
+		return nil, status.Errorf(codes.Internal, "failed to start the verification task")
+	}
+	return &apiv1.VerifyExperimentCheckpointsResponse{
+		TaskId:         string(taskID),
+		NumCheckpoints: int32(len(found.Checkpoints)),
+	}, nil
+}
+
+func (a *apiServer) ReportCheckpointIntegrity(
+	ctx context.Context, req *apiv1.ReportCheckpointIntegrityRequest,
+) (resp *apiv1.ReportCheckpointIntegrityResponse, err error) {
+	session, err := grpcutil.GetTaskSession(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	addr := checkpointVerificationAddr(session.TaskID)
+	if a.m.system.Get(addr) == nil {
+		return nil, status.Errorf(codes.NotFound,
+			"task %s does not verify checkpoints", session.TaskID)
+	}
+	return resp, a.askAtDefaultSystem(addr, req, &resp)
+}
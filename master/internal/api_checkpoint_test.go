@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestImportedCheckpointStorage(t *testing.T) {
+	bundled := map[string]interface{}{
+		"type":       "s3",
+		"bucket":     "research",
+		"access_key": model.RedactedCredential,
+		"secret_key": model.RedactedCredential,
+	}
+
+	// The redacted credentials of the exporting cluster are dropped and the bucket is rewritten.
+	storage, err := importedCheckpointStorage(bundled, map[string]interface{}{"bucket": "prod"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, storage, map[string]interface{}{"type": "s3", "bucket": "prod"})
+
+	_, err = importedCheckpointStorage(bundled, map[string]interface{}{"secret_key": "secret"})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/codeserverv1"
+	"github.com/determined-ai/determined/proto/pkg/logv1"
+)
+
+var codeServersAddr = actor.Addr("code-servers")
+
+func (a *apiServer) GetCodeServers(
+	ctx context.Context, req *apiv1.GetCodeServersRequest,
+) (resp *apiv1.GetCodeServersResponse, err error) {
+	msg, err := a.inTenantProjects(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.actorRequest("/code-servers", msg, &resp); err != nil {
+		return nil, err
+	}
+	for _, codeServer := range resp.CodeServers {
+		a.hideCodeServerToken(ctx, codeServer)
+	}
+	return resp, nil
+}
+
+func (a *apiServer) GetCodeServer(
+	ctx context.Context, req *apiv1.GetCodeServerRequest,
+) (resp *apiv1.GetCodeServerResponse, err error) {
+	err = a.actorRequest(fmt.Sprintf("/code-servers/%s", req.CodeServerId), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	a.hideCodeServerToken(ctx, resp.CodeServer)
+	return resp, nil
+}
+
+func (a *apiServer) KillCodeServer(
+	ctx context.Context, req *apiv1.KillCodeServerRequest,
+) (resp *apiv1.KillCodeServerResponse, err error) {
+	if err = a.checkCodeServerOwner(ctx, req.CodeServerId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/code-servers/%s", req.CodeServerId), req, &resp)
+}
+
+// checkCodeServerOwner is checkOwner for the code server with the given ID.
+func (a *apiServer) checkCodeServerOwner(ctx context.Context, id string) error {
+	codeServer, err := a.GetCodeServer(ctx, &apiv1.GetCodeServerRequest{CodeServerId: id})
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(
+		ctx, codeServer.CodeServer.Username, int(codeServer.CodeServer.ProjectId))
+}
+
+// hideCodeServerToken clears the token of a code server unless the current user may manage it, so
+// that other users who can see the code server cannot log into it.
+func (a *apiServer) hideCodeServerToken(ctx context.Context, codeServer *codeserverv1.CodeServer) {
+	if a.checkOwner(ctx, codeServer.Username, int(codeServer.ProjectId)) != nil {
+		codeServer.Token = ""
+	}
+}
+
+func (a *apiServer) CodeServerLogs(
+	req *apiv1.CodeServerLogsRequest, resp apiv1.Determined_CodeServerLogsServer) error {
+	if err := grpcutil.ValidateRequest(
+		grpcutil.ValidateLimit(req.Limit),
+	); err != nil {
+		return err
+	}
+
+	cmdManagerAddr := codeServersAddr.Child(req.CodeServerId)
+	eventManager := a.m.system.Get(cmdManagerAddr.Child("events"))
+
+	logRequest := api.BatchRequest{
+		Offset: int(req.Offset),
+		Limit:  int(req.Limit),
+		Follow: req.Follow,
+	}
+
+	onBatch := func(b api.Batch) error {
+		return b.ForEach(func(r interface{}) error {
+			lr := r.(*logger.Entry)
+			return resp.Send(&apiv1.CodeServerLogsResponse{
+				LogEntry: &logv1.LogEntry{Id: int32(lr.ID), Message: lr.Message},
+			})
+		})
+	}
+
+	return a.m.system.MustActorOf(
+		cmdManagerAddr.Child("logStream-"+uuid.New().String()),
+		api.NewLogStreamProcessor(
+			resp.Context(),
+			eventManager,
+			logRequest,
+			onBatch,
+		),
+	).AwaitTermination()
+}
+
+func (a *apiServer) LaunchCodeServer(
+	ctx context.Context, req *apiv1.LaunchCodeServerRequest,
+) (*apiv1.LaunchCodeServerResponse, error) {
+	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		ProjectID:         int(req.ProjectId),
+		Interactive:       true,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to prepare launch params")
+	}
+
+	if req.Preview {
+		return &apiv1.LaunchCodeServerResponse{
+			CodeServer: &codeserverv1.CodeServer{},
+			Config:     protoutils.ToStruct(*params.FullConfig),
+		}, nil
+	}
+
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
+	codeServerLaunchReq := command.CodeServerLaunchRequest{CommandParams: params}
+	codeServerIDFut := a.m.system.AskAt(codeServersAddr, codeServerLaunchReq)
+	if err = api.ProcessActorResponseError(&codeServerIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
+		return nil, err
+	}
+
+	codeServerID := codeServerIDFut.Get().(sproto.TaskID)
+	codeServer := a.m.system.AskAt(
+		codeServersAddr.Child(codeServerID), &codeserverv1.CodeServer{})
+	if err = api.ProcessActorResponseError(&codeServer); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LaunchCodeServerResponse{
+		CodeServer: codeServer.Get().(*codeserverv1.CodeServer),
+		Config:     protoutils.ToStruct(*params.FullConfig),
+	}, nil
+}
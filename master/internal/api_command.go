@@ -16,8 +16,8 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/command"
-	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
@@ -33,19 +33,35 @@ type protoCommandParams struct {
 	TemplateName string
 	Config       *pstruct.Struct
 	Files        []*utilv1.File
-	Data         []byte
-	MustZeroSlot bool
-	Preview      bool
+	// ContextArtifactID is an uploaded context artifact to launch with instead of Files.
+	ContextArtifactID string
+	Data              []byte
+	MustZeroSlot      bool
+	Preview           bool
+	ProjectID         int
+	// Interactive is whether the task is a notebook, shell, TensorBoard or code server, which pool
+	// routing sends to the interactive resource pool.
+	Interactive bool
 }
 
 func (a *apiServer) makeFullCommandSpec(
-	configBytes []byte, templateName *string, mustBeZeroSlot bool,
+	configBytes []byte, templateName *string, mustBeZeroSlot bool, workspace *model.Workspace,
+	policies configPolicies, routedPool string,
 ) (*model.CommandConfig, *tasks.TaskSpec, error) {
+	defaultPool := defaultResourcePool(workspace, routedPool)
 	resources := model.ParseJustResources(configBytes)
+	if resources.ResourcePool == "" {
+		resources.ResourcePool = defaultPool
+	}
+	resources.ResourcePool = policies.commandResourcePool(resources.ResourcePool)
 	taskSpec := a.m.makeTaskSpec(resources.ResourcePool, resources.Slots)
+	if image := workspace.DefaultImageItem(); image != nil {
+		taskSpec.TaskContainerDefaults.Image = image
+	}
 	config := command.DefaultConfig(&taskSpec.TaskContainerDefaults)
+	config.Resources.ResourcePool = defaultPool
 	if templateName != nil && *templateName != "" {
-		template, err := a.m.db.TemplateByName(*templateName)
+		template, err := a.m.db.TemplateWithStorageCredentials(*templateName)
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "failed to find template: %s", *templateName)
 		}
@@ -66,6 +82,9 @@ func (a *apiServer) makeFullCommandSpec(
 			)
 		}
 	}
+	if err := policies.mergeIntoCommandConfig(&config); err != nil {
+		return nil, nil, err
+	}
 
 	// mustBeZeroSlot indicates that this type of command may never use more than
 	// zero slots (as of Jan 2021, this is only Tensorboards). This is important
@@ -100,13 +119,20 @@ func (a *apiServer) makeFullCommandSpec(
 func (a *apiServer) prepareLaunchParams(ctx context.Context, req *protoCommandParams) (
 	*command.CommandParams, error,
 ) {
-	params := command.CommandParams{}
+	params := command.CommandParams{SpanContext: tracing.SpanContextFromContext(ctx)}
 	var err error
 
-	// Must get the user and the agent user group
-	params.User, _, err = grpcutil.GetUser(ctx, a.m.db)
+	// Must get the user, who needs to be able to create tasks in the project, and the agent user
+	// group.
+	var project *model.Project
+	params.User, project, err = a.checkPermission(ctx, req.ProjectID, model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+	params.ProjectID = project.ID
+	workspace, err := a.m.db.WorkspaceByID(project.WorkspaceID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "failed to get the user: %s", err)
+		return nil, err
 	}
 	params.AgentUserGroup, err = a.m.db.AgentUserGroup(params.User.ID)
 	if err != nil {
@@ -120,6 +146,9 @@ func (a *apiServer) prepareLaunchParams(ctx context.Context, req *protoCommandPa
 	if params.AgentUserGroup == nil {
 		params.AgentUserGroup = &a.m.config.Security.DefaultTask
 	}
+	if err = a.m.config.Security.CheckAgentUserGroup(*params.AgentUserGroup); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 
 	// Get the full configuration.
 	var configBytes []byte
@@ -131,16 +160,58 @@ func (a *apiServer) prepareLaunchParams(ctx context.Context, req *protoCommandPa
 		}
 	}
 
+	policies, err := a.m.configPolicies(params.User.ID, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	var routedPool string
+	if req.Interactive {
+		routedPool = a.m.config.PoolRouting.InteractiveResourcePool
+	}
 	params.FullConfig, params.TaskSpec, err = a.makeFullCommandSpec(
-		configBytes, &req.TemplateName, req.MustZeroSlot)
+		configBytes, &req.TemplateName, req.MustZeroSlot, workspace, policies, routedPool)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to make command spec: %s", err)
 	}
+	if errs := policies.checkCommandConfig(*params.FullConfig); len(errs) > 0 {
+		return nil, status.Error(codes.PermissionDenied, errs[0].Error())
+	}
+	resources := params.FullConfig.Resources
+	err = a.m.checkResourcePoolTenant(workspace, resources.ResourcePool, resources.Slots)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	err = a.m.checkPoolRouting(params.User, workspace.ID, routedPool,
+		policies.commandResourcePool(defaultResourcePool(workspace, routedPool)),
+		resources.ResourcePool)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	err = a.m.checkKubernetesNamespace(params.FullConfig.Environment.Kubernetes.Namespace)
+	if err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if err = a.m.db.CheckDatasets(params.FullConfig.Datasets); err != nil {
+		return nil, datasetStatus(err)
+	}
 
 	if len(req.Files) > 0 {
 		params.UserFiles = filesToArchive(req.Files)
 	}
 
+	if req.ContextArtifactID != "" {
+		if len(req.Files) > 0 {
+			return nil, status.Error(codes.InvalidArgument,
+				"files and context_artifact_id cannot both be specified")
+		}
+		if err = a.m.checkContextArtifact(params.User, req.ContextArtifactID); err != nil {
+			return nil, err
+		}
+		params.ContextArtifact = req.ContextArtifactID
+	}
+
 	if len(req.Data) > 0 {
 		var data map[string]interface{}
 		if err = json.Unmarshal(req.Data, &data); err != nil {
@@ -153,14 +224,13 @@ func (a *apiServer) prepareLaunchParams(ctx context.Context, req *protoCommandPa
 }
 
 func (a *apiServer) GetCommands(
-	_ context.Context, req *apiv1.GetCommandsRequest,
+	ctx context.Context, req *apiv1.GetCommandsRequest,
 ) (resp *apiv1.GetCommandsResponse, err error) {
-	err = a.actorRequest("/commands", req, &resp)
+	msg, err := a.inTenantProjects(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	a.sort(resp.Commands, req.OrderBy, req.SortBy, apiv1.GetCommandsRequest_SORT_BY_ID)
-	return resp, a.paginate(&resp.Pagination, &resp.Commands, req.Offset, req.Limit)
+	return resp, a.actorRequest("/commands", msg, &resp)
 }
 
 func (a *apiServer) GetCommand(
@@ -169,26 +239,69 @@ func (a *apiServer) GetCommand(
 }
 
 func (a *apiServer) KillCommand(
-	_ context.Context, req *apiv1.KillCommandRequest) (resp *apiv1.KillCommandResponse, err error) {
+	ctx context.Context, req *apiv1.KillCommandRequest) (resp *apiv1.KillCommandResponse, err error) {
+	cmd, err := a.GetCommand(ctx, &apiv1.GetCommandRequest{CommandId: req.CommandId})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(
+		ctx, cmd.Command.Username, int(cmd.Command.ProjectId),
+	); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/commands/%s", req.CommandId), req, &resp)
+}
+
+func (a *apiServer) ArchiveCommand(
+	ctx context.Context, req *apiv1.ArchiveCommandRequest,
+) (resp *apiv1.ArchiveCommandResponse, err error) {
+	if err = a.checkCommandOwner(ctx, req.CommandId); err != nil {
+		return nil, err
+	}
 	return resp, a.actorRequest(fmt.Sprintf("/commands/%s", req.CommandId), req, &resp)
 }
 
+func (a *apiServer) UnarchiveCommand(
+	ctx context.Context, req *apiv1.UnarchiveCommandRequest,
+) (resp *apiv1.UnarchiveCommandResponse, err error) {
+	if err = a.checkCommandOwner(ctx, req.CommandId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/commands/%s", req.CommandId), req, &resp)
+}
+
+// checkCommandOwner is checkOwner for the command with the given ID.
+func (a *apiServer) checkCommandOwner(ctx context.Context, id string) error {
+	cmd, err := a.GetCommand(ctx, &apiv1.GetCommandRequest{CommandId: id})
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(ctx, cmd.Command.Username, int(cmd.Command.ProjectId))
+}
+
 func (a *apiServer) LaunchCommand(
 	ctx context.Context, req *apiv1.LaunchCommandRequest,
 ) (*apiv1.LaunchCommandResponse, error) {
 	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
-		Data:         req.Data,
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		Data:              req.Data,
+		ProjectID:         int(req.ProjectId),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
 	commandLaunchReq := command.CommandLaunchRequest{CommandParams: params}
 	commandIDFut := a.m.system.AskAt(commandsAddr, commandLaunchReq)
 	if err = api.ProcessActorResponseError(&commandIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
 		return nil, err
 	}
 
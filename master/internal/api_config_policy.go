@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/configpolicyv1"
+)
+
+// groupNames returns the names of the groups by their IDs.
+func (a *apiServer) groupNames() (map[int]string, error) {
+	groups, err := a.m.db.Groups()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(groups))
+	for _, g := range groups {
+		names[g.ID] = g.Name
+	}
+	return names, nil
+}
+
+// configPolicyToProto converts a config policy to its proto representation.
+func configPolicyToProto(
+	p model.ConfigPolicy, groupNames map[int]string,
+) *configpolicyv1.ConfigPolicy {
+	pb := &configpolicyv1.ConfigPolicy{
+		Id:            int32(p.ID),
+		AllowedImages: p.Constraints.AllowedImages,
+	}
+	if p.WorkspaceID != nil {
+		pb.WorkspaceId = int32(*p.WorkspaceID)
+	}
+	if p.GroupID != nil {
+		pb.GroupName = groupNames[*p.GroupID]
+	}
+	if len(p.InvariantExperimentConfig) > 0 {
+		pb.InvariantExperimentConfig = protoutils.ToStruct(p.InvariantExperimentConfig)
+	}
+	if len(p.InvariantCommandConfig) > 0 {
+		pb.InvariantCommandConfig = protoutils.ToStruct(p.InvariantCommandConfig)
+	}
+	if p.Constraints.MaxSlots != nil {
+		pb.MaxSlots = wrapperspb.Int32(int32(*p.Constraints.MaxSlots))
+	}
+	return pb
+}
+
+func (a *apiServer) GetConfigPolicies(
+	_ context.Context, _ *apiv1.GetConfigPoliciesRequest,
+) (*apiv1.GetConfigPoliciesResponse, error) {
+	policies, err := a.m.db.ConfigPolicies()
+	if err != nil {
+		return nil, err
+	}
+	groupNames, err := a.groupNames()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetConfigPoliciesResponse{}
+	for _, p := range policies {
+		resp.ConfigPolicies = append(resp.ConfigPolicies, configPolicyToProto(p, groupNames))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostConfigPolicy(
+	_ context.Context, req *apiv1.PostConfigPolicyRequest,
+) (*apiv1.PostConfigPolicyResponse, error) {
+	pb := req.ConfigPolicy
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return pb != nil, "no config policy specified" },
+		func() (bool, string) {
+			return (pb.WorkspaceId == 0) != (pb.GroupName == ""),
+				"exactly one of workspace_id and group_name must be specified"
+		},
+		func() (bool, string) {
+			return pb.MaxSlots == nil || pb.MaxSlots.Value >= 0, "max_slots must be non-negative"
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	p := model.ConfigPolicy{
+		InvariantExperimentConfig: model.JSONObj{},
+		InvariantCommandConfig:    model.JSONObj{},
+		Constraints:               model.ConfigPolicyConstraints{AllowedImages: pb.AllowedImages},
+	}
+	if pb.InvariantExperimentConfig != nil {
+		p.InvariantExperimentConfig = pb.InvariantExperimentConfig.AsMap()
+		if _, err := parseInvariantExperimentConfig(p.InvariantExperimentConfig); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if pb.InvariantCommandConfig != nil {
+		p.InvariantCommandConfig = pb.InvariantCommandConfig.AsMap()
+		var config model.CommandConfig
+		if err := decodeInvariantCommandConfig(p.InvariantCommandConfig, &config); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if pb.MaxSlots != nil {
+		maxSlots := int(pb.MaxSlots.Value)
+		p.Constraints.MaxSlots = &maxSlots
+	}
+
+	var groupNames map[int]string
+	if pb.WorkspaceId != 0 {
+		switch _, err := a.m.db.WorkspaceByID(int(pb.WorkspaceId)); {
+		case errors.Cause(err) == db.ErrNotFound:
+			return nil, status.Errorf(codes.NotFound, "workspace not found: %d", pb.WorkspaceId)
+		case err != nil:
+			return nil, err
+		}
+		workspaceID := int(pb.WorkspaceId)
+		p.WorkspaceID = &workspaceID
+	} else {
+		var err error
+		if groupNames, err = a.groupNames(); err != nil {
+			return nil, err
+		}
+		for id, name := range groupNames {
+			if name == pb.GroupName {
+				groupID := id
+				p.GroupID = &groupID
+			}
+		}
+		if p.GroupID == nil {
+			return nil, status.Errorf(codes.NotFound, "group not found: %s", pb.GroupName)
+		}
+	}
+
+	if err := a.m.db.AddConfigPolicy(&p); err != nil {
+		return nil, err
+	}
+	return &apiv1.PostConfigPolicyResponse{ConfigPolicy: configPolicyToProto(p, groupNames)}, nil
+}
+
+func (a *apiServer) DeleteConfigPolicy(
+	_ context.Context, req *apiv1.DeleteConfigPolicyRequest,
+) (*apiv1.DeleteConfigPolicyResponse, error) {
+	switch err := a.m.db.DeleteConfigPolicy(int(req.ConfigPolicyId)); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "config policy not found: %d", req.ConfigPolicyId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteConfigPolicyResponse{}, nil
+}
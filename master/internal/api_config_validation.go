@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/utilv1"
+)
+
+// configFieldError is a problem with the value of a field of a config, named by its dotted path.
+type configFieldError struct {
+	field string
+	err   error
+}
+
+func (e configFieldError) Error() string {
+	return e.err.Error()
+}
+
+func (e configFieldError) Cause() error {
+	return e.err
+}
+
+// configErrorProtos converts the problems found with a config to protos, splitting the errors found
+// by validating it against its schema into one per invalid value.
+func configErrorProtos(errs []error) []*utilv1.ConfigError {
+	var pbErrs []*utilv1.ConfigError
+	for _, err := range errs {
+		var fieldErr configFieldError
+		var schemaErrs schemas.ValidationErrors
+		switch {
+		case errors.As(err, &fieldErr):
+			pbErrs = append(pbErrs, &utilv1.ConfigError{
+				Path: fieldErr.field, Message: fieldErr.err.Error(),
+			})
+		case errors.As(err, &schemaErrs):
+			for _, schemaErr := range schemaErrs {
+				pbErrs = append(pbErrs, schemaErrorProto(schemaErr.Error()))
+			}
+		default:
+			pbErrs = append(pbErrs, &utilv1.ConfigError{Message: err.Error()})
+		}
+	}
+	return pbErrs
+}
+
+// schemaErrorProto converts an error rendered by schemas.GetRenderedErrors, like
+// "<config>.resources.slots_per_trial: expected integer, but got string", to a proto.
+func schemaErrorProto(msg string) *utilv1.ConfigError {
+	const prefix = "<config>"
+	if !strings.HasPrefix(msg, prefix) {
+		return &utilv1.ConfigError{Message: msg}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(msg, prefix), ": ", 2)
+	if len(parts) != 2 {
+		return &utilv1.ConfigError{Message: msg}
+	}
+	return &utilv1.ConfigError{Path: strings.TrimPrefix(parts[0], "."), Message: parts[1]}
+}
+
+// checkLaunchPolicies returns the problems that would keep the user from launching a task that uses
+// the datasets.
+func (a *apiServer) checkLaunchPolicies(
+	user *model.User, datasets []model.DatasetReference,
+) ([]error, error) {
+	var errs []error
+	agentUserGroup, err := a.m.db.AgentUserGroup(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if agentUserGroup == nil {
+		agentUserGroup = &a.m.config.Security.DefaultTask
+	}
+	if err = a.m.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+		errs = append(errs, err)
+	}
+	switch err = a.m.db.CheckDatasets(datasets); {
+	case errors.Cause(err) == db.ErrNotFound:
+		errs = append(errs, configFieldError{
+			field: "datasets", err: errors.Errorf("%s is not registered", err),
+		})
+	case err != nil:
+		return nil, err
+	}
+	return errs, nil
+}
+
+func (a *apiServer) ValidateExperimentConfig(
+	ctx context.Context, req *apiv1.ValidateExperimentConfigRequest,
+) (*apiv1.ValidateExperimentConfigResponse, error) {
+	user, project, err := a.checkPermission(ctx, int(req.ProjectId), model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+
+	params := CreateExperimentParams{ConfigBytes: req.Config, ProjectID: project.ID}
+	if req.Template != "" {
+		params.Template = &req.Template
+	}
+	resolved, err := a.m.resolveExperimentConfig(&params, user)
+	if err != nil {
+		return &apiv1.ValidateExperimentConfigResponse{
+			Errors: configErrorProtos([]error{err}),
+		}, nil
+	}
+
+	config := resolved.config
+	errs := a.m.checkExperimentConfig(resolved)
+	if err = sproto.ValidateRP(a.m.system, config.Resources().ResourcePool()); err != nil {
+		errs = append(errs, configFieldError{field: "resources.resource_pool", err: err})
+	}
+	policyErrs, err := a.checkLaunchPolicies(user, experimentDatasets(config))
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ValidateExperimentConfigResponse{
+		Errors: configErrorProtos(append(errs, policyErrs...)),
+		Config: protoutils.ToStruct(config),
+	}, nil
+}
+
+func (a *apiServer) ValidateCommandConfig(
+	ctx context.Context, req *apiv1.ValidateCommandConfigRequest,
+) (*apiv1.ValidateCommandConfigResponse, error) {
+	user, project, err := a.checkPermission(ctx, int(req.ProjectId), model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+	workspace, err := a.m.db.WorkspaceByID(project.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var configBytes []byte
+	if req.Config != nil {
+		if configBytes, err = protojson.Marshal(req.Config); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to parse config: %s", err)
+		}
+	}
+	policies, err := a.m.configPolicies(user.ID, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	var routedPool string
+	if req.Interactive {
+		routedPool = a.m.config.PoolRouting.InteractiveResourcePool
+	}
+	config, taskSpec, err := a.makeFullCommandSpec(
+		configBytes, &req.TemplateName, false, workspace, policies, routedPool)
+	if err != nil {
+		return &apiv1.ValidateCommandConfigResponse{
+			Errors: configErrorProtos([]error{err}),
+		}, nil
+	}
+
+	errs := policies.checkCommandConfig(*config)
+	err = a.m.checkKubernetesNamespace(config.Environment.Kubernetes.Namespace)
+	if err != nil {
+		errs = append(errs, configFieldError{field: "environment.kubernetes.namespace", err: err})
+	}
+	resources := config.Resources
+	err = a.m.checkResourcePoolTenant(workspace, resources.ResourcePool, resources.Slots)
+	if err != nil {
+		errs = append(errs, configFieldError{field: "resources.resource_pool", err: err})
+	}
+	err = a.m.checkPoolRouting(user, workspace.ID, routedPool,
+		policies.commandResourcePool(defaultResourcePool(workspace, routedPool)),
+		resources.ResourcePool)
+	if err != nil {
+		errs = append(errs, configFieldError{field: "resources.resource_pool", err: err})
+	}
+	taskSpec.SetInner(&tasks.StartCommand{Config: *config})
+	if err = a.m.config.Security.BindMounts.CheckHostPaths(taskSpec.HostPaths()...); err != nil {
+		errs = append(errs, configFieldError{field: "bind_mounts", err: err})
+	}
+	policyErrs, err := a.checkLaunchPolicies(user, config.Datasets)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ValidateCommandConfigResponse{
+		Errors: configErrorProtos(append(errs, policyErrs...)),
+		Config: protoutils.ToStruct(config),
+	}, nil
+}
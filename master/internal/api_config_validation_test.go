@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func TestConfigErrorProtos(t *testing.T) {
+	schemaErr := errors.Wrap(schemas.ValidationErrors{
+		errors.New("<config>.resources.slots_per_trial: expected integer, but got string"),
+		errors.New("<config>: missing properties: 'entrypoint'"),
+	}, "config is invalid")
+	fieldErr := errors.Wrap(configFieldError{
+		field: "bind_mounts", err: errors.New("host path /etc may not be mounted"),
+	}, "invalid experiment configuration")
+
+	var pbErrs [][2]string
+	for _, pbErr := range configErrorProtos(
+		[]error{schemaErr, fieldErr, errors.New("tasks may not run as root")},
+	) {
+		pbErrs = append(pbErrs, [2]string{pbErr.Path, pbErr.Message})
+	}
+	assert.DeepEqual(t, pbErrs, [][2]string{
+		{"resources.slots_per_trial", "expected integer, but got string"},
+		{"", "missing properties: 'entrypoint'"},
+		{"bind_mounts", "host path /etc may not be mounted"},
+		{"", "tasks may not run as root"},
+	})
+}
+
+func TestConfigErrorProtosFromSchema(t *testing.T) {
+	_, err := expconf.ParseAnyExperimentConfigYAML([]byte(
+		"entrypoint: model_def:Trial\nresources:\n  slots_per_trial: many\n"))
+	assert.Assert(t, err != nil)
+	pbErrs := configErrorProtos([]error{err})
+	assert.Equal(t, len(pbErrs), 1)
+	assert.Equal(t, pbErrs[0].Path, "resources.slots_per_trial")
+	assert.Equal(t, pbErrs[0].Message, "expected integer or null, but got string")
+}
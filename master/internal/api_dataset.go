@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/datasetv1"
+)
+
+func datasetToProto(d model.Dataset) *datasetv1.Dataset {
+	return &datasetv1.Dataset{
+		Id:           int32(d.ID),
+		Name:         d.Name,
+		Version:      d.Version,
+		Checksum:     d.Checksum,
+		Location:     d.Location,
+		Description:  d.Description,
+		Username:     d.Username,
+		CreationTime: protoutils.ToTimestamp(d.CreationTime),
+	}
+}
+
+// experimentDatasets returns the references to the datasets that an experiment uses.
+func experimentDatasets(config expconf.ExperimentConfig) []model.DatasetReference {
+	var refs []model.DatasetReference
+	for _, d := range config.Datasets() {
+		refs = append(refs, model.DatasetReference{Name: d.Name(), Version: d.Version()})
+	}
+	return refs
+}
+
+// datasetStatus converts the error of checking the datasets that a task uses to an API error.
+func datasetStatus(err error) error {
+	if errors.Cause(err) == db.ErrNotFound {
+		return status.Errorf(codes.InvalidArgument, "%s is not registered", err)
+	}
+	return err
+}
+
+// checkDatasetOwner returns the dataset unless the user of the request neither registered it nor
+// is an admin.
+func (a *apiServer) checkDatasetOwner(ctx context.Context, id int32) (*model.Dataset, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	d, err := a.m.db.DatasetByID(int(id))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "dataset not found: %d", id)
+	case err != nil:
+		return nil, err
+	case d.OwnerID != user.ID && !user.Admin:
+		return nil, status.Error(codes.PermissionDenied,
+			"only admins may change the datasets of other users")
+	}
+	return d, nil
+}
+
+func (a *apiServer) GetDatasets(
+	_ context.Context, req *apiv1.GetDatasetsRequest,
+) (*apiv1.GetDatasetsResponse, error) {
+	datasets, err := a.m.db.Datasets(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetDatasetsResponse{}
+	for _, d := range datasets {
+		resp.Datasets = append(resp.Datasets, datasetToProto(d))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) GetDataset(
+	ctx context.Context, req *apiv1.GetDatasetRequest,
+) (*apiv1.GetDatasetResponse, error) {
+	d, err := a.m.db.DatasetByID(int(req.DatasetId))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "dataset not found: %d", req.DatasetId)
+	case err != nil:
+		return nil, err
+	}
+	resp := &apiv1.GetDatasetResponse{}
+	if err = a.m.db.QueryProtoContext(ctx, "get_dataset_lineage", resp, d.ID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the lineage of dataset %d", d.ID)
+	}
+	resp.Dataset = datasetToProto(*d)
+	return resp, nil
+}
+
+func (a *apiServer) PostDataset(
+	ctx context.Context, req *apiv1.PostDatasetRequest,
+) (*apiv1.PostDatasetResponse, error) {
+	pb := req.Dataset
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return pb != nil, "no dataset specified" },
+		func() (bool, string) { return pb.Name != "", "name must be specified" },
+		func() (bool, string) { return pb.Version != "", "version must be specified" },
+		func() (bool, string) { return pb.Location != "", "location must be specified" },
+	); err != nil {
+		return nil, err
+	}
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	d := model.Dataset{
+		Name:        pb.Name,
+		Version:     pb.Version,
+		Checksum:    pb.Checksum,
+		Location:    pb.Location,
+		Description: pb.Description,
+		OwnerID:     user.ID,
+		Username:    user.Username,
+	}
+	switch err = a.m.db.AddDataset(&d); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(codes.AlreadyExists,
+			"dataset %s already has version %s", d.Name, d.Version)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PostDatasetResponse{Dataset: datasetToProto(d)}, nil
+}
+
+func (a *apiServer) PatchDataset(
+	ctx context.Context, req *apiv1.PatchDatasetRequest,
+) (*apiv1.PatchDatasetResponse, error) {
+	pb := req.Dataset
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return pb != nil, "no dataset specified" },
+		func() (bool, string) { return pb.Location != "", "location must be specified" },
+	); err != nil {
+		return nil, err
+	}
+	d, err := a.checkDatasetOwner(ctx, req.DatasetId)
+	if err != nil {
+		return nil, err
+	}
+	if (pb.Name != "" && pb.Name != d.Name) || (pb.Version != "" && pb.Version != d.Version) {
+		return nil, status.Error(codes.InvalidArgument,
+			"the name and version of a dataset cannot change")
+	}
+	d.Checksum, d.Location, d.Description = pb.Checksum, pb.Location, pb.Description
+	switch err = a.m.db.UpdateDataset(d); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "dataset not found: %d", req.DatasetId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PatchDatasetResponse{Dataset: datasetToProto(*d)}, nil
+}
+
+func (a *apiServer) DeleteDataset(
+	ctx context.Context, req *apiv1.DeleteDatasetRequest,
+) (*apiv1.DeleteDatasetResponse, error) {
+	d, err := a.checkDatasetOwner(ctx, req.DatasetId)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.DeleteDataset(d.ID); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "dataset not found: %d", req.DatasetId)
+	case err == db.ErrDatasetInUse:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"dataset %s:%s was used by experiments or tasks", d.Name, d.Version)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteDatasetResponse{}, nil
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func (a *apiServer) GetActors(
+	_ context.Context, req *apiv1.GetActorsRequest,
+) (*apiv1.GetActorsResponse, error) {
+	introspection, ok := a.m.system.Introspect(parseActorAddress(req.Address))
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "actor %s not found", req.Address)
+	}
+	return &apiv1.GetActorsResponse{Actor: toProtoActor(introspection)}, nil
+}
+
+func (a *apiServer) GetActorState(
+	_ context.Context, req *apiv1.GetActorStateRequest,
+) (*apiv1.GetActorStateResponse, error) {
+	addr := parseActorAddress(req.Address)
+	if a.m.system.Get(addr) == nil {
+		return nil, status.Errorf(codes.NotFound, "actor %s not found", req.Address)
+	}
+	state, err := a.m.system.IntrospectState(addr, defaultAskTimeout)
+	switch {
+	case err == actor.ErrNotIntrospectable:
+		return nil, status.Errorf(codes.Unimplemented, "actor %s does not describe its state", addr)
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &apiv1.GetActorStateResponse{State: protoutils.ToStruct(state)}, nil
+}
+
+// parseActorAddress parses the address of an actor, which is the root of the master if it is empty.
+func parseActorAddress(raw string) actor.Address {
+	var addr actor.Address
+	_ = addr.UnmarshalText([]byte(path.Clean("/" + raw)))
+	return addr
+}
+
+func toProtoActor(i actor.Introspection) *apiv1.Actor {
+	pbActor := &apiv1.Actor{
+		Address:               i.Address.String(),
+		Type:                  i.Type,
+		RegisteredTime:        timestamppb.New(i.RegisteredTime),
+		MailboxLength:         int32(i.MailboxLength),
+		MessagesProcessed:     int64(i.MessagesProcessed),
+		LastMessageType:       i.LastMessageType,
+		LastMessageTime:       optionalTimestamp(i.LastMessageTime),
+		ProcessingMessageType: i.ProcessingType,
+		ProcessingSince:       optionalTimestamp(i.ProcessingSince),
+		Latency: &apiv1.ActorLatency{
+			P50Seconds: i.Latency.P50.Seconds(),
+			P90Seconds: i.Latency.P90.Seconds(),
+			P99Seconds: i.Latency.P99.Seconds(),
+			MaxSeconds: i.Latency.Max.Seconds(),
+		},
+	}
+	for _, child := range i.Children {
+		pbActor.Children = append(pbActor.Children, toProtoActor(child))
+	}
+	return pbActor
+}
+
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
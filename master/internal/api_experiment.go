@@ -16,13 +16,16 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/hpimportance"
 	"github.com/determined-ai/determined/master/internal/lttb"
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
@@ -83,7 +86,7 @@ func (a *apiServer) GetExperiment(
 }
 
 func (a *apiServer) DeleteExperiment(
-	_ context.Context, req *apiv1.DeleteExperimentRequest,
+	ctx context.Context, req *apiv1.DeleteExperimentRequest,
 ) (*apiv1.DeleteExperimentResponse, error) {
 	expID := int(req.ExperimentId)
 	exp, err := a.m.db.ExperimentByID(expID)
@@ -93,6 +96,9 @@ func (a *apiServer) DeleteExperiment(
 	case err != nil:
 		return nil, fmt.Errorf("failed to retrieve experiment: %w", err)
 	}
+	if _, _, err = a.checkPermission(ctx, exp.ProjectID, model.PermissionEditAll); err != nil {
+		return nil, err
+	}
 
 	if !model.TerminalStates[exp.State] {
 		return nil, fmt.Errorf("cannot delete experiment in %s state", exp.State)
@@ -112,6 +118,9 @@ func (a *apiServer) DeleteExperiment(
 	case agentUserGroup == nil:
 		agentUserGroup = &a.m.config.Security.DefaultTask
 	}
+	if err = a.m.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 
 	storage := exp.Config.CheckpointStorage()
 	storage.SetSaveExperimentBest(0)
@@ -128,6 +137,8 @@ func (a *apiServer) DeleteExperiment(
 		taskSpec:       a.m.taskSpec,
 		rm:             a.m.rm,
 		db:             a.m.db,
+		vault:          a.m.vault,
+		ca:             a.m.ca,
 		experiment:     exp,
 		gcTensorboards: true,
 	}).AwaitTermination(); gcErr != nil {
@@ -152,8 +163,33 @@ func (a *apiServer) DeleteExperiment(
 	return &apiv1.DeleteExperimentResponse{}, nil
 }
 
+func (a *apiServer) DeleteExperimentLogs(
+	ctx context.Context, req *apiv1.DeleteExperimentLogsRequest,
+) (*apiv1.DeleteExperimentLogsResponse, error) {
+	exp, err := a.m.db.ExperimentByID(int(req.ExperimentId))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "experiment not found")
+	case err != nil:
+		return nil, fmt.Errorf("failed to retrieve experiment: %w", err)
+	}
+	if _, _, err = a.checkPermission(ctx, exp.ProjectID, model.PermissionEditAll); err != nil {
+		return nil, err
+	}
+
+	trialIDs, err := a.m.db.ExperimentTrialIDs(exp.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to gather trial IDs for experiment")
+	}
+	logrus.Infof("deleting experiment %v logs from backend", req.ExperimentId)
+	if err = a.m.trialLogBackend.DeleteTrialLogs(trialIDs); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete trial logs from backend")
+	}
+	return &apiv1.DeleteExperimentLogsResponse{}, nil
+}
+
 func (a *apiServer) GetExperiments(
-	_ context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
+	ctx context.Context, req *apiv1.GetExperimentsRequest) (*apiv1.GetExperimentsResponse, error) {
 	// Construct the experiment filtering expression.
 	var allStates []string
 	for _, state := range req.States {
@@ -197,11 +233,11 @@ func (a *apiServer) GetExperiments(
 		orderExpr = fmt.Sprintf("id %s", sortByMap[req.OrderBy])
 	}
 
-	resp := &apiv1.GetExperimentsResponse{}
-	return resp, a.m.db.QueryProtof(
-		"get_experiments",
-		[]interface{}{orderExpr},
-		resp,
+	projectsExpr, err := a.tenantProjectsExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params := []interface{}{
 		stateFilterExpr,
 		archivedExpr,
 		userFilterExpr,
@@ -209,7 +245,118 @@ func (a *apiServer) GetExperiments(
 		req.Description,
 		req.Offset,
 		req.Limit,
-	)
+		req.ProjectId,
+		req.ParentId,
+		projectsExpr,
+	}
+	cursor, err := api.DecodeCursor(req.Cursor)
+	switch {
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case cursor != nil && req.Offset != 0:
+		return nil, status.Error(codes.InvalidArgument, "an offset cannot be combined with a cursor")
+	}
+	afterExpr := "true"
+	if cursor != nil {
+		if afterExpr, params, err = experimentsAfterCursor(
+			orderColMap[req.SortBy], req.OrderBy == apiv1.OrderBy_ORDER_BY_DESC, cursor, params,
+		); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	resp := &apiv1.GetExperimentsResponse{}
+	if err = a.m.db.QueryProtof(
+		"get_experiments", []interface{}{orderExpr, afterExpr}, resp, params...,
+	); err != nil {
+		return nil, err
+	}
+	if p := resp.Pagination; p != nil && p.EndIndex < p.Total && len(resp.Experiments) > 0 {
+		last := resp.Experiments[len(resp.Experiments)-1]
+		var key *string
+		if keyOf, ok := experimentSortKeys[orderColMap[req.SortBy]]; ok {
+			key = keyOf(last)
+		}
+		resp.NextCursor = api.Cursor{Key: key, ID: strconv.Itoa(int(last.Id))}.Encode()
+	}
+	return resp, nil
+}
+
+// experimentSortKeys read the values of the columns that experiments are sorted by as text,
+// which continues a page of experiments after the last one. Experiments are otherwise sorted
+// only by ID.
+var experimentSortKeys = map[string]func(e *experimentv1.Experiment) *string{
+	"description": func(e *experimentv1.Experiment) *string { return &e.Description },
+	"start_time": func(e *experimentv1.Experiment) *string {
+		key := e.StartTime.AsTime().Format(time.RFC3339Nano)
+		return &key
+	},
+	"end_time": func(e *experimentv1.Experiment) *string {
+		if e.EndTime == nil {
+			return nil
+		}
+		key := e.EndTime.AsTime().Format(time.RFC3339Nano)
+		return &key
+	},
+	"state": func(e *experimentv1.Experiment) *string {
+		key := e.State.String()
+		return &key
+	},
+	"num_trials": func(e *experimentv1.Experiment) *string {
+		key := strconv.Itoa(int(e.NumTrials))
+		return &key
+	},
+	"progress": func(e *experimentv1.Experiment) *string {
+		key := strconv.FormatFloat(e.Progress, 'g', -1, 64)
+		return &key
+	},
+	"username": func(e *experimentv1.Experiment) *string { return &e.Username },
+}
+
+// experimentSortTypes are the types of the columns that experiments are sorted by.
+var experimentSortTypes = map[string]string{
+	"description": "text",
+	"start_time":  "timestamptz",
+	"end_time":    "timestamptz",
+	"state":       "text",
+	"num_trials":  "bigint",
+	"progress":    "float8",
+	"username":    "text",
+}
+
+// experimentsAfterCursor returns the SQL condition that matches the experiments ordered after the
+// cursor by the column and then by ID, adding its parameters to params. Experiments without a
+// value for the column are ordered last in ascending order and first in descending order.
+func experimentsAfterCursor(
+	column string, desc bool, cursor *api.Cursor, params []interface{},
+) (string, []interface{}, error) {
+	id, err := strconv.Atoi(cursor.ID)
+	if err != nil {
+		return "", nil, errors.New("invalid cursor")
+	}
+	params = append(params, id)
+	idParam := fmt.Sprintf("$%d", len(params))
+	cmp := ">"
+	if desc {
+		cmp = "<"
+	}
+	sqlType, ok := experimentSortTypes[column]
+	switch {
+	case !ok:
+		return fmt.Sprintf("id %s %s", cmp, idParam), params, nil
+	case cursor.Key == nil && desc:
+		return fmt.Sprintf("(%s IS NOT NULL OR id < %s)", column, idParam), params, nil
+	case cursor.Key == nil:
+		return fmt.Sprintf("(%s IS NULL AND id > %s)", column, idParam), params, nil
+	}
+	params = append(params, *cursor.Key)
+	key := fmt.Sprintf("$%d::%s", len(params), sqlType)
+	after := fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s %[4]s))",
+		column, cmp, key, idParam)
+	if desc {
+		return fmt.Sprintf("(%s IS NOT NULL AND %s)", column, after), params, nil
+	}
+	return fmt.Sprintf("(%s OR %s IS NULL)", after, column), params, nil
 }
 
 func (a *apiServer) GetExperimentLabels(_ context.Context,
@@ -239,10 +386,12 @@ func (a *apiServer) GetExperimentLabels(_ context.Context,
 }
 
 func (a *apiServer) GetExperimentValidationHistory(
-	_ context.Context, req *apiv1.GetExperimentValidationHistoryRequest,
+	ctx context.Context, req *apiv1.GetExperimentValidationHistoryRequest,
 ) (*apiv1.GetExperimentValidationHistoryResponse, error) {
 	var resp apiv1.GetExperimentValidationHistoryResponse
-	switch err := a.m.db.QueryProto("proto_experiment_validation_history", &resp, req.ExperimentId); {
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "proto_experiment_validation_history", &resp, req.ExperimentId,
+	); {
 	case err == db.ErrNotFound:
 		return nil, status.Errorf(codes.NotFound, "experiment not found: %d", req.ExperimentId)
 	case err != nil:
@@ -371,6 +520,9 @@ func (a *apiServer) ActivateExperiment(
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
+	if err = a.checkExperimentOwner(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 
 	addr := experimentsAddr.Child(req.Id).String()
 	switch err = a.actorRequest(addr, req, &resp); {
@@ -389,6 +541,9 @@ func (a *apiServer) PauseExperiment(
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
+	if err = a.checkExperimentOwner(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 
 	addr := experimentsAddr.Child(req.Id).String()
 	switch err = a.actorRequest(addr, req, &resp); {
@@ -407,6 +562,9 @@ func (a *apiServer) CancelExperiment(
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
+	if err = a.checkExperimentOwner(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 
 	addr := experimentsAddr.Child(req.Id).String()
 	err = a.actorRequest(addr, req, &resp)
@@ -423,6 +581,9 @@ func (a *apiServer) KillExperiment(
 	if err = a.checkExperimentExists(int(req.Id)); err != nil {
 		return nil, err
 	}
+	if err = a.checkExperimentOwner(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
 
 	addr := experimentsAddr.Child(req.Id).String()
 	err = a.actorRequest(addr, req, &resp)
@@ -432,6 +593,45 @@ func (a *apiServer) KillExperiment(
 	return resp, err
 }
 
+func (a *apiServer) GetSearcherEvents(
+	_ context.Context, req *apiv1.GetSearcherEventsRequest,
+) (resp *apiv1.GetSearcherEventsResponse, err error) {
+	if err = a.checkExperimentExists(int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
+
+	addr := experimentsAddr.Child(req.ExperimentId).String()
+	switch err = a.actorRequest(addr, req, &resp); {
+	case status.Code(err) == codes.NotFound:
+		return nil, status.Error(codes.FailedPrecondition, "experiment in terminal state")
+	case err != nil:
+		return nil, err
+	default:
+		return resp, nil
+	}
+}
+
+func (a *apiServer) PostSearcherOperations(
+	ctx context.Context, req *apiv1.PostSearcherOperationsRequest,
+) (resp *apiv1.PostSearcherOperationsResponse, err error) {
+	if err = a.checkExperimentExists(int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
+	if err = a.checkExperimentOwner(ctx, int(req.ExperimentId)); err != nil {
+		return nil, err
+	}
+
+	addr := experimentsAddr.Child(req.ExperimentId).String()
+	switch err = a.actorRequest(addr, req, &resp); {
+	case status.Code(err) == codes.NotFound:
+		return nil, status.Error(codes.FailedPrecondition, "experiment in terminal state")
+	case err != nil:
+		return nil, err
+	default:
+		return resp, nil
+	}
+}
+
 func (a *apiServer) ArchiveExperiment(
 	ctx context.Context, req *apiv1.ArchiveExperimentRequest,
 ) (*apiv1.ArchiveExperimentResponse, error) {
@@ -441,6 +641,9 @@ func (a *apiServer) ArchiveExperiment(
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading experiment %v", id)
 	}
+	if err = a.checkExperimentOwner(ctx, id); err != nil {
+		return nil, err
+	}
 	if _, ok := model.TerminalStates[dbExp.State]; !ok {
 		return nil, errors.Errorf("cannot archive experiment %v in non terminate state %v",
 			id, dbExp.State)
@@ -469,6 +672,9 @@ func (a *apiServer) UnarchiveExperiment(
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading experiment %v", id)
 	}
+	if err = a.checkExperimentOwner(ctx, id); err != nil {
+		return nil, err
+	}
 	if _, ok := model.TerminalStates[dbExp.State]; !ok {
 		return nil, errors.Errorf("cannot unarchive experiment %v in non terminate state %v",
 			id, dbExp.State)
@@ -492,12 +698,15 @@ func (a *apiServer) PatchExperiment(
 	ctx context.Context, req *apiv1.PatchExperimentRequest,
 ) (*apiv1.PatchExperimentResponse, error) {
 	var exp experimentv1.Experiment
-	switch err := a.m.db.QueryProto("get_experiment", &exp, req.Experiment.Id); {
+	switch err := a.m.db.QueryProtoContext(ctx, "get_experiment", &exp, req.Experiment.Id); {
 	case err == db.ErrNotFound:
 		return nil, status.Errorf(codes.NotFound, "experiment not found: %d", req.Experiment.Id)
 	case err != nil:
 		return nil, errors.Wrapf(err, "error fetching experiment from database: %d", req.Experiment.Id)
 	}
+	if err := a.checkOwner(ctx, exp.Username, int(exp.ProjectId)); err != nil {
+		return nil, err
+	}
 
 	paths := req.UpdateMask.GetPaths()
 	for _, path := range paths {
@@ -546,7 +755,9 @@ func (a *apiServer) GetExperimentCheckpoints(
 
 	resp := &apiv1.GetExperimentCheckpointsResponse{}
 	resp.Checkpoints = []*checkpointv1.Checkpoint{}
-	switch err := a.m.db.QueryProto("get_checkpoints_for_experiment", &resp.Checkpoints, req.Id); {
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "get_checkpoints_for_experiment", &resp.Checkpoints, req.Id,
+	); {
 	case err == db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "no checkpoints found for experiment %d", req.Id)
@@ -593,17 +804,37 @@ func (a *apiServer) GetExperimentCheckpoints(
 func (a *apiServer) CreateExperiment(
 	ctx context.Context, req *apiv1.CreateExperimentRequest,
 ) (*apiv1.CreateExperimentResponse, error) {
+	user, project, err := a.checkPermission(ctx, int(req.ProjectId), model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+
 	detParams := CreateExperimentParams{
 		ConfigBytes:  req.Config,
 		ModelDef:     filesToArchive(req.ModelDefinition),
 		ValidateOnly: req.ValidateOnly,
+		ProjectID:    project.ID,
 	}
 	if req.ParentId != 0 {
+		// A child inherits the storage credentials and the model definition of its parent.
+		if err = a.checkExperimentOwner(ctx, int(req.ParentId)); err != nil {
+			return nil, err
+		}
 		parentID := int(req.ParentId)
 		detParams.ParentID = &parentID
 	}
+	if req.ContextArtifactId != "" {
+		if len(req.ModelDefinition) > 0 {
+			return nil, status.Error(codes.InvalidArgument,
+				"model_definition and context_artifact_id cannot both be specified")
+		}
+		detParams.ModelDef, err = a.m.readContextArtifact(ctx, user, req.ContextArtifactId)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	dbExp, validateOnly, taskSpec, err := a.m.parseCreateExperiment(&detParams)
+	dbExp, validateOnly, taskSpec, err := a.m.parseCreateExperiment(&detParams, user)
 
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid experiment: %s", err)
@@ -613,25 +844,127 @@ func (a *apiServer) CreateExperiment(
 		return &apiv1.CreateExperimentResponse{}, nil
 	}
 
-	user, _, err := grpcutil.GetUser(ctx, a.m.db)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
-	}
-
 	dbExp.OwnerID = &user.ID
 	e, err := newExperiment(a.m, dbExp, taskSpec)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create experiment: %s", err)
 	}
+	e.spanContext = tracing.SpanContextFromContext(ctx)
 	a.m.system.ActorOf(actor.Addr("experiments", e.ID), e)
 
 	protoExp, err := a.getExperiment(e.ID)
 	if err != nil {
 		return nil, err
 	}
-	return &apiv1.CreateExperimentResponse{
-		Experiment: protoExp, Config: protoutils.ToStruct(e.Config),
-	}, nil
+	config, err := redactedConfig(e.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.CreateExperimentResponse{Experiment: protoExp, Config: config}, nil
+}
+
+// redactedConfig returns a config with its storage credentials redacted, as it is stored.
+func redactedConfig(config interface{}) (*structpb.Struct, error) {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding config")
+	}
+	if configBytes, _, err = model.StripStorageCredentials(configBytes); err != nil {
+		return nil, err
+	}
+	configStruct := &structpb.Struct{}
+	if err = protojson.Unmarshal(configBytes, configStruct); err != nil {
+		return nil, errors.Wrap(err, "error decoding config")
+	}
+	return configStruct, nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the target: objects are merged recursively,
+// null values remove their keys, and any other value replaces the target.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
+
+func (a *apiServer) ForkExperiment(
+	ctx context.Context, req *apiv1.ForkExperimentRequest,
+) (*apiv1.ForkExperimentResponse, error) {
+	parentID := int(req.ExperimentId)
+	parent, err := a.getExperiment(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(ctx, parent.Username, int(parent.ProjectId)); err != nil {
+		return nil, err
+	}
+
+	// The child starts from the config of its parent as stored, with its storage credentials
+	// redacted; it inherits them when it is added.
+	configBytes, err := a.m.db.ExperimentConfigRaw(parentID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching config of experiment %d", parentID)
+	}
+	var config interface{}
+	if err = json.Unmarshal(configBytes, &config); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshaling config of experiment %d", parentID)
+	}
+	if req.ConfigPatch != nil {
+		config = mergePatch(config, req.ConfigPatch.AsMap())
+	}
+
+	// Warm starting from a checkpoint replaces any source of the parent's searcher.
+	if req.CheckpointUuid != "" {
+		ckpt, cErr := a.GetCheckpoint(
+			ctx, &apiv1.GetCheckpointRequest{CheckpointUuid: req.CheckpointUuid})
+		switch {
+		case cErr != nil:
+			return nil, cErr
+		case int(ckpt.Checkpoint.ExperimentId) != parentID:
+			return nil, status.Errorf(codes.InvalidArgument,
+				"checkpoint %s does not belong to experiment %d", req.CheckpointUuid, parentID)
+		case ckpt.Checkpoint.State != checkpointv1.State_STATE_COMPLETED:
+			return nil, status.Errorf(codes.InvalidArgument,
+				"checkpoint %s is not completed", req.CheckpointUuid)
+		}
+		config = mergePatch(config, map[string]interface{}{
+			"searcher": map[string]interface{}{
+				"source_trial_id":        nil,
+				"source_checkpoint_uuid": req.CheckpointUuid,
+			},
+		})
+	}
+
+	if configBytes, err = json.Marshal(config); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config patch: %s", err)
+	}
+	projectID := req.ProjectId
+	if projectID == 0 {
+		projectID = parent.ProjectId
+	}
+	resp, err := a.CreateExperiment(ctx, &apiv1.CreateExperimentRequest{
+		Config:       string(configBytes),
+		ValidateOnly: req.ValidateOnly,
+		ParentId:     req.ExperimentId,
+		ProjectId:    projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.ForkExperimentResponse{Experiment: resp.Experiment, Config: resp.Config}, nil
 }
 
 var defaultMetricsStreamPeriod = 30 * time.Second
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"description": "parent",
+		"hyperparameters": map[string]interface{}{
+			"lr":       0.1,
+			"momentum": 0.9,
+		},
+		"labels": []interface{}{"a", "b"},
+	}
+	patch := map[string]interface{}{
+		"description": "child",
+		"hyperparameters": map[string]interface{}{
+			"lr":       0.01,
+			"momentum": nil,
+		},
+		"labels":  []interface{}{"c"},
+		"missing": nil,
+		"searcher": map[string]interface{}{
+			"name": "single",
+		},
+	}
+	assert.DeepEqual(t, mergePatch(target, patch), map[string]interface{}{
+		"description": "child",
+		"hyperparameters": map[string]interface{}{
+			"lr": 0.01,
+		},
+		"labels": []interface{}{"c"},
+		"searcher": map[string]interface{}{
+			"name": "single",
+		},
+	})
+
+	// A patch that is not an object replaces the target entirely.
+	assert.DeepEqual(t, mergePatch(target, []interface{}{1}), []interface{}{1})
+}
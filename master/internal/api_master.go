@@ -6,6 +6,8 @@ import (
 
 	structpb "github.com/golang/protobuf/ptypes/struct"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/pkg/logger"
@@ -14,6 +16,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/masterv1"
 )
 
 func (a *apiServer) GetMaster(
@@ -24,9 +27,36 @@ func (a *apiServer) GetMaster(
 		ClusterId:        a.m.ClusterID,
 		ClusterName:      a.m.config.ClusterName,
 		TelemetryEnabled: a.m.config.Telemetry.Enabled && a.m.config.Telemetry.SegmentWebUIKey != "",
+		Draining:         a.m.drainer.Draining(),
 	}, nil
 }
 
+func (a *apiServer) DrainMaster(
+	_ context.Context, req *apiv1.DrainMasterRequest) (*apiv1.DrainMasterResponse, error) {
+	if req.GracePeriodSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "grace_period_seconds must not be negative")
+	}
+	gracePeriod := defaultDrainGracePeriod
+	if req.GracePeriodSeconds > 0 {
+		gracePeriod = time.Duration(req.GracePeriodSeconds) * time.Second
+	}
+	if !a.m.drainer.Start() {
+		return nil, status.Error(codes.FailedPrecondition, "the master is already draining")
+	}
+	go a.m.drain(gracePeriod)
+	return &apiv1.DrainMasterResponse{}, nil
+}
+
+func (a *apiServer) ReloadMasterConfig(
+	_ context.Context, _ *apiv1.ReloadMasterConfigRequest,
+) (*apiv1.ReloadMasterConfigResponse, error) {
+	changed, err := a.m.reloadConfig()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &apiv1.ReloadMasterConfigResponse{Changed: changed}, nil
+}
+
 func (a *apiServer) GetTelemetry(
 	_ context.Context, _ *apiv1.GetTelemetryRequest) (*apiv1.GetTelemetryResponse, error) {
 	resp := apiv1.GetTelemetryResponse{}
@@ -37,16 +67,31 @@ func (a *apiServer) GetTelemetry(
 	return &resp, nil
 }
 
+// configSourceToProto maps the sources of the settings of the master to their protos.
+var configSourceToProto = map[ConfigSource]masterv1.ConfigSource{
+	ConfigSourceDefault: masterv1.ConfigSource_CONFIG_SOURCE_DEFAULT,
+	ConfigSourceFile:    masterv1.ConfigSource_CONFIG_SOURCE_FILE,
+	ConfigSourceEnv:     masterv1.ConfigSource_CONFIG_SOURCE_ENV,
+	ConfigSourceFlag:    masterv1.ConfigSource_CONFIG_SOURCE_FLAG,
+}
+
 func (a *apiServer) GetMasterConfig(
 	_ context.Context, _ *apiv1.GetMasterConfigRequest) (*apiv1.GetMasterConfigResponse, error) {
+	a.m.configLock.RLock()
 	config, err := a.m.config.Printable()
+	sources := make(map[string]masterv1.ConfigSource, len(a.m.config.Sources))
+	for path, source := range a.m.config.Sources {
+		sources[path] = configSourceToProto[source]
+	}
+	a.m.configLock.RUnlock()
 	if err != nil {
 		return nil, errors.Wrap(err, "error parsing master config")
 	}
 	configStruct := &structpb.Struct{}
 	err = protojson.Unmarshal(config, configStruct)
 	return &apiv1.GetMasterConfigResponse{
-		Config: configStruct,
+		Config:  configStruct,
+		Sources: sources,
 	}, err
 }
 
@@ -89,7 +134,7 @@ func (a *apiServer) MasterLogs(
 }
 
 func (a *apiServer) ResourceAllocationRaw(
-	_ context.Context,
+	ctx context.Context,
 	req *apiv1.ResourceAllocationRawRequest,
 ) (*apiv1.ResourceAllocationRawResponse, error) {
 	resp := &apiv1.ResourceAllocationRawResponse{}
@@ -106,8 +151,8 @@ func (a *apiServer) ResourceAllocationRaw(
 		return nil, errors.New("start time cannot be after end time")
 	}
 
-	if err := a.m.db.QueryProto(
-		"get_raw_allocation", &resp.ResourceEntries, start.UTC(), end.UTC(),
+	if err := a.m.db.QueryProtoContext(
+		ctx, "get_raw_allocation", &resp.ResourceEntries, start.UTC(), end.UTC(),
 	); err != nil {
 		return nil, errors.Wrap(err, "error fetching raw allocation data")
 	}
@@ -121,3 +166,9 @@ func (a *apiServer) ResourceAllocationAggregated(
 ) (*apiv1.ResourceAllocationAggregatedResponse, error) {
 	return a.m.fetchAggregatedResourceAllocation(req)
 }
+
+func (a *apiServer) ResourceUsage(
+	_ context.Context, req *apiv1.ResourceUsageRequest,
+) (*apiv1.ResourceUsageResponse, error) {
+	return a.m.fetchResourceUsage(req)
+}
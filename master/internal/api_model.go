@@ -3,6 +3,7 @@ package internal
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -18,10 +19,19 @@ import (
 	"github.com/determined-ai/determined/proto/pkg/modelv1"
 )
 
+// labelsJSON marshals the labels of a model version, which are stored as a JSON array.
+func labelsJSON(labels []string) ([]byte, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	b, err := json.Marshal(labels)
+	return b, errors.Wrap(err, "error marshaling labels")
+}
+
 func (a *apiServer) GetModel(
-	_ context.Context, req *apiv1.GetModelRequest) (*apiv1.GetModelResponse, error) {
+	ctx context.Context, req *apiv1.GetModelRequest) (*apiv1.GetModelResponse, error) {
 	m := &modelv1.Model{}
-	switch err := a.m.db.QueryProto("get_model", m, req.ModelName); err {
+	switch err := a.m.db.QueryProtoContext(ctx, "get_model", m, req.ModelName); err {
 	case db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "model %s not found", req.ModelName)
@@ -32,9 +42,9 @@ func (a *apiServer) GetModel(
 }
 
 func (a *apiServer) GetModels(
-	_ context.Context, req *apiv1.GetModelsRequest) (*apiv1.GetModelsResponse, error) {
+	ctx context.Context, req *apiv1.GetModelsRequest) (*apiv1.GetModelsResponse, error) {
 	resp := &apiv1.GetModelsResponse{}
-	if err := a.m.db.QueryProto("get_models", &resp.Models); err != nil {
+	if err := a.m.db.QueryProtoContext(ctx, "get_models", &resp.Models); err != nil {
 		return nil, err
 	}
 
@@ -53,15 +63,15 @@ func (a *apiServer) GetModels(
 }
 
 func (a *apiServer) PostModel(
-	_ context.Context, req *apiv1.PostModelRequest) (*apiv1.PostModelResponse, error) {
+	ctx context.Context, req *apiv1.PostModelRequest) (*apiv1.PostModelResponse, error) {
 	b, err := protojson.Marshal(req.Model.Metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshaling model.Metadata")
 	}
 
 	m := &modelv1.Model{}
-	err = a.m.db.QueryProto(
-		"insert_model", m, req.Model.Name, req.Model.Description, b, time.Now(), time.Now(),
+	err = a.m.db.QueryProtoContext(
+		ctx, "insert_model", m, req.Model.Name, req.Model.Description, b, time.Now(), time.Now(),
 	)
 
 	return &apiv1.PostModelResponse{Model: m},
@@ -103,20 +113,20 @@ func (a *apiServer) PatchModel(
 		currModel.Metadata = req.Model.Metadata
 	}
 
-	err = a.m.db.QueryProto(
-		"update_model", &modelv1.Model{}, req.Model.Name, currModel.Description, newMeta, time.Now())
+	err = a.m.db.QueryProtoContext(
+		ctx, "update_model", &modelv1.Model{}, req.Model.Name, currModel.Description, newMeta, time.Now())
 
 	return &apiv1.PatchModelResponse{Model: currModel},
 		errors.Wrapf(err, "error updating model %s in database", req.Model.Name)
 }
 
 func (a *apiServer) GetModelVersion(
-	_ context.Context, req *apiv1.GetModelVersionRequest) (*apiv1.GetModelVersionResponse, error) {
+	ctx context.Context, req *apiv1.GetModelVersionRequest) (*apiv1.GetModelVersionResponse, error) {
 	resp := &apiv1.GetModelVersionResponse{}
 	resp.ModelVersion = &modelv1.ModelVersion{}
 
-	switch err := a.m.db.QueryProto(
-		"get_model_version", resp.ModelVersion, req.ModelName, req.ModelVersion); {
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "get_model_version", resp.ModelVersion, req.ModelName, req.ModelVersion); {
 	case err == db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "model %s version %d not found", req.ModelName, req.ModelVersion)
@@ -132,11 +142,22 @@ func (a *apiServer) GetModelVersions(
 		return nil, err
 	}
 
+	stage := ""
+	if req.Stage != modelv1.ModelVersion_STAGE_UNSPECIFIED {
+		stage = strings.TrimPrefix(req.Stage.String(), "STAGE_")
+	}
+
 	resp := &apiv1.GetModelVersionsResponse{Model: getResp.Model}
-	if err := a.m.db.QueryProto("get_model_versions", &resp.ModelVersions, req.ModelName); err != nil {
+	if err := a.m.db.QueryProtoContext(
+		ctx, "get_model_versions", &resp.ModelVersions, req.ModelName, stage,
+	); err != nil {
 		return nil, err
 	}
 
+	for _, mv := range resp.ModelVersions {
+		mv.Model = getResp.Model
+	}
+
 	a.sort(resp.ModelVersions, req.OrderBy, req.SortBy, apiv1.GetModelVersionsRequest_SORT_BY_VERSION)
 	return resp, a.paginate(&resp.Pagination, &resp.ModelVersions, req.Offset, req.Limit)
 }
@@ -152,7 +173,9 @@ func (a *apiServer) PostModelVersion(
 	// make sure the checkpoint exists
 	c := &checkpointv1.Checkpoint{}
 
-	switch getCheckpointErr := a.m.db.QueryProto("get_checkpoint", c, req.CheckpointUuid); {
+	switch getCheckpointErr := a.m.db.QueryProtoContext(
+		ctx, "get_checkpoint", c, req.CheckpointUuid,
+	); {
 	case getCheckpointErr == db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "checkpoint %s not found", req.CheckpointUuid)
@@ -167,18 +190,96 @@ func (a *apiServer) PostModelVersion(
 		)
 	}
 
+	metadata, err := protojson.Marshal(req.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling model version metadata")
+	}
+
+	labels, err := labelsJSON(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+
 	respModelVersion := &apiv1.PostModelVersionResponse{}
 	respModelVersion.ModelVersion = &modelv1.ModelVersion{}
 
-	err = a.m.db.QueryProto(
-		"insert_model_version",
+	err = a.m.db.QueryProtoContext(
+		ctx, "insert_model_version",
 		respModelVersion.ModelVersion,
 		req.ModelName,
 		req.CheckpointUuid,
+		metadata,
+		labels,
 	)
 
 	respModelVersion.ModelVersion.Model = getResp.Model
 	respModelVersion.ModelVersion.Checkpoint = c
+	respModelVersion.ModelVersion.ExperimentId = c.ExperimentId
+	respModelVersion.ModelVersion.TrialId = c.TrialId
 
 	return respModelVersion, errors.Wrapf(err, "error adding model version to model %s", req.ModelName)
 }
+
+func (a *apiServer) PatchModelVersion(
+	ctx context.Context, req *apiv1.PatchModelVersionRequest,
+) (*apiv1.PatchModelVersionResponse, error) {
+	metadata, err := protojson.Marshal(req.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling model version metadata")
+	}
+
+	labels, err := labelsJSON(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	switch err = a.m.db.QueryProtoContext(
+		ctx, "update_model_version", &modelv1.ModelVersion{},
+		req.ModelName, req.ModelVersion, metadata, labels,
+	); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(
+			codes.NotFound, "model %s version %d not found", req.ModelName, req.ModelVersion)
+	case err != nil:
+		return nil, errors.Wrapf(err,
+			"error updating model %s version %d", req.ModelName, req.ModelVersion)
+	}
+
+	getResp, err := a.GetModelVersion(ctx, &apiv1.GetModelVersionRequest{
+		ModelName: req.ModelName, ModelVersion: req.ModelVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PatchModelVersionResponse{ModelVersion: getResp.ModelVersion}, nil
+}
+
+func (a *apiServer) TransitionModelVersion(
+	ctx context.Context, req *apiv1.TransitionModelVersionRequest,
+) (*apiv1.TransitionModelVersionResponse, error) {
+	if req.Stage == modelv1.ModelVersion_STAGE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "stage must be specified")
+	}
+	stage := strings.TrimPrefix(req.Stage.String(), "STAGE_")
+
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "transition_model_version", &modelv1.ModelVersion{},
+		req.ModelName, req.ModelVersion, stage, req.ArchiveExisting,
+	); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(
+			codes.NotFound, "model %s version %d not found", req.ModelName, req.ModelVersion)
+	case err != nil:
+		return nil, errors.Wrapf(err,
+			"error moving model %s version %d to %s", req.ModelName, req.ModelVersion, stage)
+	}
+	log.Infof("model (%s) version %d moved to stage %s", req.ModelName, req.ModelVersion, stage)
+
+	getResp, err := a.GetModelVersion(ctx, &apiv1.GetModelVersionRequest{
+		ModelName: req.ModelName, ModelVersion: req.ModelVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.TransitionModelVersionResponse{ModelVersion: getResp.ModelVersion}, nil
+}
@@ -22,14 +22,13 @@ import (
 var notebooksAddr = actor.Addr("notebooks")
 
 func (a *apiServer) GetNotebooks(
-	_ context.Context, req *apiv1.GetNotebooksRequest,
+	ctx context.Context, req *apiv1.GetNotebooksRequest,
 ) (resp *apiv1.GetNotebooksResponse, err error) {
-	err = a.actorRequest("/notebooks", req, &resp)
+	msg, err := a.inTenantProjects(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	a.sort(resp.Notebooks, req.OrderBy, req.SortBy, apiv1.GetNotebooksRequest_SORT_BY_ID)
-	return resp, a.paginate(&resp.Pagination, &resp.Notebooks, req.Offset, req.Limit)
+	return resp, a.actorRequest("/notebooks", msg, &resp)
 }
 
 func (a *apiServer) GetNotebook(
@@ -38,10 +37,47 @@ func (a *apiServer) GetNotebook(
 }
 
 func (a *apiServer) KillNotebook(
-	_ context.Context, req *apiv1.KillNotebookRequest) (resp *apiv1.KillNotebookResponse, err error) {
+	ctx context.Context, req *apiv1.KillNotebookRequest,
+) (resp *apiv1.KillNotebookResponse, err error) {
+	notebook, err := a.GetNotebook(ctx, &apiv1.GetNotebookRequest{NotebookId: req.NotebookId})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(
+		ctx, notebook.Notebook.Username, int(notebook.Notebook.ProjectId),
+	); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/notebooks/%s", req.NotebookId), req, &resp)
+}
+
+func (a *apiServer) ArchiveNotebook(
+	ctx context.Context, req *apiv1.ArchiveNotebookRequest,
+) (resp *apiv1.ArchiveNotebookResponse, err error) {
+	if err = a.checkNotebookOwner(ctx, req.NotebookId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/notebooks/%s", req.NotebookId), req, &resp)
+}
+
+func (a *apiServer) UnarchiveNotebook(
+	ctx context.Context, req *apiv1.UnarchiveNotebookRequest,
+) (resp *apiv1.UnarchiveNotebookResponse, err error) {
+	if err = a.checkNotebookOwner(ctx, req.NotebookId); err != nil {
+		return nil, err
+	}
 	return resp, a.actorRequest(fmt.Sprintf("/notebooks/%s", req.NotebookId), req, &resp)
 }
 
+// checkNotebookOwner is checkOwner for the notebook with the given ID.
+func (a *apiServer) checkNotebookOwner(ctx context.Context, id string) error {
+	notebook, err := a.GetNotebook(ctx, &apiv1.GetNotebookRequest{NotebookId: id})
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(ctx, notebook.Notebook.Username, int(notebook.Notebook.ProjectId))
+}
+
 func (a *apiServer) NotebookLogs(
 	req *apiv1.NotebookLogsRequest, resp apiv1.Determined_NotebookLogsServer) error {
 	if err := grpcutil.ValidateRequest(
@@ -83,9 +119,12 @@ func (a *apiServer) LaunchNotebook(
 	ctx context.Context, req *apiv1.LaunchNotebookRequest,
 ) (*apiv1.LaunchNotebookResponse, error) {
 	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		ProjectID:         int(req.ProjectId),
+		Interactive:       true,
 	})
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to prepare launch params")
@@ -98,9 +137,14 @@ func (a *apiServer) LaunchNotebook(
 		}, nil
 	}
 
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
 	notebookLaunchReq := command.NotebookLaunchRequest{CommandParams: params}
 	notebookIDFut := a.m.system.AskAt(notebooksAddr, notebookLaunchReq)
 	if err = api.ProcessActorResponseError(&notebookIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
 		return nil, err
 	}
 
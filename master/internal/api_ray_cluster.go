@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/logv1"
+	"github.com/determined-ai/determined/proto/pkg/rayclusterv1"
+)
+
+var rayClustersAddr = actor.Addr("ray-clusters")
+
+func (a *apiServer) GetRayClusters(
+	ctx context.Context, req *apiv1.GetRayClustersRequest,
+) (resp *apiv1.GetRayClustersResponse, err error) {
+	msg, err := a.inTenantProjects(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest("/ray-clusters", msg, &resp)
+}
+
+func (a *apiServer) GetRayCluster(
+	_ context.Context, req *apiv1.GetRayClusterRequest,
+) (resp *apiv1.GetRayClusterResponse, err error) {
+	return resp, a.actorRequest(fmt.Sprintf("/ray-clusters/%s", req.RayClusterId), req, &resp)
+}
+
+func (a *apiServer) KillRayCluster(
+	ctx context.Context, req *apiv1.KillRayClusterRequest,
+) (resp *apiv1.KillRayClusterResponse, err error) {
+	rayCluster, err := a.GetRayCluster(ctx, &apiv1.GetRayClusterRequest{
+		RayClusterId: req.RayClusterId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(
+		ctx, rayCluster.RayCluster.Username, int(rayCluster.RayCluster.ProjectId),
+	); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/ray-clusters/%s", req.RayClusterId), req, &resp)
+}
+
+func (a *apiServer) RayClusterLogs(
+	req *apiv1.RayClusterLogsRequest, resp apiv1.Determined_RayClusterLogsServer) error {
+	if err := grpcutil.ValidateRequest(
+		grpcutil.ValidateLimit(req.Limit),
+	); err != nil {
+		return err
+	}
+
+	cmdManagerAddr := rayClustersAddr.Child(req.RayClusterId)
+	eventManager := a.m.system.Get(cmdManagerAddr.Child("events"))
+
+	logRequest := api.BatchRequest{
+		Offset: int(req.Offset),
+		Limit:  int(req.Limit),
+		Follow: req.Follow,
+	}
+
+	onBatch := func(b api.Batch) error {
+		return b.ForEach(func(r interface{}) error {
+			lr := r.(*logger.Entry)
+			return resp.Send(&apiv1.RayClusterLogsResponse{
+				LogEntry: &logv1.LogEntry{Id: int32(lr.ID), Message: lr.Message},
+			})
+		})
+	}
+
+	return a.m.system.MustActorOf(
+		cmdManagerAddr.Child("logStream-"+uuid.New().String()),
+		api.NewLogStreamProcessor(
+			resp.Context(),
+			eventManager,
+			logRequest,
+			onBatch,
+		),
+	).AwaitTermination()
+}
+
+func (a *apiServer) LaunchRayCluster(
+	ctx context.Context, req *apiv1.LaunchRayClusterRequest,
+) (*apiv1.LaunchRayClusterResponse, error) {
+	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		ProjectID:         int(req.ProjectId),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to prepare launch params")
+	}
+
+	if req.Preview {
+		return &apiv1.LaunchRayClusterResponse{
+			RayCluster: &rayclusterv1.RayCluster{Workers: req.Workers},
+			Config:     protoutils.ToStruct(*params.FullConfig),
+		}, nil
+	}
+
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
+	rayClusterLaunchReq := command.RayClusterLaunchRequest{
+		CommandParams: params,
+		Workers:       int(req.Workers),
+	}
+	rayClusterIDFut := a.m.system.AskAt(rayClustersAddr, rayClusterLaunchReq)
+	if err = api.ProcessActorResponseError(&rayClusterIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
+		return nil, err
+	}
+
+	rayClusterID := rayClusterIDFut.Get().(sproto.TaskID)
+	rayCluster := a.m.system.AskAt(
+		rayClustersAddr.Child(rayClusterID), &rayclusterv1.RayCluster{})
+	if err = api.ProcessActorResponseError(&rayCluster); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LaunchRayClusterResponse{
+		RayCluster: rayCluster.Get().(*rayclusterv1.RayCluster),
+		Config:     protoutils.ToStruct(*params.FullConfig),
+	}, nil
+}
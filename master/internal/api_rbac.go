@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/rbacv1"
+)
+
+// checkPermission is Master.checkPermission for the current user.
+func (a *apiServer) checkPermission(
+	ctx context.Context, projectID int, permission model.Permission,
+) (*model.User, *model.Project, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := a.m.checkPermission(user, projectID, permission)
+	if err != nil {
+		return nil, nil, permissionStatus(err)
+	}
+	return user, project, nil
+}
+
+// checkOwner returns an error unless the current user may change an experiment or task that the
+// given user owns in a project.
+func (a *apiServer) checkOwner(ctx context.Context, owner string, projectID int) error {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return err
+	}
+	_, err = a.m.checkPermission(user, projectID, editPermission(user.Username == owner))
+	return permissionStatus(err)
+}
+
+// checkExperimentOwner is checkOwner for the experiment with the given ID.
+func (a *apiServer) checkExperimentOwner(ctx context.Context, id int) error {
+	exp, err := a.getExperiment(id)
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(ctx, exp.Username, int(exp.ProjectId))
+}
+
+func toProtoRoleAssignment(assignment model.RoleAssignment) *rbacv1.RoleAssignment {
+	result := &rbacv1.RoleAssignment{RoleName: assignment.RoleName}
+	if assignment.WorkspaceName != nil {
+		result.WorkspaceName = *assignment.WorkspaceName
+	}
+	switch {
+	case assignment.Username != nil:
+		result.Subject = &rbacv1.RoleAssignment_Username{Username: *assignment.Username}
+	case assignment.GroupName != nil:
+		result.Subject = &rbacv1.RoleAssignment_GroupName{GroupName: *assignment.GroupName}
+	}
+	return result
+}
+
+func fromProtoRoleAssignment(assignment *rbacv1.RoleAssignment) (model.RoleAssignment, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return assignment != nil, "no role assignment specified" },
+		func() (bool, string) { return assignment.RoleName != "", "no role specified" },
+		func() (bool, string) { return assignment.Subject != nil, "no user or group specified" },
+	); err != nil {
+		return model.RoleAssignment{}, err
+	}
+	result := model.RoleAssignment{RoleName: assignment.RoleName}
+	if assignment.WorkspaceName != "" {
+		result.WorkspaceName = &assignment.WorkspaceName
+	}
+	switch subject := assignment.Subject.(type) {
+	case *rbacv1.RoleAssignment_Username:
+		result.Username = &subject.Username
+	case *rbacv1.RoleAssignment_GroupName:
+		result.GroupName = &subject.GroupName
+	}
+	return result, nil
+}
+
+func (a *apiServer) GetRoles(
+	context.Context, *apiv1.GetRolesRequest) (*apiv1.GetRolesResponse, error) {
+	roles, err := a.m.db.Roles()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetRolesResponse{}
+	for _, role := range roles {
+		protoRole := &rbacv1.Role{Name: role.Name, Builtin: role.Builtin}
+		for _, p := range role.Permissions {
+			protoRole.Permissions = append(protoRole.Permissions, string(p))
+		}
+		resp.Roles = append(resp.Roles, protoRole)
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PutRole(
+	_ context.Context, req *apiv1.PutRoleRequest) (*apiv1.PutRoleResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.Role != nil, "no role specified" },
+		func() (bool, string) { return req.Role.Name != "", "no role name specified" },
+	); err != nil {
+		return nil, err
+	}
+	role := &model.Role{Name: req.Role.Name}
+	for _, p := range req.Role.Permissions {
+		if !model.ValidPermission(model.Permission(p)) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown permission: %s", p)
+		}
+		role.Permissions = append(role.Permissions, model.Permission(p))
+	}
+	switch err := a.m.db.PutRole(role); {
+	case errors.Cause(err) == db.ErrBuiltinRole:
+		return nil, status.Errorf(codes.FailedPrecondition, "role %s is built in", role.Name)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PutRoleResponse{}, nil
+}
+
+func (a *apiServer) DeleteRole(
+	_ context.Context, req *apiv1.DeleteRoleRequest) (*apiv1.DeleteRoleResponse, error) {
+	switch err := a.m.db.DeleteRole(req.RoleName); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "role not found: %s", req.RoleName)
+	case err == db.ErrBuiltinRole:
+		return nil, status.Errorf(codes.FailedPrecondition, "role %s is built in", req.RoleName)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteRoleResponse{}, nil
+}
+
+func (a *apiServer) GetGroups(
+	context.Context, *apiv1.GetGroupsRequest) (*apiv1.GetGroupsResponse, error) {
+	groups, err := a.m.db.Groups()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetGroupsResponse{}
+	for _, group := range groups {
+		resp.Groups = append(resp.Groups, &rbacv1.Group{
+			Name:      group.Name,
+			Usernames: group.Usernames,
+		})
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PutGroup(
+	_ context.Context, req *apiv1.PutGroupRequest) (*apiv1.PutGroupResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.Group != nil, "no group specified" },
+		func() (bool, string) { return req.Group.Name != "", "no group name specified" },
+	); err != nil {
+		return nil, err
+	}
+	switch err := a.m.db.PutGroup(&model.Group{
+		Name:      req.Group.Name,
+		Usernames: req.Group.Usernames,
+	}); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, errUserNotFound
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PutGroupResponse{}, nil
+}
+
+func (a *apiServer) DeleteGroup(
+	_ context.Context, req *apiv1.DeleteGroupRequest) (*apiv1.DeleteGroupResponse, error) {
+	switch err := a.m.db.DeleteGroup(req.GroupName); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "group not found: %s", req.GroupName)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteGroupResponse{}, nil
+}
+
+func (a *apiServer) GetRoleAssignments(
+	context.Context, *apiv1.GetRoleAssignmentsRequest,
+) (*apiv1.GetRoleAssignmentsResponse, error) {
+	assignments, err := a.m.db.RoleAssignments()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetRoleAssignmentsResponse{}
+	for _, assignment := range assignments {
+		resp.RoleAssignments = append(resp.RoleAssignments, toProtoRoleAssignment(assignment))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) AssignRole(
+	_ context.Context, req *apiv1.AssignRoleRequest) (*apiv1.AssignRoleResponse, error) {
+	assignment, err := fromProtoRoleAssignment(req.RoleAssignment)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.AddRoleAssignment(assignment); {
+	case err == db.ErrNotFound:
+		return nil, status.Error(codes.NotFound, "role, user, group or workspace not found")
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Error(codes.AlreadyExists, "role is already assigned")
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.AssignRoleResponse{}, nil
+}
+
+func (a *apiServer) UnassignRole(
+	_ context.Context, req *apiv1.UnassignRoleRequest) (*apiv1.UnassignRoleResponse, error) {
+	assignment, err := fromProtoRoleAssignment(req.RoleAssignment)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.RemoveRoleAssignment(assignment); {
+	case err == db.ErrNotFound:
+		return nil, status.Error(codes.NotFound, "role assignment not found")
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.UnassignRoleResponse{}, nil
+}
@@ -3,28 +3,171 @@ package internal
 import (
 	"context"
 
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 func (a *apiServer) GetResourcePools(
-	_ context.Context, req *apiv1.GetResourcePoolsRequest,
+	ctx context.Context, req *apiv1.GetResourcePoolsRequest,
 ) (resp *apiv1.GetResourcePoolsResponse, err error) {
-	switch {
-	case sproto.UseAgentRM(a.m.system):
-		err = a.actorRequest(sproto.AgentRMAddr.String(), req, &resp)
-	case sproto.UseK8sRM(a.m.system):
-		err = a.actorRequest(sproto.K8sRMAddr.String(), req, &resp)
+	var addrs []string
+	if sproto.UseAgentRM(a.m.system) {
+		addrs = append(addrs, sproto.AgentRMAddr.String())
+	}
+	if sproto.UseK8sRM(a.m.system) {
+		addrs = append(addrs, sproto.K8sRMAddr.String())
+	}
+	if sproto.UseHPCRM(a.m.system) {
+		addrs = append(addrs, sproto.HPCRMAddr.String())
+	}
+	if len(addrs) == 0 {
+		return nil, status.Error(codes.NotFound, "cannot find appropriate resource manager")
+	}
 
-	default:
-		err = status.Error(codes.NotFound, "cannot find appropriate resource manager")
+	resp = &apiv1.GetResourcePoolsResponse{}
+	for _, addr := range addrs {
+		var rmResp *apiv1.GetResourcePoolsResponse
+		if err = a.actorRequest(addr, req, &rmResp); err != nil {
+			return nil, err
+		}
+		resp.ResourcePools = append(resp.ResourcePools, rmResp.ResourcePools...)
 	}
+	visible, err := a.tenantResourcePools(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if visible != nil {
+		a.filter(&resp.ResourcePools, func(i int) bool {
+			return visible(resp.ResourcePools[i].Name)
+		})
+	}
 
 	return resp, a.paginate(&resp.Pagination, &resp.ResourcePools, req.Offset, req.Limit)
 }
+
+// checkAddedPool returns an error unless a resource pool was added through the API. The caller
+// must hold reloadLock.
+func (a *apiServer) checkAddedPool(name string) error {
+	if a.m.isAddedPool(name) {
+		return nil
+	}
+	for _, pool := range a.m.config.ResourcePools {
+		if pool.PoolName == name {
+			return status.Errorf(codes.FailedPrecondition,
+				"resource pool %s is in the configuration of the master, which must change instead",
+				name)
+		}
+	}
+	return status.Errorf(codes.NotFound, "resource pool not found: %s", name)
+}
+
+// parseResourcePoolRequest parses the config of a resource pool from a request.
+func parseResourcePoolRequest(
+	name string, config *structpb.Struct,
+) (model.ResourcePool, resourcemanagers.ResourcePoolConfig, error) {
+	pool := model.ResourcePool{Name: name, Config: model.JSONObj{}}
+	if config != nil {
+		pool.Config = config.AsMap()
+	}
+	parsed, err := parseAddedResourcePool(pool)
+	if err != nil {
+		return pool, parsed, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return pool, parsed, nil
+}
+
+func (a *apiServer) PostResourcePool(
+	_ context.Context, req *apiv1.PostResourcePoolRequest,
+) (*apiv1.PostResourcePoolResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.PoolName != "", "no pool_name specified" },
+	); err != nil {
+		return nil, err
+	}
+	pool, config, err := parseResourcePoolRequest(req.PoolName, req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	a.m.reloadLock.Lock()
+	defer a.m.reloadLock.Unlock()
+	if err = a.checkAddedPool(req.PoolName); status.Code(err) != codes.NotFound {
+		return nil, status.Errorf(codes.AlreadyExists, "resource pool %s already exists", req.PoolName)
+	}
+	pools := append(append([]resourcemanagers.ResourcePoolConfig(nil), a.m.addedPools...), config)
+	if err = a.m.setAddedPools(pools, func() error {
+		return a.m.db.AddResourcePool(pool)
+	}); err != nil {
+		return nil, err
+	}
+	return &apiv1.PostResourcePoolResponse{Config: protoutils.ToStruct(config)}, nil
+}
+
+func (a *apiServer) PatchResourcePool(
+	_ context.Context, req *apiv1.PatchResourcePoolRequest,
+) (*apiv1.PatchResourcePoolResponse, error) {
+	pool, config, err := parseResourcePoolRequest(req.PoolName, req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	a.m.reloadLock.Lock()
+	defer a.m.reloadLock.Unlock()
+	if err = a.checkAddedPool(req.PoolName); err != nil {
+		return nil, err
+	}
+	var pools []resourcemanagers.ResourcePoolConfig
+	for _, added := range a.m.addedPools {
+		if added.PoolName == req.PoolName {
+			added = config
+		}
+		pools = append(pools, added)
+	}
+	if err = a.m.setAddedPools(pools, func() error {
+		return a.m.db.UpdateResourcePool(pool)
+	}); err != nil {
+		return nil, err
+	}
+	return &apiv1.PatchResourcePoolResponse{Config: protoutils.ToStruct(config)}, nil
+}
+
+func (a *apiServer) DeleteResourcePool(
+	_ context.Context, req *apiv1.DeleteResourcePoolRequest,
+) (*apiv1.DeleteResourcePoolResponse, error) {
+	a.m.reloadLock.Lock()
+	defer a.m.reloadLock.Unlock()
+	if err := a.checkAddedPool(req.PoolName); err != nil {
+		return nil, err
+	}
+	resp := a.m.system.Ask(a.m.rm, resourcemanagers.RemoveResourcePool{PoolName: req.PoolName})
+	if err, ok := resp.Get().(error); ok && err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	switch err := a.m.db.DeleteResourcePool(req.PoolName); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "resource pool not found: %s", req.PoolName)
+	case err != nil:
+		return nil, err
+	}
+
+	var pools []resourcemanagers.ResourcePoolConfig
+	for _, added := range a.m.addedPools {
+		if added.PoolName != req.PoolName {
+			pools = append(pools, added)
+		}
+	}
+	a.m.configLock.Lock()
+	a.m.addedPools = pools
+	a.m.configLock.Unlock()
+	return &apiv1.DeleteResourcePoolResponse{}, nil
+}
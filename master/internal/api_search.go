@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// defaultSearchLimit is the number of experiments and of tasks that a search returns by default.
+const defaultSearchLimit = 20
+
+func (a *apiServer) Search(
+	ctx context.Context, req *apiv1.SearchRequest,
+) (*apiv1.SearchResponse, error) {
+	terms := api.SearchTerms(req.Query)
+	limit := int(req.Limit)
+	switch {
+	case len(terms) == 0:
+		return nil, status.Error(codes.InvalidArgument, "the query has no words to search for")
+	case limit < 0:
+		return nil, status.Error(codes.InvalidArgument, "the limit must not be negative")
+	case limit == 0:
+		limit = defaultSearchLimit
+	}
+
+	// Each term matches the words that it is a prefix of.
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefixes = append(prefixes, term+":*")
+	}
+	var states []string
+	for _, state := range req.States {
+		states = append(states, strings.TrimPrefix(state.String(), "STATE_"))
+	}
+	archivedExpr := ""
+	if req.Archived != nil {
+		archivedExpr = strconv.FormatBool(req.Archived.Value)
+	}
+
+	projects, err := a.tenantProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apiv1.SearchResponse{}
+	if err = a.m.db.QueryProto(
+		"search_experiments",
+		&resp.Experiments,
+		strings.Join(prefixes, " & "),
+		strings.Join(states, ","),
+		archivedExpr,
+		strings.Join(req.Users, ","),
+		strings.Join(req.Labels, ","),
+		req.ProjectId,
+		limit,
+		projectsExpr(projects),
+	); err != nil {
+		return nil, err
+	}
+	// Tasks have no states or labels to filter them by.
+	if len(req.States) == 0 && len(req.Labels) == 0 {
+		var archived *bool
+		if req.Archived != nil {
+			archived = &req.Archived.Value
+		}
+		resp.Tasks = command.SearchTasks(
+			a.m.system, terms, req.Users, req.ProjectId, projects, archived, limit)
+	}
+	return resp, nil
+}
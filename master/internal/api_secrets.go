@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/secretv1"
+)
+
+func (a *apiServer) GetSecrets(
+	ctx context.Context, _ *apiv1.GetSecretsRequest) (*apiv1.GetSecretsResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := a.m.db.SecretNames(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetSecretsResponse{}
+	for _, secret := range secrets {
+		resp.Secrets = append(resp.Secrets, &secretv1.Secret{
+			Name:      secret.Name,
+			UpdatedAt: timestamppb.New(secret.UpdatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PutSecret(
+	ctx context.Context, req *apiv1.PutSecretRequest) (*apiv1.PutSecretResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if err = grpcutil.ValidateRequest(
+		func() (bool, string) { return secret != nil, "no secret specified" },
+		func() (bool, string) {
+			return model.SecretNamePattern.MatchString(secret.Name),
+				"secret names must consist of letters, digits and underscores and must not " +
+					"start with a digit"
+		},
+	); err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.UpsertSecret(&model.Secret{
+		UserID: user.ID,
+		Name:   secret.Name,
+		Value:  secret.Value,
+	}); {
+	case err == db.ErrSecretsDisabled:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PutSecretResponse{}, nil
+}
+
+func (a *apiServer) DeleteSecret(
+	ctx context.Context, req *apiv1.DeleteSecretRequest) (*apiv1.DeleteSecretResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.DeleteSecret(user.ID, req.SecretName); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "secret not found: %s", req.SecretName)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteSecretResponse{}, nil
+}
@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
+	"github.com/determined-ai/determined/proto/pkg/servingv1"
+)
+
+var servingsAddr = actor.Addr("servings")
+
+func (a *apiServer) GetServings(
+	ctx context.Context, req *apiv1.GetServingsRequest,
+) (resp *apiv1.GetServingsResponse, err error) {
+	msg, err := a.inTenantProjects(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest("/servings", msg, &resp)
+}
+
+func (a *apiServer) GetServing(
+	ctx context.Context, req *apiv1.GetServingRequest,
+) (resp *apiv1.GetServingResponse, err error) {
+	if err = a.actorRequest(fmt.Sprintf("/servings/%s", req.ServingName), req, &resp); err != nil {
+		return nil, err
+	}
+	if err = a.checkTenantProject(ctx, "serving", resp.Serving.ProjectId); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (a *apiServer) KillServing(
+	ctx context.Context, req *apiv1.KillServingRequest,
+) (resp *apiv1.KillServingResponse, err error) {
+	serving, err := a.GetServing(ctx, &apiv1.GetServingRequest{ServingName: req.ServingName})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(
+		ctx, serving.Serving.Username, int(serving.Serving.ProjectId),
+	); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/servings/%s", req.ServingName), req, &resp)
+}
+
+func (a *apiServer) LaunchServing(
+	ctx context.Context, req *apiv1.LaunchServingRequest,
+) (*apiv1.LaunchServingResponse, error) {
+	servingModel, err := a.servingModel(ctx, req.ModelName, req.ModelVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
+		TemplateName: req.TemplateName,
+		Config:       req.Config,
+		Files:        req.Files,
+		ProjectID:    int(req.ProjectId),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to prepare launch params")
+	}
+
+	servingLaunchReq := command.ServingLaunchRequest{
+		CommandParams: params,
+		Name:          req.Name,
+		Model:         servingModel,
+		HealthPath:    req.HealthPath,
+		MaxRestarts:   int(req.MaxRestarts),
+	}
+	servingNameFut := a.m.system.AskAt(servingsAddr, servingLaunchReq)
+	if err = api.ProcessActorResponseError(&servingNameFut); err != nil {
+		return nil, err
+	}
+
+	servingName := servingNameFut.Get().(string)
+	serving := a.m.system.AskAt(servingsAddr.Child(servingName), &servingv1.Serving{})
+	if err = api.ProcessActorResponseError(&serving); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LaunchServingResponse{
+		Serving: serving.Get().(*servingv1.Serving),
+		Config:  protoutils.ToStruct(*params.FullConfig),
+	}, nil
+}
+
+func (a *apiServer) UpdateServing(
+	ctx context.Context, req *apiv1.UpdateServingRequest,
+) (*apiv1.UpdateServingResponse, error) {
+	current, err := a.GetServing(ctx, &apiv1.GetServingRequest{ServingName: req.ServingName})
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkOwner(
+		ctx, current.Serving.Username, int(current.Serving.ProjectId),
+	); err != nil {
+		return nil, err
+	}
+
+	servingModel := command.ServingModel{
+		Name:           current.Serving.ModelName,
+		Version:        int(current.Serving.ModelVersion),
+		CheckpointUUID: current.Serving.CheckpointUuid,
+	}
+	if req.ModelVersion != 0 && req.ModelVersion != current.Serving.ModelVersion {
+		if servingModel, err = a.servingModel(
+			ctx, current.Serving.ModelName, req.ModelVersion,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	// Without a new config, the replica of the update is launched with that of the current one.
+	config := req.Config
+	if config == nil && req.TemplateName == "" {
+		config = current.Config
+	}
+	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
+		TemplateName: req.TemplateName,
+		Config:       config,
+		Files:        req.Files,
+		ProjectID:    int(current.Serving.ProjectId),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to prepare launch params")
+	}
+
+	resp := a.m.system.AskAt(servingsAddr.Child(req.ServingName), command.ServingUpdateRequest{
+		CommandParams: params,
+		Model:         servingModel,
+	})
+	if err = api.ProcessActorResponseError(&resp); err != nil {
+		return nil, err
+	}
+	return resp.Get().(*apiv1.UpdateServingResponse), nil
+}
+
+// servingModel returns the model version to serve, whose checkpoint must not be known to be
+// corrupted or missing.
+func (a *apiServer) servingModel(
+	ctx context.Context, modelName string, version int32,
+) (command.ServingModel, error) {
+	resp, err := a.GetModelVersion(ctx, &apiv1.GetModelVersionRequest{
+		ModelName: modelName, ModelVersion: version,
+	})
+	if err != nil {
+		return command.ServingModel{}, err
+	}
+	checkpoint := resp.ModelVersion.Checkpoint
+	switch checkpoint.Integrity {
+	case checkpointv1.Integrity_INTEGRITY_CORRUPTED, checkpointv1.Integrity_INTEGRITY_MISSING:
+		return command.ServingModel{}, status.Errorf(codes.FailedPrecondition,
+			"checkpoint %s of model %s version %d is %s", checkpoint.Uuid, modelName, version,
+			strings.ToLower(strings.TrimPrefix(checkpoint.Integrity.String(), "INTEGRITY_")))
+	}
+	return command.ServingModel{
+		Name:           modelName,
+		Version:        int(version),
+		CheckpointUUID: checkpoint.Uuid,
+	}, nil
+}
@@ -16,14 +16,13 @@ import (
 var shellsAddr = actor.Addr("shells")
 
 func (a *apiServer) GetShells(
-	_ context.Context, req *apiv1.GetShellsRequest,
+	ctx context.Context, req *apiv1.GetShellsRequest,
 ) (resp *apiv1.GetShellsResponse, err error) {
-	err = a.actorRequest("/shells", req, &resp)
+	msg, err := a.inTenantProjects(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	a.sort(resp.Shells, req.OrderBy, req.SortBy, apiv1.GetShellsRequest_SORT_BY_ID)
-	return resp, a.paginate(&resp.Pagination, &resp.Shells, req.Offset, req.Limit)
+	return resp, a.actorRequest("/shells", msg, &resp)
 }
 
 func (a *apiServer) GetShell(
@@ -32,26 +31,64 @@ func (a *apiServer) GetShell(
 }
 
 func (a *apiServer) KillShell(
-	_ context.Context, req *apiv1.KillShellRequest) (resp *apiv1.KillShellResponse, err error) {
+	ctx context.Context, req *apiv1.KillShellRequest) (resp *apiv1.KillShellResponse, err error) {
+	if err = a.checkShellOwner(ctx, req.ShellId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/shells/%s", req.ShellId), req, &resp)
+}
+
+func (a *apiServer) PostShellPort(
+	ctx context.Context, req *apiv1.PostShellPortRequest,
+) (resp *apiv1.PostShellPortResponse, err error) {
+	if err = a.checkShellOwner(ctx, req.ShellId); err != nil {
+		return nil, err
+	}
 	return resp, a.actorRequest(fmt.Sprintf("/shells/%s", req.ShellId), req, &resp)
 }
 
+func (a *apiServer) DeleteShellPort(
+	ctx context.Context, req *apiv1.DeleteShellPortRequest,
+) (resp *apiv1.DeleteShellPortResponse, err error) {
+	if err = a.checkShellOwner(ctx, req.ShellId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(fmt.Sprintf("/shells/%s", req.ShellId), req, &resp)
+}
+
+// checkShellOwner returns an error unless the current user may manage a shell.
+func (a *apiServer) checkShellOwner(ctx context.Context, shellID string) error {
+	shell, err := a.GetShell(ctx, &apiv1.GetShellRequest{ShellId: shellID})
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(ctx, shell.Shell.Username, int(shell.Shell.ProjectId))
+}
+
 func (a *apiServer) LaunchShell(
 	ctx context.Context, req *apiv1.LaunchShellRequest,
 ) (*apiv1.LaunchShellResponse, error) {
 	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
-		Data:         req.Data,
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		Data:              req.Data,
+		ProjectID:         int(req.ProjectId),
+		Interactive:       true,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
 	shellLaunchReq := command.ShellLaunchRequest{CommandParams: params}
 	shellIDFut := a.m.system.AskAt(shellsAddr, shellLaunchReq)
 	if err = api.ProcessActorResponseError(&shellIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
 		return nil, err
 	}
 
@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+const (
+	// supportBundleTrialLogLines is the number of the latest logs of each trial in a support bundle.
+	supportBundleTrialLogLines = 10000
+	// supportBundleMasterLogLines is the number of the latest master logs in a support bundle.
+	supportBundleMasterLogLines = 10000
+	supportBundleFileMode       = 0o644
+)
+
+func (a *apiServer) GetSupportBundle(
+	req *apiv1.GetSupportBundleRequest, resp apiv1.Determined_GetSupportBundleServer,
+) error {
+	var bundle archive.Archive
+	var err error
+	switch {
+	case req.ExperimentId != 0 && req.TaskId != "":
+		return status.Error(codes.InvalidArgument, "only one of experiment_id and task_id may be set")
+	case req.ExperimentId != 0:
+		bundle, err = a.experimentSupportBundle(int(req.ExperimentId))
+	case req.TaskId != "":
+		bundle, err = a.taskSupportBundle(req.TaskId)
+	default:
+		return status.Error(codes.InvalidArgument, "either experiment_id or task_id is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	b, err := archive.ToTarGz(bundle)
+	if err != nil {
+		return errors.Wrap(err, "error compressing support bundle")
+	}
+	for len(b) > 0 {
+		n := artifacts.ChunkSize
+		if len(b) < n {
+			n = len(b)
+		}
+		if err := resp.Send(&apiv1.GetSupportBundleResponse{Data: b[:n]}); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// experimentSupportBundle returns the support bundle of an experiment: the experiment and its
+// config, the runs of its trials and their latest logs, and the master logs that mention them.
+func (a *apiServer) experimentSupportBundle(id int) (archive.Archive, error) {
+	exp, err := a.getExperiment(id)
+	if err != nil {
+		return nil, err
+	}
+	expJSON, err := protojson.MarshalOptions{Indent: "  "}.Marshal(exp)
+	if err != nil {
+		return nil, err
+	}
+	config, err := a.m.db.ExperimentConfigRaw(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching the config of experiment %d", id)
+	}
+	trialIDs, err := a.m.db.ExperimentTrialIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := a.m.db.TrialTasks(trialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := archive.Archive{
+		supportBundleItem("experiment.json", expJSON),
+		supportBundleItem("config.json", config),
+	}
+	tasksItem, err := supportBundleTasks(tasks)
+	if err != nil {
+		return nil, err
+	}
+	bundle = append(bundle, tasksItem)
+	for _, trialID := range trialIDs {
+		item, err := a.supportBundleTrialLogs(trialID)
+		if err != nil {
+			return nil, err
+		}
+		bundle = append(bundle, item)
+	}
+	mentions := supportBundleMentions{experimentID: id, trialIDs: trialIDs}
+	for _, t := range tasks {
+		mentions.taskIDs = append(mentions.taskIDs, t.TaskID)
+	}
+	return append(bundle, a.supportBundleMasterLogs(mentions)), nil
+}
+
+// taskSupportBundle returns the support bundle of a task: the task, the latest logs of its trial
+// if it runs one, and the master logs that mention it.
+func (a *apiServer) taskSupportBundle(id string) (archive.Archive, error) {
+	task, err := a.m.db.TaskByID(id)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "task not found: %s", id)
+	case err != nil:
+		return nil, err
+	}
+
+	tasksItem, err := supportBundleTasks([]model.Task{*task})
+	if err != nil {
+		return nil, err
+	}
+	bundle := archive.Archive{tasksItem}
+	mentions := supportBundleMentions{taskIDs: []string{id}}
+	if task.TrialID != nil {
+		item, err := a.supportBundleTrialLogs(*task.TrialID)
+		if err != nil {
+			return nil, err
+		}
+		bundle = append(bundle, item)
+		mentions.trialIDs = []int{*task.TrialID}
+	}
+	return append(bundle, a.supportBundleMasterLogs(mentions)), nil
+}
+
+// supportBundleTasks returns the file of a support bundle with the tasks in it, which show when and
+// where the scheduler placed them.
+func supportBundleTasks(tasks []model.Task) (archive.Item, error) {
+	b, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return archive.Item{}, err
+	}
+	return supportBundleItem("tasks.json", b), nil
+}
+
+// supportBundleTrialLogs returns the file of a support bundle with the latest logs of a trial.
+func (a *apiServer) supportBundleTrialLogs(trialID int) (archive.Item, error) {
+	logs, _, err := a.m.trialLogBackend.TrialLogs(
+		trialID, supportBundleTrialLogLines, nil, apiv1.OrderBy_ORDER_BY_DESC, nil)
+	if err != nil {
+		return archive.Item{}, errors.Wrapf(err, "error fetching the logs of trial %d", trialID)
+	}
+	var b strings.Builder
+	for i := len(logs) - 1; i >= 0; i-- {
+		b.WriteString(logs[i].Message)
+		if !strings.HasSuffix(logs[i].Message, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return supportBundleItem(fmt.Sprintf("trials/%d.log", trialID), []byte(b.String())), nil
+}
+
+// supportBundleMasterLogs returns the file of a support bundle with the latest master logs that
+// mention what the bundle is for.
+func (a *apiServer) supportBundleMasterLogs(mentions supportBundleMentions) archive.Item {
+	var b strings.Builder
+	for _, e := range mentions.filter(a.m.logs.Entries(-1, -1, -1), supportBundleMasterLogLines) {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", e.Time.UTC().Format(time.RFC3339Nano), e.Level, e.Message)
+	}
+	return supportBundleItem("master.log", []byte(b.String()))
+}
+
+// supportBundleMentions are what master logs mention to be in the support bundle of an experiment
+// or a task: the IDs of its tasks, in the messages or fields of logs, or the fields of the actors
+// and logs of its experiment and trials.
+type supportBundleMentions struct {
+	experimentID int
+	trialIDs     []int
+	taskIDs      []string
+}
+
+func (m supportBundleMentions) matches(message string) bool {
+	for _, id := range m.taskIDs {
+		if strings.Contains(message, id) {
+			return true
+		}
+	}
+	for _, id := range m.trialIDs {
+		if strings.Contains(message, fmt.Sprintf(" trial-id=%q", strconv.Itoa(id))) {
+			return true
+		}
+	}
+	if m.experimentID == 0 {
+		return false
+	}
+	id := strconv.Quote(strconv.Itoa(m.experimentID))
+	return strings.Contains(message, " experiment="+id) ||
+		strings.Contains(message, " experiment-id="+id) ||
+		(strings.Contains(message, " id="+id) && strings.Contains(message, ` type="experiment"`))
+}
+
+// filter returns the latest entries, up to the limit, that mention what the bundle is for.
+func (m supportBundleMentions) filter(entries []*logger.Entry, limit int) []*logger.Entry {
+	var matched []*logger.Entry
+	for _, e := range entries {
+		if m.matches(e.Message) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	return matched
+}
+
+func supportBundleItem(path string, content []byte) archive.Item {
+	return archive.RootItem(path, content, supportBundleFileMode, tar.TypeReg)
+}
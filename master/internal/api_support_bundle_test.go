@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/logger"
+)
+
+func TestSupportBundleMentions(t *testing.T) {
+	entries := []*logger.Entry{
+		{Message: `allocated resources  task-id="abc-123"`},
+		{Message: `experiment state changed to ACTIVE  id="7" system="master" type="experiment"`},
+		{Message: `experiment state changed to ACTIVE  id="17" system="master" type="experiment"`},
+		{Message: `restored experiment  experiment="7"`},
+		{Message: `trial stopped  id="7" system="master" type="agent"`},
+		{Message: `restoring trial  trial-id="12"`},
+		{Message: `restoring trial  trial-id="123"`},
+	}
+	messages := func(es []*logger.Entry) []string {
+		var res []string
+		for _, e := range es {
+			res = append(res, e.Message)
+		}
+		return res
+	}
+
+	mentions := supportBundleMentions{
+		experimentID: 7, trialIDs: []int{12}, taskIDs: []string{"abc-123"},
+	}
+	assert.DeepEqual(t, messages(mentions.filter(entries, 10)), []string{
+		entries[0].Message, entries[1].Message, entries[3].Message, entries[5].Message,
+	})
+	assert.DeepEqual(t, messages(mentions.filter(entries, 2)), []string{
+		entries[3].Message, entries[5].Message,
+	})
+
+	mentions = supportBundleMentions{taskIDs: []string{"abc-123"}}
+	assert.DeepEqual(t, messages(mentions.filter(entries, 10)), []string{entries[0].Message})
+}
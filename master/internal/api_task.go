@@ -0,0 +1,318 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/taskv1"
+)
+
+const (
+	// maxReportedStatusLength is the maximum length of a status reported by a task.
+	maxReportedStatusLength = 256
+	// defaultProfilerSampleLimit is the number of profiler samples returned by default.
+	defaultProfilerSampleLimit = 1000
+)
+
+// statusReportingTaskAddrs are the addresses of the managers whose tasks accept status reports.
+var statusReportingTaskAddrs = []actor.Address{
+	commandsAddr, notebooksAddr, shellsAddr, tensorboardsAddr, codeServersAddr, rayClustersAddr,
+}
+
+func (a *apiServer) GetTasks(
+	ctx context.Context, req *apiv1.GetTasksRequest,
+) (*apiv1.GetTasksResponse, error) {
+	projectsExpr, err := a.tenantProjectsExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var taskTypes, states []string
+	for _, t := range req.TaskTypes {
+		taskTypes = append(taskTypes, strings.TrimPrefix(t.String(), "TASK_TYPE_"))
+	}
+	for _, s := range req.States {
+		states = append(states, strings.TrimPrefix(s.String(), "STATE_"))
+	}
+	resp := &apiv1.GetTasksResponse{}
+	return resp, a.m.db.QueryProto("get_tasks", resp,
+		strings.Join(taskTypes, ","),
+		strings.Join(states, ","),
+		strings.Join(req.Users, ","),
+		req.ProjectId,
+		req.Offset,
+		req.Limit,
+		projectsExpr,
+	)
+}
+
+func (a *apiServer) StreamTaskStateChanges(
+	req *apiv1.StreamTaskStateChangesRequest, resp apiv1.Determined_StreamTaskStateChangesServer,
+) error {
+	filter, err := a.newTaskFilter(resp.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	// Watch before listing the current tasks, so that no change is missed in between. Clients may
+	// receive a task in the same state twice.
+	changes, stop := a.m.db.WatchTasks()
+	defer stop()
+
+	if req.IncludeCurrent {
+		current, err := a.GetTasks(resp.Context(), &apiv1.GetTasksRequest{
+			TaskTypes: req.TaskTypes,
+			Users:     req.Users,
+			ProjectId: req.ProjectId,
+			Limit:     -1,
+		})
+		if err != nil {
+			return err
+		}
+		// GetTasks lists the most recently started tasks first.
+		for i := len(current.Tasks) - 1; i >= 0; i-- {
+			if err := resp.Send(
+				&apiv1.StreamTaskStateChangesResponse{Task: current.Tasks[i]}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case t, ok := <-changes:
+			if !ok {
+				return status.Error(codes.ResourceExhausted,
+					"the stream fell behind the state changes of tasks; subscribe again")
+			}
+			if !filter.matches(t) {
+				continue
+			}
+			switch visible, err := a.taskInTenantScope(filter, t); {
+			case err != nil:
+				return err
+			case !visible:
+				continue
+			}
+			task, err := a.taskProto(t, filter)
+			if err != nil {
+				return err
+			}
+			if err := resp.Send(&apiv1.StreamTaskStateChangesResponse{Task: task}); err != nil {
+				return err
+			}
+		case <-resp.Context().Done():
+			return nil
+		}
+	}
+}
+
+// taskFilter selects the tasks whose state changes a stream sends, and caches what their protos
+// need besides the rows of the tasks.
+type taskFilter struct {
+	taskTypes map[model.TaskType]bool
+	// owners are the users whose tasks are selected, or nil to select the tasks of every user.
+	owners    map[model.UserID]bool
+	projectID int
+	// scope is the tenants whose tasks are selected.
+	scope model.TenantScope
+
+	usernames   map[model.UserID]string
+	experiments map[int]int
+	// tenants caches whether the tasks are in the scope, by their IDs.
+	tenants map[string]bool
+}
+
+func (a *apiServer) newTaskFilter(
+	ctx context.Context, req *apiv1.StreamTaskStateChangesRequest,
+) (*taskFilter, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := &taskFilter{
+		taskTypes:   map[model.TaskType]bool{},
+		projectID:   int(req.ProjectId),
+		scope:       scope,
+		usernames:   map[model.UserID]string{},
+		experiments: map[int]int{},
+		tenants:     map[string]bool{},
+	}
+	for _, t := range req.TaskTypes {
+		filter.taskTypes[model.TaskType(strings.TrimPrefix(t.String(), "TASK_TYPE_"))] = true
+	}
+	if len(req.Users) > 0 {
+		filter.owners = map[model.UserID]bool{}
+		for _, username := range req.Users {
+			user, err := a.m.db.UserByUsername(username)
+			switch {
+			case errors.Cause(err) == db.ErrNotFound:
+				continue
+			case err != nil:
+				return nil, err
+			}
+			filter.owners[user.ID] = true
+			filter.usernames[user.ID] = user.Username
+		}
+	}
+	return filter, nil
+}
+
+func (f *taskFilter) matches(t model.Task) bool {
+	switch {
+	case len(f.taskTypes) > 0 && !f.taskTypes[t.TaskType]:
+		return false
+	case f.owners != nil && (t.OwnerID == nil || !f.owners[*t.OwnerID]):
+		return false
+	case f.projectID != 0 && (t.ProjectID == nil || *t.ProjectID != f.projectID):
+		return false
+	}
+	return true
+}
+
+// taskInTenantScope returns true if the task belongs to a tenant in the scope of the filter.
+func (a *apiServer) taskInTenantScope(filter *taskFilter, t model.Task) (bool, error) {
+	if filter.scope.All {
+		return true, nil
+	}
+	visible, ok := filter.tenants[t.TaskID]
+	if !ok {
+		tenantID, err := a.m.db.ResourceTenant(db.TenantTask, t.TaskID)
+		if err != nil {
+			return false, err
+		}
+		visible = filter.scope.Contains(tenantID)
+		filter.tenants[t.TaskID] = visible
+	}
+	return visible, nil
+}
+
+// taskProto converts a task to its proto, looking up its owner and the experiment of its trial
+// unless the filter has them cached.
+func (a *apiServer) taskProto(t model.Task, filter *taskFilter) (*taskv1.Task, error) {
+	task := &taskv1.Task{
+		Id:           t.TaskID,
+		Type:         t.TaskType.Proto(),
+		State:        t.State.Proto(),
+		Description:  t.Description,
+		ResourcePool: t.ResourcePool,
+		StartTime:    timestamppb.New(t.StartTime),
+	}
+	if t.EndTime != nil {
+		task.EndTime = timestamppb.New(*t.EndTime)
+	}
+	if t.ProjectID != nil {
+		task.ProjectId = int32(*t.ProjectID)
+	}
+	if t.OwnerID != nil {
+		username, ok := filter.usernames[*t.OwnerID]
+		if !ok {
+			user, err := a.m.db.UserByID(*t.OwnerID)
+			if err != nil {
+				return nil, err
+			}
+			username = user.Username
+			filter.usernames[*t.OwnerID] = username
+		}
+		task.Username = username
+	}
+	if t.TrialID != nil {
+		experimentID, ok := filter.experiments[*t.TrialID]
+		if !ok {
+			var err error
+			if experimentID, err = a.m.db.ExperimentIDByTrialID(*t.TrialID); err != nil {
+				return nil, err
+			}
+			filter.experiments[*t.TrialID] = experimentID
+		}
+		task.TrialId = int32(*t.TrialID)
+		task.ExperimentId = int32(experimentID)
+	}
+	return task, nil
+}
+
+func (a *apiServer) GetTaskProfilerMetrics(
+	_ context.Context, req *apiv1.GetTaskProfilerMetricsRequest,
+) (*apiv1.GetTaskProfilerMetricsResponse, error) {
+	if req.TaskId == "" && req.TrialId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "either task_id or trial_id is required")
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultProfilerSampleLimit
+	}
+	var since *time.Time
+	if req.Since != nil {
+		t := req.Since.AsTime()
+		since = &t
+	}
+	resp := &apiv1.GetTaskProfilerMetricsResponse{}
+	return resp, a.m.db.QueryProto("get_task_profiler_metrics", resp,
+		req.TaskId, req.TrialId, since, limit)
+}
+
+func (a *apiServer) ReportTaskStatus(
+	ctx context.Context, req *apiv1.ReportTaskStatusRequest,
+) (*apiv1.ReportTaskStatusResponse, error) {
+	if err := validateTaskStatus(req); err != nil {
+		return nil, err
+	}
+
+	session, err := grpcutil.GetTaskSession(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	return a.reportTaskStatus(session.TaskID, req)
+}
+
+// validateTaskStatus returns an InvalidArgument error if a status report is malformed.
+func validateTaskStatus(req *apiv1.ReportTaskStatusRequest) error {
+	switch {
+	case len(req.Status) > maxReportedStatusLength:
+		return status.Errorf(codes.InvalidArgument,
+			"status must be at most %d characters long", maxReportedStatusLength)
+	case math.IsNaN(req.Progress) || req.Progress < 0 || req.Progress > 100:
+		return status.Error(codes.InvalidArgument, "progress must be between 0 and 100")
+	}
+	return nil
+}
+
+// reportTaskStatus forwards a status report to the task that made it.
+func (a *apiServer) reportTaskStatus(
+	taskID string, req *apiv1.ReportTaskStatusRequest,
+) (resp *apiv1.ReportTaskStatusResponse, err error) {
+	for _, addr := range statusReportingTaskAddrs {
+		if ref := a.m.system.Get(addr.Child(taskID)); ref != nil {
+			return resp, a.askAtDefaultSystem(ref.Address(), req, &resp)
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "task %s does not accept status reports", taskID)
+}
+
+func (a *apiServer) RotateTaskToken(
+	ctx context.Context, _ *apiv1.RotateTaskTokenRequest,
+) (*apiv1.RotateTaskTokenResponse, error) {
+	session, err := grpcutil.GetTaskSession(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	switch token, err := a.m.db.RotateTaskSession(session); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, grpcutil.ErrInvalidCredentials
+	case err != nil:
+		return nil, err
+	default:
+		return &apiv1.RotateTaskTokenResponse{Token: token}, nil
+	}
+}
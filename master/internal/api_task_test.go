@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestReportTaskStatusValidation(t *testing.T) {
+	a := &apiServer{m: &Master{}}
+	for _, req := range []*apiv1.ReportTaskStatusRequest{
+		{Status: "loading", Progress: -1},
+		{Status: "loading", Progress: 100.5},
+		{Status: "loading", Progress: math.NaN()},
+		{Status: strings.Repeat("x", maxReportedStatusLength+1), Progress: 50},
+	} {
+		// Invalid reports are rejected before the task session is looked up.
+		_, err := a.ReportTaskStatus(context.Background(), req)
+		assert.Equal(t, status.Code(err), codes.InvalidArgument, "report %v", req)
+	}
+	assert.NilError(t, validateTaskStatus(&apiv1.ReportTaskStatusRequest{
+		Status: "loading", Progress: 100,
+	}))
+}
+
+func TestReportTaskStatusForwarding(t *testing.T) {
+	system := actor.NewSystem("")
+	var received *apiv1.ReportTaskStatusRequest
+	_, created := system.ActorOf(commandsAddr.Child("task"), actor.ActorFunc(
+		func(ctx *actor.Context) error {
+			if msg, ok := ctx.Message().(*apiv1.ReportTaskStatusRequest); ok {
+				received = msg
+				ctx.Respond(&apiv1.ReportTaskStatusResponse{})
+			}
+			return nil
+		}))
+	assert.Assert(t, created)
+	a := &apiServer{m: &Master{system: system}}
+
+	req := &apiv1.ReportTaskStatusRequest{Status: "loading", Progress: 42}
+	resp, err := a.reportTaskStatus("task", req)
+	assert.NilError(t, err)
+	assert.Assert(t, resp != nil)
+	assert.Equal(t, received, req)
+
+	_, err = a.reportTaskStatus("unknown", req)
+	assert.Equal(t, status.Code(err), codes.NotFound)
+}
+
+func TestTaskFilterMatches(t *testing.T) {
+	user, other := model.UserID(1), model.UserID(2)
+	project := 3
+	task := model.Task{TaskType: model.TaskTypeCommand, OwnerID: &user, ProjectID: &project}
+	ownerless := model.Task{TaskType: model.TaskTypeCommand}
+
+	assert.Assert(t, (&taskFilter{}).matches(task))
+	assert.Assert(t, (&taskFilter{}).matches(ownerless))
+
+	byType := &taskFilter{taskTypes: map[model.TaskType]bool{model.TaskTypeTrial: true}}
+	assert.Assert(t, !byType.matches(task))
+	byType.taskTypes[model.TaskTypeCommand] = true
+	assert.Assert(t, byType.matches(task))
+
+	byOwner := &taskFilter{owners: map[model.UserID]bool{other: true}}
+	assert.Assert(t, !byOwner.matches(task))
+	assert.Assert(t, !byOwner.matches(ownerless))
+	// Filtering by users that don't exist matches no task.
+	assert.Assert(t, !(&taskFilter{owners: map[model.UserID]bool{}}).matches(task))
+	byOwner.owners[user] = true
+	assert.Assert(t, byOwner.matches(task))
+
+	byProject := &taskFilter{projectID: project + 1}
+	assert.Assert(t, !byProject.matches(task))
+	assert.Assert(t, !byProject.matches(ownerless))
+	byProject.projectID = project
+	assert.Assert(t, byProject.matches(task))
+}
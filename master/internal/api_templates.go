@@ -10,14 +10,15 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/templatev1"
 )
 
 func (a *apiServer) GetTemplates(
-	_ context.Context, req *apiv1.GetTemplatesRequest) (*apiv1.GetTemplatesResponse, error) {
+	ctx context.Context, req *apiv1.GetTemplatesRequest) (*apiv1.GetTemplatesResponse, error) {
 	resp := &apiv1.GetTemplatesResponse{}
-	if err := a.m.db.QueryProto("get_templates", &resp.Templates); err != nil {
+	if err := a.m.db.QueryProtoContext(ctx, "get_templates", &resp.Templates); err != nil {
 		return nil, errors.Wrap(err, "error fetching templates from database")
 	}
 	a.filter(&resp.Templates, func(i int) bool {
@@ -28,9 +29,9 @@ func (a *apiServer) GetTemplates(
 }
 
 func (a *apiServer) GetTemplate(
-	_ context.Context, req *apiv1.GetTemplateRequest) (*apiv1.GetTemplateResponse, error) {
+	ctx context.Context, req *apiv1.GetTemplateRequest) (*apiv1.GetTemplateResponse, error) {
 	t := &templatev1.Template{}
-	switch err := a.m.db.QueryProto("get_template", t, req.TemplateName); err {
+	switch err := a.m.db.QueryProtoContext(ctx, "get_template", t, req.TemplateName); err {
 	case db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "error fetching template from database: %s", req.TemplateName)
@@ -41,13 +42,19 @@ func (a *apiServer) GetTemplate(
 }
 
 func (a *apiServer) PutTemplate(
-	_ context.Context, req *apiv1.PutTemplateRequest) (*apiv1.PutTemplateResponse, error) {
+	ctx context.Context, req *apiv1.PutTemplateRequest) (*apiv1.PutTemplateResponse, error) {
 	config, err := protojson.Marshal(req.Template.Config)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid config provided: %s", err.Error())
 	}
-	err = a.m.db.QueryProto("put_template", req.Template, req.Template.Name, config)
-	return &apiv1.PutTemplateResponse{Template: req.Template},
+	if err = a.m.db.UpsertTemplate(
+		&model.Template{Name: req.Template.Name, Config: config}); err != nil {
+		return nil, errors.Wrapf(err, "error putting template")
+	}
+	// Respond with the template as it is stored, with its storage credentials redacted.
+	t := &templatev1.Template{}
+	err = a.m.db.QueryProtoContext(ctx, "get_template", t, req.Template.Name)
+	return &apiv1.PutTemplateResponse{Template: t},
 		errors.Wrapf(err, "error putting template")
 }
 
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/tenantv1"
+)
+
+func toProtoTenant(t model.Tenant) *tenantv1.Tenant {
+	return &tenantv1.Tenant{
+		Id:             int32(t.ID),
+		Name:           t.Name,
+		Usernames:      t.Usernames,
+		WorkspaceNames: t.WorkspaceNames,
+		ResourcePools:  t.ResourcePools,
+	}
+}
+
+// visibleTenant returns the tenant with the given name, or a NotFound error if it does not exist
+// or the caller of a request may not see it.
+func (a *apiServer) visibleTenant(ctx context.Context, name string) (*model.Tenant, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := a.m.db.Tenants()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		if t.Name == name && scope.Contains(&t.ID) {
+			return &t, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "tenant not found: %s", name)
+}
+
+func (a *apiServer) GetTenants(
+	ctx context.Context, _ *apiv1.GetTenantsRequest,
+) (*apiv1.GetTenantsResponse, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := a.m.db.Tenants()
+	if err != nil {
+		return nil, err
+	}
+	// The users of a tenant only see their own.
+	resp := &apiv1.GetTenantsResponse{}
+	for _, t := range tenants {
+		if scope.Contains(&t.ID) {
+			resp.Tenants = append(resp.Tenants, toProtoTenant(t))
+		}
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PutTenant(
+	_ context.Context, req *apiv1.PutTenantRequest,
+) (*apiv1.PutTenantResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.Tenant != nil, "no tenant specified" },
+		func() (bool, string) { return req.Tenant.Name != "", "a tenant name must be specified" },
+	); err != nil {
+		return nil, err
+	}
+	t := model.Tenant{
+		Name:           req.Tenant.Name,
+		Usernames:      req.Tenant.Usernames,
+		WorkspaceNames: req.Tenant.WorkspaceNames,
+		ResourcePools:  req.Tenant.ResourcePools,
+	}
+	switch err := a.m.db.PutTenant(&t); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Error(codes.NotFound, "users and workspaces of tenants must exist")
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PutTenantResponse{Tenant: toProtoTenant(t)}, nil
+}
+
+func (a *apiServer) DeleteTenant(
+	_ context.Context, req *apiv1.DeleteTenantRequest,
+) (*apiv1.DeleteTenantResponse, error) {
+	switch err := a.m.db.DeleteTenant(req.TenantName); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "tenant not found: %s", req.TenantName)
+	case errors.Cause(err) == db.ErrNotEmpty:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"tenant %s still has users or workspaces", req.TenantName)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteTenantResponse{}, nil
+}
+
+func (a *apiServer) GetTenantUsage(
+	ctx context.Context, req *apiv1.GetTenantUsageRequest,
+) (*apiv1.GetTenantUsageResponse, error) {
+	t, err := a.visibleTenant(ctx, req.TenantName)
+	if err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	if req.EndTime != nil {
+		end = req.EndTime.AsTime()
+	}
+	start := model.BudgetPeriodStart(end)
+	if req.StartTime != nil {
+		start = req.StartTime.AsTime()
+	}
+	if !start.Before(end) {
+		return nil, status.Error(codes.InvalidArgument, "start_time must be before end_time")
+	}
+
+	slotHours, err := a.m.db.TenantSlotHours(t.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	// Costs are estimated at the current prices, like those of the resource usage.
+	prices := a.m.config.SlotHourPrices()
+	resp := &apiv1.GetTenantUsageResponse{}
+	for pool, hours := range slotHours {
+		usage := &tenantv1.TenantUsage{ResourcePool: pool, SlotHours: hours}
+		if price, ok := prices[pool]; ok {
+			usage.Cost = wrapperspb.Double(hours * price)
+			resp.TotalCost += usage.Cost.Value
+		}
+		resp.TotalSlotHours += hours
+		resp.Usage = append(resp.Usage, usage)
+	}
+	sort.Slice(resp.Usage, func(i, j int) bool {
+		return resp.Usage[i].ResourcePool < resp.Usage[j].ResourcePool
+	})
+	return resp, nil
+}
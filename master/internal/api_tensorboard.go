@@ -5,8 +5,12 @@ import (
 	"os"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/archive"
@@ -36,14 +40,13 @@ func filesToArchive(files []*utilv1.File) archive.Archive {
 }
 
 func (a *apiServer) GetTensorboards(
-	_ context.Context, req *apiv1.GetTensorboardsRequest,
+	ctx context.Context, req *apiv1.GetTensorboardsRequest,
 ) (resp *apiv1.GetTensorboardsResponse, err error) {
-	err = a.actorRequest(tensorboardsAddr.String(), req, &resp)
+	msg, err := a.inTenantProjects(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	a.sort(resp.Tensorboards, req.OrderBy, req.SortBy, apiv1.GetTensorboardsRequest_SORT_BY_ID)
-	return resp, a.paginate(&resp.Pagination, &resp.Tensorboards, req.Offset, req.Limit)
+	return resp, a.actorRequest(tensorboardsAddr.String(), msg, &resp)
 }
 
 func (a *apiServer) GetTensorboard(
@@ -53,11 +56,63 @@ func (a *apiServer) GetTensorboard(
 }
 
 func (a *apiServer) KillTensorboard(
-	_ context.Context, req *apiv1.KillTensorboardRequest,
+	ctx context.Context, req *apiv1.KillTensorboardRequest,
 ) (resp *apiv1.KillTensorboardResponse, err error) {
+	tensorboard, err := a.GetTensorboard(
+		ctx, &apiv1.GetTensorboardRequest{TensorboardId: req.TensorboardId})
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	// Killing a TensorBoard that launches with reuse share releases a launch of the caller and
+	// only kills it once no launch shares it. Callers who do not share it kill it as usual.
+	released, _ := a.m.system.AskAt(tensorboardsAddr, command.ReleaseTensorboard{
+		ID: sproto.TaskID(req.TensorboardId), Username: user.Username,
+	}).Get().(command.ReleasedTensorboard)
+	switch {
+	case released.Shared && released.Remaining > 0:
+		return &apiv1.KillTensorboardResponse{Tensorboard: tensorboard.Tensorboard}, nil
+	case !released.Shared:
+		if err = a.checkOwner(
+			ctx, tensorboard.Tensorboard.Username, int(tensorboard.Tensorboard.ProjectId),
+		); err != nil {
+			return nil, err
+		}
+	}
+	return resp, a.actorRequest(tensorboardsAddr.Child(req.TensorboardId).String(), req, &resp)
+}
+
+func (a *apiServer) ArchiveTensorboard(
+	ctx context.Context, req *apiv1.ArchiveTensorboardRequest,
+) (resp *apiv1.ArchiveTensorboardResponse, err error) {
+	if err = a.checkTensorboardOwner(ctx, req.TensorboardId); err != nil {
+		return nil, err
+	}
+	return resp, a.actorRequest(tensorboardsAddr.Child(req.TensorboardId).String(), req, &resp)
+}
+
+func (a *apiServer) UnarchiveTensorboard(
+	ctx context.Context, req *apiv1.UnarchiveTensorboardRequest,
+) (resp *apiv1.UnarchiveTensorboardResponse, err error) {
+	if err = a.checkTensorboardOwner(ctx, req.TensorboardId); err != nil {
+		return nil, err
+	}
 	return resp, a.actorRequest(tensorboardsAddr.Child(req.TensorboardId).String(), req, &resp)
 }
 
+// checkTensorboardOwner is checkOwner for the TensorBoard with the given ID.
+func (a *apiServer) checkTensorboardOwner(ctx context.Context, id string) error {
+	tensorboard, err := a.GetTensorboard(ctx, &apiv1.GetTensorboardRequest{TensorboardId: id})
+	if err != nil {
+		return err
+	}
+	return a.checkOwner(
+		ctx, tensorboard.Tensorboard.Username, int(tensorboard.Tensorboard.ProjectId))
+}
+
 func (a *apiServer) LaunchTensorboard(
 	ctx context.Context, req *apiv1.LaunchTensorboardRequest,
 ) (*apiv1.LaunchTensorboardResponse, error) {
@@ -69,24 +124,48 @@ func (a *apiServer) LaunchTensorboard(
 	for _, id := range req.TrialIds {
 		trialIds = append(trialIds, int(id))
 	}
+	if req.Reuse && (len(req.Files) > 0 || req.ContextArtifactId != "") {
+		return nil, status.Error(codes.InvalidArgument,
+			"tensorboards launched with files or a context cannot be reused")
+	}
 
 	params, err := a.prepareLaunchParams(ctx, &protoCommandParams{
-		TemplateName: req.TemplateName,
-		Config:       req.Config,
-		Files:        req.Files,
-		MustZeroSlot: true,
+		TemplateName:      req.TemplateName,
+		Config:            req.Config,
+		Files:             req.Files,
+		ContextArtifactID: req.ContextArtifactId,
+		MustZeroSlot:      true,
+		ProjectID:         int(req.ProjectId),
+		Interactive:       true,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.storeContext(ctx, params); err != nil {
+		return nil, err
+	}
+
 	tensorboardLaunchReq := command.TensorboardRequest{
 		CommandParams: params,
 		ExperimentIDs: experimentIds,
 		TrialIDs:      trialIds,
+		LogPaths:      req.LogPaths,
+		Reuse:         req.Reuse,
+	}
+	// The users of tenants do not read log paths with the credentials of the master, which reach
+	// the checkpoints of every tenant.
+	if scope.All {
+		tensorboardLaunchReq.CheckpointStorage = a.m.config.CheckpointStorage
 	}
 	tensorboardIDFut := a.m.system.AskAt(tensorboardsAddr, tensorboardLaunchReq)
 	if err = api.ProcessActorResponseError(&tensorboardIDFut); err != nil {
+		a.deleteTempContext(ctx, params)
 		return nil, err
 	}
 
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/tokenv1"
+)
+
+const (
+	defaultAPITokenTTL = 24 * time.Hour
+	maxAPITokenTTL     = 365 * 24 * time.Hour
+)
+
+func toProtoAPIToken(token model.APIToken) *tokenv1.APIToken {
+	var scopes []string
+	for _, scope := range token.Scopes() {
+		scopes = append(scopes, string(scope))
+	}
+	return &tokenv1.APIToken{
+		Id:          int32(token.ID),
+		Description: token.Description,
+		Scopes:      scopes,
+		CreatedAt:   timestamppb.New(token.CreatedAt),
+		ExpiresAt:   timestamppb.New(token.ExpiresAt),
+	}
+}
+
+func (a *apiServer) GetAPITokens(
+	ctx context.Context, _ *apiv1.GetAPITokensRequest) (*apiv1.GetAPITokensResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := a.m.db.APITokens(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetAPITokensResponse{}
+	for _, token := range tokens {
+		resp.Tokens = append(resp.Tokens, toProtoAPIToken(token))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostAPIToken(
+	ctx context.Context, req *apiv1.PostAPITokenRequest) (*apiv1.PostAPITokenResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	if ttl == 0 {
+		ttl = defaultAPITokenTTL
+	}
+	if err = grpcutil.ValidateRequest(
+		func() (bool, string) { return len(req.Scopes) > 0, "no scopes specified" },
+		func() (bool, string) {
+			for _, scope := range req.Scopes {
+				if !model.ValidTokenScope(model.TokenScope(scope)) {
+					return false, "unknown scope: " + scope
+				}
+			}
+			return true, ""
+		},
+		func() (bool, string) { return ttl > 0, "ttl_seconds must not be negative" },
+		func() (bool, string) {
+			return ttl <= maxAPITokenTTL, "API tokens must expire within 365 days"
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	token := &model.APIToken{
+		UserID:      user.ID,
+		Description: req.Description,
+		Scope:       strings.Join(req.Scopes, " "),
+		ExpiresAt:   time.Now().UTC().Add(ttl),
+	}
+	raw, err := a.m.db.AddAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostAPITokenResponse{TokenInfo: toProtoAPIToken(*token), Token: raw}, nil
+}
+
+func (a *apiServer) DeleteAPIToken(
+	ctx context.Context, req *apiv1.DeleteAPITokenRequest) (*apiv1.DeleteAPITokenResponse, error) {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	// Admins may revoke the tokens of any user, for example when one leaks. Other users do not
+	// learn whether the tokens of others exist.
+	token, err := a.m.db.APITokenByID(int(req.TokenId))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound || (err == nil && token.UserID != user.ID && !user.Admin):
+		return nil, status.Errorf(codes.NotFound, "API token not found: %d", req.TokenId)
+	case err != nil:
+		return nil, err
+	}
+	switch err = a.m.db.DeleteAPIToken(token.ID); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "API token not found: %d", req.TokenId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteAPITokenResponse{}, nil
+}
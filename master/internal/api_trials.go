@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/protoutil"
 
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/determined-ai/determined/proto/pkg/logv1"
 
@@ -219,8 +221,86 @@ func (a *apiServer) TrialLogsFields(
 	).Run(resp.Context())
 }
 
+func (a *apiServer) SearchTrialLogs(
+	req *apiv1.SearchTrialLogsRequest, resp apiv1.Determined_SearchTrialLogsServer) error {
+	if err := grpcutil.ValidateRequest(
+		grpcutil.ValidateLimit(req.Limit),
+		func() (bool, string) {
+			return req.Context >= 0 && req.Context <= maxTrialLogSearchContext,
+				fmt.Sprintf("Context must be between 0 and %d", maxTrialLogSearchContext)
+		},
+	); err != nil {
+		return err
+	}
+	if req.Pattern == "" {
+		return status.Error(codes.InvalidArgument, "pattern is required")
+	}
+	pattern, err := regexp.Compile(req.Pattern)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid pattern: %s", err)
+	}
+
+	switch exists, err := a.m.db.CheckTrialExists(int(req.TrialId)); {
+	case err != nil:
+		return err
+	case !exists:
+		return trialNotFound
+	}
+
+	filters, err := constructTrialLogsFilters(&apiv1.TrialLogsRequest{
+		TimestampBefore: req.TimestampBefore,
+		TimestampAfter:  req.TimestampAfter,
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("unsupported filter: %s", err))
+	}
+
+	// The logs are searched as they are read in batches, so only the logs around the matches that
+	// are still pending are held at once.
+	var sent int
+	send := func(matches []*trialLogMatch) (bool, error) {
+		for _, m := range matches {
+			pm, err := m.Proto()
+			if err != nil {
+				return false, err
+			}
+			if err := resp.Send(pm); err != nil {
+				return false, err
+			}
+			sent++
+			if req.Limit > 0 && sent >= int(req.Limit) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	search := trialLogSearch{pattern: pattern, context: int(req.Context)}
+	var state interface{}
+	for {
+		if err := resp.Context().Err(); err != nil {
+			return err
+		}
+		logs, next, err := a.m.trialLogBackend.TrialLogs(
+			int(req.TrialId), trialLogsBatchSize, filters, apiv1.OrderBy_ORDER_BY_ASC, state)
+		if err != nil {
+			return err
+		}
+		state = next
+		if len(logs) == 0 {
+			_, err := send(search.flush())
+			return err
+		}
+		for _, l := range logs {
+			if done, err := send(search.add(l)); err != nil || done {
+				return err
+			}
+		}
+	}
+}
+
 func (a *apiServer) GetTrialCheckpoints(
-	_ context.Context, req *apiv1.GetTrialCheckpointsRequest,
+	ctx context.Context, req *apiv1.GetTrialCheckpointsRequest,
 ) (*apiv1.GetTrialCheckpointsResponse, error) {
 	switch exists, err := a.m.db.CheckTrialExists(int(req.Id)); {
 	case err != nil:
@@ -232,7 +312,9 @@ func (a *apiServer) GetTrialCheckpoints(
 	resp := &apiv1.GetTrialCheckpointsResponse{}
 	resp.Checkpoints = []*checkpointv1.Checkpoint{}
 
-	switch err := a.m.db.QueryProto("get_checkpoints_for_trial", &resp.Checkpoints, req.Id); {
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "get_checkpoints_for_trial", &resp.Checkpoints, req.Id,
+	); {
 	case err == db.ErrNotFound:
 		return nil, status.Errorf(
 			codes.NotFound, "no checkpoints found for trial %d", req.Id)
@@ -287,6 +369,13 @@ func (a *apiServer) KillTrial(
 	case !ok:
 		return nil, status.Errorf(codes.NotFound, "trial %d not found", req.Id)
 	}
+	trial, err := a.m.db.TrialByID(int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err = a.checkExperimentOwner(ctx, trial.ExperimentID); err != nil {
+		return nil, err
+	}
 
 	resp := apiv1.KillTrialResponse{}
 	addr := actor.Addr("trials", req.Id).String()
@@ -298,7 +387,7 @@ func (a *apiServer) KillTrial(
 }
 
 func (a *apiServer) GetExperimentTrials(
-	_ context.Context, req *apiv1.GetExperimentTrialsRequest,
+	ctx context.Context, req *apiv1.GetExperimentTrialsRequest,
 ) (*apiv1.GetExperimentTrialsResponse, error) {
 	ok, err := a.m.db.CheckExperimentExists(int(req.ExperimentId))
 	switch {
@@ -366,8 +455,8 @@ func (a *apiServer) GetExperimentTrials(
 		trialIds = append(trialIds, strconv.Itoa(int(trial.Id)))
 	}
 
-	switch err := a.m.db.QueryProto(
-		"proto_get_trials_plus",
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "proto_get_trials_plus",
 		&resp.Trials,
 		"{"+strings.Join(trialIds, ",")+"}",
 	); {
@@ -380,12 +469,12 @@ func (a *apiServer) GetExperimentTrials(
 	return resp, nil
 }
 
-func (a *apiServer) GetTrial(_ context.Context, req *apiv1.GetTrialRequest) (
+func (a *apiServer) GetTrial(ctx context.Context, req *apiv1.GetTrialRequest) (
 	*apiv1.GetTrialResponse, error,
 ) {
 	resp := &apiv1.GetTrialResponse{Trial: &trialv1.Trial{}}
-	switch err := a.m.db.QueryProto(
-		"proto_get_trials_plus",
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "proto_get_trials_plus",
 		resp.Trial,
 		"{"+strconv.Itoa(int(req.TrialId))+"}",
 	); {
@@ -395,8 +484,8 @@ func (a *apiServer) GetTrial(_ context.Context, req *apiv1.GetTrialRequest) (
 		return nil, errors.Wrapf(err, "failed to get trial %d", req.TrialId)
 	}
 
-	switch err := a.m.db.QueryProto(
-		"proto_get_trial_workloads",
+	switch err := a.m.db.QueryProtoContext(
+		ctx, "proto_get_trial_workloads",
 		&resp.Workloads,
 		req.TrialId,
 	); {
@@ -681,6 +770,11 @@ func (a *apiServer) ReportTrialTrainingMetrics(
 	if err := a.m.db.AddTrainingMetrics(ctx, req.TrainingMetrics); err != nil {
 		return nil, err
 	}
+	if err := a.reportTrialMetrics(
+		int(req.TrainingMetrics.TrialId), req.TrainingMetrics.Metrics,
+	); err != nil {
+		return nil, err
+	}
 	return &apiv1.ReportTrialTrainingMetricsResponse{}, nil
 }
 
@@ -693,9 +787,28 @@ func (a *apiServer) ReportTrialValidationMetrics(
 	if err := a.m.db.AddValidationMetrics(ctx, req.ValidationMetrics); err != nil {
 		return nil, err
 	}
+	if err := a.reportTrialMetrics(
+		int(req.ValidationMetrics.TrialId), req.ValidationMetrics.Metrics,
+	); err != nil {
+		return nil, err
+	}
 	return &apiv1.ReportTrialValidationMetricsResponse{}, nil
 }
 
+// reportTrialMetrics passes the metrics that a trial reported to its actor, which checks them
+// against the early stopping rules of its experiment.
+func (a *apiServer) reportTrialMetrics(trialID int, metrics *structpb.Struct) error {
+	if metrics == nil {
+		return nil
+	}
+	trial, err := a.trialActorFromID(trialID)
+	if err != nil {
+		return err
+	}
+	a.m.system.TellAt(trial, trialReportMetrics{metrics: metrics.AsMap()})
+	return nil
+}
+
 func (a *apiServer) ReportTrialCheckpointMetadata(
 	ctx context.Context, req *apiv1.ReportTrialCheckpointMetadataRequest,
 ) (*apiv1.ReportTrialCheckpointMetadataResponse, error) {
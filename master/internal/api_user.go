@@ -3,13 +3,17 @@ package internal
 import (
 	"context"
 	"sort"
+	"strings"
 
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/ssh"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/userv1"
 )
@@ -59,13 +63,20 @@ func getUser(d *db.PgDB, username string) (*userv1.User, error) {
 }
 
 func (a *apiServer) GetUsers(
-	context.Context, *apiv1.GetUsersRequest) (*apiv1.GetUsersResponse, error) {
+	ctx context.Context, _ *apiv1.GetUsersRequest) (*apiv1.GetUsersResponse, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
 	users, err := a.m.db.UserList()
 	if err != nil {
 		return nil, err
 	}
 	result := &apiv1.GetUsersResponse{}
 	for _, user := range users {
+		if !scope.Contains(user.TenantID) {
+			continue
+		}
 		result.Users = append(result.Users, toProtoUserFromFullUser(user))
 	}
 	sort.Slice(result.Users, func(i, j int) bool {
@@ -95,10 +106,12 @@ func (a *apiServer) PostUser(
 	); err != nil {
 		return nil, err
 	}
+	// The users that the admins of tenants create belong to their tenants.
 	user := &model.User{
 		Username: req.User.Username,
 		Admin:    req.User.Admin,
 		Active:   req.User.Active,
+		TenantID: a.m.tenantScope(curUser).TenantID,
 	}
 	if err = user.UpdatePasswordHash(req.Password); err != nil {
 		return nil, err
@@ -143,3 +156,176 @@ func (a *apiServer) SetUserPassword(
 	fullUser, err := getUser(a.m.db, req.Username)
 	return &apiv1.SetUserPasswordResponse{User: fullUser}, err
 }
+
+// managedUser returns the user whose registry credentials or SSH keys a request manages, if the
+// requesting user may manage them.
+func (a *apiServer) managedUser(
+	ctx context.Context, username string,
+) (*model.User, error) {
+	curUser, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return nil, err
+	}
+	if !curUser.Admin && curUser.Username != username {
+		return nil, grpcutil.ErrPermissionDenied
+	}
+	switch user, err := a.m.db.UserByUsername(username); {
+	case err == db.ErrNotFound:
+		return nil, errUserNotFound
+	case err != nil:
+		return nil, err
+	default:
+		return user, nil
+	}
+}
+
+func (a *apiServer) GetUserRegistryCredentials(
+	ctx context.Context, req *apiv1.GetUserRegistryCredentialsRequest,
+) (*apiv1.GetUserRegistryCredentialsResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := a.m.db.RegistryCredentials(user.ID)
+	switch {
+	case errors.Cause(err) == db.ErrRegistryCredentialsDisabled:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	resp := &apiv1.GetUserRegistryCredentialsResponse{}
+	for _, cred := range creds {
+		resp.RegistryCredentials = append(resp.RegistryCredentials, &userv1.RegistryCredential{
+			ServerAddress: cred.ServerAddress,
+			Username:      cred.Username,
+		})
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PutUserRegistryCredential(
+	ctx context.Context, req *apiv1.PutUserRegistryCredentialRequest,
+) (*apiv1.PutUserRegistryCredentialResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	cred := req.RegistryCredential
+	if err = grpcutil.ValidateRequest(
+		func() (bool, string) { return cred != nil, "no registry credential specified" },
+		func() (bool, string) { return cred.ServerAddress != "", "no server address specified" },
+		func() (bool, string) { return cred.Username != "", "no registry username specified" },
+	); err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.UpsertRegistryCredential(&model.RegistryCredential{
+		UserID:        user.ID,
+		ServerAddress: cred.ServerAddress,
+		Username:      cred.Username,
+		Password:      cred.Password,
+	}); {
+	case errors.Cause(err) == db.ErrRegistryCredentialsDisabled:
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PutUserRegistryCredentialResponse{}, nil
+}
+
+func (a *apiServer) DeleteUserRegistryCredential(
+	ctx context.Context, req *apiv1.DeleteUserRegistryCredentialRequest,
+) (*apiv1.DeleteUserRegistryCredentialResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.DeleteRegistryCredential(user.ID, req.ServerAddress); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(
+			codes.NotFound, "no registry credentials stored for %s", req.ServerAddress)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteUserRegistryCredentialResponse{}, nil
+}
+
+func toProtoSSHKey(key model.SSHKey) *userv1.SSHKey {
+	return &userv1.SSHKey{
+		Id:          int32(key.ID),
+		Name:        key.Name,
+		PublicKey:   key.PublicKey,
+		Fingerprint: key.Fingerprint,
+		CreatedAt:   protoutils.ToTimestamp(key.CreatedAt),
+	}
+}
+
+func (a *apiServer) GetUserSSHKeys(
+	ctx context.Context, req *apiv1.GetUserSSHKeysRequest,
+) (*apiv1.GetUserSSHKeysResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.m.db.SSHKeys(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetUserSSHKeysResponse{}
+	for _, key := range keys {
+		resp.SshKeys = append(resp.SshKeys, toProtoSSHKey(key))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostUserSSHKey(
+	ctx context.Context, req *apiv1.PostUserSSHKeyRequest,
+) (*apiv1.PostUserSSHKeyResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ssh.ParsePublicKey(req.PublicKey)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// The name follows the key in authorized_keys files, so it must stay on one line.
+	if strings.ContainsAny(req.Name, "\r\n") {
+		return nil, status.Error(codes.InvalidArgument, "SSH key names must be a single line")
+	}
+	key := model.SSHKey{
+		UserID:      user.ID,
+		Name:        req.Name,
+		PublicKey:   publicKey.AuthorizedKey,
+		Fingerprint: publicKey.Fingerprint,
+	}
+	if key.Name == "" {
+		key.Name = publicKey.Comment
+	}
+	if key.Name == "" {
+		key.Name = publicKey.Fingerprint
+	}
+	switch err = a.m.db.AddSSHKey(&key); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(
+			codes.AlreadyExists, "SSH key %s is already registered", key.Fingerprint)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PostUserSSHKeyResponse{SshKey: toProtoSSHKey(key)}, nil
+}
+
+func (a *apiServer) DeleteUserSSHKey(
+	ctx context.Context, req *apiv1.DeleteUserSSHKeyRequest,
+) (*apiv1.DeleteUserSSHKeyResponse, error) {
+	user, err := a.managedUser(ctx, req.Username)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.DeleteSSHKey(user.ID, int(req.Id)); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "SSH key %d not found", req.Id)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteUserSSHKeyResponse{}, nil
+}
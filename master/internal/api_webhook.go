@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
+	"github.com/determined-ai/determined/proto/pkg/webhookv1"
+)
+
+func webhookToProto(w model.Webhook) *webhookv1.Webhook {
+	pb := &webhookv1.Webhook{Id: int32(w.ID), Url: w.URL}
+	if w.ProjectID != nil {
+		pb.ProjectId = int32(*w.ProjectID)
+	}
+	if w.ExperimentID != nil {
+		pb.ExperimentId = int32(*w.ExperimentID)
+	}
+	for _, state := range w.States {
+		pb.States = append(pb.States,
+			experimentv1.State(experimentv1.State_value["STATE_"+string(state)]))
+	}
+	return pb
+}
+
+// checkWebhookPermission returns an error unless the user of the request may manage the webhooks
+// of the project or experiment: the webhooks of a project are those of all experiments in it.
+func (a *apiServer) checkWebhookPermission(
+	ctx context.Context, projectID, experimentID int,
+) error {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return err
+	}
+	if projectID != 0 {
+		_, err = a.m.checkPermission(user, projectID, model.PermissionEditAll)
+		return permissionStatus(err)
+	}
+	exp, err := a.m.db.ExperimentWithoutConfigByID(experimentID)
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return status.Errorf(codes.NotFound, "experiment not found: %d", experimentID)
+	case err != nil:
+		return err
+	}
+	return permissionStatus(a.m.checkExperimentPermission(user, exp))
+}
+
+func (a *apiServer) GetWebhooks(
+	ctx context.Context, req *apiv1.GetWebhooksRequest,
+) (*apiv1.GetWebhooksResponse, error) {
+	if (req.ProjectId == 0) == (req.ExperimentId == 0) {
+		return nil, status.Error(codes.InvalidArgument,
+			"exactly one of project_id and experiment_id must be specified")
+	}
+	if err := a.checkWebhookPermission(
+		ctx, int(req.ProjectId), int(req.ExperimentId),
+	); err != nil {
+		return nil, err
+	}
+	webhooks, err := a.m.db.Webhooks(int(req.ProjectId), int(req.ExperimentId))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetWebhooksResponse{}
+	for _, w := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, webhookToProto(w))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostWebhook(
+	ctx context.Context, req *apiv1.PostWebhookRequest,
+) (*apiv1.PostWebhookResponse, error) {
+	pb := req.Webhook
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return pb != nil, "no webhook specified" },
+		func() (bool, string) {
+			return (pb.ProjectId == 0) != (pb.ExperimentId == 0),
+				"exactly one of project_id and experiment_id must be specified"
+		},
+		func() (bool, string) {
+			return strings.HasPrefix(pb.Url, "http://") || strings.HasPrefix(pb.Url, "https://"),
+				"url must be an http or https URL"
+		},
+	); err != nil {
+		return nil, err
+	}
+	w := model.Webhook{URL: pb.Url}
+	for _, s := range pb.States {
+		state := model.State(strings.TrimPrefix(s.String(), "STATE_"))
+		if !model.TerminalStates[state] {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"webhooks can only be notified of terminal states, not %v", state)
+		}
+		if !w.States.Has(state) {
+			w.States = append(w.States, state)
+		}
+	}
+	if len(w.States) == 0 {
+		w.States = model.WebhookStates{
+			model.CompletedState, model.ErrorState, model.CanceledState, model.TimeoutState,
+		}
+	}
+	if err := a.checkWebhookPermission(
+		ctx, int(pb.ProjectId), int(pb.ExperimentId),
+	); err != nil {
+		return nil, err
+	}
+	if pb.ProjectId != 0 {
+		projectID := int(pb.ProjectId)
+		w.ProjectID = &projectID
+	} else {
+		experimentID := int(pb.ExperimentId)
+		w.ExperimentID = &experimentID
+	}
+	if err := a.m.db.AddWebhook(&w); err != nil {
+		return nil, err
+	}
+	return &apiv1.PostWebhookResponse{Webhook: webhookToProto(w)}, nil
+}
+
+func (a *apiServer) DeleteWebhook(
+	ctx context.Context, req *apiv1.DeleteWebhookRequest,
+) (*apiv1.DeleteWebhookResponse, error) {
+	w, err := a.m.db.WebhookByID(int(req.WebhookId))
+	switch {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "webhook not found: %d", req.WebhookId)
+	case err != nil:
+		return nil, err
+	}
+	projectID, experimentID := 0, 0
+	if w.ProjectID != nil {
+		projectID = *w.ProjectID
+	}
+	if w.ExperimentID != nil {
+		experimentID = *w.ExperimentID
+	}
+	if err = a.checkWebhookPermission(ctx, projectID, experimentID); err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.DeleteWebhook(w.ID); {
+	case err == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "webhook not found: %d", req.WebhookId)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteWebhookResponse{}, nil
+}
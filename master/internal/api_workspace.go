@@ -0,0 +1,290 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/workspacev1"
+)
+
+func toProtoWorkspace(workspace *model.Workspace) *workspacev1.Workspace {
+	return &workspacev1.Workspace{
+		Id:                  int32(workspace.ID),
+		Name:                workspace.Name,
+		DefaultResourcePool: workspace.DefaultResourcePool,
+		DefaultImage:        workspace.DefaultImage,
+	}
+}
+
+func toProtoProject(project *model.Project) *workspacev1.Project {
+	return &workspacev1.Project{
+		Id:          int32(project.ID),
+		Name:        project.Name,
+		Description: project.Description,
+		WorkspaceId: int32(project.WorkspaceID),
+	}
+}
+
+// validateWorkspace returns an error unless the workspace has a name and its default resource
+// pool, if any, exists.
+func (a *apiServer) validateWorkspace(workspace *model.Workspace) error {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return workspace.Name != "", "no workspace name specified" },
+	); err != nil {
+		return err
+	}
+	if workspace.DefaultResourcePool != "" {
+		if err := sproto.ValidateRP(a.m.system, workspace.DefaultResourcePool); err != nil {
+			return status.Errorf(codes.InvalidArgument,
+				"resource pool does not exist: %s", workspace.DefaultResourcePool)
+		}
+		err := a.m.checkResourcePoolTenant(workspace, workspace.DefaultResourcePool, 0)
+		if err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return nil
+}
+
+// workspaceVisible returns true if the caller of a request may see the workspace and its projects:
+// the workspace belongs to a tenant that they may see and they are a member of it.
+func (a *apiServer) workspaceVisible(
+	ctx context.Context, workspace *model.Workspace,
+) (bool, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil || !scope.Contains(workspace.TenantID) {
+		return false, err
+	}
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return false, err
+	}
+	return a.m.db.IsWorkspaceMember(user, workspace.ID)
+}
+
+// getWorkspace returns the workspace with the given ID or a NotFound error, which is also returned
+// for the workspaces that the caller of the request may not see.
+func (a *apiServer) getWorkspace(ctx context.Context, id int) (*model.Workspace, error) {
+	workspace, err := a.m.db.WorkspaceByID(id)
+	if err != nil && errors.Cause(err) != db.ErrNotFound {
+		return nil, err
+	}
+	if err == nil {
+		visible, vErr := a.workspaceVisible(ctx, workspace)
+		if vErr != nil {
+			return nil, vErr
+		}
+		if visible {
+			return workspace, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "workspace not found: %d", id)
+}
+
+// checkWorkspacePermission returns an error unless the current user holds the permission within
+// the workspace.
+func (a *apiServer) checkWorkspacePermission(
+	ctx context.Context, workspaceID int, permission model.Permission,
+) error {
+	user, _, err := grpcutil.GetUser(ctx, a.m.db)
+	if err != nil {
+		return err
+	}
+	permissions, err := a.m.db.UserPermissions(user, workspaceID)
+	if err != nil {
+		return err
+	}
+	if !permissions.Has(permission) {
+		return permissionStatus(
+			permissionDeniedError{username: user.Username, permission: permission})
+	}
+	return nil
+}
+
+func (a *apiServer) GetWorkspaces(
+	ctx context.Context, _ *apiv1.GetWorkspacesRequest,
+) (*apiv1.GetWorkspacesResponse, error) {
+	workspaces, err := a.m.db.Workspaces()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetWorkspacesResponse{}
+	for i := range workspaces {
+		switch visible, err := a.workspaceVisible(ctx, &workspaces[i]); {
+		case err != nil:
+			return nil, err
+		case visible:
+			resp.Workspaces = append(resp.Workspaces, toProtoWorkspace(&workspaces[i]))
+		}
+	}
+	return resp, nil
+}
+
+func (a *apiServer) GetWorkspace(
+	ctx context.Context, req *apiv1.GetWorkspaceRequest,
+) (*apiv1.GetWorkspaceResponse, error) {
+	workspace, err := a.getWorkspace(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetWorkspaceResponse{Workspace: toProtoWorkspace(workspace)}, nil
+}
+
+func (a *apiServer) PostWorkspace(
+	ctx context.Context, req *apiv1.PostWorkspaceRequest,
+) (*apiv1.PostWorkspaceResponse, error) {
+	if req.Workspace == nil {
+		return nil, status.Error(codes.InvalidArgument, "no workspace specified")
+	}
+	// The workspaces that the admins of tenants create belong to their tenants.
+	scope, err := a.tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workspace := &model.Workspace{
+		Name:                req.Workspace.Name,
+		DefaultResourcePool: req.Workspace.DefaultResourcePool,
+		DefaultImage:        req.Workspace.DefaultImage,
+		TenantID:            scope.TenantID,
+	}
+	if err := a.validateWorkspace(workspace); err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.AddWorkspace(workspace); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(codes.AlreadyExists, "workspace %s already exists", workspace.Name)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PostWorkspaceResponse{Workspace: toProtoWorkspace(workspace)}, nil
+}
+
+func (a *apiServer) PatchWorkspace(
+	ctx context.Context, req *apiv1.PatchWorkspaceRequest,
+) (*apiv1.PatchWorkspaceResponse, error) {
+	if req.Workspace == nil {
+		return nil, status.Error(codes.InvalidArgument, "no workspace specified")
+	}
+	workspace, err := a.getWorkspace(ctx, int(req.Workspace.Id))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range req.UpdateMask.GetPaths() {
+		switch path {
+		case "name":
+			workspace.Name = req.Workspace.Name
+		case "default_resource_pool":
+			workspace.DefaultResourcePool = req.Workspace.DefaultResourcePool
+		case "default_image":
+			workspace.DefaultImage = req.Workspace.DefaultImage
+		default:
+			return nil, status.Errorf(codes.InvalidArgument,
+				"only name, default_resource_pool and default_image are mutable. cannot update %s",
+				path)
+		}
+	}
+	if err = a.validateWorkspace(workspace); err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.UpdateWorkspace(workspace); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(codes.AlreadyExists, "workspace %s already exists", workspace.Name)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PatchWorkspaceResponse{Workspace: toProtoWorkspace(workspace)}, nil
+}
+
+func (a *apiServer) DeleteWorkspace(
+	ctx context.Context, req *apiv1.DeleteWorkspaceRequest,
+) (*apiv1.DeleteWorkspaceResponse, error) {
+	if _, err := a.getWorkspace(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
+	switch err := a.m.db.DeleteWorkspace(int(req.Id)); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "workspace not found: %d", req.Id)
+	case err == db.ErrNotEmpty:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"workspace %d is the default workspace or still has projects", req.Id)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteWorkspaceResponse{}, nil
+}
+
+func (a *apiServer) GetWorkspaceProjects(
+	ctx context.Context, req *apiv1.GetWorkspaceProjectsRequest,
+) (*apiv1.GetWorkspaceProjectsResponse, error) {
+	if _, err := a.getWorkspace(ctx, int(req.Id)); err != nil {
+		return nil, err
+	}
+	projects, err := a.m.db.Projects(int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetWorkspaceProjectsResponse{}
+	for i := range projects {
+		resp.Projects = append(resp.Projects, toProtoProject(&projects[i]))
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PostProject(
+	ctx context.Context, req *apiv1.PostProjectRequest,
+) (*apiv1.PostProjectResponse, error) {
+	if err := grpcutil.ValidateRequest(
+		func() (bool, string) { return req.Project != nil, "no project specified" },
+		func() (bool, string) { return req.Project.Name != "", "no project name specified" },
+	); err != nil {
+		return nil, err
+	}
+	project := &model.Project{
+		Name:        req.Project.Name,
+		Description: req.Project.Description,
+		WorkspaceID: int(req.Project.WorkspaceId),
+	}
+	if project.WorkspaceID == 0 {
+		project.WorkspaceID = model.DefaultWorkspaceID
+	}
+	if _, err := a.getWorkspace(ctx, project.WorkspaceID); err != nil {
+		return nil, err
+	}
+	err := a.checkWorkspacePermission(ctx, project.WorkspaceID, model.PermissionEditOwn)
+	if err != nil {
+		return nil, err
+	}
+	switch err = a.m.db.AddProject(project); {
+	case err == db.ErrDuplicateRecord:
+		return nil, status.Errorf(codes.AlreadyExists, "project %s already exists", project.Name)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PostProjectResponse{Project: toProtoProject(project)}, nil
+}
+
+func (a *apiServer) DeleteProject(
+	ctx context.Context, req *apiv1.DeleteProjectRequest,
+) (*apiv1.DeleteProjectResponse, error) {
+	if _, _, err := a.checkPermission(ctx, int(req.Id), model.PermissionEditAll); err != nil {
+		return nil, err
+	}
+	switch err := a.m.db.DeleteProject(int(req.Id)); {
+	case errors.Cause(err) == db.ErrNotFound:
+		return nil, status.Errorf(codes.NotFound, "project not found: %d", req.Id)
+	case err == db.ErrNotEmpty:
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"project %d is the default project or still has experiments", req.Id)
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.DeleteProjectResponse{}, nil
+}
@@ -0,0 +1,56 @@
+package apilimits
+
+import (
+	"strings"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config is the configuration of the limits on calls to the API of the master.
+type Config struct {
+	// AdminAllowlist lists the networks, in CIDR notation or as single IP addresses, that the API
+	// methods that manage the cluster, its users and their roles may be called from. They may be
+	// called from anywhere if it is empty.
+	AdminAllowlist []string `json:"admin_allowlist"`
+	// RateLimit limits how often each user, API token, task or anonymous client calls the API.
+	RateLimit RateLimitConfig `json:"rate_limit"`
+}
+
+// RateLimitConfig configures token buckets that every caller of the API draws a token from per
+// call. Calls are rejected while the bucket of their caller is empty.
+type RateLimitConfig struct {
+	Limit
+	// Methods sets separate limits for API methods, by name, e.g. "GetExperiments". Each caller
+	// has a bucket per method listed here and one for all other methods.
+	Methods map[string]Limit `json:"methods"`
+}
+
+// Limit is the size and refill rate of a token bucket.
+type Limit struct {
+	// RequestsPerSecond is the rate at which buckets refill. Calls are not limited if it is zero.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is how many tokens buckets hold, which defaults to RequestsPerSecond rounded up.
+	Burst int `json:"burst"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	errs := []error{c.RateLimit.Limit.validate("api_limits.rate_limit")}
+	for _, network := range c.AdminAllowlist {
+		if _, err := parseNetwork(network); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for method, limit := range c.RateLimit.Methods {
+		errs = append(errs,
+			check.True(method != "" && !strings.Contains(method, "/"),
+				"api_limits.rate_limit.methods must be keyed by method names, not %q", method),
+			limit.validate("api_limits.rate_limit.methods."+method))
+	}
+	return errs
+}
+
+func (l Limit) validate(name string) error {
+	return check.True(l.RequestsPerSecond >= 0 && l.Burst >= 0,
+		"%s.requests_per_second and %s.burst must not be negative", name, name)
+}
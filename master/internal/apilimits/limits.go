@@ -0,0 +1,141 @@
+package apilimits
+
+import (
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// pruneInterval is how often buckets that have refilled are forgotten.
+const pruneInterval = time.Minute
+
+// Limits enforces the limits on calls to the API. A nil *Limits allows every call.
+type Limits struct {
+	allowlist []*net.IPNet
+	rateLimit RateLimitConfig
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastPrune time.Time
+}
+
+type bucketKey struct {
+	caller string
+	// method is empty for the bucket of the methods without their own limit.
+	method string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+	// refill is how long the bucket takes to fill up from empty.
+	refill time.Duration
+}
+
+// New creates the limits of a configuration.
+func New(config Config) (*Limits, error) {
+	l := &Limits{rateLimit: config.RateLimit, buckets: map[bucketKey]*bucket{}}
+	for _, network := range config.AdminAllowlist {
+		ipNet, err := parseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		l.allowlist = append(l.allowlist, ipNet)
+	}
+	return l, nil
+}
+
+// parseNetwork parses a network in CIDR notation or a single IP address.
+func parseNetwork(network string) (*net.IPNet, error) {
+	if !strings.Contains(network, "/") {
+		ip := net.ParseIP(network)
+		if ip == nil {
+			return nil, errors.Errorf("invalid address in api_limits.admin_allowlist: %q", network)
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, errors.Errorf("invalid network in api_limits.admin_allowlist: %q", network)
+	}
+	return ipNet, nil
+}
+
+// AllowAdmin returns true if the methods that manage the cluster may be called from an address.
+func (l *Limits) AllowAdmin(address string) bool {
+	if l == nil || len(l.allowlist) == 0 {
+		return true
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range l.allowlist {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow takes a token from the bucket of a caller for a method and returns false if it is empty.
+// The method is given by its name, e.g. "GetExperiments".
+func (l *Limits) Allow(caller, method string) bool {
+	return l.allowAt(caller, method, time.Now())
+}
+
+func (l *Limits) allowAt(caller, method string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	key := bucketKey{caller: caller}
+	limit, ok := l.rateLimit.Methods[method]
+	if ok {
+		key.method = method
+	} else {
+		limit = l.rateLimit.Limit
+	}
+	if limit.RequestsPerSecond == 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		burst := limit.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(limit.RequestsPerSecond))
+		}
+		b = &bucket{
+			limiter: rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), burst),
+			refill:  time.Duration(float64(burst) / limit.RequestsPerSecond * float64(time.Second)),
+		}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b.limiter.AllowN(now, 1)
+}
+
+// prune forgets the buckets that have been full for a while, which are the same as new ones.
+func (l *Limits) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > b.refill+pruneInterval {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package apilimits
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestAllowAdmin(t *testing.T) {
+	l, err := New(Config{AdminAllowlist: []string{"10.0.0.0/8", "192.168.1.5", "::1"}})
+	assert.NilError(t, err)
+	assert.Assert(t, l.AllowAdmin("10.1.2.3"))
+	assert.Assert(t, l.AllowAdmin("192.168.1.5"))
+	assert.Assert(t, l.AllowAdmin("::1"))
+	assert.Assert(t, !l.AllowAdmin("192.168.1.6"))
+	assert.Assert(t, !l.AllowAdmin("127.0.0.1"))
+	assert.Assert(t, !l.AllowAdmin(""))
+
+	open, err := New(Config{})
+	assert.NilError(t, err)
+	assert.Assert(t, open.AllowAdmin("192.168.1.6"))
+	assert.Assert(t, (*Limits)(nil).AllowAdmin("192.168.1.6"))
+
+	_, err = New(Config{AdminAllowlist: []string{"10.0.0.0/33"}})
+	assert.ErrorContains(t, err, "invalid network")
+}
+
+func TestAllow(t *testing.T) {
+	l, err := New(Config{RateLimit: RateLimitConfig{
+		Limit: Limit{RequestsPerSecond: 2},
+		Methods: map[string]Limit{
+			"GetExperiments": {RequestsPerSecond: 0.5, Burst: 1},
+			"GetMaster":      {},
+		},
+	}})
+	assert.NilError(t, err)
+	now := time.Now()
+
+	// Callers have their own buckets, which hold RequestsPerSecond tokens by default.
+	assert.Assert(t, l.allowAt("user 1", "GetTrials", now))
+	assert.Assert(t, l.allowAt("user 1", "GetModels", now))
+	assert.Assert(t, !l.allowAt("user 1", "GetTrials", now))
+	assert.Assert(t, l.allowAt("user 2", "GetTrials", now))
+	assert.Assert(t, l.allowAt("user 1", "GetTrials", now.Add(500*time.Millisecond)))
+
+	// Methods with their own limits have separate buckets.
+	assert.Assert(t, l.allowAt("user 1", "GetExperiments", now))
+	assert.Assert(t, !l.allowAt("user 1", "GetExperiments", now.Add(time.Second)))
+	assert.Assert(t, l.allowAt("user 1", "GetExperiments", now.Add(2*time.Second)))
+
+	// Methods may be exempt.
+	for i := 0; i < 10; i++ {
+		assert.Assert(t, l.allowAt("user 1", "GetMaster", now))
+	}
+
+	// Buckets that have refilled are forgotten.
+	l.allowAt("user 3", "GetTrials", now.Add(10*time.Minute))
+	assert.Equal(t, len(l.buckets), 1)
+
+	assert.Assert(t, (*Limits)(nil).Allow("user 1", "GetTrials"))
+	unlimited, err := New(Config{})
+	assert.NilError(t, err)
+	assert.Assert(t, unlimited.allowAt("user 1", "GetTrials", now))
+	assert.Equal(t, len(unlimited.buckets), 0)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, len(nonNil(Config{
+		AdminAllowlist: []string{"10.0.0.0/8", "::1"},
+		RateLimit: RateLimitConfig{
+			Limit:   Limit{RequestsPerSecond: 10, Burst: 20},
+			Methods: map[string]Limit{"GetExperiments": {RequestsPerSecond: 1}},
+		},
+	}.Validate())), 0)
+	assert.Equal(t, len(nonNil(Config{
+		AdminAllowlist: []string{"not an address"},
+		RateLimit: RateLimitConfig{
+			Limit: Limit{RequestsPerSecond: -1},
+			Methods: map[string]Limit{
+				"/determined.api.v1.Determined/GetExperiments": {Burst: -1},
+			},
+		},
+	}.Validate())), 4)
+}
+
+func nonNil(errs []error) []error {
+	var result []error
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
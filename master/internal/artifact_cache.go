@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+type evictBlobsTick struct{}
+
+// blobEvictor periodically evicts the blobs of artifacts that no artifact uses anymore and that are
+// past the limits of the cache of blobs.
+type blobEvictor struct {
+	artifacts *artifacts.Service
+	config    artifacts.ChunkCacheConfig
+}
+
+func (e *blobEvictor) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, evictBlobsTick:
+		// Don't return the error, since we want to keep this actor alive and try again next time.
+		evicted, err := e.artifacts.EvictBlobs(context.Background(), e.config)
+		if err != nil {
+			ctx.Log().WithError(err).Error("failed to evict artifact blobs")
+		}
+		if evicted > 0 {
+			ctx.Log().Infof("evicted %d unused artifact blobs", evicted)
+		}
+		actors.NotifyAfter(ctx, time.Duration(e.config.EvictionInterval)*time.Second, evictBlobsTick{})
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
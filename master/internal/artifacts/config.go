@@ -0,0 +1,99 @@
+package artifacts
+
+import (
+	"path/filepath"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+const (
+	// DBType stores artifacts as chunks in the database of the master.
+	DBType = "db"
+	// SharedFSType stores artifacts in a directory on the master's host.
+	SharedFSType = "shared_fs"
+	// S3Type stores artifacts in an S3 bucket.
+	S3Type = "s3"
+)
+
+// Config is the configuration of where the master stores the artifacts that users and tasks
+// upload.
+type Config struct {
+	// Type is the kind of storage: "db", "shared_fs" or "s3". Artifacts are disabled if it is
+	// empty.
+	Type string `json:"type"`
+	// StoragePath is the directory that shared_fs artifacts are stored in.
+	StoragePath string `json:"storage_path"`
+	// Bucket is the S3 bucket that s3 artifacts are stored in, under Prefix.
+	Bucket      string  `json:"bucket"`
+	Prefix      string  `json:"prefix"`
+	Region      *string `json:"region"`
+	AccessKey   *string `json:"access_key"`
+	SecretKey   *string `json:"secret_key"`
+	EndpointURL *string `json:"endpoint_url"`
+	// MaxSizeBytes is the largest that an artifact may grow to while it uploads. There is no limit
+	// if it is 0.
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+	// ChunkCache is how long the blobs that no artifact uses anymore are kept.
+	ChunkCache ChunkCacheConfig `json:"chunk_cache"`
+}
+
+// ChunkCacheConfig is the configuration of the cache of blobs, the deduplicated chunks of
+// artifacts that no artifact uses anymore, which are kept so that contexts that are uploaded again
+// with few changes only upload the chunks that changed.
+type ChunkCacheConfig struct {
+	// MaxAgeHours is the number of hours after they were last used that blobs are evicted.
+	MaxAgeHours int `json:"max_age_hours"`
+	// MaxSizeBytes is the largest total size of the cached blobs, beyond which the least recently
+	// used are evicted. There is no limit if it is 0.
+	MaxSizeBytes int64 `json:"max_size_bytes"`
+	// EvictionInterval is the duration in seconds between the runs of the job that evicts blobs.
+	EvictionInterval int `json:"eviction_interval"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c ChunkCacheConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.MaxAgeHours, 0,
+			"artifacts.chunk_cache.max_age_hours must not be negative"),
+		check.GreaterThanOrEqualTo(c.MaxSizeBytes, int64(0),
+			"artifacts.chunk_cache.max_size_bytes must not be negative"),
+		check.GreaterThan(c.EvictionInterval, 0,
+			"artifacts.chunk_cache.eviction_interval must be positive"),
+	}
+}
+
+// Enabled returns true if the master stores artifacts.
+func (c Config) Enabled() bool {
+	return c.Type != ""
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	hiddenValue := "********"
+	if c.AccessKey != nil {
+		c.AccessKey = &hiddenValue
+	}
+	if c.SecretKey != nil {
+		c.SecretKey = &hiddenValue
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	errs := []error{
+		check.GreaterThanOrEqualTo(c.MaxSizeBytes, int64(0), "artifacts.max_size_bytes must be >= 0"),
+	}
+	switch c.Type {
+	case "", DBType:
+	case SharedFSType:
+		errs = append(errs, check.True(filepath.IsAbs(c.StoragePath),
+			"artifacts.storage_path must be an absolute path for shared_fs artifacts"))
+	case S3Type:
+		errs = append(errs, check.NotEmpty(c.Bucket, "artifacts.bucket must be set for s3 artifacts"))
+	default:
+		errs = append(errs, check.In(c.Type, []string{DBType, SharedFSType, S3Type},
+			"artifacts.type must be db, shared_fs or s3"))
+	}
+	return errs
+}
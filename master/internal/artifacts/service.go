@@ -0,0 +1,453 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ChunkSize is the largest chunk that artifacts are uploaded in, so that each chunk fits in a
+// single API request.
+const ChunkSize = 2 << 20
+
+var (
+	// ErrNotUploading is returned when uploading a chunk of an artifact that was completed.
+	ErrNotUploading = errors.New("the artifact was completed")
+	// ErrNotCompleted is returned when reading an artifact that is still uploading.
+	ErrNotCompleted = errors.New("the artifact is still uploading")
+	// ErrChunkOrder is returned when the chunks of an artifact are not uploaded in order.
+	ErrChunkOrder = errors.New("the chunks of an artifact must be uploaded in order")
+	// ErrChunkSize is returned when a chunk is empty or larger than ChunkSize.
+	ErrChunkSize = errors.Errorf("chunks must have between 1 and %d bytes", ChunkSize)
+	// ErrTooLarge is returned when an artifact would grow larger than the maximum size.
+	ErrTooLarge = errors.New("the artifact is larger than the maximum size")
+	// ErrUploadMode is returned when uploading chunks of an artifact both by index and by manifest.
+	ErrUploadMode = errors.New(
+		"the chunks of an artifact are uploaded either by index or by manifest, not both")
+	// ErrInvalidHash is returned when a manifest lists a hash that is not a SHA-256 hash.
+	ErrInvalidHash = errors.New("chunk hashes must be hex-encoded SHA-256 hashes")
+	// ErrNotInManifest is returned when uploading a blob that the manifest of an artifact does not
+	// list.
+	ErrNotInManifest = errors.New("the chunk is not in the manifest of the artifact")
+	// ErrMissingChunks is returned when completing an artifact whose manifest lists blobs that were
+	// not uploaded, or that were evicted before the manifest listed them.
+	ErrMissingChunks = errors.New("chunks in the manifest of the artifact were not uploaded")
+)
+
+// hashPattern matches the hex-encoded SHA-256 hashes that identify blobs.
+var hashPattern = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// Service stores the artifacts that users upload as the contexts of their commands and that tasks
+// upload as their outputs. A nil service means that the master does not store artifacts.
+type Service struct {
+	db      *db.PgDB
+	storage storage
+	maxSize int64
+
+	mu sync.Mutex
+	// uploads serializes the chunks that are uploaded to each artifact.
+	uploads map[string]*sync.Mutex
+}
+
+// New returns the artifact service of a configuration, or nil if artifacts are disabled.
+func New(pgDB *db.PgDB, config Config) (*Service, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	storage, err := newStorage(pgDB, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		db:      pgDB,
+		storage: storage,
+		maxSize: config.MaxSizeBytes,
+		uploads: map[string]*sync.Mutex{},
+	}, nil
+}
+
+// chunkKey is the key of a chunk of an artifact in the storage.
+func chunkKey(id string, index int) string {
+	return fmt.Sprintf("%s/%08d", id, index)
+}
+
+// blobKey is the key of a blob in the storage.
+func blobKey(hash string) string {
+	return fmt.Sprintf("blobs/%s/%s", hash[:2], hash)
+}
+
+// lock returns the lock of the upload of an artifact or of a blob, locked.
+func (s *Service) lock(id string) *sync.Mutex {
+	s.mu.Lock()
+	l, ok := s.uploads[id]
+	if !ok {
+		l = &sync.Mutex{}
+		s.uploads[id] = l
+	}
+	s.mu.Unlock()
+	l.Lock()
+	return l
+}
+
+// forget drops the lock of the upload of an artifact that no longer uploads.
+func (s *Service) forget(id string) {
+	s.mu.Lock()
+	delete(s.uploads, id)
+	s.mu.Unlock()
+}
+
+// Start starts the upload of an artifact and sets its ID, state and creation time.
+func (s *Service) Start(artifact *model.Artifact) error {
+	artifact.ID = uuid.New().String()
+	artifact.State = model.ArtifactStateUploading
+	return s.db.AddArtifact(artifact)
+}
+
+// Append stores the next chunk of an artifact that is uploading. A chunk whose upload failed can
+// be uploaded again with the same index.
+func (s *Service) Append(
+	ctx context.Context, id string, index int, data []byte,
+) (*model.Artifact, error) {
+	if len(data) == 0 || len(data) > ChunkSize {
+		return nil, ErrChunkSize
+	}
+	l := s.lock(id)
+	defer l.Unlock()
+
+	artifact, err := s.db.ArtifactByID(id)
+	switch {
+	case err != nil:
+		return nil, err
+	case artifact.State != model.ArtifactStateUploading:
+		return nil, ErrNotUploading
+	case artifact.Deduplicated:
+		return nil, ErrUploadMode
+	case index != artifact.NumChunks:
+		return nil, errors.Wrapf(ErrChunkOrder, "expected chunk %d", artifact.NumChunks)
+	case s.maxSize > 0 && artifact.SizeBytes+int64(len(data)) > s.maxSize:
+		return nil, errors.Wrapf(ErrTooLarge, "%d bytes", s.maxSize)
+	}
+	if err = s.storage.put(ctx, chunkKey(id, index), data); err != nil {
+		return nil, errors.Wrapf(err, "error storing chunk %d of artifact %s", index, id)
+	}
+	artifact.NumChunks++
+	artifact.SizeBytes += int64(len(data))
+	if err = s.db.UpdateArtifact(artifact); err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// PutManifest sets the chunks of an artifact that is uploading to the blobs with the given hashes,
+// in order, and returns the hashes of those that must still be uploaded with AppendBlob. Blobs that
+// were stored before, for this or any other artifact, are not uploaded again. The manifest can be
+// put again, e.g. to resume an upload that was interrupted.
+func (s *Service) PutManifest(id string, hashes []string) (*model.Artifact, []string, error) {
+	for _, hash := range hashes {
+		if !hashPattern.MatchString(hash) {
+			return nil, nil, errors.Wrapf(ErrInvalidHash, "invalid hash %q", hash)
+		}
+	}
+	l := s.lock(id)
+	defer l.Unlock()
+
+	artifact, err := s.db.ArtifactByID(id)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case artifact.State != model.ArtifactStateUploading:
+		return nil, nil, ErrNotUploading
+	case !artifact.Deduplicated && artifact.NumChunks > 0:
+		return nil, nil, ErrUploadMode
+	}
+	if err = s.db.PutArtifactManifest(id, hashes); err != nil {
+		return nil, nil, err
+	}
+	artifact.Deduplicated = true
+	artifact.NumChunks = len(hashes)
+	if err = s.db.UpdateArtifact(artifact); err != nil {
+		return nil, nil, err
+	}
+	missing, err := s.db.MissingArtifactBlobs(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return artifact, missing, nil
+}
+
+// AppendBlob stores a blob in the manifest of an artifact that is uploading and returns its hash.
+func (s *Service) AppendBlob(ctx context.Context, id string, data []byte) (string, error) {
+	if len(data) == 0 || len(data) > ChunkSize {
+		return "", ErrChunkSize
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	artifact, err := s.db.ArtifactByID(id)
+	switch {
+	case err != nil:
+		return "", err
+	case artifact.State != model.ArtifactStateUploading:
+		return "", ErrNotUploading
+	case !artifact.Deduplicated:
+		return "", ErrUploadMode
+	}
+	manifest, err := s.db.ArtifactManifest(id)
+	if err != nil {
+		return "", err
+	}
+	if !contains(manifest, hash) {
+		return "", errors.Wrapf(ErrNotInManifest, "chunk %s", hash)
+	}
+
+	// Hold the lock of the blob so that it is not evicted while it is stored again.
+	key := blobKey(hash)
+	l := s.lock(key)
+	defer l.Unlock()
+	defer s.forget(key)
+	if err = s.storage.put(ctx, key, data); err != nil {
+		return "", errors.Wrapf(err, "error storing blob %s", hash)
+	}
+	if err = s.db.AddArtifactBlob(hash, int64(len(data))); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func contains(hashes []string, hash string) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Store stores the contents of an artifact that the master has in full, in chunks, and sets the
+// fields of the completed artifact.
+func (s *Service) Store(ctx context.Context, artifact *model.Artifact, data []byte) (err error) {
+	if err = s.Start(artifact); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = s.Delete(ctx, artifact.ID)
+		}
+	}()
+	for index := 0; len(data) > 0; index++ {
+		n := ChunkSize
+		if len(data) < n {
+			n = len(data)
+		}
+		if _, err = s.Append(ctx, artifact.ID, index, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	completed, err := s.Complete(artifact.ID)
+	if err != nil {
+		return err
+	}
+	*artifact = *completed
+	return nil
+}
+
+// Complete marks that all chunks of an artifact were uploaded, after which it can be read.
+func (s *Service) Complete(id string) (*model.Artifact, error) {
+	l := s.lock(id)
+	defer l.Unlock()
+
+	artifact, err := s.db.ArtifactByID(id)
+	switch {
+	case err != nil:
+		return nil, err
+	case artifact.State != model.ArtifactStateUploading:
+		return nil, ErrNotUploading
+	}
+	if artifact.Deduplicated {
+		missing, mErr := s.db.MissingArtifactBlobs(id)
+		if mErr != nil {
+			return nil, mErr
+		}
+		if len(missing) > 0 {
+			return nil, errors.Wrapf(ErrMissingChunks, "%d chunks are missing", len(missing))
+		}
+		if artifact.SizeBytes, err = s.db.ArtifactManifestSize(id); err != nil {
+			return nil, err
+		}
+		if s.maxSize > 0 && artifact.SizeBytes > s.maxSize {
+			return nil, errors.Wrapf(ErrTooLarge, "%d bytes", s.maxSize)
+		}
+	}
+	now := time.Now().UTC()
+	artifact.State = model.ArtifactStateCompleted
+	artifact.CompletionTime = &now
+	if err = s.db.UpdateArtifact(artifact); err != nil {
+		return nil, err
+	}
+	s.forget(id)
+	return artifact, nil
+}
+
+// Open returns a reader of the contents of a completed artifact, which reads its chunks from the
+// storage one at a time.
+func (s *Service) Open(ctx context.Context, artifact model.Artifact) (io.ReadCloser, error) {
+	if artifact.State != model.ArtifactStateCompleted {
+		return nil, ErrNotCompleted
+	}
+	var keys []string
+	if artifact.Deduplicated {
+		manifest, err := s.db.ArtifactManifest(artifact.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range manifest {
+			keys = append(keys, blobKey(hash))
+		}
+	} else {
+		for i := 0; i < artifact.NumChunks; i++ {
+			keys = append(keys, chunkKey(artifact.ID, i))
+		}
+	}
+	return &chunkReader{ctx: ctx, storage: s.storage, keys: keys}, nil
+}
+
+// ReadAll returns the contents of a completed artifact.
+func (s *Service) ReadAll(ctx context.Context, id string) ([]byte, error) {
+	artifact, err := s.db.ArtifactByID(id)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.Open(ctx, *artifact)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// Delete deletes an artifact and its chunks. The blobs of a deduplicated artifact are kept in the
+// cache of blobs until they are evicted.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	l := s.lock(id)
+	defer l.Unlock()
+
+	artifact, err := s.db.ArtifactByID(id)
+	if err != nil {
+		return err
+	}
+	if artifact.Deduplicated {
+		// Start the time that the blobs stay cached for now.
+		if err = s.db.UseArtifactBlobs(id); err != nil {
+			return err
+		}
+	} else {
+		// A chunk past the last one may have been stored by an upload whose progress was not saved.
+		for i := 0; i <= artifact.NumChunks; i++ {
+			if err = s.storage.delete(ctx, chunkKey(id, i)); err != nil {
+				return errors.Wrapf(err, "error deleting chunk %d of artifact %s", i, id)
+			}
+		}
+	}
+	if err = s.db.DeleteArtifact(id); err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+// EvictBlobs deletes the blobs that no artifact uses and that are past the limits of the cache of
+// blobs, and returns how many it deleted.
+func (s *Service) EvictBlobs(ctx context.Context, config ChunkCacheConfig) (int, error) {
+	before := time.Now().Add(-time.Duration(config.MaxAgeHours) * time.Hour)
+	hashes, err := s.db.EvictableArtifactBlobs(before, config.MaxSizeBytes)
+	if err != nil {
+		return 0, err
+	}
+	evicted := 0
+	for _, hash := range hashes {
+		deleted, err := s.evictBlob(ctx, hash)
+		if err != nil {
+			return evicted, err
+		}
+		if deleted {
+			evicted++
+		}
+	}
+	return evicted, nil
+}
+
+// evictBlob deletes a blob unless an artifact uses it again.
+func (s *Service) evictBlob(ctx context.Context, hash string) (bool, error) {
+	key := blobKey(hash)
+	l := s.lock(key)
+	defer l.Unlock()
+	defer s.forget(key)
+
+	deleted, err := s.db.DeleteUnusedArtifactBlob(hash)
+	if err != nil || !deleted {
+		return false, err
+	}
+	if err = s.storage.delete(ctx, key); err != nil {
+		return false, errors.Wrapf(err, "error deleting blob %s", hash)
+	}
+	return true, nil
+}
+
+// chunkReader reads the chunks with the given keys in order.
+type chunkReader struct {
+	ctx     context.Context
+	storage storage
+	keys    []string
+	next    int
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= len(r.keys) {
+				return 0, io.EOF
+			}
+			chunk, err := r.storage.get(r.ctx, r.keys[r.next])
+			if err != nil {
+				return 0, errors.Wrapf(err, "error reading artifact chunk %s", r.keys[r.next])
+			}
+			r.current = chunk
+			r.next++
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			if cErr := r.current.Close(); cErr != nil {
+				return n, cErr
+			}
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
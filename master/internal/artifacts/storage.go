@@ -0,0 +1,159 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// storage stores the chunks of artifacts by key.
+type storage interface {
+	// put stores a chunk, replacing any chunk with the same key.
+	put(ctx context.Context, key string, data []byte) error
+	// get opens a chunk for reading.
+	get(ctx context.Context, key string) (io.ReadCloser, error)
+	// delete deletes a chunk. It does not fail if the chunk does not exist.
+	delete(ctx context.Context, key string) error
+}
+
+func newStorage(pgDB *db.PgDB, config Config) (storage, error) {
+	switch config.Type {
+	case DBType:
+		return dbStorage{db: pgDB}, nil
+	case SharedFSType:
+		if err := os.MkdirAll(config.StoragePath, 0o700); err != nil {
+			return nil, errors.Wrap(err, "error creating artifacts.storage_path")
+		}
+		return sharedFSStorage{root: config.StoragePath}, nil
+	case S3Type:
+		awsConfig := &aws.Config{
+			Region:           config.Region,
+			Endpoint:         config.EndpointURL,
+			S3ForcePathStyle: aws.Bool(config.EndpointURL != nil),
+		}
+		if config.AccessKey != nil && config.SecretKey != nil {
+			awsConfig.Credentials = credentials.NewStaticCredentials(
+				*config.AccessKey, *config.SecretKey, "")
+		}
+		sess, err := session.NewSession(awsConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the S3 session of artifacts")
+		}
+		return s3Storage{client: s3.New(sess), bucket: config.Bucket, prefix: config.Prefix}, nil
+	default:
+		return nil, errors.Errorf("unsupported artifact storage: %s", config.Type)
+	}
+}
+
+// dbStorage stores chunks as rows of the database.
+type dbStorage struct {
+	db *db.PgDB
+}
+
+func (s dbStorage) put(_ context.Context, key string, data []byte) error {
+	return s.db.PutArtifactChunk(key, data)
+}
+
+func (s dbStorage) get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, err := s.db.ArtifactChunk(key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s dbStorage) delete(_ context.Context, key string) error {
+	return s.db.DeleteArtifactChunk(key)
+}
+
+// sharedFSStorage stores chunks as files in a directory.
+type sharedFSStorage struct {
+	root string
+}
+
+func (s sharedFSStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s sharedFSStorage) put(_ context.Context, key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never see a partial chunk.
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s sharedFSStorage) get(_ context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s sharedFSStorage) delete(_ context.Context, key string) error {
+	p := s.path(key)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Remove the directory of the artifact once its last chunk is gone, which fails while other
+	// chunks remain.
+	_ = os.Remove(filepath.Dir(p))
+	return nil
+}
+
+// s3Storage stores chunks as objects in an S3 bucket.
+type s3Storage struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+func (s s3Storage) key(key string) *string {
+	return aws.String(path.Join(s.prefix, key))
+}
+
+func (s s3Storage) put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s s3Storage) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s s3Storage) delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package artifacts
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSharedFSStorage(t *testing.T) {
+	ctx := context.Background()
+	s := sharedFSStorage{root: t.TempDir()}
+
+	assert.NilError(t, s.put(ctx, chunkKey("a", 0), []byte("first")))
+	assert.NilError(t, s.put(ctx, chunkKey("a", 0), []byte("second")))
+	r, err := s.get(ctx, chunkKey("a", 0))
+	assert.NilError(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.NilError(t, err)
+	assert.NilError(t, r.Close())
+	assert.Equal(t, string(data), "second")
+
+	assert.NilError(t, s.delete(ctx, chunkKey("a", 0)))
+	assert.NilError(t, s.delete(ctx, chunkKey("a", 1)))
+	_, err = os.Stat(filepath.Join(s.root, "a"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestChunkReader(t *testing.T) {
+	ctx := context.Background()
+	s := sharedFSStorage{root: t.TempDir()}
+	chunks := []string{"abc", "d", "efgh"}
+	for i, chunk := range chunks {
+		assert.NilError(t, s.put(ctx, chunkKey("a", i), []byte(chunk)))
+	}
+
+	r := &chunkReader{
+		ctx:     ctx,
+		storage: s,
+		keys:    []string{chunkKey("a", 0), chunkKey("a", 1), chunkKey("a", 2)},
+	}
+	data, err := ioutil.ReadAll(r)
+	assert.NilError(t, err)
+	assert.NilError(t, r.Close())
+	assert.Equal(t, string(data), "abcdefgh")
+}
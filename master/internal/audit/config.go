@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config is the audit log configuration of the master. The audit log is always recorded in the
+// database; it can additionally be sent to syslog.
+type Config struct {
+	Syslog SyslogConfig `json:"syslog"`
+}
+
+// SyslogConfig configures sending audit log entries to a syslog server.
+type SyslogConfig struct {
+	Enabled bool `json:"enabled"`
+	// Network and Address locate the syslog server, e.g. "udp" and "logs.example.com:514". The
+	// local syslog daemon is used if both are empty.
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// Tag is the program name that entries are sent with.
+	Tag string `json:"tag"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c SyslogConfig) Validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	return []error{
+		check.True(c.Network == "" || c.Network == "tcp" || c.Network == "udp" ||
+			c.Network == "unix" || c.Network == "unixgram",
+			"audit_log.syslog.network must be one of tcp, udp, unix or unixgram"),
+		check.True((c.Network == "") == (c.Address == ""),
+			"audit_log.syslog.network and audit_log.syslog.address must be set together"),
+	}
+}
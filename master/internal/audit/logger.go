@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const defaultSyslogTag = "determined-audit"
+
+// unauditedPaths are the paths outside of the gRPC API that change state too often, or on
+// behalf of tasks rather than people, to be worth recording.
+var unauditedPaths = []string{
+	"/trial_logs",
+	"/searcher/preview",
+	"/proxy/",
+}
+
+// Logger records audit log entries in the database and optionally sends them to syslog.
+type Logger struct {
+	db     *db.PgDB
+	syslog *syslog.Writer
+}
+
+// New creates a Logger.
+func New(db *db.PgDB, config Config) (*Logger, error) {
+	l := &Logger{db: db}
+	if config.Syslog.Enabled {
+		tag := config.Syslog.Tag
+		if tag == "" {
+			tag = defaultSyslogTag
+		}
+		writer, err := syslog.Dial(config.Syslog.Network, config.Syslog.Address,
+			syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+		if err != nil {
+			return nil, errors.Wrap(err, "error connecting to syslog for the audit log")
+		}
+		l.syslog = writer
+	}
+	return l, nil
+}
+
+// Record adds an entry to the audit log. Failures are logged rather than returned, since the
+// call that the entry describes has already happened.
+func (l *Logger) Record(entry model.AuditEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if err := l.db.AddAuditEntry(&entry); err != nil {
+		log.WithError(err).Errorf("failed to record %s in the audit log", entry.Method)
+	}
+	if l.syslog == nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err == nil {
+		err = l.syslog.Info(string(line))
+	}
+	if err != nil {
+		log.WithError(err).Errorf("failed to send the audit log entry for %s to syslog", entry.Method)
+	}
+}
+
+// Middleware records the HTTP requests that may change state. Requests under /api/v1 are left to
+// the gRPC server, which the gateway passes them to.
+func (l *Logger) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request := c.Request()
+		if !audited(request.Method, request.URL.Path) {
+			return next(c)
+		}
+
+		err := next(c)
+		code := c.Response().Status
+		var httpErr *echo.HTTPError
+		switch {
+		case errors.As(err, &httpErr):
+			code = httpErr.Code
+		case err != nil:
+			code = http.StatusInternalServerError
+		}
+
+		entry := model.AuditEntry{
+			Address: remoteIP(request),
+			Method:  request.Method + " " + request.URL.Path,
+			Outcome: strconv.Itoa(code),
+		}
+		if dc, ok := c.(*context.DetContext); ok {
+			if user, ok := dc.User(); ok {
+				entry.UserID = &user.ID
+				entry.Username = user.Username
+			}
+		}
+		l.Record(entry)
+		return err
+	}
+}
+
+func audited(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if strings.HasPrefix(path, "/api/v1/") {
+		return false
+	}
+	for _, p := range unauditedPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return false
+		}
+	}
+	return true
+}
+
+// remoteIP returns the IP address that an HTTP request comes from, without the port.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,17 @@
+package audit
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestAudited(t *testing.T) {
+	assert.Assert(t, audited("POST", "/experiments"))
+	assert.Assert(t, audited("DELETE", "/templates/a"))
+	assert.Assert(t, audited("PATCH", "/scim/v2/Users/1"))
+	assert.Assert(t, !audited("GET", "/experiments"))
+	assert.Assert(t, !audited("POST", "/api/v1/experiments"))
+	assert.Assert(t, !audited("POST", "/trial_logs"))
+	assert.Assert(t, !audited("PUT", "/proxy/abc/api/contents/a.ipynb"))
+}
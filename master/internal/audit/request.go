@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const redacted = "********"
+
+// unrecordedRequests are the API methods whose requests are left out of the audit log entirely,
+// since they consist of secret values.
+var unrecordedRequests = map[string]bool{
+	"PutSecret": true,
+}
+
+// storageCredentialFields are the names of the fields of storage configs that hold credentials.
+var storageCredentialFields = map[string]bool{
+	"access_key": true,
+	"secret_key": true,
+}
+
+// RequestJSON returns the request of an API call as JSON for the audit log. Fields whose names
+// mention passwords and storage credentials are redacted, including those in the experiment
+// configs that requests carry as YAML.
+func RequestJSON(method string, request interface{}) string {
+	message, ok := request.(proto.Message)
+	if !ok || unrecordedRequests[method] {
+		return ""
+	}
+	raw, err := protojson.Marshal(message)
+	if err != nil {
+		return ""
+	}
+	var fields interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return ""
+	}
+	out, err := json.Marshal(redact(fields))
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		var err error
+		for key, field := range v {
+			config, isString := field.(string)
+			switch {
+			case strings.Contains(strings.ToLower(key), "password"), storageCredentialFields[key]:
+				v[key] = redacted
+			case key == "config" && isString:
+				if v[key], err = model.RedactStorageCredentialsYAML(config); err != nil {
+					v[key] = redacted
+				}
+			default:
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// Username returns the username in a request as JSON, which identifies the user of calls that
+// are not authenticated, such as logins.
+func Username(requestJSON string) string {
+	var fields struct {
+		Username string `json:"username"`
+	}
+	_ = json.Unmarshal([]byte(requestJSON), &fields)
+	return fields.Username
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRedact(t *testing.T) {
+	var fields interface{}
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"username": "alice",
+		"password": "hunter2",
+		"user": {"username": "bob", "newPassword": "hunter3"},
+		"items": [{"Password": "hunter4", "name": "x"}]
+	}`), &fields))
+	out, err := json.Marshal(redact(fields))
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `{"items":[{"Password":"********","name":"x"}],`+
+		`"password":"********","user":{"newPassword":"********","username":"bob"},`+
+		`"username":"alice"}`)
+
+	var request interface{}
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"config": "checkpoint_storage:\n  type: s3\n  secret_key: SK\n",
+		"template": {"config": {"checkpoint_storage": {"access_key": "AK"}}}
+	}`), &request))
+	out, err = json.Marshal(redact(request))
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), `{"config":"checkpoint_storage:\n  secret_key: '********'\n`+
+		`  type: s3\n","template":{"config":{"checkpoint_storage":{"access_key":"********"}}}}`)
+}
+
+func TestUsername(t *testing.T) {
+	assert.Equal(t, Username(`{"username": "alice", "password": "********"}`), "alice")
+	assert.Equal(t, Username(`{"experimentId": 1}`), "")
+	assert.Equal(t, Username(""), "")
+}
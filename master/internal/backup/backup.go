@@ -0,0 +1,265 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+const (
+	// formatVersion is the version of the format of backups, which changes when backups are written
+	// in ways that older masters cannot restore.
+	formatVersion = 1
+	// restoreBatchSize is the number of rows of a table that are inserted at once.
+	restoreBatchSize = 500
+
+	dataSuffix     = ".jsonl.gz"
+	manifestSuffix = ".manifest.json"
+)
+
+var (
+	// ErrNotFound is returned when restoring a backup that does not exist.
+	ErrNotFound = errors.New("backup not found")
+	// ErrSchemaVersion is returned when restoring a backup that was taken at a schema version
+	// other than the one of the database.
+	ErrSchemaVersion = errors.New("the backup was taken at another schema version")
+)
+
+// Manifest describes a backup.
+type Manifest struct {
+	Name          string    `json:"name"`
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion int64     `json:"schema_version"`
+	MasterVersion string    `json:"master_version"`
+	ClusterID     string    `json:"cluster_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Rows are the numbers of rows of each table in the backup. The first line of a backup holds
+	// its manifest without them.
+	Rows map[string]int `json:"rows,omitempty"`
+}
+
+// line is a line of a backup after its manifest: a row of a table.
+type line struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Service takes and restores backups of the metadata of the cluster, which are gzipped files of
+// JSON lines. A nil service means that backups are disabled.
+type Service struct {
+	db            *db.PgDB
+	storage       storage
+	masterVersion string
+	clusterID     string
+}
+
+// New returns a service that stores backups as configured, or nil if backups are disabled.
+func New(pgDB *db.PgDB, config Config, masterVersion, clusterID string) (*Service, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	s, err := newStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{db: pgDB, storage: s, masterVersion: masterVersion, clusterID: clusterID}, nil
+}
+
+// Create takes a backup from one consistent snapshot of the database and returns its manifest.
+func (s *Service) Create(ctx context.Context) (*Manifest, error) {
+	m := &Manifest{
+		FormatVersion: formatVersion,
+		MasterVersion: s.masterVersion,
+		ClusterID:     s.clusterID,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		Rows:          make(map[string]int),
+	}
+	m.Name = "backup-" + m.CreatedAt.Format("20060102T150405Z")
+
+	pr, pw := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		err := s.write(ctx, pw, m)
+		_ = pw.CloseWithError(err)
+		written <- err
+	}()
+	err := s.storage.put(ctx, m.Name+dataSuffix, pr)
+	_ = pr.CloseWithError(errors.New("the backup stopped uploading"))
+	werr := <-written
+	if err != nil {
+		return nil, errors.Wrapf(err, "error uploading backup %s", m.Name)
+	}
+	if werr != nil {
+		return nil, errors.Wrapf(werr, "error writing backup %s", m.Name)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.storage.put(ctx, m.Name+manifestSuffix, bytes.NewReader(b)); err != nil {
+		return nil, errors.Wrapf(err, "error uploading the manifest of backup %s", m.Name)
+	}
+	return m, nil
+}
+
+// write writes the manifest of a backup and then the rows of the tables to w.
+func (s *Service) write(ctx context.Context, w io.Writer, m *Manifest) error {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	start := func(schemaVersion int64) error {
+		m.SchemaVersion = schemaVersion
+		header := *m
+		header.Rows = nil
+		return enc.Encode(header)
+	}
+	emit := func(table string, row json.RawMessage) error {
+		m.Rows[table]++
+		return enc.Encode(line{Table: table, Row: row})
+	}
+	if err := s.db.ExportTables(ctx, db.BackupTables, start, emit); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// List returns the manifests of the backups, from the newest.
+func (s *Service) List(ctx context.Context) ([]Manifest, error) {
+	keys, err := s.storage.list(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing backups")
+	}
+	var manifests []Manifest
+	for _, key := range keys {
+		if !strings.HasSuffix(key, manifestSuffix) {
+			continue
+		}
+		r, err := s.storage.get(ctx, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading backup manifest %s", key)
+		}
+		var m Manifest
+		err = json.NewDecoder(r).Decode(&m)
+		_ = r.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing backup manifest %s", key)
+		}
+		manifests = append(manifests, m)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+	return manifests, nil
+}
+
+// Restore replaces the metadata of the cluster with a backup, after checking that the backup was
+// taken at the schema version of the database. If dryRun is set, it only checks the backup. It
+// returns the manifest of the backup with the rows that it holds.
+func (s *Service) Restore(ctx context.Context, name string, dryRun bool) (*Manifest, error) {
+	if strings.ContainsAny(name, "/\\") {
+		return nil, ErrNotFound
+	}
+	f, err := s.storage.get(ctx, name+dataSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading backup %s", name)
+	}
+	r := bufio.NewReader(gz)
+
+	var m Manifest
+	if err = readLine(r, &m); err != nil {
+		return nil, errors.Wrapf(err, "error reading the manifest of backup %s", name)
+	}
+	if m.FormatVersion != formatVersion {
+		return nil, errors.Errorf(
+			"backup %s has format version %d, which this master cannot restore",
+			name, m.FormatVersion)
+	}
+	current, err := s.db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if m.SchemaVersion != current {
+		return nil, errors.Wrapf(ErrSchemaVersion,
+			"backup %s has schema version %d but the database has %d; restore it with the master "+
+				"version %s that took it", name, m.SchemaVersion, current, m.MasterVersion)
+	}
+	m.Rows = make(map[string]int)
+
+	read := func(insert func(table string, rows []json.RawMessage) error) error {
+		var table string
+		var batch []json.RawMessage
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			err := insert(table, batch)
+			batch = nil
+			return err
+		}
+		for {
+			var l line
+			switch err := readLine(r, &l); {
+			case err == io.EOF:
+				return flush()
+			case err != nil:
+				return errors.Wrapf(err, "error reading backup %s", name)
+			}
+			if l.Table != table || len(batch) >= restoreBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+				table = l.Table
+			}
+			batch = append(batch, l.Row)
+			m.Rows[l.Table]++
+		}
+	}
+
+	if dryRun {
+		known := make(map[string]bool)
+		for _, table := range db.BackupTables {
+			known[table] = true
+		}
+		err = read(func(table string, _ []json.RawMessage) error {
+			if !known[table] {
+				return errors.Errorf("backup %s holds unknown table %s", name, table)
+			}
+			return nil
+		})
+	} else {
+		err = s.db.RestoreTables(ctx, db.BackupTables, read)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// readLine decodes the next line of a backup.
+func readLine(r *bufio.Reader, v interface{}) error {
+	b, err := r.ReadBytes('\n')
+	if err == io.EOF && len(b) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,62 @@
+package backup
+
+import (
+	"path/filepath"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+const (
+	// SharedFSType stores backups in a directory on the master's host.
+	SharedFSType = "shared_fs"
+	// S3Type stores backups in an S3 bucket.
+	S3Type = "s3"
+)
+
+// Config is the configuration of where the master stores backups of the metadata of the cluster.
+type Config struct {
+	// Type is the kind of storage: "shared_fs" or "s3". Backups are disabled if it is empty.
+	Type string `json:"type"`
+	// StoragePath is the directory that shared_fs backups are stored in.
+	StoragePath string `json:"storage_path"`
+	// Bucket is the S3 bucket that s3 backups are stored in, under Prefix.
+	Bucket      string  `json:"bucket"`
+	Prefix      string  `json:"prefix"`
+	Region      *string `json:"region"`
+	AccessKey   *string `json:"access_key"`
+	SecretKey   *string `json:"secret_key"`
+	EndpointURL *string `json:"endpoint_url"`
+}
+
+// Enabled returns true if the master stores backups.
+func (c Config) Enabled() bool {
+	return c.Type != ""
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	hiddenValue := "********"
+	if c.AccessKey != nil {
+		c.AccessKey = &hiddenValue
+	}
+	if c.SecretKey != nil {
+		c.SecretKey = &hiddenValue
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	switch c.Type {
+	case "":
+		return nil
+	case SharedFSType:
+		return []error{check.True(filepath.IsAbs(c.StoragePath),
+			"backup.storage_path must be an absolute path for shared_fs backups")}
+	case S3Type:
+		return []error{check.NotEmpty(c.Bucket, "backup.bucket must be set for s3 backups")}
+	default:
+		return []error{check.In(c.Type, []string{SharedFSType, S3Type},
+			"backup.type must be shared_fs or s3")}
+	}
+}
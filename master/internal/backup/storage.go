@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+)
+
+// storage stores the files of backups by key.
+type storage interface {
+	// put stores a file from a reader, which it reads to the end.
+	put(ctx context.Context, key string, r io.Reader) error
+	// get opens a file for reading. It returns ErrNotFound if the file does not exist.
+	get(ctx context.Context, key string) (io.ReadCloser, error)
+	// list returns the keys of the files.
+	list(ctx context.Context) ([]string, error)
+}
+
+func newStorage(config Config) (storage, error) {
+	switch config.Type {
+	case SharedFSType:
+		if err := os.MkdirAll(config.StoragePath, 0o700); err != nil {
+			return nil, errors.Wrap(err, "error creating backup.storage_path")
+		}
+		return sharedFSStorage{root: config.StoragePath}, nil
+	case S3Type:
+		awsConfig := &aws.Config{
+			Region:           config.Region,
+			Endpoint:         config.EndpointURL,
+			S3ForcePathStyle: aws.Bool(config.EndpointURL != nil),
+		}
+		if config.AccessKey != nil && config.SecretKey != nil {
+			awsConfig.Credentials = credentials.NewStaticCredentials(
+				*config.AccessKey, *config.SecretKey, "")
+		}
+		sess, err := session.NewSession(awsConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the S3 session of backups")
+		}
+		return s3Storage{
+			client:   s3.New(sess),
+			uploader: s3manager.NewUploader(sess),
+			bucket:   config.Bucket,
+			prefix:   config.Prefix,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported backup storage: %s", config.Type)
+	}
+}
+
+// sharedFSStorage stores files in a directory.
+type sharedFSStorage struct {
+	root string
+}
+
+func (s sharedFSStorage) put(_ context.Context, key string, r io.Reader) error {
+	p := filepath.Join(s.root, key)
+	// Write to a temporary file first so that incomplete backups are never listed.
+	f, err := ioutil.TempFile(s.root, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (s sharedFSStorage) get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(s.root, key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s sharedFSStorage) list(context.Context) ([]string, error) {
+	files, err := ioutil.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, f := range files {
+		if !f.IsDir() && !strings.HasPrefix(f.Name(), ".tmp-") {
+			keys = append(keys, f.Name())
+		}
+	}
+	return keys, nil
+}
+
+// s3Storage stores files as objects in an S3 bucket.
+type s3Storage struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+func (s s3Storage) key(key string) *string {
+	return aws.String(path.Join(s.prefix, key))
+}
+
+func (s s3Storage) put(ctx context.Context, key string, r io.Reader) error {
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+		Body:   r,
+	})
+	return err
+}
+
+func (s s3Storage) get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s s3Storage) list(ctx context.Context) ([]string, error) {
+	prefix := s.prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var keys []string
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(aws.StringValue(obj.Key), prefix))
+		}
+		return true
+	})
+	return keys, err
+}
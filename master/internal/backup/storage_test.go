@@ -0,0 +1,32 @@
+package backup
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSharedFSStorage(t *testing.T) {
+	ctx := context.Background()
+	s := sharedFSStorage{root: t.TempDir()}
+
+	assert.NilError(t, s.put(ctx, "a"+manifestSuffix, strings.NewReader("first")))
+	assert.NilError(t, s.put(ctx, "a"+manifestSuffix, strings.NewReader("second")))
+	assert.NilError(t, s.put(ctx, "a"+dataSuffix, strings.NewReader("data")))
+	keys, err := s.list(ctx)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, keys, []string{"a" + dataSuffix, "a" + manifestSuffix})
+
+	r, err := s.get(ctx, "a"+manifestSuffix)
+	assert.NilError(t, err)
+	data, err := ioutil.ReadAll(r)
+	assert.NilError(t, err)
+	assert.NilError(t, r.Close())
+	assert.Equal(t, string(data), "second")
+
+	_, err = s.get(ctx, "b"+manifestSuffix)
+	assert.Equal(t, err, ErrNotFound)
+}
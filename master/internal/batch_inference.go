@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// maxBatchInferenceLogs is how many of the last log lines of a batch inference job are kept, which
+// are logged by the master if the job fails.
+const maxBatchInferenceLogs = 200
+
+// batchInferenceAddr returns the address of the batch inference job with the task ID, to which its
+// container reports its progress.
+func batchInferenceAddr(taskID string) actor.Address {
+	return actor.Addr("batch-inference-" + taskID)
+}
+
+// batchInferenceLineage is what a batch inference job writes along with its predictions, which ties
+// them back to the checkpoint and the model version that made them.
+type batchInferenceLineage struct {
+	TaskID         string `json:"task_id"`
+	ModelName      string `json:"model_name,omitempty"`
+	ModelVersion   int    `json:"model_version,omitempty"`
+	CheckpointUUID string `json:"checkpoint_uuid"`
+	ExperimentID   int    `json:"experiment_id"`
+	TrialID        int    `json:"trial_id"`
+	Predictor      string `json:"predictor"`
+	InputPath      string `json:"input_path"`
+	Username       string `json:"username"`
+}
+
+// batchInferenceSpec is what the container of a batch inference job reads to make its predictions.
+type batchInferenceSpec struct {
+	Checkpoint *model.Checkpoint     `json:"checkpoint"`
+	Predictor  string                `json:"predictor"`
+	InputPath  string                `json:"input_path"`
+	OutputPath string                `json:"output_path"`
+	Lineage    batchInferenceLineage `json:"lineage"`
+}
+
+// batchInferenceTask launches a container that makes the predictions of a checkpoint on the files
+// under an input path in the environment of the experiment of the checkpoint. The container reports
+// its progress with its task token, and the task records it along with how the container exited.
+type batchInferenceTask struct {
+	rm         *actor.Ref
+	db         *db.PgDB
+	experiment *model.Experiment
+	// record and job are the rows of the job in the tasks and batch_inference_jobs tables, which
+	// are added by whoever launches the job so that it can be listed right away.
+	record          *model.Task
+	job             *model.BatchInferenceJob
+	spec            json.RawMessage
+	modelDefinition archive.Archive
+
+	agentUserGroup *model.AgentUserGroup
+	taskSpec       *tasks.TaskSpec
+	vault          *vault.Client
+	ca             *ca.Authority
+
+	task        *sproto.AllocateRequest
+	allocations []sproto.Allocation
+	vaultGrant  *vault.Grant
+	killed      bool
+	logs        []sproto.ContainerLog
+}
+
+func (t *batchInferenceTask) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		t.task = &sproto.AllocateRequest{
+			ID:           sproto.TaskID(t.record.TaskID),
+			Name:         t.record.Description,
+			SlotsNeeded:  t.record.Slots,
+			ResourcePool: t.record.ResourcePool,
+			// The container uses the experiment's image, so it must run on the same platform.
+			Platform: t.experiment.Config.Resources().Platform(),
+			GPUType:  t.experiment.Config.Resources().GPUType(),
+			FittingRequirements: sproto.FittingRequirements{
+				SingleAgent: true,
+			},
+			TaskActor:      ctx.Self(),
+			NonPreemptible: true,
+		}
+		if t.record.OwnerID != nil {
+			t.task.OwnerID = *t.record.OwnerID
+		}
+		if t.record.ProjectID != nil {
+			t.task.ProjectID = *t.record.ProjectID
+		}
+		ctx.Tell(t.rm, *t.task)
+
+	case sproto.ResourcesAllocated:
+		t.record.ResourcePool = msg.ResourcePool
+		if t.killed {
+			// The job was killed while the resources were being allocated.
+			ctx.Self().Stop()
+			return nil
+		}
+		taskToken, err := t.db.StartTaskSession(string(msg.ID))
+		if err != nil {
+			return errors.Wrap(err, "cannot start a new task session for a batch inference job")
+		}
+		taskCert, taskKey, err := t.ca.IssueTaskCert(string(msg.ID))
+		if err != nil {
+			return err
+		}
+
+		registryCredentials, err := ownerRegistryCredentials(t.db, t.record.OwnerID)
+		if err != nil {
+			return err
+		}
+		secrets, err := ownerSecrets(t.db, t.record.OwnerID)
+		if err != nil {
+			return err
+		}
+		t.vaultGrant, err = fetchExperimentVaultSecrets(t.db, t.vault, t.experiment)
+		if err != nil {
+			return err
+		}
+
+		ctx.Log().Infof("starting batch inference of checkpoint %s", t.job.CheckpointUUID)
+
+		t.allocations = msg.Allocations
+		for _, a := range msg.Allocations {
+			taskSpec := *t.taskSpec
+			taskSpec.AgentUserGroup = t.agentUserGroup
+			taskSpec.RegistryCredentials = registryCredentials
+			taskSpec.Secrets = secrets
+			taskSpec.VaultSecrets = t.vaultGrant.Values()
+			taskSpec.TaskToken = taskToken
+			taskSpec.TaskCert, taskSpec.TaskKey = taskCert, taskKey
+			taskSpec.SetInner(&tasks.BatchInference{
+				ExperimentConfig: t.experiment.Config,
+				Spec:             t.spec,
+				ModelDefinition:  t.modelDefinition,
+			})
+			a.Start(ctx, taskSpec)
+		}
+
+	case *apiv1.ReportBatchInferenceProgressRequest:
+		if err := checkBatchInferenceProgress(t.job, msg); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		t.job.Progress = msg.Progress
+		t.job.RecordsProcessed = msg.RecordsProcessed
+		if err := t.db.SetBatchInferenceProgress(t.job); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(&apiv1.ReportBatchInferenceProgressResponse{})
+
+	case *apiv1.KillBatchInferenceJobRequest:
+		t.killed = true
+		if len(t.allocations) == 0 {
+			// The job has not been allocated resources yet, so there is no container to kill.
+			ctx.Self().Stop()
+		}
+		for _, a := range t.allocations {
+			a.Kill(ctx, model.ExperimentStopPolicy(t.experiment.Config))
+		}
+		ctx.Respond(&apiv1.KillBatchInferenceJobResponse{})
+
+	case sproto.ReleaseResources:
+		// Ignore the release resource message and wait for the job to finish.
+
+	case sproto.TaskContainerStateChanged:
+		if msg.Container.State != container.Terminated {
+			t.transitionRecord(ctx, model.TaskStateOf(&msg.Container))
+		}
+		if msg.Container.State == container.Running {
+			if err := t.db.BindTaskSession(
+				t.record.TaskID, msg.ContainerStarted.SourceAddresses); err != nil {
+				ctx.Log().WithError(err).Error(
+					"cannot bind the task token to the batch inference container")
+			}
+		}
+		if msg.Container.State != container.Terminated {
+			return nil
+		}
+		status := msg.ContainerStopped
+		t.setExitStatus(ctx, status.Failure == nil && !t.killed, status.String())
+
+		switch {
+		case t.killed:
+			ctx.Log().Info("batch inference was killed")
+		case status.Failure != nil:
+			ctx.Log().Errorf("batch inference failed: %v", status)
+			for _, log := range t.logs {
+				ctx.Log().Error(log.String())
+			}
+		default:
+			ctx.Log().Infof("finished batch inference of %d records, written to %s",
+				t.job.RecordsProcessed, t.job.OutputPath)
+		}
+		ctx.Self().Stop()
+
+	case sproto.ContainerLog:
+		t.logs = append(t.logs, msg)
+		if len(t.logs) > maxBatchInferenceLogs {
+			t.logs = t.logs[len(t.logs)-maxBatchInferenceLogs:]
+		}
+
+	case sproto.TaskContainerProfile:
+
+	case actor.PostStop:
+		if t.job.ExitStatus == nil {
+			if t.killed {
+				t.setExitStatus(ctx, false, "killed before it was scheduled")
+			} else {
+				t.setExitStatus(ctx, false, "stopped before its container exited")
+			}
+		}
+		t.transitionRecord(ctx, model.TaskStateTerminated)
+		t.vaultGrant.Release()
+		if err := t.db.DeleteTaskSessionByTaskID(t.record.TaskID); err != nil {
+			ctx.Log().WithError(err).Error(
+				"cannot delete task session for a batch inference job")
+		}
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// checkBatchInferenceProgress returns an error if a report of the container of a batch inference
+// job is not about that job or does not hold a valid progress.
+func checkBatchInferenceProgress(
+	job *model.BatchInferenceJob, msg *apiv1.ReportBatchInferenceProgressRequest,
+) error {
+	switch {
+	case msg.TaskId != job.TaskID:
+		return api.AsErrBadRequest("task %s cannot report the progress of batch inference job %s",
+			job.TaskID, msg.TaskId)
+	case math.IsNaN(msg.Progress) || msg.Progress < 0 || msg.Progress > 100:
+		return api.AsErrBadRequest("progress must be between 0 and 100")
+	case msg.RecordsProcessed < 0:
+		return api.AsErrBadRequest("the number of records processed cannot be negative")
+	}
+	return nil
+}
+
+// setExitStatus records how the container of the job exited.
+func (t *batchInferenceTask) setExitStatus(ctx *actor.Context, succeeded bool, status string) {
+	t.job.Succeeded, t.job.ExitStatus = &succeeded, &status
+	if err := t.db.SetBatchInferenceExitStatus(t.job); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the exit status of the batch inference job")
+	}
+}
+
+// transitionRecord moves the row of the job in the tasks table to a new state.
+func (t *batchInferenceTask) transitionRecord(ctx *actor.Context, state model.TaskState) {
+	if err := t.db.TransitionTask(t.record, state); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the state of the batch inference job")
+	}
+}
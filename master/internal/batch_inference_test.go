@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestBatchInferenceProgressReports(t *testing.T) {
+	job := &model.BatchInferenceJob{TaskID: "job"}
+
+	assert.NilError(t, checkBatchInferenceProgress(job, &apiv1.ReportBatchInferenceProgressRequest{
+		TaskId: "job", Progress: 100, RecordsProcessed: 42,
+	}))
+
+	for _, req := range []*apiv1.ReportBatchInferenceProgressRequest{
+		// Jobs may only report their own progress.
+		{TaskId: "other", Progress: 50},
+		{TaskId: "job", Progress: -1},
+		{TaskId: "job", Progress: 100.5},
+		{TaskId: "job", Progress: math.NaN()},
+		{TaskId: "job", Progress: 50, RecordsProcessed: -1},
+	} {
+		err := checkBatchInferenceProgress(job, req)
+		assert.Assert(t, errors.Is(err, api.ErrBadRequest), "report %v", req)
+	}
+}
+
+func TestValidateBatchInferenceRequest(t *testing.T) {
+	valid := func() *apiv1.LaunchBatchInferenceJobRequest {
+		return &apiv1.LaunchBatchInferenceJobRequest{
+			ModelName:    "mnist",
+			ModelVersion: 2,
+			Predictor:    "predict:predict_file",
+			InputPath:    "/data/in",
+			OutputPath:   "/data/out",
+		}
+	}
+	assert.NilError(t, validateBatchInferenceRequest(valid()))
+
+	byCheckpoint := valid()
+	byCheckpoint.ModelName, byCheckpoint.ModelVersion = "", 0
+	byCheckpoint.CheckpointUuid = "a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d"
+	assert.NilError(t, validateBatchInferenceRequest(byCheckpoint))
+
+	for _, modify := range []func(*apiv1.LaunchBatchInferenceJobRequest){
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.ModelName = "" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.CheckpointUuid = byCheckpoint.CheckpointUuid },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.ModelVersion = 0 },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.Predictor = "predict" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.Predictor = "predict:" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.Predictor = "../predict:fn" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.InputPath = "data/in" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.OutputPath = "/data/in/" },
+		func(r *apiv1.LaunchBatchInferenceJobRequest) { r.Slots = -1 },
+	} {
+		req := valid()
+		modify(req)
+		assert.Equal(t, status.Code(validateBatchInferenceRequest(req)), codes.InvalidArgument,
+			"request %v", req)
+	}
+}
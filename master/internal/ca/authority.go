@@ -0,0 +1,209 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of clients that the authority issues certificates to. The kind of a client is the
+// organizational unit of the subject of its certificates and its ID is their common name.
+const (
+	AgentClient = "agent"
+	TaskClient  = "task"
+)
+
+const (
+	authorityLifetime = 10 * 365 * 24 * time.Hour
+	// certLifetime is how long issued certificates are valid. Task certificates are only accepted
+	// along with the token of their task, which stops working when the task ends.
+	certLifetime = 365 * 24 * time.Hour
+	// clockSkew backdates certificates so that clients with slow clocks accept them right away.
+	clockSkew = 5 * time.Minute
+)
+
+// Authority issues the client certificates that agents and tasks authenticate to the master with.
+type Authority struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+	pool    *x509.CertPool
+}
+
+// New loads the certificate authority, creating it if its files do not exist. It returns nil if
+// the authority is disabled.
+func New(config Config) (*Authority, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	certPEM, certErr := ioutil.ReadFile(config.CertFile)
+	keyPEM, keyErr := ioutil.ReadFile(config.KeyFile)
+	switch {
+	case os.IsNotExist(certErr) && os.IsNotExist(keyErr):
+		var err error
+		if certPEM, keyPEM, err = createAuthority(config); err != nil {
+			return nil, err
+		}
+	case certErr != nil:
+		return nil, errors.Wrap(certErr, "error reading the certificate of the authority")
+	case keyErr != nil:
+		return nil, errors.Wrap(keyErr, "error reading the private key of the authority")
+	}
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the certificate authority")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing the certificate of the authority")
+	}
+	if !cert.IsCA {
+		return nil, errors.Errorf("%s is not the certificate of an authority", config.CertFile)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("the private key of the authority cannot sign certificates")
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &Authority{cert: cert, certPEM: certPEM, key: key, pool: pool}, nil
+}
+
+// createAuthority generates a self-signed authority and writes it to the configured files.
+func createAuthority(config Config) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error generating the private key of the authority")
+	}
+	template, err := newTemplate(pkix.Name{CommonName: "Determined master CA"}, authorityLifetime)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error creating the certificate of the authority")
+	}
+	if certPEM, keyPEM, err = encode(der, key); err != nil {
+		return nil, nil, err
+	}
+
+	for _, file := range []struct {
+		path string
+		data []byte
+		mode os.FileMode
+	}{
+		{config.KeyFile, keyPEM, 0600},
+		{config.CertFile, certPEM, 0644},
+	} {
+		if err = os.MkdirAll(filepath.Dir(file.path), 0700); err != nil {
+			return nil, nil, errors.Wrapf(err, "error creating the directory of %s", file.path)
+		}
+		if err = ioutil.WriteFile(file.path, file.data, file.mode); err != nil {
+			return nil, nil, errors.Wrapf(err, "error writing %s", file.path)
+		}
+	}
+	return certPEM, keyPEM, nil
+}
+
+// Pool returns the pool of certificates that client certificates are verified against.
+func (a *Authority) Pool() *x509.CertPool {
+	return a.pool
+}
+
+// CertPEM returns the PEM-encoded certificate of the authority.
+func (a *Authority) CertPEM() []byte {
+	return a.certPEM
+}
+
+// IssueAgentCert issues a PEM-encoded client certificate and private key for an agent.
+func (a *Authority) IssueAgentCert(agentID string) (cert, key []byte, err error) {
+	return a.issue(AgentClient, agentID)
+}
+
+// IssueTaskCert issues a PEM-encoded client certificate and private key for a task. It returns
+// nil if the authority is disabled.
+func (a *Authority) IssueTaskCert(taskID string) (cert, key []byte, err error) {
+	if a == nil {
+		return nil, nil, nil
+	}
+	return a.issue(TaskClient, taskID)
+}
+
+func (a *Authority) issue(kind, id string) (certPEM, keyPEM []byte, err error) {
+	if id == "" {
+		return nil, nil, errors.Errorf(
+			"cannot issue a certificate to a %s client without an ID", kind)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error generating a private key for %s %s", kind, id)
+	}
+	template, err := newTemplate(pkix.Name{
+		OrganizationalUnit: []string{kind},
+		CommonName:         id,
+	}, certLifetime)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, key.Public(), a.key)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "error issuing a certificate to %s %s", kind, id)
+	}
+	return encode(der, key)
+}
+
+func newTemplate(subject pkix.Name, lifetime time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating a certificate serial number")
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(lifetime),
+	}, nil
+}
+
+func encode(der []byte, key *ecdsa.PrivateKey) (certPEM, keyPEM []byte, err error) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error encoding a private key")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// ClientID returns the ID of the client of a connection if it presented a certificate that the
+// authority issued to a client of the given kind, or "" otherwise. The connection must have been
+// accepted with the pool of the authority as its client CAs.
+func ClientID(state *tls.ConnectionState, kind string) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	subject := state.VerifiedChains[0][0].Subject
+	if len(subject.OrganizationalUnit) != 1 || subject.OrganizationalUnit[0] != kind {
+		return ""
+	}
+	return subject.CommonName
+}
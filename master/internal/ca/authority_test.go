@@ -0,0 +1,102 @@
+package ca
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func newTestAuthority(t *testing.T) (*Authority, Config) {
+	dir := t.TempDir()
+	config := Config{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "ca.crt"),
+		KeyFile:  filepath.Join(dir, "ca", "ca.key"),
+	}
+	a, err := New(config)
+	assert.NilError(t, err)
+	return a, config
+}
+
+// handshake connects to a server that verifies client certificates against the authority and
+// returns the state of the server's side of the connection.
+func handshake(t *testing.T, a *Authority, certPEM, keyPEM []byte) *tls.ConnectionState {
+	serverCert, serverKey, err := a.issue("server", "master")
+	assert.NilError(t, err)
+	serverPair, err := tls.X509KeyPair(serverCert, serverKey)
+	assert.NilError(t, err)
+	clientConfig := &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	if certPEM != nil {
+		clientPair, pErr := tls.X509KeyPair(certPEM, keyPEM)
+		assert.NilError(t, pErr)
+		clientConfig.Certificates = []tls.Certificate{clientPair}
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    a.Pool(),
+	})
+	assert.NilError(t, err)
+	defer listener.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		if conn, dErr := tls.Dial("tcp", listener.Addr().String(), clientConfig); dErr == nil {
+			<-done
+			_ = conn.Close()
+		}
+	}()
+	conn, err := listener.Accept()
+	assert.NilError(t, err)
+	defer conn.Close()
+	server := conn.(*tls.Conn)
+	if err = server.Handshake(); err != nil {
+		return nil
+	}
+	state := server.ConnectionState()
+	return &state
+}
+
+func TestAuthority(t *testing.T) {
+	a, config := newTestAuthority(t)
+
+	agentCert, agentKey, err := a.IssueAgentCert("agent-1")
+	assert.NilError(t, err)
+	state := handshake(t, a, agentCert, agentKey)
+	assert.Equal(t, ClientID(state, AgentClient), "agent-1")
+	assert.Equal(t, ClientID(state, TaskClient), "")
+
+	taskCert, taskKey, err := a.IssueTaskCert("task-1")
+	assert.NilError(t, err)
+	state = handshake(t, a, taskCert, taskKey)
+	assert.Equal(t, ClientID(state, TaskClient), "task-1")
+	assert.Equal(t, ClientID(state, AgentClient), "")
+
+	// Clients without certificates are accepted but have no ID.
+	assert.Equal(t, ClientID(handshake(t, a, nil, nil), AgentClient), "")
+
+	// Certificates of other authorities are rejected.
+	other, _ := newTestAuthority(t)
+	otherCert, otherKey, err := other.IssueAgentCert("agent-1")
+	assert.NilError(t, err)
+	assert.Assert(t, handshake(t, a, otherCert, otherKey) == nil)
+
+	// The authority is loaded from its files once they exist.
+	reloaded, err := New(config)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reloaded.CertPEM(), a.CertPEM())
+	state = handshake(t, a, agentCert, agentKey)
+	assert.Equal(t, ClientID(state, AgentClient), "agent-1")
+}
+
+func TestDisabledAuthority(t *testing.T) {
+	a, err := New(Config{})
+	assert.NilError(t, err)
+	assert.Assert(t, a == nil)
+	cert, key, err := a.IssueTaskCert("task-1")
+	assert.NilError(t, err)
+	assert.Assert(t, cert == nil && key == nil)
+}
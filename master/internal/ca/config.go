@@ -0,0 +1,27 @@
+package ca
+
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config is the configuration of the certificate authority of the master.
+type Config struct {
+	// Enabled makes the master issue certificates to agents and tasks and require them for agent
+	// connections and for API requests made with task tokens.
+	Enabled bool `json:"enabled"`
+	// CertFile and KeyFile are the paths of the PEM-encoded certificate and private key of the
+	// authority. They are created when neither exists.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	return []error{
+		check.NotEmpty(c.CertFile, "security.mtls.cert_file must be set when mTLS is enabled"),
+		check.NotEmpty(c.KeyFile, "security.mtls.key_file must be set when mTLS is enabled"),
+	}
+}
@@ -0,0 +1,46 @@
+package ca
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/soheilhy/cmux"
+)
+
+type connStateKey struct{}
+
+// ConnState returns the TLS state of a connection that the master accepted, or nil if the
+// connection does not use TLS. Connections from the multiplexing listener wrap the TLS connection.
+func ConnState(conn net.Conn) *tls.ConnectionState {
+	if mux, ok := conn.(*cmux.MuxConn); ok {
+		conn = mux.Conn
+	}
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	return &state
+}
+
+// ConnContext is an http.Server ConnContext hook that makes the TLS state of connections available
+// to RequestState. The HTTP server only sets the TLS state of requests itself for connections that
+// are TLS connections, not ones that wrap them.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if state := ConnState(conn); state != nil {
+		return context.WithValue(ctx, connStateKey{}, state)
+	}
+	return ctx
+}
+
+// RequestState returns the TLS state of the connection that an HTTP request came over, or nil if
+// the connection does not use TLS.
+func RequestState(req *http.Request) *tls.ConnectionState {
+	if req.TLS != nil {
+		return req.TLS
+	}
+	state, _ := req.Context().Value(connStateKey{}).(*tls.ConnectionState)
+	return state
+}
@@ -2,11 +2,14 @@ package internal
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -22,8 +25,13 @@ type checkpointGCTask struct {
 
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
+	vault          *vault.Client
+	ca             *ca.Authority
 
-	task *sproto.AllocateRequest
+	task       *sproto.AllocateRequest
+	vaultGrant *vault.Grant
+	// record is the row of the GC in the tasks table.
+	record *model.Task
 	// TODO (DET-789): Set up proper log handling for checkpoint GC.
 	logs []sproto.ContainerLog
 }
@@ -34,19 +42,45 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 		t.task = &sproto.AllocateRequest{
 			ID:   sproto.NewTaskID(),
 			Name: fmt.Sprintf("Checkpoint GC (Experiment %d)", t.experiment.ID),
+			// The GC container uses the experiment's image, so it must run on the same platform.
+			Platform: t.experiment.Config.Resources().Platform(),
 			FittingRequirements: sproto.FittingRequirements{
 				SingleAgent: true,
 			},
 			TaskActor:      ctx.Self(),
 			NonPreemptible: true,
+			ProjectID:      t.experiment.ProjectID,
+		}
+		if t.experiment.OwnerID != nil {
+			t.task.OwnerID = *t.experiment.OwnerID
+		}
+		projectID := t.experiment.ProjectID
+		t.record = &model.Task{
+			TaskID:       string(t.task.ID),
+			TaskType:     model.TaskTypeCheckpointGC,
+			State:        model.TaskStatePending,
+			Description:  t.task.Name,
+			OwnerID:      t.experiment.OwnerID,
+			ProjectID:    &projectID,
+			ResourcePool: t.task.ResourcePool,
+			Slots:        t.task.SlotsNeeded,
+			StartTime:    time.Now().UTC(),
+		}
+		if err := t.db.AddTask(t.record); err != nil {
+			ctx.Log().WithError(err).Error("cannot record the GC task")
 		}
 		ctx.Tell(t.rm, *t.task)
 
 	case sproto.ResourcesAllocated:
+		t.record.ResourcePool = msg.ResourcePool
 		taskToken, err := t.db.StartTaskSession(string(msg.ID))
 		if err != nil {
 			return errors.Wrap(err, "cannot start a new task session for a GC task")
 		}
+		taskCert, taskKey, err := t.ca.IssueTaskCert(string(msg.ID))
+		if err != nil {
+			return err
+		}
 
 		config := t.experiment.Config.CheckpointStorage()
 
@@ -61,12 +95,29 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 			return err
 		}
 
+		registryCredentials, err := ownerRegistryCredentials(t.db, t.experiment.OwnerID)
+		if err != nil {
+			return err
+		}
+		secrets, err := ownerSecrets(t.db, t.experiment.OwnerID)
+		if err != nil {
+			return err
+		}
+		t.vaultGrant, err = fetchExperimentVaultSecrets(t.db, t.vault, t.experiment)
+		if err != nil {
+			return err
+		}
+
 		ctx.Log().Info("starting checkpoint garbage collection")
 
 		for _, a := range msg.Allocations {
 			taskSpec := *t.taskSpec
 			taskSpec.AgentUserGroup = t.agentUserGroup
+			taskSpec.RegistryCredentials = registryCredentials
+			taskSpec.Secrets = secrets
+			taskSpec.VaultSecrets = t.vaultGrant.Values()
 			taskSpec.TaskToken = taskToken
+			taskSpec.TaskCert, taskSpec.TaskKey = taskCert, taskKey
 			taskSpec.SetInner(&tasks.GCCheckpoints{
 				ExperimentID:       t.experiment.ID,
 				ExperimentConfig:   t.experiment.Config,
@@ -79,6 +130,15 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 		// Ignore the release resource message and wait for the GC job to finish.
 
 	case sproto.TaskContainerStateChanged:
+		if msg.Container.State != container.Terminated {
+			t.transitionRecord(ctx, model.TaskStateOf(&msg.Container))
+		}
+		if msg.Container.State == container.Running {
+			if err := t.db.BindTaskSession(
+				string(t.task.ID), msg.ContainerStarted.SourceAddresses); err != nil {
+				ctx.Log().WithError(err).Error("cannot bind the task token to the GC container")
+			}
+		}
 		if msg.Container.State != container.Terminated {
 			return nil
 		}
@@ -97,7 +157,12 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 	case sproto.ContainerLog:
 		t.logs = append(t.logs, msg)
 
+	case sproto.TaskContainerProfile:
+		// The profile of the GC container is stored by its agent; there is nothing to track here.
+
 	case actor.PostStop:
+		t.transitionRecord(ctx, model.TaskStateTerminated)
+		t.vaultGrant.Release()
 		if t.task != nil {
 			if err := t.db.DeleteTaskSessionByTaskID(string(t.task.ID)); err != nil {
 				ctx.Log().WithError(err).Error("cannot delete task session for a GC task")
@@ -109,3 +174,10 @@ func (t *checkpointGCTask) Receive(ctx *actor.Context) error {
 	}
 	return nil
 }
+
+// transitionRecord moves the row of the GC in the tasks table to a new state.
+func (t *checkpointGCTask) transitionRecord(ctx *actor.Context, state model.TaskState) {
+	if err := t.db.TransitionTask(t.record, state); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the state of the GC task")
+	}
+}
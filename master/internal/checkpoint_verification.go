@@ -0,0 +1,243 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// checkpointVerificationAddr returns the address of the checkpoint verification with the task ID,
+// to which its container reports what it found.
+func checkpointVerificationAddr(taskID string) actor.Address {
+	return actor.Addr("checkpoint-verification-" + taskID)
+}
+
+// checkpointsToVerify is what ExperimentCheckpointsToVerifyRaw returns, of which the task only
+// needs the UUIDs.
+type checkpointsToVerify struct {
+	Checkpoints []struct {
+		UUID string `json:"uuid"`
+	} `json:"checkpoints"`
+}
+
+// checkpointVerificationTask launches a container that verifies the files of the checkpoints of an
+// experiment against their recorded sizes and digests. The container reports what it found for each
+// checkpoint with its task token, and the task records it.
+type checkpointVerificationTask struct {
+	rm         *actor.Ref
+	db         *db.PgDB
+	experiment *model.Experiment
+	// taskID is chosen by whoever starts the verification, so that it can tell it to the user.
+	taskID sproto.TaskID
+	// toVerify is the result of ExperimentCheckpointsToVerifyRaw.
+	toVerify json.RawMessage
+
+	agentUserGroup *model.AgentUserGroup
+	taskSpec       *tasks.TaskSpec
+	vault          *vault.Client
+	ca             *ca.Authority
+
+	task       *sproto.AllocateRequest
+	vaultGrant *vault.Grant
+	// record is the row of the verification in the tasks table.
+	record *model.Task
+	// uuids are the checkpoints to verify, and results how many of them were found in each state.
+	uuids   map[string]bool
+	results map[model.CheckpointIntegrity]int
+	logs    []sproto.ContainerLog
+}
+
+func (t *checkpointVerificationTask) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		var toVerify checkpointsToVerify
+		if err := json.Unmarshal(t.toVerify, &toVerify); err != nil {
+			return errors.Wrap(err, "cannot parse the checkpoints to verify")
+		}
+		t.uuids = make(map[string]bool)
+		for _, c := range toVerify.Checkpoints {
+			t.uuids[c.UUID] = true
+		}
+		t.results = make(map[model.CheckpointIntegrity]int)
+
+		t.task = &sproto.AllocateRequest{
+			ID:   t.taskID,
+			Name: fmt.Sprintf("Checkpoint Verification (Experiment %d)", t.experiment.ID),
+			// The container uses the experiment's image, so it must run on the same platform.
+			Platform: t.experiment.Config.Resources().Platform(),
+			FittingRequirements: sproto.FittingRequirements{
+				SingleAgent: true,
+			},
+			TaskActor:      ctx.Self(),
+			NonPreemptible: true,
+			ProjectID:      t.experiment.ProjectID,
+		}
+		if t.experiment.OwnerID != nil {
+			t.task.OwnerID = *t.experiment.OwnerID
+		}
+		projectID := t.experiment.ProjectID
+		t.record = &model.Task{
+			TaskID:       string(t.task.ID),
+			TaskType:     model.TaskTypeCheckpointVerification,
+			State:        model.TaskStatePending,
+			Description:  t.task.Name,
+			OwnerID:      t.experiment.OwnerID,
+			ProjectID:    &projectID,
+			ResourcePool: t.task.ResourcePool,
+			Slots:        t.task.SlotsNeeded,
+			StartTime:    time.Now().UTC(),
+		}
+		if err := t.db.AddTask(t.record); err != nil {
+			ctx.Log().WithError(err).Error("cannot record the verification task")
+		}
+		ctx.Tell(t.rm, *t.task)
+
+	case sproto.ResourcesAllocated:
+		t.record.ResourcePool = msg.ResourcePool
+		taskToken, err := t.db.StartTaskSession(string(msg.ID))
+		if err != nil {
+			return errors.Wrap(err, "cannot start a new task session for a verification task")
+		}
+		taskCert, taskKey, err := t.ca.IssueTaskCert(string(msg.ID))
+		if err != nil {
+			return err
+		}
+
+		registryCredentials, err := ownerRegistryCredentials(t.db, t.experiment.OwnerID)
+		if err != nil {
+			return err
+		}
+		secrets, err := ownerSecrets(t.db, t.experiment.OwnerID)
+		if err != nil {
+			return err
+		}
+		t.vaultGrant, err = fetchExperimentVaultSecrets(t.db, t.vault, t.experiment)
+		if err != nil {
+			return err
+		}
+
+		ctx.Log().Infof("starting verification of %d checkpoints", len(t.uuids))
+
+		for _, a := range msg.Allocations {
+			taskSpec := *t.taskSpec
+			taskSpec.AgentUserGroup = t.agentUserGroup
+			taskSpec.RegistryCredentials = registryCredentials
+			taskSpec.Secrets = secrets
+			taskSpec.VaultSecrets = t.vaultGrant.Values()
+			taskSpec.TaskToken = taskToken
+			taskSpec.TaskCert, taskSpec.TaskKey = taskCert, taskKey
+			taskSpec.SetInner(&tasks.VerifyCheckpoints{
+				ExperimentID:     t.experiment.ID,
+				ExperimentConfig: t.experiment.Config,
+				ToVerify:         t.toVerify,
+			})
+			a.Start(ctx, taskSpec)
+		}
+
+	case *apiv1.ReportCheckpointIntegrityRequest:
+		integrity, err := t.checkReport(msg)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		if err = t.db.SetCheckpointIntegrity(msg.CheckpointUuid, integrity); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		t.results[integrity]++
+		if integrity != model.CheckpointVerified {
+			ctx.Log().Warnf("checkpoint %s is %s: %v",
+				msg.CheckpointUuid, integrity, msg.Problems)
+		}
+		ctx.Respond(&apiv1.ReportCheckpointIntegrityResponse{})
+
+	case sproto.ReleaseResources:
+		// Ignore the release resource message and wait for the verification to finish.
+
+	case sproto.TaskContainerStateChanged:
+		if msg.Container.State != container.Terminated {
+			t.transitionRecord(ctx, model.TaskStateOf(&msg.Container))
+		}
+		if msg.Container.State == container.Running {
+			if err := t.db.BindTaskSession(
+				string(t.task.ID), msg.ContainerStarted.SourceAddresses); err != nil {
+				ctx.Log().WithError(err).Error(
+					"cannot bind the task token to the verification container")
+			}
+		}
+		if msg.Container.State != container.Terminated {
+			return nil
+		}
+		status := msg.ContainerStopped
+
+		if msg.ContainerStopped.Failure != nil {
+			ctx.Log().Errorf("checkpoint verification failed: %v", status)
+			for _, log := range t.logs {
+				ctx.Log().Error(log.String())
+			}
+		} else {
+			ctx.Log().Infof(
+				"finished checkpoint verification: %d verified, %d corrupted, %d missing",
+				t.results[model.CheckpointVerified], t.results[model.CheckpointCorrupted],
+				t.results[model.CheckpointMissing])
+		}
+		ctx.Self().Stop()
+
+	case sproto.ContainerLog:
+		t.logs = append(t.logs, msg)
+
+	case sproto.TaskContainerProfile:
+
+	case actor.PostStop:
+		if t.record != nil {
+			t.transitionRecord(ctx, model.TaskStateTerminated)
+		}
+		t.vaultGrant.Release()
+		if t.task != nil {
+			if err := t.db.DeleteTaskSessionByTaskID(string(t.task.ID)); err != nil {
+				ctx.Log().WithError(err).Error(
+					"cannot delete task session for a verification task")
+			}
+		}
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// checkReport returns the integrity that a report of the container records, or an error if the
+// report is not about a checkpoint of this verification or does not tell what was found.
+func (t *checkpointVerificationTask) checkReport(
+	msg *apiv1.ReportCheckpointIntegrityRequest,
+) (model.CheckpointIntegrity, error) {
+	integrity := model.CheckpointIntegrityFromProto(msg.Integrity)
+	switch {
+	case !t.uuids[msg.CheckpointUuid]:
+		return "", api.AsErrNotFound(
+			"checkpoint %s is not verified by this task", msg.CheckpointUuid)
+	case integrity == "" || integrity == model.CheckpointUnverified:
+		return "", api.AsErrBadRequest("integrity must be verified, corrupted or missing")
+	}
+	return integrity, nil
+}
+
+// transitionRecord moves the row of the verification in the tasks table to a new state.
+func (t *checkpointVerificationTask) transitionRecord(ctx *actor.Context, state model.TaskState) {
+	if err := t.db.TransitionTask(t.record, state); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the state of the verification task")
+	}
+}
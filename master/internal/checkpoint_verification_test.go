@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
+)
+
+func TestCheckpointVerificationReports(t *testing.T) {
+	task := &checkpointVerificationTask{uuids: map[string]bool{"verified": true}}
+
+	integrity, err := task.checkReport(&apiv1.ReportCheckpointIntegrityRequest{
+		CheckpointUuid: "verified",
+		Integrity:      checkpointv1.Integrity_INTEGRITY_CORRUPTED,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, integrity, model.CheckpointCorrupted)
+
+	// Tasks may only flag the checkpoints they were asked to verify.
+	_, err = task.checkReport(&apiv1.ReportCheckpointIntegrityRequest{
+		CheckpointUuid: "other",
+		Integrity:      checkpointv1.Integrity_INTEGRITY_MISSING,
+	})
+	assert.Assert(t, errors.Is(err, api.ErrNotFound))
+
+	for _, i := range []checkpointv1.Integrity{
+		checkpointv1.Integrity_INTEGRITY_UNSPECIFIED, checkpointv1.Integrity_INTEGRITY_UNVERIFIED,
+	} {
+		_, err = task.checkReport(&apiv1.ReportCheckpointIntegrityRequest{
+			CheckpointUuid: "verified", Integrity: i,
+		})
+		assert.Assert(t, errors.Is(err, api.ErrBadRequest))
+	}
+}
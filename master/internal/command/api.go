@@ -5,7 +5,11 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -19,37 +23,91 @@ func RegisterAPIHandler(
 	db *db.PgDB,
 	proxyRef *actor.Ref,
 	timeout int,
+	notebookTimeout int,
 	defaultAgentUserGroup model.AgentUserGroup,
+	bindMountPolicy model.BindMountPolicy,
 	makeTaskSpec tasks.MakeTaskSpecFn,
+	vaultClient *vault.Client,
+	artifactStore *artifacts.Service,
+	authority *ca.Authority,
 	middleware ...echo.MiddlewareFunc,
 ) {
+	prom.Register("commands", lifecycleCollector)
+
 	system.ActorOf(actor.Addr("commands"), &commandManager{
 		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
 		db:                    db,
 		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
 	})
 	echo.Any("/commands*", api.Route(system, nil), middleware...)
 
 	system.ActorOf(actor.Addr("notebooks"), &notebookManager{
 		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
 		db:                    db,
 		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
+		timeout:               time.Duration(notebookTimeout) * time.Second,
 	})
 	echo.Any("/notebooks*", api.Route(system, nil), middleware...)
 
 	system.ActorOf(actor.Addr("shells"), &shellManager{
 		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
 		db:                    db,
 		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
 	})
 	echo.Any("/shells*", api.Route(system, nil), middleware...)
 
 	system.ActorOf(actor.Addr("tensorboard"), &tensorboardManager{
 		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
 		db:                    db,
 		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
 		proxyRef:              proxyRef,
 		timeout:               time.Duration(timeout) * time.Second,
 	})
 	echo.Any("/tensorboard*", api.Route(system, nil), middleware...)
+
+	system.ActorOf(actor.Addr("code-servers"), &codeServerManager{
+		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
+		db:                    db,
+		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
+	})
+	echo.Any("/code-servers*", api.Route(system, nil), middleware...)
+
+	system.ActorOf(actor.Addr("ray-clusters"), &rayClusterManager{
+		defaultAgentUserGroup: defaultAgentUserGroup,
+		bindMountPolicy:       bindMountPolicy,
+		db:                    db,
+		makeTaskSpec:          makeTaskSpec,
+		vault:                 vaultClient,
+		artifacts:             artifactStore,
+		ca:                    authority,
+	})
+	echo.Any("/ray-clusters*", api.Route(system, nil), middleware...)
+
+	system.ActorOf(actor.Addr("servings"), &servingManager{
+		bindMountPolicy: bindMountPolicy,
+		db:              db,
+		vault:           vaultClient,
+		artifacts:       artifactStore,
+		ca:              authority,
+	})
 }
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// archivingManagers are the addresses of the managers whose commands can be archived.
+var archivingManagers = []actor.Address{
+	actor.Addr("commands"),
+	actor.Addr("notebooks"),
+	actor.Addr("tensorboard"),
+}
+
+// archiveTask is a message asking a command to archive itself. The command responds with the
+// message, or with an error if it has not exited.
+type archiveTask struct{}
+
+// archivableTasks is a message asking a manager for its commands that exited before a time and are
+// not archived. The manager responds with a []ArchivableTask.
+type archivableTasks struct {
+	endedBefore time.Time
+	users       []string
+	projectID   int32
+}
+
+// ArchivableTask is a command, notebook or TensorBoard that can be archived, with who owns it.
+type ArchivableTask struct {
+	Addr      actor.Address
+	Username  string
+	ProjectID int32
+}
+
+// ArchivableTasks returns the commands, notebooks and TensorBoards that exited before endedBefore
+// and are not archived, limited to those owned by the users if any are given and to those in the
+// project if it is not 0.
+func ArchivableTasks(
+	system *actor.System, endedBefore time.Time, users []string, projectID int32,
+) []ArchivableTask {
+	var tasks []ArchivableTask
+	for _, manager := range archivingManagers {
+		found, ok := system.AskAt(manager, archivableTasks{
+			endedBefore: endedBefore, users: users, projectID: projectID,
+		}).Get().([]ArchivableTask)
+		if ok {
+			tasks = append(tasks, found...)
+		}
+	}
+	return tasks
+}
+
+// ArchiveTasks archives the tasks and returns how many of them it archived. Tasks that stopped
+// since they were found are skipped.
+func ArchiveTasks(system *actor.System, tasks []ArchivableTask) int {
+	archived := 0
+	for _, task := range tasks {
+		resp := system.AskAt(task.Addr, archiveTask{})
+		if _, ok := resp.Get().(archiveTask); ok {
+			archived++
+		}
+	}
+	return archived
+}
+
+// archivable returns the commands of the index that can be archived by the request.
+func (idx listIndex) archivable(msg archivableTasks) []ArchivableTask {
+	users := make(map[string]bool)
+	for _, user := range msg.users {
+		users[user] = true
+	}
+	var tasks []ArchivableTask
+	for _, e := range idx {
+		if !e.archived && !e.endTime.IsZero() && e.endTime.Before(msg.endedBefore) &&
+			(len(users) == 0 || users[e.username]) &&
+			(msg.projectID == 0 || e.projectID == msg.projectID) {
+			tasks = append(tasks, ArchivableTask{
+				Addr:      e.ref.Address(),
+				Username:  e.username,
+				ProjectID: e.projectID,
+			})
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestListIndexArchivable(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	now := time.Now()
+	idx := listIndex{}
+	for _, e := range []listEntry{
+		{id: "running", username: "alice"},
+		{id: "exited", username: "alice", endTime: now.Add(-2 * time.Hour)},
+		{id: "recent", username: "alice", endTime: now.Add(-time.Minute)},
+		{id: "archived", username: "alice", endTime: now.Add(-2 * time.Hour), archived: true},
+		{id: "other-user", username: "bob", endTime: now.Add(-2 * time.Hour)},
+		{id: "other-project", username: "alice", projectID: 2, endTime: now.Add(-2 * time.Hour)},
+	} {
+		e.ref, _ = system.ActorOf(actor.Addr(e.id), actor.ActorFunc(func(*actor.Context) error {
+			return nil
+		}))
+		idx.update(e)
+	}
+	archivable := func(msg archivableTasks) []string {
+		var ids []string
+		for _, task := range idx.archivable(msg) {
+			ids = append(ids, task.Addr.Local())
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// Only tasks that exited long enough ago and are not archived yet can be archived.
+	hourAgo := now.Add(-time.Hour)
+	assert.DeepEqual(t, archivable(archivableTasks{endedBefore: hourAgo}),
+		[]string{"exited", "other-project", "other-user"})
+	assert.DeepEqual(t, archivable(archivableTasks{
+		endedBefore: hourAgo, users: []string{"alice"}, projectID: 2,
+	}), []string{"other-project"})
+}
@@ -0,0 +1,137 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// clusterHeadAddressVar is the environment variable through which the workers of a cluster find
+// its head. It is not set in the container of the head.
+const clusterHeadAddressVar = "DET_CLUSTER_HEAD_ADDRESS"
+
+// clusterHeadAddress returns the address at which the workers of the cluster reach the port of
+// its head: the hostname of the head on the network private to the cluster, if its containers
+// joined one, or else the IP address of the head from outside its container, which is that of its
+// agent if it uses the network of the host.
+func (c *command) clusterHeadAddress() string {
+	if c.taskNetwork != nil {
+		return fmt.Sprintf("%s:%d", c.taskNetwork.Peers[0], c.clusterPort)
+	}
+	var host string
+	if len(c.addresses) > 0 {
+		host = c.addresses[0].HostIP
+	}
+	return fmt.Sprintf("%s:%d", host, c.clusterPort)
+}
+
+// startWorkers starts the workers of the cluster once its head runs, with the address of the head
+// in their environment.
+func (c *command) startWorkers(ctx *actor.Context) {
+	headAddress := c.clusterHeadAddress()
+	headVar := fmt.Sprintf("%s=%s", clusterHeadAddressVar, headAddress)
+	config := c.config
+	envVars := c.config.Environment.EnvironmentVariables
+	config.Environment.EnvironmentVariables.CPU = append(append([]string{}, envVars.CPU...), headVar)
+	config.Environment.EnvironmentVariables.GPU = append(append([]string{}, envVars.GPU...), headVar)
+
+	c.workerContainers = make(map[container.ID]*container.Container, len(c.workers))
+	for _, worker := range c.workers {
+		spec := *c.workerSpec
+		spec.SetInner(&tasks.StartCommand{
+			Config:          config,
+			UserFiles:       c.workerFiles,
+			AdditionalFiles: c.additionalFiles,
+		})
+		worker.Start(ctx, spec)
+		c.workerContainers[worker.Summary().ID] = nil
+	}
+	ctx.Log().Infof("started %d workers of the cluster with head %s", len(c.workers), headAddress)
+
+	// Like those of the head, the files of the workers are evicted from memory once they start.
+	c.workerSpec = nil
+	c.workerFiles = nil
+	c.additionalFiles = nil
+}
+
+// workerStateChanged handles a change of the state of the container of a worker. The first worker
+// that exits tears the whole cluster down, since its head cannot tell whether the worker comes
+// back.
+func (c *command) workerStateChanged(ctx *actor.Context, msg sproto.TaskContainerStateChanged) {
+	c.workerContainers[msg.Container.ID] = &msg.Container
+	c.trace.ContainerState(string(msg.Container.ID), string(msg.Container.State))
+
+	switch msg.Container.State {
+	case container.Running:
+		if err := c.db.BindTaskSession(
+			string(c.task.ID), msg.ContainerStarted.SourceAddresses); err != nil {
+			ctx.Log().WithError(err).Error("cannot bind the task token to the container")
+		}
+		if c.workersRunning() {
+			c.deleteTempContext(ctx)
+		}
+
+	case container.Terminated:
+		if !c.killed {
+			exitStatus := "a worker of the cluster exited"
+			if msg.ContainerStopped.Failure != nil {
+				exitStatus = fmt.Sprintf(
+					"a worker of the cluster failed: %s", msg.ContainerStopped.Failure.Error())
+			}
+			ctx.Log().Infof("%s, tearing down the cluster", exitStatus)
+			c.workerExitStatus = &exitStatus
+			c.killContainers(ctx)
+		}
+		c.exitIfTerminated(ctx)
+	}
+}
+
+// killContainers kills the container of the command and those of its workers that started, unless
+// they terminated already.
+func (c *command) killContainers(ctx *actor.Context) {
+	c.killed = true
+	if c.container == nil || c.container.State != container.Terminated {
+		c.allocation.Kill(ctx, c.config.StopPolicy)
+	}
+	for _, worker := range c.workers {
+		workerContainer, started := c.workerContainers[worker.Summary().ID]
+		if started && (workerContainer == nil || workerContainer.State != container.Terminated) {
+			worker.Kill(ctx, c.config.StopPolicy)
+		}
+	}
+}
+
+// workersRunning returns whether the containers of all workers of the cluster run.
+func (c *command) workersRunning() bool {
+	if len(c.workerContainers) < len(c.workers) {
+		return false
+	}
+	for _, workerContainer := range c.workerContainers {
+		if workerContainer == nil || workerContainer.State != container.Running {
+			return false
+		}
+	}
+	return true
+}
+
+// workersTerminated returns whether the containers of all workers that started terminated.
+func (c *command) workersTerminated() bool {
+	for _, workerContainer := range c.workerContainers {
+		if workerContainer == nil || workerContainer.State != container.Terminated {
+			return false
+		}
+	}
+	return true
+}
+
+// exitIfTerminated exits once the container of the command terminated, as well as those of its
+// workers, so that the resources of the cluster are only released once none of it runs.
+func (c *command) exitIfTerminated(ctx *actor.Context) {
+	if c.containerExitStatus == nil || !c.workersTerminated() {
+		return
+	}
+	c.exit(ctx, *c.containerExitStatus)
+}
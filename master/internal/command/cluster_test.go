@@ -0,0 +1,37 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+func TestClusterHeadAddress(t *testing.T) {
+	c := &command{
+		clusterPort: 4400,
+		addresses:   []container.Address{{HostIP: "10.0.0.1", HostPort: 4100, ContainerPort: 4100}},
+	}
+	assert.Equal(t, c.clusterHeadAddress(), "10.0.0.1:4400")
+
+	c.taskNetwork = container.NewTaskNetwork("task", 3)
+	assert.Equal(t, c.clusterHeadAddress(), "rank-0:4400")
+}
+
+func TestWorkersTerminated(t *testing.T) {
+	c := &command{}
+	assert.Assert(t, c.workersTerminated())
+
+	c.workerContainers = map[container.ID]*container.Container{"a": nil, "b": nil}
+	assert.Assert(t, !c.workersTerminated())
+	assert.Assert(t, !c.workersRunning())
+
+	c.workerContainers["a"] = &container.Container{State: container.Running}
+	c.workerContainers["b"] = &container.Container{State: container.Terminated}
+	assert.Assert(t, !c.workersTerminated())
+	assert.Assert(t, !c.workersRunning())
+
+	c.workerContainers["a"] = &container.Container{State: container.Terminated}
+	assert.Assert(t, c.workersTerminated())
+}
@@ -0,0 +1,227 @@
+package command
+
+import (
+	"archive/tar"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/codeserverv1"
+)
+
+const (
+	codeServerDir        = "/run/determined/code-server/"
+	codeServerTokenFile  = "/run/determined/code-server/token"
+	codeServerEntrypoint = "/run/determined/code-server/code-server-entrypoint.sh"
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
+	minCodeServerPort        = 3800
+	maxCodeServerPort        = minCodeServerPort + 299
+	codeServerServiceAddress = "/proxy/%s/"
+	// codeServerTokenBytes is the number of random bytes of the tokens of code servers.
+	codeServerTokenBytes = 24
+)
+
+// codeServerReadyPattern matches what code-server and openvscode-server log once they listen.
+var codeServerReadyPattern = regexp.MustCompile("HTTP server listening on|Web UI available at")
+
+type codeServerManager struct {
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
+
+	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
+	makeTaskSpec          tasks.MakeTaskSpecFn
+
+	// tasks are the running code servers, which the manager lists.
+	tasks listIndex
+}
+
+// CodeServerLaunchRequest describes a request to launch a new code server.
+type CodeServerLaunchRequest struct {
+	CommandParams *CommandParams
+}
+
+func (c *codeServerManager) processLaunchRequest(
+	ctx *actor.Context,
+	req CodeServerLaunchRequest,
+) (*summary, int, error) {
+	ctx.Log().Info("creating code server")
+
+	codeServer, err := c.newCodeServer(req.CommandParams)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if err = check.Validate(codeServer.config); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err = codeServer.checkBindMounts(c.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err = codeServer.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	a, _ := ctx.ActorOf(codeServer.taskID, codeServer)
+	summaryFut := ctx.Ask(a, getSummary{})
+	if err := summaryFut.Error(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	summary := summaryFut.Get().(summary)
+	ctx.Log().Infof("created code server %s", a.Address().Local())
+	return &summary, http.StatusOK, nil
+}
+
+func (c *codeServerManager) Receive(ctx *actor.Context) error {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		c.tasks = listIndex{}
+		restore(ctx, c.db, c.vault, c.artifacts, c.ca, c.makeTaskSpec)
+
+	case persistQueued:
+		persistQueuedChildren(ctx)
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		c.tasks.update(msg)
+
+	case searchTasks:
+		ctx.Respond(c.tasks.search(msg, apiv1.SearchResult_KIND_CODE_SERVER))
+
+	case *apiv1.GetCodeServersRequest:
+		items, pagination, next, err := c.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+		}, &codeserverv1.CodeServer{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		resp := &apiv1.GetCodeServersResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.CodeServers = append(resp.CodeServers, item.(*codeserverv1.CodeServer))
+		}
+		ctx.Respond(resp)
+
+	case CodeServerLaunchRequest:
+		summary, statusCode, err := c.processLaunchRequest(ctx, msg)
+		if err != nil || statusCode > 200 {
+			ctx.Respond(echo.NewHTTPError(
+				statusCode, errors.Wrap(err, "failed to launch code server").Error()))
+			return nil
+		}
+		ctx.Respond(summary.ID)
+	}
+	return nil
+}
+
+// generateCodeServerToken returns a random token that a code server asks its users for.
+func generateCodeServerToken() (string, error) {
+	token := make([]byte, codeServerTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func (c *codeServerManager) newCodeServer(params *CommandParams) (*command, error) {
+	config := params.FullConfig
+	taskID := sproto.NewTaskID()
+
+	// Select a random port from the range to assign to the code server. In host
+	// mode, this mitigates the risk of multiple code servers binding the same
+	// port on an agent.
+	port := getPort(minCodeServerPort, maxCodeServerPort)
+	portVar := fmt.Sprintf("CODE_SERVER_PORT=%d", port)
+
+	config.Environment.Ports = map[string]int{"code-server": port}
+	config.Environment.EnvironmentVariables.CPU = append(
+		config.Environment.EnvironmentVariables.CPU, portVar)
+	config.Environment.EnvironmentVariables.GPU = append(
+		config.Environment.EnvironmentVariables.GPU, portVar)
+
+	config.Entrypoint = []string{codeServerEntrypoint}
+
+	setPodSpec(config, params.TaskSpec.TaskContainerDefaults)
+
+	if config.Description == "" {
+		petName := petname.Generate(model.TaskNameGeneratorWords, model.TaskNameGeneratorSep)
+		config.Description = fmt.Sprintf("Code server (%s)", petName)
+	}
+
+	// The token is written to a file rather than set in the environment, since the config of the
+	// code server is visible to every user.
+	token, err := generateCodeServerToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating code server token")
+	}
+	serviceAddress := fmt.Sprintf(codeServerServiceAddress, taskID)
+
+	return &command{
+		taskID:          taskID,
+		config:          *config,
+		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
+		additionalFiles: archive.Archive{
+			params.AgentUserGroup.OwnedArchiveItem(codeServerDir, nil, 0700, tar.TypeDir),
+			params.AgentUserGroup.OwnedArchiveItem(
+				codeServerTokenFile, []byte(token), 0600, tar.TypeReg,
+			),
+			params.AgentUserGroup.OwnedArchiveItem(
+				codeServerEntrypoint,
+				etc.MustStaticFile(etc.CodeServerEntrypointResource),
+				0700,
+				tar.TypeReg,
+			),
+		},
+		token: token,
+
+		readinessChecks: map[string]readinessCheck{
+			"code-server": readinessChecksByName["code-server"],
+		},
+		serviceAddress: &serviceAddress,
+		stripProxyPath: true,
+
+		owner: commandOwner{
+			ID:       params.User.ID,
+			Username: params.User.Username,
+		},
+		agentUserGroup: params.AgentUserGroup,
+		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
+
+		db:        c.db,
+		vault:     c.vault,
+		artifacts: c.artifacts,
+		ca:        c.ca,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCodeServerReadyPattern(t *testing.T) {
+	for _, line := range []string{
+		"[2021-07-03T10:00:00.000Z] info  HTTP server listening on http://0.0.0.0:3901/",
+		"Web UI available at http://localhost:3901/?tkn=secret",
+	} {
+		assert.Assert(t, codeServerReadyPattern.MatchString(line), line)
+	}
+	assert.Assert(t, !codeServerReadyPattern.MatchString("info  code-server 3.10.2"))
+}
+
+func TestGenerateCodeServerToken(t *testing.T) {
+	token, err := generateCodeServerToken()
+	assert.NilError(t, err)
+	assert.Equal(t, len(token), 2*codeServerTokenBytes)
+	other, err := generateCodeServerToken()
+	assert.NilError(t, err)
+	assert.Assert(t, token != other)
+}
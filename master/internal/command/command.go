@@ -1,18 +1,24 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
-	"net/url"
 	"time"
 
 	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/golang/protobuf/ptypes/timestamp"
 
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
-	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/tracing"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
@@ -22,8 +28,11 @@ import (
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/codeserverv1"
 	"github.com/determined-ai/determined/proto/pkg/commandv1"
+	"github.com/determined-ai/determined/proto/pkg/containerv1"
 	"github.com/determined-ai/determined/proto/pkg/notebookv1"
+	"github.com/determined-ai/determined/proto/pkg/rayclusterv1"
 	"github.com/determined-ai/determined/proto/pkg/shellv1"
 	"github.com/determined-ai/determined/proto/pkg/tensorboardv1"
 )
@@ -71,28 +80,99 @@ type command struct {
 	owner          commandOwner
 	agentUserGroup *model.AgentUserGroup
 	taskSpec       *tasks.TaskSpec
-
-	taskID               sproto.TaskID
-	userFiles            archive.Archive
+	projectID      int
+
+	taskID sproto.TaskID
+	// taskType is the type of the command's task when it is not that of its parent, as for the
+	// replicas of servings.
+	taskType  model.TaskType
+	userFiles archive.Archive
+	// contextArtifact is the ID of the context artifact of the command, which is only read when
+	// the command launches, and tempContext is true if the command deletes it afterwards.
+	contextArtifact      string
+	tempContext          bool
 	additionalFiles      archive.Archive
 	readinessChecks      map[string]readinessCheck
 	readinessMessageSent bool
 	metadata             map[string]interface{}
 	serviceAddress       *string
+	// token is the secret that the server of the command asks its users for. Unlike the metadata,
+	// it is not part of the summary of the command that its events show to every user.
+	token string
 
 	registeredTime time.Time
 	task           *sproto.AllocateRequest
-	container      *container.Container
-	allocation     sproto.Allocation
-	proxyNames     []string
+	// record is the row of the command in the tasks table.
+	record     *model.Task
+	container  *container.Container
+	allocation sproto.Allocation
+	proxyNames []string
+	// tenantID is the tenant that the services of the command belong to in the proxy.
+	tenantID       *int
 	exitStatus     *string
+	exitTime       time.Time
+	archived       bool
 	addresses      []container.Address
+	reportedStatus *reportedStatus
+	resourceUsage  *resourceUsage
+	// reattachTo is the container that the command, restored after the master restarted,
+	// reattaches to once its agent reconnects.
+	reattachTo *container.ID
+
+	// spanContext is the span of the request that launched the command, and trace traces its
+	// allocation as part of that request.
+	spanContext tracing.SpanContext
+	trace       *tracing.AllocationTrace
 
 	db          *db.PgDB
+	vault       *vault.Client
+	artifacts   *artifacts.Service
+	vaultGrant  *vault.Grant
+	ca          *ca.Authority
 	proxy       *actor.Ref
 	eventStream *actor.Ref
 
 	proxyTCP bool
+	// stripProxyPath is whether the proxy forwards requests to the command without the
+	// /proxy/<task ID> prefix of their paths, for servers that can only be served from the root.
+	stripProxyPath bool
+	// forwardedPorts are the ports of the container besides its own that the command proxies, which
+	// users of shells forward after they start, e.g. to reach debuggers.
+	forwardedPorts []int
+	// pollKernels is whether the command is a notebook that polls the activity of its Jupyter
+	// kernels, and kernels is their activity once it runs.
+	pollKernels bool
+	kernels     *kernelActivity
+
+	// clusterWorkers is the number of containers that the command runs besides its own, as the
+	// workers of a cluster whose head is the container of the command, and clusterPort is the
+	// port of the head that they connect to.
+	clusterWorkers int
+	clusterPort    int
+	// workers are the allocations of the workers, and workerContainers are the containers of those
+	// that started, which they do once the head runs. Until then, they wait to start with
+	// workerSpec and workerFiles.
+	workers          []sproto.Allocation
+	workerContainers map[container.ID]*container.Container
+	workerSpec       *tasks.TaskSpec
+	workerFiles      archive.Archive
+	// taskNetwork is the network private to the cluster, if its containers joined one.
+	taskNetwork *container.TaskNetwork
+	// killed is whether the containers of the command were killed, after which the exits of its
+	// workers are expected. containerExitStatus is the exit status of the command once its own
+	// container terminated, while its workers may still run, and workerExitStatus is that of the
+	// first worker that exited unexpectedly.
+	killed              bool
+	containerExitStatus *string
+	workerExitStatus    *string
+	// startupLogs are the last log lines of the command until it is ready, and startupFailure is
+	// why it failed if it was not ready within its startup timeout.
+	startupLogs    []string
+	startupFailure *string
+	// allocatedTime is when the resources of the command were allocated, which its max runtime
+	// counts from, and timedOut is whether its containers were killed once that passed.
+	allocatedTime time.Time
+	timedOut      bool
 }
 
 // Receive implements the actor.Actor interface.
@@ -100,22 +180,36 @@ func (c *command) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
 		c.registeredTime = ctx.Self().RegisteredTime()
+		ctx.Tell(ctx.Self().Parent(), c.listEntry(ctx))
+		c.addRecord(ctx)
 		// Initialize an event stream manager.
 		c.eventStream, _ = ctx.ActorOf("events", newEventManager())
 		// Schedule the command with the cluster.
 		c.proxy = ctx.Self().System().Get(actor.Addr("proxy"))
 
+		c.trace = tracing.StartAllocation(c.spanContext, string(c.taskID))
 		c.task = &sproto.AllocateRequest{
 			ID:             c.taskID,
 			Name:           c.config.Description,
 			SlotsNeeded:    c.config.Resources.Slots,
 			Label:          c.config.Resources.AgentLabel,
 			ResourcePool:   c.config.Resources.ResourcePool,
+			Platform:       c.config.Resources.Platform,
+			GPUType:        c.config.Resources.GPUType,
 			NonPreemptible: true,
 			FittingRequirements: sproto.FittingRequirements{
-				SingleAgent: true,
+				SingleAgent: c.clusterWorkers == 0,
+				Containers:  c.clusterWorkers + 1,
 			},
-			TaskActor: ctx.Self(),
+			TaskActor:   ctx.Self(),
+			OwnerID:     c.owner.ID,
+			ProjectID:   c.projectID,
+			SpanContext: c.trace.SpanContext(),
+		}
+		if c.reattachTo != nil {
+			c.restoreContainer(ctx)
+			actors.NotifyAfter(ctx, reattachTimeout, reattachTimedOut{})
+			break
 		}
 		if err := ctx.Ask(sproto.GetRM(ctx.Self().System()), *c.task).Error(); err != nil {
 			return err
@@ -125,6 +219,7 @@ func (c *command) Receive(ctx *actor.Context) error {
 			Handler:  ctx.Self(),
 		})
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ScheduledEvent: &c.taskID})
+		countLifecycleEvent(ctx, createdEvent)
 
 	case actor.PostStop:
 		c.terminate(ctx)
@@ -168,6 +263,12 @@ func (c *command) Receive(ctx *actor.Context) error {
 			ctx.Respond(&apiv1.KillNotebookResponse{Notebook: notebook})
 		}
 
+	case *apiv1.ArchiveNotebookRequest:
+		c.setArchived(ctx, true, &apiv1.ArchiveNotebookResponse{})
+
+	case *apiv1.UnarchiveNotebookRequest:
+		c.setArchived(ctx, false, &apiv1.UnarchiveNotebookResponse{})
+
 	case *commandv1.Command:
 		ctx.Respond(c.toCommand(ctx))
 
@@ -181,6 +282,12 @@ func (c *command) Receive(ctx *actor.Context) error {
 		c.terminate(ctx)
 		ctx.Respond(&apiv1.KillCommandResponse{Command: c.toCommand(ctx)})
 
+	case *apiv1.ArchiveCommandRequest:
+		c.setArchived(ctx, true, &apiv1.ArchiveCommandResponse{})
+
+	case *apiv1.UnarchiveCommandRequest:
+		c.setArchived(ctx, false, &apiv1.UnarchiveCommandResponse{})
+
 	case *shellv1.Shell:
 		ctx.Respond(c.toShell(ctx))
 
@@ -194,6 +301,20 @@ func (c *command) Receive(ctx *actor.Context) error {
 		c.terminate(ctx)
 		ctx.Respond(&apiv1.KillShellResponse{Shell: c.toShell(ctx)})
 
+	case *apiv1.PostShellPortRequest:
+		if err := c.forwardPort(ctx, int(msg.Port)); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(&apiv1.PostShellPortResponse{ServiceId: portServiceID(c.taskID, int(msg.Port))})
+
+	case *apiv1.DeleteShellPortRequest:
+		if err := c.unforwardPort(ctx, int(msg.Port)); err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		ctx.Respond(&apiv1.DeleteShellPortResponse{})
+
 	case *tensorboardv1.Tensorboard:
 		ctx.Respond(c.toTensorboard(ctx))
 
@@ -204,45 +325,110 @@ func (c *command) Receive(ctx *actor.Context) error {
 		c.terminate(ctx)
 		ctx.Respond(&apiv1.KillTensorboardResponse{Tensorboard: c.toTensorboard(ctx)})
 
+	case *apiv1.ArchiveTensorboardRequest:
+		c.setArchived(ctx, true, &apiv1.ArchiveTensorboardResponse{})
+
+	case *apiv1.UnarchiveTensorboardRequest:
+		c.setArchived(ctx, false, &apiv1.UnarchiveTensorboardResponse{})
+
+	case *codeserverv1.CodeServer:
+		ctx.Respond(c.toCodeServer(ctx))
+
+	case *apiv1.GetCodeServerRequest:
+		ctx.Respond(&apiv1.GetCodeServerResponse{
+			CodeServer: c.toCodeServer(ctx),
+			Config:     protoutils.ToStruct(c.config),
+		})
+
+	case *apiv1.KillCodeServerRequest:
+		c.terminate(ctx)
+		ctx.Respond(&apiv1.KillCodeServerResponse{CodeServer: c.toCodeServer(ctx)})
+
+	case *rayclusterv1.RayCluster:
+		ctx.Respond(c.toRayCluster(ctx))
+
+	case *apiv1.GetRayClusterRequest:
+		ctx.Respond(&apiv1.GetRayClusterResponse{
+			RayCluster: c.toRayCluster(ctx),
+			Config:     protoutils.ToStruct(c.config),
+		})
+
+	case *apiv1.KillRayClusterRequest:
+		c.terminate(ctx)
+		ctx.Respond(&apiv1.KillRayClusterResponse{RayCluster: c.toRayCluster(ctx)})
+
+	case archiveTask:
+		c.setArchived(ctx, true, msg)
+
+	case *apiv1.ReportTaskStatusRequest:
+		c.reportedStatus = &reportedStatus{
+			Status:     msg.Status,
+			Progress:   msg.Progress,
+			ReportTime: time.Now().UTC(),
+		}
+		ctx.Respond(&apiv1.ReportTaskStatusResponse{})
+
 	case sproto.TaskContainerStateChanged:
+		if _, ok := c.workerContainers[msg.Container.ID]; ok {
+			c.workerStateChanged(ctx, msg)
+			break
+		}
 		c.container = &msg.Container
+		c.trace.ContainerState(string(msg.Container.ID), string(msg.Container.State))
+		if msg.Container.State != container.Terminated {
+			c.transitionRecord(ctx, c.State())
+		}
 
 		switch {
 		case msg.Container.State == container.Running:
 			c.addresses = msg.ContainerStarted.Addresses
-
-			names := make([]string, 0, len(c.addresses))
-			for _, address := range c.addresses {
-				// We are keying on task ID instead of container ID. Revisit this when we need to
-				// proxy multi-container tasks or when containers are created prior to being
-				// assigned to an agent.
-				ctx.Ask(c.proxy, proxy.Register{
-					ServiceID: string(c.taskID),
-					URL: &url.URL{
-						Scheme: "http",
-						Host:   fmt.Sprintf("%s:%d", address.HostIP, address.HostPort),
-					},
-					ProxyTCP: c.proxyTCP,
-				})
-				names = append(names, string(c.taskID))
+			if err := c.db.BindTaskSession(
+				string(c.task.ID), msg.ContainerStarted.SourceAddresses); err != nil {
+				ctx.Log().WithError(err).Error("cannot bind the task token to the container")
 			}
-			c.proxyNames = names
+			if len(c.workers) == 0 {
+				c.deleteTempContext(ctx)
+			} else {
+				c.startWorkers(ctx)
+			}
+
+			c.registerProxies(ctx)
+			// Persist the addresses, so that users can reach the container through the proxy as
+			// soon as the command is restored if the master restarts.
+			c.updateAllocation(ctx)
+			c.startPollingKernels(ctx)
+			countLifecycleEvent(ctx, startedEvent)
 			ctx.Tell(c.eventStream, event{
 				Snapshot: newSummary(c), ContainerStartedEvent: msg.ContainerStarted,
 			})
 
 		case msg.Container.State == container.Terminated:
-			for _, name := range c.proxyNames {
-				ctx.Tell(c.proxy, proxy.Unregister{ServiceID: name})
-			}
-			c.proxyNames = make([]string, 0)
+			c.resourceUsage = nil
 
 			exitStatus := "command exited successfully"
+			lifecycleEvent := succeededEvent
 			if msg.ContainerStopped.Failure != nil {
 				exitStatus = msg.ContainerStopped.Failure.Error()
+				lifecycleEvent = failedEvent
+				c.trace.End(msg.ContainerStopped.Failure)
+			}
+			if c.workerExitStatus != nil {
+				exitStatus = *c.workerExitStatus
+				lifecycleEvent = failedEvent
+			}
+			if c.startupFailure != nil {
+				exitStatus = *c.startupFailure
+				lifecycleEvent = failedEvent
 			}
+			if c.timedOut {
+				exitStatus = c.maxRuntimeExitStatus()
+				lifecycleEvent = timedOutEvent
+			}
+			countLifecycleEvent(ctx, lifecycleEvent)
 
-			c.exit(ctx, exitStatus)
+			c.containerExitStatus = &exitStatus
+			c.killContainers(ctx)
+			c.exitIfTerminated(ctx)
 		}
 
 	case sproto.ContainerLog:
@@ -251,8 +437,37 @@ func (c *command) Receive(ctx *actor.Context) error {
 			ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ServiceReadyEvent: &msg})
 		}
 		log := msg.String()
+		c.addStartupLog(log)
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), LogEvent: &log})
 
+	case sproto.TaskContainerProfile:
+		c.resourceUsage = newResourceUsage(msg.ContainerProfile)
+
+	case sproto.ReattachContainer:
+		c.reattach(ctx, msg)
+
+	case kernelsTick:
+		c.pollKernelActivity(ctx)
+
+	case startupTimedOut:
+		c.checkStartup(ctx, msg)
+
+	case maxRuntimeExceeded:
+		c.checkMaxRuntime(ctx, msg)
+
+	case reattachTimedOut:
+		if c.reattachTo != nil && c.exitStatus == nil {
+			c.deleteAllocation(ctx, *c.reattachTo)
+			c.exit(ctx, "the agent of the task did not reconnect after the master restarted")
+		}
+
+	case persistIfQueued:
+		if persisted, err := c.persistIfQueued(ctx); err != nil {
+			ctx.Respond(err)
+		} else {
+			ctx.Respond(persisted)
+		}
+
 	case terminateForGC:
 		ctx.Self().Stop()
 
@@ -266,38 +481,91 @@ func (c *command) receiveSchedulerMsg(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case sproto.ResourcesAllocated:
 		// Ignore this message if the command has exited.
-		if c.task == nil || msg.ID != c.task.ID {
+		if c.task == nil || msg.ID != c.task.ID || c.exitStatus != nil {
 			ctx.Log().Info("ignoring resource allocation since the command has exited.")
 			return nil
 		}
 
-		check.Panic(check.Equal(len(msg.Allocations), 1,
-			"Command should only receive an allocation of one container"))
+		check.Panic(check.Equal(len(msg.Allocations), c.clusterWorkers+1,
+			"Command should receive an allocation of one container and one for each worker"))
+		c.trace.Allocated(msg.ResourcePool, len(msg.Allocations))
+		c.record.ResourcePool = msg.ResourcePool
+
+		if c.task.Reattach != nil {
+			c.allocation = msg.Allocations[0]
+			c.startMaxRuntime(ctx)
+			ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), AssignedEvent: &msg})
+			return nil
+		}
 
 		taskToken, err := c.db.StartTaskSession(string(c.task.ID))
 		if err != nil {
 			return errors.Wrap(err, "cannot start a new task session")
 		}
+		taskCert, taskKey, err := c.ca.IssueTaskCert(string(c.task.ID))
+		if err != nil {
+			return err
+		}
+
+		registryCredentials, err := c.db.RegistryCredentials(c.owner.ID)
+		if err != nil {
+			return err
+		}
+		secrets, err := c.db.SecretValues(c.owner.ID)
+		if err != nil {
+			return err
+		}
+		if err = c.fetchVaultSecrets(); err != nil {
+			return err
+		}
+		userFiles, err := c.contextFiles(context.Background())
+		if err != nil {
+			return err
+		}
 
 		c.allocation = msg.Allocations[0]
+		c.workers = msg.Allocations[1:]
+		c.taskNetwork = nil
+		if c.taskSpec.TaskContainerDefaults.TaskNetworkDriver != "" {
+			c.taskNetwork = msg.Network
+		}
 
 		taskSpec := *c.taskSpec
+		if c.taskSpec.AgentPool {
+			taskSpec.ContextArtifact = c.contextArtifact
+		}
 		taskSpec.AgentUserGroup = c.agentUserGroup
+		taskSpec.RegistryCredentials = registryCredentials
+		taskSpec.Secrets = secrets
+		taskSpec.VaultSecrets = c.vaultGrant.Values()
 		taskSpec.TaskToken = taskToken
+		taskSpec.TaskCert, taskSpec.TaskKey = taskCert, taskKey
+		if len(c.workers) > 0 {
+			workerSpec := taskSpec
+			c.workerSpec = &workerSpec
+			c.workerFiles = userFiles
+		}
 		taskSpec.SetInner(&tasks.StartCommand{
 			Config:          c.config,
-			UserFiles:       c.userFiles,
+			UserFiles:       userFiles,
 			AdditionalFiles: c.additionalFiles,
 		})
 		msg.Allocations[0].Start(ctx, taskSpec)
+		c.allocatedTime = time.Now()
+		c.startStartupTimeout(ctx)
+		c.startMaxRuntime(ctx)
 
 		ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), AssignedEvent: &msg})
 
 		// Evict the context from memory after starting the command as it is no longer needed. We
-		// evict as soon as possible to prevent the master from hitting an OOM.
-		// TODO: Consider not storing the userFiles in memory at all.
+		// evict as soon as possible to prevent the master from hitting an OOM. Contexts are usually
+		// stored as artifacts instead, which agents download themselves. The workers of a cluster
+		// start with the additional files once its head runs.
 		c.userFiles = nil
-		c.additionalFiles = nil
+		if len(c.workers) == 0 {
+			c.additionalFiles = nil
+		}
+		c.persistAllocation(ctx)
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
@@ -314,11 +582,115 @@ func (c *command) terminate(ctx *actor.Context) {
 	}
 
 	if c.allocation == nil {
+		if c.exitStatus == nil {
+			countLifecycleEvent(ctx, abortedEvent)
+		}
 		c.exit(ctx, "task is aborted without being scheduled")
 	} else {
 		ctx.Log().Info("task forcible terminating")
-		c.allocation.Kill(ctx)
+		c.killContainers(ctx)
+	}
+}
+
+// contextFiles returns the files of the context of the command that the master sends to its
+// container. They are read from its context artifact if it has one, unless the agent downloads it.
+func (c *command) contextFiles(ctx context.Context) (archive.Archive, error) {
+	if c.contextArtifact == "" {
+		return c.userFiles, nil
+	}
+	if c.taskSpec.AgentPool {
+		return nil, nil
+	}
+	if c.artifacts == nil {
+		return nil, errors.Errorf(
+			"cannot read context artifact %s: the master does not store artifacts", c.contextArtifact)
+	}
+	data, err := c.artifacts.ReadAll(ctx, c.contextArtifact)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read context artifact %s", c.contextArtifact)
+	}
+	userFiles, err := archive.FromTarGz(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot extract context artifact %s", c.contextArtifact)
+	}
+	return userFiles, nil
+}
+
+// deleteTempContext deletes the context artifact of the command if it is temporary, once the
+// container of the command has its files.
+func (c *command) deleteTempContext(ctx *actor.Context) {
+	if !c.tempContext || c.artifacts == nil {
+		return
+	}
+	// The artifact is already gone if the command reattached after the master restarted.
+	err := c.artifacts.Delete(context.Background(), c.contextArtifact)
+	if err != nil && errors.Cause(err) != db.ErrNotFound {
+		ctx.Log().WithError(err).Errorf("cannot delete context artifact %s", c.contextArtifact)
+	}
+	c.tempContext = false
+}
+
+// checkSecrets returns an error if the command references secrets that it cannot be given.
+func (c *command) checkSecrets() error {
+	envVars := c.config.Environment.EnvironmentVariables
+	if len(tasks.SecretReferences(envVars.CPU, envVars.GPU)) > 0 {
+		secrets, err := c.db.SecretNames(c.owner.ID)
+		if err != nil {
+			return err
+		}
+		var names []string
+		for _, secret := range secrets {
+			names = append(names, secret.Name)
+		}
+		if err := tasks.CheckSecretReferences(names, envVars.CPU, envVars.GPU); err != nil {
+			return err
+		}
+	}
+	if len(tasks.VaultReferences(envVars.CPU, envVars.GPU)) == 0 {
+		return nil
+	}
+	workspace, err := c.workspace()
+	if err != nil {
+		return err
+	}
+	return c.vault.Check(workspace.Name, envVars.CPU, envVars.GPU)
+}
+
+// checkBindMounts returns an error if the policy forbids any host path that the command mounts.
+func (c *command) checkBindMounts(policy model.BindMountPolicy) error {
+	taskSpec := *c.taskSpec
+	taskSpec.SetInner(&tasks.StartCommand{Config: c.config})
+	return policy.CheckHostPaths(taskSpec.HostPaths()...)
+}
+
+// fetchVaultSecrets fetches the Vault secrets that the command's environment variables reference,
+// releasing those fetched for an earlier allocation.
+func (c *command) fetchVaultSecrets() error {
+	c.vaultGrant.Release()
+	c.vaultGrant = nil
+	envVars := c.config.Environment.EnvironmentVariables
+	if len(tasks.VaultReferences(envVars.CPU, envVars.GPU)) == 0 {
+		return nil
+	}
+	workspace, err := c.workspace()
+	if err != nil {
+		return err
 	}
+	c.vaultGrant, err = c.vault.Fetch(workspace.Name, envVars.CPU, envVars.GPU)
+	return err
+}
+
+// workspace returns the workspace of the command's project.
+func (c *command) workspace() (*model.Workspace, error) {
+	projectID := c.projectID
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	workspace, err := c.db.WorkspaceByProjectID(projectID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching the workspace of project %d", projectID)
+	}
+	return workspace, nil
 }
 
 // exit handles the following cases of command exiting:
@@ -326,7 +698,18 @@ func (c *command) terminate(ctx *actor.Context) {
 // 2. Forcible terminating a command by killing containers.
 // 3. The command container exits itself.
 func (c *command) exit(ctx *actor.Context, exitStatus string) {
+	if c.exitStatus == nil {
+		c.exitTime = time.Now()
+	}
 	c.exitStatus = &exitStatus
+	c.unregisterProxies(ctx)
+	// A restored command that exits before it reattaches never got its container back.
+	if c.allocation == nil {
+		c.container = nil
+	}
+	ctx.Tell(ctx.Self().Parent(), c.listEntry(ctx))
+	c.transitionRecord(ctx, model.TaskStateTerminated)
+	c.trace.End(nil)
 	ctx.Tell(c.eventStream, event{Snapshot: newSummary(c), ExitedEvent: c.exitStatus})
 
 	ctx.Tell(
@@ -335,6 +718,12 @@ func (c *command) exit(ctx *actor.Context, exitStatus string) {
 	)
 	actors.NotifyAfter(ctx, terminatedDuration, terminateForGC{})
 
+	c.vaultGrant.Release()
+	c.vaultGrant = nil
+
+	if c.allocation != nil {
+		c.deleteAllocation(ctx, c.allocation.Summary().ID)
+	}
 	if c.task != nil {
 		if err := c.db.DeleteTaskSessionByTaskID(string(c.task.ID)); err != nil {
 			ctx.Log().WithError(err).Error("cannot delete task session for a command")
@@ -342,6 +731,35 @@ func (c *command) exit(ctx *actor.Context, exitStatus string) {
 	}
 }
 
+// listEntry returns what the command's manager lists it by.
+func (c *command) listEntry(ctx *actor.Context) listEntry {
+	return listEntry{
+		ref:         ctx.Self(),
+		id:          ctx.Self().Address().Local(),
+		description: c.config.Description,
+		startTime:   c.registeredTime,
+		username:    c.owner.Username,
+		projectID:   int32(c.projectID),
+		endTime:     c.exitTime,
+		archived:    c.archived,
+	}
+}
+
+// setArchived archives or unarchives the command and responds with resp. Only commands that have
+// exited can be archived.
+func (c *command) setArchived(ctx *actor.Context, archived bool, resp actor.Message) {
+	if archived && c.exitStatus == nil {
+		ctx.Respond(status.Errorf(codes.FailedPrecondition,
+			"cannot archive task %s before it exits", ctx.Self().Address().Local()))
+		return
+	}
+	if c.archived != archived {
+		c.archived = archived
+		ctx.Tell(ctx.Self().Parent(), c.listEntry(ctx))
+	}
+	ctx.Respond(resp)
+}
+
 func (c *command) readinessChecksPass(ctx *actor.Context, log sproto.ContainerLog) bool {
 	for name, check := range c.readinessChecks {
 		if check(log) {
@@ -354,26 +772,75 @@ func (c *command) readinessChecksPass(ctx *actor.Context, log sproto.ContainerLo
 
 // State returns the command's state. This mirros the associated container's state
 // if available.
-func (c *command) State() State {
-	state := Pending
-	switch {
-	case c.container != nil:
-		switch c.container.State {
-		case container.Assigned:
-			state = Assigned
-		case container.Pulling:
-			state = Pulling
-		case container.Starting:
-			state = Starting
-		case container.Running:
-			state = Running
-		case container.Terminated:
-			state = Terminated
-		}
-	case c.exitStatus != nil:
-		state = Terminated
-	}
-	return state
+func (c *command) State() model.TaskState {
+	if c.container == nil && c.exitStatus != nil {
+		return model.TaskStateTerminated
+	}
+	return model.TaskStateOf(c.container)
+}
+
+// addRecord adds the row of the command to the tasks table.
+func (c *command) addRecord(ctx *actor.Context) {
+	taskType := c.taskType
+	if taskType == "" {
+		taskType = taskTypes[ctx.Self().Parent().Address().Local()]
+	}
+	c.record = &model.Task{
+		TaskID:       string(c.taskID),
+		TaskType:     taskType,
+		State:        model.TaskStatePending,
+		Description:  c.config.Description,
+		OwnerID:      &c.owner.ID,
+		ResourcePool: c.config.Resources.ResourcePool,
+		Slots:        c.config.Resources.Slots,
+		StartTime:    c.registeredTime.UTC(),
+	}
+	if c.projectID != 0 {
+		c.record.ProjectID = &c.projectID
+	}
+	if err := c.db.AddTask(c.record); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the task")
+		return
+	}
+	if err := c.db.AddTaskDatasets(c.record.TaskID, c.config.Datasets); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the datasets of the task")
+	}
+}
+
+// transitionRecord moves the row of the command in the tasks table to a new state.
+func (c *command) transitionRecord(ctx *actor.Context, state model.TaskState) {
+	if err := c.db.TransitionTask(c.record, state); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the state of the task")
+	}
+}
+
+// IntrospectState implements the actor.Introspectable interface.
+func (c *command) IntrospectState() interface{} {
+	return struct {
+		TaskID               sproto.TaskID        `json:"task_id"`
+		Description          string               `json:"description"`
+		State                model.TaskState      `json:"state"`
+		RegisteredTime       time.Time            `json:"registered_time"`
+		Allocated            bool                 `json:"allocated"`
+		Container            *container.Container `json:"container"`
+		Addresses            []container.Address  `json:"addresses"`
+		ProxyNames           []string             `json:"proxy_names"`
+		ReadinessMessageSent bool                 `json:"readiness_message_sent"`
+		ReportedStatus       *reportedStatus      `json:"reported_status"`
+		ExitStatus           *string              `json:"exit_status"`
+	}{
+		TaskID:               c.taskID,
+		Description:          c.config.Description,
+		State:                c.State(),
+		RegisteredTime:       c.registeredTime,
+		Allocated:            c.allocation != nil,
+		Container:            c.container,
+		Addresses:            c.addresses,
+		ProxyNames:           c.proxyNames,
+		ReadinessMessageSent: c.readinessMessageSent,
+		ReportedStatus:       c.reportedStatus,
+		ExitStatus:           c.exitStatus,
+	}
 }
 
 func (c *command) toNotebook(ctx *actor.Context) (*notebookv1.Notebook, error) {
@@ -386,6 +853,10 @@ func (c *command) toNotebook(ctx *actor.Context) (*notebookv1.Notebook, error) {
 	if c.exitStatus != nil {
 		exitStatus = *c.exitStatus
 	}
+	var lastActivity *timestamp.Timestamp
+	if c.kernels != nil {
+		lastActivity = protoutils.ToTimestamp(c.kernels.LastActivity)
+	}
 
 	return &notebookv1.Notebook{
 		Id:             ctx.Self().Address().Local(),
@@ -396,7 +867,13 @@ func (c *command) toNotebook(ctx *actor.Context) (*notebookv1.Notebook, error) {
 		StartTime:      protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
 		Username:       c.owner.Username,
 		ResourcePool:   c.config.Resources.ResourcePool,
+		ProjectId:      int32(c.projectID),
 		ExitStatus:     exitStatus,
+		ReportedStatus: c.reportedStatus.Proto(),
+		ResourceUsage:  c.resourceUsage.Proto(),
+		Archived:       c.archived,
+		LastActivity:   lastActivity,
+		KernelsBusy:    c.kernels != nil && c.kernels.Busy,
 	}, nil
 }
 
@@ -407,14 +884,18 @@ func (c *command) toCommand(ctx *actor.Context) *commandv1.Command {
 	}
 
 	return &commandv1.Command{
-		Id:           ctx.Self().Address().Local(),
-		State:        c.State().Proto(),
-		Description:  c.config.Description,
-		Container:    c.container.Proto(),
-		StartTime:    protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
-		Username:     c.owner.Username,
-		ResourcePool: c.config.Resources.ResourcePool,
-		ExitStatus:   exitStatus,
+		Id:             ctx.Self().Address().Local(),
+		State:          c.State().Proto(),
+		Description:    c.config.Description,
+		Container:      c.container.Proto(),
+		StartTime:      protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		Username:       c.owner.Username,
+		ResourcePool:   c.config.Resources.ResourcePool,
+		ProjectId:      int32(c.projectID),
+		ExitStatus:     exitStatus,
+		ReportedStatus: c.reportedStatus.Proto(),
+		ResourceUsage:  c.resourceUsage.Proto(),
+		Archived:       c.archived,
 	}
 }
 
@@ -428,6 +909,10 @@ func (c *command) toShell(ctx *actor.Context) *shellv1.Shell {
 	for _, addr := range c.addresses {
 		addresses = append(addresses, protoutils.ToStruct(addr))
 	}
+	forwardedPorts := make([]int32, 0, len(c.forwardedPorts))
+	for _, port := range c.forwardedPorts {
+		forwardedPorts = append(forwardedPorts, int32(port))
+	}
 
 	return &shellv1.Shell{
 		Id:             ctx.Self().Address().Local(),
@@ -439,9 +924,13 @@ func (c *command) toShell(ctx *actor.Context) *shellv1.Shell {
 		PublicKey:      c.metadata["publicKey"].(string),
 		Username:       c.owner.Username,
 		ResourcePool:   c.config.Resources.ResourcePool,
+		ProjectId:      int32(c.projectID),
 		ExitStatus:     exitStatus,
 		Addresses:      addresses,
 		AgentUserGroup: protoutils.ToStruct(c.agentUserGroup),
+		ReportedStatus: c.reportedStatus.Proto(),
+		ResourceUsage:  c.resourceUsage.Proto(),
+		ForwardedPorts: forwardedPorts,
 	}
 }
 
@@ -459,6 +948,8 @@ func (c *command) toTensorboard(ctx *actor.Context) *tensorboardv1.Tensorboard {
 	for _, id := range c.metadata["trial_ids"].([]int) {
 		tids = append(tids, int32(id))
 	}
+	// TensorBoards from before log paths have none.
+	logPaths, _ := c.metadata["log_paths"].([]string)
 	return &tensorboardv1.Tensorboard{
 		Id:             ctx.Self().Address().Local(),
 		State:          c.State().Proto(),
@@ -468,9 +959,68 @@ func (c *command) toTensorboard(ctx *actor.Context) *tensorboardv1.Tensorboard {
 		ServiceAddress: fmt.Sprintf(tensorboardServiceAddress, c.taskID),
 		ExperimentIds:  eids,
 		TrialIds:       tids,
+		LogPaths:       logPaths,
+		Username:       c.owner.Username,
+		ResourcePool:   c.config.Resources.ResourcePool,
+		ProjectId:      int32(c.projectID),
+		ExitStatus:     exitStatus,
+		ReportedStatus: c.reportedStatus.Proto(),
+		ResourceUsage:  c.resourceUsage.Proto(),
+		Archived:       c.archived,
+	}
+}
+
+func (c *command) toCodeServer(ctx *actor.Context) *codeserverv1.CodeServer {
+	exitStatus := protoutils.DefaultStringValue
+	if c.exitStatus != nil {
+		exitStatus = *c.exitStatus
+	}
+
+	return &codeserverv1.CodeServer{
+		Id:             ctx.Self().Address().Local(),
+		State:          c.State().Proto(),
+		Description:    c.config.Description,
+		StartTime:      protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		Container:      c.container.Proto(),
 		Username:       c.owner.Username,
+		ServiceAddress: fmt.Sprintf(codeServerServiceAddress, c.taskID),
+		Token:          c.token,
 		ResourcePool:   c.config.Resources.ResourcePool,
+		ProjectId:      int32(c.projectID),
 		ExitStatus:     exitStatus,
+		ReportedStatus: c.reportedStatus.Proto(),
+		ResourceUsage:  c.resourceUsage.Proto(),
+	}
+}
+
+func (c *command) toRayCluster(ctx *actor.Context) *rayclusterv1.RayCluster {
+	exitStatus := protoutils.DefaultStringValue
+	if c.exitStatus != nil {
+		exitStatus = *c.exitStatus
+	}
+
+	var workerContainers []*containerv1.Container
+	for _, worker := range c.workers {
+		if workerContainer := c.workerContainers[worker.Summary().ID]; workerContainer != nil {
+			workerContainers = append(workerContainers, workerContainer.Proto())
+		}
+	}
+
+	return &rayclusterv1.RayCluster{
+		Id:               ctx.Self().Address().Local(),
+		State:            c.State().Proto(),
+		Description:      c.config.Description,
+		StartTime:        protoutils.ToTimestamp(ctx.Self().RegisteredTime()),
+		Container:        c.container.Proto(),
+		Username:         c.owner.Username,
+		ServiceAddress:   fmt.Sprintf(rayClusterServiceAddress, c.taskID),
+		ResourcePool:     c.config.Resources.ResourcePool,
+		ProjectId:        int32(c.projectID),
+		ExitStatus:       exitStatus,
+		ReportedStatus:   c.reportedStatus.Proto(),
+		ResourceUsage:    c.resourceUsage.Proto(),
+		Workers:          int32(c.clusterWorkers),
+		WorkerContainers: workerContainers,
 	}
 }
 
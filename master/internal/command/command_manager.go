@@ -8,8 +8,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -25,10 +28,17 @@ import (
 var shellFormEntrypoint = []string{"/bin/sh", "-c"}
 
 type commandManager struct {
-	db *db.PgDB
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
 
 	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
 	makeTaskSpec          tasks.MakeTaskSpecFn
+
+	// tasks are the running commands, which the manager lists.
+	tasks listIndex
 }
 
 // CommandLaunchRequest describes a request to launch a new command.
@@ -37,17 +47,43 @@ type CommandLaunchRequest struct {
 }
 
 func (c *commandManager) Receive(ctx *actor.Context) error {
-	switch msg := ctx.Message().(type) {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		c.tasks = listIndex{}
+		restore(ctx, c.db, c.vault, c.artifacts, c.ca, c.makeTaskSpec)
+
+	case persistQueued:
+		persistQueuedChildren(ctx)
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		c.tasks.update(msg)
+
+	case searchTasks:
+		ctx.Respond(c.tasks.search(msg, apiv1.SearchResult_KIND_COMMAND))
+
+	case archivableTasks:
+		ctx.Respond(c.tasks.archivable(msg))
+
 	case *apiv1.GetCommandsRequest:
-		resp := &apiv1.GetCommandsResponse{}
-		users := make(map[string]bool)
-		for _, user := range msg.Users {
-			users[user] = true
+		items, pagination, next, err := c.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+			archived:  archivedFilter(msg.Archived),
+		}, &commandv1.Command{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
 		}
-		for _, command := range ctx.AskAll(&commandv1.Command{}, ctx.Children()...).GetAll() {
-			if typed := command.(*commandv1.Command); len(users) == 0 || users[typed.Username] {
-				resp.Commands = append(resp.Commands, typed)
-			}
+		resp := &apiv1.GetCommandsResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.Commands = append(resp.Commands, item.(*commandv1.Command))
 		}
 		ctx.Respond(resp)
 
@@ -75,6 +111,12 @@ func (c *commandManager) processLaunchRequest(
 	if err := check.Validate(command.config); err != nil {
 		return nil, http.StatusBadRequest, err
 	}
+	if err := command.checkBindMounts(c.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err := command.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
 	a, _ := ctx.ActorOf(command.taskID, command)
 	summaryFut := ctx.Ask(a, getSummary{})
@@ -102,16 +144,23 @@ func (c *commandManager) newCommand(params *CommandParams) *command {
 	setPodSpec(config, params.TaskSpec.TaskContainerDefaults)
 
 	return &command{
-		taskID:    sproto.NewTaskID(),
-		config:    *params.FullConfig,
-		userFiles: params.UserFiles,
+		taskID:          sproto.NewTaskID(),
+		config:          *params.FullConfig,
+		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
 		owner: commandOwner{
 			ID:       params.User.ID,
 			Username: params.User.Username,
 		},
 		agentUserGroup: params.AgentUserGroup,
 		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
 
-		db: c.db,
+		db:        c.db,
+		vault:     c.vault,
+		artifacts: c.artifacts,
+		ca:        c.ca,
 	}
 }
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+const (
+	// kernelPollInterval is how often notebooks poll the activity of their Jupyter kernels.
+	kernelPollInterval = 30 * time.Second
+	// kernelPollTimeout bounds how long a notebook waits for its Jupyter server to respond.
+	kernelPollTimeout = 5 * time.Second
+)
+
+// kernelsTick is a message telling a notebook to poll the activity of its kernels.
+type kernelsTick struct{}
+
+// kernelActivity is the activity of the Jupyter kernels of a notebook. Unlike requests through
+// the proxy, which a browser that merely has the notebook open keeps making, kernels are only
+// active while they execute code.
+type kernelActivity struct {
+	// LastActivity is when a kernel was last active, or when the notebook started running if no
+	// kernel has been active since.
+	LastActivity time.Time `json:"last_activity"`
+	// Busy is whether a kernel was executing code when the notebook last polled them.
+	Busy bool `json:"busy"`
+}
+
+// jupyterKernel is a kernel as the /api/kernels endpoint of Jupyter servers lists it.
+type jupyterKernel struct {
+	ExecutionState string    `json:"execution_state"`
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// update updates the activity with the kernels that the Jupyter server lists at the given time.
+func (k *kernelActivity) update(kernels []jupyterKernel, now time.Time) {
+	k.Busy = false
+	for _, kernel := range kernels {
+		if kernel.ExecutionState == "busy" {
+			k.Busy = true
+		}
+		if kernel.LastActivity.After(k.LastActivity) {
+			k.LastActivity = kernel.LastActivity
+		}
+	}
+	if k.Busy {
+		k.LastActivity = now
+	}
+}
+
+// idleSince returns whether no kernel has been active for the given duration.
+func (k *kernelActivity) idleSince(timeout time.Duration, now time.Time) bool {
+	return k != nil && !k.Busy && now.After(k.LastActivity.Add(timeout))
+}
+
+// fetchKernels lists the kernels of the Jupyter server that is served at baseURL.
+func fetchKernels(ctx context.Context, client *http.Client, baseURL string) (
+	[]jupyterKernel, error,
+) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"api/kernels", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("the Jupyter server responded with %s", resp.Status)
+	}
+	var kernels []jupyterKernel
+	if err := json.NewDecoder(resp.Body).Decode(&kernels); err != nil {
+		return nil, errors.Wrap(err, "invalid list of kernels")
+	}
+	return kernels, nil
+}
+
+// startPollingKernels starts to poll the kernels of a notebook once its container runs.
+func (c *command) startPollingKernels(ctx *actor.Context) {
+	if !c.pollKernels || c.kernels != nil {
+		return
+	}
+	c.kernels = &kernelActivity{LastActivity: time.Now()}
+	actors.NotifyAfter(ctx, kernelPollInterval, kernelsTick{})
+}
+
+// pollKernelActivity polls the Jupyter server of the notebook for the activity of its kernels. It
+// reaches the server at the address that the proxy forwards requests for the notebook to, under
+// the base URL of the server.
+func (c *command) pollKernelActivity(ctx *actor.Context) {
+	if c.exitStatus != nil || c.container == nil || c.container.State != container.Running ||
+		len(c.addresses) == 0 {
+		return
+	}
+	defer actors.NotifyAfter(ctx, kernelPollInterval, kernelsTick{})
+
+	address := c.addresses[0]
+	baseURL := fmt.Sprintf("http://%s:%d/proxy/%s/", address.HostIP, address.HostPort, c.taskID)
+	pollCtx, cancel := context.WithTimeout(context.Background(), kernelPollTimeout)
+	defer cancel()
+	kernels, err := fetchKernels(pollCtx, http.DefaultClient, baseURL)
+	if err != nil {
+		ctx.Log().WithError(err).Debug("cannot poll the activity of the kernels")
+		return
+	}
+	c.kernels.update(kernels, time.Now())
+}
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestKernelActivity(t *testing.T) {
+	start := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	activity := &kernelActivity{LastActivity: start}
+
+	// A browser that has the notebook open does not make its kernels active.
+	activity.update([]jupyterKernel{
+		{ExecutionState: "idle", LastActivity: start.Add(-time.Hour)},
+	}, start.Add(time.Minute))
+	assert.Equal(t, activity.LastActivity, start)
+	assert.Assert(t, activity.idleSince(30*time.Minute, start.Add(time.Hour)))
+
+	activity.update([]jupyterKernel{
+		{ExecutionState: "idle", LastActivity: start.Add(10 * time.Minute)},
+		{ExecutionState: "busy", LastActivity: start.Add(20 * time.Minute)},
+	}, start.Add(2*time.Hour))
+	assert.Assert(t, activity.Busy)
+	assert.Equal(t, activity.LastActivity, start.Add(2*time.Hour))
+	assert.Assert(t, !activity.idleSince(0, start.Add(3*time.Hour)))
+
+	activity.update(nil, start.Add(3*time.Hour))
+	assert.Assert(t, !activity.Busy)
+	assert.Assert(t, activity.idleSince(30*time.Minute, start.Add(3*time.Hour)))
+
+	var none *kernelActivity
+	assert.Assert(t, !none.idleSince(0, start))
+}
+
+func TestFetchKernels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/task/api/kernels" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write([]byte(`[{"id": "k", "name": "python3", "execution_state": "busy",
+			"last_activity": "2021-07-01T12:00:00.123456Z", "connections": 1}]`))
+		assert.NilError(t, err)
+	}))
+	defer server.Close()
+
+	kernels, err := fetchKernels(context.Background(), server.Client(), server.URL+"/proxy/task/")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, kernels, []jupyterKernel{{
+		ExecutionState: "busy",
+		LastActivity:   time.Date(2021, 7, 1, 12, 0, 0, 123456000, time.UTC),
+	}})
+
+	_, err = fetchKernels(context.Background(), server.Client(), server.URL+"/proxy/other/")
+	assert.ErrorContains(t, err, "404")
+}
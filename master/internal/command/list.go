@@ -0,0 +1,227 @@
+package command
+
+import (
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// The keys that commands are sorted by besides their IDs, which are the values of the SortBy enums
+// of the requests to list commands, notebooks, shells and TensorBoards.
+const (
+	sortByDescription = 2
+	sortByStartTime   = 4
+)
+
+// listEntry is a message that a command tells its manager when it starts, exits and is archived
+// or unarchived, with what the manager filters and sorts its commands by. The manager lists its
+// commands from these, so that it only asks the commands on the requested page for their state.
+type listEntry struct {
+	ref         *actor.Ref
+	id          string
+	description string
+	startTime   time.Time
+	username    string
+	projectID   int32
+	// endTime is when the command exited, or zero if it has not.
+	endTime  time.Time
+	archived bool
+}
+
+// key returns the sort key of the entry as text.
+func (e listEntry) key(sortBy int32) *string {
+	var key string
+	switch sortBy {
+	case sortByDescription:
+		key = e.description
+	case sortByStartTime:
+		key = e.startTime.UTC().Format(time.RFC3339Nano)
+	default:
+		return nil
+	}
+	return &key
+}
+
+// before returns whether the entry is ordered before another, by the sort key and then by ID.
+func (e listEntry) before(other listEntry, sortBy int32, orderBy apiv1.OrderBy) bool {
+	if orderBy == apiv1.OrderBy_ORDER_BY_DESC {
+		e, other = other, e
+	}
+	switch {
+	case sortBy == sortByDescription && e.description != other.description:
+		return e.description < other.description
+	case sortBy == sortByStartTime && !e.startTime.Equal(other.startTime):
+		return e.startTime.Before(other.startTime)
+	}
+	return e.id < other.id
+}
+
+// listRequest is what the requests to list commands, notebooks, shells and TensorBoards have in
+// common.
+type listRequest struct {
+	sortBy    int32
+	orderBy   apiv1.OrderBy
+	offset    int32
+	limit     int32
+	cursor    string
+	users     []string
+	projectID int32
+	// projects limits the commands to those in the given projects, unless it is nil.
+	projects map[int32]bool
+	// archived limits the commands to those that are archived or not. Archived commands are
+	// hidden if it is nil.
+	archived *bool
+}
+
+// InProjects asks a manager for what Request asks for among only its commands in the given
+// projects, for callers that may only see some projects.
+type InProjects struct {
+	Request  actor.Message
+	Projects map[int32]bool
+}
+
+// unwrapInProjects returns the request of a message and the projects that it is limited to, or
+// nil if it is not limited.
+func unwrapInProjects(msg actor.Message) (actor.Message, map[int32]bool) {
+	if m, ok := msg.(InProjects); ok {
+		return m.Request, m.Projects
+	}
+	return msg, nil
+}
+
+// inProjects returns true if the entry is in one of the projects, or if projects is nil.
+func (e listEntry) inProjects(projects map[int32]bool) bool {
+	if projects == nil {
+		return true
+	}
+	projectID := e.projectID
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	return projects[projectID]
+}
+
+// archivedFilter returns the value of an archived filter of a request, or nil if it is unset.
+func archivedFilter(archived *wrapperspb.BoolValue) *bool {
+	if archived == nil {
+		return nil
+	}
+	return &archived.Value
+}
+
+// listIndex holds the list entries of the running commands of a manager.
+type listIndex map[*actor.Ref]listEntry
+
+// update adds the commands that start to the index and removes those that stop.
+func (idx listIndex) update(msg actor.Message) {
+	switch msg := msg.(type) {
+	case listEntry:
+		idx[msg.ref] = msg
+	case actor.ChildStopped:
+		delete(idx, msg.Child)
+	case actor.ChildFailed:
+		delete(idx, msg.Child)
+	}
+}
+
+// page filters and sorts the commands of the index and asks those on the requested page for msg.
+// It returns their responses in order, the pagination and the cursor of the next page, which is
+// empty on the last page.
+func (idx listIndex) page(
+	ctx *actor.Context, req listRequest, msg actor.Message,
+) ([]actor.Message, *apiv1.Pagination, string, error) {
+	cursor, err := api.DecodeCursor(req.cursor)
+	switch {
+	case err != nil:
+		return nil, nil, "", status.Error(codes.InvalidArgument, err.Error())
+	case cursor != nil && req.offset != 0:
+		return nil, nil, "", status.Error(codes.InvalidArgument,
+			"an offset cannot be combined with a cursor")
+	}
+
+	users := make(map[string]bool)
+	for _, user := range req.users {
+		users[user] = true
+	}
+	var entries []listEntry
+	for _, e := range idx {
+		if (len(users) == 0 || users[e.username]) &&
+			(req.projectID == 0 || e.projectID == req.projectID) &&
+			e.inProjects(req.projects) &&
+			((req.archived == nil && !e.archived) ||
+				(req.archived != nil && e.archived == *req.archived)) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].before(entries[j], req.sortBy, req.orderBy)
+	})
+
+	offset := int(req.offset)
+	if cursor != nil {
+		last, err := cursorEntry(cursor, req.sortBy)
+		if err != nil {
+			return nil, nil, "", status.Error(codes.InvalidArgument, err.Error())
+		}
+		offset = sort.Search(len(entries), func(i int) bool {
+			return last.before(entries[i], req.sortBy, req.orderBy)
+		})
+	}
+	p, err := api.Paginate(len(entries), offset, int(req.limit))
+	if err != nil {
+		return nil, nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	entries, remaining := entries[p.StartIndex:p.EndIndex], len(entries)-p.EndIndex
+
+	refs := make([]*actor.Ref, 0, len(entries))
+	for _, e := range entries {
+		refs = append(refs, e.ref)
+	}
+	resps := ctx.AskAll(msg, refs...).GetAll()
+	results := make([]actor.Message, 0, len(entries))
+	for _, e := range entries {
+		// Commands that stopped since they were listed do not respond.
+		if resp, ok := resps[e.ref]; ok && resp != nil {
+			results = append(results, resp)
+		}
+	}
+
+	var next string
+	if remaining > 0 && len(entries) > 0 {
+		last := entries[len(entries)-1]
+		next = api.Cursor{Key: last.key(req.sortBy), ID: last.id}.Encode()
+	}
+	return results, &apiv1.Pagination{
+		Offset:     req.offset,
+		Limit:      req.limit,
+		StartIndex: int32(p.StartIndex),
+		EndIndex:   int32(p.EndIndex),
+		Total:      int32(p.EndIndex + remaining),
+	}, next, nil
+}
+
+// cursorEntry returns an entry with the sort key and ID of the last entry of the previous page.
+func cursorEntry(cursor *api.Cursor, sortBy int32) (listEntry, error) {
+	e := listEntry{id: cursor.ID}
+	if cursor.Key != nil {
+		switch sortBy {
+		case sortByDescription:
+			e.description = *cursor.Key
+		case sortByStartTime:
+			startTime, err := time.Parse(time.RFC3339Nano, *cursor.Key)
+			if err != nil {
+				return e, err
+			}
+			e.startTime = startTime
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+type getListTestID struct{}
+
+// listTestTask is a task that lists itself with its manager and responds with its ID.
+type listTestTask struct {
+	description string
+}
+
+func (l *listTestTask) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		ctx.Tell(ctx.Self().Parent(), listEntry{
+			ref:         ctx.Self(),
+			id:          ctx.Self().Address().Local(),
+			description: l.description,
+			startTime:   ctx.Self().RegisteredTime(),
+		})
+	case getListTestID:
+		ctx.Respond(ctx.Self().Address().Local())
+	}
+	return nil
+}
+
+// listTestManager is a manager of tasks that responds to list requests with a page of their IDs.
+type listTestManager struct {
+	tasks listIndex
+}
+
+type listTestPage struct {
+	ids        []string
+	pagination *apiv1.Pagination
+	next       string
+}
+
+func (l *listTestManager) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		l.tasks = listIndex{}
+		for i := 0; i < 5; i++ {
+			// Tasks 0 and 1 share a description, so they are ordered by ID.
+			ctx.ActorOf(fmt.Sprintf("task-%d", i), &listTestTask{
+				description: fmt.Sprintf("description-%d", (5-i)/2),
+			})
+		}
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		l.tasks.update(msg)
+	case listRequest:
+		items, pagination, next, err := l.tasks.page(ctx, msg, getListTestID{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		page := listTestPage{pagination: pagination, next: next}
+		for _, item := range items {
+			page.ids = append(page.ids, item.(string))
+		}
+		ctx.Respond(page)
+	}
+	return nil
+}
+
+func TestListIndexPage(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	manager, _ := system.ActorOf(actor.Addr("manager"), &listTestManager{})
+	list := func(req listRequest) listTestPage {
+		resp := system.Ask(manager, req)
+		assert.NilError(t, resp.Error())
+		return resp.Get().(listTestPage)
+	}
+	for list(listRequest{}).pagination.Total < 5 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Following the cursors visits every task once in order.
+	req := listRequest{sortBy: sortByDescription, orderBy: apiv1.OrderBy_ORDER_BY_DESC, limit: 2}
+	var ids []string
+	for {
+		page := list(req)
+		assert.Equal(t, page.pagination.StartIndex, int32(len(ids)))
+		ids = append(ids, page.ids...)
+		if page.next == "" {
+			break
+		}
+		req.cursor = page.next
+	}
+	assert.DeepEqual(t, ids, []string{"task-1", "task-0", "task-3", "task-2", "task-4"})
+
+	// The next page stays consistent when tasks on the previous pages stop.
+	req = listRequest{sortBy: sortByStartTime, limit: 2}
+	page := list(req)
+	assert.DeepEqual(t, page.ids, []string{"task-0", "task-1"})
+	assert.NilError(t, system.Get(manager.Address().Child("task-0")).StopAndAwaitTermination())
+	req.cursor = page.next
+	for list(listRequest{}).pagination.Total > 4 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.DeepEqual(t, list(req).ids, []string{"task-2", "task-3"})
+
+	// An offset cannot be combined with a cursor.
+	req.offset = 1
+	assert.ErrorContains(t, system.Ask(manager, req).Error(), "cursor")
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// maxRuntimeExceeded tells a command that its container ran for its max runtime.
+type maxRuntimeExceeded struct {
+	containerID container.ID
+}
+
+// startMaxRuntime has the command kill its containers once its max runtime passed since its
+// resources were allocated, if it has one. Commands that were restored after the master restarted
+// count from their original allocation.
+func (c *command) startMaxRuntime(ctx *actor.Context) {
+	if c.config.MaxRuntime == 0 {
+		return
+	}
+	deadline := c.allocatedTime.Add(time.Duration(c.config.MaxRuntime) * time.Second)
+	actors.NotifyAfter(ctx, time.Until(deadline),
+		maxRuntimeExceeded{containerID: c.allocation.Summary().ID})
+}
+
+// checkMaxRuntime kills the containers of the command once its max runtime passed, unless it
+// exited or was killed already.
+func (c *command) checkMaxRuntime(ctx *actor.Context, msg maxRuntimeExceeded) {
+	if c.exitStatus != nil || c.killed || c.allocation == nil ||
+		c.allocation.Summary().ID != msg.containerID {
+		return
+	}
+	ctx.Log().Info(c.maxRuntimeExitStatus())
+	c.timedOut = true
+	c.killContainers(ctx)
+}
+
+// maxRuntimeExitStatus is the exit status of a command that was killed once its max runtime
+// passed.
+func (c *command) maxRuntimeExitStatus() string {
+	return fmt.Sprintf("TIMEOUT: the task exceeded its max_runtime of %d seconds",
+		c.config.MaxRuntime)
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// The lifecycle events of commands that are counted.
+const (
+	createdEvent   = "created"
+	startedEvent   = "started"
+	succeededEvent = "succeeded"
+	failedEvent    = "failed"
+	abortedEvent   = "aborted"
+	timedOutEvent  = "timed_out"
+)
+
+type lifecycleKey struct {
+	kind  string
+	event string
+}
+
+var (
+	lifecycleLock   sync.Mutex
+	lifecycleCounts = make(map[lifecycleKey]int)
+)
+
+// countLifecycleEvent counts an event in the lifecycle of a command. The kind of the command, e.g.
+// "notebook", is named after the manager that created it.
+func countLifecycleEvent(ctx *actor.Context, event string) {
+	kind := strings.TrimSuffix(ctx.Self().Parent().Address().Local(), "s")
+	lifecycleLock.Lock()
+	defer lifecycleLock.Unlock()
+	lifecycleCounts[lifecycleKey{kind: kind, event: event}]++
+}
+
+// lifecycleCollector reports the lifecycle events of commands, notebooks, shells and TensorBoards.
+func lifecycleCollector() []prom.Metric {
+	lifecycleLock.Lock()
+	defer lifecycleLock.Unlock()
+	events := prom.Metric{
+		Name: "det_command_lifecycle_events_total",
+		Help: "Number of commands, notebooks, shells and TensorBoards that reached each stage of " +
+			"their lifecycle.",
+		Type: prom.Counter,
+	}
+	for key, n := range lifecycleCounts {
+		events.Samples = append(events.Samples, prom.Sample{
+			Labels: map[string]string{"kind": key.kind, "event": key.event},
+			Value:  float64(n),
+		})
+	}
+	return []prom.Metric{events}
+}
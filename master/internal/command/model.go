@@ -1,6 +1,7 @@
 package command
 
 import (
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/tasks"
@@ -8,10 +9,19 @@ import (
 
 // CommandParams describes parameters for launching a command.
 type CommandParams struct {
-	UserFiles      archive.Archive
+	UserFiles archive.Archive
+	// ContextArtifact is the ID of an uploaded context artifact that the command runs with instead
+	// of UserFiles.
+	ContextArtifact string
+	// TempContext is true if the context artifact was stored from the files of the launch request,
+	// in which case the command deletes it once its container started or it exited.
+	TempContext    bool
 	Data           map[string]interface{}
 	FullConfig     *model.CommandConfig
 	TaskSpec       *tasks.TaskSpec
 	User           *model.User
 	AgentUserGroup *model.AgentUserGroup
+	ProjectID      int
+	// SpanContext is the span of the request that launched the command.
+	SpanContext tracing.SpanContext
 }
@@ -8,16 +8,22 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/etc"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/tasks"
@@ -31,7 +37,8 @@ const (
 	jupyterDataDir    = "/run/determined/jupyter/data"
 	jupyterRuntimeDir = "/run/determined/jupyter/runtime"
 	jupyterEntrypoint = "/run/determined/jupyter/notebook-entrypoint.sh"
-	// Agent ports 2600 - 3500 are split between TensorBoards, Notebooks, and Shells.
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
 	minNotebookPort     = 2900
 	maxNotebookPort     = minNotebookPort + 299
 	notebookConfigFile  = "/run/determined/workdir/jupyter-conf.py"
@@ -84,12 +91,24 @@ c.NotebookApp.token          = ""
 }
 
 type notebookManager struct {
-	db *db.PgDB
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
 
 	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
 	makeTaskSpec          tasks.MakeTaskSpecFn
+	// timeout is how long the kernels of a notebook may be idle before it is terminated, or zero
+	// if notebooks are not terminated when idle.
+	timeout time.Duration
+
+	// tasks are the running notebooks, which the manager lists.
+	tasks listIndex
 }
 
+type notebookTick struct{}
+
 // NotebookLaunchRequest describes a request to launch a new notebook.
 type NotebookLaunchRequest struct {
 	CommandParams *CommandParams
@@ -109,6 +128,12 @@ func (n *notebookManager) processLaunchRequest(
 	if err = check.Validate(notebook.config); err != nil {
 		return nil, http.StatusBadRequest, err
 	}
+	if err = notebook.checkBindMounts(n.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err = notebook.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
 	a, _ := ctx.ActorOf(notebook.taskID, notebook)
 	summaryFut := ctx.Ask(a, getSummary{})
@@ -121,17 +146,59 @@ func (n *notebookManager) processLaunchRequest(
 }
 
 func (n *notebookManager) Receive(ctx *actor.Context) error {
-	switch msg := ctx.Message().(type) {
-	case *apiv1.GetNotebooksRequest:
-		resp := &apiv1.GetNotebooksResponse{}
-		users := make(map[string]bool)
-		for _, user := range msg.Users {
-			users[user] = true
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		n.tasks = listIndex{}
+		restore(ctx, n.db, n.vault, n.artifacts, n.ca, n.makeTaskSpec)
+		if n.timeout > 0 {
+			actors.NotifyAfter(ctx, tickInterval, notebookTick{})
 		}
-		for _, notebook := range ctx.AskAll(&notebookv1.Notebook{}, ctx.Children()...).GetAll() {
-			if typed := notebook.(*notebookv1.Notebook); len(users) == 0 || users[typed.Username] {
-				resp.Notebooks = append(resp.Notebooks, typed)
+
+	case notebookTick:
+		now := time.Now()
+		for _, ref := range ctx.Children() {
+			notebook, ok := ctx.Ask(ref, getSummary{}).Get().(summary)
+			if !ok || notebook.State != container.Running.String() ||
+				!notebook.Kernels.idleSince(n.timeout, now) {
+				continue
 			}
+			ctx.Log().Infof("killing %s since its kernels are idle", notebook.Config.Description)
+			ctx.Ask(ref, &apiv1.KillNotebookRequest{})
+		}
+		actors.NotifyAfter(ctx, tickInterval, notebookTick{})
+
+	case persistQueued:
+		persistQueuedChildren(ctx)
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		n.tasks.update(msg)
+
+	case searchTasks:
+		ctx.Respond(n.tasks.search(msg, apiv1.SearchResult_KIND_NOTEBOOK))
+
+	case archivableTasks:
+		ctx.Respond(n.tasks.archivable(msg))
+
+	case *apiv1.GetNotebooksRequest:
+		items, pagination, next, err := n.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+			archived:  archivedFilter(msg.Archived),
+		}, &notebookv1.Notebook{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		resp := &apiv1.GetNotebooksResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.Notebooks = append(resp.Notebooks, item.(*notebookv1.Notebook))
 		}
 		ctx.Respond(resp)
 
@@ -185,9 +252,11 @@ func (n *notebookManager) newNotebook(params *CommandParams) (*command, error) {
 	}
 
 	return &command{
-		taskID:    taskID,
-		config:    *config,
-		userFiles: params.UserFiles,
+		taskID:          taskID,
+		config:          *config,
+		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
 		additionalFiles: archive.Archive{
 			params.AgentUserGroup.OwnedArchiveItem(jupyterDir, nil, 0700, tar.TypeDir),
 			params.AgentUserGroup.OwnedArchiveItem(jupyterConfigDir, nil, 0700, tar.TypeDir),
@@ -211,11 +280,10 @@ func (n *notebookManager) newNotebook(params *CommandParams) (*command, error) {
 		},
 
 		readinessChecks: map[string]readinessCheck{
-			"notebook": func(log sproto.ContainerLog) bool {
-				return jupyterReadyPattern.MatchString(log.String())
-			},
+			"notebook": readinessChecksByName["notebook"],
 		},
 		serviceAddress: &serviceAddress,
+		pollKernels:    true,
 
 		owner: commandOwner{
 			ID:       params.User.ID,
@@ -223,7 +291,12 @@ func (n *notebookManager) newNotebook(params *CommandParams) (*command, error) {
 		},
 		agentUserGroup: params.AgentUserGroup,
 		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
 
-		db: n.db,
+		db:        n.db,
+		vault:     n.vault,
+		artifacts: n.artifacts,
+		ca:        n.ca,
 	}, nil
 }
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"fmt"
+	"net/url"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// portServiceID is the ID that a forwarded port of a task is proxied as, at /proxy/<ID>/.
+func portServiceID(taskID sproto.TaskID, port int) string {
+	return fmt.Sprintf("%s:%d", taskID, port)
+}
+
+// registerProxies registers the addresses of the container of the command with the proxy, as well
+// as the ports that it forwards. They belong to the tenant of the command's workspace; if that
+// cannot be told, nothing is registered rather than exposing the command to other tenants.
+func (c *command) registerProxies(ctx *actor.Context) {
+	workspace, err := c.workspace()
+	if err != nil {
+		ctx.Log().WithError(err).Error("not proxying the command")
+		return
+	}
+	c.tenantID = workspace.TenantID
+	names := make([]string, 0, len(c.addresses))
+	for _, address := range c.addresses {
+		// We are keying on task ID instead of container ID. Revisit this when we need to
+		// proxy multi-container tasks or when containers are created prior to being
+		// assigned to an agent.
+		ctx.Ask(c.proxy, proxy.Register{
+			ServiceID: string(c.taskID),
+			URL: &url.URL{
+				Scheme: "http",
+				Host:   fmt.Sprintf("%s:%d", address.HostIP, address.HostPort),
+			},
+			ProxyTCP:  c.proxyTCP,
+			StripPath: c.stripProxyPath,
+			TenantID:  c.tenantID,
+		})
+		names = append(names, string(c.taskID))
+	}
+	c.proxyNames = names
+	c.registerPorts(ctx, c.forwardedPorts...)
+}
+
+// unregisterProxies unregisters everything that the command registered with the proxy.
+func (c *command) unregisterProxies(ctx *actor.Context) {
+	for _, name := range c.proxyNames {
+		ctx.Tell(c.proxy, proxy.Unregister{ServiceID: name})
+	}
+	c.proxyNames = make([]string, 0)
+}
+
+// registerPorts registers forwarded ports of the command with the proxy, which tunnels TCP
+// connections to them over WebSockets. Unlike the ports that the container publishes, they are
+// reached at the IP address of the container itself, which is the address of its agent if the
+// container uses the network of the host.
+func (c *command) registerPorts(ctx *actor.Context, ports ...int) {
+	if len(c.addresses) == 0 {
+		return
+	}
+	ip := c.addresses[0].ContainerIP
+	for _, port := range ports {
+		name := portServiceID(c.taskID, port)
+		ctx.Ask(c.proxy, proxy.Register{
+			ServiceID: name,
+			URL:       &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%d", ip, port)},
+			ProxyTCP:  true,
+			TenantID:  c.tenantID,
+		})
+		c.proxyNames = append(c.proxyNames, name)
+	}
+}
+
+// forwardPort starts to proxy a port of the running container of the command.
+func (c *command) forwardPort(ctx *actor.Context, port int) error {
+	switch {
+	case port < 1 || port > 65535:
+		return status.Errorf(codes.InvalidArgument, "invalid port %d", port)
+	case c.container == nil || c.container.State != container.Running || len(c.addresses) == 0:
+		return status.Errorf(codes.FailedPrecondition,
+			"cannot forward ports of task %s before it runs", c.taskID)
+	}
+	for _, p := range c.forwardedPorts {
+		if p == port {
+			return nil
+		}
+	}
+	c.forwardedPorts = append(c.forwardedPorts, port)
+	c.registerPorts(ctx, port)
+	c.updateAllocation(ctx)
+	ctx.Log().Infof("forwarding port %d", port)
+	return nil
+}
+
+// unforwardPort stops proxying a port that was forwarded.
+func (c *command) unforwardPort(ctx *actor.Context, port int) error {
+	for i, p := range c.forwardedPorts {
+		if p != port {
+			continue
+		}
+		c.forwardedPorts = append(c.forwardedPorts[:i], c.forwardedPorts[i+1:]...)
+		name := portServiceID(c.taskID, port)
+		ctx.Tell(c.proxy, proxy.Unregister{ServiceID: name})
+		for j, n := range c.proxyNames {
+			if n == name {
+				c.proxyNames = append(c.proxyNames[:j], c.proxyNames[j+1:]...)
+				break
+			}
+		}
+		c.updateAllocation(ctx)
+		ctx.Log().Infof("stopped forwarding port %d", port)
+		return nil
+	}
+	return status.Errorf(codes.NotFound, "port %d of task %s is not forwarded", port, c.taskID)
+}
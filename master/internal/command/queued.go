@@ -0,0 +1,301 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// managers are the addresses of the actors that manage commands, notebooks, shells, TensorBoards,
+// code servers and Ray clusters.
+var managers = []actor.Address{
+	actor.Addr("commands"),
+	actor.Addr("notebooks"),
+	actor.Addr("shells"),
+	actor.Addr("tensorboard"),
+	actor.Addr("code-servers"),
+	actor.Addr("ray-clusters"),
+}
+
+// taskTypes are the types of the tasks of the managers by their names.
+var taskTypes = map[string]model.TaskType{
+	"commands":     model.TaskTypeCommand,
+	"notebooks":    model.TaskTypeNotebook,
+	"shells":       model.TaskTypeShell,
+	"tensorboard":  model.TaskTypeTensorboard,
+	"code-servers": model.TaskTypeCodeServer,
+	"ray-clusters": model.TaskTypeRayCluster,
+}
+
+// readinessChecksByName are the readiness checks of commands by the names they are persisted by.
+var readinessChecksByName = map[string]readinessCheck{
+	"notebook": func(log sproto.ContainerLog) bool {
+		return jupyterReadyPattern.MatchString(log.String())
+	},
+	"shell": func(log sproto.ContainerLog) bool {
+		return strings.Contains(log.String(), "Server listening on")
+	},
+	"tensorboard": func(log sproto.ContainerLog) bool {
+		return strings.Contains(log.String(), "TensorBoard contains metrics")
+	},
+	"code-server": func(log sproto.ContainerLog) bool {
+		return codeServerReadyPattern.MatchString(log.String())
+	},
+	"ray": func(log sproto.ContainerLog) bool {
+		return strings.Contains(log.String(), "Ray runtime started")
+	},
+}
+
+// persistQueued is a message asking a manager to persist its commands that wait for resources.
+// The manager responds with how many it persisted.
+type persistQueued struct{}
+
+// persistIfQueued is a message asking a command to persist itself and exit if it waits for
+// resources. The command responds with whether it did or an error.
+type persistIfQueued struct{}
+
+// commandSpec is what is persisted of a command, from which its manager restores it with the same
+// task ID when the master restarts: commands that waited for resources are launched again and
+// those that ran reattach to their containers.
+type commandSpec struct {
+	Config                model.CommandConfig               `json:"config"`
+	Owner                 commandOwner                      `json:"owner"`
+	AgentUserGroup        *model.AgentUserGroup             `json:"agent_user_group"`
+	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
+	ProjectID             int                               `json:"project_id"`
+	UserFiles             archive.Archive                   `json:"user_files"`
+	ContextArtifact       string                            `json:"context_artifact"`
+	TempContext           bool                              `json:"temp_context"`
+	AdditionalFiles       archive.Archive                   `json:"additional_files"`
+	Metadata              map[string]interface{}            `json:"metadata"`
+	ServiceAddress        *string                           `json:"service_address"`
+	Token                 string                            `json:"token"`
+	ReadinessChecks       []string                          `json:"readiness_checks"`
+	ProxyTCP              bool                              `json:"proxy_tcp"`
+	StripProxyPath        bool                              `json:"strip_proxy_path"`
+	ForwardedPorts        []int                             `json:"forwarded_ports"`
+	Addresses             []container.Address               `json:"addresses"`
+	PollKernels           bool                              `json:"poll_kernels"`
+	ClusterWorkers        int                               `json:"cluster_workers"`
+	ClusterPort           int                               `json:"cluster_port"`
+}
+
+// PersistQueued persists the commands, notebooks, shells and TensorBoards that wait for resources
+// and aborts them, so that they are launched again when the master restarts. It returns how many
+// were persisted.
+func PersistQueued(system *actor.System) int {
+	persisted := 0
+	for _, manager := range managers {
+		if n, ok := system.AskAt(manager, persistQueued{}).Get().(int); ok {
+			persisted += n
+		}
+	}
+	return persisted
+}
+
+// persistQueuedChildren asks the commands of a manager to persist themselves if they wait for
+// resources and responds with how many did.
+func persistQueuedChildren(ctx *actor.Context) {
+	persisted := 0
+	for ref, resp := range ctx.AskAll(persistIfQueued{}, ctx.Children()...).GetAll() {
+		switch resp := resp.(type) {
+		case bool:
+			if resp {
+				persisted++
+			}
+		case error:
+			ctx.Log().WithError(resp).Errorf("cannot persist %s", ref.Address().Local())
+		}
+	}
+	ctx.Respond(persisted)
+}
+
+// persistIfQueued persists the command and exits if it waits for resources.
+func (c *command) persistIfQueued(ctx *actor.Context) (bool, error) {
+	if c.allocation != nil || c.exitStatus != nil || c.reattachTo != nil {
+		return false, nil
+	}
+	data, err := c.marshalSpec()
+	if err != nil {
+		return false, err
+	}
+	manager := ctx.Self().Parent().Address().Local()
+	if err := c.db.AddQueuedCommand(string(c.taskID), manager, data); err != nil {
+		return false, err
+	}
+	// The command that is launched again owns the temporary context from now on.
+	c.tempContext = false
+	c.exit(ctx, "the master shut down for an upgrade; the task is launched again when it restarts")
+	return true, nil
+}
+
+// marshalSpec encodes what is persisted of the command.
+func (c *command) marshalSpec() ([]byte, error) {
+	spec := commandSpec{
+		Config:                c.config,
+		Owner:                 c.owner,
+		AgentUserGroup:        c.agentUserGroup,
+		TaskContainerDefaults: c.taskSpec.TaskContainerDefaults,
+		ProjectID:             c.projectID,
+		UserFiles:             c.userFiles,
+		ContextArtifact:       c.contextArtifact,
+		TempContext:           c.tempContext,
+		AdditionalFiles:       c.additionalFiles,
+		Metadata:              c.metadata,
+		ServiceAddress:        c.serviceAddress,
+		Token:                 c.token,
+		ProxyTCP:              c.proxyTCP,
+		StripProxyPath:        c.stripProxyPath,
+		ForwardedPorts:        c.forwardedPorts,
+		Addresses:             c.addresses,
+		PollKernels:           c.pollKernels,
+		ClusterWorkers:        c.clusterWorkers,
+		ClusterPort:           c.clusterPort,
+	}
+	for name := range c.readinessChecks {
+		spec.ReadinessChecks = append(spec.ReadinessChecks, name)
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error encoding command %s", c.taskID)
+	}
+	return data, nil
+}
+
+// restore restores the commands of a manager that were persisted before the master restarted.
+func restore(
+	ctx *actor.Context,
+	pgDB *db.PgDB,
+	vaultClient *vault.Client,
+	artifactStore *artifacts.Service,
+	authority *ca.Authority,
+	makeTaskSpec tasks.MakeTaskSpecFn,
+) {
+	restoreQueued(ctx, pgDB, vaultClient, artifactStore, authority, makeTaskSpec)
+	restoreAllocated(ctx, pgDB, vaultClient, artifactStore, authority, makeTaskSpec)
+}
+
+// restoreQueued launches the commands of a manager that were persisted while they waited for
+// resources, before the master restarted.
+func restoreQueued(
+	ctx *actor.Context,
+	pgDB *db.PgDB,
+	vaultClient *vault.Client,
+	artifactStore *artifacts.Service,
+	authority *ca.Authority,
+	makeTaskSpec tasks.MakeTaskSpecFn,
+) {
+	queued, err := pgDB.QueuedCommands(ctx.Self().Address().Local())
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot launch the tasks queued before the master restarted")
+		return
+	}
+	for _, q := range queued {
+		cmd, err := unmarshalCommand(q.Spec, pgDB, vaultClient, artifactStore, authority, makeTaskSpec)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot decode queued task %s", q.TaskID)
+			continue
+		}
+		cmd.taskID = sproto.TaskID(q.TaskID)
+		ctx.ActorOf(q.TaskID, cmd)
+		if err := pgDB.DeleteQueuedCommand(q.TaskID); err != nil {
+			ctx.Log().WithError(err).Errorf("cannot delete queued task %s", q.TaskID)
+		}
+		ctx.Log().Infof("launched %s again, which was queued before the master restarted", q.TaskID)
+	}
+}
+
+// unmarshalCommand decodes a persisted command.
+func unmarshalCommand(
+	data []byte,
+	pgDB *db.PgDB,
+	vaultClient *vault.Client,
+	artifactStore *artifacts.Service,
+	authority *ca.Authority,
+	makeTaskSpec tasks.MakeTaskSpecFn,
+) (*command, error) {
+	var spec commandSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	restoreMetadataIDs(spec.Metadata)
+	taskSpec := makeTaskSpec(spec.Config.Resources.ResourcePool, spec.Config.Resources.Slots)
+	taskSpec.TaskContainerDefaults = spec.TaskContainerDefaults
+	readinessChecks := make(map[string]readinessCheck, len(spec.ReadinessChecks))
+	for _, name := range spec.ReadinessChecks {
+		if readiness, ok := readinessChecksByName[name]; ok {
+			readinessChecks[name] = readiness
+		}
+	}
+	return &command{
+		config:          spec.Config,
+		userFiles:       spec.UserFiles,
+		contextArtifact: spec.ContextArtifact,
+		tempContext:     spec.TempContext,
+		additionalFiles: spec.AdditionalFiles,
+		metadata:        spec.Metadata,
+		readinessChecks: readinessChecks,
+		serviceAddress:  spec.ServiceAddress,
+		token:           spec.Token,
+
+		owner:          spec.Owner,
+		agentUserGroup: spec.AgentUserGroup,
+		taskSpec:       &taskSpec,
+		projectID:      spec.ProjectID,
+
+		proxyTCP:       spec.ProxyTCP,
+		stripProxyPath: spec.StripProxyPath,
+		forwardedPorts: spec.ForwardedPorts,
+		addresses:      spec.Addresses,
+		pollKernels:    spec.PollKernels,
+		clusterWorkers: spec.ClusterWorkers,
+		clusterPort:    spec.ClusterPort,
+
+		db:        pgDB,
+		vault:     vaultClient,
+		artifacts: artifactStore,
+		ca:        authority,
+	}, nil
+}
+
+// restoreMetadataIDs restores the experiment and trial IDs in the metadata of TensorBoards to
+// []int, which JSON decodes as []interface{} of floats, or nil for empty slices, and their log
+// paths to []string.
+func restoreMetadataIDs(metadata map[string]interface{}) {
+	if value, ok := metadata["log_paths"]; ok {
+		values, _ := value.([]interface{})
+		paths := make([]string, 0, len(values))
+		for _, path := range values {
+			if path, ok := path.(string); ok {
+				paths = append(paths, path)
+			}
+		}
+		metadata["log_paths"] = paths
+	}
+	for _, key := range []string{"experiment_ids", "trial_ids"} {
+		value, ok := metadata[key]
+		if !ok {
+			continue
+		}
+		values, _ := value.([]interface{})
+		ids := make([]int, 0, len(values))
+		for _, id := range values {
+			if id, ok := id.(float64); ok {
+				ids = append(ids, int(id))
+			}
+		}
+		metadata[key] = ids
+	}
+}
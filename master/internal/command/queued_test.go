@@ -0,0 +1,28 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestRestoreMetadataIDs(t *testing.T) {
+	data, err := json.Marshal(map[string]interface{}{
+		"experiment_ids": []int{1, 2},
+		"trial_ids":      []int(nil),
+		"log_paths":      []string{"s3://bucket/logs"},
+	})
+	assert.NilError(t, err)
+	var metadata map[string]interface{}
+	assert.NilError(t, json.Unmarshal(data, &metadata))
+
+	restoreMetadataIDs(metadata)
+	assert.DeepEqual(t, metadata["experiment_ids"].([]int), []int{1, 2})
+	assert.DeepEqual(t, metadata["trial_ids"].([]int), []int{})
+	assert.DeepEqual(t, metadata["log_paths"].([]string), []string{"s3://bucket/logs"})
+
+	shell := map[string]interface{}{"privateKey": "key"}
+	restoreMetadataIDs(shell)
+	assert.DeepEqual(t, shell, map[string]interface{}{"privateKey": "key"})
+}
@@ -0,0 +1,217 @@
+package command
+
+import (
+	"archive/tar"
+	"fmt"
+	"net/http"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/rayclusterv1"
+)
+
+const (
+	rayDir        = "/run/determined/ray/"
+	rayEntrypoint = "/run/determined/ray/ray-entrypoint.sh"
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
+	minRayDashboardPort      = 4100
+	maxRayDashboardPort      = minRayDashboardPort + 299
+	minRayHeadPort           = 4400
+	maxRayHeadPort           = minRayHeadPort + 299
+	rayClusterServiceAddress = "/proxy/%s/"
+)
+
+type rayClusterManager struct {
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
+
+	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
+	makeTaskSpec          tasks.MakeTaskSpecFn
+
+	// tasks are the running Ray clusters, which the manager lists.
+	tasks listIndex
+}
+
+// RayClusterLaunchRequest describes a request to launch a new Ray cluster with a number of workers
+// besides its head.
+type RayClusterLaunchRequest struct {
+	CommandParams *CommandParams
+	Workers       int
+}
+
+func (r *rayClusterManager) processLaunchRequest(
+	ctx *actor.Context,
+	req RayClusterLaunchRequest,
+) (*summary, int, error) {
+	ctx.Log().Info("creating Ray cluster")
+
+	if req.Workers < 0 {
+		return nil, http.StatusBadRequest, errors.Errorf(
+			"the number of workers (%d) cannot be negative", req.Workers)
+	}
+	if slots := req.CommandParams.FullConfig.Resources.Slots; slots%(req.Workers+1) != 0 {
+		return nil, http.StatusBadRequest, errors.Errorf(
+			"the slots of the Ray cluster (%d) must be divisible by its number of containers (%d)",
+			slots, req.Workers+1)
+	}
+
+	rayCluster := r.newRayCluster(req.CommandParams, req.Workers)
+
+	if err := check.Validate(rayCluster.config); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := rayCluster.checkBindMounts(r.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err := rayCluster.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	a, _ := ctx.ActorOf(rayCluster.taskID, rayCluster)
+	summaryFut := ctx.Ask(a, getSummary{})
+	if err := summaryFut.Error(); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	summary := summaryFut.Get().(summary)
+	ctx.Log().Infof("created Ray cluster %s", a.Address().Local())
+	return &summary, http.StatusOK, nil
+}
+
+func (r *rayClusterManager) Receive(ctx *actor.Context) error {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		r.tasks = listIndex{}
+		restore(ctx, r.db, r.vault, r.artifacts, r.ca, r.makeTaskSpec)
+
+	case persistQueued:
+		persistQueuedChildren(ctx)
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		r.tasks.update(msg)
+
+	case searchTasks:
+		ctx.Respond(r.tasks.search(msg, apiv1.SearchResult_KIND_RAY_CLUSTER))
+
+	case *apiv1.GetRayClustersRequest:
+		items, pagination, next, err := r.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+		}, &rayclusterv1.RayCluster{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		resp := &apiv1.GetRayClustersResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.RayClusters = append(resp.RayClusters, item.(*rayclusterv1.RayCluster))
+		}
+		ctx.Respond(resp)
+
+	case RayClusterLaunchRequest:
+		summary, statusCode, err := r.processLaunchRequest(ctx, msg)
+		if err != nil || statusCode > 200 {
+			ctx.Respond(echo.NewHTTPError(
+				statusCode, errors.Wrap(err, "failed to launch Ray cluster").Error()))
+			return nil
+		}
+		ctx.Respond(summary.ID)
+	}
+	return nil
+}
+
+func (r *rayClusterManager) newRayCluster(params *CommandParams, workers int) *command {
+	config := params.FullConfig
+	taskID := sproto.NewTaskID()
+
+	// Select random ports from the ranges for the dashboard and the head, which the workers connect
+	// to. In host mode, this mitigates the risk of multiple Ray clusters binding the same ports on
+	// an agent. Only the dashboard is published, since the workers either reach the head on the
+	// network of the host or on the network private to the cluster.
+	dashboardPort := getPort(minRayDashboardPort, maxRayDashboardPort)
+	headPort := getPort(minRayHeadPort, maxRayHeadPort)
+	portVars := []string{
+		fmt.Sprintf("DET_RAY_DASHBOARD_PORT=%d", dashboardPort),
+		fmt.Sprintf("DET_RAY_HEAD_PORT=%d", headPort),
+	}
+
+	config.Environment.Ports = map[string]int{"ray-dashboard": dashboardPort}
+	config.Environment.EnvironmentVariables.CPU = append(
+		config.Environment.EnvironmentVariables.CPU, portVars...)
+	config.Environment.EnvironmentVariables.GPU = append(
+		config.Environment.EnvironmentVariables.GPU, portVars...)
+
+	config.Entrypoint = []string{rayEntrypoint}
+
+	setPodSpec(config, params.TaskSpec.TaskContainerDefaults)
+
+	if config.Description == "" {
+		petName := petname.Generate(model.TaskNameGeneratorWords, model.TaskNameGeneratorSep)
+		config.Description = fmt.Sprintf("Ray cluster (%s)", petName)
+	}
+
+	serviceAddress := fmt.Sprintf(rayClusterServiceAddress, taskID)
+
+	return &command{
+		taskID:          taskID,
+		config:          *config,
+		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
+		additionalFiles: archive.Archive{
+			params.AgentUserGroup.OwnedArchiveItem(rayDir, nil, 0700, tar.TypeDir),
+			params.AgentUserGroup.OwnedArchiveItem(
+				rayEntrypoint,
+				etc.MustStaticFile(etc.RayEntrypointResource),
+				0700,
+				tar.TypeReg,
+			),
+		},
+
+		readinessChecks: map[string]readinessCheck{
+			"ray": readinessChecksByName["ray"],
+		},
+		serviceAddress: &serviceAddress,
+		stripProxyPath: true,
+		clusterWorkers: workers,
+		clusterPort:    headPort,
+
+		owner: commandOwner{
+			ID:       params.User.ID,
+			Username: params.User.Username,
+		},
+		agentUserGroup: params.AgentUserGroup,
+		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
+
+		db:        r.db,
+		vault:     r.vault,
+		artifacts: r.artifacts,
+		ca:        r.ca,
+	}
+}
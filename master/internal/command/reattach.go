@@ -0,0 +1,141 @@
+package command
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// reattachTimeout is how long a command restored after the master restarted waits for the agent
+// of its container to reconnect before it gives up on the container.
+const reattachTimeout = 5 * time.Minute
+
+// reattachTimedOut is a message telling a restored command that its agent did not reconnect in
+// time.
+type reattachTimedOut struct{}
+
+// persistAllocation persists the allocation of the command's container, so that the command is
+// restored and reattaches to the container if the master restarts while it runs. Only containers
+// on agents outlive the master. The replicas of servings are not persisted, since their servings
+// are not restored, and neither are clusters, which only reattach to their heads.
+func (c *command) persistAllocation(ctx *actor.Context) {
+	summary := c.allocation.Summary()
+	if ctx.Self().System().Get(sproto.AgentsAddr.Child(summary.Agent)) == nil ||
+		c.taskType == model.TaskTypeServing || len(c.workers) > 0 {
+		return
+	}
+	spec, err := c.marshalSpec()
+	if err == nil {
+		err = c.db.AddAllocation(db.Allocation{
+			ContainerID: string(summary.ID),
+			TaskID:      string(c.taskID),
+			TaskActor:   ctx.Self().Address().String(),
+			AgentID:     summary.Agent,
+			Spec:        spec,
+		})
+	}
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot persist the allocation of the command")
+	}
+}
+
+// updateAllocation persists the spec of the command again with the allocation of its container,
+// e.g. once the ports that it forwards change, so that it is restored as it is now.
+func (c *command) updateAllocation(ctx *actor.Context) {
+	if c.allocation == nil {
+		return
+	}
+	spec, err := c.marshalSpec()
+	if err == nil {
+		err = c.db.UpdateAllocationSpec(string(c.allocation.Summary().ID), spec)
+	}
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot update the allocation of the command")
+	}
+}
+
+// deleteAllocation deletes the persisted allocation of a container of the command.
+func (c *command) deleteAllocation(ctx *actor.Context, id container.ID) {
+	if err := c.db.DeleteAllocation(string(id)); err != nil {
+		ctx.Log().WithError(err).Error("cannot delete the allocation of the command")
+	}
+}
+
+// restoreContainer restores the container of a command that was restored after the master
+// restarted as running, if the addresses of the container were persisted. Its container normally
+// outlives the master, so users can reach it through the proxy again right away, rather than only
+// once its agent reconnects. The command exits if the agent does not reconnect in time.
+func (c *command) restoreContainer(ctx *actor.Context) {
+	if len(c.addresses) == 0 {
+		return
+	}
+	c.container = &container.Container{
+		Parent: ctx.Self().Address(),
+		ID:     *c.reattachTo,
+		State:  container.Running,
+	}
+	c.registerProxies(ctx)
+	ctx.Log().Infof("registered the addresses of container %s again", c.container.ID)
+}
+
+// reattach claims the container that the command was restored for, which its agent found running
+// when it reconnected, by responding with the request that the resource pool allocates it to.
+func (c *command) reattach(ctx *actor.Context, msg sproto.ReattachContainer) {
+	if c.reattachTo == nil || *c.reattachTo != msg.Container.ID || c.exitStatus != nil {
+		return
+	}
+	c.reattachTo = nil
+	c.task.ResourcePool = msg.ResourcePool
+	c.task.Reattach = &msg
+	// The container served before the master restarted and does not log its readiness again.
+	c.readinessMessageSent = true
+	ctx.Respond(*c.task)
+	ctx.Tell(sproto.GetRM(ctx.Self().System()), sproto.SetGroupPriority{
+		Priority: c.config.Resources.Priority,
+		Handler:  ctx.Self(),
+	})
+}
+
+// restoreAllocated restores the commands of a manager whose containers ran before the master
+// restarted. Each waits for the agent of its container to reconnect and reattaches to it.
+func restoreAllocated(
+	ctx *actor.Context,
+	pgDB *db.PgDB,
+	vaultClient *vault.Client,
+	artifactStore *artifacts.Service,
+	authority *ca.Authority,
+	makeTaskSpec tasks.MakeTaskSpecFn,
+) {
+	allocations, err := pgDB.Allocations()
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot restore the tasks that ran before the master restarted")
+		return
+	}
+	for _, a := range allocations {
+		var address actor.Address
+		if err := address.UnmarshalText([]byte(a.TaskActor)); err != nil ||
+			address.Parent() != ctx.Self().Address() {
+			continue
+		}
+		cmd, err := unmarshalCommand(a.Spec, pgDB, vaultClient, artifactStore, authority, makeTaskSpec)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot decode task %s", a.TaskID)
+			continue
+		}
+		cmd.taskID = sproto.TaskID(a.TaskID)
+		cmd.allocatedTime = a.StartTime
+		reattachTo := container.ID(a.ContainerID)
+		cmd.reattachTo = &reattachTo
+		ctx.ActorOf(address.Local(), cmd)
+		ctx.Log().Infof("restored %s, which reattaches to container %s once agent %s reconnects",
+			a.TaskID, a.ContainerID, a.AgentID)
+	}
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// searchTasks is a message asking a manager for its commands whose descriptions match all the
+// terms. The manager responds with a []*apiv1.SearchResult.
+type searchTasks struct {
+	terms     []string
+	users     []string
+	projectID int32
+	// projects limits the commands to those in the given projects, unless it is nil.
+	projects map[int32]bool
+	// archived limits the commands to those that are archived or not, unless it is nil.
+	archived *bool
+}
+
+// SearchTasks returns up to limit of the commands, notebooks, shells, TensorBoards, code servers
+// and Ray clusters whose descriptions match all the terms, best first. If archived is not nil, it
+// only returns those that are archived or not, and if projects is not nil, only those in the given
+// projects.
+func SearchTasks(
+	system *actor.System, terms, users []string, projectID int32, projects map[int32]bool,
+	archived *bool, limit int,
+) []*apiv1.SearchResult {
+	var results []*apiv1.SearchResult
+	for _, manager := range managers {
+		found, ok := system.AskAt(manager, searchTasks{
+			terms: terms, users: users, projectID: projectID, projects: projects, archived: archived,
+		}).Get().([]*apiv1.SearchResult)
+		if ok {
+			results = append(results, found...)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Id < results[j].Id
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// search returns the commands of the index whose descriptions match all the terms of the search.
+func (idx listIndex) search(msg searchTasks, kind apiv1.SearchResult_Kind) []*apiv1.SearchResult {
+	users := make(map[string]bool)
+	for _, user := range msg.users {
+		users[user] = true
+	}
+	var results []*apiv1.SearchResult
+	for _, e := range idx {
+		if (len(users) > 0 && !users[e.username]) ||
+			(msg.projectID != 0 && e.projectID != msg.projectID) ||
+			!e.inProjects(msg.projects) ||
+			(msg.archived != nil && e.archived != *msg.archived) {
+			continue
+		}
+		if score := matchScore(msg.terms, e.description); score > 0 {
+			results = append(results, &apiv1.SearchResult{
+				Kind:        kind,
+				Id:          e.id,
+				Description: e.description,
+				Username:    e.username,
+				ProjectId:   e.projectID,
+				Score:       score,
+			})
+		}
+	}
+	return results
+}
+
+// matchScore returns how well the words of the text match the terms, from 0 if a term matches
+// none of them to 1 if each term is one of them. Terms that are only a prefix of a word count half.
+func matchScore(terms []string, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := api.SearchTerms(text)
+	score := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			switch {
+			case word == term:
+				best = 1
+			case best == 0 && strings.HasPrefix(word, term):
+				best = 0.5
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+	return score / float64(len(terms))
+}
@@ -0,0 +1,15 @@
+package command
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMatchScore(t *testing.T) {
+	const description = "Notebook (finely-tuned kitten)"
+	assert.Equal(t, matchScore([]string{"kitten"}, description), 1.0)
+	assert.Equal(t, matchScore([]string{"kit", "notebook"}, description), 0.75)
+	assert.Equal(t, matchScore([]string{"kitten", "puppy"}, description), 0.0)
+	assert.Equal(t, matchScore(nil, description), 0.0)
+}
@@ -0,0 +1,448 @@
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/commandv1"
+	"github.com/determined-ai/determined/proto/pkg/servingv1"
+)
+
+const (
+	servingCheckInterval = 10 * time.Second
+	servingCheckTimeout  = 5 * time.Second
+	// servingMaxFailedChecks is how many health checks in a row a replica that passed them before
+	// may fail until it is restarted.
+	servingMaxFailedChecks = 3
+	// The backoff of restarting a replica doubles with each restart in a row, up to the maximum.
+	servingMinBackoff = 10 * time.Second
+	servingMaxBackoff = 5 * time.Minute
+)
+
+type (
+	// servingTick is a message asking a serving to check the health of its replicas.
+	servingTick struct{}
+	// restartReplica is a message asking a serving to start a replica in place of the one that
+	// exited.
+	restartReplica struct{}
+)
+
+// replica is a command that runs the model server of a serving.
+type replica struct {
+	ref    *actor.Ref
+	model  ServingModel
+	params *CommandParams
+	// url is the address of the model server, which is known once the replica runs.
+	url     *url.URL
+	healthy bool
+	// failedChecks is how many health checks in a row the replica failed since it passed one.
+	failedChecks int
+}
+
+// serving runs the model server of a registered model version as a replica, which it restarts
+// when it exits or stops passing its health checks. The master proxies the serving under its
+// stable name rather than the task ID of the replica. A rolling update starts another replica with
+// the new model version or config and replaces the current one once the new one is healthy.
+type serving struct {
+	name        string
+	healthPath  string
+	maxRestarts int
+	// model and params are those of the current replica, which it is restarted with.
+	model  ServingModel
+	params *CommandParams
+
+	registeredTime time.Time
+	current        *replica
+	// next is the replica that a rolling update started, until it replaces the current one.
+	next *replica
+	// restarts is how many times the replica was restarted since it last passed a health check.
+	restarts int
+	killed   bool
+
+	bindMountPolicy model.BindMountPolicy
+	db              *db.PgDB
+	vault           *vault.Client
+	artifacts       *artifacts.Service
+	ca              *ca.Authority
+	proxy           *actor.Ref
+	client          *http.Client
+}
+
+// Receive implements the actor.Actor interface.
+func (s *serving) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		s.registeredTime = ctx.Self().RegisteredTime()
+		s.proxy = ctx.Self().System().Get(actor.Addr("proxy"))
+		s.client = &http.Client{Timeout: servingCheckTimeout}
+		ctx.Tell(ctx.Self().Parent(), s.listEntry(ctx))
+		s.current = s.startReplica(ctx, s.model, s.params)
+		actors.NotifyAfter(ctx, servingCheckInterval, servingTick{})
+
+	case actor.PostStop:
+		ctx.Tell(s.proxy, proxy.Unregister{ServiceID: s.name})
+
+	case listEntry:
+		// Replicas tell their serving when they start and when they exit.
+		if !msg.endTime.IsZero() {
+			s.replicaExited(ctx, msg.ref)
+		}
+
+	case actor.ChildFailed:
+		s.replicaExited(ctx, msg.Child)
+
+	case actor.ChildStopped:
+		// Replicas stop once their serving is done with them.
+
+	case servingTick:
+		s.checkReplicas(ctx)
+		actors.NotifyAfter(ctx, servingCheckInterval, servingTick{})
+
+	case restartReplica:
+		if !s.killed && s.current == nil {
+			s.current = s.startReplica(ctx, s.model, s.params)
+		}
+
+	case *servingv1.Serving:
+		ctx.Respond(s.toServing(ctx))
+
+	case *apiv1.GetServingRequest:
+		ctx.Respond(&apiv1.GetServingResponse{
+			Serving: s.toServing(ctx),
+			Config:  protoutils.ToStruct(*s.params.FullConfig),
+		})
+
+	case *apiv1.KillServingRequest:
+		s.kill(ctx)
+		ctx.Respond(&apiv1.KillServingResponse{Serving: s.toServing(ctx)})
+
+	case ServingUpdateRequest:
+		if s.killed {
+			ctx.Respond(status.Errorf(codes.FailedPrecondition,
+				"serving %s is being killed", s.name))
+			return nil
+		}
+		params := msg.CommandParams
+		if params.FullConfig.Description == "" {
+			params.FullConfig.Description = s.params.FullConfig.Description
+		}
+		if params.UserFiles == nil && params.ContextArtifact == "" {
+			params.UserFiles = s.params.UserFiles
+			params.ContextArtifact = s.params.ContextArtifact
+		}
+		if statusCode, err := s.checkReplica(s.newReplica(msg.Model, params)); err != nil {
+			ctx.Respond(echo.NewHTTPError(
+				statusCode,
+				errors.Wrap(err, "failed to update serving").Error(),
+			))
+			return nil
+		}
+		if s.next != nil {
+			ctx.Log().Infof("replacing the rolling update to replica %s",
+				s.next.ref.Address().Local())
+			ctx.Ask(s.next.ref, &apiv1.KillCommandRequest{})
+		}
+		s.next = s.startReplica(ctx, msg.Model, params)
+		ctx.Respond(&apiv1.UpdateServingResponse{Serving: s.toServing(ctx)})
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+// newReplica returns a command that runs the model server of the model version with the params.
+func (s *serving) newReplica(m ServingModel, params *CommandParams) *command {
+	config := *params.FullConfig
+
+	// Select a random port from the range to assign to the model server. In host mode, this
+	// mitigates the risk of multiple model servers binding the same port on an agent.
+	port := getPort(minServingPort, maxServingPort)
+	config.Environment.Ports = map[string]int{"serving": port}
+	servingVars := []string{
+		fmt.Sprintf("DET_SERVING_NAME=%s", s.name),
+		fmt.Sprintf("DET_SERVING_MODEL_NAME=%s", m.Name),
+		fmt.Sprintf("DET_SERVING_MODEL_VERSION=%d", m.Version),
+		fmt.Sprintf("DET_SERVING_CHECKPOINT_UUID=%s", m.CheckpointUUID),
+		fmt.Sprintf("DET_SERVING_PORT=%d", port),
+	}
+	envVars := &config.Environment.EnvironmentVariables
+	envVars.CPU = append(append([]string{}, envVars.CPU...), servingVars...)
+	envVars.GPU = append(append([]string{}, envVars.GPU...), servingVars...)
+
+	if len(config.Entrypoint) == 1 {
+		config.Entrypoint = append(shellFormEntrypoint, config.Entrypoint...)
+	}
+	setPodSpec(&config, params.TaskSpec.TaskContainerDefaults)
+
+	return &command{
+		taskID:          sproto.NewTaskID(),
+		taskType:        model.TaskTypeServing,
+		config:          config,
+		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		owner: commandOwner{
+			ID:       params.User.ID,
+			Username: params.User.Username,
+		},
+		agentUserGroup: params.AgentUserGroup,
+		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
+
+		db:        s.db,
+		vault:     s.vault,
+		artifacts: s.artifacts,
+		ca:        s.ca,
+	}
+}
+
+// checkReplica returns an error and the HTTP status code of it if the replica cannot be launched.
+func (s *serving) checkReplica(cmd *command) (int, error) {
+	if err := check.Validate(cmd.config); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := cmd.checkBindMounts(s.bindMountPolicy); err != nil {
+		return http.StatusForbidden, err
+	}
+	if err := cmd.checkSecrets(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// startReplica launches a replica that serves the model version with the params.
+func (s *serving) startReplica(
+	ctx *actor.Context, m ServingModel, params *CommandParams,
+) *replica {
+	cmd := s.newReplica(m, params)
+	ref, _ := ctx.ActorOf(cmd.taskID, cmd)
+	ctx.Log().Infof("started replica %s of model %s version %d", cmd.taskID, m.Name, m.Version)
+	return &replica{ref: ref, model: m, params: params}
+}
+
+// replicaExited handles a replica that exited. The current replica is restarted unless the serving
+// is killed or it restarted too often without passing a health check.
+func (s *serving) replicaExited(ctx *actor.Context, ref *actor.Ref) {
+	ctx.Tell(ref, terminateForGC{})
+	switch {
+	case s.next != nil && s.next.ref == ref:
+		ctx.Log().Warnf("replica %s of the rolling update exited before it passed a health check",
+			ref.Address().Local())
+		s.next = nil
+
+	case s.current != nil && s.current.ref == ref:
+		if s.current.healthy {
+			ctx.Tell(s.proxy, proxy.Unregister{ServiceID: s.name})
+		}
+		s.current = nil
+		switch {
+		case s.killed:
+		case s.restarts >= s.maxRestarts:
+			ctx.Log().Errorf("giving up on serving %s after %d restarts without passing a health "+
+				"check", s.name, s.restarts)
+			s.kill(ctx)
+			return
+		default:
+			backoff := restartBackoff(s.restarts)
+			ctx.Log().Warnf("replica %s exited, restarting it in %s",
+				ref.Address().Local(), backoff)
+			s.restarts++
+			actors.NotifyAfter(ctx, backoff, restartReplica{})
+		}
+	}
+	if s.killed && s.current == nil && s.next == nil {
+		ctx.Self().Stop()
+	}
+}
+
+// restartBackoff returns how long to wait before restarting a replica that exited after the given
+// number of restarts in a row.
+func restartBackoff(restarts int) time.Duration {
+	backoff := servingMinBackoff
+	for i := 0; i < restarts && backoff < servingMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > servingMaxBackoff {
+		return servingMaxBackoff
+	}
+	return backoff
+}
+
+// checkReplicas checks the health of the replicas. The replica of a rolling update replaces the
+// current one once it is healthy, and a replica that fails too many health checks is restarted.
+func (s *serving) checkReplicas(ctx *actor.Context) {
+	if s.next != nil {
+		if s.checkHealth(ctx, s.next) {
+			s.promote(ctx)
+		}
+	}
+	if s.current == nil {
+		return
+	}
+	wasHealthy := s.current.healthy
+	if s.checkHealth(ctx, s.current) && !wasHealthy {
+		s.restarts = 0
+		s.register(ctx)
+	}
+	if s.current.failedChecks >= servingMaxFailedChecks {
+		ctx.Log().Warnf("restarting replica %s after it failed %d health checks in a row",
+			s.current.ref.Address().Local(), s.current.failedChecks)
+		s.current.failedChecks = 0
+		ctx.Ask(s.current.ref, &apiv1.KillCommandRequest{})
+	}
+}
+
+// checkHealth checks the health of a replica once it runs and returns whether it passed.
+func (s *serving) checkHealth(ctx *actor.Context, r *replica) bool {
+	if r.url == nil {
+		snapshot, ok := ctx.Ask(r.ref, getSummary{}).Get().(summary)
+		if !ok || snapshot.State != model.TaskStateRunning.String() || len(snapshot.Addresses) == 0 {
+			return false
+		}
+		address := snapshot.Addresses[0]
+		r.url = &url.URL{
+			Scheme: "http",
+			Host:   fmt.Sprintf("%s:%d", address.HostIP, address.HostPort),
+		}
+	}
+
+	healthy := false
+	resp, err := s.client.Get(r.url.String() + s.healthPath)
+	if err == nil {
+		healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+		_ = resp.Body.Close()
+	}
+
+	switch {
+	case healthy:
+		if !r.healthy {
+			ctx.Log().Infof("replica %s passed its health check", r.ref.Address().Local())
+		}
+		r.healthy = true
+		r.failedChecks = 0
+	case r.healthy:
+		r.failedChecks++
+		ctx.Log().Warnf("replica %s failed its health check: %v",
+			r.ref.Address().Local(), healthCheckError(resp, err))
+	}
+	return healthy
+}
+
+// healthCheckError describes why a health check failed.
+func healthCheckError(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.Errorf("status %s", resp.Status)
+}
+
+// register proxies the serving to its current replica. The serving belongs to the tenant of the
+// workspace of its project; if that cannot be told, it is not proxied.
+func (s *serving) register(ctx *actor.Context) {
+	projectID := s.params.ProjectID
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	workspace, err := s.db.WorkspaceByProjectID(projectID)
+	if err != nil {
+		ctx.Log().WithError(err).Errorf("not proxying serving %s", s.name)
+		return
+	}
+	ctx.Tell(s.proxy, proxy.Register{
+		ServiceID: s.name,
+		URL:       s.current.url,
+		TenantID:  workspace.TenantID,
+	})
+}
+
+// promote replaces the current replica with that of the rolling update, which is healthy, and
+// proxies the serving to it.
+func (s *serving) promote(ctx *actor.Context) {
+	old := s.current
+	s.current, s.next = s.next, nil
+	s.model, s.params = s.current.model, s.current.params
+	s.restarts = 0
+	s.register(ctx)
+	ctx.Tell(ctx.Self().Parent(), s.listEntry(ctx))
+	ctx.Log().Infof("replica %s of model %s version %d replaced the current replica",
+		s.current.ref.Address().Local(), s.model.Name, s.model.Version)
+	if old != nil {
+		ctx.Ask(old.ref, &apiv1.KillCommandRequest{})
+	}
+}
+
+// kill kills the replicas of the serving, which stops once they exit.
+func (s *serving) kill(ctx *actor.Context) {
+	s.killed = true
+	ctx.Tell(s.proxy, proxy.Unregister{ServiceID: s.name})
+	for _, r := range []*replica{s.current, s.next} {
+		if r != nil {
+			ctx.Ask(r.ref, &apiv1.KillCommandRequest{})
+		}
+	}
+	if s.current == nil && s.next == nil {
+		ctx.Self().Stop()
+	}
+}
+
+// listEntry returns what the serving's manager lists it by.
+func (s *serving) listEntry(ctx *actor.Context) listEntry {
+	return listEntry{
+		ref:         ctx.Self(),
+		id:          s.name,
+		description: s.params.FullConfig.Description,
+		startTime:   s.registeredTime,
+		username:    s.params.User.Username,
+		projectID:   int32(s.params.ProjectID),
+	}
+}
+
+func (s *serving) toServing(ctx *actor.Context) *servingv1.Serving {
+	serving := &servingv1.Serving{
+		Name:           s.name,
+		Description:    s.params.FullConfig.Description,
+		ModelName:      s.model.Name,
+		ModelVersion:   int32(s.model.Version),
+		CheckpointUuid: s.model.CheckpointUUID,
+		StartTime:      protoutils.ToTimestamp(s.registeredTime),
+		State:          model.TaskStatePending.Proto(),
+		Restarts:       int32(s.restarts),
+		Username:       s.params.User.Username,
+		ProjectId:      int32(s.params.ProjectID),
+		ResourcePool:   s.params.FullConfig.Resources.ResourcePool,
+		ServiceAddress: fmt.Sprintf(servingServiceAddress, s.name),
+		HealthPath:     s.healthPath,
+	}
+	if s.current != nil {
+		serving.ReplicaId = s.current.ref.Address().Local()
+		serving.Healthy = s.current.healthy
+		if cmd, ok := ctx.Ask(s.current.ref, &commandv1.Command{}).Get().(*commandv1.Command); ok {
+			serving.State = cmd.State
+			serving.Container = cmd.Container
+		}
+	}
+	if s.next != nil {
+		serving.UpdatingReplicaId = s.next.ref.Address().Local()
+	}
+	return serving
+}
@@ -0,0 +1,182 @@
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/servingv1"
+)
+
+const (
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
+	minServingPort        = 3500
+	maxServingPort        = minServingPort + 299
+	servingServiceAddress = "/proxy/%s/"
+	// maxServingNameLength keeps the names of servings valid as DNS labels.
+	maxServingNameLength      = 63
+	defaultServingHealthPath  = "/"
+	defaultServingMaxRestarts = 5
+)
+
+var servingNamePattern = regexp.MustCompile("^[a-z][a-z0-9-]*$")
+
+// ServingModel is the model version that a serving serves.
+type ServingModel struct {
+	Name           string
+	Version        int
+	CheckpointUUID string
+}
+
+// ServingLaunchRequest describes a request to launch a new serving of a model version.
+type ServingLaunchRequest struct {
+	CommandParams *CommandParams
+	Name          string
+	Model         ServingModel
+	HealthPath    string
+	MaxRestarts   int
+}
+
+// ServingUpdateRequest describes a rolling update of a serving to another model version or config.
+type ServingUpdateRequest struct {
+	CommandParams *CommandParams
+	Model         ServingModel
+}
+
+type servingManager struct {
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
+
+	bindMountPolicy model.BindMountPolicy
+
+	// tasks are the running servings, which the manager lists.
+	tasks listIndex
+}
+
+func (s *servingManager) Receive(ctx *actor.Context) error {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		s.tasks = listIndex{}
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		s.tasks.update(msg)
+
+	case *apiv1.GetServingsRequest:
+		items, pagination, next, err := s.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+		}, &servingv1.Serving{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		resp := &apiv1.GetServingsResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.Servings = append(resp.Servings, item.(*servingv1.Serving))
+		}
+		ctx.Respond(resp)
+
+	case ServingLaunchRequest:
+		statusCode, err := s.processLaunchRequest(ctx, msg)
+		if err != nil || statusCode > 200 {
+			ctx.Respond(echo.NewHTTPError(
+				statusCode,
+				errors.Wrap(err, "failed to launch serving").Error(),
+			))
+			return nil
+		}
+		ctx.Respond(msg.Name)
+	}
+	return nil
+}
+
+func (s *servingManager) processLaunchRequest(
+	ctx *actor.Context,
+	req ServingLaunchRequest,
+) (int, error) {
+	ctx.Log().Infof("creating serving %s", req.Name)
+
+	if err := validateServingName(req.Name); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if ctx.Child(req.Name) != nil {
+		return http.StatusConflict, errors.Errorf("serving %s already exists", req.Name)
+	}
+
+	config := req.CommandParams.FullConfig
+	if config.Description == "" {
+		config.Description = fmt.Sprintf("Serving (%s)", req.Name)
+	}
+	healthPath := req.HealthPath
+	if healthPath == "" {
+		healthPath = defaultServingHealthPath
+	} else if !strings.HasPrefix(healthPath, "/") {
+		healthPath = "/" + healthPath
+	}
+	maxRestarts := req.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = defaultServingMaxRestarts
+	}
+
+	srv := &serving{
+		name:        req.Name,
+		healthPath:  healthPath,
+		maxRestarts: maxRestarts,
+		model:       req.Model,
+		params:      req.CommandParams,
+
+		bindMountPolicy: s.bindMountPolicy,
+		db:              s.db,
+		vault:           s.vault,
+		artifacts:       s.artifacts,
+		ca:              s.ca,
+	}
+	if statusCode, err := srv.checkReplica(
+		srv.newReplica(req.Model, req.CommandParams),
+	); err != nil {
+		return statusCode, err
+	}
+
+	ctx.ActorOf(req.Name, srv)
+	ctx.Log().Infof("created serving %s", req.Name)
+	return http.StatusOK, nil
+}
+
+// validateServingName returns an error if the name cannot be that of a serving. Servings are
+// proxied under their names, which must not look like the IDs of other tasks.
+func validateServingName(name string) error {
+	if _, err := uuid.Parse(name); err == nil {
+		return errors.New("the name of a serving cannot be a UUID")
+	}
+	switch {
+	case len(name) > maxServingNameLength:
+		return errors.Errorf(
+			"the name of a serving cannot be longer than %d characters", maxServingNameLength)
+	case !servingNamePattern.MatchString(name):
+		return errors.New("the name of a serving must consist of lowercase letters, digits and " +
+			"dashes, and start with a letter")
+	}
+	return nil
+}
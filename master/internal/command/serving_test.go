@@ -0,0 +1,34 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestValidateServingName(t *testing.T) {
+	for _, name := range []string{"mnist", "mnist-v2", "a"} {
+		assert.NilError(t, validateServingName(name), name)
+	}
+	for _, name := range []string{
+		"",
+		"Mnist",
+		"2mnist",
+		"mnist_v2",
+		"mnist/v2",
+		strings.Repeat("a", maxServingNameLength+1),
+		"a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d",
+	} {
+		assert.ErrorContains(t, validateServingName(name), "serving", name)
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	assert.Equal(t, restartBackoff(0), servingMinBackoff)
+	assert.Equal(t, restartBackoff(1), 2*servingMinBackoff)
+	assert.Equal(t, restartBackoff(3), 8*servingMinBackoff)
+	assert.Equal(t, restartBackoff(5), servingMaxBackoff)
+	assert.Equal(t, restartBackoff(1000), servingMaxBackoff)
+	assert.Assert(t, restartBackoff(4) < servingMaxBackoff)
+}
@@ -11,8 +11,11 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/check"
@@ -31,16 +34,24 @@ const (
 	shellHostPrivKeyFile    = "/run/determined/ssh/id_rsa"
 	shellHostPubKeyFile     = "/run/determined/ssh/id_rsa.pub"
 	shellEntrypointScript   = "/run/determined/ssh/shell-entrypoint.sh"
-	// Agent ports 2600 - 3500 are split between TensorBoards, Notebooks, and Shells.
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
 	minSshdPort = 3200
 	maxSshdPort = minSshdPort + 299
 )
 
 type shellManager struct {
-	db *db.PgDB
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
 
 	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
 	makeTaskSpec          tasks.MakeTaskSpecFn
+
+	// tasks are the running shells, which the manager lists.
+	tasks listIndex
 }
 
 // ShellLaunchRequest describes a request to launch a new shell.
@@ -49,17 +60,39 @@ type ShellLaunchRequest struct {
 }
 
 func (s *shellManager) Receive(ctx *actor.Context) error {
-	switch msg := ctx.Message().(type) {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
+	case actor.PreStart:
+		s.tasks = listIndex{}
+		restore(ctx, s.db, s.vault, s.artifacts, s.ca, s.makeTaskSpec)
+
+	case persistQueued:
+		persistQueuedChildren(ctx)
+
+	case listEntry, actor.ChildStopped, actor.ChildFailed:
+		s.tasks.update(msg)
+
+	case searchTasks:
+		ctx.Respond(s.tasks.search(msg, apiv1.SearchResult_KIND_SHELL))
+
 	case *apiv1.GetShellsRequest:
-		resp := &apiv1.GetShellsResponse{}
-		users := make(map[string]bool)
-		for _, user := range msg.Users {
-			users[user] = true
+		items, pagination, next, err := s.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+		}, &shellv1.Shell{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
 		}
-		for _, shell := range ctx.AskAll(&shellv1.Shell{}, ctx.Children()...).GetAll() {
-			if typed := shell.(*shellv1.Shell); len(users) == 0 || users[typed.Username] {
-				resp.Shells = append(resp.Shells, typed)
-			}
+		resp := &apiv1.GetShellsResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.Shells = append(resp.Shells, item.(*shellv1.Shell))
 		}
 		ctx.Respond(resp)
 
@@ -90,13 +123,23 @@ func (s *shellManager) processLaunchRequest(
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
+	userKeys, err := s.db.SSHKeys(params.User.ID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
 
 	ctx.Log().Info("creating shell")
 
-	shell := s.newShell(params, keys)
+	shell := s.newShell(params, keys, userKeys)
 	if err = check.Validate(shell.config); err != nil {
 		return nil, http.StatusBadRequest, err
 	}
+	if err = shell.checkBindMounts(s.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err = shell.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
 	a, _ := ctx.ActorOf(shell.taskID, shell)
 	summaryFut := ctx.Ask(a, getSummary{})
@@ -111,6 +154,7 @@ func (s *shellManager) processLaunchRequest(
 func (s *shellManager) newShell(
 	params *CommandParams,
 	keyPair ssh.PrivateAndPublicKeys,
+	userKeys []model.SSHKey,
 ) *command {
 	config := params.FullConfig
 
@@ -137,10 +181,21 @@ func (s *shellManager) newShell(
 
 	setPodSpec(config, params.TaskSpec.TaskContainerDefaults)
 
+	// The generated key pair is the host key of the shell. Unless the user registered SSH keys,
+	// the shell authorizes it as well and the user connects with its private key.
+	authorizedKeys, privateKey := keyPair.PublicKey, string(keyPair.PrivateKey)
+	if len(userKeys) > 0 {
+		var lines []string
+		for _, key := range userKeys {
+			lines = append(lines, key.PublicKey+" "+key.Name+"\n")
+		}
+		authorizedKeys, privateKey = []byte(strings.Join(lines, "")), ""
+	}
+
 	additionalFiles := archive.Archive{
 		params.AgentUserGroup.OwnedArchiveItem(shellSSHDir, nil, 0700, tar.TypeDir),
 		params.AgentUserGroup.OwnedArchiveItem(
-			shellAuthorizedKeysFile, keyPair.PublicKey, 0644, tar.TypeReg,
+			shellAuthorizedKeysFile, authorizedKeys, 0644, tar.TypeReg,
 		),
 		params.AgentUserGroup.OwnedArchiveItem(
 			shellHostPrivKeyFile, keyPair.PrivateKey, 0600, tar.TypeReg,
@@ -166,15 +221,15 @@ func (s *shellManager) newShell(
 		taskID:          taskID,
 		config:          *config,
 		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
 		additionalFiles: additionalFiles,
 		metadata: map[string]interface{}{
-			"privateKey": string(keyPair.PrivateKey),
+			"privateKey": privateKey,
 			"publicKey":  string(keyPair.PublicKey),
 		},
 		readinessChecks: map[string]readinessCheck{
-			"shell": func(log sproto.ContainerLog) bool {
-				return strings.Contains(log.String(), "Server listening on")
-			},
+			"shell": readinessChecksByName["shell"],
 		},
 
 		serviceAddress: &serviceAddress,
@@ -184,9 +239,14 @@ func (s *shellManager) newShell(
 		},
 		agentUserGroup: params.AgentUserGroup,
 		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
 
 		proxyTCP: true,
 
-		db: s.db,
+		db:        s.db,
+		vault:     s.vault,
+		artifacts: s.artifacts,
+		ca:        s.ca,
 	}
 }
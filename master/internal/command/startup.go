@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+// startupLogLines is the number of the last log lines of a command that its startup failure shows.
+const startupLogLines = 10
+
+// startupTimedOut tells a command that the startup timeout of its container passed.
+type startupTimedOut struct {
+	containerID container.ID
+}
+
+// startStartupTimeout has the command check that its container is ready once its startup timeout
+// passes, if it has one.
+func (c *command) startStartupTimeout(ctx *actor.Context) {
+	if c.config.StartupTimeout == 0 {
+		return
+	}
+	actors.NotifyAfter(ctx, time.Duration(c.config.StartupTimeout)*time.Second,
+		startupTimedOut{containerID: c.allocation.Summary().ID})
+}
+
+// ready returns whether the readiness checks of the command passed, or for commands without any,
+// whether its container runs.
+func (c *command) ready() bool {
+	if c.readinessMessageSent {
+		return true
+	}
+	return len(c.readinessChecks) == 0 && c.container != nil &&
+		c.container.State == container.Running
+}
+
+// addStartupLog keeps the last log lines of the command until it is ready, which its startup
+// failure shows.
+func (c *command) addStartupLog(log string) {
+	if c.ready() {
+		c.startupLogs = nil
+		return
+	}
+	c.startupLogs = append(c.startupLogs, strings.TrimRight(log, "\n"))
+	if len(c.startupLogs) > startupLogLines {
+		c.startupLogs = c.startupLogs[len(c.startupLogs)-startupLogLines:]
+	}
+}
+
+// checkStartup kills the containers of the command if it is not ready once its startup timeout
+// passed, failing it with the last lines of its logs.
+func (c *command) checkStartup(ctx *actor.Context, msg startupTimedOut) {
+	if c.exitStatus != nil || c.killed || c.allocation == nil ||
+		c.allocation.Summary().ID != msg.containerID || c.ready() {
+		return
+	}
+	failure := fmt.Sprintf(
+		"the task did not become ready within its startup_timeout of %d seconds",
+		c.config.StartupTimeout)
+	if len(c.startupLogs) > 0 {
+		failure += "; last logs:\n" + strings.Join(c.startupLogs, "\n")
+	}
+	ctx.Log().Info(failure)
+	c.startupFailure = &failure
+	c.killContainers(ctx)
+}
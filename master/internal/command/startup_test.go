@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/container"
+)
+
+func TestStartupLogs(t *testing.T) {
+	c := &command{readinessChecks: map[string]readinessCheck{
+		"ready": func(sproto.ContainerLog) bool { return false },
+	}}
+	for i := 0; i < startupLogLines+5; i++ {
+		c.addStartupLog(fmt.Sprintf("line %d\n", i))
+	}
+	assert.Equal(t, len(c.startupLogs), startupLogLines)
+	assert.Equal(t, c.startupLogs[0], "line 5")
+	assert.Equal(t, c.startupLogs[startupLogLines-1], fmt.Sprintf("line %d", startupLogLines+4))
+
+	// Running is not ready until the readiness checks pass.
+	c.container = &container.Container{State: container.Running}
+	assert.Assert(t, !c.ready())
+	c.readinessMessageSent = true
+	assert.Assert(t, c.ready())
+	c.addStartupLog("served")
+	assert.Assert(t, c.startupLogs == nil)
+
+	// Commands without readiness checks are ready once they run.
+	c = &command{container: &container.Container{State: container.Starting}}
+	assert.Assert(t, !c.ready())
+	c.container.State = container.Running
+	assert.Assert(t, c.ready())
+}
@@ -1,49 +1,68 @@
 package command
 
 import (
+	"time"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/proto/pkg/taskv1"
 )
 
-// State represents the current state of the container.
-type State string
+// reportedStatus is the most recent status reported by the code running inside a command.
+type reportedStatus struct {
+	Status     string    `json:"status"`
+	Progress   float64   `json:"progress"`
+	ReportTime time.Time `json:"report_time"`
+}
 
-func (s State) String() string {
-	return string(s)
+// Proto returns the proto representation of the reported status.
+func (r *reportedStatus) Proto() *taskv1.ReportedStatus {
+	if r == nil {
+		return nil
+	}
+	return &taskv1.ReportedStatus{
+		Status:     r.Status,
+		Progress:   r.Progress,
+		ReportTime: protoutils.ToTimestamp(r.ReportTime),
+	}
 }
 
-const (
-	// Pending state denotes that the command is awaiting allocation.
-	Pending State = "PENDING"
-	// Assigned state denotes that the command has been assigned to an agent but has not started
-	// yet.
-	Assigned State = "ASSIGNED"
-	// Pulling state denotes that the command's base image is being pulled from the Docker registry.
-	Pulling State = "PULLING"
-	// Starting state denotes that the image has been pulled and the task is being started, but the
-	// task is not ready yet.
-	Starting State = "STARTING"
-	// Running state denotes that the service in the command is running.
-	Running State = "RUNNING"
-	// Terminated state denotes that the command has exited or has been aborted
-	Terminated State = "TERMINATED"
-)
+// resourceUsage is the most recent utilization of the container of a command, sampled by its
+// agent. The utilization of its GPUs is their mean, and their memory is summed.
+type resourceUsage struct {
+	SampleTime            time.Time `json:"sample_time"`
+	CPUUtilizationPercent float64   `json:"cpu_utilization_percent"`
+	MemoryUsedBytes       uint64    `json:"memory_used_bytes"`
+	GPUUtilizationPercent float64   `json:"gpu_utilization_percent"`
+	GPUMemoryUsedMiB      int       `json:"gpu_memory_used_mib"`
+	GPUMemoryTotalMiB     int       `json:"gpu_memory_total_mib"`
+}
 
-// Proto returns the proto representation of the task state.
-func (s State) Proto() taskv1.State {
-	switch s {
-	case Pending:
-		return taskv1.State_STATE_PENDING
-	case Assigned:
-		return taskv1.State_STATE_ASSIGNED
-	case Pulling:
-		return taskv1.State_STATE_PULLING
-	case Starting:
-		return taskv1.State_STATE_STARTING
-	case Running:
-		return taskv1.State_STATE_RUNNING
-	case Terminated:
-		return taskv1.State_STATE_TERMINATED
-	default:
-		return taskv1.State_STATE_UNSPECIFIED
+func newResourceUsage(profile aproto.ContainerProfile) *resourceUsage {
+	u := &resourceUsage{
+		SampleTime:            profile.Time,
+		CPUUtilizationPercent: profile.CPUUtilizationPercent,
+		MemoryUsedBytes:       profile.MemoryUsedBytes,
+	}
+	for _, gpu := range profile.GPUs {
+		u.GPUUtilizationPercent += gpu.UtilizationPercent / float64(len(profile.GPUs))
+		u.GPUMemoryUsedMiB += gpu.MemoryUsedMiB
+		u.GPUMemoryTotalMiB += gpu.MemoryTotalMiB
+	}
+	return u
+}
+
+// Proto returns the proto representation of the resource usage.
+func (u *resourceUsage) Proto() *taskv1.ResourceUsage {
+	if u == nil {
+		return nil
+	}
+	return &taskv1.ResourceUsage{
+		SampleTime:            protoutils.ToTimestamp(u.SampleTime),
+		CpuUtilizationPercent: u.CPUUtilizationPercent,
+		MemoryUsedBytes:       int64(u.MemoryUsedBytes),
+		GpuUtilizationPercent: u.GPUUtilizationPercent,
+		GpuMemoryUsedMib:      int32(u.GPUMemoryUsedMiB),
+		GpuMemoryTotalMib:     int32(u.GPUMemoryTotalMiB),
 	}
 }
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	aproto "github.com/determined-ai/determined/master/pkg/agent"
+)
+
+func TestNewResourceUsage(t *testing.T) {
+	now := time.Now()
+	usage := newResourceUsage(aproto.ContainerProfile{
+		Time:                  now,
+		CPUUtilizationPercent: 150,
+		MemoryUsedBytes:       1 << 30,
+		GPUs: []aproto.DeviceUtilization{
+			{ID: 0, UtilizationPercent: 90, MemoryUsedMiB: 1000, MemoryTotalMiB: 16000},
+			{ID: 1, UtilizationPercent: 10, MemoryUsedMiB: 500, MemoryTotalMiB: 16000},
+		},
+	})
+	assert.DeepEqual(t, *usage, resourceUsage{
+		SampleTime:            now,
+		CPUUtilizationPercent: 150,
+		MemoryUsedBytes:       1 << 30,
+		GPUUtilizationPercent: 50,
+		GPUMemoryUsedMiB:      1500,
+		GPUMemoryTotalMiB:     32000,
+	})
+
+	// Containers without GPUs report no GPU utilization.
+	usage = newResourceUsage(aproto.ContainerProfile{Time: now, CPUUtilizationPercent: 5})
+	assert.Equal(t, usage.GPUUtilizationPercent, 0.0)
+	assert.Equal(t, usage.GPUMemoryTotalMiB, 0)
+}
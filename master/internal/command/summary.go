@@ -30,6 +30,9 @@ type (
 		IsReady        bool                   `json:"is_ready"`
 		AgentUserGroup *model.AgentUserGroup  `json:"agent_user_group"`
 		ResourcePool   string                 `json:"resource_pool"`
+		ReportedStatus *reportedStatus        `json:"reported_status"`
+		ResourceUsage  *resourceUsage         `json:"resource_usage"`
+		Kernels        *kernelActivity        `json:"kernels,omitempty"`
 	}
 )
 
@@ -48,5 +51,8 @@ func newSummary(c *command) summary {
 		IsReady:        c.readinessMessageSent,
 		AgentUserGroup: c.agentUserGroup,
 		ResourcePool:   c.config.Resources.ResourcePool,
+		ReportedStatus: c.reportedStatus,
+		ResourceUsage:  c.resourceUsage,
+		Kernels:        c.kernels,
 	}
 }
@@ -2,6 +2,7 @@ package command
 
 import (
 	"archive/tar"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,9 +14,12 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
@@ -33,7 +37,8 @@ import (
 
 const (
 	expConfPath = "/run/determined/workdir/experiment_config.json"
-	// Agent ports 2600 - 3500 are split between TensorBoards, Notebooks, and Shells.
+	// Agent ports 2600 - 4700 are split between TensorBoards, Notebooks, Shells, servings, code
+	// servers, and the dashboards and heads of Ray clusters.
 	minTensorBoardPort        = 2600
 	maxTensorBoardPort        = minTensorBoardPort + 299
 	tensorboardEntrypointFile = "/run/determined/workdir/tensorboard-entrypoint.sh"
@@ -47,6 +52,106 @@ type TensorboardRequest struct {
 
 	ExperimentIDs []int `json:"experiment_ids"`
 	TrialIDs      []int `json:"trial_ids"`
+	// LogPaths are the storage paths of logs that no experiment wrote, such as s3://bucket/prefix
+	// or gs://bucket/prefix.
+	LogPaths []string `json:"log_paths"`
+	// CheckpointStorage is the checkpoint storage of the master. TensorBoards read the log paths
+	// in its S3 bucket with its credentials.
+	CheckpointStorage expconf.CheckpointStorageConfig `json:"-"`
+	// Reuse is whether to reuse a running TensorBoard that another launch with reuse started for
+	// the same logs and config instead of starting another one.
+	Reuse bool `json:"-"`
+}
+
+// ReleaseTensorboard releases one launch by a user of a TensorBoard that launches with reuse
+// share. The manager responds with a ReleasedTensorboard.
+type ReleaseTensorboard struct {
+	ID       sproto.TaskID
+	Username string
+}
+
+// ReleasedTensorboard is whether the user of a ReleaseTensorboard shared the TensorBoard and how
+// many launches by any user still share it.
+type ReleasedTensorboard struct {
+	Shared    bool
+	Remaining int
+}
+
+// sharedTensorboard is a TensorBoard that launches with reuse share, with the number of launches
+// of each user that share it.
+type sharedTensorboard struct {
+	id    sproto.TaskID
+	users map[string]int
+}
+
+// sharedKey returns the key of the TensorBoards that a launch with reuse may share: those of the
+// same logs, credentials, project and config, whatever order the logs are listed in.
+func sharedKey(req TensorboardRequest) (string, error) {
+	experimentIDs := append([]int{}, req.ExperimentIDs...)
+	trialIDs := append([]int{}, req.TrialIDs...)
+	logPaths := append([]string{}, req.LogPaths...)
+	sort.Ints(experimentIDs)
+	sort.Ints(trialIDs)
+	sort.Strings(logPaths)
+	key, err := json.Marshal(struct {
+		ExperimentIDs     []int
+		TrialIDs          []int
+		LogPaths          []string
+		MasterCredentials bool
+		ProjectID         int
+		Config            *model.CommandConfig
+	}{
+		ExperimentIDs:     experimentIDs,
+		TrialIDs:          trialIDs,
+		LogPaths:          logPaths,
+		MasterCredentials: req.CheckpointStorage.RawS3Config != nil,
+		ProjectID:         req.CommandParams.ProjectID,
+		Config:            req.CommandParams.FullConfig,
+	})
+	return string(key), err
+}
+
+// parseLogPath returns the scheme and bucket of a storage path of logs, which must be in S3 or
+// GCS.
+func parseLogPath(path string) (scheme, bucket string, err error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid log path %s", path)
+	}
+	if (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+		return "", "", errors.Errorf(
+			"log path %s must be in the form s3://bucket/prefix or gs://bucket/prefix", path)
+	}
+	return u.Scheme, u.Host, nil
+}
+
+// setS3EnvVars sets the environment variables that TensorBoard reads an S3 bucket with: the
+// credentials and endpoint of its storage config.
+func setS3EnvVars(c expconf.S3Config, envVars map[string]string) error {
+	if c.AccessKey() != nil {
+		envVars["AWS_ACCESS_KEY_ID"] = *c.AccessKey()
+	}
+	if c.SecretKey() != nil {
+		envVars["AWS_SECRET_ACCESS_KEY"] = *c.SecretKey()
+	}
+	if c.EndpointURL() != nil {
+		endpoint, err := url.Parse(*c.EndpointURL())
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError,
+				"unable to parse checkpoint_storage.s3.endpoint_url")
+		}
+
+		// The TensorBoard container needs access to the original URL
+		// and the URL in "host:port" form.
+		envVars["DET_S3_ENDPOINT"] = *c.EndpointURL()
+		envVars["S3_ENDPOINT"] = endpoint.Host
+
+		envVars["S3_USE_HTTPS"] = "0"
+		if endpoint.Scheme == "https" {
+			envVars["S3_USE_HTTPS"] = "1"
+		}
+	}
+	return nil
 }
 
 type tensorboardConfig struct {
@@ -55,30 +160,68 @@ type tensorboardConfig struct {
 }
 
 type tensorboardManager struct {
-	db *db.PgDB
+	db        *db.PgDB
+	vault     *vault.Client
+	artifacts *artifacts.Service
+	ca        *ca.Authority
 
 	defaultAgentUserGroup model.AgentUserGroup
+	bindMountPolicy       model.BindMountPolicy
 	timeout               time.Duration
 	proxyRef              *actor.Ref
 	makeTaskSpec          tasks.MakeTaskSpecFn
+
+	// tasks are the running TensorBoards, which the manager lists.
+	tasks listIndex
+	// shared are the TensorBoards that launches with reuse share, by their sharedKey.
+	shared map[string]*sharedTensorboard
 }
 
 type tensorboardTick struct{}
 
 func (t *tensorboardManager) Receive(ctx *actor.Context) error {
-	switch msg := ctx.Message().(type) {
+	msg, projects := unwrapInProjects(ctx.Message())
+	switch msg := msg.(type) {
 	case actor.PreStart:
+		t.tasks = listIndex{}
+		t.shared = map[string]*sharedTensorboard{}
+		restore(ctx, t.db, t.vault, t.artifacts, t.ca, t.makeTaskSpec)
 		actors.NotifyAfter(ctx, tickInterval, tensorboardTick{})
+	case persistQueued:
+		persistQueuedChildren(ctx)
+	case listEntry:
+		t.tasks.update(msg)
+	case actor.ChildStopped:
+		t.tasks.update(msg)
+		t.unshare(sproto.TaskID(msg.Child.Address().Local()))
+	case actor.ChildFailed:
+		t.tasks.update(msg)
+		t.unshare(sproto.TaskID(msg.Child.Address().Local()))
+	case ReleaseTensorboard:
+		ctx.Respond(t.release(msg))
+	case searchTasks:
+		ctx.Respond(t.tasks.search(msg, apiv1.SearchResult_KIND_TENSORBOARD))
+	case archivableTasks:
+		ctx.Respond(t.tasks.archivable(msg))
 	case *apiv1.GetTensorboardsRequest:
-		resp := &apiv1.GetTensorboardsResponse{}
-		users := make(map[string]bool)
-		for _, user := range msg.Users {
-			users[user] = true
+		items, pagination, next, err := t.tasks.page(ctx, listRequest{
+			sortBy:    int32(msg.SortBy),
+			orderBy:   msg.OrderBy,
+			offset:    msg.Offset,
+			limit:     msg.Limit,
+			cursor:    msg.Cursor,
+			users:     msg.Users,
+			projectID: msg.ProjectId,
+			projects:  projects,
+			archived:  archivedFilter(msg.Archived),
+		}, &tensorboardv1.Tensorboard{})
+		if err != nil {
+			ctx.Respond(err)
+			return nil
 		}
-		for _, tensorboard := range ctx.AskAll(&tensorboardv1.Tensorboard{}, ctx.Children()...).GetAll() {
-			if typed := tensorboard.(*tensorboardv1.Tensorboard); len(users) == 0 || users[typed.Username] {
-				resp.Tensorboards = append(resp.Tensorboards, typed)
-			}
+		resp := &apiv1.GetTensorboardsResponse{Pagination: pagination, NextCursor: next}
+		for _, item := range items {
+			resp.Tensorboards = append(resp.Tensorboards, item.(*tensorboardv1.Tensorboard))
 		}
 		ctx.Respond(resp)
 
@@ -103,6 +246,19 @@ func (t *tensorboardManager) Receive(ctx *actor.Context) error {
 
 		actors.NotifyAfter(ctx, tickInterval, tensorboardTick{})
 	case TensorboardRequest:
+		var key string
+		if msg.Reuse {
+			var err error
+			if key, err = sharedKey(msg); err != nil {
+				ctx.Respond(errors.Wrap(err, "failed to launch Tensorboard"))
+				return nil
+			}
+			if id, ok := t.reuse(ctx, key, msg.CommandParams.User.Username); ok {
+				ctx.Log().Infof("reusing tensorboard %s", id)
+				ctx.Respond(id)
+				return nil
+			}
+		}
 		summary, statusCode, err := t.processLaunchRequest(ctx, &msg)
 		if err != nil || statusCode > 200 {
 			ctx.Respond(echo.NewHTTPError(statusCode,
@@ -110,12 +266,70 @@ func (t *tensorboardManager) Receive(ctx *actor.Context) error {
 			))
 			return nil
 		}
+		if msg.Reuse {
+			t.shared[key] = &sharedTensorboard{
+				id:    summary.ID,
+				users: map[string]int{msg.CommandParams.User.Username: 1},
+			}
+		}
 		ctx.Respond(summary.ID)
 	}
 
 	return nil
 }
 
+// reuse adds a launch by a user to the TensorBoard of a shared key, if it has not terminated.
+func (t *tensorboardManager) reuse(
+	ctx *actor.Context, key, username string,
+) (sproto.TaskID, bool) {
+	shared, ok := t.shared[key]
+	if !ok {
+		return "", false
+	}
+	ref := ctx.Child(string(shared.id))
+	if ref == nil {
+		delete(t.shared, key)
+		return "", false
+	}
+	s, ok := ctx.Ask(ref, getSummary{}).Get().(summary)
+	if !ok || s.State == model.TaskStateTerminated.String() {
+		return "", false
+	}
+	shared.users[username]++
+	return shared.id, true
+}
+
+// release removes a launch by a user from the TensorBoard that it shares.
+func (t *tensorboardManager) release(msg ReleaseTensorboard) ReleasedTensorboard {
+	for _, shared := range t.shared {
+		if shared.id != msg.ID {
+			continue
+		}
+		if shared.users[msg.Username] == 0 {
+			return ReleasedTensorboard{}
+		}
+		shared.users[msg.Username]--
+		if shared.users[msg.Username] == 0 {
+			delete(shared.users, msg.Username)
+		}
+		var remaining int
+		for _, n := range shared.users {
+			remaining += n
+		}
+		return ReleasedTensorboard{Shared: true, Remaining: remaining}
+	}
+	return ReleasedTensorboard{}
+}
+
+// unshare stops launches with reuse from sharing a TensorBoard that has stopped.
+func (t *tensorboardManager) unshare(id sproto.TaskID) {
+	for key, shared := range t.shared {
+		if shared.id == id {
+			delete(t.shared, key)
+		}
+	}
+}
+
 func (t *tensorboardManager) processLaunchRequest(
 	ctx *actor.Context,
 	req *TensorboardRequest,
@@ -123,13 +337,18 @@ func (t *tensorboardManager) processLaunchRequest(
 	var err error
 	params := req.CommandParams
 
-	if len(req.ExperimentIDs) == 0 && len(req.TrialIDs) == 0 {
-		err = errors.New("must set experiment or trial ids")
+	if len(req.ExperimentIDs) == 0 && len(req.TrialIDs) == 0 && len(req.LogPaths) == 0 {
+		err = errors.New("must set experiment ids, trial ids or log paths")
 		return nil, http.StatusBadRequest, err
 	}
+	for _, path := range req.LogPaths {
+		if _, _, err = parseLogPath(path); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
 
-	ctx.Log().Infof("creating tensorboard (experiment id(s): %v trial id(s): %v)",
-		req.ExperimentIDs, req.TrialIDs)
+	ctx.Log().Infof("creating tensorboard (experiment id(s): %v trial id(s): %v log path(s): %v)",
+		req.ExperimentIDs, req.TrialIDs, req.LogPaths)
 
 	b, err := t.newTensorBoard(params, *req)
 
@@ -142,6 +361,12 @@ func (t *tensorboardManager) processLaunchRequest(
 		err = errors.Wrap(err, "failed to validate tensorboard config")
 		return nil, http.StatusBadRequest, err
 	}
+	if err := b.checkBindMounts(t.bindMountPolicy); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+	if err := b.checkSecrets(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 
 	a, _ := ctx.ActorOf(b.taskID, b)
 	summaryFut := ctx.Ask(a, getSummary{})
@@ -162,7 +387,7 @@ func (t *tensorboardManager) newTensorBoard(
 		return nil, echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	if len(exps) == 0 {
+	if len(exps) == 0 && len(req.LogPaths) == 0 {
 		return nil, echo.NewHTTPError(http.StatusInternalServerError, "no experiments found")
 	}
 
@@ -203,28 +428,8 @@ func (t *tensorboardManager) newTensorBoard(
 			logBasePath = c.PathInContainer()
 
 		case expconf.S3Config:
-			if c.AccessKey() != nil {
-				uniqEnvVars["AWS_ACCESS_KEY_ID"] = *c.AccessKey()
-			}
-			if c.SecretKey() != nil {
-				uniqEnvVars["AWS_SECRET_ACCESS_KEY"] = *c.SecretKey()
-			}
-			if c.EndpointURL() != nil {
-				endpoint, urlErr := url.Parse(*c.EndpointURL())
-				if urlErr != nil {
-					return nil, echo.NewHTTPError(http.StatusInternalServerError,
-						"unable to parse checkpoint_storage.s3.endpoint_url")
-				}
-
-				// The TensorBoard container needs access to the original URL
-				// and the URL in "host:port" form.
-				uniqEnvVars["DET_S3_ENDPOINT"] = *c.EndpointURL()
-				uniqEnvVars["S3_ENDPOINT"] = endpoint.Host
-
-				uniqEnvVars["S3_USE_HTTPS"] = "0"
-				if endpoint.Scheme == "https" {
-					uniqEnvVars["S3_USE_HTTPS"] = "1"
-				}
+			if err = setS3EnvVars(c, uniqEnvVars); err != nil {
+				return nil, err
 			}
 
 			uniqEnvVars["AWS_BUCKET"] = c.Bucket()
@@ -266,22 +471,42 @@ func (t *tensorboardManager) newTensorBoard(
 		}
 	}
 
+	// Log paths in S3 are read with the credentials of the checkpoint storage of the master if
+	// they are in its bucket, and otherwise with those that the environment of the TensorBoard
+	// provides.
+	for _, path := range req.LogPaths {
+		scheme, bucket, err := parseLogPath(path)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if scheme == "s3" {
+			c, ok := req.CheckpointStorage.GetUnionMember().(expconf.S3Config)
+			if ok && c.Bucket() == bucket {
+				if err = setS3EnvVars(c, uniqEnvVars); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := uniqEnvVars["AWS_BUCKET"]; !ok {
+				uniqEnvVars["AWS_BUCKET"] = bucket
+			}
+		}
+		logDirs = append(logDirs, path)
+	}
+
 	// Get the most recent experiment config as raw json and add it to the container. This
 	// is used to determine if the experiment is backed by S3.
-	mostRecentExpID := exps[len(exps)-1].ID
-	confBytes, err := t.db.ExperimentConfigRaw(mostRecentExpID)
-	if err != nil {
-		return nil, errors.Wrapf(err, "error loading raw experiment config: %d", mostRecentExpID)
-	}
+	if len(exps) > 0 {
+		mostRecentExpID := exps[len(exps)-1].ID
+		confBytes, err := t.db.ExperimentConfigRaw(mostRecentExpID)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "error loading raw experiment config: %d", mostRecentExpID)
+		}
 
-	if err != nil {
-		return nil, echo.NewHTTPError(http.StatusInternalServerError,
-			"unable to marshal experiment configuration")
+		additionalFiles = append(additionalFiles,
+			params.AgentUserGroup.OwnedArchiveItem(expConfPath, confBytes, 0700, tar.TypeReg))
 	}
 
-	additionalFiles = append(additionalFiles,
-		params.AgentUserGroup.OwnedArchiveItem(expConfPath, confBytes, 0700, tar.TypeReg))
-
 	// Multiple experiments may have different s3 credentials. We sort the
 	// experiments in ascending experiment ID order and dedupicate the
 	// environment variables by key name. This gives the behavior of selecting
@@ -316,15 +541,16 @@ func (t *tensorboardManager) newTensorBoard(
 		taskID:          taskID,
 		config:          *config,
 		userFiles:       params.UserFiles,
+		contextArtifact: params.ContextArtifact,
+		tempContext:     params.TempContext,
 		additionalFiles: additionalFiles,
 		metadata: map[string]interface{}{
 			"experiment_ids": req.ExperimentIDs,
 			"trial_ids":      req.TrialIDs,
+			"log_paths":      req.LogPaths,
 		},
 		readinessChecks: map[string]readinessCheck{
-			"tensorboard": func(log sproto.ContainerLog) bool {
-				return strings.Contains(log.String(), "TensorBoard contains metrics")
-			},
+			"tensorboard": readinessChecksByName["tensorboard"],
 		},
 		serviceAddress: &serviceAddress,
 		owner: commandOwner{
@@ -333,8 +559,13 @@ func (t *tensorboardManager) newTensorBoard(
 		},
 		agentUserGroup: params.AgentUserGroup,
 		taskSpec:       params.TaskSpec,
+		projectID:      params.ProjectID,
+		spanContext:    params.SpanContext,
 
-		db: t.db,
+		db:        t.db,
+		vault:     t.vault,
+		artifacts: t.artifacts,
+		ca:        t.ca,
 	}, nil
 }
 
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func TestRefineArgs(t *testing.T) {
@@ -34,3 +36,48 @@ func TestRefineArgs(t *testing.T) {
 
 	assert.DeepEqual(t, args, expected)
 }
+
+func TestParseLogPath(t *testing.T) {
+	scheme, bucket, err := parseLogPath("s3://logs/runs/1")
+	assert.NilError(t, err)
+	assert.Equal(t, scheme, "s3")
+	assert.Equal(t, bucket, "logs")
+
+	scheme, bucket, err = parseLogPath("gs://logs")
+	assert.NilError(t, err)
+	assert.Equal(t, scheme, "gs")
+	assert.Equal(t, bucket, "logs")
+
+	for _, path := range []string{"/mnt/logs", "hdfs://logs/runs", "s3:///runs"} {
+		_, _, err = parseLogPath(path)
+		assert.ErrorContains(t, err, "must be in the form")
+	}
+}
+
+func TestSharedKey(t *testing.T) {
+	params := &CommandParams{ProjectID: 1, FullConfig: &model.CommandConfig{}}
+	key, err := sharedKey(TensorboardRequest{
+		CommandParams: params, ExperimentIDs: []int{2, 1}, LogPaths: []string{"s3://b", "gs://a"},
+	})
+	assert.NilError(t, err)
+
+	reordered, err := sharedKey(TensorboardRequest{
+		CommandParams: params, ExperimentIDs: []int{1, 2}, LogPaths: []string{"gs://a", "s3://b"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, key, reordered)
+
+	others := []TensorboardRequest{
+		{CommandParams: params, ExperimentIDs: []int{1}, LogPaths: []string{"gs://a", "s3://b"}},
+		{CommandParams: params, ExperimentIDs: []int{1, 2}, TrialIDs: []int{3}},
+		{
+			CommandParams: &CommandParams{ProjectID: 2, FullConfig: &model.CommandConfig{}},
+			ExperimentIDs: []int{1, 2}, LogPaths: []string{"gs://a", "s3://b"},
+		},
+	}
+	for _, req := range others {
+		other, err := sharedKey(req)
+		assert.NilError(t, err)
+		assert.Assert(t, key != other)
+	}
+}
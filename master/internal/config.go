@@ -2,15 +2,30 @@ package internal
 
 import (
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/determined-ai/determined/master/internal/apilimits"
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/backup"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/hpimportance"
+	"github.com/determined-ai/determined/master/internal/mlflow"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/scim"
+	"github.com/determined-ai/determined/master/internal/sso"
+	"github.com/determined-ai/determined/master/internal/tracing"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
@@ -40,6 +55,7 @@ func DefaultConfig() *Config {
 				User:  "root",
 				Group: "root",
 			},
+			BindTaskTokens: true,
 		},
 		// If left unspecified, the port is later filled in with 8080 (no TLS) or 8443 (TLS).
 		Port:        0,
@@ -61,6 +77,28 @@ func DefaultConfig() *Config {
 			CoresPerWorker: 1,
 			MaxTrees:       100,
 		},
+		ActorWatchdog: ActorWatchdogConfig{
+			SlowHandlerTimeout: 2 * 60,
+		},
+		LogRetention: LogRetentionConfig{
+			PruneInterval: 60 * 60,
+		},
+		Artifacts: artifacts.Config{
+			Type: artifacts.DBType,
+			ChunkCache: artifacts.ChunkCacheConfig{
+				MaxAgeHours:      7 * 24,
+				EvictionInterval: 60 * 60,
+			},
+		},
+		MetricsDownsampling: MetricsDownsamplingConfig{
+			MaxStepsPerTrial: 1000,
+			Interval:         60 * 60,
+		},
+		PrometheusRemoteWrite: PrometheusRemoteWriteConfig{
+			Interval:       60,
+			ClusterMetrics: []string{"det_resource_pool_", "det_agent_"},
+		},
+		Tracing:        tracing.DefaultConfig(),
 		ResourceConfig: resourcemanagers.DefaultResourceConfig(),
 	}
 }
@@ -74,6 +112,7 @@ type Config struct {
 	Log                   logger.Config                     `json:"log"`
 	DB                    db.Config                         `json:"db"`
 	TensorBoardTimeout    int                               `json:"tensorboard_timeout"`
+	NotebookTimeout       int                               `json:"notebook_timeout"`
 	Security              SecurityConfig                    `json:"security"`
 	CheckpointStorage     expconf.CheckpointStorageConfig   `json:"checkpoint_storage"`
 	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
@@ -85,16 +124,68 @@ type Config struct {
 	ClusterName           string                            `json:"cluster_name"`
 	Logging               model.LoggingConfig               `json:"logging"`
 	HPImportance          hpimportance.HPImportanceConfig   `json:"hyperparameter_importance"`
+	AuditLog              audit.Config                      `json:"audit_log"`
+	Vault                 vault.Config                      `json:"vault"`
+	APILimits             apilimits.Config                  `json:"api_limits"`
+	Tracing               tracing.Config                    `json:"tracing"`
+	ActorWatchdog         ActorWatchdogConfig               `json:"actor_watchdog"`
+	LogRetention          LogRetentionConfig                `json:"log_retention"`
+	MetricsDownsampling   MetricsDownsamplingConfig         `json:"metrics_downsampling"`
+	PrometheusRemoteWrite PrometheusRemoteWriteConfig       `json:"prometheus_remote_write"`
+	Artifacts             artifacts.Config                  `json:"artifacts"`
+	Backup                backup.Config                     `json:"backup"`
+	Tenancy               TenancyConfig                     `json:"tenancy"`
+	PoolRouting           PoolRoutingConfig                 `json:"pool_routing"`
+	MLflow                mlflow.Config                     `json:"mlflow"`
 
 	*resourcemanagers.ResourceConfig
+
+	// Sources are where each setting came from, by its dotted path, e.g. "db.host". Lists are
+	// single settings. It is nil unless the configuration was read from its sources.
+	Sources map[string]ConfigSource `json:"-"`
 }
 
+// ConfigSource is where a setting of the configuration came from.
+type ConfigSource string
+
+// The sources of settings, from the lowest to the highest precedence.
+const (
+	ConfigSourceDefault ConfigSource = "default"
+	ConfigSourceFile    ConfigSource = "file"
+	ConfigSourceEnv     ConfigSource = "env"
+	ConfigSourceFlag    ConfigSource = "flag"
+)
+
 // Printable returns a printable string.
 func (c Config) Printable() ([]byte, error) {
 	const hiddenValue = "********"
 	c.DB.Password = hiddenValue
 	c.Telemetry.SegmentMasterKey = hiddenValue
 	c.Telemetry.SegmentWebUIKey = hiddenValue
+	if c.Security.RegistryCredentialsKey != "" {
+		c.Security.RegistryCredentialsKey = hiddenValue
+	}
+	if c.Security.SecretsKey != "" {
+		c.Security.SecretsKey = hiddenValue
+	}
+	if c.Security.StorageCredentialsKey != "" {
+		c.Security.StorageCredentialsKey = hiddenValue
+	}
+	c.Security.SSO = c.Security.SSO.Printable()
+	c.Security.SCIM = c.Security.SCIM.Printable()
+	c.Vault = c.Vault.Printable()
+	c.Artifacts = c.Artifacts.Printable()
+	c.Backup = c.Backup.Printable()
+	c.Tracing = c.Tracing.Printable()
+	if c.PrometheusRemoteWrite.BearerToken != "" {
+		c.PrometheusRemoteWrite.BearerToken = hiddenValue
+	}
+	if elastic := c.Logging.ElasticLoggingConfig; elastic != nil && elastic.Security.Password != nil {
+		hiddenPassword := hiddenValue
+		printable := *elastic
+		printable.Security.Password = &hiddenPassword
+		c.Logging.ElasticLoggingConfig = &printable
+	}
 
 	c.CheckpointStorage.Printable()
 
@@ -123,8 +214,8 @@ func (c *Config) Resolve() error {
 
 	c.DB.Migrations = fmt.Sprintf("file://%s", filepath.Join(c.Root, "static/migrations"))
 
-	if c.ResourceManager.AgentRM != nil && c.ResourceManager.AgentRM.Scheduler == nil {
-		c.ResourceManager.AgentRM.Scheduler = resourcemanagers.DefaultSchedulerConfig()
+	if agentRM := c.AgentResourceManager(); agentRM != nil && agentRM.Scheduler == nil {
+		agentRM.Scheduler = resourcemanagers.DefaultSchedulerConfig()
 	}
 
 	if err := c.ResolveResource(); err != nil {
@@ -142,6 +233,95 @@ func (c *Config) Resolve() error {
 type SecurityConfig struct {
 	DefaultTask model.AgentUserGroup `json:"default_task"`
 	TLS         TLSConfig            `json:"tls"`
+	// RequireNonRoot rejects tasks that would run as the root user or group on the agent.
+	RequireNonRoot bool `json:"require_non_root"`
+	// RegistryCredentialsKey is the base64-encoded AES key that users' registry credentials are
+	// encrypted with in the database. Storing registry credentials is disabled if it is empty.
+	RegistryCredentialsKey string `json:"registry_credentials_key"`
+	// SecretsKey is the base64-encoded AES key that users' secrets are encrypted with in the
+	// database. Storing secrets is disabled if it is empty.
+	SecretsKey string `json:"secrets_key"`
+	// StorageCredentialsKey is the base64-encoded AES key that the storage credentials of
+	// experiments and templates are encrypted with in the database. They are stored apart from
+	// configs but unencrypted if it is empty.
+	StorageCredentialsKey string `json:"storage_credentials_key"`
+	// BindMounts restricts the host paths that commands and experiments may bind-mount.
+	BindMounts model.BindMountPolicy `json:"bind_mounts"`
+	// SSO lets users log in through an OpenID Connect or SAML identity provider.
+	SSO sso.Config `json:"sso"`
+	// SCIM lets identity providers create and deactivate users and manage groups.
+	SCIM scim.Config `json:"scim"`
+	// BindTaskTokens restricts the token of each task to the addresses of its containers. It
+	// should be disabled if tasks reach the master through a proxy.
+	BindTaskTokens bool `json:"bind_task_tokens"`
+	// MTLS issues client certificates to agents and tasks and requires them on the data plane.
+	MTLS ca.Config `json:"mtls"`
+}
+
+// Validate implements the check.Validatable interface.
+func (s SecurityConfig) Validate() []error {
+	var errs []error
+	if s.RequireNonRoot && s.DefaultTask.IsRoot() {
+		errs = append(errs, errors.New(
+			"security.default_task must not run as root when security.require_non_root is set"))
+	}
+	if s.RegistryCredentialsKey != "" {
+		if _, err := s.RegistryCredentialsKeyBytes(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.SecretsKey != "" {
+		if _, err := s.SecretsKeyBytes(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.StorageCredentialsKey != "" {
+		if _, err := s.StorageCredentialsKeyBytes(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.MTLS.Enabled && !s.TLS.Enabled() {
+		errs = append(errs, errors.New("security.mtls requires security.tls to be configured"))
+	}
+	return errs
+}
+
+// RegistryCredentialsKeyBytes decodes the registry credentials key.
+func (s SecurityConfig) RegistryCredentialsKeyBytes() ([]byte, error) {
+	return decodeAESKey("security.registry_credentials_key", s.RegistryCredentialsKey)
+}
+
+// SecretsKeyBytes decodes the secrets key.
+func (s SecurityConfig) SecretsKeyBytes() ([]byte, error) {
+	return decodeAESKey("security.secrets_key", s.SecretsKey)
+}
+
+// StorageCredentialsKeyBytes decodes the storage credentials key.
+func (s SecurityConfig) StorageCredentialsKeyBytes() ([]byte, error) {
+	return decodeAESKey("security.storage_credentials_key", s.StorageCredentialsKey)
+}
+
+// decodeAESKey decodes a base64-encoded AES key from the option with the given name.
+func decodeAESKey(option, encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s must be base64-encoded", option)
+	}
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.Errorf("%s must be 16, 24 or 32 bytes long, got %d", option, len(key))
+	}
+	return key, nil
+}
+
+// CheckAgentUserGroup returns an error if the security policy forbids running tasks as the given
+// agent user and group.
+func (s SecurityConfig) CheckAgentUserGroup(aug model.AgentUserGroup) error {
+	if s.RequireNonRoot && aug.IsRoot() {
+		return errors.Errorf(
+			"tasks may not run as root (agent user %s, uid %d, group %s, gid %d); "+
+				"set a non-root agent user and group for the user", aug.User, aug.UID, aug.Group, aug.GID)
+	}
+	return nil
 }
 
 // TLSConfig is the configuration for setting up serving over TLS.
@@ -176,6 +356,129 @@ func (t *TLSConfig) ReadCertificate() (*tls.Certificate, error) {
 	return &cert, err
 }
 
+// ActorWatchdogConfig is the configuration of the watchdog that logs the actors of the master that
+// are slow to process messages.
+type ActorWatchdogConfig struct {
+	// SlowHandlerTimeout is the duration in seconds after which an actor that is still processing
+	// a message is logged with the stack traces of all goroutines. The watchdog is disabled if it
+	// is zero.
+	SlowHandlerTimeout int `json:"slow_handler_timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c ActorWatchdogConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.SlowHandlerTimeout, 0,
+			"actor_watchdog.slow_handler_timeout must not be negative"),
+	}
+}
+
+// TenancyConfig is the configuration of how tenants are isolated from each other.
+type TenancyConfig struct {
+	// Strict is whether the users of a tenant only see and use the users, workspaces, resource
+	// pools and tasks of their own tenant, as well as the services that those tasks proxy.
+	Strict bool `json:"strict"`
+}
+
+// PoolRoutingConfig is the configuration of the resource pools that experiments and interactive
+// tasks go to when they name none, so that large experiments do not hold up notebooks, shells and
+// TensorBoards. The defaults of the resource manager apply to the kinds of tasks without a pool.
+type PoolRoutingConfig struct {
+	// InteractiveResourcePool is the pool of notebooks, shells, TensorBoards and code servers.
+	InteractiveResourcePool string `json:"interactive_resource_pool"`
+	// BatchResourcePool is the pool of experiments.
+	BatchResourcePool string `json:"batch_resource_pool"`
+}
+
+// LogRetentionConfig is the configuration of how long the master keeps trial logs in the
+// database. The log_retention section of the configuration of an experiment overrides it for the
+// trials of the experiment.
+type LogRetentionConfig struct {
+	// Days is the number of days after a trial ends that its logs are deleted. Logs are kept
+	// indefinitely if it is zero.
+	Days int `json:"days"`
+	// MaxLinesPerTrial is the number of lines of its most recent logs that each trial keeps. Trials
+	// keep all their logs if it is zero.
+	MaxLinesPerTrial int `json:"max_lines_per_trial"`
+	// PruneInterval is the duration in seconds between the runs of the job that deletes logs.
+	PruneInterval int `json:"prune_interval"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c LogRetentionConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.Days, 0, "log_retention.days must not be negative"),
+		check.GreaterThanOrEqualTo(c.MaxLinesPerTrial, 0,
+			"log_retention.max_lines_per_trial must not be negative"),
+		check.GreaterThan(c.PruneInterval, 0, "log_retention.prune_interval must be positive"),
+	}
+}
+
+// MetricsDownsamplingConfig is the configuration of how the master thins out the training metrics
+// of old experiments, so that the metrics of trials with many steps are quick to query.
+type MetricsDownsamplingConfig struct {
+	// AfterDays is the number of days after an experiment ends that the training metrics of its
+	// trials are downsampled. Metrics are never downsampled if it is zero.
+	AfterDays int `json:"after_days"`
+	// MaxStepsPerTrial is the number of steps of training metrics that each trial keeps, besides
+	// the steps at which it validated or checkpointed and its last step.
+	MaxStepsPerTrial int `json:"max_steps_per_trial"`
+	// Interval is the duration in seconds between the runs of the job that downsamples metrics.
+	Interval int `json:"interval"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c MetricsDownsamplingConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(c.AfterDays, 0,
+			"metrics_downsampling.after_days must not be negative"),
+		check.GreaterThan(c.MaxStepsPerTrial, 0,
+			"metrics_downsampling.max_steps_per_trial must be positive"),
+		check.GreaterThan(c.Interval, 0, "metrics_downsampling.interval must be positive"),
+	}
+}
+
+// prometheusLabelName matches the valid names of Prometheus labels.
+var prometheusLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PrometheusRemoteWriteConfig is the configuration of how the master exports the metrics of
+// trials and of the cluster to a Prometheus-compatible remote write endpoint.
+type PrometheusRemoteWriteConfig struct {
+	// URL is the remote write endpoint. Metrics are not exported if it is empty.
+	URL string `json:"url"`
+	// BearerToken authenticates the master to the endpoint, if set.
+	BearerToken string `json:"bearer_token"`
+	// Interval is the duration in seconds between writes.
+	Interval int `json:"interval"`
+	// TrainingMetrics and ValidationMetrics are the names of the metrics of trials to export.
+	TrainingMetrics   []string `json:"training_metrics"`
+	ValidationMetrics []string `json:"validation_metrics"`
+	// ClusterMetrics are the prefixes of the names of the metrics served at /metrics to export.
+	ClusterMetrics []string `json:"cluster_metrics"`
+	// Labels are added to every exported series, e.g. to tell clusters apart.
+	Labels map[string]string `json:"labels"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c PrometheusRemoteWriteConfig) Validate() []error {
+	if c.URL == "" {
+		return nil
+	}
+	errs := []error{
+		check.GreaterThan(c.Interval, 0, "prometheus_remote_write.interval must be positive"),
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		errs = append(errs, errors.Errorf(
+			"prometheus_remote_write.url must be an http or https URL: %s", c.URL))
+	}
+	for name := range c.Labels {
+		errs = append(errs, check.True(prometheusLabelName.MatchString(name) &&
+			!strings.HasPrefix(name, "__"),
+			"prometheus_remote_write.labels has an invalid label name: %s", name))
+	}
+	return errs
+}
+
 // TelemetryConfig is the configuration for telemetry.
 type TelemetryConfig struct {
 	Enabled          bool   `json:"enabled"`
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// configPolicies are the config policies that apply to an experiment or command, in the order that
+// they were added. The invariant configs of later policies take precedence, and every constraint
+// of every policy must hold.
+type configPolicies []model.ConfigPolicy
+
+// configPolicies returns the config policies of a workspace and of the groups of a user.
+func (m *Master) configPolicies(userID model.UserID, workspaceID int) (configPolicies, error) {
+	policies, err := m.db.UserConfigPolicies(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return configPolicies(policies), nil
+}
+
+// parseInvariantExperimentConfig parses the invariant experiment config of a policy, which may set
+// any part of an experiment config.
+func parseInvariantExperimentConfig(invariant model.JSONObj) (expconf.ExperimentConfig, error) {
+	var config expconf.ExperimentConfig
+	b, err := json.Marshal(invariant)
+	if err != nil {
+		return config, err
+	}
+	if err = yaml.Unmarshal(b, &config, yaml.DisallowUnknownFields); err != nil {
+		return config, errors.Wrap(err, "invalid invariant experiment config")
+	}
+	return config, nil
+}
+
+// decodeInvariantCommandConfig decodes the invariant command config of a policy over a command
+// config, replacing the values that it sets.
+func decodeInvariantCommandConfig(invariant model.JSONObj, config *model.CommandConfig) error {
+	if len(invariant) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(invariant)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return errors.Wrap(dec.Decode(config), "invalid invariant command config")
+}
+
+// mergeIntoExperimentConfig merges the invariant experiment configs of the policies over a config.
+func (p configPolicies) mergeIntoExperimentConfig(config expconf.ExperimentConfig) (
+	expconf.ExperimentConfig, error,
+) {
+	for _, policy := range p {
+		if len(policy.InvariantExperimentConfig) == 0 {
+			continue
+		}
+		invariant, err := parseInvariantExperimentConfig(policy.InvariantExperimentConfig)
+		if err != nil {
+			return config, errors.Wrapf(err, "config policy %d", policy.ID)
+		}
+		config = schemas.Merge(invariant, config).(expconf.ExperimentConfig)
+	}
+	return config, nil
+}
+
+// mergeIntoCommandConfig merges the invariant command configs of the policies over a config.
+func (p configPolicies) mergeIntoCommandConfig(config *model.CommandConfig) error {
+	for _, policy := range p {
+		if err := decodeInvariantCommandConfig(policy.InvariantCommandConfig, config); err != nil {
+			return errors.Wrapf(err, "config policy %d", policy.ID)
+		}
+	}
+	return nil
+}
+
+// commandResourcePool returns the resource pool that the invariant command configs of the policies
+// force commands into, or pool if they don't.
+func (p configPolicies) commandResourcePool(pool string) string {
+	for _, policy := range p {
+		resources, ok := policy.InvariantCommandConfig["resources"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if forced, ok := resources["resource_pool"].(string); ok {
+			pool = forced
+		}
+	}
+	return pool
+}
+
+// experimentResourcePool returns the resource pool that the invariant experiment configs of the
+// policies force experiments into, or pool if they don't.
+func (p configPolicies) experimentResourcePool(pool string) string {
+	for _, policy := range p {
+		resources, ok := policy.InvariantExperimentConfig["resources"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if forced, ok := resources["resource_pool"].(string); ok {
+			pool = forced
+		}
+	}
+	return pool
+}
+
+// checkConstraints returns an error for each constraint of the policies that a trial or command
+// that uses the slots and runs the image violates. slotsField is the path of the slots in its
+// config.
+func (p configPolicies) checkConstraints(slotsField string, slots int, image string) []error {
+	var errs []error
+	for _, policy := range p {
+		c := policy.Constraints
+		if !c.AllowsSlots(slots) {
+			errs = append(errs, configFieldError{field: slotsField, err: errors.Errorf(
+				"config policy %d allows at most %d slots, not %d", policy.ID, *c.MaxSlots, slots)})
+		}
+		if !c.AllowsImage(image) {
+			errs = append(errs, configFieldError{field: "environment.image", err: errors.Errorf(
+				"config policy %d does not allow the image %s, only %s",
+				policy.ID, image, strings.Join(c.AllowedImages, ", "))})
+		}
+	}
+	return errs
+}
+
+// checkExperimentConfig returns an error for each constraint of the policies that the trials of an
+// experiment violate.
+func (p configPolicies) checkExperimentConfig(config expconf.ExperimentConfig) []error {
+	slots := config.Resources().SlotsPerTrial()
+	image := config.Environment().Image().CPU()
+	if slots > 0 {
+		image = config.Environment().Image().GPU()
+	}
+	return p.checkConstraints("resources.slots_per_trial", slots, image)
+}
+
+// checkCommandConfig returns an error for each constraint of the policies that a command violates.
+func (p configPolicies) checkCommandConfig(config model.CommandConfig) []error {
+	image := config.Environment.Image.CPU
+	if config.Resources.Slots > 0 {
+		image = config.Environment.Image.GPU
+	}
+	return p.checkConstraints("resources.slots", config.Resources.Slots, image)
+}
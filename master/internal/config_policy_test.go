@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestConfigPoliciesCommandConfig(t *testing.T) {
+	maxSlots := 2
+	policies := configPolicies{
+		{
+			ID: 1,
+			InvariantCommandConfig: model.JSONObj{
+				"resources": map[string]interface{}{"resource_pool": "shared"},
+			},
+		},
+		{
+			ID: 2,
+			InvariantCommandConfig: model.JSONObj{
+				"resources": map[string]interface{}{"resource_pool": "research"},
+			},
+			Constraints: model.ConfigPolicyConstraints{
+				MaxSlots: &maxSlots, AllowedImages: []string{"determinedai/*"},
+			},
+		},
+	}
+	assert.Equal(t, policies.commandResourcePool("default"), "research")
+	assert.Equal(t, configPolicies{}.commandResourcePool("default"), "default")
+
+	config := model.CommandConfig{}
+	config.Resources.ResourcePool = "gpu"
+	config.Resources.Slots = 4
+	config.Environment.Image = model.RuntimeItem{CPU: "ubuntu", GPU: "ubuntu-gpu"}
+	assert.NilError(t, policies.mergeIntoCommandConfig(&config))
+	assert.Equal(t, config.Resources.ResourcePool, "research")
+	// Values that the invariant configs don't set are kept.
+	assert.Equal(t, config.Resources.Slots, 4)
+
+	errs := policies.checkCommandConfig(config)
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0].(configFieldError).field, "resources.slots")
+	assert.Equal(t, errs[1].(configFieldError).field, "environment.image")
+
+	config.Resources.Slots = 0
+	config.Environment.Image.CPU = "determinedai/environments:py-3.8"
+	assert.Equal(t, len(policies.checkCommandConfig(config)), 0)
+}
+
+func TestConfigPoliciesRejectUnknownFields(t *testing.T) {
+	policies := configPolicies{{
+		ID:                     1,
+		InvariantCommandConfig: model.JSONObj{"resource": map[string]interface{}{}},
+	}}
+	assert.ErrorContains(t,
+		policies.mergeIntoCommandConfig(&model.CommandConfig{}), "invalid invariant command config")
+}
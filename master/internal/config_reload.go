@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// reloadableConfig are the sections of the configuration of the master that can change while it
+// runs, without dropping the connections of agents. ResourceConfig.CheckReload checks the changes
+// to the resource sections further. Webhooks are not among them, since they are stored in the
+// database and take effect as soon as they are added.
+var reloadableConfig = map[string]bool{
+	"log_retention":    true,
+	"resource_manager": true,
+	"resource_pools":   true,
+}
+
+// configSections returns the JSON of each top-level section of a configuration.
+func configSections(config *Config) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to convert config to JSON")
+	}
+	var sections map[string]json.RawMessage
+	if err = json.Unmarshal(b, &sections); err != nil {
+		return nil, errors.Wrap(err, "unable to split config into sections")
+	}
+	return sections, nil
+}
+
+// configChanges returns the sections of the configuration that changed from old to reloaded, or
+// an error if any of the changes takes a restart of the master.
+func configChanges(old, reloaded *Config) ([]string, error) {
+	oldSections, err := configSections(old)
+	if err != nil {
+		return nil, err
+	}
+	reloadedSections, err := configSections(reloaded)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for name := range oldSections {
+		names[name] = true
+	}
+	for name := range reloadedSections {
+		names[name] = true
+	}
+
+	var changed, errs []string
+	for name := range names {
+		if bytes.Equal(oldSections[name], reloadedSections[name]) {
+			continue
+		}
+		changed = append(changed, name)
+		if !reloadableConfig[name] {
+			errs = append(errs, name+" cannot change")
+		}
+	}
+	for _, err := range old.ResourceConfig.CheckReload(*reloaded.ResourceConfig) {
+		errs = append(errs, err.Error())
+	}
+	sort.Strings(changed)
+	sort.Strings(errs)
+	if len(errs) > 0 {
+		return nil, errors.Errorf(
+			"the master must restart to apply its configuration: %s", strings.Join(errs, "; "))
+	}
+	return changed, nil
+}
+
+// reloadConfig reads the configuration of the master again and applies it, if every change to it
+// can be applied while the master runs. It returns the sections of the configuration that changed.
+// The resource pools that were added through the API are kept after the reloaded ones.
+func (m *Master) reloadConfig() ([]string, error) {
+	if m.LoadConfig == nil {
+		return nil, errors.New("the master cannot reload its configuration")
+	}
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+
+	reloaded, err := m.LoadConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot reload the configuration")
+	}
+	// Only reloads change the configuration, so it can be read without configLock here.
+	changed, err := configChanges(m.config, reloaded)
+	if err != nil {
+		return nil, err
+	}
+	resourceConfig := m.withAddedPools(reloaded.ResourceConfig)
+	if err = check.Validate(resourceConfig); err != nil {
+		return nil, errors.Wrap(err, "the reloaded resource pools conflict with the added ones")
+	}
+
+	m.configLock.Lock()
+	// Settings can move between the file and their defaults without changing.
+	m.config.Sources = reloaded.Sources
+	if len(changed) == 0 {
+		m.configLock.Unlock()
+		return nil, nil
+	}
+	m.config.LogRetention = reloaded.LogRetention
+	m.config.ResourcePools = reloaded.ResourcePools
+	if agentRM := m.config.AgentResourceManager(); agentRM != nil {
+		agentRM.Scheduler = reloaded.AgentResourceManager().Scheduler
+	}
+	m.configLock.Unlock()
+
+	if pruner := m.system.Get(actor.Addr("log-pruner")); pruner != nil {
+		m.system.Tell(pruner, reloaded.LogRetention)
+	}
+	if agentRM := reloaded.AgentResourceManager(); agentRM != nil {
+		m.system.Tell(m.rm, resourcemanagers.UpdateResourcePools{
+			Scheduler: agentRM.Scheduler,
+			Pools:     resourceConfig.ResourcePools,
+		})
+	}
+	log.Infof("reloaded the configuration, which changed %s", strings.Join(changed, ", "))
+	return changed, nil
+}
+
+// configReloader reloads the configuration of the master when the master receives SIGHUP.
+type configReloader struct {
+	reload func() ([]string, error)
+}
+
+func (r *configReloader) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		actors.NotifyOnSignal(ctx, syscall.SIGHUP)
+
+	case syscall.Signal:
+		ctx.Log().Info("reloading the configuration on SIGHUP")
+		switch changed, err := r.reload(); {
+		case err != nil:
+			ctx.Log().WithError(err).Error("failed to reload the configuration")
+		case len(changed) == 0:
+			ctx.Log().Info("the configuration did not change")
+		}
+
+	case actor.PostStop, actor.ChildStopped, actor.ChildFailed:
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
@@ -165,3 +165,115 @@ checkpoint_storage:
 	assert.NilError(t, err)
 	assert.DeepEqual(t, unmarshaled, expected)
 }
+
+func TestSecurityConfigRequireNonRoot(t *testing.T) {
+	root := model.AgentUserGroup{User: "root", UID: 0, Group: "root", GID: 0}
+	user := model.AgentUserGroup{User: "user", UID: 1000, Group: "user", GID: 1000}
+	rootGroup := model.AgentUserGroup{User: "user", UID: 1000, Group: "root", GID: 0}
+
+	permissive := SecurityConfig{DefaultTask: root}
+	assert.Equal(t, len(permissive.Validate()), 0)
+	assert.NilError(t, permissive.CheckAgentUserGroup(root))
+
+	strict := SecurityConfig{DefaultTask: root, RequireNonRoot: true}
+	assert.Equal(t, len(strict.Validate()), 1)
+
+	strict.DefaultTask = user
+	assert.Equal(t, len(strict.Validate()), 0)
+	assert.NilError(t, strict.CheckAgentUserGroup(user))
+	assert.ErrorContains(t, strict.CheckAgentUserGroup(root), "may not run as root")
+	assert.ErrorContains(t, strict.CheckAgentUserGroup(rootGroup), "may not run as root")
+}
+
+func TestSecurityConfigRegistryCredentialsKey(t *testing.T) {
+	valid := SecurityConfig{RegistryCredentialsKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}
+	assert.Equal(t, len(valid.Validate()), 0)
+	key, err := valid.RegistryCredentialsKeyBytes()
+	assert.NilError(t, err)
+	assert.Equal(t, len(key), 32)
+
+	short := SecurityConfig{RegistryCredentialsKey: "c2hvcnQ="}
+	assert.Equal(t, len(short.Validate()), 1)
+
+	notBase64 := SecurityConfig{RegistryCredentialsKey: "not base64!"}
+	assert.Equal(t, len(notBase64.Validate()), 1)
+}
+
+func TestSecurityConfigSecretsKey(t *testing.T) {
+	valid := SecurityConfig{SecretsKey: "MDEyMzQ1Njc4OWFiY2RlZg=="}
+	assert.Equal(t, len(valid.Validate()), 0)
+	key, err := valid.SecretsKeyBytes()
+	assert.NilError(t, err)
+	assert.Equal(t, len(key), 16)
+
+	short := SecurityConfig{SecretsKey: "c2hvcnQ="}
+	errs := short.Validate()
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "security.secrets_key must be 16, 24 or 32 bytes long")
+}
+
+func TestSecurityConfigStorageCredentialsKey(t *testing.T) {
+	valid := SecurityConfig{StorageCredentialsKey: "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3"}
+	assert.Equal(t, len(valid.Validate()), 0)
+	key, err := valid.StorageCredentialsKeyBytes()
+	assert.NilError(t, err)
+	assert.Equal(t, len(key), 24)
+
+	short := SecurityConfig{StorageCredentialsKey: "c2hvcnQ="}
+	errs := short.Validate()
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "security.storage_credentials_key must be 16, 24 or 32 bytes")
+}
+
+func TestConfigChanges(t *testing.T) {
+	parse := func(raw string) *Config {
+		config := DefaultConfig()
+		assert.NilError(t, yaml.Unmarshal([]byte(raw), config, yaml.DisallowUnknownFields))
+		assert.NilError(t, config.Resolve())
+		return config
+	}
+	config := parse(`
+log_retention:
+  days: 30
+resource_manager:
+  type: agent
+resource_pools:
+  - pool_name: default
+`)
+
+	changed, err := configChanges(config, parse(`
+log_retention:
+  days: 7
+resource_manager:
+  type: agent
+  scheduler:
+    type: priority
+resource_pools:
+  - pool_name: default
+    max_cpu_containers_per_agent: 10
+  - pool_name: cpu
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changed, []string{"log_retention", "resource_manager", "resource_pools"})
+
+	changed, err = configChanges(config, parse(`
+log_retention:
+  days: 30
+resource_manager:
+  type: agent
+resource_pools:
+  - pool_name: default
+`))
+	assert.NilError(t, err)
+	assert.Equal(t, len(changed), 0)
+
+	_, err = configChanges(config, parse(`
+port: 9090
+resource_manager:
+  type: agent
+resource_pools:
+  - pool_name: gpu
+`))
+	assert.ErrorContains(t, err, "port cannot change")
+	assert.ErrorContains(t, err, "resource pool default cannot be removed")
+}
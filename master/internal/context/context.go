@@ -42,3 +42,17 @@ func (c *DetContext) MustGetUserSession() model.UserSession {
 	}
 	return session.(model.UserSession)
 }
+
+// UserSession returns the user session for the relevant echo request context. It returns false if
+// the user authenticated with an API token rather than a session.
+func (c *DetContext) UserSession() (model.UserSession, bool) {
+	session, ok := c.Get("user-session").(model.UserSession)
+	return session, ok
+}
+
+// User returns the user for the relevant echo request context. It returns false if the request
+// is not authenticated.
+func (c *DetContext) User() (model.User, bool) {
+	user, ok := c.Get("user").(model.User)
+	return user, ok
+}
@@ -16,9 +16,11 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/determined-ai/determined/master/internal/elastic"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -33,19 +35,33 @@ import (
 	"github.com/soheilhy/cmux"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/apilimits"
+	"github.com/determined-ai/determined/master/internal/artifacts"
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/backup"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/command"
 	detContext "github.com/determined-ai/determined/master/internal/context"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/drain"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/hpimportance"
+	"github.com/determined-ai/determined/master/internal/mlflow"
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/internal/scim"
+	"github.com/determined-ai/determined/master/internal/sso"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/internal/template"
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
+	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/etc"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -63,9 +79,19 @@ type Master struct {
 	ClusterID string
 	MasterID  string
 	Version   string
+	// LoadConfig reads the configuration of the master again when it is reloaded, which is
+	// disabled if it is nil.
+	LoadConfig func() (*Config, error)
 
 	config   *Config
 	taskSpec *tasks.TaskSpec
+	// configLock guards the settings of config that change when it is reloaded, and reloadLock
+	// keeps reloads from running at the same time.
+	configLock sync.RWMutex
+	reloadLock sync.Mutex
+	// addedPools are the resource pools that were added through the API, which configLock also
+	// guards.
+	addedPools []resourcemanagers.ResourcePoolConfig
 
 	logs            *logger.LogBuffer
 	system          *actor.System
@@ -77,6 +103,17 @@ type Master struct {
 	trialLogger     *actor.Ref
 	trialLogBackend TrialLogBackend
 	hpImportance    *actor.Ref
+	webhooks        *actor.Ref
+	auditLogger     *audit.Logger
+	vault           *vault.Client
+	artifacts       *artifacts.Service
+	backups         *backup.Service
+	// ca issues the client certificates of agents and tasks. It is nil unless mTLS is enabled.
+	ca *ca.Authority
+	// drainer lets API calls finish when the master drains before an upgrade, after which stop
+	// ends Run.
+	drainer *drain.Drainer
+	stop    context.CancelFunc
 }
 
 // New creates an instance of the Determined master.
@@ -87,31 +124,40 @@ func New(version string, logStore *logger.LogBuffer, config *Config) *Master {
 		Version:  version,
 		logs:     logStore,
 		config:   config,
+		drainer:  drain.New(),
 	}
 }
 
 func (m *Master) getConfig(echo.Context) (interface{}, error) {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
 	return m.config.Printable()
 }
 
 // makeTaskSpec is the master method that we will pass around as a tasks.MakeTaskSpecFn.  It's
-// behavior is deterministic for the lifetime of the master process.
+// behavior only changes when the master reloads the configuration of its resource pools.
 func (m *Master) makeTaskSpec(poolName string, numSlots int) tasks.TaskSpec {
+	m.configLock.RLock()
+	defer m.configLock.RUnlock()
+
 	// Always fall back to the top-level TaskContainerDefaults
 	taskContainerDefaults := m.config.TaskContainerDefaults
+	agentPool := false
 
-	// Only look for pool settings with Agent resource managers.
-	if m.config.ResourceManager.AgentRM != nil {
-		if poolName == "" {
+	// Only look for pool settings with Agent resource managers. Tasks without a pool only land in
+	// the agent resource manager's default pools when it is the primary resource manager.
+	if agentRM := m.config.AgentResourceManager(); agentRM != nil {
+		if poolName == "" && m.config.ResourceManager.AgentRM != nil {
 			if numSlots == 0 {
-				poolName = m.config.ResourceManager.AgentRM.DefaultCPUResourcePool
+				poolName = agentRM.DefaultCPUResourcePool
 			} else {
-				poolName = m.config.ResourceManager.AgentRM.DefaultGPUResourcePool
+				poolName = agentRM.DefaultGPUResourcePool
 			}
 		}
 		// Iterate through configured pools looking for a TaskContainerDefaults setting.
-		for _, pool := range m.config.ResourcePools {
+		for _, pool := range m.withAddedPools(m.config.ResourceConfig).ResourcePools {
 			if poolName == pool.PoolName {
+				agentPool = true
 				if pool.TaskContainerDefaults == nil {
 					break
 				}
@@ -123,10 +169,21 @@ func (m *Master) makeTaskSpec(poolName string, numSlots int) tasks.TaskSpec {
 	// Not a deep copy, but deep enough not to overwrite the master's TaskContainerDefaults.
 	taskSpec := *m.taskSpec
 	taskSpec.TaskContainerDefaults = taskContainerDefaults
+	taskSpec.AgentPool = agentPool
 
 	return taskSpec
 }
 
+// checkKubernetesNamespace returns an error if the cluster does not let tasks launch pods in the
+// Kubernetes namespace. Tasks on agent-based clusters have no namespace, so anything goes there.
+func (m *Master) checkKubernetesNamespace(namespace string) error {
+	k8sRM := m.config.KubernetesResourceManager()
+	if k8sRM == nil {
+		return nil
+	}
+	return k8sRM.CheckNamespace(namespace)
+}
+
 // Info returns this master's information.
 func (m *Master) Info() aproto.MasterInfo {
 	telemetryInfo := aproto.TelemetryInfo{}
@@ -302,6 +359,115 @@ func (m *Master) fetchAggregatedResourceAllocation(
 	}
 }
 
+func (m *Master) fetchResourceUsage(
+	req *apiv1.ResourceUsageRequest,
+) (*apiv1.ResourceUsageResponse, error) {
+	var layout, unit string
+	switch req.Period {
+	case masterv1.ResourceAllocationAggregationPeriod_RESOURCE_ALLOCATION_AGGREGATION_PERIOD_DAILY:
+		layout, unit = "2006-01-02", "day"
+	case masterv1.ResourceAllocationAggregationPeriod_RESOURCE_ALLOCATION_AGGREGATION_PERIOD_MONTHLY:
+		layout, unit = "2006-01", "month"
+	default:
+		return nil, errors.New("no aggregation period specified")
+	}
+	start, err := time.Parse(layout, req.StartDate)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid start date")
+	}
+	end, err := time.Parse(layout, req.EndDate)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid end date")
+	}
+	if unit == "month" {
+		end = end.AddDate(0, 1, -1)
+	}
+	if start.After(end) {
+		return nil, errors.New("start date cannot be after end date")
+	}
+
+	var taskTypes []string
+	for _, t := range req.TaskTypes {
+		taskTypes = append(taskTypes, strings.TrimPrefix(t.String(), "TASK_TYPE_"))
+	}
+	resp := &apiv1.ResourceUsageResponse{}
+	if err := m.db.QueryProto(
+		"get_resource_usage", &resp.Entries, start.UTC(), end.UTC(), unit,
+		strings.Join(req.Users, ","), req.ProjectId, strings.Join(req.ResourcePools, ","),
+		strings.Join(taskTypes, ","), req.ExperimentId,
+	); err != nil {
+		return nil, errors.Wrap(err, "error fetching resource usage")
+	}
+
+	// Costs are estimated at the current prices, since prices are not recorded with the usage.
+	prices := m.config.SlotHourPrices()
+	for _, entry := range resp.Entries {
+		if price, ok := prices[entry.ResourcePool]; ok {
+			entry.Cost = wrapperspb.Double(entry.SlotHours * price)
+			resp.TotalCost += entry.Cost.Value
+		}
+	}
+	return resp, nil
+}
+
+// getResourceUsage exports the usage of resources as CSV, for chargeback.
+func (m *Master) getResourceUsage(c echo.Context) error {
+	args := struct {
+		Start        string  `query:"start_date"`
+		End          string  `query:"end_date"`
+		Period       string  `query:"period"`
+		User         *string `query:"users"`
+		ProjectID    int     `query:"project_id"`
+		ExperimentID int     `query:"experiment_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return err
+	}
+	req := &apiv1.ResourceUsageRequest{
+		StartDate: args.Start,
+		EndDate:   args.End,
+		Period: masterv1.ResourceAllocationAggregationPeriod(
+			masterv1.ResourceAllocationAggregationPeriod_value[args.Period],
+		),
+		ProjectId:    int32(args.ProjectID),
+		ExperimentId: int32(args.ExperimentID),
+	}
+	if args.User != nil {
+		req.Users = []string{*args.User}
+	}
+	resp, err := m.fetchResourceUsage(req)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Content-Type", "text/csv")
+	csvWriter := csv.NewWriter(c.Response())
+	header := []string{
+		"period_start", "username", "project_id", "experiment_id", "resource_pool", "task_type",
+		"slot_hours", "cost",
+	}
+	if err = csvWriter.Write(header); err != nil {
+		return err
+	}
+	for _, entry := range resp.Entries {
+		cost := ""
+		if entry.Cost != nil {
+			cost = fmt.Sprintf("%f", entry.Cost.Value)
+		}
+		fields := []string{
+			entry.PeriodStart, entry.Username, strconv.Itoa(int(entry.ProjectId)),
+			strconv.Itoa(int(entry.ExperimentId)), entry.ResourcePool,
+			strings.TrimPrefix(entry.TaskType.String(), "TASK_TYPE_"),
+			fmt.Sprintf("%f", entry.SlotHours), cost,
+		}
+		if err = csvWriter.Write(fields); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return nil
+}
+
 func (m *Master) getAggregatedResourceAllocation(c echo.Context) error {
 	args := struct {
 		Start  string `query:"start_date"`
@@ -375,11 +541,22 @@ func (m *Master) startServers(ctx context.Context, cert *tls.Certificate) error
 	defer closeWithErrCheck("base", baseListener)
 
 	if cert != nil {
-		baseListener = tls.NewListener(baseListener, &tls.Config{
+		tlsConfig := &tls.Config{
 			Certificates:             []tls.Certificate{*cert},
 			MinVersion:               tls.VersionTLS12,
 			PreferServerCipherSuites: true,
-		})
+		}
+		if m.ca != nil {
+			// Only agents and tasks have client certificates, so other clients may connect without.
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			tlsConfig.ClientCAs = m.ca.Pool()
+		}
+		baseListener = tls.NewListener(baseListener, tlsConfig)
+	}
+
+	limits, err := apilimits.New(m.config.APILimits)
+	if err != nil {
+		return err
 	}
 
 	// Initialize listeners and multiplexing.
@@ -408,7 +585,8 @@ func (m *Master) startServers(ctx context.Context, cert *tls.Certificate) error
 		}()
 	}
 	start("gRPC server", func() error {
-		srv := grpcutil.NewGRPCServer(m.db, &apiServer{m: m})
+		srv := grpcutil.NewGRPCServer(
+			m.db, &apiServer{m: m}, m.auditLogger, limits, m.drainer, m.config.Tenancy.Strict)
 		// We should defer srv.Stop() here, but cmux does not unblock accept calls when underlying
 		// listeners close and grpc-go depends on cmux unblocking and closing, Stop() blocks
 		// indefinitely when using cmux.
@@ -417,6 +595,7 @@ func (m *Master) startServers(ctx context.Context, cert *tls.Certificate) error
 	})
 	start("HTTP server", func() error {
 		m.echo.Listener = httpListener
+		m.echo.Server.ConnContext = ca.ConnContext
 		m.echo.HidePort = true
 		defer closeWithErrCheck("echo", m.echo)
 		return m.echo.StartServer(m.echo.Server)
@@ -511,16 +690,26 @@ func (m *Master) postTrialLogs(c echo.Context) (interface{}, error) {
 		return nil, err
 	}
 
-	var logs []model.TrialLog
+	var logs []*model.TrialLog
 	if err = json.Unmarshal(body, &logs); err != nil {
 		return nil, err
 	}
 
+	batch := make(trialLogBatch, 0, len(logs))
 	for _, l := range logs {
 		if l.TrialID == 0 {
 			continue
 		}
-		m.system.Tell(m.trialLogger, l)
+		batch = append(batch, l)
+	}
+	if len(batch) == 0 {
+		return "", nil
+	}
+	// Fluent Bit on the agent buffers the logs and retries them if the master is backed up.
+	if accepted, ok := m.system.Ask(m.trialLogger, batch).Get().(bool); !ok || !accepted {
+		c.Response().Header().Set("Retry-After", "1")
+		return nil, echo.NewHTTPError(http.StatusServiceUnavailable,
+			"too many trial logs are waiting to be saved; try again later")
 	}
 	return "", nil
 }
@@ -528,6 +717,8 @@ func (m *Master) postTrialLogs(c echo.Context) (interface{}, error) {
 // Run causes the Determined master to connect the database and begin listening for HTTP requests.
 func (m *Master) Run(ctx context.Context) error {
 	log.Infof("Determined master %s (built with %s)", m.Version, runtime.Version())
+	ctx, m.stop = context.WithCancel(ctx)
+	defer m.stop()
 
 	var err error
 
@@ -535,16 +726,67 @@ func (m *Master) Run(ctx context.Context) error {
 		return errors.Wrap(err, "could not set static root")
 	}
 
+	tracing.Setup(m.config.Tracing)
+	defer tracing.Shutdown()
+
 	m.db, err = db.Setup(&m.config.DB)
 	if err != nil {
 		return err
 	}
 	defer closeWithErrCheck("db", m.db)
 
+	if m.config.Security.RegistryCredentialsKey != "" {
+		key, kErr := m.config.Security.RegistryCredentialsKeyBytes()
+		if kErr != nil {
+			return kErr
+		}
+		if err = m.db.SetRegistryCredentialsKey(key); err != nil {
+			return err
+		}
+	}
+	if m.config.Security.SecretsKey != "" {
+		key, kErr := m.config.Security.SecretsKeyBytes()
+		if kErr != nil {
+			return kErr
+		}
+		if err = m.db.SetSecretsKey(key); err != nil {
+			return err
+		}
+	}
+	if m.config.Security.StorageCredentialsKey != "" {
+		key, kErr := m.config.Security.StorageCredentialsKeyBytes()
+		if kErr != nil {
+			return kErr
+		}
+		if err = m.db.SetStorageCredentialsKey(key); err != nil {
+			return err
+		}
+	}
+	if err = m.db.MigrateStorageCredentials(); err != nil {
+		return err
+	}
+	m.db.SetTaskSessionBinding(m.config.Security.BindTaskTokens)
+	if m.auditLogger, err = audit.New(m.db, m.config.AuditLog); err != nil {
+		return err
+	}
+	if m.vault, err = vault.New(m.config.Vault); err != nil {
+		return errors.Wrap(err, "cannot initialize Vault client")
+	}
+	if m.artifacts, err = artifacts.New(m.db, m.config.Artifacts); err != nil {
+		return errors.Wrap(err, "cannot initialize artifact storage")
+	}
+	if m.ca, err = ca.New(m.config.Security.MTLS); err != nil {
+		return errors.Wrap(err, "cannot initialize the certificate authority")
+	}
+	m.db.SetTaskSessionCertificates(m.ca != nil)
+
 	m.ClusterID, err = m.db.GetClusterID()
 	if err != nil {
 		return errors.Wrap(err, "could not fetch cluster id from database")
 	}
+	if m.backups, err = backup.New(m.db, m.config.Backup, m.Version, m.ClusterID); err != nil {
+		return errors.Wrap(err, "cannot initialize backup storage")
+	}
 	cert, err := m.config.Security.TLS.ReadCertificate()
 	if err != nil {
 		return errors.Wrap(err, "failed to read TLS certificate")
@@ -569,6 +811,9 @@ func (m *Master) Run(ctx context.Context) error {
 	//     +- Resource Pool (resourcemanagers.ResourcePool: <resource-pool-name>)
 	//         +- Provisioner (provisioner.Provisioner: provisioner)
 	// +- KubernetesResourceManager (scheduler.KubernetesResourceManager: kubernetesRM)
+	// +- HPCResourceManager (resourcemanagers.hpcResourceManager: hpcRM)
+	// +- HPC Jobs (hpc.jobs: hpcJobs)
+	//     +- Job (hpc.job: job-<container-id>)
 	// +- Service Proxy (proxy.Proxy: proxy)
 	// +- RWCoordinator (internal.rw_coordinator: rwCoordinator)
 	// +- Telemetry (telemetry.telemetry: telemetry)
@@ -578,6 +823,9 @@ func (m *Master) Run(ctx context.Context) error {
 	//         +- Trial (internal.trial: <trial-request-id>)
 	//             +- Websocket (actors.WebSocket: <remote-address>)
 	m.system = actor.NewSystem("master")
+	if timeout := m.config.ActorWatchdog.SlowHandlerTimeout; timeout > 0 {
+		m.system.StartWatchdog(time.Duration(timeout) * time.Second)
+	}
 
 	switch {
 	case m.config.Logging.DefaultLoggingConfig != nil:
@@ -592,14 +840,24 @@ func (m *Master) Run(ctx context.Context) error {
 		panic("unsupported logging backend")
 	}
 	m.trialLogger, _ = m.system.ActorOf(actor.Addr("trialLogger"), newTrialLogger(m.trialLogBackend))
+	prom.Register("trial_logs", trialLoggerCollector(m.system, m.trialLogger))
 
 	userService, err := user.New(m.db, m.system)
 	if err != nil {
 		return errors.Wrap(err, "cannot initialize user manager")
 	}
 	authFuncs := []echo.MiddlewareFunc{userService.ProcessAuthentication}
+	// In strict tenancy, users reach the services of tasks through the proxy as they do the rest of
+	// the master, only within their tenants.
+	var proxyFuncs []echo.MiddlewareFunc
+	proxyActor := &proxy.Proxy{}
+	if m.config.Tenancy.Strict {
+		authFuncs = append(authFuncs, m.tenancyMiddleware)
+		proxyFuncs = authFuncs
+		proxyActor.Authorize = m.authorizeProxy
+	}
 
-	m.proxy, _ = m.system.ActorOf(actor.Addr("proxy"), &proxy.Proxy{})
+	m.proxy, _ = m.system.ActorOf(actor.Addr("proxy"), proxyActor)
 
 	// Used to decide whether we add trailing slash to the paths or not affecting
 	// relative links in web pages hosted under these routes.
@@ -610,12 +868,34 @@ func (m *Master) Run(ctx context.Context) error {
 	}
 
 	m.system.MustActorOf(actor.Addr("allocation-aggregator"), &allocationAggregator{db: m.db})
+	// Logs in Elasticsearch are retained by its index lifecycle policies instead.
+	if m.config.Logging.DefaultLoggingConfig != nil {
+		m.system.MustActorOf(actor.Addr("log-pruner"), &logPruner{
+			db: m.db, config: m.config.LogRetention,
+		})
+	}
+	if m.artifacts != nil {
+		m.system.MustActorOf(actor.Addr("artifact-blob-evictor"), &blobEvictor{
+			artifacts: m.artifacts, config: m.config.Artifacts.ChunkCache,
+		})
+	}
+	if m.config.MetricsDownsampling.AfterDays > 0 {
+		m.system.MustActorOf(actor.Addr("metrics-downsampler"), &metricsDownsampler{
+			db: m.db, config: m.config.MetricsDownsampling,
+		})
+	}
+	if m.config.PrometheusRemoteWrite.URL != "" {
+		m.system.MustActorOf(actor.Addr("metrics-remote-writer"), &metricsRemoteWriter{
+			db: m.db, config: m.config.PrometheusRemoteWrite,
+		})
+	}
 
 	hpi, err := hpimportance.NewManager(m.db, m.system, m.config.HPImportance, m.config.Root)
 	if err != nil {
 		return err
 	}
 	m.hpImportance, _ = m.system.ActorOf(actor.Addr(hpimportance.RootAddr), hpi)
+	m.webhooks, _ = m.system.ActorOf(actor.Addr(webhooks.RootAddr), webhooks.NewManager(m.db))
 
 	// Initialize the HTTP server and listen for incoming requests.
 	m.echo = echo.New()
@@ -652,6 +932,7 @@ func (m *Master) Run(ctx context.Context) error {
 		}
 	})
 
+	m.echo.Use(m.auditLogger.Middleware)
 	m.echo.Use(convertDBErrorsToNotFound)
 
 	m.echo.Logger = logger.New()
@@ -663,7 +944,21 @@ func (m *Master) Run(ctx context.Context) error {
 		MasterInfo:     m.Info(),
 		LoggingOptions: m.config.Logging,
 	}
-	m.rm = resourcemanagers.Setup(m.system, m.echo, m.config.ResourceConfig, agentOpts, cert)
+	if m.config.AgentResourceManager() != nil {
+		if m.addedPools, err = m.loadAddedResourcePools(); err != nil {
+			return errors.Wrap(err, "cannot load the resource pools added through the API")
+		}
+	}
+	resourceConfig := m.withAddedPools(m.config.ResourceConfig)
+	if err = check.Validate(resourceConfig); err != nil {
+		return errors.Wrap(err, "invalid resource pools added through the API")
+	}
+	m.rm = resourcemanagers.Setup(
+		m.system, m.echo, &resourceConfig, agentOpts, cert, m.ca != nil, m.db,
+	)
+	if m.LoadConfig != nil {
+		m.system.MustActorOf(actor.Addr("config-reloader"), &configReloader{reload: m.reloadConfig})
+	}
 	tasksGroup := m.echo.Group("/tasks", authFuncs...)
 	tasksGroup.GET("", api.Route(m.getTasks))
 	tasksGroup.GET("/:task_id", api.Route(m.getTask))
@@ -738,6 +1033,8 @@ func (m *Master) Run(ctx context.Context) error {
 
 	m.echo.GET("/config", api.Route(m.getConfig))
 	m.echo.GET("/info", api.Route(m.getInfo))
+	m.echo.GET("/healthz", m.getHealthz)
+	m.echo.GET("/readyz", m.getReadyz)
 	m.echo.GET("/logs", api.Route(m.getMasterLogs), authFuncs...)
 
 	m.echo.GET("/experiment-list", api.Route(m.getExperimentList), authFuncs...)
@@ -753,7 +1050,7 @@ func (m *Master) Run(ctx context.Context) error {
 	experimentsGroup.GET("/:experiment_id/summary", api.Route(m.getExperimentSummary))
 	experimentsGroup.GET("/:experiment_id/metrics/summary", api.Route(m.getExperimentSummaryMetrics))
 	experimentsGroup.PATCH("/:experiment_id", api.Route(m.patchExperiment))
-	experimentsGroup.POST("", api.Route(m.postExperiment))
+	experimentsGroup.POST("", api.Route(m.postExperiment), drain.RejectLaunches(m.drainer))
 	experimentsGroup.POST("/:experiment_id/kill", api.Route(m.postExperimentKill))
 
 	searcherGroup := m.echo.Group("/searcher", authFuncs...)
@@ -774,6 +1071,7 @@ func (m *Master) Run(ctx context.Context) error {
 	resourcesGroup := m.echo.Group("/resources", authFuncs...)
 	resourcesGroup.GET("/allocation/raw", m.getRawResourceAllocation)
 	resourcesGroup.GET("/allocation/aggregated", m.getAggregatedResourceAllocation)
+	resourcesGroup.GET("/usage", m.getResourceUsage)
 
 	m.echo.POST("/trial_logs", api.Route(m.postTrialLogs))
 
@@ -789,18 +1087,40 @@ func (m *Master) Run(ctx context.Context) error {
 	m.echo.Any("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
 	m.echo.Any("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
 
+	prom.Register("actors", actorCollector(m.system))
+	m.echo.GET("/metrics", echo.WrapHandler(prom.Handler()))
+	m.echo.GET("/prom/det-state-metrics", echo.WrapHandler(prom.Handler()))
+
 	handler := m.system.AskAt(actor.Addr("proxy"), proxy.NewProxyHandler{ServiceID: "service"})
-	m.echo.Any("/proxy/:service/*", handler.Get().(echo.HandlerFunc))
+	m.echo.Any("/proxy/:service/*", handler.Get().(echo.HandlerFunc), proxyFuncs...)
 
 	user.RegisterAPIHandler(m.echo, userService, authFuncs...)
+	if m.config.Security.SSO.Enabled() {
+		ssoService, err := sso.New(m.db, m.config.Security.SSO)
+		if err != nil {
+			return errors.Wrap(err, "cannot initialize single sign-on")
+		}
+		sso.RegisterAPIHandler(m.echo, ssoService)
+	}
+	if m.config.Security.SCIM.Enabled() {
+		scim.RegisterAPIHandler(m.echo, scim.New(m.db, m.config.Security.SCIM))
+	}
+	if m.config.MLflow.Enabled {
+		mlflow.RegisterAPIHandler(m.echo, mlflow.New(m.db), authFuncs...)
+	}
 	command.RegisterAPIHandler(
 		m.system,
 		m.echo,
 		m.db,
 		m.proxy,
 		m.config.TensorBoardTimeout,
+		m.config.NotebookTimeout,
 		m.config.Security.DefaultTask,
+		m.config.Security.BindMounts,
 		m.makeTaskSpec,
+		m.vault,
+		m.artifacts,
+		m.ca,
 		authFuncs...,
 	)
 	template.RegisterAPIHandler(m.echo, m.db, authFuncs...)
@@ -824,5 +1144,10 @@ func (m *Master) Run(ctx context.Context) error {
 		log.Info("telemetry reporting is disabled")
 	}
 
-	return m.startServers(ctx, cert)
+	err = m.startServers(ctx, cert)
+	if errors.Is(err, context.Canceled) && m.drainer.Draining() {
+		log.Info("the master has drained and exits")
+		return nil
+	}
+	return err
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/status"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/context"
@@ -263,6 +264,10 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "loading experiment %v", args.ExperimentID)
 	}
+	user := c.(*context.DetContext).MustGetUser()
+	if err = m.checkExperimentPermission(&user, dbExp); err != nil {
+		return nil, permissionHTTPError(err)
+	}
 
 	agentUserGroup, err := m.db.AgentUserGroup(*dbExp.OwnerID)
 	if err != nil {
@@ -272,6 +277,12 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 	if agentUserGroup == nil {
 		agentUserGroup = &m.config.Security.DefaultTask
 	}
+	// Changing the checkpoint storage launches a checkpoint GC task as the agent user.
+	if patch.CheckpointStorage != nil {
+		if err = m.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+			return nil, echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+	}
 
 	if patch.Archived != nil {
 		dbExp.Archived = *patch.Archived
@@ -341,6 +352,8 @@ func (m *Master) patchExperiment(c echo.Context) (interface{}, error) {
 				taskSpec:       m.taskSpec,
 				rm:             m.rm,
 				db:             m.db,
+				vault:          m.vault,
+				ca:             m.ca,
 				experiment:     dbExp,
 			})
 	}
@@ -360,34 +373,117 @@ type CreateExperimentParams struct {
 	GitCommitter  *string         `json:"git_committer"`
 	GitCommitDate *time.Time      `json:"git_commit_date"`
 	ValidateOnly  bool            `json:"validate_only"`
+	ProjectID     int             `json:"project_id"`
+	// ContextArtifactID is an uploaded context artifact to use instead of ModelDef.
+	ContextArtifactID string `json:"context_artifact_id"`
 }
 
-func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
+func (m *Master) parseCreateExperiment(params *CreateExperimentParams, user *model.User) (
 	*model.Experiment, bool, *tasks.TaskSpec, error,
+) {
+	resolved, err := m.resolveExperimentConfig(params, user)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if errs := m.checkExperimentConfig(resolved); len(errs) > 0 {
+		return nil, false, nil, errs[0]
+	}
+	config, project := resolved.config, resolved.project
+
+	var modelBytes []byte
+	if params.ParentID != nil {
+		var dbErr error
+		modelBytes, dbErr = m.db.ExperimentModelDefinitionRaw(*params.ParentID)
+		if dbErr != nil {
+			return nil, false, nil, errors.Wrapf(
+				dbErr, "unable to find parent experiment %v", *params.ParentID)
+		}
+	} else {
+		var compressErr error
+		modelBytes, compressErr = archive.ToTarGz(params.ModelDef)
+		if compressErr != nil {
+			return nil, false, nil, errors.Wrapf(
+				compressErr, "unable to find compress model definition")
+		}
+	}
+
+	dbExp, err := model.NewExperiment(
+		config, params.ConfigBytes, modelBytes, params.ParentID, params.Archived,
+		params.GitRemote, params.GitCommit, params.GitCommitter, params.GitCommitDate)
+	if dbExp != nil {
+		dbExp.ProjectID = project.ID
+	}
+	return dbExp, params.ValidateOnly, &resolved.taskSpec, err
+}
+
+// resolvedExperimentConfig is the config of a new experiment with every default applied.
+type resolvedExperimentConfig struct {
+	config    expconf.ExperimentConfig
+	taskSpec  tasks.TaskSpec
+	project   *model.Project
+	workspace *model.Workspace
+	policies  configPolicies
+	user      *model.User
+	// routedPool is the resource pool that pool routing sends the experiment to, if any.
+	routedPool string
+}
+
+// resolveExperimentConfig parses the config of a new experiment and applies to it the template, the
+// invariant configs of the config policies of the user and workspace, the defaults of the
+// workspace of its project, the task container defaults and the checkpoint storage of the master,
+// and the defaults of the schema. It does not check that the result is complete.
+func (m *Master) resolveExperimentConfig(params *CreateExperimentParams, user *model.User) (
+	*resolvedExperimentConfig, error,
 ) {
 	// Read the config as the user provided it.
 	config, err := expconf.ParseAnyExperimentConfigYAML([]byte(params.ConfigBytes))
 	if err != nil {
-		return nil, false, nil, errors.Wrap(err, "invalid experiment configuration")
+		return nil, errors.Wrap(err, "invalid experiment configuration")
 	}
 
 	// Apply the template that the user specified.
 	if params.Template != nil {
-		template, terr := m.db.TemplateByName(*params.Template)
+		template, terr := m.db.TemplateWithStorageCredentials(*params.Template)
 		if terr != nil {
-			return nil, false, nil, terr
+			return nil, terr
 		}
 		var tc expconf.ExperimentConfig
 		if yerr := yaml.Unmarshal(template.Config, &tc, yaml.DisallowUnknownFields); yerr != nil {
-			return nil, false, nil, yerr
+			return nil, yerr
 		}
 		// Merge the template into the config.
 		config = schemas.Merge(config, tc).(expconf.ExperimentConfig)
 	}
 
+	// Fill in the defaults of the project's workspace, which the config policies take precedence
+	// over.
+	project, workspace, err := m.projectWorkspace(params.ProjectID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find project %d", params.ProjectID)
+	}
+	policies, err := m.configPolicies(user.ID, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if config, err = policies.mergeIntoExperimentConfig(config); err != nil {
+		return nil, err
+	}
+	routedPool := m.config.PoolRouting.BatchResourcePool
+	if defaultPool := defaultResourcePool(workspace, routedPool); defaultPool != "" {
+		if config.RawResources == nil {
+			config.RawResources = &expconf.ResourcesConfig{}
+		}
+		if config.RawResources.RawResourcePool == nil {
+			config.RawResources.RawResourcePool = &defaultPool
+		}
+	}
+
 	// Merge the appropriate TaskContainerDefaults into the config.
 	resources := schemas.WithDefaults(config).(expconf.ExperimentConfig).Resources()
 	taskSpec := m.makeTaskSpec(resources.ResourcePool(), resources.SlotsPerTrial())
+	if image := workspace.DefaultImageItem(); image != nil {
+		taskSpec.TaskContainerDefaults.Image = image
+	}
 	taskSpec.TaskContainerDefaults.MergeIntoConfig(&config)
 
 	// Merge in the master's checkpoint storage into the config.
@@ -397,33 +493,55 @@ func (m *Master) parseCreateExperiment(params *CreateExperimentParams) (
 
 	// Lastly, apply any json-schema-defined defaults.
 	config = schemas.WithDefaults(config).(expconf.ExperimentConfig)
+	return &resolvedExperimentConfig{
+		config: config, taskSpec: taskSpec, project: project, workspace: workspace,
+		policies: policies, user: user, routedPool: routedPool,
+	}, nil
+}
 
+// checkExperimentConfig returns every problem with a resolved experiment config: the fields that
+// are still missing or invalid, and the settings that the policies of the cluster forbid.
+func (m *Master) checkExperimentConfig(resolved *resolvedExperimentConfig) []error {
+	config, taskSpec := resolved.config, resolved.taskSpec
+	var errs []error
 	// Make sure the experiment config has all eventuallyRequired fields.
-	if err = schemas.IsComplete(config); err != nil {
-		return nil, false, nil, errors.Wrap(err, "invalid experiment configuration")
+	if err := schemas.IsComplete(config); err != nil {
+		errs = append(errs, errors.Wrap(err, "invalid experiment configuration"))
 	}
 
-	var modelBytes []byte
-	if params.ParentID != nil {
-		var dbErr error
-		modelBytes, dbErr = m.db.ExperimentModelDefinitionRaw(*params.ParentID)
-		if dbErr != nil {
-			return nil, false, nil, errors.Wrapf(
-				dbErr, "unable to find parent experiment %v", *params.ParentID)
+	// Checkpoint GC tasks mount a subset of what trials do.
+	taskSpec.SetInner(&tasks.StartTrial{ExperimentConfig: config})
+	if err := m.config.Security.BindMounts.CheckHostPaths(taskSpec.HostPaths()...); err != nil {
+		errs = append(errs, configFieldError{field: "bind_mounts", err: err})
+	}
+	if namespace := config.Environment().Kubernetes().Namespace(); namespace != nil {
+		if err := m.checkKubernetesNamespace(*namespace); err != nil {
+			errs = append(errs, configFieldError{field: "environment.kubernetes.namespace", err: err})
 		}
-	} else {
-		var compressErr error
-		modelBytes, compressErr = archive.ToTarGz(params.ModelDef)
-		if compressErr != nil {
-			return nil, false, nil, errors.Wrapf(
-				compressErr, "unable to find compress model definition")
+	}
+	resources := config.Resources()
+	if err := m.checkResourcePoolTenant(
+		resolved.workspace, resources.ResourcePool(), resources.SlotsPerTrial(),
+	); err != nil {
+		errs = append(errs, configFieldError{field: "resources.resource_pool", err: err})
+	}
+	if err := m.checkPoolRouting(
+		resolved.user, resolved.workspace.ID, resolved.routedPool,
+		resolved.policies.experimentResourcePool(
+			defaultResourcePool(resolved.workspace, resolved.routedPool)),
+		resources.ResourcePool(),
+	); err != nil {
+		errs = append(errs, configFieldError{field: "resources.resource_pool", err: err})
+	}
+	for _, window := range config.SchedulingWindows() {
+		if _, err := window.Location(); err != nil {
+			errs = append(errs, configFieldError{
+				field: "scheduling_windows",
+				err:   errors.Wrap(err, "invalid experiment configuration"),
+			})
 		}
 	}
-
-	dbExp, err := model.NewExperiment(
-		config, params.ConfigBytes, modelBytes, params.ParentID, params.Archived,
-		params.GitRemote, params.GitCommit, params.GitCommitter, params.GitCommitDate)
-	return dbExp, params.ValidateOnly, &taskSpec, err
+	return append(errs, resolved.policies.checkExperimentConfig(config)...)
 }
 
 func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
@@ -438,8 +556,31 @@ func (m *Master) postExperiment(c echo.Context) (interface{}, error) {
 	if err = json.Unmarshal(body, &params); err != nil {
 		return nil, errors.Wrap(err, "invalid experiment params")
 	}
+	project, err := m.checkPermission(&user, params.ProjectID, model.PermissionEditOwn)
+	if err != nil {
+		return nil, permissionHTTPError(err)
+	}
+	params.ProjectID = project.ID
+	// A child inherits the storage credentials and the model definition of its parent.
+	if params.ParentID != nil {
+		if err = m.checkExperimentIDPermission(c, *params.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.ContextArtifactID != "" {
+		if len(params.ModelDef) > 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest,
+				"model_definition and context_artifact_id cannot both be specified")
+		}
+		params.ModelDef, err = m.readContextArtifact(
+			c.Request().Context(), &user, params.ContextArtifactID)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, status.Convert(err).Message())
+		}
+	}
 
-	dbExp, validateOnly, taskSpec, err := m.parseCreateExperiment(&params)
+	dbExp, validateOnly, taskSpec, err := m.parseCreateExperiment(&params, &user)
 	if err != nil {
 		return nil, echo.NewHTTPError(
 			http.StatusBadRequest,
@@ -475,6 +616,10 @@ func (m *Master) postExperimentKill(c echo.Context) (interface{}, error) {
 		return nil, err
 	}
 
+	if err := m.checkExperimentIDPermission(c, args.ExperimentID); err != nil {
+		return nil, err
+	}
+
 	resp := m.system.AskAt(actor.Addr("experiments", args.ExperimentID), killExperiment{})
 	if resp.Source() == nil {
 		return nil, echo.NewHTTPError(http.StatusNotFound,
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// actorCollector returns a Prometheus collector reporting the inboxes of the actors of the master,
+// by the type of the actors.
+func actorCollector(system *actor.System) prom.Collector {
+	return func() []prom.Metric {
+		actors := prom.Metric{
+			Name: "det_actors",
+			Help: "Number of running actors of each type.",
+			Type: prom.Gauge,
+		}
+		messages := prom.Metric{
+			Name: "det_actor_mailbox_messages",
+			Help: "Number of messages waiting in the inboxes of all actors of each type.",
+			Type: prom.Gauge,
+		}
+		maxMessages := prom.Metric{
+			Name: "det_actor_mailbox_max_messages",
+			Help: "Number of messages waiting in the fullest inbox of the actors of each type.",
+			Type: prom.Gauge,
+		}
+		for typeName, stats := range system.MailboxStats() {
+			labels := map[string]string{"actor_type": typeName}
+			actors.Samples = append(actors.Samples,
+				prom.Sample{Labels: labels, Value: float64(stats.Actors)})
+			messages.Samples = append(messages.Samples,
+				prom.Sample{Labels: labels, Value: float64(stats.Messages)})
+			maxMessages.Samples = append(maxMessages.Samples,
+				prom.Sample{Labels: labels, Value: float64(stats.MaxMessages)})
+		}
+		return []prom.Metric{actors, messages, maxMessages}
+	}
+}
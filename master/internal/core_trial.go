@@ -25,6 +25,9 @@ func (m *Master) postTrialKill(c echo.Context) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = m.checkExperimentIDPermission(c, trial.ExperimentID); err != nil {
+		return nil, err
+	}
 	resp := m.system.AskAt(actor.Addr("experiments", trial.ExperimentID),
 		getTrial{trialID: args.TrialID})
 	if resp.Source() == nil {
@@ -1,10 +1,17 @@
 package db
 
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
 // DefaultConfig returns the default configuration of the database.
 func DefaultConfig() *Config {
 	return &Config{
-		Migrations: "file://static/migrations",
-		SSLMode:    sslModeDisable,
+		Migrations:      "file://static/migrations",
+		SSLMode:         sslModeDisable,
+		MaxOpenConns:    48,
+		MaxIdleConns:    16,
+		ConnMaxIdleTime: 300,
 	}
 }
 
@@ -18,4 +25,25 @@ type Config struct {
 	Name        string `json:"name"`
 	SSLMode     string `json:"ssl_mode"`
 	SSLRootCert string `json:"ssl_root_cert"`
+
+	// MaxOpenConns is the maximum number of connections that the master opens to the database.
+	// Statements wait for a free connection once they are all in use.
+	MaxOpenConns int `json:"max_open_conns"`
+	// MaxIdleConns is the maximum number of idle connections kept open for later statements.
+	MaxIdleConns int `json:"max_idle_conns"`
+	// ConnMaxIdleTime is the duration in seconds after which an idle connection is closed. Idle
+	// connections are kept open indefinitely if it is zero.
+	ConnMaxIdleTime int `json:"conn_max_idle_time"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	return []error{
+		check.GreaterThan(c.MaxOpenConns, 0, "db.max_open_conns must be positive"),
+		check.GreaterThanOrEqualTo(c.MaxIdleConns, 0, "db.max_idle_conns must not be negative"),
+		check.LessThanOrEqualTo(c.MaxIdleConns, c.MaxOpenConns,
+			"db.max_idle_conns must not exceed db.max_open_conns"),
+		check.GreaterThanOrEqualTo(c.ConnMaxIdleTime, 0,
+			"db.conn_max_idle_time must not be negative"),
+	}
 }
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// newAEAD returns the AES-GCM cipher for a key, which must be 16, 24 or 32 bytes long.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSecret encrypts plaintext, prefixed by the nonce it was encrypted with.
+func sealSecret(aead cipher.AEAD, plaintext string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "error generating nonce")
+	}
+	return aead.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+// openSecret decrypts a secret encrypted by sealSecret.
+func openSecret(aead cipher.AEAD, sealed []byte) (string, error) {
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "error decrypting secret; was the key changed?")
+	}
+	return string(plaintext), nil
+}
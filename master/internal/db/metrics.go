@@ -0,0 +1,342 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/internal/tracing"
+)
+
+// maxTracedStatementLength bounds the length of the statements recorded in traces.
+const maxTracedStatementLength = 1024
+
+// queryMetrics records the latency and errors of the statements that the master runs against the
+// database.
+type queryMetrics struct {
+	// queryErrors and execErrors are first so that they are aligned for atomic access on 32-bit
+	// platforms.
+	queryErrors uint64
+	execErrors  uint64
+	copyErrors  uint64
+	queries     *prom.HistogramValue
+	execs       *prom.HistogramValue
+	copies      *prom.HistogramValue
+}
+
+func newQueryMetrics() *queryMetrics {
+	return &queryMetrics{
+		queries: prom.NewHistogramValue(prom.DefaultLatencyBuckets),
+		execs:   prom.NewHistogramValue(prom.DefaultLatencyBuckets),
+		copies:  prom.NewHistogramValue(prom.DefaultLatencyBuckets),
+	}
+}
+
+// observeQuery records a query that started at the given time and failed if err is not nil.
+func (m *queryMetrics) observeQuery(start time.Time, err error) {
+	m.observe(m.queries, &m.queryErrors, start, err)
+}
+
+// observeExec records a statement other than a query that started at the given time and failed if
+// err is not nil.
+func (m *queryMetrics) observeExec(start time.Time, err error) {
+	m.observe(m.execs, &m.execErrors, start, err)
+}
+
+// observeCopy records a bulk copy that started at the given time and failed if err is not nil.
+func (m *queryMetrics) observeCopy(start time.Time, err error) {
+	m.observe(m.copies, &m.copyErrors, start, err)
+}
+
+func (m *queryMetrics) observe(h *prom.HistogramValue, errs *uint64, start time.Time, err error) {
+	h.Observe(time.Since(start).Seconds())
+	if err != nil && err != driver.ErrSkip {
+		atomic.AddUint64(errs, 1)
+	}
+}
+
+// startSpan starts a span for a statement if it runs on behalf of a traced request.
+func startSpan(ctx context.Context, name, query string) *tracing.Span {
+	if !tracing.SpanContextFromContext(ctx).Sampled {
+		return nil
+	}
+	_, span := tracing.Start(ctx, name, tracing.KindClient)
+	span.SetAttribute("db.system", "postgresql")
+	if len(query) > maxTracedStatementLength {
+		query = query[:maxTracedStatementLength]
+	}
+	span.SetAttribute("db.statement", query)
+	return span
+}
+
+// collector returns a Prometheus collector reporting the statement latencies and errors and the
+// state of the connection pool of the database.
+func (db *PgDB) collector() prom.Collector {
+	return func() []prom.Metric {
+		stats := db.sql.Stats()
+		return []prom.Metric{
+			{
+				Name: "det_db_query_duration_seconds",
+				Help: "Time until the database returns the first results of queries, or completes " +
+					"other statements.",
+				Type: prom.Histogram,
+				Samples: append(append(
+					db.metrics.queries.Samples(map[string]string{"operation": "query"}),
+					db.metrics.execs.Samples(map[string]string{"operation": "exec"})...),
+					db.metrics.copies.Samples(map[string]string{"operation": "copy"})...),
+			},
+			{
+				Name: "det_db_query_errors_total",
+				Help: "Number of queries and other statements that the database failed.",
+				Type: prom.Counter,
+				Samples: []prom.Sample{
+					{
+						Labels: map[string]string{"operation": "query"},
+						Value:  float64(atomic.LoadUint64(&db.metrics.queryErrors)),
+					},
+					{
+						Labels: map[string]string{"operation": "exec"},
+						Value:  float64(atomic.LoadUint64(&db.metrics.execErrors)),
+					},
+					{
+						Labels: map[string]string{"operation": "copy"},
+						Value:  float64(atomic.LoadUint64(&db.metrics.copyErrors)),
+					},
+				},
+			},
+			{
+				Name:    "det_db_connections_max",
+				Help:    "Maximum number of open connections to the database.",
+				Type:    prom.Gauge,
+				Samples: []prom.Sample{{Value: float64(stats.MaxOpenConnections)}},
+			},
+			{
+				Name: "det_db_connections_open",
+				Help: "Number of open connections to the database.",
+				Type: prom.Gauge,
+				Samples: []prom.Sample{
+					{Labels: map[string]string{"state": "in_use"}, Value: float64(stats.InUse)},
+					{Labels: map[string]string{"state": "idle"}, Value: float64(stats.Idle)},
+				},
+			},
+			{
+				Name:    "det_db_connection_waits_total",
+				Help:    "Number of times a statement waited for a free connection to the database.",
+				Type:    prom.Counter,
+				Samples: []prom.Sample{{Value: float64(stats.WaitCount)}},
+			},
+			{
+				Name:    "det_db_connection_wait_seconds_total",
+				Help:    "Total time statements waited for a free connection to the database.",
+				Type:    prom.Counter,
+				Samples: []prom.Sample{{Value: stats.WaitDuration.Seconds()}},
+			},
+			{
+				Name: "det_db_connections_closed_total",
+				Help: "Number of idle connections to the database that were closed because too many " +
+					"were idle or they were idle for too long.",
+				Type: prom.Counter,
+				Samples: []prom.Sample{
+					{
+						Labels: map[string]string{"reason": "max_idle_conns"},
+						Value:  float64(stats.MaxIdleClosed),
+					},
+					{
+						Labels: map[string]string{"reason": "conn_max_idle_time"},
+						Value:  float64(stats.MaxIdleTimeClosed),
+					},
+				},
+			},
+		}
+	}
+}
+
+// connectInstrumented connects to a Postgres database through connections that record the latency
+// of their statements.
+func connectInstrumented(url string, metrics *queryMetrics) (*sqlx.DB, error) {
+	// Opening a database does not connect to it, it only looks up the driver.
+	pgx, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+	d := pgx.Driver()
+	if err = pgx.Close(); err != nil {
+		return nil, err
+	}
+
+	c := &instrumentedConnector{driver: d, url: url, metrics: metrics}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c.inner, err = dc.OpenConnector(url); err != nil {
+			return nil, err
+		}
+	}
+	db := sqlx.NewDb(sql.OpenDB(c), "pgx")
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+type instrumentedConnector struct {
+	driver  driver.Driver
+	inner   driver.Connector
+	url     string
+	metrics *queryMetrics
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if c.inner != nil {
+		conn, err = c.inner.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, metrics: c.metrics}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConn times the statements run on a connection. It forwards the optional interfaces
+// of database/sql/driver to the connection it wraps, which falls back to the defaults of
+// database/sql if the connection does not implement them.
+type instrumentedConn struct {
+	driver.Conn
+	metrics *queryMetrics
+}
+
+func (c *instrumentedConn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	span := startSpan(ctx, "db.query", query)
+	defer span.End()
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.metrics.observeQuery(start, err)
+	span.SetError(err)
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	span := startSpan(ctx, "db.exec", query)
+	defer span.End()
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.metrics.observeExec(start, err)
+	span.SetError(err)
+	return result, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, metrics: c.metrics}, nil
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt times the executions of a prepared statement. Its arguments are checked by the
+// connection that prepared it.
+type instrumentedStmt struct {
+	driver.Stmt
+	metrics *queryMetrics
+}
+
+func (s *instrumentedStmt) QueryContext(
+	ctx context.Context, args []driver.NamedValue,
+) (rows driver.Rows, err error) {
+	defer func(start time.Time) { s.metrics.observeQuery(start, err) }(time.Now())
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values) //nolint:staticcheck
+}
+
+func (s *instrumentedStmt) ExecContext(
+	ctx context.Context, args []driver.NamedValue,
+) (result driver.Result, err error) {
+	defer func(start time.Time) { s.metrics.observeExec(start, err) }(time.Now())
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values) //nolint:staticcheck
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("the database driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
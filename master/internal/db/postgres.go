@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"crypto/cipher"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -12,7 +13,8 @@ import (
 	postgresM "github.com/golang-migrate/migrate/database/postgres"
 	_ "github.com/golang-migrate/migrate/source/file" // Load migrations from files.
 	"github.com/google/uuid"
-	_ "github.com/jackc/pgx/v4/stdlib" // Import Postgres driver.
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -29,15 +31,34 @@ type PgDB struct {
 	tokenKeys *model.AuthTokenKeypair
 	sql       *sqlx.DB
 	queries   *staticQueryMap
+	// registryCredentialsCipher encrypts stored registry credentials. It is nil if no key is set.
+	registryCredentialsCipher cipher.AEAD
+	// secretsCipher encrypts stored secrets. It is nil if no key is set.
+	secretsCipher cipher.AEAD
+	// storageCredentialsCipher encrypts the storage credentials of experiments and templates. They
+	// are stored unencrypted if it is nil.
+	storageCredentialsCipher cipher.AEAD
+	// bindTaskSessions restricts task tokens to the addresses of the containers of their tasks.
+	bindTaskSessions bool
+	// certifyTaskSessions restricts task tokens to clients with the certificates of their tasks.
+	certifyTaskSessions bool
+	metrics             *queryMetrics
+	// taskWatchers receive the state changes of tasks as they are persisted.
+	taskWatchers taskWatchers
 }
 
 // ConnectPostgres connects to a Postgres database.
 func ConnectPostgres(url string) (*PgDB, error) {
 	numTries := 0
+	metrics := newQueryMetrics()
 	for {
-		sql, err := sqlx.Connect("pgx", url)
+		sql, err := connectInstrumented(url, metrics)
 		if err == nil {
-			return &PgDB{sql: sql, queries: &staticQueryMap{queries: make(map[string]string)}}, err
+			return &PgDB{
+				sql:     sql,
+				queries: &staticQueryMap{queries: make(map[string]string)},
+				metrics: metrics,
+			}, nil
 		}
 		numTries++
 		if numTries >= 15 {
@@ -89,6 +110,11 @@ func (db *PgDB) Close() error {
 	return db.sql.Close()
 }
 
+// Ping checks that the database is reachable.
+func (db *PgDB) Ping(ctx context.Context) error {
+	return db.sql.PingContext(ctx)
+}
+
 // namedGet is a convenience method for a named query for a single value.
 func (db *PgDB) namedGet(dest interface{}, query string, arg interface{}) error {
 	nstmt, err := db.sql.PrepareNamed(query)
@@ -161,6 +187,43 @@ func (db *PgDB) query(q string, obj interface{}, args ...interface{}) error {
 	return nil
 }
 
+// copyFrom copies rows into the columns of a table in bulk over a connection of the pool. It uses
+// the COPY protocol of Postgres, which database/sql does not expose.
+func (db *PgDB) copyFrom(
+	ctx context.Context, table string, columns []string, rows pgx.CopyFromSource,
+) (int64, error) {
+	conn, err := db.sql.Conn(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "error acquiring database connection")
+	}
+	defer func() {
+		if cErr := conn.Close(); cErr != nil {
+			log.WithError(cErr).Error("error releasing database connection")
+		}
+	}()
+
+	var copied int64
+	err = conn.Raw(func(driverConn interface{}) error {
+		instrumented, ok := driverConn.(*instrumentedConn)
+		if !ok {
+			return errors.Errorf("cannot copy over a connection of type %T", driverConn)
+		}
+		pgxConn, ok := instrumented.Conn.(*stdlib.Conn)
+		if !ok {
+			return errors.Errorf("cannot copy over a connection of type %T", instrumented.Conn)
+		}
+		span := startSpan(ctx, "db.copy", "COPY "+table)
+		defer span.End()
+		start := time.Now()
+		var cErr error
+		copied, cErr = pgxConn.Conn().CopyFrom(ctx, pgx.Identifier{table}, columns, rows)
+		db.metrics.observeCopy(start, cErr)
+		span.SetError(cErr)
+		return cErr
+	})
+	return copied, err
+}
+
 // query executes a query returning a single row and unmarshals the result into a slice.
 func (db *PgDB) queryRows(query string, v interface{}, args ...interface{}) error {
 	parser := func(rows *sqlx.Rows, val interface{}) error { return rows.StructScan(val) }
@@ -334,7 +397,7 @@ FROM (
 SELECT row_to_json(e)
 FROM (
     SELECT e.archived, e.config, e.end_time, e.git_commit, e.git_commit_date, e.git_committer,
-           e.git_remote, e.id, e.start_time, e.state, e.progress,
+           e.git_remote, e.id, e.start_time, e.state, e.progress, e.estimated_end_time,
            (SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id) u)
 			as owner,
            (SELECT coalesce(jsonb_agg(t ORDER BY id ASC), '[]'::jsonb)
@@ -551,7 +614,7 @@ func (db *PgDB) ExperimentRaw(id int) ([]byte, error) {
 SELECT row_to_json(e)
 FROM (
     SELECT e.archived, e.config, e.end_time, e.git_commit, e.git_commit_date, e.git_committer,
-           e.git_remote, e.id, e.start_time, e.state, e.progress,
+           e.git_remote, e.id, e.start_time, e.state, e.progress, e.estimated_end_time,
            (SELECT to_json(u) FROM (SELECT id, username FROM users WHERE id = e.owner_id) u)
 			as owner,
            (SELECT coalesce(jsonb_agg(t ORDER BY id ASC), '[]'::jsonb)
@@ -670,20 +733,51 @@ FROM (
 ) descs`, skipArchived, skipInactive, username)
 }
 
-// AddExperiment adds the experiment to the database and sets its ID.
+// AddExperiment adds the experiment to the database and sets its ID. The storage credentials in
+// its config are stored apart from it; a child experiment inherits the credentials of its parent
+// that were redacted from its config, and they are put back into its config. Callers must check
+// that the owner of a child may use its parent.
 func (db *PgDB) AddExperiment(experiment *model.Experiment) error {
 	if experiment.ID != 0 {
 		return errors.Errorf("error adding an experiment with non-zero id %v", experiment.ID)
 	}
-	err := db.namedGet(&experiment.ID, `
+	if experiment.ProjectID == 0 {
+		experiment.ProjectID = model.DefaultProjectID
+	}
+	var inherited model.StorageCredentials
+	if experiment.ParentID != nil {
+		var err error
+		if inherited, err = db.experimentStorageCredentials(*experiment.ParentID); err != nil {
+			return err
+		}
+	}
+	stored, err := db.stripExperimentConfig(experiment.Config, inherited)
+	if err != nil {
+		return errors.Wrap(err, "error inserting experiment")
+	}
+	originalConfig, err := model.RedactStorageCredentialsYAML(experiment.OriginalConfig)
+	if err != nil {
+		return errors.Wrap(err, "error inserting experiment")
+	}
+	err = db.sql.QueryRowx(`
 INSERT INTO experiments
 (state, config, model_definition, start_time, end_time, archived,
- git_remote, git_commit, git_committer, git_commit_date, owner_id, original_config)
-VALUES (:state, :config, :model_definition, :start_time, :end_time, :archived,
-        :git_remote, :git_commit, :git_committer, :git_commit_date, :owner_id, :original_config)
-RETURNING id`, experiment)
+ git_remote, git_commit, git_committer, git_commit_date, owner_id, original_config, project_id,
+ storage_credentials, storage_credentials_encrypted)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id`,
+		experiment.State, stored.Config, experiment.ModelDefinitionBytes, experiment.StartTime,
+		experiment.EndTime, experiment.Archived, experiment.GitRemote, experiment.GitCommit,
+		experiment.GitCommitter, experiment.GitCommitDate, experiment.OwnerID, originalConfig,
+		experiment.ProjectID, stored.Credentials, stored.Encrypted,
+	).Scan(&experiment.ID)
 	if err != nil {
-		return errors.Wrapf(err, "error inserting experiment %v", *experiment)
+		return errors.Wrap(err, "error inserting experiment")
+	}
+	experiment.StorageCredentials = stored.Credentials
+	experiment.StorageCredentialsEncrypted = stored.Encrypted
+	if len(inherited) > 0 {
+		return db.restoreExperimentConfig(experiment)
 	}
 	return nil
 }
@@ -694,11 +788,15 @@ func (db *PgDB) ExperimentByID(id int) (*model.Experiment, error) {
 
 	if err := db.query(`
 SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, project_id,
+       storage_credentials, storage_credentials_encrypted
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
 	}
+	if err := db.restoreExperimentConfig(&experiment); err != nil {
+		return nil, err
+	}
 
 	return &experiment, nil
 }
@@ -711,7 +809,7 @@ func (db *PgDB) ExperimentWithoutConfigByID(id int) (*model.Experiment, error) {
 
 	if err := db.query(`
 SELECT id, state, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, project_id
 FROM experiments
 WHERE id = $1`, &experiment, id); err != nil {
 		return nil, err
@@ -746,9 +844,11 @@ FROM experiments e, trials t  WHERE t.id = $1 AND e.id = t.experiment_id`,
 func (db *PgDB) NonTerminalExperiments() ([]*model.Experiment, error) {
 	rows, err := db.sql.Queryx(`
 SELECT id, state, config, model_definition, start_time, end_time, archived,
-       git_remote, git_commit, git_committer, git_commit_date, owner_id
+       git_remote, git_commit, git_committer, git_commit_date, owner_id, project_id,
+       storage_credentials, storage_credentials_encrypted
 FROM experiments
-WHERE state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', 'STOPPING_ERROR')`)
+WHERE state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', 'STOPPING_ERROR',
+    'STOPPING_TIMEOUT')`)
 	if err == sql.ErrNoRows {
 		return nil, errors.WithStack(ErrNotFound)
 	} else if err != nil {
@@ -778,6 +878,9 @@ WHERE state IN ('ACTIVE', 'PAUSED', 'STOPPING_CANCELED', 'STOPPING_COMPLETED', '
 			}
 			continue
 		}
+		if err = db.restoreExperimentConfig(&exp); err != nil {
+			return nil, err
+		}
 		exps = append(exps, &exp)
 	}
 	return exps, nil
@@ -833,13 +936,29 @@ func (db *PgDB) TerminateExperimentInRestart(id int, state model.State) error {
 	return nil
 }
 
-// SaveExperimentConfig saves the current experiment config to the database.
+// SaveExperimentConfig saves the current experiment config to the database, with its storage
+// credentials stored apart from it.
 func (db *PgDB) SaveExperimentConfig(experiment *model.Experiment) error {
-	query := `
+	stored, err := db.stripExperimentConfig(experiment.Config, nil)
+	if err != nil {
+		return errors.Wrapf(err, "error saving config of experiment %d", experiment.ID)
+	}
+	result, err := db.sql.Exec(`
 UPDATE experiments
-SET config=:config
-WHERE id = :id`
-	return db.namedExecOne(query, experiment)
+SET config = $2, storage_credentials = $3, storage_credentials_encrypted = $4
+WHERE id = $1`, experiment.ID, stored.Config, stored.Credentials, stored.Encrypted)
+	if err != nil {
+		return errors.Wrapf(err, "error saving config of experiment %d", experiment.ID)
+	}
+	if num, err := result.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "error saving config of experiment %d", experiment.ID)
+	} else if num != 1 {
+		return errors.Errorf("error saving config of experiment %d: %d rows affected",
+			experiment.ID, num)
+	}
+	experiment.StorageCredentials = stored.Credentials
+	experiment.StorageCredentialsEncrypted = stored.Encrypted
+	return nil
 }
 
 // SaveExperimentState saves the current experiment state to the database.
@@ -921,9 +1040,12 @@ EXISTS(
 	return exists, err
 }
 
-// SaveExperimentProgress stores the progress for an experiment in the database.
-func (db *PgDB) SaveExperimentProgress(id int, progress *float64) error {
-	res, err := db.sql.Exec(`UPDATE experiments SET progress = $1 WHERE id = $2`, progress, id)
+// SaveExperimentProgress stores the progress for an experiment in the database, along with when
+// the experiment is estimated to end.
+func (db *PgDB) SaveExperimentProgress(id int, progress *float64, eta *time.Time) error {
+	res, err := db.sql.Exec(`
+UPDATE experiments SET progress = $1, estimated_end_time = $2
+WHERE id = $3`, progress, eta, id)
 	if err != nil {
 		return errors.Wrap(err, "saving experiment progress")
 	}
@@ -937,13 +1059,23 @@ func (db *PgDB) SaveExperimentProgress(id int, progress *float64) error {
 
 // ExperimentConfig returns the full config object for an experiment.
 func (db *PgDB) ExperimentConfig(id int) (expconf.ExperimentConfig, error) {
-	expConfigBytes, err := db.rawQuery(`
-SELECT config
+	var row struct {
+		Config                      []byte `db:"config"`
+		StorageCredentials          []byte `db:"storage_credentials"`
+		StorageCredentialsEncrypted bool   `db:"storage_credentials_encrypted"`
+	}
+	if err := db.query(`
+SELECT config, storage_credentials, storage_credentials_encrypted
 FROM experiments
-WHERE id = $1`, id)
-	if err != nil {
+WHERE id = $1`, &row, id); err != nil {
 		return expconf.ExperimentConfig{}, err
 	}
+	expConfigBytes, err := db.restoreConfig(
+		row.Config, row.StorageCredentials, row.StorageCredentialsEncrypted)
+	if err != nil {
+		return expconf.ExperimentConfig{}, errors.Wrapf(
+			err, "error restoring storage credentials of experiment %d", id)
+	}
 	expConfig, err := expconf.ParseAnyExperimentConfigYAML(expConfigBytes)
 	if err != nil {
 		return expconf.ExperimentConfig{}, errors.WithStack(err)
@@ -1593,10 +1725,12 @@ WHERE trial_id = $1
 		if _, err := tx.NamedExecContext(ctx, `
 INSERT INTO raw_checkpoints
 	(trial_id, trial_run_id, state, start_time, end_time, total_batches,
-	 total_records, total_epochs, uuid, resources, framework, format, determined_version)
+	 total_records, total_epochs, uuid, resources, digests, framework, format,
+	 determined_version)
 VALUES
 	(:trial_id, :trial_run_id, :state, :start_time, now(), :total_batches,
-	 :total_records, :total_epochs, :uuid, :resources, :framework, :format, :determined_version)
+	 :total_records, :total_epochs, :uuid, :resources, :digests, :framework, :format,
+	 :determined_version)
 `, model.Checkpoint{
 			TrialID:           int(m.TrialId),
 			TrialRunID:        int(m.TrialRunId),
@@ -1607,6 +1741,7 @@ VALUES
 			TotalEpochs:       m.TotalEpochs,
 			UUID:              &m.Uuid,
 			Resources:         model.JSONObjFromMapStringInt64(m.Resources),
+			Digests:           model.JSONObjFromMapStringString(m.Digests),
 			Framework:         m.Framework,
 			Format:            m.Format,
 			DeterminedVersion: m.DeterminedVersion,
@@ -1823,7 +1958,8 @@ RETURNING id`, checkpoint); err != nil {
 func (db *PgDB) CheckpointByTotalBatches(trialID, totalBatches int) (*model.Checkpoint, error) {
 	var checkpoint model.Checkpoint
 	if err := db.query(`
-SELECT id, trial_id, total_batches, state, start_time, end_time, uuid, resources, metadata
+SELECT id, trial_id, total_batches, state, start_time, end_time, uuid, resources, digests,
+	metadata
 FROM checkpoints
 WHERE trial_id = $1
 AND total_batches = $2`, &checkpoint, trialID, totalBatches); errors.Cause(err) == ErrNotFound {
@@ -1835,13 +1971,15 @@ AND total_batches = $2`, &checkpoint, trialID, totalBatches); errors.Cause(err)
 	return &checkpoint, nil
 }
 
-// CheckpointByUUID looks up a checkpoint by UUID, returning nil if none exists.
+// CheckpointByUUID looks up a checkpoint by UUID, returning nil if none exists. Checkpoints
+// imported from other clusters belong to no trial and are not returned.
 func (db *PgDB) CheckpointByUUID(id uuid.UUID) (*model.Checkpoint, error) {
 	var checkpoint model.Checkpoint
 	if err := db.query(`
 SELECT id, trial_id, total_batches, state, start_time, end_time, uuid, resources, metadata
 FROM checkpoints
-WHERE uuid = $1`, &checkpoint, id.String()); errors.Cause(err) == ErrNotFound {
+WHERE uuid = $1
+AND trial_id IS NOT NULL`, &checkpoint, id.String()); errors.Cause(err) == ErrNotFound {
 		return nil, nil
 	} else if err != nil {
 		return nil, errors.Wrapf(err, "error querying for checkpoint (%v)", id.String())
@@ -1874,7 +2012,8 @@ func (db *PgDB) UpdateCheckpoint(
 	newCheckpoint model.Checkpoint,
 ) error {
 	if len(newCheckpoint.State) == 0 && len(*newCheckpoint.UUID) == 0 &&
-		len(newCheckpoint.Resources) == 0 && len(newCheckpoint.Metadata) == 0 {
+		len(newCheckpoint.Resources) == 0 && len(newCheckpoint.Digests) == 0 &&
+		len(newCheckpoint.Metadata) == 0 {
 		return nil
 	}
 
@@ -1918,6 +2057,14 @@ func (db *PgDB) UpdateCheckpoint(
 		checkpoint.Resources = newCheckpoint.Resources
 		toUpdate = append(toUpdate, "resources")
 	}
+	if len(newCheckpoint.Digests) != 0 {
+		if len(checkpoint.Digests) != 0 {
+			return errors.Errorf("checkpoint (%v, %v) already has digests",
+				trialID, totalBatches)
+		}
+		checkpoint.Digests = newCheckpoint.Digests
+		toUpdate = append(toUpdate, "digests")
+	}
 	if len(newCheckpoint.Metadata) != 0 {
 		if len(checkpoint.Metadata) == 0 {
 			checkpoint.Metadata = model.JSONObj{}
@@ -2044,7 +2191,14 @@ WHERE id = $1
 func (db *PgDB) queryRowsWithParser(
 	query string, p func(*sqlx.Rows, interface{}) error, v interface{}, args ...interface{},
 ) error {
-	rows, err := db.sql.Queryx(query, args...)
+	return db.queryRowsWithParserContext(context.Background(), query, p, v, args...)
+}
+
+func (db *PgDB) queryRowsWithParserContext(
+	ctx context.Context, query string, p func(*sqlx.Rows, interface{}) error, v interface{},
+	args ...interface{},
+) error {
+	rows, err := db.sql.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AgentTokens returns all agent registration tokens.
+func (db *PgDB) AgentTokens() ([]model.AgentToken, error) {
+	var tokens []model.AgentToken
+	if err := db.queryRows(`
+SELECT * FROM agent_registration_tokens ORDER BY id`, &tokens); err != nil {
+		return nil, errors.Wrap(err, "error fetching agent registration tokens")
+	}
+	return tokens, nil
+}
+
+// AgentTokenByHash returns the agent registration token with the hash.
+func (db *PgDB) AgentTokenByHash(hash string) (*model.AgentToken, error) {
+	var token model.AgentToken
+	if err := db.query(`
+SELECT * FROM agent_registration_tokens WHERE token_hash = $1`, &token, hash); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// AddAgentToken creates an agent registration token and returns the token itself, which is not
+// stored.
+func (db *PgDB) AddAgentToken(token *model.AgentToken) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "error generating agent registration token")
+	}
+	raw := base64.RawURLEncoding.EncodeToString(secret)
+	token.TokenHash = model.HashAgentToken(raw)
+	token.CreatedAt = time.Now().UTC()
+
+	if err := db.namedGet(&token.ID, `
+INSERT INTO agent_registration_tokens (resource_pool, description, token_hash, created_at)
+VALUES (:resource_pool, :description, :token_hash, :created_at)
+RETURNING id`, token); err != nil {
+		return "", errors.Wrap(err, "error adding agent registration token")
+	}
+	return raw, nil
+}
+
+// DeleteAgentToken deletes an agent registration token and the approvals that are bound to it.
+// Agents that joined with it stay connected.
+func (db *PgDB) DeleteAgentToken(id int) error {
+	result, err := db.sql.Exec(`DELETE FROM agent_registration_tokens WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting agent registration token %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting agent registration token %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AgentApproved returns whether an admin approved an agent with the identity. Approvals hold for
+// the address and the registration token that the agent had when it was approved, unless the agent
+// connected with the certificate issued for its ID.
+func (db *PgDB) AgentApproved(agentID string, identity model.AgentIdentity) (bool, error) {
+	var approved bool
+	if err := db.sql.QueryRow(`
+SELECT EXISTS(
+    SELECT 1 FROM agent_approvals
+    WHERE agent_id = $1 AND ($2 OR (address = $3 AND token_id IS NOT DISTINCT FROM $4)))`,
+		agentID, identity.Certified, identity.Address, identity.TokenID,
+	).Scan(&approved); err != nil {
+		return false, errors.Wrapf(err, "error checking the approval of agent %s", agentID)
+	}
+	return approved, nil
+}
+
+// ApproveAgent records that an admin approved a connected agent with the identity, replacing any
+// earlier approval of its ID.
+func (db *PgDB) ApproveAgent(agentID string, identity model.AgentIdentity) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO agent_approvals (agent_id, address, token_id) VALUES ($1, $2, $3)
+ON CONFLICT (agent_id) DO UPDATE
+SET address = EXCLUDED.address, token_id = EXCLUDED.token_id, approved_at = now()`,
+		agentID, identity.Address, identity.TokenID); err != nil {
+		return errors.Wrapf(err, "error approving agent %s", agentID)
+	}
+	return nil
+}
+
+// RevokeAgentApproval deletes the approval of an agent.
+func (db *PgDB) RevokeAgentApproval(agentID string) error {
+	result, err := db.sql.Exec(`DELETE FROM agent_approvals WHERE agent_id = $1`, agentID)
+	if err != nil {
+		return errors.Wrapf(err, "error revoking the approval of agent %s", agentID)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error revoking the approval of agent %s", agentID)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
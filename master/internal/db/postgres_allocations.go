@@ -0,0 +1,113 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Allocation is a container that the master started for a task which can outlive a restart of the
+// master, so that the task can reattach to the container once its agent reconnects.
+type Allocation struct {
+	ContainerID string `db:"container_id"`
+	TaskID      string `db:"task_id"`
+	// TaskActor is the address of the actor of the task, e.g. "/notebooks/<task ID>".
+	TaskActor string `db:"task_actor"`
+	AgentID   string `db:"agent_id"`
+	// Spec is what the manager of the task needs to restore it.
+	Spec      []byte    `db:"spec"`
+	Encrypted bool      `db:"encrypted"`
+	StartTime time.Time `db:"start_time"`
+}
+
+// AddAllocation persists the allocation of a container. Its spec is encrypted like the storage
+// credentials of experiments, which it may hold, if security.storage_credentials_key is set.
+func (db *PgDB) AddAllocation(a Allocation) error {
+	a.StartTime = time.Now().UTC()
+	if db.storageCredentialsCipher != nil {
+		sealed, err := sealSecret(db.storageCredentialsCipher, string(a.Spec))
+		if err != nil {
+			return err
+		}
+		a.Spec, a.Encrypted = sealed, true
+	}
+	if _, err := db.sql.NamedExec(`
+INSERT INTO allocations (container_id, task_id, task_actor, agent_id, spec, encrypted, start_time)
+VALUES (:container_id, :task_id, :task_actor, :agent_id, :spec, :encrypted, :start_time)`,
+		a); err != nil {
+		return errors.Wrapf(err, "error persisting allocation of container %s", a.ContainerID)
+	}
+	return nil
+}
+
+// UpdateAllocationSpec replaces the spec of the persisted allocation of a container, if it is
+// persisted, e.g. once the ports that its task forwards change.
+func (db *PgDB) UpdateAllocationSpec(containerID string, spec []byte) error {
+	encrypted := false
+	if db.storageCredentialsCipher != nil {
+		sealed, err := sealSecret(db.storageCredentialsCipher, string(spec))
+		if err != nil {
+			return err
+		}
+		spec, encrypted = sealed, true
+	}
+	if _, err := db.sql.Exec(`
+UPDATE allocations
+SET spec = $2, encrypted = $3
+WHERE container_id = $1`, containerID, spec, encrypted); err != nil {
+		return errors.Wrapf(err, "error updating allocation of container %s", containerID)
+	}
+	return nil
+}
+
+// Allocations returns the persisted allocations, oldest first, with their specs decrypted.
+func (db *PgDB) Allocations() ([]Allocation, error) {
+	var allocations []Allocation
+	if err := db.queryRows(`
+SELECT * FROM allocations
+ORDER BY start_time`, &allocations); err != nil {
+		return nil, errors.Wrap(err, "error fetching allocations")
+	}
+	return db.openAllocations(allocations)
+}
+
+// AgentAllocations returns the persisted allocations of the containers on an agent, with their
+// specs decrypted.
+func (db *PgDB) AgentAllocations(agentID string) ([]Allocation, error) {
+	var allocations []Allocation
+	if err := db.queryRows(`
+SELECT * FROM allocations
+WHERE agent_id = $1
+ORDER BY start_time`, &allocations, agentID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching allocations of agent %s", agentID)
+	}
+	return db.openAllocations(allocations)
+}
+
+func (db *PgDB) openAllocations(allocations []Allocation) ([]Allocation, error) {
+	for i, a := range allocations {
+		if !a.Encrypted {
+			continue
+		}
+		if db.storageCredentialsCipher == nil {
+			return nil, errors.Wrapf(ErrStorageCredentialsKeyMissing,
+				"error reading allocation of container %s", a.ContainerID)
+		}
+		spec, err := openSecret(db.storageCredentialsCipher, a.Spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading allocation of container %s", a.ContainerID)
+		}
+		allocations[i].Spec, allocations[i].Encrypted = []byte(spec), false
+	}
+	return allocations, nil
+}
+
+// DeleteAllocation deletes the allocation of a container once it stops or cannot be reattached.
+func (db *PgDB) DeleteAllocation(containerID string) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM allocations
+WHERE container_id = $1`, containerID); err != nil {
+		return errors.Wrapf(err, "error deleting allocation of container %s", containerID)
+	}
+	return nil
+}
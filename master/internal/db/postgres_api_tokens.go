@@ -0,0 +1,107 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func hashAPIToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// AddAPIToken creates an API token and returns the token itself, which is not stored. Expired
+// tokens are deleted along the way.
+func (db *PgDB) AddAPIToken(token *model.APIToken) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "error generating API token")
+	}
+	raw := model.APITokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	token.TokenHash = hashAPIToken(raw)
+	token.CreatedAt = time.Now().UTC()
+
+	if _, err := db.sql.Exec(`
+DELETE FROM api_tokens WHERE expires_at < $1`, token.CreatedAt); err != nil {
+		return "", errors.Wrap(err, "error deleting expired API tokens")
+	}
+	if err := db.namedGet(&token.ID, `
+INSERT INTO api_tokens (user_id, description, token_hash, scope, created_at, expires_at)
+VALUES (:user_id, :description, :token_hash, :scope, :created_at, :expires_at)
+RETURNING id`, token); err != nil {
+		return "", errors.Wrapf(err, "error adding API token for user %d", token.UserID)
+	}
+	return raw, nil
+}
+
+// APITokens returns the API tokens of a user that have not expired.
+func (db *PgDB) APITokens(userID model.UserID) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	if err := db.queryRows(`
+SELECT * FROM api_tokens
+WHERE user_id = $1 AND expires_at >= $2
+ORDER BY id`, &tokens, userID, time.Now().UTC()); err != nil {
+		return nil, errors.Wrapf(err, "error fetching API tokens of user %d", userID)
+	}
+	return tokens, nil
+}
+
+// APITokenByID looks up an API token by ID, returning ErrNotFound if it does not exist.
+func (db *PgDB) APITokenByID(id int) (*model.APIToken, error) {
+	var token model.APIToken
+	if err := db.query(`
+SELECT * FROM api_tokens
+WHERE id = $1`, &token, id); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteAPIToken revokes an API token.
+func (db *PgDB) DeleteAPIToken(id int) error {
+	result, err := db.sql.Exec(`
+DELETE FROM api_tokens WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting API token %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting API token %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UserByAPIToken returns the user that an API token acts on behalf of, along with the token. It
+// returns ErrNotFound if the token does not exist or has expired.
+func (db *PgDB) UserByAPIToken(raw string) (*model.User, *model.APIToken, error) {
+	var token model.APIToken
+	if err := db.query(`
+SELECT * FROM api_tokens
+WHERE token_hash = $1`, &token, hashAPIToken(raw)); errors.Cause(err) == ErrNotFound {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if token.ExpiresAt.Before(time.Now().UTC()) {
+		return nil, nil, ErrNotFound
+	}
+
+	var user model.User
+	if err := db.query(`
+SELECT * FROM users
+WHERE id = $1`, &user, token.UserID); errors.Cause(err) == ErrNotFound {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return &user, &token, nil
+}
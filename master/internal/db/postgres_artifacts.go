@@ -0,0 +1,238 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const selectArtifacts = `
+SELECT id, name, type, state, task_id, owner_id, size_bytes, num_chunks, creation_time,
+    completion_time, deduplicated
+FROM artifacts`
+
+// Artifacts returns the artifacts that a task uploaded, if taskID is not empty, or that a user
+// uploaded, if ownerID is not 0.
+func (db *PgDB) Artifacts(taskID string, ownerID model.UserID) ([]model.Artifact, error) {
+	var artifacts []model.Artifact
+	if err := db.queryRows(selectArtifacts+`
+WHERE ($1 = '' OR task_id = $1) AND ($2 = 0 OR owner_id = $2)
+ORDER BY creation_time`, &artifacts, taskID, ownerID); err != nil {
+		return nil, errors.Wrap(err, "error fetching artifacts")
+	}
+	return artifacts, nil
+}
+
+// ArtifactByID returns an artifact.
+func (db *PgDB) ArtifactByID(id string) (*model.Artifact, error) {
+	var artifact model.Artifact
+	if err := db.query(selectArtifacts+`
+WHERE id = $1`, &artifact, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching artifact %s", id)
+	}
+	return &artifact, nil
+}
+
+// AddArtifact persists a new artifact whose upload started and sets its creation time.
+func (db *PgDB) AddArtifact(artifact *model.Artifact) error {
+	nstmt, err := db.sql.PrepareNamed(`
+INSERT INTO artifacts (id, name, type, state, task_id, owner_id)
+VALUES (:id, :name, :type, :state, :task_id, :owner_id)
+RETURNING creation_time`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing to add artifact")
+	}
+	defer nstmt.Close()
+	if err = nstmt.QueryRowx(artifact).Scan(&artifact.CreationTime); err != nil {
+		return errors.Wrapf(err, "error adding artifact %s", artifact.ID)
+	}
+	return nil
+}
+
+// UpdateArtifact stores the progress of the upload of an artifact: its size, number of chunks,
+// state, completion time and whether it is deduplicated.
+func (db *PgDB) UpdateArtifact(artifact *model.Artifact) error {
+	result, err := db.sql.NamedExec(`
+UPDATE artifacts
+SET size_bytes = :size_bytes, num_chunks = :num_chunks, state = :state,
+    completion_time = :completion_time, deduplicated = :deduplicated
+WHERE id = :id`, artifact)
+	if err != nil {
+		return errors.Wrapf(err, "error updating artifact %s", artifact.ID)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error updating artifact %s", artifact.ID)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteArtifact deletes an artifact.
+func (db *PgDB) DeleteArtifact(id string) error {
+	result, err := db.sql.Exec(`DELETE FROM artifacts WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting artifact %s", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting artifact %s", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PutArtifactChunk stores a chunk of an artifact in the database, replacing any chunk with the
+// same key.
+func (db *PgDB) PutArtifactChunk(key string, data []byte) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO artifact_chunks (key, data) VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data`, key, data); err != nil {
+		return errors.Wrapf(err, "error storing artifact chunk %s", key)
+	}
+	return nil
+}
+
+// ArtifactChunk returns a chunk of an artifact that is stored in the database.
+func (db *PgDB) ArtifactChunk(key string) ([]byte, error) {
+	data, err := db.rawQuery(`SELECT data FROM artifact_chunks WHERE key = $1`, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error fetching artifact chunk %s", key)
+	}
+	return data, nil
+}
+
+// DeleteArtifactChunk deletes a chunk of an artifact from the database. It does not fail if the
+// chunk does not exist.
+func (db *PgDB) DeleteArtifactChunk(key string) error {
+	if _, err := db.sql.Exec(`DELETE FROM artifact_chunks WHERE key = $1`, key); err != nil {
+		return errors.Wrapf(err, "error deleting artifact chunk %s", key)
+	}
+	return nil
+}
+
+// useArtifactBlobs marks the blobs in the manifest of an artifact as used now.
+const useArtifactBlobs = `
+UPDATE artifact_blobs SET last_used_time = now()
+WHERE hash IN (SELECT hash FROM artifact_manifests WHERE artifact_id = $1)`
+
+// PutArtifactManifest replaces the manifest of an artifact with the hashes of its blobs, in order,
+// and marks the blobs that it shares with other artifacts as used now.
+func (db *PgDB) PutArtifactManifest(id string, hashes []string) error {
+	return db.withTransaction("put artifact manifest", func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM artifact_manifests WHERE artifact_id = $1`, id); err != nil {
+			return errors.Wrapf(err, "error deleting the manifest of artifact %s", id)
+		}
+		if _, err := tx.Exec(`
+INSERT INTO artifact_manifests (artifact_id, chunk_index, hash)
+SELECT $1, m.ordinality - 1, m.hash
+FROM unnest(string_to_array($2, ',')) WITH ORDINALITY AS m(hash, ordinality)`,
+			id, strings.Join(hashes, ",")); err != nil {
+			return errors.Wrapf(err, "error adding the manifest of artifact %s", id)
+		}
+		if _, err := tx.Exec(useArtifactBlobs, id); err != nil {
+			return errors.Wrapf(err, "error using the blobs of artifact %s", id)
+		}
+		return nil
+	})
+}
+
+// ArtifactManifest returns the hashes of the blobs of an artifact, in order.
+func (db *PgDB) ArtifactManifest(id string) ([]string, error) {
+	var hashes []string
+	if err := db.sql.Select(&hashes, `
+SELECT hash FROM artifact_manifests WHERE artifact_id = $1 ORDER BY chunk_index`, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the manifest of artifact %s", id)
+	}
+	return hashes, nil
+}
+
+// MissingArtifactBlobs returns the hashes in the manifest of an artifact of the blobs that are not
+// stored yet.
+func (db *PgDB) MissingArtifactBlobs(id string) ([]string, error) {
+	var hashes []string
+	if err := db.sql.Select(&hashes, `
+SELECT DISTINCT m.hash
+FROM artifact_manifests m
+LEFT JOIN artifact_blobs b ON b.hash = m.hash
+WHERE m.artifact_id = $1 AND b.hash IS NULL
+ORDER BY m.hash`, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the missing blobs of artifact %s", id)
+	}
+	return hashes, nil
+}
+
+// ArtifactManifestSize returns the total size of the blobs in the manifest of an artifact.
+func (db *PgDB) ArtifactManifestSize(id string) (int64, error) {
+	var size int64
+	if err := db.sql.Get(&size, `
+SELECT COALESCE(sum(b.size_bytes), 0)
+FROM artifact_manifests m
+JOIN artifact_blobs b ON b.hash = m.hash
+WHERE m.artifact_id = $1`, id); err != nil {
+		return 0, errors.Wrapf(err, "error fetching the size of artifact %s", id)
+	}
+	return size, nil
+}
+
+// AddArtifactBlob records a blob that was stored, or marks it as used now if it already was.
+func (db *PgDB) AddArtifactBlob(hash string, size int64) error {
+	if _, err := db.sql.Exec(`
+INSERT INTO artifact_blobs (hash, size_bytes) VALUES ($1, $2)
+ON CONFLICT (hash) DO UPDATE SET last_used_time = now()`, hash, size); err != nil {
+		return errors.Wrapf(err, "error adding artifact blob %s", hash)
+	}
+	return nil
+}
+
+// UseArtifactBlobs marks the blobs in the manifest of an artifact as used now, so that they stay
+// cached for as long as possible once the artifact is deleted.
+func (db *PgDB) UseArtifactBlobs(id string) error {
+	if _, err := db.sql.Exec(useArtifactBlobs, id); err != nil {
+		return errors.Wrapf(err, "error using the blobs of artifact %s", id)
+	}
+	return nil
+}
+
+// EvictableArtifactBlobs returns the hashes of the blobs that no artifact uses and that are to be
+// evicted from the cache: those last used before a time and, if maxSize is not 0, the least
+// recently used beyond that total size.
+func (db *PgDB) EvictableArtifactBlobs(before time.Time, maxSize int64) ([]string, error) {
+	var hashes []string
+	if err := db.sql.Select(&hashes, `
+SELECT hash
+FROM (
+    SELECT hash, last_used_time,
+        sum(size_bytes) OVER (ORDER BY last_used_time DESC, hash) AS cumulative_size
+    FROM artifact_blobs b
+    WHERE NOT EXISTS (SELECT 1 FROM artifact_manifests m WHERE m.hash = b.hash)
+) unused
+WHERE last_used_time < $1 OR ($2 > 0 AND cumulative_size > $2)`, before, maxSize); err != nil {
+		return nil, errors.Wrap(err, "error fetching evictable artifact blobs")
+	}
+	return hashes, nil
+}
+
+// DeleteUnusedArtifactBlob deletes the record of a blob, unless an artifact uses it again, and
+// returns whether it was deleted.
+func (db *PgDB) DeleteUnusedArtifactBlob(hash string) (bool, error) {
+	result, err := db.sql.Exec(`
+DELETE FROM artifact_blobs b
+WHERE hash = $1 AND NOT EXISTS (SELECT 1 FROM artifact_manifests m WHERE m.hash = b.hash)`, hash)
+	if err != nil {
+		return false, errors.Wrapf(err, "error deleting artifact blob %s", hash)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "error deleting artifact blob %s", hash)
+	}
+	return num == 1, nil
+}
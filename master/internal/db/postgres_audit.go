@@ -0,0 +1,40 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddAuditEntry adds an entry to the audit log.
+func (db *PgDB) AddAuditEntry(entry *model.AuditEntry) error {
+	if err := db.namedGet(&entry.ID, `
+INSERT INTO audit_log (time, user_id, username, task_id, address, method, request, outcome)
+VALUES (:time, :user_id, :username, :task_id, :address, :method, :request, :outcome)
+RETURNING id`, entry); err != nil {
+		return errors.Wrapf(err, "error adding audit log entry for %s", entry.Method)
+	}
+	return nil
+}
+
+// AuditEntryCount returns the number of entries in the audit log.
+func (db *PgDB) AuditEntryCount() (int, error) {
+	var count int
+	if err := db.sql.Get(&count, `SELECT count(*) FROM audit_log`); err != nil {
+		return 0, errors.Wrap(err, "error counting audit log entries")
+	}
+	return count, nil
+}
+
+// AuditEntries returns at most limit entries of the audit log in the order they were added,
+// starting after skipping offset entries.
+func (db *PgDB) AuditEntries(offset, limit int) ([]*model.AuditEntry, error) {
+	var entries []*model.AuditEntry
+	if err := db.queryRows(`
+SELECT * FROM audit_log
+ORDER BY id
+OFFSET $1 LIMIT $2`, &entries, offset, limit); err != nil {
+		return nil, errors.Wrap(err, "error fetching audit log entries")
+	}
+	return entries, nil
+}
@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// BackupTables are the tables of cluster metadata that backups hold: tenants, users with their
+// sessions, tokens, secrets and keys, RBAC, workspaces, templates, experiments with their trials,
+// metrics, logs and snapshots, checkpoints, the model registry, tasks, datasets, artifacts and the
+// audit log. Every table that references one of them is one of them too, since restoring could not
+// otherwise empty the tables. Each table comes after the tables that it references.
+var BackupTables = []string{
+	"tenants",
+	"tenant_resource_pools",
+	"users",
+	"user_sessions",
+	"api_tokens",
+	"secrets",
+	"registry_credentials",
+	"user_ssh_keys",
+	"audit_log",
+	"agent_user_groups",
+	"groups",
+	"group_members",
+	"roles",
+	"role_permissions",
+	"workspaces",
+	"projects",
+	"role_assignments",
+	"config_policies",
+	"budgets",
+	"templates",
+	"experiments",
+	"trials",
+	"raw_steps",
+	"raw_validations",
+	"raw_checkpoints",
+	"trial_logs",
+	"experiment_snapshots",
+	"trial_snapshots",
+	"webhooks",
+	"models",
+	"model_versions",
+	"tasks",
+	"batch_inference_jobs",
+	"task_profiler_samples",
+	"datasets",
+	"experiment_datasets",
+	"task_datasets",
+	"artifacts",
+	"artifact_manifests",
+}
+
+// restoreDeferredColumns are the columns of backup tables that reference tables after them. They
+// are restored after every table, which breaks the cycles between the tables.
+var restoreDeferredColumns = map[string]string{
+	"trials": "warm_start_checkpoint_id",
+}
+
+// SchemaVersion returns the version of the last migration that was applied to the database.
+func (db *PgDB) SchemaVersion() (int64, error) {
+	return schemaVersion(db.sql)
+}
+
+func schemaVersion(q sqlx.Queryer) (int64, error) {
+	var version int64
+	var dirty bool
+	if err := q.QueryRowx(`SELECT version, dirty FROM schema_migrations`).Scan(
+		&version, &dirty,
+	); err != nil {
+		return 0, errors.Wrap(err, "error fetching the schema version")
+	}
+	if dirty {
+		return 0, errors.Errorf("the migration to schema version %d did not complete", version)
+	}
+	return version, nil
+}
+
+// ExportTables reads every row of the tables as JSON from one consistent snapshot of the database.
+// It calls start with the schema version of the snapshot, then emit with each row in the order of
+// the tables.
+func (db *PgDB) ExportTables(
+	ctx context.Context, tables []string, start func(schemaVersion int64) error,
+	emit func(table string, row json.RawMessage) error,
+) error {
+	tx, err := db.sql.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error starting the export")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	version, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if err = start(version); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err = exportTable(ctx, tx, table, emit); err != nil {
+			return errors.Wrapf(err, "error exporting table %s", table)
+		}
+	}
+	return nil
+}
+
+func exportTable(
+	ctx context.Context, tx *sqlx.Tx, table string, emit func(string, json.RawMessage) error,
+) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT row_to_json(t) FROM %s t`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row []byte
+		if err = rows.Scan(&row); err != nil {
+			return err
+		}
+		if err = emit(table, row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RestoreTables replaces the rows of the tables with the ones that read inserts, in one
+// transaction. The tables are emptied first, which fails if a table that is not restored
+// references them, and read must insert the rows of each table after those of the tables that it
+// references. The sequences of the tables continue after the restored IDs.
+func (db *PgDB) RestoreTables(
+	ctx context.Context, tables []string,
+	read func(insert func(table string, rows []json.RawMessage) error) error,
+) error {
+	return db.withTransaction("restore", func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			`TRUNCATE %s`, strings.Join(tables, ", "))); err != nil {
+			return errors.Wrap(err, "error emptying the restored tables")
+		}
+
+		known := make(map[string]bool, len(tables))
+		for _, table := range tables {
+			known[table] = true
+		}
+		var deferred []func() error
+		insert := func(table string, rows []json.RawMessage) error {
+			if !known[table] {
+				return errors.Errorf("unknown table %s", table)
+			}
+			b, err := json.Marshal(rows)
+			if err != nil {
+				return err
+			}
+			column, ok := restoreDeferredColumns[table]
+			if !ok {
+				_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %[1]s SELECT * FROM json_populate_recordset(NULL::%[1]s, $1)`, table), b)
+				return errors.Wrapf(err, "error restoring table %s", table)
+			}
+			if _, err = tx.ExecContext(ctx, fmt.Sprintf(`
+INSERT INTO %[1]s SELECT * FROM jsonb_populate_recordset(NULL::%[1]s,
+    (SELECT jsonb_agg(r - '%[2]s') FROM jsonb_array_elements($1::jsonb) r))`,
+				table, column), b); err != nil {
+				return errors.Wrapf(err, "error restoring table %s", table)
+			}
+			deferred = append(deferred, func() error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+UPDATE %[1]s t SET %[2]s = (r->>'%[2]s')::int
+FROM jsonb_array_elements($1::jsonb) r
+WHERE t.id = (r->>'id')::int AND r->>'%[2]s' IS NOT NULL`, table, column), b)
+				return errors.Wrapf(err, "error restoring %s of table %s", column, table)
+			})
+			return nil
+		}
+		if err := read(insert); err != nil {
+			return err
+		}
+		for _, restore := range deferred {
+			if err := restore(); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range tables {
+			if err := resetSequence(ctx, tx, table); err != nil {
+				return errors.Wrapf(err, "error resetting the ID sequence of table %s", table)
+			}
+		}
+		return nil
+	})
+}
+
+// resetSequence makes the sequence of the IDs of a table, if it has one, continue after the
+// largest ID in the table.
+func resetSequence(ctx context.Context, tx *sqlx.Tx, table string) error {
+	var sequence sql.NullString
+	switch err := tx.QueryRowxContext(ctx, `
+SELECT pg_get_serial_sequence($1, attname) FROM pg_attribute
+WHERE attrelid = $1::regclass AND attname = 'id'`, table).Scan(&sequence); {
+	case err == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return err
+	case !sequence.Valid:
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(
+		`SELECT setval($1, coalesce((SELECT max(id) FROM %s), 0) + 1, false)`, table),
+		sequence.String)
+	return err
+}
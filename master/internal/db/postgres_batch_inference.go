@@ -0,0 +1,51 @@
+package db
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddBatchInferenceJob persists a batch inference job along with its task, so that the job can be
+// listed as soon as it is launched.
+func (db *PgDB) AddBatchInferenceJob(t *model.Task, job *model.BatchInferenceJob) error {
+	return db.withTransaction("add batch inference job", func(tx *sqlx.Tx) error {
+		if _, err := tx.NamedExec(insertTask, t); err != nil {
+			return errors.Wrapf(err, "error persisting task %s", t.TaskID)
+		}
+		if _, err := tx.NamedExec(`
+INSERT INTO batch_inference_jobs (task_id, checkpoint_uuid, model_name, model_version, predictor,
+    input_path, output_path)
+VALUES (:task_id, :checkpoint_uuid, :model_name, :model_version, :predictor,
+    :input_path, :output_path)`, job); err != nil {
+			return errors.Wrapf(err, "error persisting batch inference job %s", job.TaskID)
+		}
+		return nil
+	})
+}
+
+// SetBatchInferenceProgress records the fraction of the input files that a batch inference job
+// has made predictions for, and how many records it has processed.
+func (db *PgDB) SetBatchInferenceProgress(job *model.BatchInferenceJob) error {
+	if err := db.namedExecOne(`
+UPDATE batch_inference_jobs
+SET progress = :progress, records_processed = :records_processed
+WHERE task_id = :task_id`, job); err != nil {
+		return errors.Wrapf(err, "error recording the progress of batch inference job %s",
+			job.TaskID)
+	}
+	return nil
+}
+
+// SetBatchInferenceExitStatus records how the container of a batch inference job exited.
+func (db *PgDB) SetBatchInferenceExitStatus(job *model.BatchInferenceJob) error {
+	if err := db.namedExecOne(`
+UPDATE batch_inference_jobs
+SET succeeded = :succeeded, exit_status = :exit_status
+WHERE task_id = :task_id`, job); err != nil {
+		return errors.Wrapf(err, "error recording the exit status of batch inference job %s",
+			job.TaskID)
+	}
+	return nil
+}
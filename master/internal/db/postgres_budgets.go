@@ -0,0 +1,76 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Budgets returns all budgets.
+func (db *PgDB) Budgets() ([]model.Budget, error) {
+	var budgets []model.Budget
+	if err := db.queryRows(`SELECT * FROM budgets ORDER BY id`, &budgets); err != nil {
+		return nil, errors.Wrap(err, "error fetching budgets")
+	}
+	return budgets, nil
+}
+
+// AddBudget persists a new budget and sets its ID.
+func (db *PgDB) AddBudget(budget *model.Budget) error {
+	if err := db.namedGet(&budget.ID, `
+INSERT INTO budgets (user_id, project_id, unit, limit_value, mode, preempt)
+VALUES (:user_id, :project_id, :unit, :limit_value, :mode, :preempt)
+RETURNING id`, budget); err != nil {
+		return errors.Wrap(err, "error adding budget")
+	}
+	return nil
+}
+
+// DeleteBudget deletes a budget.
+func (db *PgDB) DeleteBudget(id int) error {
+	result, err := db.sql.Exec(`DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting budget %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting budget %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// BudgetSlotHours returns the slot-hours that the tasks limited by a budget have been assigned in
+// each resource pool since a time, including those of the tasks that are still running.
+func (db *PgDB) BudgetSlotHours(budget model.Budget, since time.Time) (map[string]float64, error) {
+	var rows []struct {
+		ResourcePool string  `db:"resource_pool"`
+		SlotHours    float64 `db:"slot_hours"`
+	}
+	if err := db.queryRows(`
+WITH const AS (
+    SELECT $1::timestamp AS since, now() AT TIME ZONE 'utc' AS now
+)
+SELECT
+    coalesce(nullif(resource_pool, ''), 'default') AS resource_pool,
+    sum(
+        extract(epoch FROM coalesce(end_time, const.now) - greatest(assigned_time, const.since))
+        * slots
+    ) / 3600 AS slot_hours
+FROM tasks, const
+WHERE assigned_time IS NOT NULL
+    AND (end_time IS NULL OR end_time > const.since)
+    AND (owner_id = $2 OR project_id = $3)
+GROUP BY 1`, &rows, since.UTC(), budget.UserID, budget.ProjectID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the usage of budget %d", budget.ID)
+	}
+	slotHours := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		slotHours[row.ResourcePool] = row.SlotHours
+	}
+	return slotHours, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddImportedCheckpoint adds a completed checkpoint imported from another cluster, which belongs
+// to no trial, along with what its source tells about it. It returns ErrDuplicateRecord if a
+// checkpoint with the same UUID exists.
+func (db *PgDB) AddImportedCheckpoint(checkpoint *model.Checkpoint, source model.JSONObj) error {
+	err := db.sql.QueryRowx(`
+INSERT INTO checkpoints
+(trial_id, total_batches, state, start_time, end_time, uuid, resources, metadata, framework,
+ format, determined_version, import_source)
+VALUES (NULL, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id`,
+		checkpoint.TotalBatches, checkpoint.State, checkpoint.StartTime, checkpoint.EndTime,
+		checkpoint.UUID, checkpoint.Resources, checkpoint.Metadata, checkpoint.Framework,
+		checkpoint.Format, checkpoint.DeterminedVersion, source,
+	).Scan(&checkpoint.ID)
+	if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrapf(err, "error importing checkpoint %v", *checkpoint.UUID)
+}
+
+// ExperimentCheckpointsToVerifyRaw returns a JSON object whose checkpoints are the completed
+// checkpoints of an experiment with their recorded resources and digests, limited to those with the
+// given UUIDs if any are given.
+func (db *PgDB) ExperimentCheckpointsToVerifyRaw(id int, uuids []string) ([]byte, error) {
+	return db.rawQuery(`
+SELECT jsonb_build_object('checkpoints', coalesce(jsonb_agg(c ORDER BY c.id ASC), '[]'::jsonb))
+FROM (
+    SELECT c.id, c.uuid, c.resources, c.digests, c.framework, c.format
+    FROM checkpoints c
+    JOIN trials t ON c.trial_id = t.id
+    WHERE t.experiment_id = $1
+      AND c.state = 'COMPLETED'
+      AND ($2 = '' OR c.uuid::text = ANY(string_to_array($2, ',')))
+) c`, id, strings.Join(uuids, ","))
+}
+
+// SetCheckpointIntegrity records what a verification of the files of a checkpoint found.
+func (db *PgDB) SetCheckpointIntegrity(uuid string, integrity model.CheckpointIntegrity) error {
+	res, err := db.sql.Exec(`
+UPDATE raw_checkpoints SET integrity = $2, verified_time = now() WHERE uuid = $1`,
+		uuid, integrity)
+	if err != nil {
+		return errors.Wrapf(err, "error recording the integrity of checkpoint %v", uuid)
+	}
+	if num, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "error recording the integrity of checkpoint %v", uuid)
+	} else if num == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
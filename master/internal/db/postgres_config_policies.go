@@ -0,0 +1,65 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ConfigPolicies returns all config policies.
+func (db *PgDB) ConfigPolicies() ([]model.ConfigPolicy, error) {
+	var policies []model.ConfigPolicy
+	if err := db.queryRows(`
+SELECT * FROM config_policies ORDER BY id`, &policies); err != nil {
+		return nil, errors.Wrap(err, "error fetching config policies")
+	}
+	return policies, nil
+}
+
+// UserConfigPolicies returns the config policies of a workspace and of the groups of a user, in the
+// order that they were added.
+func (db *PgDB) UserConfigPolicies(
+	userID model.UserID, workspaceID int,
+) ([]model.ConfigPolicy, error) {
+	var policies []model.ConfigPolicy
+	if err := db.queryRows(`
+SELECT * FROM config_policies
+WHERE workspace_id = $2
+    OR group_id IN (SELECT group_id FROM group_members WHERE user_id = $1)
+ORDER BY id`, &policies, userID, workspaceID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching config policies of user %d", userID)
+	}
+	return policies, nil
+}
+
+// AddConfigPolicy persists a new config policy and sets its ID.
+func (db *PgDB) AddConfigPolicy(policy *model.ConfigPolicy) error {
+	if err := db.namedGet(&policy.ID, `
+INSERT INTO config_policies (
+    workspace_id, group_id, invariant_experiment_config, invariant_command_config, constraints
+)
+VALUES (
+    :workspace_id, :group_id, :invariant_experiment_config, :invariant_command_config,
+    :constraints
+)
+RETURNING id`, policy); err != nil {
+		return errors.Wrap(err, "error adding config policy")
+	}
+	return nil
+}
+
+// DeleteConfigPolicy deletes a config policy.
+func (db *PgDB) DeleteConfigPolicy(id int) error {
+	result, err := db.sql.Exec(`DELETE FROM config_policies WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting config policy %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting config policy %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// foreignKeyViolation is the error code that Postgres uses to indicate that a delete would leave
+// rows that reference the deleted rows.
+const foreignKeyViolation = "23503"
+
+// ErrDatasetInUse is returned when deleting a dataset that experiments or tasks used.
+var ErrDatasetInUse = errors.New("dataset is in use")
+
+const selectDatasets = `
+SELECT d.id, d.name, d.version, d.checksum, d.location, d.description, d.owner_id,
+    u.username, d.creation_time
+FROM datasets d
+JOIN users u ON u.id = d.owner_id`
+
+// Datasets returns the versions of the dataset with a name, or of all datasets if the name is
+// empty.
+func (db *PgDB) Datasets(name string) ([]model.Dataset, error) {
+	var datasets []model.Dataset
+	if err := db.queryRows(selectDatasets+`
+WHERE $1 = '' OR d.name = $1
+ORDER BY d.name, d.creation_time`, &datasets, name); err != nil {
+		return nil, errors.Wrap(err, "error fetching datasets")
+	}
+	return datasets, nil
+}
+
+// DatasetByID returns a dataset.
+func (db *PgDB) DatasetByID(id int) (*model.Dataset, error) {
+	var dataset model.Dataset
+	if err := db.query(selectDatasets+`
+WHERE d.id = $1`, &dataset, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching dataset %d", id)
+	}
+	return &dataset, nil
+}
+
+// AddDataset persists a new dataset and sets its ID and creation time. It returns
+// ErrDuplicateRecord if the dataset already has the version.
+func (db *PgDB) AddDataset(dataset *model.Dataset) error {
+	nstmt, err := db.sql.PrepareNamed(`
+INSERT INTO datasets (name, version, checksum, location, description, owner_id)
+VALUES (:name, :version, :checksum, :location, :description, :owner_id)
+RETURNING id, creation_time`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing to add dataset")
+	}
+	defer nstmt.Close()
+	if err = nstmt.QueryRowx(dataset).Scan(&dataset.ID, &dataset.CreationTime); err != nil {
+		if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+			return ErrDuplicateRecord
+		}
+		return errors.Wrapf(err, "error adding dataset %s:%s", dataset.Name, dataset.Version)
+	}
+	return nil
+}
+
+// UpdateDataset stores the checksum, location and description of a dataset. The name and version
+// of a dataset do not change, since experiments and tasks refer to datasets by them.
+func (db *PgDB) UpdateDataset(dataset *model.Dataset) error {
+	result, err := db.sql.NamedExec(`
+UPDATE datasets SET checksum = :checksum, location = :location, description = :description
+WHERE id = :id`, dataset)
+	if err != nil {
+		return errors.Wrapf(err, "error updating dataset %d", dataset.ID)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error updating dataset %d", dataset.ID)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteDataset deletes a dataset. It returns ErrDatasetInUse if any experiment or task used the
+// dataset, so that the lineage of what was trained on it is kept.
+func (db *PgDB) DeleteDataset(id int) error {
+	result, err := db.sql.Exec(`DELETE FROM datasets WHERE id = $1`, id)
+	if err != nil {
+		if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok &&
+			pgerr.Code == foreignKeyViolation {
+			return ErrDatasetInUse
+		}
+		return errors.Wrapf(err, "error deleting dataset %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting dataset %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CheckDatasets returns an error that wraps ErrNotFound, and names the dataset, if any of the
+// referenced datasets is not registered.
+func (db *PgDB) CheckDatasets(refs []model.DatasetReference) error {
+	for _, ref := range refs {
+		var exists bool
+		if err := db.sql.QueryRowx(`
+SELECT EXISTS(SELECT 1 FROM datasets WHERE name = $1 AND version = $2)`,
+			ref.Name, ref.Version).Scan(&exists); err != nil {
+			return errors.Wrapf(err, "error checking dataset %s", ref)
+		}
+		if !exists {
+			return errors.Wrapf(ErrNotFound, "dataset %s", ref)
+		}
+	}
+	return nil
+}
+
+// AddExperimentDatasets records that an experiment uses the referenced datasets.
+func (db *PgDB) AddExperimentDatasets(experimentID int, refs []model.DatasetReference) error {
+	return db.addDatasetUses(`
+INSERT INTO experiment_datasets (experiment_id, dataset_id)
+SELECT $1, id FROM datasets WHERE name = $2 AND version = $3
+ON CONFLICT DO NOTHING`, experimentID, refs)
+}
+
+// AddTaskDatasets records that a task uses the referenced datasets. A task that is launched again
+// after the master restarted keeps its datasets.
+func (db *PgDB) AddTaskDatasets(taskID string, refs []model.DatasetReference) error {
+	return db.addDatasetUses(`
+INSERT INTO task_datasets (task_id, dataset_id)
+SELECT $1, id FROM datasets WHERE name = $2 AND version = $3
+ON CONFLICT DO NOTHING`, taskID, refs)
+}
+
+func (db *PgDB) addDatasetUses(
+	insert string, user interface{}, refs []model.DatasetReference,
+) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	return db.withTransaction("add dataset uses", func(tx *sqlx.Tx) error {
+		for _, ref := range refs {
+			if _, err := tx.Exec(insert, user, ref.Name, ref.Version); err != nil {
+				return errors.Wrapf(err, "error recording the use of dataset %s", ref)
+			}
+		}
+		return nil
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -600,3 +602,62 @@ WHERE (hpimportance->>'partial')::boolean=true`, &rows)
 	}
 	return ids, hpis, nil
 }
+
+// ArchivableExperiment is an experiment that can be archived, with who owns it.
+type ArchivableExperiment struct {
+	ID        int    `db:"id"`
+	Username  string `db:"username"`
+	ProjectID int    `db:"project_id"`
+}
+
+// ArchivableExperiments returns the experiments that ended before endedBefore in one of the given
+// terminal states and are not archived, limited to those owned by the users if any are given and
+// to those in the project if it is not 0.
+func (db *PgDB) ArchivableExperiments(
+	endedBefore time.Time, states []model.State, users []string, projectID int,
+) ([]ArchivableExperiment, error) {
+	names := make([]string, 0, len(states))
+	for _, state := range states {
+		names = append(names, string(state))
+	}
+	var exps []ArchivableExperiment
+	if err := db.sql.Select(&exps, `
+SELECT e.id, u.username, e.project_id
+FROM experiments e
+JOIN users u ON e.owner_id = u.id
+WHERE NOT e.archived
+  AND e.state IN (SELECT unnest(string_to_array($1, ','))::experiment_state)
+  AND e.end_time < $2
+  AND ($3 = '' OR u.username IN (SELECT unnest(string_to_array($3, ','))))
+  AND ($4 = 0 OR e.project_id = $4)
+ORDER BY e.id`,
+		strings.Join(names, ","), endedBefore, strings.Join(users, ","), projectID,
+	); err != nil {
+		return nil, errors.Wrap(err, "error querying archivable experiments")
+	}
+	return exps, nil
+}
+
+// ArchiveExperiments archives the experiments with the given IDs and returns how many of them it
+// archived, leaving out those that were already archived.
+func (db *PgDB) ArchiveExperiments(ids []int) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	idStrs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		idStrs = append(idStrs, strconv.Itoa(id))
+	}
+	res, err := db.sql.Exec(`
+UPDATE experiments SET archived = true
+WHERE id IN (SELECT unnest(string_to_array($1, ','))::int) AND NOT archived`,
+		strings.Join(idStrs, ","))
+	if err != nil {
+		return 0, errors.Wrap(err, "error archiving experiments")
+	}
+	archived, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "error archiving experiments")
+	}
+	return int(archived), nil
+}
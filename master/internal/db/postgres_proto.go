@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -13,8 +14,16 @@ import (
 // QueryProto returns the result of the query. Any placeholder parameters are replaced
 // with supplied args. Enum values must be the full name of the enum.
 func (db *PgDB) QueryProto(queryName string, v interface{}, args ...interface{}) error {
+	return db.QueryProtoContext(context.Background(), queryName, v, args...)
+}
+
+// QueryProtoContext is like QueryProto, but runs the query as part of the request of the context.
+func (db *PgDB) QueryProtoContext(
+	ctx context.Context, queryName string, v interface{}, args ...interface{},
+) error {
 	return errors.Wrapf(
-		db.queryRowsWithParser(db.queries.getOrLoad(queryName), protoParser, v, args...),
+		db.queryRowsWithParserContext(
+			ctx, db.queries.getOrLoad(queryName), protoParser, v, args...),
 		"error running query: %v", queryName,
 	)
 }
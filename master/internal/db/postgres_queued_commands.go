@@ -0,0 +1,84 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// QueuedCommand is a command that waited for resources when the master drained, which its manager
+// launches again when the master restarts.
+type QueuedCommand struct {
+	TaskID string `db:"task_id"`
+	// Manager is the name of the actor that manages the command, e.g. "notebooks".
+	Manager string `db:"manager"`
+	// Spec is what the manager needs to launch the command again.
+	Spec       []byte    `db:"spec"`
+	Encrypted  bool      `db:"encrypted"`
+	QueuedTime time.Time `db:"queued_time"`
+}
+
+// AddQueuedCommand persists a command that waits for resources. Its spec is encrypted like the
+// storage credentials of experiments, which it may hold, if security.storage_credentials_key is
+// set.
+func (db *PgDB) AddQueuedCommand(taskID, manager string, spec []byte) error {
+	queued := QueuedCommand{
+		TaskID:     taskID,
+		Manager:    manager,
+		Spec:       spec,
+		QueuedTime: time.Now().UTC(),
+	}
+	if db.storageCredentialsCipher != nil {
+		sealed, err := sealSecret(db.storageCredentialsCipher, string(spec))
+		if err != nil {
+			return err
+		}
+		queued.Spec, queued.Encrypted = sealed, true
+	}
+	if _, err := db.sql.NamedExec(`
+INSERT INTO queued_commands (task_id, manager, spec, encrypted, queued_time)
+VALUES (:task_id, :manager, :spec, :encrypted, :queued_time)
+ON CONFLICT (task_id)
+DO
+UPDATE SET spec=:spec, encrypted=:encrypted, queued_time=:queued_time`, queued); err != nil {
+		return errors.Wrapf(err, "error persisting queued command %s", taskID)
+	}
+	return nil
+}
+
+// QueuedCommands returns the queued commands of a manager, oldest first, with their specs
+// decrypted.
+func (db *PgDB) QueuedCommands(manager string) ([]QueuedCommand, error) {
+	var queued []QueuedCommand
+	if err := db.queryRows(`
+SELECT * FROM queued_commands
+WHERE manager = $1
+ORDER BY queued_time`, &queued, manager); err != nil {
+		return nil, errors.Wrapf(err, "error fetching queued commands of %s", manager)
+	}
+	for i, q := range queued {
+		if !q.Encrypted {
+			continue
+		}
+		if db.storageCredentialsCipher == nil {
+			return nil, errors.Wrapf(ErrStorageCredentialsKeyMissing,
+				"error reading queued command %s", q.TaskID)
+		}
+		spec, err := openSecret(db.storageCredentialsCipher, q.Spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading queued command %s", q.TaskID)
+		}
+		queued[i].Spec, queued[i].Encrypted = []byte(spec), false
+	}
+	return queued, nil
+}
+
+// DeleteQueuedCommand deletes a queued command once it is launched again.
+func (db *PgDB) DeleteQueuedCommand(taskID string) error {
+	if _, err := db.sql.Exec(`
+DELETE FROM queued_commands
+WHERE task_id = $1`, taskID); err != nil {
+		return errors.Wrapf(err, "error deleting queued command %s", taskID)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ErrBuiltinRole is returned when trying to change or delete one of the built-in roles.
+var ErrBuiltinRole = errors.New("built-in roles cannot be changed")
+
+// userRoleGrants returns the roles assigned to a user and to their groups, or the default role
+// for the whole cluster if they have none.
+func (db *PgDB) userRoleGrants(user *model.User) ([]model.RoleGrant, error) {
+	var rows []struct {
+		RoleID      int     `db:"role_id"`
+		WorkspaceID *int    `db:"workspace_id"`
+		Permission  *string `db:"permission"`
+	}
+	if err := db.queryRows(`
+WITH assigned AS (
+  SELECT ra.role_id, ra.workspace_id FROM role_assignments ra
+  WHERE ra.user_id = $1
+  UNION
+  SELECT ra.role_id, ra.workspace_id FROM role_assignments ra
+  JOIN group_members gm ON gm.group_id = ra.group_id
+  WHERE gm.user_id = $1
+), granted AS (
+  SELECT role_id, workspace_id FROM assigned
+  UNION
+  SELECT id AS role_id, NULL AS workspace_id FROM roles
+  WHERE name = $2 AND NOT EXISTS (SELECT 1 FROM assigned)
+)
+SELECT g.role_id, g.workspace_id, rp.permission
+FROM granted g
+LEFT OUTER JOIN role_permissions rp ON rp.role_id = g.role_id
+ORDER BY g.role_id, g.workspace_id`,
+		&rows, user.ID, model.DefaultRoleName); err != nil {
+		return nil, errors.Wrapf(err, "error fetching roles of user %s", user.Username)
+	}
+	var grants []model.RoleGrant
+	for i, row := range rows {
+		if i == 0 || row.RoleID != rows[i-1].RoleID ||
+			!sameWorkspace(row.WorkspaceID, rows[i-1].WorkspaceID) {
+			grants = append(grants, model.RoleGrant{WorkspaceID: row.WorkspaceID})
+		}
+		// Roles without permissions, such as viewer, still make their holders members.
+		if row.Permission != nil {
+			grant := &grants[len(grants)-1]
+			grant.Permissions = append(grant.Permissions, model.Permission(*row.Permission))
+		}
+	}
+	return grants, nil
+}
+
+func sameWorkspace(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// UserPermissions returns the permissions granted to a user by the roles assigned to them and to
+// their groups, either for the whole cluster or, if workspaceID is not zero, within that
+// workspace. Users without any role get the permissions of the default role, and admins hold
+// every permission.
+func (db *PgDB) UserPermissions(
+	user *model.User, workspaceID int,
+) (model.PermissionSet, error) {
+	if user.Admin {
+		return model.NewPermissionSet(model.AllPermissions...), nil
+	}
+	grants, err := db.userRoleGrants(user)
+	if err != nil {
+		return nil, err
+	}
+	return model.GrantedPermissions(grants, workspaceID), nil
+}
+
+// IsWorkspaceMember returns true if a user may see a workspace and its projects: they hold a role
+// within it or for the whole cluster, or they are an admin.
+func (db *PgDB) IsWorkspaceMember(user *model.User, workspaceID int) (bool, error) {
+	if user.Admin {
+		return true, nil
+	}
+	grants, err := db.userRoleGrants(user)
+	if err != nil {
+		return false, err
+	}
+	return model.IsWorkspaceMember(grants, workspaceID), nil
+}
+
+// Roles returns all roles along with their permissions.
+func (db *PgDB) Roles() ([]model.Role, error) {
+	var roles []model.Role
+	if err := db.queryRows(`
+SELECT id, name, builtin FROM roles
+ORDER BY name`, &roles); err != nil {
+		return nil, errors.Wrap(err, "error fetching roles")
+	}
+	var rows []struct {
+		RoleID     int              `db:"role_id"`
+		Permission model.Permission `db:"permission"`
+	}
+	if err := db.queryRows(`
+SELECT role_id, permission FROM role_permissions
+ORDER BY permission`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error fetching role permissions")
+	}
+	byID := make(map[int]*model.Role, len(roles))
+	for i := range roles {
+		roles[i].Permissions = []model.Permission{}
+		byID[roles[i].ID] = &roles[i]
+	}
+	for _, row := range rows {
+		if role, ok := byID[row.RoleID]; ok {
+			role.Permissions = append(role.Permissions, row.Permission)
+		}
+	}
+	return roles, nil
+}
+
+// PutRole creates a role or replaces the permissions of an existing role.
+func (db *PgDB) PutRole(role *model.Role) error {
+	for _, p := range role.Permissions {
+		if !model.ValidPermission(p) {
+			return errors.Errorf("error setting role %s: unknown permission %q", role.Name, p)
+		}
+	}
+	return db.withTransaction("put role", func(tx *sqlx.Tx) error {
+		var builtin bool
+		switch err := tx.QueryRowx(`
+SELECT builtin FROM roles WHERE name = $1`, role.Name).Scan(&builtin); {
+		case err == nil && builtin:
+			return ErrBuiltinRole
+		case err == nil, err == sql.ErrNoRows:
+		default:
+			return errors.Wrapf(err, "error fetching role %s", role.Name)
+		}
+
+		if err := tx.QueryRowx(`
+INSERT INTO roles (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id`, role.Name).Scan(&role.ID); err != nil {
+			return errors.Wrapf(err, "error setting role %s", role.Name)
+		}
+		if _, err := tx.Exec(`
+DELETE FROM role_permissions WHERE role_id = $1`, role.ID); err != nil {
+			return errors.Wrapf(err, "error clearing permissions of role %s", role.Name)
+		}
+		for _, p := range role.Permissions {
+			if _, err := tx.Exec(`
+INSERT INTO role_permissions (role_id, permission) VALUES ($1, $2)
+ON CONFLICT DO NOTHING`, role.ID, p); err != nil {
+				return errors.Wrapf(err, "error adding permission %s to role %s", p, role.Name)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRole deletes a role that is not built in, along with its assignments.
+func (db *PgDB) DeleteRole(name string) error {
+	var builtin bool
+	switch err := db.sql.QueryRow(`
+SELECT builtin FROM roles WHERE name = $1`, name).Scan(&builtin); {
+	case err == sql.ErrNoRows:
+		return ErrNotFound
+	case err != nil:
+		return errors.Wrapf(err, "error deleting role %s", name)
+	case builtin:
+		return ErrBuiltinRole
+	}
+	if _, err := db.sql.Exec(`
+DELETE FROM roles WHERE name = $1`, name); err != nil {
+		return errors.Wrapf(err, "error deleting role %s", name)
+	}
+	return nil
+}
+
+// Groups returns all groups along with the usernames of their members.
+func (db *PgDB) Groups() ([]model.Group, error) {
+	var groups []model.Group
+	if err := db.queryRows(`
+SELECT id, name FROM groups
+ORDER BY name`, &groups); err != nil {
+		return nil, errors.Wrap(err, "error fetching groups")
+	}
+	var rows []struct {
+		GroupID  int    `db:"group_id"`
+		Username string `db:"username"`
+	}
+	if err := db.queryRows(`
+SELECT gm.group_id, u.username FROM group_members gm
+JOIN users u ON u.id = gm.user_id
+ORDER BY u.username`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error fetching group members")
+	}
+	byID := make(map[int]*model.Group, len(groups))
+	for i := range groups {
+		groups[i].Usernames = []string{}
+		byID[groups[i].ID] = &groups[i]
+	}
+	for _, row := range rows {
+		if group, ok := byID[row.GroupID]; ok {
+			group.Usernames = append(group.Usernames, row.Username)
+		}
+	}
+	return groups, nil
+}
+
+// PutGroup creates a group or replaces the members of an existing group. It returns ErrNotFound
+// if any of the members does not exist.
+func (db *PgDB) PutGroup(group *model.Group) error {
+	return db.withTransaction("put group", func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowx(`
+INSERT INTO groups (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id`, group.Name).Scan(&group.ID); err != nil {
+			return errors.Wrapf(err, "error setting group %s", group.Name)
+		}
+		return setGroupMembers(tx, group)
+	})
+}
+
+// UpdateGroup renames the group with the ID of a group and replaces its members, all or nothing.
+// It returns ErrNotFound if the group or any of the members does not exist, and
+// ErrDuplicateRecord if another group has the name.
+func (db *PgDB) UpdateGroup(group *model.Group) error {
+	return db.withTransaction("update group", func(tx *sqlx.Tx) error {
+		result, err := tx.Exec(`
+UPDATE groups SET name = $2 WHERE id = $1`, group.ID, group.Name)
+		if err != nil {
+			if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+				return ErrDuplicateRecord
+			}
+			return errors.Wrapf(err, "error renaming group %d", group.ID)
+		}
+		num, err := result.RowsAffected()
+		if err != nil {
+			return errors.Wrapf(err, "error renaming group %d", group.ID)
+		}
+		if num != 1 {
+			return ErrNotFound
+		}
+		return setGroupMembers(tx, group)
+	})
+}
+
+// setGroupMembers replaces the members of a group.
+func setGroupMembers(tx *sqlx.Tx, group *model.Group) error {
+	if _, err := tx.Exec(`
+DELETE FROM group_members WHERE group_id = $1`, group.ID); err != nil {
+		return errors.Wrapf(err, "error clearing members of group %s", group.Name)
+	}
+	for _, username := range group.Usernames {
+		result, err := tx.Exec(`
+INSERT INTO group_members (group_id, user_id)
+SELECT $1, id FROM users WHERE username = $2
+ON CONFLICT DO NOTHING`, group.ID, username)
+		if err != nil {
+			return errors.Wrapf(err, "error adding %s to group %s", username, group.Name)
+		}
+		if num, err := result.RowsAffected(); err != nil {
+			return errors.Wrapf(err, "error adding %s to group %s", username, group.Name)
+		} else if num == 0 {
+			var exists bool
+			if err := tx.QueryRow(`
+SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists); err != nil {
+				return errors.Wrapf(err, "error adding %s to group %s", username, group.Name)
+			}
+			if !exists {
+				return ErrNotFound
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteGroup deletes a group along with its role assignments.
+func (db *PgDB) DeleteGroup(name string) error {
+	result, err := db.sql.Exec(`
+DELETE FROM groups WHERE name = $1`, name)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting group %s", name)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting group %s", name)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RoleAssignments returns all role assignments.
+func (db *PgDB) RoleAssignments() ([]model.RoleAssignment, error) {
+	var assignments []model.RoleAssignment
+	if err := db.queryRows(`
+SELECT r.name AS role_name, u.username, g.name AS group_name, w.name AS workspace_name
+FROM role_assignments ra
+JOIN roles r ON r.id = ra.role_id
+LEFT JOIN users u ON u.id = ra.user_id
+LEFT JOIN groups g ON g.id = ra.group_id
+LEFT JOIN workspaces w ON w.id = ra.workspace_id
+ORDER BY r.name, u.username, g.name, w.name`, &assignments); err != nil {
+		return nil, errors.Wrap(err, "error fetching role assignments")
+	}
+	return assignments, nil
+}
+
+// AddRoleAssignment assigns a role to a user or a group. It returns ErrNotFound if the role, user,
+// group or workspace does not exist, and ErrDuplicateRecord if the role is already assigned.
+func (db *PgDB) AddRoleAssignment(assignment model.RoleAssignment) error {
+	result, err := db.sql.Exec(`
+INSERT INTO role_assignments (role_id, user_id, group_id, workspace_id)
+SELECT r.id, u.id, g.id, w.id
+FROM roles r
+LEFT JOIN users u ON u.username = $2::text
+LEFT JOIN groups g ON g.name = $3::text
+LEFT JOIN workspaces w ON w.name = $4::text
+WHERE r.name = $1
+  AND (u.id IS NOT NULL OR $2::text IS NULL)
+  AND (g.id IS NOT NULL OR $3::text IS NULL)
+  AND (w.id IS NOT NULL OR $4::text IS NULL)`,
+		assignment.RoleName, assignment.Username, assignment.GroupName, assignment.WorkspaceName)
+	if err != nil {
+		if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+			return ErrDuplicateRecord
+		}
+		return errors.Wrapf(err, "error assigning role %s", assignment.RoleName)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error assigning role %s", assignment.RoleName)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveRoleAssignment takes a role away from a user or a group.
+func (db *PgDB) RemoveRoleAssignment(assignment model.RoleAssignment) error {
+	result, err := db.sql.Exec(`
+DELETE FROM role_assignments ra
+USING roles r
+WHERE r.id = ra.role_id AND r.name = $1
+  AND ra.user_id IS NOT DISTINCT FROM (SELECT id FROM users WHERE username = $2::text)
+  AND ra.group_id IS NOT DISTINCT FROM (SELECT id FROM groups WHERE name = $3::text)
+  AND ra.workspace_id IS NOT DISTINCT FROM (SELECT id FROM workspaces WHERE name = $4::text)`,
+		assignment.RoleName, assignment.Username, assignment.GroupName, assignment.WorkspaceName)
+	if err != nil {
+		return errors.Wrapf(err, "error removing role %s", assignment.RoleName)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error removing role %s", assignment.RoleName)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetSSORoleAssignments replaces the roles that single sign-on assigned to a user. Unknown roles
+// are ignored, as are roles that are already assigned to the user directly.
+func (db *PgDB) SetSSORoleAssignments(userID model.UserID, roleNames []string) error {
+	return db.withTransaction("set sso role assignments", func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(`
+DELETE FROM role_assignments WHERE user_id = $1 AND sso`, userID); err != nil {
+			return errors.Wrapf(err, "error clearing role assignments of user %d", userID)
+		}
+		for _, name := range roleNames {
+			if _, err := tx.Exec(`
+INSERT INTO role_assignments (role_id, user_id, sso)
+SELECT id, $2, true FROM roles WHERE name = $1
+ON CONFLICT DO NOTHING`, name, userID); err != nil {
+				return errors.Wrapf(err, "error assigning role %s to user %d", name, userID)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ErrRegistryCredentialsDisabled is returned when registry credentials are stored or read without
+// a key to encrypt them with.
+var ErrRegistryCredentialsDisabled = errors.New(
+	"registry credentials require security.registry_credentials_key to be set")
+
+// SetRegistryCredentialsKey sets the AES key that registry credential passwords are encrypted with.
+// The key must be 16, 24 or 32 bytes long.
+func (db *PgDB) SetRegistryCredentialsKey(key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return errors.Wrap(err, "invalid registry credentials key")
+	}
+	db.registryCredentialsCipher = aead
+	return nil
+}
+
+// RegistryCredentials returns the registry credentials of a user, with their passwords decrypted.
+func (db *PgDB) RegistryCredentials(userID model.UserID) ([]model.RegistryCredential, error) {
+	var creds []model.RegistryCredential
+	if err := db.queryRows(`
+SELECT * FROM registry_credentials
+WHERE user_id = $1
+ORDER BY server_address`, &creds, userID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching registry credentials of user %d", userID)
+	}
+	for i := range creds {
+		if db.registryCredentialsCipher == nil {
+			return nil, ErrRegistryCredentialsDisabled
+		}
+		password, err := openSecret(db.registryCredentialsCipher, creds[i].EncryptedPassword)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading registry credentials for %s",
+				creds[i].ServerAddress)
+		}
+		creds[i].Password = password
+	}
+	return creds, nil
+}
+
+// UpsertRegistryCredential creates or replaces a user's credentials for a registry.
+func (db *PgDB) UpsertRegistryCredential(cred *model.RegistryCredential) error {
+	if len(cred.ServerAddress) == 0 {
+		return errors.New("error setting registry credentials: empty server address")
+	}
+	if db.registryCredentialsCipher == nil {
+		return ErrRegistryCredentialsDisabled
+	}
+	encrypted, err := sealSecret(db.registryCredentialsCipher, cred.Password)
+	if err != nil {
+		return err
+	}
+	cred.EncryptedPassword = encrypted
+	err = db.namedGet(&cred.ID, `
+INSERT INTO registry_credentials (user_id, server_address, username, encrypted_password)
+VALUES (:user_id, :server_address, :username, :encrypted_password)
+ON CONFLICT (user_id, server_address)
+DO
+UPDATE SET username=:username, encrypted_password=:encrypted_password, updated_at=now()
+RETURNING id`, cred)
+	if err != nil {
+		return errors.Wrapf(err, "error setting registry credentials for %s", cred.ServerAddress)
+	}
+	return nil
+}
+
+// DeleteRegistryCredential deletes a user's credentials for a registry.
+func (db *PgDB) DeleteRegistryCredential(userID model.UserID, serverAddress string) error {
+	result, err := db.sql.Exec(`
+DELETE FROM registry_credentials
+WHERE user_id=$1 AND server_address=$2`, userID, serverAddress)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting registry credentials for %s", serverAddress)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting registry credentials for %s", serverAddress)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ResourcePools returns the resource pools that were added through the API, by name.
+func (db *PgDB) ResourcePools() ([]model.ResourcePool, error) {
+	var pools []model.ResourcePool
+	if err := db.queryRows(`
+SELECT * FROM resource_pools ORDER BY name`, &pools); err != nil {
+		return nil, errors.Wrap(err, "error fetching resource pools")
+	}
+	return pools, nil
+}
+
+// AddResourcePool persists a new resource pool. It returns ErrDuplicateRecord if a pool with the
+// same name was already added.
+func (db *PgDB) AddResourcePool(pool model.ResourcePool) error {
+	if _, err := db.sql.NamedExec(`
+INSERT INTO resource_pools (name, config)
+VALUES (:name, :config)`, pool); err != nil {
+		if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+			return ErrDuplicateRecord
+		}
+		return errors.Wrapf(err, "error adding resource pool %s", pool.Name)
+	}
+	return nil
+}
+
+// UpdateResourcePool replaces the config of a resource pool.
+func (db *PgDB) UpdateResourcePool(pool model.ResourcePool) error {
+	result, err := db.sql.NamedExec(`
+UPDATE resource_pools SET config = :config
+WHERE name = :name`, pool)
+	if err != nil {
+		return errors.Wrapf(err, "error updating resource pool %s", pool.Name)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error updating resource pool %s", pool.Name)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteResourcePool deletes a resource pool that was added through the API.
+func (db *PgDB) DeleteResourcePool(name string) error {
+	result, err := db.sql.Exec(`DELETE FROM resource_pools WHERE name = $1`, name)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting resource pool %s", name)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting resource pool %s", name)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
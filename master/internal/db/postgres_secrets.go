@@ -0,0 +1,100 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ErrSecretsDisabled is returned when secrets are stored or read without a key to encrypt them
+// with.
+var ErrSecretsDisabled = errors.New("secrets require security.secrets_key to be set")
+
+// SetSecretsKey sets the AES key that secret values are encrypted with. The key must be 16, 24 or
+// 32 bytes long.
+func (db *PgDB) SetSecretsKey(key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return errors.Wrap(err, "invalid secrets key")
+	}
+	db.secretsCipher = aead
+	return nil
+}
+
+// SecretNames returns the secrets of a user without their values.
+func (db *PgDB) SecretNames(userID model.UserID) ([]model.Secret, error) {
+	var secrets []model.Secret
+	if err := db.queryRows(`
+SELECT id, user_id, name, updated_at FROM secrets
+WHERE user_id = $1
+ORDER BY name`, &secrets, userID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching secrets of user %d", userID)
+	}
+	return secrets, nil
+}
+
+// SecretValues returns the values of a user's secrets by name.
+func (db *PgDB) SecretValues(userID model.UserID) (map[string]string, error) {
+	var secrets []model.Secret
+	if err := db.queryRows(`
+SELECT * FROM secrets
+WHERE user_id = $1`, &secrets, userID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching secrets of user %d", userID)
+	}
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		if db.secretsCipher == nil {
+			return nil, ErrSecretsDisabled
+		}
+		value, err := openSecret(db.secretsCipher, secret.EncryptedValue)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading secret %s", secret.Name)
+		}
+		values[secret.Name] = value
+	}
+	return values, nil
+}
+
+// UpsertSecret creates or replaces a user's secret.
+func (db *PgDB) UpsertSecret(secret *model.Secret) error {
+	if !model.SecretNamePattern.MatchString(secret.Name) {
+		return errors.Errorf("error setting secret: invalid name %q", secret.Name)
+	}
+	if db.secretsCipher == nil {
+		return ErrSecretsDisabled
+	}
+	encrypted, err := sealSecret(db.secretsCipher, secret.Value)
+	if err != nil {
+		return err
+	}
+	secret.EncryptedValue = encrypted
+	err = db.namedGet(&secret.ID, `
+INSERT INTO secrets (user_id, name, encrypted_value)
+VALUES (:user_id, :name, :encrypted_value)
+ON CONFLICT (user_id, name)
+DO
+UPDATE SET encrypted_value=:encrypted_value, updated_at=now()
+RETURNING id`, secret)
+	if err != nil {
+		return errors.Wrapf(err, "error setting secret %s", secret.Name)
+	}
+	return nil
+}
+
+// DeleteSecret deletes a user's secret.
+func (db *PgDB) DeleteSecret(userID model.UserID, name string) error {
+	result, err := db.sql.Exec(`
+DELETE FROM secrets
+WHERE user_id=$1 AND name=$2`, userID, name)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting secret %s", name)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting secret %s", name)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
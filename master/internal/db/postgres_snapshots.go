@@ -113,7 +113,7 @@ DELETE FROM experiment_snapshots
 WHERE experiment_id IN (
 	SELECT id
 	FROM experiments
-	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR'))`); err != nil {
+	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR', 'TIMEOUT'))`); err != nil {
 			return errors.Wrap(err, "failed to delete experiment snapshots")
 		}
 		if _, err := tx.Exec(`
@@ -121,7 +121,7 @@ DELETE FROM trial_snapshots
 WHERE experiment_id IN (
 	SELECT id
 	FROM experiments
-	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR'))`); err != nil {
+	WHERE state IN ('COMPLETED', 'CANCELED', 'ERROR', 'TIMEOUT'))`); err != nil {
 			return errors.Wrap(err, "failed to delete trial snapshots")
 		}
 		return nil
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// SSHKeys returns the public keys that a user registered, oldest first.
+func (db *PgDB) SSHKeys(userID model.UserID) ([]model.SSHKey, error) {
+	var keys []model.SSHKey
+	if err := db.queryRows(`
+SELECT * FROM user_ssh_keys
+WHERE user_id = $1
+ORDER BY id`, &keys, userID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching SSH keys of user %d", userID)
+	}
+	return keys, nil
+}
+
+// AddSSHKey registers a public key of a user and sets its ID and creation time. It returns
+// ErrDuplicateRecord if the user already registered the key.
+func (db *PgDB) AddSSHKey(key *model.SSHKey) error {
+	nstmt, err := db.sql.PrepareNamed(`
+INSERT INTO user_ssh_keys (user_id, name, public_key, fingerprint)
+VALUES (:user_id, :name, :public_key, :fingerprint)
+RETURNING id, created_at`)
+	if err != nil {
+		return errors.Wrap(err, "error preparing to add SSH key")
+	}
+	defer nstmt.Close()
+	if err = nstmt.QueryRowx(key).Scan(&key.ID, &key.CreatedAt); err != nil {
+		if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+			return ErrDuplicateRecord
+		}
+		return errors.Wrapf(err, "error adding SSH key %s", key.Fingerprint)
+	}
+	return nil
+}
+
+// DeleteSSHKey deletes a public key that a user registered.
+func (db *PgDB) DeleteSSHKey(userID model.UserID, id int) error {
+	result, err := db.sql.Exec(`
+DELETE FROM user_ssh_keys
+WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting SSH key %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting SSH key %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
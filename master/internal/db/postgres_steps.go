@@ -0,0 +1,112 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DownsampleTrialMetrics thins out the training metrics of up to limit trials that were not
+// downsampled yet, of experiments that ended more than afterDays days ago. Each trial keeps about
+// maxStepsPerTrial evenly spaced steps, along with its last step and those at which it validated
+// or checkpointed, which the queries of validations and checkpoints join against. It returns the
+// number of trials downsampled and of steps deleted.
+func (db *PgDB) DownsampleTrialMetrics(afterDays, maxStepsPerTrial, limit int) (int, int, error) {
+	var downsampled struct {
+		Trials int `db:"trials"`
+		Steps  int `db:"steps"`
+	}
+	if err := db.sql.QueryRowx(`
+WITH eligible AS (
+  SELECT t.id
+  FROM trials t
+  JOIN experiments e ON t.experiment_id = e.id
+  WHERE NOT t.metrics_downsampled
+    AND e.state IN ('COMPLETED', 'CANCELED', 'ERROR', 'TIMEOUT')
+    AND e.end_time < now() - make_interval(days => $1)
+  ORDER BY t.id
+  LIMIT $3
+), ranked AS (
+  SELECT s.trial_id, s.id,
+    row_number() OVER (PARTITION BY s.trial_id ORDER BY s.total_batches) AS n,
+    count(*) OVER (PARTITION BY s.trial_id) AS total
+  FROM raw_steps s
+  WHERE s.trial_id IN (SELECT id FROM eligible) AND NOT s.archived
+), deleted AS (
+  DELETE FROM raw_steps s
+  USING ranked r
+  WHERE s.trial_id = r.trial_id AND s.id = r.id
+    AND r.total > $2
+    AND r.n < r.total
+    AND (r.n - 1) % ceil(r.total::float8 / $2)::int != 0
+    AND NOT EXISTS (
+      SELECT 1 FROM raw_validations v
+      WHERE v.trial_id = s.trial_id AND v.total_batches = s.total_batches)
+    AND NOT EXISTS (
+      SELECT 1 FROM raw_checkpoints c
+      WHERE c.trial_id = s.trial_id AND c.total_batches = s.total_batches)
+  RETURNING s.trial_id
+), marked AS (
+  UPDATE trials SET metrics_downsampled = true
+  WHERE id IN (SELECT id FROM eligible)
+  RETURNING id
+)
+SELECT (SELECT count(*) FROM marked) AS trials, (SELECT count(*) FROM deleted) AS steps`,
+		afterDays, maxStepsPerTrial, limit).StructScan(&downsampled); err != nil {
+		return 0, 0, errors.Wrap(err, "error downsampling trial metrics")
+	}
+	return downsampled.Trials, downsampled.Steps, nil
+}
+
+// ReportedMetric is a value of a metric that a trial reported.
+type ReportedMetric struct {
+	// Kind is either "training" or "validation".
+	Kind         string    `db:"kind"`
+	ExperimentID int       `db:"experiment_id"`
+	TrialID      int       `db:"trial_id"`
+	Name         string    `db:"name"`
+	Value        float64   `db:"value"`
+	TotalBatches int       `db:"total_batches"`
+	EndTime      time.Time `db:"end_time"`
+}
+
+// TrialMetricsReportedBetween returns the numeric values of the named training and validation
+// metrics that trials reported after start and up to end, ordered by the time they were reported.
+func (db *PgDB) TrialMetricsReportedBetween(
+	training, validation []string, start, end time.Time,
+) ([]ReportedMetric, error) {
+	trainingNames, err := json.Marshal(training)
+	if err != nil {
+		return nil, err
+	}
+	validationNames, err := json.Marshal(validation)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []ReportedMetric
+	if err = db.sql.Select(&metrics, `
+WITH recent_trials AS (
+  SELECT id, experiment_id FROM trials
+  WHERE end_time IS NULL OR end_time > $3
+)
+SELECT 'training' AS kind, t.experiment_id, s.trial_id, k.key AS name,
+  (k.value #>> '{}')::float8 AS value, s.total_batches, s.end_time
+FROM recent_trials t
+JOIN steps s ON s.trial_id = t.id, jsonb_each(s.metrics->'avg_metrics') k
+WHERE s.state = 'COMPLETED' AND s.end_time > $3 AND s.end_time <= $4
+  AND k.key IN (SELECT jsonb_array_elements_text($1::jsonb))
+  AND jsonb_typeof(k.value) = 'number'
+UNION ALL
+SELECT 'validation' AS kind, t.experiment_id, v.trial_id, k.key AS name,
+  (k.value #>> '{}')::float8 AS value, v.total_batches, v.end_time
+FROM recent_trials t
+JOIN validations v ON v.trial_id = t.id, jsonb_each(v.metrics->'validation_metrics') k
+WHERE v.state = 'COMPLETED' AND v.end_time > $3 AND v.end_time <= $4
+  AND k.key IN (SELECT jsonb_array_elements_text($2::jsonb))
+  AND jsonb_typeof(k.value) = 'number'
+ORDER BY end_time`, string(trainingNames), string(validationNames), start, end); err != nil {
+		return nil, errors.Wrap(err, "error querying reported trial metrics")
+	}
+	return metrics, nil
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// ErrStorageCredentialsKeyMissing is returned when encrypted storage credentials are read without
+// the key they were encrypted with.
+var ErrStorageCredentialsKeyMissing = errors.New(
+	"storage credentials are encrypted, but security.storage_credentials_key is not set")
+
+// SetStorageCredentialsKey sets the AES key that the storage credentials of experiments and
+// templates are encrypted with. The key must be 16, 24 or 32 bytes long.
+func (db *PgDB) SetStorageCredentialsKey(key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return errors.Wrap(err, "invalid storage credentials key")
+	}
+	db.storageCredentialsCipher = aead
+	return nil
+}
+
+// storedConfig is a config as it is stored in the database, with its storage credentials apart.
+type storedConfig struct {
+	Config      []byte
+	Credentials []byte
+	Encrypted   bool
+}
+
+// stripConfig removes the storage credentials from a config and seals them, encrypting them if a
+// key is set. Credentials in inherited are kept for the paths that the config does not set.
+func (db *PgDB) stripConfig(
+	config []byte, inherited model.StorageCredentials,
+) (storedConfig, error) {
+	stripped, creds, err := model.StripStorageCredentials(config)
+	if err != nil {
+		return storedConfig{}, err
+	}
+	for path, value := range inherited {
+		if _, ok := creds[path]; !ok {
+			creds[path] = value
+		}
+	}
+	stored := storedConfig{Config: stripped}
+	if len(creds) == 0 {
+		return stored, nil
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return storedConfig{}, errors.Wrap(err, "error encoding storage credentials")
+	}
+	if db.storageCredentialsCipher == nil {
+		stored.Credentials = data
+		return stored, nil
+	}
+	if stored.Credentials, err = sealSecret(db.storageCredentialsCipher, string(data)); err != nil {
+		return storedConfig{}, err
+	}
+	stored.Encrypted = true
+	return stored, nil
+}
+
+// stripExperimentConfig validates the config of an experiment and removes its storage
+// credentials.
+func (db *PgDB) stripExperimentConfig(
+	config expconf.ExperimentConfig, inherited model.StorageCredentials,
+) (storedConfig, error) {
+	value, err := config.Value()
+	if err != nil {
+		return storedConfig{}, err
+	}
+	return db.stripConfig(value.([]byte), inherited)
+}
+
+// openStorageCredentials returns the storage credentials sealed by stripConfig.
+func (db *PgDB) openStorageCredentials(
+	data []byte, encrypted bool,
+) (model.StorageCredentials, error) {
+	creds := model.StorageCredentials{}
+	if len(data) == 0 {
+		return creds, nil
+	}
+	if encrypted {
+		if db.storageCredentialsCipher == nil {
+			return nil, ErrStorageCredentialsKeyMissing
+		}
+		plaintext, err := openSecret(db.storageCredentialsCipher, data)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading storage credentials")
+		}
+		data = []byte(plaintext)
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, errors.Wrap(err, "error decoding storage credentials")
+	}
+	return creds, nil
+}
+
+// restoreConfig puts the sealed storage credentials back into a config.
+func (db *PgDB) restoreConfig(config, data []byte, encrypted bool) ([]byte, error) {
+	if len(data) == 0 {
+		return config, nil
+	}
+	creds, err := db.openStorageCredentials(data, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return model.RestoreStorageCredentials(config, creds)
+}
+
+// restoreExperimentConfig puts the storage credentials of an experiment back into its config.
+func (db *PgDB) restoreExperimentConfig(experiment *model.Experiment) error {
+	if len(experiment.StorageCredentials) == 0 {
+		return nil
+	}
+	config, err := json.Marshal(experiment.Config)
+	if err != nil {
+		return errors.Wrapf(err, "error encoding config of experiment %d", experiment.ID)
+	}
+	config, err = db.restoreConfig(
+		config, experiment.StorageCredentials, experiment.StorageCredentialsEncrypted)
+	if err != nil {
+		return errors.Wrapf(err, "error restoring storage credentials of experiment %d",
+			experiment.ID)
+	}
+	parsed, err := expconf.ParseAnyExperimentConfigJSON(config)
+	if err != nil {
+		return errors.Wrapf(err, "error parsing config of experiment %d", experiment.ID)
+	}
+	experiment.Config = schemas.WithDefaults(parsed).(expconf.ExperimentConfig)
+	return nil
+}
+
+// experimentStorageCredentials returns the storage credentials of an experiment.
+func (db *PgDB) experimentStorageCredentials(id int) (model.StorageCredentials, error) {
+	var row struct {
+		StorageCredentials          []byte `db:"storage_credentials"`
+		StorageCredentialsEncrypted bool   `db:"storage_credentials_encrypted"`
+	}
+	if err := db.query(`
+SELECT storage_credentials, storage_credentials_encrypted
+FROM experiments
+WHERE id = $1`, &row, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching storage credentials of experiment %d", id)
+	}
+	return db.openStorageCredentials(row.StorageCredentials, row.StorageCredentialsEncrypted)
+}
+
+// storageCredentialsMigrationFilter selects the rows whose configs still hold storage credentials
+// and, if a key is set, the rows whose storage credentials are not encrypted yet.
+func (db *PgDB) storageCredentialsMigrationFilter() string {
+	var conditions []string
+	for _, path := range model.StorageCredentialPaths {
+		jsonPath := fmt.Sprintf("'{%s}'", strings.Replace(path, ".", ",", 1))
+		conditions = append(conditions, fmt.Sprintf(
+			"(jsonb_typeof(config #> %[1]s) = 'string' AND config #>> %[1]s != '%[2]s')",
+			jsonPath, model.RedactedCredential))
+	}
+	if db.storageCredentialsCipher != nil {
+		conditions = append(conditions,
+			"(storage_credentials IS NOT NULL AND NOT storage_credentials_encrypted)")
+	}
+	return strings.Join(conditions, " OR ")
+}
+
+// MigrateStorageCredentials moves the storage credentials that configs of experiments and
+// templates still hold into their own columns, and encrypts the stored credentials that are not
+// encrypted once a key is set.
+func (db *PgDB) MigrateStorageCredentials() error {
+	filter := db.storageCredentialsMigrationFilter()
+
+	var experiments []struct {
+		ID                          int     `db:"id"`
+		Config                      []byte  `db:"config"`
+		OriginalConfig              *string `db:"original_config"`
+		StorageCredentials          []byte  `db:"storage_credentials"`
+		StorageCredentialsEncrypted bool    `db:"storage_credentials_encrypted"`
+	}
+	if err := db.queryRows(`
+SELECT id, config, original_config, storage_credentials, storage_credentials_encrypted
+FROM experiments
+WHERE `+filter, &experiments); err != nil {
+		return errors.Wrap(err, "error fetching experiments with storage credentials")
+	}
+	for _, e := range experiments {
+		creds, err := db.openStorageCredentials(e.StorageCredentials, e.StorageCredentialsEncrypted)
+		if err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of experiment %d", e.ID)
+		}
+		stored, err := db.stripConfig(e.Config, creds)
+		if err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of experiment %d", e.ID)
+		}
+		original := e.OriginalConfig
+		if original != nil {
+			redacted, err := model.RedactStorageCredentialsYAML(*original)
+			if err != nil {
+				return errors.Wrapf(err, "error migrating storage credentials of experiment %d", e.ID)
+			}
+			original = &redacted
+		}
+		if _, err := db.sql.Exec(`
+UPDATE experiments
+SET config = $2, original_config = $3,
+    storage_credentials = $4, storage_credentials_encrypted = $5
+WHERE id = $1`, e.ID, stored.Config, original, stored.Credentials, stored.Encrypted); err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of experiment %d", e.ID)
+		}
+	}
+
+	var templates []model.Template
+	if err := db.queryRows(`
+SELECT name, config, storage_credentials, storage_credentials_encrypted
+FROM templates
+WHERE `+filter, &templates); err != nil {
+		return errors.Wrap(err, "error fetching templates with storage credentials")
+	}
+	for _, t := range templates {
+		creds, err := db.openStorageCredentials(t.StorageCredentials, t.StorageCredentialsEncrypted)
+		if err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of template %q", t.Name)
+		}
+		stored, err := db.stripConfig(t.Config, creds)
+		if err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of template %q", t.Name)
+		}
+		if _, err := db.sql.Exec(`
+UPDATE templates
+SET config = $2, storage_credentials = $3, storage_credentials_encrypted = $4
+WHERE name = $1`, t.Name, stored.Config, stored.Credentials, stored.Encrypted); err != nil {
+			return errors.Wrapf(err, "error migrating storage credentials of template %q", t.Name)
+		}
+	}
+
+	if len(experiments) > 0 || len(templates) > 0 {
+		log.Infof("secured the storage credentials of %d experiments and %d templates",
+			len(experiments), len(templates))
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// AddTaskProfilerSample persists a sample of the utilization of a container of a task.
+func (db *PgDB) AddTaskProfilerSample(sample *model.TaskProfilerSample) error {
+	if _, err := db.sql.NamedExec(`
+INSERT INTO task_profiler_samples (task_id, container_id, agent_id, ts, cpu_utilization_percent,
+    memory_used_bytes, network_rx_bytes_per_second, network_tx_bytes_per_second, gpus)
+VALUES (:task_id, :container_id, :agent_id, :ts, :cpu_utilization_percent,
+    :memory_used_bytes, :network_rx_bytes_per_second, :network_tx_bytes_per_second, :gpus)`,
+		sample); err != nil {
+		return errors.Wrapf(err, "error adding profiler sample of task %s", sample.TaskID)
+	}
+	return nil
+}
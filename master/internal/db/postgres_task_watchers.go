@@ -0,0 +1,56 @@
+package db
+
+import (
+	"sync"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// taskWatcherBuffer is the number of state changes that a watcher of tasks may fall behind by
+// before it is dropped.
+const taskWatcherBuffer = 256
+
+// taskWatchers fans the persisted state changes of tasks out to their watchers. Its zero value has
+// no watchers.
+type taskWatchers struct {
+	mu       sync.Mutex
+	nextID   int
+	watchers map[int]chan model.Task
+}
+
+// WatchTasks returns a channel that receives every task whose state is persisted from now on and a
+// function that stops watching. The channel is closed if the watcher falls too far behind, so that
+// persisting the state of a task never waits for a watcher.
+func (db *PgDB) WatchTasks() (<-chan model.Task, func()) {
+	w := &db.taskWatchers
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watchers == nil {
+		w.watchers = make(map[int]chan model.Task)
+	}
+	id := w.nextID
+	w.nextID++
+	changes := make(chan model.Task, taskWatcherBuffer)
+	w.watchers[id] = changes
+	return changes, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if changes, ok := w.watchers[id]; ok {
+			delete(w.watchers, id)
+			close(changes)
+		}
+	}
+}
+
+func (w *taskWatchers) publish(t model.Task) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for id, changes := range w.watchers {
+		select {
+		case changes <- t:
+		default:
+			delete(w.watchers, id)
+			close(changes)
+		}
+	}
+}
@@ -1,18 +1,126 @@
 package db
 
 import (
+	"database/sql"
+
 	"github.com/o1egl/paseto"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/model"
 )
 
-// initTaskSessions creates a row in the task_sessions table.
+// ErrTaskTokenAddress is returned when a task token is used from an address that the containers
+// of its task do not have.
+var ErrTaskTokenAddress = errors.New("task token used from outside the containers of its task")
+
+// ErrTaskTokenCertificate is returned when a task token is used without the client certificate
+// that was issued to its task.
+var ErrTaskTokenCertificate = errors.New("task token used without the certificate of its task")
+
+// initTaskSessions creates a row in the task_sessions table. The sessions of tasks with persisted
+// allocations are kept, since the tasks reattach to their containers.
 func (db *PgDB) initTaskSessions() error {
-	_, err := db.sql.Exec("DELETE FROM task_sessions")
+	_, err := db.sql.Exec(`
+DELETE FROM task_sessions
+WHERE task_id NOT IN (SELECT task_id FROM allocations)`)
+	return err
+}
+
+// initTasks terminates the tasks that had not terminated when the master stopped, except those
+// with persisted allocations, since they reattach to their containers.
+func (db *PgDB) initTasks() error {
+	_, err := db.sql.Exec(`
+UPDATE tasks
+SET state = 'TERMINATED', end_time = now() at time zone 'utc'
+WHERE state != 'TERMINATED' AND task_id NOT IN (SELECT task_id FROM allocations)`)
 	return err
 }
 
+const insertTask = `
+INSERT INTO tasks (task_id, task_type, state, description, owner_id, project_id, trial_id,
+    resource_pool, slots, start_time, assigned_time, end_time)
+VALUES (:task_id, :task_type, :state, :description, :owner_id, :project_id, :trial_id,
+    :resource_pool, :slots, :start_time, :assigned_time, :end_time)
+ON CONFLICT (task_id) DO UPDATE SET state = EXCLUDED.state, end_time = EXCLUDED.end_time`
+
+// AddTask persists a task. A task that is launched again with the same ID after the master
+// restarted continues its row in the state that it is added in.
+func (db *PgDB) AddTask(t *model.Task) error {
+	if _, err := db.sql.NamedExec(insertTask, t); err != nil {
+		return errors.Wrapf(err, "error persisting task %s", t.TaskID)
+	}
+	db.taskWatchers.publish(*t)
+	return nil
+}
+
+// TaskOwner returns the user that launched a task, or nil if the master launched it.
+func (db *PgDB) TaskOwner(taskID string) (*model.UserID, error) {
+	var task struct {
+		OwnerID *model.UserID `db:"owner_id"`
+	}
+	if err := db.query(`SELECT owner_id FROM tasks WHERE task_id = $1`, &task, taskID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the owner of task %s", taskID)
+	}
+	return task.OwnerID, nil
+}
+
+// selectTasks selects the columns of model.Task from the tasks table.
+const selectTasks = `
+SELECT task_id, task_type, state, description, owner_id, project_id, trial_id, resource_pool,
+    slots, start_time, assigned_time, end_time
+FROM tasks
+`
+
+// TaskByID returns a task.
+func (db *PgDB) TaskByID(taskID string) (*model.Task, error) {
+	var task model.Task
+	if err := db.query(selectTasks+`WHERE task_id = $1`, &task, taskID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching task %s", taskID)
+	}
+	return &task, nil
+}
+
+// TrialTasks returns the runs of the given trials, in the order that they started.
+func (db *PgDB) TrialTasks(trialIDs []int) ([]model.Task, error) {
+	var tasks []model.Task
+	if err := db.queryRows(selectTasks+`
+WHERE trial_id IN (SELECT unnest($1::int [])::int)
+ORDER BY start_time`, &tasks, trialIDs); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the tasks of trials %v", trialIDs)
+	}
+	return tasks, nil
+}
+
+// TransitionTask moves a task to a new state and persists it, with the resources that it was
+// allocated, if its state changed.
+func (db *PgDB) TransitionTask(t *model.Task, state model.TaskState) error {
+	changed, err := t.Transition(state)
+	if err != nil || !changed {
+		return err
+	}
+	if _, err := db.sql.NamedExec(`
+UPDATE tasks
+SET state = :state, resource_pool = :resource_pool, slots = :slots,
+    assigned_time = COALESCE(assigned_time, :assigned_time), end_time = :end_time
+WHERE task_id = :task_id`, t); err != nil {
+		return errors.Wrapf(err, "error updating task %s", t.TaskID)
+	}
+	db.taskWatchers.publish(*t)
+	return nil
+}
+
+// SetTaskSessionBinding sets whether task tokens are only valid from the addresses of the
+// containers of their tasks.
+func (db *PgDB) SetTaskSessionBinding(enabled bool) {
+	db.bindTaskSessions = enabled
+}
+
+// SetTaskSessionCertificates sets whether task tokens are only valid from clients that present the
+// certificates issued to their tasks.
+func (db *PgDB) SetTaskSessionCertificates(required bool) {
+	db.certifyTaskSessions = required
+}
+
 // StartTaskSession creates a row in the task_sessions table.
 func (db *PgDB) StartTaskSession(taskID string) (string, error) {
 	taskSession := &model.TaskSession{
@@ -23,36 +131,100 @@ func (db *PgDB) StartTaskSession(taskID string) (string, error) {
 	if err := db.namedGet(&taskSession.ID, query, *taskSession); err != nil {
 		return "", err
 	}
+	return db.signTaskSession(taskSession)
+}
+
+// RotateTaskSession issues a new token for a task session and revokes its earlier tokens. It
+// returns ErrNotFound if the session was deleted or its token was already rotated.
+func (db *PgDB) RotateTaskSession(session *model.TaskSession) (string, error) {
+	rotated := *session
+	err := db.sql.QueryRow(`
+UPDATE task_sessions SET generation = generation + 1
+WHERE id = $1 AND generation = $2
+RETURNING generation`, session.ID, session.Generation).Scan(&rotated.Generation)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", errors.Wrapf(err, "error rotating the token of task %s", session.TaskID)
+	}
+	return db.signTaskSession(&rotated)
+}
 
+func (db *PgDB) signTaskSession(session *model.TaskSession) (string, error) {
 	v2 := paseto.NewV2()
-	token, err := v2.Sign(db.tokenKeys.PrivateKey, taskSession, nil)
+	token, err := v2.Sign(db.tokenKeys.PrivateKey, session, nil)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to generate task authentication token")
 	}
 	return token, nil
 }
 
-// TaskSessionByToken returns a task session given an authentication token.
-func (db *PgDB) TaskSessionByToken(token string) (*model.TaskSession, error) {
+// BindTaskSession adds to the addresses that the token of a task is valid from. Tokens are valid
+// from any address until the first address is bound.
+func (db *PgDB) BindTaskSession(taskID string, addresses []string) error {
+	if len(addresses) == 0 || !db.bindTaskSessions {
+		return nil
+	}
+	for _, address := range addresses {
+		if _, err := db.sql.Exec(`
+INSERT INTO task_session_addresses (session_id, address)
+SELECT id, $2 FROM task_sessions WHERE task_id = $1
+ON CONFLICT DO NOTHING`, taskID, address); err != nil {
+			return errors.Wrapf(err, "error binding the token of task %s to %s", taskID, address)
+		}
+	}
+	return nil
+}
+
+// TaskSessionByToken returns a task session given an authentication token, the address that the
+// token is used from and the ID of the task whose certificate the client presented, if any. Tokens
+// are valid until their task ends or they are rotated. It returns ErrNotFound if the token is
+// invalid, was rotated or belongs to a session that was deleted, ErrTaskTokenCertificate if
+// certificates are required and the client did not present the one of the token's task, and
+// ErrTaskTokenAddress if the token is bound to other addresses.
+func (db *PgDB) TaskSessionByToken(token, address, certTaskID string) (*model.TaskSession, error) {
 	v2 := paseto.NewV2()
 
-	var session model.TaskSession
-	err := v2.Verify(token, db.tokenKeys.PublicKey, &session, nil)
-	if err != nil {
+	var claims model.TaskSession
+	if err := v2.Verify(token, db.tokenKeys.PublicKey, &claims, nil); err != nil {
 		return nil, ErrNotFound
 	}
 
+	var session model.TaskSession
 	query := `SELECT * FROM task_sessions WHERE id=$1`
-	if err := db.query(query, &session, session.ID); errors.Cause(err) == ErrNotFound {
+	if err := db.query(query, &session, claims.ID); errors.Cause(err) == ErrNotFound {
 		return nil, ErrNotFound
 	} else if err != nil {
 		return nil, err
 	}
+	if session.Generation != claims.Generation {
+		return nil, ErrNotFound
+	}
 
-	return &session, nil
+	if db.certifyTaskSessions && certTaskID != session.TaskID {
+		return nil, ErrTaskTokenCertificate
+	}
+	if !db.bindTaskSessions {
+		return &session, nil
+	}
+	var addresses []string
+	if err := db.sql.Select(&addresses, `
+SELECT address FROM task_session_addresses WHERE session_id=$1`, session.ID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the addresses of task session %d", session.ID)
+	}
+	if len(addresses) == 0 {
+		return &session, nil
+	}
+	for _, a := range addresses {
+		if a == address {
+			return &session, nil
+		}
+	}
+	return nil, ErrTaskTokenAddress
 }
 
-// DeleteTaskSessionByTaskID deletes the task session with the given ID.
+// DeleteTaskSessionByTaskID deletes the task session with the given ID. Every token of the
+// session stops working immediately.
 func (db *PgDB) DeleteTaskSessionByTaskID(taskID string) error {
 	_, err := db.sql.Exec("DELETE FROM task_sessions WHERE task_id=$1", taskID)
 	return err
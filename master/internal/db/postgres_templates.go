@@ -12,23 +12,50 @@ func (db *PgDB) TemplateList() (values []model.Template, err error) {
 	return values, err
 }
 
-// TemplateByName looks up a config template by name in a database.
+// TemplateByName looks up a config template by name in a database. Its storage credentials are
+// redacted.
 func (db *PgDB) TemplateByName(name string) (value model.Template, err error) {
 	err = db.Query("get_template", &value, name)
 	return value, err
 }
 
-// UpsertTemplate creates or updates a config template.
+// TemplateWithStorageCredentials looks up a config template by name in a database, with its
+// storage credentials restored, for applying it to a new task.
+func (db *PgDB) TemplateWithStorageCredentials(name string) (model.Template, error) {
+	var tpl model.Template
+	if err := db.query(`
+SELECT name, config, storage_credentials, storage_credentials_encrypted
+FROM templates
+WHERE name = $1`, &tpl, name); err != nil {
+		return model.Template{}, err
+	}
+	config, err := db.restoreConfig(
+		tpl.Config, tpl.StorageCredentials, tpl.StorageCredentialsEncrypted)
+	if err != nil {
+		return model.Template{}, errors.Wrapf(
+			err, "error restoring storage credentials of template '%v'", name)
+	}
+	tpl.Config = config
+	return tpl, nil
+}
+
+// UpsertTemplate creates or updates a config template. The storage credentials in its config are
+// stored apart from it.
 func (db *PgDB) UpsertTemplate(tpl *model.Template) error {
 	if len(tpl.Name) == 0 {
 		return errors.New("error setting a template: empty name")
 	}
-	err := db.namedExecOne(`
-INSERT INTO templates (name, config)
-VALUES (:name, :config)
+	stored, err := db.stripConfig(tpl.Config, nil)
+	if err != nil {
+		return errors.Wrapf(err, "error setting a template '%v'", tpl.Name)
+	}
+	_, err = db.sql.Exec(`
+INSERT INTO templates (name, config, storage_credentials, storage_credentials_encrypted)
+VALUES ($1, $2, $3, $4)
 ON CONFLICT (name)
 DO
-UPDATE SET config=:config`, tpl)
+UPDATE SET config=$2, storage_credentials=$3, storage_credentials_encrypted=$4`,
+		tpl.Name, stored.Config, stored.Credentials, stored.Encrypted)
 	if err != nil {
 		return errors.Wrapf(err, "error setting a template '%v'", tpl.Name)
 	}
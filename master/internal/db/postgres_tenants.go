@@ -0,0 +1,278 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// TenantResource is a kind of resource that belongs to a tenant: users directly, and everything
+// else through the workspace that it was created in.
+type TenantResource string
+
+// The kinds of resources that belong to tenants, by the IDs that identify them.
+const (
+	TenantUser       TenantResource = "user"
+	TenantWorkspace  TenantResource = "workspace"
+	TenantProject    TenantResource = "project"
+	TenantExperiment TenantResource = "experiment"
+	TenantTrial      TenantResource = "trial"
+	TenantCheckpoint TenantResource = "checkpoint"
+	TenantTask       TenantResource = "task"
+)
+
+// tenantQueries look up the tenant of each kind of resource by its ID. Tasks that were not created
+// in a project, such as those that garbage collect checkpoints, belong to the default project.
+var tenantQueries = map[TenantResource]string{
+	TenantUser: `
+SELECT tenant_id FROM users WHERE username = $1`,
+	TenantWorkspace: `
+SELECT tenant_id FROM workspaces WHERE id = $1`,
+	TenantProject: `
+SELECT w.tenant_id FROM projects p
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE p.id = $1`,
+	TenantExperiment: `
+SELECT w.tenant_id FROM experiments e
+JOIN projects p ON p.id = e.project_id
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE e.id = $1`,
+	TenantTrial: `
+SELECT w.tenant_id FROM trials t
+JOIN experiments e ON e.id = t.experiment_id
+JOIN projects p ON p.id = e.project_id
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE t.id = $1`,
+	TenantCheckpoint: `
+SELECT w.tenant_id FROM checkpoints c
+JOIN trials t ON t.id = c.trial_id
+JOIN experiments e ON e.id = t.experiment_id
+JOIN projects p ON p.id = e.project_id
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE c.uuid::text = $1`,
+	TenantTask: `
+SELECT w.tenant_id FROM tasks t
+LEFT JOIN trials tr ON tr.id = t.trial_id
+LEFT JOIN experiments e ON e.id = tr.experiment_id
+JOIN projects p ON p.id = coalesce(t.project_id, e.project_id, 1)
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE t.task_id = $1`,
+}
+
+// ResourceTenant returns the ID of the tenant that a resource belongs to, or nil if it belongs to
+// none. It returns ErrNotFound if the resource does not exist.
+func (db *PgDB) ResourceTenant(resource TenantResource, id interface{}) (*int, error) {
+	var tenantID *int
+	if err := db.sql.QueryRowx(tenantQueries[resource], id).Scan(&tenantID); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrapf(err, "error fetching the tenant of %s %v", resource, id)
+	}
+	return tenantID, nil
+}
+
+// TenantProjects returns the IDs of the projects in the workspaces of a tenant, or of no tenant if
+// tenantID is nil.
+func (db *PgDB) TenantProjects(tenantID *int) ([]int, error) {
+	var ids []int
+	if err := db.sql.Select(&ids, `
+SELECT p.id FROM projects p
+JOIN workspaces w ON w.id = p.workspace_id
+WHERE w.tenant_id IS NOT DISTINCT FROM $1
+ORDER BY p.id`, tenantID); err != nil {
+		return nil, errors.Wrap(err, "error fetching the projects of the tenant")
+	}
+	return ids, nil
+}
+
+// ResourcePoolTenants returns the IDs of the tenants of the resource pools that belong to one.
+func (db *PgDB) ResourcePoolTenants() (map[string]int, error) {
+	var rows []struct {
+		ResourcePool string `db:"resource_pool"`
+		TenantID     int    `db:"tenant_id"`
+	}
+	if err := db.queryRows(`
+SELECT resource_pool, tenant_id FROM tenant_resource_pools`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error fetching the tenants of resource pools")
+	}
+	tenants := make(map[string]int, len(rows))
+	for _, row := range rows {
+		tenants[row.ResourcePool] = row.TenantID
+	}
+	return tenants, nil
+}
+
+// Tenants returns all tenants along with their members.
+func (db *PgDB) Tenants() ([]model.Tenant, error) {
+	var tenants []model.Tenant
+	if err := db.queryRows(`
+SELECT id, name FROM tenants
+ORDER BY name`, &tenants); err != nil {
+		return nil, errors.Wrap(err, "error fetching tenants")
+	}
+	var rows []struct {
+		TenantID int    `db:"tenant_id"`
+		Kind     string `db:"kind"`
+		Name     string `db:"name"`
+	}
+	if err := db.queryRows(`
+SELECT tenant_id, 'user' AS kind, username AS name FROM users WHERE tenant_id IS NOT NULL
+UNION ALL
+SELECT tenant_id, 'workspace', name FROM workspaces WHERE tenant_id IS NOT NULL
+UNION ALL
+SELECT tenant_id, 'resource_pool', resource_pool FROM tenant_resource_pools
+ORDER BY name`, &rows); err != nil {
+		return nil, errors.Wrap(err, "error fetching tenant members")
+	}
+	byID := make(map[int]*model.Tenant, len(tenants))
+	for i := range tenants {
+		tenants[i].Usernames = []string{}
+		tenants[i].WorkspaceNames = []string{}
+		tenants[i].ResourcePools = []string{}
+		byID[tenants[i].ID] = &tenants[i]
+	}
+	for _, row := range rows {
+		tenant, ok := byID[row.TenantID]
+		if !ok {
+			continue
+		}
+		switch row.Kind {
+		case "user":
+			tenant.Usernames = append(tenant.Usernames, row.Name)
+		case "workspace":
+			tenant.WorkspaceNames = append(tenant.WorkspaceNames, row.Name)
+		case "resource_pool":
+			tenant.ResourcePools = append(tenant.ResourcePools, row.Name)
+		}
+	}
+	return tenants, nil
+}
+
+// PutTenant creates a tenant or replaces the members of an existing tenant. Users, workspaces and
+// resource pools that belonged to another tenant move to this one. It returns ErrNotFound if any
+// of the users or workspaces does not exist.
+func (db *PgDB) PutTenant(tenant *model.Tenant) error {
+	return db.withTransaction("put tenant", func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowx(`
+INSERT INTO tenants (name) VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id`, tenant.Name).Scan(&tenant.ID); err != nil {
+			return errors.Wrapf(err, "error setting tenant %s", tenant.Name)
+		}
+		for _, table := range []struct {
+			name, column string
+			values       []string
+		}{
+			{"users", "username", tenant.Usernames},
+			{"workspaces", "name", tenant.WorkspaceNames},
+		} {
+			if err := setTenantMembers(tx, tenant, table.name, table.column, table.values); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`
+DELETE FROM tenant_resource_pools WHERE tenant_id = $1`, tenant.ID); err != nil {
+			return errors.Wrapf(err, "error clearing resource pools of tenant %s", tenant.Name)
+		}
+		for _, pool := range tenant.ResourcePools {
+			if _, err := tx.Exec(`
+INSERT INTO tenant_resource_pools (resource_pool, tenant_id) VALUES ($1, $2)
+ON CONFLICT (resource_pool) DO UPDATE SET tenant_id = EXCLUDED.tenant_id`,
+				pool, tenant.ID); err != nil {
+				return errors.Wrapf(err, "error adding %s to tenant %s", pool, tenant.Name)
+			}
+		}
+		return nil
+	})
+}
+
+// setTenantMembers makes the rows of a table with the given names the only ones of a tenant.
+func setTenantMembers(
+	tx *sqlx.Tx, tenant *model.Tenant, table, column string, names []string,
+) error {
+	if _, err := tx.Exec(`
+UPDATE `+table+` SET tenant_id = NULL WHERE tenant_id = $1`, tenant.ID); err != nil {
+		return errors.Wrapf(err, "error clearing %s of tenant %s", table, tenant.Name)
+	}
+	for _, name := range names {
+		result, err := tx.Exec(`
+UPDATE `+table+` SET tenant_id = $1 WHERE `+column+` = $2`, tenant.ID, name)
+		if err != nil {
+			return errors.Wrapf(err, "error adding %s to tenant %s", name, tenant.Name)
+		}
+		if num, err := result.RowsAffected(); err != nil {
+			return errors.Wrapf(err, "error adding %s to tenant %s", name, tenant.Name)
+		} else if num == 0 {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+// DeleteTenant deletes a tenant that has no users or workspaces, along with its resource pools,
+// which then belong to no tenant. It returns ErrNotEmpty if the tenant still has members.
+func (db *PgDB) DeleteTenant(name string) error {
+	result, err := db.sql.Exec(`
+DELETE FROM tenants WHERE name = $1`, name)
+	if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == foreignKeyViolation {
+		return ErrNotEmpty
+	} else if err != nil {
+		return errors.Wrapf(err, "error deleting tenant %s", name)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting tenant %s", name)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TenantSlotHours returns the slot-hours that the tasks of a tenant have been assigned in each
+// resource pool between two times, including those of the tasks that are still running. Tasks
+// belong to the tenant of the workspace that they were created in.
+func (db *PgDB) TenantSlotHours(
+	tenantID int, since, until time.Time,
+) (map[string]float64, error) {
+	var rows []struct {
+		ResourcePool string  `db:"resource_pool"`
+		SlotHours    float64 `db:"slot_hours"`
+	}
+	if err := db.queryRows(`
+WITH const AS (
+    SELECT $1::timestamp AS since, least($2::timestamp, now() AT TIME ZONE 'utc') AS until
+)
+SELECT
+    coalesce(nullif(t.resource_pool, ''), 'default') AS resource_pool,
+    sum(
+        extract(epoch FROM least(coalesce(t.end_time, const.until), const.until)
+            - greatest(t.assigned_time, const.since))
+        * t.slots
+    ) / 3600 AS slot_hours
+FROM tasks t
+LEFT JOIN trials tr ON tr.id = t.trial_id
+LEFT JOIN experiments e ON e.id = tr.experiment_id
+JOIN projects p ON p.id = coalesce(t.project_id, e.project_id, 1)
+JOIN workspaces w ON w.id = p.workspace_id,
+    const
+WHERE t.assigned_time IS NOT NULL
+    AND t.assigned_time < const.until
+    AND (t.end_time IS NULL OR t.end_time > const.since)
+    AND w.tenant_id = $3
+GROUP BY 1`, &rows, since.UTC(), until.UTC(), tenantID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching the usage of tenant %d", tenantID)
+	}
+	slotHours := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		slotHours[row.ResourcePool] = row.SlotHours
+	}
+	return slotHours, nil
+}
@@ -1,9 +1,10 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -78,39 +79,35 @@ ORDER BY timestamp %s OFFSET $2 LIMIT $3
 	return b, offset + len(b), nil
 }
 
-// AddTrialLogs adds a list of *model.TrialLog objects to the database with automatic IDs.
+// trialLogColumns are the columns that AddTrialLogs copies trial logs into.
+var trialLogColumns = []string{
+	"trial_id", "message", "log", "agent_id", "container_id", "rank_id", "timestamp", "level",
+	"stdtype", "source",
+}
+
+// AddTrialLogs adds a list of *model.TrialLog objects to the database with automatic IDs. They are
+// copied in bulk, which keeps up with far more logs than inserting them.
 func (db *PgDB) AddTrialLogs(logs []*model.TrialLog) error {
 	if len(logs) == 0 {
 		return nil
 	}
 
-	var text strings.Builder
-	text.WriteString(`
-INSERT INTO trial_logs
-  (trial_id, message, log, agent_id, container_id, rank_id, timestamp, level, stdtype, source)
- VALUES
-`)
-
-	args := make([]interface{}, 0, len(logs)*10)
-
-	for i, log := range logs {
-		if i > 0 {
-			text.WriteString(",")
-		}
-		fmt.Fprintf(&text, " ($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			i*10+1, i*10+2, i*10+3, i*10+4, i*10+5, i*10+6, i*10+7, i*10+8, i*10+9, i*10+10)
-
-		var l *model.RawString
+	rows := make([][]interface{}, 0, len(logs))
+	for _, log := range logs {
+		// The message and log are bytea, like model.RawString.
+		var l []byte
 		if log.Log != nil {
-			r := model.RawString(*log.Log)
-			l = &r
+			l = []byte(*log.Log)
 		}
-
-		args = append(args, log.TrialID, log.Message, l, log.AgentID, log.ContainerID, log.RankID,
-			log.Timestamp, log.Level, log.StdType, log.Source)
+		rows = append(rows, []interface{}{
+			log.TrialID, []byte(log.Message), l, log.AgentID, log.ContainerID, log.RankID,
+			log.Timestamp, log.Level, log.StdType, log.Source,
+		})
 	}
 
-	if _, err := db.sql.Exec(text.String(), args...); err != nil {
+	if _, err := db.copyFrom(
+		context.Background(), "trial_logs", trialLogColumns, pgx.CopyFromRows(rows),
+	); err != nil {
 		return errors.Wrapf(err, "error inserting %d trial logs", len(logs))
 	}
 
@@ -128,6 +125,50 @@ WHERE trial_id IN (SELECT unnest($1::int [])::int);
 	return nil
 }
 
+// PruneTrialLogs deletes the logs of the trials that ended more than the given number of days
+// ago and the logs of each trial beyond its most recent maxLinesPerTrial lines. The log_retention
+// section of the configuration of an experiment overrides both for its trials; zero keeps the
+// logs. It returns how many log lines were deleted.
+func (db *PgDB) PruneTrialLogs(days, maxLinesPerTrial int) (int64, error) {
+	expired, err := db.sql.Exec(`
+DELETE FROM trial_logs l
+USING trials t, experiments e
+WHERE l.trial_id = t.id AND t.experiment_id = e.id AND t.end_time IS NOT NULL
+  AND coalesce((e.config->'log_retention'->>'days')::int, $1) > 0
+  AND t.end_time < now() - make_interval(
+    days => coalesce((e.config->'log_retention'->>'days')::int, $1))`, days)
+	if err != nil {
+		return 0, errors.Wrap(err, "error deleting expired trial logs")
+	}
+	numExpired, err := expired.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "error checking deleted trial logs")
+	}
+
+	// Each trial keeps the logs from the oldest of its most recent lines that it is allowed.
+	excess, err := db.sql.Exec(`
+DELETE FROM trial_logs l
+USING (
+  SELECT r.trial_id, r.id
+  FROM (
+    SELECT trial_id, id, row_number() OVER (PARTITION BY trial_id ORDER BY id DESC) AS n
+    FROM trial_logs
+  ) r
+  JOIN trials t ON r.trial_id = t.id
+  JOIN experiments e ON t.experiment_id = e.id
+  WHERE r.n = coalesce((e.config->'log_retention'->>'max_lines_per_trial')::int, $1)
+) oldest_kept
+WHERE l.trial_id = oldest_kept.trial_id AND l.id < oldest_kept.id`, maxLinesPerTrial)
+	if err != nil {
+		return numExpired, errors.Wrap(err, "error deleting excess trial logs")
+	}
+	numExcess, err := excess.RowsAffected()
+	if err != nil {
+		return numExpired, errors.Wrap(err, "error checking deleted trial logs")
+	}
+	return numExpired + numExcess, nil
+}
+
 // TrialLogsCount returns the number of logs in postgres for the given trial.
 func (db *PgDB) TrialLogsCount(trialID int, fs []api.Filter) (int, error) {
 	params := []interface{}{trialID}
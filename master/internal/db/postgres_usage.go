@@ -0,0 +1,51 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdateUsageAggregation adds the slot-seconds of tasks to the usage_aggregates table for each day
+// that has ended since the last day that was aggregated.
+func (db *PgDB) UpdateUsageAggregation() error {
+	var lastDatePtr *time.Time
+	if err := db.sql.QueryRow(
+		`SELECT max(date)::timestamp FROM usage_aggregates`,
+	).Scan(&lastDatePtr); err != nil {
+		return errors.Wrap(err, "failed to find last usage aggregate")
+	}
+
+	// The values periodStart takes on are all midnight UTC for each day that is to be aggregated.
+	var periodStart time.Time
+	if lastDatePtr == nil {
+		var firstDatePtr *time.Time
+		if err := db.sql.QueryRow(
+			`SELECT date_trunc('day', min(assigned_time)) FROM tasks`,
+		).Scan(&firstDatePtr); err != nil {
+			return errors.Wrap(err, "failed to find first assigned task")
+		}
+		if firstDatePtr == nil {
+			// No task was ever assigned resources; nothing to do.
+			return nil
+		}
+		periodStart = firstDatePtr.UTC()
+	} else {
+		periodStart = lastDatePtr.UTC().AddDate(0, 0, 1)
+	}
+
+	// Only days that have ended are aggregated, as in UpdateResourceAllocationAggregation. Days
+	// without any usage have no rows, so they are aggregated again the next time, which is cheap.
+	targetDate := time.Now().UTC().AddDate(0, 0, -1)
+	for ; periodStart.Before(targetDate); periodStart = periodStart.AddDate(0, 0, 1) {
+		t0 := time.Now()
+		if _, err := db.sql.Exec(
+			db.queries.getOrLoad("update_usage_aggregates"), periodStart,
+		); err != nil {
+			return errors.Wrap(err, "failed to add usage aggregate")
+		}
+		log.Infof("aggregated resource usage for %v in %s", periodStart, time.Since(t0))
+	}
+	return nil
+}
@@ -94,8 +94,8 @@ func (db *PgDB) UserByUsername(username string) (*model.User, error) {
 func addUser(tx *sqlx.Tx, user *model.User) (model.UserID, error) {
 	stmt, err := tx.PrepareNamed(`
 INSERT INTO users
-(username, admin, active)
-VALUES (:username, :admin, :active)
+(username, admin, active, remote, tenant_id)
+VALUES (:username, :admin, :active, :remote, :tenant_id)
 RETURNING id`)
 	if err != nil {
 		return 0, errors.WithStack(err)
@@ -259,7 +259,7 @@ func (db *PgDB) UserByID(userID model.UserID) (*model.FullUser, error) {
 	var fu model.FullUser
 	if err := db.query(`
 SELECT
-	u.id, u.username, u.admin, u.active,
+	u.id, u.username, u.admin, u.active, u.remote, u.tenant_id,
 	h.uid AS agent_uid, h.gid AS agent_gid, h.user_ AS agent_user, h.group_ AS agent_group
 FROM users u
 LEFT OUTER JOIN agent_user_groups h ON (u.id = h.user_id)
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const selectWebhooks = `
+SELECT id, project_id, experiment_id, url, array_to_string(states, ',') AS states
+FROM webhooks`
+
+// Webhooks returns the webhooks of a project, if projectID is not 0, or of an experiment.
+func (db *PgDB) Webhooks(projectID, experimentID int) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.queryRows(selectWebhooks+`
+WHERE ($1 > 0 AND project_id = $1) OR ($2 > 0 AND experiment_id = $2)
+ORDER BY id`, &webhooks, projectID, experimentID); err != nil {
+		return nil, errors.Wrap(err, "error fetching webhooks")
+	}
+	return webhooks, nil
+}
+
+// WebhookByID returns a webhook.
+func (db *PgDB) WebhookByID(id int) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := db.query(selectWebhooks+`
+WHERE id = $1`, &webhook, id); err != nil {
+		return nil, errors.Wrapf(err, "error fetching webhook %d", id)
+	}
+	return &webhook, nil
+}
+
+// ExperimentWebhooks returns the webhooks to notify when an experiment ends in a state: those of
+// the experiment, or those of its project if the experiment has none.
+func (db *PgDB) ExperimentWebhooks(experimentID int, state model.State) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.queryRows(selectWebhooks+` w
+WHERE $2::experiment_state = ANY(w.states) AND (
+    w.experiment_id = $1 OR (
+        w.project_id = (SELECT project_id FROM experiments WHERE id = $1)
+        AND NOT EXISTS (SELECT 1 FROM webhooks x WHERE x.experiment_id = $1)
+    )
+)
+ORDER BY id`, &webhooks, experimentID, state); err != nil {
+		return nil, errors.Wrapf(err, "error fetching webhooks of experiment %d", experimentID)
+	}
+	return webhooks, nil
+}
+
+// ExperimentAlertWebhooks returns the webhooks to notify when an alert of an experiment fires:
+// those of the experiment, or those of its project if the experiment has none, whatever their
+// states.
+func (db *PgDB) ExperimentAlertWebhooks(experimentID int) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.queryRows(selectWebhooks+` w
+WHERE w.experiment_id = $1 OR (
+    w.project_id = (SELECT project_id FROM experiments WHERE id = $1)
+    AND NOT EXISTS (SELECT 1 FROM webhooks x WHERE x.experiment_id = $1)
+)
+ORDER BY id`, &webhooks, experimentID); err != nil {
+		return nil, errors.Wrapf(err, "error fetching webhooks of experiment %d", experimentID)
+	}
+	return webhooks, nil
+}
+
+// AddWebhook persists a new webhook and sets its ID.
+func (db *PgDB) AddWebhook(webhook *model.Webhook) error {
+	if err := db.namedGet(&webhook.ID, `
+INSERT INTO webhooks (project_id, experiment_id, url, states)
+VALUES (:project_id, :experiment_id, :url, string_to_array(:states, ',')::experiment_state[])
+RETURNING id`, webhook); err != nil {
+		return errors.Wrap(err, "error adding webhook")
+	}
+	return nil
+}
+
+// DeleteWebhook deletes a webhook.
+func (db *PgDB) DeleteWebhook(id int) error {
+	result, err := db.sql.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting webhook %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting webhook %d", id)
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ExperimentBestValidation returns the trial with the best validation of an experiment by its
+// searcher metric, and the metrics of that validation. The trial ID is 0 if the experiment has no
+// validations.
+func (db *PgDB) ExperimentBestValidation(experimentID int) (int, model.JSONObj, error) {
+	var best struct {
+		TrialID int           `db:"trial_id"`
+		Metrics model.JSONObj `db:"metrics"`
+	}
+	switch err := db.query(`
+SELECT t.id AS trial_id, v.metrics->'validation_metrics' AS metrics
+FROM trials t
+JOIN validations v ON v.id = t.best_validation_id
+JOIN experiments e ON e.id = t.experiment_id
+WHERE t.experiment_id = $1
+ORDER BY
+    (CASE WHEN coalesce((e.config->'searcher'->>'smaller_is_better')::boolean, true)
+        THEN 1 ELSE -1 END)
+    * (v.metrics->'validation_metrics'->>(e.config->'searcher'->>'metric'))::float8 ASC NULLS LAST
+LIMIT 1`, &best, experimentID); {
+	case errors.Cause(err) == ErrNotFound:
+		return 0, nil, nil
+	case err != nil:
+		return 0, nil, errors.Wrapf(err,
+			"error fetching the best validation of experiment %d", experimentID)
+	}
+	return best.TrialID, best.Metrics, nil
+}
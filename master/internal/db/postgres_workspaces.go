@@ -0,0 +1,155 @@
+package db
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ErrNotEmpty is returned when trying to delete a workspace or project that still contains
+// projects or experiments.
+var ErrNotEmpty = errors.New("not empty")
+
+// Workspaces returns all workspaces.
+func (db *PgDB) Workspaces() ([]model.Workspace, error) {
+	var workspaces []model.Workspace
+	if err := db.queryRows(`
+SELECT * FROM workspaces
+ORDER BY id`, &workspaces); err != nil {
+		return nil, errors.Wrap(err, "error fetching workspaces")
+	}
+	return workspaces, nil
+}
+
+// WorkspaceByID looks up a workspace by ID, returning ErrNotFound if it does not exist.
+func (db *PgDB) WorkspaceByID(id int) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := db.query(`
+SELECT * FROM workspaces
+WHERE id = $1`, &workspace, id); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// WorkspaceByProjectID looks up the workspace of a project, returning ErrNotFound if the project
+// does not exist.
+func (db *PgDB) WorkspaceByProjectID(projectID int) (*model.Workspace, error) {
+	var workspace model.Workspace
+	if err := db.query(`
+SELECT w.* FROM workspaces w
+JOIN projects p ON p.workspace_id = w.id
+WHERE p.id = $1`, &workspace, projectID); err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// AddWorkspace creates a workspace. It returns ErrDuplicateRecord if the name is taken.
+func (db *PgDB) AddWorkspace(workspace *model.Workspace) error {
+	err := db.namedGet(&workspace.ID, `
+INSERT INTO workspaces (name, default_resource_pool, default_image, tenant_id)
+VALUES (:name, :default_resource_pool, :default_image, :tenant_id)
+RETURNING id`, workspace)
+	if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrapf(err, "error adding workspace %s", workspace.Name)
+}
+
+// UpdateWorkspace updates the name and defaults of a workspace.
+func (db *PgDB) UpdateWorkspace(workspace *model.Workspace) error {
+	err := db.namedExecOne(`
+UPDATE workspaces
+SET name = :name, default_resource_pool = :default_resource_pool, default_image = :default_image
+WHERE id = :id`, workspace)
+	if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrapf(err, "error updating workspace %d", workspace.ID)
+}
+
+// DeleteWorkspace deletes a workspace that has no projects.
+func (db *PgDB) DeleteWorkspace(id int) error {
+	if id == model.DefaultWorkspaceID {
+		return ErrNotEmpty
+	}
+	result, err := db.sql.Exec(`
+DELETE FROM workspaces
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM projects WHERE workspace_id = $1)`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting workspace %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting workspace %d", id)
+	}
+	if num == 1 {
+		return nil
+	}
+	if _, err := db.WorkspaceByID(id); err != nil {
+		return err
+	}
+	return ErrNotEmpty
+}
+
+// Projects returns the projects of a workspace, or of all workspaces if workspaceID is zero.
+func (db *PgDB) Projects(workspaceID int) ([]model.Project, error) {
+	var projects []model.Project
+	if err := db.queryRows(`
+SELECT * FROM projects
+WHERE $1 = 0 OR workspace_id = $1
+ORDER BY id`, &projects, workspaceID); err != nil {
+		return nil, errors.Wrap(err, "error fetching projects")
+	}
+	return projects, nil
+}
+
+// ProjectByID looks up a project by ID, returning ErrNotFound if it does not exist.
+func (db *PgDB) ProjectByID(id int) (*model.Project, error) {
+	var project model.Project
+	if err := db.query(`
+SELECT * FROM projects
+WHERE id = $1`, &project, id); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// AddProject creates a project. It returns ErrDuplicateRecord if the workspace already has a
+// project with the same name.
+func (db *PgDB) AddProject(project *model.Project) error {
+	err := db.namedGet(&project.ID, `
+INSERT INTO projects (name, description, workspace_id)
+VALUES (:name, :description, :workspace_id)
+RETURNING id`, project)
+	if pgerr, ok := errors.Cause(err).(*pgconn.PgError); ok && pgerr.Code == uniqueViolation {
+		return ErrDuplicateRecord
+	}
+	return errors.Wrapf(err, "error adding project %s", project.Name)
+}
+
+// DeleteProject deletes a project that has no experiments.
+func (db *PgDB) DeleteProject(id int) error {
+	if id == model.DefaultProjectID {
+		return ErrNotEmpty
+	}
+	result, err := db.sql.Exec(`
+DELETE FROM projects
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM experiments WHERE project_id = $1)`, id)
+	if err != nil {
+		return errors.Wrapf(err, "error deleting project %d", id)
+	}
+	num, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "error deleting project %d", id)
+	}
+	if num == 1 {
+		return nil
+	}
+	if _, err := db.ProjectByID(id); err != nil {
+		return err
+	}
+	return ErrNotEmpty
+}
@@ -2,12 +2,13 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-)
 
-const maxOpenConns = 48
+	"github.com/determined-ai/determined/master/internal/prom"
+)
 
 const (
 	cnxTpl         = "postgres://%s:%s@%s:%s/%s?application_name=determined-master"
@@ -25,7 +26,10 @@ func Setup(opts *Config) (*PgDB, error) {
 		return nil, errors.Wrapf(err, "error connecting to database: %s:%s", opts.Host, opts.Port)
 	}
 
-	db.sql.SetMaxOpenConns(maxOpenConns)
+	db.sql.SetMaxOpenConns(opts.MaxOpenConns)
+	db.sql.SetMaxIdleConns(opts.MaxIdleConns)
+	db.sql.SetConnMaxIdleTime(time.Duration(opts.ConnMaxIdleTime) * time.Second)
+	prom.Register("db", db.collector())
 
 	log.Infof("running migrations from %v", opts.Migrations)
 	if err = db.Migrate(opts.Migrations); err != nil {
@@ -37,5 +41,8 @@ func Setup(opts *Config) (*PgDB, error) {
 	if err = db.initTaskSessions(); err != nil {
 		return nil, err
 	}
+	if err = db.initTasks(); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
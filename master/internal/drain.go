@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/command"
+)
+
+// defaultDrainGracePeriod is how long API calls may take to finish when the master drains, unless
+// the caller of DrainMaster sets it.
+const defaultDrainGracePeriod = time.Minute
+
+// drain lets the API calls of the master finish within the grace period, persists the commands
+// that wait for resources and ends Run. The drainer must have been started, so that the master no
+// longer launches tasks. Experiments need nothing more, since their trials are restored from their
+// snapshots when the master restarts.
+func (m *Master) drain(gracePeriod time.Duration) {
+	log.Infof("draining the master for an upgrade, API calls have %s to finish", gracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+	if canceled := m.drainer.Wait(ctx); canceled > 0 {
+		log.Infof("canceled %d API calls that did not finish in time", canceled)
+	}
+	log.Infof("persisted %d tasks that wait for resources", command.PersistQueued(m.system))
+	m.stop()
+}
@@ -0,0 +1,115 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LaunchRejection explains why calls that launch tasks are rejected while the master drains.
+const LaunchRejection = "the master is shutting down for an upgrade and does not launch tasks, " +
+	"retry once it restarts"
+
+// Drainer tracks the calls to the API of the master, so that the master can let them finish before
+// it exits for an upgrade. A nil *Drainer never drains.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	nextID   int
+	calls    map[int]context.CancelFunc
+	// idle is closed once no calls are left, while Wait waits for it.
+	idle chan struct{}
+}
+
+// New creates a Drainer.
+func New() *Drainer {
+	return &Drainer{calls: map[int]context.CancelFunc{}}
+}
+
+// Draining returns true once the master has begun to drain.
+func (d *Drainer) Draining() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Start begins to drain. It returns false if the master was already draining.
+func (d *Drainer) Start() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.draining = true
+	return true
+}
+
+// Track records a call until the returned function is called. The context of the call is canceled
+// if it is still running when the master stops waiting for calls to finish.
+func (d *Drainer) Track(ctx context.Context) (context.Context, func()) {
+	if d == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := d.nextID
+	d.nextID++
+	d.calls[id] = cancel
+	return ctx, func() {
+		cancel()
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.calls, id)
+		if len(d.calls) == 0 && d.idle != nil {
+			close(d.idle)
+			d.idle = nil
+		}
+	}
+}
+
+// Wait waits until no calls are left or the context ends, which is the grace period of calls that
+// stream, e.g. follow logs. It then cancels the calls that are left and returns how many there
+// were.
+func (d *Drainer) Wait(ctx context.Context) int {
+	d.mu.Lock()
+	if len(d.calls) == 0 {
+		d.mu.Unlock()
+		return 0
+	}
+	idle := make(chan struct{})
+	d.idle = idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.idle = nil
+	for _, cancel := range d.calls {
+		cancel()
+	}
+	return len(d.calls)
+}
+
+// RejectLaunches returns echo middleware for the routes that launch tasks, which rejects their
+// requests with 503 Service Unavailable while the master drains.
+func RejectLaunches(d *Drainer) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if d.Draining() {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, LaunchRejection)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gotest.tools/assert"
+)
+
+func TestDrainer(t *testing.T) {
+	d := New()
+	assert.Assert(t, !d.Draining())
+	assert.Assert(t, !(*Drainer)(nil).Draining())
+
+	_, done := d.Track(context.Background())
+	stream, streamDone := d.Track(context.Background())
+	assert.Assert(t, d.Start())
+	assert.Assert(t, d.Draining())
+	assert.Assert(t, !d.Start(), "the master is already draining")
+
+	// Calls that finish within the grace period are not canceled.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, d.Wait(ctx), 1)
+	assert.Equal(t, stream.Err(), context.Canceled)
+	streamDone()
+
+	assert.Equal(t, d.Wait(context.Background()), 0)
+}
+
+func TestDrainerIdle(t *testing.T) {
+	d := New()
+	_, done := d.Track(context.Background())
+	d.Start()
+	go done()
+	assert.Equal(t, d.Wait(context.Background()), 0)
+}
+
+func TestRejectLaunches(t *testing.T) {
+	d := New()
+	e := echo.New()
+	e.POST("/experiments", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, RejectLaunches(d))
+	launch := func() int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/experiments", nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, launch(), http.StatusOK)
+	d.Start()
+	assert.Equal(t, launch(), http.StatusServiceUnavailable)
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+type (
+	// earlyStoppingState is the state of the early stopping rules of the experiment for a trial,
+	// with the state of each rule at the same index as the rule.
+	earlyStoppingState struct {
+		Rules []earlyStoppingRuleState `json:"rules"`
+	}
+	// earlyStoppingRuleState is the best value of the metric of a plateau rule, and how many reports
+	// of it since the best did not improve on it.
+	earlyStoppingRuleState struct {
+		Best             *float64 `json:"best"`
+		ReportsSinceBest int      `json:"reports_since_best"`
+	}
+)
+
+// check updates the state of the rules with the metrics that the trial reported and returns why the
+// trial must stop, if it broke any of the rules.
+func (s *earlyStoppingState) check(
+	rules expconf.EarlyStoppingConfig, metrics map[string]interface{},
+) string {
+	for len(s.Rules) < len(rules) {
+		s.Rules = append(s.Rules, earlyStoppingRuleState{})
+	}
+
+	for i, rule := range rules {
+		if rule.Metric() == nil {
+			if rule.Type() != expconf.NaNRule {
+				continue
+			}
+			for name, raw := range metrics {
+				if value, ok := metricValue(raw); ok && !isFinite(value) {
+					return fmt.Sprintf("metric %s is %v", name, value)
+				}
+			}
+			continue
+		}
+
+		name := *rule.Metric()
+		value, ok := metricValue(metrics[name])
+		if !ok {
+			continue
+		}
+		switch rule.Type() {
+		case expconf.NaNRule:
+			if !isFinite(value) {
+				return fmt.Sprintf("metric %s is %v", name, value)
+			}
+		case expconf.ThresholdRule:
+			if rule.Max() != nil && value > *rule.Max() {
+				return fmt.Sprintf("metric %s of %v is above its max of %v", name, value, *rule.Max())
+			}
+			if rule.Min() != nil && value < *rule.Min() {
+				return fmt.Sprintf("metric %s of %v is below its min of %v", name, value, *rule.Min())
+			}
+		case expconf.PlateauRule:
+			state := &s.Rules[i]
+			if state.Best == nil || improves(value, *state.Best, rule) {
+				state.Best = &value
+				state.ReportsSinceBest = 0
+				continue
+			}
+			state.ReportsSinceBest++
+			if state.ReportsSinceBest >= rule.Patience() {
+				return fmt.Sprintf("metric %s did not improve on %v by %v in %d reports",
+					name, *state.Best, rule.MinDelta(), state.ReportsSinceBest)
+			}
+		}
+	}
+	return ""
+}
+
+func improves(value, best float64, rule expconf.EarlyStoppingRule) bool {
+	if rule.SmallerIsBetter() {
+		return value < best-rule.MinDelta()
+	}
+	return value > best+rule.MinDelta()
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// metricValue returns the value of a scalar metric, which JSON encodes as a string when it is not
+// finite.
+func metricValue(raw interface{}) (float64, bool) {
+	switch raw := raw.(type) {
+	case float64:
+		return raw, true
+	case int:
+		return float64(raw), true
+	case string:
+		value, err := strconv.ParseFloat(raw, 64)
+		return value, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"math"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func TestEarlyStopping(t *testing.T) {
+	rules := schemas.WithDefaults(expconf.EarlyStoppingConfig{
+		{RawType: expconf.NaNRule},
+		{RawType: expconf.ThresholdRule, RawMetric: ptrs.StringPtr("loss"), RawMax: ptrs.Float64Ptr(10)},
+		{
+			RawType:     expconf.PlateauRule,
+			RawMetric:   ptrs.StringPtr("validation_loss"),
+			RawPatience: ptrs.IntPtr(2),
+			RawMinDelta: ptrs.Float64Ptr(0.1),
+		},
+	}).(expconf.EarlyStoppingConfig)
+
+	var state earlyStoppingState
+	assert.Equal(t, state.check(rules, map[string]interface{}{"loss": 1.0}), "")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"loss": "NaN"}), "metric loss is NaN")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"accuracy": math.Inf(1)}),
+		"metric accuracy is +Inf")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"loss": 11.0}),
+		"metric loss of 11 is above its max of 10")
+
+	// The plateau rule stops the trial after its patience runs out without an improvement.
+	assert.Equal(t, state.check(rules, map[string]interface{}{"validation_loss": 1.0}), "")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"validation_loss": 0.8}), "")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"validation_loss": 0.75}), "")
+	assert.Equal(t, state.check(rules, map[string]interface{}{"validation_loss": 0.72}),
+		"metric validation_loss did not improve on 0.8 by 0.1 in 2 reports")
+}
@@ -3,6 +3,7 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
@@ -10,11 +11,16 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/api"
 
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/hpimportance"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
+	"github.com/determined-ai/determined/master/internal/tracing"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/searcher"
@@ -58,8 +64,17 @@ type (
 	}
 	getTrial       struct{ trialID int }
 	killExperiment struct{}
+
+	// schedulingWindowTick checks whether the experiment is in its scheduling windows.
+	schedulingWindowTick struct{}
+	// maxRuntimeExceeded stops the experiment once it ran for its max_runtime.
+	maxRuntimeExceeded struct{}
 )
 
+// schedulingWindowCheckPeriod is how often an experiment with scheduling windows checks whether to
+// pause or resume.
+const schedulingWindowCheckPeriod = time.Minute
+
 type trialSnapshot struct {
 	requestID model.RequestID
 	trialID   int
@@ -78,6 +93,12 @@ type (
 	experimentState struct {
 		SearcherState  json.RawMessage `json:"searcher_state"`
 		BestValidation *float64        `json:"best_validation"`
+		// PausedBySchedulingWindow is whether the experiment is paused until its next scheduling
+		// window, rather than by its user.
+		PausedBySchedulingWindow bool `json:"paused_by_scheduling_window"`
+		// ProgressHistory is the recent progress of the experiment, which its end time is
+		// estimated from.
+		ProgressHistory progressHistory `json:"progress_history"`
 	}
 
 	experiment struct {
@@ -88,17 +109,24 @@ type (
 		rm                  *actor.Ref
 		trialLogger         *actor.Ref
 		hpImportance        *actor.Ref
+		webhooks            *actor.Ref
 		db                  *db.PgDB
 		searcher            *searcher.Searcher
 		warmStartCheckpoint *model.Checkpoint
 
 		agentUserGroup *model.AgentUserGroup
 		taskSpec       *tasks.TaskSpec
+		vault          *vault.Client
+		ca             *ca.Authority
 
 		TrialCurrentOperation map[model.RequestID]searcher.ValidateAfter
 
 		faultToleranceEnabled bool
 		restored              bool
+
+		// spanContext is the span of the request that created the experiment. Only the trials that
+		// the experiment starts with continue its trace.
+		spanContext tracing.SpanContext
 	}
 )
 
@@ -155,12 +183,6 @@ func newExperiment(master *Master, expModel *model.Experiment, taskSpec *tasks.T
 		return nil, err
 	}
 
-	if expModel.ID == 0 {
-		if err = master.db.AddExperiment(expModel); err != nil {
-			return nil, err
-		}
-	}
-
 	agentUserGroup, err := master.db.AgentUserGroup(*expModel.OwnerID)
 	if err != nil {
 		return nil, err
@@ -169,6 +191,28 @@ func newExperiment(master *Master, expModel *model.Experiment, taskSpec *tasks.T
 	if agentUserGroup == nil {
 		agentUserGroup = &master.config.Security.DefaultTask
 	}
+	if err = master.config.Security.CheckAgentUserGroup(*agentUserGroup); err != nil {
+		return nil, err
+	}
+
+	if expModel.ID == 0 {
+		if err = checkExperimentSecrets(master.db, *expModel.OwnerID, expModel.Config); err != nil {
+			return nil, err
+		}
+		if err = checkExperimentVaultSecrets(master.db, master.vault, expModel); err != nil {
+			return nil, err
+		}
+		datasets := experimentDatasets(expModel.Config)
+		if err = master.db.CheckDatasets(datasets); err != nil {
+			return nil, err
+		}
+		if err = master.db.AddExperiment(expModel); err != nil {
+			return nil, err
+		}
+		if err = master.db.AddExperimentDatasets(expModel.ID, datasets); err != nil {
+			return nil, err
+		}
+	}
 
 	return &experiment{
 		Experiment:          expModel,
@@ -176,12 +220,15 @@ func newExperiment(master *Master, expModel *model.Experiment, taskSpec *tasks.T
 		rm:                  master.rm,
 		trialLogger:         master.trialLogger,
 		hpImportance:        master.hpImportance,
+		webhooks:            master.webhooks,
 		db:                  master.db,
 		searcher:            search,
 		warmStartCheckpoint: checkpoint,
 
 		agentUserGroup: agentUserGroup,
 		taskSpec:       taskSpec,
+		vault:          master.vault,
+		ca:             master.ca,
 
 		TrialCurrentOperation: map[model.RequestID]searcher.ValidateAfter{},
 
@@ -217,6 +264,14 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		// Since e.searcher.TrialOperations should have all trials that were previously
 		// allocated, we can stop trying to restore new trials after processing these.
 		e.restored = false
+		e.spanContext = tracing.SpanContext{}
+		if len(e.Config.SchedulingWindows()) > 0 {
+			e.checkSchedulingWindows(ctx)
+		}
+		if maxRuntime := e.Config.MaxRuntime(); maxRuntime != nil {
+			deadline := e.StartTime.Add(time.Duration(*maxRuntime) * time.Second)
+			actors.NotifyAfter(ctx, time.Until(deadline), maxRuntimeExceeded{})
+		}
 	case trialCreated:
 		ops, err := e.searcher.TrialCreated(msg.create, msg.trialID)
 		e.processOperations(ctx, ops, err)
@@ -237,14 +292,25 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			ctx.Respond(err)
 		}
 		e.processOperations(ctx, ops, err)
+	case metricAlertFired:
+		ctx.Log().Infof("alert %q fired on trial %d with %s of %v", msg.name, msg.trialID,
+			msg.metric, msg.value)
+		paused := msg.pause && e.State == model.ActiveState && e.updateState(ctx, model.PausedState)
+		ctx.Tell(e.webhooks, webhooks.MetricAlert{
+			ExperimentID: e.ID,
+			Description:  e.Config.Description().String(),
+			ProjectID:    e.ProjectID,
+			TrialID:      msg.trialID,
+			Name:         msg.name,
+			Metric:       msg.metric,
+			Value:        msg.value,
+			Paused:       paused,
+		})
 	case trialQueryIsBestValidation:
 		ctx.Respond(e.isBestValidation(msg.validationMetrics))
 	case trialReportProgress:
 		e.searcher.SetTrialProgress(msg.requestID, msg.progress)
-		progress := e.searcher.Progress()
-		if err := e.db.SaveExperimentProgress(e.ID, &progress); err != nil {
-			ctx.Log().WithError(err).Error("failed to save experiment progress")
-		}
+		progress := e.saveProgress(ctx)
 		ctx.Tell(e.hpImportance, hpimportance.ExperimentProgress{ID: e.ID, Progress: progress})
 	case trialGetCurrentOperation:
 		requestID, ok := e.searcher.RequestID(msg.trialID)
@@ -269,6 +335,46 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		e.trialClosed(ctx, model.MustParseRequestID(msg.Child.Address().Local()))
 	case trialClosed:
 		e.trialClosed(ctx, msg.requestID)
+	case schedulingWindowTick:
+		e.checkSchedulingWindows(ctx)
+	case maxRuntimeExceeded:
+		if model.RunningStates[e.State] {
+			ctx.Log().Infof("stopping experiment that exceeded its max_runtime of %d seconds",
+				*e.Config.MaxRuntime())
+			// Trials are not killed, so that they checkpoint before they exit.
+			e.updateState(ctx, model.StoppingTimeoutState)
+		}
+	case *apiv1.GetSearcherEventsRequest:
+		events, err := e.searcher.CustomSearchEvents()
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		resp := &apiv1.GetSearcherEventsResponse{}
+		for _, event := range events {
+			resp.SearcherEvents = append(resp.SearcherEvents, event.ToProto())
+		}
+		ctx.Respond(resp)
+	case *apiv1.PostSearcherOperationsRequest:
+		if model.StoppingStates[e.State] {
+			ctx.Respond(status.Errorf(codes.FailedPrecondition,
+				"experiment in incompatible state %s", e.State))
+			return nil
+		}
+		ops, err := e.searcher.CustomSearchOperations(
+			int(msg.TriggeredByEventId), msg.Progress, msg.SearcherOperations)
+		if err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		e.processOperations(ctx, ops, nil)
+		e.saveProgress(ctx)
+		// The operations are not carried by a trial message, so the snapshot is saved here; the
+		// searcher is not snapshotted once it has shut down.
+		if e.faultToleranceEnabled && !e.searcher.Shutdown {
+			e.snapshotAndSave(ctx, trialSnapshot{})
+		}
+		ctx.Respond(&apiv1.PostSearcherOperationsResponse{})
 
 	case getTrial:
 		requestID, ok := e.searcher.RequestID(msg.trialID)
@@ -304,7 +410,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 
 	// Experiment shutdown logic.
 	case actor.PostStop:
-		if err := e.db.SaveExperimentProgress(e.ID, nil); err != nil {
+		if err := e.db.SaveExperimentProgress(e.ID, nil, nil); err != nil {
 			ctx.Log().Error(err)
 		}
 
@@ -326,12 +432,21 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 			taskSpec:       e.taskSpec,
 			rm:             e.rm,
 			db:             e.db,
+			vault:          e.vault,
+			ca:             e.ca,
 			experiment:     e.Experiment,
 		})
 
 		if e.State == model.CompletedState {
 			ctx.Tell(e.hpImportance, hpimportance.ExperimentCompleted{ID: e.ID})
 		}
+		ctx.Tell(e.webhooks, webhooks.ExperimentEnded{
+			ID:          e.ID,
+			Description: e.Config.Description().String(),
+			ProjectID:   e.ProjectID,
+			State:       e.State,
+			EndTime:     *e.EndTime,
+		})
 
 		if err := e.db.DeleteSnapshotsForExperiment(e.Experiment.ID); err != nil {
 			ctx.Log().WithError(err).Errorf(
@@ -341,6 +456,12 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 		ctx.Log().Info("experiment shut down successfully")
 
 	case *apiv1.ActivateExperimentRequest:
+		if e.State == model.PausedState && !e.Config.SchedulingWindows().Contains(time.Now()) {
+			// Outside of its scheduling windows, the experiment stays paused until the next one.
+			e.setPausedBySchedulingWindow(ctx, true)
+			ctx.Respond(&apiv1.ActivateExperimentResponse{})
+			return nil
+		}
 		switch ok := e.updateState(ctx, model.ActiveState); ok {
 		case true:
 			ctx.Respond(&apiv1.ActivateExperimentResponse{})
@@ -352,6 +473,7 @@ func (e *experiment) Receive(ctx *actor.Context) error {
 	case *apiv1.PauseExperimentRequest:
 		switch ok := e.updateState(ctx, model.PausedState); ok {
 		case true:
+			e.setPausedBySchedulingWindow(ctx, false)
 			ctx.Respond(&apiv1.PauseExperimentResponse{})
 		default:
 			ctx.Respond(status.Errorf(codes.FailedPrecondition,
@@ -542,6 +664,10 @@ func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
 	if err := e.db.SaveExperimentState(e.Experiment); err != nil {
 		ctx.Log().Errorf("error saving experiment state: %s", err)
 	}
+	if state != model.ActiveState {
+		e.ProgressHistory.interrupt()
+		e.saveProgress(ctx)
+	}
 	if e.canTerminate(ctx) {
 		ctx.Self().Stop()
 	}
@@ -549,6 +675,54 @@ func (e *experiment) updateState(ctx *actor.Context, state model.State) bool {
 	return true
 }
 
+// saveProgress records the progress of the searcher and stores it, along with when the experiment
+// is estimated to end if it is running, and returns the progress.
+func (e *experiment) saveProgress(ctx *actor.Context) float64 {
+	progress := e.searcher.Progress()
+	var eta *time.Time
+	if e.State == model.ActiveState {
+		e.ProgressHistory.record(time.Now(), progress)
+		eta = e.ProgressHistory.estimate()
+	}
+	if err := e.db.SaveExperimentProgress(e.ID, &progress, eta); err != nil {
+		ctx.Log().WithError(err).Error("failed to save experiment progress")
+	}
+	return progress
+}
+
+// checkSchedulingWindows pauses the experiment outside of its scheduling windows and resumes it in
+// them, if the windows paused it, until the experiment stops.
+func (e *experiment) checkSchedulingWindows(ctx *actor.Context) {
+	if model.StoppingStates[e.State] || model.TerminalStates[e.State] {
+		return
+	}
+	inWindow := e.Config.SchedulingWindows().Contains(time.Now())
+	switch {
+	case !inWindow && e.State == model.ActiveState:
+		ctx.Log().Info("pausing experiment outside of its scheduling windows")
+		if e.updateState(ctx, model.PausedState) {
+			e.setPausedBySchedulingWindow(ctx, true)
+		}
+	case inWindow && e.State == model.PausedState && e.PausedBySchedulingWindow:
+		ctx.Log().Info("resuming experiment in its scheduling windows")
+		if e.updateState(ctx, model.ActiveState) {
+			e.setPausedBySchedulingWindow(ctx, false)
+		}
+	}
+	actors.NotifyAfter(ctx, schedulingWindowCheckPeriod, schedulingWindowTick{})
+}
+
+func (e *experiment) setPausedBySchedulingWindow(ctx *actor.Context, paused bool) {
+	if e.PausedBySchedulingWindow == paused {
+		return
+	}
+	e.PausedBySchedulingWindow = paused
+	// The searcher is not snapshotted once it has shut down.
+	if e.faultToleranceEnabled && !e.searcher.Shutdown {
+		e.snapshotAndSave(ctx, trialSnapshot{})
+	}
+}
+
 func (e *experiment) canTerminate(ctx *actor.Context) bool {
 	return model.StoppingStates[e.State] && len(ctx.Children()) == 0
 }
@@ -567,6 +741,8 @@ func (e *experiment) Restore(experimentSnapshot json.RawMessage) error {
 	if err := json.Unmarshal(experimentSnapshot, &e.experimentState); err != nil {
 		return errors.Wrap(err, "failed to unmarshal experiment snapshot")
 	}
+	// The experiment did not run while the master was down.
+	e.ProgressHistory.interrupt()
 	if err := e.searcher.Restore(e.SearcherState); err != nil {
 		return errors.Wrap(err, "failed to restore searcher snapshot")
 	}
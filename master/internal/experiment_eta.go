@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"math"
+	"time"
+)
+
+// maxProgressSamples is how many of the most recent progress reports of an experiment its end time
+// is estimated from.
+const maxProgressSamples = 100
+
+type (
+	// progressHistory is the recent progress of an experiment, which estimates when the experiment
+	// will finish. Only the time the experiment spent running counts, so that pauses and restarts
+	// of the master do not slow the estimated rate of progress.
+	progressHistory struct {
+		Samples []progressSample `json:"samples"`
+		// ActiveSeconds is how long the experiment ran from its first sample to its last.
+		ActiveSeconds float64 `json:"active_seconds"`
+		// Interrupted is whether the experiment stopped running since its last sample.
+		Interrupted bool `json:"interrupted"`
+	}
+	// progressSample is the progress of an experiment after it ran for a number of seconds.
+	progressSample struct {
+		Time          time.Time `json:"time"`
+		ActiveSeconds float64   `json:"active_seconds"`
+		Progress      float64   `json:"progress"`
+	}
+)
+
+// record adds the progress of the experiment at a time to its history.
+func (h *progressHistory) record(now time.Time, progress float64) {
+	if n := len(h.Samples); n > 0 && !h.Interrupted {
+		if elapsed := now.Sub(h.Samples[n-1].Time).Seconds(); elapsed > 0 {
+			h.ActiveSeconds += elapsed
+		}
+	}
+	h.Interrupted = false
+	h.Samples = append(h.Samples, progressSample{
+		Time: now, ActiveSeconds: h.ActiveSeconds, Progress: progress,
+	})
+	if len(h.Samples) > maxProgressSamples {
+		h.Samples = h.Samples[len(h.Samples)-maxProgressSamples:]
+	}
+}
+
+// interrupt marks that the experiment stopped running, so that the time until its next sample does
+// not count toward its rate of progress.
+func (h *progressHistory) interrupt() {
+	h.Interrupted = true
+}
+
+// estimate returns when the experiment will finish at the rate of progress of its recent samples,
+// or nil if it made no progress over them.
+func (h *progressHistory) estimate() *time.Time {
+	if len(h.Samples) < 2 {
+		return nil
+	}
+	first, last := h.Samples[0], h.Samples[len(h.Samples)-1]
+	progress, seconds := last.Progress-first.Progress, last.ActiveSeconds-first.ActiveSeconds
+	if progress <= 0 || seconds <= 0 {
+		return nil
+	}
+	left := math.Max(0, 1-last.Progress)
+	remaining := time.Duration(left / progress * seconds * float64(time.Second))
+	eta := last.Time.Add(remaining)
+	return &eta
+}
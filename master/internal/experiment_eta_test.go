@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestProgressHistory(t *testing.T) {
+	start := time.Now()
+	var h progressHistory
+	h.record(start, 0.1)
+	assert.Assert(t, h.estimate() == nil)
+
+	// 10% of the experiment every minute leaves 8 minutes.
+	h.record(start.Add(time.Minute), 0.2)
+	assert.Equal(t, *h.estimate(), start.Add(9*time.Minute))
+
+	// The time that the experiment was paused does not slow its estimated progress.
+	h.interrupt()
+	h.record(start.Add(time.Hour), 0.2)
+	h.record(start.Add(time.Hour+time.Minute), 0.3)
+	assert.Equal(t, *h.estimate(), start.Add(time.Hour+8*time.Minute))
+
+	// Experiments that make no progress have no estimate.
+	var stalled progressHistory
+	stalled.record(start, 0.5)
+	stalled.record(start.Add(time.Minute), 0.5)
+	assert.Assert(t, stalled.estimate() == nil)
+}
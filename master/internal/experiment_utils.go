@@ -42,17 +42,22 @@ func checkpointFromTrialIDOrUUID(
 }
 
 // checkpointFromCheckpointMetrics converts a workload.CheckpointMetrics into a model.Checkpoint
-// with the UUID, and Resources fields filled out.
+// with the UUID, Resources and Digests fields filled out.
 func checkpointFromCheckpointMetrics(metrics workload.CheckpointMetrics) model.Checkpoint {
 	resources := model.JSONObj{}
 	for key, value := range metrics.Resources {
 		resources[key] = value
 	}
+	digests := model.JSONObj{}
+	for key, value := range metrics.Digests {
+		digests[key] = value
+	}
 
 	id := metrics.UUID.String()
 	return model.Checkpoint{
 		UUID:      &id,
 		Resources: resources,
+		Digests:   digests,
 		Framework: metrics.Framework,
 		Format:    metrics.Format,
 	}
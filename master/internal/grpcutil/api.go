@@ -18,14 +18,24 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/apilimits"
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/drain"
 	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 const jsonPretty = "application/json+pretty"
 
-// NewGRPCServer creates a Determined gRPC service.
-func NewGRPCServer(db *db.PgDB, srv proto.DeterminedServer) *grpc.Server {
+// NewGRPCServer creates a Determined gRPC service. Calls that change state are recorded in the
+// audit log unless auditLogger is nil, calls are subject to limits unless it is nil, and calls are
+// drained by the drainer unless it is nil. If strictTenancy is set, the users of tenants cannot
+// address the resources of other tenants.
+func NewGRPCServer(
+	db *db.PgDB, srv proto.DeterminedServer, auditLogger *audit.Logger, limits *apilimits.Limits,
+	drainer *drain.Drainer, strictTenancy bool,
+) *grpc.Server {
 	// In go-grpc, the INFO log level is used primarily for debugging
 	// purposes, so omit INFO messages from the master log.
 	logger := logrus.New()
@@ -38,12 +48,16 @@ func NewGRPCServer(db *db.PgDB, srv proto.DeterminedServer) *grpc.Server {
 		grpclogrus.WithLevels(grpcCodeToLogrusLevel),
 	}
 	grpcS := grpc.NewServer(
+		grpc.Creds(connStateCredentials{}),
 		grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(
 			grpclogrus.StreamServerInterceptor(logEntry, opts...),
 			grpcrecovery.StreamServerInterceptor(),
-			streamAuthInterceptor(db),
+			streamDrainInterceptor(drainer),
+			streamAuthInterceptor(db, limits),
+			streamTenancyInterceptor(db, strictTenancy),
 		)),
 		grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(
+			unaryTracingInterceptor,
 			grpclogrus.UnaryServerInterceptor(logEntry, opts...),
 			grpcrecovery.UnaryServerInterceptor(grpcrecovery.WithRecoveryHandler(
 				func(p interface{}) (err error) {
@@ -51,7 +65,10 @@ func NewGRPCServer(db *db.PgDB, srv proto.DeterminedServer) *grpc.Server {
 					return status.Errorf(codes.Internal, "%s", p)
 				},
 			)),
-			unaryAuthInterceptor(db),
+			unaryDrainInterceptor(drainer),
+			unaryAuditInterceptor(db, auditLogger),
+			unaryAuthInterceptor(db, limits),
+			unaryTenancyInterceptor(db, strictTenancy),
 		)),
 	)
 	proto.RegisterDeterminedServer(grpcS, srv)
@@ -97,6 +114,15 @@ func RegisterHTTPProxy(ctx context.Context, e *echo.Echo, port int, cert *tls.Ce
 				request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cookie.Value))
 			}
 		}
+		// The gateway passes on headers with this prefix as gRPC metadata, so setting the header
+		// also replaces any value that the client sent.
+		request.Header.Set(runtime.MetadataHeaderPrefix+gatewayClientHeader,
+			gatewaySecret+","+gatewayClientAddress(request))
+		request.Header.Set(runtime.MetadataHeaderPrefix+gatewayClientCertHeader,
+			gatewaySecret+","+ca.ClientID(ca.RequestState(request), ca.TaskClient))
+		if traceparent := request.Header.Get(traceparentHeader); traceparent != "" {
+			request.Header.Set(runtime.MetadataHeaderPrefix+traceparentHeader, traceparent)
+		}
 		if _, ok := request.URL.Query()["pretty"]; ok {
 			request.Header.Set("Accept", jsonPretty)
 		}
@@ -0,0 +1,64 @@
+package grpcutil
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/audit"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// unauditedMethods lists the API methods that change state but are left out of the audit log,
+// since tasks call them constantly to report on their progress.
+var unauditedMethods = map[string]bool{
+	"/determined.api.v1.Determined/ReportTaskStatus":                true,
+	"/determined.api.v1.Determined/RotateTaskToken":                 true,
+	"/determined.api.v1.Determined/PostTrialProfilerMetricsBatch":   true,
+	"/determined.api.v1.Determined/TrialPreemptionSignal":           true,
+	"/determined.api.v1.Determined/CompleteTrialSearcherValidation": true,
+	"/determined.api.v1.Determined/ReportTrialSearcherEarlyExit":    true,
+	"/determined.api.v1.Determined/ReportTrialProgress":             true,
+	"/determined.api.v1.Determined/ReportTrialTrainingMetrics":      true,
+	"/determined.api.v1.Determined/ReportTrialValidationMetrics":    true,
+	"/determined.api.v1.Determined/ReportTrialCheckpointMetadata":   true,
+	"/determined.api.v1.Determined/ReportCheckpointIntegrity":       true,
+	"/determined.api.v1.Determined/ReportBatchInferenceProgress":    true,
+}
+
+// unaryAuditInterceptor records the calls to API methods that change state, including those that
+// are rejected, in the audit log.
+func unaryAuditInterceptor(d *db.PgDB, logger *audit.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if logger == nil || isReadMethod(info.FullMethod) || unauditedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		name := methodName(info.FullMethod)
+		md, _ := metadata.FromIncomingContext(ctx)
+		entry := model.AuditEntry{
+			Address: clientAddress(ctx, md),
+			Method:  name,
+			Request: audit.RequestJSON(name, req),
+		}
+		// Identify the caller before the call, which may end their session.
+		if session, err := GetTaskSession(ctx, d); err == nil {
+			entry.TaskID = session.TaskID
+		} else if user, _, _, err := authenticateUser(ctx, d); err == nil {
+			entry.UserID = &user.ID
+			entry.Username = user.Username
+		} else {
+			entry.Username = audit.Username(entry.Request)
+		}
+
+		resp, err := handler(ctx, req)
+		entry.Outcome = status.Code(err).String()
+		logger.Record(entry)
+		return resp, err
+	}
+}
@@ -2,17 +2,27 @@ package grpcutil
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	// nolint:staticcheck // This is needed until grpc-gateway fully transitions.
 	"github.com/golang/protobuf/proto"
+	"github.com/labstack/echo/v4"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
+	"github.com/determined-ai/determined/master/internal/apilimits"
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -23,7 +33,12 @@ const (
 	gatewayTokenHeader = "grpcgateway-authorization"
 	taskTokenHeader    = "x-task-token"
 	userTokenHeader    = "x-user-token"
-	cookieName         = "auth"
+	// gatewayClientHeader carries the address of the client of a request through the gRPC gateway.
+	gatewayClientHeader = "determined-gateway-client"
+	// gatewayClientCertHeader carries the ID of the task whose certificate the client of a request
+	// through the gRPC gateway presented.
+	gatewayClientCertHeader = "determined-gateway-client-cert"
+	cookieName              = "auth"
 )
 
 var unauthenticatedMethods = map[string]bool{
@@ -32,8 +47,116 @@ var unauthenticatedMethods = map[string]bool{
 	"/determined.api.v1.Determined/GetTelemetry": true,
 }
 
-var adminMethods = map[string]bool{
-	"/determined.api.v1.Determined/DeleteExperiment": true,
+// methodPermissions maps API methods to the cluster-level permission users need to call them.
+// Methods that are not listed are open to every logged in user, except for the methods that create
+// and change experiments and tasks, which check the permissions of the user within the workspace
+// of the experiment or task in their handlers.
+var methodPermissions = map[string]model.Permission{
+	"/determined.api.v1.Determined/PutTemplate":            model.PermissionEditOwn,
+	"/determined.api.v1.Determined/DeleteTemplate":         model.PermissionEditOwn,
+	"/determined.api.v1.Determined/PostModel":              model.PermissionEditOwn,
+	"/determined.api.v1.Determined/PatchModel":             model.PermissionEditOwn,
+	"/determined.api.v1.Determined/PostModelVersion":       model.PermissionEditOwn,
+	"/determined.api.v1.Determined/PatchModelVersion":      model.PermissionEditOwn,
+	"/determined.api.v1.Determined/TransitionModelVersion": model.PermissionEditOwn,
+	"/determined.api.v1.Determined/PostCheckpointMetadata": model.PermissionEditOwn,
+	"/determined.api.v1.Determined/ImportCheckpoint":       model.PermissionEditOwn,
+
+	"/determined.api.v1.Determined/EnableAgent":     model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DisableAgent":    model.PermissionManageCluster,
+	"/determined.api.v1.Determined/EnableSlot":      model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DisableSlot":     model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PostWorkspace":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PatchWorkspace":  model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteWorkspace": model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PullImages":      model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DrainMaster":     model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PostBudget":      model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteBudget":    model.PermissionManageCluster,
+
+	// Reloading the config changes how the cluster schedules tasks.
+	"/determined.api.v1.Determined/ReloadMasterConfig": model.PermissionManageCluster,
+
+	// Resource pools decide where the agents of the cluster run tasks.
+	"/determined.api.v1.Determined/PostResourcePool":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PatchResourcePool":  model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteResourcePool": model.PermissionManageCluster,
+
+	// Config policies constrain what every user may launch.
+	"/determined.api.v1.Determined/PostConfigPolicy":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteConfigPolicy": model.PermissionManageCluster,
+
+	// Agent certificates let their holders join the cluster and run its tasks.
+	"/determined.api.v1.Determined/IssueAgentCertificate": model.PermissionManageCluster,
+
+	// Agent registration decides which hosts may join the cluster and run its tasks.
+	"/determined.api.v1.Determined/ApproveAgent":     model.PermissionManageCluster,
+	"/determined.api.v1.Determined/RevokeAgent":      model.PermissionManageCluster,
+	"/determined.api.v1.Determined/GetAgentTokens":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PostAgentToken":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteAgentToken": model.PermissionManageCluster,
+
+	// Rolling upgrades shut down the agents of the cluster.
+	"/determined.api.v1.Determined/PostAgentUpgrade":   model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteAgentUpgrade": model.PermissionManageCluster,
+
+	// Repartitioning the GPUs of an agent drains it and restarts it.
+	"/determined.api.v1.Determined/PostAgentMIGLayout": model.PermissionManageCluster,
+	"/determined.api.v1.Determined/GetAgentMIGLayout":  model.PermissionManageCluster,
+
+	// Backups hold the metadata of every user, including password hashes, and restoring them
+	// replaces it.
+	"/determined.api.v1.Determined/GetBackups":    model.PermissionManageCluster,
+	"/determined.api.v1.Determined/PostBackup":    model.PermissionManageCluster,
+	"/determined.api.v1.Determined/RestoreBackup": model.PermissionManageCluster,
+
+	// Tenants decide which users see and use which workspaces and resource pools.
+	"/determined.api.v1.Determined/PutTenant":    model.PermissionManageCluster,
+	"/determined.api.v1.Determined/DeleteTenant": model.PermissionManageCluster,
+
+	"/determined.api.v1.Determined/PutRole":      model.PermissionManageRoles,
+	"/determined.api.v1.Determined/DeleteRole":   model.PermissionManageRoles,
+	"/determined.api.v1.Determined/PutGroup":     model.PermissionManageRoles,
+	"/determined.api.v1.Determined/DeleteGroup":  model.PermissionManageRoles,
+	"/determined.api.v1.Determined/AssignRole":   model.PermissionManageRoles,
+	"/determined.api.v1.Determined/UnassignRole": model.PermissionManageRoles,
+
+	// Unlike the rest of the cluster, the audit log is only visible to those who manage it.
+	"/determined.api.v1.Determined/GetAuditLogs": model.PermissionManageCluster,
+
+	// The state of actors includes that of every user's tasks.
+	"/determined.api.v1.Determined/GetActors":     model.PermissionManageCluster,
+	"/determined.api.v1.Determined/GetActorState": model.PermissionManageCluster,
+}
+
+// readMethods lists the API methods that only read, besides those whose names start with Get,
+// which API tokens with the read scope may call.
+var readMethods = map[string]bool{
+	"/determined.api.v1.Determined/CurrentUser":                  true,
+	"/determined.api.v1.Determined/MasterLogs":                   true,
+	"/determined.api.v1.Determined/TrialLogs":                    true,
+	"/determined.api.v1.Determined/TrialLogsFields":              true,
+	"/determined.api.v1.Determined/SearchTrialLogs":              true,
+	"/determined.api.v1.Determined/NotebookLogs":                 true,
+	"/determined.api.v1.Determined/CodeServerLogs":               true,
+	"/determined.api.v1.Determined/RayClusterLogs":               true,
+	"/determined.api.v1.Determined/MetricNames":                  true,
+	"/determined.api.v1.Determined/MetricBatches":                true,
+	"/determined.api.v1.Determined/TrialsSnapshot":               true,
+	"/determined.api.v1.Determined/TrialsSample":                 true,
+	"/determined.api.v1.Determined/PreviewHPSearch":              true,
+	"/determined.api.v1.Determined/ResourceAllocationRaw":        true,
+	"/determined.api.v1.Determined/ResourceAllocationAggregated": true,
+	"/determined.api.v1.Determined/ResourceUsage":                true,
+	"/determined.api.v1.Determined/DownloadArtifact":             true,
+	"/determined.api.v1.Determined/StreamTaskStateChanges":       true,
+}
+
+// submitExperimentMethods lists the API methods that API tokens with the submit_experiments scope
+// may call.
+var submitExperimentMethods = map[string]bool{
+	"/determined.api.v1.Determined/CreateExperiment":   true,
+	"/determined.api.v1.Determined/ActivateExperiment": true,
 }
 
 var (
@@ -62,76 +185,292 @@ func GetTaskSession(ctx context.Context, d *db.PgDB) (*model.TaskSession, error)
 	}
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	switch session, err := d.TaskSessionByToken(token); err {
+	switch session, err := d.TaskSessionByToken(
+		token, clientAddress(ctx, md), clientTaskID(ctx, md),
+	); err {
 	case nil:
 		return session, nil
 	case db.ErrNotFound:
 		return nil, ErrInvalidCredentials
+	case db.ErrTaskTokenAddress, db.ErrTaskTokenCertificate:
+		return nil, status.Error(codes.PermissionDenied, err.Error())
 	default:
 		return nil, err
 	}
 }
 
-// GetUser returns the currently logged in user.
+// gatewaySecret authenticates the headers that the gRPC gateway of this process sets about the
+// client of a request, so that other clients cannot claim an address or certificate that is not
+// theirs.
+var gatewaySecret = func() string {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(secret)
+}()
+
+// gatewayValue returns the value of a header that the gRPC gateway of this process set on a
+// request. Requests through the gateway come from the master itself.
+func gatewayValue(ctx context.Context, md metadata.MD, header string) (string, bool) {
+	if ip := net.ParseIP(peerHost(ctx)); ip == nil || !ip.IsLoopback() {
+		return "", false
+	}
+	values := md[header]
+	if len(values) != 1 {
+		return "", false
+	}
+	parts := strings.SplitN(values[0], ",", 2)
+	if len(parts) != 2 ||
+		subtle.ConstantTimeCompare([]byte(parts[0]), []byte(gatewaySecret)) != 1 {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// peerHost returns the IP address of the peer of a request.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// clientAddress returns the IP address that a request comes from. Requests through the gRPC gateway
+// come from the master itself, so the gateway passes on the address of the original client.
+func clientAddress(ctx context.Context, md metadata.MD) string {
+	if address, ok := gatewayValue(ctx, md, gatewayClientHeader); ok {
+		return address
+	}
+	return peerHost(ctx)
+}
+
+// clientTaskID returns the ID of the task whose certificate the client of a request presented, or
+// "" if it presented none. The gateway passes on the certificate of the original client.
+func clientTaskID(ctx context.Context, md metadata.MD) string {
+	if taskID, ok := gatewayValue(ctx, md, gatewayClientCertHeader); ok {
+		return taskID
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return ca.ClientID(&info.State, ca.TaskClient)
+		}
+	}
+	return ""
+}
+
+// gatewayClientAddress returns the IP address of the client of a request to the gRPC gateway. The
+// X-Forwarded-For header is only trusted as far as it was set by proxies on the master's host.
+var gatewayClientAddress = echo.ExtractIPFromXFFHeader(
+	echo.TrustLinkLocal(false), echo.TrustPrivateNet(false),
+)
+
+// GetUser returns the currently logged in user. The session is nil if the user authenticated with
+// an API token.
 func GetUser(ctx context.Context, d *db.PgDB) (*model.User, *model.UserSession, error) {
+	user, session, _, err := authenticateUser(ctx, d)
+	return user, session, err
+}
+
+// authenticateUser returns the currently logged in user along with either their session or the API
+// token they authenticated with.
+func authenticateUser(
+	ctx context.Context, d *db.PgDB,
+) (*model.User, *model.UserSession, *model.APIToken, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, nil, ErrTokenMissing
+		return nil, nil, nil, ErrTokenMissing
 	}
 	tokens := md[userTokenHeader]
 	if len(tokens) == 0 {
 		tokens = md[gatewayTokenHeader]
 		if len(tokens) == 0 {
-			return nil, nil, ErrTokenMissing
+			return nil, nil, nil, ErrTokenMissing
 		}
 	}
 
 	token := tokens[0]
 	if !strings.HasPrefix(token, "Bearer ") {
-		return nil, nil, ErrInvalidCredentials
+		return nil, nil, nil, ErrInvalidCredentials
 	}
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	switch user, session, err := d.UserByToken(token); err {
+	var user *model.User
+	var session *model.UserSession
+	var apiToken *model.APIToken
+	var err error
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		user, apiToken, err = d.UserByAPIToken(token)
+	} else {
+		user, session, err = d.UserByToken(token)
+	}
+	switch err {
 	case nil:
 		if !user.Active {
-			return nil, nil, ErrPermissionDenied
+			return nil, nil, nil, ErrPermissionDenied
 		}
-		return user, session, nil
+		return user, session, apiToken, nil
 	case db.ErrNotFound:
-		return nil, nil, ErrInvalidCredentials
+		return nil, nil, nil, ErrInvalidCredentials
 	default:
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 }
 
-func streamAuthInterceptor(db *db.PgDB) grpc.StreamServerInterceptor {
+// checkTokenScope checks that the scopes of an API token allow calling a method. Requests
+// authenticated with a session rather than an API token pass.
+func checkTokenScope(token *model.APIToken, method string) error {
+	if token == nil || token.HasScope(model.TokenScopeAll) {
+		return nil
+	}
+	name := methodName(method)
+	if isReadMethod(method) && token.HasScope(model.TokenScopeRead) {
+		return nil
+	}
+	if token.HasScope(model.TokenScopeSubmitExperiments) && submitExperimentMethods[method] {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "the scopes of the API token do not allow %s", name)
+}
+
+// methodName returns the name of an API method without its service.
+func methodName(method string) string {
+	return method[strings.LastIndex(method, "/")+1:]
+}
+
+// isReadMethod returns true if an API method only reads.
+func isReadMethod(method string) bool {
+	return strings.HasPrefix(methodName(method), "Get") || readMethods[method]
+}
+
+func checkMethodPermission(d *db.PgDB, user *model.User, method string) error {
+	required, ok := methodPermissions[method]
+	if !ok {
+		return nil
+	}
+	permissions, err := d.UserPermissions(user, 0)
+	if err != nil {
+		return err
+	}
+	if !permissions.Has(required) {
+		return status.Errorf(codes.PermissionDenied,
+			"user %s does not have the %s permission", user.Username, required)
+	}
+	return nil
+}
+
+// checkTaskMethod returns an error if a request that carries a task token calls a method that
+// requires a permission. Task tokens are not tied to a user whose permissions could be checked.
+func checkTaskMethod(ctx context.Context, method string) error {
+	if _, ok := methodPermissions[method]; !ok {
+		return nil
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[taskTokenHeader]) > 0 {
+		return status.Errorf(codes.PermissionDenied, "tasks cannot call %s", method)
+	}
+	return nil
+}
+
+// checkAdminAddress returns an error if a method that manages the cluster, its users or their roles
+// is called from an address outside the admin allowlist.
+func checkAdminAddress(ctx context.Context, limits *apilimits.Limits, method string) error {
+	switch methodPermissions[method] {
+	case model.PermissionManageCluster, model.PermissionManageRoles:
+	default:
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if address := clientAddress(ctx, md); !limits.AllowAdmin(address) {
+		return status.Errorf(codes.PermissionDenied,
+			"%s cannot be called from %s", methodName(method), address)
+	}
+	return nil
+}
+
+// checkRateLimit returns an error if a caller has used up its rate limit for a method.
+func checkRateLimit(limits *apilimits.Limits, caller, method string) error {
+	if !limits.Allow(caller, methodName(method)) {
+		return status.Errorf(codes.ResourceExhausted,
+			"too many calls to %s; try again later", methodName(method))
+	}
+	return nil
+}
+
+// userCaller returns the caller that the requests of a user are rate limited as: the API token
+// that they authenticated with, if any, or else the user.
+func userCaller(user *model.User, apiToken *model.APIToken) string {
+	if apiToken != nil {
+		return fmt.Sprintf("token %d", apiToken.ID)
+	}
+	return fmt.Sprintf("user %d", user.ID)
+}
+
+func streamAuthInterceptor(db *db.PgDB, limits *apilimits.Limits) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
 	) error {
-		if _, _, err := GetUser(ss.Context(), db); err != nil {
+		if err := checkAdminAddress(ss.Context(), limits, info.FullMethod); err != nil {
+			return err
+		}
+		u, _, apiToken, err := authenticateUser(ss.Context(), db)
+		if err != nil {
+			return err
+		}
+		if err = checkTokenScope(apiToken, info.FullMethod); err != nil {
+			return err
+		}
+		if err = checkMethodPermission(db, u, info.FullMethod); err != nil {
+			return err
+		}
+		if err = checkRateLimit(limits, userCaller(u, apiToken), info.FullMethod); err != nil {
 			return err
 		}
 		return handler(srv, ss)
 	}
 }
 
-func unaryAuthInterceptor(db *db.PgDB) grpc.UnaryServerInterceptor {
+func unaryAuthInterceptor(db *db.PgDB, limits *apilimits.Limits) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
 	) (resp interface{}, err error) {
-		if !unauthenticatedMethods[info.FullMethod] {
-			if _, err = GetTaskSession(ctx, db); err == ErrTokenMissing {
-				switch u, _, uErr := GetUser(ctx, db); {
-				case uErr != nil:
+		if err = checkAdminAddress(ctx, limits, info.FullMethod); err != nil {
+			return nil, err
+		}
+		var caller string
+		if unauthenticatedMethods[info.FullMethod] {
+			md, _ := metadata.FromIncomingContext(ctx)
+			caller = "address " + clientAddress(ctx, md)
+		} else {
+			if err = checkTaskMethod(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			switch session, sErr := GetTaskSession(ctx, db); sErr {
+			case nil:
+				caller = "task " + session.TaskID
+			case ErrTokenMissing:
+				u, _, apiToken, uErr := authenticateUser(ctx, db)
+				if uErr != nil {
 					return nil, uErr
-				case !u.Admin && adminMethods[info.FullMethod]:
-					return nil, ErrPermissionDenied
 				}
-			} else if err != nil && err != ErrTokenMissing {
-				return nil, err
+				if err = checkTokenScope(apiToken, info.FullMethod); err != nil {
+					return nil, err
+				}
+				if err = checkMethodPermission(db, u, info.FullMethod); err != nil {
+					return nil, err
+				}
+				caller = userCaller(u, apiToken)
+			default:
+				return nil, sErr
 			}
 		}
+		if err = checkRateLimit(limits, caller, info.FullMethod); err != nil {
+			return nil, err
+		}
 		return handler(ctx, req)
 	}
 }
@@ -0,0 +1,139 @@
+package grpcutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/apilimits"
+)
+
+func TestUnaryAuthInterceptorRejectsTaskTokens(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(taskTokenHeader, "Bearer task-token"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/determined.api.v1.Determined/AssignRole"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		t.Fatal("the handler must not be called")
+		return nil, nil
+	}
+
+	_, err := unaryAuthInterceptor(nil, nil)(ctx, nil, info, handler)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	for method := range methodPermissions {
+		assert.Equal(t, status.Code(checkTaskMethod(ctx, method)), codes.PermissionDenied, method)
+	}
+	assert.NilError(t, checkTaskMethod(ctx, "/determined.api.v1.Determined/ReportTaskStatus"))
+	assert.NilError(t, checkTaskMethod(context.Background(), info.FullMethod))
+}
+
+func TestClientAddress(t *testing.T) {
+	fromPeer := func(addr string, md metadata.MD) string {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		assert.NilError(t, err)
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+		return clientAddress(ctx, md)
+	}
+	gateway := metadata.Pairs(gatewayClientHeader, gatewaySecret+",10.0.0.5")
+	forged := metadata.Pairs(gatewayClientHeader, "guess,10.0.0.5")
+
+	// Direct requests come from their peer, whatever they claim.
+	assert.Equal(t, fromPeer("10.0.0.7:4000", nil), "10.0.0.7")
+	assert.Equal(t, fromPeer("10.0.0.7:4000", gateway), "10.0.0.7")
+	assert.Equal(t, fromPeer("127.0.0.1:4000", forged), "127.0.0.1")
+	// Requests through the gateway come from the client that the gateway saw.
+	assert.Equal(t, fromPeer("127.0.0.1:4000", gateway), "10.0.0.5")
+}
+
+func TestClientTaskID(t *testing.T) {
+	fromPeer := func(addr string, leaf *x509.Certificate, md metadata.MD) string {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		assert.NilError(t, err)
+		p := &peer.Peer{Addr: tcpAddr}
+		if leaf != nil {
+			p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{leaf}},
+			}}
+		}
+		return clientTaskID(peer.NewContext(context.Background(), p), md)
+	}
+	cert := func(kind, id string) *x509.Certificate {
+		return &x509.Certificate{
+			Subject: pkix.Name{OrganizationalUnit: []string{kind}, CommonName: id},
+		}
+	}
+	task, agent := cert("task", "t1"), cert("agent", "a1")
+	gateway := metadata.Pairs(gatewayClientCertHeader, gatewaySecret+",t2")
+	forged := metadata.Pairs(gatewayClientCertHeader, "guess,t2")
+
+	assert.Equal(t, fromPeer("10.0.0.7:4000", task, nil), "t1")
+	assert.Equal(t, fromPeer("10.0.0.7:4000", agent, nil), "")
+	assert.Equal(t, fromPeer("10.0.0.7:4000", nil, nil), "")
+	// Only the gateway can pass on the certificate of its client.
+	assert.Equal(t, fromPeer("10.0.0.7:4000", nil, gateway), "")
+	assert.Equal(t, fromPeer("127.0.0.1:4000", nil, forged), "")
+	assert.Equal(t, fromPeer("127.0.0.1:4000", nil, gateway), "t2")
+	assert.Equal(t, fromPeer("127.0.0.1:4000", nil,
+		metadata.Pairs(gatewayClientCertHeader, gatewaySecret+",")), "")
+}
+
+func TestGatewayClientAddress(t *testing.T) {
+	request := func(remoteAddr, forwardedFor string) string {
+		req := httptest.NewRequest("GET", "/api/v1/master", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return gatewayClientAddress(req)
+	}
+
+	// Direct requests.
+	assert.Equal(t, request("10.0.0.7:4000", ""), "10.0.0.7")
+	assert.Equal(t, request("10.0.0.7:4000", "10.0.0.5"), "10.0.0.7")
+	// Requests through a proxy on the master's host, which appends the address of its client.
+	assert.Equal(t, request("127.0.0.1:4000", "10.0.0.5"), "10.0.0.5")
+	assert.Equal(t, request("127.0.0.1:4000", "1.2.3.4, 10.0.0.5"), "10.0.0.5")
+}
+
+func TestCheckAdminAddress(t *testing.T) {
+	limits, err := apilimits.New(apilimits.Config{AdminAllowlist: []string{"10.0.0.0/8"}})
+	assert.NilError(t, err)
+	from := func(addr string) context.Context {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		assert.NilError(t, err)
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+	}
+	const admin = "/determined.api.v1.Determined/DisableAgent"
+
+	assert.NilError(t, checkAdminAddress(from("10.0.0.7:4000"), limits, admin))
+	assert.Equal(t, status.Code(checkAdminAddress(from("192.168.0.1:4000"), limits, admin)),
+		codes.PermissionDenied)
+	assert.NilError(t, checkAdminAddress(from("192.168.0.1:4000"), limits,
+		"/determined.api.v1.Determined/GetExperiments"))
+	assert.NilError(t, checkAdminAddress(from("192.168.0.1:4000"), nil, admin))
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	limits, err := apilimits.New(apilimits.Config{RateLimit: apilimits.RateLimitConfig{
+		Limit: apilimits.Limit{RequestsPerSecond: 1},
+	}})
+	assert.NilError(t, err)
+	const method = "/determined.api.v1.Determined/GetExperiments"
+
+	assert.NilError(t, checkRateLimit(limits, "user 1", method))
+	assert.Equal(t, status.Code(checkRateLimit(limits, "user 1", method)), codes.ResourceExhausted)
+	assert.NilError(t, checkRateLimit(limits, "user 2", method))
+	assert.NilError(t, checkRateLimit(nil, "user 1", method))
+}
@@ -0,0 +1,45 @@
+package grpcutil
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/determined-ai/determined/master/internal/ca"
+)
+
+// connStateCredentials passes on the TLS state of the connections that the gRPC server is handed.
+// The master's listener terminates TLS before connections reach the server, so the server would not
+// otherwise see the client certificates of its peers.
+type connStateCredentials struct{}
+
+func (connStateCredentials) ClientHandshake(
+	context.Context, string, net.Conn,
+) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("the master's gRPC credentials are only for serving")
+}
+
+func (connStateCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	state := ca.ConnState(conn)
+	if state == nil {
+		return conn, nil, nil
+	}
+	return conn, credentials.TLSInfo{
+		State:          *state,
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+	}, nil
+}
+
+func (connStateCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls"}
+}
+
+func (c connStateCredentials) Clone() credentials.TransportCredentials {
+	return c
+}
+
+func (connStateCredentials) OverrideServerName(string) error {
+	return nil
+}
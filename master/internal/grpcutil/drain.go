@@ -0,0 +1,54 @@
+package grpcutil
+
+import (
+	"context"
+
+	grpcmiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/drain"
+)
+
+// launchMethods are the API methods that launch tasks, which are rejected while the master drains.
+var launchMethods = map[string]bool{
+	"/determined.api.v1.Determined/CreateExperiment":            true,
+	"/determined.api.v1.Determined/LaunchCommand":               true,
+	"/determined.api.v1.Determined/LaunchNotebook":              true,
+	"/determined.api.v1.Determined/LaunchShell":                 true,
+	"/determined.api.v1.Determined/LaunchTensorboard":           true,
+	"/determined.api.v1.Determined/LaunchCodeServer":            true,
+	"/determined.api.v1.Determined/LaunchRayCluster":            true,
+	"/determined.api.v1.Determined/ForkExperiment":              true,
+	"/determined.api.v1.Determined/LaunchServing":               true,
+	"/determined.api.v1.Determined/VerifyExperimentCheckpoints": true,
+	"/determined.api.v1.Determined/LaunchBatchInferenceJob":     true,
+}
+
+var errDraining = status.Error(codes.Unavailable, drain.LaunchRejection)
+
+func unaryDrainInterceptor(drainer *drain.Drainer) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if launchMethods[info.FullMethod] && drainer.Draining() {
+			return nil, errDraining
+		}
+		ctx, done := drainer.Track(ctx)
+		defer done()
+		return handler(ctx, req)
+	}
+}
+
+func streamDrainInterceptor(drainer *drain.Drainer) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		wrapped := grpcmiddleware.WrapServerStream(ss)
+		var done func()
+		wrapped.WrappedContext, done = drainer.Track(ss.Context())
+		defer done()
+		return handler(srv, wrapped)
+	}
+}
@@ -0,0 +1,39 @@
+package grpcutil
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"gotest.tools/assert"
+
+	proto "github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// unimplementedServer is a DeterminedServer whose methods are all unimplemented.
+type unimplementedServer struct {
+	proto.DeterminedServer
+}
+
+// TestLaunchMethods checks that every method of the API that launches tasks, which are named
+// Launch*, Create* or Fork*, is rejected while the master drains.
+func TestLaunchMethods(t *testing.T) {
+	server := grpc.NewServer()
+	proto.RegisterDeterminedServer(server, unimplementedServer{})
+	service := "determined.api.v1.Determined"
+	methods := make(map[string]bool)
+	for _, method := range server.GetServiceInfo()[service].Methods {
+		fullMethod := "/" + service + "/" + method.Name
+		methods[fullMethod] = true
+		for _, prefix := range []string{"Launch", "Create", "Fork"} {
+			if strings.HasPrefix(method.Name, prefix) {
+				assert.Assert(t, launchMethods[fullMethod], "%s is not rejected while draining",
+					fullMethod)
+			}
+		}
+	}
+	assert.Assert(t, len(methods) > 0)
+	for fullMethod := range launchMethods {
+		assert.Assert(t, methods[fullMethod], "%s is not a method of the API", fullMethod)
+	}
+}
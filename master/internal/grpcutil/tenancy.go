@@ -0,0 +1,193 @@
+package grpcutil
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// tenantAdminMethods are the methods that manage the cluster which the admins of tenants may call
+// in strict tenancy, since their handlers keep to the tenants of their callers.
+var tenantAdminMethods = map[string]bool{
+	"/determined.api.v1.Determined/PostWorkspace":   true,
+	"/determined.api.v1.Determined/PatchWorkspace":  true,
+	"/determined.api.v1.Determined/DeleteWorkspace": true,
+}
+
+// tenantWideMethods are the methods that read about every tenant without filtering by them, which
+// only callers who see every tenant may call in strict tenancy.
+var tenantWideMethods = map[string]bool{
+	"/determined.api.v1.Determined/MasterLogs":                   true,
+	"/determined.api.v1.Determined/ResourceAllocationRaw":        true,
+	"/determined.api.v1.Determined/ResourceAllocationAggregated": true,
+	"/determined.api.v1.Determined/ResourceUsage":                true,
+	"/determined.api.v1.Determined/GetBudgets":                   true,
+}
+
+// checkTenancyMethod returns an error if a caller who only sees some tenants calls a method that
+// reaches every tenant: one that manages the whole cluster or reads about every tenant.
+func checkTenancyMethod(scope model.TenantScope, method string) error {
+	if scope.All {
+		return nil
+	}
+	switch permission := methodPermissions[method]; {
+	case tenantWideMethods[method],
+		permission == model.PermissionManageCluster && !tenantAdminMethods[method],
+		permission == model.PermissionManageRoles:
+		return status.Errorf(codes.PermissionDenied,
+			"%s is not available to the users of tenants", methodName(method))
+	}
+	return nil
+}
+
+// requestResources returns the resources that a request identifies, by their kinds. Requests
+// identify resources by fields that are named alike across the API.
+func requestResources(req interface{}) map[db.TenantResource]interface{} {
+	resources := make(map[db.TenantResource]interface{})
+	if r, ok := req.(interface{ GetUsername() string }); ok && r.GetUsername() != "" {
+		resources[db.TenantUser] = r.GetUsername()
+	}
+	if r, ok := req.(interface{ GetWorkspaceId() int32 }); ok && r.GetWorkspaceId() != 0 {
+		resources[db.TenantWorkspace] = r.GetWorkspaceId()
+	}
+	if r, ok := req.(interface{ GetProjectId() int32 }); ok && r.GetProjectId() != 0 {
+		resources[db.TenantProject] = r.GetProjectId()
+	}
+	if r, ok := req.(interface{ GetExperimentId() int32 }); ok && r.GetExperimentId() != 0 {
+		resources[db.TenantExperiment] = r.GetExperimentId()
+	}
+	if r, ok := req.(interface{ GetTrialId() int32 }); ok && r.GetTrialId() != 0 {
+		resources[db.TenantTrial] = r.GetTrialId()
+	}
+	if r, ok := req.(interface{ GetCheckpointUuid() string }); ok && r.GetCheckpointUuid() != "" {
+		resources[db.TenantCheckpoint] = r.GetCheckpointUuid()
+	}
+	var taskID string
+	switch r := req.(type) {
+	case interface{ GetTaskId() string }:
+		taskID = r.GetTaskId()
+	case interface{ GetCommandId() string }:
+		taskID = r.GetCommandId()
+	case interface{ GetNotebookId() string }:
+		taskID = r.GetNotebookId()
+	case interface{ GetShellId() string }:
+		taskID = r.GetShellId()
+	case interface{ GetTensorboardId() string }:
+		taskID = r.GetTensorboardId()
+	}
+	if taskID != "" {
+		resources[db.TenantTask] = taskID
+	}
+	return resources
+}
+
+// checkTenancy returns a NotFound error if a request identifies a resource of a tenant outside the
+// scope of its caller, as if the resource did not exist. Resources that do not exist are
+// left to the handler of the request.
+func checkTenancy(d *db.PgDB, scope model.TenantScope, req interface{}) error {
+	if scope.All {
+		return nil
+	}
+	for resource, id := range requestResources(req) {
+		tenantID, err := d.ResourceTenant(resource, id)
+		switch {
+		case errors.Cause(err) == db.ErrNotFound:
+			continue
+		case err != nil:
+			return err
+		case !scope.Contains(tenantID):
+			return status.Errorf(codes.NotFound, "%s not found: %v", resource, id)
+		}
+	}
+	return nil
+}
+
+// tenancyServerStream checks each request that a stream receives.
+type tenancyServerStream struct {
+	grpc.ServerStream
+	d     *db.PgDB
+	scope model.TenantScope
+}
+
+func (s tenancyServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkTenancy(s.d, s.scope, m)
+}
+
+// GetTenantScope returns the tenants whose resources the caller of a request may see: those of the
+// user, or for tasks, those of the tenant of the task.
+func GetTenantScope(ctx context.Context, d *db.PgDB, strict bool) (model.TenantScope, error) {
+	if !strict {
+		return model.TenantScope{All: true}, nil
+	}
+	switch session, err := GetTaskSession(ctx, d); err {
+	case nil:
+		tenantID, err := d.ResourceTenant(db.TenantTask, session.TaskID)
+		if err != nil && errors.Cause(err) != db.ErrNotFound {
+			return model.TenantScope{}, err
+		}
+		return model.TenantScope{TenantID: tenantID}, nil
+	case ErrTokenMissing:
+		user, _, err := GetUser(ctx, d)
+		if err != nil {
+			return model.TenantScope{}, err
+		}
+		return model.NewTenantScope(user, strict), nil
+	default:
+		return model.TenantScope{}, err
+	}
+}
+
+// streamTenancyInterceptor hides the resources of other tenants from the users of tenants when
+// tenant isolation is strict.
+func streamTenancyInterceptor(d *db.PgDB, strict bool) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if !strict {
+			return handler(srv, ss)
+		}
+		scope, err := GetTenantScope(ss.Context(), d, strict)
+		if err != nil {
+			return err
+		}
+		if err = checkTenancyMethod(scope, info.FullMethod); err != nil {
+			return err
+		}
+		if scope.All {
+			return handler(srv, ss)
+		}
+		return handler(srv, tenancyServerStream{ServerStream: ss, d: d, scope: scope})
+	}
+}
+
+// unaryTenancyInterceptor hides the resources of other tenants from the users of tenants when
+// tenant isolation is strict.
+func unaryTenancyInterceptor(d *db.PgDB, strict bool) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !strict || unauthenticatedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		scope, err := GetTenantScope(ctx, d, strict)
+		if err != nil {
+			return nil, err
+		}
+		if err = checkTenancyMethod(scope, info.FullMethod); err != nil {
+			return nil, err
+		}
+		if err = checkTenancy(d, scope, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,40 @@
+package grpcutil
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/tracing"
+)
+
+// traceparentHeader carries the W3C trace context of callers that trace their own requests.
+const traceparentHeader = "traceparent"
+
+// unaryTracingInterceptor starts the traces of API calls, continuing the traces of callers that
+// send a trace context. Streaming calls are not traced since they last as long as their clients
+// follow logs or events.
+func unaryTracingInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(traceparentHeader); len(values) > 0 {
+			ctx = tracing.ContextWithSpanContext(ctx, tracing.ParseTraceparent(values[0]))
+		}
+	}
+	name := strings.TrimPrefix(info.FullMethod, "/")
+	ctx, span := tracing.Start(ctx, name, tracing.KindServer)
+	defer span.End()
+	span.SetAttribute("rpc.system", "grpc")
+	method := methodName(info.FullMethod)
+	span.SetAttribute("rpc.service", strings.TrimSuffix(name, "/"+method))
+	span.SetAttribute("rpc.method", method)
+
+	resp, err := handler(ctx, req)
+	span.SetAttribute("rpc.grpc.status_code", int(status.Code(err)))
+	span.SetError(err)
+	return resp, err
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// healthCheckTimeout is how long each subsystem of the master has to answer a health check.
+const healthCheckTimeout = 5 * time.Second
+
+// Health statuses of the master and its subsystems.
+const (
+	healthOK     = "ok"
+	healthFailed = "failed"
+)
+
+// subsystemHealth is the outcome of the health check of a subsystem of the master.
+type subsystemHealth struct {
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// healthReport is the health of the master: ok if every subsystem that it checked is.
+type healthReport struct {
+	Status     string                     `json:"status"`
+	Subsystems map[string]subsystemHealth `json:"subsystems"`
+}
+
+// healthCheck checks the health of a subsystem, returning an error if it is unhealthy.
+type healthCheck func(ctx context.Context) error
+
+// pingActor returns a health check that an actor answers a ping within the timeout, which it only
+// does once it handled the messages before it.
+func pingActor(system *actor.System, ref *actor.Ref) healthCheck {
+	return func(ctx context.Context) error {
+		if ref == nil {
+			return errors.New("not running")
+		}
+		deadline, _ := ctx.Deadline()
+		if _, ok := system.Ask(ref, actor.Ping{}).GetOrTimeout(time.Until(deadline)); !ok {
+			return errors.New("did not respond in time")
+		}
+		return nil
+	}
+}
+
+// livenessChecks are the checks of the subsystems that the master cannot recover without a
+// restart.
+func (m *Master) livenessChecks() map[string]healthCheck {
+	return map[string]healthCheck{
+		"actor_system": pingActor(m.system, m.system.Ref),
+	}
+}
+
+// readinessChecks are the checks of the subsystems that the master needs to serve requests.
+func (m *Master) readinessChecks() map[string]healthCheck {
+	checks := m.livenessChecks()
+	checks["database"] = m.db.Ping
+	checks["resource_manager"] = pingActor(m.system, m.rm)
+	checks["proxy"] = pingActor(m.system, m.proxy)
+	return checks
+}
+
+// checkHealth runs health checks concurrently.
+func checkHealth(checks map[string]healthCheck) healthReport {
+	report := healthReport{Status: healthOK, Subsystems: map[string]subsystemHealth{}}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			health := subsystemHealth{
+				Status:    healthOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				health.Status = healthFailed
+				health.Error = err.Error()
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			report.Subsystems[name] = health
+			if err != nil {
+				report.Status = healthFailed
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+// respondHealth responds with a health report, with status 503 if the master is unhealthy.
+func respondHealth(c echo.Context, report healthReport) error {
+	if report.Status != healthOK {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// getHealthz reports whether the master is alive, for liveness probes.
+func (m *Master) getHealthz(c echo.Context) error {
+	return respondHealth(c, checkHealth(m.livenessChecks()))
+}
+
+// getReadyz reports whether the master can serve requests, for load balancers and readiness
+// probes.
+func (m *Master) getReadyz(c echo.Context) error {
+	return respondHealth(c, checkHealth(m.readinessChecks()))
+}
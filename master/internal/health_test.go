@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+)
+
+func TestCheckHealth(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failed := func(context.Context) error { return errors.New("unreachable") }
+
+	report := checkHealth(map[string]healthCheck{"a": ok, "b": ok})
+	assert.Equal(t, report.Status, healthOK)
+	assert.Equal(t, len(report.Subsystems), 2)
+
+	report = checkHealth(map[string]healthCheck{"a": ok, "b": failed})
+	assert.Equal(t, report.Status, healthFailed)
+	assert.Equal(t, report.Subsystems["a"].Status, healthOK)
+	assert.Equal(t, report.Subsystems["b"].Status, healthFailed)
+	assert.Equal(t, report.Subsystems["b"].Error, "unreachable")
+}
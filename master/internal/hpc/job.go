@@ -0,0 +1,365 @@
+package hpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/agent"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	// commandTimeout bounds how long the workload manager's commands may take, since they block
+	// the job actor.
+	commandTimeout = 30 * time.Second
+)
+
+type pollJob struct{}
+
+// job submits the batch job that runs a task container and follows it by polling the workload
+// manager until the job exits, forwarding the job's output as container logs.
+type job struct {
+	config          Config
+	wm              workloadManager
+	masterTLSConfig model.TLSClientConfig
+	taskActor       *actor.Ref
+	taskSpec        tasks.TaskSpec
+	slots           int
+
+	name       string
+	dir        string
+	jobID      string
+	container  cproto.Container
+	lastReason string
+	logOffset  int64
+	canceled   bool
+	exited     bool
+	err        error
+}
+
+func newJob(
+	msg sproto.StartHPCJob, config Config, wm workloadManager, masterTLSConfig model.TLSClientConfig,
+) *job {
+	name := "det-" + msg.Spec.ContainerID
+	return &job{
+		config:          config,
+		wm:              wm,
+		masterTLSConfig: masterTLSConfig,
+		taskActor:       msg.TaskActor,
+		taskSpec:        msg.Spec,
+		slots:           msg.Slots,
+		name:            name,
+		dir:             filepath.Join(config.JobStorageRoot, name),
+		container: cproto.Container{
+			Parent: msg.TaskActor.Address(),
+			ID:     cproto.ID(msg.Spec.ContainerID),
+			State:  cproto.Assigned,
+		},
+	}
+}
+
+func (j *job) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		ctx.AddLabel("job", j.name)
+		if err := j.submit(ctx); err != nil {
+			ctx.Log().WithError(err).Error("error submitting job")
+			j.insertLog(ctx, err.Error())
+			j.err = err
+			ctx.Self().Stop()
+			return nil
+		}
+		actors.NotifyAfter(ctx, pollInterval, pollJob{})
+
+	case pollJob:
+		j.poll(ctx)
+
+	case sproto.KillHPCJob:
+		ctx.Log().Info("received request to cancel job")
+		j.cancel(ctx)
+
+	case actor.PostStop:
+		j.finalizeTaskState(ctx)
+		if !j.exited {
+			j.cancel(ctx)
+		}
+		if err := os.RemoveAll(j.dir); err != nil {
+			ctx.Log().WithError(err).Warnf("error removing job directory %s", j.dir)
+		}
+
+	default:
+		ctx.Log().Errorf("unexpected message %T", msg)
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (j *job) outputPath() string {
+	return filepath.Join(j.dir, "output.log")
+}
+
+func (j *job) submit(ctx *actor.Context) error {
+	spec := j.taskSpec
+	// The job is granted whole GPUs, which the container sees as its own devices.
+	for i := 0; i < j.slots; i++ {
+		spec.Devices = append(spec.Devices, device.Device{ID: i, Type: device.GPU})
+	}
+	containerSpec := tasks.ToContainerSpec(spec)
+
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return errors.Wrapf(err, "error creating job directory %s", j.dir)
+	}
+	binds, err := stageArchives(j.dir, containerSpec.RunSpec.Archives)
+	if err != nil {
+		return err
+	}
+	binds = append(binds, mountBinds(containerSpec.RunSpec.HostConfig.Mounts)...)
+
+	script, err := jobScript(j.wm, jobSpec{
+		name:             j.name,
+		dir:              j.dir,
+		outputPath:       j.outputPath(),
+		slots:            j.slots,
+		partition:        j.config.Partition,
+		submitArgs:       j.config.SubmitArgs,
+		containerRuntime: j.config.ContainerRuntime,
+		image:            containerSpec.RunSpec.ContainerConfig.Image,
+		registryAuth:     containerSpec.PullSpec.Registry,
+		entrypoint:       containerSpec.RunSpec.ContainerConfig.Cmd,
+		workDir:          containerSpec.RunSpec.ContainerConfig.WorkingDir,
+		env:              append(containerSpec.RunSpec.ContainerConfig.Env, j.envVars()...),
+		binds:            binds,
+	})
+	if err != nil {
+		return err
+	}
+	// The script holds the task's environment, which may include secrets, so only the master's
+	// user may read it.
+	scriptPath := filepath.Join(j.dir, "job.sh")
+	if err = ioutil.WriteFile(scriptPath, []byte(script), 0700); err != nil {
+		return errors.Wrapf(err, "error writing job script %s", scriptPath)
+	}
+
+	cmdCtx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if j.jobID, err = j.wm.submit(cmdCtx, scriptPath); err != nil {
+		return errors.Wrap(err, "error submitting job")
+	}
+	ctx.Log().Infof("submitted job %s", j.jobID)
+	j.insertLog(ctx, fmt.Sprintf("submitted %s job %s", j.config.WorkloadManager, j.jobID))
+	return nil
+}
+
+// envVars returns the environment variables that the agent would otherwise set for the container.
+func (j *job) envVars() []string {
+	var slotIDs []string
+	for i := 0; i < j.slots; i++ {
+		slotIDs = append(slotIDs, strconv.Itoa(i))
+	}
+	envVars := []string{
+		"DET_CLUSTER_ID=" + j.taskSpec.ClusterID,
+		fmt.Sprintf("DET_MASTER=%s:%d", j.config.MasterHost, j.config.MasterPort),
+		"DET_MASTER_HOST=" + j.config.MasterHost,
+		"DET_MASTER_ADDR=" + j.config.MasterHost,
+		fmt.Sprintf("DET_MASTER_PORT=%d", j.config.MasterPort),
+		"DET_AGENT_ID=" + j.name,
+		"DET_CONTAINER_ID=" + j.taskSpec.ContainerID,
+		fmt.Sprintf("DET_SLOT_IDS=[%s]", strings.Join(slotIDs, ",")),
+		fmt.Sprintf("DET_USE_GPU=%t", j.slots > 0),
+	}
+	if j.masterTLSConfig.CertificateName != "" {
+		envVars = append(envVars, "DET_MASTER_CERT_NAME="+j.masterTLSConfig.CertificateName)
+	}
+	return envVars
+}
+
+func (j *job) poll(ctx *actor.Context) {
+	cmdCtx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	status, err := j.wm.status(cmdCtx, j.jobID)
+	if err != nil {
+		// The workload manager may be briefly unavailable, so keep trying.
+		ctx.Log().WithError(err).Warnf("error checking the status of job %s", j.jobID)
+		j.readOutput(ctx, false)
+		actors.NotifyAfter(ctx, pollInterval, pollJob{})
+		return
+	}
+
+	switch status.state {
+	case jobPending:
+		if status.reason != "" && status.reason != j.lastReason {
+			j.insertLog(ctx, fmt.Sprintf("job %s is pending: %s", j.jobID, status.reason))
+		}
+		j.lastReason = status.reason
+
+	case jobRunning:
+		j.readOutput(ctx, false)
+		if j.container.State != cproto.Assigned {
+			break
+		}
+		// The container runtime pulls the image once the job runs, which is not observable from
+		// here, so the job is reported to pass through every state at once.
+		for _, state := range []cproto.State{cproto.Pulling, cproto.Starting, cproto.Running} {
+			ctx.Log().Infof("transitioning job state from %s to %s", j.container.State, state)
+			j.container = j.container.Transition(state)
+			if state != cproto.Running {
+				ctx.Tell(j.taskActor, sproto.TaskContainerStateChanged{Container: j.container})
+			}
+		}
+		// Containers share the network of the node that they run on.
+		var addresses []cproto.Address
+		for _, port := range j.taskSpec.Environment().Ports() {
+			addresses = append(addresses, cproto.Address{
+				ContainerIP:   status.host,
+				ContainerPort: port,
+				HostIP:        status.host,
+				HostPort:      port,
+			})
+		}
+		ctx.Tell(j.taskActor, sproto.TaskContainerStateChanged{
+			Container:        j.container,
+			ContainerStarted: &sproto.TaskContainerStarted{Addresses: addresses},
+		})
+
+	case jobExited:
+		j.readOutput(ctx, true)
+		j.receiveJobExited(ctx, status)
+		return
+	}
+	actors.NotifyAfter(ctx, pollInterval, pollJob{})
+}
+
+func (j *job) receiveJobExited(ctx *actor.Context, status jobStatus) {
+	j.exited = true
+	ctx.Log().Infof("transitioning job state from %s to %s", j.container.State, cproto.Terminated)
+	j.container = j.container.Transition(cproto.Terminated)
+
+	stopped := sproto.TaskContainerStopped{}
+	if status.exitCode == agent.SuccessExitCode {
+		ctx.Log().Infof("job exited successfully")
+	} else {
+		ctx.Log().Infof("job failed with exit code %d %s", status.exitCode, status.reason)
+		if status.reason != "" {
+			j.insertLog(ctx, fmt.Sprintf("job %s ended: %s", j.jobID, status.reason))
+		}
+		exitCode := agent.ExitCode(status.exitCode)
+		failureType := agent.ContainerFailed
+		if status.preempted {
+			failureType = agent.ContainerPreempted
+		}
+		stopped.ContainerStopped.Failure = &agent.ContainerFailure{
+			FailureType: failureType,
+			ErrMsg:      status.reason,
+			ExitCode:    &exitCode,
+		}
+	}
+	ctx.Tell(j.taskActor, sproto.TaskContainerStateChanged{
+		Container:        j.container,
+		ContainerStopped: &stopped,
+	})
+	ctx.Self().Stop()
+}
+
+func (j *job) cancel(ctx *actor.Context) {
+	if j.canceled || j.exited {
+		return
+	}
+	j.canceled = true
+	if j.jobID == "" {
+		ctx.Self().Stop()
+		return
+	}
+
+	cmdCtx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	if err := j.wm.cancel(cmdCtx, j.jobID); err != nil {
+		ctx.Log().WithError(err).Errorf("error canceling job %s", j.jobID)
+		return
+	}
+	ctx.Log().Infof("canceled job %s", j.jobID)
+}
+
+// readOutput forwards the output that the job has written since it was last read. Until the job
+// exits, an incomplete last line is left for the next read.
+func (j *job) readOutput(ctx *actor.Context, final bool) {
+	f, err := os.Open(j.outputPath())
+	if err != nil {
+		// The output file is only created once the job starts.
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err = f.Seek(j.logOffset, io.SeekStart); err != nil {
+		ctx.Log().WithError(err).Warn("error reading job output")
+		return
+	}
+	output, err := ioutil.ReadAll(f)
+	if err != nil {
+		ctx.Log().WithError(err).Warn("error reading job output")
+		return
+	}
+
+	end := bytes.LastIndexByte(output, '\n') + 1
+	if final {
+		end = len(output)
+	}
+	for _, line := range strings.SplitAfter(string(output[:end]), "\n") {
+		if line == "" {
+			continue
+		}
+		ctx.Tell(j.taskActor, sproto.ContainerLog{
+			Container: j.container,
+			Timestamp: time.Now().UTC(),
+			RunMessage: &agent.RunMessage{
+				Value:   line,
+				StdType: stdcopy.Stdout,
+			},
+		})
+	}
+	j.logOffset += int64(end)
+}
+
+func (j *job) insertLog(ctx *actor.Context, msg string) {
+	ctx.Tell(j.taskActor, sproto.ContainerLog{
+		Container:  j.container,
+		Timestamp:  time.Now().UTC(),
+		AuxMessage: &msg,
+	})
+}
+
+func (j *job) finalizeTaskState(ctx *actor.Context) {
+	if j.container.State == cproto.Terminated {
+		return
+	}
+	ctx.Log().Warnf("updating container state after job actor exited unexpectedly")
+	j.container = j.container.Transition(cproto.Terminated)
+	err := j.err
+	if err == nil {
+		err = errors.New("job actor exited while the job was running")
+	}
+	ctx.Tell(j.taskActor, sproto.TaskContainerStateChanged{
+		Container: j.container,
+		ContainerStopped: &sproto.TaskContainerStopped{
+			ContainerStopped: agent.ContainerError(agent.TaskError, err),
+		},
+	})
+}
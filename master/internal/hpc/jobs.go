@@ -0,0 +1,117 @@
+// Package hpc runs tasks as batch jobs of an HPC workload manager such as Slurm or PBS, so that
+// Determined can share a cluster with the site's other workloads. Each task container runs in a
+// Singularity (or Apptainer) container inside a job of its own.
+package hpc
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/check"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Config configures how the jobs of tasks are submitted and run.
+type Config struct {
+	// WorkloadManager is Slurm or PBS.
+	WorkloadManager string
+	// JobStorageRoot is a directory that the master and all compute nodes share, in which the
+	// scripts, files and output of jobs are kept.
+	JobStorageRoot string
+	// Partition is the Slurm partition or PBS queue that jobs are submitted to.
+	Partition string
+	// SubmitArgs are added to the directives of every job script.
+	SubmitArgs []string
+	// ContainerRuntime is the command that runs containers, e.g. singularity or apptainer.
+	ContainerRuntime string
+	// MasterHost and MasterPort are where task containers reach the master.
+	MasterHost string
+	MasterPort int
+}
+
+// High level overview of the actors within the hpc package:
+//
+//   jobs
+//     +- job(s): submits a batch job and follows it until it exits. One per container in a task.
+type jobs struct {
+	cluster         *actor.Ref
+	config          Config
+	wm              workloadManager
+	masterTLSConfig model.TLSClientConfig
+
+	jobHandlers map[cproto.ID]*actor.Ref
+}
+
+// Initialize creates the actor that runs the tasks of the HPC resource manager as batch jobs.
+func Initialize(
+	s *actor.System, c *actor.Ref, config Config, masterTLSConfig model.TLSClientConfig,
+) *actor.Ref {
+	wm, err := newWorkloadManager(config.WorkloadManager)
+	check.Panic(err)
+	jobsActor, ok := s.ActorOf(sproto.HPCJobsAddr, &jobs{
+		cluster:         c,
+		config:          config,
+		wm:              wm,
+		masterTLSConfig: masterTLSConfig,
+		jobHandlers:     make(map[cproto.ID]*actor.Ref),
+	})
+	check.Panic(check.True(ok, "hpc jobs address already taken"))
+	return jobsActor
+}
+
+func (j *jobs) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		ctx.Tell(j.cluster, sproto.SetHPCJobs{Jobs: ctx.Self()})
+
+	case sproto.StartHPCJob:
+		if err := j.receiveStartHPCJob(ctx, msg); err != nil {
+			return err
+		}
+
+	case sproto.KillHPCJob:
+		ref, ok := j.jobHandlers[msg.ContainerID]
+		if !ok {
+			ctx.Log().WithField("container-id", msg.ContainerID).Warn(
+				"received kill request for unknown job")
+			return nil
+		}
+		ctx.Tell(ref, msg)
+
+	case actor.ChildStopped:
+		j.cleanUpJobHandler(msg.Child)
+
+	case actor.ChildFailed:
+		j.cleanUpJobHandler(msg.Child)
+
+	case actor.PostStop:
+
+	default:
+		ctx.Log().Errorf("unexpected message %T", msg)
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (j *jobs) receiveStartHPCJob(ctx *actor.Context, msg sproto.StartHPCJob) error {
+	id := cproto.ID(msg.Spec.ContainerID)
+	ref, ok := ctx.ActorOf(
+		"job-"+msg.Spec.ContainerID, newJob(msg, j.config, j.wm, j.masterTLSConfig))
+	if !ok {
+		return errors.Errorf("job actor %s already exists", ref.Address().String())
+	}
+	ctx.Log().WithField("handler", ref.Address()).Infof("registering job handler")
+	j.jobHandlers[id] = ref
+	return nil
+}
+
+func (j *jobs) cleanUpJobHandler(ref *actor.Ref) {
+	for id, handler := range j.jobHandlers {
+		if handler == ref {
+			delete(j.jobHandlers, id)
+			return
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package hpc
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+// envNamePattern matches the names of environment variables that are safe to export from the job
+// script.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jobSpec describes the batch job that runs a task container.
+type jobSpec struct {
+	name       string
+	dir        string
+	outputPath string
+	slots      int
+	partition  string
+	submitArgs []string
+
+	// containerRuntime is the Singularity-compatible command that runs the container.
+	containerRuntime string
+	image            string
+	registryAuth     *types.AuthConfig
+	entrypoint       []string
+	workDir          string
+	env              []string
+	binds            []string
+}
+
+// stageArchives writes the files that the container needs into the job directory, which
+// compute nodes share with the master, and returns the bind mounts that place them in the
+// container.
+func stageArchives(dir string, runArchives []cproto.RunArchive) ([]string, error) {
+	var binds []string
+	for idx, runArchive := range runArchives {
+		root := filepath.Join(dir, "files", fmt.Sprint(idx))
+		items := make(map[string]bool)
+		for _, item := range runArchive.Archive {
+			items[path.Clean(item.Path)] = true
+		}
+		for _, item := range runArchive.Archive {
+			target, err := stagedPath(root, item.Path)
+			if err != nil {
+				return nil, err
+			}
+			if err = writeArchiveItem(target, item); err != nil {
+				return nil, err
+			}
+			// Items inside directories of the same archive arrive with their directory. Binding a
+			// symbolic link binds what it points to on the compute node, so links are only usable
+			// within the directories that are bound as a whole.
+			if items[path.Dir(path.Clean(item.Path))] || item.Type == tar.TypeSymlink {
+				continue
+			}
+			binds = append(binds, fmt.Sprintf("%s:%s", target, path.Join(runArchive.Path, item.Path)))
+		}
+	}
+	return binds, nil
+}
+
+// stagedPath returns the path under root of an archive item, or an error if the path leaves root
+// or passes through a symbolic link, which could point anywhere the master can write.
+func stagedPath(root, itemPath string) (string, error) {
+	target := filepath.Join(root, itemPath)
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("archive item %s is outside of the job directory", itemPath)
+	}
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		switch {
+		case os.IsNotExist(err):
+			return target, nil
+		case err != nil:
+			return "", err
+		case info.Mode()&os.ModeSymlink != 0:
+			return "", errors.Errorf("archive item %s passes through a symbolic link", itemPath)
+		}
+	}
+	return target, nil
+}
+
+func writeArchiveItem(target string, item archive.Item) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return errors.Wrapf(err, "error creating the parent directory of %s", target)
+	}
+	switch item.Type {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, item.FileMode.Perm()); err != nil {
+			return errors.Wrapf(err, "error creating directory %s", target)
+		}
+	case tar.TypeSymlink:
+		if err := os.Symlink(string(item.Content), target); err != nil {
+			return errors.Wrapf(err, "error creating symlink %s", target)
+		}
+	default:
+		// Do not follow a symbolic link at the target, which stagedPath only allows as the last
+		// part of the path.
+		f, err := os.OpenFile(
+			target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, item.FileMode.Perm())
+		if err != nil {
+			return errors.Wrapf(err, "error writing %s", target)
+		}
+		if _, err = f.Write(item.Content); err != nil {
+			_ = f.Close()
+			return errors.Wrapf(err, "error writing %s", target)
+		}
+		if err = f.Close(); err != nil {
+			return errors.Wrapf(err, "error writing %s", target)
+		}
+	}
+	return nil
+}
+
+// mountBinds translates Docker bind mounts into container runtime bind mounts.
+func mountBinds(mounts []mount.Mount) []string {
+	var binds []string
+	for _, m := range mounts {
+		bind := m.Source + ":" + m.Target
+		if m.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+	}
+	return binds
+}
+
+// jobScript returns the batch script that runs the job's container, or an error if an environment
+// variable of the container has a name that the shell would not take as one.
+func jobScript(wm workloadManager, spec jobSpec) (string, error) {
+	lines := []string{"#!/bin/bash"}
+	lines = append(lines, wm.directives(spec)...)
+	lines = append(lines, "", "set -e", "cd "+shellQuote(spec.dir), "")
+
+	// Variables prefixed with SINGULARITYENV_ are passed into the container by both Singularity
+	// and Apptainer; --cleanenv keeps out everything else from the node.
+	env := append([]string{}, spec.env...)
+	sort.Strings(env)
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if !envNamePattern.MatchString(kv[0]) {
+			return "", errors.Errorf("invalid environment variable name %q", kv[0])
+		}
+		lines = append(lines, fmt.Sprintf("export SINGULARITYENV_%s=%s", kv[0], shellQuote(kv[1])))
+	}
+
+	if auth := spec.registryAuth; auth != nil {
+		lines = append(lines,
+			"export SINGULARITY_DOCKER_USERNAME="+shellQuote(auth.Username),
+			"export SINGULARITY_DOCKER_PASSWORD="+shellQuote(auth.Password))
+	}
+
+	args := []string{spec.containerRuntime, "exec", "--cleanenv", "--pwd", spec.workDir}
+	if spec.slots > 0 {
+		args = append(args, "--nv")
+	}
+	for _, bind := range spec.binds {
+		args = append(args, "--bind", bind)
+	}
+	args = append(args, containerImage(spec.image))
+	args = append(args, spec.entrypoint...)
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	lines = append(lines, "", "exec "+strings.Join(args, " \\\n    "), "")
+	return strings.Join(lines, "\n"), nil
+}
+
+// containerImage returns the image reference that the container runtime pulls. Images without a
+// transport are taken to be Docker images.
+func containerImage(image string) string {
+	if strings.Contains(image, "://") {
+		return image
+	}
+	return "docker://" + image
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
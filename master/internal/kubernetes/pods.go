@@ -21,6 +21,7 @@ import (
 
 	k8sV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	k8sClient "k8s.io/client-go/kubernetes"
 	typedV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
@@ -39,16 +40,19 @@ type podMetadata struct {
 //   pods
 //     +- pod(s): manages pod lifecycle. One per container in a task.
 //        +- podLogStreamer: stream logs for a specific pod.
-//     +- informer: sends updates about pod states
-//     +- events: sends updates about kubernetes events.
+//     +- informer(s): sends updates about pod states. One per namespace.
+//     +- events: sends updates about kubernetes events. One per namespace.
+//     +- preemptions: sends pod preemption requests. One per namespace.
 //     +- requestQueue: queues requests to create / delete kubernetes resources.
 //        +- requestProcessingWorkers: processes request to create / delete kubernetes resources.
 type pods struct {
 	cluster                  *actor.Ref
 	namespace                string
+	namespaces               []string
 	masterServiceName        string
 	leaveKubernetesResources bool
 	scheduler                string
+	priorityClasses          []PriorityClass
 
 	clientSet        *k8sClient.Clientset
 	masterIP         string
@@ -57,10 +61,8 @@ type pods struct {
 	loggingTLSConfig model.TLSClientConfig
 	loggingConfig    model.LoggingConfig
 
-	informer                *actor.Ref
+	namespaceListeners      map[*actor.Ref]string
 	nodeInformer            *actor.Ref
-	eventListener           *actor.Ref
-	preemptionListener      *actor.Ref
 	resourceRequestQueue    *actor.Ref
 	podNameToPodHandler     map[string]*actor.Ref
 	containerIDToPodHandler map[string]*actor.Ref
@@ -68,21 +70,26 @@ type pods struct {
 
 	currentNodes map[string]*k8sV1.Node
 
-	podInterface       typedV1.PodInterface
-	configMapInterface typedV1.ConfigMapInterface
+	podInterfaces       map[string]typedV1.PodInterface
+	configMapInterfaces map[string]typedV1.ConfigMapInterface
+	podGroupInterfaces  map[string]dynamic.ResourceInterface
+	claimInterfaces     map[string]typedV1.PersistentVolumeClaimInterface
 }
 
-// Initialize creates a new global agent actor.
+// Initialize creates a new global agent actor. Task pods are launched in the default namespace
+// unless they request one of the other namespaces.
 func Initialize(
 	s *actor.System,
 	e *echo.Echo,
 	c *actor.Ref,
 	namespace string,
+	namespaces []string,
 	masterServiceName string,
 	masterTLSConfig model.TLSClientConfig,
 	loggingConfig model.LoggingConfig,
 	leaveKubernetesResources bool,
 	scheduler string,
+	priorityClasses []PriorityClass,
 ) *actor.Ref {
 	loggingTLSConfig := masterTLSConfig
 	if loggingConfig.ElasticLoggingConfig != nil {
@@ -92,9 +99,11 @@ func Initialize(
 	podsActor, ok := s.ActorOf(actor.Addr("pods"), &pods{
 		cluster:                  c,
 		namespace:                namespace,
+		namespaces:               namespaces,
 		masterServiceName:        masterServiceName,
 		masterTLSConfig:          masterTLSConfig,
 		scheduler:                scheduler,
+		priorityClasses:          priorityClasses,
 		loggingTLSConfig:         loggingTLSConfig,
 		loggingConfig:            loggingConfig,
 		podNameToPodHandler:      make(map[string]*actor.Ref),
@@ -102,11 +111,15 @@ func Initialize(
 		podHandlerToMetadata:     make(map[*actor.Ref]podMetadata),
 		leaveKubernetesResources: leaveKubernetesResources,
 		currentNodes:             make(map[string]*k8sV1.Node),
+		namespaceListeners:       make(map[*actor.Ref]string),
 	})
 	check.Panic(check.True(ok, "pods address already taken"))
 
-	// We re-use the agents endpoint for the default resource manager.
-	e.Any("/agents", api.Route(s, podsActor))
+	// We re-use the agents endpoint for the default resource manager. There is no echo when the
+	// agent resource manager runs too and serves the endpoint itself.
+	if e != nil {
+		e.Any("/agents", api.Route(s, podsActor))
+	}
 	return podsActor
 }
 
@@ -123,10 +136,12 @@ func (p *pods) Receive(ctx *actor.Context) error {
 		if err := p.deleteExistingKubernetesResources(ctx); err != nil {
 			return err
 		}
-		p.startPodInformer(ctx)
 		p.startNodeInformer(ctx)
-		p.startEventListener(ctx)
-		p.startPreemptionListener(ctx)
+		for _, namespace := range p.namespaces {
+			p.startPodInformer(ctx, namespace)
+			p.startEventListener(ctx, namespace)
+			p.startPreemptionListener(ctx, namespace)
+		}
 		ctx.Tell(p.cluster, sproto.SetPods{Pods: ctx.Self()})
 
 	case sproto.StartTaskPod:
@@ -160,15 +175,12 @@ func (p *pods) Receive(ctx *actor.Context) error {
 		}
 
 	case actor.ChildFailed:
+		if listener, ok := p.namespaceListeners[msg.Child]; ok {
+			return errors.Errorf("%s failed", listener)
+		}
 		switch msg.Child {
-		case p.informer:
-			return errors.Errorf("pod informer failed")
 		case p.nodeInformer:
 			return errors.Errorf("node informer failed")
-		case p.eventListener:
-			return errors.Errorf("event listener failed")
-		case p.preemptionListener:
-			return errors.Errorf("preemption listener failed")
 		case p.resourceRequestQueue:
 			return errors.Errorf("resource request actor failed")
 		}
@@ -201,8 +213,27 @@ func (p *pods) startClientSet(ctx *actor.Context) error {
 		return errors.Wrap(err, "failed to initialize kubernetes clientSet")
 	}
 
-	p.podInterface = p.clientSet.CoreV1().Pods(p.namespace)
-	p.configMapInterface = p.clientSet.CoreV1().ConfigMaps(p.namespace)
+	p.podInterfaces = make(map[string]typedV1.PodInterface)
+	p.configMapInterfaces = make(map[string]typedV1.ConfigMapInterface)
+	p.claimInterfaces = make(map[string]typedV1.PersistentVolumeClaimInterface)
+	for _, namespace := range p.namespaces {
+		p.podInterfaces[namespace] = p.clientSet.CoreV1().Pods(namespace)
+		p.configMapInterfaces[namespace] = p.clientSet.CoreV1().ConfigMaps(namespace)
+		p.claimInterfaces[namespace] = p.clientSet.CoreV1().PersistentVolumeClaims(namespace)
+	}
+
+	// Volcano pod groups are custom resources, which the typed clientSet does not know about.
+	p.podGroupInterfaces = make(map[string]dynamic.ResourceInterface)
+	if p.scheduler == volcanoScheduler {
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize kubernetes dynamic client")
+		}
+		for _, namespace := range p.namespaces {
+			p.podGroupInterfaces[namespace] = dynamicClient.Resource(podGroupResource).Namespace(
+				namespace)
+		}
+	}
 
 	ctx.Log().Infof("kubernetes clientSet initialized")
 	return nil
@@ -224,65 +255,106 @@ func (p *pods) getMasterIPAndPort(ctx *actor.Context) error {
 func (p *pods) deleteExistingKubernetesResources(ctx *actor.Context) error {
 	listOptions := metaV1.ListOptions{LabelSelector: determinedLabel}
 
-	configMaps, err := p.configMapInterface.List(listOptions)
-	if err != nil {
-		return errors.Wrap(err, "error listing existing config maps")
-	}
-	for _, configMap := range configMaps.Items {
-		if configMap.Namespace != p.namespace {
-			continue
+	for _, namespace := range p.namespaces {
+		configMaps, err := p.configMapInterfaces[namespace].List(listOptions)
+		if err != nil {
+			return errors.Wrapf(err, "error listing existing config maps in %s", namespace)
 		}
+		for _, configMap := range configMaps.Items {
+			if configMap.Namespace != namespace {
+				continue
+			}
 
-		ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
-			handler: ctx.Self(), configMapName: configMap.Name})
-	}
+			ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
+				handler: ctx.Self(), namespace: namespace, configMapName: configMap.Name})
+		}
 
-	pods, err := p.podInterface.List(listOptions)
-	if err != nil {
-		return errors.Wrap(err, "error listing existing pod")
-	}
-	for _, pod := range pods.Items {
-		if pod.Namespace != p.namespace {
-			continue
+		pods, err := p.podInterfaces[namespace].List(listOptions)
+		if err != nil {
+			return errors.Wrapf(err, "error listing existing pod in %s", namespace)
+		}
+		for _, pod := range pods.Items {
+			if pod.Namespace != namespace {
+				continue
+			}
+
+			ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
+				handler: ctx.Self(), namespace: namespace, podName: pod.Name})
 		}
 
-		ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
-			handler: ctx.Self(), podName: pod.Name})
+		claims, err := p.claimInterfaces[namespace].List(listOptions)
+		if err != nil {
+			return errors.Wrapf(err, "error listing existing persistent volume claims in %s",
+				namespace)
+		}
+		for _, claim := range claims.Items {
+			if claim.Namespace != namespace {
+				continue
+			}
+
+			ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
+				handler: ctx.Self(), namespace: namespace, claimName: claim.Name})
+		}
+
+		podGroupInterface, ok := p.podGroupInterfaces[namespace]
+		if !ok {
+			continue
+		}
+		podGroups, err := podGroupInterface.List(listOptions)
+		if err != nil {
+			return errors.Wrapf(err, "error listing existing pod groups in %s", namespace)
+		}
+		for _, podGroup := range podGroups.Items {
+			ctx.Tell(p.resourceRequestQueue, deleteKubernetesResources{
+				handler: ctx.Self(), namespace: namespace, podGroupName: podGroup.GetName()})
+		}
 	}
 
 	return nil
 }
 
-func (p *pods) startPodInformer(ctx *actor.Context) {
-	p.informer, _ = ctx.ActorOf("pod-informer", newInformer(p.podInterface, p.namespace, ctx.Self()))
+func (p *pods) startPodInformer(ctx *actor.Context, namespace string) {
+	ref, _ := ctx.ActorOf(
+		"pod-informer-"+namespace,
+		newInformer(p.podInterfaces[namespace], namespace, ctx.Self()),
+	)
+	p.namespaceListeners[ref] = fmt.Sprintf("pod informer for namespace %s", namespace)
 }
 
 func (p *pods) startNodeInformer(ctx *actor.Context) {
 	p.nodeInformer, _ = ctx.ActorOf("node-informer", newNodeInformer(p.clientSet, ctx.Self()))
 }
 
-func (p *pods) startEventListener(ctx *actor.Context) {
-	p.eventListener, _ = ctx.ActorOf(
-		"event-listener", newEventListener(p.clientSet, p.namespace, ctx.Self()))
+func (p *pods) startEventListener(ctx *actor.Context, namespace string) {
+	ref, _ := ctx.ActorOf(
+		"event-listener-"+namespace, newEventListener(p.clientSet, namespace, ctx.Self()))
+	p.namespaceListeners[ref] = fmt.Sprintf("event listener for namespace %s", namespace)
 }
 
-func (p *pods) startPreemptionListener(ctx *actor.Context) {
-	p.preemptionListener, _ = ctx.ActorOf(
-		"preemption-listener", newPreemptionListener(p.clientSet, p.namespace, ctx.Self()))
+func (p *pods) startPreemptionListener(ctx *actor.Context, namespace string) {
+	ref, _ := ctx.ActorOf(
+		"preemption-listener-"+namespace, newPreemptionListener(p.clientSet, namespace, ctx.Self()))
+	p.namespaceListeners[ref] = fmt.Sprintf("preemption listener for namespace %s", namespace)
 }
 
 func (p *pods) startResourceRequestQueue(ctx *actor.Context) {
 	p.resourceRequestQueue, _ = ctx.ActorOf(
 		"kubernetes-resource-request-queue",
-		newRequestQueue(p.podInterface, p.configMapInterface),
+		newRequestQueue(
+			p.podInterfaces, p.configMapInterfaces, p.podGroupInterfaces, p.claimInterfaces),
 	)
 }
 
 func (p *pods) receiveStartTaskPod(ctx *actor.Context, msg sproto.StartTaskPod) error {
+	namespace := p.namespace
+	if taskNamespace := msg.Spec.Environment().Kubernetes().Namespace(); taskNamespace != nil {
+		namespace = *taskNamespace
+	}
 	newPodHandler := newPod(
-		msg, p.cluster, msg.Spec.ClusterID, p.clientSet, p.namespace, p.masterIP, p.masterPort,
-		p.masterTLSConfig, p.loggingTLSConfig, p.loggingConfig, p.podInterface, p.configMapInterface,
-		p.resourceRequestQueue, p.leaveKubernetesResources, p.scheduler,
+		msg, p.cluster, msg.Spec.ClusterID, p.clientSet, namespace, p.masterIP, p.masterPort,
+		p.masterTLSConfig, p.loggingTLSConfig, p.loggingConfig, p.podInterfaces[namespace],
+		p.configMapInterfaces[namespace], p.resourceRequestQueue, p.leaveKubernetesResources,
+		p.scheduler, priorityClassName(p.priorityClasses, msg.Priority),
 	)
 	ref, ok := ctx.ActorOf(fmt.Sprintf("pod-%s", msg.Spec.ContainerID), newPodHandler)
 	if !ok {
@@ -443,6 +515,10 @@ func (p *pods) summarize(ctx *actor.Context) map[string]agent.AgentSummary {
 					Device:    device.Device{Type: device.GPU},
 					Enabled:   true,
 					Container: podInfo.container,
+					Allocation: &agent.SlotAllocation{
+						TaskID:      sproto.TaskID(podInfo.taskID),
+						ContainerID: podInfo.container.ID,
+					},
 				}
 				curSlot++
 			}
@@ -462,6 +538,9 @@ func (p *pods) summarize(ctx *actor.Context) map[string]agent.AgentSummary {
 			Slots:          slotsSummary,
 			NumContainers:  len(podByNode[node.Name]),
 			ResourcePool:   "",
+			Platform:       node.Status.NodeInfo.OperatingSystem + "/" + node.Status.NodeInfo.Architecture,
+			// Node health is managed by Kubernetes itself.
+			Health: agent.HealthSummary{Healthy: true},
 		}
 	}
 
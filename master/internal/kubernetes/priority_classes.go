@@ -0,0 +1,45 @@
+package kubernetes
+
+import (
+	"sort"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// PriorityClass maps the Determined scheduling priorities up to and including MaxPriority onto a
+// Kubernetes PriorityClass. Since smaller Determined priorities are more important, the classes
+// that cover smaller priorities should have larger Kubernetes priority values.
+type PriorityClass struct {
+	MaxPriority int    `json:"max_priority"`
+	Name        string `json:"name"`
+}
+
+// Validate implements the check.Validatable interface.
+func (p PriorityClass) Validate() []error {
+	return []error{
+		check.NotEmpty(p.Name, "priority class names must not be empty"),
+		check.GreaterThanOrEqualTo(p.MaxPriority, model.MinUserSchedulingPriority,
+			"max_priority must be >= %d", model.MinUserSchedulingPriority),
+		check.LessThanOrEqualTo(p.MaxPriority, model.MaxUserSchedulingPriority,
+			"max_priority must be <= %d", model.MaxUserSchedulingPriority),
+	}
+}
+
+// priorityClassName returns the name of the class that covers the priority, or "" if there is no
+// such class or the task has no priority.
+func priorityClassName(classes []PriorityClass, priority *int) string {
+	if priority == nil {
+		return ""
+	}
+	sorted := append([]PriorityClass{}, classes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MaxPriority < sorted[j].MaxPriority
+	})
+	for _, class := range sorted {
+		if *priority <= class.MaxPriority {
+			return class.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestPriorityClassName(t *testing.T) {
+	classes := []PriorityClass{
+		{MaxPriority: 99, Name: "low"},
+		{MaxPriority: 10, Name: "high"},
+		{MaxPriority: 50, Name: "medium"},
+	}
+	assert.NilError(t, check.Validate(classes))
+
+	priority := func(p int) *int { return &p }
+	assert.Equal(t, priorityClassName(classes, nil), "")
+	assert.Equal(t, priorityClassName(classes, priority(1)), "high")
+	assert.Equal(t, priorityClassName(classes, priority(10)), "high")
+	assert.Equal(t, priorityClassName(classes, priority(42)), "medium")
+	assert.Equal(t, priorityClassName(classes, priority(99)), "low")
+	assert.Equal(t, priorityClassName(classes[1:2], priority(42)), "")
+
+	assert.Assert(t, check.Validate(PriorityClass{MaxPriority: 100, Name: "x"}) != nil)
+}
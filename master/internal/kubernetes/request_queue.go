@@ -8,6 +8,8 @@ import (
 	"github.com/determined-ai/determined/master/pkg/actor"
 
 	k8sV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	typedV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
@@ -22,12 +24,23 @@ type (
 		handler       *actor.Ref
 		podSpec       *k8sV1.Pod
 		configMapSpec *k8sV1.ConfigMap
+		// podGroupSpec, if set, is a Volcano PodGroup that the pod belongs to. It is shared by all
+		// pods of the task and may already exist.
+		podGroupSpec *unstructured.Unstructured
+		// claimSpec, if set, is a persistent volume claim that the pod mounts. It may already
+		// exist, in which case it is used as is.
+		claimSpec *k8sV1.PersistentVolumeClaim
 	}
 
 	deleteKubernetesResources struct {
 		handler       *actor.Ref
+		namespace     string
 		podName       string
 		configMapName string
+		podGroupName  string
+		claimName     string
+		// gracePeriod is the number of seconds that the pod has to exit, if not the default.
+		gracePeriod int
 	}
 )
 
@@ -94,8 +107,10 @@ type queuedResourceRequest struct {
 //  requestProcessingWorkers notify the requestQueue that they are available to receive work
 //  by sending a `workerAvailable` message.
 type requestQueue struct {
-	podInterface       typedV1.PodInterface
-	configMapInterface typedV1.ConfigMapInterface
+	podInterfaces       map[string]typedV1.PodInterface
+	configMapInterfaces map[string]typedV1.ConfigMapInterface
+	podGroupInterfaces  map[string]dynamic.ResourceInterface
+	claimInterfaces     map[string]typedV1.PersistentVolumeClaimInterface
 
 	queue                    []*queuedResourceRequest
 	pendingResourceCreations map[*actor.Ref]*queuedResourceRequest
@@ -106,12 +121,16 @@ type requestQueue struct {
 }
 
 func newRequestQueue(
-	podInterface typedV1.PodInterface,
-	configMapInterface typedV1.ConfigMapInterface,
+	podInterfaces map[string]typedV1.PodInterface,
+	configMapInterfaces map[string]typedV1.ConfigMapInterface,
+	podGroupInterfaces map[string]dynamic.ResourceInterface,
+	claimInterfaces map[string]typedV1.PersistentVolumeClaimInterface,
 ) *requestQueue {
 	return &requestQueue{
-		podInterface:       podInterface,
-		configMapInterface: configMapInterface,
+		podInterfaces:       podInterfaces,
+		configMapInterfaces: configMapInterfaces,
+		podGroupInterfaces:  podGroupInterfaces,
+		claimInterfaces:     claimInterfaces,
 
 		queue:                    make([]*queuedResourceRequest, 0),
 		pendingResourceCreations: make(map[*actor.Ref]*queuedResourceRequest),
@@ -129,8 +148,10 @@ func (r *requestQueue) Receive(ctx *actor.Context) error {
 			newWorker, ok := ctx.ActorOf(
 				fmt.Sprintf("kubernetes-worker-%d", i),
 				&requestProcessingWorker{
-					podInterface:       r.podInterface,
-					configMapInterface: r.configMapInterface,
+					podInterfaces:       r.podInterfaces,
+					configMapInterfaces: r.configMapInterfaces,
+					podGroupInterfaces:  r.podGroupInterfaces,
+					claimInterfaces:     r.claimInterfaces,
 				},
 			)
 			if !ok {
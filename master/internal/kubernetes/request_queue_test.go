@@ -84,7 +84,12 @@ func TestRequestQueueCreatingManyPod(t *testing.T) {
 	podInterface := &mockPodInterface{pods: make(map[string]*k8sV1.Pod)}
 	configMapInterface := &mockConfigMapInterface{configMaps: make(map[string]*k8sV1.ConfigMap)}
 
-	k8sRequestQueue := newRequestQueue(podInterface, configMapInterface)
+	k8sRequestQueue := newRequestQueue(
+		map[string]typedV1.PodInterface{"": podInterface},
+		map[string]typedV1.ConfigMapInterface{"": configMapInterface},
+		nil,
+		nil,
+	)
 	requestQueueActor, _ := system.ActorOf(
 		actor.Addr("request-queue"),
 		k8sRequestQueue,
@@ -112,7 +117,12 @@ func TestRequestQueueCreatingAndDeletingManyPod(t *testing.T) {
 	podInterface := &mockPodInterface{pods: make(map[string]*k8sV1.Pod)}
 	configMapInterface := &mockConfigMapInterface{configMaps: make(map[string]*k8sV1.ConfigMap)}
 
-	k8sRequestQueue := newRequestQueue(podInterface, configMapInterface)
+	k8sRequestQueue := newRequestQueue(
+		map[string]typedV1.PodInterface{"": podInterface},
+		map[string]typedV1.ConfigMapInterface{"": configMapInterface},
+		nil,
+		nil,
+	)
 	requestQueueActor, _ := system.ActorOf(
 		actor.Addr("request-queue"),
 		k8sRequestQueue,
@@ -141,7 +151,12 @@ func TestRequestQueueCreatingThenDeletingManyPods(t *testing.T) {
 	podInterface := &mockPodInterface{pods: make(map[string]*k8sV1.Pod)}
 	configMapInterface := &mockConfigMapInterface{configMaps: make(map[string]*k8sV1.ConfigMap)}
 
-	k8sRequestQueue := newRequestQueue(podInterface, configMapInterface)
+	k8sRequestQueue := newRequestQueue(
+		map[string]typedV1.PodInterface{"": podInterface},
+		map[string]typedV1.ConfigMapInterface{"": configMapInterface},
+		nil,
+		nil,
+	)
 	requestQueueActor, _ := system.ActorOf(
 		actor.Addr("request-queue"),
 		k8sRequestQueue,
@@ -178,7 +193,12 @@ func TestRequestQueueCreatingAndDeletingManyPodWithDelay(t *testing.T) {
 	}
 	configMapInterface := &mockConfigMapInterface{configMaps: make(map[string]*k8sV1.ConfigMap)}
 
-	k8sRequestQueue := newRequestQueue(podInterface, configMapInterface)
+	k8sRequestQueue := newRequestQueue(
+		map[string]typedV1.PodInterface{"": podInterface},
+		map[string]typedV1.ConfigMapInterface{"": configMapInterface},
+		nil,
+		nil,
+	)
 	requestQueueActor, _ := system.ActorOf(
 		actor.Addr("request-queue"),
 		k8sRequestQueue,
@@ -1,15 +1,21 @@
 package kubernetes
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/determined-ai/determined/master/pkg/actor"
 
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	typedV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
 type requestProcessingWorker struct {
-	podInterface       typedV1.PodInterface
-	configMapInterface typedV1.ConfigMapInterface
+	podInterfaces       map[string]typedV1.PodInterface
+	configMapInterfaces map[string]typedV1.ConfigMapInterface
+	podGroupInterfaces  map[string]dynamic.ResourceInterface
+	claimInterfaces     map[string]typedV1.PersistentVolumeClaimInterface
 }
 
 func (r *requestProcessingWorker) Receive(ctx *actor.Context) error {
@@ -39,7 +45,15 @@ func (r *requestProcessingWorker) receiveCreateKubernetesResources(
 	ctx *actor.Context,
 	msg createKubernetesResources,
 ) {
-	configMap, err := r.configMapInterface.Create(msg.configMapSpec)
+	podInterface, configMapInterface, err := r.interfaces(msg.podSpec.Namespace)
+	if err != nil {
+		ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
+			"error creating pod %s", msg.podSpec.Name)
+		ctx.Tell(msg.handler, resourceCreationFailed{err: err})
+		return
+	}
+
+	configMap, err := configMapInterface.Create(msg.configMapSpec)
 	if err != nil {
 		ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
 			"error creating configMap %s", msg.configMapSpec.Name)
@@ -49,8 +63,26 @@ func (r *requestProcessingWorker) receiveCreateKubernetesResources(
 	ctx.Log().WithField("handler", msg.handler.Address()).Infof(
 		"created configMap %s", configMap.Name)
 
+	if msg.podGroupSpec != nil {
+		if err = r.createPodGroup(ctx, msg); err != nil {
+			ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
+				"error creating pod group %s", msg.podGroupSpec.GetName())
+			ctx.Tell(msg.handler, resourceCreationFailed{err: err})
+			return
+		}
+	}
+
+	if msg.claimSpec != nil {
+		if err = r.createClaim(ctx, msg); err != nil {
+			ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
+				"error creating persistent volume claim %s", msg.claimSpec.Name)
+			ctx.Tell(msg.handler, resourceCreationFailed{err: err})
+			return
+		}
+	}
+
 	ctx.Log().Debugf("launching pod with spec %v", msg.podSpec)
-	pod, err := r.podInterface.Create(msg.podSpec)
+	pod, err := podInterface.Create(msg.podSpec)
 	if err != nil {
 		ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
 			"error creating pod %s", msg.podSpec.Name)
@@ -65,12 +97,19 @@ func (r *requestProcessingWorker) receiveDeleteKubernetesResources(
 	msg deleteKubernetesResources,
 ) {
 	var gracePeriod int64 = deletionGracePeriod
-	var err error
+	if msg.gracePeriod > 0 {
+		gracePeriod = int64(msg.gracePeriod)
+	}
+	podInterface, configMapInterface, err := r.interfaces(msg.namespace)
+	if err != nil {
+		ctx.Tell(msg.handler, resourceDeletionFailed{err: err})
+		return
+	}
 
 	// If resource creation failed, we will still try to delete those resources which
 	// will also result in a failure.
 	if len(msg.podName) > 0 {
-		err = r.podInterface.Delete(msg.podName, &metaV1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		err = podInterface.Delete(msg.podName, &metaV1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
 		if err != nil {
 			ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
 				"failed to delete pod %s", msg.podName)
@@ -81,7 +120,7 @@ func (r *requestProcessingWorker) receiveDeleteKubernetesResources(
 	}
 
 	if len(msg.configMapName) > 0 {
-		errDeletingConfigMap := r.configMapInterface.Delete(msg.configMapName, &metaV1.DeleteOptions{
+		errDeletingConfigMap := configMapInterface.Delete(msg.configMapName, &metaV1.DeleteOptions{
 			GracePeriodSeconds: &gracePeriod})
 		if errDeletingConfigMap != nil {
 			ctx.Log().WithField("handler", msg.handler.Address()).WithError(err).Errorf(
@@ -93,9 +132,115 @@ func (r *requestProcessingWorker) receiveDeleteKubernetesResources(
 		}
 	}
 
+	// The pod group is shared by the other pods of the task, so whichever of them is deleted first
+	// deletes it.
+	if len(msg.podGroupName) > 0 {
+		errDeletingPodGroup := r.deletePodGroup(msg.namespace, msg.podGroupName)
+		if errDeletingPodGroup != nil {
+			ctx.Log().WithField("handler", msg.handler.Address()).WithError(errDeletingPodGroup).Errorf(
+				"failed to delete pod group %s", msg.podGroupName)
+			err = errDeletingPodGroup
+		}
+	}
+
+	if len(msg.claimName) > 0 {
+		errDeletingClaim := r.deleteClaim(msg.namespace, msg.claimName)
+		if errDeletingClaim != nil {
+			ctx.Log().WithField("handler", msg.handler.Address()).WithError(errDeletingClaim).Errorf(
+				"failed to delete persistent volume claim %s", msg.claimName)
+			err = errDeletingClaim
+		} else {
+			ctx.Log().WithField("handler", msg.handler.Address()).Infof(
+				"deleted persistent volume claim %s", msg.claimName)
+		}
+	}
+
 	// It is possible that the actor that sent the message is no longer around (if sent from
 	// actor.PostStop). However this should have no impact on correctness.
 	if err != nil {
 		ctx.Tell(msg.handler, resourceDeletionFailed{err: err})
 	}
 }
+
+// interfaces returns the clients for the pods and configMaps of a namespace.
+func (r *requestProcessingWorker) interfaces(
+	namespace string,
+) (typedV1.PodInterface, typedV1.ConfigMapInterface, error) {
+	podInterface, ok := r.podInterfaces[namespace]
+	if !ok {
+		return nil, nil, errors.Errorf("kubernetes namespace %s is not managed by Determined", namespace)
+	}
+	return podInterface, r.configMapInterfaces[namespace], nil
+}
+
+// createPodGroup creates the pod group of a pod unless another pod of the task already has.
+func (r *requestProcessingWorker) createPodGroup(
+	ctx *actor.Context, msg createKubernetesResources,
+) error {
+	podGroupInterface, ok := r.podGroupInterfaces[msg.podSpec.Namespace]
+	if !ok {
+		return errors.Errorf("kubernetes namespace %s is not managed by Determined",
+			msg.podSpec.Namespace)
+	}
+	_, err := podGroupInterface.Create(msg.podGroupSpec, metaV1.CreateOptions{})
+	switch {
+	case k8sErrors.IsAlreadyExists(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	ctx.Log().WithField("handler", msg.handler.Address()).Infof(
+		"created pod group %s", msg.podGroupSpec.GetName())
+	return nil
+}
+
+// deletePodGroup deletes a pod group, which may have been deleted already.
+func (r *requestProcessingWorker) deletePodGroup(namespace, name string) error {
+	podGroupInterface, ok := r.podGroupInterfaces[namespace]
+	if !ok {
+		return errors.Errorf("kubernetes namespace %s is not managed by Determined", namespace)
+	}
+	if err := podGroupInterface.Delete(name, &metaV1.DeleteOptions{}); err != nil &&
+		!k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// createClaim creates the persistent volume claim of a pod unless it already exists, e.g. because
+// an earlier task created it to be reused.
+func (r *requestProcessingWorker) createClaim(
+	ctx *actor.Context, msg createKubernetesResources,
+) error {
+	claimInterface, ok := r.claimInterfaces[msg.podSpec.Namespace]
+	if !ok {
+		return errors.Errorf("kubernetes namespace %s is not managed by Determined",
+			msg.podSpec.Namespace)
+	}
+	_, err := claimInterface.Create(msg.claimSpec)
+	switch {
+	case k8sErrors.IsAlreadyExists(err):
+		ctx.Log().WithField("handler", msg.handler.Address()).Infof(
+			"using existing persistent volume claim %s", msg.claimSpec.Name)
+		return nil
+	case err != nil:
+		return err
+	}
+	ctx.Log().WithField("handler", msg.handler.Address()).Infof(
+		"created persistent volume claim %s", msg.claimSpec.Name)
+	return nil
+}
+
+// deleteClaim deletes a persistent volume claim. Kubernetes defers the deletion until no pod
+// uses the claim anymore.
+func (r *requestProcessingWorker) deleteClaim(namespace, name string) error {
+	claimInterface, ok := r.claimInterfaces[namespace]
+	if !ok {
+		return errors.Errorf("kubernetes namespace %s is not managed by Determined", namespace)
+	}
+	if err := claimInterface.Delete(name, &metaV1.DeleteOptions{}); err != nil &&
+		!k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
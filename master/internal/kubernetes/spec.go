@@ -36,7 +36,7 @@ const (
 )
 
 func (p *pod) configureResourcesRequirements() k8sV1.ResourceRequirements {
-	return k8sV1.ResourceRequirements{
+	requirements := k8sV1.ResourceRequirements{
 		Limits: map[k8sV1.ResourceName]resource.Quantity{
 			"nvidia.com/gpu": *resource.NewQuantity(int64(p.gpus), resource.DecimalSI),
 		},
@@ -44,6 +44,21 @@ func (p *pod) configureResourcesRequirements() k8sV1.ResourceRequirements {
 			"nvidia.com/gpu": *resource.NewQuantity(int64(p.gpus), resource.DecimalSI),
 		},
 	}
+	// Requests match limits so that pods are scheduled onto nodes that have the resources that
+	// they may use, whatever the defaults of their namespace are.
+	resources := p.taskSpec.ResourcesConfig()
+	if cpus := resources.CPULimit(); cpus != nil {
+		// A limit of 0 means no limit, so fractions of a millicore round up.
+		quantity := *resource.NewMilliQuantity(int64(math.Ceil(*cpus*1000)), resource.DecimalSI)
+		requirements.Limits[k8sV1.ResourceCPU] = quantity
+		requirements.Requests[k8sV1.ResourceCPU] = quantity
+	}
+	if memory := resources.MemoryLimit(); memory != nil {
+		quantity := *resource.NewQuantity(int64(*memory), resource.BinarySI)
+		requirements.Limits[k8sV1.ResourceMemory] = quantity
+		requirements.Requests[k8sV1.ResourceMemory] = quantity
+	}
+	return requirements
 }
 
 func (p *pod) configureEnvVars(
@@ -51,8 +66,11 @@ func (p *pod) configureEnvVars(
 	environment expconf.EnvironmentConfig,
 	deviceType device.Type,
 ) ([]k8sV1.EnvVar, error) {
-	for _, envVar := range environment.EnvironmentVariables().For(deviceType) {
-		envVarSplit := strings.Split(envVar, "=")
+	for _, envVar := range p.taskSpec.ResolveSecrets(
+		environment.EnvironmentVariables().For(deviceType),
+	) {
+		// Split on the first "=" only, since resolved secrets may contain more.
+		envVarSplit := strings.SplitN(envVar, "=", 2)
 		if len(envVarSplit) != 2 {
 			return nil, errors.Errorf("unable to split envVar %s", envVar)
 		}
@@ -182,6 +200,10 @@ func (p *pod) configureVolumes(
 }
 
 func (p *pod) modifyPodSpec(newPod *k8sV1.Pod, scheduler string) {
+	if newPod.Spec.PriorityClassName == "" && p.taskSpec.Description() != gcTask {
+		newPod.Spec.PriorityClassName = p.priorityClassName
+	}
+
 	if p.taskSpec.Description() == cmdTask {
 		return
 	}
@@ -192,6 +214,32 @@ func (p *pod) modifyPodSpec(newPod *k8sV1.Pod, scheduler string) {
 		}
 		p.configureCoscheduler(newPod, scheduler)
 	}
+
+	if scheduler == volcanoScheduler {
+		if newPod.Spec.SchedulerName == "" {
+			newPod.Spec.SchedulerName = scheduler
+		}
+		p.configureVolcano(newPod)
+	}
+}
+
+// configureVolcano places the pods of tasks that span several pods into a Volcano PodGroup, which
+// is created along with the pods. Pods whose spec already names a group are left alone.
+func (p *pod) configureVolcano(newPod *k8sV1.Pod) {
+	if newPod.Spec.SchedulerName != volcanoScheduler || p.gangSize <= 1 {
+		return
+	}
+	if _, ok := newPod.ObjectMeta.Annotations[volcanoGroupAnnotation]; ok {
+		return
+	}
+
+	if newPod.ObjectMeta.Annotations == nil {
+		newPod.ObjectMeta.Annotations = make(map[string]string)
+	}
+	p.podGroupName = podGroupName(p.taskSpec.TaskID)
+	newPod.ObjectMeta.Annotations[volcanoGroupAnnotation] = p.podGroupName
+	p.podGroup = podGroupSpec(
+		p.namespace, p.podGroupName, p.taskSpec.TaskID, p.gangSize, newPod.Spec.PriorityClassName)
 }
 
 func (p *pod) configureCoscheduler(newPod *k8sV1.Pod, scheduler string) {
@@ -294,10 +342,35 @@ func (p *pod) configurePodSpec(
 	podSpec.Spec.HostNetwork = p.taskSpec.TaskContainerDefaults.NetworkMode.IsHost()
 	podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, determinedInitContainers)
 	podSpec.Spec.RestartPolicy = k8sV1.RestartPolicyNever
+	p.configureNodePlatform(podSpec)
 
 	return podSpec
 }
 
+// configureNodePlatform restricts the pod to nodes of the platform requested by the task, unless
+// the pod spec already selects on those well-known node labels.
+func (p *pod) configureNodePlatform(podSpec *k8sV1.Pod) {
+	platform := p.taskSpec.ResourcesConfig().Platform()
+	if platform == "" {
+		return
+	}
+	parts := strings.SplitN(platform, "/", 2)
+	if len(parts) != 2 {
+		return
+	}
+	if podSpec.Spec.NodeSelector == nil {
+		podSpec.Spec.NodeSelector = make(map[string]string)
+	}
+	for key, value := range map[string]string{
+		"kubernetes.io/os":   parts[0],
+		"kubernetes.io/arch": parts[1],
+	} {
+		if _, ok := podSpec.Spec.NodeSelector[key]; !ok {
+			podSpec.Spec.NodeSelector[key] = value
+		}
+	}
+}
+
 func (p *pod) createPodSpec(ctx *actor.Context, scheduler string) error {
 	deviceType := device.CPU
 	if p.gpus > 0 {
@@ -312,6 +385,21 @@ func (p *pod) createPodSpec(ctx *actor.Context, scheduler string) error {
 		ctx, spec.Mounts(), runArchives,
 	)
 
+	workspace := spec.Workspace()
+	if workspace != nil {
+		claim, err := workspaceClaimSpec(p.namespace, spec.TaskID, *workspace)
+		if err != nil {
+			return err
+		}
+		p.workspaceClaim = claim
+		if _, owned := workspaceClaimName(spec.TaskID, *workspace); owned {
+			p.workspaceClaimName = claim.Name
+		}
+		workspaceMount, workspaceVolume := configureWorkspaceVolume(claim.Name, *workspace)
+		volumeMounts = append(volumeMounts, workspaceMount)
+		volumes = append(volumes, workspaceVolume)
+	}
+
 	env := spec.Environment()
 
 	for _, port := range env.Ports() {
@@ -420,6 +508,10 @@ func (p *pod) createPodSpec(ctx *actor.Context, scheduler string) error {
 
 	p.pod = p.configurePodSpec(
 		ctx, volumes, initContainer, container, sidecars, (*k8sV1.Pod)(env.PodSpec()), scheduler)
+	configureServiceAccountAndTolerations(p.pod, env.Kubernetes())
+	if workspace != nil {
+		configureWorkspaceOwnership(p.pod, spec.AgentUserGroup)
+	}
 
 	p.configMap, err = p.configureConfigMapSpec(runArchives, fluentFiles)
 	if err != nil {
@@ -428,6 +520,15 @@ func (p *pod) createPodSpec(ctx *actor.Context, scheduler string) error {
 	return nil
 }
 
+// configureServiceAccountAndTolerations applies the task's Kubernetes settings to its pod. They
+// take precedence over the service account in the pod spec and add to its tolerations.
+func configureServiceAccountAndTolerations(pod *k8sV1.Pod, config expconf.KubernetesConfig) {
+	if name := config.ServiceAccountName(); name != nil {
+		pod.Spec.ServiceAccountName = *name
+	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, config.Tolerations()...)
+}
+
 func configureUniqueName(t tasks.TaskSpec) string {
 	return fmt.Sprintf("%s-%s-%s", t.Description(), t.TaskID, petName.Generate(2, "-"))
 }
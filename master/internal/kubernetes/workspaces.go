@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+
+	k8sV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const workspaceVolumeName = "det-workspace"
+
+// workspaceClaimName returns the name of the persistent volume claim that backs a task's
+// workspace, and whether the claim belongs to the task alone and is deleted along with it.
+func workspaceClaimName(taskID string, config model.WorkspaceConfig) (string, bool) {
+	if config.ClaimName != "" {
+		return config.ClaimName, false
+	}
+	return "det-workspace-" + taskID, true
+}
+
+// workspaceClaimSpec returns the persistent volume claim to create for a task's workspace if it
+// does not exist yet.
+func workspaceClaimSpec(
+	namespace, taskID string, config model.WorkspaceConfig,
+) (*k8sV1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(config.Size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid workspace size %s", config.Size)
+	}
+	name, owned := workspaceClaimName(taskID, config)
+	claim := &k8sV1.PersistentVolumeClaim{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: k8sV1.PersistentVolumeClaimSpec{
+			AccessModes: []k8sV1.PersistentVolumeAccessMode{k8sV1.ReadWriteOnce},
+			Resources: k8sV1.ResourceRequirements{
+				Requests: k8sV1.ResourceList{k8sV1.ResourceStorage: size},
+			},
+			StorageClassName: config.StorageClass,
+		},
+	}
+	// Only claims that belong to a single task are cleaned up with Determined's other resources.
+	if owned {
+		claim.ObjectMeta.Labels = map[string]string{determinedLabel: taskID}
+	}
+	return claim, nil
+}
+
+func configureWorkspaceVolume(
+	claimName string, config model.WorkspaceConfig,
+) (k8sV1.VolumeMount, k8sV1.Volume) {
+	volumeMount := k8sV1.VolumeMount{
+		Name:      workspaceVolumeName,
+		MountPath: config.ContainerPath(),
+	}
+	volume := k8sV1.Volume{
+		Name: workspaceVolumeName,
+		VolumeSource: k8sV1.VolumeSource{
+			PersistentVolumeClaim: &k8sV1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	}
+	return volumeMount, volume
+}
+
+// configureWorkspaceOwnership makes the workspace volume writable by the task's user, unless the
+// pod spec already chose a group for its volumes.
+func configureWorkspaceOwnership(pod *k8sV1.Pod, agentUserGroup *model.AgentUserGroup) {
+	if agentUserGroup == nil {
+		return
+	}
+	if pod.Spec.SecurityContext == nil {
+		pod.Spec.SecurityContext = &k8sV1.PodSecurityContext{}
+	}
+	if pod.Spec.SecurityContext.FSGroup == nil {
+		groupID := int64(agentUserGroup.GID)
+		pod.Spec.SecurityContext.FSGroup = &groupID
+	}
+}
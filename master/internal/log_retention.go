@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+type pruneLogsTick struct{}
+
+// logPruner periodically deletes the trial logs that are past their retention, so that the
+// database does not grow without bound.
+type logPruner struct {
+	db     *db.PgDB
+	config LogRetentionConfig
+}
+
+func (p *logPruner) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case LogRetentionConfig:
+		// The master reloaded its configuration; the new interval applies after the next run.
+		p.config = msg
+
+	case actor.PreStart, pruneLogsTick:
+		// Don't return the error, since we want to keep this actor alive and try again next time.
+		pruned, err := p.db.PruneTrialLogs(p.config.Days, p.config.MaxLinesPerTrial)
+		if err != nil {
+			ctx.Log().WithError(err).Error("failed to prune trial logs")
+		}
+		if pruned > 0 {
+			ctx.Log().Infof("pruned %d trial log lines past their retention", pruned)
+		}
+		actors.NotifyAfter(ctx, time.Duration(p.config.PruneInterval)*time.Second, pruneLogsTick{})
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
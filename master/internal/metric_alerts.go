@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+type (
+	// metricAlertsState is the state of the alerts of the experiment for a trial, with the state of
+	// each alert at the same index as the alert.
+	metricAlertsState struct {
+		Rules []metricAlertState `json:"rules"`
+	}
+	// metricAlertState counts the reports in a row that broke the threshold of an alert, and whether
+	// the alert fired since its metric last met the threshold.
+	metricAlertState struct {
+		BrokenReports int  `json:"broken_reports"`
+		Fired         bool `json:"fired"`
+	}
+
+	// metricAlertFired is the message a trial sends to its experiment when an alert fires.
+	metricAlertFired struct {
+		trialID int
+		name    string
+		metric  string
+		value   float64
+		pause   bool
+	}
+)
+
+// check updates the state of the alerts with the metrics that the trial reported and returns the
+// alerts that fire. An alert fires once the metric broke its threshold for enough reports in a row,
+// and only fires again after the metric meets the threshold.
+func (s *metricAlertsState) check(
+	rules expconf.AlertsConfig, metrics map[string]interface{},
+) []metricAlertFired {
+	for len(s.Rules) < len(rules) {
+		s.Rules = append(s.Rules, metricAlertState{})
+	}
+
+	var fired []metricAlertFired
+	for i, rule := range rules {
+		value, ok := metricValue(metrics[rule.Metric()])
+		if !ok {
+			continue
+		}
+		state := &s.Rules[i]
+		if !breaksThreshold(value, rule) {
+			*state = metricAlertState{}
+			continue
+		}
+		state.BrokenReports++
+		if state.Fired || state.BrokenReports < rule.ForReports() {
+			continue
+		}
+		state.Fired = true
+		fired = append(fired, metricAlertFired{
+			name:   alertName(rule),
+			metric: rule.Metric(),
+			value:  value,
+			pause:  rule.Action() == expconf.PauseAction,
+		})
+	}
+	return fired
+}
+
+func breaksThreshold(value float64, rule expconf.AlertRule) bool {
+	return (rule.Above() != nil && value > *rule.Above()) ||
+		(rule.Below() != nil && value < *rule.Below())
+}
+
+// alertName returns the name of the alert, or describes its threshold if it has none.
+func alertName(rule expconf.AlertRule) string {
+	switch {
+	case rule.Name() != nil:
+		return *rule.Name()
+	case rule.Above() != nil:
+		return fmt.Sprintf("%s above %v", rule.Metric(), *rule.Above())
+	default:
+		return fmt.Sprintf("%s below %v", rule.Metric(), *rule.Below())
+	}
+}
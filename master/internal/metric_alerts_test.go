@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func TestMetricAlerts(t *testing.T) {
+	rules := schemas.WithDefaults(expconf.AlertsConfig{
+		{
+			RawMetric:     "loss",
+			RawAbove:      ptrs.Float64Ptr(10),
+			RawForReports: ptrs.IntPtr(2),
+			RawAction:     ptrs.StringPtr(expconf.PauseAction),
+		},
+		{RawName: ptrs.StringPtr("slow"), RawMetric: "samples_per_second", RawBelow: ptrs.Float64Ptr(5)},
+	}).(expconf.AlertsConfig)
+
+	var state metricAlertsState
+	assert.Equal(t, len(state.check(rules, map[string]interface{}{"loss": 11.0})), 0)
+	fired := state.check(rules, map[string]interface{}{"loss": 12.0})
+	assert.Equal(t, len(fired), 1)
+	assert.Equal(t, fired[0],
+		metricAlertFired{name: "loss above 10", metric: "loss", value: 12, pause: true})
+
+	// An alert does not fire again until its metric meets the threshold.
+	assert.Equal(t, len(state.check(rules, map[string]interface{}{"loss": 13.0})), 0)
+	assert.Equal(t, len(state.check(rules, map[string]interface{}{"loss": 1.0})), 0)
+	assert.Equal(t, len(state.check(rules, map[string]interface{}{"loss": 11.0})), 0)
+	assert.Equal(t, len(state.check(rules, map[string]interface{}{"loss": 11.0})), 1)
+
+	fired = state.check(rules, map[string]interface{}{"samples_per_second": 4})
+	assert.Equal(t, len(fired), 1)
+	assert.Equal(t, fired[0], metricAlertFired{name: "slow", metric: "samples_per_second", value: 4})
+}
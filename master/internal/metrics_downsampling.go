@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+// downsampleTrialsBatch is the number of trials whose metrics are downsampled in each statement,
+// which bounds how long the statement holds its locks.
+const downsampleTrialsBatch = 100
+
+type downsampleMetricsTick struct{}
+
+// metricsDownsampler periodically thins out the training metrics of old experiments, so that the
+// metrics of trials with many steps stay quick to query.
+type metricsDownsampler struct {
+	db     *db.PgDB
+	config MetricsDownsamplingConfig
+}
+
+func (d *metricsDownsampler) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart, downsampleMetricsTick:
+		totalTrials, totalSteps := 0, 0
+		for {
+			trials, steps, err := d.db.DownsampleTrialMetrics(
+				d.config.AfterDays, d.config.MaxStepsPerTrial, downsampleTrialsBatch)
+			if err != nil {
+				// Don't return the error, since we want to keep this actor alive and try again
+				// next time.
+				ctx.Log().WithError(err).Error("failed to downsample trial metrics")
+				break
+			}
+			totalTrials += trials
+			totalSteps += steps
+			if trials < downsampleTrialsBatch {
+				break
+			}
+		}
+		if totalTrials > 0 {
+			ctx.Log().Infof("downsampled the metrics of %d trials, deleting %d steps",
+				totalTrials, totalSteps)
+		}
+		actors.NotifyAfter(ctx, time.Duration(d.config.Interval)*time.Second, downsampleMetricsTick{})
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
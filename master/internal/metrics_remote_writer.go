@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+)
+
+// remoteWriteTimeout bounds how long each write to the remote write endpoint takes.
+const remoteWriteTimeout = 30 * time.Second
+
+type remoteWriteMetricsTick struct{}
+
+// metricsRemoteWriter periodically exports the selected metrics of trials and of the cluster to a
+// Prometheus-compatible remote write endpoint, so that teams can alert on the progress of training
+// with their existing monitoring.
+type metricsRemoteWriter struct {
+	db     *db.PgDB
+	config PrometheusRemoteWriteConfig
+	client *prom.RemoteWriteClient
+
+	// since is the time up to which the metrics that trials reported were written.
+	since time.Time
+}
+
+func (w *metricsRemoteWriter) Receive(ctx *actor.Context) error {
+	switch ctx.Message().(type) {
+	case actor.PreStart:
+		w.client = &prom.RemoteWriteClient{
+			URL:         w.config.URL,
+			BearerToken: w.config.BearerToken,
+			Client:      &http.Client{Timeout: remoteWriteTimeout},
+		}
+		// Metrics that trials reported before the master started are not backfilled.
+		w.since = time.Now()
+		actors.NotifyAfter(ctx, w.interval(), remoteWriteMetricsTick{})
+
+	case remoteWriteMetricsTick:
+		w.write(ctx)
+		actors.NotifyAfter(ctx, w.interval(), remoteWriteMetricsTick{})
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (w *metricsRemoteWriter) interval() time.Duration {
+	return time.Duration(w.config.Interval) * time.Second
+}
+
+func (w *metricsRemoteWriter) write(ctx *actor.Context) {
+	until := time.Now()
+	series := w.clusterSeries(until)
+	trialSeries, err := w.trialSeries(until)
+	if err != nil {
+		// Don't return the error, since we want to keep this actor alive and try again next time.
+		ctx.Log().WithError(err).Error("failed to query trial metrics to remote-write")
+		return
+	}
+	series = append(series, trialSeries...)
+	for _, s := range series {
+		for name, value := range w.config.Labels {
+			s.Labels[name] = value
+		}
+	}
+
+	if len(series) > 0 {
+		writeCtx, cancel := context.WithTimeout(context.Background(), remoteWriteTimeout)
+		defer cancel()
+		retry, err := w.client.Write(writeCtx, series)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("failed to remote-write %d series", len(series))
+			// The metrics of trials are written again next time, unless the endpoint rejected them.
+			if retry {
+				return
+			}
+		}
+	}
+	w.since = until
+}
+
+// clusterSeries returns the series of the metrics of the master that are selected for export.
+func (w *metricsRemoteWriter) clusterSeries(t time.Time) []prom.TimeSeries {
+	var selected []prom.Metric
+	for _, m := range prom.Gather() {
+		for _, prefix := range w.config.ClusterMetrics {
+			if strings.HasPrefix(m.Name, prefix) {
+				selected = append(selected, m)
+				break
+			}
+		}
+	}
+	return prom.Series(selected, t)
+}
+
+// trialSeries returns a series for each selected metric of each trial that reported it since the
+// last write, named det_trial_training_metric or det_trial_validation_metric.
+func (w *metricsRemoteWriter) trialSeries(until time.Time) ([]prom.TimeSeries, error) {
+	if len(w.config.TrainingMetrics) == 0 && len(w.config.ValidationMetrics) == 0 {
+		return nil, nil
+	}
+	metrics, err := w.db.TrialMetricsReportedBetween(
+		w.config.TrainingMetrics, w.config.ValidationMetrics, w.since, until)
+	if err != nil {
+		return nil, err
+	}
+	return trialMetricSeries(metrics), nil
+}
+
+func trialMetricSeries(metrics []db.ReportedMetric) []prom.TimeSeries {
+	type seriesKey struct {
+		kind    string
+		trialID int
+		name    string
+	}
+	var series []prom.TimeSeries
+	indexes := map[seriesKey]int{}
+	for _, m := range metrics {
+		key := seriesKey{kind: m.Kind, trialID: m.TrialID, name: m.Name}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(series)
+			indexes[key] = i
+			series = append(series, prom.TimeSeries{Labels: map[string]string{
+				"__name__":      "det_trial_" + m.Kind + "_metric",
+				"experiment_id": strconv.Itoa(m.ExperimentID),
+				"trial_id":      strconv.Itoa(m.TrialID),
+				"metric":        m.Name,
+			}})
+		}
+		series[i].Samples = append(series[i].Samples, prom.RemoteSample{
+			Value: m.Value, Timestamp: m.EndTime,
+		})
+	}
+	return series
+}
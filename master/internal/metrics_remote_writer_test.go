@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/prom"
+)
+
+func TestTrialMetricSeries(t *testing.T) {
+	t0 := time.Unix(1625140800, 0)
+	t1 := t0.Add(time.Minute)
+	series := trialMetricSeries([]db.ReportedMetric{
+		{Kind: "training", ExperimentID: 1, TrialID: 2, Name: "loss", Value: 0.5, EndTime: t0},
+		{Kind: "validation", ExperimentID: 1, TrialID: 2, Name: "loss", Value: 0.7, EndTime: t0},
+		{Kind: "training", ExperimentID: 1, TrialID: 2, Name: "loss", Value: 0.4, EndTime: t1},
+	})
+	assert.DeepEqual(t, series, []prom.TimeSeries{
+		{
+			Labels: map[string]string{
+				"__name__":      "det_trial_training_metric",
+				"experiment_id": "1",
+				"trial_id":      "2",
+				"metric":        "loss",
+			},
+			Samples: []prom.RemoteSample{
+				{Value: 0.5, Timestamp: t0},
+				{Value: 0.4, Timestamp: t1},
+			},
+		},
+		{
+			Labels: map[string]string{
+				"__name__":      "det_trial_validation_metric",
+				"experiment_id": "1",
+				"trial_id":      "2",
+				"metric":        "loss",
+			},
+			Samples: []prom.RemoteSample{{Value: 0.7, Timestamp: t0}},
+		},
+	})
+}
+
+func TestPrometheusRemoteWriteConfigValidate(t *testing.T) {
+	config := DefaultConfig().PrometheusRemoteWrite
+	assert.Equal(t, len(config.Validate()), 0)
+
+	config.URL = "https://prometheus.example.com/api/v1/write"
+	config.Labels = map[string]string{"cluster": "prod"}
+	for _, err := range config.Validate() {
+		assert.NilError(t, err)
+	}
+
+	config.URL = "prometheus.example.com"
+	config.Labels = map[string]string{"__cluster": "prod"}
+	var errs []error
+	for _, err := range config.Validate() {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	assert.Equal(t, len(errs), 2)
+}
@@ -0,0 +1,20 @@
+package mlflow
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAPIHandler registers the read-only routes of the MLflow tracking API. MLflow clients
+// authenticate with the token of a Determined user, which they send as a bearer token when it is
+// set in MLFLOW_TRACKING_TOKEN.
+func RegisterAPIHandler(echo *echo.Echo, s *Service, m ...echo.MiddlewareFunc) {
+	g := echo.Group("/api/2.0/mlflow", m...)
+	g.GET("/experiments/list", route(s.searchExperiments))
+	g.GET("/experiments/search", route(s.searchExperiments))
+	g.POST("/experiments/search", route(s.searchExperiments))
+	g.GET("/experiments/get", route(s.getExperiment))
+	g.GET("/experiments/get-by-name", route(s.getExperimentByName))
+	g.GET("/runs/get", route(s.getRun))
+	g.POST("/runs/search", route(s.searchRuns))
+	g.GET("/metrics/get-history", route(s.getMetricHistory))
+}
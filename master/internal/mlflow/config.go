@@ -0,0 +1,7 @@
+package mlflow
+
+// Config is the configuration of the MLflow tracking API of the master.
+type Config struct {
+	// Enabled exposes the experiments and trials of the cluster as MLflow experiments and runs.
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,312 @@
+package mlflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// See https://mlflow.org/docs/latest/rest-api.html.
+const (
+	lifecycleActive  = "active"
+	lifecycleDeleted = "deleted"
+
+	viewActiveOnly  = "ACTIVE_ONLY"
+	viewDeletedOnly = "DELETED_ONLY"
+	viewAll         = "ALL"
+
+	// Metrics are named after the kind of workload that reported them, since trials often report
+	// training and validation metrics with the same names.
+	trainingPrefix   = "training/"
+	validationPrefix = "validation/"
+
+	// defaultMaxResults is the page size of searches that do not set one, as in MLflow.
+	defaultMaxResults = 1000
+)
+
+// mlflowError is an error response of the MLflow API.
+type mlflowError struct {
+	status    int
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (e *mlflowError) Error() string {
+	return e.Message
+}
+
+func notFound(format string, args ...interface{}) *mlflowError {
+	return &mlflowError{
+		status:    http.StatusNotFound,
+		ErrorCode: "RESOURCE_DOES_NOT_EXIST",
+		Message:   fmt.Sprintf(format, args...),
+	}
+}
+
+func invalidParameter(format string, args ...interface{}) *mlflowError {
+	return &mlflowError{
+		status:    http.StatusBadRequest,
+		ErrorCode: "INVALID_PARAMETER_VALUE",
+		Message:   fmt.Sprintf(format, args...),
+	}
+}
+
+type tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type experiment struct {
+	ExperimentID     string `json:"experiment_id"`
+	Name             string `json:"name"`
+	ArtifactLocation string `json:"artifact_location"`
+	LifecycleStage   string `json:"lifecycle_stage"`
+	CreationTime     int64  `json:"creation_time"`
+	LastUpdateTime   int64  `json:"last_update_time"`
+	Tags             []tag  `json:"tags,omitempty"`
+}
+
+type metric struct {
+	Key       string  `json:"key" db:"key"`
+	Value     float64 `json:"value" db:"value"`
+	Timestamp int64   `json:"timestamp" db:"timestamp"`
+	Step      int64   `json:"step" db:"step"`
+}
+
+type runInfo struct {
+	RunID          string `json:"run_id"`
+	RunUUID        string `json:"run_uuid"`
+	RunName        string `json:"run_name"`
+	ExperimentID   string `json:"experiment_id"`
+	UserID         string `json:"user_id"`
+	Status         string `json:"status"`
+	StartTime      int64  `json:"start_time"`
+	EndTime        int64  `json:"end_time,omitempty"`
+	ArtifactURI    string `json:"artifact_uri"`
+	LifecycleStage string `json:"lifecycle_stage"`
+}
+
+type runData struct {
+	Metrics []metric `json:"metrics"`
+	Params  []tag    `json:"params"`
+	Tags    []tag    `json:"tags"`
+}
+
+type run struct {
+	Info runInfo `json:"info"`
+	Data runData `json:"data"`
+}
+
+// experimentRow is a Determined experiment, as returned by mlflow_get_experiments.sql.
+type experimentRow struct {
+	ID          int        `db:"id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	Labels      []byte     `db:"labels"`
+	Archived    bool       `db:"archived"`
+	StartTime   time.Time  `db:"start_time"`
+	EndTime     *time.Time `db:"end_time"`
+	Username    string     `db:"username"`
+}
+
+// trialRow is a Determined trial along with the latest value of each of its metrics, as returned
+// by mlflow_get_runs.sql.
+type trialRow struct {
+	ID           int         `db:"id"`
+	ExperimentID int         `db:"experiment_id"`
+	State        model.State `db:"state"`
+	StartTime    time.Time   `db:"start_time"`
+	EndTime      *time.Time  `db:"end_time"`
+	HParams      []byte      `db:"hparams"`
+	Archived     bool        `db:"archived"`
+	Username     string      `db:"username"`
+	Metrics      []byte      `db:"metrics"`
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func lifecycleStage(archived bool) string {
+	if archived {
+		return lifecycleDeleted
+	}
+	return lifecycleActive
+}
+
+// toExperiment converts a Determined experiment to an MLflow experiment. Archived experiments are
+// reported as deleted, which hides them from MLflow clients by default.
+func toExperiment(row experimentRow) experiment {
+	exp := experiment{
+		ExperimentID:   strconv.Itoa(row.ID),
+		Name:           row.Name,
+		LifecycleStage: lifecycleStage(row.Archived),
+		CreationTime:   toMillis(row.StartTime),
+		LastUpdateTime: toMillis(row.StartTime),
+		Tags:           []tag{{Key: "mlflow.user", Value: row.Username}},
+	}
+	if row.EndTime != nil {
+		exp.LastUpdateTime = toMillis(*row.EndTime)
+	}
+	if row.Description != "" {
+		exp.Tags = append(exp.Tags, tag{Key: "mlflow.note.content", Value: row.Description})
+	}
+	var labels []string
+	if err := json.Unmarshal(row.Labels, &labels); err == nil {
+		for _, label := range labels {
+			exp.Tags = append(exp.Tags, tag{Key: "determined.label." + label, Value: "true"})
+		}
+	}
+	return exp
+}
+
+// runStatus maps the state of a trial to the status of an MLflow run.
+func runStatus(state model.State) string {
+	switch state {
+	case model.CompletedState:
+		return "FINISHED"
+	case model.ErrorState:
+		return "FAILED"
+	case model.CanceledState:
+		return "KILLED"
+	default:
+		return "RUNNING"
+	}
+}
+
+// flattenParams converts the hyperparameters of a trial to MLflow params, naming nested
+// hyperparameters by their path and rendering values that are not strings as JSON.
+func flattenParams(prefix string, hparams map[string]interface{}, params []tag) []tag {
+	keys := make([]string, 0, len(hparams))
+	for key := range hparams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch value := hparams[key].(type) {
+		case map[string]interface{}:
+			params = flattenParams(prefix+key+".", value, params)
+		case string:
+			params = append(params, tag{Key: prefix + key, Value: value})
+		default:
+			rendered, err := json.Marshal(value)
+			if err != nil {
+				rendered = []byte(fmt.Sprint(value))
+			}
+			params = append(params, tag{Key: prefix + key, Value: string(rendered)})
+		}
+	}
+	return params
+}
+
+// toRun converts a Determined trial to an MLflow run.
+func toRun(row trialRow) (run, error) {
+	runID := strconv.Itoa(row.ID)
+	runName := fmt.Sprintf("Trial %d", row.ID)
+	r := run{
+		Info: runInfo{
+			RunID:          runID,
+			RunUUID:        runID,
+			RunName:        runName,
+			ExperimentID:   strconv.Itoa(row.ExperimentID),
+			UserID:         row.Username,
+			Status:         runStatus(row.State),
+			StartTime:      toMillis(row.StartTime),
+			LifecycleStage: lifecycleStage(row.Archived),
+		},
+		Data: runData{
+			Metrics: []metric{},
+			Params:  []tag{},
+			Tags: []tag{
+				{Key: "mlflow.runName", Value: runName},
+				{Key: "mlflow.user", Value: row.Username},
+			},
+		},
+	}
+	if row.EndTime != nil {
+		r.Info.EndTime = toMillis(*row.EndTime)
+	}
+
+	var hparams map[string]interface{}
+	if len(row.HParams) > 0 {
+		if err := json.Unmarshal(row.HParams, &hparams); err != nil {
+			return run{}, errors.Wrapf(err, "parsing hyperparameters of trial %d", row.ID)
+		}
+	}
+	r.Data.Params = flattenParams("", hparams, r.Data.Params)
+	if len(row.Metrics) > 0 {
+		if err := json.Unmarshal(row.Metrics, &r.Data.Metrics); err != nil {
+			return run{}, errors.Wrapf(err, "parsing metrics of trial %d", row.ID)
+		}
+	}
+	return r, nil
+}
+
+// splitMetricKey splits the key of an MLflow metric into the kind of workload that reported it and
+// the name of the metric in Determined.
+func splitMetricKey(key string) (kind, name string, ok bool) {
+	switch {
+	case strings.HasPrefix(key, trainingPrefix):
+		return "training", strings.TrimPrefix(key, trainingPrefix), true
+	case strings.HasPrefix(key, validationPrefix):
+		return "validation", strings.TrimPrefix(key, validationPrefix), true
+	default:
+		return "", "", false
+	}
+}
+
+// parseID parses the ID of an experiment or a run.
+func parseID(param, value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, invalidParameter("invalid value %q for parameter '%s'", value, param)
+	}
+	return id, nil
+}
+
+// parseViewType returns the view type of a search, which defaults to active resources only.
+func parseViewType(value string) (string, error) {
+	switch value {
+	case "", viewActiveOnly:
+		return viewActiveOnly, nil
+	case viewDeletedOnly, viewAll:
+		return value, nil
+	default:
+		return "", invalidParameter("invalid value %q for parameter 'view_type'", value)
+	}
+}
+
+// parsePage returns the offset and the size of the page that a search asks for. Page tokens are
+// the offsets of the pages they continue from.
+func parsePage(maxResults int, pageToken string) (offset, limit int, err error) {
+	if maxResults < 0 {
+		return 0, 0, invalidParameter("invalid value %d for parameter 'max_results'", maxResults)
+	}
+	limit = maxResults
+	if limit == 0 {
+		limit = defaultMaxResults
+	}
+	if pageToken != "" {
+		if offset, err = strconv.Atoi(pageToken); err != nil || offset < 0 {
+			return 0, 0, invalidParameter("invalid page token %q", pageToken)
+		}
+	}
+	return offset, limit, nil
+}
+
+// nextPageToken returns the token of the page after the one at the offset, given the number of
+// results found when asking for one more result than fits in the page.
+func nextPageToken(offset, limit, found int) string {
+	if found <= limit {
+		return ""
+	}
+	return strconv.Itoa(offset + limit)
+}
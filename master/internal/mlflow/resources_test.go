@@ -0,0 +1,106 @@
+package mlflow
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestFlattenParams(t *testing.T) {
+	params := flattenParams("", map[string]interface{}{
+		"lr":        0.01,
+		"optimizer": "adam",
+		"layers":    []interface{}{64.0, 32.0},
+		"model": map[string]interface{}{
+			"dropout": 0.5,
+			"norm":    true,
+		},
+	}, nil)
+	assert.DeepEqual(t, params, []tag{
+		{Key: "layers", Value: "[64,32]"},
+		{Key: "lr", Value: "0.01"},
+		{Key: "model.dropout", Value: "0.5"},
+		{Key: "model.norm", Value: "true"},
+		{Key: "optimizer", Value: "adam"},
+	})
+}
+
+func TestToRun(t *testing.T) {
+	start := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	r, err := toRun(trialRow{
+		ID:           7,
+		ExperimentID: 3,
+		State:        model.CompletedState,
+		StartTime:    start,
+		EndTime:      &end,
+		HParams:      []byte(`{"lr": 0.1}`),
+		Username:     "alice",
+		Metrics: []byte(`[
+			{"key": "training/loss", "value": 0.5, "timestamp": 1625144400000, "step": 100},
+			{"key": "validation/loss", "value": 0.6, "timestamp": 1625144400000, "step": 100}
+		]`),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, r.Info.RunID, "7")
+	assert.Equal(t, r.Info.ExperimentID, "3")
+	assert.Equal(t, r.Info.Status, "FINISHED")
+	assert.Equal(t, r.Info.StartTime, int64(1625140800000))
+	assert.Equal(t, r.Info.EndTime, int64(1625144400000))
+	assert.Equal(t, r.Info.LifecycleStage, lifecycleActive)
+	assert.DeepEqual(t, r.Data.Params, []tag{{Key: "lr", Value: "0.1"}})
+	assert.DeepEqual(t, r.Data.Metrics, []metric{
+		{Key: "training/loss", Value: 0.5, Timestamp: 1625144400000, Step: 100},
+		{Key: "validation/loss", Value: 0.6, Timestamp: 1625144400000, Step: 100},
+	})
+}
+
+func TestRunStatus(t *testing.T) {
+	for state, expected := range map[model.State]string{
+		model.ActiveState:            "RUNNING",
+		model.PausedState:            "RUNNING",
+		model.StoppingCompletedState: "RUNNING",
+		model.CompletedState:         "FINISHED",
+		model.CanceledState:          "KILLED",
+		model.ErrorState:             "FAILED",
+	} {
+		assert.Equal(t, runStatus(state), expected, "state %s", state)
+	}
+}
+
+func TestSplitMetricKey(t *testing.T) {
+	kind, name, ok := splitMetricKey("validation/accuracy")
+	assert.Assert(t, ok)
+	assert.Equal(t, kind, "validation")
+	assert.Equal(t, name, "accuracy")
+
+	kind, name, ok = splitMetricKey("training/loss/sum")
+	assert.Assert(t, ok)
+	assert.Equal(t, kind, "training")
+	assert.Equal(t, name, "loss/sum")
+
+	_, _, ok = splitMetricKey("loss")
+	assert.Assert(t, !ok)
+}
+
+func TestPage(t *testing.T) {
+	offset, limit, err := parsePage(0, "")
+	assert.NilError(t, err)
+	assert.Equal(t, offset, 0)
+	assert.Equal(t, limit, defaultMaxResults)
+	assert.Equal(t, nextPageToken(offset, limit, defaultMaxResults), "")
+
+	offset, limit, err = parsePage(10, "20")
+	assert.NilError(t, err)
+	assert.Equal(t, offset, 20)
+	assert.Equal(t, limit, 10)
+	assert.Equal(t, nextPageToken(offset, limit, 11), "30")
+
+	_, _, err = parsePage(10, "next")
+	assert.ErrorContains(t, err, "invalid page token")
+	_, _, err = parsePage(-1, "")
+	assert.ErrorContains(t, err, "max_results")
+}
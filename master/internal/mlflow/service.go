@@ -0,0 +1,285 @@
+package mlflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// Service is a read-only MLflow tracking server backed by the database of the master. Experiments
+// are exposed as MLflow experiments and their trials as runs, with the hyperparameters of the
+// trials as params and their training and validation metrics as metrics.
+type Service struct {
+	db *db.PgDB
+}
+
+// New creates an MLflow service.
+func New(db *db.PgDB) *Service {
+	return &Service{db: db}
+}
+
+// route turns a handler into an echo handler that renders errors as MLflow error responses.
+func route(handler func(c echo.Context) (interface{}, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := handler(c)
+		if err != nil {
+			var mlflowErr *mlflowError
+			if !errors.As(err, &mlflowErr) {
+				log.WithError(err).Errorf("error handling MLflow request %s %s",
+					c.Request().Method, c.Request().URL.Path)
+				mlflowErr = &mlflowError{
+					status:    http.StatusInternalServerError,
+					ErrorCode: "INTERNAL_ERROR",
+					Message:   "internal server error",
+				}
+			}
+			return c.JSON(mlflowErr.status, mlflowErr)
+		}
+		return c.JSON(http.StatusOK, body)
+	}
+}
+
+// int64Value is an integer that MLflow clients may send either as a number or, as protobuf
+// renders 64-bit integers in JSON, as a string.
+type int64Value int64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *int64Value) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		var s string
+		if err = json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		n = json.Number(s)
+	}
+	value, err := n.Int64()
+	*i = int64Value(value)
+	return err
+}
+
+// searchRequest holds the parameters shared by searches for experiments and runs.
+type searchRequest struct {
+	ExperimentIDs []string   `json:"experiment_ids"`
+	Filter        string     `json:"filter"`
+	ViewType      string     `json:"view_type"`
+	RunViewType   string     `json:"run_view_type"`
+	MaxResults    int64Value `json:"max_results"`
+	PageToken     string     `json:"page_token"`
+}
+
+// bindSearch reads the parameters of a search from the body of a POST request or from the query
+// of a GET request.
+func bindSearch(c echo.Context) (*searchRequest, error) {
+	req := &searchRequest{}
+	if c.Request().Method == http.MethodPost {
+		if err := json.NewDecoder(c.Request().Body).Decode(req); err != nil {
+			return nil, invalidParameter("malformed request body: %s", err)
+		}
+	} else {
+		req.Filter = c.QueryParam("filter")
+		req.ViewType = c.QueryParam("view_type")
+		req.PageToken = c.QueryParam("page_token")
+		if maxResults := c.QueryParam("max_results"); maxResults != "" {
+			value, err := strconv.ParseInt(maxResults, 10, 64)
+			if err != nil {
+				return nil, invalidParameter(
+					"invalid value %q for parameter 'max_results'", maxResults)
+			}
+			req.MaxResults = int64Value(value)
+		}
+	}
+	if req.Filter != "" {
+		return nil, invalidParameter("search filters are not supported")
+	}
+	return req, nil
+}
+
+func (s *Service) experiments(
+	id int, name, viewType string, offset, limit int,
+) ([]experiment, error) {
+	var rows []experimentRow
+	if err := s.db.Query(
+		"mlflow_get_experiments", &rows, id, name, viewType, offset, limit,
+	); err != nil {
+		return nil, errors.Wrap(err, "querying experiments")
+	}
+	exps := make([]experiment, 0, len(rows))
+	for _, row := range rows {
+		exps = append(exps, toExperiment(row))
+	}
+	return exps, nil
+}
+
+func (s *Service) runs(
+	id int, experimentIDs []int, viewType string, offset, limit int,
+) ([]run, error) {
+	ids := make([]string, 0, len(experimentIDs))
+	for _, experimentID := range experimentIDs {
+		ids = append(ids, strconv.Itoa(experimentID))
+	}
+	var rows []trialRow
+	if err := s.db.Query(
+		"mlflow_get_runs", &rows, id, strings.Join(ids, ","), viewType, offset, limit,
+	); err != nil {
+		return nil, errors.Wrap(err, "querying trials")
+	}
+	runs := make([]run, 0, len(rows))
+	for _, row := range rows {
+		r, err := toRun(row)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, nil
+}
+
+func (s *Service) searchExperiments(c echo.Context) (interface{}, error) {
+	req, err := bindSearch(c)
+	if err != nil {
+		return nil, err
+	}
+	viewType, err := parseViewType(req.ViewType)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit, err := parsePage(int(req.MaxResults), req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	exps, err := s.experiments(0, "", viewType, offset, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	next := nextPageToken(offset, limit, len(exps))
+	if len(exps) > limit {
+		exps = exps[:limit]
+	}
+	return map[string]interface{}{"experiments": exps, "next_page_token": next}, nil
+}
+
+func (s *Service) getExperiment(c echo.Context) (interface{}, error) {
+	id, err := parseID("experiment_id", c.QueryParam("experiment_id"))
+	if err != nil {
+		return nil, err
+	}
+	exps, err := s.experiments(id, "", viewAll, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(exps) == 0 {
+		return nil, notFound("No Experiment with id=%d exists", id)
+	}
+	return map[string]interface{}{"experiment": exps[0]}, nil
+}
+
+// getExperimentByName returns the latest experiment with the name, since the names of Determined
+// experiments are not unique.
+func (s *Service) getExperimentByName(c echo.Context) (interface{}, error) {
+	name := c.QueryParam("experiment_name")
+	if name == "" {
+		return nil, invalidParameter("missing value for required parameter 'experiment_name'")
+	}
+	exps, err := s.experiments(0, name, viewAll, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(exps) == 0 {
+		return nil, notFound("Experiment '%s' does not exist", name)
+	}
+	return map[string]interface{}{"experiment": exps[0]}, nil
+}
+
+// runID returns the ID of the run of a request, which older MLflow clients call run_uuid.
+func runID(c echo.Context) (int, error) {
+	if value := c.QueryParam("run_id"); value != "" {
+		return parseID("run_id", value)
+	}
+	return parseID("run_id", c.QueryParam("run_uuid"))
+}
+
+func (s *Service) getRun(c echo.Context) (interface{}, error) {
+	id, err := runID(c)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := s.runs(id, nil, viewAll, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, notFound("Run '%d' not found", id)
+	}
+	return map[string]interface{}{"run": runs[0]}, nil
+}
+
+func (s *Service) searchRuns(c echo.Context) (interface{}, error) {
+	req, err := bindSearch(c)
+	if err != nil {
+		return nil, err
+	}
+	viewType, err := parseViewType(req.RunViewType)
+	if err != nil {
+		return nil, err
+	}
+	offset, limit, err := parsePage(int(req.MaxResults), req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	experimentIDs := make([]int, 0, len(req.ExperimentIDs))
+	for _, value := range req.ExperimentIDs {
+		id, err := parseID("experiment_ids", value)
+		if err != nil {
+			return nil, err
+		}
+		experimentIDs = append(experimentIDs, id)
+	}
+	if len(experimentIDs) == 0 {
+		return nil, invalidParameter("missing value for required parameter 'experiment_ids'")
+	}
+	runs, err := s.runs(0, experimentIDs, viewType, offset, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	next := nextPageToken(offset, limit, len(runs))
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return map[string]interface{}{"runs": runs, "next_page_token": next}, nil
+}
+
+func (s *Service) getMetricHistory(c echo.Context) (interface{}, error) {
+	id, err := runID(c)
+	if err != nil {
+		return nil, err
+	}
+	key := c.QueryParam("metric_key")
+	kind, name, ok := splitMetricKey(key)
+	if !ok {
+		return nil, invalidParameter(
+			"metric keys must start with '%s' or '%s'", trainingPrefix, validationPrefix)
+	}
+	runs, err := s.runs(id, nil, viewAll, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, notFound("Run '%d' not found", id)
+	}
+	metrics := []metric{}
+	if err = s.db.Query("mlflow_get_metric_history", &metrics, id, kind, name); err != nil {
+		return nil, errors.Wrap(err, "querying metrics")
+	}
+	for i := range metrics {
+		metrics[i].Key = key
+	}
+	return map[string]interface{}{"metrics": metrics}, nil
+}
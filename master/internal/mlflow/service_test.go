@@ -0,0 +1,46 @@
+package mlflow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"gotest.tools/assert"
+)
+
+func TestBindSearch(t *testing.T) {
+	body := `{"experiment_ids": ["1", "2"], "max_results": "50", "run_view_type": "ALL"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/2.0/mlflow/runs/search",
+		strings.NewReader(body))
+	search, err := bindSearch(echo.New().NewContext(req, httptest.NewRecorder()))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, search.ExperimentIDs, []string{"1", "2"})
+	assert.Equal(t, search.MaxResults, int64Value(50))
+	assert.Equal(t, search.RunViewType, viewAll)
+
+	req = httptest.NewRequest(http.MethodGet,
+		"/api/2.0/mlflow/experiments/search?max_results=5&page_token=10", nil)
+	search, err = bindSearch(echo.New().NewContext(req, httptest.NewRecorder()))
+	assert.NilError(t, err)
+	assert.Equal(t, search.MaxResults, int64Value(5))
+	assert.Equal(t, search.PageToken, "10")
+
+	req = httptest.NewRequest(http.MethodGet,
+		"/api/2.0/mlflow/experiments/search?filter=name%3D%27a%27", nil)
+	_, err = bindSearch(echo.New().NewContext(req, httptest.NewRecorder()))
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestRouteErrors(t *testing.T) {
+	handler := route(func(c echo.Context) (interface{}, error) {
+		return nil, notFound("Run '%d' not found", 3)
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/2.0/mlflow/runs/get?run_id=3", nil)
+	assert.NilError(t, handler(echo.New().NewContext(req, rec)))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+	assert.Equal(t, strings.TrimSpace(rec.Body.String()),
+		`{"error_code":"RESOURCE_DOES_NOT_EXIST","message":"Run '3' not found"}`)
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// defaultResourcePool returns the resource pool of the tasks created in a workspace that name
+// none: the default pool of the workspace, or else the pool that pool routing sends them to.
+func defaultResourcePool(workspace *model.Workspace, routedPool string) string {
+	if workspace.DefaultResourcePool != "" {
+		return workspace.DefaultResourcePool
+	}
+	return routedPool
+}
+
+// checkPoolRouting returns an error unless a user may create a task in a resource pool within a
+// workspace, when pool routing sends tasks of its kind to routedPool. Choosing a pool other than
+// the one that the task gets by default, from its workspace, its config policies or the routing,
+// requires the override_pool_routing permission.
+func (m *Master) checkPoolRouting(
+	user *model.User, workspaceID int, routedPool, defaultPool, pool string,
+) error {
+	if routedPool == "" || pool == defaultPool {
+		return nil
+	}
+	permissions, err := m.db.UserPermissions(user, workspaceID)
+	if err != nil {
+		return err
+	}
+	if !permissions.Has(model.PermissionOverridePoolRouting) {
+		return errors.Errorf(
+			"user %s does not have the %s permission to use resource pool %s instead of %s",
+			user.Username, model.PermissionOverridePoolRouting, pool, defaultPool)
+	}
+	return nil
+}
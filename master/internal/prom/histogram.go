@@ -0,0 +1,73 @@
+package prom
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the buckets of latency histograms.
+var DefaultLatencyBuckets = []float64{
+	.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10,
+}
+
+// HistogramValue counts observations in cumulative buckets. It is safe for concurrent use.
+type HistogramValue struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramValue creates a histogram with buckets of the given upper bounds.
+func NewHistogramValue(bounds []float64) *HistogramValue {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+	return &HistogramValue{bounds: sorted, counts: make([]uint64, len(sorted))}
+}
+
+// Observe adds an observation to the histogram.
+func (h *HistogramValue) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := sort.SearchFloat64s(h.bounds, v); i < len(h.bounds) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// Samples returns the bucket, sum and count samples of the histogram with the given labels.
+func (h *HistogramValue) Samples(labels map[string]string) []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := make([]Sample, 0, len(h.bounds)+3)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		samples = append(samples, Sample{
+			Suffix: "_bucket",
+			Labels: withLabel(labels, "le", formatValue(bound)),
+			Value:  float64(cumulative),
+		})
+	}
+	return append(samples,
+		Sample{
+			Suffix: "_bucket",
+			Labels: withLabel(labels, "le", formatValue(math.Inf(1))),
+			Value:  float64(h.count),
+		},
+		Sample{Suffix: "_sum", Labels: labels, Value: h.sum},
+		Sample{Suffix: "_count", Labels: labels, Value: float64(h.count)},
+	)
+}
+
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
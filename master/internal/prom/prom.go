@@ -0,0 +1,176 @@
+// Package prom exposes master state in the Prometheus text exposition format.
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MetricType is the Prometheus type of a metric family.
+type MetricType string
+
+const (
+	// Gauge is a metric that can go up and down.
+	Gauge MetricType = "gauge"
+	// Counter is a metric that only increases.
+	Counter MetricType = "counter"
+	// Histogram is a metric that counts observations in buckets.
+	Histogram MetricType = "histogram"
+)
+
+// Sample is a single labeled value of a metric family.
+type Sample struct {
+	// Suffix is appended to the name of the metric family, e.g. "_bucket" for the buckets of a
+	// histogram.
+	Suffix string
+	Labels map[string]string
+	Value  float64
+}
+
+// Metric is a metric family, i.e. a named set of samples sharing a type and help text.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []Sample
+}
+
+// Collector returns the current value of a set of metrics.
+type Collector func() []Metric
+
+var (
+	collectorsLock sync.Mutex
+	collectors     = make(map[string]Collector)
+)
+
+// Register registers a collector under the given name, replacing any collector previously
+// registered under that name.
+func Register(name string, c Collector) {
+	collectorsLock.Lock()
+	defer collectorsLock.Unlock()
+	collectors[name] = c
+}
+
+// Unregister removes the collector registered under the given name.
+func Unregister(name string) {
+	collectorsLock.Lock()
+	defer collectorsLock.Unlock()
+	delete(collectors, name)
+}
+
+// Gather runs every registered collector and returns the resulting metrics sorted by name.
+func Gather() []Metric {
+	collectorsLock.Lock()
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cs := make([]Collector, 0, len(names))
+	for _, name := range names {
+		cs = append(cs, collectors[name])
+	}
+	collectorsLock.Unlock()
+
+	var metrics []Metric
+	for _, c := range cs {
+		metrics = append(metrics, c()...)
+	}
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	return metrics
+}
+
+// Handler returns an HTTP handler serving all registered metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := Write(w, Gather()); err != nil {
+			log.WithError(err).Error("failed to write prometheus metrics")
+		}
+	})
+}
+
+// Write renders the metrics in the Prometheus text exposition format.
+func Write(w io.Writer, metrics []Metric) error {
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		if m.Help != "" {
+			fmt.Fprintf(buf, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+		}
+		if m.Type != "" {
+			fmt.Fprintf(buf, "# TYPE %s %s\n", m.Name, m.Type)
+		}
+		for _, s := range m.Samples {
+			buf.WriteString(m.Name)
+			buf.WriteString(s.Suffix)
+			writeLabels(buf, s.Labels)
+			buf.WriteByte(' ')
+			buf.WriteString(formatValue(s.Value))
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Flush()
+}
+
+func writeLabels(buf *bufio.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%s=\"%s\"", k, escapeLabelValue(labels[k]))
+	}
+	buf.WriteByte('}')
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer("\\", `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, "\"", `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// BoolValue converts a boolean into a sample value.
+func BoolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
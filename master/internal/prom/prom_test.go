@@ -0,0 +1,65 @@
+package prom
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, []Metric{
+		{
+			Name: "det_agent_healthy",
+			Help: "Whether the agent is healthy.\nOne line.",
+			Type: Gauge,
+			Samples: []Sample{
+				{Labels: map[string]string{"resource_pool": "default", "agent_id": "a\"1"}, Value: 1},
+				{Value: math.Inf(1)},
+			},
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), `# HELP det_agent_healthy Whether the agent is healthy.\nOne line.
+# TYPE det_agent_healthy gauge
+det_agent_healthy{agent_id="a\"1",resource_pool="default"} 1
+det_agent_healthy +Inf
+`)
+}
+
+func TestGather(t *testing.T) {
+	Register("b", func() []Metric { return []Metric{{Name: "b_metric"}} })
+	Register("a", func() []Metric { return []Metric{{Name: "a_metric"}} })
+	defer Unregister("a")
+	defer Unregister("b")
+
+	metrics := Gather()
+	assert.Equal(t, len(metrics), 2)
+	assert.Equal(t, metrics[0].Name, "a_metric")
+	assert.Equal(t, metrics[1].Name, "b_metric")
+}
+
+func TestHistogramValue(t *testing.T) {
+	h := NewHistogramValue([]float64{1, 0.1})
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	var buf bytes.Buffer
+	err := Write(&buf, []Metric{{
+		Name:    "det_db_query_duration_seconds",
+		Type:    Histogram,
+		Samples: h.Samples(map[string]string{"operation": "query"}),
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, buf.String(), `# TYPE det_db_query_duration_seconds histogram
+det_db_query_duration_seconds_bucket{le="0.1",operation="query"} 2
+det_db_query_duration_seconds_bucket{le="1",operation="query"} 3
+det_db_query_duration_seconds_bucket{le="+Inf",operation="query"} 4
+det_db_query_duration_seconds_sum{operation="query"} 3.65
+det_db_query_duration_seconds_count{operation="query"} 4
+`)
+}
@@ -0,0 +1,150 @@
+package prom
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// See https://prometheus.io/docs/concepts/remote_write_spec/.
+const (
+	remoteWriteVersion = "0.1.0"
+	// maxErrorBody is how much of the body of a failed response is kept in the error.
+	maxErrorBody = 512
+)
+
+// RemoteSample is a value of a time series at a point in time.
+type RemoteSample struct {
+	Value     float64
+	Timestamp time.Time
+}
+
+// TimeSeries is a labeled series of samples, named by its __name__ label.
+type TimeSeries struct {
+	Labels  map[string]string
+	Samples []RemoteSample
+}
+
+// Series converts the samples of metrics into time series, all sampled at the given time.
+func Series(metrics []Metric, t time.Time) []TimeSeries {
+	var series []TimeSeries
+	for _, m := range metrics {
+		for _, s := range m.Samples {
+			series = append(series, TimeSeries{
+				Labels:  withLabel(s.Labels, "__name__", m.Name+s.Suffix),
+				Samples: []RemoteSample{{Value: s.Value, Timestamp: t}},
+			})
+		}
+	}
+	return series
+}
+
+// EncodeWriteRequest encodes time series as a snappy-compressed remote write request.
+func EncodeWriteRequest(series []TimeSeries) []byte {
+	var req []byte
+	for _, ts := range series {
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, encodeTimeSeries(ts))
+	}
+	return snappyEncode(req)
+}
+
+func encodeTimeSeries(ts TimeSeries) []byte {
+	// Receivers require the labels of a series to be sorted by name.
+	names := make([]string, 0, len(ts.Labels))
+	for name := range ts.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b []byte
+	for _, name := range names {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, ts.Labels[name])
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, label)
+	}
+	for _, s := range ts.Samples {
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.Value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(
+			sample, uint64(s.Timestamp.UnixNano()/int64(time.Millisecond)))
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, sample)
+	}
+	return b
+}
+
+// snappyEncode frames data in the snappy block format as a sequence of literals, which every snappy
+// decoder accepts. Metrics are small enough that compressing them is not worth a dependency.
+func snappyEncode(data []byte) []byte {
+	const maxLiteral = 1 << 16
+	b := protowire.AppendVarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxLiteral {
+			n = maxLiteral
+		}
+		if n <= 60 {
+			b = append(b, byte(n-1)<<2)
+		} else {
+			// A tag of 61 is followed by the length minus one in two little-endian bytes.
+			b = append(b, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		b = append(b, data[:n]...)
+		data = data[n:]
+	}
+	return b
+}
+
+// RemoteWriteClient writes time series to a Prometheus-compatible remote write endpoint.
+type RemoteWriteClient struct {
+	URL         string
+	BearerToken string
+	Client      *http.Client
+}
+
+// Write sends the time series to the endpoint. It returns whether a failed write may succeed if
+// retried, which is the case unless the endpoint rejected the series as invalid.
+func (c *RemoteWriteClient) Write(ctx context.Context, series []TimeSeries) (bool, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, c.URL, bytes.NewReader(EncodeWriteRequest(series)))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "determined-master")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", remoteWriteVersion)
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	retry := resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, errors.Errorf("remote write failed with status %s: %s",
+		resp.Status, bytes.TrimSpace(body))
+}
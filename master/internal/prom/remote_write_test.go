@@ -0,0 +1,131 @@
+package prom
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"gotest.tools/assert"
+)
+
+// snappyDecode decodes a snappy block made of literals, as produced by snappyEncode.
+func snappyDecode(t *testing.T, b []byte) []byte {
+	n, l := protowire.ConsumeVarint(b)
+	assert.Assert(t, l > 0)
+	b = b[l:]
+	var data []byte
+	for len(b) > 0 {
+		tag := b[0]
+		assert.Equal(t, tag&3, byte(0), "only literals are expected")
+		size := int(tag>>2) + 1
+		b = b[1:]
+		if tag>>2 == 61 {
+			size = int(b[0]) | int(b[1])<<8 + 1
+			b = b[2:]
+		}
+		data = append(data, b[:size]...)
+		b = b[size:]
+	}
+	assert.Equal(t, uint64(len(data)), n)
+	return data
+}
+
+// fields returns the fields of a protobuf message by number, with the raw bytes of length-delimited
+// fields and the values of the others.
+func fields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	result := map[protowire.Number][]interface{}{}
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		assert.Assert(t, l > 0)
+		b = b[l:]
+		var value interface{}
+		switch typ {
+		case protowire.BytesType:
+			value, l = protowire.ConsumeBytes(b)
+		case protowire.Fixed64Type:
+			value, l = protowire.ConsumeFixed64(b)
+		case protowire.VarintType:
+			value, l = protowire.ConsumeVarint(b)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		assert.Assert(t, l > 0)
+		b = b[l:]
+		result[num] = append(result[num], value)
+	}
+	return result
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	ts := time.Unix(1625140800, 0)
+	encoded := EncodeWriteRequest(Series([]Metric{{
+		Name: "det_resource_pool_slots",
+		Samples: []Sample{
+			{Labels: map[string]string{"resource_pool": "default"}, Value: 8},
+		},
+	}}, ts))
+
+	req := fields(t, snappyDecode(t, encoded))
+	assert.Equal(t, len(req[1]), 1)
+	series := fields(t, req[1][0].([]byte))
+
+	var labels [][2]string
+	for _, raw := range series[1] {
+		label := fields(t, raw.([]byte))
+		labels = append(labels, [2]string{
+			string(label[1][0].([]byte)), string(label[2][0].([]byte)),
+		})
+	}
+	assert.DeepEqual(t, labels, [][2]string{
+		{"__name__", "det_resource_pool_slots"},
+		{"resource_pool", "default"},
+	})
+
+	assert.Equal(t, len(series[2]), 1)
+	sample := fields(t, series[2][0].([]byte))
+	assert.Equal(t, math.Float64frombits(sample[1][0].(uint64)), 8.0)
+	assert.Equal(t, sample[2][0].(uint64), uint64(1625140800000))
+}
+
+func TestSnappyEncodeLongInput(t *testing.T) {
+	data := bytes.Repeat([]byte("determined"), 10000)
+	assert.DeepEqual(t, snappyDecode(t, snappyEncode(data)), data)
+}
+
+func TestRemoteWriteClient(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Content-Encoding"), "snappy")
+		assert.Equal(t, r.Header.Get("Authorization"), "Bearer secret")
+		_, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := &RemoteWriteClient{URL: server.URL, BearerToken: "secret", Client: server.Client()}
+	series := []TimeSeries{{
+		Labels:  map[string]string{"__name__": "up"},
+		Samples: []RemoteSample{{Value: 1, Timestamp: time.Now()}},
+	}}
+
+	retry, err := client.Write(context.Background(), series)
+	assert.NilError(t, err)
+	assert.Assert(t, !retry)
+
+	status = http.StatusServiceUnavailable
+	retry, err = client.Write(context.Background(), series)
+	assert.ErrorContains(t, err, "503")
+	assert.Assert(t, retry)
+
+	status = http.StatusBadRequest
+	retry, err = client.Write(context.Background(), series)
+	assert.ErrorContains(t, err, "400")
+	assert.Assert(t, !retry)
+}
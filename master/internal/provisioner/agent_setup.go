@@ -22,6 +22,18 @@ type agentSetupScriptConfig struct {
 	AgentID                      string
 	ResourcePool                 string
 	LogOptions                   string
+	// AgentInterruptionWatcher is the cloud provider whose metadata service the agent watches for
+	// interruptions of spot or preemptible instances, if any.
+	AgentInterruptionWatcher string
+}
+
+// interruptionWatcher returns the interruption watcher that agents should run if their instances
+// can be reclaimed by the cloud provider.
+func interruptionWatcher(interruptible bool, provider string) string {
+	if !interruptible {
+		return ""
+	}
+	return provider
 }
 
 func mustMakeAgentSetupScript(config agentSetupScriptConfig) []byte {
@@ -30,6 +30,7 @@ func TestAgentSetupScript(t *testing.T) {
 		AgentNetwork:                 "default",
 		AgentID:                      "test.id",
 		ResourcePool:                 "test-pool",
+		AgentInterruptionWatcher:     "aws",
 	}
 
 	// nolint
@@ -81,6 +82,7 @@ docker run --init --name determined-agent  \
     -e DET_MASTER_PORT="8080" \
     -e DET_SECURITY_TLS_MASTER_CERT_NAME="certname" \
     -e DET_RESOURCE_POOL="test-pool" \
+    -e DET_INTERRUPTION_WATCHER="aws" \
     -e DET_FLUENT_IMAGE="fluent-test" \
     -v /var/run/docker.sock:/var/run/docker.sock \
     -v /usr/local/determined/container_startup_script:/usr/local/determined/container_startup_script \
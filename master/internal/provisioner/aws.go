@@ -130,6 +130,7 @@ func newAWSCluster(
 			AgentID:                      `$(ec2metadata --instance-id)`,
 			ResourcePool:                 resourcePool,
 			LogOptions:                   config.AWS.buildDockerLogString(),
+			AgentInterruptionWatcher:     interruptionWatcher(config.AWS.SpotEnabled, "aws"),
 		}),
 	}
 
@@ -122,6 +122,18 @@ func (c Config) Validate() []error {
 	return errs
 }
 
+// SlotsPerInstance returns the number of slots of the instances that the provisioner launches.
+func (c Config) SlotsPerInstance() int {
+	switch {
+	case c.AWS != nil:
+		return c.AWS.InstanceType.Slots()
+	case c.GCP != nil:
+		return c.GCP.InstanceType.Slots()
+	default:
+		return 0
+	}
+}
+
 func (c Config) mustParseMasterURL() url.URL {
 	masterURL, err := url.Parse(c.MasterURL)
 	if err != nil {
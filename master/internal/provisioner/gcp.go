@@ -86,7 +86,8 @@ func newGCPCluster(
 		MasterCertBase64:             masterCertBase64,
 		AgentID: `$(curl "http://metadata.google.internal/computeMetadata/v1/instance/` +
 			`name" -H "Metadata-Flavor: Google")`,
-		ResourcePool: resourcePool,
+		ResourcePool:             resourcePool,
+		AgentInterruptionWatcher: interruptionWatcher(config.GCP.InstanceType.Preemptible, "gcp"),
 	}))
 
 	cluster := &gcpCluster{
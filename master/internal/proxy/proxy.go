@@ -11,6 +11,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/pkg/actor"
 )
 
@@ -22,6 +23,11 @@ type (
 		ServiceID string
 		URL       *url.URL
 		ProxyTCP  bool
+		// StripPath is whether requests are forwarded without the ".../:service-name" prefix of
+		// their paths, for services that can only be served from the root.
+		StripPath bool
+		// TenantID is the ID of the tenant that the service belongs to, if any.
+		TenantID *int
 	}
 	// Unregister removes the service from the proxy. All future requests until the service name is
 	// registered again will be responded with a 404 response. If the service is not registered with
@@ -41,12 +47,23 @@ type Service struct {
 	URL           *url.URL
 	LastRequested time.Time
 	ProxyTCP      bool
+	StripPath     bool
+	TenantID      *int
 }
 
 // Proxy is an actor that proxies requests to registered services.
 type Proxy struct {
+	// Authorize, if set, returns an error if the user of a request may not reach services of the
+	// tenant with the given ID.
+	Authorize func(c echo.Context, tenantID *int) error
+
 	lock     sync.RWMutex
 	services map[string]*Service
+
+	// connections and requests count the open and the total proxied requests by protocol.
+	statsLock   sync.Mutex
+	connections map[string]int
+	requests    map[string]int
 }
 
 // Receive implements the actor.Actor interface.
@@ -54,6 +71,9 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
 	case actor.PreStart:
 		p.services = make(map[string]*Service)
+		p.connections = make(map[string]int)
+		p.requests = make(map[string]int)
+		prom.Register("proxy", p.collector)
 	case Register:
 		if msg.ServiceID == "" {
 			return nil
@@ -61,7 +81,9 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 		p.lock.Lock()
 		defer p.lock.Unlock()
 		ctx.Log().Infof("registering service: %s (%v)", msg.ServiceID, msg.URL)
-		p.services[msg.ServiceID] = &Service{msg.URL, time.Now(), msg.ProxyTCP}
+		p.services[msg.ServiceID] = &Service{
+			msg.URL, time.Now(), msg.ProxyTCP, msg.StripPath, msg.TenantID,
+		}
 
 		if ctx.ExpectingResponse() {
 			ctx.Respond(nil)
@@ -75,6 +97,7 @@ func (p *Proxy) Receive(ctx *actor.Context) error {
 	case GetSummary:
 		ctx.Respond(p.getSummary())
 	case actor.PostStop:
+		prom.Unregister("proxy")
 		p.lock.Lock()
 		defer p.lock.Unlock()
 		// Erase all services from the proxy in case any handlers are still active.
@@ -94,7 +117,9 @@ func (p *Proxy) getService(serviceName string) *Service {
 
 	// Make a copy to avoid callers mutating the object outside of this locked method.
 	sURL := *service.URL
-	return &Service{&sURL, service.LastRequested, service.ProxyTCP}
+	return &Service{
+		&sURL, service.LastRequested, service.ProxyTCP, service.StripPath, service.TenantID,
+	}
 }
 
 // Service an HTTP request through the /proxy/:service/* route.
@@ -107,6 +132,11 @@ func (p *Proxy) newProxyHandler(serviceID string) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusNotFound,
 				fmt.Sprintf("service not found: %s", serviceName))
 		}
+		if p.Authorize != nil {
+			if err := p.Authorize(c, service.TenantID); err != nil {
+				return err
+			}
+		}
 
 		// Set proxy headers.
 		req := c.Request()
@@ -119,17 +149,27 @@ func (p *Proxy) newProxyHandler(serviceID string) echo.HandlerFunc {
 		if c.IsWebSocket() && req.Header.Get(echo.HeaderXForwardedFor) == "" {
 			req.Header.Set(echo.HeaderXForwardedFor, c.RealIP())
 		}
+		if service.StripPath {
+			req.URL.Path = "/" + c.Param("*")
+			req.URL.RawPath = ""
+		}
 
 		// Proxy the request to the target host.
 		var proxy http.Handler
+		var protocol string
 		switch {
 		case service.ProxyTCP:
 			proxy = newSingleHostReverseTCPOverWebSocketProxy(c, service.URL)
+			protocol = "tcp"
 		case c.IsWebSocket():
 			proxy = newSingleHostReverseWebSocketProxy(c, service.URL)
+			protocol = "websocket"
 		default:
 			proxy = httputil.NewSingleHostReverseProxy(service.URL)
+			protocol = "http"
 		}
+		p.trackConnection(protocol, 1)
+		defer p.trackConnection(protocol, -1)
 		proxy.ServeHTTP(c.Response(), req)
 
 		return nil
@@ -143,12 +183,65 @@ func (p *Proxy) getSummary() map[string]Service {
 
 	for id, service := range p.services {
 		sURL := *service.URL
-		snapshot[id] = Service{&sURL, service.LastRequested, service.ProxyTCP}
+		snapshot[id] = Service{
+			&sURL, service.LastRequested, service.ProxyTCP, service.StripPath, service.TenantID,
+		}
 	}
 
 	return snapshot
 }
 
+// trackConnection records that a proxied connection has opened (delta 1) or closed (delta -1).
+func (p *Proxy) trackConnection(protocol string, delta int) {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	p.connections[protocol] += delta
+	if delta > 0 {
+		p.requests[protocol]++
+	}
+}
+
+// collector reports the registered services and the proxied connections to Prometheus.
+func (p *Proxy) collector() []prom.Metric {
+	p.lock.RLock()
+	services := len(p.services)
+	p.lock.RUnlock()
+
+	connections := prom.Metric{
+		Name: "det_proxy_connections",
+		Help: "Number of open connections proxied to services in the cluster, by protocol.",
+		Type: prom.Gauge,
+	}
+	requests := prom.Metric{
+		Name: "det_proxy_requests_total",
+		Help: "Number of requests proxied to services in the cluster, by protocol.",
+		Type: prom.Counter,
+	}
+	p.statsLock.Lock()
+	for protocol, n := range p.connections {
+		connections.Samples = append(connections.Samples, prom.Sample{
+			Labels: map[string]string{"protocol": protocol}, Value: float64(n),
+		})
+	}
+	for protocol, n := range p.requests {
+		requests.Samples = append(requests.Samples, prom.Sample{
+			Labels: map[string]string{"protocol": protocol}, Value: float64(n),
+		})
+	}
+	p.statsLock.Unlock()
+
+	return []prom.Metric{
+		{
+			Name:    "det_proxy_services",
+			Help:    "Number of services registered with the proxy.",
+			Type:    prom.Gauge,
+			Samples: []prom.Sample{{Value: float64(services)}},
+		},
+		connections,
+		requests,
+	}
+}
+
 func asyncCopy(dst io.Writer, src io.Reader) chan error {
 	errs := make(chan error, 1)
 	go func() {
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// permissionDeniedError is returned when a user lacks a permission within a workspace.
+type permissionDeniedError struct {
+	username   string
+	permission model.Permission
+}
+
+func (e permissionDeniedError) Error() string {
+	return fmt.Sprintf("user %s does not have the %s permission", e.username, e.permission)
+}
+
+// editPermission returns the permission a user needs to change an experiment or task: their own
+// with edit_own, and those of other users with edit_all.
+func editPermission(owner bool) model.Permission {
+	if owner {
+		return model.PermissionEditOwn
+	}
+	return model.PermissionEditAll
+}
+
+// checkPermission returns the project with the given ID, or the default project if the ID is
+// zero, or an error unless the user holds the permission within the workspace of the project.
+// Projects of other tenants are not found.
+func (m *Master) checkPermission(
+	user *model.User, projectID int, permission model.Permission,
+) (*model.Project, error) {
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	project, err := m.db.ProjectByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if scope := m.tenantScope(user); !scope.All {
+		tenantID, err := m.db.ResourceTenant(db.TenantProject, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if !scope.Contains(tenantID) {
+			return nil, db.ErrNotFound
+		}
+	}
+	permissions, err := m.db.UserPermissions(user, project.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !permissions.Has(permission) {
+		return nil, permissionDeniedError{username: user.Username, permission: permission}
+	}
+	return project, nil
+}
+
+// checkExperimentPermission is checkPermission for changing an experiment.
+func (m *Master) checkExperimentPermission(user *model.User, exp *model.Experiment) error {
+	owner := exp.OwnerID != nil && *exp.OwnerID == user.ID
+	_, err := m.checkPermission(user, exp.ProjectID, editPermission(owner))
+	return err
+}
+
+// checkExperimentIDPermission is checkExperimentPermission for the user of an echo request and
+// the experiment with the given ID. It returns HTTP errors.
+func (m *Master) checkExperimentIDPermission(c echo.Context, id int) error {
+	exp, err := m.db.ExperimentWithoutConfigByID(id)
+	if errors.Cause(err) == db.ErrNotFound {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("experiment not found: %d", id))
+	} else if err != nil {
+		return err
+	}
+	user := c.(*context.DetContext).MustGetUser()
+	return permissionHTTPError(m.checkExperimentPermission(&user, exp))
+}
+
+// permissionStatus converts the errors of checkPermission to gRPC statuses.
+func permissionStatus(err error) error {
+	switch e := errors.Cause(err).(type) {
+	case nil:
+		return nil
+	case permissionDeniedError:
+		return status.Error(codes.PermissionDenied, e.Error())
+	default:
+		if e == db.ErrNotFound {
+			return status.Error(codes.NotFound, "project not found")
+		}
+		return err
+	}
+}
+
+// permissionHTTPError converts the errors of checkPermission to HTTP errors.
+func permissionHTTPError(err error) error {
+	switch e := errors.Cause(err).(type) {
+	case nil:
+		return nil
+	case permissionDeniedError:
+		return echo.NewHTTPError(http.StatusForbidden, e.Error())
+	default:
+		if e == db.ErrNotFound {
+			return echo.NewHTTPError(http.StatusNotFound, "project not found")
+		}
+		return err
+	}
+}
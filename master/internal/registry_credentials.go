@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ownerRegistryCredentials returns the stored registry credentials of an experiment's owner, if
+// the experiment has one.
+func ownerRegistryCredentials(
+	pg *db.PgDB, ownerID *model.UserID,
+) ([]model.RegistryCredential, error) {
+	if ownerID == nil {
+		return nil, nil
+	}
+	creds, err := pg.RegistryCredentials(*ownerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load the owner's registry credentials")
+	}
+	return creds, nil
+}
@@ -41,6 +41,9 @@ func (a *allocationAggregator) Receive(ctx *actor.Context) error {
 		if err := a.db.UpdateResourceAllocationAggregation(); err != nil {
 			ctx.Log().Errorf("failed to aggregate resource allocation: %s", err)
 		}
+		if err := a.db.UpdateUsageAggregation(); err != nil {
+			ctx.Log().Errorf("failed to aggregate resource usage: %s", err)
+		}
 		a.schedule(ctx)
 
 	default:
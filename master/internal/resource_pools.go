@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/resourcemanagers"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// parseAddedResourcePool parses the config of a resource pool that was added through the API.
+func parseAddedResourcePool(pool model.ResourcePool) (resourcemanagers.ResourcePoolConfig, error) {
+	var config resourcemanagers.ResourcePoolConfig
+	if _, ok := pool.Config["pool_name"]; ok {
+		return config, errors.New("the config of a resource pool cannot set pool_name")
+	}
+	b, err := json.Marshal(pool.Config)
+	if err != nil {
+		return config, err
+	}
+	if err = json.Unmarshal(b, &config); err != nil {
+		return config, errors.Wrapf(err, "invalid config of resource pool %s", pool.Name)
+	}
+	config.PoolName = pool.Name
+	return config, nil
+}
+
+// loadAddedResourcePools reads the resource pools that were added through the API.
+func (m *Master) loadAddedResourcePools() ([]resourcemanagers.ResourcePoolConfig, error) {
+	pools, err := m.db.ResourcePools()
+	if err != nil {
+		return nil, err
+	}
+	var configs []resourcemanagers.ResourcePoolConfig
+	for _, pool := range pools {
+		config, err := parseAddedResourcePool(pool)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// withAddedPools returns a copy of a resource configuration with the resource pools that were added
+// through the API after the ones that it configures. The caller must hold configLock.
+func (m *Master) withAddedPools(
+	config *resourcemanagers.ResourceConfig,
+) resourcemanagers.ResourceConfig {
+	merged := *config
+	merged.ResourcePools = append(
+		append([]resourcemanagers.ResourcePoolConfig(nil), config.ResourcePools...),
+		m.addedPools...)
+	return merged
+}
+
+// isAddedPool returns whether a resource pool was added through the API. The caller must hold
+// configLock.
+func (m *Master) isAddedPool(name string) bool {
+	for _, pool := range m.addedPools {
+		if pool.PoolName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setAddedPools changes the resource pools that were added through the API, after checking that
+// the resource pools of the master can change that way while it runs, and persist stores the
+// change. The caller must hold reloadLock, which keeps reloads of the configuration from sending
+// the resource managers other pools at the same time.
+func (m *Master) setAddedPools(
+	pools []resourcemanagers.ResourcePoolConfig, persist func() error,
+) error {
+	// Only reloads change the configuration, so it can be read without configLock here.
+	agentRM := m.config.AgentResourceManager()
+	if agentRM == nil {
+		return status.Error(codes.FailedPrecondition,
+			"resource pools can only be added to the agent resource manager")
+	}
+	current := m.withAddedPools(m.config.ResourceConfig)
+	changed := *m.config.ResourceConfig
+	changed.ResourcePools = append(
+		append([]resourcemanagers.ResourcePoolConfig(nil), m.config.ResourcePools...), pools...)
+	if err := check.Validate(changed); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	var errs []string
+	for _, err := range current.CheckReload(changed) {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return status.Error(codes.InvalidArgument, strings.Join(errs, "; "))
+	}
+	if err := persist(); err != nil {
+		return err
+	}
+
+	m.configLock.Lock()
+	m.addedPools = pools
+	m.configLock.Unlock()
+	m.system.Tell(m.rm, resourcemanagers.UpdateResourcePools{
+		Scheduler: agentRM.Scheduler,
+		Pools:     changed.ResourcePools,
+	})
+	return nil
+}
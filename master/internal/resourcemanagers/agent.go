@@ -6,6 +6,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/check"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // agentState holds the scheduler state for an agent. The implementation of agent-related operations
@@ -14,22 +15,56 @@ type agentState struct {
 	handler *actor.Ref
 	devices map[device.Device]*cproto.ID
 	label   string
+	// platform is the "os/arch" platform of the agent; only tasks for that platform are scheduled
+	// onto it.
+	platform string
+	// healthy is false when the agent has reported problems; no new tasks are scheduled onto
+	// unhealthy agents.
+	healthy bool
+	// interrupted is true once the cloud provider has announced that it will reclaim the agent's
+	// instance; its tasks are released and no new tasks are scheduled onto it.
+	interrupted bool
+	// unhealthyDevices are devices that have reported errors; they are not allocated to new
+	// containers until they recover.
+	unhealthyDevices map[device.Device]bool
+	// topology describes how the agent's GPUs are interconnected, if the agent reported it, and
+	// topologyPolicy is how the pool takes it into account when placing tasks.
+	topology       *device.Topology
+	topologyPolicy TopologyConfig
 
 	// Since we only model GPUs as devices/slots and assume each slot can be allocated with
 	// one container, we add one additional field to keep track of zero-slot containers.
 	// We need this field to know if the agent is idle.
 	zeroSlotContainers    map[cproto.ID]bool
 	maxZeroSlotContainers int
+	// agentMaxZeroSlotContainers is the limit of zero-slot containers that the agent itself sets,
+	// which lowers that of its pool; it is zero if the agent leaves it to the pool.
+	agentMaxZeroSlotContainers int
 }
 
 // newAgentState returns a new agent empty agent state backed by the handler.
 func newAgentState(msg sproto.AddAgent, maxZeroSlotContainers int) *agentState {
-	return &agentState{
-		handler:               msg.Agent,
-		label:                 msg.Label,
-		devices:               make(map[device.Device]*cproto.ID),
-		zeroSlotContainers:    make(map[cproto.ID]bool),
-		maxZeroSlotContainers: maxZeroSlotContainers,
+	a := &agentState{
+		handler:                    msg.Agent,
+		label:                      msg.Label,
+		platform:                   model.PlatformOrDefault(msg.Platform),
+		healthy:                    true,
+		topology:                   msg.Topology,
+		devices:                    make(map[device.Device]*cproto.ID),
+		unhealthyDevices:           make(map[device.Device]bool),
+		zeroSlotContainers:         make(map[cproto.ID]bool),
+		agentMaxZeroSlotContainers: msg.MaxZeroSlotContainers,
+	}
+	a.setMaxZeroSlotContainers(maxZeroSlotContainers)
+	return a
+}
+
+// setMaxZeroSlotContainers limits the zero-slot containers of the agent to the limit of its pool,
+// or to its own limit if that is lower.
+func (a *agentState) setMaxZeroSlotContainers(poolMax int) {
+	a.maxZeroSlotContainers = poolMax
+	if a.agentMaxZeroSlotContainers > 0 && a.agentMaxZeroSlotContainers < poolMax {
+		a.maxZeroSlotContainers = a.agentMaxZeroSlotContainers
 	}
 }
 
@@ -37,9 +72,26 @@ func (a *agentState) numSlots() int {
 	return len(a.devices)
 }
 
-// numEmptySlots returns the number of slots that have not been allocated to containers.
-func (a *agentState) numEmptySlots() (slots int) {
-	return a.numSlots() - a.numUsedSlots()
+// numEmptySlots returns the number of healthy slots that have not been allocated to containers.
+func (a *agentState) numEmptySlots() int {
+	return a.numEmptySlotsOfType("")
+}
+
+// numEmptySlotsOfType returns the number of empty slots whose devices are of the model of GPU, or
+// of any model if it is empty.
+func (a *agentState) numEmptySlotsOfType(gpuType string) (slots int) {
+	for d := range a.devices {
+		if a.freeDevice(d, gpuType) {
+			slots++
+		}
+	}
+	return slots
+}
+
+// freeDevice returns whether the device is healthy, has not been allocated to a container and, if
+// a model of GPU is given, is of that model.
+func (a *agentState) freeDevice(d device.Device, gpuType string) bool {
+	return a.devices[d] == nil && !a.unhealthyDevices[d] && (gpuType == "" || d.IsModel(gpuType))
 }
 
 // numUsedSlots returns the number of slots that have been allocated to containers.
@@ -60,26 +112,49 @@ func (a *agentState) idle() bool {
 	return len(a.zeroSlotContainers) == 0 && a.numUsedSlots() == 0
 }
 
-func (a *agentState) allocateFreeDevices(slots int, id cproto.ID) []device.Device {
+// allocateFreeDevices allocates free devices to a container, which are of the model of GPU if one
+// is given.
+func (a *agentState) allocateFreeDevices(slots int, id cproto.ID, gpuType string) []device.Device {
 	if slots == 0 {
 		a.zeroSlotContainers[id] = true
 		return nil
 	}
 	cid := id
-	devices := make([]device.Device, 0, slots)
-	for d, dcid := range a.devices {
-		if dcid == nil {
-			a.devices[d] = &cid
-			devices = append(devices, d)
-		}
-		if len(devices) == slots {
-			break
+	var devices []device.Device
+	if topologyEnabled(a.topologyPolicy.NVLink) {
+		devices = a.nvlinkedFreeDevices(slots, gpuType)
+	}
+	if devices == nil {
+		devices = make([]device.Device, 0, slots)
+		for d := range a.devices {
+			if len(devices) == slots {
+				break
+			}
+			if a.freeDevice(d, gpuType) {
+				devices = append(devices, d)
+			}
 		}
 	}
 	check.Panic(check.True(len(devices) == slots, "not enough devices"))
+	for _, d := range devices {
+		a.devices[d] = &cid
+	}
 	return devices
 }
 
+// claimDevices records that a container holds devices, which it was started with before the agent
+// reconnected.
+func (a *agentState) claimDevices(devices []device.Device, id cproto.ID) {
+	if len(devices) == 0 {
+		a.zeroSlotContainers[id] = true
+		return
+	}
+	for _, d := range devices {
+		cid := id
+		a.devices[d] = &cid
+	}
+}
+
 func (a *agentState) deallocateContainer(id cproto.ID) {
 	delete(a.zeroSlotContainers, id)
 	for d, cid := range a.devices {
@@ -93,9 +168,17 @@ func (a *agentState) deepCopy() *agentState {
 	copiedAgent := &agentState{
 		handler:               a.handler,
 		label:                 a.label,
+		platform:              a.platform,
+		healthy:               a.healthy,
+		interrupted:           a.interrupted,
+		topology:              a.topology,
+		topologyPolicy:        a.topologyPolicy,
 		devices:               make(map[device.Device]*cproto.ID),
+		unhealthyDevices:      make(map[device.Device]bool),
 		zeroSlotContainers:    make(map[cproto.ID]bool),
 		maxZeroSlotContainers: a.maxZeroSlotContainers,
+
+		agentMaxZeroSlotContainers: a.agentMaxZeroSlotContainers,
 	}
 
 	for originalDevice, id := range a.devices {
@@ -104,8 +187,14 @@ func (a *agentState) deepCopy() *agentState {
 			Brand: originalDevice.Brand,
 			UUID:  originalDevice.UUID,
 			Type:  originalDevice.Type,
+
+			MIGProfile: originalDevice.MIGProfile,
+			ParentID:   originalDevice.ParentID,
 		}
 		copiedAgent.devices[copiedDevice] = id
+		if a.unhealthyDevices[originalDevice] {
+			copiedAgent.unhealthyDevices[copiedDevice] = true
+		}
 	}
 
 	for originalKey, originalValue := range a.zeroSlotContainers {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
@@ -22,8 +23,11 @@ type agentResourceManager struct {
 }
 
 func newAgentResourceManager(config *ResourceConfig, cert *tls.Certificate) *agentResourceManager {
+	// The resource manager keeps its own copy of its configuration, since the scheduler in it
+	// changes when the master reloads its configuration.
+	agentRMConfig := *config.AgentResourceManager()
 	return &agentResourceManager{
-		config:      config.ResourceManager.AgentRM,
+		config:      &agentRMConfig,
 		poolsConfig: config.ResourcePools,
 		cert:        cert,
 		pools:       make(map[string]*actor.Ref),
@@ -73,6 +77,14 @@ func (a *agentResourceManager) Receive(ctx *actor.Context) error {
 	case sproto.SetTaskName:
 		a.forwardToAllPools(ctx, msg)
 
+	case UpdateResourcePools:
+		a.updateResourcePools(ctx, msg)
+
+	case RemoveResourcePool:
+		if err := a.removeResourcePool(ctx, msg.PoolName); err != nil {
+			ctx.Respond(err)
+		}
+
 	case sproto.GetDefaultGPUResourcePoolRequest:
 		ctx.Respond(sproto.GetDefaultGPUResourcePoolResponse{PoolName: a.config.DefaultGPUResourcePool})
 
@@ -129,6 +141,63 @@ func (a *agentResourceManager) createResourcePool(
 	return ref
 }
 
+// updateResourcePools applies a reloaded configuration of the scheduler and the resource pools,
+// creating the pools that are new.
+func (a *agentResourceManager) updateResourcePools(ctx *actor.Context, msg UpdateResourcePools) {
+	a.config.Scheduler = msg.Scheduler
+	a.poolsConfig = msg.Pools
+	for _, config := range msg.Pools {
+		ref, ok := a.pools[config.PoolName]
+		if !ok {
+			if ref = a.createResourcePool(ctx, config, a.cert); ref != nil {
+				a.pools[config.PoolName] = ref
+			}
+			continue
+		}
+		if config.Scheduler == nil {
+			config.Scheduler = a.config.Scheduler
+		}
+		ctx.Tell(ref, updateResourcePool{config: config})
+	}
+	a.notifyAgentLabelPools(ctx)
+}
+
+// removeResourcePool stops a resource pool that no agents are in and no tasks are queued or
+// running in.
+func (a *agentResourceManager) removeResourcePool(ctx *actor.Context, name string) error {
+	ref, ok := a.pools[name]
+	if !ok {
+		return errors.Errorf("cannot find resource pool %s", name)
+	}
+	if summary := ctx.Ask(ref, GetResourceSummary{}).Get().(ResourceSummary); summary.numAgents > 0 {
+		return errors.Errorf("resource pool %s still has %d agents", name, summary.numAgents)
+	}
+	tasks := ctx.Ask(ref, sproto.GetTaskSummaries{}).Get().(map[sproto.TaskID]TaskSummary)
+	if len(tasks) > 0 {
+		return errors.Errorf("resource pool %s still has %d tasks", name, len(tasks))
+	}
+
+	ctx.Log().Infof("removing resource pool: %s", name)
+	ref.Stop()
+	delete(a.pools, name)
+	var pools []ResourcePoolConfig
+	for _, config := range a.poolsConfig {
+		if config.PoolName != name {
+			pools = append(pools, config)
+		}
+	}
+	a.poolsConfig = pools
+	a.notifyAgentLabelPools(ctx)
+	return nil
+}
+
+// notifyAgentLabelPools tells the agents which resource pools claim the agents with each label.
+func (a *agentResourceManager) notifyAgentLabelPools(ctx *actor.Context) {
+	if agents := ctx.Self().System().Get(sproto.AgentsAddr); agents != nil {
+		ctx.Tell(agents, sproto.SetAgentLabelPools{Pools: agentLabelPools(a.poolsConfig)})
+	}
+}
+
 func (a *agentResourceManager) forwardToPool(
 	ctx *actor.Context, resourcePool string, msg actor.Message,
 ) {
@@ -274,6 +343,9 @@ func (a *agentResourceManager) createResourcePoolSummary(
 		InstanceType:                 instanceType,
 		Details:                      &resourcepoolv1.ResourcePoolDetail{},
 	}
+	if price, ok := pool.SlotHourPrice(); ok {
+		resp.SlotHourPrice = wrapperspb.Double(price)
+	}
 	if pool.Provider != nil {
 		resp.MinAgents = int32(pool.Provider.MinInstances)
 		resp.MaxAgents = int32(pool.Provider.MaxInstances)
@@ -368,6 +440,7 @@ func (a *agentResourceManager) createResourcePoolSummary(
 	resp.SlotsUsed = int32(resourceSummary.numActiveSlots)
 	resp.CpuContainerCapacity = int32(resourceSummary.maxNumCPUContainers)
 	resp.CpuContainersRunning = int32(resourceSummary.numActiveCPUContainers)
+	resp.SpotInterruptions = int32(resourceSummary.numSpotInterruptions)
 
 	return resp, nil
 }
@@ -0,0 +1,143 @@
+package resourcemanagers
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// budgetInterval is how often budgets are evaluated again, which releases the held allocate
+// requests whose budgets are no longer exceeded and preempts the tasks of exceeded budgets.
+const budgetInterval = time.Minute
+
+// budgetTick periodically triggers the evaluation of budgets.
+type budgetTick struct{}
+
+// budgetEnforcer admits allocate requests according to the budgets of the owners and projects of
+// their tasks. Requests of hard budgets that are exceeded are held until they are not anymore,
+// e.g. because the budget was raised or a new month began.
+type budgetEnforcer struct {
+	db *db.PgDB
+	// prices is the price of a slot-hour in each resource pool that has one.
+	prices map[string]float64
+	// admitted and held are the allocate requests of tasks that were forwarded to the resource
+	// managers and that are held back, by task.
+	admitted map[*actor.Ref]sproto.AllocateRequest
+	held     map[*actor.Ref]sproto.AllocateRequest
+	// warned is the start of the month that each soft budget was last warned about.
+	warned map[int]time.Time
+}
+
+func newBudgetEnforcer(pgDB *db.PgDB, prices map[string]float64) *budgetEnforcer {
+	return &budgetEnforcer{
+		db:       pgDB,
+		prices:   prices,
+		admitted: make(map[*actor.Ref]sproto.AllocateRequest),
+		held:     make(map[*actor.Ref]sproto.AllocateRequest),
+		warned:   make(map[int]time.Time),
+	}
+}
+
+// admit returns whether the allocate request may be forwarded to a resource manager, and holds it
+// otherwise. Tasks that reattach to running containers or that use no slots are always admitted.
+func (b *budgetEnforcer) admit(ctx *actor.Context, msg sproto.AllocateRequest) bool {
+	if _, ok := b.admitted[msg.TaskActor]; !ok {
+		if _, ok := b.held[msg.TaskActor]; !ok {
+			actors.NotifyOnStop(ctx, msg.TaskActor, sproto.ResourcesReleased{TaskActor: msg.TaskActor})
+		}
+	}
+	delete(b.held, msg.TaskActor)
+	if msg.Reattach == nil && msg.SlotsNeeded > 0 {
+		for _, budget := range b.exceeded(ctx, &msg) {
+			if budget.Mode == model.BudgetModeHard {
+				ctx.Log().Warnf("holding task %s (%s) since budget %d is exceeded",
+					msg.ID, msg.Name, budget.ID)
+				b.held[msg.TaskActor] = msg
+				return false
+			}
+		}
+	}
+	b.admitted[msg.TaskActor] = msg
+	return true
+}
+
+// released forgets a task whose resources were released.
+func (b *budgetEnforcer) released(task *actor.Ref) {
+	delete(b.admitted, task)
+	delete(b.held, task)
+}
+
+// tick evaluates the budgets again. It preempts the admitted tasks of exceeded hard budgets that
+// preempt, and returns the held requests that may now be forwarded to resource managers.
+func (b *budgetEnforcer) tick(ctx *actor.Context) []sproto.AllocateRequest {
+	exceeded := b.exceeded(ctx, nil)
+	blocks := func(msg sproto.AllocateRequest, preempt bool) bool {
+		for _, budget := range exceeded {
+			if budget.Mode == model.BudgetModeHard && (!preempt || budget.Preempt) &&
+				budget.Applies(msg.OwnerID, msg.ProjectID) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for task, msg := range b.admitted {
+		if msg.NonPreemptible || msg.SlotsNeeded == 0 || !blocks(msg, true) {
+			continue
+		}
+		ctx.Log().Warnf("preempting task %s (%s) since its budget is exceeded", msg.ID, msg.Name)
+		ctx.Tell(task, sproto.ReleaseResources{ResourcePool: msg.ResourcePool})
+		delete(b.admitted, task)
+	}
+
+	var admitted []sproto.AllocateRequest
+	for task, msg := range b.held {
+		if !blocks(msg, false) {
+			ctx.Log().Infof("admitting task %s (%s) since its budgets allow it", msg.ID, msg.Name)
+			delete(b.held, task)
+			b.admitted[task] = msg
+			admitted = append(admitted, msg)
+		}
+	}
+	return admitted
+}
+
+// exceeded returns the budgets that are exceeded, limited to those that apply to the request if
+// it is not nil, and warns about the soft ones once a month. Budgets whose usage cannot be
+// determined are not exceeded, so that a failing database does not stop the cluster.
+func (b *budgetEnforcer) exceeded(
+	ctx *actor.Context, msg *sproto.AllocateRequest,
+) []model.Budget {
+	budgets, err := b.db.Budgets()
+	if err != nil {
+		ctx.Log().WithError(err).Error("cannot evaluate budgets")
+		return nil
+	}
+	periodStart := model.BudgetPeriodStart(time.Now())
+	var exceeded []model.Budget
+	for _, budget := range budgets {
+		if msg != nil && !budget.Applies(msg.OwnerID, msg.ProjectID) {
+			continue
+		}
+		slotHours, err := b.db.BudgetSlotHours(budget, periodStart)
+		if err != nil {
+			ctx.Log().WithError(err).Errorf("cannot evaluate budget %d", budget.ID)
+			continue
+		}
+		usage := budget.Usage(slotHours, b.prices)
+		if usage < budget.Limit {
+			continue
+		}
+		exceeded = append(exceeded, budget)
+		if budget.Mode == model.BudgetModeSoft && !b.warned[budget.ID].Equal(periodStart) {
+			ctx.Log().Warnf("budget %d is exceeded: %.2f of %.2f %s used this month",
+				budget.ID, usage, budget.Limit, budget.Unit)
+			b.warned[budget.ID] = periodStart
+		}
+	}
+	return exceeded
+}
@@ -1,6 +1,12 @@
 package resourcemanagers
 
-import "github.com/pkg/errors"
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+)
 
 // DefaultResourceConfig returns the default resource configuration.
 func DefaultResourceConfig() *ResourceConfig {
@@ -13,6 +19,10 @@ func DefaultResourceConfig() *ResourceConfig {
 type ResourceConfig struct {
 	ResourceManager *ResourceManagerConfig `json:"resource_manager"`
 	ResourcePools   []ResourcePoolConfig   `json:"resource_pools"`
+
+	// AdditionalResourceManagers run alongside the primary ResourceManager. Tasks reach them by
+	// selecting one of their resource pools.
+	AdditionalResourceManagers []*ResourceManagerConfig `json:"additional_resource_managers"`
 }
 
 // ResolveResource resolves the config.
@@ -22,10 +32,11 @@ func (r *ResourceConfig) ResolveResource() error {
 			AgentRM: &AgentResourceManagerConfig{},
 		}
 	}
-	if r.ResourceManager.AgentRM == nil && r.ResourceManager.KubernetesRM == nil {
+	if r.ResourceManager.AgentRM == nil && r.ResourceManager.KubernetesRM == nil &&
+		r.ResourceManager.HPCRM() == nil {
 		r.ResourceManager.AgentRM = &AgentResourceManagerConfig{}
 	}
-	if r.ResourceManager.AgentRM != nil && r.ResourcePools == nil {
+	if r.AgentResourceManager() != nil && r.ResourcePools == nil {
 		defaultPool := defaultRPConfig()
 		defaultPool.PoolName = defaultResourcePoolName
 		r.ResourcePools = []ResourcePoolConfig{defaultPool}
@@ -33,16 +44,197 @@ func (r *ResourceConfig) ResolveResource() error {
 	return nil
 }
 
+// SlotHourPrices returns the price of a slot-hour in each resource pool that has one.
+func (r ResourceConfig) SlotHourPrices() map[string]float64 {
+	prices := make(map[string]float64)
+	for _, pool := range r.ResourcePools {
+		if price, ok := pool.SlotHourPrice(); ok {
+			prices[pool.PoolName] = price
+		}
+	}
+	return prices
+}
+
 // Validate implements the check.Validatable interface.
 func (r ResourceConfig) Validate() []error {
 	errs := make([]error, 0)
 	poolNames := make(map[string]bool)
+	labelPools := make(map[string]string)
 	for ix, rp := range r.ResourcePools {
 		if _, ok := poolNames[rp.PoolName]; ok {
 			errs = append(errs, errors.Errorf("%d resource pool has a duplicate name: %s", ix, rp.PoolName))
 		} else {
 			poolNames[rp.PoolName] = true
 		}
+		if rp.AgentLabel == "" {
+			continue
+		}
+		if other, ok := labelPools[rp.AgentLabel]; ok {
+			errs = append(errs, errors.Errorf(
+				"resource pools %s and %s cannot both claim the agents labeled %s",
+				other, rp.PoolName, rp.AgentLabel))
+		} else {
+			labelPools[rp.AgentLabel] = rp.PoolName
+		}
+	}
+
+	rmTypes := make(map[string]bool)
+	poolRMs := make(map[string]string)
+	for _, rm := range r.ResourceManagers() {
+		if rm == nil {
+			continue
+		}
+		rmType := resourceManagerType(rm)
+		if rmTypes[rmType] {
+			errs = append(errs, errors.Errorf("only one %s resource manager may be configured", rmType))
+		}
+		rmTypes[rmType] = true
+		for _, pool := range r.poolNames(rm) {
+			if other, ok := poolRMs[pool]; ok && other != rmType {
+				errs = append(errs, errors.Errorf(
+					"resource pool %s belongs to both the %s and the %s resource manager",
+					pool, other, rmType))
+			}
+			poolRMs[pool] = rmType
+		}
+	}
+	return errs
+}
+
+// UpdateResourcePools is a message to apply a reloaded configuration of the scheduler of the agent
+// resource manager and of its resource pools, which CheckReload accepted. The pools include the
+// ones added through the API.
+type UpdateResourcePools struct {
+	Scheduler *SchedulerConfig
+	Pools     []ResourcePoolConfig
+}
+
+// RemoveResourcePool is a message to remove a resource pool of the agent resource manager. It is
+// refused while agents are in the pool or tasks are queued or running in it.
+type RemoveResourcePool struct {
+	PoolName string
+}
+
+// agentLabelPools maps the labels of agents to the resource pools that claim them.
+func agentLabelPools(pools []ResourcePoolConfig) map[string]string {
+	labelPools := make(map[string]string)
+	for _, pool := range pools {
+		if pool.AgentLabel != "" {
+			labelPools[pool.AgentLabel] = pool.PoolName
+		}
+	}
+	return labelPools
+}
+
+// CheckReload returns an error for each change from r to the reloaded configuration that takes a
+// restart of the master. Only the scheduler of the agent resource manager and its resource pools
+// can change while the master runs: pools can be added, and change anything but their provider
+// and pricing.
+func (r ResourceConfig) CheckReload(reloaded ResourceConfig) []error {
+	var errs []error
+	if !reflect.DeepEqual(
+		withoutAgentScheduler(r.ResourceManagers()),
+		withoutAgentScheduler(reloaded.ResourceManagers()),
+	) {
+		errs = append(errs, errors.New(
+			"resource managers cannot change, except for the scheduler of the agent resource manager"))
+	}
+
+	pools := make(map[string]ResourcePoolConfig)
+	for _, pool := range r.ResourcePools {
+		pools[pool.PoolName] = pool
+	}
+	reloadedPools := make(map[string]bool)
+	for _, pool := range reloaded.ResourcePools {
+		reloadedPools[pool.PoolName] = true
+		old, ok := pools[pool.PoolName]
+		if ok && !reflect.DeepEqual(old.Provider, pool.Provider) {
+			errs = append(errs, errors.Errorf(
+				"the provider of resource pool %s cannot change", pool.PoolName))
+		}
+		// Budgets are enforced at the prices of the pools when the master started.
+		if !reflect.DeepEqual(old.Pricing, pool.Pricing) {
+			errs = append(errs, errors.Errorf(
+				"the pricing of resource pool %s cannot change", pool.PoolName))
+		}
+	}
+	for _, pool := range r.ResourcePools {
+		if !reloadedPools[pool.PoolName] {
+			errs = append(errs, errors.Errorf("resource pool %s cannot be removed", pool.PoolName))
+		}
 	}
 	return errs
 }
+
+// withoutAgentScheduler returns copies of the configurations of resource managers without the
+// scheduler of the agent resource manager.
+func withoutAgentScheduler(rms []*ResourceManagerConfig) []ResourceManagerConfig {
+	var configs []ResourceManagerConfig
+	for _, rm := range rms {
+		if rm == nil {
+			continue
+		}
+		config := *rm
+		if config.AgentRM != nil {
+			agentRM := *config.AgentRM
+			agentRM.Scheduler = nil
+			config.AgentRM = &agentRM
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// ResourceManagers returns every configured resource manager, starting with the primary one.
+func (r ResourceConfig) ResourceManagers() []*ResourceManagerConfig {
+	return append([]*ResourceManagerConfig{r.ResourceManager}, r.AdditionalResourceManagers...)
+}
+
+// AgentResourceManager returns the configuration of the agent resource manager, if any.
+func (r ResourceConfig) AgentResourceManager() *AgentResourceManagerConfig {
+	for _, rm := range r.ResourceManagers() {
+		if rm != nil && rm.AgentRM != nil {
+			return rm.AgentRM
+		}
+	}
+	return nil
+}
+
+// KubernetesResourceManager returns the configuration of the Kubernetes resource manager, if any.
+func (r ResourceConfig) KubernetesResourceManager() *KubernetesResourceManagerConfig {
+	for _, rm := range r.ResourceManagers() {
+		if rm != nil && rm.KubernetesRM != nil {
+			return rm.KubernetesRM
+		}
+	}
+	return nil
+}
+
+// poolNames returns the names of the resource pools that the resource manager backs.
+func (r ResourceConfig) poolNames(rm *ResourceManagerConfig) []string {
+	switch {
+	case rm.AgentRM != nil:
+		var names []string
+		for _, pool := range r.ResourcePools {
+			names = append(names, pool.PoolName)
+		}
+		return names
+	case rm.KubernetesRM != nil:
+		return []string{sproto.K8sRPName}
+	case rm.HPCRM() != nil:
+		return []string{sproto.HPCRPName}
+	default:
+		return nil
+	}
+}
+
+func resourceManagerType(rm *ResourceManagerConfig) string {
+	switch {
+	case rm.AgentRM != nil:
+		return "agent"
+	case rm.KubernetesRM != nil:
+		return "kubernetes"
+	default:
+		return "slurm or pbs"
+	}
+}
@@ -0,0 +1,73 @@
+package resourcemanagers
+
+import (
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+// shrinkElasticTasks lowers the slots of pending elastic tasks to the most that currently fit in
+// the pool, so that they can start instead of waiting for their full size. Tasks that do not fit
+// at any size wait for their full size, which is also what the provisioner scales up for.
+func (rp *ResourcePool) shrinkElasticTasks() {
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		if !req.Elastic() || rp.taskList.GetAllocations(req.TaskActor) != nil {
+			continue
+		}
+		if slots := largestElasticFit(req, rp.agents, rp.fittingMethod); slots > 0 {
+			req.SlotsNeeded = slots
+		} else {
+			req.SlotsNeeded = req.MaxSlotsNeeded
+		}
+	}
+}
+
+// growElasticTasks asks an elastic task that runs with fewer slots than it wants to release its
+// resources once it would fit with more, so that it restarts larger from its latest checkpoint.
+// Tasks are only grown while no task is waiting for resources, and one at a time, since growing a
+// task takes up the capacity that the next one would have been measured against.
+func (rp *ResourcePool) growElasticTasks(ctx *actor.Context) {
+	for it := rp.taskList.iterator(); it.next(); {
+		if rp.taskList.GetAllocations(it.value().TaskActor) == nil {
+			return
+		}
+	}
+
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		if !req.Elastic() || req.SlotsNeeded >= req.MaxSlotsNeeded {
+			continue
+		}
+
+		// Measure the task against the pool as if its own containers were already gone.
+		agents := deepCopyAgents(rp.agents)
+		for _, allocation := range rp.taskList.GetAllocations(req.TaskActor).Allocations {
+			typed := allocation.(*containerAllocation)
+			if agent, ok := agents[typed.agent.handler]; ok {
+				agent.deallocateContainer(typed.container.id)
+			}
+		}
+
+		if slots := largestElasticFit(req, agents, rp.fittingMethod); slots > req.SlotsNeeded {
+			ctx.Log().Infof("growing %s from %d to %d slots",
+				req.TaskActor.Address(), req.SlotsNeeded, slots)
+			rp.releaseResource(ctx, req.TaskActor)
+			return
+		}
+	}
+}
+
+// largestElasticFit returns the most slots, between the minimum and maximum of an elastic task,
+// with which the task fits onto the agents, or 0 if it does not fit at all.
+func largestElasticFit(
+	req *sproto.AllocateRequest, agents map[*actor.Ref]*agentState, fittingMethod SoftConstraint,
+) int {
+	resized := *req
+	for slots := req.MaxSlotsNeeded; slots >= req.MinSlotsNeeded; slots-- {
+		resized.SlotsNeeded = slots
+		if len(findFits(&resized, agents, fittingMethod)) > 0 {
+			return slots
+		}
+	}
+	return 0
+}
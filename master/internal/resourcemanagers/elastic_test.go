@@ -0,0 +1,67 @@
+package resourcemanagers
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+)
+
+func TestShrinkElasticTasks(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 4}}
+	tasks := []*mockTask{
+		{id: "task1", slotsNeeded: 2, allocatedAgent: agents[0], containerStarted: true},
+		{id: "elastic", slotsNeeded: 4, minSlotsNeeded: 1, maxSlotsNeeded: 4},
+		{id: "too-large", slotsNeeded: 4, minSlotsNeeded: 3, maxSlotsNeeded: 4},
+	}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+	assert.NilError(t, ref.StopAndAwaitTermination())
+
+	rp.shrinkElasticTasks()
+
+	elastic, ok := rp.taskList.GetTaskByHandler(system.Get(actor.Addr("elastic")))
+	assert.Assert(t, ok)
+	assert.Equal(t, elastic.SlotsNeeded, 2)
+	tooLarge, ok := rp.taskList.GetTaskByHandler(system.Get(actor.Addr("too-large")))
+	assert.Assert(t, ok)
+	assert.Equal(t, tooLarge.SlotsNeeded, 4)
+}
+
+func TestGrowElasticTasks(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 4}}
+	tasks := []*mockTask{
+		{
+			id: "elastic", slotsNeeded: 2, minSlotsNeeded: 1, maxSlotsNeeded: 4,
+			allocatedAgent: agents[0], containerStarted: true,
+		},
+	}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+
+	rp.reschedule = true
+	system.Ask(ref, schedulerTick{}).Get()
+
+	// Wait for the task to hand its resources back to the pool so it can restart larger.
+	system.Ask(system.Get(actor.Addr("elastic")), actor.Ping{}).Get()
+	system.Ask(ref, actor.Ping{}).Get()
+
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	_, ok := rp.taskList.GetTaskByHandler(system.Get(actor.Addr("elastic")))
+	assert.Assert(t, !ok)
+}
+
+func TestLargestElasticFit(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := map[*actor.Ref]*agentState{}
+	forceAddAgent(t, system, agents, "agent1", 4, 1, 0)
+	forceAddAgent(t, system, agents, "agent2", 4, 0, 0)
+
+	req := &sproto.AllocateRequest{MinSlotsNeeded: 2, MaxSlotsNeeded: 8}
+	assert.Equal(t, largestElasticFit(req, agents, BestFit), 4)
+
+	req = &sproto.AllocateRequest{MinSlotsNeeded: 5, MaxSlotsNeeded: 7}
+	assert.Equal(t, largestElasticFit(req, agents, BestFit), 0)
+}
@@ -21,7 +21,11 @@ type SoftConstraint func(req *sproto.AllocateRequest, agent *agentState) float64
 // fittingState is the basis for assigning a task to one or more agents for execution.
 type fittingState struct {
 	Agent *agentState
-	Score float64
+	// TopologyMatch is true if the agent can give the task GPUs that are all connected over
+	// NVLink; such agents are preferred regardless of their score if the pool takes NVLink into
+	// account.
+	TopologyMatch bool
+	Score         float64
 	// Use hash distances besides scores of fitting here in order to
 	// load balance across agents for tasks that would have no preference
 	// for which agent they go onto if the scores are tied. Use hash distance
@@ -44,6 +48,10 @@ func (c candidateList) Less(i, j int) bool {
 	a := c[i]
 	b := c[j]
 	switch {
+	case a.TopologyMatch && !b.TopologyMatch:
+		return true
+	case !a.TopologyMatch && b.TopologyMatch:
+		return false
 	case a.Score > b.Score:
 		return true
 	case a.Score < b.Score:
@@ -68,6 +76,9 @@ func findFits(
 	// TODO(DET-4035): Some of this code is duplicated in calculateDesiredNewAgentNum()
 	//    to prevent the provisioner from scaling up for jobs that can never be scheduled in
 	//    the current cluster configuration.
+	if req.FittingRequirements.Containers > 1 {
+		return findSpreadFits(req, agents, fittingMethod)
+	}
 	if fit := findSharedAgentFit(req, agents, fittingMethod); fit != nil {
 		return []*fittingState{fit}
 	}
@@ -103,9 +114,14 @@ func findDedicatedAgentFits(
 	// 2) Multi-agent tasks will receive all the slots on every agent they are scheduled on.
 	agentsByNumSlots := make(map[int][]*agentState)
 	for _, agent := range agentStates {
-		constraints := []HardConstraint{labelSatisfied, agentSlotUnusedSatisfied}
+		constraints := []HardConstraint{
+			labelSatisfied, agentSlotUnusedSatisfied, agentHealthySatisfied, platformSatisfied,
+			nvlinkSatisfied,
+		}
 		if isViable(req, agent, constraints...) {
-			agentsByNumSlots[agent.numEmptySlots()] = append(agentsByNumSlots[agent.numEmptySlots()], agent)
+			// Tasks that ask for a model of GPU get the free GPUs of that model of each agent.
+			n := agent.numEmptySlotsOfType(req.GPUType)
+			agentsByNumSlots[n] = append(agentsByNumSlots[n], agent)
 		}
 	}
 
@@ -118,7 +134,6 @@ func findDedicatedAgentFits(
 	// agents as possible, thus we prioritize the largest agents.
 	sort.Sort(sort.Reverse(numSlots))
 
-	var candidateNumSlots int
 	for _, n := range numSlots {
 		if n == 0 {
 			continue
@@ -129,39 +144,82 @@ func findDedicatedAgentFits(
 		}
 
 		maxSlots := len(agentsByNumSlots[n]) * n
-		if s := req.SlotsNeeded; maxSlots >= s {
-			candidateNumSlots = n
-			break
+		if s := req.SlotsNeeded; maxSlots < s {
+			continue
+		}
+
+		var candidates candidateList
+		for _, agent := range agentsByNumSlots[n] {
+			candidates = append(candidates, &fittingState{
+				Agent:         agent,
+				TopologyMatch: topologyEnabled(agent.topologyPolicy.NVLink) && nvlinkMatch(req, agent),
+				Score:         fittingMethod(req, agent),
+				HashDistance:  hashDistance(req, agent),
+			})
 		}
+
+		sort.Sort(candidates)
+
+		numContainers := req.SlotsNeeded / n
+		slotsPerContainer := req.SlotsNeeded / numContainers
+		fits := railAlignedFits(candidates, numContainers)
+		if fits == nil {
+			continue
+		}
+		for _, c := range fits {
+			c.Slots = slotsPerContainer
+		}
+
+		return fits
 	}
 
-	if candidateNumSlots == 0 {
-		log.Debugf("Task: %s which requires %d slots, can not be scheduled onto multiple agents "+
-			"in the current cluster configuration. When running on multiple agents, number of "+
-			"slots per trial must be either set to 1 or a multiple of the GPUs per agent.",
-			req.ID, req.SlotsNeeded,
-		)
+	log.Debugf("Task: %s which requires %d slots, can not be scheduled onto multiple agents "+
+		"in the current cluster configuration. When running on multiple agents, number of "+
+		"slots per trial must be either set to 1 or a multiple of the GPUs per agent.",
+		req.ID, req.SlotsNeeded,
+	)
+	return nil
+}
+
+// findSpreadFits fits a task that asks for a number of containers onto as many different agents,
+// each of which must have room for an equal share of the slots of the task.
+func findSpreadFits(
+	req *sproto.AllocateRequest, agents map[*actor.Ref]*agentState, fittingMethod SoftConstraint,
+) []*fittingState {
+	numContainers := req.FittingRequirements.Containers
+	if req.SlotsNeeded%numContainers != 0 {
 		return nil
 	}
+	containerReq := *req
+	containerReq.SlotsNeeded = req.SlotsNeeded / numContainers
 
 	var candidates candidateList
-	for _, agent := range agentsByNumSlots[candidateNumSlots] {
+	for _, agent := range agents {
+		if !isViable(&containerReq, agent, slotsSatisfied, maxZeroSlotContainersSatisfied,
+			labelSatisfied, agentHealthySatisfied, platformSatisfied, gpuTypeSatisfied,
+			nvlinkSatisfied) {
+			continue
+		}
+
 		candidates = append(candidates, &fittingState{
-			Agent:        agent,
-			Score:        fittingMethod(req, agent),
+			Agent: agent,
+			TopologyMatch: topologyEnabled(agent.topologyPolicy.NVLink) &&
+				nvlinkMatch(&containerReq, agent),
+			Score:        fittingMethod(&containerReq, agent),
 			HashDistance: hashDistance(req, agent),
 		})
 	}
 
+	if len(candidates) < numContainers {
+		return nil
+	}
+
 	sort.Sort(candidates)
 
-	numContainers := req.SlotsNeeded / candidateNumSlots
-	slotsPerContainer := req.SlotsNeeded / numContainers
 	fits := candidates[:numContainers]
-	for _, c := range fits {
-		c.Slots = slotsPerContainer
+	for _, fit := range fits {
+		fit.Slots = containerReq.SlotsNeeded
 	}
-
 	return fits
 }
 
@@ -170,14 +228,16 @@ func findSharedAgentFit(
 ) *fittingState {
 	var candidates candidateList
 	for _, agent := range agents {
-		if !isViable(req, agent, slotsSatisfied, maxZeroSlotContainersSatisfied, labelSatisfied) {
+		if !isViable(req, agent, slotsSatisfied, maxZeroSlotContainersSatisfied, labelSatisfied,
+			agentHealthySatisfied, platformSatisfied, gpuTypeSatisfied, nvlinkSatisfied) {
 			continue
 		}
 
 		candidates = append(candidates, &fittingState{
-			Agent:        agent,
-			Score:        fittingMethod(req, agent),
-			HashDistance: hashDistance(req, agent),
+			Agent:         agent,
+			TopologyMatch: topologyEnabled(agent.topologyPolicy.NVLink) && nvlinkMatch(req, agent),
+			Score:         fittingMethod(req, agent),
+			HashDistance:  hashDistance(req, agent),
 		})
 	}
 
@@ -16,6 +16,19 @@ func labelSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
 	return req.Label == agent.label
 }
 
+// platformSatisfied returns true if the agent runs on the platform that the task asks for, if any.
+// Tasks that ask for none, such as checkpoint GC, run on agents of every platform.
+func platformSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
+	return req.Platform == "" || req.Platform == agent.platform
+}
+
+// gpuTypeSatisfied returns true if the agent has enough free GPUs of the model that the task asks
+// for, if any.
+func gpuTypeSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
+	return req.GPUType == "" || req.SlotsNeeded == 0 ||
+		req.SlotsNeeded <= agent.numEmptySlotsOfType(req.GPUType)
+}
+
 func maxZeroSlotContainersSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
 	if req.SlotsNeeded == 0 {
 		if agent.maxZeroSlotContainers == 0 {
@@ -26,6 +39,10 @@ func maxZeroSlotContainersSatisfied(req *sproto.AllocateRequest, agent *agentSta
 	return true
 }
 
+func agentHealthySatisfied(_ *sproto.AllocateRequest, agent *agentState) bool {
+	return agent.healthy && !agent.interrupted
+}
+
 func agentSlotUnusedSatisfied(_ *sproto.AllocateRequest, agent *agentState) bool {
 	return agent.numUsedSlots() == 0
 }
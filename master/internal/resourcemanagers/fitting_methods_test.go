@@ -80,3 +80,26 @@ func TestWorstFit(t *testing.T) {
 		newFakeAgentState(t, system, "agent8", "", 10, 5, 100, 0),
 	), 0.5)
 }
+
+func TestMaxZeroSlotContainersOfAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	ref, created := system.ActorOf(actor.Addr("agent"), &mockAgent{id: "agent"})
+	assert.Assert(t, created)
+	req := &sproto.AllocateRequest{SlotsNeeded: 0}
+
+	state := newAgentState(sproto.AddAgent{Agent: ref, MaxZeroSlotContainers: 2}, 100)
+	assert.Equal(t, state.maxZeroSlotContainers, 2)
+	state.zeroSlotContainers["a"] = true
+	assert.Assert(t, maxZeroSlotContainersSatisfied(req, state))
+	state.zeroSlotContainers["b"] = true
+	assert.Assert(t, !maxZeroSlotContainersSatisfied(req, state))
+
+	// The limit of the pool still applies when it is lower, including when it changes.
+	state.setMaxZeroSlotContainers(1)
+	assert.Equal(t, state.maxZeroSlotContainers, 1)
+	state.setMaxZeroSlotContainers(0)
+	assert.Assert(t, !maxZeroSlotContainersSatisfied(req, state))
+
+	state = newAgentState(sproto.AddAgent{Agent: ref}, 100)
+	assert.Equal(t, state.maxZeroSlotContainers, 100)
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func TestIsViable(t *testing.T) {
@@ -23,6 +26,135 @@ func TestIsViable(t *testing.T) {
 		newFakeAgentState(t, system, "agent4", "", 1, 0, 100, 0), slotsSatisfied))
 }
 
+func TestIsViableUnhealthyAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	req := &sproto.AllocateRequest{SlotsNeeded: 1}
+
+	agent := newFakeAgentState(t, system, "agent1", "", 4, 0, 100, 0)
+	assert.Assert(t, isViable(req, agent, slotsSatisfied, agentHealthySatisfied))
+	agent.healthy = false
+	assert.Assert(t, !isViable(req, agent, slotsSatisfied, agentHealthySatisfied))
+	assert.Equal(t, len(findFits(req, map[*actor.Ref]*agentState{agent.handler: agent}, BestFit)), 0)
+}
+
+func TestUnhealthyDevicesExcluded(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	req := &sproto.AllocateRequest{SlotsNeeded: 4}
+
+	agent := newFakeAgentState(t, system, "agent1", "", 4, 0, 100, 0)
+	agents := map[*actor.Ref]*agentState{agent.handler: agent}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 1)
+
+	agent.unhealthyDevices[device.Device{ID: 2}] = true
+	assert.Equal(t, agent.numEmptySlots(), 3)
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+
+	req = &sproto.AllocateRequest{SlotsNeeded: 3}
+	for _, d := range agent.allocateFreeDevices(req.SlotsNeeded, cproto.NewID(), "") {
+		assert.Assert(t, d.ID != 2)
+	}
+	assert.Equal(t, agent.numEmptySlots(), 0)
+}
+
+func TestPlatformSatisfied(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	agent := newFakeAgentState(t, system, "agent1", "", 4, 0, 100, 0)
+	agents := map[*actor.Ref]*agentState{agent.handler: agent}
+	assert.Equal(t, agent.platform, model.DefaultPlatform)
+	assert.Equal(t, len(findFits(&sproto.AllocateRequest{SlotsNeeded: 1}, agents, BestFit)), 1)
+
+	req := &sproto.AllocateRequest{SlotsNeeded: 1, Platform: "linux/arm64"}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+
+	agent.platform = "linux/arm64"
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 1)
+	assert.Equal(t, len(findFits(&sproto.AllocateRequest{SlotsNeeded: 1}, agents, BestFit)), 1)
+
+	req = &sproto.AllocateRequest{SlotsNeeded: 1, Platform: model.DefaultPlatform}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+}
+
+func TestPlatformUnsetFitsArm64Pool(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	agents := map[*actor.Ref]*agentState{}
+	for _, id := range []string{"agent1", "agent2"} {
+		agent := newFakeAgentState(t, system, id, "", 4, 0, 100, 0)
+		agent.platform = "linux/arm64"
+		agents[agent.handler] = agent
+	}
+
+	// Tasks without a platform, like checkpoint GC and TensorBoards, fit on the arm64 agents.
+	for _, req := range []*sproto.AllocateRequest{
+		{SlotsNeeded: 0},
+		{SlotsNeeded: 1},
+		{SlotsNeeded: 8},
+	} {
+		assert.Assert(t, len(findFits(req, agents, BestFit)) > 0,
+			"expected a fit for %d slots", req.SlotsNeeded)
+	}
+}
+
+func TestGPUTypeSatisfied(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	setBrand := func(agent *agentState, brand string) {
+		agent.devices = map[device.Device]*cproto.ID{}
+		for i := 0; i < 2; i++ {
+			agent.devices[device.Device{ID: i, Brand: brand, Type: device.GPU}] = nil
+		}
+	}
+	a100 := newFakeAgentState(t, system, "a100", "", 2, 0, 100, 0)
+	setBrand(a100, "NVIDIA A100-SXM4-40GB")
+	v100 := newFakeAgentState(t, system, "v100", "", 2, 0, 100, 0)
+	setBrand(v100, "Tesla V100-SXM2-16GB")
+	agents := map[*actor.Ref]*agentState{a100.handler: a100, v100.handler: v100}
+
+	for _, gpuType := range []string{"a100", "NVIDIA A100", "nvidia-a100-sxm4-40gb"} {
+		req := &sproto.AllocateRequest{SlotsNeeded: 2, GPUType: gpuType}
+		fits := findFits(req, agents, BestFit)
+		assert.Equal(t, len(fits), 1)
+		assert.Equal(t, fits[0].Agent, a100)
+	}
+
+	req := &sproto.AllocateRequest{SlotsNeeded: 1, GPUType: "a10"}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+
+	a100.allocateFreeDevices(2, cproto.NewID(), "")
+	req = &sproto.AllocateRequest{SlotsNeeded: 1, GPUType: "a100"}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+	req = &sproto.AllocateRequest{SlotsNeeded: 1}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 1)
+}
+
+func TestGPUTypeOfMIGInstances(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	agent := newFakeAgentState(t, system, "agent1", "", 0, 0, 100, 0)
+	brand := "NVIDIA A100-SXM4-40GB MIG "
+	for i, profile := range []string{"3g.20gb", "2g.10gb", "2g.10gb"} {
+		agent.devices[device.Device{
+			ID: i + 1, Brand: brand + profile, Type: device.GPU, MIGProfile: profile,
+		}] = nil
+	}
+	agents := map[*actor.Ref]*agentState{agent.handler: agent}
+
+	req := &sproto.AllocateRequest{SlotsNeeded: 2, GPUType: "3g.20gb"}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+
+	req = &sproto.AllocateRequest{SlotsNeeded: 1, GPUType: "3g.20gb"}
+	fits := findFits(req, agents, BestFit)
+	assert.Equal(t, len(fits), 1)
+	devices := agent.allocateFreeDevices(fits[0].Slots, cproto.NewID(), req.GPUType)
+	assert.Equal(t, len(devices), 1)
+	assert.Equal(t, devices[0].MIGProfile, "3g.20gb")
+
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+	req = &sproto.AllocateRequest{SlotsNeeded: 2, GPUType: "2g.10gb"}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 1)
+}
+
 func TestFindFits(t *testing.T) {
 	type testCase struct {
 		Name          string
@@ -440,6 +572,81 @@ func TestFindDedicatedAgentFits(t *testing.T) {
 	}
 }
 
+func TestFindSpreadFits(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+
+	type testCase struct {
+		Name             string
+		SlotsNeeded      int
+		Containers       int
+		AgentCapacities  []int
+		ExpectedAgentFit []int
+	}
+
+	testCases := []testCase{
+		{
+			Name:             "One container per agent",
+			SlotsNeeded:      6,
+			Containers:       3,
+			AgentCapacities:  []int{4, 2, 1, 2},
+			ExpectedAgentFit: []int{0, 1, 3},
+		},
+		{
+			Name:             "Zero-slot containers",
+			SlotsNeeded:      0,
+			Containers:       2,
+			AgentCapacities:  []int{0, 0},
+			ExpectedAgentFit: []int{0, 1},
+		},
+		{
+			Name:            "Fewer agents than containers",
+			SlotsNeeded:     4,
+			Containers:      3,
+			AgentCapacities: []int{8, 8},
+		},
+		{
+			Name:            "Slots needed should be a multiple of the containers",
+			SlotsNeeded:     5,
+			Containers:      2,
+			AgentCapacities: []int{8, 8},
+		},
+	}
+
+	for idx := range testCases {
+		tc := testCases[idx]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			var index []*agentState
+			for i, capacity := range tc.AgentCapacities {
+				index = append(index,
+					newFakeAgentState(t, system, fmt.Sprintf("%s-agent-%d", tc.Name, i), "", capacity, 0, 100, 0))
+			}
+			agents, index := byHandler(index...)
+			agentIndex := make(map[*agentState]int)
+			for idx, agent := range index {
+				agentIndex[agent] = idx
+			}
+
+			req := &sproto.AllocateRequest{
+				ID:          "task1",
+				SlotsNeeded: tc.SlotsNeeded,
+				FittingRequirements: sproto.FittingRequirements{
+					Containers: tc.Containers,
+				},
+			}
+			fits := findFits(req, agents, BestFit)
+
+			var agentFit sort.IntSlice
+			for _, fit := range fits {
+				agentFit = append(agentFit, agentIndex[fit.Agent])
+				assert.Equal(t, fit.Slots, tc.SlotsNeeded/tc.Containers)
+			}
+			sort.Sort(agentFit)
+			assert.DeepEqual(t, tc.ExpectedAgentFit, []int(agentFit))
+		})
+	}
+}
+
 func byHandler(handlers ...*agentState) (map[*actor.Ref]*agentState, []*agentState) {
 	agents := make(map[*actor.Ref]*agentState)
 	index := make([]*agentState, 0, len(handlers))
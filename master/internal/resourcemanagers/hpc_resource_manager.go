@@ -0,0 +1,312 @@
+package resourcemanagers
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
+	"github.com/determined-ai/determined/master/pkg/check"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	image "github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
+)
+
+const hpcScheduler = "hpc"
+const hpcDummyResourcePool = sproto.HPCRPName
+
+// hpcResourceManager hands tasks to an HPC workload manager, which queues their jobs alongside
+// the site's other workloads. Tasks are assigned resources as soon as their group allows it; the
+// workload manager decides when the jobs actually run.
+type hpcResourceManager struct {
+	config          *HPCResourceManagerConfig
+	workloadManager string
+
+	reqList           *taskList
+	groups            map[*actor.Ref]*group
+	slotsUsedPerGroup map[*group]int
+
+	// Represent all jobs as a single agent.
+	agent *agentState
+
+	reschedule bool
+}
+
+func newHPCResourceManager(
+	config *HPCResourceManagerConfig, workloadManager string,
+) actor.Actor {
+	return &hpcResourceManager{
+		config:          config,
+		workloadManager: workloadManager,
+
+		reqList:           newTaskList(),
+		groups:            make(map[*actor.Ref]*group),
+		slotsUsedPerGroup: make(map[*group]int),
+	}
+}
+
+func (h *hpcResourceManager) Receive(ctx *actor.Context) error {
+	reschedule := true
+	defer func() {
+		// Default to scheduling every 500ms if a message was received, but allow messages
+		// that don't affect the cluster to be skipped.
+		h.reschedule = h.reschedule || reschedule
+	}()
+
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		actors.NotifyAfter(ctx, actionCoolDown, schedulerTick{})
+
+	case sproto.SetHPCJobs:
+		check.Panic(check.True(h.agent == nil, "should only set jobs once"))
+		h.agent = &agentState{
+			handler:            msg.Jobs,
+			devices:            make(map[device.Device]*cproto.ID),
+			zeroSlotContainers: make(map[cproto.ID]bool),
+		}
+
+	case
+		groupActorStopped,
+		sproto.SetGroupMaxSlots,
+		sproto.SetGroupWeight,
+		sproto.SetGroupPriority,
+		sproto.SetTaskName,
+		sproto.AllocateRequest,
+		sproto.ResourcesReleased:
+		return h.receiveRequestMsg(ctx)
+
+	case sproto.GetTaskSummary:
+		if resp := getTaskSummary(h.reqList, *msg.ID, h.groups, hpcScheduler); resp != nil {
+			ctx.Respond(*resp)
+		}
+		reschedule = false
+
+	case sproto.GetTaskSummaries:
+		reschedule = false
+		ctx.Respond(getTaskSummaries(h.reqList, h.groups, hpcScheduler))
+
+	case *apiv1.GetResourcePoolsRequest:
+		resp := &apiv1.GetResourcePoolsResponse{
+			ResourcePools: []*resourcepoolv1.ResourcePool{h.summarizeDummyResourcePool()},
+		}
+		ctx.Respond(resp)
+
+	case sproto.GetDefaultGPUResourcePoolRequest:
+		ctx.Respond(sproto.GetDefaultGPUResourcePoolResponse{PoolName: ""})
+
+	case sproto.GetDefaultCPUResourcePoolRequest:
+		ctx.Respond(sproto.GetDefaultCPUResourcePoolResponse{PoolName: ""})
+
+	case schedulerTick:
+		if h.reschedule {
+			h.schedulePendingTasks(ctx)
+		}
+		h.reschedule = false
+		reschedule = false
+		actors.NotifyAfter(ctx, actionCoolDown, schedulerTick{})
+
+	default:
+		reschedule = false
+		ctx.Log().Errorf("unexpected message %T", msg)
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+
+	return nil
+}
+
+func (h *hpcResourceManager) summarizeDummyResourcePool() *resourcepoolv1.ResourcePool {
+	slotsUsed := 0
+	for _, slotsUsedByGroup := range h.slotsUsedPerGroup {
+		slotsUsed += slotsUsedByGroup
+	}
+	return &resourcepoolv1.ResourcePool{
+		Name:                   hpcDummyResourcePool,
+		Description:            "Pool of resources managed by " + h.workloadManager,
+		Type:                   resourcepoolv1.ResourcePoolType_RESOURCE_POOL_TYPE_STATIC,
+		SlotsUsed:              int32(slotsUsed),
+		CpuContainersRunning:   int32(h.agent.numZeroSlotContainers()),
+		DefaultGpuPool:         true,
+		DefaultCpuPool:         true,
+		SlotsPerAgent:          int32(h.config.MaxSlotsPerJob),
+		SchedulerType:          resourcepoolv1.SchedulerType_SCHEDULER_TYPE_UNSPECIFIED,
+		SchedulerFittingPolicy: resourcepoolv1.FittingPolicy_FITTING_POLICY_UNSPECIFIED,
+		Location:               h.workloadManager,
+		InstanceType:           h.config.Partition,
+		Details:                &resourcepoolv1.ResourcePoolDetail{},
+	}
+}
+
+func (h *hpcResourceManager) receiveRequestMsg(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case groupActorStopped:
+		delete(h.slotsUsedPerGroup, h.groups[msg.Ref])
+		delete(h.groups, msg.Ref)
+
+	case sproto.SetGroupMaxSlots:
+		h.getOrCreateGroup(ctx, msg.Handler).maxSlots = msg.MaxSlots
+
+	case sproto.SetGroupWeight:
+		// SetGroupWeight is not supported by the HPC RM.
+
+	case sproto.SetGroupPriority:
+		// SetGroupPriority is not supported by the HPC RM; the workload manager orders its queue.
+
+	case sproto.SetTaskName:
+		if task, found := h.reqList.GetTaskByHandler(msg.TaskHandler); found {
+			task.Name = msg.Name
+		}
+
+	case sproto.AllocateRequest:
+		h.addTask(ctx, msg)
+
+	case sproto.ResourcesReleased:
+		h.resourcesReleased(ctx, msg.TaskActor)
+
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (h *hpcResourceManager) addTask(ctx *actor.Context, msg sproto.AllocateRequest) {
+	actors.NotifyOnStop(ctx, msg.TaskActor, sproto.ResourcesReleased{TaskActor: msg.TaskActor})
+
+	if len(msg.ID) == 0 {
+		msg.ID = sproto.TaskID(uuid.New().String())
+	}
+	if msg.Group == nil {
+		msg.Group = msg.TaskActor
+	}
+	h.getOrCreateGroup(ctx, msg.Group)
+	if len(msg.Name) == 0 {
+		msg.Name = "Unnamed-HPC-Task"
+	}
+
+	ctx.Log().Infof(
+		"resources are requested by %s (Task ID: %s)",
+		msg.TaskActor.Address(), msg.ID,
+	)
+	h.reqList.AddTask(&msg)
+}
+
+func (h *hpcResourceManager) assignResources(ctx *actor.Context, req *sproto.AllocateRequest) {
+	numJobs := 1
+	slotsPerJob := req.SlotsNeeded
+	if h.config.MaxSlotsPerJob > 0 && req.SlotsNeeded > h.config.MaxSlotsPerJob {
+		if req.SlotsNeeded%h.config.MaxSlotsPerJob != 0 {
+			ctx.Log().WithField("task-id", req.ID).Errorf(
+				"task number of slots (%d) is not schedulable on the configured "+
+					"max_slots_per_job (%d)", req.SlotsNeeded, h.config.MaxSlotsPerJob)
+			return
+		}
+
+		numJobs = req.SlotsNeeded / h.config.MaxSlotsPerJob
+		slotsPerJob = h.config.MaxSlotsPerJob
+	}
+
+	h.slotsUsedPerGroup[h.groups[req.Group]] += req.SlotsNeeded
+
+	allocations := make([]sproto.Allocation, 0, numJobs)
+	for job := 0; job < numJobs; job++ {
+		container := newContainer(req, h.agent, slotsPerJob)
+		if slotsPerJob == 0 {
+			h.agent.zeroSlotContainers[container.id] = true
+		}
+		allocations = append(allocations, &hpcAllocation{
+			req:       req,
+			agent:     h.agent,
+			container: container,
+		})
+	}
+
+	assigned := sproto.ResourcesAllocated{
+		ID: req.ID, Allocations: allocations, Slots: req.SlotsNeeded,
+	}
+	h.reqList.SetAllocations(req.TaskActor, &assigned)
+	req.TaskActor.System().Tell(req.TaskActor, assigned)
+
+	ctx.Log().
+		WithField("task-id", req.ID).
+		WithField("task-handler", req.TaskActor.Address()).
+		Infof("resources assigned with %d jobs", numJobs)
+}
+
+func (h *hpcResourceManager) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
+	ctx.Log().Infof("resources are released for %s", handler.Address())
+	req, ok := h.reqList.GetTaskByHandler(handler)
+	if assigned := h.reqList.GetAllocations(handler); ok && assigned != nil {
+		if group := h.groups[req.Group]; group != nil {
+			h.slotsUsedPerGroup[group] -= req.SlotsNeeded
+		}
+		for _, allocation := range assigned.Allocations {
+			delete(h.agent.zeroSlotContainers, allocation.(*hpcAllocation).container.id)
+		}
+	}
+	h.reqList.RemoveTaskByHandler(handler)
+}
+
+func (h *hpcResourceManager) getOrCreateGroup(ctx *actor.Context, handler *actor.Ref) *group {
+	if g, ok := h.groups[handler]; ok {
+		return g
+	}
+	g := &group{handler: handler, weight: 1}
+	h.groups[handler] = g
+	h.slotsUsedPerGroup[g] = 0
+
+	if ctx != nil && handler != nil { // ctx is nil only for testing purposes.
+		actors.NotifyOnStop(ctx, handler, groupActorStopped{})
+	}
+	return g
+}
+
+func (h *hpcResourceManager) schedulePendingTasks(ctx *actor.Context) {
+	for it := h.reqList.iterator(); it.next(); {
+		req := it.value()
+		group := h.groups[req.Group]
+		assigned := h.reqList.GetAllocations(req.TaskActor)
+		if unassigned := assigned == nil || len(assigned.Allocations) == 0; unassigned {
+			if maxSlots := group.maxSlots; maxSlots != nil {
+				if h.slotsUsedPerGroup[group]+req.SlotsNeeded > *maxSlots {
+					continue
+				}
+			}
+
+			h.assignResources(ctx, req)
+		}
+	}
+}
+
+type hpcAllocation struct {
+	req       *sproto.AllocateRequest
+	container *container
+	agent     *agentState
+}
+
+// Summary summarizes a container allocation.
+func (a hpcAllocation) Summary() sproto.ContainerSummary {
+	return sproto.ContainerSummary{
+		TaskID: a.req.ID,
+		ID:     a.container.id,
+		Agent:  a.agent.handler.Address().Local(),
+	}
+}
+
+// Start notifies the jobs actor that it should submit a job for the provided task spec.
+func (a hpcAllocation) Start(ctx *actor.Context, spec image.TaskSpec) {
+	spec.ContainerID = string(a.container.id)
+	spec.TaskID = string(a.req.ID)
+	ctx.Tell(a.agent.handler, sproto.StartHPCJob{
+		TaskActor: a.req.TaskActor,
+		Spec:      spec,
+		Slots:     a.container.slots,
+	})
+}
+
+// Kill notifies the jobs actor that it should cancel the job. The workload manager stops the job
+// as it is configured to, so the stop policy does not apply.
+func (a hpcAllocation) Kill(ctx *actor.Context, _ model.StopPolicy) {
+	ctx.Tell(a.agent.handler, sproto.KillHPCJob{ContainerID: a.container.id})
+}
@@ -9,13 +9,14 @@ import (
 	"github.com/determined-ai/determined/master/pkg/check"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 	image "github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
 )
 
 const kubernetesScheduler = "kubernetes"
-const kubernetesDummyResourcePool = "kubernetes"
+const kubernetesDummyResourcePool = sproto.K8sRPName
 
 // kubernetesResourceProvider manages the lifecycle of k8s resources.
 type kubernetesResourceManager struct {
@@ -154,8 +155,13 @@ func (k *kubernetesResourceManager) receiveRequestMsg(ctx *actor.Context) error
 	case sproto.SetGroupMaxSlots:
 		k.getOrCreateGroup(ctx, msg.Handler).maxSlots = msg.MaxSlots
 
-	case sproto.SetGroupWeight, sproto.SetGroupPriority:
-		// SetGroupWeight and SetGroupPriority are not supported by the Kubernetes RP.
+	case sproto.SetGroupWeight:
+		// SetGroupWeight is not supported by the Kubernetes RP.
+
+	case sproto.SetGroupPriority:
+		// Priorities do not affect the order tasks are assigned pods in, but they are passed on
+		// to the Kubernetes scheduler through the pods' priority classes.
+		k.getOrCreateGroup(ctx, msg.Handler).priority = msg.Priority
 
 	case sproto.SetTaskName:
 		k.receiveSetTaskName(ctx, msg)
@@ -204,7 +210,16 @@ func (k *kubernetesResourceManager) assignResources(
 ) {
 	numPods := 1
 	slotsPerPod := req.SlotsNeeded
-	if req.SlotsNeeded > 1 {
+	if containers := req.FittingRequirements.Containers; containers > 1 {
+		if req.SlotsNeeded%containers != 0 {
+			ctx.Log().WithField("task-id", req.ID).Errorf(
+				"task number of slots (%d) is not divisible by its number of containers (%d)",
+				req.SlotsNeeded, containers)
+			return
+		}
+		numPods = containers
+		slotsPerPod = req.SlotsNeeded / containers
+	} else if req.SlotsNeeded > 1 {
 		if k.config.MaxSlotsPerPod == 0 {
 			ctx.Log().WithField("task-id", req.ID).Error(
 				"set max_slots_per_pod > 0 to schedule tasks with slots")
@@ -230,16 +245,26 @@ func (k *kubernetesResourceManager) assignResources(
 	k.slotsUsedPerGroup[k.groups[req.Group]] += req.SlotsNeeded
 
 	allocations := make([]sproto.Allocation, 0, numPods)
+	// The pods of tasks that ask for a number of containers are not necessarily started at once, so
+	// they are not scheduled as a gang.
+	gangSize := numPods
+	if req.FittingRequirements.Containers > 1 {
+		gangSize = 1
+	}
 	for pod := 0; pod < numPods; pod++ {
 		container := newContainer(req, k.agent, slotsPerPod)
 		allocations = append(allocations, &podAllocation{
 			req:       req,
+			group:     k.groups[req.Group],
 			agent:     k.agent,
 			container: container,
+			gangSize:  gangSize,
 		})
 	}
 
-	assigned := sproto.ResourcesAllocated{ID: req.ID, Allocations: allocations}
+	assigned := sproto.ResourcesAllocated{
+		ID: req.ID, Allocations: allocations, Slots: req.SlotsNeeded,
+	}
 	k.reqList.SetAllocations(req.TaskActor, &assigned)
 	req.TaskActor.System().Tell(req.TaskActor, assigned)
 
@@ -298,8 +323,11 @@ func (k *kubernetesResourceManager) schedulePendingTasks(ctx *actor.Context) {
 
 type podAllocation struct {
 	req       *sproto.AllocateRequest
+	group     *group
 	container *container
 	agent     *agentState
+	// gangSize is the number of pods of the task that must be scheduled together.
+	gangSize int
 }
 
 // Summary summarizes a container allocation.
@@ -320,13 +348,16 @@ func (p podAllocation) Start(ctx *actor.Context, spec image.TaskSpec) {
 		TaskActor: p.req.TaskActor,
 		Spec:      spec,
 		Slots:     p.container.slots,
+		Priority:  p.group.priority,
+		GangSize:  p.gangSize,
 	})
 }
 
 // Kill notifies the pods actor that it should stop the pod.
-func (p podAllocation) Kill(ctx *actor.Context) {
+func (p podAllocation) Kill(ctx *actor.Context, stop model.StopPolicy) {
 	handler := p.agent.handler
 	ctx.Tell(handler, sproto.KillTaskPod{
 		PodID: p.container.id,
+		Stop:  stop,
 	})
 }
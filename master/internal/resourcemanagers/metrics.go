@@ -0,0 +1,82 @@
+package resourcemanagers
+
+import (
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// poolCollector returns a Prometheus collector reporting the allocations and slots of the resource
+// pools of every resource manager.
+func poolCollector(system *actor.System, rm *actor.Ref, refs []*actor.Ref) prom.Collector {
+	return func() []prom.Metric {
+		allocations := prom.Metric{
+			Name: "det_resource_pool_allocations",
+			Help: "Number of tasks in the resource pool that are queued or have been allocated.",
+			Type: prom.Gauge,
+		}
+		slots := prom.Metric{
+			Name: "det_resource_pool_slots",
+			Help: "Number of slots of the agents in the resource pool.",
+			Type: prom.Gauge,
+		}
+		slotsUsed := prom.Metric{
+			Name: "det_resource_pool_slots_used",
+			Help: "Number of slots in the resource pool that are allocated to tasks.",
+			Type: prom.Gauge,
+		}
+		utilization := prom.Metric{
+			Name: "det_resource_pool_slot_utilization",
+			Help: "Fraction of the slots in the resource pool that are allocated to tasks.",
+			Type: prom.Gauge,
+		}
+
+		queued := make(map[string]int)
+		running := make(map[string]int)
+		for _, ref := range refs {
+			pools := system.Ask(ref, &apiv1.GetResourcePoolsRequest{}).Get()
+			resp, ok := pools.(*apiv1.GetResourcePoolsResponse)
+			if !ok {
+				continue
+			}
+			for _, pool := range resp.ResourcePools {
+				labels := map[string]string{"resource_pool": pool.Name}
+				queued[pool.Name], running[pool.Name] = 0, 0
+				slots.Samples = append(slots.Samples,
+					prom.Sample{Labels: labels, Value: float64(pool.SlotsAvailable)})
+				slotsUsed.Samples = append(slotsUsed.Samples,
+					prom.Sample{Labels: labels, Value: float64(pool.SlotsUsed)})
+				var used float64
+				if pool.SlotsAvailable > 0 {
+					used = float64(pool.SlotsUsed) / float64(pool.SlotsAvailable)
+				}
+				utilization.Samples = append(utilization.Samples,
+					prom.Sample{Labels: labels, Value: used})
+			}
+		}
+
+		tasks := system.Ask(rm, sproto.GetTaskSummaries{}).Get()
+		summaries, _ := tasks.(map[sproto.TaskID]TaskSummary)
+		for _, summary := range summaries {
+			if len(summary.Containers) == 0 {
+				queued[summary.ResourcePool]++
+			} else {
+				running[summary.ResourcePool]++
+			}
+		}
+		for pool, n := range queued {
+			allocations.Samples = append(allocations.Samples, prom.Sample{
+				Labels: map[string]string{"resource_pool": pool, "state": "queued"},
+				Value:  float64(n),
+			})
+		}
+		for pool, n := range running {
+			allocations.Samples = append(allocations.Samples, prom.Sample{
+				Labels: map[string]string{"resource_pool": pool, "state": "running"},
+				Value:  float64(n),
+			})
+		}
+		return []prom.Metric{allocations, slots, slotsUsed, utilization}
+	}
+}
@@ -136,7 +136,7 @@ func (p *priorityScheduler) prioritySchedulerWithFilter(
 				log.Debugf(
 					"Not preempting tasks for task %s as it will be able to launch "+
 						"once already scheduled preemptions complete", prioritizedAllocation.Name)
-				addTaskToAgents(fits)
+				addTaskToAgents(prioritizedAllocation, fits)
 				continue
 			}
 
@@ -194,7 +194,7 @@ func trySchedulingTaskViaPreemption(
 			preemptedTasks[preemptionCandidate.TaskActor] = true
 
 			if fits := findFits(allocationRequest, localAgentsState, fittingMethod); len(fits) > 0 {
-				addTaskToAgents(fits)
+				addTaskToAgents(allocationRequest, fits)
 				return true, localAgentsState, preemptedTasks
 			}
 		}
@@ -220,7 +220,7 @@ func trySchedulingPendingTasksInPriority(
 			unSuccessfulAllocations = append(unSuccessfulAllocations, allocationRequest)
 			continue
 		}
-		addTaskToAgents(fits)
+		addTaskToAgents(allocationRequest, fits)
 		successfulAllocations = append(successfulAllocations, allocationRequest)
 	}
 
@@ -296,9 +296,9 @@ func deepCopyAgents(agents map[*actor.Ref]*agentState) map[*actor.Ref]*agentStat
 	return copiedAgents
 }
 
-func addTaskToAgents(fits []*fittingState) {
+func addTaskToAgents(req *sproto.AllocateRequest, fits []*fittingState) {
 	for _, fit := range fits {
-		fit.Agent.allocateFreeDevices(fit.Slots, cproto.NewID())
+		fit.Agent.allocateFreeDevices(fit.Slots, cproto.NewID(), req.GPUType)
 	}
 }
 
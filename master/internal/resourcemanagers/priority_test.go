@@ -843,7 +843,7 @@ func AllocateTasks(
 
 		for _, fit := range fits {
 			container := newContainer(req, fit.Agent, fit.Slots)
-			devices := fit.Agent.allocateFreeDevices(fit.Slots, container.id)
+			devices := fit.Agent.allocateFreeDevices(fit.Slots, container.id, "")
 			allocated := &sproto.ResourcesAllocated{
 				ID: req.ID,
 				Allocations: []sproto.Allocation{
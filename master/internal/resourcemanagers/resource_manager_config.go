@@ -2,8 +2,16 @@ package resourcemanagers
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"strings"
 
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/agent"
+	"github.com/determined-ai/determined/master/internal/hpc"
+	"github.com/determined-ai/determined/master/internal/kubernetes"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/union"
 )
 
@@ -13,6 +21,8 @@ const defaultResourcePoolName = "default"
 type ResourceManagerConfig struct {
 	AgentRM      *AgentResourceManagerConfig      `union:"type,agent" json:"-"`
 	KubernetesRM *KubernetesResourceManagerConfig `union:"type,kubernetes" json:"-"`
+	SlurmRM      *HPCResourceManagerConfig        `union:"type,slurm" json:"-"`
+	PBSRM        *HPCResourceManagerConfig        `union:"type,pbs" json:"-"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -32,7 +42,7 @@ func (r *ResourceManagerConfig) UnmarshalJSON(data []byte) error {
 	}
 
 	// Fill in the default config.
-	if r.AgentRM == nil && r.KubernetesRM == nil {
+	if r.AgentRM == nil && r.KubernetesRM == nil && r.SlurmRM == nil && r.PBSRM == nil {
 		r.AgentRM = &AgentResourceManagerConfig{
 			Scheduler: &SchedulerConfig{
 				FittingPolicy: defaultFitPolicy,
@@ -44,11 +54,37 @@ func (r *ResourceManagerConfig) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// HPCRM returns the configuration of the Slurm or PBS resource manager, if one is configured.
+func (r ResourceManagerConfig) HPCRM() *HPCResourceManagerConfig {
+	if r.SlurmRM != nil {
+		return r.SlurmRM
+	}
+	return r.PBSRM
+}
+
+// WorkloadManager returns the workload manager that the HPC resource manager submits jobs to.
+func (r ResourceManagerConfig) WorkloadManager() string {
+	if r.SlurmRM != nil {
+		return hpc.Slurm
+	}
+	return hpc.PBS
+}
+
 // AgentResourceManagerConfig hosts configuration fields for the determined resource manager.
 type AgentResourceManagerConfig struct {
-	Scheduler              *SchedulerConfig `json:"scheduler"`
-	DefaultCPUResourcePool string           `json:"default_cpu_resource_pool"`
-	DefaultGPUResourcePool string           `json:"default_gpu_resource_pool"`
+	Scheduler              *SchedulerConfig    `json:"scheduler"`
+	DefaultCPUResourcePool string              `json:"default_cpu_resource_pool"`
+	DefaultGPUResourcePool string              `json:"default_gpu_resource_pool"`
+	AgentHealth            *agent.HealthConfig `json:"agent_health"`
+	// Profiling configures how agents sample the utilization of task containers.
+	Profiling *agent.ProfilingConfig `json:"profiling"`
+	// AgentRegistration configures which agents may join the cluster.
+	AgentRegistration agent.RegistrationConfig `json:"agent_registration"`
+	// AgentVersionSkew is what the master does with agents whose version is incompatible with its
+	// own. Defaults to warning about them.
+	AgentVersionSkew agent.VersionSkewPolicy `json:"agent_version_skew"`
+	// TaskHooks are the commands and HTTP calls that agents run around the containers of tasks.
+	TaskHooks model.TaskHooksConfig `json:"task_hooks"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -72,6 +108,8 @@ func (a AgentResourceManagerConfig) Validate() []error {
 	return []error{
 		check.NotEmpty(a.DefaultCPUResourcePool, "default_cpu_resource_pool should be non-empty"),
 		check.NotEmpty(a.DefaultGPUResourcePool, "default_gpu_resource_pool should be non-empty"),
+		check.In(string(a.AgentVersionSkew), agent.VersionSkewPolicies,
+			"agent_version_skew must be one of ignore, warn and refuse"),
 	}
 }
 
@@ -82,11 +120,84 @@ type KubernetesResourceManagerConfig struct {
 	MasterServiceName        string `json:"master_service_name"`
 	LeaveKubernetesResources bool   `json:"leave_kubernetes_resources"`
 	DefaultScheduler         string `json:"default_scheduler"`
+
+	// AllowedNamespaces are the namespaces, in addition to Namespace, that tasks may choose to
+	// launch their pods in.
+	AllowedNamespaces []string `json:"allowed_namespaces"`
+	// PriorityClasses assign Kubernetes priority classes to pods based on their tasks' priorities.
+	PriorityClasses []kubernetes.PriorityClass `json:"priority_classes"`
 }
 
 // Validate implements the check.Validatable interface.
 func (k KubernetesResourceManagerConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.GreaterThanOrEqualTo(k.MaxSlotsPerPod, 0, "max_slots_per_pod must be >= 0"),
 	}
+	for _, namespace := range k.AllowedNamespaces {
+		errs = append(errs, check.NotEmpty(namespace, "allowed_namespaces must not contain empty names"))
+	}
+	return errs
+}
+
+// Namespaces returns every namespace that task pods may be launched in, starting with the default.
+func (k KubernetesResourceManagerConfig) Namespaces() []string {
+	namespaces := []string{k.Namespace}
+	for _, namespace := range k.AllowedNamespaces {
+		if namespace != k.Namespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// CheckNamespace returns an error if tasks may not launch pods in the namespace. An empty
+// namespace refers to the default one and is always allowed.
+func (k KubernetesResourceManagerConfig) CheckNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	for _, allowed := range k.Namespaces() {
+		if namespace == allowed {
+			return nil
+		}
+	}
+	return errors.Errorf("tasks may not launch pods in the Kubernetes namespace %s; allowed "+
+		"namespaces are %s", namespace, strings.Join(k.Namespaces(), ", "))
+}
+
+// HPCResourceManagerConfig hosts configuration fields for the resource managers that run tasks as
+// batch jobs of an HPC workload manager.
+type HPCResourceManagerConfig struct {
+	JobStorageRoot   string   `json:"job_storage_root"`
+	Partition        string   `json:"partition"`
+	SubmitArgs       []string `json:"submit_args"`
+	ContainerRuntime string   `json:"container_runtime"`
+	MasterHost       string   `json:"master_host"`
+	MasterPort       int      `json:"master_port"`
+	MaxSlotsPerJob   int      `json:"max_slots_per_job"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *HPCResourceManagerConfig) UnmarshalJSON(data []byte) error {
+	type DefaultParser *HPCResourceManagerConfig
+	if err := json.Unmarshal(data, DefaultParser(h)); err != nil {
+		return err
+	}
+
+	if h.ContainerRuntime == "" {
+		h.ContainerRuntime = "singularity"
+	}
+	if h.MasterPort == 0 {
+		h.MasterPort = 8080
+	}
+	return nil
+}
+
+// Validate implements the check.Validatable interface.
+func (h HPCResourceManagerConfig) Validate() []error {
+	return []error{
+		check.True(filepath.IsAbs(h.JobStorageRoot), "job_storage_root must be an absolute path"),
+		check.NotEmpty(h.MasterHost, "master_host should be non-empty"),
+		check.GreaterThanOrEqualTo(h.MaxSlotsPerJob, 0, "max_slots_per_job must be >= 0"),
+	}
 }
@@ -0,0 +1,160 @@
+package resourcemanagers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestKubernetesNamespaces(t *testing.T) {
+	config := KubernetesResourceManagerConfig{
+		Namespace:         "default",
+		AllowedNamespaces: []string{"research", "default"},
+	}
+	assert.DeepEqual(t, config.Namespaces(), []string{"default", "research"})
+
+	assert.NilError(t, config.CheckNamespace(""))
+	assert.NilError(t, config.CheckNamespace("default"))
+	assert.NilError(t, config.CheckNamespace("research"))
+	assert.ErrorContains(t, config.CheckNamespace("kube-system"), "kube-system")
+}
+
+func TestHPCResourceManagerConfig(t *testing.T) {
+	var config ResourceManagerConfig
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"type": "slurm",
+		"job_storage_root": "/shared/determined",
+		"master_host": "login-1"
+	}`), &config))
+
+	assert.Assert(t, config.AgentRM == nil)
+	assert.Equal(t, config.WorkloadManager(), "slurm")
+	assert.DeepEqual(t, *config.HPCRM(), HPCResourceManagerConfig{
+		JobStorageRoot:   "/shared/determined",
+		ContainerRuntime: "singularity",
+		MasterHost:       "login-1",
+		MasterPort:       8080,
+	})
+	assert.NilError(t, check.Validate(config))
+
+	config.HPCRM().JobStorageRoot = "determined"
+	assert.ErrorContains(t, check.Validate(config), "job_storage_root")
+}
+
+func TestAdditionalResourceManagers(t *testing.T) {
+	hpcRM := &HPCResourceManagerConfig{JobStorageRoot: "/shared", MasterHost: "login-1"}
+	config := ResourceConfig{
+		ResourceManager: &ResourceManagerConfig{AgentRM: &AgentResourceManagerConfig{
+			DefaultCPUResourcePool: defaultResourcePoolName,
+			DefaultGPUResourcePool: defaultResourcePoolName,
+		}},
+		ResourcePools: []ResourcePoolConfig{{PoolName: defaultResourcePoolName}},
+		AdditionalResourceManagers: []*ResourceManagerConfig{
+			{KubernetesRM: &KubernetesResourceManagerConfig{}},
+			{SlurmRM: hpcRM},
+		},
+	}
+	assert.Assert(t, config.AgentResourceManager() == config.ResourceManager.AgentRM)
+	assert.Assert(t, config.KubernetesResourceManager() != nil)
+	assert.Equal(t, len(config.Validate()), 0)
+
+	config.AdditionalResourceManagers = append(config.AdditionalResourceManagers,
+		&ResourceManagerConfig{PBSRM: hpcRM})
+	config.ResourcePools = append(config.ResourcePools, ResourcePoolConfig{PoolName: "kubernetes"})
+	errs := config.Validate()
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs[0], "resource pool kubernetes belongs to both")
+	assert.ErrorContains(t, errs[1], "only one slurm or pbs resource manager")
+}
+
+func TestResourcePoolPricing(t *testing.T) {
+	var pool ResourcePoolConfig
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"pool_name": "gpu",
+		"pricing": {"slot_hour": 2.5}
+	}`), &pool))
+	assert.NilError(t, check.Validate(pool))
+	price, ok := pool.SlotHourPrice()
+	assert.Assert(t, ok)
+	assert.Equal(t, price, 2.5)
+
+	// Prices of instances are split between their slots.
+	assert.NilError(t, json.Unmarshal([]byte(`{
+		"pool_name": "aws",
+		"provider": {"type": "aws", "instance_type": "p3.8xlarge"},
+		"pricing": {"instance_hour": 12}
+	}`), &pool))
+	price, ok = pool.SlotHourPrice()
+	assert.Assert(t, ok)
+	assert.Equal(t, price, 3.0)
+
+	pool = ResourcePoolConfig{PoolName: "cpu"}
+	_, ok = pool.SlotHourPrice()
+	assert.Assert(t, !ok)
+	pool.Pricing = &PricingConfig{}
+	assert.ErrorContains(t, check.Validate(pool), "exactly one of slot_hour and instance_hour")
+	instanceHour := 1.0
+	pool.Pricing.InstanceHour = &instanceHour
+	assert.ErrorContains(t, check.Validate(pool), "requires a provider")
+}
+
+func TestCheckReload(t *testing.T) {
+	newConfig := func() ResourceConfig {
+		return ResourceConfig{
+			ResourceManager: &ResourceManagerConfig{AgentRM: &AgentResourceManagerConfig{
+				Scheduler:              DefaultSchedulerConfig(),
+				DefaultCPUResourcePool: defaultResourcePoolName,
+				DefaultGPUResourcePool: defaultResourcePoolName,
+			}},
+			ResourcePools: []ResourcePoolConfig{{PoolName: defaultResourcePoolName}},
+		}
+	}
+	config := newConfig()
+
+	// The scheduler can change, and pools can be added or change their description.
+	reloaded := newConfig()
+	reloaded.ResourceManager.AgentRM.Scheduler.FittingPolicy = worst
+	reloaded.ResourcePools[0].Description = "on-premises GPUs"
+	reloaded.ResourcePools = append(reloaded.ResourcePools, ResourcePoolConfig{PoolName: "cpu"})
+	assert.Equal(t, len(config.CheckReload(reloaded)), 0)
+
+	reloaded = newConfig()
+	reloaded.ResourceManager.AgentRM.DefaultCPUResourcePool = "cpu"
+	slotHour := 1.0
+	reloaded.ResourcePools = []ResourcePoolConfig{{
+		PoolName: "gpu", Pricing: &PricingConfig{SlotHour: &slotHour},
+	}}
+	errs := config.CheckReload(reloaded)
+	assert.Equal(t, len(errs), 3)
+	assert.ErrorContains(t, errs[0], "resource managers cannot change")
+	assert.ErrorContains(t, errs[1], "the pricing of resource pool gpu cannot change")
+	assert.ErrorContains(t, errs[2], "resource pool default cannot be removed")
+}
+
+func TestAgentLabelPools(t *testing.T) {
+	config := ResourceConfig{
+		ResourceManager: &ResourceManagerConfig{AgentRM: &AgentResourceManagerConfig{
+			DefaultCPUResourcePool: defaultResourcePoolName,
+			DefaultGPUResourcePool: defaultResourcePoolName,
+		}},
+		ResourcePools: []ResourcePoolConfig{
+			{PoolName: defaultResourcePoolName},
+			{PoolName: "a100", AgentLabel: "a100"},
+			{PoolName: "v100", AgentLabel: "v100"},
+		},
+	}
+	assert.Equal(t, len(config.Validate()), 0)
+	assert.DeepEqual(t, agentLabelPools(config.ResourcePools), map[string]string{
+		"a100": "a100",
+		"v100": "v100",
+	})
+
+	config.ResourcePools = append(config.ResourcePools,
+		ResourcePoolConfig{PoolName: "other", AgentLabel: "a100"})
+	errs := config.Validate()
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "resource pools a100 and other cannot both claim")
+}
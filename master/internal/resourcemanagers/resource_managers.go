@@ -4,8 +4,11 @@ import (
 	"crypto/tls"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/actor/actors"
 )
 
 const (
@@ -15,46 +18,148 @@ const (
 // schedulerTick periodically triggers the scheduler to act.
 type schedulerTick struct{}
 
-// ResourceManagers manages the configured resource providers.
-// Currently support only one resource manager at a time.
+// ResourceManagers routes messages to the configured resource managers. Every resource pool
+// belongs to exactly one resource manager; requests that do not name a pool go to the primary one.
 type ResourceManagers struct {
-	ref *actor.Ref
+	// ref is the primary resource manager and refs are all of them, starting with the primary.
+	ref  *actor.Ref
+	refs []*actor.Ref
+	// pools maps the name of each resource pool to the resource manager that backs it.
+	pools map[string]*actor.Ref
+	// agentRM is the agent resource manager, if one is configured.
+	agentRM *actor.Ref
+	// budgets admits allocate requests according to budgets, which are not enforced if it is nil.
+	budgets *budgetEnforcer
+}
+
+func newResourceManagers(config *ResourceConfig, refs []*actor.Ref) *ResourceManagers {
+	rm := &ResourceManagers{
+		ref:   refs[0],
+		refs:  refs,
+		pools: make(map[string]*actor.Ref),
+	}
+	for ix, rmConfig := range config.ResourceManagers() {
+		if rmConfig.AgentRM != nil {
+			rm.agentRM = refs[ix]
+		}
+		for _, pool := range config.poolNames(rmConfig) {
+			rm.pools[pool] = refs[ix]
+		}
+	}
+	return rm
 }
 
 // NewResourceManagers creates an instance of ResourceManagers.
 func NewResourceManagers(
 	system *actor.System, config *ResourceConfig, cert *tls.Certificate,
 ) *ResourceManagers {
-	var ref *actor.Ref
-	switch {
-	case config.ResourceManager.AgentRM != nil:
-		ref, _ = system.ActorOf(
-			actor.Addr("agentRM"),
-			newAgentResourceManager(config, cert),
-		)
-
-	case config.ResourceManager.KubernetesRM != nil:
-		ref, _ = system.ActorOf(
-			actor.Addr("kubernetesRM"),
-			newKubernetesResourceManager(config.ResourceManager.KubernetesRM),
-		)
-
-	default:
-		panic("no expected resource manager config is defined")
+	var refs []*actor.Ref
+	for _, rmConfig := range config.ResourceManagers() {
+		var ref *actor.Ref
+		switch {
+		case rmConfig.AgentRM != nil:
+			ref, _ = system.ActorOf(
+				actor.Addr("agentRM"),
+				newAgentResourceManager(config, cert),
+			)
+
+		case rmConfig.KubernetesRM != nil:
+			ref, _ = system.ActorOf(
+				actor.Addr("kubernetesRM"),
+				newKubernetesResourceManager(rmConfig.KubernetesRM),
+			)
+
+		case rmConfig.HPCRM() != nil:
+			ref, _ = system.ActorOf(
+				sproto.HPCRMAddr,
+				newHPCResourceManager(rmConfig.HPCRM(), rmConfig.WorkloadManager()),
+			)
+
+		default:
+			panic("no expected resource manager config is defined")
+		}
+		refs = append(refs, ref)
 	}
 
-	return &ResourceManagers{ref: ref}
+	return newResourceManagers(config, refs)
 }
 
 // Receive implements the actor.Actor interface.
 func (rm *ResourceManagers) Receive(ctx *actor.Context) error {
 	switch msg := ctx.Message().(type) {
+	case actor.PreStart:
+		if rm.budgets != nil {
+			actors.NotifyAfter(ctx, budgetInterval, budgetTick{})
+		}
+
+	case budgetTick:
+		for _, req := range rm.budgets.tick(ctx) {
+			ctx.Tell(rm.route(req.ResourcePool), req)
+		}
+		actors.NotifyAfter(ctx, budgetInterval, budgetTick{})
+
+	case sproto.AllocateRequest:
+		if rm.budgets == nil || rm.budgets.admit(ctx, msg) {
+			rm.forward(ctx, rm.route(msg.ResourcePool), msg)
+		}
+
+	case
+		sproto.GetDefaultGPUResourcePoolRequest,
+		sproto.GetDefaultCPUResourcePoolRequest:
+		rm.forward(ctx, rm.ref, msg)
+
 	case
-		sproto.AllocateRequest, sproto.ResourcesReleased,
-		sproto.SetGroupMaxSlots, sproto.SetGroupWeight,
-		sproto.SetGroupPriority, sproto.GetTaskSummary,
-		sproto.GetTaskSummaries, sproto.SetTaskName:
-		rm.forward(ctx, msg)
+		sproto.ResourcesReleased, sproto.SetGroupMaxSlots,
+		sproto.SetGroupWeight, sproto.SetGroupPriority,
+		sproto.SetTaskName:
+		if msg, ok := msg.(sproto.ResourcesReleased); ok && rm.budgets != nil {
+			rm.budgets.released(msg.TaskActor)
+		}
+		// The router does not track which resource manager a task or group was sent to, and
+		// resource managers ignore the tasks and groups that they do not know about.
+		for _, ref := range rm.refs {
+			ctx.Tell(ref, msg)
+		}
+
+	case sproto.GetTaskSummary:
+		for _, ref := range rm.refs {
+			if resp := ctx.Ask(ref, msg); !resp.Empty() {
+				ctx.Respond(resp.Get())
+				return nil
+			}
+		}
+
+	case UpdateResourcePools:
+		if rm.agentRM == nil {
+			ctx.Log().Warn("ignoring resource pools without an agent resource manager")
+			return nil
+		}
+		for _, pool := range msg.Pools {
+			rm.pools[pool.PoolName] = rm.agentRM
+		}
+		ctx.Tell(rm.agentRM, msg)
+
+	case RemoveResourcePool:
+		if rm.agentRM == nil || rm.pools[msg.PoolName] != rm.agentRM {
+			ctx.Respond(errors.Errorf(
+				"resource pool %s does not belong to the agent resource manager", msg.PoolName))
+			return nil
+		}
+		if err, ok := ctx.Ask(rm.agentRM, msg).Get().(error); ok && err != nil {
+			ctx.Respond(err)
+			return nil
+		}
+		delete(rm.pools, msg.PoolName)
+
+	case sproto.GetTaskSummaries:
+		summaries := make(map[sproto.TaskID]TaskSummary)
+		for _, ref := range rm.refs {
+			rmSummaries, _ := ctx.Ask(ref, msg).Get().(map[sproto.TaskID]TaskSummary)
+			for id, summary := range rmSummaries {
+				summaries[id] = summary
+			}
+		}
+		ctx.Respond(summaries)
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
@@ -63,12 +168,20 @@ func (rm *ResourceManagers) Receive(ctx *actor.Context) error {
 	return nil
 }
 
-func (rm *ResourceManagers) forward(ctx *actor.Context, msg actor.Message) {
+// route returns the resource manager that backs the resource pool.
+func (rm *ResourceManagers) route(pool string) *actor.Ref {
+	if ref, ok := rm.pools[pool]; ok {
+		return ref
+	}
+	return rm.ref
+}
+
+func (rm *ResourceManagers) forward(ctx *actor.Context, ref *actor.Ref, msg actor.Message) {
 	if ctx.ExpectingResponse() {
-		response := ctx.Ask(rm.ref, msg)
+		response := ctx.Ask(ref, msg)
 		ctx.Respond(response.Get())
 	} else {
-		ctx.Tell(rm.ref, msg)
+		ctx.Tell(ref, msg)
 	}
 }
 
@@ -81,6 +194,9 @@ func GetResourceManagerType(rmConfig *ResourceManagerConfig) string {
 	case rmConfig.KubernetesRM != nil:
 		return "kubernetesRM"
 
+	case rmConfig.HPCRM() != nil:
+		return "hpcRM"
+
 	default:
 		panic("no expected resource manager config is defined")
 	}
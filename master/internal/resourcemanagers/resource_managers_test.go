@@ -35,3 +35,73 @@ func TestResourceManagerForwardMessage(t *testing.T) {
 	assert.DeepEqual(t, taskSummary, make(map[sproto.TaskID]TaskSummary))
 	assert.NilError(t, rpActor.StopAndAwaitTermination())
 }
+
+func TestResourceManagerRoutesResourcePools(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	conf := &ResourceConfig{
+		ResourceManager: &ResourceManagerConfig{
+			AgentRM: &AgentResourceManagerConfig{
+				Scheduler: &SchedulerConfig{
+					FairShare:     &FairShareSchedulerConfig{},
+					FittingPolicy: best,
+				},
+			},
+		},
+		ResourcePools: []ResourcePoolConfig{
+			{
+				PoolName:                 defaultResourcePoolName,
+				MaxCPUContainersPerAgent: 100,
+			},
+		},
+		AdditionalResourceManagers: []*ResourceManagerConfig{
+			{KubernetesRM: &KubernetesResourceManagerConfig{}},
+		},
+	}
+
+	rms := NewResourceManagers(system, conf, nil)
+	assert.Equal(t, len(rms.refs), 2)
+	assert.Equal(t, rms.route(""), rms.refs[0])
+	assert.Equal(t, rms.route(defaultResourcePoolName), rms.refs[0])
+	assert.Equal(t, rms.route(sproto.K8sRPName), rms.refs[1])
+
+	rpActor, created := system.ActorOf(actor.Addr("resourceManagers"), rms)
+	assert.Assert(t, created)
+	taskSummary := system.Ask(rpActor, sproto.GetTaskSummaries{}).Get()
+	assert.DeepEqual(t, taskSummary, make(map[sproto.TaskID]TaskSummary))
+	assert.NilError(t, rpActor.StopAndAwaitTermination())
+}
+
+func TestPoolCollector(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	conf := &ResourceConfig{
+		ResourceManager: &ResourceManagerConfig{
+			AgentRM: &AgentResourceManagerConfig{
+				Scheduler: &SchedulerConfig{
+					FairShare:     &FairShareSchedulerConfig{},
+					FittingPolicy: best,
+				},
+			},
+		},
+		ResourcePools: []ResourcePoolConfig{
+			{
+				PoolName:                 defaultResourcePoolName,
+				MaxCPUContainersPerAgent: 100,
+			},
+		},
+	}
+	rms := NewResourceManagers(system, conf, nil)
+	rpActor, created := system.ActorOf(actor.Addr("resourceManagers"), rms)
+	assert.Assert(t, created)
+
+	metrics := poolCollector(system, rpActor, rms.refs)()
+	assert.Equal(t, len(metrics), 4)
+	assert.Equal(t, metrics[0].Name, "det_resource_pool_allocations")
+	assert.Equal(t, len(metrics[0].Samples), 2)
+	for _, metric := range metrics {
+		for _, sample := range metric.Samples {
+			assert.Equal(t, sample.Labels["resource_pool"], defaultResourcePoolName)
+			assert.Equal(t, sample.Value, 0.0)
+		}
+	}
+	assert.NilError(t, rpActor.StopAndAwaitTermination())
+}
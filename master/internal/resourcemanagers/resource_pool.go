@@ -9,12 +9,14 @@ import (
 	"github.com/determined-ai/determined/master/internal/provisioner"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/telemetry"
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/check"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 	image "github.com/determined-ai/determined/master/pkg/tasks"
 )
 
@@ -33,6 +35,10 @@ type ResourcePool struct {
 	groups      map[*actor.Ref]*group
 	scalingInfo *sproto.ScalingInfo
 
+	// spotInterruptions counts the agents that the cloud provider reclaimed, or announced it would
+	// reclaim, while they were in the pool.
+	spotInterruptions int
+
 	reschedule bool
 
 	// Track notifyOnStop for testing purposes.
@@ -40,6 +46,12 @@ type ResourcePool struct {
 	notifications     []<-chan struct{}
 }
 
+// updateResourcePool is a message to apply a reloaded configuration of the resource pool, with
+// its scheduler resolved.
+type updateResourcePool struct {
+	config ResourcePoolConfig
+}
+
 // GetResourceSummary is a message to request a summary of the resources used by the
 // resource pool (agents, slots, cpu containers).
 type GetResourceSummary struct{}
@@ -82,6 +94,28 @@ func (rp *ResourcePool) setupProvisioner(ctx *actor.Context) error {
 	return nil
 }
 
+// updateConfig applies a reloaded configuration of the pool, keeping its tasks and the agents that
+// are connected to it. The provider of the pool cannot change while the master runs.
+func (rp *ResourcePool) updateConfig(ctx *actor.Context, config ResourcePoolConfig) {
+	ctx.Log().Infof("updating resource pool: %s", config.PoolName)
+	config.Provider = rp.config.Provider
+	rp.config = &config
+	rp.scheduler = MakeScheduler(config.Scheduler)
+	rp.fittingMethod = MakeFitFunction(config.Scheduler.FittingPolicy)
+	for _, agent := range rp.agents {
+		agent.setMaxZeroSlotContainers(config.MaxCPUContainersPerAgent)
+		agent.topologyPolicy = config.Scheduler.topologyPolicy()
+	}
+	// Groups that were created under another scheduler have no priority yet.
+	if config.Scheduler.Priority != nil {
+		for _, g := range rp.groups {
+			if g.priority == nil {
+				g.priority = config.Scheduler.Priority.DefaultPriority
+			}
+		}
+	}
+}
+
 func (rp *ResourcePool) addTask(ctx *actor.Context, msg sproto.AllocateRequest) {
 	rp.notifyOnStop(ctx, msg.TaskActor, sproto.ResourcesReleased{TaskActor: msg.TaskActor})
 
@@ -101,6 +135,9 @@ func (rp *ResourcePool) addTask(ctx *actor.Context, msg sproto.AllocateRequest)
 		msg.TaskActor.Address(), msg.ID,
 	)
 	rp.taskList.AddTask(&msg)
+	if msg.Reattach != nil {
+		rp.reattachResources(ctx, &msg)
+	}
 }
 
 func (rp *ResourcePool) receiveSetTaskName(ctx *actor.Context, msg sproto.SetTaskName) {
@@ -112,25 +149,46 @@ func (rp *ResourcePool) receiveSetTaskName(ctx *actor.Context, msg sproto.SetTas
 // allocateResources assigns resources based on a request and notifies the request
 // handler of the assignment. It returns true if it is successfully allocated.
 func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *sproto.AllocateRequest) bool {
+	// The span is only exported if the task fits, so that traces show the attempt that placed it
+	// rather than every scheduling pass that it waited through.
+	span := tracing.StartFrom(req.SpanContext, "resource_pool.allocate")
 	fits := findFits(req, rp.agents, rp.fittingMethod)
 
 	if len(fits) == 0 {
 		return false
 	}
+	defer span.End()
+	span.SetAttribute("resource_pool", rp.config.PoolName)
+	span.SetAttribute("slots", req.SlotsNeeded)
+	span.SetAttribute("containers", len(fits))
+
+	var network *cproto.TaskNetwork
+	if len(fits) > 1 {
+		network = cproto.NewTaskNetwork(string(req.ID), len(fits))
+	}
 
 	allocations := make([]sproto.Allocation, 0, len(fits))
-	for _, fit := range fits {
+	for rank, fit := range fits {
 		container := newContainer(req, fit.Agent, fit.Slots)
-		allocations = append(allocations, &containerAllocation{
+		allocation := &containerAllocation{
 			req:       req,
 			agent:     fit.Agent,
 			container: container,
-			devices:   fit.Agent.allocateFreeDevices(fit.Slots, container.id),
-		})
+			devices:   fit.Agent.allocateFreeDevices(fit.Slots, container.id, req.GPUType),
+		}
+		if network != nil {
+			allocation.network = network
+			allocation.peer = network.Peers[rank]
+		}
+		allocations = append(allocations, allocation)
 	}
 
 	allocated := sproto.ResourcesAllocated{
-		ID: req.ID, ResourcePool: rp.config.PoolName, Allocations: allocations,
+		ID:           req.ID,
+		ResourcePool: rp.config.PoolName,
+		Allocations:  allocations,
+		Slots:        req.SlotsNeeded,
+		Network:      network,
 	}
 	rp.taskList.SetAllocations(req.TaskActor, &allocated)
 	req.TaskActor.System().Tell(req.TaskActor, allocated)
@@ -139,11 +197,55 @@ func (rp *ResourcePool) allocateResources(ctx *actor.Context, req *sproto.Alloca
 	return true
 }
 
+// reattachResources allocates the container that a task reattaches to, which its agent found
+// running when it reconnected, along with the devices it holds.
+func (rp *ResourcePool) reattachResources(ctx *actor.Context, req *sproto.AllocateRequest) {
+	agent, ok := rp.agents[req.Reattach.Agent]
+	if !ok {
+		ctx.Log().Warnf("cannot reattach %s to container %s on unknown agent %s, scheduling it",
+			req.TaskActor.Address(), req.Reattach.Container.ID, req.Reattach.Agent.Address())
+		return
+	}
+	devices := req.Reattach.Container.Devices
+	c := &container{req: req, id: req.Reattach.Container.ID, slots: len(devices), agent: agent}
+	agent.claimDevices(devices, c.id)
+
+	allocated := sproto.ResourcesAllocated{
+		ID:           req.ID,
+		ResourcePool: rp.config.PoolName,
+		Allocations: []sproto.Allocation{
+			&containerAllocation{req: req, agent: agent, container: c, devices: devices},
+		},
+		Slots: len(devices),
+	}
+	rp.taskList.SetAllocations(req.TaskActor, &allocated)
+	req.TaskActor.System().Tell(req.TaskActor, allocated)
+	ctx.Log().Infof("reattached %s to container %s", req.TaskActor.Address(), c.id)
+}
+
 func (rp *ResourcePool) releaseResource(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("releasing resources taken by %s", handler.Address())
 	handler.System().Tell(handler, sproto.ReleaseResources{ResourcePool: rp.config.PoolName})
 }
 
+// releaseTasksOnAgent asks every task with a container on the agent to release its resources, so
+// that it can checkpoint and be rescheduled elsewhere.
+func (rp *ResourcePool) releaseTasksOnAgent(ctx *actor.Context, agent *agentState) {
+	for it := rp.taskList.iterator(); it.next(); {
+		req := it.value()
+		allocated := rp.taskList.GetAllocations(req.TaskActor)
+		if allocated == nil {
+			continue
+		}
+		for _, allocation := range allocated.Allocations {
+			if allocation.(*containerAllocation).agent == agent {
+				rp.releaseResource(ctx, req.TaskActor)
+				break
+			}
+		}
+	}
+}
+
 func (rp *ResourcePool) resourcesReleased(ctx *actor.Context, handler *actor.Ref) {
 	ctx.Log().Infof("resources are released for %s", handler.Address())
 	if allocated := rp.taskList.GetAllocations(handler); allocated != nil {
@@ -226,7 +328,10 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		sproto.AddAgent,
 		sproto.AddDevice,
 		sproto.RemoveDevice,
-		sproto.RemoveAgent:
+		sproto.RemoveAgent,
+		sproto.UpdateAgentHealth,
+		sproto.UpdateDeviceHealth,
+		sproto.AgentInterrupted:
 		return rp.receiveAgentMsg(ctx)
 
 	case
@@ -250,12 +355,18 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 		reschedule = false
 		ctx.Respond(getTaskSummaries(rp.taskList, rp.groups, rp.config.Scheduler.GetType()))
 
+	case updateResourcePool:
+		rp.updateConfig(ctx, msg.config)
+
 	case GetResourceSummary:
 		reschedule = false
-		ctx.Respond(getResourceSummary(rp.agents))
+		summary := getResourceSummary(rp.agents)
+		summary.numSpotInterruptions = rp.spotInterruptions
+		ctx.Respond(summary)
 
 	case schedulerTick:
 		if rp.reschedule {
+			rp.shrinkElasticTasks()
 			toAllocate, toRelease := rp.scheduler.Schedule(rp)
 			for _, req := range toAllocate {
 				rp.allocateResources(ctx, req)
@@ -263,6 +374,7 @@ func (rp *ResourcePool) Receive(ctx *actor.Context) error {
 			for _, taskActor := range toRelease {
 				rp.releaseResource(ctx, taskActor)
 			}
+			rp.growElasticTasks(ctx)
 			rp.sendScalingInfo(ctx)
 		}
 		rp.reschedule = false
@@ -281,12 +393,16 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 	case sproto.AddAgent:
 		ctx.Log().Infof("adding agent: %s", msg.Agent.Address().Local())
 		rp.agents[msg.Agent] = newAgentState(msg, rp.config.MaxCPUContainersPerAgent)
+		rp.agents[msg.Agent].topologyPolicy = rp.config.Scheduler.topologyPolicy()
 
 	case sproto.AddDevice:
 		ctx.Log().Infof("adding device: %s on %s", msg.Device.String(), msg.Agent.Address().Local())
 		state, ok := rp.agents[msg.Agent]
 		check.Panic(check.True(ok, "error adding device, agent not found: %s", msg.Agent.Address()))
-		state.devices[msg.Device] = msg.ContainerID
+		// A device that a reattached container claimed before its slot reported it stays claimed.
+		if msg.ContainerID != nil || state.devices[msg.Device] == nil {
+			state.devices[msg.Device] = msg.ContainerID
+		}
 
 	case sproto.RemoveDevice:
 		ctx.Log().Infof("removing device: %s (%s)", msg.Device.String(), msg.Agent.Address().Local())
@@ -298,6 +414,44 @@ func (rp *ResourcePool) receiveAgentMsg(ctx *actor.Context) error {
 		ctx.Log().Infof("removing agent: %s", msg.Agent.Address().Local())
 		delete(rp.agents, msg.Agent)
 
+	case sproto.UpdateAgentHealth:
+		state, ok := rp.agents[msg.Agent]
+		if !ok {
+			ctx.Log().Warnf("ignoring health update for unknown agent: %s", msg.Agent.Address())
+			return nil
+		}
+		ctx.Log().Infof("setting agent %s healthy: %t", msg.Agent.Address().Local(), msg.Healthy)
+		state.healthy = msg.Healthy
+
+	case sproto.UpdateDeviceHealth:
+		state, ok := rp.agents[msg.Agent]
+		if !ok {
+			ctx.Log().Warnf("ignoring device health update for unknown agent: %s", msg.Agent.Address())
+			return nil
+		}
+		ctx.Log().Infof("setting device %s on %s healthy: %t",
+			msg.Device.String(), msg.Agent.Address().Local(), msg.Healthy)
+		if msg.Healthy {
+			delete(state.unhealthyDevices, msg.Device)
+		} else {
+			state.unhealthyDevices[msg.Device] = true
+		}
+
+	case sproto.AgentInterrupted:
+		state, ok := rp.agents[msg.Agent]
+		if !ok {
+			ctx.Log().Warnf("ignoring interruption of unknown agent: %s", msg.Agent.Address())
+			return nil
+		}
+		if state.interrupted {
+			return nil
+		}
+		ctx.Log().Warnf("agent %s will be interrupted at %s, releasing its tasks",
+			msg.Agent.Address().Local(), msg.Deadline)
+		state.interrupted = true
+		rp.spotInterruptions++
+		rp.releaseTasksOnAgent(ctx, state)
+
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
 	}
@@ -347,6 +501,11 @@ type containerAllocation struct {
 	container *container
 	agent     *agentState
 	devices   []device.Device
+
+	// network is the network private to a multi-container task, on which the container is known
+	// as peer.
+	network *cproto.TaskNetwork
+	peer    string
 }
 
 // Summary summarizes a container allocation.
@@ -364,8 +523,12 @@ func (c containerAllocation) Start(ctx *actor.Context, spec image.TaskSpec) {
 	spec.ContainerID = string(c.container.id)
 	spec.TaskID = string(c.req.ID)
 	spec.Devices = c.devices
+	spec.TaskNetwork = c.network
+	spec.TaskNetworkPeer = c.peer
 	ctx.Tell(handler, sproto.StartTaskContainer{
 		TaskActor: c.req.TaskActor,
+		TaskID:    c.req.ID,
+		TaskName:  c.req.Name,
 		StartContainer: aproto.StartContainer{
 			Container: cproto.Container{
 				Parent:  c.req.TaskActor.Address(),
@@ -373,15 +536,17 @@ func (c containerAllocation) Start(ctx *actor.Context, spec image.TaskSpec) {
 				State:   cproto.Assigned,
 				Devices: c.devices,
 			},
-			Spec: image.ToContainerSpec(spec),
+			Spec:   image.ToContainerSpec(spec),
+			TaskID: string(c.req.ID),
 		},
 	})
 }
 
 // KillContainer notifies the agent to kill the container.
-func (c containerAllocation) Kill(ctx *actor.Context) {
+func (c containerAllocation) Kill(ctx *actor.Context, stop model.StopPolicy) {
 	ctx.Tell(c.agent.handler, sproto.KillTaskContainer{
 		ContainerID: c.container.id,
+		Stop:        stop,
 	})
 }
 
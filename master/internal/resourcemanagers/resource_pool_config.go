@@ -23,6 +23,9 @@ type ResourcePoolConfig struct {
 	Scheduler                *SchedulerConfig                   `json:"scheduler,omitempty"`
 	MaxCPUContainersPerAgent int                                `json:"max_cpu_containers_per_agent"`
 	TaskContainerDefaults    *model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
+	Pricing                  *PricingConfig                     `json:"pricing,omitempty"`
+	// AgentLabel claims the agents with the label for the pool, whichever pool they ask to join.
+	AgentLabel string `json:"agent_label,omitempty"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -38,5 +41,41 @@ func (r ResourcePoolConfig) Validate() []error {
 		check.True(len(r.PoolName) != 0, "resource pool name cannot be empty"),
 		check.True(r.MaxCPUContainersPerAgent >= 0,
 			"resource pool max cpu containers per agent should be >= 0"),
+		check.True(r.Pricing == nil || r.Pricing.InstanceHour == nil ||
+			(r.Provider != nil && r.Provider.SlotsPerInstance() > 0),
+			"resource pool pricing per instance hour requires a provider with GPU instances"),
+	}
+}
+
+// SlotHourPrice returns the price of a slot-hour in the pool, and false if the pool has no price.
+func (r ResourcePoolConfig) SlotHourPrice() (float64, bool) {
+	switch {
+	case r.Pricing == nil:
+		return 0, false
+	case r.Pricing.SlotHour != nil:
+		return *r.Pricing.SlotHour, true
+	case r.Pricing.InstanceHour != nil && r.Provider != nil && r.Provider.SlotsPerInstance() > 0:
+		return *r.Pricing.InstanceHour / float64(r.Provider.SlotsPerInstance()), true
+	default:
+		return 0, false
+	}
+}
+
+// PricingConfig is the price of the resources of a pool, from which the cost of the tasks that run
+// in it is estimated. It is either the price of a slot-hour, or of an hour of the instances that
+// the provider of the pool launches.
+type PricingConfig struct {
+	SlotHour     *float64 `json:"slot_hour"`
+	InstanceHour *float64 `json:"instance_hour"`
+}
+
+// Validate implements the check.Validatable interface.
+func (p PricingConfig) Validate() []error {
+	return []error{
+		check.True((p.SlotHour == nil) != (p.InstanceHour == nil),
+			"pricing must specify exactly one of slot_hour and instance_hour"),
+		check.True(p.SlotHour == nil || *p.SlotHour >= 0, "pricing slot_hour must be >= 0"),
+		check.True(p.InstanceHour == nil || *p.InstanceHour >= 0,
+			"pricing instance_hour must be >= 0"),
 	}
 }
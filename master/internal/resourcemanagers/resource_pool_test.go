@@ -10,6 +10,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
 )
 
 func TestCleanUpTaskWhenTaskActorStopsWithError(t *testing.T) {
@@ -94,6 +95,39 @@ func TestCleanUpTaskWhenTaskActorReleaseResources(t *testing.T) {
 	assert.Equal(t, rp.taskList.len(), 0)
 }
 
+func TestReattachResources(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 2}}
+	rp, ref := setupResourcePool(t, system, nil, nil, nil, agents)
+
+	agentRef := system.Get(actor.Addr("agent"))
+	taskRef, created := system.ActorOf(actor.Addr("task"), &mockTask{id: "task", slotsNeeded: 1})
+	assert.Assert(t, created)
+	reattached := device.Device{ID: 1}
+	system.Tell(ref, sproto.AllocateRequest{
+		ID:          "task",
+		TaskActor:   taskRef,
+		SlotsNeeded: 1,
+		Reattach: &sproto.ReattachContainer{
+			Agent:     agentRef,
+			Container: cproto.Container{ID: "container", Devices: []device.Device{reattached}},
+		},
+	})
+	// The slot of the device reports it only after the resource pool allocated it.
+	system.Tell(ref, sproto.AddDevice{DeviceID: sproto.DeviceID{Agent: agentRef, Device: reattached}})
+	system.Ask(ref, actor.Ping{}).Get()
+
+	allocated := rp.taskList.GetAllocations(taskRef)
+	assert.Assert(t, allocated != nil)
+	assert.Equal(t, allocated.Allocations[0].Summary().ID, cproto.ID("container"))
+	assert.Equal(t, *rp.agents[agentRef].devices[reattached], cproto.ID("container"))
+	assert.Equal(t, rp.agents[agentRef].numUsedSlots(), 1)
+
+	system.Tell(ref, sproto.ResourcesReleased{TaskActor: taskRef})
+	system.Ask(ref, actor.Ping{}).Get()
+	assert.Equal(t, rp.agents[agentRef].numUsedSlots(), 0)
+}
+
 func TestScalingInfoAgentSummary(t *testing.T) {
 	system := actor.NewSystem(t.Name())
 	agents := []*mockAgent{
@@ -205,3 +239,109 @@ func TestSettingGroupPriority(t *testing.T) {
 	assert.Equal(t, *rp.groups[groupRefOne].priority, updatedPriority)
 	assert.Equal(t, *rp.groups[groupRefTwo].priority, defaultPriority)
 }
+
+func TestReleaseTasksOnInterruptedAgent(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{
+		{id: "agent1", slots: 1},
+		{id: "agent2", slots: 1},
+	}
+	tasks := []*mockTask{
+		{id: "task1", slotsNeeded: 1, allocatedAgent: agents[0], containerStarted: true},
+		{id: "task2", slotsNeeded: 1, allocatedAgent: agents[1], containerStarted: true},
+	}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+
+	agent1 := system.Get(actor.Addr("agent1"))
+	system.Ask(ref, sproto.AgentInterrupted{Agent: agent1}).Get()
+	// Repeated notices for the same agent are only counted once.
+	system.Ask(ref, sproto.AgentInterrupted{Agent: agent1}).Get()
+
+	// Wait for task1 to hand its resources back to the pool.
+	system.Ask(system.Get(actor.Addr("task1")), actor.Ping{}).Get()
+	system.Ask(ref, actor.Ping{}).Get()
+
+	summary := system.Ask(ref, GetResourceSummary{}).Get().(ResourceSummary)
+	assert.Equal(t, summary.numSpotInterruptions, 1)
+
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	assert.Assert(t, rp.agents[agent1].interrupted)
+	assert.Assert(t, !agentHealthySatisfied(nil, rp.agents[agent1]))
+	_, ok := rp.taskList.GetTaskByHandler(system.Get(actor.Addr("task1")))
+	assert.Assert(t, !ok)
+	_, ok = rp.taskList.GetTaskByHandler(system.Get(actor.Addr("task2")))
+	assert.Assert(t, ok)
+}
+
+func TestTaskNetworkForMultiAgentAllocation(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{
+		{id: "agent1", slots: 2},
+		{id: "agent2", slots: 2},
+		{id: "agent3", slots: 2},
+	}
+	tasks := []*mockTask{{id: "distributed", slotsNeeded: 4}}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+
+	rp.reschedule = true
+	system.Ask(ref, schedulerTick{}).Get()
+	assert.NilError(t, ref.StopAndAwaitTermination())
+
+	distributed := rp.taskList.GetAllocations(system.Get(actor.Addr("distributed")))
+	assert.Assert(t, distributed != nil)
+	assert.Equal(t, len(distributed.Allocations), 2)
+	assert.Assert(t, distributed.Network != nil)
+	assert.DeepEqual(t, distributed.Network.Peers, []string{"rank-0", "rank-1"})
+	for rank, allocation := range distributed.Allocations {
+		typed := allocation.(*containerAllocation)
+		assert.Equal(t, typed.network, distributed.Network)
+		assert.Equal(t, typed.peer, distributed.Network.Peers[rank])
+	}
+}
+
+func TestNoTaskNetworkForSingleAgentAllocation(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 2}}
+	tasks := []*mockTask{{id: "single", slotsNeeded: 2}}
+	rp, ref := setupResourcePool(t, system, nil, tasks, nil, agents)
+
+	rp.reschedule = true
+	system.Ask(ref, schedulerTick{}).Get()
+	assert.NilError(t, ref.StopAndAwaitTermination())
+
+	single := rp.taskList.GetAllocations(system.Get(actor.Addr("single")))
+	assert.Assert(t, single != nil)
+	assert.Assert(t, single.Network == nil)
+	assert.Equal(t, single.Allocations[0].(*containerAllocation).peer, "")
+}
+
+func TestUpdateResourcePoolConfig(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	agents := []*mockAgent{{id: "agent", slots: 1, maxZeroSlotContainers: 1}}
+	rp, ref := setupResourcePool(t, system, nil, nil, nil, agents)
+
+	group, created := system.ActorOf(actor.Addr("group"), &mockGroup{})
+	assert.Assert(t, created)
+	system.Tell(ref, sproto.SetGroupWeight{Weight: 2, Handler: group})
+
+	// Groups get the default priority once the pool switches to the priority scheduler.
+	defaultPriority := 50
+	config := ResourcePoolConfig{
+		PoolName: "pool",
+		Scheduler: &SchedulerConfig{
+			Priority:      &PrioritySchedulerConfig{DefaultPriority: &defaultPriority},
+			FittingPolicy: worst,
+		},
+		MaxCPUContainersPerAgent: 10,
+	}
+	system.Tell(ref, updateResourcePool{config: config})
+	system.Ask(ref, actor.Ping{}).Get()
+
+	assert.NilError(t, ref.StopAndAwaitTermination())
+	assert.Equal(t, rp.config.Scheduler.GetType(), priorityScheduling)
+	assert.Equal(t, *rp.groups[group].priority, defaultPriority)
+	assert.Equal(t, rp.groups[group].weight, 2.0)
+	for _, agent := range rp.agents {
+		assert.Equal(t, agent.maxZeroSlotContainers, 10)
+	}
+}
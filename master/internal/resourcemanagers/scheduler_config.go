@@ -18,6 +18,10 @@ const (
 	best             = "best"
 	worst            = "worst"
 	defaultFitPolicy = best
+
+	topologyOff  = "off"
+	topologySoft = "soft"
+	topologyHard = "hard"
 )
 
 // DefaultSchedulerConfig returns the default fair share configuration for the scheduler.
@@ -34,6 +38,7 @@ type SchedulerConfig struct {
 	Priority      *PrioritySchedulerConfig   `union:"type,priority" json:"-"`
 	RoundRobin    *RoundRobinSchedulerConfig `union:"type,round_robin" json:"-"`
 	FittingPolicy string                     `json:"fitting_policy"`
+	Topology      *TopologyConfig            `json:"topology,omitempty"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -76,6 +81,14 @@ func (s SchedulerConfig) Validate() []error {
 	}
 }
 
+// topologyPolicy returns the configured topology policy, or the default one if there is none.
+func (s *SchedulerConfig) topologyPolicy() TopologyConfig {
+	if s == nil || s.Topology == nil {
+		return defaultTopologyConfig()
+	}
+	return *s.Topology
+}
+
 // GetType returns the type of scheduler that is configured.
 func (s *SchedulerConfig) GetType() string {
 	switch {
@@ -99,6 +112,38 @@ type PrioritySchedulerConfig struct {
 	DefaultPriority *int `json:"default_priority"`
 }
 
+// TopologyConfig holds how the scheduler takes the way GPUs are interconnected into account when
+// placing multi-slot tasks. Each constraint is either "off", "soft" to prefer placements that
+// satisfy it, or "hard" to only allow placements that satisfy it.
+type TopologyConfig struct {
+	// NVLink constrains the GPUs a task gets on an agent to be connected to each other over
+	// NVLink.
+	NVLink string `json:"nvlink"`
+	// RailAlignment constrains the agents of a multi-agent task to have their network interfaces
+	// wired to the same GPUs, so that each GPU reaches its peers on other agents over its own rail.
+	RailAlignment string `json:"rail_alignment"`
+}
+
+func defaultTopologyConfig() TopologyConfig {
+	return TopologyConfig{NVLink: topologySoft, RailAlignment: topologySoft}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *TopologyConfig) UnmarshalJSON(data []byte) error {
+	*t = defaultTopologyConfig()
+	type DefaultParser *TopologyConfig
+	return json.Unmarshal(data, DefaultParser(t))
+}
+
+// Validate implements the check.Validatable interface.
+func (t TopologyConfig) Validate() []error {
+	policies := []interface{}{topologyOff, topologySoft, topologyHard}
+	return []error{
+		check.Contains(t.NVLink, policies, "invalid nvlink topology policy"),
+		check.Contains(t.RailAlignment, policies, "invalid rail_alignment topology policy"),
+	}
+}
+
 // RoundRobinSchedulerConfig holds the configurations for the round robing scheduler.
 type RoundRobinSchedulerConfig struct{}
 
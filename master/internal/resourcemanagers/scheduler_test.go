@@ -56,6 +56,8 @@ type mockTask struct {
 	resourcePool     string
 	allocatedAgent   *mockAgent
 	containerStarted bool
+	minSlotsNeeded   int
+	maxSlotsNeeded   int
 }
 
 func (t *mockTask) Receive(ctx *actor.Context) error {
@@ -71,6 +73,8 @@ func (t *mockTask) Receive(ctx *actor.Context) error {
 			Label:          t.label,
 			ResourcePool:   t.resourcePool,
 			TaskActor:      ctx.Self(),
+			MinSlotsNeeded: t.minSlotsNeeded,
+			MaxSlotsNeeded: t.maxSlotsNeeded,
 		}
 		if t.group == nil {
 			task.Group = ctx.Self()
@@ -235,13 +239,13 @@ func newFakeAgentState(
 			NonPreemptible: false,
 		}
 		container := newContainer(req, state, req.SlotsNeeded)
-		state.allocateFreeDevices(req.SlotsNeeded, container.id)
+		state.allocateFreeDevices(req.SlotsNeeded, container.id, "")
 	}
 
 	for i := 0; i < zeroSlotContainers; i++ {
 		req := &sproto.AllocateRequest{}
 		container := newContainer(req, state, req.SlotsNeeded)
-		state.allocateFreeDevices(req.SlotsNeeded, container.id)
+		state.allocateFreeDevices(req.SlotsNeeded, container.id, "")
 	}
 	return state
 }
@@ -309,6 +313,7 @@ func setupSchedulerStates(
 		agent := &agentState{
 			handler:               ref,
 			label:                 mockAgent.label,
+			healthy:               true,
 			devices:               make(map[device.Device]*cproto.ID),
 			zeroSlotContainers:    make(map[cproto.ID]bool),
 			maxZeroSlotContainers: mockAgent.maxZeroSlotContainers,
@@ -348,6 +353,8 @@ func setupSchedulerStates(
 			Label:          mockTask.label,
 			TaskActor:      ref,
 			NonPreemptible: mockTask.nonPreemptible,
+			MinSlotsNeeded: mockTask.minSlotsNeeded,
+			MaxSlotsNeeded: mockTask.maxSlotsNeeded,
 		}
 		if mockTask.group == nil {
 			req.Group = ref
@@ -10,7 +10,11 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/agent"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/hpc"
 	"github.com/determined-ai/determined/master/internal/kubernetes"
+	"github.com/determined-ai/determined/master/internal/prom"
+	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -48,34 +52,59 @@ func makeTLSConfig(cert *tls.Certificate) (model.TLSClientConfig, error) {
 	}, nil
 }
 
-// Setup sets up the actor and endpoints for resource managers.
+// Setup sets up the actor and endpoints for resource managers. Agents must connect with the
+// certificates issued to them if requireAgentCerts is set.
 func Setup(
 	system *actor.System,
 	echo *echo.Echo,
 	config *ResourceConfig,
 	opts *aproto.MasterSetAgentOptions,
 	cert *tls.Certificate,
+	requireAgentCerts bool,
+	pgDB *db.PgDB,
 ) *actor.Ref {
-	var ref *actor.Ref
-	switch {
-	case config.ResourceManager.AgentRM != nil:
-		ref = setupAgentResourceManager(system, echo, config, opts, cert)
-	case config.ResourceManager.KubernetesRM != nil:
-		tlsConfig, err := makeTLSConfig(cert)
-		if err != nil {
-			panic(errors.Wrap(err, "failed to set up TLS config"))
+	var refs []*actor.Ref
+	for _, rmConfig := range config.ResourceManagers() {
+		var ref *actor.Ref
+		switch {
+		case rmConfig.AgentRM != nil:
+			ref = setupAgentResourceManager(
+				system, echo, config, opts, cert, requireAgentCerts, pgDB,
+			)
+		case rmConfig.KubernetesRM != nil:
+			tlsConfig, err := makeTLSConfig(cert)
+			if err != nil {
+				panic(errors.Wrap(err, "failed to set up TLS config"))
+			}
+			// The agents serve the agent endpoint when both kinds of resource managers run.
+			podsEcho := echo
+			if config.AgentResourceManager() != nil {
+				podsEcho = nil
+			}
+			ref = setupKubernetesResourceManager(
+				system, podsEcho, rmConfig.KubernetesRM, tlsConfig, opts.LoggingOptions,
+			)
+		case rmConfig.HPCRM() != nil:
+			tlsConfig, err := makeTLSConfig(cert)
+			if err != nil {
+				panic(errors.Wrap(err, "failed to set up TLS config"))
+			}
+			ref = setupHPCResourceManager(system, rmConfig, tlsConfig)
+		default:
+			panic("no expected resource manager config is defined")
 		}
-		ref = setupKubernetesResourceManager(
-			system, echo, config.ResourceManager.KubernetesRM, tlsConfig, opts.LoggingOptions,
-		)
-	default:
-		panic("no expected resource manager config is defined")
+		refs = append(refs, ref)
 	}
 
-	rm, ok := system.ActorOf(actor.Addr("resourceManagers"), &ResourceManagers{ref: ref})
+	rms := newResourceManagers(config, refs)
+	if pgDB != nil {
+		rms.budgets = newBudgetEnforcer(pgDB, config.SlotHourPrices())
+	}
+	rm, ok := system.ActorOf(sproto.ResourceManagerAddr, rms)
 	if !ok {
 		panic("cannot create resource managers")
 	}
+	prom.Register("resource_pools", poolCollector(system, rm, refs))
 	return rm
 }
 
@@ -85,6 +114,8 @@ func setupAgentResourceManager(
 	config *ResourceConfig,
 	opts *aproto.MasterSetAgentOptions,
 	cert *tls.Certificate,
+	requireCerts bool,
+	pgDB *db.PgDB,
 ) *actor.Ref {
 	ref, _ := system.ActorOf(
 		actor.Addr("agentRM"),
@@ -92,7 +123,21 @@ func setupAgentResourceManager(
 	)
 	system.Ask(ref, actor.Ping{}).Get()
 
-	agent.Initialize(system, echo, opts)
+	agentRM := config.AgentResourceManager()
+	health := agent.DefaultHealthConfig()
+	if agentRM.AgentHealth != nil {
+		health = *agentRM.AgentHealth
+	}
+	profiling := agent.DefaultProfilingConfig()
+	if agentRM.Profiling != nil {
+		profiling = *agentRM.Profiling
+	}
+	agentOpts := *opts
+	agentOpts.TaskHooks = agentRM.TaskHooks
+	agent.Initialize(
+		system, echo, &agentOpts, health, profiling, agentRM.AgentRegistration, agentRM.AgentVersionSkew,
+		requireCerts, pgDB, agentLabelPools(config.ResourcePools),
+	)
 	return ref
 }
 
@@ -110,8 +155,33 @@ func setupKubernetesResourceManager(
 	system.Ask(ref, actor.Ping{}).Get()
 
 	kubernetes.Initialize(
-		system, echo, ref, config.Namespace, config.MasterServiceName, masterTLSConfig, loggingConfig,
-		config.LeaveKubernetesResources, config.DefaultScheduler,
+		system, echo, ref, config.Namespace, config.Namespaces(), config.MasterServiceName,
+		masterTLSConfig, loggingConfig, config.LeaveKubernetesResources, config.DefaultScheduler,
+		config.PriorityClasses,
+	)
+	return ref
+}
+
+func setupHPCResourceManager(
+	system *actor.System,
+	config *ResourceManagerConfig,
+	masterTLSConfig model.TLSClientConfig,
+) *actor.Ref {
+	hpcConfig := config.HPCRM()
+	ref, _ := system.ActorOf(
+		sproto.HPCRMAddr,
+		newHPCResourceManager(hpcConfig, config.WorkloadManager()),
 	)
+	system.Ask(ref, actor.Ping{}).Get()
+
+	hpc.Initialize(system, ref, hpc.Config{
+		WorkloadManager:  config.WorkloadManager(),
+		JobStorageRoot:   hpcConfig.JobStorageRoot,
+		Partition:        hpcConfig.Partition,
+		SubmitArgs:       hpcConfig.SubmitArgs,
+		ContainerRuntime: hpcConfig.ContainerRuntime,
+		MasterHost:       hpcConfig.MasterHost,
+		MasterPort:       hpcConfig.MasterPort,
+	}, masterTLSConfig)
 	return ref
 }
@@ -90,6 +90,7 @@ type ResourceSummary struct {
 	numActiveSlots         int
 	maxNumCPUContainers    int
 	numActiveCPUContainers int
+	numSpotInterruptions   int
 }
 
 func getResourceSummary(
@@ -0,0 +1,130 @@
+package resourcemanagers
+
+import (
+	"sort"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+func topologyEnabled(policy string) bool {
+	return policy == topologySoft || policy == topologyHard
+}
+
+// nvlinkMatch returns true if the agent can give the task GPUs that are all connected to each
+// other over NVLink. Tasks that get at most one GPU of the agent, and agents without GPUs, match
+// trivially.
+func nvlinkMatch(req *sproto.AllocateRequest, agent *agentState) bool {
+	// Multi-agent tasks get every free slot of each of their agents.
+	slots := req.SlotsNeeded
+	if empty := agent.numEmptySlotsOfType(req.GPUType); empty < slots {
+		slots = empty
+	}
+	if slots < 2 || !agent.hasGPUs() {
+		return true
+	}
+	return agent.nvlinkedFreeDevices(slots, req.GPUType) != nil
+}
+
+// nvlinkSatisfied is the hard constraint form of nvlinkMatch; it only applies if the pool
+// requires NVLink-connected GPUs.
+func nvlinkSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
+	return agent.topologyPolicy.NVLink != topologyHard || nvlinkMatch(req, agent)
+}
+
+func (a *agentState) hasGPUs() bool {
+	for d := range a.devices {
+		if d.Type == device.GPU {
+			return true
+		}
+	}
+	return false
+}
+
+// nvlinkedFreeDevices returns the given number of free, healthy GPUs of the agent, of the model if
+// one is given, that are all connected to each other over NVLink, or nil if there are not that
+// many.
+func (a *agentState) nvlinkedFreeDevices(slots int, gpuType string) []device.Device {
+	if a.topology == nil || slots < 2 {
+		return nil
+	}
+
+	var free []device.Device
+	for d := range a.devices {
+		if a.freeDevice(d, gpuType) && d.Type == device.GPU {
+			free = append(free, d)
+		}
+	}
+	sort.Slice(free, func(i, j int) bool { return free[i].ID < free[j].ID })
+
+	// Agents have a handful of GPUs, so a backtracking search for a fully connected set is cheap.
+	var set []device.Device
+	var extend func(start int) bool
+	extend = func(start int) bool {
+		if len(set) == slots {
+			return true
+		}
+		for i := start; i <= len(free)-(slots-len(set)); i++ {
+			linked := true
+			for _, d := range set {
+				if !a.topology.NVLinked(d.ID, free[i].ID) {
+					linked = false
+					break
+				}
+			}
+			if !linked {
+				continue
+			}
+			set = append(set, free[i])
+			if extend(i + 1) {
+				return true
+			}
+			set = set[:len(set)-1]
+		}
+		return false
+	}
+	if !extend(0) {
+		return nil
+	}
+	return set
+}
+
+// rails returns how the agent's network interfaces are wired to its GPUs, or the empty string if
+// the agent did not report it or some of its GPUs have no network interface of their own.
+func (a *agentState) rails() string {
+	var gpus []int
+	for d := range a.devices {
+		if d.Type == device.GPU {
+			gpus = append(gpus, d.ID)
+		}
+	}
+	return a.topology.Rails(gpus)
+}
+
+// railAlignedFits picks the agents for a multi-agent task from the sorted candidates. If the pool
+// takes rail alignment into account, the best candidates that share the same rails are picked,
+// so that each GPU of the task reaches its peers on the other agents over its own rail.
+func railAlignedFits(candidates candidateList, numContainers int) candidateList {
+	if len(candidates) < numContainers {
+		return nil
+	}
+
+	policy := candidates[0].Agent.topologyPolicy.RailAlignment
+	if topologyEnabled(policy) {
+		byRails := make(map[string]candidateList)
+		for _, c := range candidates {
+			rails := c.Agent.rails()
+			if rails == "" {
+				continue
+			}
+			byRails[rails] = append(byRails[rails], c)
+			if len(byRails[rails]) == numContainers {
+				return byRails[rails]
+			}
+		}
+		if policy == topologyHard {
+			return nil
+		}
+	}
+	return candidates[:numContainers]
+}
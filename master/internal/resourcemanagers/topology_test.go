@@ -0,0 +1,103 @@
+package resourcemanagers
+
+import (
+	"sort"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/device"
+)
+
+func newTopologyAgentState(
+	t *testing.T,
+	system *actor.System,
+	id string,
+	gpus int,
+	topology *device.Topology,
+	policy TopologyConfig,
+) *agentState {
+	ref, created := system.ActorOf(actor.Addr(id), &mockAgent{id: id, slots: gpus})
+	assert.Assert(t, created)
+	state := newAgentState(sproto.AddAgent{Agent: ref, Topology: topology}, 100)
+	state.topologyPolicy = policy
+	for i := 0; i < gpus; i++ {
+		state.devices[device.Device{ID: i, Type: device.GPU}] = nil
+	}
+	return state
+}
+
+func TestNVLinkTopology(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	pairs := &device.Topology{NVLinks: map[int][]int{0: {1}, 1: {0}, 2: {3}, 3: {2}}}
+
+	agent := newTopologyAgentState(t, system, "agent", 4, pairs, TopologyConfig{NVLink: topologyHard})
+	agents := map[*actor.Ref]*agentState{agent.handler: agent}
+	assert.Equal(t, len(findFits(&sproto.AllocateRequest{SlotsNeeded: 2}, agents, BestFit)), 1)
+	assert.Equal(t, len(findFits(&sproto.AllocateRequest{SlotsNeeded: 3}, agents, BestFit)), 0)
+
+	id := cproto.NewID()
+	var ids []int
+	for _, d := range agent.allocateFreeDevices(2, id, "") {
+		ids = append(ids, d.ID)
+	}
+	sort.Ints(ids)
+	assert.Assert(t, pairs.NVLinked(ids[0], ids[1]), "devices %v are not connected", ids)
+
+	agent.topologyPolicy.NVLink = topologyOff
+	agent.deallocateContainer(id)
+	assert.Equal(t, len(findFits(&sproto.AllocateRequest{SlotsNeeded: 3}, agents, BestFit)), 1)
+}
+
+func TestNVLinkTopologyPreferred(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	policy := TopologyConfig{NVLink: topologySoft}
+	unlinked := newTopologyAgentState(t, system, "unlinked", 3, &device.Topology{}, policy)
+	linked := newTopologyAgentState(t, system, "linked", 4, &device.Topology{
+		NVLinks: map[int][]int{0: {1}, 1: {0}},
+	}, policy)
+	agents, _ := byHandler(unlinked, linked)
+
+	// Best fit alone would pack the task onto the smaller agent.
+	fits := findFits(&sproto.AllocateRequest{SlotsNeeded: 2}, agents, BestFit)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, linked)
+
+	linked.topologyPolicy.NVLink = topologyOff
+	unlinked.topologyPolicy.NVLink = topologyOff
+	fits = findFits(&sproto.AllocateRequest{SlotsNeeded: 2}, agents, BestFit)
+	assert.Equal(t, len(fits), 1)
+	assert.Equal(t, fits[0].Agent, unlinked)
+}
+
+func TestRailAlignment(t *testing.T) {
+	system := actor.NewSystem(t.Name())
+	policy := TopologyConfig{RailAlignment: topologyHard}
+	rails := func() *device.Topology {
+		return &device.Topology{NICs: map[int][]string{0: {"mlx5_0"}, 1: {"mlx5_1"}}}
+	}
+	agent1 := newTopologyAgentState(t, system, "agent1", 2, rails(), policy)
+	agent2 := newTopologyAgentState(t, system, "agent2", 2, rails(), policy)
+	crossed := newTopologyAgentState(t, system, "crossed", 2, &device.Topology{
+		NICs: map[int][]string{0: {"mlx5_1"}, 1: {"mlx5_0"}},
+	}, policy)
+	agents, _ := byHandler(agent1, agent2, crossed)
+
+	req := &sproto.AllocateRequest{SlotsNeeded: 4}
+	fits := findFits(req, agents, BestFit)
+	assert.Equal(t, len(fits), 2)
+	for _, fit := range fits {
+		assert.Assert(t, fit.Agent != crossed)
+	}
+
+	agent2.healthy = false
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 0)
+
+	for _, agent := range agents {
+		agent.topologyPolicy.RailAlignment = topologySoft
+	}
+	assert.Equal(t, len(findFits(req, agents, BestFit)), 2)
+}
@@ -0,0 +1,24 @@
+package scim
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAPIHandler registers the SCIM routes, which authenticate with the configured bearer
+// token rather than with user sessions.
+func RegisterAPIHandler(echo *echo.Echo, s *Service) {
+	g := echo.Group("/scim/v2", s.authenticate)
+	g.GET("/ServiceProviderConfig", route(s.getServiceProviderConfig))
+	g.GET("/Users", route(s.getUsers))
+	g.POST("/Users", route(s.postUser))
+	g.GET("/Users/:id", route(s.getUser))
+	g.PUT("/Users/:id", route(s.putUser))
+	g.PATCH("/Users/:id", route(s.patchUser))
+	g.DELETE("/Users/:id", route(s.deleteUser))
+	g.GET("/Groups", route(s.getGroups))
+	g.POST("/Groups", route(s.postGroup))
+	g.GET("/Groups/:id", route(s.getGroup))
+	g.PUT("/Groups/:id", route(s.putGroup))
+	g.PATCH("/Groups/:id", route(s.patchGroup))
+	g.DELETE("/Groups/:id", route(s.deleteGroup))
+}
@@ -0,0 +1,39 @@
+package scim
+
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// minTokenLength is the shortest bearer token accepted for SCIM clients.
+const minTokenLength = 16
+
+// Config is the SCIM configuration of the master.
+type Config struct {
+	// Token is the bearer token that identity providers authenticate to the SCIM API with. The
+	// SCIM API is disabled if it is empty.
+	Token string `json:"token"`
+}
+
+// Enabled returns true if the SCIM API is enabled.
+func (c Config) Enabled() bool {
+	return c.Token != ""
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	if c.Token != "" {
+		c.Token = "********"
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	if !c.Enabled() {
+		return nil
+	}
+	return []error{
+		check.True(len(c.Token) >= minTokenLength,
+			"scim.token must be at least 16 characters long"),
+	}
+}
@@ -0,0 +1,278 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// See https://tools.ietf.org/html/rfc7643 and https://tools.ietf.org/html/rfc7644.
+const (
+	userSchema          = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema         = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	listResponseSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	errorSchema         = "urn:ietf:params:scim:api:messages:2.0:Error"
+	serviceConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	// contentType is the media type of SCIM requests and responses.
+	contentType = "application/scim+json"
+)
+
+// scimError is an error response of the SCIM API.
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func (e *scimError) Error() string {
+	return e.Detail
+}
+
+func newError(status int, scimType, detail string) *scimError {
+	return &scimError{
+		Schemas:  []string{errorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func notFound(detail string) *scimError {
+	return newError(http.StatusNotFound, "", detail)
+}
+
+func badRequest(scimType, detail string) *scimError {
+	return newError(http.StatusBadRequest, scimType, detail)
+}
+
+func conflict(detail string) *scimError {
+	return newError(http.StatusConflict, "uniqueness", detail)
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+// reference points from a group to one of its members or from a user to one of their groups.
+type reference struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type user struct {
+	Schemas  []string    `json:"schemas"`
+	ID       string      `json:"id,omitempty"`
+	UserName string      `json:"userName"`
+	Active   *bool       `json:"active,omitempty"`
+	Groups   []reference `json:"groups,omitempty"`
+	Meta     *meta       `json:"meta,omitempty"`
+}
+
+type group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []reference `json:"members"`
+	Meta        *meta       `json:"meta,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+// filterPattern matches the only filters that are supported, equality on a single attribute.
+var filterPattern = regexp.MustCompile(`^\s*(\w+)\s+(?i:eq)\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// parseFilter returns the attribute and the value of a filter of the form `attribute eq "value"`.
+// An empty filter matches everything and is returned as an empty attribute.
+func parseFilter(filter string) (attribute, value string, err error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", badRequest(
+			"invalidFilter", "only filters of the form `attribute eq \"value\"` are supported")
+	}
+	if err := json.Unmarshal([]byte(`"`+match[2]+`"`), &value); err != nil {
+		return "", "", badRequest("invalidFilter", "malformed filter value")
+	}
+	return match[1], value, nil
+}
+
+// memberFilterPattern matches the paths that select group members by ID.
+var memberFilterPattern = regexp.MustCompile(
+	`^(?i:members)\s*\[\s*(?i:value)\s+(?i:eq)\s+"([^"]*)"\s*\]$`)
+
+// page returns the items of the 1-based page that starts at startIndex and holds at most count
+// items, as the startIndex and count query parameters describe.
+func page(total int, startIndexParam, countParam string) (start, end int) {
+	start, end = 0, total
+	if i, err := strconv.Atoi(startIndexParam); err == nil && i > 1 {
+		start = i - 1
+	}
+	if start > total {
+		start = total
+	}
+	if n, err := strconv.Atoi(countParam); err == nil && n >= 0 && start+n < total {
+		end = start + n
+	}
+	return start, end
+}
+
+// parseBool accepts the booleans that identity providers send, which are sometimes strings.
+func parseBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	return false, badRequest("invalidValue", "expected a boolean, got "+string(raw))
+}
+
+// userState holds the attributes of a user that the SCIM API can change.
+type userState struct {
+	userName string
+	active   bool
+}
+
+// applyUserPatch applies the operations of a PATCH request to a user.
+func applyUserPatch(state *userState, operations []patchOperation) error {
+	for _, operation := range operations {
+		if op := strings.ToLower(operation.Op); op != "replace" && op != "add" {
+			return badRequest("invalidSyntax", "unsupported operation on users: "+operation.Op)
+		}
+		values := map[string]json.RawMessage{}
+		if operation.Path == "" {
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return badRequest("invalidValue", "operations without a path need an object value")
+			}
+		} else {
+			values[operation.Path] = operation.Value
+		}
+		for path, value := range values {
+			switch strings.ToLower(path) {
+			case "active":
+				active, err := parseBool(value)
+				if err != nil {
+					return err
+				}
+				state.active = active
+			case "username":
+				if err := json.Unmarshal(value, &state.userName); err != nil {
+					return badRequest("invalidValue", "userName must be a string")
+				}
+			default:
+				// Attributes that Determined does not store, such as names and emails, are ignored.
+			}
+		}
+	}
+	return nil
+}
+
+// groupState holds the attributes of a group that the SCIM API can change.
+type groupState struct {
+	displayName string
+	// members holds the IDs of the members.
+	members map[string]bool
+}
+
+func memberIDs(raw json.RawMessage) ([]string, error) {
+	var members []reference
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, badRequest("invalidValue", "members must be a list of references")
+	}
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Value)
+	}
+	return ids, nil
+}
+
+// applyGroupPatch applies the operations of a PATCH request to a group.
+func applyGroupPatch(state *groupState, operations []patchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return badRequest("invalidSyntax", "unsupported operation: "+operation.Op)
+		}
+		path := strings.TrimSpace(operation.Path)
+		if match := memberFilterPattern.FindStringSubmatch(path); match != nil {
+			if op != "remove" {
+				return badRequest("invalidPath", "members can only be selected for removal")
+			}
+			delete(state.members, match[1])
+			continue
+		}
+
+		values := map[string]json.RawMessage{}
+		switch {
+		case path != "":
+			values[path] = operation.Value
+		case op == "remove":
+			return badRequest("noTarget", "remove operations need a path")
+		default:
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return badRequest("invalidValue", "operations without a path need an object value")
+			}
+		}
+		for path, value := range values {
+			switch strings.ToLower(path) {
+			case "displayname":
+				if op == "remove" {
+					return badRequest("mutability", "displayName cannot be removed")
+				}
+				if err := json.Unmarshal(value, &state.displayName); err != nil {
+					return badRequest("invalidValue", "displayName must be a string")
+				}
+			case "members":
+				hasValue := len(value) > 0 && string(value) != "null"
+				var ids []string
+				if hasValue || op != "remove" {
+					var err error
+					if ids, err = memberIDs(value); err != nil {
+						return err
+					}
+				}
+				if op == "replace" || (op == "remove" && !hasValue) {
+					state.members = map[string]bool{}
+				}
+				for _, id := range ids {
+					if op == "remove" {
+						delete(state.members, id)
+					} else {
+						state.members[id] = true
+					}
+				}
+			default:
+				// Attributes that Determined does not store, such as external IDs, are ignored.
+			}
+		}
+	}
+	return nil
+}
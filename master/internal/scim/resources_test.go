@@ -0,0 +1,99 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	attribute, value, err := parseFilter(`userName eq "alice@example.com"`)
+	assert.NilError(t, err)
+	assert.Equal(t, attribute, "userName")
+	assert.Equal(t, value, "alice@example.com")
+
+	attribute, value, err = parseFilter(`displayName EQ "ml \"research\""`)
+	assert.NilError(t, err)
+	assert.Equal(t, attribute, "displayName")
+	assert.Equal(t, value, `ml "research"`)
+
+	attribute, _, err = parseFilter("")
+	assert.NilError(t, err)
+	assert.Equal(t, attribute, "")
+
+	_, _, err = parseFilter(`userName sw "a" and active eq true`)
+	assert.ErrorContains(t, err, "only filters")
+}
+
+func TestPage(t *testing.T) {
+	for _, tc := range []struct {
+		startIndex, count string
+		start, end        int
+	}{
+		{"", "", 0, 10},
+		{"1", "3", 0, 3},
+		{"4", "3", 3, 6},
+		{"9", "5", 8, 10},
+		{"20", "5", 10, 10},
+		{"0", "0", 0, 0},
+	} {
+		start, end := page(10, tc.startIndex, tc.count)
+		assert.Equal(t, start, tc.start, tc)
+		assert.Equal(t, end, tc.end, tc)
+	}
+}
+
+func operations(t *testing.T, raw string) []patchOperation {
+	var request patchRequest
+	assert.NilError(t, json.Unmarshal([]byte(raw), &request))
+	return request.Operations
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	state := userState{userName: "alice", active: true}
+	assert.NilError(t, applyUserPatch(&state, operations(t, `{"Operations": [
+		{"op": "replace", "path": "active", "value": false}
+	]}`)))
+	assert.Equal(t, state, userState{userName: "alice", active: false})
+
+	// Some identity providers leave out the path and send booleans as strings.
+	assert.NilError(t, applyUserPatch(&state, operations(t, `{"Operations": [
+		{"op": "Replace", "value": {"active": "True", "userName": "alice2", "displayName": "A"}}
+	]}`)))
+	assert.Equal(t, state, userState{userName: "alice2", active: true})
+
+	assert.ErrorContains(t, applyUserPatch(&state, operations(t, `{"Operations": [
+		{"op": "remove", "path": "active"}
+	]}`)), "unsupported operation")
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	state := groupState{displayName: "ml", members: map[string]bool{"1": true, "2": true}}
+	assert.NilError(t, applyGroupPatch(&state, operations(t, `{"Operations": [
+		{"op": "add", "path": "members", "value": [{"value": "3"}]},
+		{"op": "remove", "path": "members[value eq \"1\"]"},
+		{"op": "replace", "path": "displayName", "value": "research"}
+	]}`)))
+	assert.Equal(t, state.displayName, "research")
+	assert.DeepEqual(t, state.members, map[string]bool{"2": true, "3": true})
+
+	assert.NilError(t, applyGroupPatch(&state, operations(t, `{"Operations": [
+		{"op": "Remove", "path": "members", "value": [{"value": "2"}]}
+	]}`)))
+	assert.DeepEqual(t, state.members, map[string]bool{"3": true})
+
+	assert.NilError(t, applyGroupPatch(&state, operations(t, `{"Operations": [
+		{"op": "replace", "value": {"members": [{"value": "4"}, {"value": "5"}]}}
+	]}`)))
+	assert.DeepEqual(t, state.members, map[string]bool{"4": true, "5": true})
+
+	assert.NilError(t, applyGroupPatch(&state, operations(t, `{"Operations": [
+		{"op": "remove", "path": "members"}
+	]}`)))
+	assert.DeepEqual(t, state.members, map[string]bool{})
+
+	assert.ErrorContains(t, applyGroupPatch(&state, operations(t, `{"Operations": [
+		{"op": "add", "path": "members[value eq \"1\"]"}
+	]}`)), "only be selected for removal")
+}
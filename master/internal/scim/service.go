@@ -0,0 +1,576 @@
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Service is a SCIM 2.0 server that lets identity providers create and deactivate users and manage
+// groups. Users are never deleted, since experiments and tasks refer to them; deleting a user
+// through SCIM deactivates it instead. Only remote users, who log in through the identity provider,
+// are visible to SCIM; local users, such as the built-in admin, can be neither read nor changed.
+type Service struct {
+	db    store
+	token string
+
+	// mu serializes changes, since group changes read and then replace whole groups.
+	mu sync.Mutex
+}
+
+// store is the part of the database that the SCIM service uses.
+type store interface {
+	UserList() ([]model.FullUser, error)
+	AddUser(user *model.User, ug *model.AgentUserGroup) error
+	UpdateUser(updated *model.User, toUpdate []string, ug *model.AgentUserGroup) error
+	UpdateUsername(userID *model.UserID, newUsername string) error
+	Groups() ([]model.Group, error)
+	PutGroup(group *model.Group) error
+	UpdateGroup(group *model.Group) error
+	DeleteGroup(name string) error
+}
+
+// New creates a SCIM service.
+func New(db *db.PgDB, config Config) *Service {
+	return &Service{db: db, token: config.Token}
+}
+
+// authenticate rejects requests that do not carry the configured bearer token.
+func (s *Service) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			return respond(c, http.StatusUnauthorized, newError(
+				http.StatusUnauthorized, "", "invalid SCIM bearer token"))
+		}
+		return next(c)
+	}
+}
+
+func respond(c echo.Context, status int, body interface{}) error {
+	if body == nil {
+		return c.NoContent(status)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.Blob(status, contentType, data)
+}
+
+// route turns a handler into an echo handler that renders errors as SCIM error responses.
+func route(handler func(c echo.Context) (int, interface{}, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status, body, err := handler(c)
+		if err != nil {
+			var scimErr *scimError
+			if !errors.As(err, &scimErr) {
+				log.WithError(err).Errorf("error handling SCIM request %s %s",
+					c.Request().Method, c.Request().URL.Path)
+				scimErr = newError(http.StatusInternalServerError, "", "internal server error")
+			}
+			status, _ = strconv.Atoi(scimErr.Status)
+			body = scimErr
+		}
+		return respond(c, status, body)
+	}
+}
+
+func decode(c echo.Context, v interface{}) error {
+	if err := json.NewDecoder(c.Request().Body).Decode(v); err != nil {
+		return badRequest("invalidSyntax", "malformed request body: "+err.Error())
+	}
+	return nil
+}
+
+func location(c echo.Context, path string) string {
+	return fmt.Sprintf("%s://%s/scim/v2%s", c.Scheme(), c.Request().Host, path)
+}
+
+func (s *Service) getServiceProviderConfig(c echo.Context) (int, interface{}, error) {
+	type supported struct {
+		Supported bool `json:"supported"`
+	}
+	return http.StatusOK, map[string]interface{}{
+		"schemas":        []string{serviceConfigSchema},
+		"patch":          supported{true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 0},
+		"changePassword": supported{false},
+		"sort":           supported{false},
+		"etag":           supported{false},
+		"authenticationSchemes": []map[string]string{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "The token configured in security.scim.token.",
+		}},
+	}, nil
+}
+
+// snapshot holds the remote users and the groups of the cluster.
+type snapshot struct {
+	users      []model.FullUser
+	usersByID  map[string]model.FullUser
+	groups     []model.Group
+	groupsByID map[string]model.Group
+	// localUsernames are the usernames of the users that SCIM cannot see.
+	localUsernames map[string]bool
+}
+
+func (s *Service) snapshot() (*snapshot, error) {
+	allUsers, err := s.db.UserList()
+	if err != nil {
+		return nil, err
+	}
+	var users []model.FullUser
+	localUsernames := map[string]bool{}
+	for _, u := range allUsers {
+		if u.Remote {
+			users = append(users, u)
+		} else {
+			localUsernames[u.Username] = true
+		}
+	}
+	groups, err := s.db.Groups()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	snap := &snapshot{
+		users:          users,
+		usersByID:      make(map[string]model.FullUser, len(users)),
+		localUsernames: localUsernames,
+		groups:         groups,
+		groupsByID:     make(map[string]model.Group, len(groups)),
+	}
+	for _, u := range users {
+		snap.usersByID[strconv.Itoa(int(u.ID))] = u
+	}
+	for _, g := range groups {
+		snap.groupsByID[strconv.Itoa(g.ID)] = g
+	}
+	return snap, nil
+}
+
+func (snap *snapshot) user(c echo.Context, u model.FullUser) user {
+	id := strconv.Itoa(int(u.ID))
+	active := u.Active
+	result := user{
+		Schemas:  []string{userSchema},
+		ID:       id,
+		UserName: u.Username,
+		Active:   &active,
+		Meta:     &meta{ResourceType: "User", Location: location(c, "/Users/"+id)},
+	}
+	for _, g := range snap.groups {
+		for _, username := range g.Usernames {
+			if username == u.Username {
+				result.Groups = append(result.Groups, reference{
+					Value: strconv.Itoa(g.ID), Display: g.Name,
+				})
+			}
+		}
+	}
+	return result
+}
+
+func (snap *snapshot) group(c echo.Context, g model.Group) group {
+	id := strconv.Itoa(g.ID)
+	result := group{
+		Schemas:     []string{groupSchema},
+		ID:          id,
+		DisplayName: g.Name,
+		Members:     []reference{},
+		Meta:        &meta{ResourceType: "Group", Location: location(c, "/Groups/"+id)},
+	}
+	for _, username := range g.Usernames {
+		for _, u := range snap.users {
+			if u.Username == username {
+				result.Members = append(result.Members, reference{
+					Value: strconv.Itoa(int(u.ID)), Display: u.Username,
+				})
+			}
+		}
+	}
+	return result
+}
+
+func (snap *snapshot) findUser(id string) (model.FullUser, error) {
+	u, ok := snap.usersByID[id]
+	if !ok {
+		return model.FullUser{}, notFound("user " + id + " not found")
+	}
+	return u, nil
+}
+
+func (snap *snapshot) findGroup(id string) (model.Group, error) {
+	g, ok := snap.groupsByID[id]
+	if !ok {
+		return model.Group{}, notFound("group " + id + " not found")
+	}
+	return g, nil
+}
+
+// usernames returns the usernames of the users with the given IDs in a stable order.
+func (snap *snapshot) usernames(ids map[string]bool) ([]string, error) {
+	usernames := make([]string, 0, len(ids))
+	for id := range ids {
+		u, ok := snap.usersByID[id]
+		if !ok {
+			return nil, badRequest("invalidValue", "member "+id+" is not a user")
+		}
+		usernames = append(usernames, u.Username)
+	}
+	sort.Strings(usernames)
+	return usernames, nil
+}
+
+func list(c echo.Context, resources []interface{}) listResponse {
+	start, end := page(len(resources), c.QueryParam("startIndex"), c.QueryParam("count"))
+	return listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   start + 1,
+		ItemsPerPage: end - start,
+		Resources:    resources[start:end],
+	}
+}
+
+func (s *Service) getUsers(c echo.Context) (int, interface{}, error) {
+	attribute, value, err := parseFilter(c.QueryParam("filter"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if attribute != "" && !strings.EqualFold(attribute, "userName") {
+		return 0, nil, badRequest("invalidFilter", "users can only be filtered by userName")
+	}
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	resources := []interface{}{}
+	for _, u := range snap.users {
+		if attribute == "" || strings.EqualFold(u.Username, value) {
+			resources = append(resources, snap.user(c, u))
+		}
+	}
+	return http.StatusOK, list(c, resources), nil
+}
+
+func (s *Service) getUser(c echo.Context) (int, interface{}, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	u, err := snap.findUser(c.Param("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, snap.user(c, u), nil
+}
+
+func (s *Service) postUser(c echo.Context) (int, interface{}, error) {
+	var params user
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	username := strings.ToLower(strings.TrimSpace(params.UserName))
+	if username == "" {
+		return 0, nil, badRequest("invalidValue", "userName must be set")
+	}
+	active := params.Active == nil || *params.Active
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Users provisioned through SCIM log in through single sign-on.
+	switch err := s.db.AddUser(
+		&model.User{Username: username, Active: active, Remote: true}, nil,
+	); {
+	case err == db.ErrDuplicateRecord:
+		return 0, nil, conflict("user " + username + " already exists")
+	case err != nil:
+		return 0, nil, err
+	}
+	log.Infof("SCIM created user %s", username)
+
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, u := range snap.users {
+		if u.Username == username {
+			return http.StatusCreated, snap.user(c, u), nil
+		}
+	}
+	return 0, nil, errors.Errorf("user %s disappeared after being created", username)
+}
+
+// updateUser changes a user to match the state that an identity provider asked for.
+func (s *Service) updateUser(c echo.Context, id string, update func(*userState) error) (
+	int, interface{}, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	current, err := snap.findUser(id)
+	if err != nil {
+		return 0, nil, err
+	}
+	state := userState{userName: current.Username, active: current.Active}
+	if err = update(&state); err != nil {
+		return 0, nil, err
+	}
+
+	username := strings.ToLower(strings.TrimSpace(state.userName))
+	if username == "" {
+		return 0, nil, badRequest("invalidValue", "userName must be set")
+	}
+	if username != current.Username {
+		if snap.localUsernames[username] {
+			return 0, nil, conflict("user " + username + " already exists")
+		}
+		for _, u := range snap.users {
+			if u.Username == username {
+				return 0, nil, conflict("user " + username + " already exists")
+			}
+		}
+		if err = s.db.UpdateUsername(&current.ID, username); err != nil {
+			return 0, nil, err
+		}
+		log.Infof("SCIM renamed user %s to %s", current.Username, username)
+		current.Username = username
+	}
+	if state.active != current.Active {
+		if err = s.db.UpdateUser(&model.User{
+			ID: current.ID, Username: current.Username, Active: state.active,
+		}, []string{"active"}, nil); err != nil {
+			return 0, nil, err
+		}
+		log.Infof("SCIM set user %s active to %t", current.Username, state.active)
+		current.Active = state.active
+	}
+	snap.usersByID[id] = current
+	for i := range snap.users {
+		if snap.users[i].ID == current.ID {
+			snap.users[i] = current
+		}
+	}
+	return http.StatusOK, snap.user(c, current), nil
+}
+
+func (s *Service) putUser(c echo.Context) (int, interface{}, error) {
+	var params user
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	return s.updateUser(c, c.Param("id"), func(state *userState) error {
+		state.userName = params.UserName
+		if params.Active != nil {
+			state.active = *params.Active
+		}
+		return nil
+	})
+}
+
+func (s *Service) patchUser(c echo.Context) (int, interface{}, error) {
+	var params patchRequest
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	return s.updateUser(c, c.Param("id"), func(state *userState) error {
+		return applyUserPatch(state, params.Operations)
+	})
+}
+
+func (s *Service) deleteUser(c echo.Context) (int, interface{}, error) {
+	if _, _, err := s.updateUser(c, c.Param("id"), func(state *userState) error {
+		state.active = false
+		return nil
+	}); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Service) getGroups(c echo.Context) (int, interface{}, error) {
+	attribute, value, err := parseFilter(c.QueryParam("filter"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if attribute != "" && !strings.EqualFold(attribute, "displayName") {
+		return 0, nil, badRequest("invalidFilter", "groups can only be filtered by displayName")
+	}
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	resources := []interface{}{}
+	for _, g := range snap.groups {
+		if attribute == "" || strings.EqualFold(g.Name, value) {
+			resources = append(resources, snap.group(c, g))
+		}
+	}
+	return http.StatusOK, list(c, resources), nil
+}
+
+func (s *Service) getGroup(c echo.Context) (int, interface{}, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	g, err := snap.findGroup(c.Param("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, snap.group(c, g), nil
+}
+
+func (s *Service) postGroup(c echo.Context) (int, interface{}, error) {
+	var params group
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	name := strings.TrimSpace(params.DisplayName)
+	if name == "" {
+		return 0, nil, badRequest("invalidValue", "displayName must be set")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, g := range snap.groups {
+		if g.Name == name {
+			return 0, nil, conflict("group " + name + " already exists")
+		}
+	}
+	members := map[string]bool{}
+	for _, member := range params.Members {
+		members[member.Value] = true
+	}
+	usernames, err := snap.usernames(members)
+	if err != nil {
+		return 0, nil, err
+	}
+	created := model.Group{Name: name, Usernames: usernames}
+	if err = s.db.PutGroup(&created); err != nil {
+		return 0, nil, err
+	}
+	log.Infof("SCIM created group %s", name)
+	return http.StatusCreated, snap.group(c, created), nil
+}
+
+// updateGroup changes a group to match the state that an identity provider asked for.
+func (s *Service) updateGroup(c echo.Context, id string, update func(*groupState) error) (
+	int, interface{}, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	current, err := snap.findGroup(id)
+	if err != nil {
+		return 0, nil, err
+	}
+	state := groupState{displayName: current.Name, members: map[string]bool{}}
+	for _, u := range snap.users {
+		for _, username := range current.Usernames {
+			if u.Username == username {
+				state.members[strconv.Itoa(int(u.ID))] = true
+			}
+		}
+	}
+	if err = update(&state); err != nil {
+		return 0, nil, err
+	}
+
+	name := strings.TrimSpace(state.displayName)
+	if name == "" {
+		return 0, nil, badRequest("invalidValue", "displayName must be set")
+	}
+	usernames, err := snap.usernames(state.members)
+	if err != nil {
+		return 0, nil, err
+	}
+	// Local members are invisible to SCIM, so it cannot remove them.
+	for _, username := range current.Usernames {
+		if snap.localUsernames[username] {
+			usernames = append(usernames, username)
+		}
+	}
+	sort.Strings(usernames)
+	updated := model.Group{ID: current.ID, Name: name, Usernames: usernames}
+	switch err = s.db.UpdateGroup(&updated); {
+	case errors.Cause(err) == db.ErrDuplicateRecord:
+		return 0, nil, conflict("group " + name + " already exists")
+	case err != nil:
+		return 0, nil, err
+	}
+	if name != current.Name {
+		log.Infof("SCIM renamed group %s to %s", current.Name, name)
+	}
+	return http.StatusOK, snap.group(c, updated), nil
+}
+
+func (s *Service) putGroup(c echo.Context) (int, interface{}, error) {
+	var params group
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	return s.updateGroup(c, c.Param("id"), func(state *groupState) error {
+		state.displayName = params.DisplayName
+		state.members = map[string]bool{}
+		for _, member := range params.Members {
+			state.members[member.Value] = true
+		}
+		return nil
+	})
+}
+
+func (s *Service) patchGroup(c echo.Context) (int, interface{}, error) {
+	var params patchRequest
+	if err := decode(c, &params); err != nil {
+		return 0, nil, err
+	}
+	return s.updateGroup(c, c.Param("id"), func(state *groupState) error {
+		return applyGroupPatch(state, params.Operations)
+	})
+}
+
+func (s *Service) deleteGroup(c echo.Context) (int, interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap, err := s.snapshot()
+	if err != nil {
+		return 0, nil, err
+	}
+	g, err := snap.findGroup(c.Param("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = s.db.DeleteGroup(g.Name); err != nil {
+		return 0, nil, err
+	}
+	log.Infof("SCIM deleted group %s", g.Name)
+	return http.StatusNoContent, nil, nil
+}
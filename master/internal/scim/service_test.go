@@ -0,0 +1,132 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestAuthenticate(t *testing.T) {
+	s := &Service{token: "secret"}
+	handler := s.authenticate(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	for header, expected := range map[string]int{
+		"Bearer secret": http.StatusNoContent,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"secret":        http.StatusUnauthorized,
+		"":              http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+		assert.NilError(t, handler(echo.New().NewContext(req, rec)))
+		assert.Equal(t, rec.Code, expected, "header %q", header)
+	}
+}
+
+// fakeStore is an in-memory store that records the changes made to its users.
+type fakeStore struct {
+	users   []model.FullUser
+	groups  []model.Group
+	changes []string
+}
+
+func (f *fakeStore) UserList() ([]model.FullUser, error) {
+	return append([]model.FullUser(nil), f.users...), nil
+}
+
+func (f *fakeStore) AddUser(user *model.User, ug *model.AgentUserGroup) error {
+	f.changes = append(f.changes, "add "+user.Username)
+	return nil
+}
+
+func (f *fakeStore) UpdateUser(
+	updated *model.User, toUpdate []string, ug *model.AgentUserGroup,
+) error {
+	f.changes = append(f.changes, "update "+updated.Username)
+	return nil
+}
+
+func (f *fakeStore) UpdateUsername(userID *model.UserID, newUsername string) error {
+	f.changes = append(f.changes, "rename "+newUsername)
+	return nil
+}
+
+func (f *fakeStore) Groups() ([]model.Group, error) {
+	return append([]model.Group(nil), f.groups...), nil
+}
+
+func (f *fakeStore) PutGroup(group *model.Group) error {
+	f.groups = append(f.groups, *group)
+	return nil
+}
+
+func (f *fakeStore) UpdateGroup(group *model.Group) error {
+	for i := range f.groups {
+		if f.groups[i].ID == group.ID {
+			f.groups[i] = *group
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) DeleteGroup(name string) error {
+	return nil
+}
+
+func TestLocalUsersAreInvisible(t *testing.T) {
+	store := &fakeStore{
+		users: []model.FullUser{
+			{ID: 1, Username: "admin", Admin: true, Active: true},
+			{ID: 2, Username: "alice", Active: true, Remote: true},
+		},
+		groups: []model.Group{{ID: 1, Name: "team", Usernames: []string{"admin", "alice"}}},
+	}
+	e := echo.New()
+	RegisterAPIHandler(e, &Service{db: store, token: "secret"})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/scim/v2/Users", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	var users struct {
+		Resources []user `json:"Resources"`
+	}
+	assert.NilError(t, json.Unmarshal(rec.Body.Bytes(), &users))
+	assert.Equal(t, len(users.Resources), 1)
+	assert.Equal(t, users.Resources[0].UserName, "alice")
+
+	for _, tc := range []struct {
+		method string
+		body   string
+	}{
+		{http.MethodGet, ""},
+		{http.MethodPut, `{"userName": "mallory", "active": true}`},
+		{http.MethodPatch, `{"Operations": [{"op": "replace", "path": "active", "value": false}]}`},
+		{http.MethodDelete, ""},
+	} {
+		rec = do(tc.method, "/scim/v2/Users/1", tc.body)
+		assert.Equal(t, rec.Code, http.StatusNotFound, "%s of a local user", tc.method)
+	}
+	rec = do(http.MethodPut, "/scim/v2/Users/2", `{"userName": "admin"}`)
+	assert.Equal(t, rec.Code, http.StatusConflict)
+	assert.Equal(t, len(store.changes), 0, "changes: %v", store.changes)
+
+	// Replacing the members of a group keeps the local members that SCIM cannot see.
+	rec = do(http.MethodPut, "/scim/v2/Groups/1", `{"displayName": "team", "members": []}`)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.DeepEqual(t, store.groups[0].Usernames, []string{"admin"})
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/vault"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// ownerSecrets returns the values of the secrets of an experiment's owner, if the experiment has
+// one.
+func ownerSecrets(pg *db.PgDB, ownerID *model.UserID) (map[string]string, error) {
+	if ownerID == nil {
+		return nil, nil
+	}
+	secrets, err := pg.SecretValues(*ownerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load the owner's secrets")
+	}
+	return secrets, nil
+}
+
+// checkExperimentSecrets returns an error if the environment variables of an experiment reference
+// secrets that its owner does not have.
+func checkExperimentSecrets(
+	pg *db.PgDB, ownerID model.UserID, config expconf.ExperimentConfig,
+) error {
+	envVars := config.Environment().EnvironmentVariables()
+	references := tasks.SecretReferences(envVars.For(device.CPU), envVars.For(device.GPU))
+	if len(references) == 0 {
+		return nil
+	}
+	secrets, err := pg.SecretNames(ownerID)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	return tasks.CheckSecretReferences(names, envVars.For(device.CPU), envVars.For(device.GPU))
+}
+
+// experimentWorkspace returns the name of the workspace of an experiment if its environment
+// variables reference Vault secrets, and "" otherwise.
+func experimentWorkspace(pg *db.PgDB, exp *model.Experiment) (string, error) {
+	envVars := exp.Config.Environment().EnvironmentVariables()
+	if len(tasks.VaultReferences(envVars.For(device.CPU), envVars.For(device.GPU))) == 0 {
+		return "", nil
+	}
+	projectID := exp.ProjectID
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	workspace, err := pg.WorkspaceByProjectID(projectID)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot find the workspace of project %d", projectID)
+	}
+	return workspace.Name, nil
+}
+
+// checkExperimentVaultSecrets returns an error if the tasks of an experiment cannot fetch the
+// Vault secrets that its environment variables reference.
+func checkExperimentVaultSecrets(
+	pg *db.PgDB, client *vault.Client, exp *model.Experiment,
+) error {
+	workspace, err := experimentWorkspace(pg, exp)
+	if err != nil || workspace == "" {
+		return err
+	}
+	envVars := exp.Config.Environment().EnvironmentVariables()
+	return client.Check(workspace, envVars.For(device.CPU), envVars.For(device.GPU))
+}
+
+// fetchExperimentVaultSecrets fetches the Vault secrets that the environment variables of an
+// experiment reference for one of its tasks. The grant, if any, must be released when the task
+// ends.
+func fetchExperimentVaultSecrets(
+	pg *db.PgDB, client *vault.Client, exp *model.Experiment,
+) (*vault.Grant, error) {
+	workspace, err := experimentWorkspace(pg, exp)
+	if err != nil || workspace == "" {
+		return nil, err
+	}
+	envVars := exp.Config.Environment().EnvironmentVariables()
+	return client.Fetch(workspace, envVars.For(device.CPU), envVars.For(device.GPU))
+}
@@ -3,11 +3,13 @@ package sproto
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/determined-ai/determined/master/pkg/actor"
 	aproto "github.com/determined-ai/determined/master/pkg/agent"
 	cproto "github.com/determined-ai/determined/master/pkg/container"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 // DeviceID is the unique identifier for a device in the cluster.
@@ -20,8 +22,13 @@ type DeviceID struct {
 type (
 	// AddAgent adds the agent to the cluster.
 	AddAgent struct {
-		Agent *actor.Ref
-		Label string
+		Agent    *actor.Ref
+		Label    string
+		Platform string
+		Topology *device.Topology
+		// MaxZeroSlotContainers limits the zero-slot containers of the agent below the limit of
+		// its pool; it is zero if the agent leaves it to the pool.
+		MaxZeroSlotContainers int
 	}
 	// AddDevice makes the device immediately available for scheduling.
 	AddDevice struct {
@@ -36,6 +43,24 @@ type (
 	RemoveAgent struct {
 		Agent *actor.Ref
 	}
+	// UpdateAgentHealth notifies the resource pool that the health of an agent changed. No new
+	// tasks are scheduled onto unhealthy agents.
+	UpdateAgentHealth struct {
+		Agent   *actor.Ref
+		Healthy bool
+	}
+	// UpdateDeviceHealth notifies the resource pool that the health of a device changed. No new
+	// tasks are scheduled onto unhealthy devices.
+	UpdateDeviceHealth struct {
+		DeviceID
+		Healthy bool
+	}
+	// AgentInterrupted notifies the resource pool that the cloud provider is about to reclaim the
+	// instance of an agent. Its tasks are released so they can checkpoint before the deadline.
+	AgentInterrupted struct {
+		Agent    *actor.Ref
+		Deadline time.Time
+	}
 )
 
 // Message protocol from the default resource manager to an agent actor.
@@ -43,11 +68,32 @@ type (
 	// StartTaskContainer notifies the agent to start the task with the provided task spec.
 	StartTaskContainer struct {
 		TaskActor *actor.Ref
+		TaskID    TaskID
+		TaskName  string
 		aproto.StartContainer
 	}
-	// KillTaskContainer notifies the agent to kill a task container.
+	// KillTaskContainer notifies the agent to kill a task container, as its stop policy says.
 	KillTaskContainer struct {
 		ContainerID cproto.ID
+		Stop        model.StopPolicy
+	}
+	// SetAgentLabelPools maps the labels of agents to the resource pools that claim them. Agents
+	// with a claimed label join its pool instead of the one that they asked for, moving to it once
+	// they run no containers.
+	SetAgentLabelPools struct {
+		Pools map[string]string
+	}
+)
+
+// Message protocol from an agent actor to task actors.
+type (
+	// ReattachContainer asks the task actor of a persisted allocation whether it reattaches to the
+	// container, which the agent found running when it reconnected to the master. A task actor that
+	// does responds with the AllocateRequest that the resource pool allocates the container to.
+	ReattachContainer struct {
+		Agent        *actor.Ref
+		ResourcePool string
+		Container    cproto.Container
 	}
 )
 
@@ -17,6 +17,17 @@ var (
 	AgentsAddr = actor.Addr("agents")
 	// PodsAddr is the actor address of the pods.
 	PodsAddr = actor.Addr("pods")
+	// HPCRMAddr is the actor address of the HPC resource manager.
+	HPCRMAddr = actor.Addr("hpcRM")
+	// HPCJobsAddr is the actor address of the HPC jobs.
+	HPCJobsAddr = actor.Addr("hpcJobs")
+)
+
+const (
+	// K8sRPName is the name of the resource pool that represents the Kubernetes cluster.
+	K8sRPName = "kubernetes"
+	// HPCRPName is the name of the resource pool that represents the HPC cluster.
+	HPCRPName = "hpc"
 )
 
 type (
@@ -54,6 +65,11 @@ func UseK8sRM(system *actor.System) bool {
 	return system.Get(PodsAddr) != nil
 }
 
+// UseHPCRM returns if using the HPC resource manager.
+func UseHPCRM(system *actor.System) bool {
+	return system.Get(HPCJobsAddr) != nil
+}
+
 // GetRP returns the resource pool.
 func GetRP(system *actor.System, name string) *actor.Ref {
 	if rm := system.Get(AgentRMAddr); rm != nil {
@@ -62,34 +78,27 @@ func GetRP(system *actor.System, name string) *actor.Ref {
 	return nil
 }
 
-// GetCurrentRM returns either the k8s resource manager or the agents
-// resource manager, depending on which exists.
-func GetCurrentRM(system *actor.System) *actor.Ref {
-	if UseK8sRM(system) {
-		return system.Get(K8sRMAddr)
-	}
-	if UseAgentRM(system) {
-		return system.Get(AgentRMAddr)
-	}
-	panic("There should either be a k8s resource manager or an agent resource manager")
-}
-
 // GetDefaultGPUResourcePool returns the default GPU resource pool.
 func GetDefaultGPUResourcePool(system *actor.System) string {
-	resp := system.Ask(GetCurrentRM(system), GetDefaultGPUResourcePoolRequest{}).Get()
+	resp := system.Ask(GetRM(system), GetDefaultGPUResourcePoolRequest{}).Get()
 	return resp.(GetDefaultGPUResourcePoolResponse).PoolName
 }
 
 // GetDefaultCPUResourcePool returns the default CPU resource pool.
 func GetDefaultCPUResourcePool(system *actor.System) string {
-	resp := system.Ask(GetCurrentRM(system), GetDefaultCPUResourcePoolRequest{}).Get()
+	resp := system.Ask(GetRM(system), GetDefaultCPUResourcePoolRequest{}).Get()
 	return resp.(GetDefaultCPUResourcePoolResponse).PoolName
 }
 
-// ValidateRP validates if the resource pool exists when using the agent resource manager.
+// ValidateRP validates if the resource pool exists in any of the resource managers.
 func ValidateRP(system *actor.System, name string) error {
-	if name == "" || UseAgentRM(system) && GetRP(system, name) != nil {
-		return nil
+	switch {
+	case name == "":
+	case UseAgentRM(system) && GetRP(system, name) != nil:
+	case UseK8sRM(system) && name == K8sRPName:
+	case UseHPCRM(system) && name == HPCRPName:
+	default:
+		return errors.Errorf("cannot find resource pool: %s", name)
 	}
-	return errors.Errorf("cannot find resource pool: %s", name)
+	return nil
 }
@@ -0,0 +1,26 @@
+package sproto
+
+import (
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// Incoming HPC jobs actor messages; HPC jobs actors must accept these messages.
+type (
+	// StartHPCJob notifies the HPC jobs actor to submit a batch job that runs the task spec.
+	StartHPCJob struct {
+		TaskActor *actor.Ref
+		Spec      tasks.TaskSpec
+		Slots     int
+	}
+	// KillHPCJob notifies the HPC jobs actor to cancel a job.
+	KillHPCJob struct {
+		ContainerID container.ID
+	}
+)
+
+// SetHPCJobs sets the HPC jobs actor for the HPC resource manager.
+type SetHPCJobs struct {
+	Jobs *actor.Ref
+}
@@ -3,6 +3,7 @@ package sproto
 import (
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 )
 
@@ -13,10 +14,17 @@ type (
 		TaskActor *actor.Ref
 		Spec      tasks.TaskSpec
 		Slots     int
+		// Priority is the task's scheduling priority, if it has one.
+		Priority *int
+		// GangSize is the number of pods that the task runs in, all of which must be scheduled
+		// together.
+		GangSize int
 	}
-	// KillTaskPod notifies the pods actor to kill a pod.
+	// KillTaskPod notifies the pods actor to kill a pod. Pods receive the stop signal of their
+	// image, so only the grace period of the stop policy applies.
 	KillTaskPod struct {
 		PodID container.ID
+		Stop  model.StopPolicy
 	}
 )
 
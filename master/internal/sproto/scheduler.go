@@ -4,4 +4,8 @@ package sproto
 type FittingRequirements struct {
 	// SingleAgent specifies that the task must be located within a single agent.
 	SingleAgent bool
+	// Containers, if more than one, is the number of containers that the task runs in, each on a
+	// different agent and with an equal share of the slots of the task. Unlike the containers of
+	// distributed trials, they need not take all the slots of their agents.
+	Containers int
 }
@@ -3,7 +3,10 @@ package sproto
 import (
 	"github.com/google/uuid"
 
+	"github.com/determined-ai/determined/master/internal/tracing"
 	"github.com/determined-ai/determined/master/pkg/actor"
+	cproto "github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 )
 
@@ -18,8 +21,30 @@ type (
 		NonPreemptible      bool
 		Label               string
 		ResourcePool        string
+		Platform            string
 		FittingRequirements FittingRequirements
 		TaskActor           *actor.Ref
+
+		// GPUType is the model of GPU, like a100, that the task must run on; any model if empty.
+		GPUType string
+
+		// OwnerID and ProjectID are who the usage of the task is accounted to, which budgets limit.
+		OwnerID   model.UserID
+		ProjectID int
+
+		// MinSlotsNeeded and MaxSlotsNeeded bound the slots of elastic tasks, which can run with any
+		// number of slots in between. Resource pools lower SlotsNeeded towards MinSlotsNeeded while
+		// such a task waits for resources, and ask it to release its resources so that it restarts
+		// with more slots once the capacity frees up.
+		MinSlotsNeeded int
+		MaxSlotsNeeded int
+
+		// SpanContext is the span that resource managers continue the trace of the task from.
+		SpanContext tracing.SpanContext
+
+		// Reattach is the container, found running when its agent reconnected, that the task is
+		// allocated instead of being scheduled.
+		Reattach *ReattachContainer
 	}
 	// ResourcesReleased notifies resource providers to return resources from a task.
 	ResourcesReleased struct {
@@ -43,6 +68,11 @@ type (
 		ID           TaskID
 		ResourcePool string
 		Allocations  []Allocation
+		// Slots is the number of slots across all of the allocations.
+		Slots int
+		// Network is the network private to the task that its containers join, with a peer for
+		// each allocation in order. It is only set for allocations with more than one container.
+		Network *cproto.TaskNetwork
 	}
 	// ReleaseResources notifies the task actor to release resources.
 	ReleaseResources struct {
@@ -50,6 +80,11 @@ type (
 	}
 )
 
+// Elastic returns true if the task can run with a varying number of slots.
+func (r AllocateRequest) Elastic() bool {
+	return r.MinSlotsNeeded > 0 && r.MinSlotsNeeded < r.MaxSlotsNeeded
+}
+
 // TaskID is the ID of a task.
 type TaskID string
 
@@ -63,5 +98,6 @@ func NewTaskID() TaskID {
 type Allocation interface {
 	Summary() ContainerSummary
 	Start(ctx *actor.Context, spec tasks.TaskSpec)
-	Kill(ctx *actor.Context)
+	// Kill stops the containers of the allocation as the stop policy says.
+	Kill(ctx *actor.Context, stop model.StopPolicy)
 }
@@ -27,6 +27,9 @@ type (
 	// TaskContainerStarted contains the information needed by tasks from container started.
 	TaskContainerStarted struct {
 		Addresses []container.Address
+		// SourceAddresses are the IP addresses that requests from the container reach the master
+		// from, which the task token of the container is bound to.
+		SourceAddresses []string
 	}
 	// TaskContainerStopped contains the information needed by tasks from container stopped.
 	TaskContainerStopped struct {
@@ -39,6 +42,11 @@ type (
 		ContainerStarted *TaskContainerStarted
 		ContainerStopped *TaskContainerStopped
 	}
+	// TaskContainerProfile notifies the task actor of the latest sample of the utilization of one
+	// of its containers.
+	TaskContainerProfile struct {
+		agent.ContainerProfile
+	}
 
 	// SetGroupMaxSlots sets the maximum number of slots that a group can consume in the cluster.
 	SetGroupMaxSlots struct {
@@ -0,0 +1,23 @@
+package sso
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/determined-ai/determined/master/internal/api"
+)
+
+// RegisterAPIHandler registers the single sign-on routes. Users are not authenticated yet when
+// they reach them, so no authentication middleware is applied.
+func RegisterAPIHandler(echo *echo.Echo, s *Service) {
+	echo.GET("/sso/providers", api.Route(s.getProviders))
+	if s.oidc != nil {
+		echo.GET("/sso/oidc/login", s.getOIDCLogin)
+		echo.GET("/sso/oidc/callback", s.getOIDCCallback)
+		echo.POST("/sso/oidc/token", api.Route(s.postOIDCToken))
+	}
+	if s.saml != nil {
+		echo.GET("/sso/saml/login", s.getSAMLLogin)
+		echo.POST("/sso/saml/acs", s.postSAMLACS)
+		echo.GET("/sso/saml/metadata", s.getSAMLMetadata)
+	}
+}
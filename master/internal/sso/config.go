@@ -0,0 +1,136 @@
+package sso
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config is the single sign-on configuration of the master. Users of an OpenID Connect or SAML
+// identity provider log in through it instead of with a Determined password.
+type Config struct {
+	// MasterURL is the URL at which users' browsers and the identity provider reach the master.
+	// The OIDC redirect URL and the SAML assertion consumer service URL are derived from it.
+	MasterURL string `json:"master_url"`
+	// AutoProvisionUsers creates a Determined user the first time an unknown user logs in.
+	AutoProvisionUsers bool `json:"auto_provision_users"`
+	// GroupRoles maps the groups that the identity provider reports for a user to the names of
+	// the roles the user holds while a member. It is applied every time the user logs in.
+	GroupRoles map[string]string `json:"group_roles"`
+
+	OIDC *OIDCConfig `json:"oidc"`
+	SAML *SAMLConfig `json:"saml"`
+}
+
+// OIDCConfig configures logging in through an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the issuer URL of the provider, which serves its discovery document.
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// UsernameClaim is the claim of the ID token that holds the Determined username.
+	UsernameClaim string `json:"username_claim"`
+	// GroupsClaim is the claim of the ID token that lists the groups of the user.
+	GroupsClaim string `json:"groups_claim"`
+	// Scopes are requested in addition to the openid scope.
+	Scopes []string `json:"scopes"`
+	// ExchangeAudiences are the audiences, besides the client ID, of the ID tokens that may be
+	// exchanged for Determined tokens, e.g. the client IDs of command-line tools.
+	ExchangeAudiences []string `json:"exchange_audiences"`
+}
+
+// SAMLConfig configures logging in through a SAML 2.0 identity provider.
+type SAMLConfig struct {
+	// IDPSSOURL is the single sign-on URL of the provider, which accepts the HTTP-Redirect
+	// binding.
+	IDPSSOURL string `json:"idp_sso_url"`
+	// IDPEntityID is the issuer of the provider's assertions. It is not checked if empty.
+	IDPEntityID string `json:"idp_entity_id"`
+	// IDPCertificate is the path of the PEM-encoded certificate the provider signs with.
+	IDPCertificate string `json:"idp_certificate"`
+	// EntityID identifies the master to the provider. It defaults to the metadata URL.
+	EntityID string `json:"entity_id"`
+	// UsernameAttribute is the attribute that holds the Determined username. The name ID of the
+	// subject is used if it is empty.
+	UsernameAttribute string `json:"username_attribute"`
+	// GroupsAttribute is the attribute that lists the groups of the user.
+	GroupsAttribute string `json:"groups_attribute"`
+}
+
+const (
+	defaultUsernameClaim   = "email"
+	defaultGroupsClaim     = "groups"
+	defaultGroupsAttribute = "groups"
+)
+
+// Enabled returns true if any identity provider is configured.
+func (c Config) Enabled() bool {
+	return c.OIDC != nil || c.SAML != nil
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	if c.OIDC != nil && c.OIDC.ClientSecret != "" {
+		oidc := *c.OIDC
+		oidc.ClientSecret = "********"
+		c.OIDC = &oidc
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	if !c.Enabled() {
+		return nil
+	}
+	errs := []error{
+		check.True(c.MasterURL != "", "master_url must be set to enable single sign-on"),
+	}
+	if c.MasterURL != "" {
+		if u, err := url.Parse(c.MasterURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, errors.Errorf("master_url is not an absolute URL: %s", c.MasterURL))
+		}
+	}
+	if c.OIDC != nil {
+		errs = append(errs,
+			check.True(c.OIDC.Issuer != "", "oidc.issuer must be set"),
+			check.True(c.OIDC.ClientID != "", "oidc.client_id must be set"),
+		)
+	}
+	if c.SAML != nil {
+		errs = append(errs,
+			check.True(c.SAML.IDPSSOURL != "", "saml.idp_sso_url must be set"),
+			check.True(c.SAML.IDPCertificate != "", "saml.idp_certificate must be set"),
+		)
+	}
+	return errs
+}
+
+// url returns the absolute URL of a path of the master.
+func (c Config) url(path string) string {
+	return strings.TrimSuffix(c.MasterURL, "/") + path
+}
+
+func (c OIDCConfig) usernameClaim() string {
+	if c.UsernameClaim == "" {
+		return defaultUsernameClaim
+	}
+	return c.UsernameClaim
+}
+
+func (c OIDCConfig) groupsClaim() string {
+	if c.GroupsClaim == "" {
+		return defaultGroupsClaim
+	}
+	return c.GroupsClaim
+}
+
+func (c SAMLConfig) groupsAttribute() string {
+	if c.GroupsAttribute == "" {
+		return defaultGroupsAttribute
+	}
+	return c.GroupsAttribute
+}
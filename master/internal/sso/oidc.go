@@ -0,0 +1,411 @@
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// clockSkew is how far the clocks of the master and an identity provider may disagree.
+	clockSkew = 2 * time.Minute
+	// keysRefreshInterval limits how often the signing keys of a provider are fetched again when a
+	// token is signed with an unknown key.
+	keysRefreshInterval = time.Minute
+)
+
+// oidcDiscovery is the part of an OpenID Connect discovery document that the master uses.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// oidcProvider logs users in through an OpenID Connect provider. The discovery document and the
+// signing keys of the provider are fetched when first needed.
+type oidcProvider struct {
+	config      OIDCConfig
+	redirectURL string
+	client      *http.Client
+	now         func() time.Time
+
+	mu          sync.Mutex
+	discovery   *oidcDiscovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+func newOIDCProvider(config OIDCConfig, redirectURL string) *oidcProvider {
+	return &oidcProvider{
+		config:      config,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+}
+
+func (p *oidcProvider) getJSON(url string, v interface{}) error {
+	resp, err := p.client.Get(url) // #nosec G107
+	if err != nil {
+		return errors.Wrapf(err, "error fetching %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("error fetching %s: %s", url, resp.Status)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(v), "error parsing %s", url)
+}
+
+// getDiscovery returns the discovery document of the provider.
+func (p *oidcProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	var discovery oidcDiscovery
+	url := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(url, &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(p.config.Issuer, "/") {
+		return nil, errors.Errorf(
+			"OIDC provider reports issuer %s instead of %s", discovery.Issuer, p.config.Issuer)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// key returns the public key with the given ID, fetching the keys of the provider again if it is
+// not known. An empty ID matches the only key of providers that have a single one.
+func (p *oidcProvider) key(kid string) (crypto.PublicKey, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lookup := func() crypto.PublicKey {
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key
+			}
+		}
+		return p.keys[kid]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	if p.now().Sub(p.keysFetched) < keysRefreshInterval {
+		return nil, errors.Errorf("unknown signing key %q", kid)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = p.getJSON(discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	p.keysFetched = p.now()
+	p.keys = make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			p.keys[jwk.Kid] = key
+		}
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown signing key %q", kid)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC key is not on its curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, errors.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// verifySignature checks the signature of a JSON Web Token with the given algorithm.
+func verifySignature(key crypto.PublicKey, alg string, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported signing algorithm %s", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' {
+			return errors.Errorf("%s token signed with an RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(signature) != 2*size {
+			return errors.Errorf("invalid %s signature", alg)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key")
+	}
+}
+
+// verify checks the signature and the claims of an ID token issued for one of the audiences and
+// returns its claims. The nonce is only checked if it is not empty.
+func (p *oidcProvider) verify(
+	rawToken string, audiences []string, nonce string,
+) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed ID token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token header")
+	}
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, errors.Wrap(err, "malformed ID token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token signature")
+	}
+	key, err := p.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err = verifySignature(
+		key, header.Alg, []byte(parts[0]+"."+parts[1]), signature,
+	); err != nil {
+		return nil, errors.Wrap(err, "invalid ID token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed ID token payload")
+	}
+	var claims map[string]interface{}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "malformed ID token payload")
+	}
+
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	now := p.now()
+	if iss, _ := claims["iss"].(string); iss != discovery.Issuer {
+		return nil, errors.Errorf("ID token issued by %q", iss)
+	}
+	if !audienceMatches(claims["aud"], audiences) {
+		return nil, errors.New("ID token issued for another audience")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("ID token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok &&
+		now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("ID token not valid yet")
+	}
+	if nonce != "" {
+		if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+			return nil, errors.New("ID token nonce does not match")
+		}
+	}
+	return claims, nil
+}
+
+func audienceMatches(aud interface{}, audiences []string) bool {
+	var tokenAudiences []string
+	switch aud := aud.(type) {
+	case string:
+		tokenAudiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				tokenAudiences = append(tokenAudiences, s)
+			}
+		}
+	}
+	for _, a := range tokenAudiences {
+		for _, expected := range audiences {
+			if a == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// identity returns the user that the claims of an ID token describe.
+func (p *oidcProvider) identity(claims map[string]interface{}) (identity, error) {
+	username, _ := claims[p.config.usernameClaim()].(string)
+	if username == "" {
+		return identity{}, errors.Errorf("ID token has no %s claim", p.config.usernameClaim())
+	}
+	// Providers may let users enter any email address, so only verified ones identify them.
+	if p.config.usernameClaim() == "email" && claims["email_verified"] != true {
+		return identity{}, errors.New("ID token has an unverified email address")
+	}
+	result := identity{username: username}
+	switch groups := claims[p.config.groupsClaim()].(type) {
+	case string:
+		result.groups = []string{groups}
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				result.groups = append(result.groups, s)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (p *oidcProvider) oauth2Config() (*oauth2.Config, error) {
+	discovery, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		RedirectURL: p.redirectURL,
+		Scopes:      append([]string{"openid"}, p.config.Scopes...),
+	}, nil
+}
+
+// authCodeURL returns the URL of the provider that users log in at.
+func (p *oidcProvider) authCodeURL(state, nonce string) (string, error) {
+	config, err := p.oauth2Config()
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+// exchange redeems the authorization code that the provider redirected a user back with.
+func (p *oidcProvider) exchange(ctx context.Context, code, nonce string) (identity, error) {
+	config, err := p.oauth2Config()
+	if err != nil {
+		return identity{}, err
+	}
+	token, err := config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code)
+	if err != nil {
+		return identity{}, errors.Wrap(err, "error redeeming authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return identity{}, errors.New("OIDC provider returned no ID token")
+	}
+	claims, err := p.verify(rawIDToken, []string{p.config.ClientID}, nonce)
+	if err != nil {
+		return identity{}, err
+	}
+	return p.identity(claims)
+}
+
+// exchangeIDToken returns the user of an ID token that a client obtained from the provider itself.
+func (p *oidcProvider) exchangeIDToken(rawIDToken string) (identity, error) {
+	audiences := append([]string{p.config.ClientID}, p.config.ExchangeAudiences...)
+	claims, err := p.verify(rawIDToken, audiences, "")
+	if err != nil {
+		return identity{}, err
+	}
+	return p.identity(claims)
+}
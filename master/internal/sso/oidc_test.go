@@ -0,0 +1,130 @@
+package sso
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	issuer := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]jsonWebKey{"keys": {{
+			Kty: "RSA",
+			Kid: "key-1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	assert.NilError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NilError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	assert.NilError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCVerify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := newOIDCProvider(OIDCConfig{
+		Issuer:            issuer.server.URL,
+		ClientID:          "determined",
+		ExchangeAudiences: []string{"determined-cli"},
+	}, "https://det.example.com/sso/oidc/callback")
+	now := time.Now()
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":            issuer.server.URL,
+			"aud":            "determined",
+			"exp":            now.Add(time.Hour).Unix(),
+			"nonce":          "nonce-1",
+			"email":          "alice@example.com",
+			"groups":         []string{"ml", "admins"},
+			"email_verified": true,
+		}
+		for k, v := range changes {
+			c[k] = v
+		}
+		return c
+	}
+
+	claimsOK, err := p.verify(issuer.sign(t, "key-1", claims(nil)), []string{"determined"}, "nonce-1")
+	assert.NilError(t, err)
+	ident, err := p.identity(claimsOK)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ident.groups, []string{"ml", "admins"})
+	assert.Equal(t, ident.username, "alice@example.com")
+
+	for _, verified := range []interface{}{nil, false, "true"} {
+		_, err = p.identity(claims(map[string]interface{}{"email_verified": verified}))
+		assert.ErrorContains(t, err, "unverified email", "email_verified %v", verified)
+	}
+
+	ident, err = p.exchangeIDToken(issuer.sign(t, "key-1", claims(map[string]interface{}{
+		"aud": []string{"determined-cli"},
+	})))
+	assert.NilError(t, err)
+	assert.Equal(t, ident.username, "alice@example.com")
+
+	for name, token := range map[string]string{
+		"wrong issuer": issuer.sign(t, "key-1", claims(map[string]interface{}{
+			"iss": "https://evil.example.com",
+		})),
+		"wrong audience": issuer.sign(t, "key-1", claims(map[string]interface{}{"aud": "other"})),
+		"expired": issuer.sign(t, "key-1", claims(map[string]interface{}{
+			"exp": now.Add(-time.Hour).Unix(),
+		})),
+		"not valid yet": issuer.sign(t, "key-1", claims(map[string]interface{}{
+			"nbf": now.Add(time.Hour).Unix(),
+		})),
+		"wrong nonce": issuer.sign(t, "key-1", claims(map[string]interface{}{"nonce": "other"})),
+		"unknown key": issuer.sign(t, "key-2", claims(nil)),
+		"unsigned": "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(
+			[]byte(`{"iss":"`+issuer.server.URL+`","aud":"determined"}`)) + ".",
+	} {
+		_, err := p.verify(token, []string{"determined"}, "nonce-1")
+		assert.Assert(t, err != nil, name)
+	}
+
+	token := issuer.sign(t, "key-1", claims(nil))
+	tampered := token[:len(token)-4] + "AAAA"
+	_, err = p.verify(tampered, []string{"determined"}, "nonce-1")
+	assert.ErrorContains(t, err, "invalid ID token signature")
+}
@@ -0,0 +1,443 @@
+package sso
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/pkg/errors"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlMetadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	samlStatusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	samlBearer          = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	samlHTTPPostBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// Only SHA-2 based signatures are accepted.
+var (
+	samlSignatureAlgorithms = map[string]bool{
+		dsig.RSASHA256SignatureMethod: true,
+		dsig.RSASHA512SignatureMethod: true,
+	}
+	samlDigestAlgorithms = map[string]bool{
+		"http://www.w3.org/2001/04/xmlenc#sha256": true,
+		"http://www.w3.org/2001/04/xmlenc#sha512": true,
+	}
+)
+
+// samlProvider logs users in through a SAML 2.0 identity provider. Requests are sent with the
+// HTTP-Redirect binding and responses are received with the HTTP-POST binding; either the response
+// or the assertion in it must be signed with the configured certificate.
+type samlProvider struct {
+	config    SAMLConfig
+	entityID  string
+	acsURL    string
+	validator *dsig.ValidationContext
+	now       func() time.Time
+
+	// used holds the IDs of accepted assertions until they expire, so that they cannot be replayed.
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func newSAMLProvider(config SAMLConfig, entityID, acsURL string) (*samlProvider, error) {
+	certPEM, err := ioutil.ReadFile(config.IDPCertificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading SAML identity provider certificate")
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.Errorf("%s is not a PEM-encoded certificate", config.IDPCertificate)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing SAML identity provider certificate")
+	}
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
+		return nil, errors.New("SAML identity provider certificate must have an RSA key")
+	}
+	if config.EntityID != "" {
+		entityID = config.EntityID
+	}
+	return &samlProvider{
+		config:   config,
+		entityID: entityID,
+		acsURL:   acsURL,
+		validator: dsig.NewDefaultValidationContext(
+			&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}}),
+		now:  time.Now,
+		used: map[string]time.Time{},
+	}, nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// authnRequestURL returns the URL that sends a user to the identity provider with an
+// authentication request of the given ID.
+func (p *samlProvider) authnRequestURL(id, relayState string) (string, error) {
+	request := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" `+
+		`Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" `+
+		`ProtocolBinding="%s"><saml:Issuer>%s</saml:Issuer>`+
+		`<samlp:NameIDPolicy AllowCreate="true"/></samlp:AuthnRequest>`,
+		samlProtocolNamespace, samlAssertionNamespace, xmlEscape(id),
+		p.now().UTC().Format(time.RFC3339), xmlEscape(p.config.IDPSSOURL),
+		xmlEscape(p.acsURL), samlHTTPPostBinding, xmlEscape(p.entityID))
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err = w.Write([]byte(request)); err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"SAMLRequest": {base64.StdEncoding.EncodeToString(buf.Bytes())},
+		"RelayState":  {relayState},
+	}
+	separator := "?"
+	if strings.Contains(p.config.IDPSSOURL, "?") {
+		separator = "&"
+	}
+	return p.config.IDPSSOURL + separator + query.Encode(), nil
+}
+
+// metadata returns the service provider metadata of the master.
+func (p *samlProvider) metadata() []byte {
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<md:EntityDescriptor xmlns:md="%s" entityID="%s">`+
+		`<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" `+
+		`protocolSupportEnumeration="%s">`+
+		`<md:AssertionConsumerService Binding="%s" Location="%s" index="1"/>`+
+		`</md:SPSSODescriptor></md:EntityDescriptor>`+"\n",
+		samlMetadataNamespace, xmlEscape(p.entityID), samlProtocolNamespace,
+		samlHTTPPostBinding, xmlEscape(p.acsURL)))
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
+// parseXML parses a document and returns its root element. Documents with a DTD are rejected.
+func parseXML(data []byte) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, errors.Wrap(err, "malformed XML")
+	}
+	for _, token := range doc.Child {
+		if _, ok := token.(*etree.Directive); ok {
+			return nil, errors.New("XML documents with a DTD are not accepted")
+		}
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, errors.New("malformed XML: no root element")
+	}
+	return root, nil
+}
+
+// samlElements returns the child elements with the given namespace URI and local name.
+func samlElements(el *etree.Element, space, tag string) []*etree.Element {
+	var result []*etree.Element
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == space {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// samlElement returns the only child element with the given namespace URI and local name.
+func samlElement(el *etree.Element, space, tag string) (*etree.Element, error) {
+	switch elements := samlElements(el, space, tag); len(elements) {
+	case 1:
+		return elements[0], nil
+	case 0:
+		return nil, errors.Errorf("%s has no %s element", el.Tag, tag)
+	default:
+		return nil, errors.Errorf("%s has more than one %s element", el.Tag, tag)
+	}
+}
+
+// samlAttr returns the value of an attribute without a prefix.
+func samlAttr(el *etree.Element, key string) string {
+	for _, attr := range el.Attr {
+		if attr.Space == "" && attr.Key == key {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// samlText returns all character data directly inside an element. Unlike etree, it does not stop
+// at the first comment, so that a comment cannot cut a username short.
+func samlText(el *etree.Element) string {
+	var b strings.Builder
+	for _, token := range el.Child {
+		if data, ok := token.(*etree.CharData); ok {
+			b.WriteString(data.Data)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// checkSignatureAlgorithms returns an error if any signature in an element uses an algorithm that
+// is not accepted.
+func checkSignatureAlgorithms(el *etree.Element) error {
+	return etreeutils.NSFindIterate(el, dsig.Namespace, dsig.SignatureTag,
+		func(ctx etreeutils.NSContext, signature *etree.Element) error {
+			for _, method := range signature.FindElements(".//SignatureMethod") {
+				if algorithm := samlAttr(method, dsig.AlgorithmAttr); !samlSignatureAlgorithms[algorithm] {
+					return errors.Errorf("unsupported signature method %s", algorithm)
+				}
+			}
+			for _, method := range signature.FindElements(".//DigestMethod") {
+				if algorithm := samlAttr(method, dsig.AlgorithmAttr); !samlDigestAlgorithms[algorithm] {
+					return errors.Errorf("unsupported digest method %s", algorithm)
+				}
+			}
+			return nil
+		})
+}
+
+// verifySignature checks the enveloped signature of an element and returns the element as it was
+// signed, without the signature. The result is detached from the document, with the namespaces in
+// scope declared on it, so nothing outside of it can change what it means.
+func (p *samlProvider) verifySignature(el *etree.Element) (*etree.Element, error) {
+	if err := checkSignatureAlgorithms(el); err != nil {
+		return nil, err
+	}
+	ctx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed XML")
+	}
+	detached, err := etreeutils.NSDetatch(ctx, el)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed XML")
+	}
+	verified, err := p.validator.Validate(detached)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid signature of %s", el.Tag)
+	}
+	return verified, nil
+}
+
+func parseSAMLTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	return t, errors.Wrapf(err, "malformed time %q", s)
+}
+
+// parseResponse verifies a base64-encoded response to the authentication request with the given ID
+// and returns the user it describes.
+func (p *samlProvider) parseResponse(encoded, requestID string) (identity, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return identity{}, errors.Wrap(err, "malformed SAML response")
+	}
+	response, err := parseXML(data)
+	if err != nil {
+		return identity{}, err
+	}
+	if response.Tag != "Response" || response.NamespaceURI() != samlProtocolNamespace {
+		return identity{}, errors.New("not a SAML response")
+	}
+
+	// Only the elements that were verified are used from here on, so that signed elements cannot
+	// be moved around the document and unsigned ones cannot be slipped in next to them.
+	responseSigned := len(samlElements(response, dsig.Namespace, dsig.SignatureTag)) > 0
+	if responseSigned {
+		if response, err = p.verifySignature(response); err != nil {
+			return identity{}, err
+		}
+	}
+	if destination := samlAttr(response, "Destination"); destination != "" &&
+		destination != p.acsURL {
+		return identity{}, errors.Errorf("SAML response sent to %s", destination)
+	}
+	if samlAttr(response, "InResponseTo") != requestID {
+		return identity{}, errors.New("SAML response does not answer the authentication request")
+	}
+	status, err := samlElement(response, samlProtocolNamespace, "Status")
+	if err != nil {
+		return identity{}, err
+	}
+	statusCode, err := samlElement(status, samlProtocolNamespace, "StatusCode")
+	if err != nil {
+		return identity{}, err
+	}
+	if code := samlAttr(statusCode, "Value"); code != samlStatusSuccess {
+		return identity{}, errors.Errorf("SAML authentication failed: %s", code)
+	}
+	if len(samlElements(response, samlAssertionNamespace, "EncryptedAssertion")) > 0 {
+		return identity{}, errors.New("encrypted SAML assertions are not supported")
+	}
+	assertion, err := samlElement(response, samlAssertionNamespace, "Assertion")
+	if err != nil {
+		return identity{}, err
+	}
+	if !responseSigned || len(samlElements(assertion, dsig.Namespace, dsig.SignatureTag)) > 0 {
+		if assertion, err = p.verifySignature(assertion); err != nil {
+			return identity{}, err
+		}
+	}
+	return p.checkAssertion(assertion, requestID)
+}
+
+// checkAssertion checks the issuer, the subject and the conditions of a verified assertion and
+// returns the user it describes.
+func (p *samlProvider) checkAssertion(
+	assertion *etree.Element, requestID string,
+) (identity, error) {
+	now := p.now()
+	issuer, err := samlElement(assertion, samlAssertionNamespace, "Issuer")
+	if err != nil {
+		return identity{}, err
+	}
+	if p.config.IDPEntityID != "" && samlText(issuer) != p.config.IDPEntityID {
+		return identity{}, errors.Errorf("SAML assertion issued by %s", samlText(issuer))
+	}
+
+	subject, err := samlElement(assertion, samlAssertionNamespace, "Subject")
+	if err != nil {
+		return identity{}, err
+	}
+	var expiry time.Time
+	confirmations := samlElements(subject, samlAssertionNamespace, "SubjectConfirmation")
+	for _, confirmation := range confirmations {
+		if samlAttr(confirmation, "Method") != samlBearer {
+			continue
+		}
+		data, err := samlElement(confirmation, samlAssertionNamespace, "SubjectConfirmationData")
+		if err != nil {
+			return identity{}, err
+		}
+		notOnOrAfter, err := parseSAMLTime(samlAttr(data, "NotOnOrAfter"))
+		if err != nil {
+			return identity{}, err
+		}
+		if now.Add(-clockSkew).Before(notOnOrAfter) &&
+			samlAttr(data, "Recipient") == p.acsURL &&
+			(samlAttr(data, "InResponseTo") == "" || samlAttr(data, "InResponseTo") == requestID) {
+			expiry = notOnOrAfter
+			break
+		}
+	}
+	if expiry.IsZero() {
+		return identity{}, errors.New("SAML assertion has no valid bearer subject confirmation")
+	}
+
+	conditions := samlElements(assertion, samlAssertionNamespace, "Conditions")
+	if len(conditions) > 0 {
+		if err = p.checkConditions(conditions[0]); err != nil {
+			return identity{}, err
+		}
+	}
+
+	result := identity{}
+	if nameIDs := samlElements(subject, samlAssertionNamespace, "NameID"); len(nameIDs) == 1 {
+		result.username = samlText(nameIDs[0])
+	}
+	for _, statement := range samlElements(assertion, samlAssertionNamespace, "AttributeStatement") {
+		for _, attribute := range samlElements(statement, samlAssertionNamespace, "Attribute") {
+			values := samlElements(attribute, samlAssertionNamespace, "AttributeValue")
+			switch name := samlAttr(attribute, "Name"); {
+			case name == p.config.UsernameAttribute && len(values) > 0:
+				result.username = samlText(values[0])
+			case name == p.config.groupsAttribute():
+				for _, value := range values {
+					result.groups = append(result.groups, samlText(value))
+				}
+			}
+		}
+	}
+	if result.username == "" {
+		return identity{}, errors.New("SAML assertion does not name the user")
+	}
+
+	if err = p.markUsed(samlAttr(assertion, "ID"), expiry); err != nil {
+		return identity{}, err
+	}
+	return result, nil
+}
+
+func (p *samlProvider) checkConditions(conditions *etree.Element) error {
+	now := p.now()
+	if notBefore := samlAttr(conditions, "NotBefore"); notBefore != "" {
+		t, err := parseSAMLTime(notBefore)
+		if err != nil {
+			return err
+		}
+		if now.Add(clockSkew).Before(t) {
+			return errors.New("SAML assertion not valid yet")
+		}
+	}
+	if notOnOrAfter := samlAttr(conditions, "NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := parseSAMLTime(notOnOrAfter)
+		if err != nil {
+			return err
+		}
+		if !now.Add(-clockSkew).Before(t) {
+			return errors.New("SAML assertion expired")
+		}
+	}
+	// Every audience restriction must include the master.
+	restrictions := samlElements(conditions, samlAssertionNamespace, "AudienceRestriction")
+	for _, restriction := range restrictions {
+		found := false
+		for _, audience := range samlElements(restriction, samlAssertionNamespace, "Audience") {
+			if samlText(audience) == p.entityID {
+				found = true
+			}
+		}
+		if !found {
+			return errors.New("SAML assertion issued for another audience")
+		}
+	}
+	return nil
+}
+
+// markUsed records the ID of an accepted assertion until it expires, failing if the assertion was
+// already used.
+func (p *samlProvider) markUsed(id string, expiry time.Time) error {
+	if id == "" {
+		return errors.New("SAML assertion has no ID")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for usedID, usedExpiry := range p.used {
+		if now.After(usedExpiry.Add(clockSkew)) {
+			delete(p.used, usedID)
+		}
+	}
+	if _, ok := p.used[id]; ok {
+		return errors.New("SAML assertion was already used")
+	}
+	p.used[id] = expiry
+	return nil
+}
@@ -0,0 +1,207 @@
+package sso
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"gotest.tools/assert"
+)
+
+const (
+	testACSURL   = "https://det.example.com/sso/saml/acs"
+	testEntityID = "https://det.example.com/sso/saml/metadata"
+	testIDP      = "https://idp.example.com"
+)
+
+func TestParseXML(t *testing.T) {
+	root, err := parseXML([]byte(`<a:root xmlns:a="urn:a" xmlns:b="urn:b" ID="1" b:ID="2">` +
+		`<b:child>one<!-- comment -->two</b:child><a:child/></a:root>`))
+	assert.NilError(t, err)
+	assert.Equal(t, samlAttr(root, "ID"), "1")
+	assert.Equal(t, len(samlElements(root, "urn:a", "child")), 1)
+	child, err := samlElement(root, "urn:b", "child")
+	assert.NilError(t, err)
+	assert.Equal(t, samlText(child), "onetwo")
+
+	_, err = parseXML([]byte(`<!DOCTYPE a [<!ENTITY e "e">]><a>&e;</a>`))
+	assert.Assert(t, err != nil)
+}
+
+// testIDPSigner signs SAML elements like an identity provider. It implements dsig.X509KeyStore.
+type testIDPSigner struct {
+	key  *rsa.PrivateKey
+	cert []byte
+	hash crypto.Hash
+}
+
+func (s *testIDPSigner) GetKeyPair() (*rsa.PrivateKey, []byte, error) {
+	return s.key, s.cert, nil
+}
+
+func newTestSAMLProvider(t *testing.T) (*samlProvider, *testIDPSigner) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	path := filepath.Join(t.TempDir(), "idp.pem")
+	assert.NilError(t, ioutil.WriteFile(
+		path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	p, err := newSAMLProvider(SAMLConfig{
+		IDPSSOURL:      testIDP + "/sso",
+		IDPEntityID:    testIDP,
+		IDPCertificate: path,
+	}, testEntityID, testACSURL)
+	assert.NilError(t, err)
+	return p, &testIDPSigner{key: key, cert: der, hash: crypto.SHA256}
+}
+
+// sign returns the element with an enveloped signature inserted after its issuer.
+func (s *testIDPSigner) sign(t *testing.T, element string) string {
+	el, err := parseXML([]byte(element))
+	assert.NilError(t, err)
+	ctx := dsig.NewDefaultSigningContext(s)
+	ctx.Hash = s.hash
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	signature, err := ctx.ConstructSignature(el, true)
+	assert.NilError(t, err)
+	el.InsertChildAt(1, signature)
+
+	doc := etree.NewDocument()
+	doc.SetRoot(el)
+	signed, err := doc.WriteToString()
+	assert.NilError(t, err)
+	return signed
+}
+
+func testAssertion(id, requestID, username string, now time.Time) string {
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>`+
+		`<saml:Subject><saml:NameID>%s</saml:NameID>`+
+		`<saml:SubjectConfirmation Method="%s"><saml:SubjectConfirmationData `+
+		`NotOnOrAfter="%s" Recipient="%s" InResponseTo="%s"/></saml:SubjectConfirmation>`+
+		`</saml:Subject><saml:Conditions NotBefore="%s" NotOnOrAfter="%s">`+
+		`<saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction>`+
+		`</saml:Conditions><saml:AttributeStatement><saml:Attribute Name="groups">`+
+		`<saml:AttributeValue>ml</saml:AttributeValue>`+
+		`<saml:AttributeValue>admins</saml:AttributeValue>`+
+		`</saml:Attribute></saml:AttributeStatement></saml:Assertion>`,
+		samlAssertionNamespace, id, now.Format(time.RFC3339), testIDP, username, samlBearer,
+		now.Add(5*time.Minute).Format(time.RFC3339), testACSURL, requestID,
+		now.Add(-time.Minute).Format(time.RFC3339), now.Add(5*time.Minute).Format(time.RFC3339),
+		testEntityID)
+}
+
+func testResponse(id, requestID, assertion string) string {
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="%s" xmlns:saml="%s" ID="%s" `+
+		`Version="2.0" Destination="%s" InResponseTo="%s"><saml:Issuer>%s</saml:Issuer>`+
+		`<samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`,
+		samlProtocolNamespace, samlAssertionNamespace, id, testACSURL, requestID, testIDP,
+		samlStatusSuccess, assertion)
+}
+
+func encodeResponse(response string) string {
+	return base64.StdEncoding.EncodeToString([]byte(response))
+}
+
+func TestSAMLParseResponse(t *testing.T) {
+	p, idp := newTestSAMLProvider(t)
+	now := time.Now().UTC()
+
+	assertion := idp.sign(t, testAssertion("assertion-1", "request-1", "alice", now))
+	response := encodeResponse(testResponse("response-1", "request-1", assertion))
+	ident, err := p.parseResponse(response, "request-1")
+	assert.NilError(t, err)
+	assert.Equal(t, ident.username, "alice")
+	assert.DeepEqual(t, ident.groups, []string{"ml", "admins"})
+
+	_, err = p.parseResponse(response, "request-1")
+	assert.ErrorContains(t, err, "already used")
+
+	_, err = p.parseResponse(response, "request-2")
+	assert.ErrorContains(t, err, "does not answer")
+
+	// Either the response or the assertion may be signed.
+	signedResponse := idp.sign(t, testResponse("response-2", "request-1",
+		testAssertion("assertion-2", "request-1", "alice", now)))
+	ident, err = p.parseResponse(encodeResponse(signedResponse), "request-1")
+	assert.NilError(t, err)
+	assert.Equal(t, ident.username, "alice")
+
+	unsigned := testAssertion("assertion-3", "request-1", "alice", now)
+	_, err = p.parseResponse(encodeResponse(testResponse("response-3", "request-1", unsigned)),
+		"request-1")
+	assert.ErrorContains(t, err, "invalid signature of Assertion")
+
+	expired := idp.sign(t, testAssertion("assertion-4", "request-1", "alice", now.Add(-time.Hour)))
+	_, err = p.parseResponse(encodeResponse(testResponse("response-4", "request-1", expired)),
+		"request-1")
+	assert.ErrorContains(t, err, "no valid bearer subject confirmation")
+
+	idp.hash = crypto.SHA1
+	sha1Signed := idp.sign(t, testAssertion("assertion-5", "request-1", "alice", now))
+	idp.hash = crypto.SHA256
+	_, err = p.parseResponse(encodeResponse(testResponse("response-5", "request-1", sha1Signed)),
+		"request-1")
+	assert.ErrorContains(t, err, "unsupported signature method")
+}
+
+func TestSAMLParseResponseForgeries(t *testing.T) {
+	p, idp := newTestSAMLProvider(t)
+	now := time.Now().UTC()
+	signed := func(id string) string {
+		return idp.sign(t, testAssertion(id, "request-1", "alice", now))
+	}
+	forged := func(id string) string {
+		return testAssertion(id, "request-1", "admin", now)
+	}
+	// wrap puts an element inside the subject of an assertion, where it is not checked.
+	wrap := func(assertion, inner string) string {
+		return strings.Replace(assertion, "<saml:Subject>", "<saml:Subject>"+inner, 1)
+	}
+	signedResponse := idp.sign(t, testResponse("response-1", "request-1", forged("assertion-1")))
+
+	for name, response := range map[string]string{
+		"tampered assertion": testResponse("response-1", "request-1", strings.Replace(
+			signed("assertion-2"), "<saml:NameID>alice<", "<saml:NameID>admin<", 1)),
+		"tampered response": strings.Replace(
+			signedResponse, "<saml:NameID>admin<", "<saml:NameID>root<", 1),
+		"assertion next to a signed one": testResponse("response-1", "request-1",
+			forged("assertion-3")+signed("assertion-4")),
+		"assertion injected into a signed response": strings.Replace(signedResponse,
+			"</samlp:Response>", forged("assertion-5")+"</samlp:Response>", 1),
+		"assertion wrapping a signed one": testResponse("response-1", "request-1",
+			wrap(forged("assertion-6"), signed("assertion-7"))),
+		"assertion with the ID of a signed one": testResponse("response-1", "request-1",
+			wrap(forged("assertion-8"), strings.Replace(
+				signed("assertion-8"), `ID="assertion-8"`, `ID="original"`, 1))),
+		"response wrapping a signed one": testResponse("response-2", "request-1",
+			wrap(forged("assertion-9"), signedResponse)),
+		"comment in the username": testResponse("response-1", "request-1", strings.Replace(
+			idp.sign(t, testAssertion("assertion-10", "request-1", "admin.evil.example.com", now)),
+			"<saml:NameID>admin.", "<saml:NameID>admin<!---->.", 1)),
+	} {
+		_, err := p.parseResponse(encodeResponse(response), "request-1")
+		assert.Assert(t, err != nil, name)
+	}
+}
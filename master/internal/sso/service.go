@@ -0,0 +1,357 @@
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// loginTimeout is how long users have to log in at the identity provider.
+	loginTimeout = 10 * time.Minute
+	// stateCookie binds an OIDC login to the browser that started it.
+	stateCookie = "sso_state"
+	// defaultRedirect is where users land after logging in, unless they asked for another page.
+	defaultRedirect = "/det/"
+)
+
+// identity is a user as reported by an identity provider.
+type identity struct {
+	username string
+	groups   []string
+}
+
+// pendingLogin is a login that the master sent to an identity provider.
+type pendingLogin struct {
+	nonce    string
+	redirect string
+	expiry   time.Time
+}
+
+// Service logs users in through the configured identity providers and exchanges their identities
+// for Determined sessions.
+type Service struct {
+	db     *db.PgDB
+	config Config
+	oidc   *oidcProvider
+	saml   *samlProvider
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// New creates a single sign-on service.
+func New(db *db.PgDB, config Config) (*Service, error) {
+	s := &Service{db: db, config: config, pending: map[string]pendingLogin{}}
+	if config.OIDC != nil {
+		s.oidc = newOIDCProvider(*config.OIDC, config.url("/sso/oidc/callback"))
+	}
+	if config.SAML != nil {
+		saml, err := newSAMLProvider(
+			*config.SAML, config.url("/sso/saml/metadata"), config.url("/sso/saml/acs"))
+		if err != nil {
+			return nil, err
+		}
+		s.saml = saml
+	}
+
+	roles, err := db.Roles()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		known[role.Name] = true
+	}
+	for group, role := range config.GroupRoles {
+		if !known[role] {
+			log.Warnf("single sign-on maps group %s to unknown role %s", group, role)
+		}
+	}
+	return s, nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "id-" + hex.EncodeToString(b), nil
+}
+
+// checkRedirect returns the page to send a user to after logging in. It must be a page of the
+// master or, for the command-line tools, a port on the local host.
+func checkRedirect(redirect string) (target string, local bool, err error) {
+	if redirect == "" {
+		return defaultRedirect, false, nil
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return "", false, echo.NewHTTPError(http.StatusBadRequest, "invalid redirect")
+	}
+	switch {
+	case u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/") &&
+		!strings.HasPrefix(redirect, "//") && !strings.HasPrefix(redirect, "/\\"):
+		return redirect, false, nil
+	case u.Scheme == "http" && u.Port() != "" &&
+		(u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1"):
+		return redirect, true, nil
+	default:
+		return "", false, echo.NewHTTPError(
+			http.StatusBadRequest, "redirect must be a page of the master or of the local host")
+	}
+}
+
+// startLogin records a login that is sent to an identity provider under the given key.
+func (s *Service) startLogin(key string, login pendingLogin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, p := range s.pending {
+		if now.After(p.expiry) {
+			delete(s.pending, k)
+		}
+	}
+	login.expiry = now.Add(loginTimeout)
+	s.pending[key] = login
+}
+
+// finishLogin returns and forgets the login with the given key.
+func (s *Service) finishLogin(key string) (pendingLogin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	login, ok := s.pending[key]
+	delete(s.pending, key)
+	if !ok || time.Now().After(login.expiry) {
+		return pendingLogin{}, echo.NewHTTPError(
+			http.StatusBadRequest, "unknown or expired login; please log in again")
+	}
+	return login, nil
+}
+
+// checkSSOUser returns an error if a user may not log in through single sign-on. Only remote users
+// can, so that an identity provider cannot be used to take over local accounts such as admin.
+func checkSSOUser(user *model.User) error {
+	switch {
+	case !user.Remote:
+		return echo.NewHTTPError(http.StatusForbidden,
+			fmt.Sprintf("user %s is not a single sign-on user", user.Username))
+	case !user.Active:
+		return echo.NewHTTPError(
+			http.StatusForbidden, fmt.Sprintf("user %s is not active", user.Username))
+	}
+	return nil
+}
+
+// user returns the Determined user of an identity, creating it if that is allowed, and applies the
+// roles that the groups of the identity map to.
+func (s *Service) user(ident identity) (*model.User, error) {
+	username := strings.ToLower(strings.TrimSpace(ident.username))
+	user, err := s.db.UserByUsername(username)
+	if err == db.ErrNotFound && s.config.AutoProvisionUsers {
+		log.Infof("creating user %s on first single sign-on login", username)
+		err = s.db.AddUser(&model.User{Username: username, Active: true, Remote: true}, nil)
+		if err != nil && err != db.ErrDuplicateRecord {
+			return nil, err
+		}
+		user, err = s.db.UserByUsername(username)
+	}
+	switch {
+	case err == db.ErrNotFound:
+		return nil, echo.NewHTTPError(
+			http.StatusForbidden, fmt.Sprintf("user %s does not exist", username))
+	case err != nil:
+		return nil, err
+	}
+	if err = checkSSOUser(user); err != nil {
+		return nil, err
+	}
+
+	if len(s.config.GroupRoles) > 0 {
+		roles := map[string]bool{}
+		for _, group := range ident.groups {
+			if role, ok := s.config.GroupRoles[group]; ok {
+				roles[role] = true
+			}
+		}
+		roleNames := make([]string, 0, len(roles))
+		for role := range roles {
+			roleNames = append(roleNames, role)
+		}
+		sort.Strings(roleNames)
+		if err = s.db.SetSSORoleAssignments(user.ID, roleNames); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// login starts a session for an identity and sends the browser on to the page it asked for. Local
+// pages receive the token in the query string, while pages of the master rely on a cookie.
+func (s *Service) login(c echo.Context, ident identity, redirect string) error {
+	user, err := s.user(ident)
+	if err != nil {
+		return err
+	}
+	token, err := s.db.StartUserSession(user)
+	if err != nil {
+		return err
+	}
+	target, local, err := checkRedirect(redirect)
+	if err != nil {
+		return err
+	}
+	if local {
+		u, _ := url.Parse(target)
+		query := u.Query()
+		query.Set("token", token)
+		u.RawQuery = query.Encode()
+		return c.Redirect(http.StatusSeeOther, u.String())
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     "auth",
+		Value:    token,
+		Path:     "/",
+		Expires:  time.Now().Add(db.SessionDuration),
+		HttpOnly: false,
+	})
+	return c.Redirect(http.StatusSeeOther, target)
+}
+
+func (s *Service) getProviders(c echo.Context) (interface{}, error) {
+	type provider struct {
+		Name     string `json:"name"`
+		LoginURL string `json:"login_url"`
+	}
+	providers := []provider{}
+	if s.oidc != nil {
+		providers = append(providers, provider{Name: "oidc", LoginURL: "/sso/oidc/login"})
+	}
+	if s.saml != nil {
+		providers = append(providers, provider{Name: "saml", LoginURL: "/sso/saml/login"})
+	}
+	return providers, nil
+}
+
+func (s *Service) getOIDCLogin(c echo.Context) error {
+	redirect := c.QueryParam("redirect")
+	if _, _, err := checkRedirect(redirect); err != nil {
+		return err
+	}
+	state, err := randomID()
+	if err != nil {
+		return err
+	}
+	nonce, err := randomID()
+	if err != nil {
+		return err
+	}
+	authURL, err := s.oidc.authCodeURL(state, nonce)
+	if err != nil {
+		return errors.Wrap(err, "error contacting the OIDC provider")
+	}
+	s.startLogin(state, pendingLogin{nonce: nonce, redirect: redirect})
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/sso/",
+		Expires:  time.Now().Add(loginTimeout),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (s *Service) getOIDCCallback(c echo.Context) error {
+	if errorCode := c.QueryParam("error"); errorCode != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized,
+			"OIDC login failed: "+errorCode+" "+c.QueryParam("error_description"))
+	}
+	state := c.QueryParam("state")
+	cookie, err := c.Cookie(stateCookie)
+	if err != nil || state == "" || cookie.Value != state {
+		return echo.NewHTTPError(http.StatusBadRequest, "OIDC login was started in another browser")
+	}
+	login, err := s.finishLogin(state)
+	if err != nil {
+		return err
+	}
+	ident, err := s.oidc.exchange(c.Request().Context(), c.QueryParam("code"), login.nonce)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	return s.login(c, ident, login.redirect)
+}
+
+func (s *Service) postOIDCToken(c echo.Context) (interface{}, error) {
+	var params struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := c.Bind(&params); err != nil || params.IDToken == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "id_token must be set")
+	}
+	ident, err := s.oidc.exchangeIDToken(params.IDToken)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	user, err := s.user(ident)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.db.StartUserSession(user)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Token string `json:"token"`
+	}{Token: token}, nil
+}
+
+func (s *Service) getSAMLLogin(c echo.Context) error {
+	redirect := c.QueryParam("redirect")
+	if _, _, err := checkRedirect(redirect); err != nil {
+		return err
+	}
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+	// The relay state comes back with the response, which the identity provider posts from its
+	// own site, so it carries the request ID rather than a cookie.
+	authURL, err := s.saml.authnRequestURL(id, id)
+	if err != nil {
+		return err
+	}
+	s.startLogin(id, pendingLogin{redirect: redirect})
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (s *Service) postSAMLACS(c echo.Context) error {
+	requestID := c.FormValue("RelayState")
+	login, err := s.finishLogin(requestID)
+	if err != nil {
+		return err
+	}
+	ident, err := s.saml.parseResponse(c.FormValue("SAMLResponse"), requestID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	return s.login(c, ident, login.redirect)
+}
+
+func (s *Service) getSAMLMetadata(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/samlmetadata+xml", s.saml.metadata())
+}
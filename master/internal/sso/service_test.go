@@ -0,0 +1,20 @@
+package sso
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestCheckSSOUser(t *testing.T) {
+	assert.NilError(t, checkSSOUser(&model.User{Username: "alice", Active: true, Remote: true}))
+	assert.ErrorContains(t,
+		checkSSOUser(&model.User{Username: "admin", Active: true, Admin: true}),
+		"not a single sign-on user")
+	assert.ErrorContains(t,
+		checkSSOUser(&model.User{Username: "bob", Active: true}), "not a single sign-on user")
+	assert.ErrorContains(t,
+		checkSSOUser(&model.User{Username: "carol", Remote: true}), "not active")
+}
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/command"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// tenantRouteParams are the parameters of echo routes that identify resources of tenants.
+var tenantRouteParams = map[string]db.TenantResource{
+	"experiment_id":   db.TenantExperiment,
+	"trial_id":        db.TenantTrial,
+	"checkpoint_uuid": db.TenantCheckpoint,
+	"task_id":         db.TenantTask,
+	"username":        db.TenantUser,
+}
+
+// tenantWideRoutes are the echo routes that list the resources of every tenant, which only users
+// who see every tenant may get in strict tenancy. The users of tenants list them through the API.
+var tenantWideRoutes = map[string]bool{
+	"/experiment-list":      true,
+	"/experiment-summaries": true,
+	"/experiments":          true,
+	"/checkpoints":          true,
+	"/tasks":                true,
+	"/users":                true,
+	"/logs":                 true,
+}
+
+// tenantWidePrefixes are the prefixes of the echo routes that reach the resources of every tenant
+// by any method.
+var tenantWidePrefixes = []string{
+	"/resources/",
+	"/commands",
+	"/notebooks",
+	"/shells",
+	"/tensorboard",
+	"/code-servers",
+	"/ray-clusters",
+	"/api/2.0/mlflow/",
+}
+
+// tenantScope returns the tenants whose resources the user may see.
+func (m *Master) tenantScope(user *model.User) model.TenantScope {
+	return model.NewTenantScope(user, m.config.Tenancy.Strict)
+}
+
+// tenancyMiddleware hides the resources of other tenants from the users of tenants on the echo
+// routes. It follows the authentication of the routes, and is only used in strict tenancy.
+func (m *Master) tenancyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := c.(*detContext.DetContext).MustGetUser()
+		scope := m.tenantScope(&user)
+		if scope.All {
+			return next(c)
+		}
+
+		path := c.Path()
+		wide := tenantWideRoutes[path] && c.Request().Method == http.MethodGet
+		for _, prefix := range tenantWidePrefixes {
+			wide = wide || strings.HasPrefix(path, prefix)
+		}
+		if wide {
+			return echo.NewHTTPError(http.StatusForbidden,
+				fmt.Sprintf("%s is not available to the users of tenants", path))
+		}
+
+		for _, name := range c.ParamNames() {
+			resource, ok := tenantRouteParams[name]
+			if !ok {
+				continue
+			}
+			var id interface{} = c.Param(name)
+			if resource == db.TenantExperiment || resource == db.TenantTrial {
+				n, err := strconv.Atoi(c.Param(name))
+				if err != nil {
+					// Leave malformed IDs to the handler.
+					continue
+				}
+				id = n
+			}
+			tenantID, err := m.db.ResourceTenant(resource, id)
+			switch {
+			case errors.Cause(err) == db.ErrNotFound:
+				continue
+			case err != nil:
+				return err
+			case !scope.Contains(tenantID):
+				return echo.NewHTTPError(http.StatusNotFound,
+					fmt.Sprintf("%s not found: %s", resource, c.Param(name)))
+			}
+		}
+		return next(c)
+	}
+}
+
+// authorizeProxy returns an error unless the user of a request may reach the services of the
+// tenant with the given ID through the proxy.
+func (m *Master) authorizeProxy(c echo.Context, tenantID *int) error {
+	user := c.(*detContext.DetContext).MustGetUser()
+	if !m.tenantScope(&user).Contains(tenantID) {
+		return echo.NewHTTPError(http.StatusNotFound,
+			fmt.Sprintf("service not found: %s", c.Param("service")))
+	}
+	return nil
+}
+
+// checkResourcePoolTenant returns an error unless the tasks created in a workspace may use a
+// resource pool. In strict tenancy, they may only use the pools of the tenant of the workspace, or
+// the pools of no tenant if the workspace belongs to none. An empty pool is the default pool for
+// tasks of that many slots.
+func (m *Master) checkResourcePoolTenant(workspace *model.Workspace, pool string, slots int) error {
+	if !m.config.Tenancy.Strict {
+		return nil
+	}
+	if pool == "" {
+		if slots == 0 {
+			pool = sproto.GetDefaultCPUResourcePool(m.system)
+		} else {
+			pool = sproto.GetDefaultGPUResourcePool(m.system)
+		}
+	}
+	tenants, err := m.db.ResourcePoolTenants()
+	if err != nil {
+		return err
+	}
+	var tenantID *int
+	if id, ok := tenants[pool]; ok {
+		tenantID = &id
+	}
+	if !(model.TenantScope{TenantID: workspace.TenantID}).Contains(tenantID) {
+		return errors.Errorf(
+			"resource pool %s cannot be used from workspace %s", pool, workspace.Name)
+	}
+	return nil
+}
+
+// tenantScope returns the tenants whose resources the caller of a request may see.
+func (a *apiServer) tenantScope(ctx context.Context) (model.TenantScope, error) {
+	return grpcutil.GetTenantScope(ctx, a.m.db, a.m.config.Tenancy.Strict)
+}
+
+// tenantProjects returns the IDs of the projects that the caller of a request may see, or nil if
+// it may see every project.
+func (a *apiServer) tenantProjects(ctx context.Context) (map[int32]bool, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil || scope.All {
+		return nil, err
+	}
+	ids, err := a.m.db.TenantProjects(scope.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	projects := make(map[int32]bool, len(ids))
+	for _, id := range ids {
+		projects[int32(id)] = true
+	}
+	return projects, nil
+}
+
+// projectsExpr returns the comma-separated IDs of projects for queries to filter by, or "" if
+// projects is nil.
+func projectsExpr(projects map[int32]bool) string {
+	if projects == nil {
+		return ""
+	}
+	if len(projects) == 0 {
+		// No project has the ID 0, and an empty list would not filter at all.
+		return "0"
+	}
+	ids := make([]string, 0, len(projects))
+	for id := range projects {
+		ids = append(ids, strconv.Itoa(int(id)))
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// tenantProjectsExpr is projectsExpr for the projects that the caller of a request may see.
+func (a *apiServer) tenantProjectsExpr(ctx context.Context) (string, error) {
+	projects, err := a.tenantProjects(ctx)
+	if err != nil {
+		return "", err
+	}
+	return projectsExpr(projects), nil
+}
+
+// checkTenantProject returns a NotFound error unless the caller of a request may see the project
+// with the given ID, or the default project if it is zero.
+func (a *apiServer) checkTenantProject(ctx context.Context, kind string, projectID int32) error {
+	projects, err := a.tenantProjects(ctx)
+	if err != nil || projects == nil {
+		return err
+	}
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	if !projects[projectID] {
+		return status.Errorf(codes.NotFound, "%s not found", kind)
+	}
+	return nil
+}
+
+// inTenantProjects limits a request to list or search the commands, notebooks, shells,
+// TensorBoards, code servers, Ray clusters or servings of their manager to those in the projects
+// that the caller of the request may see.
+func (a *apiServer) inTenantProjects(
+	ctx context.Context, req actor.Message,
+) (actor.Message, error) {
+	projects, err := a.tenantProjects(ctx)
+	if err != nil || projects == nil {
+		return req, err
+	}
+	return command.InProjects{Request: req, Projects: projects}, nil
+}
+
+// tenantResourcePools returns whether the caller of a request may see each resource pool: those of
+// its tenant, or those of no tenant. It returns nil if the caller may see every pool.
+func (a *apiServer) tenantResourcePools(ctx context.Context) (func(pool string) bool, error) {
+	scope, err := a.tenantScope(ctx)
+	if err != nil || scope.All {
+		return nil, err
+	}
+	tenants, err := a.m.db.ResourcePoolTenants()
+	if err != nil {
+		return nil, err
+	}
+	return func(pool string) bool {
+		if id, ok := tenants[pool]; ok {
+			return scope.Contains(&id)
+		}
+		return scope.Contains(nil)
+	}, nil
+}
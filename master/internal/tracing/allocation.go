@@ -0,0 +1,72 @@
+package tracing
+
+// AllocationTrace traces how a task waits for resources and then for its containers to run. It
+// records an "allocate" span from the request for resources until they are allocated and a "start
+// containers" span from then until every container runs. A nil *AllocationTrace does nothing.
+type AllocationTrace struct {
+	parent     SpanContext
+	allocate   *Span
+	start      *Span
+	containers int
+	running    map[string]bool
+}
+
+// StartAllocation starts tracing an allocation of resources to a task as part of the trace of the
+// parent span. It returns nil if the trace is not recorded.
+func StartAllocation(parent SpanContext, taskID string) *AllocationTrace {
+	allocate := StartFrom(parent, "allocate")
+	if allocate == nil {
+		return nil
+	}
+	allocate.SetAttribute("task.id", taskID)
+	return &AllocationTrace{parent: parent, allocate: allocate, running: make(map[string]bool)}
+}
+
+// SpanContext returns the span context that resource managers continue the trace from.
+func (a *AllocationTrace) SpanContext() SpanContext {
+	if a == nil {
+		return SpanContext{}
+	}
+	return a.allocate.Context()
+}
+
+// Allocated records that the resources of the task have been allocated to a number of containers.
+func (a *AllocationTrace) Allocated(resourcePool string, containers int) {
+	if a == nil || a.start != nil {
+		return
+	}
+	a.allocate.SetAttribute("resource_pool", resourcePool)
+	a.allocate.SetAttribute("containers", containers)
+	a.allocate.End()
+	a.containers = containers
+	a.start = StartFrom(a.parent, "start containers")
+	a.start.SetAttribute("containers", containers)
+}
+
+// ContainerState records that a container of the task changed its state.
+func (a *AllocationTrace) ContainerState(containerID, state string) {
+	if a == nil || a.start == nil {
+		return
+	}
+	a.start.AddEvent(containerID + " " + state)
+	if state == "RUNNING" {
+		a.running[containerID] = true
+		if len(a.running) >= a.containers {
+			a.start.End()
+		}
+	}
+}
+
+// End ends the spans of the allocation that are still open, marking them as failed with err if it
+// is not nil.
+func (a *AllocationTrace) End(err error) {
+	if a == nil {
+		return
+	}
+	a.allocate.SetError(err)
+	a.allocate.End()
+	if a.start != nil {
+		a.start.SetError(err)
+		a.start.End()
+	}
+}
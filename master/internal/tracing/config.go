@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"net/url"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config configures the export of the traces of the master to an OpenTelemetry collector.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP receiver of the collector, e.g.
+	// "http://otel-collector:4318". Spans are exported to its /v1/traces path. Tracing is
+	// disabled if it is empty.
+	Endpoint string `json:"endpoint"`
+	// Headers are sent with every export, e.g. to authenticate with the collector.
+	Headers map[string]string `json:"headers"`
+	// ServiceName is the service.name resource attribute of the spans.
+	ServiceName string `json:"service_name"`
+	// SampleRate is the fraction of traces that are recorded. Traces started by callers that
+	// propagate a trace context are recorded if the callers record them.
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// DefaultConfig returns the default configuration, which disables tracing.
+func DefaultConfig() Config {
+	return Config{ServiceName: "determined-master", SampleRate: 1}
+}
+
+// Enabled returns true if traces are exported.
+func (c Config) Enabled() bool {
+	return c.Endpoint != ""
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	if len(c.Headers) > 0 {
+		headers := make(map[string]string, len(c.Headers))
+		for key := range c.Headers {
+			headers[key] = "********"
+		}
+		c.Headers = headers
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	if !c.Enabled() {
+		return nil
+	}
+	endpoint, err := url.Parse(c.Endpoint)
+	return []error{
+		check.True(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") &&
+			endpoint.Host != "", "tracing.endpoint must be an http or https URL"),
+		check.NotEmpty(c.ServiceName, "tracing.service_name must be set"),
+		check.True(c.SampleRate >= 0 && c.SampleRate <= 1,
+			"tracing.sample_rate must be between 0 and 1"),
+	}
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The types below are the JSON encoding of OTLP export requests, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanData `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	spanData struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		ParentSpanID      string      `json:"parentSpanId,omitempty"`
+		Name              string      `json:"name"`
+		Kind              SpanKind    `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []keyValue  `json:"attributes,omitempty"`
+		Events            []eventData `json:"events,omitempty"`
+		Status            status      `json:"status"`
+	}
+	eventData struct {
+		TimeUnixNano string `json:"timeUnixNano"`
+		Name         string `json:"name"`
+	}
+	status struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// statusError is the OTLP status code of failed spans.
+const statusError = 2
+
+func (t *Tracer) request(spans []*Span) exportRequest {
+	data := make([]spanData, 0, len(spans))
+	for _, s := range spans {
+		data = append(data, s.data())
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{
+			attribute("service.name", t.config.ServiceName),
+		}},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "github.com/determined-ai/determined/master"},
+			Spans: data,
+		}},
+	}}}
+}
+
+func (s *Span) data() spanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := spanData{
+		TraceID:           hex.EncodeToString(s.sc.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.sc.SpanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+	}
+	if s.parent != (SpanID{}) {
+		d.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	keys := make([]string, 0, len(s.attributes))
+	for key := range s.attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		d.Attributes = append(d.Attributes, attribute(key, s.attributes[key]))
+	}
+	for _, e := range s.events {
+		d.Events = append(d.Events, eventData{TimeUnixNano: unixNano(e.time), Name: e.name})
+	}
+	if s.err != nil {
+		d.Status = status{Code: statusError, Message: s.err.Error()}
+	}
+	return d
+}
+
+func attribute(key string, value interface{}) keyValue {
+	var v anyValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		i := strconv.Itoa(value)
+		v.IntValue = &i
+	case int32:
+		i := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &i
+	case int64:
+		i := strconv.FormatInt(value, 10)
+		v.IntValue = &i
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return keyValue{Key: key, Value: v}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
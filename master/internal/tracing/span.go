@@ -0,0 +1,213 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// SpanContext identifies a span across actors and processes. The zero value is not a span.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled is true if the spans of the trace are recorded.
+	Sampled bool
+}
+
+// IsValid returns true if the span context identifies a span.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent returns the span context as a W3C traceparent header value, or the empty string if
+// it is not valid.
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s",
+		hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value. It returns the zero SpanContext if the
+// value is malformed.
+func ParseTraceparent(value string) SpanContext {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}
+	}
+	var sc SpanContext
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}
+	}
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// SpanKind is the role of a span in a trace, using the values of OTLP.
+type SpanKind int
+
+// The kinds of spans.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type event struct {
+	name string
+	time time.Time
+}
+
+// Span is an operation in a trace. A nil *Span, which is returned for the spans of traces that are
+// not recorded, accepts every call and does nothing. Spans are exported when they end, after which
+// they no longer change.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanID
+	name   string
+	kind   SpanKind
+	start  time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	events     []event
+	err        error
+	ended      bool
+}
+
+// Context returns the span context of the span, which is the zero SpanContext for nil spans.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttribute sets an attribute of the span. Values must be strings, booleans, integers or
+// floats; other values are recorded as strings.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// AddEvent records that something happened during the span.
+func (s *Span) AddEvent(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.events = append(s.events, event{name: name, time: time.Now()})
+}
+
+// SetError marks the span as failed with an error. It does nothing if err is nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.err = err
+}
+
+// End ends the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.export(s)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of the context that carries a span context, so that spans
+// started from it are its children.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context that the context carries, if any.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// Start starts a span that is a child of the span that the context carries, or the root of a new
+// trace if it carries none, and returns a copy of the context that carries the new span. The span is
+// nil if the trace is not recorded, but the context still carries that decision to its children.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	t := current()
+	if t == nil {
+		return ctx, nil
+	}
+	sc, span := t.start(SpanContextFromContext(ctx), name, kind)
+	return ContextWithSpanContext(ctx, sc), span
+}
+
+// StartFrom starts a span that is a child of the span of a span context. Actors use it to continue
+// the traces of the requests that they handle, so it returns nil if the span context is not valid.
+func StartFrom(parent SpanContext, name string) *Span {
+	t := current()
+	if t == nil || !parent.IsValid() {
+		return nil
+	}
+	_, span := t.start(parent, name, KindInternal)
+	return span
+}
@@ -0,0 +1,201 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxQueuedSpans is how many ended spans wait for export before new ones are dropped.
+	maxQueuedSpans = 4096
+	// maxBatchSize is how many spans are exported at once.
+	maxBatchSize = 512
+	// exportInterval is how often spans are exported if fewer than a batch are queued.
+	exportInterval = 5 * time.Second
+	exportTimeout  = 10 * time.Second
+)
+
+var (
+	globalLock   sync.RWMutex
+	globalTracer *Tracer
+)
+
+// Setup starts exporting the spans of the master if the configuration enables tracing.
+func Setup(config Config) {
+	if !config.Enabled() {
+		return
+	}
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	globalTracer = New(config)
+}
+
+// Shutdown exports the spans that have ended and stops tracing.
+func Shutdown() {
+	globalLock.Lock()
+	t := globalTracer
+	globalTracer = nil
+	globalLock.Unlock()
+	t.Close()
+}
+
+func current() *Tracer {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return globalTracer
+}
+
+// Tracer records spans and exports them to an OpenTelemetry collector over OTLP/HTTP.
+type Tracer struct {
+	config Config
+	url    string
+	client *http.Client
+
+	queue chan *Span
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New creates a tracer and starts exporting its spans.
+func New(config Config) *Tracer {
+	t := &Tracer{
+		config: config,
+		url:    strings.TrimSuffix(config.Endpoint, "/") + "/v1/traces",
+		client: &http.Client{Timeout: exportTimeout},
+		queue:  make(chan *Span, maxQueuedSpans),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// Close exports the spans that have ended and stops the tracer.
+func (t *Tracer) Close() {
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	<-t.done
+}
+
+func (t *Tracer) start(parent SpanContext, name string, kind SpanKind) (SpanContext, *Span) {
+	sc := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample()
+	}
+	if !sc.Sampled {
+		return sc, nil
+	}
+	return sc, &Span{
+		tracer: t,
+		sc:     sc,
+		parent: parent.SpanID,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+	}
+}
+
+func (t *Tracer) sample() bool {
+	switch {
+	case t.config.SampleRate >= 1:
+		return true
+	case t.config.SampleRate <= 0:
+		return false
+	}
+	const precision = 1 << 30
+	n, err := rand.Int(rand.Reader, big.NewInt(precision))
+	return err == nil && float64(n.Int64()) < t.config.SampleRate*precision
+}
+
+func (t *Tracer) export(s *Span) {
+	select {
+	case t.queue <- s:
+	default:
+		log.Debugf("dropping span %s since too many spans are waiting for export", s.name)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.post(batch); err != nil {
+			log.WithError(err).Warnf("failed to export %d spans", len(batch))
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			if batch = append(batch, s); len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case s := <-t.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) post(spans []*Span) error {
+	body, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("the collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
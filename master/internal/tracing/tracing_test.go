@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+)
+
+func TestTraceparent(t *testing.T) {
+	const value = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc := ParseTraceparent(value)
+	assert.Assert(t, sc.IsValid())
+	assert.Assert(t, sc.Sampled)
+	assert.Equal(t, sc.Traceparent(), value)
+
+	unsampled := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	assert.Assert(t, unsampled.IsValid())
+	assert.Assert(t, !unsampled.Sampled)
+
+	for _, malformed := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		assert.Equal(t, ParseTraceparent(malformed), SpanContext{}, malformed)
+	}
+	assert.Equal(t, SpanContext{}.Traceparent(), "")
+}
+
+func TestValidate(t *testing.T) {
+	assert.Equal(t, len(nonNil(DefaultConfig().Validate())), 0)
+
+	config := DefaultConfig()
+	config.Endpoint = "https://otel-collector:4318"
+	assert.Equal(t, len(nonNil(config.Validate())), 0)
+
+	config.Endpoint = "otel-collector:4318"
+	config.ServiceName = ""
+	config.SampleRate = 2
+	assert.Equal(t, len(nonNil(config.Validate())), 3)
+}
+
+func TestExport(t *testing.T) {
+	var lock sync.Mutex
+	var requests []exportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v1/traces")
+		assert.Equal(t, r.Header.Get("Authorization"), "secret")
+		var req exportRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&req))
+		lock.Lock()
+		defer lock.Unlock()
+		requests = append(requests, req)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Endpoint = server.URL
+	config.Headers = map[string]string{"Authorization": "secret"}
+	Setup(config)
+
+	ctx, root := Start(context.Background(), "GetMaster", KindServer)
+	root.SetAttribute("rpc.method", "GetMaster")
+	root.SetError(errors.New("failed"))
+	trace := StartAllocation(SpanContextFromContext(ctx), "task")
+	trace.Allocated("default", 2)
+	trace.ContainerState("a", "RUNNING")
+	trace.ContainerState("b", "PULLING")
+	trace.End(nil)
+	root.End()
+	root.SetAttribute("ignored", true)
+	Shutdown()
+
+	assert.Equal(t, len(requests), 1)
+	resource := requests[0].ResourceSpans[0]
+	assert.Equal(t, *resource.Resource.Attributes[0].Value.StringValue, "determined-master")
+	spans := resource.ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 3)
+	allocate, start, call := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, call.Name, "GetMaster")
+	assert.Equal(t, call.Kind, KindServer)
+	assert.Equal(t, call.ParentSpanID, "")
+	assert.Equal(t, call.Status, status{Code: statusError, Message: "failed"})
+	assert.Equal(t, len(call.Attributes), 1)
+	assert.Equal(t, *call.Attributes[0].Value.StringValue, "GetMaster")
+
+	assert.Equal(t, allocate.Name, "allocate")
+	assert.Equal(t, allocate.TraceID, call.TraceID)
+	assert.Equal(t, allocate.ParentSpanID, call.SpanID)
+	assert.Equal(t, start.Name, "start containers")
+	assert.Equal(t, start.ParentSpanID, call.SpanID)
+	assert.Equal(t, len(start.Events), 2)
+	assert.Equal(t, *start.Attributes[0].Value.IntValue, "2")
+}
+
+func TestUnsampled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unsampled spans were exported")
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Endpoint = server.URL
+	config.SampleRate = 0
+	Setup(config)
+	defer Shutdown()
+
+	ctx, root := Start(context.Background(), "GetMaster", KindServer)
+	assert.Assert(t, root == nil)
+	sc := SpanContextFromContext(ctx)
+	assert.Assert(t, sc.IsValid())
+	assert.Assert(t, !sc.Sampled)
+	_, child := Start(ctx, "db.query", KindClient)
+	assert.Assert(t, child == nil)
+	assert.Assert(t, StartAllocation(sc, "task") == nil)
+
+	// Callers that record their traces override the sample rate.
+	parent := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	span := StartFrom(parent, "allocate")
+	assert.Assert(t, span != nil)
+	assert.Equal(t, span.Context().TraceID, parent.TraceID)
+}
+
+func nonNil(errs []error) []error {
+	var result []error
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
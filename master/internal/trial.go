@@ -14,8 +14,11 @@ import (
 
 	"github.com/determined-ai/determined/master/pkg/workload"
 
+	"github.com/determined-ai/determined/master/internal/ca"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/tracing"
+	"github.com/determined-ai/determined/master/internal/vault"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/actor/api"
@@ -75,6 +78,12 @@ type (
 	killTrial    struct{}
 	trialAborted struct{}
 
+	// trialReportMetrics carries the metrics that a trial reported through the API, for the early
+	// stopping rules of its experiment.
+	trialReportMetrics struct {
+		metrics map[string]interface{}
+	}
+
 	// This message is used to synchronize the trial workload sequencer with the searcher. It allows
 	// the searcher to get more operations to the trial workload sequencer as a result of the trial
 	// completing a searcher operation before the trial decides to tell the scheduler it is
@@ -187,6 +196,12 @@ type (
 		TerminationSent            bool `json:"termination_sent"`
 		CancelUnready              bool `json:"cancel_unready"`
 		Killed                     bool `json:"killed"`
+
+		// EarlyStopped is whether an early stopping rule of the experiment stopped the trial.
+		EarlyStopped  bool               `json:"early_stopped"`
+		EarlyStopping earlyStoppingState `json:"early_stopping"`
+		// Alerts tracks the alert rules of the experiment against the metrics of the trial.
+		Alerts metricAlertsState `json:"alerts"`
 	}
 
 	// trial is an actor which is responsible for handling:
@@ -221,6 +236,10 @@ type (
 		// The following fields tracks the interaction with the resource providers.
 		task        *sproto.AllocateRequest
 		allocations []sproto.Allocation
+		// record is the row of the current run of the trial in the tasks table.
+		record *model.Task
+		// taskNetwork is the network private to the trial that its containers joined, if any.
+		taskNetwork *cproto.TaskNetwork
 
 		// The following fields tracks containers and their states.
 		lastContainerConnectedTime time.Time
@@ -237,6 +256,15 @@ type (
 		taskSpec       *tasks.TaskSpec
 		privateKey     []byte
 		publicKey      []byte
+		vault          *vault.Client
+		ca             *ca.Authority
+		// vaultGrant holds the Vault secrets of the current run of the trial, if it uses any.
+		vaultGrant *vault.Grant
+
+		// spanContext is the span of the request that created the trial, if any, and trace traces
+		// the first allocation of the trial as part of that request.
+		spanContext tracing.SpanContext
+		trace       *tracing.AllocationTrace
 
 		preemptionWatchers map[uuid.UUID]chan<- bool
 	}
@@ -276,6 +304,9 @@ func newTrial(
 
 		agentUserGroup: exp.agentUserGroup,
 		taskSpec:       exp.taskSpec,
+		vault:          exp.vault,
+		ca:             exp.ca,
+		spanContext:    exp.spanContext,
 
 		preemptionWatchers: make(map[uuid.UUID]chan<- bool),
 	}
@@ -300,10 +331,18 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		t.experimentState = msg
 	case []searcher.Operation:
 		t.processOperations(msg)
+	case trialReportMetrics:
+		if err := t.checkMetrics(ctx, msg.metrics); err != nil {
+			return err
+		}
 
 	case sproto.ContainerLog:
 		t.insertLog(ctx, msg.Container, msg.Message())
 
+	case sproto.TaskContainerProfile:
+		// Trials report their own metrics; the utilization of their containers is only stored as
+		// the profile of their task.
+
 	case trialAborted:
 		// This is to handle trial being aborted. It does nothing here but requires
 		// the code below this switch statement to handle releasing resources in
@@ -325,6 +364,7 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		delete(t.preemptionWatchers, msg.id)
 
 	case actor.PostStop:
+		t.vaultGrant.Release()
 		if !t.idSet {
 			return nil
 		}
@@ -343,7 +383,8 @@ func (t *trial) Receive(ctx *actor.Context) error {
 		}
 		ctx.Log().Info("trial stopped successfully")
 		endState := model.CompletedState
-		if t.experimentState == model.StoppingCanceledState || t.Killed {
+		if t.experimentState == model.StoppingCanceledState ||
+			t.experimentState == model.StoppingTimeoutState || t.Killed {
 			endState = model.CanceledState
 		}
 		if err := t.db.UpdateTrial(t.id, endState); err != nil {
@@ -364,6 +405,10 @@ func (t *trial) Receive(ctx *actor.Context) error {
 			ctx.Self().Stop()
 		} else if !t.sequencer.UpToDate() && t.experimentState == model.ActiveState {
 			slotsNeeded := t.experiment.Config.Resources().SlotsPerTrial()
+			minSlotsNeeded := slotsNeeded
+			if minSlots := t.experiment.Config.Resources().MinSlotsPerTrial(); minSlots != nil {
+				minSlotsNeeded = *minSlots
+			}
 			label := t.experiment.Config.Resources().AgentLabel()
 			resourcePool := t.experiment.Config.Resources().ResourcePool()
 			var name string
@@ -373,19 +418,33 @@ func (t *trial) Receive(ctx *actor.Context) error {
 				name = fmt.Sprintf("Trial (Experiment %d)", t.experiment.ID)
 			}
 
+			taskID := sproto.NewTaskID()
+			t.trace.End(nil)
+			t.trace = tracing.StartAllocation(t.spanContext, string(taskID))
+			t.spanContext = tracing.SpanContext{}
 			t.task = &sproto.AllocateRequest{
-				ID:             sproto.NewTaskID(),
+				ID:             taskID,
 				Name:           name,
 				Group:          ctx.Self().Parent(),
 				SlotsNeeded:    slotsNeeded,
 				NonPreemptible: false,
 				Label:          label,
 				ResourcePool:   resourcePool,
+				Platform:       t.experiment.Config.Resources().Platform(),
+				GPUType:        t.experiment.Config.Resources().GPUType(),
 				FittingRequirements: sproto.FittingRequirements{
 					SingleAgent: false,
 				},
-				TaskActor: ctx.Self(),
+				TaskActor:      ctx.Self(),
+				ProjectID:      t.experiment.ProjectID,
+				MinSlotsNeeded: minSlotsNeeded,
+				MaxSlotsNeeded: slotsNeeded,
+				SpanContext:    t.trace.SpanContext(),
+			}
+			if t.experiment.OwnerID != nil {
+				t.task.OwnerID = *t.experiment.OwnerID
 			}
+			t.addRecord(ctx)
 			if err := ctx.Ask(t.rm, *t.task).Error(); err != nil {
 				ctx.Log().Error(err)
 				t.terminated(ctx)
@@ -505,6 +564,7 @@ func (t *trial) processContainerMsg(ctx *actor.Context) error {
 		}
 
 	case sproto.TaskContainerStateChanged:
+		t.trace.ContainerState(string(msg.Container.ID), string(msg.Container.State))
 		if msg.Container.State != cproto.Assigned {
 			t.startedContainers[msg.Container.ID] = true
 		}
@@ -560,8 +620,20 @@ func (t *trial) processAllocated(
 		ctx.Log().Info("ignoring resource allocation since it is from the last run of the trial.")
 		return nil
 	}
+	t.trace.Allocated(msg.ResourcePool, len(msg.Allocations))
 
 	t.allocations = msg.Allocations
+	if t.record != nil {
+		t.record.ResourcePool = msg.ResourcePool
+		if t.task.Elastic() {
+			t.record.Slots = msg.Slots
+		}
+	}
+	t.transitionRecord(ctx, model.TaskStateAssigned)
+	t.taskNetwork = nil
+	if t.taskSpec.TaskContainerDefaults.TaskNetworkDriver != "" {
+		t.taskNetwork = msg.Network
+	}
 
 	if len(t.privateKey) == 0 {
 		generatedKeys, err := ssh.GenerateKey(nil)
@@ -623,6 +695,16 @@ func (t *trial) processAllocated(
 
 	ctx.Log().Infof("starting trial container: %v", w)
 
+	// Elastic trials may be allocated fewer slots than slots_per_trial; the harness sizes the
+	// distributed job, and splits the global batch, according to the slots it is told it has.
+	experimentConfig := t.experiment.Config
+	if t.task.Elastic() && msg.Slots != t.task.MaxSlotsNeeded {
+		ctx.Log().Infof("starting elastic trial with %d of %d slots", msg.Slots, t.task.MaxSlotsNeeded)
+		resources := experimentConfig.Resources()
+		resources.SetSlotsPerTrial(msg.Slots)
+		experimentConfig.SetResources(resources)
+	}
+
 	additionalFiles := archive.Archive{
 		t.agentUserGroup.OwnedArchiveItem(
 			trialEntrypointFile,
@@ -660,13 +742,33 @@ func (t *trial) processAllocated(
 	if err != nil {
 		return errors.Wrap(err, "cannot start a new task session for a trial")
 	}
+	taskCert, taskKey, err := t.ca.IssueTaskCert(string(t.task.ID))
+	if err != nil {
+		return err
+	}
+	registryCredentials, err := ownerRegistryCredentials(t.db, t.experiment.OwnerID)
+	if err != nil {
+		return err
+	}
+	secrets, err := ownerSecrets(t.db, t.experiment.OwnerID)
+	if err != nil {
+		return err
+	}
+	t.vaultGrant.Release()
+	if t.vaultGrant, err = fetchExperimentVaultSecrets(t.db, t.vault, t.experiment); err != nil {
+		return err
+	}
 	for rank, a := range msg.Allocations {
 		t.containerRanks[a.Summary().ID] = rank
 		taskSpec := *t.taskSpec
 		taskSpec.AgentUserGroup = t.agentUserGroup
+		taskSpec.RegistryCredentials = registryCredentials
+		taskSpec.Secrets = secrets
+		taskSpec.VaultSecrets = t.vaultGrant.Values()
 		taskSpec.TaskToken = taskToken
+		taskSpec.TaskCert, taskSpec.TaskKey = taskCert, taskKey
 		taskSpec.SetInner(&tasks.StartTrial{
-			ExperimentConfig:    t.experiment.Config,
+			ExperimentConfig:    experimentConfig,
 			ModelDefinition:     t.modelDefinition,
 			HParams:             t.create.Hparams,
 			TrialSeed:           t.create.TrialSeed,
@@ -725,10 +827,10 @@ func (t *trial) processCompletedWorkload(ctx *actor.Context, msg workload.Comple
 		}).Get().(bool)
 	}
 	op, err := t.sequencer.WorkloadCompleted(msg, isBestValidationFunc)
-	switch {
-	case err != nil:
+	if err != nil {
 		return errors.Wrap(err, "failed to pass completed message to sequencer")
-	case op != nil:
+	}
+	if op != nil {
 		m, err := msg.ValidationMetrics.Metric(t.experiment.Config.Searcher().Metric())
 		if err != nil {
 			return err
@@ -742,13 +844,65 @@ func (t *trial) processCompletedWorkload(ctx *actor.Context, msg workload.Comple
 		}); err != nil {
 			return errors.Wrap(err, "failed to report validation with snapshot")
 		}
+	}
+
+	var metrics map[string]interface{}
+	switch {
+	case msg.ValidationMetrics != nil:
+		metrics = msg.ValidationMetrics.Metrics
+	case msg.RunMetrics != nil:
+		metrics, _ = msg.RunMetrics["avg_metrics"].(map[string]interface{})
+	}
+	if err := t.checkMetrics(ctx, metrics); err != nil {
+		return err
+	}
+
+	if op != nil {
 		// If we talked to the searcher, fully synchronize with the it to allow it to relay any
 		// new operations to the trial.
 		ctx.Tell(ctx.Self().Parent(), sendNextWorkload{runID: t.RunID})
 		return nil
-	default:
-		return t.sendNextWorkload(ctx)
 	}
+	return t.sendNextWorkload(ctx)
+}
+
+// checkMetrics checks the metrics that the trial reported against the alert rules and the early
+// stopping rules of the experiment.
+func (t *trial) checkMetrics(ctx *actor.Context, metrics map[string]interface{}) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	for _, fired := range t.Alerts.check(t.experiment.Config.Alerts(), metrics) {
+		fired.trialID = t.id
+		ctx.Tell(ctx.Self().Parent(), fired)
+	}
+	return t.checkEarlyStopping(ctx, metrics)
+}
+
+// checkEarlyStopping checks the metrics that the trial reported against the early stopping rules
+// of the experiment. A trial that breaks one exits early, as though its user stopped it, after it
+// checkpoints.
+func (t *trial) checkEarlyStopping(ctx *actor.Context, metrics map[string]interface{}) error {
+	rules := t.experiment.Config.EarlyStopping()
+	if t.EarlyStopped || len(rules) == 0 || len(metrics) == 0 {
+		return nil
+	}
+	reason := t.EarlyStopping.check(rules, metrics)
+	if reason == "" {
+		return nil
+	}
+
+	ctx.Log().Infof("stopping trial early: %s", reason)
+	t.EarlyStopped = true
+	if err := t.tellWithSnapshot(ctx, ctx.Self().Parent(), func(s trialSnapshot) interface{} {
+		return trialReportEarlyExit{reason: workload.UserCanceled, trialSnapshot: s}
+	}); err != nil {
+		return errors.Wrap(err, "failed to report early stop with snapshot")
+	}
+	if t.task != nil {
+		t.terminate(ctx, false)
+	}
+	return nil
 }
 
 func (t *trial) sendNextWorkload(ctx *actor.Context) error {
@@ -838,6 +992,16 @@ func formatAddress(p cproto.Address) string {
 	return fmt.Sprintf("%s:%d", p.HostIP, p.HostPort)
 }
 
+// rendezvousAddress returns the address at which the other containers of the trial reach a port of
+// the container with the given rank: its hostname on the trial's own network, if it joined one,
+// or else the port it published on its agent.
+func (t *trial) rendezvousAddress(rank int, p cproto.Address) string {
+	if t.taskNetwork != nil {
+		return fmt.Sprintf("%s:%d", t.taskNetwork.Peers[rank], p.ContainerPort)
+	}
+	return formatAddress(p)
+}
+
 func (t *trial) killAndRemoveSocket(ctx *actor.Context, id cproto.ID) {
 	if skt, ok := t.containerSockets[id]; ok {
 		addr := skt.Address().Local()
@@ -940,8 +1104,8 @@ func (t *trial) pushRendezvous(ctx *actor.Context) error {
 		}
 
 		if numAddrs := len(addrs); numAddrs == 2 {
-			addrs1 = append(addrs1, formatAddress(addrs[0]))
-			addrs2 = append(addrs2, formatAddress(addrs[1]))
+			addrs1 = append(addrs1, t.rendezvousAddress(caddr.Ordinal, addrs[0]))
+			addrs2 = append(addrs2, t.rendezvousAddress(caddr.Ordinal, addrs[1]))
 		} else {
 			ctx.Log().Errorf(
 				"found %d rendezvous addresses instead of 2 for container %s; dropping rendezvous addresses %v",
@@ -980,6 +1144,13 @@ func (t *trial) processContainerRunning(
 
 	t.containers[msg.Container.ID] = msg.Container
 	t.containerAddresses[msg.Container.ID] = msg.ContainerStarted.Addresses
+	if len(t.containers) == len(t.allocations) {
+		t.transitionRecord(ctx, model.TaskStateRunning)
+	}
+	if err := t.db.BindTaskSession(
+		string(t.task.ID), msg.ContainerStarted.SourceAddresses); err != nil {
+		ctx.Log().WithError(err).Error("cannot bind the task token to the container")
+	}
 	if err := t.pushRendezvous(ctx); err != nil {
 		return errors.Wrap(err, "failed to push rendezvous to trial containers")
 	}
@@ -1019,6 +1190,39 @@ func (t *trial) processContainerTerminated(
 	}
 }
 
+// addRecord adds the row of the current run of the trial to the tasks table.
+func (t *trial) addRecord(ctx *actor.Context) {
+	projectID := t.experiment.ProjectID
+	t.record = &model.Task{
+		TaskID:       string(t.task.ID),
+		TaskType:     model.TaskTypeTrial,
+		State:        model.TaskStatePending,
+		Description:  t.task.Name,
+		OwnerID:      t.experiment.OwnerID,
+		ProjectID:    &projectID,
+		ResourcePool: t.task.ResourcePool,
+		Slots:        t.task.SlotsNeeded,
+		StartTime:    time.Now().UTC(),
+	}
+	if t.idSet {
+		t.record.TrialID = &t.id
+	}
+	if err := t.db.AddTask(t.record); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the task of the trial")
+	}
+}
+
+// transitionRecord moves the row of the current run of the trial in the tasks table to a new
+// state.
+func (t *trial) transitionRecord(ctx *actor.Context, state model.TaskState) {
+	if t.record == nil {
+		return
+	}
+	if err := t.db.TransitionTask(t.record, state); err != nil {
+		ctx.Log().WithError(err).Error("cannot record the state of the task of the trial")
+	}
+}
+
 func (t *trial) canLog(ctx *actor.Context, msg string) bool {
 	// Log messages should never come in before the trial ID is set, since no trial runners are
 	// launched until after the trial ID is set. But for futureproofing, we will log an error while
@@ -1083,7 +1287,8 @@ func (t *trial) reset() error {
 }
 
 func (t *trial) trialClosing() bool {
-	return t.sequencer.ExitingEarly || t.Killed || t.Restarts > t.experiment.Config.MaxRestarts() ||
+	return t.sequencer.ExitingEarly || t.Killed || t.EarlyStopped ||
+		t.Restarts > t.experiment.Config.MaxRestarts() ||
 		(t.close != nil && t.sequencer.UpToDate()) ||
 		model.StoppingStates[t.experimentState]
 }
@@ -1098,7 +1303,7 @@ func (t *trial) terminate(ctx *actor.Context, kill bool) {
 		ctx.Log().Info("forcibly terminating trial")
 		if t.task != nil && t.allocations != nil {
 			for _, allocation := range t.allocations {
-				allocation.Kill(ctx)
+				allocation.Kill(ctx, model.ExperimentStopPolicy(t.experiment.Config))
 			}
 		}
 	case !t.PendingGracefulTermination:
@@ -1129,6 +1334,7 @@ func (t *trial) terminated(ctx *actor.Context) {
 	}
 
 	terminationSent := t.TerminationSent
+	t.trace.End(nil)
 
 	if err := t.db.CompleteTrialRun(t.id, t.RunID); err != nil {
 		ctx.Log().WithError(err).Error("failed to mark trial run completed")
@@ -1141,8 +1347,13 @@ func (t *trial) terminated(ctx *actor.Context) {
 			ctx.Log().WithError(err).Error("error delete task session for a trial")
 		}
 	}
+	t.vaultGrant.Release()
+	t.vaultGrant = nil
+	t.transitionRecord(ctx, model.TaskStateTerminated)
+	t.record = nil
 	t.task = nil
 	t.allocations = nil
+	t.taskNetwork = nil
 	t.containerRanks = make(map[cproto.ID]int)
 	ctx.Tell(t.rm, sproto.ResourcesReleased{TaskActor: ctx.Self()})
 
@@ -1175,6 +1386,18 @@ func (t *trial) terminated(ctx *actor.Context) {
 	case status.Failure.FailureType == aproto.TaskAborted:
 		ctx.Log().Info("trial runner is aborted successfully")
 		return
+	case status.Failure.FailureType == aproto.ContainerPreempted:
+		// Preemption is not the trial's fault, so it does not count as a restart, but any progress
+		// since the last checkpoint is lost.
+		ctx.Log().Info("trial runner was preempted by the cluster")
+		if err := t.reset(); err != nil {
+			ctx.Log().Warn("failed to reset trial", err)
+		}
+		ctx.Tell(ctx.Self().Parent(), trialReportProgress{
+			requestID: t.create.RequestID,
+			progress:  t.sequencer.Progress(),
+		})
+		return
 	}
 
 	ctx.Log().Errorf("unexpected failure of trial after restart %d/%d: %v",
@@ -1237,6 +1460,47 @@ func (t *trial) preemptWatchers() {
 	}
 }
 
+// IntrospectState implements the actor.Introspectable interface.
+func (t *trial) IntrospectState() interface{} {
+	containers := make(map[cproto.ID]string)
+	for id := range t.startedContainers {
+		containers[id] = "STARTED"
+	}
+	for id, c := range t.containers {
+		containers[id] = c.State.String()
+	}
+	for id, c := range t.terminatedContainers {
+		containers[id] = fmt.Sprintf("TERMINATED: %s", c.exitStatus)
+	}
+	var taskID *sproto.TaskID
+	if t.task != nil {
+		taskID = &t.task.ID
+	}
+	state := t.trialState
+	state.TrialWorkloadSequencerState, _ = t.sequencer.Snapshot()
+	return struct {
+		trialState
+		ID                int                  `json:"id"`
+		ExperimentID      int                  `json:"experiment_id"`
+		ExperimentState   model.State          `json:"experiment_state"`
+		TaskID            *sproto.TaskID       `json:"task_id"`
+		Allocations       int                  `json:"allocations"`
+		Containers        map[cproto.ID]string `json:"containers"`
+		ConnectedSockets  int                  `json:"connected_sockets"`
+		AllReadySucceeded bool                 `json:"all_ready_succeeded"`
+	}{
+		trialState:        state,
+		ID:                t.id,
+		ExperimentID:      t.experiment.ID,
+		ExperimentState:   t.experimentState,
+		TaskID:            taskID,
+		Allocations:       len(t.allocations),
+		Containers:        containers,
+		ConnectedSockets:  len(t.containerSockets),
+		AllReadySucceeded: t.allReadySucceeded,
+	}
+}
+
 func (t *trial) Snapshot() (json.RawMessage, error) {
 	sequencerSnapshot, err := t.sequencer.Snapshot()
 	if err != nil {
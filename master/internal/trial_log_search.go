@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"regexp"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// maxTrialLogSearchContext is the most logs before and after each match that a search of trial
+// logs returns.
+const maxTrialLogSearchContext = 100
+
+// trialLogMatch is a trial log that matched a search, with the logs around it.
+type trialLogMatch struct {
+	match  *model.TrialLog
+	before []*model.TrialLog
+	after  []*model.TrialLog
+}
+
+// Proto converts a match to its protobuf representation.
+func (m trialLogMatch) Proto() (*apiv1.SearchTrialLogsResponse, error) {
+	match, err := m.match.Proto()
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.SearchTrialLogsResponse{Match: match}
+	for _, l := range m.before {
+		pl, err := l.Proto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Before = append(resp.Before, pl)
+	}
+	for _, l := range m.after {
+		pl, err := l.Proto()
+		if err != nil {
+			return nil, err
+		}
+		resp.After = append(resp.After, pl)
+	}
+	return resp, nil
+}
+
+// trialLogSearch finds the trial logs that match a pattern as they are added in order, keeping
+// up to context logs before and after each match.
+type trialLogSearch struct {
+	pattern *regexp.Regexp
+	context int
+
+	// recent are the latest logs, which come before the next match.
+	recent []*model.TrialLog
+	// pending are the matches that still wait for the logs after them.
+	pending []*trialLogMatch
+}
+
+// add adds the next log to the search, returning the matches that it completed.
+func (s *trialLogSearch) add(log *model.TrialLog) []*trialLogMatch {
+	var done []*trialLogMatch
+	pending := s.pending[:0]
+	for _, m := range s.pending {
+		m.after = append(m.after, log)
+		if len(m.after) == s.context {
+			done = append(done, m)
+		} else {
+			pending = append(pending, m)
+		}
+	}
+	s.pending = pending
+
+	if s.pattern.MatchString(trialLogText(log)) {
+		m := &trialLogMatch{match: log, before: append([]*model.TrialLog{}, s.recent...)}
+		if s.context == 0 {
+			done = append(done, m)
+		} else {
+			s.pending = append(s.pending, m)
+		}
+	}
+
+	if s.context > 0 {
+		s.recent = append(s.recent, log)
+		if len(s.recent) > s.context {
+			s.recent = s.recent[1:]
+		}
+	}
+	return done
+}
+
+// flush returns the matches that did not complete before the logs ended.
+func (s *trialLogSearch) flush() []*trialLogMatch {
+	done := s.pending
+	s.pending = nil
+	return done
+}
+
+// trialLogText returns the text of a log that a search matches against: what the trial logged,
+// or the whole message for logs from before it was stored separately.
+func trialLogText(log *model.TrialLog) string {
+	if log.Log != nil {
+		return *log.Log
+	}
+	return log.Message
+}
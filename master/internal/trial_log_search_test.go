@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestTrialLogSearch(t *testing.T) {
+	var logs []*model.TrialLog
+	for _, text := range []string{"a", "error: 1", "b", "c", "error: 2", "d"} {
+		text := text
+		logs = append(logs, &model.TrialLog{Log: &text})
+	}
+	texts := func(ls []*model.TrialLog) []string {
+		res := []string{}
+		for _, l := range ls {
+			res = append(res, trialLogText(l))
+		}
+		return res
+	}
+
+	search := trialLogSearch{pattern: regexp.MustCompile(`^error`), context: 2}
+	var matches []*trialLogMatch
+	for _, l := range logs {
+		matches = append(matches, search.add(l)...)
+	}
+	assert.Equal(t, len(matches), 1)
+	matches = append(matches, search.flush()...)
+	assert.Equal(t, len(matches), 2)
+
+	assert.Equal(t, trialLogText(matches[0].match), "error: 1")
+	assert.DeepEqual(t, texts(matches[0].before), []string{"a"})
+	assert.DeepEqual(t, texts(matches[0].after), []string{"b", "c"})
+	assert.Equal(t, trialLogText(matches[1].match), "error: 2")
+	assert.DeepEqual(t, texts(matches[1].before), []string{"b", "c"})
+	assert.DeepEqual(t, texts(matches[1].after), []string{"d"})
+
+	search = trialLogSearch{pattern: regexp.MustCompile(`^[a-d]$`)}
+	matches = nil
+	for _, l := range logs {
+		matches = append(matches, search.add(l)...)
+	}
+	assert.Equal(t, len(matches), 4)
+	assert.Equal(t, len(search.flush()), 0)
+	assert.Equal(t, len(matches[0].before), 0)
+	assert.Equal(t, len(matches[0].after), 0)
+
+	message := "[2021-01-01T00:00:00Z] [abcdefgh] || error: legacy"
+	assert.Equal(t, trialLogText(&model.TrialLog{Message: message}), message)
+}
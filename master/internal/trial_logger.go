@@ -3,6 +3,7 @@ package internal
 import (
 	"time"
 
+	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/pkg/actor"
 	"github.com/determined-ai/determined/master/pkg/actor/actors"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -12,10 +13,16 @@ const (
 	// logFlushInterval is the longest time that the trialLogger will buffer logs in memory before
 	// flushing them to the database. This is set low to ensure a good user experience.
 	logFlushInterval = 20 * time.Millisecond
-	// logBuffer is the largest number of logs lines that can be buffered before flushing them to
-	// the database. For the strategy of many-rows-per-insert, performance was significantly worse
-	// below 500, and no improvements after 1000.
+	// logBuffer is the number of buffered log lines at which they are flushed without waiting for
+	// logFlushInterval.
 	logBuffer = 1000
+	// maxLogBatch is the largest number of log lines written to the backend at once. Larger
+	// batches amortize the round trips to the database when the backend falls behind.
+	maxLogBatch = 10000
+	// maxPendingLogs is the number of buffered log lines beyond which the trialLogger rejects the
+	// logs that agents post, so that they buffer and retry them until the backend catches up
+	// instead of the master running out of memory.
+	maxPendingLogs = 100000
 )
 
 type (
@@ -23,21 +30,45 @@ type (
 	// NotifyAfter(), which is used to guarantee that logs are not held too
 	// long without flushing.
 	flushLogs struct{}
+
+	// trialLogBatch is a message with the logs that an agent posted. The trialLogger responds
+	// with whether it accepted them.
+	trialLogBatch []*model.TrialLog
+
+	// logsFlushed is a message that the trialLogger sends to itself once a batch of logs is
+	// written to the backend.
+	logsFlushed struct {
+		count int
+		err   error
+	}
+
+	// trialLoggerStats is a message asking the trialLogger for its state.
+	trialLoggerStats struct{}
+
+	// trialLoggerState is the state of the trialLogger.
+	trialLoggerState struct {
+		pending  int
+		flushing int
+		rejected int
+	}
 )
 
 type trialLogger struct {
-	backend      TrialLogBackend
-	pending      []*model.TrialLog
-	lastLogFlush time.Time
+	backend TrialLogBackend
+	pending []*model.TrialLog
+	// flushing is the number of log lines being written to the backend. At most one batch is
+	// written at a time, while the logs that arrive meanwhile are buffered in pending.
+	flushing int
+	// rejected is the number of batches of logs rejected because too many were pending.
+	rejected int
 }
 
 // newTrialLogger creates an actor which can buffer up trial logs and flush them periodically.
 // There should only be one trialLogger shared across the entire system.
 func newTrialLogger(backend TrialLogBackend) actor.Actor {
 	return &trialLogger{
-		backend:      backend,
-		lastLogFlush: time.Now(),
-		pending:      make([]*model.TrialLog, 0, logBuffer),
+		backend: backend,
+		pending: make([]*model.TrialLog, 0, logBuffer),
 	}
 }
 
@@ -47,16 +78,46 @@ func (l *trialLogger) Receive(ctx *actor.Context) error {
 		actors.NotifyAfter(ctx, logFlushInterval, flushLogs{})
 
 	case flushLogs:
-		l.tryFlushLogs(ctx, true)
+		l.flush(ctx)
 		actors.NotifyAfter(ctx, logFlushInterval, flushLogs{})
 
 	case model.TrialLog:
 		l.pending = append(l.pending, &msg)
-		l.tryFlushLogs(ctx, false)
+		if len(l.pending) >= logBuffer {
+			l.flush(ctx)
+		}
+
+	case trialLogBatch:
+		if len(l.pending)+l.flushing >= maxPendingLogs {
+			l.rejected++
+			ctx.Respond(false)
+			return nil
+		}
+		l.pending = append(l.pending, msg...)
+		if len(l.pending) >= logBuffer {
+			l.flush(ctx)
+		}
+		ctx.Respond(true)
+
+	case logsFlushed:
+		if msg.err != nil {
+			ctx.Log().WithError(msg.err).Errorf("failed to save %d trial logs", msg.count)
+		}
+		l.flushing = 0
+		if len(l.pending) >= logBuffer {
+			l.flush(ctx)
+		}
+
+	case trialLoggerStats:
+		ctx.Respond(trialLoggerState{
+			pending: len(l.pending), flushing: l.flushing, rejected: l.rejected,
+		})
 
 	case actor.PostStop:
 		// Flush any final logs.
-		l.tryFlushLogs(ctx, true)
+		if err := l.backend.AddTrialLogs(l.pending); err != nil {
+			ctx.Log().WithError(err).Errorf("failed to save trial logs")
+		}
 
 	default:
 		return actor.ErrUnexpectedMessage(ctx)
@@ -64,11 +125,48 @@ func (l *trialLogger) Receive(ctx *actor.Context) error {
 	return nil
 }
 
-func (l *trialLogger) tryFlushLogs(ctx *actor.Context, forceFlush bool) {
-	if forceFlush || len(l.pending) >= logBuffer {
-		if err := l.backend.AddTrialLogs(l.pending); err != nil {
-			ctx.Log().WithError(err).Errorf("failed to save trial logs")
+// flush writes a batch of the pending logs to the backend in the background, unless a batch is
+// still being written.
+func (l *trialLogger) flush(ctx *actor.Context) {
+	if l.flushing > 0 || len(l.pending) == 0 {
+		return
+	}
+	batch := l.pending
+	if len(batch) > maxLogBatch {
+		batch = batch[:maxLogBatch]
+	}
+	l.pending = append(make([]*model.TrialLog, 0, logBuffer), l.pending[len(batch):]...)
+	l.flushing = len(batch)
+	go func() {
+		ctx.Tell(ctx.Self(), logsFlushed{count: len(batch), err: l.backend.AddTrialLogs(batch)})
+	}()
+}
+
+// trialLoggerCollector returns a Prometheus collector reporting the logs buffered by the
+// trialLogger.
+func trialLoggerCollector(system *actor.System, loggerRef *actor.Ref) prom.Collector {
+	return func() []prom.Metric {
+		state, ok := system.Ask(loggerRef, trialLoggerStats{}).Get().(trialLoggerState)
+		if !ok {
+			return nil
+		}
+		return []prom.Metric{
+			{
+				Name: "det_trial_logs_buffered",
+				Help: "Number of trial log lines buffered in the master until they are saved.",
+				Type: prom.Gauge,
+				Samples: []prom.Sample{
+					{Labels: map[string]string{"state": "pending"}, Value: float64(state.pending)},
+					{Labels: map[string]string{"state": "flushing"}, Value: float64(state.flushing)},
+				},
+			},
+			{
+				Name: "det_trial_log_batches_rejected_total",
+				Help: "Number of batches of trial logs that agents posted which were rejected " +
+					"because too many logs were buffered.",
+				Type:    prom.Counter,
+				Samples: []prom.Sample{{Value: float64(state.rejected)}},
+			},
 		}
-		l.pending = l.pending[:0]
 	}
 }
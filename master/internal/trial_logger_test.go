@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// blockingLogBackend is a trial log backend whose writes block until it is unblocked.
+type blockingLogBackend struct {
+	TrialLogBackend
+	unblock chan struct{}
+	mu      sync.Mutex
+	added   int
+}
+
+func (b *blockingLogBackend) AddTrialLogs(logs []*model.TrialLog) error {
+	<-b.unblock
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.added += len(logs)
+	return nil
+}
+
+func makeTrialLogBatch(n int) trialLogBatch {
+	batch := make(trialLogBatch, 0, n)
+	for i := 0; i < n; i++ {
+		batch = append(batch, &model.TrialLog{TrialID: 1})
+	}
+	return batch
+}
+
+func TestTrialLoggerBackpressure(t *testing.T) {
+	backend := &blockingLogBackend{unblock: make(chan struct{})}
+	system := actor.NewSystem(t.Name())
+	logger, _ := system.ActorOf(actor.Addr("trialLogger"), newTrialLogger(backend))
+
+	// The logs are accepted while the backend is blocked until too many are pending.
+	accepted := 0
+	for system.Ask(logger, makeTrialLogBatch(logBuffer)).Get().(bool) {
+		accepted += logBuffer
+	}
+	assert.Equal(t, accepted, maxPendingLogs)
+	state := system.Ask(logger, trialLoggerStats{}).Get().(trialLoggerState)
+	assert.Equal(t, state.flushing, logBuffer)
+	assert.Equal(t, state.pending, maxPendingLogs-logBuffer)
+	assert.Equal(t, state.rejected, 1)
+
+	// Once the backend catches up, the logger accepts logs again.
+	close(backend.unblock)
+	for state.pending > 0 || state.flushing > 0 {
+		time.Sleep(logFlushInterval)
+		state = system.Ask(logger, trialLoggerStats{}).Get().(trialLoggerState)
+	}
+	backend.mu.Lock()
+	assert.Equal(t, backend.added, maxPendingLogs)
+	backend.mu.Unlock()
+	assert.Assert(t, system.Ask(logger, makeTrialLogBatch(logBuffer)).Get().(bool))
+}
@@ -81,16 +81,30 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 			return echo.NewHTTPError(http.StatusUnauthorized)
 		}
 
-		user, userSession, err := s.db.UserByToken(token)
+		var user *model.User
+		var userSession *model.UserSession
+		var apiToken *model.APIToken
+		var err error
+		if strings.HasPrefix(token, model.APITokenPrefix) {
+			user, apiToken, err = s.db.UserByAPIToken(token)
+		} else {
+			user, userSession, err = s.db.UserByToken(token)
+		}
 		switch err {
 		case nil:
 			if !user.Active {
 				return echo.NewHTTPError(http.StatusForbidden)
 			}
+			if apiToken != nil && !apiTokenAllows(apiToken, c.Request().Method, c.Request().URL.Path) {
+				return echo.NewHTTPError(
+					http.StatusForbidden, "the scopes of the API token do not allow this request")
+			}
 			// Set data on the request context that might be useful to
 			// event handlers.
 			c.(*context.DetContext).SetUser(*user)
-			c.(*context.DetContext).SetUserSession(*userSession)
+			if userSession != nil {
+				c.(*context.DetContext).SetUserSession(*userSession)
+			}
 			return next(c)
 		case db.ErrNotFound:
 			return echo.NewHTTPError(http.StatusUnauthorized)
@@ -100,6 +114,20 @@ func (s *Service) ProcessAuthentication(next echo.HandlerFunc) echo.HandlerFunc
 	}
 }
 
+// apiTokenAllows returns true if the scopes of an API token allow an HTTP request.
+func apiTokenAllows(token *model.APIToken, method, path string) bool {
+	switch {
+	case token.HasScope(model.TokenScopeAll):
+		return true
+	case method == http.MethodGet || method == http.MethodHead:
+		return token.HasScope(model.TokenScopeRead)
+	case method == http.MethodPost && path == "/experiments":
+		return token.HasScope(model.TokenScopeSubmitExperiments)
+	default:
+		return false
+	}
+}
+
 func (s *Service) postLogout(c echo.Context) (interface{}, error) {
 	// Delete the cookie if one is set.
 	if cookie, err := c.Cookie("auth"); err == nil {
@@ -109,7 +137,11 @@ func (s *Service) postLogout(c echo.Context) (interface{}, error) {
 	}
 
 	// Delete the user session information from the database.
-	sess := c.(*context.DetContext).MustGetUserSession()
+	sess, ok := c.(*context.DetContext).UserSession()
+	if !ok {
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, "API tokens cannot log out; revoke the token instead")
+	}
 
 	if err := s.db.DeleteUserSessionByID(sess.ID); err != nil {
 		return nil, err
@@ -0,0 +1,200 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// requestTimeout bounds each request to the Vault server.
+const requestTimeout = 30 * time.Second
+
+// Client fetches secrets from Vault for tasks. A nil client means that Vault is not configured.
+type Client struct {
+	config Config
+	http   *http.Client
+}
+
+// New returns a client for the Vault server of a configuration, or nil if the Vault integration
+// is disabled.
+func New(config Config) (*Client, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CACert != "" {
+		pem, err := ioutil.ReadFile(config.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading vault.ca_cert")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in %s", config.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		config: config,
+		http:   &http.Client{Transport: transport, Timeout: requestTimeout},
+	}, nil
+}
+
+// secret is the response of the Vault API to reading secrets and managing tokens and leases.
+type secret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// do sends a request to the Vault API with a token and decodes the response into out, if it is
+// not nil.
+func (c *Client) do(method, path, token string, body interface{}, out interface{}) error {
+	reader := bytes.NewReader(nil)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	url := strings.TrimSuffix(c.config.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if c.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "error requesting %s from Vault", path)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "error reading the response of Vault to %s", path)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		return errors.Errorf("Vault rejected %s %s with status %d: %s",
+			method, path, resp.StatusCode, strings.Join(failure.Errors, "; "))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(data, out),
+		"error decoding the response of Vault to %s", path)
+}
+
+// lease is a token or a lease of a secret, with the TTL that Vault last granted it.
+type lease struct {
+	id        string
+	ttl       time.Duration
+	renewable bool
+}
+
+// createToken creates a child token of the master's token with a token role. The ID of the
+// returned lease is the token.
+func (c *Client) createToken(role string) (lease, error) {
+	var out secret
+	if err := c.do(http.MethodPost, "auth/token/create/"+role, c.config.Token,
+		map[string]interface{}{}, &out); err != nil {
+		return lease{}, err
+	}
+	if out.Auth == nil || out.Auth.ClientToken == "" {
+		return lease{}, errors.Errorf("Vault did not create a token with role %s", role)
+	}
+	return lease{
+		id:        out.Auth.ClientToken,
+		ttl:       seconds(out.Auth.LeaseDuration),
+		renewable: out.Auth.Renewable,
+	}, nil
+}
+
+// read reads a secret with a token. The data of version 2 key/value secrets is unwrapped.
+func (c *Client) read(path, token string) (secret, error) {
+	var out secret
+	if err := c.do(http.MethodGet, path, token, nil, &out); err != nil {
+		return secret{}, err
+	}
+	if out.Data == nil {
+		return secret{}, errors.Errorf("Vault has no secret at %s", path)
+	}
+	if data, ok := out.Data["data"].(map[string]interface{}); ok {
+		if _, ok := out.Data["metadata"].(map[string]interface{}); ok {
+			out.Data = data
+		}
+	}
+	return out, nil
+}
+
+// renewToken extends the TTL of a token by increment and returns its new TTL.
+func (c *Client) renewToken(token string, increment time.Duration) (time.Duration, error) {
+	var out secret
+	if err := c.do(http.MethodPost, "auth/token/renew-self", token,
+		map[string]interface{}{"increment": int(increment.Seconds())}, &out); err != nil {
+		return 0, err
+	}
+	if out.Auth == nil {
+		return 0, errors.New("Vault did not renew the token")
+	}
+	return seconds(out.Auth.LeaseDuration), nil
+}
+
+// renewLease extends the TTL of a lease by increment and returns its new TTL.
+func (c *Client) renewLease(
+	token, leaseID string, increment time.Duration,
+) (time.Duration, error) {
+	var out secret
+	if err := c.do(http.MethodPut, "sys/leases/renew", token, map[string]interface{}{
+		"lease_id":  leaseID,
+		"increment": int(increment.Seconds()),
+	}, &out); err != nil {
+		return 0, err
+	}
+	return seconds(out.LeaseDuration), nil
+}
+
+// revokeToken revokes a token, which revokes the leases of the secrets read with it too.
+func (c *Client) revokeToken(token string) error {
+	return c.do(http.MethodPost, "auth/token/revoke-self", token, nil, nil)
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// fieldValue returns a field of the data of a secret as a string.
+func fieldValue(data map[string]interface{}, field string) (string, bool) {
+	switch value := data[field].(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	default:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err == nil
+	}
+}
@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"net/url"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Config is the Vault configuration of the master.
+type Config struct {
+	// Address is the URL of the Vault server, e.g. "https://vault.example.com:8200". The Vault
+	// integration is disabled if it is empty.
+	Address string `json:"address"`
+	// Token is the Vault token that the master authenticates with. It must be allowed to create
+	// tokens with the roles that workspaces are mapped to.
+	Token string `json:"token"`
+	// Namespace is the Vault Enterprise namespace to use, if any.
+	Namespace string `json:"namespace"`
+	// CACert is the path of a PEM file with the certificates that the Vault server's certificate
+	// is verified against, instead of the system's.
+	CACert string `json:"ca_cert"`
+	// WorkspaceRoles maps the names of workspaces to the token roles that the tokens their tasks
+	// read secrets with are created with, so that the policies of a role decide what the tasks of
+	// a workspace can read.
+	WorkspaceRoles map[string]string `json:"workspace_roles"`
+	// DefaultRole is the token role of workspaces that WorkspaceRoles does not map. Tasks of such
+	// workspaces cannot use Vault if it is empty.
+	DefaultRole string `json:"default_role"`
+}
+
+// Enabled returns true if the Vault integration is enabled.
+func (c Config) Enabled() bool {
+	return c.Address != ""
+}
+
+// Printable returns a copy of the configuration without secrets.
+func (c Config) Printable() Config {
+	if c.Token != "" {
+		c.Token = "********"
+	}
+	return c
+}
+
+// Validate implements the check.Validatable interface.
+func (c Config) Validate() []error {
+	if !c.Enabled() {
+		return nil
+	}
+	address, err := url.Parse(c.Address)
+	errs := []error{
+		check.True(err == nil && (address.Scheme == "http" || address.Scheme == "https") &&
+			address.Host != "", "vault.address must be an http or https URL"),
+		check.NotEmpty(c.Token, "vault.token must be set when vault.address is set"),
+	}
+	for workspace, role := range c.WorkspaceRoles {
+		errs = append(errs, check.NotEmpty(role,
+			"vault.workspace_roles must not map workspace %q to an empty role", workspace))
+	}
+	return errs
+}
+
+// role returns the token role for the tasks of a workspace, or "" if they cannot use Vault.
+func (c Config) role(workspace string) string {
+	if role, ok := c.WorkspaceRoles[workspace]; ok {
+		return role
+	}
+	return c.DefaultRole
+}
@@ -0,0 +1,176 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// minRenewInterval keeps renewals from spinning once Vault only grants short extensions, as it
+// does when a lease nears its maximum TTL.
+const minRenewInterval = 10 * time.Second
+
+// ErrNotConfigured is returned when tasks reference Vault secrets but Vault is not configured.
+var ErrNotConfigured = errors.New(
+	"environment variables reference Vault secrets, but the master has no Vault configuration")
+
+// Check returns an error if the tasks of a workspace cannot fetch the Vault secrets that
+// environment variables reference. It does not contact Vault.
+func (c *Client) Check(workspace string, envVars ...[]string) error {
+	if len(tasks.VaultReferences(envVars...)) == 0 {
+		return nil
+	}
+	if c == nil {
+		return ErrNotConfigured
+	}
+	if c.config.role(workspace) == "" {
+		return errors.Errorf("workspace %q is not mapped to a Vault role", workspace)
+	}
+	return nil
+}
+
+// Grant holds the Vault secrets fetched for a task and keeps renewing their leases, and the token
+// they were read with, until it is released.
+type Grant struct {
+	client *Client
+	values map[string]string
+	token  lease
+	leases []lease
+	cancel context.CancelFunc
+}
+
+// Fetch reads the Vault secrets that environment variables reference for a task of a workspace,
+// with a new token created with the workspace's role. It returns a nil grant if they reference
+// none. The grant must be released when the task ends.
+func (c *Client) Fetch(workspace string, envVars ...[]string) (*Grant, error) {
+	references := tasks.VaultReferences(envVars...)
+	if len(references) == 0 {
+		return nil, nil
+	}
+	if err := c.Check(workspace, envVars...); err != nil {
+		return nil, err
+	}
+	token, err := c.createToken(c.config.role(workspace))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating a Vault token for workspace %q", workspace)
+	}
+	g := &Grant{client: c, values: map[string]string{}, token: token}
+	secrets := map[string]secret{}
+	for _, reference := range references {
+		parts := strings.SplitN(reference, "#", 2)
+		path, field := parts[0], parts[1]
+		s, ok := secrets[path]
+		if !ok {
+			if s, err = c.read(path, token.id); err != nil {
+				go g.revoke()
+				return nil, errors.Wrapf(err, "error reading Vault secret %s", path)
+			}
+			secrets[path] = s
+			if s.LeaseID != "" {
+				g.leases = append(g.leases, lease{
+					id: s.LeaseID, ttl: seconds(s.LeaseDuration), renewable: s.Renewable,
+				})
+			}
+		}
+		value, ok := fieldValue(s.Data, field)
+		if !ok {
+			go g.revoke()
+			return nil, errors.Errorf("Vault secret %s has no field %s", path, field)
+		}
+		g.values[reference] = value
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go g.renewLoop(ctx)
+	return g, nil
+}
+
+// Values returns the values of the fields of the secrets of a grant by "path#field", as
+// tasks.TaskSpec.VaultSecrets holds them.
+func (g *Grant) Values() map[string]string {
+	if g == nil {
+		return nil
+	}
+	return g.values
+}
+
+// Release stops renewing a grant and revokes its token, which revokes the leases of its secrets
+// too. Releasing a nil grant does nothing.
+func (g *Grant) Release() {
+	if g == nil {
+		return
+	}
+	g.cancel()
+	go g.revoke()
+}
+
+func (g *Grant) revoke() {
+	if err := g.client.revokeToken(g.token.id); err != nil {
+		log.WithError(err).Warn("error revoking a Vault token of a task")
+	}
+}
+
+func (g *Grant) renewLoop(ctx context.Context) {
+	for {
+		interval, ok := g.renewInterval()
+		if !ok {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+			g.renew()
+		}
+	}
+}
+
+// renewInterval returns half of the shortest TTL among the token and the leases that are still
+// renewable, or false if none is.
+func (g *Grant) renewInterval() (time.Duration, bool) {
+	var shortest time.Duration
+	for _, l := range append([]lease{g.token}, g.leases...) {
+		if l.renewable && l.ttl > 0 && (shortest == 0 || l.ttl < shortest) {
+			shortest = l.ttl
+		}
+	}
+	if shortest == 0 {
+		return 0, false
+	}
+	if shortest/2 < minRenewInterval {
+		return minRenewInterval, true
+	}
+	return shortest / 2, true
+}
+
+// renew extends the token and the leases of a grant. Those that Vault refuses to renew are not
+// tried again.
+func (g *Grant) renew() {
+	if g.token.renewable && g.token.ttl > 0 {
+		ttl, err := g.client.renewToken(g.token.id, g.token.ttl)
+		if err != nil {
+			log.WithError(err).Warn("error renewing a Vault token of a task")
+			g.token.renewable = false
+		} else {
+			g.token.ttl = ttl
+		}
+	}
+	for i, l := range g.leases {
+		if !l.renewable || l.ttl <= 0 {
+			continue
+		}
+		ttl, err := g.client.renewLease(g.token.id, l.id, l.ttl)
+		if err != nil {
+			log.WithError(err).Warnf("error renewing Vault lease %s", l.id)
+			g.leases[i].renewable = false
+		} else {
+			g.leases[i].ttl = ttl
+		}
+	}
+}
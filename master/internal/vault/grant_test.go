@@ -0,0 +1,150 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// fakeVault serves the parts of the Vault API that grants use.
+type fakeVault struct {
+	t        *testing.T
+	renewals map[string]int
+	revoked  chan string
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Vault-Token")
+	var response interface{}
+	switch r.Method + " " + r.URL.Path {
+	case "POST /v1/auth/token/create/ml":
+		assert.Equal(v.t, token, "master-token")
+		response = map[string]interface{}{"auth": map[string]interface{}{
+			"client_token": "task-token", "lease_duration": 60, "renewable": true,
+		}}
+	case "GET /v1/aws/creds/ml":
+		assert.Equal(v.t, token, "task-token")
+		response = map[string]interface{}{
+			"lease_id": "aws/creds/ml/1", "lease_duration": 120, "renewable": true,
+			"data": map[string]interface{}{"access_key": "AK", "secret_key": "SK"},
+		}
+	case "GET /v1/secret/data/team":
+		response = map[string]interface{}{"data": map[string]interface{}{
+			"data":     map[string]interface{}{"token": "t", "port": 5432},
+			"metadata": map[string]interface{}{"version": 3},
+		}}
+	case "POST /v1/auth/token/renew-self":
+		v.renewals[token]++
+		response = map[string]interface{}{"auth": map[string]interface{}{"lease_duration": 30}}
+	case "PUT /v1/sys/leases/renew":
+		var body struct {
+			LeaseID string `json:"lease_id"`
+		}
+		assert.NilError(v.t, json.NewDecoder(r.Body).Decode(&body))
+		v.renewals[body.LeaseID]++
+		http.Error(w, `{"errors": ["lease is not renewable"]}`, http.StatusBadRequest)
+		return
+	case "POST /v1/auth/token/revoke-self":
+		v.revoked <- token
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(w, `{"errors": ["not found"]}`, http.StatusNotFound)
+		return
+	}
+	assert.NilError(v.t, json.NewEncoder(w).Encode(response))
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeVault) {
+	vault := &fakeVault{t: t, renewals: map[string]int{}, revoked: make(chan string, 10)}
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	client, err := New(Config{
+		Address:        server.URL,
+		Token:          "master-token",
+		WorkspaceRoles: map[string]string{"ml-team": "ml"},
+	})
+	assert.NilError(t, err)
+	return client, vault
+}
+
+func TestFetch(t *testing.T) {
+	client, vault := newTestClient(t)
+	grant, err := client.Fetch("ml-team", []string{
+		"AWS_ACCESS_KEY_ID=${vault:aws/creds/ml#access_key}",
+		"AWS_SECRET_ACCESS_KEY=${vault:aws/creds/ml#secret_key}",
+	}, []string{"DB=${vault:secret/data/team#token}:${vault:secret/data/team#port}"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, grant.Values(), map[string]string{
+		"aws/creds/ml#access_key": "AK",
+		"aws/creds/ml#secret_key": "SK",
+		"secret/data/team#token":  "t",
+		"secret/data/team#port":   "5432",
+	})
+
+	interval, ok := grant.renewInterval()
+	assert.Assert(t, ok)
+	assert.Equal(t, interval, 30*time.Second)
+	grant.renew()
+	assert.Equal(t, vault.renewals["task-token"], 1)
+	assert.Equal(t, vault.renewals["aws/creds/ml/1"], 1)
+	assert.Equal(t, grant.token.ttl, 30*time.Second)
+	grant.renew()
+	assert.Equal(t, vault.renewals["aws/creds/ml/1"], 1, "refused leases are not renewed again")
+
+	grant.Release()
+	select {
+	case token := <-vault.revoked:
+		assert.Equal(t, token, "task-token")
+	case <-time.After(10 * time.Second):
+		t.Fatal("the token of a released grant was not revoked")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	client, vault := newTestClient(t)
+
+	grant, err := client.Fetch("ml-team", []string{"PLAIN=value"})
+	assert.NilError(t, err)
+	assert.Assert(t, grant == nil)
+	assert.Assert(t, grant.Values() == nil)
+	grant.Release()
+
+	_, err = client.Fetch("other", []string{"KEY=${vault:aws/creds/ml#access_key}"})
+	assert.ErrorContains(t, err, `workspace "other" is not mapped to a Vault role`)
+
+	_, err = client.Fetch("ml-team", []string{"KEY=${vault:aws/creds/ml#missing}"})
+	assert.ErrorContains(t, err, "has no field missing")
+	assert.Equal(t, <-vault.revoked, "task-token")
+
+	_, err = client.Fetch("ml-team", []string{"KEY=${vault:kv/missing#value}"})
+	assert.ErrorContains(t, err, "status 404: not found")
+	assert.Equal(t, <-vault.revoked, "task-token")
+
+	var disabled *Client
+	assert.NilError(t, disabled.Check("ml-team", []string{"PLAIN=value"}))
+	assert.Equal(t, disabled.Check("ml-team", []string{"KEY=${vault:kv/a#b}"}), ErrNotConfigured)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.Equal(t, len(Config{}.Validate()), 0)
+
+	var failures int
+	for _, err := range (Config{
+		Address:        "vault:8200",
+		WorkspaceRoles: map[string]string{"ml-team": ""},
+	}).Validate() {
+		if err != nil {
+			failures++
+		}
+	}
+	assert.Equal(t, failures, 3)
+
+	for _, err := range (Config{Address: "https://vault:8200", Token: "t"}).Validate() {
+		assert.NilError(t, err)
+	}
+}
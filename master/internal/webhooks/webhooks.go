@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/actor"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// RootAddr is the path to use for looking up the manager actor.
+	RootAddr = "webhooks"
+
+	// EventExperimentEnded is the event of the payloads sent when experiments end.
+	EventExperimentEnded = "EXPERIMENT_ENDED"
+	// EventMetricAlert is the event of the payloads sent when alerts on trial metrics fire.
+	EventMetricAlert = "METRIC_ALERT"
+
+	// Deliveries that fail are retried with exponential backoff from retryDelay.
+	maxAttempts = 5
+	retryDelay  = 5 * time.Second
+	postTimeout = 10 * time.Second
+)
+
+// ExperimentEnded is the message an experiment sends once it is in a terminal state.
+type ExperimentEnded struct {
+	ID          int
+	Description string
+	ProjectID   int
+	State       model.State
+	EndTime     time.Time
+}
+
+// Payload is the JSON body posted to the webhooks of an experiment when it ends.
+type Payload struct {
+	Event        string      `json:"event"`
+	ExperimentID int         `json:"experiment_id"`
+	Description  string      `json:"description"`
+	ProjectID    int         `json:"project_id"`
+	State        model.State `json:"state"`
+	EndTime      time.Time   `json:"end_time"`
+	// BestTrialID is the trial with the best validation by the searcher metric, and Metrics are the
+	// metrics of that validation. Both are null if the experiment has no validations.
+	BestTrialID *int          `json:"best_trial_id"`
+	Metrics     model.JSONObj `json:"metrics"`
+}
+
+// MetricAlert is the message an experiment sends when an alert on the metrics of a trial fires.
+type MetricAlert struct {
+	ExperimentID int
+	Description  string
+	ProjectID    int
+	TrialID      int
+	Name         string
+	Metric       string
+	Value        float64
+	// Paused is whether the alert paused the experiment.
+	Paused bool
+}
+
+// AlertPayload is the JSON body posted to the webhooks of an experiment when an alert fires.
+type AlertPayload struct {
+	Event        string `json:"event"`
+	ExperimentID int    `json:"experiment_id"`
+	Description  string `json:"description"`
+	ProjectID    int    `json:"project_id"`
+	TrialID      int    `json:"trial_id"`
+	Alert        string `json:"alert"`
+	Metric       string `json:"metric"`
+	// Value is the value of the metric, which is a string when it is not finite, as it is in the
+	// metrics that trials report.
+	Value  interface{} `json:"value"`
+	Paused bool        `json:"paused"`
+}
+
+type manager struct {
+	db     *db.PgDB
+	client *http.Client
+}
+
+// NewManager creates the actor that notifies webhooks when experiments end or their alerts fire.
+func NewManager(db *db.PgDB) actor.Actor {
+	return &manager{db: db, client: &http.Client{Timeout: postTimeout}}
+}
+
+func (m *manager) Receive(ctx *actor.Context) error {
+	switch msg := ctx.Message().(type) {
+	case actor.PreStart, actor.PostStop:
+	case ExperimentEnded:
+		m.experimentEnded(ctx, msg)
+	case MetricAlert:
+		m.metricAlert(ctx, msg)
+	default:
+		return actor.ErrUnexpectedMessage(ctx)
+	}
+	return nil
+}
+
+func (m *manager) experimentEnded(ctx *actor.Context, msg ExperimentEnded) {
+	hooks, err := m.db.ExperimentWebhooks(msg.ID, msg.State)
+	if err != nil {
+		ctx.Log().WithError(err).Errorf("failed to notify the webhooks of experiment %d", msg.ID)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	payload := Payload{
+		Event:        EventExperimentEnded,
+		ExperimentID: msg.ID,
+		Description:  msg.Description,
+		ProjectID:    msg.ProjectID,
+		State:        msg.State,
+		EndTime:      msg.EndTime,
+	}
+	trialID, metrics, err := m.db.ExperimentBestValidation(msg.ID)
+	if err != nil {
+		ctx.Log().WithError(err).Warnf("notifying the webhooks of experiment %d without metrics",
+			msg.ID)
+	} else if trialID != 0 {
+		payload.BestTrialID = &trialID
+		payload.Metrics = metrics
+	}
+	m.notify(ctx, msg.ID, hooks, payload)
+}
+
+func (m *manager) metricAlert(ctx *actor.Context, msg MetricAlert) {
+	hooks, err := m.db.ExperimentAlertWebhooks(msg.ExperimentID)
+	if err != nil {
+		ctx.Log().WithError(err).Errorf("failed to notify the webhooks of experiment %d",
+			msg.ExperimentID)
+		return
+	}
+	m.notify(ctx, msg.ExperimentID, hooks, AlertPayload{
+		Event:        EventMetricAlert,
+		ExperimentID: msg.ExperimentID,
+		Description:  msg.Description,
+		ProjectID:    msg.ProjectID,
+		TrialID:      msg.TrialID,
+		Alert:        msg.Name,
+		Metric:       msg.Metric,
+		Value:        metricValue(msg.Value),
+		Paused:       msg.Paused,
+	})
+}
+
+func metricValue(value float64) interface{} {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+// notify delivers the payload to each of the webhooks of an experiment in the background.
+func (m *manager) notify(
+	ctx *actor.Context, experimentID int, hooks []model.Webhook, payload interface{},
+) {
+	if len(hooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		ctx.Log().WithError(err).Errorf("failed to notify the webhooks of experiment %d",
+			experimentID)
+		return
+	}
+	for _, hook := range hooks {
+		go m.deliver(hook, body)
+	}
+}
+
+// deliver posts the body to the webhook until it succeeds or runs out of attempts.
+func (m *manager) deliver(hook model.Webhook, body []byte) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := m.post(hook.URL, body)
+		if err == nil {
+			return
+		}
+		if attempt == maxAttempts {
+			log.WithError(err).Errorf("giving up on notifying webhook %d after %d attempts",
+				hook.ID, attempt)
+			return
+		}
+		log.WithError(err).Warnf("failed to notify webhook %d, retrying in %s", hook.ID, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (m *manager) post(url string, body []byte) error {
+	resp, err := m.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("the webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPost(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("Content-Type"))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	m := &manager{client: server.Client()}
+	assert.NilError(t, m.post(server.URL+"/ok", []byte(`{}`)))
+	assert.ErrorContains(t, m.post(server.URL+"/fail", []byte(`{}`)), "500")
+	assert.DeepEqual(t, received, []string{"application/json", "application/json"})
+}
@@ -0,0 +1,22 @@
+package internal
+
+import (
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// projectWorkspace returns the project with the given ID, or the default project if the ID is
+// zero, along with the workspace it belongs to.
+func (m *Master) projectWorkspace(projectID int) (*model.Project, *model.Workspace, error) {
+	if projectID == 0 {
+		projectID = model.DefaultProjectID
+	}
+	project, err := m.db.ProjectByID(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	workspace, err := m.db.WorkspaceByID(project.WorkspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, workspace, nil
+}
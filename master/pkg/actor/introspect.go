@@ -0,0 +1,225 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// latencyWindow is how many of the latest messages of an actor its latency percentiles cover.
+const latencyWindow = 256
+
+// ErrNotIntrospectable is returned for the state of actors that do not implement Introspectable.
+var ErrNotIntrospectable = errors.New("the actor does not describe its state")
+
+// Introspectable is implemented by actors that can describe their state for debugging.
+type Introspectable interface {
+	// IntrospectState returns a summary of the state of the actor that marshals to a JSON object.
+	// It is called by the goroutine of the actor between messages, so it may read any state.
+	IntrospectState() interface{}
+}
+
+// introspectState is an internal message asking an actor for the summary of its state.
+type introspectState struct{}
+
+// introspectedState is the response to introspectState.
+type introspectedState struct {
+	state interface{}
+	err   error
+}
+
+// Latency summarizes how long an actor took to process its latest messages.
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Introspection describes an actor and its descendants for debugging.
+type Introspection struct {
+	Address        Address
+	Type           string
+	RegisteredTime time.Time
+	// MailboxLength is the number of messages waiting for the actor.
+	MailboxLength int
+	// MessagesProcessed is the number of messages that the actor has processed.
+	MessagesProcessed uint64
+	// LastMessageType and LastMessageTime describe the message that the actor processed last, if
+	// any.
+	LastMessageType string
+	LastMessageTime time.Time
+	// ProcessingType and ProcessingSince describe the message that the actor is processing, if
+	// any. Actors that have been processing a message for long are likely stuck.
+	ProcessingType  string
+	ProcessingSince time.Time
+	// Latency covers the latest messages that the actor processed.
+	Latency  Latency
+	Children []Introspection
+}
+
+// processingStats records how an actor processes its messages.
+type processingStats struct {
+	lock            sync.Mutex
+	processed       uint64
+	latencies       [latencyWindow]time.Duration
+	lastType        string
+	lastTime        time.Time
+	processingType  string
+	processingSince time.Time
+	// waitingOn is the actor whose response the actor is blocked on while processing a message.
+	waitingOn *Ref
+}
+
+func (p *processingStats) begin(message Message) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.processingType = messageType(message)
+	p.processingSince = time.Now()
+}
+
+func (p *processingStats) end() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	p.latencies[p.processed%latencyWindow] = now.Sub(p.processingSince)
+	p.processed++
+	p.lastType, p.lastTime = p.processingType, now
+	p.processingType, p.processingSince = "", time.Time{}
+	p.waitingOn = nil
+}
+
+// processing returns the type of the message being processed, when its processing began and its
+// sequence number among the messages of the actor.
+func (p *processingStats) processing() (string, time.Time, uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.processingType, p.processingSince, p.processed
+}
+
+// waitFor records that the actor is waiting on the source, unless it is not processing a message,
+// in which case other goroutines are waiting on its behalf. It returns whether it was recorded.
+func (p *processingStats) waitFor(source *Ref) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.processingType == "" {
+		return false
+	}
+	p.waitingOn = source
+	return true
+}
+
+func (p *processingStats) doneWaiting(source *Ref) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.waitingOn == source {
+		p.waitingOn = nil
+	}
+}
+
+func (p *processingStats) waitingFor() *Ref {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.waitingOn
+}
+
+func (p *processingStats) introspect(i *Introspection) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	i.MessagesProcessed = p.processed
+	i.LastMessageType, i.LastMessageTime = p.lastType, p.lastTime
+	i.ProcessingType, i.ProcessingSince = p.processingType, p.processingSince
+
+	n := p.processed
+	if n > latencyWindow {
+		n = latencyWindow
+	}
+	if n == 0 {
+		return
+	}
+	latencies := make([]time.Duration, n)
+	copy(latencies, p.latencies[:n])
+	sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+	percentile := func(q float64) time.Duration {
+		return latencies[int(q*float64(len(latencies)-1)+0.5)]
+	}
+	i.Latency = Latency{
+		P50: percentile(0.5),
+		P90: percentile(0.9),
+		P99: percentile(0.99),
+		Max: latencies[len(latencies)-1],
+	}
+}
+
+func messageType(message Message) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", message), "*")
+}
+
+func actorType(ref *Ref) string {
+	return strings.TrimPrefix(reflect.TypeOf(ref.actor).String(), "*")
+}
+
+// Introspect describes the actor at the address and its descendants. It returns false if there is
+// no actor at the address.
+func (s *System) Introspect(address Address) (Introspection, bool) {
+	s.refsLock.RLock()
+	children := make(map[Address][]*Ref)
+	for addr, ref := range s.refs {
+		children[addr.Parent()] = append(children[addr.Parent()], ref)
+	}
+	s.refsLock.RUnlock()
+
+	ref := s.Get(address)
+	if ref == nil {
+		return Introspection{}, false
+	}
+	return introspect(ref, children), true
+}
+
+func introspect(ref *Ref, children map[Address][]*Ref) Introspection {
+	i := Introspection{
+		Address:        ref.address,
+		Type:           actorType(ref),
+		RegisteredTime: ref.registeredTime,
+		MailboxLength:  ref.inbox.len(),
+	}
+	ref.stats.introspect(&i)
+	for _, child := range children[ref.address] {
+		i.Children = append(i.Children, introspect(child, children))
+	}
+	sort.Slice(i.Children, func(a, b int) bool {
+		return i.Children[a].Address.String() < i.Children[b].Address.String()
+	})
+	return i
+}
+
+// IntrospectState asks the actor at the address to describe its state. Since the actor describes
+// it between messages, it returns an error if the actor does not respond within the timeout, which
+// names the message that the actor is stuck on.
+func (s *System) IntrospectState(address Address, timeout time.Duration) (interface{}, error) {
+	ref := s.Get(address)
+	if ref == nil {
+		return nil, errors.Errorf("no actor at %s", address)
+	}
+	resp, ok := ref.ask(context.Background(), nil, introspectState{}).GetOrTimeout(timeout)
+	if !ok {
+		var i Introspection
+		ref.stats.introspect(&i)
+		if i.ProcessingType == "" {
+			return nil, errors.Errorf("%s did not respond within %s", address, timeout)
+		}
+		return nil, errors.Errorf("%s did not respond within %s, it has been processing %s since %s",
+			address, timeout, i.ProcessingType, i.ProcessingSince.Format(time.RFC3339))
+	}
+	state, ok := resp.(introspectedState)
+	if !ok {
+		return nil, errors.Errorf("%s stopped", address)
+	}
+	return state.state, state.err
+}
@@ -0,0 +1,69 @@
+package actor
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type introspectableActor struct {
+	mockActor
+}
+
+func (a *introspectableActor) IntrospectState() interface{} {
+	return len(a.messages)
+}
+
+func TestSystem_Introspect(t *testing.T) {
+	system := NewSystem(t.Name())
+	blocking := &blockingActor{blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	parent, _ := system.ActorOf(Addr("parent"), &mockActor{})
+	ref, _ := system.ActorOf(Addr("parent", "blocking"), blocking)
+	system.ActorOf(Addr("parent", "introspectable"), &introspectableActor{})
+
+	system.Ask(parent, 1).Get()
+	system.Ask(parent, 2).Get()
+	system.Tell(ref, "first")
+	<-blocking.blocked
+	system.Tell(ref, "second")
+
+	i, ok := system.Introspect(Addr("parent"))
+	assert.Assert(t, ok)
+	assert.Equal(t, i.Address, Addr("parent"))
+	assert.Equal(t, i.Type, "actor.mockActor")
+	assert.Equal(t, i.MailboxLength, 0)
+	// PreStart, the creation of the children and the two messages.
+	assert.Equal(t, i.MessagesProcessed, uint64(5))
+	assert.Equal(t, i.LastMessageType, "int")
+	assert.Equal(t, i.ProcessingType, "")
+	assert.Assert(t, i.Latency.Max >= i.Latency.P50)
+
+	assert.Equal(t, len(i.Children), 2)
+	blocked := i.Children[0]
+	assert.Equal(t, blocked.Address, Addr("parent", "blocking"))
+	assert.Equal(t, blocked.MailboxLength, 1)
+	assert.Equal(t, blocked.ProcessingType, "string")
+	assert.Assert(t, !blocked.ProcessingSince.IsZero())
+	assert.Equal(t, i.Children[1].Address, Addr("parent", "introspectable"))
+
+	root, ok := system.Introspect(system.Address())
+	assert.Assert(t, ok)
+	assert.Equal(t, len(root.Children), 1)
+	_, ok = system.Introspect(Addr("missing"))
+	assert.Assert(t, !ok)
+
+	// Actors describe their state between messages.
+	state, err := system.IntrospectState(Addr("parent", "introspectable"), time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, state, 1, "only PreStart was received")
+	_, err = system.IntrospectState(Addr("parent"), time.Second)
+	assert.Equal(t, err, ErrNotIntrospectable)
+	_, err = system.IntrospectState(ref.Address(), 10*time.Millisecond)
+	assert.ErrorContains(t, err, "it has been processing string since")
+	_, err = system.IntrospectState(Addr("missing"), time.Second)
+	assert.ErrorContains(t, err, "no actor at /missing")
+
+	close(blocking.release)
+	assert.NilError(t, system.StopAndAwaitTermination())
+}
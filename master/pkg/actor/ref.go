@@ -49,6 +49,7 @@ type Ref struct {
 	children     map[Address]*Ref
 	deadChildren map[Address]bool
 	inbox        *inbox
+	stats        processingStats
 
 	// lLock locks on interactions with close listeners. When adding close listeners, if the actor
 	// is already shut down, the error is returned. Otherwise, a new listener is created and will be
@@ -202,6 +203,8 @@ func (r *Ref) processMessage() bool {
 	ctx := r.inbox.get()
 
 	r.log.Tracef("get %T, inbox length: %v", ctx.message, r.inbox.len())
+	r.stats.begin(ctx.message)
+	defer r.stats.end()
 
 	if traceEnabled {
 		defer traceReceive(ctx, r)()
@@ -217,6 +220,13 @@ func (r *Ref) processMessage() bool {
 	case Ping:
 		ctx.Respond(typed)
 		return false
+	case introspectState:
+		if i, ok := r.actor.(Introspectable); ok {
+			ctx.Respond(introspectedState{state: i.IntrospectState()})
+		} else {
+			ctx.Respond(introspectedState{err: ErrNotIntrospectable})
+		}
+		return false
 	case createChild:
 		child, created := r.createChild(typed.address, typed.actor)
 		ctx.Respond(childCreated{
@@ -258,7 +268,10 @@ func (r *Ref) processMessage() bool {
 
 func (r *Ref) run() {
 	defer r.close()
-	if r.err = r.sendInternalMessage(PreStart{}); r.err != nil {
+	r.stats.begin(PreStart{})
+	r.err = r.sendInternalMessage(PreStart{})
+	r.stats.end()
+	if r.err != nil {
 		return
 	}
 	for {
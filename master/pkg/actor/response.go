@@ -30,8 +30,10 @@ type Response interface {
 }
 
 type response struct {
-	lock    sync.Mutex
-	source  *Ref
+	lock   sync.Mutex
+	source *Ref
+	// waiter is the actor that asked the source, which is blocked while it waits for the response.
+	waiter  *Ref
 	fetched bool
 	future  chan Message
 	result  Message
@@ -51,11 +53,19 @@ func (r *response) Source() *Ref {
 }
 
 func (r *response) get() Message {
+	return r.fetch(r.waiter)
+}
+
+// fetch waits for the response, recording that the waiter, if any, is blocked on the source.
+func (r *response) fetch(waiter *Ref) Message {
 	if r.fetched {
 		return r.result
 	}
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	if waiter != nil && !r.fetched {
+		defer waiter.waitingFor(r.source)()
+	}
 	r.fetched = true
 	r.result = <-r.future
 	return r.result
@@ -77,18 +87,21 @@ func (r *response) GetOrElse(defaultValue Message) Message {
 }
 
 func (r *response) GetOrElseTimeout(defaultValue Message, timeout time.Duration) (Message, bool) {
-	future := make(chan Message, 1)
-	go func() {
-		future <- r.get()
-	}()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.fetched {
+		return r.result, true
+	}
+	// Waiting with a timeout never deadlocks, so it is not recorded. The future is waited on
+	// directly rather than through fetch, which would hold the lock until the actor responds and so
+	// keep the timeout from returning.
 	t := time.NewTimer(timeout)
 	defer t.Stop()
 	select {
-	case result := <-future:
-		return result, true
+	case r.result = <-r.future:
+		r.fetched = true
+		return r.result, true
 	case <-t.C:
-		r.lock.Lock()
-		defer r.lock.Unlock()
 		r.fetched = true
 		r.result = errNoResponse
 		return defaultValue, false
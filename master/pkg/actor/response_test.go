@@ -20,3 +20,21 @@ func TestResponseTimeout(t *testing.T) {
 	assert.Assert(t, result.(bool))
 	assert.Assert(t, !ok)
 }
+
+func TestResponseTimeoutDoesNotWait(t *testing.T) {
+	system := NewSystem(t.Name())
+	ref, _ := system.ActorOf(Addr("test"), ActorFunc(func(context *Context) error {
+		if context.ExpectingResponse() {
+			time.Sleep(1 * time.Second)
+			context.Respond(false)
+		}
+		return nil
+	}))
+	start := time.Now()
+	resp := system.Ask(ref, "")
+	_, ok := resp.GetOrTimeout(1 * time.Millisecond)
+	assert.Assert(t, !ok)
+	// A response that timed out is empty rather than waited on again.
+	assert.Assert(t, resp.Empty())
+	assert.Assert(t, time.Since(start) < 500*time.Millisecond, "the timeout waited for the response")
+}
@@ -84,6 +84,41 @@ func (s *System) Get(address Address) *Ref {
 	return s.refs[address]
 }
 
+// MailboxStats summarizes the inboxes of the actors of one type.
+type MailboxStats struct {
+	// Actors is the number of actors of the type.
+	Actors int
+	// Messages is the number of messages waiting in the inboxes of all of them.
+	Messages int
+	// MaxMessages is the number of messages waiting in the fullest inbox.
+	MaxMessages int
+}
+
+// MailboxStats returns the stats of the inboxes of the actors in the system, keyed by the type of
+// the actors, e.g. "internal.experiment".
+func (s *System) MailboxStats() map[string]MailboxStats {
+	s.refsLock.RLock()
+	refs := make([]*Ref, 0, len(s.refs))
+	for _, ref := range s.refs {
+		refs = append(refs, ref)
+	}
+	s.refsLock.RUnlock()
+
+	stats := make(map[string]MailboxStats)
+	for _, ref := range refs {
+		typeName := actorType(ref)
+		n := ref.inbox.len()
+		stat := stats[typeName]
+		stat.Actors++
+		stat.Messages += n
+		if n > stat.MaxMessages {
+			stat.MaxMessages = n
+		}
+		stats[typeName] = stat
+	}
+	return stats
+}
+
 // ActorOf adds the actor with the provided address.
 // The second return value denotes whether a new actor was created or not.
 func (s *System) ActorOf(address Address, actor Actor) (*Ref, bool) {
@@ -78,3 +78,38 @@ func TestSystem_AskAll(t *testing.T) {
 	}
 	assert.Equal(t, index, 3)
 }
+
+type blockingActor struct {
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingActor) Receive(context *Context) error {
+	if _, ok := context.Message().(string); ok {
+		a.blocked <- struct{}{}
+		<-a.release
+	}
+	return nil
+}
+
+func TestSystem_MailboxStats(t *testing.T) {
+	system := NewSystem(t.Name())
+	blocking := &blockingActor{blocked: make(chan struct{}, 3), release: make(chan struct{})}
+	ref, _ := system.ActorOf(Addr("blocking"), blocking)
+	system.ActorOf(Addr("idle"), &blockingActor{})
+
+	system.Tell(ref, "first")
+	<-blocking.blocked
+	system.Tell(ref, "second")
+	system.Tell(ref, "third")
+
+	stats := system.MailboxStats()
+	assert.DeepEqual(t, stats["actor.blockingActor"], MailboxStats{
+		Actors:      2,
+		Messages:    2,
+		MaxMessages: 2,
+	})
+
+	close(blocking.release)
+	assert.NilError(t, system.StopAndAwaitTermination())
+}
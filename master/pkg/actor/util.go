@@ -40,6 +40,10 @@ func askAll(
 	wg.Add(len(actors))
 	for _, actor := range actors {
 		resp := actor.ask(ctx, sender, message)
+		// The goroutine below waits on behalf of the sender, which may not be waiting itself.
+		if resp, ok := resp.(*response); ok {
+			resp.waiter = nil
+		}
 		go func() {
 			defer wg.Done()
 			// Wait for the response to be ready before putting into the result channel.
@@ -0,0 +1,128 @@
+package actor
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxWatchdogInterval bounds how late slow handlers are reported after they cross the threshold.
+	maxWatchdogInterval = 10 * time.Second
+	// maxStackBytes bounds the stack traces of all goroutines that the watchdog logs.
+	maxStackBytes = 16 << 20
+)
+
+// StartWatchdog logs a warning with the stack traces of all goroutines whenever an actor has been
+// processing a message for longer than the threshold. Each message is reported once. The watchdog
+// stops with the system.
+//
+// Circular asks, where actors wait on the responses of each other, are logged regardless.
+func (s *System) StartWatchdog(threshold time.Duration) {
+	interval := threshold / 2
+	if interval > maxWatchdogInterval {
+		interval = maxWatchdogInterval
+	}
+	stopped := make(chan struct{})
+	go func() {
+		_ = s.AwaitTermination()
+		close(stopped)
+	}()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		reported := make(map[*Ref]uint64)
+		for {
+			select {
+			case <-ticker.C:
+				s.reportSlowHandlers(threshold, reported)
+			case <-stopped:
+				return
+			}
+		}
+	}()
+}
+
+// reportSlowHandlers logs the actors that have been processing a message for longer than the
+// threshold, except those already reported for that message, which reported records by the
+// sequence number of the message.
+func (s *System) reportSlowHandlers(threshold time.Duration, reported map[*Ref]uint64) {
+	s.refsLock.RLock()
+	refs := make(map[*Ref]bool, len(s.refs)+1)
+	refs[s.Ref] = true
+	for _, ref := range s.refs {
+		refs[ref] = true
+	}
+	s.refsLock.RUnlock()
+
+	for ref := range reported {
+		if !refs[ref] {
+			delete(reported, ref)
+		}
+	}
+
+	var slow []string
+	for ref := range refs {
+		messageType, since, seq := ref.stats.processing()
+		if messageType == "" || time.Since(since) < threshold {
+			continue
+		}
+		if last, ok := reported[ref]; ok && last == seq {
+			continue
+		}
+		reported[ref] = seq
+		slow = append(slow, fmt.Sprintf("%s has been processing %s for %s",
+			ref.address, messageType, time.Since(since).Round(time.Millisecond)))
+	}
+	if len(slow) == 0 {
+		return
+	}
+	log.WithField("system", s.id).Warnf(
+		"actors are slow to process messages, which may hang the master: %s\n%s",
+		strings.Join(slow, ", "), allStacks())
+}
+
+// waitingFor records that the actor is blocked on the response of the source until the returned
+// function is called. If the source is, in turn, waiting on the actor, the actors will never
+// respond to each other, which is logged with the stack traces of all goroutines.
+func (r *Ref) waitingFor(source *Ref) func() {
+	if !r.stats.waitFor(source) {
+		return func() {}
+	}
+	if cycle := waitCycle(r); cycle != nil {
+		r.log.Errorf("circular ask, the actors are likely deadlocked: %s\n%s",
+			strings.Join(cycle, " -> "), allStacks())
+	}
+	return func() { r.stats.doneWaiting(source) }
+}
+
+// waitCycle returns the actors in the chain of waits that starts and ends at the actor, along with
+// the messages they are processing, or nil if there is no such chain.
+func waitCycle(start *Ref) []string {
+	var cycle []string
+	seen := make(map[*Ref]bool)
+	for ref := start; ref != nil && !seen[ref]; ref = ref.stats.waitingFor() {
+		seen[ref] = true
+		messageType, _, _ := ref.stats.processing()
+		cycle = append(cycle, fmt.Sprintf("%s (processing %s)", ref.address, messageType))
+		if ref.stats.waitingFor() == start {
+			return append(cycle, start.address.String())
+		}
+	}
+	return nil
+}
+
+// allStacks returns the stack traces of all goroutines.
+func allStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackBytes {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
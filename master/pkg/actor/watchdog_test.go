@@ -0,0 +1,82 @@
+package actor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/assert"
+)
+
+type askingActor struct {
+	target *Ref
+}
+
+func (a *askingActor) Receive(context *Context) error {
+	if _, ok := context.Message().(string); ok {
+		context.Ask(a.target, "ask").Get()
+	}
+	return nil
+}
+
+func countLogs(hook *test.Hook, substr string) int {
+	count := 0
+	for _, entry := range hook.AllEntries() {
+		if strings.Contains(entry.Message, substr) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSystem_StartWatchdog(t *testing.T) {
+	hook := test.NewGlobal()
+	system := NewSystem(t.Name())
+	system.StartWatchdog(20 * time.Millisecond)
+	blocking := &blockingActor{blocked: make(chan struct{}, 2), release: make(chan struct{})}
+	ref, _ := system.ActorOf(Addr("blocking"), blocking)
+
+	system.Tell(ref, "first")
+	<-blocking.blocked
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, countLogs(hook, "/blocking has been processing string"), 1,
+		"each message is reported once")
+	assert.Assert(t, countLogs(hook, "goroutine ") > 0, "the stack traces are logged")
+
+	blocking.release <- struct{}{}
+	system.Tell(ref, "second")
+	<-blocking.blocked
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, countLogs(hook, "/blocking has been processing string"), 2)
+
+	close(blocking.release)
+	assert.NilError(t, system.StopAndAwaitTermination())
+}
+
+func TestRef_CircularAsk(t *testing.T) {
+	hook := test.NewGlobal()
+	system := NewSystem(t.Name())
+	blocking := &blockingActor{blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	target, _ := system.ActorOf(Addr("target"), blocking)
+	asking, _ := system.ActorOf(Addr("asking"), &askingActor{target: target})
+
+	system.Tell(asking, "go")
+	<-blocking.blocked
+	for asking.stats.waitingFor() != target {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, countLogs(hook, "circular ask"), 0)
+
+	// The target waiting on the asking actor would close the cycle.
+	done := target.waitingFor(asking)
+	assert.Equal(t, countLogs(hook, "circular ask"), 1)
+	assert.Assert(t, countLogs(hook,
+		"/target (processing string) -> /asking (processing string) -> /target") == 1)
+	done()
+	assert.Assert(t, target.stats.waitingFor() == nil)
+
+	close(blocking.release)
+	assert.NilError(t, system.StopAndAwaitTermination())
+	assert.Assert(t, asking.stats.waitingFor() == nil)
+}
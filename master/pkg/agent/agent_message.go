@@ -2,6 +2,7 @@ package agent
 
 import (
 	"syscall"
+	"time"
 
 	"github.com/determined-ai/determined/master/pkg/model"
 
@@ -13,6 +14,10 @@ type AgentMessage struct {
 	MasterSetAgentOptions *MasterSetAgentOptions
 	StartContainer        *StartContainer
 	SignalContainer       *SignalContainer
+	PullImages            *PullImages
+	ReattachContainers    *ReattachContainers
+	ShutdownAgent         *ShutdownAgent
+	ConfigureMIG          *ConfigureMIG
 }
 
 // MasterSetAgentOptions is the first message sent to an agent by the master. It lets
@@ -22,16 +27,60 @@ type AgentMessage struct {
 type MasterSetAgentOptions struct {
 	MasterInfo     MasterInfo
 	LoggingOptions model.LoggingConfig
+	// HeartbeatPeriod is how often the agent should report its health to the master. A zero
+	// value disables heartbeats.
+	HeartbeatPeriod time.Duration
+	// ProfilingPeriod is how often the agent should sample the utilization of its task containers.
+	// A zero value disables profiling.
+	ProfilingPeriod time.Duration
+	// TaskHooks are what the agent runs before the containers of tasks start and after they stop.
+	TaskHooks model.TaskHooksConfig
 }
 
 // StartContainer notifies the agent to start a container with the provided spec.
 type StartContainer struct {
 	Container container.Container
 	Spec      container.Spec
+	// TaskID is the ID of the task that the container belongs to.
+	TaskID string
 }
 
-// SignalContainer notifies the agent to send the requested signal to the container.
+// PullImages notifies the agent to pull images ahead of the tasks that use them and, if Pin is
+// set, to keep pruning of unused images from removing them.
+type PullImages struct {
+	Images []string
+	Pin    bool
+}
+
+// SignalContainer notifies the agent to send the requested signal to the container. If it has a
+// grace period, the agent kills the container once that passed if it still runs.
 type SignalContainer struct {
 	ContainerID container.ID
 	Signal      syscall.Signal
+	GracePeriod time.Duration
+}
+
+// ReattachContainers answers the containers that the agent reported in AgentStarted: the agent
+// reattaches to these and kills the others, which no task of the master claims.
+type ReattachContainers struct {
+	ContainerIDs []container.ID
+}
+
+// ShutdownAgent notifies the agent to shut down, e.g. so that its supervisor restarts it with a new
+// version during a rolling upgrade, or because the master refuses its version.
+type ShutdownAgent struct {
+	Reason string
+}
+
+// ConfigureMIG notifies the drained agent to partition its GPUs into MIG instances and to restart,
+// so that it rejoins with the instances as its slots.
+type ConfigureMIG struct {
+	Layouts []MIGLayout
+}
+
+// MIGLayout is how a GPU is partitioned into MIG instances: one for each of the profiles, like
+// 3g.20gb. A GPU without profiles is not partitioned.
+type MIGLayout struct {
+	GPU      int
+	Profiles []string
 }
@@ -80,4 +80,8 @@ const (
 
 	// AgentError denotes that the agent failed to launch the container.
 	AgentError = FailureType("agent failed to launch the container")
+
+	// ContainerPreempted denotes that the cluster stopped the container to make room for other
+	// work, e.g., when Kubernetes preempts or evicts a pod.
+	ContainerPreempted = FailureType("container was preempted by the cluster")
 )
@@ -30,11 +30,19 @@ type MasterInfo struct {
 	Telemetry   TelemetryInfo `json:"telemetry"`
 }
 
+// RegistrationTokenHeader is the header in which agents present their registration token when they
+// connect to a master that requires one.
+const RegistrationTokenHeader = "X-Determined-Agent-Token"
+
 // MasterMessage is a union type for all messages sent from agents.
 type MasterMessage struct {
 	AgentStarted          *AgentStarted
 	ContainerStateChanged *ContainerStateChanged
 	ContainerLog          *ContainerLog
+	AgentHeartbeat        *AgentHeartbeat
+	AgentInterrupted      *AgentInterrupted
+	ContainerProfile      *ContainerProfile
+	MIGConfigured         *MIGConfigured
 }
 
 // AgentStarted notifies the master that the agent has started up.
@@ -42,6 +50,68 @@ type AgentStarted struct {
 	Version string
 	Label   string
 	Devices []device.Device
+	// Platform is the "os/arch" platform of the agent host, e.g. "linux/arm64".
+	Platform string
+	// Topology describes how the agent's GPUs are interconnected; it is nil if the agent could
+	// not detect it.
+	Topology *device.Topology
+	// Containers are the task containers that the agent found running when it started, e.g. those
+	// it left running when it restarted along with the master.
+	Containers []container.Container
+	// MaxZeroSlotContainers limits the zero-slot containers that the agent runs at once, below the
+	// limit of its resource pool; it is zero if the agent leaves it to the pool.
+	MaxZeroSlotContainers int
+}
+
+// AgentHeartbeat periodically notifies the master that the agent is alive, along with health
+// information about the host the agent is running on.
+type AgentHeartbeat struct {
+	SentAt          time.Time
+	DiskUsedPercent float64
+	Devices         []DeviceHealth
+	Utilization     []DeviceUtilization
+}
+
+// AgentInterrupted notifies the master that the cloud provider is about to reclaim the spot or
+// preemptible instance the agent is running on.
+type AgentInterrupted struct {
+	Deadline time.Time
+}
+
+// DeviceHealth describes the health of a single device on the agent.
+type DeviceHealth struct {
+	ID int
+	// ECCErrors is the number of uncorrectable ECC errors the device has reported.
+	ECCErrors int
+	// XIDErrors is the number of fatal XID errors the driver has logged for the device.
+	XIDErrors int
+	// Error is set if the health of the device could not be queried.
+	Error string
+}
+
+// DeviceUtilization is a sample of how heavily a device on the agent is being used.
+type DeviceUtilization struct {
+	ID                 int
+	UtilizationPercent float64
+	MemoryUsedMiB      int
+	MemoryTotalMiB     int
+}
+
+// ContainerProfile is a sample of how heavily a running task container uses the resources of the
+// agent, which the agent sends every profiling period.
+type ContainerProfile struct {
+	Container container.Container
+	Time      time.Time
+	// CPUUtilizationPercent is the CPU time the container used since the previous sample, as a
+	// percentage of one core.
+	CPUUtilizationPercent float64
+	MemoryUsedBytes       uint64
+	// The network rates are zero for containers that share the network of the host, since their
+	// traffic cannot be told apart from that of the host.
+	NetworkRxBytesPerSecond float64
+	NetworkTxBytesPerSecond float64
+	// GPUs are samples of the GPUs of the container.
+	GPUs []DeviceUtilization
 }
 
 // ContainerStateChanged notifies the master that the agent transitioned the container state.
@@ -126,6 +196,21 @@ func (c ContainerStarted) Addresses() []container.Address {
 	return addresses
 }
 
+// NetworkIPs returns the IP addresses of the container on its Docker networks. Containers that use
+// the network of their host have none.
+func (c ContainerStarted) NetworkIPs() []string {
+	if c.ContainerInfo.NetworkSettings == nil {
+		return nil
+	}
+	var ips []string
+	for _, network := range c.ContainerInfo.NetworkSettings.Networks {
+		if network != nil && network.IPAddress != "" {
+			ips = append(ips, network.IPAddress)
+		}
+	}
+	return ips
+}
+
 // ContainerStopped notifies the master that a container was stopped on the agent.
 type ContainerStopped struct {
 	Failure *ContainerFailure
@@ -153,3 +238,9 @@ type RunMessage struct {
 	Value   string
 	StdType stdcopy.StdType
 }
+
+// MIGConfigured notifies the master that the agent partitioned its GPUs as asked by ConfigureMIG,
+// after which it restarts, or why it could not.
+type MIGConfigured struct {
+	Error string
+}
@@ -0,0 +1,22 @@
+package container
+
+import "fmt"
+
+// TaskNetwork is a network private to the containers of a single multi-container task. Each
+// container joins it under the hostname of its rank, so that concurrent distributed tasks on the
+// same agents do not contend for ports on the host network.
+type TaskNetwork struct {
+	// Name is the name of the Docker network.
+	Name string `json:"name"`
+	// Peers are the hostnames of the task's containers on the network, in rank order.
+	Peers []string `json:"peers"`
+}
+
+// NewTaskNetwork returns the network for a task with the given number of containers.
+func NewTaskNetwork(taskID string, containers int) *TaskNetwork {
+	peers := make([]string, 0, containers)
+	for rank := 0; rank < containers; rank++ {
+		peers = append(peers, fmt.Sprintf("rank-%d", rank))
+	}
+	return &TaskNetwork{Name: "det-task-" + taskID, Peers: peers}
+}
@@ -18,6 +18,8 @@ type Spec struct {
 type PullSpec struct {
 	ForcePull bool
 	Registry  *types.AuthConfig
+	// Platform is the "os/arch" variant of the image to pull; empty means the daemon's default.
+	Platform string
 }
 
 // RunSpec contains configs for ContainerCreate, CopyToContainer, and ContainerStart calls.
@@ -27,7 +29,15 @@ type RunSpec struct {
 	NetworkingConfig network.NetworkingConfig
 	ChecksConfig     ChecksConfig
 
-	Archives         []RunArchive
+	// TaskNetworkDriver, if set, is the Docker network driver with which the agent creates the
+	// network named by the network mode of the container before creating the container, unless the
+	// network already exists; the agent removes the network again once the container exits.
+	TaskNetworkDriver string
+
+	Archives []RunArchive
+	// RemoteArchives are archives that the agent downloads from the master and copies into the
+	// container along with Archives, so that the master does not send or hold their files.
+	RemoteArchives   []RemoteArchive
 	UseFluentLogging bool
 }
 
@@ -53,3 +63,18 @@ type RunArchive struct {
 	Archive     archive.Archive
 	CopyOptions types.CopyToContainerOptions
 }
+
+// RemoteArchive is a .tar.gz artifact of the master that the agent downloads and copies into a
+// container before starting it.
+type RemoteArchive struct {
+	Path       string
+	ArtifactID string
+	// TaskToken authenticates the download as the task of the container. TaskCert and TaskKey are
+	// the PEM-encoded client certificate and key of the task, if the master requires them.
+	TaskToken string
+	TaskCert  []byte
+	TaskKey   []byte
+	// UserID and GroupID own the files of the archive in the container.
+	UserID  int
+	GroupID int
+}
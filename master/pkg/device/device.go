@@ -2,6 +2,8 @@ package device
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/determined-ai/determined/proto/pkg/devicev1"
 )
@@ -38,12 +40,55 @@ type Device struct {
 	Brand string `json:"brand"`
 	UUID  string `json:"uuid"`
 	Type  Type   `json:"type"`
+	// MIGProfile is the profile, like 3g.20gb, of the MIG instance that the device is, and ParentID
+	// the ID of the GPU that the instance is a partition of; both are unset for whole devices.
+	MIGProfile string `json:"mig_profile,omitempty"`
+	ParentID   int    `json:"parent_id,omitempty"`
 }
 
 func (d *Device) String() string {
 	return fmt.Sprintf("%s%d (%s)", d.Type, d.ID, d.Brand)
 }
 
+// PhysicalID returns the ID of the GPU that the device is: its own, or for a MIG instance, the ID
+// of the GPU that it is a partition of.
+func (d *Device) PhysicalID() int {
+	if d.MIGProfile != "" {
+		return d.ParentID
+	}
+	return d.ID
+}
+
+// IsModel returns true if the brand of the device is, or contains as a word, the model, ignoring
+// case; a100, NVIDIA A100 and nvidia-a100-sxm4-40gb are all models of an NVIDIA A100-SXM4-40GB.
+func (d *Device) IsModel(model string) bool {
+	if strings.EqualFold(d.Brand, model) {
+		return true
+	}
+	brand := words(d.Brand)
+	want := words(model)
+	for i := 0; len(want) > 0 && i+len(want) <= len(brand); i++ {
+		match := true
+		for j := range want {
+			if !strings.EqualFold(brand[i+j], want[j]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits a string into its runs of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
 // Proto returns the proto representation of the device.
 func (d *Device) Proto() *devicev1.Device {
 	if d == nil {
@@ -54,5 +99,8 @@ func (d *Device) Proto() *devicev1.Device {
 		Brand: d.Brand,
 		Uuid:  d.UUID,
 		Type:  d.Type.Proto(),
+
+		MigProfile: d.MIGProfile,
+		ParentId:   int32(d.ParentID),
 	}
 }
@@ -0,0 +1,52 @@
+package device
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Topology describes how the GPUs of an agent are connected to each other and to the agent's
+// network interfaces. GPUs are identified by their device IDs.
+type Topology struct {
+	// NVLinks maps each GPU to the GPUs it is directly connected to over NVLink.
+	NVLinks map[int][]int `json:"nvlinks"`
+	// NICs maps each GPU to the RDMA network interfaces that share a PCIe switch with it, so
+	// that traffic between the two does not have to cross the CPU.
+	NICs map[int][]string `json:"nics"`
+}
+
+// NVLinked returns true if the two GPUs are directly connected over NVLink.
+func (t *Topology) NVLinked(a, b int) bool {
+	if t == nil {
+		return false
+	}
+	for _, peer := range t.NVLinks[a] {
+		if peer == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Rails returns a description of which network interfaces are local to which of the given GPUs,
+// or the empty string if any of them has no local network interface. Agents whose GPUs are
+// wired to their network interfaces in the same way return the same rails.
+func (t *Topology) Rails(gpus []int) string {
+	if t == nil || len(gpus) == 0 {
+		return ""
+	}
+	sorted := append([]int(nil), gpus...)
+	sort.Ints(sorted)
+
+	rails := make([]string, 0, len(sorted))
+	for _, gpu := range sorted {
+		nics := append([]string(nil), t.NICs[gpu]...)
+		if len(nics) == 0 {
+			return ""
+		}
+		sort.Strings(nics)
+		rails = append(rails, fmt.Sprintf("%d=%s", gpu, strings.Join(nics, "+")))
+	}
+	return strings.Join(rails, ",")
+}
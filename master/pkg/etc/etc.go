@@ -20,10 +20,18 @@ const (
 	ShellEntrypointResource = "shell-entrypoint.sh"
 	// GCCheckpointsEntrypointResource is the script to run checkpoint GC.
 	GCCheckpointsEntrypointResource = "gc-checkpoints-entrypoint.sh"
+	// VerifyCheckpointsEntrypointResource is the script to run checkpoint verification.
+	VerifyCheckpointsEntrypointResource = "verify-checkpoints-entrypoint.sh"
+	// BatchInferenceEntrypointResource is the script to run a batch inference job.
+	BatchInferenceEntrypointResource = "batch-inference-entrypoint.sh"
 	// NotebookTemplateResource is the template notebook config file.
 	NotebookTemplateResource = "notebook-template.ipynb"
 	// NotebookEntrypointResource is the script to set up a notebook.
 	NotebookEntrypointResource = "notebook-entrypoint.sh"
+	// CodeServerEntrypointResource is the script to set up a VS Code server.
+	CodeServerEntrypointResource = "code-server-entrypoint.sh"
+	// RayEntrypointResource is the script to start the head or a worker of a Ray cluster.
+	RayEntrypointResource = "ray-entrypoint.sh"
 	// TensorboardEntryScriptResource is the script to set up TensorBoard.
 	TensorboardEntryScriptResource = "tensorboard-entrypoint.sh"
 	// TrialEntrypointScriptResource is the script to set up a trial.
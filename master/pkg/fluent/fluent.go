@@ -63,6 +63,8 @@ func makeOutputConfig(
   Json_date_key timestamp
   Json_date_format iso8601
   storage.total_limit_size 1G
+  # The master rejects logs while too many wait to be saved; keep retrying them until it catches up.
+  Retry_Limit False
 `, masterHost, masterPort)
 
 	case loggingConfig.ElasticLoggingConfig != nil:
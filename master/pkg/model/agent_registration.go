@@ -0,0 +1,35 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// AgentToken represents a row from the `agent_registration_tokens` table: a token that agents
+// present to join the cluster when the master requires one. Only the hash of the token is stored.
+type AgentToken struct {
+	ID int `db:"id"`
+	// ResourcePool is the resource pool that the token lets agents join, or empty for any pool.
+	ResourcePool string    `db:"resource_pool"`
+	Description  string    `db:"description"`
+	TokenHash    string    `db:"token_hash"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// HashAgentToken returns the hash of an agent registration token that is stored.
+func HashAgentToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// AgentIdentity is what the master knows of a connected agent beyond the ID that it reports, which
+// any host can claim.
+type AgentIdentity struct {
+	// Address is the host that the agent connected from.
+	Address string
+	// TokenID is the registration token that the agent presented, if the master requires one.
+	TokenID *int
+	// Certified is set if the agent connected with the certificate issued for its ID.
+	Certified bool
+}
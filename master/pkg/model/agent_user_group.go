@@ -46,6 +46,12 @@ func (c AgentUserGroup) Validate() []error {
 	return errs
 }
 
+// IsRoot returns true if processes running as the AgentUserGroup have root privileges, either
+// through the root user or the root group.
+func (c AgentUserGroup) IsRoot() bool {
+	return c.UID == 0 || c.GID == 0
+}
+
 // OwnedArchiveItem will create an archive.Item owned by the AgentUserGroup, or by root if c is nil.
 func (c *AgentUserGroup) OwnedArchiveItem(
 	path string, content []byte, mode int, fileType byte,
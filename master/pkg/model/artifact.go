@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// ArtifactType is what an artifact is used for.
+type ArtifactType string
+
+const (
+	// ArtifactTypeContext is a .tar.gz archive that a user uploaded as the context of commands.
+	ArtifactTypeContext ArtifactType = "CONTEXT"
+	// ArtifactTypeOutput is a file that a task uploaded as its output.
+	ArtifactTypeOutput ArtifactType = "OUTPUT"
+)
+
+// ArtifactState is the state of the upload of an artifact.
+type ArtifactState string
+
+const (
+	// ArtifactStateUploading denotes that chunks of the artifact are still being uploaded.
+	ArtifactStateUploading ArtifactState = "UPLOADING"
+	// ArtifactStateCompleted denotes that the artifact was uploaded in full and can be downloaded.
+	ArtifactStateCompleted ArtifactState = "COMPLETED"
+)
+
+// Artifact represents a row from the `artifacts` table: a file whose contents are stored as
+// numbered chunks in the artifact storage of the master, or as the blobs listed by its manifest.
+type Artifact struct {
+	ID    string        `db:"id"`
+	Name  string        `db:"name"`
+	Type  ArtifactType  `db:"type"`
+	State ArtifactState `db:"state"`
+	// TaskID is the task that uploaded an output artifact.
+	TaskID         *string    `db:"task_id"`
+	OwnerID        UserID     `db:"owner_id"`
+	SizeBytes      int64      `db:"size_bytes"`
+	NumChunks      int        `db:"num_chunks"`
+	CreationTime   time.Time  `db:"creation_time"`
+	CompletionTime *time.Time `db:"completion_time"`
+	// Deduplicated denotes that the chunks of the artifact are blobs, which are stored once by the
+	// hashes of their contents and listed by the manifest of the artifact.
+	Deduplicated bool `db:"deduplicated"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// AuditEntry represents a row from the `audit_log` table: an API call that changed, or tried to
+// change, the state of the cluster.
+type AuditEntry struct {
+	ID   int64     `db:"id" json:"id"`
+	Time time.Time `db:"time" json:"time"`
+	// UserID and Username identify the user that made the call. Username may be set without
+	// UserID, e.g. for failed logins.
+	UserID   *UserID `db:"user_id" json:"user_id"`
+	Username string  `db:"username" json:"username"`
+	// TaskID identifies the task that made the call, if it authenticated with a task token.
+	TaskID  string `db:"task_id" json:"task_id"`
+	Address string `db:"address" json:"address"`
+	// Method is the name of the gRPC method or the HTTP method and path of the call.
+	Method string `db:"method" json:"method"`
+	// Request holds the request as JSON with secrets removed.
+	Request string `db:"request" json:"request"`
+	Outcome string `db:"outcome" json:"outcome"`
+}
@@ -0,0 +1,21 @@
+package model
+
+// BatchInferenceJob represents a row from the `batch_inference_jobs` table: the predictions of a
+// checkpoint on the files under an input path, which are written under an output path. The
+// model version is recorded when the checkpoint was chosen by one, so that the predictions can be
+// traced back to it.
+type BatchInferenceJob struct {
+	TaskID         string  `db:"task_id"`
+	CheckpointUUID string  `db:"checkpoint_uuid"`
+	ModelName      *string `db:"model_name"`
+	ModelVersion   *int    `db:"model_version"`
+	// Predictor is the "module:function" that the job calls with the path of the checkpoint and
+	// those of each input file and its output file.
+	Predictor        string  `db:"predictor"`
+	InputPath        string  `db:"input_path"`
+	OutputPath       string  `db:"output_path"`
+	Progress         float64 `db:"progress"`
+	RecordsProcessed int64   `db:"records_processed"`
+	Succeeded        *bool   `db:"succeeded"`
+	ExitStatus       *string `db:"exit_status"`
+}
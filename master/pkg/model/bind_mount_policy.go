@@ -0,0 +1,69 @@
+package model
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// BindMountPolicy restricts the host paths that tasks may bind-mount. A path is covered by an
+// entry if it is the entry itself or lies underneath it.
+type BindMountPolicy struct {
+	// AllowedHostPaths, if not empty, are the only host paths that may be mounted.
+	AllowedHostPaths []string `json:"allowed_host_paths"`
+	// DeniedHostPaths may never be mounted, even if they are also allowed.
+	DeniedHostPaths []string `json:"denied_host_paths"`
+}
+
+// Validate implements the check.Validatable interface.
+func (p BindMountPolicy) Validate() []error {
+	var errs []error
+	for _, path := range append(append([]string{}, p.AllowedHostPaths...), p.DeniedHostPaths...) {
+		errs = append(errs, check.True(filepath.IsAbs(path),
+			"bind mount policy paths must be absolute, got %q", path))
+	}
+	return errs
+}
+
+// CheckHostPath returns an error if the policy forbids mounting the host path.
+func (p BindMountPolicy) CheckHostPath(hostPath string) error {
+	if denied := coveringPath(p.DeniedHostPaths, hostPath); denied != "" {
+		return errors.Errorf("bind mounts of %s are not allowed: %s is denied by the cluster's "+
+			"bind mount policy", hostPath, denied)
+	}
+	if len(p.AllowedHostPaths) > 0 && coveringPath(p.AllowedHostPaths, hostPath) == "" {
+		return errors.Errorf("bind mounts of %s are not allowed: the cluster's bind mount policy "+
+			"only allows mounting %s", hostPath, strings.Join(p.AllowedHostPaths, ", "))
+	}
+	return nil
+}
+
+// CheckHostPaths returns an error describing every host path that the policy forbids mounting.
+func (p BindMountPolicy) CheckHostPaths(hostPaths ...string) error {
+	var msgs []string
+	for _, hostPath := range hostPaths {
+		if err := p.CheckHostPath(hostPath); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// coveringPath returns the first of the paths that is or contains the target, or "" if none do.
+// Paths are compared lexically, so the target is not resolved through symlinks.
+func coveringPath(paths []string, target string) string {
+	target = filepath.Clean(target)
+	for _, path := range paths {
+		clean := filepath.Clean(path)
+		if target == clean || strings.HasPrefix(target, strings.TrimSuffix(clean, "/")+"/") {
+			return path
+		}
+	}
+	return ""
+}
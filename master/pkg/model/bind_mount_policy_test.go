@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestBindMountPolicy(t *testing.T) {
+	policy := BindMountPolicy{
+		AllowedHostPaths: []string{"/data", "/scratch/"},
+		DeniedHostPaths:  []string{"/data/secrets"},
+	}
+	if err := check.Validate(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		hostPath string
+		allowed  bool
+	}{
+		{"/data", true},
+		{"/data/imagenet", true},
+		{"/scratch/user", true},
+		{"/data/../etc", false},
+		{"/database", false},
+		{"/data/secrets", false},
+		{"/data/secrets/key", false},
+		{"/", false},
+	}
+	for _, tc := range tests {
+		if err := policy.CheckHostPath(tc.hostPath); (err == nil) != tc.allowed {
+			t.Errorf("CheckHostPath(%q) = %v, expected allowed: %v", tc.hostPath, err, tc.allowed)
+		}
+	}
+
+	if err := (BindMountPolicy{}).CheckHostPath("/"); err != nil {
+		t.Errorf("expected an empty policy to allow every path, got %v", err)
+	}
+	if err := check.Validate(BindMountPolicy{DeniedHostPaths: []string{"data"}}); err == nil {
+		t.Error("expected a relative policy path to be invalid")
+	}
+}
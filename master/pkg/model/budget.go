@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+)
+
+// BudgetUnit is what a budget limits.
+type BudgetUnit string
+
+const (
+	// BudgetUnitSlotHours limits the slot-hours that tasks are assigned.
+	BudgetUnitSlotHours BudgetUnit = "SLOT_HOURS"
+	// BudgetUnitCost limits the estimated cost of the slot-hours that tasks are assigned, at the
+	// prices of their resource pools.
+	BudgetUnitCost BudgetUnit = "COST"
+)
+
+// BudgetMode is how a budget is enforced once it is exceeded.
+type BudgetMode string
+
+const (
+	// BudgetModeSoft only warns that the budget is exceeded.
+	BudgetModeSoft BudgetMode = "SOFT"
+	// BudgetModeHard holds new allocations until the budget is no longer exceeded.
+	BudgetModeHard BudgetMode = "HARD"
+)
+
+// Budget represents a row from the `budgets` table: a limit on the usage of resources by the tasks
+// of a user or of a project during each calendar month, in UTC.
+type Budget struct {
+	ID        int        `db:"id"`
+	UserID    *UserID    `db:"user_id"`
+	ProjectID *int       `db:"project_id"`
+	Unit      BudgetUnit `db:"unit"`
+	Limit     float64    `db:"limit_value"`
+	Mode      BudgetMode `db:"mode"`
+	// Preempt also releases the resources of the running preemptible tasks of a hard budget once it
+	// is exceeded.
+	Preempt bool `db:"preempt"`
+}
+
+// Applies returns whether the budget limits the tasks of the owner in the project.
+func (b Budget) Applies(ownerID UserID, projectID int) bool {
+	return (b.UserID != nil && *b.UserID == ownerID) ||
+		(b.ProjectID != nil && *b.ProjectID == projectID)
+}
+
+// Usage returns the usage of the budget in its unit, given the slot-hours used in each resource
+// pool and the price of a slot-hour in each pool that has one. Slot-hours in pools without a price
+// cost nothing.
+func (b Budget) Usage(slotHours map[string]float64, prices map[string]float64) float64 {
+	usage := 0.0
+	for pool, hours := range slotHours {
+		if b.Unit == BudgetUnitCost {
+			hours *= prices[pool]
+		}
+		usage += hours
+	}
+	return usage
+}
+
+// BudgetPeriodStart returns the start of the month that budgets are accounted for at the time.
+func BudgetPeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
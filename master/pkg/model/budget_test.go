@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestBudgetUsage(t *testing.T) {
+	slotHours := map[string]float64{"gpu": 10, "cpu": 4}
+	prices := map[string]float64{"gpu": 2.5}
+
+	budget := Budget{Unit: BudgetUnitSlotHours}
+	assert.Equal(t, budget.Usage(slotHours, prices), 14.0)
+	budget.Unit = BudgetUnitCost
+	assert.Equal(t, budget.Usage(slotHours, prices), 25.0)
+}
+
+func TestBudgetApplies(t *testing.T) {
+	userID, projectID := UserID(1), 2
+	assert.Assert(t, Budget{UserID: &userID}.Applies(1, 3))
+	assert.Assert(t, !Budget{UserID: &userID}.Applies(2, 2))
+	assert.Assert(t, Budget{ProjectID: &projectID}.Applies(2, 2))
+}
+
+func TestBudgetPeriodStart(t *testing.T) {
+	now := time.Date(2021, 6, 17, 13, 30, 0, 0, time.UTC)
+	assert.Equal(t, BudgetPeriodStart(now), time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,37 @@
+package model
+
+import "github.com/determined-ai/determined/proto/pkg/checkpointv1"
+
+// CheckpointIntegrity is what the last verification of the files of a checkpoint found.
+type CheckpointIntegrity string
+
+const (
+	// CheckpointUnverified denotes that the checkpoint was never verified.
+	CheckpointUnverified CheckpointIntegrity = "UNVERIFIED"
+	// CheckpointVerified denotes that all files of the checkpoint match their recorded sizes and
+	// digests.
+	CheckpointVerified CheckpointIntegrity = "VERIFIED"
+	// CheckpointCorrupted denotes that some files of the checkpoint are missing or do not match
+	// their recorded sizes or digests.
+	CheckpointCorrupted CheckpointIntegrity = "CORRUPTED"
+	// CheckpointMissing denotes that none of the files of the checkpoint could be read from its
+	// storage.
+	CheckpointMissing CheckpointIntegrity = "MISSING"
+)
+
+// CheckpointIntegrityFromProto returns the integrity of a checkpoint from its proto
+// representation, or an empty integrity if it is unspecified.
+func CheckpointIntegrityFromProto(i checkpointv1.Integrity) CheckpointIntegrity {
+	switch i {
+	case checkpointv1.Integrity_INTEGRITY_UNVERIFIED:
+		return CheckpointUnverified
+	case checkpointv1.Integrity_INTEGRITY_VERIFIED:
+		return CheckpointVerified
+	case checkpointv1.Integrity_INTEGRITY_CORRUPTED:
+		return CheckpointCorrupted
+	case checkpointv1.Integrity_INTEGRITY_MISSING:
+		return CheckpointMissing
+	default:
+		return ""
+	}
+}
@@ -1,9 +1,17 @@
 package model
 
 import (
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/determined-ai/determined/master/pkg/check"
 )
 
+// DefaultWorkspaceMountPath is where workspaces are mounted unless they specify otherwise. It is
+// inside the working directory of the task, so that notebooks show it in their file browser.
+const DefaultWorkspaceMountPath = "/run/determined/workdir/workspace"
+
 // CommandConfig holds the necessary configurations to launch a command task in
 // the cluster.
 type CommandConfig struct {
@@ -13,6 +21,18 @@ type CommandConfig struct {
 	Resources       ResourcesConfig  `json:"resources"`
 	Entrypoint      []string         `json:"entrypoint"`
 	TensorBoardArgs []string         `json:"tensorboard_args"`
+	// Workspace is a persistent volume that holds the command's files on Kubernetes.
+	Workspace *WorkspaceConfig `json:"workspace"`
+	// Datasets are the versions of registered datasets that the command uses.
+	Datasets []DatasetReference `json:"datasets"`
+	// StartupTimeout is the number of seconds after its resources are allocated that the command
+	// must become ready within, or be killed. Zero disables it.
+	StartupTimeout int `json:"startup_timeout"`
+	// MaxRuntime is the number of seconds after its resources are allocated that the command is
+	// killed. Zero disables it.
+	MaxRuntime int `json:"max_runtime"`
+	// StopPolicy is how the containers of the command are stopped when it is killed.
+	StopPolicy
 }
 
 // Validate implements the check.Validatable interface.
@@ -20,5 +40,36 @@ func (c *CommandConfig) Validate() []error {
 	return []error{
 		check.GreaterThanOrEqualTo(c.Resources.Slots, 0, "resources.slots must be >= 0"),
 		check.GreaterThan(len(c.Entrypoint), 0, "entrypoint must be non-empty"),
+		check.GreaterThanOrEqualTo(c.StartupTimeout, 0, "startup_timeout must be >= 0"),
+		check.GreaterThanOrEqualTo(c.MaxRuntime, 0, "max_runtime must be >= 0"),
+	}
+}
+
+// WorkspaceConfig configures the persistent volume claim that backs a command's workspace on
+// Kubernetes. The claim is created if it does not exist. Claims that are named in the config are
+// kept when the command exits, so that a later command can attach them again; otherwise the
+// claim is deleted along with the command.
+type WorkspaceConfig struct {
+	ClaimName    string  `json:"claim_name"`
+	Size         string  `json:"size"`
+	StorageClass *string `json:"storage_class"`
+	MountPath    string  `json:"mount_path"`
+}
+
+// Validate implements the check.Validatable interface.
+func (w WorkspaceConfig) Validate() []error {
+	_, err := resource.ParseQuantity(w.Size)
+	return []error{
+		check.True(err == nil, "workspace.size must be a Kubernetes quantity such as 10Gi"),
+		check.True(w.MountPath == "" || filepath.IsAbs(w.MountPath),
+			"workspace.mount_path must be an absolute path"),
+	}
+}
+
+// ContainerPath returns where the workspace is mounted in the container.
+func (w WorkspaceConfig) ContainerPath() string {
+	if w.MountPath == "" {
+		return DefaultWorkspaceMountPath
 	}
+	return w.MountPath
 }
@@ -13,6 +13,7 @@ func TestConfigValidate(t *testing.T) {
 		Environment Environment
 		Resources   ResourcesConfig
 		Entrypoint  []string
+		Workspace   *WorkspaceConfig
 	}
 	type testCase struct {
 		name    string
@@ -45,6 +46,35 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid workspace",
+			fields: fields{
+				Resources:   resources,
+				Environment: environment,
+				Entrypoint:  []string{"test"},
+				Workspace:   &WorkspaceConfig{Size: "10Gi", MountPath: "/workspace"},
+			},
+		},
+		{
+			name: "invalid workspace size",
+			fields: fields{
+				Resources:   resources,
+				Environment: environment,
+				Entrypoint:  []string{"test"},
+				Workspace:   &WorkspaceConfig{Size: "ten gigabytes"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "relative workspace mount path",
+			fields: fields{
+				Resources:   resources,
+				Environment: environment,
+				Entrypoint:  []string{"test"},
+				Workspace:   &WorkspaceConfig{Size: "10Gi", MountPath: "workspace"},
+			},
+			wantErr: true,
+		},
 	}
 	runTestCase := func(t *testing.T, tc testCase) {
 		t.Run(tc.name, func(t *testing.T) {
@@ -54,6 +84,7 @@ func TestConfigValidate(t *testing.T) {
 				Environment: tc.fields.Environment,
 				Resources:   tc.fields.Resources,
 				Entrypoint:  tc.fields.Entrypoint,
+				Workspace:   tc.fields.Workspace,
 			}
 			if err := check.Validate(c); (err != nil) != tc.wantErr {
 				t.Errorf("config.Validate() error = %v, wantErr %v", err, tc.wantErr)
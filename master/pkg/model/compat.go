@@ -27,16 +27,21 @@ func (d DevicesConfig) ToExpconf() expconf.DevicesConfig {
 // ToExpconf translates old model objects into an expconf object.
 func (r ResourcesConfig) ToExpconf() expconf.ResourcesConfig {
 	return schemas.WithDefaults(expconf.ResourcesConfig{
-		RawSlots:          ptrs.IntPtr(r.Slots),
-		RawMaxSlots:       r.MaxSlots,
-		RawSlotsPerTrial:  ptrs.IntPtr(r.SlotsPerTrial),
-		RawWeight:         ptrs.Float64Ptr(r.Weight),
-		RawNativeParallel: ptrs.BoolPtr(r.NativeParallel),
-		RawShmSize:        r.ShmSize,
-		RawAgentLabel:     ptrs.StringPtr(r.AgentLabel),
-		RawResourcePool:   ptrs.StringPtr(r.ResourcePool),
-		RawPriority:       r.Priority,
-		RawDevices:        r.Devices.ToExpconf(),
+		RawSlots:            ptrs.IntPtr(r.Slots),
+		RawMaxSlots:         r.MaxSlots,
+		RawSlotsPerTrial:    ptrs.IntPtr(r.SlotsPerTrial),
+		RawWeight:           ptrs.Float64Ptr(r.Weight),
+		RawNativeParallel:   ptrs.BoolPtr(r.NativeParallel),
+		RawShmSize:          r.ShmSize,
+		RawCPULimit:         r.CPULimit,
+		RawMemoryLimit:      r.MemoryLimit,
+		RawAgentLabel:       ptrs.StringPtr(r.AgentLabel),
+		RawResourcePool:     ptrs.StringPtr(r.ResourcePool),
+		RawPriority:         r.Priority,
+		RawPlatform:         ptrs.StringPtr(r.Platform),
+		RawGPUType:          ptrs.StringPtr(r.GPUType),
+		RawMinSlotsPerTrial: r.MinSlotsPerTrial,
+		RawDevices:          r.Devices.ToExpconf(),
 	}).(expconf.ResourcesConfig)
 }
 
@@ -47,6 +52,7 @@ func (b BindMount) ToExpconf() expconf.BindMount {
 		RawContainerPath: b.ContainerPath,
 		RawReadOnly:      ptrs.BoolPtr(b.ReadOnly),
 		RawPropagation:   ptrs.StringPtr(b.Propagation),
+		RawPlatform:      ptrs.StringPtr(b.Platform),
 	}).(expconf.BindMount)
 }
 
@@ -66,6 +72,7 @@ func ToModelBindMount(b expconf.BindMount) BindMount {
 		ContainerPath: b.ContainerPath(),
 		ReadOnly:      b.ReadOnly(),
 		Propagation:   b.Propagation(),
+		Platform:      b.Platform(),
 	}
 }
 
@@ -89,6 +96,7 @@ func (r RuntimeItem) ToExpconf() expconf.EnvironmentImageMap {
 func (e Environment) ToExpconf() expconf.EnvironmentConfig {
 	image := e.Image.ToExpconf()
 	vars := e.EnvironmentVariables.ToExpconf()
+	kubernetes := e.Kubernetes.ToExpconf()
 
 	return schemas.WithDefaults(expconf.EnvironmentConfig{
 		RawImage:                &image,
@@ -97,5 +105,18 @@ func (e Environment) ToExpconf() expconf.EnvironmentConfig {
 		RawRegistryAuth:         e.RegistryAuth,
 		RawForcePullImage:       ptrs.BoolPtr(e.ForcePullImage),
 		RawPodSpec:              (*expconf.PodSpec)(e.PodSpec),
+		RawKubernetes:           &kubernetes,
 	}).(expconf.EnvironmentConfig)
 }
+
+// ToExpconf translates old model objects into an expconf object.
+func (k KubernetesConfig) ToExpconf() expconf.KubernetesConfig {
+	out := expconf.KubernetesConfig{RawTolerations: k.Tolerations}
+	if k.Namespace != "" {
+		out.RawNamespace = ptrs.StringPtr(k.Namespace)
+	}
+	if k.ServiceAccountName != "" {
+		out.RawServiceAccountName = ptrs.StringPtr(k.ServiceAccountName)
+	}
+	return out
+}
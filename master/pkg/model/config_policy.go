@@ -0,0 +1,73 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigPolicyConstraints limit the configs of the experiments and commands that a config policy
+// applies to.
+type ConfigPolicyConstraints struct {
+	// MaxSlots caps the slots of each trial and command.
+	MaxSlots *int `json:"max_slots,omitempty"`
+	// AllowedImages are the images that tasks may run. A pattern that ends with "*" matches every
+	// image that starts with the rest of it.
+	AllowedImages []string `json:"allowed_images,omitempty"`
+}
+
+// Value marshals the constraints to JSON.
+func (c ConfigPolicyConstraints) Value() (driver.Value, error) {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling config policy constraints")
+	}
+	return bytes, nil
+}
+
+// Scan unmarshals the constraints from JSON.
+func (c *ConfigPolicyConstraints) Scan(src interface{}) error {
+	bytes, ok := src.([]byte)
+	if !ok {
+		return errors.Errorf("unable to convert to []byte: %v", src)
+	}
+	*c = ConfigPolicyConstraints{}
+	return errors.Wrap(json.Unmarshal(bytes, c), "error unmarshaling config policy constraints")
+}
+
+// AllowsSlots returns whether each trial or command may use the number of slots.
+func (c ConfigPolicyConstraints) AllowsSlots(slots int) bool {
+	return c.MaxSlots == nil || slots <= *c.MaxSlots
+}
+
+// AllowsImage returns whether tasks may run the image.
+func (c ConfigPolicyConstraints) AllowsImage(image string) bool {
+	if len(c.AllowedImages) == 0 {
+		return true
+	}
+	for _, pattern := range c.AllowedImages {
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+			if strings.HasPrefix(image, prefix) {
+				return true
+			}
+		} else if image == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigPolicy represents a row from the `config_policies` table: what an admin enforces on the
+// configs of the experiments and commands launched in a workspace or by the members of a group.
+type ConfigPolicy struct {
+	ID          int  `db:"id"`
+	WorkspaceID *int `db:"workspace_id"`
+	GroupID     *int `db:"group_id"`
+	// InvariantExperimentConfig and InvariantCommandConfig are merged over the configs of
+	// experiments and commands, replacing the values that they set.
+	InvariantExperimentConfig JSONObj                 `db:"invariant_experiment_config"`
+	InvariantCommandConfig    JSONObj                 `db:"invariant_command_config"`
+	Constraints               ConfigPolicyConstraints `db:"constraints"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConfigPolicyConstraints(t *testing.T) {
+	maxSlots := 4
+	c := ConfigPolicyConstraints{
+		MaxSlots:      &maxSlots,
+		AllowedImages: []string{"determinedai/*", "ubuntu:20.04"},
+	}
+	assert.Assert(t, c.AllowsSlots(4))
+	assert.Assert(t, !c.AllowsSlots(5))
+	assert.Assert(t, c.AllowsImage("determinedai/environments:cuda-11.1"))
+	assert.Assert(t, c.AllowsImage("ubuntu:20.04"))
+	assert.Assert(t, !c.AllowsImage("ubuntu:22.04"))
+	assert.Assert(t, !c.AllowsImage("evil/determinedai/environments"))
+
+	unconstrained := ConfigPolicyConstraints{}
+	assert.Assert(t, unconstrained.AllowsSlots(64))
+	assert.Assert(t, unconstrained.AllowsImage("anything"))
+}
+
+func TestConfigPolicyConstraintsRoundTrip(t *testing.T) {
+	maxSlots := 0
+	c := ConfigPolicyConstraints{MaxSlots: &maxSlots, AllowedImages: []string{"a/*"}}
+	value, err := c.Value()
+	assert.NilError(t, err)
+	var scanned ConfigPolicyConstraints
+	assert.NilError(t, scanned.Scan(value))
+	assert.DeepEqual(t, scanned, c)
+}
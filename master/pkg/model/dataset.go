@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+// Dataset represents a row from the `datasets` table: a version of a dataset that experiments and
+// commands can declare that they use.
+type Dataset struct {
+	ID          int    `db:"id"`
+	Name        string `db:"name"`
+	Version     string `db:"version"`
+	Checksum    string `db:"checksum"`
+	Location    string `db:"location"`
+	Description string `db:"description"`
+	OwnerID     UserID `db:"owner_id"`
+	// Username is the name of the owner of the dataset, which is not stored in the table.
+	Username     string    `db:"username"`
+	CreationTime time.Time `db:"creation_time"`
+}
+
+// DatasetReference is a version of a dataset that a command declares it uses.
+type DatasetReference struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Validate implements the check.Validatable interface.
+func (d DatasetReference) Validate() []error {
+	return []error{
+		check.NotEmpty(d.Name, "datasets must have a name"),
+		check.NotEmpty(d.Version, "datasets must have a version"),
+	}
+}
+
+// String returns the reference as name:version.
+func (d DatasetReference) String() string {
+	return fmt.Sprintf("%s:%s", d.Name, d.Version)
+}
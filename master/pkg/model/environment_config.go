@@ -32,10 +32,19 @@ type Environment struct {
 	ForcePullImage bool              `json:"force_pull_image"`
 	PodSpec        *k8sV1.Pod        `json:"pod_spec"`
 
+	Kubernetes KubernetesConfig `json:"kubernetes"`
+
 	AddCapabilities  []string `json:"add_capabilities"`
 	DropCapabilities []string `json:"drop_capabilities"`
 }
 
+// KubernetesConfig configures the Kubernetes pods that a task is launched in.
+type KubernetesConfig struct {
+	Namespace          string             `json:"namespace,omitempty"`
+	ServiceAccountName string             `json:"service_account_name,omitempty"`
+	Tolerations        []k8sV1.Toleration `json:"tolerations,omitempty"`
+}
+
 // RuntimeItem configures the runtime image.
 type RuntimeItem struct {
 	CPU string `json:"cpu,omitempty"`
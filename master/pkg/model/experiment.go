@@ -46,6 +46,10 @@ const (
 	StoppingCompletedState State = "STOPPING_COMPLETED"
 	// StoppingErrorState constant.
 	StoppingErrorState State = "STOPPING_ERROR"
+	// StoppingTimeoutState constant.
+	StoppingTimeoutState State = "STOPPING_TIMEOUT"
+	// TimeoutState constant.
+	TimeoutState State = "TIMEOUT"
 
 	// TrialWorkloadSequencerType constant.
 	TrialWorkloadSequencerType WorkloadSequencerType = "TRIAL_WORKLOAD_SEQUENCER"
@@ -82,6 +86,7 @@ var StoppingStates = map[State]bool{
 	StoppingCanceledState:  true,
 	StoppingCompletedState: true,
 	StoppingErrorState:     true,
+	StoppingTimeoutState:   true,
 }
 
 // TerminalStates are the valid terminal states.
@@ -89,6 +94,7 @@ var TerminalStates = map[State]bool{
 	CanceledState:  true,
 	CompletedState: true,
 	ErrorState:     true,
+	TimeoutState:   true,
 }
 
 // ManualStates are the states the user can set an experiment to.
@@ -103,6 +109,7 @@ var StoppingToTerminalStates = map[State]State{
 	StoppingCanceledState:  CanceledState,
 	StoppingCompletedState: CompletedState,
 	StoppingErrorState:     ErrorState,
+	StoppingTimeoutState:   TimeoutState,
 }
 
 // ExperimentTransitions maps experiment states to their possible transitions.
@@ -112,15 +119,18 @@ var ExperimentTransitions = map[State]map[State]bool{
 		StoppingCanceledState:  true,
 		StoppingCompletedState: true,
 		StoppingErrorState:     true,
+		StoppingTimeoutState:   true,
 	},
 	CanceledState:  {},
 	CompletedState: {},
 	ErrorState:     {},
+	TimeoutState:   {},
 	PausedState: {
 		ActiveState:            true,
 		StoppingCanceledState:  true,
 		StoppingCompletedState: true,
 		StoppingErrorState:     true,
+		StoppingTimeoutState:   true,
 	},
 	StoppingCanceledState: {
 		CanceledState:      true,
@@ -134,6 +144,10 @@ var ExperimentTransitions = map[State]map[State]bool{
 		ActiveState: true,
 		ErrorState:  true,
 	},
+	StoppingTimeoutState: {
+		TimeoutState:       true,
+		StoppingErrorState: true,
+	},
 }
 
 // ExperimentReverseTransitions lists possible ancestor states.
@@ -202,6 +216,11 @@ type Experiment struct {
 	GitCommitter         *string    `db:"git_committer"`
 	GitCommitDate        *time.Time `db:"git_commit_date"`
 	OwnerID              *UserID    `db:"owner_id"`
+	ProjectID            int        `db:"project_id"`
+	// StorageCredentials holds the storage credentials that were removed from the stored config,
+	// encrypted if StorageCredentialsEncrypted is set.
+	StorageCredentials          []byte `db:"storage_credentials" json:"-"`
+	StorageCredentialsEncrypted bool   `db:"storage_credentials_encrypted" json:"-"`
 }
 
 // ExperimentDescriptor is a minimal description of an experiment.
@@ -380,6 +399,7 @@ type Checkpoint struct {
 	EndTime           *time.Time `db:"end_time" json:"end_time"`
 	UUID              *string    `db:"uuid" json:"uuid"`
 	Resources         JSONObj    `db:"resources" json:"resources"`
+	Digests           JSONObj    `db:"digests" json:"digests"`
 	Metadata          JSONObj    `db:"metadata" json:"metadata"`
 	Framework         string     `db:"framework" json:"framework"`
 	Format            string     `db:"format" json:"format"`
@@ -227,6 +227,17 @@ type ResourcesConfig struct {
 	AgentLabel     string  `json:"agent_label"`
 	ResourcePool   string  `json:"resource_pool"`
 	Priority       *int    `json:"priority,omitempty"`
+	Platform       string  `json:"platform,omitempty"`
+	GPUType        string  `json:"gpu_type,omitempty"`
+
+	// CPULimit is the number of CPUs that the task's container may use.
+	CPULimit *float64 `json:"cpu_limit,omitempty"`
+	// MemoryLimit is the number of bytes of memory that the task's container may use.
+	MemoryLimit *int `json:"memory_limit,omitempty"`
+
+	// MinSlotsPerTrial makes trials elastic: they run with as many slots as are available, from
+	// MinSlotsPerTrial up to SlotsPerTrial.
+	MinSlotsPerTrial *int `json:"min_slots_per_trial,omitempty"`
 
 	Devices DevicesConfig `json:"devices"`
 }
@@ -269,6 +280,9 @@ func ValidatePrioritySetting(priority *int) []error {
 	return errs
 }
 
+// minMemoryLimit is the smallest memory limit that Docker accepts for a container.
+const minMemoryLimit = 6 * 1024 * 1024
+
 // Validate implements the check.Validatable interface.
 func (r ResourcesConfig) Validate() []error {
 	errs := []error{
@@ -277,6 +291,16 @@ func (r ResourcesConfig) Validate() []error {
 		check.GreaterThanOrEqualTo(
 			r.MaxSlots, r.SlotsPerTrial, "max_slots must be >= slots_per_trial"),
 		check.GreaterThanOrEqualTo(r.ShmSize, 0, "shm_size must be >= 0"),
+		check.GreaterThan(r.CPULimit, float64(0), "cpu_limit must be > 0"),
+		check.GreaterThanOrEqualTo(
+			r.MemoryLimit, minMemoryLimit, "memory_limit must be at least 6 MiB"),
+		check.GreaterThan(r.MinSlotsPerTrial, 0, "min_slots_per_trial must be > 0"),
+		check.LessThanOrEqualTo(r.MinSlotsPerTrial, r.SlotsPerTrial,
+			"min_slots_per_trial must be <= slots_per_trial"),
+	}
+	if r.Platform != "" {
+		errs = append(errs, check.Match(r.Platform, platformPattern,
+			"platform must be of the form os/arch, like linux/arm64"))
 	}
 	errs = append(errs, ValidatePrioritySetting(r.Priority)...)
 	return errs
@@ -326,6 +350,8 @@ type BindMount struct {
 	ContainerPath string `json:"container_path"`
 	ReadOnly      bool   `json:"read_only"`
 	Propagation   string `json:"propagation"`
+	// Platform restricts the mount to tasks running on the given "os/arch" platform.
+	Platform string `json:"platform,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestExperimentTimeoutTransition(t *testing.T) {
+	e := &Experiment{State: PausedState}
+	changed, err := e.Transition(StoppingTimeoutState)
+	assert.NilError(t, err)
+	assert.Assert(t, changed)
+	assert.Assert(t, e.EndTime == nil)
+
+	_, err = e.Transition(ActiveState)
+	assert.ErrorContains(t, err, "illegal transition")
+
+	changed, err = e.Transition(StoppingToTerminalStates[e.State])
+	assert.NilError(t, err)
+	assert.Assert(t, changed)
+	assert.Equal(t, e.State, TimeoutState)
+	assert.Assert(t, e.EndTime != nil)
+}
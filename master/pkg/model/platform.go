@@ -0,0 +1,14 @@
+package model
+
+// DefaultPlatform is the platform assumed for agents and tasks that do not declare one.
+const DefaultPlatform = "linux/amd64"
+
+const platformPattern = "^[a-z0-9_]+/[a-z0-9_]+$"
+
+// PlatformOrDefault returns the given "os/arch" platform, or DefaultPlatform if it is empty.
+func PlatformOrDefault(platform string) string {
+	if platform == "" {
+		return DefaultPlatform
+	}
+	return platform
+}
@@ -0,0 +1,157 @@
+package model
+
+import "sort"
+
+// Permission is the right to perform a kind of action through the API. Every logged in user may
+// view the cluster and its experiments and tasks; permissions only govern changes.
+type Permission string
+
+const (
+	// PermissionEditOwn allows creating experiments, tasks, templates and models, and changing
+	// the experiments and tasks the user owns.
+	PermissionEditOwn Permission = "edit_own"
+	// PermissionEditAll allows changing experiments and tasks owned by other users.
+	PermissionEditAll Permission = "edit_all"
+	// PermissionManageCluster allows changing cluster-level settings, e.g. disabling agents.
+	PermissionManageCluster Permission = "manage_cluster"
+	// PermissionManageRoles allows managing roles, groups, and role assignments.
+	PermissionManageRoles Permission = "manage_roles"
+	// PermissionOverridePoolRouting allows creating experiments and interactive tasks in resource
+	// pools other than those that the pool routing of the master sends them to.
+	PermissionOverridePoolRouting Permission = "override_pool_routing"
+)
+
+// AllPermissions lists every permission, which admins are implicitly granted.
+var AllPermissions = []Permission{
+	PermissionEditOwn,
+	PermissionEditAll,
+	PermissionManageCluster,
+	PermissionManageRoles,
+	PermissionOverridePoolRouting,
+}
+
+// WorkspacePermissions lists the permissions that roles assigned within a workspace grant; the
+// other permissions only apply to the cluster as a whole.
+var WorkspacePermissions = []Permission{
+	PermissionEditOwn,
+	PermissionEditAll,
+	PermissionOverridePoolRouting,
+}
+
+// IsWorkspacePermission returns true if the permission can be granted within a workspace.
+func IsWorkspacePermission(p Permission) bool {
+	for _, scoped := range WorkspacePermissions {
+		if p == scoped {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRoleName is the role of users that have not been assigned any role, either directly or
+// through one of their groups, in any workspace or for the whole cluster.
+const DefaultRoleName = "editor"
+
+// ValidPermission returns true if the permission is known.
+func ValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is the set of permissions that a user holds.
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet returns a set of the given permissions.
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// Has returns true if the set contains the permission.
+func (s PermissionSet) Has(p Permission) bool {
+	return s[p]
+}
+
+// List returns the permissions of the set in a stable order.
+func (s PermissionSet) List() []Permission {
+	permissions := make([]Permission, 0, len(s))
+	for p := range s {
+		permissions = append(permissions, p)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
+
+// Role represents a row from the `roles` table along with the permissions it grants. Built-in
+// roles cannot be changed or deleted.
+type Role struct {
+	ID          int          `db:"id" json:"id"`
+	Name        string       `db:"name" json:"name"`
+	Builtin     bool         `db:"builtin" json:"builtin"`
+	Permissions []Permission `db:"-" json:"permissions"`
+}
+
+// RoleGrant is a role that a user holds, directly or through one of their groups, for the whole
+// cluster or, if WorkspaceID is set, within a workspace.
+type RoleGrant struct {
+	WorkspaceID *int
+	Permissions []Permission
+}
+
+// appliesTo returns true if the grant applies within the workspace. Only grants for the whole
+// cluster apply to workspace 0, which stands for the cluster itself.
+func (g RoleGrant) appliesTo(workspaceID int) bool {
+	return g.WorkspaceID == nil || *g.WorkspaceID == workspaceID
+}
+
+// GrantedPermissions returns the permissions that a user holding the roles has within a
+// workspace, or for the whole cluster if the workspace is 0. Roles held within a workspace only
+// grant the permissions that apply to workspaces.
+func GrantedPermissions(grants []RoleGrant, workspaceID int) PermissionSet {
+	permissions := NewPermissionSet()
+	for _, grant := range grants {
+		if !grant.appliesTo(workspaceID) {
+			continue
+		}
+		for _, p := range grant.Permissions {
+			if grant.WorkspaceID == nil || IsWorkspacePermission(p) {
+				permissions[p] = true
+			}
+		}
+	}
+	return permissions
+}
+
+// IsWorkspaceMember returns true if a user holding the roles is a member of the workspace: they
+// hold a role, even one without permissions, within the workspace or for the whole cluster.
+func IsWorkspaceMember(grants []RoleGrant, workspaceID int) bool {
+	for _, grant := range grants {
+		if grant.appliesTo(workspaceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Group represents a row from the `groups` table along with the usernames of its members.
+type Group struct {
+	ID        int      `db:"id" json:"id"`
+	Name      string   `db:"name" json:"name"`
+	Usernames []string `db:"-" json:"usernames"`
+}
+
+// RoleAssignment grants a role to either a user or a group, within a workspace or, if the
+// workspace is not set, for the whole cluster.
+type RoleAssignment struct {
+	RoleName      string  `db:"role_name" json:"role_name"`
+	Username      *string `db:"username" json:"username"`
+	GroupName     *string `db:"group_name" json:"group_name"`
+	WorkspaceName *string `db:"workspace_name" json:"workspace_name"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestWorkspaceRoleGrants(t *testing.T) {
+	ours, theirs := 1, 2
+	editor := []Permission{PermissionEditOwn}
+	operator := []Permission{PermissionEditOwn, PermissionEditAll, PermissionOverridePoolRouting}
+	admin := AllPermissions
+
+	cases := []struct {
+		name    string
+		grants  []RoleGrant
+		member  bool
+		ours    []Permission
+		theirs  []Permission
+		cluster []Permission
+	}{
+		{
+			name:   "editor within our workspace",
+			grants: []RoleGrant{{WorkspaceID: &ours, Permissions: editor}},
+			member: true,
+			ours:   editor,
+		},
+		{
+			name:   "viewer within our workspace",
+			grants: []RoleGrant{{WorkspaceID: &ours}},
+			member: true,
+		},
+		{
+			name:   "cluster admin within our workspace",
+			grants: []RoleGrant{{WorkspaceID: &ours, Permissions: admin}},
+			member: true,
+			ours:   WorkspacePermissions,
+		},
+		{
+			name:    "operator of the cluster",
+			grants:  []RoleGrant{{Permissions: operator}},
+			member:  true,
+			ours:    operator,
+			theirs:  operator,
+			cluster: operator,
+		},
+		{
+			name: "no roles",
+		},
+	}
+	for _, tc := range cases {
+		// Only the roles held for the whole cluster make a user a member of their workspace.
+		clusterWide := tc.cluster != nil
+		assert.Equal(t, IsWorkspaceMember(tc.grants, ours), tc.member, tc.name)
+		assert.Equal(t, IsWorkspaceMember(tc.grants, theirs), clusterWide, tc.name)
+		assert.DeepEqual(t, GrantedPermissions(tc.grants, ours).List(),
+			NewPermissionSet(tc.ours...).List())
+		assert.DeepEqual(t, GrantedPermissions(tc.grants, theirs).List(),
+			NewPermissionSet(tc.theirs...).List())
+		assert.DeepEqual(t, GrantedPermissions(tc.grants, 0).List(),
+			NewPermissionSet(tc.cluster...).List())
+	}
+}
+
+func TestNonMembersCannotChangeWorkspace(t *testing.T) {
+	ours, theirs := 1, 2
+	grants := []RoleGrant{{WorkspaceID: &ours, Permissions: AllPermissions}}
+
+	// Without a role in their workspace, a user cannot see it, change its projects, or create or
+	// move experiments and tasks into it, whatever their roles elsewhere.
+	assert.Assert(t, !IsWorkspaceMember(grants, theirs))
+	for _, p := range AllPermissions {
+		assert.Assert(t, !GrantedPermissions(grants, theirs).Has(p), p)
+	}
+	// Roles within a workspace do not grant the permissions that only apply to the cluster.
+	assert.Assert(t, !GrantedPermissions(grants, ours).Has(PermissionManageCluster))
+	assert.Assert(t, !GrantedPermissions(grants, ours).Has(PermissionManageRoles))
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// RegistryCredential represents a row from the `registry_credentials` table: a user's credentials
+// for a container image registry. The password is only stored encrypted.
+type RegistryCredential struct {
+	ID                int       `db:"id" json:"id"`
+	UserID            UserID    `db:"user_id" json:"user_id"`
+	ServerAddress     string    `db:"server_address" json:"server_address"`
+	Username          string    `db:"username" json:"username"`
+	Password          string    `db:"-" json:"-"`
+	EncryptedPassword []byte    `db:"encrypted_password" json:"-"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// AuthConfig returns the credentials in the form that image pulls take them.
+func (c RegistryCredential) AuthConfig() types.AuthConfig {
+	return types.AuthConfig{
+		Username:      c.Username,
+		Password:      c.Password,
+		ServerAddress: c.ServerAddress,
+	}
+}
@@ -0,0 +1,10 @@
+package model
+
+// ResourcePool represents a row from the `resource_pools` table: a resource pool that was added
+// through the API rather than in the configuration of the master.
+type ResourcePool struct {
+	Name string `db:"name"`
+	// Config is the configuration of the pool, as in the resource_pools of the configuration of the
+	// master, without its name.
+	Config JSONObj `db:"config"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// SecretNamePattern matches valid secret names, which follow the rules of environment variable
+// names so that secrets read naturally where they are referenced.
+var SecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Secret represents a row from the `secrets` table: a named value that belongs to a user and that
+// the user's tasks can reference in their environment variables. The value is only stored
+// encrypted.
+type Secret struct {
+	ID             int       `db:"id" json:"id"`
+	UserID         UserID    `db:"user_id" json:"user_id"`
+	Name           string    `db:"name" json:"name"`
+	Value          string    `db:"-" json:"-"`
+	EncryptedValue []byte    `db:"encrypted_value" json:"-"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// SSHKey represents a row from the `user_ssh_keys` table: a public key that a user registered to
+// log in to their shells with.
+type SSHKey struct {
+	ID     int    `db:"id" json:"id"`
+	UserID UserID `db:"user_id" json:"user_id"`
+	Name   string `db:"name" json:"name"`
+	// PublicKey is the key in the format of authorized_keys files, without a comment.
+	PublicKey   string    `db:"public_key" json:"public_key"`
+	Fingerprint string    `db:"fingerprint" json:"fingerprint"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// stopSignals are the signals that tasks can be stopped with, by name.
+var stopSignals = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// StopPolicy is how the containers of a task are stopped when it is killed: they receive the stop
+// signal, and SIGKILL once the grace period passed if they still run. Without a grace period, they
+// receive SIGKILL right away.
+type StopPolicy struct {
+	// Signal is the name of the stop signal. Defaults to SIGTERM.
+	Signal string `json:"stop_signal"`
+	// GracePeriod is the number of seconds that the containers have to exit.
+	GracePeriod int `json:"stop_grace_period"`
+}
+
+// ExperimentStopPolicy returns the stop policy of the containers of an experiment, which its
+// config sets.
+func ExperimentStopPolicy(config expconf.ExperimentConfig) StopPolicy {
+	var s StopPolicy
+	if signal := config.StopSignal(); signal != nil {
+		s.Signal = *signal
+	}
+	if gracePeriod := config.StopGracePeriod(); gracePeriod != nil {
+		s.GracePeriod = *gracePeriod
+	}
+	return s
+}
+
+// Validate implements the check.Validatable interface.
+func (s StopPolicy) Validate() []error {
+	_, ok := stopSignals[s.Signal]
+	return []error{
+		check.True(s.Signal == "" || ok,
+			"stop_signal must be one of SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1 and SIGUSR2"),
+		check.GreaterThanOrEqualTo(s.GracePeriod, 0, "stop_grace_period must be >= 0"),
+	}
+}
+
+// StopSignal returns the signal that the containers receive first, which is SIGKILL without a
+// grace period.
+func (s StopPolicy) StopSignal() syscall.Signal {
+	if s.GracePeriod == 0 {
+		return syscall.SIGKILL
+	}
+	if signal, ok := stopSignals[s.Signal]; ok {
+		return signal
+	}
+	return syscall.SIGTERM
+}
+
+// GracePeriodDuration returns how long the containers have to exit after the stop signal.
+func (s StopPolicy) GracePeriodDuration() time.Duration {
+	return time.Duration(s.GracePeriod) * time.Second
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+func TestStopPolicy(t *testing.T) {
+	// Without a grace period, containers are killed right away.
+	assert.Equal(t, StopPolicy{}.StopSignal(), syscall.SIGKILL)
+	assert.Equal(t, StopPolicy{Signal: "SIGINT"}.StopSignal(), syscall.SIGKILL)
+
+	s := StopPolicy{GracePeriod: 30}
+	assert.Equal(t, s.StopSignal(), syscall.SIGTERM)
+	assert.Equal(t, s.GracePeriodDuration(), 30*time.Second)
+	s.Signal = "SIGUSR1"
+	assert.Equal(t, s.StopSignal(), syscall.SIGUSR1)
+	assert.NilError(t, check.Validate(s))
+
+	assert.ErrorContains(t, check.Validate(StopPolicy{Signal: "SIGSTOP"}), "stop_signal")
+	assert.ErrorContains(t, check.Validate(StopPolicy{GracePeriod: -1}), "stop_grace_period")
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// RedactedCredential replaces storage credentials in the configurations stored in the database.
+const RedactedCredential = "********"
+
+// StorageCredentialPaths are the paths of the storage credentials in experiment configurations
+// and templates, as section.key.
+var StorageCredentialPaths = []string{
+	"checkpoint_storage.access_key",
+	"checkpoint_storage.secret_key",
+	"data_layer.access_key",
+	"data_layer.secret_key",
+	"tensorboard_storage.access_key",
+	"tensorboard_storage.secret_key",
+}
+
+// StorageCredentials holds the storage credentials removed from a configuration, keyed by their
+// path in it.
+type StorageCredentials map[string]string
+
+// walkStorageCredentials calls fn with the section and the key of each storage credential that a
+// configuration holds as a string. It returns true if fn changed any of them.
+func walkStorageCredentials(
+	config map[string]interface{}, fn func(section map[string]interface{}, path, key string) bool,
+) bool {
+	changed := false
+	for _, path := range StorageCredentialPaths {
+		parts := strings.SplitN(path, ".", 2)
+		section, ok := config[parts[0]].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := section[parts[1]].(string); !ok {
+			continue
+		}
+		if fn(section, path, parts[1]) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// StripStorageCredentials replaces the storage credentials in a JSON or YAML configuration with
+// RedactedCredential and returns them. The configuration is returned as JSON if any credentials
+// were removed and unchanged otherwise.
+func StripStorageCredentials(config []byte) ([]byte, StorageCredentials, error) {
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(config, &parsed); err != nil {
+		return nil, nil, errors.Wrap(err, "error parsing configuration")
+	}
+	creds := StorageCredentials{}
+	stripped := walkStorageCredentials(parsed,
+		func(section map[string]interface{}, path, key string) bool {
+			value := section[key].(string)
+			if value == RedactedCredential {
+				return false
+			}
+			creds[path] = value
+			section[key] = RedactedCredential
+			return true
+		})
+	if !stripped {
+		return config, creds, nil
+	}
+	out, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error encoding configuration")
+	}
+	return out, creds, nil
+}
+
+// RestoreStorageCredentials returns a JSON or YAML configuration as JSON, with the redacted
+// storage credentials that creds holds put back.
+func RestoreStorageCredentials(config []byte, creds StorageCredentials) ([]byte, error) {
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(config, &parsed); err != nil {
+		return nil, errors.Wrap(err, "error parsing configuration")
+	}
+	walkStorageCredentials(parsed, func(section map[string]interface{}, path, key string) bool {
+		if value, ok := creds[path]; ok && section[key] == RedactedCredential {
+			section[key] = value
+			return true
+		}
+		return false
+	})
+	out, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding configuration")
+	}
+	return out, nil
+}
+
+// RedactStorageCredentialsYAML replaces the storage credentials in a YAML configuration with
+// RedactedCredential. The configuration is returned unchanged, comments and all, if it holds no
+// credentials.
+func RedactStorageCredentialsYAML(config string) (string, error) {
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &parsed); err != nil {
+		return "", errors.Wrap(err, "error parsing configuration")
+	}
+	redacted := walkStorageCredentials(parsed,
+		func(section map[string]interface{}, path, key string) bool {
+			if section[key] == RedactedCredential {
+				return false
+			}
+			section[key] = RedactedCredential
+			return true
+		})
+	if !redacted {
+		return config, nil
+	}
+	out, err := yaml.Marshal(parsed)
+	if err != nil {
+		return "", errors.Wrap(err, "error encoding configuration")
+	}
+	return string(out), nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestStripAndRestoreStorageCredentials(t *testing.T) {
+	config := []byte(`{
+		"checkpoint_storage": {"type": "s3", "bucket": "b", "access_key": "AK", "secret_key": "SK"},
+		"data_layer": {"type": "s3", "secret_key": "********"},
+		"tensorboard_storage": {"type": "s3", "access_key": null}
+	}`)
+	stripped, creds, err := StripStorageCredentials(config)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, StorageCredentials{
+		"checkpoint_storage.access_key": "AK",
+		"checkpoint_storage.secret_key": "SK",
+	})
+
+	var parsed map[string]map[string]interface{}
+	assert.NilError(t, json.Unmarshal(stripped, &parsed))
+	assert.Equal(t, parsed["checkpoint_storage"]["access_key"], RedactedCredential)
+	assert.Equal(t, parsed["checkpoint_storage"]["secret_key"], RedactedCredential)
+	assert.Equal(t, parsed["checkpoint_storage"]["bucket"], "b")
+	assert.Equal(t, parsed["tensorboard_storage"]["access_key"], nil)
+
+	creds["data_layer.secret_key"] = "DSK"
+	creds["data_layer.access_key"] = "unused"
+	restored, err := RestoreStorageCredentials(stripped, creds)
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(restored, &parsed))
+	assert.Equal(t, parsed["checkpoint_storage"]["access_key"], "AK")
+	assert.Equal(t, parsed["checkpoint_storage"]["secret_key"], "SK")
+	assert.Equal(t, parsed["data_layer"]["secret_key"], "DSK")
+	_, ok := parsed["data_layer"]["access_key"]
+	assert.Assert(t, !ok)
+}
+
+func TestStripStorageCredentialsUnchanged(t *testing.T) {
+	config := []byte("checkpoint_storage:\n  type: gcs  # no keys\n  bucket: b\n")
+	stripped, creds, err := StripStorageCredentials(config)
+	assert.NilError(t, err)
+	assert.Equal(t, string(stripped), string(config))
+	assert.Equal(t, len(creds), 0)
+}
+
+func TestRedactStorageCredentialsYAML(t *testing.T) {
+	unchanged := "# comment\nsearcher:\n  name: single\n"
+	redacted, err := RedactStorageCredentialsYAML(unchanged)
+	assert.NilError(t, err)
+	assert.Equal(t, redacted, unchanged)
+
+	redacted, err = RedactStorageCredentialsYAML(
+		"checkpoint_storage:\n  type: s3\n  secret_key: SK\n")
+	assert.NilError(t, err)
+	assert.Equal(t, redacted, "checkpoint_storage:\n  secret_key: '********'\n  type: s3\n")
+}
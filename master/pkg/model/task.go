@@ -0,0 +1,205 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/pkg/container"
+	"github.com/determined-ai/determined/proto/pkg/taskv1"
+)
+
+// TaskType is the kind of work that a task allocates resources for.
+type TaskType string
+
+const (
+	// TaskTypeTrial is a run of a trial.
+	TaskTypeTrial TaskType = "TRIAL"
+	// TaskTypeCommand is a command.
+	TaskTypeCommand TaskType = "COMMAND"
+	// TaskTypeNotebook is a notebook.
+	TaskTypeNotebook TaskType = "NOTEBOOK"
+	// TaskTypeShell is a shell.
+	TaskTypeShell TaskType = "SHELL"
+	// TaskTypeTensorboard is a TensorBoard.
+	TaskTypeTensorboard TaskType = "TENSORBOARD"
+	// TaskTypeCheckpointGC is a garbage collection of the checkpoints of an experiment.
+	TaskTypeCheckpointGC TaskType = "CHECKPOINT_GC"
+	// TaskTypeCheckpointVerification is a verification of the files of the checkpoints of an
+	// experiment.
+	TaskTypeCheckpointVerification TaskType = "CHECKPOINT_VERIFICATION"
+	// TaskTypeServing is a replica of a serving of a model version.
+	TaskTypeServing TaskType = "SERVING"
+	// TaskTypeBatchInference is a batch inference job of a checkpoint.
+	TaskTypeBatchInference TaskType = "BATCH_INFERENCE"
+	// TaskTypeCodeServer is a VS Code server.
+	TaskTypeCodeServer TaskType = "CODE_SERVER"
+	// TaskTypeRayCluster is the head and workers of a Ray cluster.
+	TaskTypeRayCluster TaskType = "RAY_CLUSTER"
+)
+
+// Proto returns the proto representation of the task type.
+func (t TaskType) Proto() taskv1.TaskType {
+	switch t {
+	case TaskTypeTrial:
+		return taskv1.TaskType_TASK_TYPE_TRIAL
+	case TaskTypeCommand:
+		return taskv1.TaskType_TASK_TYPE_COMMAND
+	case TaskTypeNotebook:
+		return taskv1.TaskType_TASK_TYPE_NOTEBOOK
+	case TaskTypeShell:
+		return taskv1.TaskType_TASK_TYPE_SHELL
+	case TaskTypeTensorboard:
+		return taskv1.TaskType_TASK_TYPE_TENSORBOARD
+	case TaskTypeCheckpointGC:
+		return taskv1.TaskType_TASK_TYPE_CHECKPOINT_GC
+	case TaskTypeCheckpointVerification:
+		return taskv1.TaskType_TASK_TYPE_CHECKPOINT_VERIFICATION
+	case TaskTypeServing:
+		return taskv1.TaskType_TASK_TYPE_SERVING
+	case TaskTypeBatchInference:
+		return taskv1.TaskType_TASK_TYPE_BATCH_INFERENCE
+	case TaskTypeCodeServer:
+		return taskv1.TaskType_TASK_TYPE_CODE_SERVER
+	case TaskTypeRayCluster:
+		return taskv1.TaskType_TASK_TYPE_RAY_CLUSTER
+	default:
+		return taskv1.TaskType_TASK_TYPE_UNSPECIFIED
+	}
+}
+
+// TaskState is the state of a task, which all kinds of tasks share.
+type TaskState string
+
+func (s TaskState) String() string {
+	return string(s)
+}
+
+const (
+	// TaskStatePending denotes that the task is awaiting allocation.
+	TaskStatePending TaskState = "PENDING"
+	// TaskStateAssigned denotes that the task has been assigned to an agent but has not started
+	// yet.
+	TaskStateAssigned TaskState = "ASSIGNED"
+	// TaskStatePulling denotes that the task's base image is being pulled from the Docker
+	// registry.
+	TaskStatePulling TaskState = "PULLING"
+	// TaskStateStarting denotes that the image has been pulled and the task is being started, but
+	// the task is not ready yet.
+	TaskStateStarting TaskState = "STARTING"
+	// TaskStateRunning denotes that the service in the task is running.
+	TaskStateRunning TaskState = "RUNNING"
+	// TaskStateTerminated denotes that the task has exited or has been aborted.
+	TaskStateTerminated TaskState = "TERMINATED"
+)
+
+// TaskTransitions maps task states to their possible transitions. Tasks only move forward, and may
+// skip states, e.g. when their image was pulled before or they reattach to a running container.
+var TaskTransitions = map[TaskState]map[TaskState]bool{
+	TaskStatePending: {
+		TaskStateAssigned:   true,
+		TaskStatePulling:    true,
+		TaskStateStarting:   true,
+		TaskStateRunning:    true,
+		TaskStateTerminated: true,
+	},
+	TaskStateAssigned: {
+		TaskStatePulling:    true,
+		TaskStateStarting:   true,
+		TaskStateRunning:    true,
+		TaskStateTerminated: true,
+	},
+	TaskStatePulling: {
+		TaskStateStarting:   true,
+		TaskStateRunning:    true,
+		TaskStateTerminated: true,
+	},
+	TaskStateStarting: {
+		TaskStateRunning:    true,
+		TaskStateTerminated: true,
+	},
+	TaskStateRunning: {
+		TaskStateTerminated: true,
+	},
+	TaskStateTerminated: {},
+}
+
+// TaskStateOf returns the state of a task with the given container, which is nil until the task
+// is assigned one.
+func TaskStateOf(c *container.Container) TaskState {
+	if c == nil {
+		return TaskStatePending
+	}
+	switch c.State {
+	case container.Assigned:
+		return TaskStateAssigned
+	case container.Pulling:
+		return TaskStatePulling
+	case container.Starting:
+		return TaskStateStarting
+	case container.Running:
+		return TaskStateRunning
+	default:
+		return TaskStateTerminated
+	}
+}
+
+// Proto returns the proto representation of the task state.
+func (s TaskState) Proto() taskv1.State {
+	switch s {
+	case TaskStatePending:
+		return taskv1.State_STATE_PENDING
+	case TaskStateAssigned:
+		return taskv1.State_STATE_ASSIGNED
+	case TaskStatePulling:
+		return taskv1.State_STATE_PULLING
+	case TaskStateStarting:
+		return taskv1.State_STATE_STARTING
+	case TaskStateRunning:
+		return taskv1.State_STATE_RUNNING
+	case TaskStateTerminated:
+		return taskv1.State_STATE_TERMINATED
+	default:
+		return taskv1.State_STATE_UNSPECIFIED
+	}
+}
+
+// Task represents a row from the `tasks` table: an allocation of resources for a trial run,
+// command, notebook, shell, TensorBoard, checkpoint GC, checkpoint verification, replica of a
+// serving or batch inference job. Its usage of resources is accounted from its AssignedTime, when
+// it left the pending state.
+type Task struct {
+	TaskID       string     `db:"task_id" json:"task_id"`
+	TaskType     TaskType   `db:"task_type" json:"task_type"`
+	State        TaskState  `db:"state" json:"state"`
+	Description  string     `db:"description" json:"description"`
+	OwnerID      *UserID    `db:"owner_id" json:"owner_id"`
+	ProjectID    *int       `db:"project_id" json:"project_id"`
+	TrialID      *int       `db:"trial_id" json:"trial_id"`
+	ResourcePool string     `db:"resource_pool" json:"resource_pool"`
+	Slots        int        `db:"slots" json:"slots"`
+	StartTime    time.Time  `db:"start_time" json:"start_time"`
+	AssignedTime *time.Time `db:"assigned_time" json:"assigned_time"`
+	EndTime      *time.Time `db:"end_time" json:"end_time"`
+}
+
+// Transition changes the state of the task to the new state. If the state was not modified the
+// first return value returns false. If the state transition is illegal, an error is returned.
+func (t *Task) Transition(state TaskState) (bool, error) {
+	if t.State == state {
+		return false, nil
+	}
+	if !TaskTransitions[t.State][state] {
+		return false, errors.Errorf("illegal transition %v -> %v for task %v",
+			t.State, state, t.TaskID)
+	}
+	now := time.Now().UTC()
+	if t.State == TaskStatePending && state != TaskStateTerminated {
+		t.AssignedTime = &now
+	}
+	t.State = state
+	if state == TaskStateTerminated {
+		t.EndTime = &now
+	}
+	return true, nil
+}
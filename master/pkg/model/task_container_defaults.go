@@ -25,6 +25,7 @@ type TaskContainerDefaultsConfig struct {
 	GLOOPortRange          string                `json:"gloo_port_range,omitempty"`
 	ShmSizeBytes           int64                 `json:"shm_size_bytes,omitempty"`
 	NetworkMode            container.NetworkMode `json:"network_mode,omitempty"`
+	TaskNetworkDriver      string                `json:"task_network_driver,omitempty"`
 	CPUPodSpec             *k8sV1.Pod            `json:"cpu_pod_spec"`
 	GPUPodSpec             *k8sV1.Pod            `json:"gpu_pod_spec"`
 	Image                  *RuntimeItem          `json:"image,omitempty"`
@@ -93,6 +94,8 @@ func (c *TaskContainerDefaultsConfig) Validate() []error {
 	errs := []error{
 		check.GreaterThan(c.ShmSizeBytes, int64(0), "shm_size_bytes must be >= 0"),
 		check.NotEmpty(string(c.NetworkMode), "network_mode must be set"),
+		check.Contains(c.TaskNetworkDriver, []interface{}{"", "bridge", "overlay"},
+			"task_network_driver must be bridge or overlay"),
 	}
 
 	if err := validatePortRange(c.NCCLPortRange); err != nil {
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+)
+
+const defaultTaskHookTimeout = 60
+
+// TaskHook is a command that agents run on their hosts, or an HTTP endpoint that they POST to,
+// around the containers of tasks.
+type TaskHook struct {
+	Command []string `json:"command"`
+	URL     string   `json:"url"`
+	// Timeout is the number of seconds that the hook may take. Defaults to 60.
+	Timeout int `json:"timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (h TaskHook) Validate() []error {
+	return []error{
+		check.True((len(h.Command) > 0) != (h.URL != ""),
+			"task hooks must have exactly one of command and url"),
+		check.GreaterThanOrEqualTo(h.Timeout, 0, "task hook timeout must not be negative"),
+	}
+}
+
+// TimeoutDuration returns how long the hook may take.
+func (h TaskHook) TimeoutDuration() time.Duration {
+	if h.Timeout == 0 {
+		return defaultTaskHookTimeout * time.Second
+	}
+	return time.Duration(h.Timeout) * time.Second
+}
+
+// TaskHooksConfig configures the hooks that agents run before the containers of tasks start and
+// after they stop, in order. A failing pre-start hook fails the start of its container.
+type TaskHooksConfig struct {
+	PreStart []TaskHook `json:"pre_start"`
+	PostStop []TaskHook `json:"post_stop"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// TaskProfilerSample is a row of the `task_profiler_samples` table: how heavily a container of a
+// task used the resources of its agent at a point in time.
+type TaskProfilerSample struct {
+	TaskID      string    `db:"task_id"`
+	ContainerID string    `db:"container_id"`
+	AgentID     string    `db:"agent_id"`
+	Time        time.Time `db:"ts"`
+	// CPUUtilizationPercent is a percentage of one core.
+	CPUUtilizationPercent   float64          `db:"cpu_utilization_percent"`
+	MemoryUsedBytes         int64            `db:"memory_used_bytes"`
+	NetworkRxBytesPerSecond float64          `db:"network_rx_bytes_per_second"`
+	NetworkTxBytesPerSecond float64          `db:"network_tx_bytes_per_second"`
+	GPUs                    TaskProfilerGPUs `db:"gpus"`
+}
+
+// TaskProfilerGPU is how heavily a container used one of its GPUs.
+type TaskProfilerGPU struct {
+	DeviceID           int     `json:"device_id"`
+	UtilizationPercent float64 `json:"utilization_percent"`
+	MemoryUsedMiB      int     `json:"memory_used_mib"`
+	MemoryTotalMiB     int     `json:"memory_total_mib"`
+}
+
+// TaskProfilerGPUs are the GPUs of a sample, which are stored as a JSON array.
+type TaskProfilerGPUs []TaskProfilerGPU
+
+// Value marshals the GPUs to a JSON array.
+func (g TaskProfilerGPUs) Value() (driver.Value, error) {
+	if g == nil {
+		g = TaskProfilerGPUs{}
+	}
+	return json.Marshal(g)
+}
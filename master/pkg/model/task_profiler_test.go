@@ -0,0 +1,18 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestTaskProfilerGPUsValue(t *testing.T) {
+	value, err := TaskProfilerGPUs(nil).Value()
+	assert.NilError(t, err)
+	assert.Equal(t, string(value.([]byte)), "[]")
+
+	value, err = TaskProfilerGPUs{{DeviceID: 1, UtilizationPercent: 50}}.Value()
+	assert.NilError(t, err)
+	assert.Equal(t, string(value.([]byte)),
+		`[{"device_id":1,"utilization_percent":50,"memory_used_mib":0,"memory_total_mib":0}]`)
+}
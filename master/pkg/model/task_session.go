@@ -4,4 +4,7 @@ package model
 type TaskSession struct {
 	ID     SessionID `db:"id" json:"id"`
 	TaskID string    `db:"task_id" json:"task_id"`
+	// Generation is signed into each token of the session. Only tokens of the current generation
+	// are valid; rotating the token of a session moves it to the next generation.
+	Generation int `db:"generation" json:"generation"`
 }
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestTaskTransition(t *testing.T) {
+	task := Task{TaskID: "task", State: TaskStatePending}
+
+	changed, err := task.Transition(TaskStateRunning)
+	assert.NilError(t, err)
+	assert.Assert(t, changed)
+	assert.Assert(t, task.AssignedTime != nil)
+
+	changed, err = task.Transition(TaskStateRunning)
+	assert.NilError(t, err)
+	assert.Assert(t, !changed)
+
+	_, err = task.Transition(TaskStatePulling)
+	assert.ErrorContains(t, err, "illegal transition RUNNING -> PULLING")
+	assert.Equal(t, task.State, TaskStateRunning)
+	assert.Assert(t, task.EndTime == nil)
+
+	changed, err = task.Transition(TaskStateTerminated)
+	assert.NilError(t, err)
+	assert.Assert(t, changed)
+	assert.Assert(t, task.EndTime != nil)
+
+	// Tasks that terminate before they are assigned use no resources.
+	task = Task{TaskID: "canceled", State: TaskStatePending}
+	_, err = task.Transition(TaskStateTerminated)
+	assert.NilError(t, err)
+	assert.Assert(t, task.AssignedTime == nil)
+}
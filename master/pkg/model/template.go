@@ -4,4 +4,8 @@ package model
 type Template struct {
 	Name   string `db:"name" json:"name"`
 	Config []byte `db:"config" json:"config"`
+	// StorageCredentials holds the storage credentials that were removed from the stored config,
+	// encrypted if StorageCredentialsEncrypted is set.
+	StorageCredentials          []byte `db:"storage_credentials" json:"-"`
+	StorageCredentialsEncrypted bool   `db:"storage_credentials_encrypted" json:"-"`
 }
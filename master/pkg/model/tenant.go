@@ -0,0 +1,44 @@
+package model
+
+// Tenant represents a row from the `tenants` table along with its members. Tenants partition the
+// users, workspaces and resource pools of the cluster; in strict tenancy, the users of a tenant
+// only see and use those of their own tenant.
+type Tenant struct {
+	ID             int      `db:"id" json:"id"`
+	Name           string   `db:"name" json:"name"`
+	Usernames      []string `db:"-" json:"usernames"`
+	WorkspaceNames []string `db:"-" json:"workspace_names"`
+	ResourcePools  []string `db:"-" json:"resource_pools"`
+}
+
+// TenantScope is the part of the cluster that a user may see and use: every tenant, or only the
+// users, workspaces, resource pools and tasks of one tenant or of no tenant.
+type TenantScope struct {
+	// All is whether the user may see every tenant.
+	All bool
+	// TenantID is the tenant that the user may see, or nil for what belongs to no tenant.
+	TenantID *int
+}
+
+// NewTenantScope returns the scope of a user. Unless tenancy is strict, every user sees every
+// tenant. In strict tenancy, users only see their own tenant, except for admins without a tenant,
+// who operate the whole cluster.
+func NewTenantScope(user *User, strict bool) TenantScope {
+	if !strict || (user.Admin && user.TenantID == nil) {
+		return TenantScope{All: true}
+	}
+	return TenantScope{TenantID: user.TenantID}
+}
+
+// Contains returns true if the scope includes what belongs to the tenant with the given ID, or to
+// no tenant if the ID is nil.
+func (s TenantScope) Contains(tenantID *int) bool {
+	switch {
+	case s.All:
+		return true
+	case s.TenantID == nil || tenantID == nil:
+		return s.TenantID == nil && tenantID == nil
+	default:
+		return *s.TenantID == *tenantID
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestTenantScope(t *testing.T) {
+	a, b := 1, 2
+	operator := &User{Admin: true}
+	tenantAdmin := &User{Admin: true, TenantID: &a}
+	member := &User{TenantID: &a}
+	outsider := &User{}
+
+	assert.Assert(t, NewTenantScope(member, false).Contains(&b))
+	assert.Assert(t, NewTenantScope(operator, true).Contains(&b))
+
+	scope := NewTenantScope(tenantAdmin, true)
+	assert.Assert(t, scope.Contains(&a))
+	assert.Assert(t, !scope.Contains(&b))
+	assert.Assert(t, !scope.Contains(nil))
+	assert.Equal(t, NewTenantScope(member, true), scope)
+
+	scope = NewTenantScope(outsider, true)
+	assert.Assert(t, scope.Contains(nil))
+	assert.Assert(t, !scope.Contains(&a))
+}
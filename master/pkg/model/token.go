@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APITokenPrefix starts every API token, which tells them apart from session tokens.
+const APITokenPrefix = "det_"
+
+// TokenScope limits what an API token may do on behalf of its user. A token never grants more
+// than its user's permissions.
+type TokenScope string
+
+const (
+	// TokenScopeRead allows only reading the cluster, its experiments and its tasks.
+	TokenScopeRead TokenScope = "read"
+	// TokenScopeSubmitExperiments allows only creating and activating experiments.
+	TokenScopeSubmitExperiments TokenScope = "submit_experiments"
+	// TokenScopeAll allows everything that the user may do.
+	TokenScopeAll TokenScope = "all"
+)
+
+// ValidTokenScope returns true if the scope is known.
+func ValidTokenScope(s TokenScope) bool {
+	switch s {
+	case TokenScopeRead, TokenScopeSubmitExperiments, TokenScopeAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIToken represents a row from the `api_tokens` table. Only a hash of the token is stored.
+type APIToken struct {
+	ID          int    `db:"id" json:"id"`
+	UserID      UserID `db:"user_id" json:"user_id"`
+	Description string `db:"description" json:"description"`
+	TokenHash   []byte `db:"token_hash" json:"-"`
+	// Scope lists the scopes of the token separated by spaces, as in OAuth 2.0.
+	Scope     string    `db:"scope" json:"scope"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// Scopes returns the scopes of the token.
+func (t APIToken) Scopes() []TokenScope {
+	var scopes []TokenScope
+	for _, s := range strings.Fields(t.Scope) {
+		scopes = append(scopes, TokenScope(s))
+	}
+	return scopes
+}
+
+// HasScope returns true if the token has the scope or the scope that allows everything.
+func (t APIToken) HasScope(scope TokenScope) bool {
+	for _, s := range t.Scopes() {
+		if s == scope || s == TokenScopeAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestAPITokenHasScope(t *testing.T) {
+	token := APIToken{Scope: "read submit_experiments"}
+	assert.DeepEqual(t, token.Scopes(), []TokenScope{TokenScopeRead, TokenScopeSubmitExperiments})
+	assert.Assert(t, token.HasScope(TokenScopeRead))
+	assert.Assert(t, token.HasScope(TokenScopeSubmitExperiments))
+	assert.Assert(t, !token.HasScope(TokenScopeAll))
+
+	token = APIToken{Scope: "all"}
+	assert.Assert(t, token.HasScope(TokenScopeRead))
+	assert.Assert(t, token.HasScope(TokenScopeAll))
+
+	assert.Assert(t, !APIToken{}.HasScope(TokenScopeRead))
+	assert.Assert(t, !ValidTokenScope("write"))
+}
@@ -19,6 +19,15 @@ func JSONObjFromMapStringInt64(m map[string]int64) JSONObj {
 	return r
 }
 
+// JSONObjFromMapStringString converts map[string]string to a JSONObj.
+func JSONObjFromMapStringString(m map[string]string) JSONObj {
+	r := make(JSONObj)
+	for k, v := range m {
+		r[k] = v
+	}
+	return r
+}
+
 // Value marshals a []byte.
 func (j JSONObj) Value() (driver.Value, error) {
 	bytes, err := json.Marshal(j)
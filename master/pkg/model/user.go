@@ -23,6 +23,10 @@ type User struct {
 	PasswordHash null.String `db:"password_hash" json:"-"`
 	Admin        bool        `db:"admin" json:"admin"`
 	Active       bool        `db:"active" json:"active"`
+	// Remote users log in through single sign-on and cannot log in with a password.
+	Remote bool `db:"remote" json:"remote"`
+	// TenantID is the tenant that the user belongs to, if any.
+	TenantID *int `db:"tenant_id" json:"tenant_id"`
 }
 
 // UserSession corresponds to a row in the "user_sessions" DB table.
@@ -38,6 +42,8 @@ type FullUser struct {
 	Username string `db:"username" json:"username"`
 	Admin    bool   `db:"admin" json:"admin"`
 	Active   bool   `db:"active" json:"active"`
+	Remote   bool   `db:"remote" json:"remote"`
+	TenantID *int   `db:"tenant_id" json:"tenant_id"`
 
 	AgentUID   null.Int    `db:"agent_uid" json:"agent_uid"`
 	AgentGID   null.Int    `db:"agent_gid" json:"agent_gid"`
@@ -47,6 +53,10 @@ type FullUser struct {
 
 // ValidatePassword checks that the supplied password is correct.
 func (user User) ValidatePassword(password string) bool {
+	if user.Remote {
+		return false
+	}
+
 	// If an empty password was posted, we need to check that the
 	// user is a password-less user.
 	if password == "" {
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"database/sql/driver"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WebhookStates are the terminal states of experiments that a webhook is notified of. They convert
+// to a comma-separated string in SQL queries, which queries convert to and from an array.
+type WebhookStates []State
+
+// Value joins the states with commas.
+func (s WebhookStates) Value() (driver.Value, error) {
+	states := make([]string, 0, len(s))
+	for _, state := range s {
+		states = append(states, string(state))
+	}
+	return strings.Join(states, ","), nil
+}
+
+// Scan splits a comma-separated string into states.
+func (s *WebhookStates) Scan(src interface{}) error {
+	var joined string
+	switch src := src.(type) {
+	case string:
+		joined = src
+	case []byte:
+		joined = string(src)
+	default:
+		return errors.Errorf("unexpected type: %T", src)
+	}
+	*s = nil
+	if joined == "" {
+		return nil
+	}
+	for _, state := range strings.Split(joined, ",") {
+		*s = append(*s, State(state))
+	}
+	return nil
+}
+
+// Has returns whether the webhook is notified of experiments that end in the state.
+func (s WebhookStates) Has(state State) bool {
+	for _, other := range s {
+		if other == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook represents a row from the `webhooks` table: a URL that is notified when an experiment, or
+// any experiment in a project that has no webhooks of its own, ends in one of the states.
+type Webhook struct {
+	ID           int           `db:"id"`
+	ProjectID    *int          `db:"project_id"`
+	ExperimentID *int          `db:"experiment_id"`
+	URL          string        `db:"url"`
+	States       WebhookStates `db:"states"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestWebhookStates(t *testing.T) {
+	states := WebhookStates{CompletedState, ErrorState}
+	value, err := states.Value()
+	assert.NilError(t, err)
+	assert.Equal(t, value, "COMPLETED,ERROR")
+
+	var scanned WebhookStates
+	assert.NilError(t, scanned.Scan([]byte("COMPLETED,ERROR")))
+	assert.DeepEqual(t, scanned, states)
+	assert.Assert(t, scanned.Has(ErrorState))
+	assert.Assert(t, !scanned.Has(CanceledState))
+
+	assert.NilError(t, scanned.Scan(""))
+	assert.Equal(t, len(scanned), 0)
+}
@@ -0,0 +1,37 @@
+package model
+
+// DefaultProjectID is the project of experiments and tasks that are not created in a particular
+// project. It belongs to the workspace with the ID DefaultWorkspaceID.
+const DefaultProjectID = 1
+
+// DefaultWorkspaceID is the workspace of the default project.
+const DefaultWorkspaceID = 1
+
+// Workspace represents a row from the `workspaces` table. Workspaces group projects and hold the
+// defaults of the experiments and tasks created in them.
+type Workspace struct {
+	ID                  int    `db:"id" json:"id"`
+	Name                string `db:"name" json:"name"`
+	DefaultResourcePool string `db:"default_resource_pool" json:"default_resource_pool"`
+	DefaultImage        string `db:"default_image" json:"default_image"`
+	// TenantID is the tenant that the workspace belongs to, if any.
+	TenantID *int `db:"tenant_id" json:"tenant_id"`
+}
+
+// DefaultImageItem returns the workspace's default image for both CPU and GPU tasks, or nil if
+// the workspace does not have one.
+func (w *Workspace) DefaultImageItem() *RuntimeItem {
+	if w == nil || w.DefaultImage == "" {
+		return nil
+	}
+	return &RuntimeItem{CPU: w.DefaultImage, GPU: w.DefaultImage}
+}
+
+// Project represents a row from the `projects` table. Experiments, notebooks, shells, commands and
+// TensorBoards are organized under projects.
+type Project struct {
+	ID          int    `db:"id" json:"id"`
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+	WorkspaceID int    `db:"workspace_id" json:"workspace_id"`
+}
@@ -0,0 +1,28 @@
+package expconf
+
+// All the actions of alert rules.
+const (
+	// NotifyAction notifies the webhooks of the experiment of the alert.
+	NotifyAction = "notify"
+	// PauseAction pauses the experiment as well as notifying its webhooks.
+	PauseAction = "pause"
+)
+
+//go:generate ../gen.sh
+// AlertsConfigV0 is the configuration for the alerts on the metrics that the trials of an
+// experiment report.
+type AlertsConfigV0 []AlertRuleV0
+
+//go:generate ../gen.sh
+// AlertRuleV0 is an alert that fires when a trial reports a metric above or below a threshold for
+// a number of reports in a row.
+type AlertRuleV0 struct {
+	RawName   *string  `json:"name"`
+	RawMetric string   `json:"metric"`
+	RawAbove  *float64 `json:"above"`
+	RawBelow  *float64 `json:"below"`
+	// RawForReports is the number of reports in a row that break the threshold before the alert
+	// fires.
+	RawForReports *int    `json:"for_reports"`
+	RawAction     *string `json:"action"`
+}
@@ -0,0 +1,12 @@
+package expconf
+
+//go:generate ../gen.sh
+// DatasetsConfigV0 is the configuration for the registered datasets that an experiment uses.
+type DatasetsConfigV0 []DatasetV0
+
+//go:generate ../gen.sh
+// DatasetV0 is a version of a registered dataset.
+type DatasetV0 struct {
+	RawName    string `json:"name"`
+	RawVersion string `json:"version"`
+}
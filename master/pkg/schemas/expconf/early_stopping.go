@@ -0,0 +1,33 @@
+package expconf
+
+// All the types of early stopping rules.
+const (
+	// NaNRule stops trials that report metrics that are NaN or infinite.
+	NaNRule = "nan"
+	// ThresholdRule stops trials whose metric diverges past a min or a max.
+	ThresholdRule = "threshold"
+	// PlateauRule stops trials whose metric stops improving.
+	PlateauRule = "plateau"
+)
+
+//go:generate ../gen.sh
+// EarlyStoppingConfigV0 is the configuration for the rules by which the master stops the trials of
+// an experiment early, based on the metrics that they report.
+type EarlyStoppingConfigV0 []EarlyStoppingRuleV0
+
+//go:generate ../gen.sh
+// EarlyStoppingRuleV0 is a rule on a metric that trials report, which stops the trials that break
+// it.
+type EarlyStoppingRuleV0 struct {
+	RawType string `json:"type"`
+	// RawMetric is the metric the rule is on; a NaN rule without one is on every metric.
+	RawMetric *string `json:"metric"`
+	// RawMin and RawMax are the bounds of the metric for a threshold rule.
+	RawMin *float64 `json:"min"`
+	RawMax *float64 `json:"max"`
+	// RawPatience is the number of reports of the metric without an improvement of at least
+	// RawMinDelta after which a plateau rule stops the trial.
+	RawPatience        *int     `json:"patience"`
+	RawMinDelta        *float64 `json:"min_delta"`
+	RawSmallerIsBetter *bool    `json:"smaller_is_better"`
+}
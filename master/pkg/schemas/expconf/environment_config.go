@@ -51,10 +51,20 @@ type EnvironmentConfigV0 struct {
 	RawForcePullImage *bool             `json:"force_pull_image"`
 	RawPodSpec        *PodSpec          `json:"pod_spec"`
 
+	RawKubernetes *KubernetesConfigV0 `json:"kubernetes"`
+
 	RawAddCapabilities  []string `json:"add_capabilities"`
 	RawDropCapabilities []string `json:"drop_capabilities"`
 }
 
+//go:generate ../gen.sh --import k8sV1:k8s.io/api/core/v1
+// KubernetesConfigV0 configures the Kubernetes pods that a task is launched in.
+type KubernetesConfigV0 struct {
+	RawNamespace          *string            `json:"namespace"`
+	RawServiceAccountName *string            `json:"service_account_name"`
+	RawTolerations        []k8sV1.Toleration `json:"tolerations"`
+}
+
 //go:generate ../gen.sh
 // EnvironmentImageMapV0 configures the runtime image.
 type EnvironmentImageMapV0 struct {
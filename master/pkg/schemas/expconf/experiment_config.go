@@ -16,19 +16,24 @@ import (
 //go:generate ../gen.sh
 // ExperimentConfigV0 is a versioned experiment config.
 type ExperimentConfigV0 struct {
+	RawAlerts                   AlertsConfigV0              `json:"alerts"`
 	RawBindMounts               BindMountsConfigV0          `json:"bind_mounts"`
 	RawCheckpointPolicy         *string                     `json:"checkpoint_policy"`
 	RawCheckpointStorage        *CheckpointStorageConfigV0  `json:"checkpoint_storage"`
 	RawDataLayer                *DataLayerConfigV0          `json:"data_layer"`
 	RawData                     map[string]interface{}      `json:"data"`
+	RawDatasets                 DatasetsConfigV0            `json:"datasets"`
 	RawDebug                    *bool                       `json:"debug"`
 	RawDescription              Description                 `json:"description"`
+	RawEarlyStopping            EarlyStoppingConfigV0       `json:"early_stopping"`
 	RawEntrypoint               *string                     `json:"entrypoint"`
 	RawEnvironment              *EnvironmentConfigV0        `json:"environment"`
 	RawHyperparameters          HyperparametersV0           `json:"hyperparameters"`
 	RawInternal                 *InternalConfigV0           `json:"internal,omitempty"`
 	RawLabels                   LabelsV0                    `json:"labels"`
+	RawLogRetention             *LogRetentionConfigV0       `json:"log_retention"`
 	RawMaxRestarts              *int                        `json:"max_restarts"`
+	RawMaxRuntime               *int                        `json:"max_runtime"`
 	RawMinCheckpointPeriod      *LengthV0                   `json:"min_checkpoint_period"`
 	RawMinValidationPeriod      *LengthV0                   `json:"min_validation_period"`
 	RawOptimizations            *OptimizationsConfigV0      `json:"optimizations"`
@@ -38,8 +43,11 @@ type ExperimentConfigV0 struct {
 	RawReproducibility          *ReproducibilityConfigV0    `json:"reproducibility"`
 	RawResources                *ResourcesConfigV0          `json:"resources"`
 	RawSchedulingUnit           *int                        `json:"scheduling_unit"`
+	RawSchedulingWindows        SchedulingWindowsConfigV0   `json:"scheduling_windows"`
 	RawSearcher                 *SearcherConfigV0           `json:"searcher"`
 	RawSecurity                 *SecurityConfigV0           `json:"security,omitempty"`
+	RawStopGracePeriod          *int                        `json:"stop_grace_period"`
+	RawStopSignal               *string                     `json:"stop_signal"`
 	RawTensorboardStorage       *TensorboardStorageConfigV0 `json:"tensorboard_storage,omitempty"`
 }
 
@@ -180,9 +188,17 @@ type ResourcesConfigV0 struct {
 	RawWeight         *float64 `json:"weight"`
 	RawNativeParallel *bool    `json:"native_parallel,omitempty"`
 	RawShmSize        *int     `json:"shm_size"`
+	RawCPULimit       *float64 `json:"cpu_limit"`
+	RawMemoryLimit    *int     `json:"memory_limit"`
 	RawAgentLabel     *string  `json:"agent_label"`
 	RawResourcePool   *string  `json:"resource_pool"`
 	RawPriority       *int     `json:"priority"`
+	RawPlatform       *string  `json:"platform"`
+	RawGPUType        *string  `json:"gpu_type"`
+
+	// MinSlotsPerTrial makes trials elastic: they run with as many slots as are available, from
+	// MinSlotsPerTrial up to SlotsPerTrial.
+	RawMinSlotsPerTrial *int `json:"min_slots_per_trial"`
 
 	RawDevices DevicesConfigV0 `json:"devices"`
 }
@@ -222,6 +238,7 @@ type BindMountV0 struct {
 	RawContainerPath string  `json:"container_path"`
 	RawReadOnly      *bool   `json:"read_only"`
 	RawPropagation   *string `json:"propagation"`
+	RawPlatform      *string `json:"platform"`
 }
 
 //go:generate ../gen.sh
@@ -3,6 +3,7 @@ package expconf
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
 
@@ -58,3 +59,35 @@ func TestDescription(t *testing.T) {
 
 	assert.DeepEqual(t, newConfig.Description().String(), "my_description")
 }
+
+func TestSchedulingWindows(t *testing.T) {
+	weekends := schemas.WithDefaults(SchedulingWindow{
+		RawDays:  []string{"saturday", "sunday"},
+		RawStart: "00:00",
+		RawEnd:   "00:00",
+	}).(SchedulingWindow)
+	nights := schemas.WithDefaults(SchedulingWindow{
+		RawStart:    "20:00",
+		RawEnd:      "06:00",
+		RawTimeZone: ptrs.StringPtr("America/New_York"),
+	}).(SchedulingWindow)
+	windows := SchedulingWindowsConfig{weekends, nights}
+
+	// 2021-07-03 is a Saturday.
+	saturday := time.Date(2021, 7, 3, 12, 0, 0, 0, time.UTC)
+	assert.Assert(t, weekends.Contains(saturday))
+	assert.Assert(t, !weekends.Contains(saturday.AddDate(0, 0, -1)))
+	// The weekend runs until midnight on Sunday.
+	assert.Assert(t, weekends.Contains(time.Date(2021, 7, 4, 23, 59, 0, 0, time.UTC)))
+	assert.Assert(t, !weekends.Contains(time.Date(2021, 7, 5, 0, 0, 0, 0, time.UTC)))
+
+	// Nights run past midnight, in their own time zone.
+	assert.Assert(t, nights.Contains(time.Date(2021, 7, 6, 1, 0, 0, 0, time.UTC)))
+	assert.Assert(t, nights.Contains(time.Date(2021, 7, 6, 9, 0, 0, 0, time.UTC)))
+	assert.Assert(t, !nights.Contains(time.Date(2021, 7, 6, 11, 0, 0, 0, time.UTC)))
+
+	monday := time.Date(2021, 7, 5, 16, 0, 0, 0, time.UTC)
+	assert.Assert(t, !windows.Contains(monday))
+	assert.Assert(t, windows.Contains(saturday))
+	assert.Assert(t, SchedulingWindowsConfig{}.Contains(monday))
+}
@@ -3,16 +3,23 @@ package expconf
 // This file defines the latest version of each config, which should be used throughout the system.
 
 type AdaptiveASHAConfig = AdaptiveASHAConfigV0
+type AlertRule = AlertRuleV0
+type AlertsConfig = AlertsConfigV0
 type AsyncHalvingConfig = AsyncHalvingConfigV0
 type BindMount = BindMountV0
 type BindMountsConfig = BindMountsConfigV0
 type CategoricalHyperparameter = CategoricalHyperparameterV0
 type CheckpointStorageConfig = CheckpointStorageConfigV0
 type ConstHyperparameter = ConstHyperparameterV0
+type CustomConfig = CustomConfigV0
 type DataLayerConfig = DataLayerConfigV0
+type Dataset = DatasetV0
+type DatasetsConfig = DatasetsConfigV0
 type DevicesConfig = DevicesConfigV0
 type Device = DeviceV0
 type DoubleHyperparameter = DoubleHyperparameterV0
+type EarlyStoppingConfig = EarlyStoppingConfigV0
+type EarlyStoppingRule = EarlyStoppingRuleV0
 type EnvironmentConfig = EnvironmentConfigV0
 type EnvironmentImageMap = EnvironmentImageMapV0
 type EnvironmentVariablesMap = EnvironmentVariablesMapV0
@@ -24,15 +31,19 @@ type HDFSConfig = HDFSConfigV0
 type Hyperparameter = HyperparameterV0
 type Hyperparameters = HyperparametersV0
 type IntHyperparameter = IntHyperparameterV0
+type KubernetesConfig = KubernetesConfigV0
 type Labels = LabelsV0
 type Length = LengthV0
 type LogHyperparameter = LogHyperparameterV0
+type LogRetentionConfig = LogRetentionConfigV0
 type OptimizationsConfig = OptimizationsConfigV0
 type PBTConfig = PBTConfigV0
 type ProfilingConfig = ProfilingConfigV0
 type RandomConfig = RandomConfigV0
 type ReproducibilityConfig = ReproducibilityConfigV0
 type ResourcesConfig = ResourcesConfigV0
+type SchedulingWindow = SchedulingWindowV0
+type SchedulingWindowsConfig = SchedulingWindowsConfigV0
 type S3Config = S3ConfigV0
 type S3DataLayerConfig = S3DataLayerConfigV0
 type SearcherConfig = SearcherConfigV0
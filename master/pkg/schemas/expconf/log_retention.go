@@ -0,0 +1,11 @@
+package expconf
+
+//go:generate ../gen.sh
+// LogRetentionConfigV0 overrides how long the master keeps the logs of the trials of an
+// experiment.
+type LogRetentionConfigV0 struct {
+	// RawDays is the number of days after a trial ends that its logs are deleted.
+	RawDays *int `json:"days"`
+	// RawMaxLinesPerTrial is the number of lines of its most recent logs that a trial keeps.
+	RawMaxLinesPerTrial *int `json:"max_lines_per_trial"`
+}
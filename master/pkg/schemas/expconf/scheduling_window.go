@@ -0,0 +1,85 @@
+package expconf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//go:generate ../gen.sh
+// SchedulingWindowsConfigV0 is the configuration for the times an experiment may run. An experiment
+// without scheduling windows may run at any time.
+type SchedulingWindowsConfigV0 []SchedulingWindowV0
+
+// Contains returns whether the time is in any of the scheduling windows, which is always the case
+// when there are none.
+func (s SchedulingWindowsConfigV0) Contains(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, w := range s {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+//go:generate ../gen.sh
+// SchedulingWindowV0 is a weekly window of time in which an experiment may run. A window whose end
+// is not after its start runs past midnight into the next day.
+type SchedulingWindowV0 struct {
+	// RawDays are the days the window starts on, or every day if there are none.
+	RawDays     []string `json:"days"`
+	RawStart    string   `json:"start"`
+	RawEnd      string   `json:"end"`
+	RawTimeZone *string  `json:"time_zone"`
+}
+
+// Location returns the time zone of the window.
+func (w SchedulingWindowV0) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(w.TimeZone())
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone of scheduling window: %w", err)
+	}
+	return loc, nil
+}
+
+// Contains returns whether the time is in the window.
+func (w SchedulingWindowV0) Contains(t time.Time) bool {
+	loc, err := w.Location()
+	if err != nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	start, end := minuteOfDay(w.Start()), minuteOfDay(w.End())
+
+	if start < end {
+		return w.startsOn(t.Weekday()) && start <= minute && minute < end
+	}
+	yesterday := t.AddDate(0, 0, -1).Weekday()
+	return (w.startsOn(t.Weekday()) && minute >= start) || (w.startsOn(yesterday) && minute < end)
+}
+
+func (w SchedulingWindowV0) startsOn(day time.Weekday) bool {
+	if len(w.Days()) == 0 {
+		return true
+	}
+	for _, d := range w.Days() {
+		if strings.EqualFold(d, day.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// minuteOfDay converts a time of the form HH:MM, as checked by the schema, to the minutes since
+// midnight.
+func minuteOfDay(hhmm string) int {
+	var hour, minute int
+	if _, err := fmt.Sscanf(hhmm, "%d:%d", &hour, &minute); err != nil {
+		return 0
+	}
+	return hour*60 + minute
+}
@@ -17,6 +17,7 @@ type SearcherConfigV0 struct {
 	RawAsyncHalvingConfig *AsyncHalvingConfigV0 `union:"name,async_halving" json:"-"`
 	RawAdaptiveASHAConfig *AdaptiveASHAConfigV0 `union:"name,adaptive_asha" json:"-"`
 	RawPBTConfig          *PBTConfigV0          `union:"name,pbt" json:"-"`
+	RawCustomConfig       *CustomConfigV0       `union:"name,custom" json:"-"`
 
 	RawMetric               *string `json:"metric"`
 	RawSmallerIsBetter      *bool   `json:"smaller_is_better"`
@@ -53,6 +54,8 @@ func (s SearcherConfigV0) Unit() Unit {
 		return s.RawAdaptiveASHAConfig.Unit()
 	case s.RawPBTConfig != nil:
 		return s.RawPBTConfig.Unit()
+	case s.RawCustomConfig != nil:
+		return s.RawCustomConfig.Unit()
 	default:
 		panic("no searcher type specified")
 	}
@@ -174,3 +177,22 @@ type PBTConfigV0 struct {
 func (p PBTConfigV0) Unit() Unit {
 	return p.RawLengthPerRound.Unit
 }
+
+//go:generate ../gen.sh
+// CustomConfigV0 configures a custom search, whose operations are made by a process outside of the
+// master.
+type CustomConfigV0 struct {
+	RawUnitName *string `json:"unit"`
+}
+
+// Unit implements the model.InUnits interface.
+func (c CustomConfigV0) Unit() Unit {
+	switch c.UnitName() {
+	case "records":
+		return Records
+	case "epochs":
+		return Epochs
+	default:
+		return Batches
+	}
+}
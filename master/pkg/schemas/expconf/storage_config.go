@@ -43,16 +43,19 @@ func (c *CheckpointStorageConfigV0) UnmarshalJSON(data []byte) error {
 	return errors.Wrap(json.Unmarshal(data, DefaultParser(c)), "failed to parse checkpoint storage")
 }
 
-// Printable modifies the object with secrets hidden.
+// Printable modifies the object with secrets hidden. The S3 config is copied first, so that the
+// copies of the object that share it keep their secrets.
 func (c *CheckpointStorageConfigV0) Printable() {
 	hiddenValue := "********"
 	if c.RawS3Config != nil {
-		if c.RawS3Config.RawAccessKey != nil {
-			c.RawS3Config.RawAccessKey = &hiddenValue
+		s3 := *c.RawS3Config
+		if s3.RawAccessKey != nil {
+			s3.RawAccessKey = &hiddenValue
 		}
-		if c.RawS3Config.RawSecretKey != nil {
-			c.RawS3Config.RawSecretKey = &hiddenValue
+		if s3.RawSecretKey != nil {
+			s3.RawSecretKey = &hiddenValue
 		}
+		c.RawS3Config = &s3
 	}
 }
 
@@ -0,0 +1,75 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (a AlertRuleV0) Name() *string {
+	return a.RawName
+}
+
+func (a *AlertRuleV0) SetName(val *string) {
+	a.RawName = val
+}
+
+func (a AlertRuleV0) Metric() string {
+	return a.RawMetric
+}
+
+func (a *AlertRuleV0) SetMetric(val string) {
+	a.RawMetric = val
+}
+
+func (a AlertRuleV0) Above() *float64 {
+	return a.RawAbove
+}
+
+func (a *AlertRuleV0) SetAbove(val *float64) {
+	a.RawAbove = val
+}
+
+func (a AlertRuleV0) Below() *float64 {
+	return a.RawBelow
+}
+
+func (a *AlertRuleV0) SetBelow(val *float64) {
+	a.RawBelow = val
+}
+
+func (a AlertRuleV0) ForReports() int {
+	if a.RawForReports == nil {
+		panic("You must call WithDefaults on AlertRuleV0 before .ForReports")
+	}
+	return *a.RawForReports
+}
+
+func (a *AlertRuleV0) SetForReports(val int) {
+	a.RawForReports = &val
+}
+
+func (a AlertRuleV0) Action() string {
+	if a.RawAction == nil {
+		panic("You must call WithDefaults on AlertRuleV0 before .Action")
+	}
+	return *a.RawAction
+}
+
+func (a *AlertRuleV0) SetAction(val string) {
+	a.RawAction = &val
+}
+
+func (a AlertRuleV0) ParsedSchema() interface{} {
+	return schemas.ParsedAlertRuleV0()
+}
+
+func (a AlertRuleV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/alert-rule.json")
+}
+
+func (a AlertRuleV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/alert-rule.json")
+}
@@ -0,0 +1,21 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (a AlertsConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedAlertsConfigV0()
+}
+
+func (a AlertsConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/alerts.json")
+}
+
+func (a AlertsConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/alerts.json")
+}
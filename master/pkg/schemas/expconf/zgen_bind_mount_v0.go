@@ -46,6 +46,17 @@ func (b *BindMountV0) SetPropagation(val string) {
 	b.RawPropagation = &val
 }
 
+func (b BindMountV0) Platform() string {
+	if b.RawPlatform == nil {
+		panic("You must call WithDefaults on BindMountV0 before .Platform")
+	}
+	return *b.RawPlatform
+}
+
+func (b *BindMountV0) SetPlatform(val string) {
+	b.RawPlatform = &val
+}
+
 func (b BindMountV0) ParsedSchema() interface{} {
 	return schemas.ParsedBindMountV0()
 }
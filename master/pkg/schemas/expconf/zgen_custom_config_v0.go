@@ -0,0 +1,32 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (c CustomConfigV0) UnitName() string {
+	if c.RawUnitName == nil {
+		panic("You must call WithDefaults on CustomConfigV0 before .UnitName")
+	}
+	return *c.RawUnitName
+}
+
+func (c *CustomConfigV0) SetUnitName(val string) {
+	c.RawUnitName = &val
+}
+
+func (c CustomConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedCustomConfigV0()
+}
+
+func (c CustomConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/searcher-custom.json")
+}
+
+func (c CustomConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/searcher-custom.json")
+}
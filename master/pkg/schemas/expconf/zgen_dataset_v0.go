@@ -0,0 +1,37 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (d DatasetV0) Name() string {
+	return d.RawName
+}
+
+func (d *DatasetV0) SetName(val string) {
+	d.RawName = val
+}
+
+func (d DatasetV0) Version() string {
+	return d.RawVersion
+}
+
+func (d *DatasetV0) SetVersion(val string) {
+	d.RawVersion = val
+}
+
+func (d DatasetV0) ParsedSchema() interface{} {
+	return schemas.ParsedDatasetV0()
+}
+
+func (d DatasetV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/dataset.json")
+}
+
+func (d DatasetV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/dataset.json")
+}
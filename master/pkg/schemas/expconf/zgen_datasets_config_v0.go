@@ -0,0 +1,21 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (d DatasetsConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedDatasetsConfigV0()
+}
+
+func (d DatasetsConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/datasets.json")
+}
+
+func (d DatasetsConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/datasets.json")
+}
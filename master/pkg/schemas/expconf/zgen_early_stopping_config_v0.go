@@ -0,0 +1,21 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (e EarlyStoppingConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedEarlyStoppingConfigV0()
+}
+
+func (e EarlyStoppingConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/early-stopping.json")
+}
+
+func (e EarlyStoppingConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/early-stopping.json")
+}
@@ -0,0 +1,86 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (e EarlyStoppingRuleV0) Type() string {
+	return e.RawType
+}
+
+func (e *EarlyStoppingRuleV0) SetType(val string) {
+	e.RawType = val
+}
+
+func (e EarlyStoppingRuleV0) Metric() *string {
+	return e.RawMetric
+}
+
+func (e *EarlyStoppingRuleV0) SetMetric(val *string) {
+	e.RawMetric = val
+}
+
+func (e EarlyStoppingRuleV0) Min() *float64 {
+	return e.RawMin
+}
+
+func (e *EarlyStoppingRuleV0) SetMin(val *float64) {
+	e.RawMin = val
+}
+
+func (e EarlyStoppingRuleV0) Max() *float64 {
+	return e.RawMax
+}
+
+func (e *EarlyStoppingRuleV0) SetMax(val *float64) {
+	e.RawMax = val
+}
+
+func (e EarlyStoppingRuleV0) Patience() int {
+	if e.RawPatience == nil {
+		panic("You must call WithDefaults on EarlyStoppingRuleV0 before .Patience")
+	}
+	return *e.RawPatience
+}
+
+func (e *EarlyStoppingRuleV0) SetPatience(val int) {
+	e.RawPatience = &val
+}
+
+func (e EarlyStoppingRuleV0) MinDelta() float64 {
+	if e.RawMinDelta == nil {
+		panic("You must call WithDefaults on EarlyStoppingRuleV0 before .MinDelta")
+	}
+	return *e.RawMinDelta
+}
+
+func (e *EarlyStoppingRuleV0) SetMinDelta(val float64) {
+	e.RawMinDelta = &val
+}
+
+func (e EarlyStoppingRuleV0) SmallerIsBetter() bool {
+	if e.RawSmallerIsBetter == nil {
+		panic("You must call WithDefaults on EarlyStoppingRuleV0 before .SmallerIsBetter")
+	}
+	return *e.RawSmallerIsBetter
+}
+
+func (e *EarlyStoppingRuleV0) SetSmallerIsBetter(val bool) {
+	e.RawSmallerIsBetter = &val
+}
+
+func (e EarlyStoppingRuleV0) ParsedSchema() interface{} {
+	return schemas.ParsedEarlyStoppingRuleV0()
+}
+
+func (e EarlyStoppingRuleV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/early-stopping-rule.json")
+}
+
+func (e EarlyStoppingRuleV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/early-stopping-rule.json")
+}
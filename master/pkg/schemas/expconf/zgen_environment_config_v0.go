@@ -66,6 +66,17 @@ func (e *EnvironmentConfigV0) SetPodSpec(val *PodSpec) {
 	e.RawPodSpec = val
 }
 
+func (e EnvironmentConfigV0) Kubernetes() KubernetesConfigV0 {
+	if e.RawKubernetes == nil {
+		panic("You must call WithDefaults on EnvironmentConfigV0 before .Kubernetes")
+	}
+	return *e.RawKubernetes
+}
+
+func (e *EnvironmentConfigV0) SetKubernetes(val KubernetesConfigV0) {
+	e.RawKubernetes = &val
+}
+
 func (e EnvironmentConfigV0) AddCapabilities() []string {
 	return e.RawAddCapabilities
 }
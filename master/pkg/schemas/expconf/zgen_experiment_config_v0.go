@@ -8,6 +8,14 @@ import (
 	"github.com/determined-ai/determined/master/pkg/schemas"
 )
 
+func (e ExperimentConfigV0) Alerts() AlertsConfigV0 {
+	return e.RawAlerts
+}
+
+func (e *ExperimentConfigV0) SetAlerts(val AlertsConfigV0) {
+	e.RawAlerts = val
+}
+
 func (e ExperimentConfigV0) BindMounts() BindMountsConfigV0 {
 	return e.RawBindMounts
 }
@@ -57,6 +65,14 @@ func (e *ExperimentConfigV0) SetData(val map[string]interface{}) {
 	e.RawData = val
 }
 
+func (e ExperimentConfigV0) Datasets() DatasetsConfigV0 {
+	return e.RawDatasets
+}
+
+func (e *ExperimentConfigV0) SetDatasets(val DatasetsConfigV0) {
+	e.RawDatasets = val
+}
+
 func (e ExperimentConfigV0) Debug() bool {
 	if e.RawDebug == nil {
 		panic("You must call WithDefaults on ExperimentConfigV0 before .Debug")
@@ -76,6 +92,14 @@ func (e *ExperimentConfigV0) SetDescription(val Description) {
 	e.RawDescription = val
 }
 
+func (e ExperimentConfigV0) EarlyStopping() EarlyStoppingConfigV0 {
+	return e.RawEarlyStopping
+}
+
+func (e *ExperimentConfigV0) SetEarlyStopping(val EarlyStoppingConfigV0) {
+	e.RawEarlyStopping = val
+}
+
 func (e ExperimentConfigV0) Entrypoint() *string {
 	return e.RawEntrypoint
 }
@@ -119,6 +143,17 @@ func (e *ExperimentConfigV0) SetLabels(val LabelsV0) {
 	e.RawLabels = val
 }
 
+func (e ExperimentConfigV0) LogRetention() LogRetentionConfigV0 {
+	if e.RawLogRetention == nil {
+		panic("You must call WithDefaults on ExperimentConfigV0 before .LogRetention")
+	}
+	return *e.RawLogRetention
+}
+
+func (e *ExperimentConfigV0) SetLogRetention(val LogRetentionConfigV0) {
+	e.RawLogRetention = &val
+}
+
 func (e ExperimentConfigV0) MaxRestarts() int {
 	if e.RawMaxRestarts == nil {
 		panic("You must call WithDefaults on ExperimentConfigV0 before .MaxRestarts")
@@ -130,6 +165,14 @@ func (e *ExperimentConfigV0) SetMaxRestarts(val int) {
 	e.RawMaxRestarts = &val
 }
 
+func (e ExperimentConfigV0) MaxRuntime() *int {
+	return e.RawMaxRuntime
+}
+
+func (e *ExperimentConfigV0) SetMaxRuntime(val *int) {
+	e.RawMaxRuntime = val
+}
+
 func (e ExperimentConfigV0) MinCheckpointPeriod() LengthV0 {
 	if e.RawMinCheckpointPeriod == nil {
 		panic("You must call WithDefaults on ExperimentConfigV0 before .MinCheckpointPeriod")
@@ -229,6 +272,14 @@ func (e *ExperimentConfigV0) SetSchedulingUnit(val int) {
 	e.RawSchedulingUnit = &val
 }
 
+func (e ExperimentConfigV0) SchedulingWindows() SchedulingWindowsConfigV0 {
+	return e.RawSchedulingWindows
+}
+
+func (e *ExperimentConfigV0) SetSchedulingWindows(val SchedulingWindowsConfigV0) {
+	e.RawSchedulingWindows = val
+}
+
 func (e ExperimentConfigV0) Searcher() SearcherConfigV0 {
 	if e.RawSearcher == nil {
 		panic("You must call WithDefaults on ExperimentConfigV0 before .Searcher")
@@ -248,6 +299,22 @@ func (e *ExperimentConfigV0) SetSecurity(val *SecurityConfigV0) {
 	e.RawSecurity = val
 }
 
+func (e ExperimentConfigV0) StopGracePeriod() *int {
+	return e.RawStopGracePeriod
+}
+
+func (e *ExperimentConfigV0) SetStopGracePeriod(val *int) {
+	e.RawStopGracePeriod = val
+}
+
+func (e ExperimentConfigV0) StopSignal() *string {
+	return e.RawStopSignal
+}
+
+func (e *ExperimentConfigV0) SetStopSignal(val *string) {
+	e.RawStopSignal = val
+}
+
 func (e ExperimentConfigV0) TensorboardStorage() *TensorboardStorageConfigV0 {
 	return e.RawTensorboardStorage
 }
@@ -0,0 +1,46 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+	k8sV1 "k8s.io/api/core/v1"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (k KubernetesConfigV0) Namespace() *string {
+	return k.RawNamespace
+}
+
+func (k *KubernetesConfigV0) SetNamespace(val *string) {
+	k.RawNamespace = val
+}
+
+func (k KubernetesConfigV0) ServiceAccountName() *string {
+	return k.RawServiceAccountName
+}
+
+func (k *KubernetesConfigV0) SetServiceAccountName(val *string) {
+	k.RawServiceAccountName = val
+}
+
+func (k KubernetesConfigV0) Tolerations() []k8sV1.Toleration {
+	return k.RawTolerations
+}
+
+func (k *KubernetesConfigV0) SetTolerations(val []k8sV1.Toleration) {
+	k.RawTolerations = val
+}
+
+func (k KubernetesConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedKubernetesConfigV0()
+}
+
+func (k KubernetesConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/kubernetes.json")
+}
+
+func (k KubernetesConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/kubernetes.json")
+}
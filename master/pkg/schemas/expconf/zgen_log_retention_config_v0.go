@@ -0,0 +1,37 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (l LogRetentionConfigV0) Days() *int {
+	return l.RawDays
+}
+
+func (l *LogRetentionConfigV0) SetDays(val *int) {
+	l.RawDays = val
+}
+
+func (l LogRetentionConfigV0) MaxLinesPerTrial() *int {
+	return l.RawMaxLinesPerTrial
+}
+
+func (l *LogRetentionConfigV0) SetMaxLinesPerTrial(val *int) {
+	l.RawMaxLinesPerTrial = val
+}
+
+func (l LogRetentionConfigV0) ParsedSchema() interface{} {
+	return schemas.ParsedLogRetentionConfigV0()
+}
+
+func (l LogRetentionConfigV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/log-retention.json")
+}
+
+func (l LogRetentionConfigV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/log-retention.json")
+}
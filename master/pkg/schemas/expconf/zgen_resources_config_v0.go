@@ -65,6 +65,22 @@ func (r *ResourcesConfigV0) SetShmSize(val *int) {
 	r.RawShmSize = val
 }
 
+func (r ResourcesConfigV0) CPULimit() *float64 {
+	return r.RawCPULimit
+}
+
+func (r *ResourcesConfigV0) SetCPULimit(val *float64) {
+	r.RawCPULimit = val
+}
+
+func (r ResourcesConfigV0) MemoryLimit() *int {
+	return r.RawMemoryLimit
+}
+
+func (r *ResourcesConfigV0) SetMemoryLimit(val *int) {
+	r.RawMemoryLimit = val
+}
+
 func (r ResourcesConfigV0) AgentLabel() string {
 	if r.RawAgentLabel == nil {
 		panic("You must call WithDefaults on ResourcesConfigV0 before .AgentLabel")
@@ -95,6 +111,36 @@ func (r *ResourcesConfigV0) SetPriority(val *int) {
 	r.RawPriority = val
 }
 
+func (r ResourcesConfigV0) Platform() string {
+	if r.RawPlatform == nil {
+		panic("You must call WithDefaults on ResourcesConfigV0 before .Platform")
+	}
+	return *r.RawPlatform
+}
+
+func (r *ResourcesConfigV0) SetPlatform(val string) {
+	r.RawPlatform = &val
+}
+
+func (r ResourcesConfigV0) GPUType() string {
+	if r.RawGPUType == nil {
+		panic("You must call WithDefaults on ResourcesConfigV0 before .GPUType")
+	}
+	return *r.RawGPUType
+}
+
+func (r *ResourcesConfigV0) SetGPUType(val string) {
+	r.RawGPUType = &val
+}
+
+func (r ResourcesConfigV0) MinSlotsPerTrial() *int {
+	return r.RawMinSlotsPerTrial
+}
+
+func (r *ResourcesConfigV0) SetMinSlotsPerTrial(val *int) {
+	r.RawMinSlotsPerTrial = val
+}
+
 func (r ResourcesConfigV0) Devices() DevicesConfigV0 {
 	return r.RawDevices
 }
@@ -0,0 +1,56 @@
+// Code generated by gen.py. DO NOT EDIT.
+
+package expconf
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v2"
+
+	"github.com/determined-ai/determined/master/pkg/schemas"
+)
+
+func (s SchedulingWindowV0) Days() []string {
+	return s.RawDays
+}
+
+func (s *SchedulingWindowV0) SetDays(val []string) {
+	s.RawDays = val
+}
+
+func (s SchedulingWindowV0) Start() string {
+	return s.RawStart
+}
+
+func (s *SchedulingWindowV0) SetStart(val string) {
+	s.RawStart = val
+}
+
+func (s SchedulingWindowV0) End() string {
+	return s.RawEnd
+}
+
+func (s *SchedulingWindowV0) SetEnd(val string) {
+	s.RawEnd = val
+}
+
+func (s SchedulingWindowV0) TimeZone() string {
+	if s.RawTimeZone == nil {
+		panic("You must call WithDefaults on SchedulingWindowV0 before .TimeZone")
+	}
+	return *s.RawTimeZone
+}
+
+func (s *SchedulingWindowV0) SetTimeZone(val string) {
+	s.RawTimeZone = &val
+}
+
+func (s SchedulingWindowV0) ParsedSchema() interface{} {
+	return schemas.ParsedSchedulingWindowV0()
+}
+
+func (s SchedulingWindowV0) SanityValidator() *jsonschema.Schema {
+	return schemas.GetSanityValidator("http://determined.ai/schemas/expconf/v0/scheduling-window.json")
+}
+
+func (s SchedulingWindowV0) CompletenessValidator() *jsonschema.Schema {
+	return schemas.GetCompletenessValidator("http://determined.ai/schemas/expconf/v0/scheduling-window.json")
+}
@@ -65,6 +65,9 @@ func (s SearcherConfigV0) GetUnionMember() interface{} {
 	if s.RawPBTConfig != nil {
 		return *s.RawPBTConfig
 	}
+	if s.RawCustomConfig != nil {
+		return *s.RawCustomConfig
+	}
 	panic("no union member defined")
 }
 
@@ -29,8 +29,7 @@ func SaneBytes(schema Schema, byts []byte) error {
 	validator := schema.SanityValidator()
 	err := validator.Validate(bytes.NewReader(byts))
 	if err != nil {
-		err = errors.New(JoinErrors(GetRenderedErrors(err, byts), "\n"))
-		return errors.Wrap(err, "config is invalid")
+		return errors.Wrap(ValidationErrors(GetRenderedErrors(err, byts)), "config is invalid")
 	}
 	return nil
 }
@@ -46,8 +45,8 @@ func IsComplete(schema Schema) error {
 	validator := schema.CompletenessValidator()
 	err = validator.Validate(bytes.NewReader(byts))
 	if err != nil {
-		err = errors.New(JoinErrors(GetRenderedErrors(err, byts), "\n"))
-		return errors.Wrap(err, "config is invalid or incomplete")
+		return errors.Wrap(
+			ValidationErrors(GetRenderedErrors(err, byts)), "config is invalid or incomplete")
 	}
 
 	return nil
@@ -47,6 +47,14 @@ func JoinErrors(errs []error, joiner string) string {
 	return strings.Join(strs, joiner)
 }
 
+// ValidationErrors are the user-facing errors found by validating a config against a schema. Each
+// is rendered as "<config>" followed by the path to the invalid value, a colon and the problem.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	return JoinErrors(e, "\n")
+}
+
 // GetRenderedErrors takes a jsonschema valiation plus the bytes that caused it and returns
 // user-facing errors.
 func GetRenderedErrors(err error, byts []byte) []error {
@@ -7,6 +7,95 @@ import (
 )
 
 var (
+	textAlertRuleV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/alert-rule.json",
+    "title": "AlertRule",
+    "additionalProperties": false,
+    "required": [
+        "metric"
+    ],
+    "type": "object",
+    "properties": {
+        "name": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "metric": {
+            "type": "string"
+        },
+        "above": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "below": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "for_reports": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": 1
+        },
+        "action": {
+            "enum": [
+                null,
+                "notify",
+                "pause"
+            ],
+            "default": "notify"
+        }
+    },
+    "checks": {
+        "alert rules must set above or below": {
+            "anyOf": [
+                {
+                    "required": [
+                        "above"
+                    ],
+                    "properties": {
+                        "above": {
+                            "type": "number"
+                        }
+                    }
+                },
+                {
+                    "required": [
+                        "below"
+                    ],
+                    "properties": {
+                        "below": {
+                            "type": "number"
+                        }
+                    }
+                }
+            ]
+        }
+    }
+}
+`)
+	textAlertsConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/alerts.json",
+    "title": "AlertsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/alert-rule.json"
+    }
+}
+`)
 	textBindMountV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
     "$id": "http://determined.ai/schemas/expconf/v0/bind-mount.json",
@@ -43,6 +132,13 @@ var (
             ],
             "default": false
         },
+        "platform": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": ""
+        },
         "propagation": {
             "type": [
                 "string",
@@ -575,6 +671,36 @@ var (
         ]
     }
 }
+`)
+	textDatasetV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/dataset.json",
+    "title": "Dataset",
+    "additionalProperties": false,
+    "required": [
+        "name",
+        "version"
+    ],
+    "type": "object",
+    "properties": {
+        "name": {
+            "type": "string"
+        },
+        "version": {
+            "type": "string"
+        }
+    }
+}
+`)
+	textDatasetsConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/datasets.json",
+    "title": "DatasetsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/dataset.json"
+    }
+}
 `)
 	textDeviceV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -612,6 +738,141 @@ var (
         "$ref": "http://determined.ai/schemas/expconf/v0/device.json"
     }
 }
+`)
+	textEarlyStoppingRuleV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json",
+    "title": "EarlyStoppingRule",
+    "additionalProperties": false,
+    "required": [
+        "type"
+    ],
+    "type": "object",
+    "properties": {
+        "type": {
+            "enum": [
+                "nan",
+                "threshold",
+                "plateau"
+            ]
+        },
+        "metric": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "min": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "max": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "default": null
+        },
+        "patience": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": 5
+        },
+        "min_delta": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "minimum": 0,
+            "default": 0
+        },
+        "smaller_is_better": {
+            "type": [
+                "boolean",
+                "null"
+            ],
+            "default": true
+        }
+    },
+    "checks": {
+        "threshold and plateau rules must set a metric": {
+            "conditional": {
+                "when": {
+                    "properties": {
+                        "type": {
+                            "enum": [
+                                "threshold",
+                                "plateau"
+                            ]
+                        }
+                    }
+                },
+                "enforce": {
+                    "required": [
+                        "metric"
+                    ],
+                    "properties": {
+                        "metric": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "threshold rules must set a min or a max": {
+            "conditional": {
+                "when": {
+                    "properties": {
+                        "type": {
+                            "const": "threshold"
+                        }
+                    }
+                },
+                "enforce": {
+                    "anyOf": [
+                        {
+                            "required": [
+                                "min"
+                            ],
+                            "properties": {
+                                "min": {
+                                    "type": "number"
+                                }
+                            }
+                        },
+                        {
+                            "required": [
+                                "max"
+                            ],
+                            "properties": {
+                                "max": {
+                                    "type": "number"
+                                }
+                            }
+                        }
+                    ]
+                }
+            }
+        }
+    }
+}
+`)
+	textEarlyStoppingConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/early-stopping.json",
+    "title": "EarlyStoppingConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json"
+    }
+}
 `)
 	textEnvironmentImageMapV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -787,6 +1048,14 @@ var (
                 "type": "string"
             }
         },
+        "kubernetes": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": {},
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/kubernetes.json"
+        },
         "pod_spec": {
             "type": [
                 "object",
@@ -853,6 +1122,14 @@ var (
         "searcher"
     ],
     "properties": {
+        "alerts": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/alerts.json"
+        },
         "bind_mounts": {
             "type": [
                 "array",
@@ -895,6 +1172,14 @@ var (
             },
             "optionalRef": "http://determined.ai/schemas/expconf/v0/data-layer.json"
         },
+        "datasets": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/datasets.json"
+        },
         "debug": {
             "type": [
                 "boolean",
@@ -909,6 +1194,14 @@ var (
             ],
             "default": null
         },
+        "early_stopping": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/early-stopping.json"
+        },
         "entrypoint": {
             "type": [
                 "string",
@@ -955,6 +1248,14 @@ var (
                 "type": "string"
             }
         },
+        "log_retention": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": {},
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/log-retention.json"
+        },
         "max_restarts": {
             "type": [
                 "integer",
@@ -963,6 +1264,14 @@ var (
             "minimum": 0,
             "default": 5
         },
+        "max_runtime": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": null
+        },
         "min_checkpoint_period": {
             "type": [
                 "object",
@@ -1037,6 +1346,14 @@ var (
             "minimum": 1,
             "default": 100
         },
+        "scheduling_windows": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/scheduling-windows.json"
+        },
         "searcher": {
             "type": [
                 "object",
@@ -1053,6 +1370,22 @@ var (
             "default": null,
             "optionalRef": "http://determined.ai/schemas/expconf/v0/security.json"
         },
+        "stop_grace_period": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        },
+        "stop_signal": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "pattern": "^SIG(HUP|INT|QUIT|TERM|USR1|USR2)$",
+            "default": null
+        },
         "tensorboard_storage": {
             "type": [
                 "object",
@@ -1525,6 +1858,41 @@ var (
         }
     }
 }
+`)
+	textKubernetesConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/kubernetes.json",
+    "title": "KubernetesConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [],
+    "properties": {
+        "namespace": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "service_account_name": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "tolerations": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "items": {
+                "type": "object"
+            }
+        }
+    }
+}
 `)
 	textLengthV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -1578,6 +1946,33 @@ var (
         ]
     }
 }
+`)
+	textLogRetentionConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/log-retention.json",
+    "title": "LogRetentionConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [],
+    "properties": {
+        "days": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        },
+        "max_lines_per_trial": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": null
+        }
+    }
+}
 `)
 	textNativeConfigV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -1845,6 +2240,14 @@ var (
             ],
             "default": ""
         },
+        "cpu_limit": {
+            "type": [
+                "number",
+                "null"
+            ],
+            "exclusiveMinimum": 0,
+            "default": null
+        },
         "devices": {
             "type": [
                 "array",
@@ -1853,6 +2256,13 @@ var (
             "default": [],
             "optionalRef": "http://determined.ai/schemas/expconf/v0/devices.json"
         },
+        "gpu_type": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": ""
+        },
         "max_slots": {
             "type": [
                 "integer",
@@ -1860,6 +2270,22 @@ var (
             ],
             "default": null
         },
+        "memory_limit": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 6291456,
+            "default": null
+        },
+        "min_slots_per_trial": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 1,
+            "default": null
+        },
         "native_parallel": {
             "type": [
                 "boolean",
@@ -1867,6 +2293,18 @@ var (
             ],
             "default": false
         },
+        "platform": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "checks": {
+                "platform must be of the form os/arch, like linux/arm64": {
+                    "pattern": "^([a-z0-9_]+/[a-z0-9_]+)?$"
+                }
+            },
+            "default": ""
+        },
         "priority": {
             "type": [
                 "integer",
@@ -1912,6 +2350,15 @@ var (
             ],
             "default": 1
         }
+    },
+    "checks": {
+        "min_slots_per_trial must be less than or equal to slots_per_trial": {
+            "compareProperties": {
+                "type": "a<=b",
+                "a": "min_slots_per_trial",
+                "b": "slots_per_trial"
+            }
+        }
     }
 }
 `)
@@ -1985,6 +2432,71 @@ var (
         }
     }
 }
+`)
+	textSchedulingWindowV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/scheduling-window.json",
+    "title": "SchedulingWindow",
+    "additionalProperties": false,
+    "required": [
+        "start",
+        "end"
+    ],
+    "type": "object",
+    "properties": {
+        "days": {
+            "type": [
+                "array",
+                "null"
+            ],
+            "default": [],
+            "items": {
+                "enum": [
+                    "monday",
+                    "tuesday",
+                    "wednesday",
+                    "thursday",
+                    "friday",
+                    "saturday",
+                    "sunday"
+                ]
+            }
+        },
+        "start": {
+            "type": "string",
+            "checks": {
+                "start must be a time of the form HH:MM": {
+                    "pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+                }
+            }
+        },
+        "end": {
+            "type": "string",
+            "checks": {
+                "end must be a time of the form HH:MM": {
+                    "pattern": "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+                }
+            }
+        },
+        "time_zone": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": "UTC"
+        }
+    }
+}
+`)
+	textSchedulingWindowsConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/scheduling-windows.json",
+    "title": "SchedulingWindowsConfig",
+    "type": "array",
+    "items": {
+        "$ref": "http://determined.ai/schemas/expconf/v0/scheduling-window.json"
+    }
+}
 `)
 	textAdaptiveASHAConfigV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -2197,6 +2709,62 @@ var (
         }
     }
 }
+`)
+	textCustomConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/searcher-custom.json",
+    "title": "CustomConfig",
+    "type": "object",
+    "additionalProperties": false,
+    "required": [
+        "name"
+    ],
+    "eventuallyRequired": [
+        "metric"
+    ],
+    "properties": {
+        "name": {
+            "const": "custom"
+        },
+        "unit": {
+            "enum": [
+                null,
+                "records",
+                "batches",
+                "epochs"
+            ],
+            "default": "batches"
+        },
+        "metric": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        },
+        "smaller_is_better": {
+            "type": [
+                "boolean",
+                "null"
+            ],
+            "default": true
+        },
+        "source_trial_id": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "default": null
+        },
+        "source_checkpoint_uuid": {
+            "type": [
+                "string",
+                "null"
+            ],
+            "default": null
+        }
+    }
+}
 `)
 	textGridConfigV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -2546,7 +3114,7 @@ var (
         },
         "enforce": {
             "union": {
-                "defaultMessage": "is not an object where object[\"name\"] is one of 'single', 'random', 'grid', 'adaptive_asha', or 'pbt'",
+                "defaultMessage": "is not an object where object[\"name\"] is one of 'single', 'random', 'grid', 'adaptive_asha', 'pbt', or 'custom'",
                 "items": [
                     {
                         "unionKey": "const:name=single",
@@ -2571,6 +3139,10 @@ var (
                     {
                         "unionKey": "const:name=async_halving",
                         "$ref": "http://determined.ai/schemas/expconf/v0/searcher-async-halving.json"
+                    },
+                    {
+                        "unionKey": "const:name=custom",
+                        "$ref": "http://determined.ai/schemas/expconf/v0/searcher-custom.json"
                     }
                 ]
             }
@@ -2597,6 +3169,7 @@ var (
         "population_size": true,
         "replace_function": true,
         "stop_once": true,
+        "unit": true,
         "metric": {
             "type": [
                 "string",
@@ -2924,6 +3497,10 @@ var (
     }
 }
 `)
+	schemaAlertRuleV0 interface{}
+
+	schemaAlertsConfigV0 interface{}
+
 	schemaBindMountV0 interface{}
 
 	schemaBindMountsConfigV0 interface{}
@@ -2948,10 +3525,18 @@ var (
 
 	schemaDataLayerConfigV0 interface{}
 
+	schemaDatasetV0 interface{}
+
+	schemaDatasetsConfigV0 interface{}
+
 	schemaDeviceV0 interface{}
 
 	schemaDevicesConfigV0 interface{}
 
+	schemaEarlyStoppingRuleV0 interface{}
+
+	schemaEarlyStoppingConfigV0 interface{}
+
 	schemaEnvironmentImageMapV0 interface{}
 
 	schemaEnvironmentImageV0 interface{}
@@ -2986,8 +3571,12 @@ var (
 
 	schemaKerberosConfigV0 interface{}
 
+	schemaKubernetesConfigV0 interface{}
+
 	schemaLengthV0 interface{}
 
+	schemaLogRetentionConfigV0 interface{}
+
 	schemaNativeConfigV0 interface{}
 
 	schemaOptimizationsConfigV0 interface{}
@@ -3002,10 +3591,16 @@ var (
 
 	schemaS3ConfigV0 interface{}
 
+	schemaSchedulingWindowV0 interface{}
+
+	schemaSchedulingWindowsConfigV0 interface{}
+
 	schemaAdaptiveASHAConfigV0 interface{}
 
 	schemaAsyncHalvingConfigV0 interface{}
 
+	schemaCustomConfigV0 interface{}
+
 	schemaGridConfigV0 interface{}
 
 	schemaPBTConfigV0 interface{}
@@ -3037,6 +3632,28 @@ var (
 	cachedSchemaBytesMap map[string][]byte
 )
 
+func ParsedAlertRuleV0() interface{} {
+	if schemaAlertRuleV0 != nil {
+		return schemaAlertRuleV0
+	}
+	err := json.Unmarshal(textAlertRuleV0, &schemaAlertRuleV0)
+	if err != nil {
+		panic("invalid embedded json for AlertRuleV0")
+	}
+	return schemaAlertRuleV0
+}
+
+func ParsedAlertsConfigV0() interface{} {
+	if schemaAlertsConfigV0 != nil {
+		return schemaAlertsConfigV0
+	}
+	err := json.Unmarshal(textAlertsConfigV0, &schemaAlertsConfigV0)
+	if err != nil {
+		panic("invalid embedded json for AlertsConfigV0")
+	}
+	return schemaAlertsConfigV0
+}
+
 func ParsedBindMountV0() interface{} {
 	if schemaBindMountV0 != nil {
 		return schemaBindMountV0
@@ -3169,6 +3786,28 @@ func ParsedDataLayerConfigV0() interface{} {
 	return schemaDataLayerConfigV0
 }
 
+func ParsedDatasetV0() interface{} {
+	if schemaDatasetV0 != nil {
+		return schemaDatasetV0
+	}
+	err := json.Unmarshal(textDatasetV0, &schemaDatasetV0)
+	if err != nil {
+		panic("invalid embedded json for DatasetV0")
+	}
+	return schemaDatasetV0
+}
+
+func ParsedDatasetsConfigV0() interface{} {
+	if schemaDatasetsConfigV0 != nil {
+		return schemaDatasetsConfigV0
+	}
+	err := json.Unmarshal(textDatasetsConfigV0, &schemaDatasetsConfigV0)
+	if err != nil {
+		panic("invalid embedded json for DatasetsConfigV0")
+	}
+	return schemaDatasetsConfigV0
+}
+
 func ParsedDeviceV0() interface{} {
 	if schemaDeviceV0 != nil {
 		return schemaDeviceV0
@@ -3191,6 +3830,28 @@ func ParsedDevicesConfigV0() interface{} {
 	return schemaDevicesConfigV0
 }
 
+func ParsedEarlyStoppingRuleV0() interface{} {
+	if schemaEarlyStoppingRuleV0 != nil {
+		return schemaEarlyStoppingRuleV0
+	}
+	err := json.Unmarshal(textEarlyStoppingRuleV0, &schemaEarlyStoppingRuleV0)
+	if err != nil {
+		panic("invalid embedded json for EarlyStoppingRuleV0")
+	}
+	return schemaEarlyStoppingRuleV0
+}
+
+func ParsedEarlyStoppingConfigV0() interface{} {
+	if schemaEarlyStoppingConfigV0 != nil {
+		return schemaEarlyStoppingConfigV0
+	}
+	err := json.Unmarshal(textEarlyStoppingConfigV0, &schemaEarlyStoppingConfigV0)
+	if err != nil {
+		panic("invalid embedded json for EarlyStoppingConfigV0")
+	}
+	return schemaEarlyStoppingConfigV0
+}
+
 func ParsedEnvironmentImageMapV0() interface{} {
 	if schemaEnvironmentImageMapV0 != nil {
 		return schemaEnvironmentImageMapV0
@@ -3378,6 +4039,17 @@ func ParsedKerberosConfigV0() interface{} {
 	return schemaKerberosConfigV0
 }
 
+func ParsedKubernetesConfigV0() interface{} {
+	if schemaKubernetesConfigV0 != nil {
+		return schemaKubernetesConfigV0
+	}
+	err := json.Unmarshal(textKubernetesConfigV0, &schemaKubernetesConfigV0)
+	if err != nil {
+		panic("invalid embedded json for KubernetesConfigV0")
+	}
+	return schemaKubernetesConfigV0
+}
+
 func ParsedLengthV0() interface{} {
 	if schemaLengthV0 != nil {
 		return schemaLengthV0
@@ -3389,6 +4061,17 @@ func ParsedLengthV0() interface{} {
 	return schemaLengthV0
 }
 
+func ParsedLogRetentionConfigV0() interface{} {
+	if schemaLogRetentionConfigV0 != nil {
+		return schemaLogRetentionConfigV0
+	}
+	err := json.Unmarshal(textLogRetentionConfigV0, &schemaLogRetentionConfigV0)
+	if err != nil {
+		panic("invalid embedded json for LogRetentionConfigV0")
+	}
+	return schemaLogRetentionConfigV0
+}
+
 func ParsedNativeConfigV0() interface{} {
 	if schemaNativeConfigV0 != nil {
 		return schemaNativeConfigV0
@@ -3466,6 +4149,28 @@ func ParsedS3ConfigV0() interface{} {
 	return schemaS3ConfigV0
 }
 
+func ParsedSchedulingWindowV0() interface{} {
+	if schemaSchedulingWindowV0 != nil {
+		return schemaSchedulingWindowV0
+	}
+	err := json.Unmarshal(textSchedulingWindowV0, &schemaSchedulingWindowV0)
+	if err != nil {
+		panic("invalid embedded json for SchedulingWindowV0")
+	}
+	return schemaSchedulingWindowV0
+}
+
+func ParsedSchedulingWindowsConfigV0() interface{} {
+	if schemaSchedulingWindowsConfigV0 != nil {
+		return schemaSchedulingWindowsConfigV0
+	}
+	err := json.Unmarshal(textSchedulingWindowsConfigV0, &schemaSchedulingWindowsConfigV0)
+	if err != nil {
+		panic("invalid embedded json for SchedulingWindowsConfigV0")
+	}
+	return schemaSchedulingWindowsConfigV0
+}
+
 func ParsedAdaptiveASHAConfigV0() interface{} {
 	if schemaAdaptiveASHAConfigV0 != nil {
 		return schemaAdaptiveASHAConfigV0
@@ -3488,6 +4193,17 @@ func ParsedAsyncHalvingConfigV0() interface{} {
 	return schemaAsyncHalvingConfigV0
 }
 
+func ParsedCustomConfigV0() interface{} {
+	if schemaCustomConfigV0 != nil {
+		return schemaCustomConfigV0
+	}
+	err := json.Unmarshal(textCustomConfigV0, &schemaCustomConfigV0)
+	if err != nil {
+		panic("invalid embedded json for CustomConfigV0")
+	}
+	return schemaCustomConfigV0
+}
+
 func ParsedGridConfigV0() interface{} {
 	if schemaGridConfigV0 != nil {
 		return schemaGridConfigV0
@@ -3637,6 +4353,10 @@ func schemaBytesMap() map[string][]byte {
 	}
 	var url string
 	cachedSchemaBytesMap = map[string][]byte{}
+	url = "http://determined.ai/schemas/expconf/v0/alert-rule.json"
+	cachedSchemaBytesMap[url] = textAlertRuleV0
+	url = "http://determined.ai/schemas/expconf/v0/alerts.json"
+	cachedSchemaBytesMap[url] = textAlertsConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/bind-mount.json"
 	cachedSchemaBytesMap[url] = textBindMountV0
 	url = "http://determined.ai/schemas/expconf/v0/bind-mounts.json"
@@ -3661,10 +4381,18 @@ func schemaBytesMap() map[string][]byte {
 	cachedSchemaBytesMap[url] = textSharedFSDataLayerConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/data-layer.json"
 	cachedSchemaBytesMap[url] = textDataLayerConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/dataset.json"
+	cachedSchemaBytesMap[url] = textDatasetV0
+	url = "http://determined.ai/schemas/expconf/v0/datasets.json"
+	cachedSchemaBytesMap[url] = textDatasetsConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/device.json"
 	cachedSchemaBytesMap[url] = textDeviceV0
 	url = "http://determined.ai/schemas/expconf/v0/devices.json"
 	cachedSchemaBytesMap[url] = textDevicesConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/early-stopping-rule.json"
+	cachedSchemaBytesMap[url] = textEarlyStoppingRuleV0
+	url = "http://determined.ai/schemas/expconf/v0/early-stopping.json"
+	cachedSchemaBytesMap[url] = textEarlyStoppingConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/environment-image-map.json"
 	cachedSchemaBytesMap[url] = textEnvironmentImageMapV0
 	url = "http://determined.ai/schemas/expconf/v0/environment-image.json"
@@ -3699,8 +4427,12 @@ func schemaBytesMap() map[string][]byte {
 	cachedSchemaBytesMap[url] = textInternalConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/kerberos.json"
 	cachedSchemaBytesMap[url] = textKerberosConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/kubernetes.json"
+	cachedSchemaBytesMap[url] = textKubernetesConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/length.json"
 	cachedSchemaBytesMap[url] = textLengthV0
+	url = "http://determined.ai/schemas/expconf/v0/log-retention.json"
+	cachedSchemaBytesMap[url] = textLogRetentionConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/native.json"
 	cachedSchemaBytesMap[url] = textNativeConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/optimizations.json"
@@ -3715,10 +4447,16 @@ func schemaBytesMap() map[string][]byte {
 	cachedSchemaBytesMap[url] = textResourcesConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/s3.json"
 	cachedSchemaBytesMap[url] = textS3ConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/scheduling-window.json"
+	cachedSchemaBytesMap[url] = textSchedulingWindowV0
+	url = "http://determined.ai/schemas/expconf/v0/scheduling-windows.json"
+	cachedSchemaBytesMap[url] = textSchedulingWindowsConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/searcher-adaptive-asha.json"
 	cachedSchemaBytesMap[url] = textAdaptiveASHAConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/searcher-async-halving.json"
 	cachedSchemaBytesMap[url] = textAsyncHalvingConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/searcher-custom.json"
+	cachedSchemaBytesMap[url] = textCustomConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/searcher-grid.json"
 	cachedSchemaBytesMap[url] = textGridConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/searcher-pbt.json"
@@ -0,0 +1,164 @@
+package searcher
+
+import (
+	"encoding/json"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/master/pkg/workload"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
+)
+
+// CustomSearchEventType is the type of an event of a custom search.
+type CustomSearchEventType string
+
+// All the events of a custom search.
+const (
+	InitialOperationsEvent   CustomSearchEventType = "initial_operations"
+	TrialCreatedEvent        CustomSearchEventType = "trial_created"
+	ValidationCompletedEvent CustomSearchEventType = "validation_completed"
+	TrialClosedEvent         CustomSearchEventType = "trial_closed"
+	TrialExitedEarlyEvent    CustomSearchEventType = "trial_exited_early"
+)
+
+// CustomSearchEvent is an event of a custom search, which is kept until the process driving the
+// search answers it with its operations.
+type CustomSearchEvent struct {
+	ID           int                   `json:"id"`
+	Type         CustomSearchEventType `json:"type"`
+	RequestID    model.RequestID       `json:"request_id"`
+	Metric       float64               `json:"metric"`
+	ExitedReason workload.ExitedReason `json:"exited_reason"`
+}
+
+// ToProto converts a CustomSearchEvent to its protobuf representation.
+func (e CustomSearchEvent) ToProto() *experimentv1.SearcherEvent {
+	event := &experimentv1.SearcherEvent{Id: int32(e.ID)}
+	requestID := e.RequestID.String()
+	switch e.Type {
+	case InitialOperationsEvent:
+		event.Event = &experimentv1.SearcherEvent_InitialOperations{
+			InitialOperations: &experimentv1.InitialOperations{},
+		}
+	case TrialCreatedEvent:
+		event.Event = &experimentv1.SearcherEvent_TrialCreated{
+			TrialCreated: &experimentv1.TrialCreated{RequestId: requestID},
+		}
+	case ValidationCompletedEvent:
+		event.Event = &experimentv1.SearcherEvent_ValidationCompleted{
+			ValidationCompleted: &experimentv1.ValidationCompleted{
+				RequestId: requestID, Metric: e.Metric,
+			},
+		}
+	case TrialClosedEvent:
+		event.Event = &experimentv1.SearcherEvent_TrialClosed{
+			TrialClosed: &experimentv1.TrialClosed{RequestId: requestID},
+		}
+	case TrialExitedEarlyEvent:
+		reason := experimentv1.TrialExitedEarly_EXITED_REASON_UNSPECIFIED
+		switch e.ExitedReason {
+		case workload.InvalidHP:
+			reason = experimentv1.TrialExitedEarly_EXITED_REASON_INVALID_HP
+		case workload.UserCanceled:
+			reason = experimentv1.TrialExitedEarly_EXITED_REASON_USER_REQUESTED_STOP
+		}
+		event.Event = &experimentv1.SearcherEvent_TrialExitedEarly{
+			TrialExitedEarly: &experimentv1.TrialExitedEarly{
+				RequestId: requestID, ExitedReason: reason,
+			},
+		}
+	}
+	return event
+}
+
+type (
+	// customSearchState stores the events that the process driving the search has not answered yet,
+	// along with the progress of the search as it last reported it.
+	customSearchState struct {
+		Events           []CustomSearchEvent `json:"events"`
+		LastEventID      int                 `json:"last_event_id"`
+		Progress         float64             `json:"progress"`
+		SearchMethodType SearchMethodType    `json:"search_method_type"`
+	}
+	// customSearch is a search whose operations are made by a process outside of the master, such
+	// as one wrapping Optuna or Ax. The search only queues the events of the experiment for the
+	// process, which posts its operations in answer to them.
+	customSearch struct {
+		expconf.CustomConfig
+		customSearchState
+	}
+)
+
+func newCustomSearch(config expconf.CustomConfig) SearchMethod {
+	return &customSearch{
+		CustomConfig: config,
+		customSearchState: customSearchState{
+			SearchMethodType: CustomSearch,
+		},
+	}
+}
+
+func (s *customSearch) addEvent(event CustomSearchEvent) {
+	s.LastEventID++
+	event.ID = s.LastEventID
+	s.Events = append(s.Events, event)
+}
+
+// acknowledge drops the events up to and including the one with the ID, which the process driving
+// the search has answered.
+func (s *customSearch) acknowledge(eventID int) {
+	i := 0
+	for i < len(s.Events) && s.Events[i].ID <= eventID {
+		i++
+	}
+	s.Events = s.Events[i:]
+}
+
+func (s *customSearch) initialOperations(context) ([]Operation, error) {
+	s.addEvent(CustomSearchEvent{Type: InitialOperationsEvent})
+	return nil, nil
+}
+
+func (s *customSearch) trialCreated(_ context, requestID model.RequestID) ([]Operation, error) {
+	s.addEvent(CustomSearchEvent{Type: TrialCreatedEvent, RequestID: requestID})
+	return nil, nil
+}
+
+func (s *customSearch) validationCompleted(
+	_ context, requestID model.RequestID, metric float64,
+) ([]Operation, error) {
+	s.addEvent(CustomSearchEvent{
+		Type: ValidationCompletedEvent, RequestID: requestID, Metric: metric,
+	})
+	return nil, nil
+}
+
+func (s *customSearch) trialClosed(_ context, requestID model.RequestID) ([]Operation, error) {
+	s.addEvent(CustomSearchEvent{Type: TrialClosedEvent, RequestID: requestID})
+	return nil, nil
+}
+
+// trialExitedEarly leaves it to the process driving the search to decide whether the search fails.
+func (s *customSearch) trialExitedEarly(
+	_ context, requestID model.RequestID, exitedReason workload.ExitedReason,
+) ([]Operation, error) {
+	s.addEvent(CustomSearchEvent{
+		Type: TrialExitedEarlyEvent, RequestID: requestID, ExitedReason: exitedReason,
+	})
+	return nil, nil
+}
+
+func (s *customSearch) progress(map[model.RequestID]model.PartialUnits) float64 {
+	return s.Progress
+}
+
+func (s *customSearch) Snapshot() (json.RawMessage, error) {
+	return json.Marshal(s.customSearchState)
+}
+
+func (s *customSearch) Restore(state json.RawMessage) error {
+	if state == nil {
+		return nil
+	}
+	return json.Unmarshal(state, &s.customSearchState)
+}
@@ -0,0 +1,168 @@
+package searcher
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
+)
+
+func customOps(ops ...interface{}) []*experimentv1.CustomSearcherOperation {
+	var protoOps []*experimentv1.CustomSearcherOperation
+	for _, op := range ops {
+		protoOp := &experimentv1.CustomSearcherOperation{}
+		switch op := op.(type) {
+		case *experimentv1.CreateTrialOperation:
+			protoOp.Union = &experimentv1.CustomSearcherOperation_CreateTrial{CreateTrial: op}
+		case *experimentv1.ValidateAfterTrialOperation:
+			protoOp.Union = &experimentv1.CustomSearcherOperation_ValidateAfter{ValidateAfter: op}
+		case *experimentv1.CloseTrialOperation:
+			protoOp.Union = &experimentv1.CustomSearcherOperation_CloseTrial{CloseTrial: op}
+		case *experimentv1.ShutdownOperation:
+			protoOp.Union = &experimentv1.CustomSearcherOperation_Shutdown{Shutdown: op}
+		}
+		protoOps = append(protoOps, protoOp)
+	}
+	return protoOps
+}
+
+func TestCustomSearch(t *testing.T) {
+	config := schemas.WithDefaults(expconf.CustomConfig{}).(expconf.CustomConfig)
+	assert.Equal(t, config.Unit(), expconf.Batches)
+	hparams := expconf.Hyperparameters{
+		"lr": expconf.Hyperparameter{
+			RawDoubleHyperparameter: &expconf.DoubleHyperparameter{RawMinval: 0, RawMaxval: 1},
+		},
+		"global_batch_size": expconf.Hyperparameter{
+			RawConstHyperparameter: &expconf.ConstHyperparameter{RawVal: 32},
+		},
+	}
+	s := NewSearcher(0, newCustomSearch(config), hparams)
+
+	ops, err := s.InitialOperations()
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 0)
+	events, err := s.CustomSearchEvents()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []CustomSearchEvent{{ID: 1, Type: InitialOperationsEvent}})
+
+	requestID := model.NewRequestID(s.Rand)
+	lr, err := structpb.NewStruct(map[string]interface{}{"lr": 0.1})
+	assert.NilError(t, err)
+	length := expconf.NewLengthInBatches(100).ToProto()
+	ops, err = s.CustomSearchOperations(1, 0.1, customOps(
+		&experimentv1.CreateTrialOperation{RequestId: requestID.String(), Hyperparams: lr},
+		&experimentv1.ValidateAfterTrialOperation{RequestId: requestID.String(), Length: length},
+		&experimentv1.CloseTrialOperation{RequestId: requestID.String()},
+	))
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 3)
+	create := ops[0].(Create)
+	assert.Equal(t, create.RequestID, requestID)
+	assert.DeepEqual(t, create.Hparams, hparamSample{"lr": 0.1, "global_batch_size": 32})
+	assert.Equal(t, ops[1], NewValidateAfter(requestID, expconf.NewLengthInBatches(100)))
+	assert.Equal(t, s.Progress(), 0.1)
+	events, err = s.CustomSearchEvents()
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+
+	_, err = s.TrialCreated(create, 1)
+	assert.NilError(t, err)
+	_, err = s.ValidationCompleted(1, 0.5, ops[1].(ValidateAfter))
+	assert.NilError(t, err)
+	// The search goes on after its trials are closed until it is shut down.
+	ops, err = s.TrialClosed(requestID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(ops), 0)
+	assert.Assert(t, !s.Shutdown)
+
+	// The events are kept across snapshots until they are answered.
+	snapshot, err := s.Snapshot()
+	assert.NilError(t, err)
+	restored := NewSearcher(0, newCustomSearch(config), hparams)
+	assert.NilError(t, restored.Restore(snapshot))
+	events, err = restored.CustomSearchEvents()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []CustomSearchEvent{
+		{ID: 2, Type: TrialCreatedEvent, RequestID: requestID},
+		{ID: 3, Type: ValidationCompletedEvent, RequestID: requestID, Metric: 0.5},
+		{ID: 4, Type: TrialClosedEvent, RequestID: requestID},
+	})
+
+	ops, err = restored.CustomSearchOperations(3, 1, customOps(&experimentv1.ShutdownOperation{}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ops, []Operation{Shutdown{}})
+	assert.Assert(t, restored.Shutdown)
+	events, err = restored.CustomSearchEvents()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []CustomSearchEvent{
+		{ID: 4, Type: TrialClosedEvent, RequestID: requestID},
+	})
+}
+
+func TestCustomSearchInvalidOperations(t *testing.T) {
+	config := schemas.WithDefaults(expconf.CustomConfig{
+		RawUnitName: ptrs.StringPtr("epochs"),
+	}).(expconf.CustomConfig)
+	hparams := expconf.Hyperparameters{
+		"lr": expconf.Hyperparameter{
+			RawDoubleHyperparameter: &expconf.DoubleHyperparameter{RawMinval: 0, RawMaxval: 1},
+		},
+	}
+	s := NewSearcher(0, newCustomSearch(config), hparams)
+	_, err := s.InitialOperations()
+	assert.NilError(t, err)
+
+	created := model.NewRequestID(s.Rand)
+	lr, err := structpb.NewStruct(map[string]interface{}{"lr": 0.1})
+	assert.NilError(t, err)
+	_, err = s.CustomSearchOperations(1, 0, customOps(
+		&experimentv1.CreateTrialOperation{RequestId: created.String(), Hyperparams: lr},
+	))
+	assert.NilError(t, err)
+
+	unknown := model.NewRequestID(s.Rand).String()
+	unknownHparam, err := structpb.NewStruct(map[string]interface{}{"lr": 0.1, "momentum": 0.9})
+	assert.NilError(t, err)
+	for _, ops := range [][]*experimentv1.CustomSearcherOperation{
+		customOps(&experimentv1.CreateTrialOperation{RequestId: "trial"}),
+		customOps(&experimentv1.CreateTrialOperation{RequestId: created.String(), Hyperparams: lr}),
+		// Hyperparameters that are not constant must be given, and only known ones.
+		customOps(&experimentv1.CreateTrialOperation{RequestId: unknown}),
+		customOps(&experimentv1.CreateTrialOperation{
+			RequestId: unknown, Hyperparams: unknownHparam,
+		}),
+		customOps(&experimentv1.ValidateAfterTrialOperation{
+			RequestId: unknown, Length: expconf.NewLengthInEpochs(1).ToProto(),
+		}),
+		// Lengths must be in the unit of the searcher.
+		customOps(&experimentv1.ValidateAfterTrialOperation{
+			RequestId: created.String(), Length: expconf.NewLengthInBatches(1).ToProto(),
+		}),
+		customOps(&experimentv1.ValidateAfterTrialOperation{
+			RequestId: created.String(), Length: expconf.NewLengthInEpochs(0).ToProto(),
+		}),
+		customOps(
+			&experimentv1.CloseTrialOperation{RequestId: created.String()},
+			&experimentv1.CloseTrialOperation{RequestId: created.String()},
+		),
+	} {
+		_, err := s.CustomSearchOperations(0, 0, ops)
+		assert.ErrorContains(t, err, "", "operations %v", ops)
+	}
+	_, err = s.CustomSearchOperations(0, 1.5, nil)
+	assert.ErrorContains(t, err, "progress")
+
+	// Only custom searches take operations from outside of the master.
+	random := NewSearcher(0, newRandomSearch(schemas.WithDefaults(expconf.RandomConfig{
+		RawMaxTrials: ptrs.IntPtr(1), RawMaxLength: lengthPtr(expconf.NewLengthInBatches(1)),
+	}).(expconf.RandomConfig)), hparams)
+	_, err = random.CustomSearchEvents()
+	assert.ErrorContains(t, err, "custom searcher")
+}
@@ -59,6 +59,8 @@ const (
 	AdaptiveASHASearch SearchMethodType = "adaptive_asha"
 	// PBTSearch is the SearchMethodType for a PBT searcher.
 	PBTSearch SearchMethodType = "pbt"
+	// CustomSearch is the SearchMethodType for a custom searcher.
+	CustomSearch SearchMethodType = "custom"
 )
 
 // NewSearchMethod returns a new search method for the provided searcher configuration.
@@ -79,6 +81,8 @@ func NewSearchMethod(c expconf.SearcherConfig) SearchMethod {
 		return newAdaptiveASHASearch(*c.RawAdaptiveASHAConfig, c.SmallerIsBetter())
 	case c.RawPBTConfig != nil:
 		return newPBTSearch(*c.RawPBTConfig, c.SmallerIsBetter())
+	case c.RawCustomConfig != nil:
+		return newCustomSearch(*c.RawCustomConfig)
 	default:
 		panic("no searcher type specified")
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/nprand"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/experimentv1"
 )
 
 type (
@@ -155,7 +156,9 @@ func (s *Searcher) TrialClosed(requestID model.RequestID) ([]Operation, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "error while handling a trial closed event: %s", requestID)
 	}
-	if s.TrialsRequested == len(s.TrialsClosed) {
+	// Custom searches end only when the process driving them shuts them down.
+	_, custom := s.method.(*customSearch)
+	if !custom && s.TrialsRequested == len(s.TrialsClosed) {
 		shutdown := Shutdown{Failure: len(s.Failures) >= s.TrialsRequested}
 		operations = append(operations, shutdown)
 	}
@@ -201,6 +204,148 @@ func (s *Searcher) Record(ops []Operation) {
 	}
 }
 
+// CustomSearchEvents returns the events of a custom search that the process driving it has not
+// answered yet.
+func (s *Searcher) CustomSearchEvents() ([]CustomSearchEvent, error) {
+	method, err := s.customSearch()
+	if err != nil {
+		return nil, err
+	}
+	return method.Events, nil
+}
+
+// CustomSearchOperations records the operations that the process driving a custom search made in
+// answer to the events up to and including the one with the ID, along with its estimate of the
+// progress of the search, and returns them for the experiment to carry out.
+func (s *Searcher) CustomSearchOperations(
+	eventID int, progress float64, protoOps []*experimentv1.CustomSearcherOperation,
+) ([]Operation, error) {
+	method, err := s.customSearch()
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(progress) || progress < 0 || progress > 1 {
+		return nil, api.AsErrBadRequest("progress must be between 0 and 1")
+	}
+
+	created := map[model.RequestID]bool{}
+	for _, op := range s.TrialOperations {
+		if create, ok := op.(Create); ok {
+			created[create.RequestID] = true
+		}
+	}
+	closing := map[model.RequestID]bool{}
+	for requestID := range s.TrialsClosed {
+		closing[requestID] = true
+	}
+	for _, op := range s.TrialOperations {
+		if c, ok := op.(Close); ok {
+			closing[c.RequestID] = true
+		}
+	}
+	// parseTrial returns the request ID of an operation on a trial that is created and not closed.
+	parseTrial := func(id string) (model.RequestID, error) {
+		requestID, err := model.ParseRequestID(id)
+		switch {
+		case err != nil:
+			return requestID, api.AsErrBadRequest("invalid request ID %q: %s", id, err)
+		case !created[requestID]:
+			return requestID, api.AsErrBadRequest("trial %s was not created", requestID)
+		case closing[requestID]:
+			return requestID, api.AsErrBadRequest("trial %s is already closed", requestID)
+		}
+		return requestID, nil
+	}
+
+	var ops []Operation
+	for _, protoOp := range protoOps {
+		switch op := protoOp.Union.(type) {
+		case *experimentv1.CustomSearcherOperation_CreateTrial:
+			requestID, err := model.ParseRequestID(op.CreateTrial.RequestId)
+			switch {
+			case err != nil:
+				return nil, api.AsErrBadRequest(
+					"invalid request ID %q: %s", op.CreateTrial.RequestId, err)
+			case created[requestID]:
+				return nil, api.AsErrBadRequest("trial %s was already created", requestID)
+			}
+			hparams, err := customHparams(s.hparams, op.CreateTrial.Hyperparams.AsMap())
+			if err != nil {
+				return nil, err
+			}
+			create := NewCreate(s.Rand, hparams, model.TrialWorkloadSequencerType)
+			create.RequestID = requestID
+			created[requestID] = true
+			ops = append(ops, create)
+		case *experimentv1.CustomSearcherOperation_ValidateAfter:
+			requestID, err := parseTrial(op.ValidateAfter.RequestId)
+			if err != nil {
+				return nil, err
+			}
+			length := expconf.LengthFromProto(op.ValidateAfter.Length)
+			switch {
+			case length.Unit != method.Unit():
+				return nil, api.AsErrBadRequest(
+					"the length of trial %s must be in the unit of the searcher, %s",
+					requestID, method.UnitName())
+			case length.Units <= 0:
+				return nil, api.AsErrBadRequest("the length of trial %s must be positive", requestID)
+			}
+			ops = append(ops, NewValidateAfter(requestID, length))
+		case *experimentv1.CustomSearcherOperation_CloseTrial:
+			requestID, err := parseTrial(op.CloseTrial.RequestId)
+			if err != nil {
+				return nil, err
+			}
+			closing[requestID] = true
+			ops = append(ops, NewClose(requestID))
+		case *experimentv1.CustomSearcherOperation_Shutdown:
+			ops = append(ops, Shutdown{Failure: op.Shutdown.Failure})
+		default:
+			return nil, api.AsErrBadRequest("unknown searcher operation %v", protoOp)
+		}
+	}
+
+	method.acknowledge(eventID)
+	method.Progress = progress
+	s.Record(ops)
+	return ops, nil
+}
+
+func (s *Searcher) customSearch() (*customSearch, error) {
+	method, ok := s.method.(*customSearch)
+	if !ok {
+		return nil, api.AsErrBadRequest("the experiment does not use a custom searcher")
+	}
+	return method, nil
+}
+
+// customHparams returns the hyperparameters of a trial of a custom search, where the constant
+// hyperparameters that the process driving the search left out take their configured value.
+func customHparams(
+	h expconf.Hyperparameters, given map[string]interface{},
+) (hparamSample, error) {
+	sample := hparamSample{}
+	for name, val := range given {
+		if _, ok := h[name]; !ok {
+			return nil, api.AsErrBadRequest("unknown hyperparameter %s", name)
+		}
+		sample[name] = val
+	}
+	var err error
+	h.Each(func(name string, param expconf.Hyperparameter) {
+		if _, ok := sample[name]; ok || err != nil {
+			return
+		}
+		if param.RawConstHyperparameter == nil {
+			err = api.AsErrBadRequest("hyperparameter %s must be given", name)
+			return
+		}
+		sample[name] = param.RawConstHyperparameter.Val()
+	})
+	return sample, err
+}
+
 // Snapshot returns a searchers current state.
 func (s *Searcher) Snapshot() (json.RawMessage, error) {
 	b, err := s.method.Snapshot()
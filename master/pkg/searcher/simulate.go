@@ -74,6 +74,11 @@ func Simulate(
 		random = rand.New(rand.NewSource(*seed))
 	}
 
+	if _, ok := s.method.(*customSearch); ok {
+		return simulation, errors.New(
+			"custom searches cannot be simulated, since their operations are made outside of the master")
+	}
+
 	lengthCompleted := make(map[model.RequestID]model.PartialUnits)
 	pending := make(map[model.RequestID][]Operation)
 	trialIDs := make(map[model.RequestID]int)
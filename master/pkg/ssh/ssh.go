@@ -1,10 +1,12 @@
 package ssh
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"strings"
 
 	"github.com/pkg/errors"
 	sshlib "golang.org/x/crypto/ssh"
@@ -59,3 +61,30 @@ func GenerateKey(passphrase *string) (PrivateAndPublicKeys, error) {
 
 	return generatedKeys, nil
 }
+
+// PublicKey is a public key that users register to authorize themselves with.
+type PublicKey struct {
+	// AuthorizedKey is the key in the format of authorized_keys files, without a comment.
+	AuthorizedKey string
+	Fingerprint   string
+	Comment       string
+}
+
+// ParsePublicKey parses a single public key in the format of authorized_keys files, as ssh-keygen
+// writes them to .pub files.
+func ParsePublicKey(data string) (PublicKey, error) {
+	key, comment, options, rest, err := sshlib.ParseAuthorizedKey([]byte(data))
+	switch {
+	case err != nil:
+		return PublicKey{}, errors.Wrap(err, "invalid public key")
+	case len(options) > 0:
+		return PublicKey{}, errors.New("public keys must not have options")
+	case len(bytes.TrimSpace(rest)) > 0:
+		return PublicKey{}, errors.New("expected a single public key")
+	}
+	return PublicKey{
+		AuthorizedKey: strings.TrimSpace(string(sshlib.MarshalAuthorizedKey(key))),
+		Fingerprint:   sshlib.FingerprintSHA256(key),
+		Comment:       comment,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	keys, err := GenerateKey(nil)
+	assert.NilError(t, err)
+	authorizedKey := strings.TrimSpace(string(keys.PublicKey))
+
+	key, err := ParsePublicKey(authorizedKey + " user@laptop\n")
+	assert.NilError(t, err)
+	assert.Equal(t, key.AuthorizedKey, authorizedKey)
+	assert.Equal(t, key.Comment, "user@laptop")
+	assert.Assert(t, strings.HasPrefix(key.Fingerprint, "SHA256:"))
+
+	_, err = ParsePublicKey(`command="ls" ` + authorizedKey)
+	assert.ErrorContains(t, err, "options")
+	_, err = ParsePublicKey(authorizedKey + "\n" + authorizedKey)
+	assert.ErrorContains(t, err, "single")
+	_, err = ParsePublicKey("ssh-rsa garbage")
+	assert.ErrorContains(t, err, "invalid public key")
+}
@@ -88,6 +88,19 @@ func masterCertArchive(cert *tls.Certificate) container.RunArchive {
 	return wrapArchive(arch, "/")
 }
 
+// taskCertArchive writes the client certificate and private key of a task, if it has them. Only
+// the task's user may read the key.
+func taskCertArchive(cert, key []byte, aug *model.AgentUserGroup) container.RunArchive {
+	var arch archive.Archive
+	if len(cert) != 0 {
+		arch = append(arch,
+			aug.OwnedArchiveItem(taskCertPath, cert, 0644, tar.TypeReg),
+			aug.OwnedArchiveItem(taskKeyPath, key, 0600, tar.TypeReg),
+		)
+	}
+	return wrapArchive(arch, "/")
+}
+
 func wrapArchive(archive archive.Archive, path string) container.RunArchive {
 	return container.RunArchive{Path: path, Archive: archive}
 }
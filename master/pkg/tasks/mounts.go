@@ -5,13 +5,18 @@ import (
 
 	"github.com/docker/docker/api/types/mount"
 
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 )
 
-// ToDockerMounts converts expconf bind mounts to container mounts.
-func ToDockerMounts(bindMounts []expconf.BindMount) []mount.Mount {
+// ToDockerMounts converts expconf bind mounts to container mounts, skipping any mounts that are
+// restricted to a platform other than the one the task runs on.
+func ToDockerMounts(bindMounts []expconf.BindMount, platform string) []mount.Mount {
 	dockerMounts := make([]mount.Mount, 0, len(bindMounts))
 	for _, m := range bindMounts {
+		if m.Platform() != "" && m.Platform() != model.PlatformOrDefault(platform) {
+			continue
+		}
 		target := m.ContainerPath()
 		if !filepath.IsAbs(target) {
 			target = filepath.Join(ContainerWorkDir, target)
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+// dockerHubRegistry is the normalized address of Docker Hub, which goes by several names.
+const dockerHubRegistry = "docker.io"
+
+// registryAuth returns the task owner's stored credentials for the registry that an image is
+// pulled from, or nil if the owner has none.
+func (t TaskSpec) registryAuth(image string) *types.AuthConfig {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil
+	}
+	domain := reference.Domain(named)
+	for _, cred := range t.RegistryCredentials {
+		if normalizeRegistry(cred.ServerAddress) == domain {
+			auth := cred.AuthConfig()
+			return &auth
+		}
+	}
+	return nil
+}
+
+// normalizeRegistry reduces a registry server address, which may be given as a URL such as
+// "https://index.docker.io/v1/", to the domain that image references name.
+func normalizeRegistry(address string) string {
+	address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+	address = strings.SplitN(address, "/", 2)[0]
+	switch address {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubRegistry
+	}
+	return address
+}
@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// secretReference matches a reference to one of the task owner's secrets in the value of an
+// environment variable, like "${secret:WANDB_API_KEY}".
+var secretReference = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// vaultReference matches a reference to a field of a Vault secret in the value of an environment
+// variable, like "${vault:aws/creds/ml#secret_key}". The part before the "#" is the path of the
+// secret and the part after it the field of its data.
+var vaultReference = regexp.MustCompile(`\$\{vault:([A-Za-z0-9_./-]+)#([A-Za-z0-9_.-]+)\}`)
+
+// SecretReferences returns the names of the secrets that environment variables reference.
+func SecretReferences(envVars ...[]string) []string {
+	names := map[string]bool{}
+	for _, vars := range envVars {
+		for _, envVar := range vars {
+			for _, match := range secretReference.FindAllStringSubmatch(envVar, -1) {
+				names[match[1]] = true
+			}
+		}
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// VaultReferences returns the Vault secrets that environment variables reference, as
+// "path#field".
+func VaultReferences(envVars ...[]string) []string {
+	references := map[string]bool{}
+	for _, vars := range envVars {
+		for _, envVar := range vars {
+			for _, match := range vaultReference.FindAllStringSubmatch(envVar, -1) {
+				references[match[1]+"#"+match[2]] = true
+			}
+		}
+	}
+	var sorted []string
+	for reference := range references {
+		sorted = append(sorted, reference)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// CheckSecretReferences returns an error naming the secrets that environment variables reference
+// but that are not among the given secret names.
+func CheckSecretReferences(secretNames []string, envVars ...[]string) error {
+	known := map[string]bool{}
+	for _, name := range secretNames {
+		known[name] = true
+	}
+	var missing []string
+	for _, name := range SecretReferences(envVars...) {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("environment variables reference undefined secrets: %v", missing)
+	}
+	return nil
+}
+
+// ResolveSecrets replaces references to the task owner's secrets and to Vault secrets in
+// environment variables with their values. References to secrets that were not fetched are left
+// unchanged.
+func (t TaskSpec) ResolveSecrets(envVars []string) []string {
+	if len(t.Secrets) == 0 && len(t.VaultSecrets) == 0 {
+		return envVars
+	}
+	resolved := make([]string, 0, len(envVars))
+	for _, envVar := range envVars {
+		envVar = secretReference.ReplaceAllStringFunc(envVar, func(ref string) string {
+			if value, ok := t.Secrets[secretReference.FindStringSubmatch(ref)[1]]; ok {
+				return value
+			}
+			return ref
+		})
+		envVar = vaultReference.ReplaceAllStringFunc(envVar, func(ref string) string {
+			match := vaultReference.FindStringSubmatch(ref)
+			if value, ok := t.VaultSecrets[match[1]+"#"+match[2]]; ok {
+				return value
+			}
+			return ref
+		})
+		resolved = append(resolved, envVar)
+	}
+	return resolved
+}
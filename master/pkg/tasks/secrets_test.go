@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	envVars := []string{
+		"WANDB_API_KEY=${secret:wandb}",
+		"DB_URL=postgres://user:${secret:db_password}@db/${secret:missing}",
+		"PLAIN=value",
+	}
+	if refs := SecretReferences(envVars); !reflect.DeepEqual(
+		refs, []string{"db_password", "missing", "wandb"}) {
+		t.Errorf("unexpected secret references: %v", refs)
+	}
+	if err := CheckSecretReferences([]string{"wandb", "db_password"}, envVars); err == nil {
+		t.Error("expected a reference to a missing secret to be rejected")
+	}
+
+	spec := TaskSpec{Secrets: map[string]string{"wandb": "k3y", "db_password": "p=ss"}}
+	expected := []string{
+		"WANDB_API_KEY=k3y",
+		"DB_URL=postgres://user:p=ss@db/${secret:missing}",
+		"PLAIN=value",
+	}
+	if resolved := spec.ResolveSecrets(envVars); !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("expected %v, got %v", expected, resolved)
+	}
+	if envVars[0] != "WANDB_API_KEY=${secret:wandb}" {
+		t.Error("expected the original environment variables to be unchanged")
+	}
+}
+
+func TestResolveVaultSecrets(t *testing.T) {
+	envVars := []string{
+		"AWS_ACCESS_KEY_ID=${vault:aws/creds/ml#access_key}",
+		"AWS_SECRET_ACCESS_KEY=${vault:aws/creds/ml#secret_key}",
+		"TOKEN=${vault:secret/data/team#token}-${secret:suffix}",
+		"MISSING=${vault:kv/other#value}",
+	}
+	if refs := VaultReferences(envVars); !reflect.DeepEqual(refs, []string{
+		"aws/creds/ml#access_key", "aws/creds/ml#secret_key", "kv/other#value",
+		"secret/data/team#token",
+	}) {
+		t.Errorf("unexpected Vault references: %v", refs)
+	}
+
+	spec := TaskSpec{
+		Secrets: map[string]string{"suffix": "s"},
+		VaultSecrets: map[string]string{
+			"aws/creds/ml#access_key": "AK",
+			"aws/creds/ml#secret_key": "SK",
+			"secret/data/team#token":  "t",
+		},
+	}
+	expected := []string{
+		"AWS_ACCESS_KEY_ID=AK",
+		"AWS_SECRET_ACCESS_KEY=SK",
+		"TOKEN=t-s",
+		"MISSING=${vault:kv/other#value}",
+	}
+	if resolved := spec.ResolveSecrets(envVars); !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("expected %v, got %v", expected, resolved)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	docker "github.com/docker/docker/api/types/container"
+	dnetwork "github.com/docker/docker/api/types/network"
 
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/container"
@@ -24,6 +25,8 @@ const (
 	shadowPath        = "/run/determined/etc/shadow"
 	groupPath         = "/run/determined/etc/group"
 	certPath          = "/run/determined/etc/ssl/master.crt"
+	taskCertPath      = "/run/determined/etc/ssl/task.crt"
+	taskKeyPath       = "/run/determined/etc/ssl/task.key"
 )
 
 // workDirArchive ensures that the workdir is created and owned by the user.
@@ -71,10 +74,21 @@ func ToContainerSpec(t TaskSpec) container.Spec {
 	if len(t.Devices) > 0 {
 		deviceType = t.Devices[0].Type
 	}
-	envVars = append(envVars, env.EnvironmentVariables().For(deviceType)...)
+	envVars = append(envVars, t.ResolveSecrets(env.EnvironmentVariables().For(deviceType))...)
 
 	network := t.TaskContainerDefaults.NetworkMode
-	if t.UseHostMode() {
+	var networking dnetwork.NetworkingConfig
+	var taskNetworkDriver string
+	switch taskNetwork := t.taskNetwork(); {
+	case taskNetwork != nil:
+		// The containers of the task reach each other by hostname on a network of their own
+		// instead of through the ports they publish on the host network.
+		network = docker.NetworkMode(taskNetwork.Name)
+		networking.EndpointsConfig = map[string]*dnetwork.EndpointSettings{
+			taskNetwork.Name: {Aliases: []string{t.TaskNetworkPeer}},
+		}
+		taskNetworkDriver = t.TaskContainerDefaults.TaskNetworkDriver
+	case t.UseHostMode():
 		network = hostMode
 	}
 
@@ -93,10 +107,25 @@ func ToContainerSpec(t TaskSpec) container.Spec {
 		})
 	}
 
+	var nanoCPUs, memory int64
+	if cpus := resources.CPULimit(); cpus != nil {
+		nanoCPUs = int64(*cpus * 1e9)
+	}
+	if limit := resources.MemoryLimit(); limit != nil {
+		memory = int64(*limit)
+	}
+
+	image := env.Image().For(deviceType)
+	registry := env.RegistryAuth()
+	if registry == nil {
+		registry = t.registryAuth(image)
+	}
+
 	spec := container.Spec{
 		PullSpec: container.PullSpec{
-			Registry:  env.RegistryAuth(),
+			Registry:  registry,
 			ForcePull: env.ForcePullImage(),
+			Platform:  resources.Platform(),
 		},
 		RunSpec: container.RunSpec{
 			ContainerConfig: docker.Config{
@@ -104,7 +133,7 @@ func ToContainerSpec(t TaskSpec) container.Spec {
 				ExposedPorts: toPortSet(env.Ports()),
 				Env:          envVars,
 				Cmd:          t.Entrypoint(),
-				Image:        env.Image().For(deviceType),
+				Image:        image,
 				WorkingDir:   ContainerWorkDir,
 			},
 			HostConfig: docker.HostConfig{
@@ -116,11 +145,16 @@ func ToContainerSpec(t TaskSpec) container.Spec {
 				CapDrop:         env.DropCapabilities(),
 
 				Resources: docker.Resources{
-					Devices: devices,
+					Devices:  devices,
+					NanoCPUs: nanoCPUs,
+					Memory:   memory,
 				},
 			},
-			Archives:         t.Archives(),
-			UseFluentLogging: t.UseFluentLogging(),
+			NetworkingConfig:  networking,
+			TaskNetworkDriver: taskNetworkDriver,
+			Archives:          t.Archives(),
+			RemoteArchives:    t.RemoteArchives(),
+			UseFluentLogging:  t.UseFluentLogging(),
 		},
 	}
 
@@ -55,6 +55,8 @@ type InnerSpec interface {
 	UseHostMode() bool
 	//ResourcesConfig returns the resources config of the model
 	ResourcesConfig() expconf.ResourcesConfig
+	// Workspace returns the persistent workspace of this task, if it has one.
+	Workspace() *model.WorkspaceConfig
 }
 
 // This alias allows TaskSpec to privately embed the public InnerSpec so that it can reuse (some of)
@@ -65,16 +67,41 @@ type inner = InnerSpec
 type TaskSpec struct {
 	inner
 
-	TaskID         string
-	TaskToken      string
+	TaskID    string
+	TaskToken string
+	// TaskCert and TaskKey are the PEM-encoded client certificate and private key that the task
+	// authenticates to the master with along with its token. They are empty unless mTLS is enabled.
+	TaskCert       []byte
+	TaskKey        []byte
 	ContainerID    string
 	Devices        []device.Device
 	AgentUserGroup *model.AgentUserGroup
+	// TaskNetwork is the network private to a multi-container task that the resource manager set
+	// up for it, and TaskNetworkPeer is the container's hostname on it. Containers only join it if
+	// the task container defaults set a task network driver.
+	TaskNetwork     *container.TaskNetwork
+	TaskNetworkPeer string
+	// RegistryCredentials are the task owner's stored registry credentials, used to pull images
+	// whose configuration does not specify any.
+	RegistryCredentials []model.RegistryCredential
+	// Secrets are the values of the task owner's secrets by name, which environment variables may
+	// reference. They must never be written to logs or returned by the API.
+	Secrets map[string]string
+	// VaultSecrets are the values of the fields of Vault secrets that environment variables
+	// reference, keyed by "path#field". The same care applies as to Secrets.
+	VaultSecrets map[string]string
+
+	// ContextArtifact is the artifact holding the context of the task, which the agent downloads
+	// into the container instead of the master sending its files.
+	ContextArtifact string
 
 	ClusterID             string
 	HarnessPath           string
 	TaskContainerDefaults model.TaskContainerDefaultsConfig
 	MasterCert            *tls.Certificate
+	// AgentPool is true if the task runs in a resource pool of agents, which can download remote
+	// archives from the master. Kubernetes pods and HPC jobs cannot.
+	AgentPool bool
 }
 
 // SetInner sets the concrete task represented by this spec.
@@ -82,12 +109,32 @@ func (t *TaskSpec) SetInner(inner InnerSpec) {
 	t.inner = inner
 }
 
+// HostPaths returns the paths on agent hosts that the task mounts: the sources of its bind mounts
+// and the hostPath volumes of its Kubernetes pod spec.
+func (t TaskSpec) HostPaths() []string {
+	var hostPaths []string
+	for _, m := range t.Mounts() {
+		if m.Type == mount.TypeBind {
+			hostPaths = append(hostPaths, m.Source)
+		}
+	}
+	if podSpec := t.Environment().PodSpec(); podSpec != nil {
+		for _, volume := range podSpec.Spec.Volumes {
+			if volume.HostPath != nil {
+				hostPaths = append(hostPaths, volume.HostPath.Path)
+			}
+		}
+	}
+	return hostPaths
+}
+
 func (t *TaskSpec) baseArchives() []container.RunArchive {
 	return []container.RunArchive{
 		workDirArchive(t.AgentUserGroup),
 		injectUserArchive(t.AgentUserGroup),
 		harnessArchive(t.HarnessPath, t.AgentUserGroup),
 		masterCertArchive(t.MasterCert),
+		taskCertArchive(t.TaskCert, t.TaskKey, t.AgentUserGroup),
 	}
 }
 
@@ -116,15 +163,51 @@ func (t *TaskSpec) baseEnvVars() map[string]string {
 		e["DET_USE_TLS"] = "true"
 		e["DET_MASTER_CERT_FILE"] = certPath
 	}
+	if len(t.TaskCert) != 0 {
+		e["DET_TASK_CERT_FILE"] = taskCertPath
+		e["DET_TASK_KEY_FILE"] = taskKeyPath
+	}
+
+	if network := t.taskNetwork(); network != nil {
+		e["DET_TASK_PEERS"] = strings.Join(network.Peers, ",")
+	}
 
 	return e
 }
 
+// taskNetwork returns the network private to the task that the container joins, or nil if the
+// container uses the network mode of the task container defaults.
+func (t *TaskSpec) taskNetwork() *container.TaskNetwork {
+	if t.TaskContainerDefaults.TaskNetworkDriver == "" {
+		return nil
+	}
+	return t.TaskNetwork
+}
+
 // Archives returns the archives that should be included in the container for this task.
 func (t *TaskSpec) Archives() []container.RunArchive {
 	return append(t.baseArchives(), t.inner.Archives(t.AgentUserGroup)...)
 }
 
+// RemoteArchives returns the archives that the agent should download into the container for this
+// task.
+func (t *TaskSpec) RemoteArchives() []container.RemoteArchive {
+	if t.ContextArtifact == "" {
+		return nil
+	}
+	remote := container.RemoteArchive{
+		Path:       ContainerWorkDir,
+		ArtifactID: t.ContextArtifact,
+		TaskToken:  t.TaskToken,
+		TaskCert:   t.TaskCert,
+		TaskKey:    t.TaskKey,
+	}
+	if t.AgentUserGroup != nil {
+		remote.UserID, remote.GroupID = t.AgentUserGroup.UID, t.AgentUserGroup.GID
+	}
+	return []container.RemoteArchive{remote}
+}
+
 // Environment returns the container environment for this task.
 func (t *TaskSpec) Environment() expconf.EnvironmentConfig { return t.inner.Environment(*t) }
 
@@ -171,7 +254,7 @@ func (s StartCommand) LoggingFields() map[string]string { return nil }
 
 // Mounts implements InnerSpec.
 func (s StartCommand) Mounts() []mount.Mount {
-	return ToDockerMounts(s.Config.BindMounts.ToExpconf())
+	return ToDockerMounts(s.Config.BindMounts.ToExpconf(), s.Config.Resources.Platform)
 }
 
 // ShmSize implements InnerSpec.
@@ -193,6 +276,9 @@ func (s StartCommand) ResourcesConfig() expconf.ResourcesConfig {
 	return s.Config.Resources.ToExpconf()
 }
 
+// Workspace implements InnerSpec.
+func (s StartCommand) Workspace() *model.WorkspaceConfig { return s.Config.Workspace }
+
 // GCCheckpoints is a description of a task for running checkpoint GC.
 type GCCheckpoints struct {
 	ExperimentID       int
@@ -252,9 +338,19 @@ func (g GCCheckpoints) Entrypoint() []string {
 
 // Environment implements InnerSpec.
 func (g GCCheckpoints) Environment(t TaskSpec) expconf.EnvironmentConfig {
-	// Keep only the EnvironmentVariables provided by the experiment's config.
+	return checkpointStorageEnvironment(g.ExperimentConfig, t)
+}
+
+// checkpointStorageEnvironment returns the container environment for a task that accesses the
+// checkpoint storage of an experiment.
+func checkpointStorageEnvironment(
+	config expconf.ExperimentConfig, t TaskSpec,
+) expconf.EnvironmentConfig {
+	// Keep only the EnvironmentVariables and Kubernetes settings provided by the experiment's
+	// config, so that checkpoints are accessed from the same namespace the trials ran in.
 	env := expconf.EnvironmentConfig{
-		RawEnvironmentVariables: g.ExperimentConfig.Environment().RawEnvironmentVariables,
+		RawEnvironmentVariables: config.Environment().RawEnvironmentVariables,
+		RawKubernetes:           config.Environment().RawKubernetes,
 	}
 
 	// Fill the rest of the environment with default values.
@@ -275,8 +371,14 @@ func (g GCCheckpoints) LoggingFields() map[string]string { return nil }
 
 // Mounts implements InnerSpec.
 func (g GCCheckpoints) Mounts() []mount.Mount {
-	mounts := ToDockerMounts(g.ExperimentConfig.BindMounts())
-	if fs := g.ExperimentConfig.CheckpointStorage().RawSharedFSConfig; fs != nil {
+	return checkpointStorageMounts(g.ExperimentConfig)
+}
+
+// checkpointStorageMounts returns the Docker mounts for a task that accesses the checkpoint storage
+// of an experiment.
+func checkpointStorageMounts(config expconf.ExperimentConfig) []mount.Mount {
+	mounts := ToDockerMounts(config.BindMounts(), config.Resources().Platform())
+	if fs := config.CheckpointStorage().RawSharedFSConfig; fs != nil {
 		mounts = append(mounts, mount.Mount{
 			Type:   mount.TypeBind,
 			Source: fs.HostPath(),
@@ -303,6 +405,190 @@ func (g GCCheckpoints) ResourcesConfig() expconf.ResourcesConfig {
 	return g.ExperimentConfig.Resources()
 }
 
+// Workspace implements InnerSpec.
+func (g GCCheckpoints) Workspace() *model.WorkspaceConfig { return nil }
+
+// VerifyCheckpoints is a description of a task for verifying the files of checkpoints against their
+// recorded sizes and digests.
+type VerifyCheckpoints struct {
+	ExperimentID     int
+	ExperimentConfig expconf.ExperimentConfig
+	ToVerify         json.RawMessage
+}
+
+// Archives implements InnerSpec.
+func (v VerifyCheckpoints) Archives(u *model.AgentUserGroup) []container.RunArchive {
+	return []container.RunArchive{
+		wrapArchive(
+			archive.Archive{
+				u.OwnedArchiveItem(
+					"experiment_config.json",
+					[]byte(jsonify(v.ExperimentConfig)),
+					0600,
+					tar.TypeReg,
+				),
+				u.OwnedArchiveItem(
+					"checkpoints_to_verify.json",
+					[]byte(jsonify(v.ToVerify)),
+					0600,
+					tar.TypeReg,
+				),
+				u.OwnedArchiveItem(
+					etc.VerifyCheckpointsEntrypointResource,
+					etc.MustStaticFile(etc.VerifyCheckpointsEntrypointResource),
+					0700,
+					tar.TypeReg,
+				),
+			},
+			ContainerWorkDir,
+		),
+	}
+}
+
+// Description implements InnerSpec.
+func (v VerifyCheckpoints) Description() string { return "verify" }
+
+// Entrypoint implements InnerSpec.
+func (v VerifyCheckpoints) Entrypoint() []string {
+	return []string{
+		filepath.Join(ContainerWorkDir, etc.VerifyCheckpointsEntrypointResource),
+		"--experiment-id",
+		strconv.Itoa(v.ExperimentID),
+		"--experiment-config",
+		"experiment_config.json",
+		"--verify",
+		"checkpoints_to_verify.json",
+	}
+}
+
+// Environment implements InnerSpec.
+func (v VerifyCheckpoints) Environment(t TaskSpec) expconf.EnvironmentConfig {
+	return checkpointStorageEnvironment(v.ExperimentConfig, t)
+}
+
+// EnvVars implements InnerSpec.
+func (v VerifyCheckpoints) EnvVars(TaskSpec) map[string]string { return nil }
+
+// LoggingFields implements InnerSpec.
+func (v VerifyCheckpoints) LoggingFields() map[string]string { return nil }
+
+// Mounts implements InnerSpec.
+func (v VerifyCheckpoints) Mounts() []mount.Mount {
+	return checkpointStorageMounts(v.ExperimentConfig)
+}
+
+// ShmSize implements InnerSpec.
+func (v VerifyCheckpoints) ShmSize() int64 { return 0 }
+
+// UseFluentLogging implements InnerSpec.
+func (v VerifyCheckpoints) UseFluentLogging() bool { return false }
+
+// UseHostMode implements InnerSpec.
+func (v VerifyCheckpoints) UseHostMode() bool { return false }
+
+// ResourcesConfig implements InnerSpec.
+func (v VerifyCheckpoints) ResourcesConfig() expconf.ResourcesConfig {
+	return v.ExperimentConfig.Resources()
+}
+
+// Workspace implements InnerSpec.
+func (v VerifyCheckpoints) Workspace() *model.WorkspaceConfig { return nil }
+
+// BatchInference is a description of a task for making the predictions of a checkpoint on the
+// files under an input path.
+type BatchInference struct {
+	ExperimentConfig expconf.ExperimentConfig
+	// Spec holds the checkpoint, the predictor, the input and output paths, and the lineage that the
+	// container records with the predictions.
+	Spec json.RawMessage
+	// ModelDefinition holds the module of the predictor, which is either the files launched with
+	// the job or the model definition of the experiment of the checkpoint.
+	ModelDefinition archive.Archive
+}
+
+// Archives implements InnerSpec.
+func (b BatchInference) Archives(u *model.AgentUserGroup) []container.RunArchive {
+	return []container.RunArchive{
+		wrapArchive(u.OwnArchive(b.ModelDefinition), ContainerWorkDir),
+		wrapArchive(
+			archive.Archive{
+				u.OwnedArchiveItem(
+					"experiment_config.json",
+					[]byte(jsonify(b.ExperimentConfig)),
+					0600,
+					tar.TypeReg,
+				),
+				u.OwnedArchiveItem(
+					"batch_inference.json",
+					[]byte(jsonify(b.Spec)),
+					0600,
+					tar.TypeReg,
+				),
+				u.OwnedArchiveItem(
+					etc.BatchInferenceEntrypointResource,
+					etc.MustStaticFile(etc.BatchInferenceEntrypointResource),
+					0700,
+					tar.TypeReg,
+				),
+			},
+			ContainerWorkDir,
+		),
+	}
+}
+
+// Description implements InnerSpec.
+func (b BatchInference) Description() string { return "batch-inference" }
+
+// Entrypoint implements InnerSpec.
+func (b BatchInference) Entrypoint() []string {
+	return []string{
+		filepath.Join(ContainerWorkDir, etc.BatchInferenceEntrypointResource),
+		"--experiment-config",
+		"experiment_config.json",
+		"--spec",
+		"batch_inference.json",
+	}
+}
+
+// Environment implements InnerSpec. The predictor runs in the environment of the trials that saved
+// the checkpoint, so that it can load it with the same libraries.
+func (b BatchInference) Environment(TaskSpec) expconf.EnvironmentConfig {
+	return b.ExperimentConfig.Environment()
+}
+
+// EnvVars implements InnerSpec.
+func (b BatchInference) EnvVars(TaskSpec) map[string]string { return nil }
+
+// LoggingFields implements InnerSpec.
+func (b BatchInference) LoggingFields() map[string]string { return nil }
+
+// Mounts implements InnerSpec.
+func (b BatchInference) Mounts() []mount.Mount {
+	return checkpointStorageMounts(b.ExperimentConfig)
+}
+
+// ShmSize implements InnerSpec.
+func (b BatchInference) ShmSize() int64 {
+	if shm := b.ExperimentConfig.Resources().ShmSize(); shm != nil {
+		return int64(*shm)
+	}
+	return 0
+}
+
+// UseFluentLogging implements InnerSpec.
+func (b BatchInference) UseFluentLogging() bool { return false }
+
+// UseHostMode implements InnerSpec.
+func (b BatchInference) UseHostMode() bool { return false }
+
+// ResourcesConfig implements InnerSpec.
+func (b BatchInference) ResourcesConfig() expconf.ResourcesConfig {
+	return b.ExperimentConfig.Resources()
+}
+
+// Workspace implements InnerSpec.
+func (b BatchInference) Workspace() *model.WorkspaceConfig { return nil }
+
 // StartTrial is a description of a task for running a trial container.
 type StartTrial struct {
 	ExperimentConfig    expconf.ExperimentConfig
@@ -404,7 +690,8 @@ func (s StartTrial) LoggingFields() map[string]string {
 
 // Mounts implements InnerSpec.
 func (s StartTrial) Mounts() []mount.Mount {
-	mounts := ToDockerMounts(s.ExperimentConfig.BindMounts())
+	mounts := ToDockerMounts(
+		s.ExperimentConfig.BindMounts(), s.ExperimentConfig.Resources().Platform())
 	addMount := func(source, target string, bindOpts *mount.BindOptions) {
 		mounts = append(mounts, mount.Mount{
 			Type: mount.TypeBind, Source: source, Target: target, BindOptions: bindOpts,
@@ -456,3 +743,6 @@ func (s StartTrial) ShmSize() int64 {
 func (s StartTrial) ResourcesConfig() expconf.ResourcesConfig {
 	return s.ExperimentConfig.Resources()
 }
+
+// Workspace implements InnerSpec.
+func (s StartTrial) Workspace() *model.WorkspaceConfig { return nil }
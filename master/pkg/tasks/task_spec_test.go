@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+
+	k8sV1 "k8s.io/api/core/v1"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func hostPathPodSpec(path string) *expconf.PodSpec {
+	return &expconf.PodSpec{Spec: k8sV1.PodSpec{Volumes: []k8sV1.Volume{{
+		Name:         "host",
+		VolumeSource: k8sV1.VolumeSource{HostPath: &k8sV1.HostPathVolumeSource{Path: path}},
+	}}}}
+}
+
+func TestTrialHostPaths(t *testing.T) {
+	config := schemas.WithDefaults(expconf.ExperimentConfig{
+		RawBindMounts: expconf.BindMountsConfig{{
+			RawHostPath: "/data", RawContainerPath: "/data",
+		}},
+		RawCheckpointStorage: &expconf.CheckpointStorageConfig{
+			RawSharedFSConfig: &expconf.SharedFSConfig{RawHostPath: ptrs.StringPtr("/checkpoints")},
+		},
+		RawDataLayer: &expconf.DataLayerConfig{
+			RawS3Config: &expconf.S3DataLayerConfig{
+				RawBucket:                  ptrs.StringPtr("bucket"),
+				RawBucketDirectoryPath:     ptrs.StringPtr("dir"),
+				RawLocalCacheHostPath:      ptrs.StringPtr("/cache"),
+				RawLocalCacheContainerPath: ptrs.StringPtr("/cache"),
+			},
+		},
+		RawEnvironment: &expconf.EnvironmentConfig{RawPodSpec: hostPathPodSpec("/etc")},
+	}).(expconf.ExperimentConfig)
+
+	spec := TaskSpec{}
+	spec.SetInner(&StartTrial{ExperimentConfig: config})
+	expected := []string{"/data", "/checkpoints", "/cache", "/etc"}
+	if hostPaths := spec.HostPaths(); !reflect.DeepEqual(hostPaths, expected) {
+		t.Errorf("expected host paths %v, got %v", expected, hostPaths)
+	}
+}
+
+func TestCommandHostPaths(t *testing.T) {
+	config := model.CommandConfig{
+		BindMounts: model.BindMountsConfig{{HostPath: "/data", ContainerPath: "/data"}},
+		Environment: model.Environment{
+			PodSpec: (*k8sV1.Pod)(hostPathPodSpec("/var/run/docker.sock")),
+		},
+	}
+
+	spec := TaskSpec{}
+	spec.SetInner(&StartCommand{Config: config})
+	expected := []string{"/data", "/var/run/docker.sock"}
+	if hostPaths := spec.HostPaths(); !reflect.DeepEqual(hostPaths, expected) {
+		t.Errorf("expected host paths %v, got %v", expected, hostPaths)
+	}
+}
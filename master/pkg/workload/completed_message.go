@@ -48,10 +48,11 @@ func (message *CompletedMessage) UnmarshalJSON(bytes []byte) error {
 // CheckpointMetrics contains the checkpoint metadata returned by the StorageManager after
 // completing a checkpoint.
 type CheckpointMetrics struct {
-	UUID      uuid.UUID      `json:"uuid"`
-	Resources map[string]int `json:"resources"`
-	Framework string         `json:"framework"`
-	Format    string         `json:"format"`
+	UUID      uuid.UUID         `json:"uuid"`
+	Resources map[string]int    `json:"resources"`
+	Digests   map[string]string `json:"digests"`
+	Framework string            `json:"framework"`
+	Format    string            `json:"format"`
 }
 
 // ValidationMetrics contains the user-defined metrics calculated after a validation
@@ -0,0 +1,9 @@
+CREATE TABLE public.registry_credentials (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    server_address text NOT NULL,
+    username text NOT NULL,
+    encrypted_password bytea NOT NULL,
+    updated_at timestamp without time zone NOT NULL DEFAULT now(),
+    CONSTRAINT registry_credentials_user_id_server_address_unique UNIQUE (user_id, server_address)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE public.secrets (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    encrypted_value bytea NOT NULL,
+    updated_at timestamp without time zone NOT NULL DEFAULT now(),
+    CONSTRAINT secrets_user_id_name_unique UNIQUE (user_id, name)
+);
@@ -0,0 +1,5 @@
+DROP TABLE public.role_assignments;
+DROP TABLE public.group_members;
+DROP TABLE public.groups;
+DROP TABLE public.role_permissions;
+DROP TABLE public.roles;
@@ -0,0 +1,51 @@
+CREATE TABLE public.roles (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    builtin boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE public.role_permissions (
+    role_id integer NOT NULL REFERENCES public.roles(id) ON DELETE CASCADE,
+    permission text NOT NULL,
+    PRIMARY KEY (role_id, permission)
+);
+
+CREATE TABLE public.groups (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+CREATE TABLE public.group_members (
+    group_id integer NOT NULL REFERENCES public.groups(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE TABLE public.role_assignments (
+    id SERIAL PRIMARY KEY,
+    role_id integer NOT NULL REFERENCES public.roles(id) ON DELETE CASCADE,
+    user_id integer REFERENCES public.users(id) ON DELETE CASCADE,
+    group_id integer REFERENCES public.groups(id) ON DELETE CASCADE,
+    CONSTRAINT role_assignments_one_subject CHECK ((user_id IS NULL) <> (group_id IS NULL)),
+    CONSTRAINT role_assignments_role_id_user_id_unique UNIQUE (role_id, user_id),
+    CONSTRAINT role_assignments_role_id_group_id_unique UNIQUE (role_id, group_id)
+);
+
+INSERT INTO public.roles (name, builtin) VALUES
+    ('viewer', true),
+    ('editor', true),
+    ('operator', true),
+    ('cluster_admin', true);
+
+INSERT INTO public.role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM public.roles r
+JOIN (VALUES
+    ('editor', 'edit_own'),
+    ('operator', 'edit_own'),
+    ('operator', 'edit_all'),
+    ('cluster_admin', 'edit_own'),
+    ('cluster_admin', 'edit_all'),
+    ('cluster_admin', 'manage_cluster'),
+    ('cluster_admin', 'manage_roles')
+) AS p (role_name, permission) ON p.role_name = r.name;
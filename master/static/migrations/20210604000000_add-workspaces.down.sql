@@ -0,0 +1,12 @@
+DROP INDEX public.role_assignments_user_unique;
+DROP INDEX public.role_assignments_group_unique;
+DELETE FROM public.role_assignments WHERE workspace_id IS NOT NULL;
+ALTER TABLE public.role_assignments
+    DROP COLUMN workspace_id,
+    ADD CONSTRAINT role_assignments_role_id_user_id_unique UNIQUE (role_id, user_id),
+    ADD CONSTRAINT role_assignments_role_id_group_id_unique UNIQUE (role_id, group_id);
+
+ALTER TABLE public.experiments DROP COLUMN project_id;
+
+DROP TABLE public.projects;
+DROP TABLE public.workspaces;
@@ -0,0 +1,35 @@
+CREATE TABLE public.workspaces (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    default_resource_pool text NOT NULL DEFAULT '',
+    default_image text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE public.projects (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    workspace_id integer NOT NULL REFERENCES public.workspaces(id),
+    CONSTRAINT projects_workspace_id_name_unique UNIQUE (workspace_id, name)
+);
+
+-- Existing and unassigned experiments and tasks belong to this project.
+INSERT INTO public.workspaces (id, name) VALUES (1, 'Uncategorized');
+INSERT INTO public.projects (id, name, workspace_id) VALUES (1, 'Uncategorized', 1);
+SELECT setval('workspaces_id_seq', 1);
+SELECT setval('projects_id_seq', 1);
+
+ALTER TABLE public.experiments
+    ADD COLUMN project_id integer NOT NULL DEFAULT 1 REFERENCES public.projects(id);
+
+-- Roles may be assigned within a workspace rather than for the whole cluster.
+ALTER TABLE public.role_assignments
+    ADD COLUMN workspace_id integer REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    DROP CONSTRAINT role_assignments_role_id_user_id_unique,
+    DROP CONSTRAINT role_assignments_role_id_group_id_unique;
+CREATE UNIQUE INDEX role_assignments_user_unique
+    ON public.role_assignments (role_id, user_id, COALESCE(workspace_id, 0))
+    WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX role_assignments_group_unique
+    ON public.role_assignments (role_id, group_id, COALESCE(workspace_id, 0))
+    WHERE group_id IS NOT NULL;
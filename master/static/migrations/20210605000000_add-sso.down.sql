@@ -0,0 +1,3 @@
+ALTER TABLE public.role_assignments DROP COLUMN sso;
+
+ALTER TABLE public.users DROP COLUMN remote;
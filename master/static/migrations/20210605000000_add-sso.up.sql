@@ -0,0 +1,5 @@
+-- Remote users log in through single sign-on and have no password.
+ALTER TABLE public.users ADD COLUMN remote boolean NOT NULL DEFAULT false;
+
+-- Single sign-on replaces the role assignments it made whenever the user logs in.
+ALTER TABLE public.role_assignments ADD COLUMN sso boolean NOT NULL DEFAULT false;
@@ -0,0 +1,11 @@
+CREATE TABLE public.api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    description text NOT NULL DEFAULT '',
+    token_hash bytea NOT NULL UNIQUE,
+    scope text NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT now(),
+    expires_at timestamp without time zone NOT NULL
+);
+
+CREATE INDEX ix_api_tokens_user_id ON public.api_tokens USING btree (user_id);
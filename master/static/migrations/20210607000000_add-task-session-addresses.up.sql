@@ -0,0 +1,5 @@
+CREATE TABLE public.task_session_addresses (
+    session_id integer NOT NULL REFERENCES public.task_sessions(id) ON DELETE CASCADE,
+    address text NOT NULL,
+    PRIMARY KEY (session_id, address)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE public.audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    time timestamp without time zone NOT NULL,
+    user_id integer NULL REFERENCES public.users(id) ON DELETE SET NULL,
+    username text NOT NULL DEFAULT '',
+    task_id text NOT NULL DEFAULT '',
+    address text NOT NULL DEFAULT '',
+    method text NOT NULL,
+    request text NOT NULL DEFAULT '',
+    outcome text NOT NULL
+);
@@ -0,0 +1,7 @@
+ALTER TABLE public.templates
+    DROP COLUMN storage_credentials,
+    DROP COLUMN storage_credentials_encrypted;
+
+ALTER TABLE public.experiments
+    DROP COLUMN storage_credentials,
+    DROP COLUMN storage_credentials_encrypted;
@@ -0,0 +1,7 @@
+ALTER TABLE public.experiments
+    ADD COLUMN storage_credentials bytea,
+    ADD COLUMN storage_credentials_encrypted boolean NOT NULL DEFAULT false;
+
+ALTER TABLE public.templates
+    ADD COLUMN storage_credentials bytea,
+    ADD COLUMN storage_credentials_encrypted boolean NOT NULL DEFAULT false;
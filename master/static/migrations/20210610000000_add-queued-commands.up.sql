@@ -0,0 +1,7 @@
+CREATE TABLE public.queued_commands (
+    task_id text PRIMARY KEY,
+    manager text NOT NULL,
+    spec bytea NOT NULL,
+    encrypted boolean NOT NULL DEFAULT false,
+    queued_time timestamp without time zone NOT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE public.allocations (
+    container_id text PRIMARY KEY,
+    task_id text NOT NULL,
+    task_actor text NOT NULL,
+    agent_id text NOT NULL,
+    spec bytea NOT NULL,
+    encrypted boolean NOT NULL DEFAULT false,
+    start_time timestamp without time zone NOT NULL
+);
+
+CREATE INDEX ix_allocations_agent_id ON public.allocations USING btree (agent_id);
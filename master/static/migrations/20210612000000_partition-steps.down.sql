@@ -0,0 +1,42 @@
+ALTER TABLE public.trials
+    DROP COLUMN metrics_downsampled;
+
+DROP VIEW steps;
+
+DROP TRIGGER trials_steps_partition ON public.trials;
+DROP FUNCTION public.create_trial_steps_partition;
+DROP FUNCTION public.ensure_steps_partition;
+
+ALTER TABLE public.raw_steps
+    RENAME TO raw_steps_partitioned;
+
+CREATE TABLE public.raw_steps (
+    trial_id integer NOT NULL,
+    id integer NOT NULL,
+    state public.step_state NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone,
+    metrics jsonb,
+    total_batches integer NOT NULL DEFAULT 0,
+    total_records integer NOT NULL DEFAULT 0,
+    total_epochs real NOT NULL DEFAULT 0,
+    trial_run_id integer NOT NULL DEFAULT 0,
+    archived boolean NOT NULL DEFAULT false,
+    CONSTRAINT steps_pkey PRIMARY KEY (trial_id, id),
+    CONSTRAINT steps_trial_id_run_id_total_batches_unique
+        UNIQUE (trial_id, trial_run_id, total_batches),
+    CONSTRAINT steps_trial_id_fkey FOREIGN KEY (trial_id) REFERENCES public.trials(id)
+);
+
+INSERT INTO public.raw_steps
+    (trial_id, id, state, start_time, end_time, metrics,
+     total_batches, total_records, total_epochs, trial_run_id, archived)
+SELECT
+    trial_id, id, state, start_time, end_time, metrics,
+    total_batches, total_records, total_epochs, trial_run_id, archived
+FROM public.raw_steps_partitioned;
+
+DROP TABLE public.raw_steps_partitioned;
+
+CREATE VIEW steps AS
+    SELECT * FROM raw_steps WHERE NOT archived;
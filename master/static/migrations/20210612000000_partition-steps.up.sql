@@ -0,0 +1,83 @@
+-- Partition the training metrics by ranges of trial IDs. Trial IDs increase as experiments are
+-- created, so each partition holds the metrics of a contiguous span of experiments, and queries
+-- for the metrics of a trial only scan the indexes of its partition.
+DROP VIEW steps;
+
+ALTER TABLE public.raw_steps
+    RENAME TO raw_steps_unpartitioned;
+
+CREATE TABLE public.raw_steps (
+    trial_id integer NOT NULL,
+    id integer NOT NULL,
+    state public.step_state NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    end_time timestamp with time zone,
+    metrics jsonb,
+    total_batches integer NOT NULL DEFAULT 0,
+    total_records integer NOT NULL DEFAULT 0,
+    total_epochs real NOT NULL DEFAULT 0,
+    trial_run_id integer NOT NULL DEFAULT 0,
+    archived boolean NOT NULL DEFAULT false
+) PARTITION BY RANGE (trial_id);
+
+-- Partitioned tables cannot have keys of their own in PostgreSQL 10, so each partition is created
+-- with them.
+CREATE FUNCTION public.ensure_steps_partition(tid integer) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    first_trial integer := tid / 10000 * 10000;
+    partition_name text := format('raw_steps_%s', tid / 10000);
+BEGIN
+    IF to_regclass(format('public.%I', partition_name)) IS NOT NULL THEN
+        RETURN;
+    END IF;
+    -- Serialize trials that are created concurrently in a new range.
+    PERFORM pg_advisory_xact_lock(hashtext('raw_steps_partitions'));
+    IF to_regclass(format('public.%I', partition_name)) IS NOT NULL THEN
+        RETURN;
+    END IF;
+    EXECUTE format(
+        'CREATE TABLE public.%I PARTITION OF public.raw_steps FOR VALUES FROM (%s) TO (%s)',
+        partition_name, first_trial, first_trial + 10000);
+    EXECUTE format(
+        'ALTER TABLE public.%I
+            ADD PRIMARY KEY (trial_id, id),
+            ADD UNIQUE (trial_id, trial_run_id, total_batches),
+            ADD FOREIGN KEY (trial_id) REFERENCES public.trials(id)',
+        partition_name);
+END;
+$$;
+
+CREATE FUNCTION public.create_trial_steps_partition() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    PERFORM public.ensure_steps_partition(NEW.id);
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER trials_steps_partition
+    AFTER INSERT ON public.trials
+    FOR EACH ROW EXECUTE PROCEDURE public.create_trial_steps_partition();
+
+SELECT public.ensure_steps_partition(p * 10000)
+FROM generate_series(0, (SELECT coalesce(max(id), 0) FROM public.trials) / 10000) AS p;
+
+INSERT INTO public.raw_steps
+    (trial_id, id, state, start_time, end_time, metrics,
+     total_batches, total_records, total_epochs, trial_run_id, archived)
+SELECT
+    trial_id, id, state, start_time, end_time, metrics,
+    total_batches, total_records, total_epochs, trial_run_id, archived
+FROM public.raw_steps_unpartitioned;
+
+DROP TABLE public.raw_steps_unpartitioned;
+
+CREATE VIEW steps AS
+    SELECT * FROM raw_steps WHERE NOT archived;
+
+-- Whether the training metrics of the trial were downsampled since its experiment ended.
+ALTER TABLE public.trials
+    ADD COLUMN metrics_downsampled boolean NOT NULL DEFAULT false;
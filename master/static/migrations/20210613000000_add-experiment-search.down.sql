@@ -0,0 +1,7 @@
+DROP TRIGGER experiments_search_vector ON public.experiments;
+
+ALTER TABLE public.experiments
+    DROP COLUMN search_vector;
+
+DROP FUNCTION public.set_experiment_search_vector;
+DROP FUNCTION public.experiment_search_vector;
@@ -0,0 +1,45 @@
+-- The words of the description, labels and hyperparameters of an experiment that it is searched
+-- by, weighted in that order.
+CREATE FUNCTION public.experiment_search_vector(config jsonb) RETURNS tsvector
+    LANGUAGE sql IMMUTABLE
+    AS $$
+SELECT
+    setweight(to_tsvector('simple', coalesce(config->>'description', '')), 'A')
+    || setweight(to_tsvector('simple', coalesce((
+        SELECT string_agg(label, ' ')
+        FROM jsonb_array_elements_text(
+            CASE WHEN jsonb_typeof(config->'labels') = 'array'
+            THEN config->'labels' ELSE '[]'::jsonb END
+        ) AS label
+    ), '')), 'B')
+    || setweight(to_tsvector('simple', coalesce((
+        SELECT string_agg(
+            hp.key || ' ' || coalesce(hp.value->>'val', '')
+                || ' ' || coalesce(hp.value->>'vals', ''),
+            ' ')
+        FROM jsonb_each(
+            CASE WHEN jsonb_typeof(config->'hyperparameters') = 'object'
+            THEN config->'hyperparameters' ELSE '{}'::jsonb END
+        ) AS hp
+    ), '')), 'C')
+$$;
+
+CREATE FUNCTION public.set_experiment_search_vector() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.search_vector := public.experiment_search_vector(NEW.config);
+    RETURN NEW;
+END;
+$$;
+
+ALTER TABLE public.experiments
+    ADD COLUMN search_vector tsvector;
+
+UPDATE public.experiments SET search_vector = public.experiment_search_vector(config);
+
+CREATE TRIGGER experiments_search_vector
+    BEFORE INSERT OR UPDATE OF config ON public.experiments
+    FOR EACH ROW EXECUTE PROCEDURE public.set_experiment_search_vector();
+
+CREATE INDEX ix_experiments_search_vector ON public.experiments USING gin (search_vector);
@@ -0,0 +1,4 @@
+DROP TABLE public.tasks;
+
+DROP TYPE public.task_state;
+DROP TYPE public.task_type;
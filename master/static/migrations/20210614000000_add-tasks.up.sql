@@ -0,0 +1,35 @@
+CREATE TYPE public.task_type AS ENUM (
+    'TRIAL',
+    'COMMAND',
+    'NOTEBOOK',
+    'SHELL',
+    'TENSORBOARD',
+    'CHECKPOINT_GC'
+);
+
+CREATE TYPE public.task_state AS ENUM (
+    'PENDING',
+    'ASSIGNED',
+    'PULLING',
+    'STARTING',
+    'RUNNING',
+    'TERMINATED'
+);
+
+CREATE TABLE public.tasks (
+    task_id text PRIMARY KEY,
+    task_type public.task_type NOT NULL,
+    state public.task_state NOT NULL,
+    description text NOT NULL,
+    owner_id integer REFERENCES public.users(id),
+    project_id integer,
+    trial_id integer,
+    resource_pool text NOT NULL,
+    start_time timestamp without time zone NOT NULL,
+    end_time timestamp without time zone
+);
+
+-- Most queries are for the tasks that have not terminated yet.
+CREATE INDEX ix_tasks_unterminated ON public.tasks USING btree (start_time)
+    WHERE state != 'TERMINATED';
+CREATE INDEX ix_tasks_trial_id ON public.tasks USING btree (trial_id);
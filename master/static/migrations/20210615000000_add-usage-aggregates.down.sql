@@ -0,0 +1,5 @@
+DROP TABLE public.usage_aggregates;
+
+ALTER TABLE public.tasks
+    DROP COLUMN slots,
+    DROP COLUMN assigned_time;
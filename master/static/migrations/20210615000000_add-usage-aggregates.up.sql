@@ -0,0 +1,16 @@
+ALTER TABLE public.tasks
+    ADD COLUMN slots integer NOT NULL DEFAULT 0,
+    ADD COLUMN assigned_time timestamp without time zone;
+
+-- The slot-seconds that tasks were assigned each day, grouped by who owns them, their project, the
+-- resource pool they ran in and their type.
+CREATE TABLE public.usage_aggregates (
+    date date NOT NULL,
+    username text NOT NULL,
+    project_id integer NOT NULL,
+    resource_pool text NOT NULL,
+    task_type public.task_type NOT NULL,
+    slot_seconds float NOT NULL,
+    CONSTRAINT usage_aggregates_keys_unique
+        UNIQUE (date, username, project_id, resource_pool, task_type)
+);
@@ -0,0 +1,15 @@
+-- Merge the usage of the experiments of each day into one row.
+WITH removed AS (
+    DELETE FROM public.usage_aggregates WHERE experiment_id != 0 RETURNING *
+)
+INSERT INTO public.usage_aggregates
+    (date, username, project_id, experiment_id, resource_pool, task_type, slot_seconds)
+SELECT date, username, project_id, 0, resource_pool, task_type, sum(slot_seconds)
+FROM removed
+GROUP BY date, username, project_id, resource_pool, task_type;
+
+ALTER TABLE public.usage_aggregates
+    DROP CONSTRAINT usage_aggregates_keys_unique,
+    DROP COLUMN experiment_id,
+    ADD CONSTRAINT usage_aggregates_keys_unique
+        UNIQUE (date, username, project_id, resource_pool, task_type);
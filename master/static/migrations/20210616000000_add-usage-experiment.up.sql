@@ -0,0 +1,6 @@
+-- Usage of trials is also grouped by their experiment, which is 0 for other tasks.
+ALTER TABLE public.usage_aggregates
+    ADD COLUMN experiment_id integer NOT NULL DEFAULT 0,
+    DROP CONSTRAINT usage_aggregates_keys_unique,
+    ADD CONSTRAINT usage_aggregates_keys_unique
+        UNIQUE (date, username, project_id, experiment_id, resource_pool, task_type);
@@ -0,0 +1,6 @@
+DROP INDEX public.ix_tasks_end_time;
+
+DROP TABLE public.budgets;
+
+DROP TYPE public.budget_mode;
+DROP TYPE public.budget_unit;
@@ -0,0 +1,24 @@
+CREATE TYPE public.budget_unit AS ENUM (
+    'SLOT_HOURS',
+    'COST'
+);
+
+CREATE TYPE public.budget_mode AS ENUM (
+    'SOFT',
+    'HARD'
+);
+
+-- Each budget limits the usage of the tasks of either a user or a project each month.
+CREATE TABLE public.budgets (
+    id SERIAL PRIMARY KEY,
+    user_id integer REFERENCES public.users(id) ON DELETE CASCADE,
+    project_id integer,
+    unit public.budget_unit NOT NULL,
+    limit_value float NOT NULL CHECK (limit_value >= 0),
+    mode public.budget_mode NOT NULL,
+    preempt boolean NOT NULL DEFAULT false,
+    CHECK ((user_id IS NULL) != (project_id IS NULL))
+);
+
+-- Budgets add up the usage of the tasks that ran during the current month.
+CREATE INDEX ix_tasks_end_time ON public.tasks USING btree (end_time);
@@ -0,0 +1,13 @@
+-- Each webhook is notified when an experiment ends in one of its states. Webhooks of a project are
+-- the default for the experiments in it that have no webhooks of their own.
+CREATE TABLE public.webhooks (
+    id SERIAL PRIMARY KEY,
+    project_id integer REFERENCES public.projects(id) ON DELETE CASCADE,
+    experiment_id integer REFERENCES public.experiments(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    states public.experiment_state[] NOT NULL,
+    CHECK ((project_id IS NULL) != (experiment_id IS NULL))
+);
+
+CREATE INDEX ix_webhooks_project_id ON public.webhooks USING btree (project_id);
+CREATE INDEX ix_webhooks_experiment_id ON public.webhooks USING btree (experiment_id);
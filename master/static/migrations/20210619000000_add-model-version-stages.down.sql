@@ -0,0 +1,3 @@
+ALTER TABLE public.model_versions DROP COLUMN stage, DROP COLUMN labels;
+
+DROP TYPE public.model_version_stage;
@@ -0,0 +1,10 @@
+CREATE TYPE public.model_version_stage AS ENUM (
+    'NONE',
+    'STAGING',
+    'PRODUCTION',
+    'ARCHIVED'
+);
+
+ALTER TABLE public.model_versions
+    ADD COLUMN labels jsonb NOT NULL DEFAULT '[]',
+    ADD COLUMN stage public.model_version_stage NOT NULL DEFAULT 'NONE';
@@ -0,0 +1,12 @@
+DELETE FROM public.model_versions
+WHERE checkpoint_uuid IN (SELECT uuid FROM public.raw_checkpoints WHERE trial_id IS NULL);
+DELETE FROM public.raw_checkpoints WHERE trial_id IS NULL;
+
+DROP VIEW checkpoints;
+
+ALTER TABLE public.raw_checkpoints
+    DROP COLUMN import_source,
+    ALTER COLUMN trial_id SET NOT NULL;
+
+CREATE VIEW checkpoints AS
+    SELECT * FROM raw_checkpoints WHERE NOT archived;
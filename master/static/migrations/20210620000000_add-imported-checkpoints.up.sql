@@ -0,0 +1,8 @@
+-- Checkpoints imported from other clusters belong to no trial here. Their source holds what the
+-- trials and experiments of other checkpoints tell about them.
+ALTER TABLE public.raw_checkpoints
+    ALTER COLUMN trial_id DROP NOT NULL,
+    ADD COLUMN import_source jsonb;
+
+CREATE OR REPLACE VIEW checkpoints AS
+    SELECT * FROM raw_checkpoints WHERE NOT archived;
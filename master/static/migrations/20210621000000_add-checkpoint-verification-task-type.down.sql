@@ -0,0 +1,17 @@
+DELETE FROM public.tasks WHERE task_type = 'CHECKPOINT_VERIFICATION';
+
+ALTER TYPE public.task_type RENAME TO _task_type;
+
+CREATE TYPE public.task_type AS ENUM (
+    'TRIAL',
+    'COMMAND',
+    'NOTEBOOK',
+    'SHELL',
+    'TENSORBOARD',
+    'CHECKPOINT_GC'
+);
+
+ALTER TABLE public.tasks
+    ALTER COLUMN task_type TYPE public.task_type USING task_type::text::public.task_type;
+
+DROP TYPE public._task_type;
@@ -0,0 +1,11 @@
+DROP VIEW checkpoints;
+
+ALTER TABLE public.raw_checkpoints
+    DROP COLUMN digests,
+    DROP COLUMN integrity,
+    DROP COLUMN verified_time;
+
+DROP TYPE public.checkpoint_integrity;
+
+CREATE VIEW checkpoints AS
+    SELECT * FROM raw_checkpoints WHERE NOT archived;
@@ -0,0 +1,16 @@
+CREATE TYPE public.checkpoint_integrity AS ENUM (
+    'UNVERIFIED',
+    'VERIFIED',
+    'CORRUPTED',
+    'MISSING'
+);
+
+-- Digests map the paths of the files of a checkpoint to their SHA-256 digests. Checkpoints saved
+-- before digests were recorded are only verified against the sizes of their files.
+ALTER TABLE public.raw_checkpoints
+    ADD COLUMN digests jsonb,
+    ADD COLUMN integrity public.checkpoint_integrity NOT NULL DEFAULT 'UNVERIFIED',
+    ADD COLUMN verified_time timestamp without time zone;
+
+CREATE OR REPLACE VIEW checkpoints AS
+    SELECT * FROM raw_checkpoints WHERE NOT archived;
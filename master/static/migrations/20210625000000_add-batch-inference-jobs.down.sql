@@ -0,0 +1 @@
+DROP TABLE public.batch_inference_jobs;
@@ -0,0 +1,22 @@
+-- The state, owner and times of a batch inference job are those of its task.
+CREATE TABLE public.batch_inference_jobs (
+    task_id text PRIMARY KEY REFERENCES public.tasks(task_id) ON DELETE CASCADE,
+    checkpoint_uuid uuid NOT NULL,
+    -- The model version that the checkpoint was chosen by, if any.
+    model_name text,
+    model_version integer,
+    predictor text NOT NULL,
+    input_path text NOT NULL,
+    output_path text NOT NULL,
+    -- The percentage of the input files that predictions were made for.
+    progress double precision NOT NULL DEFAULT 0,
+    records_processed bigint NOT NULL DEFAULT 0,
+    -- How the container of the job exited, which is NULL until it does.
+    succeeded boolean,
+    exit_status text
+);
+
+CREATE INDEX ix_batch_inference_jobs_checkpoint_uuid
+    ON public.batch_inference_jobs USING btree (checkpoint_uuid);
+CREATE INDEX ix_batch_inference_jobs_model
+    ON public.batch_inference_jobs USING btree (model_name, model_version);
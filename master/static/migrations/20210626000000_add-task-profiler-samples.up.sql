@@ -0,0 +1,19 @@
+-- Samples of how heavily the containers of tasks used the resources of their agents, which agents
+-- take every profiling period.
+CREATE TABLE public.task_profiler_samples (
+    id bigserial PRIMARY KEY,
+    task_id text NOT NULL REFERENCES public.tasks(task_id) ON DELETE CASCADE,
+    container_id text NOT NULL,
+    agent_id text NOT NULL,
+    ts timestamp with time zone NOT NULL,
+    -- A percentage of one core.
+    cpu_utilization_percent double precision NOT NULL,
+    memory_used_bytes bigint NOT NULL,
+    network_rx_bytes_per_second double precision NOT NULL,
+    network_tx_bytes_per_second double precision NOT NULL,
+    -- The device ID, utilization and memory of each GPU of the container.
+    gpus jsonb NOT NULL DEFAULT '[]'::jsonb
+);
+
+CREATE INDEX ix_task_profiler_samples_task_id_ts
+    ON public.task_profiler_samples USING btree (task_id, ts);
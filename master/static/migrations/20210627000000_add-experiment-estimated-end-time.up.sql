@@ -0,0 +1,2 @@
+ALTER TABLE public.experiments
+    ADD COLUMN estimated_end_time timestamptz NULL;
@@ -0,0 +1,3 @@
+DROP TABLE public.task_datasets;
+DROP TABLE public.experiment_datasets;
+DROP TABLE public.datasets;
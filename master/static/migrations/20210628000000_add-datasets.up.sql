@@ -0,0 +1,30 @@
+-- Versions of datasets that experiments and commands declare that they use.
+CREATE TABLE public.datasets (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    version text NOT NULL,
+    checksum text NOT NULL DEFAULT '',
+    location text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    owner_id integer NOT NULL REFERENCES public.users(id),
+    creation_time timestamp with time zone NOT NULL DEFAULT now(),
+    UNIQUE (name, version)
+);
+
+-- The datasets that experiments and tasks were launched with. Datasets cannot be deleted while
+-- they are in use, so that the lineage of the model versions trained on them is kept.
+CREATE TABLE public.experiment_datasets (
+    experiment_id integer NOT NULL REFERENCES public.experiments(id) ON DELETE CASCADE,
+    dataset_id integer NOT NULL REFERENCES public.datasets(id),
+    PRIMARY KEY (experiment_id, dataset_id)
+);
+
+CREATE TABLE public.task_datasets (
+    task_id text NOT NULL REFERENCES public.tasks(task_id) ON DELETE CASCADE,
+    dataset_id integer NOT NULL REFERENCES public.datasets(id),
+    PRIMARY KEY (task_id, dataset_id)
+);
+
+CREATE INDEX ix_experiment_datasets_dataset_id
+    ON public.experiment_datasets USING btree (dataset_id);
+CREATE INDEX ix_task_datasets_dataset_id ON public.task_datasets USING btree (dataset_id);
@@ -0,0 +1,3 @@
+DROP TABLE public.artifacts;
+DROP TYPE public.artifact_state;
+DROP TYPE public.artifact_type;
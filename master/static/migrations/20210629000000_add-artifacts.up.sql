@@ -0,0 +1,28 @@
+CREATE TYPE public.artifact_type AS ENUM (
+    'CONTEXT',
+    'OUTPUT'
+);
+
+CREATE TYPE public.artifact_state AS ENUM (
+    'UPLOADING',
+    'COMPLETED'
+);
+
+-- Files that users upload as the contexts of their commands and that tasks upload as their
+-- outputs. Their contents are stored in the artifact storage of the master as numbered chunks.
+CREATE TABLE public.artifacts (
+    id text PRIMARY KEY,
+    name text NOT NULL,
+    type public.artifact_type NOT NULL,
+    state public.artifact_state NOT NULL DEFAULT 'UPLOADING',
+    -- The task that uploaded an output artifact.
+    task_id text REFERENCES public.tasks(task_id) ON DELETE CASCADE,
+    owner_id integer NOT NULL REFERENCES public.users(id),
+    size_bytes bigint NOT NULL DEFAULT 0,
+    num_chunks integer NOT NULL DEFAULT 0,
+    creation_time timestamp with time zone NOT NULL DEFAULT now(),
+    completion_time timestamp with time zone
+);
+
+CREATE INDEX ix_artifacts_task_id ON public.artifacts USING btree (task_id);
+CREATE INDEX ix_artifacts_owner_id ON public.artifacts USING btree (owner_id);
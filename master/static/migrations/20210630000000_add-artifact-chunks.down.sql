@@ -0,0 +1 @@
+DROP TABLE public.artifact_chunks;
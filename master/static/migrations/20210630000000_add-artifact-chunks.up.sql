@@ -0,0 +1,6 @@
+-- The chunks of artifacts when the master stores artifacts in the database, keyed by the
+-- artifact ID and the index of the chunk.
+CREATE TABLE public.artifact_chunks (
+    key text PRIMARY KEY,
+    data bytea NOT NULL
+);
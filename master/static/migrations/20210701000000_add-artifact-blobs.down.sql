@@ -0,0 +1,5 @@
+ALTER TABLE public.artifacts DROP COLUMN deduplicated;
+
+DROP TABLE public.artifact_manifests;
+
+DROP TABLE public.artifact_blobs;
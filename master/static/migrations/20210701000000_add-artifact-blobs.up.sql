@@ -0,0 +1,20 @@
+-- Chunks of artifacts that are stored once by the SHA-256 hash of their contents, so that
+-- artifacts with chunks in common, like the contexts of experiments that are submitted again with
+-- few changes, share them. Blobs that no artifact uses are kept as a cache until they are evicted.
+CREATE TABLE public.artifact_blobs (
+    hash text PRIMARY KEY,
+    size_bytes bigint NOT NULL,
+    last_used_time timestamp with time zone NOT NULL DEFAULT now()
+);
+
+-- The blobs that make up the artifacts that are uploaded by their manifests, in order.
+CREATE TABLE public.artifact_manifests (
+    artifact_id text NOT NULL REFERENCES public.artifacts(id) ON DELETE CASCADE,
+    chunk_index integer NOT NULL,
+    hash text NOT NULL,
+    PRIMARY KEY (artifact_id, chunk_index)
+);
+
+CREATE INDEX ix_artifact_manifests_hash ON public.artifact_manifests USING btree (hash);
+
+ALTER TABLE public.artifacts ADD COLUMN deduplicated boolean NOT NULL DEFAULT false;
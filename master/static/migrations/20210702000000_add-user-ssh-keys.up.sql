@@ -0,0 +1,9 @@
+CREATE TABLE public.user_ssh_keys (
+    id SERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    public_key text NOT NULL,
+    fingerprint text NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT now(),
+    CONSTRAINT user_ssh_keys_user_id_fingerprint_unique UNIQUE (user_id, fingerprint)
+);
@@ -0,0 +1,23 @@
+DELETE FROM public.tasks WHERE task_type = 'CODE_SERVER';
+DELETE FROM public.usage_aggregates WHERE task_type = 'CODE_SERVER';
+
+ALTER TYPE public.task_type RENAME TO _task_type;
+
+CREATE TYPE public.task_type AS ENUM (
+    'TRIAL',
+    'COMMAND',
+    'NOTEBOOK',
+    'SHELL',
+    'TENSORBOARD',
+    'CHECKPOINT_GC',
+    'CHECKPOINT_VERIFICATION',
+    'SERVING',
+    'BATCH_INFERENCE'
+);
+
+ALTER TABLE public.tasks
+    ALTER COLUMN task_type TYPE public.task_type USING task_type::text::public.task_type;
+ALTER TABLE public.usage_aggregates
+    ALTER COLUMN task_type TYPE public.task_type USING task_type::text::public.task_type;
+
+DROP TYPE public._task_type;
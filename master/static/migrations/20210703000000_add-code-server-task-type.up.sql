@@ -0,0 +1,2 @@
+-- Adding a value to an enum cannot be combined with other statements in the same transaction.
+ALTER TYPE public.task_type ADD VALUE 'CODE_SERVER';
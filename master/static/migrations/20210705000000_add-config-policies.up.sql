@@ -0,0 +1,11 @@
+-- Each config policy is enforced on the configs of the experiments and commands launched in either
+-- a workspace or by the members of a group.
+CREATE TABLE public.config_policies (
+    id SERIAL PRIMARY KEY,
+    workspace_id integer REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    group_id integer REFERENCES public.groups(id) ON DELETE CASCADE,
+    invariant_experiment_config jsonb NOT NULL DEFAULT '{}',
+    invariant_command_config jsonb NOT NULL DEFAULT '{}',
+    constraints jsonb NOT NULL DEFAULT '{}',
+    CHECK ((workspace_id IS NULL) != (group_id IS NULL))
+);
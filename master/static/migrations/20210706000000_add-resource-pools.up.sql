@@ -0,0 +1,6 @@
+-- Resource pools added through the API, alongside the ones in the configuration of the master. The
+-- config of each pool is its entry in resource_pools of the configuration, without its name.
+CREATE TABLE public.resource_pools (
+    name text PRIMARY KEY,
+    config jsonb NOT NULL DEFAULT '{}'
+);
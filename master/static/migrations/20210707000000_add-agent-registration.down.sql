@@ -0,0 +1,2 @@
+DROP TABLE public.agent_approvals;
+DROP TABLE public.agent_registration_tokens;
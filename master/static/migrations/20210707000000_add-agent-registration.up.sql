@@ -0,0 +1,15 @@
+-- Tokens that agents present to join the cluster, each for one resource pool or, if resource_pool
+-- is empty, for any pool.
+CREATE TABLE public.agent_registration_tokens (
+    id SERIAL PRIMARY KEY,
+    resource_pool text NOT NULL DEFAULT '',
+    description text NOT NULL DEFAULT '',
+    token_hash text NOT NULL UNIQUE,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+-- The agents that an admin approved to have tasks scheduled onto them.
+CREATE TABLE public.agent_approvals (
+    agent_id text PRIMARY KEY,
+    approved_at timestamp with time zone NOT NULL DEFAULT now()
+);
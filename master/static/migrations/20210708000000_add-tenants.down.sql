@@ -0,0 +1,4 @@
+ALTER TABLE public.workspaces DROP COLUMN tenant_id;
+ALTER TABLE public.users DROP COLUMN tenant_id;
+DROP TABLE public.tenant_resource_pools;
+DROP TABLE public.tenants;
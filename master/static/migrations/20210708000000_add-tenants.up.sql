@@ -0,0 +1,14 @@
+-- Tenants partition the users, workspaces and resource pools of the cluster.
+CREATE TABLE public.tenants (
+    id SERIAL PRIMARY KEY,
+    name text NOT NULL UNIQUE
+);
+
+-- The resource pools that belong to a tenant. A pool belongs to at most one tenant.
+CREATE TABLE public.tenant_resource_pools (
+    resource_pool text PRIMARY KEY,
+    tenant_id integer NOT NULL REFERENCES public.tenants(id) ON DELETE CASCADE
+);
+
+ALTER TABLE public.users ADD COLUMN tenant_id integer REFERENCES public.tenants(id);
+ALTER TABLE public.workspaces ADD COLUMN tenant_id integer REFERENCES public.tenants(id);
@@ -0,0 +1 @@
+DELETE FROM public.role_permissions WHERE permission = 'override_pool_routing';
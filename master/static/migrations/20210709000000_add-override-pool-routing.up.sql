@@ -0,0 +1,8 @@
+INSERT INTO public.role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM public.roles r
+JOIN (VALUES
+    ('operator', 'override_pool_routing'),
+    ('cluster_admin', 'override_pool_routing')
+) AS p (role_name, permission) ON p.role_name = r.name
+WHERE r.builtin;
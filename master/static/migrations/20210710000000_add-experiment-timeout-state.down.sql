@@ -0,0 +1,23 @@
+UPDATE public.experiments SET state = 'STOPPING_CANCELED' WHERE state = 'STOPPING_TIMEOUT';
+UPDATE public.webhooks SET states = array_remove(states, 'STOPPING_TIMEOUT');
+
+ALTER TYPE public.experiment_state RENAME TO _experiment_state;
+
+CREATE TYPE public.experiment_state AS ENUM (
+    'ACTIVE',
+    'CANCELED',
+    'COMPLETED',
+    'ERROR',
+    'PAUSED',
+    'STOPPING_CANCELED',
+    'STOPPING_COMPLETED',
+    'STOPPING_ERROR'
+);
+
+ALTER TABLE public.experiments
+    ALTER COLUMN state TYPE public.experiment_state USING state::text::public.experiment_state;
+ALTER TABLE public.webhooks
+    ALTER COLUMN states TYPE public.experiment_state[]
+    USING states::text[]::public.experiment_state[];
+
+DROP TYPE public._experiment_state;
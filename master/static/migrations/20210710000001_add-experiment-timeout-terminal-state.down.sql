@@ -0,0 +1,2 @@
+UPDATE public.experiments SET state = 'CANCELED' WHERE state = 'TIMEOUT';
+UPDATE public.webhooks SET states = array_remove(states, 'TIMEOUT');
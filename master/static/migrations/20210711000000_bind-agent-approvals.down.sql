@@ -0,0 +1,3 @@
+ALTER TABLE public.agent_approvals
+    DROP COLUMN token_id,
+    DROP COLUMN address;
@@ -0,0 +1,6 @@
+-- Approvals are bound to the address that an agent connected from and the registration token that
+-- it presented when it was approved, so that other hosts cannot join by reporting its ID. Earlier
+-- approvals are bound to neither and only hold for agents that connect with their certificates.
+ALTER TABLE public.agent_approvals
+    ADD COLUMN address text NOT NULL DEFAULT '',
+    ADD COLUMN token_id integer REFERENCES public.agent_registration_tokens(id) ON DELETE CASCADE;
@@ -0,0 +1 @@
+ALTER TABLE public.task_sessions DROP COLUMN generation;
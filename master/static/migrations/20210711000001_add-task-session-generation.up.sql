@@ -0,0 +1,3 @@
+-- The generation of the token of each task session. Rotating the token increments it, which revokes
+-- the tokens of earlier generations.
+ALTER TABLE public.task_sessions ADD COLUMN generation integer NOT NULL DEFAULT 0;
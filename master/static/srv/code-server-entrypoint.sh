@@ -0,0 +1,42 @@
+#!/usr/bin/env bash
+
+set -e
+
+WORKING_DIR="/run/determined/workdir"
+STARTUP_HOOK="startup-hook.sh"
+TOKEN_FILE="/run/determined/code-server/token"
+export PATH="/run/determined/pythonuserbase/bin:$PATH"
+if [ -z "$DET_PYTHON_EXECUTABLE" ] ; then
+    export DET_PYTHON_EXECUTABLE="python3"
+fi
+if ! /bin/which "$DET_PYTHON_EXECUTABLE" >/dev/null 2>&1 ; then
+    echo "error: unable to find python3 as \"$DET_PYTHON_EXECUTABLE\"" >&2
+    echo "please install python3 or set the environment variable DET_PYTHON_EXECUTABLE=/path/to/python3" >&2
+    exit 1
+fi
+
+# See the notebook entrypoint for why HOME is set here.
+if [ "$HOME" = "/" ] ; then
+    HOME="$(set -o pipefail; getent passwd "$(whoami)" | cut -d: -f6)" || HOME="$WORKING_DIR"
+    export HOME
+fi
+
+"$DET_PYTHON_EXECUTABLE" -m pip install -q --user /opt/determined/wheels/determined*.whl
+
+pushd ${WORKING_DIR} && test -f "${STARTUP_HOOK}" && source "${STARTUP_HOOK}" && popd
+
+# The master proxies the code server at /proxy/<task ID>/ and strips that prefix from the paths
+# of requests, so both servers are served from the root and rely on relative links.
+if /bin/which code-server >/dev/null 2>&1 ; then
+    PASSWORD="$(cat "$TOKEN_FILE")"
+    export PASSWORD
+    exec code-server --auth password --disable-telemetry --disable-update-check \
+        --bind-addr "0.0.0.0:${CODE_SERVER_PORT}" "$WORKING_DIR"
+fi
+if /bin/which openvscode-server >/dev/null 2>&1 ; then
+    exec openvscode-server --connection-token-file "$TOKEN_FILE" --disable-telemetry \
+        --host 0.0.0.0 --port "${CODE_SERVER_PORT}" --default-folder "$WORKING_DIR"
+fi
+echo "error: unable to find code-server or openvscode-server" >&2
+echo "please use an image that has one of them on its PATH, or install one in a startup hook" >&2
+exit 1
@@ -0,0 +1,26 @@
+SELECT
+    j.task_id AS task_id,
+    COALESCE(j.model_name, '') AS model_name,
+    COALESCE(j.model_version, 0) AS model_version,
+    j.checkpoint_uuid::text AS checkpoint_uuid,
+    COALESCE(tr.experiment_id, 0) AS experiment_id,
+    COALESCE(c.trial_id, 0) AS trial_id,
+    j.predictor AS predictor,
+    j.input_path AS input_path,
+    j.output_path AS output_path,
+    'STATE_' || t.state AS state,
+    j.progress AS progress,
+    j.records_processed AS records_processed,
+    t.start_time AS start_time,
+    t.end_time AS end_time,
+    COALESCE(u.username, '') AS username,
+    COALESCE(t.project_id, 0) AS project_id,
+    t.resource_pool AS resource_pool,
+    COALESCE(j.succeeded, false) AS succeeded,
+    COALESCE(j.exit_status, '') AS exit_status
+FROM batch_inference_jobs j
+JOIN tasks t ON j.task_id = t.task_id
+LEFT JOIN users u ON t.owner_id = u.id
+LEFT JOIN checkpoints c ON j.checkpoint_uuid = c.uuid
+LEFT JOIN trials tr ON c.trial_id = tr.id
+WHERE j.task_id = $1
@@ -0,0 +1,47 @@
+WITH filtered_jobs AS (
+    SELECT
+        j.task_id AS task_id,
+        COALESCE(j.model_name, '') AS model_name,
+        COALESCE(j.model_version, 0) AS model_version,
+        j.checkpoint_uuid::text AS checkpoint_uuid,
+        COALESCE(tr.experiment_id, 0) AS experiment_id,
+        COALESCE(c.trial_id, 0) AS trial_id,
+        j.predictor AS predictor,
+        j.input_path AS input_path,
+        j.output_path AS output_path,
+        'STATE_' || t.state AS state,
+        j.progress AS progress,
+        j.records_processed AS records_processed,
+        t.start_time AS start_time,
+        t.end_time AS end_time,
+        COALESCE(u.username, '') AS username,
+        COALESCE(t.project_id, 0) AS project_id,
+        t.resource_pool AS resource_pool,
+        COALESCE(j.succeeded, false) AS succeeded,
+        COALESCE(j.exit_status, '') AS exit_status
+    FROM batch_inference_jobs j
+    JOIN tasks t ON j.task_id = t.task_id
+    LEFT JOIN users u ON t.owner_id = u.id
+    LEFT JOIN checkpoints c ON j.checkpoint_uuid = c.uuid
+    LEFT JOIN trials tr ON c.trial_id = tr.id
+    WHERE
+        ($1 = '' OR j.model_name = $1)
+        AND ($2 = 0 OR j.model_version = $2)
+        AND ($3 = '' OR j.checkpoint_uuid::text = $3)
+        AND ($4 = '' OR u.username IN (SELECT unnest(string_to_array($4, ','))))
+        AND ($5 = 0 OR t.project_id = $5)
+        AND (
+            $8 = ''
+            OR coalesce(t.project_id, 1) IN (SELECT unnest(string_to_array($8, ','))::int)
+        )
+), page_info AS (
+    SELECT public.page_info((SELECT COUNT(*) AS count FROM filtered_jobs), $6, $7) AS page_info
+)
+SELECT
+   (SELECT coalesce(json_agg(paginated_jobs), '[]'::json) FROM (
+        SELECT * FROM filtered_jobs
+        ORDER BY start_time DESC, task_id
+        OFFSET (SELECT p.page_info->>'start_index' FROM page_info p)::bigint
+        LIMIT (SELECT (p.page_info->>'end_index')::bigint - (p.page_info->>'start_index')::bigint FROM page_info p)
+    ) AS paginated_jobs) AS jobs,
+    (SELECT p.page_info FROM page_info p) AS pagination
@@ -1,9 +1,9 @@
 SELECT
     c.uuid::text AS uuid,
-    e.config AS experiment_config,
-    e.id AS  experiment_id,
-    t.id AS trial_id,
-    t.hparams as hparams,
+    COALESCE(e.config, c.import_source->'experiment_config') AS experiment_config,
+    COALESCE(e.id, 0) AS experiment_id,
+    COALESCE(t.id, 0) AS trial_id,
+    COALESCE(t.hparams, c.import_source->'hparams') as hparams,
     c.total_batches AS batch_number,
     c.start_time AS start_time,
     c.end_time AS end_time,
@@ -12,12 +12,17 @@ SELECT
     COALESCE(c.framework, '') as framework,
     COALESCE(c.format, '') as format,
     COALESCE(c.determined_version, '') as determined_version,
-    v.metrics AS metrics,
-    (v.metrics->'validation_metrics'->>(e.config->'searcher'->>'metric'))::float8 AS searcher_metric,
-    'STATE_' || v.state AS validation_state,
-    'STATE_' || c.state AS state
+    'INTEGRITY_' || c.integrity AS integrity,
+    c.verified_time AS verified_time,
+    COALESCE(v.metrics, c.import_source->'metrics') AS metrics,
+    (COALESCE(v.metrics, c.import_source->'metrics')->'validation_metrics'->>(
+        COALESCE(e.config, c.import_source->'experiment_config')->'searcher'->>'metric'
+    ))::float8 AS searcher_metric,
+    COALESCE('STATE_' || v.state, c.import_source->>'validation_state') AS validation_state,
+    'STATE_' || c.state AS state,
+    COALESCE(c.import_source->>'cluster_id', '') AS import_cluster_id
 FROM checkpoints c
 LEFT JOIN validations v ON v.total_batches = c.total_batches AND v.trial_id = c.trial_id
-JOIN trials t ON c.trial_id = t.id
-JOIN experiments e ON t.experiment_id = e.id
+LEFT JOIN trials t ON c.trial_id = t.id
+LEFT JOIN experiments e ON t.experiment_id = e.id
 WHERE c.uuid = $1
@@ -13,6 +13,8 @@ SELECT
     COALESCE(c.framework, '') as framework,
     COALESCE(c.format, '') as format,
     COALESCE(c.determined_version, '') as determined_version,
+    'INTEGRITY_' || c.integrity AS integrity,
+    c.verified_time AS verified_time,
     v.metrics AS metrics,
     'STATE_' || v.state AS validation_state,
     (v.metrics->'validation_metrics'->>(e.config->'searcher'->>'metric'))::float8 AS searcher_metric
@@ -0,0 +1,14 @@
+SELECT
+    (SELECT COALESCE(json_agg(ed.experiment_id ORDER BY ed.experiment_id), '[]')
+     FROM experiment_datasets ed WHERE ed.dataset_id = $1) AS experiment_ids,
+    (SELECT COALESCE(json_agg(td.task_id ORDER BY td.task_id), '[]')
+     FROM task_datasets td WHERE td.dataset_id = $1) AS task_ids,
+    (SELECT COALESCE(json_agg(mv ORDER BY mv.model_name, mv.version), '[]')
+     FROM (
+         SELECT mv.model_name, mv.version, t.experiment_id
+         FROM model_versions mv
+         JOIN checkpoints c ON c.uuid = mv.checkpoint_uuid
+         JOIN trials t ON t.id = c.trial_id
+         JOIN experiment_datasets ed ON ed.experiment_id = t.experiment_id
+         WHERE ed.dataset_id = $1
+     ) mv) AS model_versions
@@ -10,7 +10,10 @@ SELECT
     (SELECT COUNT(*) FROM trials t WHERE e.id = t.experiment_id) AS num_trials,
     e.archived AS archived,
     COALESCE(e.progress, 0) AS progress,
-    u.username AS username
+    e.estimated_end_time AS estimated_end_time,
+    u.username AS username,
+    e.project_id AS project_id,
+    e.parent_id AS parent_id
 FROM
     experiments e
 JOIN users u ON e.owner_id = u.id
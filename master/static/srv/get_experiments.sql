@@ -11,7 +11,10 @@ WITH filtered_exps AS (
         (SELECT COUNT(*) FROM trials t WHERE e.id = t.experiment_id) AS num_trials,
         e.archived AS archived,
         COALESCE(e.progress, 0) AS progress,
-        u.username AS username
+        e.estimated_end_time AS estimated_end_time,
+        u.username AS username,
+        e.project_id AS project_id,
+        e.parent_id AS parent_id
     FROM experiments e
     JOIN users u ON e.owner_id = u.id
     WHERE
@@ -29,13 +32,21 @@ WITH filtered_exps AS (
                 ))
             )
         AND ($5 = '' OR POSITION($5 IN (e.config->>'description')) > 0)
+        AND ($8 = 0 OR e.project_id = $8)
+        AND ($9 = 0 OR e.parent_id = $9)
+        AND ($10 = '' OR e.project_id IN (SELECT unnest(string_to_array($10, ','))::int))
 ), page_info AS (
-    SELECT public.page_info((SELECT COUNT(*) AS count FROM filtered_exps), $6, $7) AS page_info
+    -- A page that continues from a cursor starts after the experiments ordered before it.
+    SELECT public.page_info(
+        (SELECT COUNT(*) AS count FROM filtered_exps),
+        $6 + (SELECT COUNT(*) FROM filtered_exps WHERE (%[2]s) IS NOT TRUE)::int,
+        $7
+    ) AS page_info
 )
 SELECT
    (SELECT coalesce(json_agg(paginated_exps), '[]'::json) FROM (
         SELECT * FROM filtered_exps
-        ORDER BY %s
+        ORDER BY %[1]s
         OFFSET (SELECT p.page_info->>'start_index' FROM page_info p)::bigint
         LIMIT (SELECT (p.page_info->>'end_index')::bigint - (p.page_info->>'start_index')::bigint FROM page_info p)
     ) AS paginated_exps) AS experiments,
@@ -1,5 +1,6 @@
 WITH mv AS (
-  SELECT version, checkpoint_uuid
+  SELECT version, checkpoint_uuid, creation_time, last_updated_time,
+      COALESCE(metadata, '{}') AS metadata, labels, 'STAGE_' || stage AS stage
     FROM model_versions
     WHERE model_name = $1 AND version = $2
 ),
@@ -9,31 +10,49 @@ m AS (
 c AS (
   SELECT
     c.uuid::text AS uuid,
-    e.config AS experiment_config,
-    e.id AS  experiment_id,
-    t.id AS trial_id,
-    t.hparams as hparams,
-    s.total_batches AS batch_number,
-    s.start_time AS start_time,
-    s.end_time AS end_time,
+    COALESCE(e.config, c.import_source->'experiment_config') AS experiment_config,
+    COALESCE(e.id, 0) AS  experiment_id,
+    COALESCE(t.id, 0) AS trial_id,
+    COALESCE(t.hparams, c.import_source->'hparams') as hparams,
+    c.total_batches AS batch_number,
+    COALESCE(s.start_time, c.start_time) AS start_time,
+    COALESCE(s.end_time, c.end_time) AS end_time,
     c.resources AS resources,
     COALESCE(c.metadata, '{}') AS metadata,
     COALESCE(c.framework, '') as framework,
     COALESCE(c.format, '') as format,
     COALESCE(c.determined_version, '') as determined_version,
-    v.metrics AS metrics,
-    'STATE_' || v.state AS validation_state,
-    'STATE_' || c.state AS state
+    'INTEGRITY_' || c.integrity AS integrity,
+    c.verified_time AS verified_time,
+    COALESCE(v.metrics, c.import_source->'metrics') AS metrics,
+    COALESCE('STATE_' || v.state, c.import_source->>'validation_state') AS validation_state,
+    'STATE_' || c.state AS state,
+    COALESCE(c.import_source->>'cluster_id', '') AS import_cluster_id
   FROM checkpoints c
-  JOIN steps s ON c.total_batches = s.total_batches AND c.trial_id = s.trial_id
-  LEFT JOIN validations v ON v.total_batches = s.total_batches AND v.trial_id = s.trial_id
-  JOIN trials t ON s.trial_id = t.id
-  JOIN experiments e ON t.experiment_id = e.id
+  LEFT JOIN steps s ON c.total_batches = s.total_batches AND c.trial_id = s.trial_id
+  LEFT JOIN validations v ON v.total_batches = c.total_batches AND v.trial_id = c.trial_id
+  LEFT JOIN trials t ON c.trial_id = t.id
+  LEFT JOIN experiments e ON t.experiment_id = e.id
   WHERE c.uuid = (SELECT checkpoint_uuid FROM mv)
 )
 SELECT
     to_json(c) AS checkpoint,
     to_json(m) AS model,
-    version AS version,
-    creation_time
+    mv.version AS version,
+    mv.creation_time,
+    mv.last_updated_time,
+    mv.metadata,
+    mv.labels,
+    mv.stage,
+    c.experiment_id,
+    c.trial_id,
+    (SELECT COALESCE(json_agg(d ORDER BY d.name, d.version), '[]')
+     FROM (
+         SELECT d.id, d.name, d.version, d.checksum, d.location, d.description, u.username,
+             d.creation_time
+         FROM experiment_datasets ed
+         JOIN datasets d ON d.id = ed.dataset_id
+         JOIN users u ON u.id = d.owner_id
+         WHERE ed.experiment_id = c.experiment_id
+     ) d) AS datasets
     FROM c, m, mv
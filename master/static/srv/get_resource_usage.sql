@@ -0,0 +1,48 @@
+WITH periods AS (
+    SELECT
+        date_trunc($3, usage_aggregates.date :: timestamp) AS period_start,
+        username,
+        project_id,
+        experiment_id,
+        resource_pool,
+        task_type,
+        sum(slot_seconds) AS slot_seconds
+    FROM
+        usage_aggregates
+    WHERE
+        usage_aggregates.date BETWEEN $1 :: date AND $2 :: date
+        AND ($4 = '' OR username IN (SELECT unnest(string_to_array($4, ','))))
+        AND ($5 = 0 OR project_id = $5)
+        AND ($6 = '' OR resource_pool IN (SELECT unnest(string_to_array($6, ','))))
+        AND ($7 = '' OR task_type IN (SELECT unnest(string_to_array($7, ','))::task_type))
+        AND ($8 = 0 OR experiment_id = $8)
+    GROUP BY
+        date_trunc($3, usage_aggregates.date :: timestamp),
+        username,
+        project_id,
+        experiment_id,
+        resource_pool,
+        task_type
+)
+SELECT
+    to_char(period_start, CASE WHEN $3 = 'month' THEN 'YYYY-MM' ELSE 'YYYY-MM-DD' END)
+        AS period_start,
+    CASE
+        WHEN $3 = 'month' THEN 'RESOURCE_ALLOCATION_AGGREGATION_PERIOD_MONTHLY'
+        ELSE 'RESOURCE_ALLOCATION_AGGREGATION_PERIOD_DAILY'
+    END AS period,
+    username,
+    project_id,
+    experiment_id,
+    resource_pool,
+    'TASK_TYPE_' || task_type AS task_type,
+    slot_seconds / 3600 AS slot_hours
+FROM
+    periods
+ORDER BY
+    period_start,
+    username,
+    project_id,
+    experiment_id,
+    resource_pool,
+    task_type
@@ -0,0 +1,21 @@
+SELECT coalesce(json_agg(samples), '[]'::json) AS samples FROM (
+    SELECT
+        s.task_id AS task_id,
+        s.container_id AS container_id,
+        s.agent_id AS agent_id,
+        s.ts AS time,
+        s.cpu_utilization_percent AS cpu_utilization_percent,
+        s.memory_used_bytes AS memory_used_bytes,
+        s.network_rx_bytes_per_second AS network_rx_bytes_per_second,
+        s.network_tx_bytes_per_second AS network_tx_bytes_per_second,
+        s.gpus AS gpus
+    FROM task_profiler_samples s
+    WHERE
+        (
+            s.task_id = $1
+            OR s.task_id IN (SELECT t.task_id FROM tasks t WHERE $2 > 0 AND t.trial_id = $2)
+        )
+        AND ($3::timestamptz IS NULL OR s.ts > $3)
+    ORDER BY s.ts, s.id
+    LIMIT $4
+) AS samples
@@ -0,0 +1,42 @@
+WITH filtered_tasks AS (
+    SELECT
+        t.task_id AS id,
+        'TASK_TYPE_' || t.task_type AS type,
+        'STATE_' || t.state AS state,
+        t.description AS description,
+        COALESCE(u.username, '') AS username,
+        COALESCE(t.project_id, 0) AS project_id,
+        COALESCE(t.trial_id, 0) AS trial_id,
+        COALESCE(tr.experiment_id, 0) AS experiment_id,
+        t.resource_pool AS resource_pool,
+        t.start_time AS start_time,
+        t.end_time AS end_time
+    FROM tasks t
+    LEFT JOIN users u ON t.owner_id = u.id
+    LEFT JOIN trials tr ON t.trial_id = tr.id
+    LEFT JOIN experiments e ON tr.experiment_id = e.id
+    WHERE
+        ($1 = '' OR t.task_type IN (SELECT unnest(string_to_array($1, ','))::task_type))
+        -- Tasks that have not terminated are returned unless states are given.
+        AND (
+            ($2 = '' AND t.state != 'TERMINATED')
+            OR t.state IN (SELECT unnest(string_to_array($2, ','))::task_state)
+        )
+        AND ($3 = '' OR u.username IN (SELECT unnest(string_to_array($3, ','))))
+        AND ($4 = 0 OR t.project_id = $4)
+        AND (
+            $7 = ''
+            OR coalesce(t.project_id, e.project_id, 1)
+                IN (SELECT unnest(string_to_array($7, ','))::int)
+        )
+), page_info AS (
+    SELECT public.page_info((SELECT COUNT(*) AS count FROM filtered_tasks), $5, $6) AS page_info
+)
+SELECT
+   (SELECT coalesce(json_agg(paginated_tasks), '[]'::json) FROM (
+        SELECT * FROM filtered_tasks
+        ORDER BY start_time DESC, id
+        OFFSET (SELECT p.page_info->>'start_index' FROM page_info p)::bigint
+        LIMIT (SELECT (p.page_info->>'end_index')::bigint - (p.page_info->>'start_index')::bigint FROM page_info p)
+    ) AS paginated_tasks) AS tasks,
+    (SELECT p.page_info FROM page_info p) AS pagination
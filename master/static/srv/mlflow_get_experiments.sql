@@ -0,0 +1,16 @@
+SELECT e.id,
+       e.config->>'name' AS name,
+       coalesce(e.config->>'description', '') AS description,
+       coalesce(e.config->'labels', '[]'::jsonb) AS labels,
+       e.archived,
+       e.start_time,
+       e.end_time,
+       u.username
+FROM experiments e
+  JOIN users u ON e.owner_id = u.id
+WHERE ($1 = 0 OR e.id = $1)
+  AND ($2 = '' OR e.config->>'name' = $2)
+  AND ($3 = 'ALL' OR e.archived = ($3 = 'DELETED_ONLY'))
+ORDER BY e.id DESC
+OFFSET $4
+LIMIT $5
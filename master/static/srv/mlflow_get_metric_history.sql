@@ -0,0 +1,18 @@
+SELECT (s.metrics->'avg_metrics'->>$3)::float8 AS value,
+       (extract(epoch FROM coalesce(s.end_time, s.start_time)) * 1000)::bigint AS "timestamp",
+       s.total_batches AS step
+FROM steps s
+WHERE s.trial_id = $1
+  AND $2 = 'training'
+  AND s.state = 'COMPLETED'
+  AND jsonb_typeof(s.metrics->'avg_metrics'->$3) = 'number'
+UNION ALL
+SELECT (v.metrics->'validation_metrics'->>$3)::float8 AS value,
+       (extract(epoch FROM coalesce(v.end_time, v.start_time)) * 1000)::bigint AS "timestamp",
+       v.total_batches AS step
+FROM validations v
+WHERE v.trial_id = $1
+  AND $2 = 'validation'
+  AND v.state = 'COMPLETED'
+  AND jsonb_typeof(v.metrics->'validation_metrics'->$3) = 'number'
+ORDER BY step
@@ -0,0 +1,41 @@
+SELECT t.id,
+       t.experiment_id,
+       t.state,
+       t.start_time,
+       t.end_time,
+       t.hparams,
+       e.archived,
+       u.username,
+       (SELECT coalesce(jsonb_agg(m ORDER BY m.key), '[]'::jsonb)
+        FROM (
+          (SELECT DISTINCT ON (k.key)
+                  'training/' || k.key AS key,
+                  (k.value #>> '{}')::float8 AS value,
+                  (extract(epoch FROM coalesce(s.end_time, s.start_time)) * 1000)::bigint AS "timestamp",
+                  s.total_batches AS step
+           FROM steps s, jsonb_each(s.metrics->'avg_metrics') k
+           WHERE s.trial_id = t.id
+             AND s.state = 'COMPLETED'
+             AND jsonb_typeof(k.value) = 'number'
+           ORDER BY k.key, s.total_batches DESC)
+          UNION ALL
+          (SELECT DISTINCT ON (k.key)
+                  'validation/' || k.key AS key,
+                  (k.value #>> '{}')::float8 AS value,
+                  (extract(epoch FROM coalesce(v.end_time, v.start_time)) * 1000)::bigint AS "timestamp",
+                  v.total_batches AS step
+           FROM validations v, jsonb_each(v.metrics->'validation_metrics') k
+           WHERE v.trial_id = t.id
+             AND v.state = 'COMPLETED'
+             AND jsonb_typeof(k.value) = 'number'
+           ORDER BY k.key, v.total_batches DESC)
+        ) m) AS metrics
+FROM trials t
+  JOIN experiments e ON t.experiment_id = e.id
+  JOIN users u ON e.owner_id = u.id
+WHERE ($1 = 0 OR t.id = $1)
+  AND ($2 = '' OR t.experiment_id IN (SELECT unnest(string_to_array($2, ','))::int))
+  AND ($3 = 'ALL' OR e.archived = ($3 = 'DELETED_ONLY'))
+ORDER BY t.id DESC
+OFFSET $4
+LIMIT $5
@@ -0,0 +1,59 @@
+#!/usr/bin/env bash
+
+set -e
+
+WORKING_DIR="/run/determined/workdir"
+STARTUP_HOOK="startup-hook.sh"
+export PATH="/run/determined/pythonuserbase/bin:$PATH"
+if [ -z "$DET_PYTHON_EXECUTABLE" ] ; then
+    export DET_PYTHON_EXECUTABLE="python3"
+fi
+if ! /bin/which "$DET_PYTHON_EXECUTABLE" >/dev/null 2>&1 ; then
+    echo "error: unable to find python3 as \"$DET_PYTHON_EXECUTABLE\"" >&2
+    echo "please install python3 or set the environment variable DET_PYTHON_EXECUTABLE=/path/to/python3" >&2
+    exit 1
+fi
+
+# See the notebook entrypoint for why HOME is set here.
+if [ "$HOME" = "/" ] ; then
+    HOME="$(set -o pipefail; getent passwd "$(whoami)" | cut -d: -f6)" || HOME="$WORKING_DIR"
+    export HOME
+fi
+
+"$DET_PYTHON_EXECUTABLE" -m pip install -q --user /opt/determined/wheels/determined*.whl
+
+pushd ${WORKING_DIR} && test -f "${STARTUP_HOOK}" && source "${STARTUP_HOOK}" && popd
+
+if ! /bin/which ray >/dev/null 2>&1 ; then
+    echo "error: unable to find ray" >&2
+    echo "please use an image that has ray[default] installed, or install it in a startup hook" >&2
+    exit 1
+fi
+
+# The master only starts the workers once the container of the head runs, which may be before the
+# head listens, so they wait for it.
+if [ -n "$DET_CLUSTER_HEAD_ADDRESS" ] ; then
+    "$DET_PYTHON_EXECUTABLE" - "$DET_CLUSTER_HEAD_ADDRESS" <<'PYTHON'
+import socket
+import sys
+import time
+
+address = sys.argv[1]
+host, port = address.rsplit(":", 1)
+deadline = time.time() + 600
+while True:
+    try:
+        socket.create_connection((host, int(port)), timeout=5).close()
+        break
+    except OSError:
+        if time.time() > deadline:
+            sys.exit("error: unable to reach the head of the cluster at {}".format(address))
+        time.sleep(1)
+PYTHON
+    exec ray start --block --disable-usage-stats --address "$DET_CLUSTER_HEAD_ADDRESS"
+fi
+
+# The master proxies the dashboard at /proxy/<task ID>/ and strips that prefix from the paths of
+# requests, so it is served from the root.
+exec ray start --block --disable-usage-stats --head --port "$DET_RAY_HEAD_PORT" \
+    --dashboard-host 0.0.0.0 --dashboard-port "$DET_RAY_DASHBOARD_PORT"
@@ -0,0 +1,27 @@
+SELECT
+    'KIND_EXPERIMENT' AS kind,
+    e.id::text AS id,
+    coalesce(e.config->>'description', '') AS description,
+    CASE WHEN jsonb_typeof(e.config->'labels') = 'array'
+    THEN e.config->'labels' ELSE '[]'::jsonb END AS labels,
+    u.username AS username,
+    e.project_id AS project_id,
+    ts_rank(e.search_vector, q)::float8 AS score
+FROM experiments e
+JOIN users u ON e.owner_id = u.id,
+    to_tsquery('simple', $1) q
+WHERE e.search_vector @@ q
+    AND ($2 = '' OR e.state IN (SELECT unnest(string_to_array($2, ','))::experiment_state))
+    AND ($3 = '' OR e.archived = $3::BOOL)
+    AND ($4 = '' OR (u.username IN (SELECT unnest(string_to_array($4, ',')))))
+    AND (
+        $5 = ''
+        OR string_to_array($5, ',') <@ ARRAY(SELECT jsonb_array_elements_text(
+            CASE WHEN jsonb_typeof(e.config->'labels') = 'array'
+            THEN e.config->'labels' ELSE '[]'::jsonb END
+        ))
+    )
+    AND ($6 = 0 OR e.project_id = $6)
+    AND ($8 = '' OR e.project_id IN (SELECT unnest(string_to_array($8, ','))::int))
+ORDER BY score DESC, e.id DESC
+LIMIT $7
@@ -0,0 +1,11 @@
+-- Moving a version to staging or production may archive the other versions in the stage.
+WITH archived AS (
+  UPDATE model_versions SET stage = 'ARCHIVED', last_updated_time = current_timestamp
+  WHERE model_name = $1 AND version != $2 AND $4
+    AND $3::model_version_stage IN ('STAGING', 'PRODUCTION')
+    AND stage = $3::model_version_stage
+    AND EXISTS (SELECT 1 FROM model_versions WHERE model_name = $1 AND version = $2)
+)
+UPDATE model_versions SET stage = $3::model_version_stage, last_updated_time = current_timestamp
+WHERE model_name = $1 AND version = $2
+RETURNING version;
@@ -0,0 +1,3 @@
+UPDATE model_versions SET metadata = $3, labels = $4, last_updated_time = current_timestamp
+WHERE model_name = $1 AND version = $2
+RETURNING version;
@@ -0,0 +1,69 @@
+WITH const AS (
+    SELECT
+        tsrange(
+            $1 :: timestamp,
+            ($1 :: timestamp + interval '1 day')
+        ) AS period
+),
+-- Tasks that were assigned resources at any time during the target day, along with the
+-- slot-seconds of the overlap of their assignment with it. Tasks that have not terminated are
+-- still assigned.
+assignments AS (
+    SELECT
+        coalesce(users.username, '') AS username,
+        coalesce(tasks.project_id, 0) AS project_id,
+        coalesce(trials.experiment_id, 0) AS experiment_id,
+        coalesce(nullif(tasks.resource_pool, ''), 'default') AS resource_pool,
+        tasks.task_type,
+        extract(
+            epoch
+            FROM
+                -- `*` computes the intersection of the two ranges.
+                upper(const.period * range) - lower(const.period * range)
+        ) * tasks.slots AS slot_seconds
+    FROM
+        (
+            SELECT
+                *,
+                tsrange(assigned_time, coalesce(end_time, 'infinity')) AS range
+            FROM
+                tasks
+            WHERE
+                assigned_time IS NOT NULL
+        ) AS tasks
+        LEFT JOIN users ON tasks.owner_id = users.id
+        LEFT JOIN trials ON tasks.trial_id = trials.id,
+        const
+    WHERE
+        -- `&&` determines whether the ranges overlap.
+        const.period && tasks.range
+)
+INSERT INTO
+    usage_aggregates (
+        date,
+        username,
+        project_id,
+        experiment_id,
+        resource_pool,
+        task_type,
+        slot_seconds
+    )
+SELECT
+    lower(const.period) AS date,
+    username,
+    project_id,
+    experiment_id,
+    resource_pool,
+    task_type,
+    sum(slot_seconds) AS slot_seconds
+FROM
+    assignments,
+    const
+GROUP BY
+    lower(const.period),
+    username,
+    project_id,
+    experiment_id,
+    resource_pool,
+    task_type
+ON CONFLICT DO NOTHING
@@ -0,0 +1,79 @@
+// +build integration
+
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func addAgentToken(t *testing.T) int {
+	token := &model.AgentToken{Description: t.Name()}
+	_, err := pgDB.AddAgentToken(token)
+	assert.NilError(t, err)
+	return token.ID
+}
+
+func TestAgentApprovalIsBoundToIdentity(t *testing.T) {
+	ours, theirs := addAgentToken(t), addAgentToken(t)
+	defer func() { assert.NilError(t, pgDB.DeleteAgentToken(ours)) }()
+	id := "agent-" + uuid.New().String()
+	approved := model.AgentIdentity{Address: "10.0.0.1", TokenID: &ours}
+	assert.NilError(t, pgDB.ApproveAgent(id, approved))
+
+	// Other hosts that report the ID of the agent are not approved.
+	cases := []struct {
+		name     string
+		identity model.AgentIdentity
+		approved bool
+	}{
+		{"the approved agent", approved, true},
+		{"another host", model.AgentIdentity{Address: "10.0.0.2", TokenID: &ours}, false},
+		{"another token", model.AgentIdentity{Address: "10.0.0.1", TokenID: &theirs}, false},
+		{"no token", model.AgentIdentity{Address: "10.0.0.1"}, false},
+		{"the certificate", model.AgentIdentity{Address: "10.0.0.2", Certified: true}, true},
+	}
+	for _, tc := range cases {
+		ok, err := pgDB.AgentApproved(id, tc.identity)
+		assert.NilError(t, err)
+		assert.Equal(t, ok, tc.approved, tc.name)
+	}
+
+	// Approving the agent again binds the approval to its new identity.
+	moved := model.AgentIdentity{Address: "10.0.0.2", TokenID: &theirs}
+	assert.NilError(t, pgDB.ApproveAgent(id, moved))
+	ok, err := pgDB.AgentApproved(id, approved)
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+	ok, err = pgDB.AgentApproved(id, moved)
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+
+	// Deleting the registration token revokes the approvals that are bound to it.
+	assert.NilError(t, pgDB.DeleteAgentToken(theirs))
+	ok, err = pgDB.AgentApproved(id, model.AgentIdentity{Certified: true})
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+}
+
+func TestRevokeAgentApproval(t *testing.T) {
+	id := "agent-" + uuid.New().String()
+	identity := model.AgentIdentity{Address: "10.0.0.1"}
+	assert.NilError(t, pgDB.ApproveAgent(id, identity))
+
+	assert.NilError(t, pgDB.RevokeAgentApproval(id))
+	ok, err := pgDB.AgentApproved(id, identity)
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+	ok, err = pgDB.AgentApproved(id, model.AgentIdentity{Certified: true})
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	assert.Equal(t, errors.Cause(pgDB.RevokeAgentApproval(id)), db.ErrNotFound)
+}
@@ -0,0 +1,86 @@
+// +build integration
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/master/test/testutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+func TestCreateChildExperimentRequiresParentPermission(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, cl, creds, err := testutils.RunMaster(ctx, nil)
+	defer cancel()
+	assert.NilError(t, err, "failed to start master")
+
+	parent := testutils.ExperimentModel()
+	assert.NilError(t, pgDB.AddExperiment(parent))
+	config, err := json.Marshal(parent.Config)
+	assert.NilError(t, err)
+	req := &apiv1.CreateExperimentRequest{
+		Config:       string(config),
+		ParentId:     int32(parent.ID),
+		ValidateOnly: true,
+	}
+
+	// Another user without the edit_all permission may not inherit from the experiment.
+	otherCreds, err := testutils.UserCredentials(ctx, cl, pgDB, "other-"+uuid.New().String())
+	assert.NilError(t, err)
+	_, err = cl.CreateExperiment(otherCreds, req)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied, err)
+
+	// Its owner may.
+	_, err = cl.CreateExperiment(creds, req)
+	assert.NilError(t, err)
+}
+
+func TestForkExperimentKeepsCredentialsSecret(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, cl, creds, err := testutils.RunMaster(ctx, nil)
+	defer cancel()
+	assert.NilError(t, err, "failed to start master")
+
+	parent := testutils.ExperimentModel(testutils.ExperimentModelOptionFunc(
+		func(e *model.Experiment) {
+			storage := e.Config.RawCheckpointStorage
+			storage.RawSharedFSConfig = nil
+			storage.RawS3Config = &expconf.S3Config{
+				RawBucket:    ptrs.StringPtr("bucket"),
+				RawAccessKey: ptrs.StringPtr("access"),
+				RawSecretKey: ptrs.StringPtr("secret"),
+			}
+		}))
+	assert.NilError(t, pgDB.AddExperiment(parent))
+	req := &apiv1.ForkExperimentRequest{ExperimentId: int32(parent.ID)}
+
+	// Another user without the edit_all permission may not fork the experiment.
+	otherCreds, err := testutils.UserCredentials(ctx, cl, pgDB, "other-"+uuid.New().String())
+	assert.NilError(t, err)
+	_, err = cl.ForkExperiment(otherCreds, req)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied, err)
+
+	// Its owner may, and the child inherits the credentials without them being returned.
+	resp, err := cl.ForkExperiment(creds, req)
+	assert.NilError(t, err)
+	storage := resp.Config.AsMap()["checkpoint_storage"].(map[string]interface{})
+	assert.Equal(t, storage["access_key"], model.RedactedCredential)
+	assert.Equal(t, storage["secret_key"], model.RedactedCredential)
+
+	child, err := pgDB.ExperimentByID(int(resp.Experiment.Id))
+	assert.NilError(t, err)
+	s3 := child.Config.RawCheckpointStorage.RawS3Config
+	assert.Equal(t, *s3.RawAccessKey, "access")
+	assert.Equal(t, *s3.RawSecretKey, "secret")
+}
@@ -0,0 +1,64 @@
+// +build integration
+
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestTaskTokenRotation(t *testing.T) {
+	taskID := uuid.New().String()
+	first, err := pgDB.StartTaskSession(taskID)
+	assert.NilError(t, err)
+	defer func() { assert.NilError(t, pgDB.DeleteTaskSessionByTaskID(taskID)) }()
+	session, err := pgDB.TaskSessionByToken(first, "", "")
+	assert.NilError(t, err)
+	assert.Equal(t, session.TaskID, taskID)
+
+	// Rotating the token revokes the earlier one, which can neither be used nor rotated again.
+	second, err := pgDB.RotateTaskSession(session)
+	assert.NilError(t, err)
+	_, err = pgDB.TaskSessionByToken(first, "", "")
+	assert.Equal(t, err, db.ErrNotFound)
+	_, err = pgDB.RotateTaskSession(session)
+	assert.Equal(t, err, db.ErrNotFound)
+
+	rotated, err := pgDB.TaskSessionByToken(second, "", "")
+	assert.NilError(t, err)
+	assert.Equal(t, rotated.ID, session.ID)
+	assert.Equal(t, rotated.TaskID, taskID)
+	third, err := pgDB.RotateTaskSession(rotated)
+	assert.NilError(t, err)
+	_, err = pgDB.TaskSessionByToken(second, "", "")
+	assert.Equal(t, err, db.ErrNotFound)
+	_, err = pgDB.TaskSessionByToken(third, "", "")
+	assert.NilError(t, err)
+}
+
+func TestTaskTokenExpiresWithTask(t *testing.T) {
+	taskID := uuid.New().String()
+	token, err := pgDB.StartTaskSession(taskID)
+	assert.NilError(t, err)
+	session, err := pgDB.TaskSessionByToken(token, "", "")
+	assert.NilError(t, err)
+	rotated, err := pgDB.RotateTaskSession(session)
+	assert.NilError(t, err)
+
+	// Every token of a task stops working once the task ends, and cannot be rotated after.
+	assert.NilError(t, pgDB.DeleteTaskSessionByTaskID(taskID))
+	for _, tok := range []string{token, rotated} {
+		_, err = pgDB.TaskSessionByToken(tok, "", "")
+		assert.Equal(t, err, db.ErrNotFound)
+	}
+	_, err = pgDB.RotateTaskSession(session)
+	assert.Equal(t, err, db.ErrNotFound)
+
+	// Tokens that were not issued by the master are rejected.
+	_, err = pgDB.TaskSessionByToken("v2.public.forged", "", "")
+	assert.Equal(t, err, db.ErrNotFound)
+}
@@ -0,0 +1,79 @@
+// +build integration
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/test/testutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/workspacev1"
+)
+
+func TestWorkspaceNonMembers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, cl, _, err := testutils.RunMaster(ctx, nil)
+	defer cancel()
+	assert.NilError(t, err, "failed to start master")
+
+	ours := &model.Workspace{Name: "ours-" + uuid.New().String()}
+	theirs := &model.Workspace{Name: "theirs-" + uuid.New().String()}
+	assert.NilError(t, pgDB.AddWorkspace(ours))
+	assert.NilError(t, pgDB.AddWorkspace(theirs))
+	theirProject := &model.Project{Name: "project", WorkspaceID: theirs.ID}
+	assert.NilError(t, pgDB.AddProject(theirProject))
+
+	// The user is an operator within our workspace only.
+	username := "member-" + uuid.New().String()
+	memberCreds, err := testutils.UserCredentials(ctx, cl, pgDB, username)
+	assert.NilError(t, err)
+	assert.NilError(t, pgDB.AddRoleAssignment(model.RoleAssignment{
+		RoleName: "operator", Username: &username, WorkspaceName: &ours.Name,
+	}))
+
+	// They see and may change our workspace.
+	_, err = cl.GetWorkspace(memberCreds, &apiv1.GetWorkspaceRequest{Id: int32(ours.ID)})
+	assert.NilError(t, err)
+	_, err = cl.PostProject(memberCreds, &apiv1.PostProjectRequest{
+		Project: &workspacev1.Project{Name: "project", WorkspaceId: int32(ours.ID)},
+	})
+	assert.NilError(t, err)
+
+	// Their workspace and its projects do not exist for them.
+	workspaces, err := cl.GetWorkspaces(memberCreds, &apiv1.GetWorkspacesRequest{})
+	assert.NilError(t, err)
+	var names []string
+	for _, w := range workspaces.Workspaces {
+		names = append(names, w.Name)
+	}
+	assert.DeepEqual(t, names, []string{ours.Name})
+	_, err = cl.GetWorkspace(memberCreds, &apiv1.GetWorkspaceRequest{Id: int32(theirs.ID)})
+	assert.Equal(t, status.Code(err), codes.NotFound, err)
+	_, err = cl.GetWorkspaceProjects(
+		memberCreds, &apiv1.GetWorkspaceProjectsRequest{Id: int32(theirs.ID)})
+	assert.Equal(t, status.Code(err), codes.NotFound, err)
+
+	// They may neither change their workspace nor create experiments in it.
+	_, err = cl.PostProject(memberCreds, &apiv1.PostProjectRequest{
+		Project: &workspacev1.Project{Name: "intruder", WorkspaceId: int32(theirs.ID)},
+	})
+	assert.Equal(t, status.Code(err), codes.NotFound, err)
+	_, err = cl.DeleteProject(memberCreds, &apiv1.DeleteProjectRequest{Id: int32(theirProject.ID)})
+	assert.Equal(t, status.Code(err), codes.PermissionDenied, err)
+	config, err := json.Marshal(testutils.ExperimentModel().Config)
+	assert.NilError(t, err)
+	_, err = cl.CreateExperiment(memberCreds, &apiv1.CreateExperimentRequest{
+		Config:       string(config),
+		ProjectId:    int32(theirProject.ID),
+		ValidateOnly: true,
+	})
+	assert.Equal(t, status.Code(err), codes.PermissionDenied, err)
+}
@@ -0,0 +1,72 @@
+// +build integration
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestRestoreTablesOverPopulatedDatabase(t *testing.T) {
+	ctx := context.Background()
+	assert.NilError(t, pgDB.Migrate("file://../../../static/migrations"))
+
+	user, err := pgDB.UserByUsername("determined")
+	assert.NilError(t, err)
+	key := model.SSHKey{
+		UserID:      user.ID,
+		Name:        "backup",
+		PublicKey:   "ssh-ed25519 AAAAbackup",
+		Fingerprint: "SHA256:backup",
+	}
+	assert.NilError(t, pgDB.AddSSHKey(&key))
+	defer func() {
+		_ = pgDB.DeleteSSHKey(user.ID, key.ID)
+	}()
+	hasKey := func() bool {
+		keys, err := pgDB.SSHKeys(user.ID)
+		assert.NilError(t, err)
+		for _, k := range keys {
+			if k.ID == key.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	rows := make(map[string][]json.RawMessage)
+	assert.NilError(t, pgDB.ExportTables(ctx, db.BackupTables,
+		func(int64) error { return nil },
+		func(table string, row json.RawMessage) error {
+			rows[table] = append(rows[table], row)
+			return nil
+		}))
+	restore := func(tables []string) error {
+		return pgDB.RestoreTables(ctx, tables,
+			func(insert func(string, []json.RawMessage) error) error {
+				for _, table := range tables {
+					if len(rows[table]) == 0 {
+						continue
+					}
+					if err := insert(table, rows[table]); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+	}
+
+	// Every table that references a backed-up table is backed up, so its rows are restored.
+	assert.NilError(t, restore(db.BackupTables))
+	assert.Assert(t, hasKey())
+
+	// Restoring tables that others reference without those is refused rather than emptying them.
+	assert.ErrorContains(t, restore([]string{"users"}), "error emptying the restored tables")
+	assert.Assert(t, hasKey())
+}
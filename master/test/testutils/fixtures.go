@@ -190,3 +190,19 @@ func APICredentials(ctx context.Context, cl apiv1.DeterminedClient) (context.Con
 	return metadata.AppendToOutgoingContext(
 		ctx, "x-user-token", fmt.Sprintf("Bearer %s", resp.Token)), nil
 }
+
+// UserCredentials adds an active user without a password and returns a context with the
+// credentials of a session of theirs.
+func UserCredentials(
+	ctx context.Context, cl apiv1.DeterminedClient, pgDB *db.PgDB, username string,
+) (context.Context, error) {
+	if err := pgDB.AddUser(&model.User{Username: username, Active: true}, nil); err != nil {
+		return nil, fmt.Errorf("failed to add user %s: %w", username, err)
+	}
+	resp, err := cl.Login(context.TODO(), &apiv1.LoginRequest{Username: username})
+	if err != nil {
+		return nil, fmt.Errorf("failed to login as %s: %w", username, err)
+	}
+	return metadata.AppendToOutgoingContext(
+		ctx, "x-user-token", fmt.Sprintf("Bearer %s", resp.Token)), nil
+}
@@ -251,10 +251,14 @@ def get_defaulted_type(schema: Schema, tag: str, type: str) -> Tuple[str, str, b
     eventuallyRequired = required or tag in schema.schema.get("eventuallyRequired", [])
 
     KNOWN_MAP_OR_SLICE_ALIAS_TYPES = [
+        "AlertsConfigV0",
         "BindMountsConfigV0",
+        "DatasetsConfigV0",
         "DevicesConfigV0",
+        "EarlyStoppingConfigV0",
         "HyperparametersV0",
         "LabelsV0",
+        "SchedulingWindowsConfigV0",
         # Technically Description is a struct containing a string pointer, which exists only to
         # handle the semantics of runtime defaultables.  But it has the same mechanics as a map or
         # slice alias, so we include it here.
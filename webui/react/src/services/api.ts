@@ -13,7 +13,6 @@ import {
   ExperimentBase, ExperimentPagination, Log, ResourcePool, Telemetry, TrialDetails,
   TrialPagination, ValidationHistory,
 } from 'types';
-import { terminalCommandStates, tsbMatchesSource } from 'utils/types';
 
 export { isAuthFailure, isLoginFailure, isNotFound } from './utils';
 
@@ -174,12 +173,7 @@ export const launchTensorboard = generateDetApi<
 export const openOrCreateTensorboard = async (
   params: LaunchTensorboardParams,
 ): Promise<CommandTask> => {
-  const tensorboards = await getTensorboards({});
-  const match = tensorboards.find(tensorboard =>
-    !terminalCommandStates.has(tensorboard.state)
-    && tsbMatchesSource(tensorboard, params));
-  if (match) return match;
-  return launchTensorboard(params);
+  return launchTensorboard({ ...params, reuse: true });
 };
 
 export const killTask = async (task: CommandTask): Promise<void> => {
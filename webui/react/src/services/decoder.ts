@@ -235,6 +235,8 @@ const experimentStateMap = {
   [Sdk.Determinedexperimentv1State.COMPLETED]: types.RunState.Completed,
   [Sdk.Determinedexperimentv1State.ERROR]: types.RunState.Errored,
   [Sdk.Determinedexperimentv1State.DELETED]: types.RunState.Deleted,
+  [Sdk.Determinedexperimentv1State.STOPPINGTIMEOUT]: types.RunState.StoppingTimeout,
+  [Sdk.Determinedexperimentv1State.TIMEOUT]: types.RunState.TimedOut,
 };
 
 export const decodeCheckpointState = (
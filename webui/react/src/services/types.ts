@@ -84,7 +84,8 @@ export interface GetExperimentsParams extends PaginationParams {
   | 'SORT_BY_USER';
   states?: Array<'STATE_UNSPECIFIED' | 'STATE_ACTIVE' | 'STATE_PAUSED'
   | 'STATE_STOPPING_COMPLETED' | 'STATE_STOPPING_CANCELED' | 'STATE_STOPPING_ERROR'
-  | 'STATE_COMPLETED' | 'STATE_CANCELED' | 'STATE_ERROR' | 'STATE_DELETED'>;
+  | 'STATE_COMPLETED' | 'STATE_CANCELED' | 'STATE_ERROR' | 'STATE_DELETED'
+  | 'STATE_STOPPING_TIMEOUT' | 'STATE_TIMEOUT'>;
   users?: Array<string>;
 }
 
@@ -108,6 +109,7 @@ export interface PatchExperimentParams extends ExperimentIdParams {
 
 export interface LaunchTensorboardParams {
   experimentIds?: Array<number>;
+  reuse?: boolean;
   trialIds?: Array<number>;
 }
 
@@ -226,6 +226,8 @@ const stateColorMapping = {
   [RunState.StoppingCanceled]: 'inactive',
   [RunState.StoppingCompleted]: 'success',
   [RunState.StoppingError]: 'failed',
+  [RunState.StoppingTimeout]: 'inactive',
+  [RunState.TimedOut]: 'inactive',
   [RunState.Unspecified]: 'inactive',
   [CommandState.Pending]: 'suspended',
   [CommandState.Assigned]: 'suspended',
@@ -264,6 +264,8 @@ export enum RunState {
   Completed = 'COMPLETED',
   StoppingError = 'STOPPING_ERROR',
   Errored = 'ERROR',
+  StoppingTimeout = 'STOPPING_TIMEOUT',
+  TimedOut = 'TIMEOUT',
   Deleted = 'DELETED',
   Unspecified = 'UNSPECIFIED',
 }
@@ -11,6 +11,8 @@ const runStateSortValues: Record<RunState, number> = {
   [RunState.Completed]: 5,
   [RunState.StoppingCanceled]: 6,
   [RunState.Canceled]: 7,
+  [RunState.StoppingTimeout]: 6,
+  [RunState.TimedOut]: 7,
   [RunState.Deleted]: 7,
   [RunState.Unspecified]: 8,
 };
@@ -7,9 +7,7 @@ import {
   SlotState, Workload,
 } from 'types';
 
-import { LaunchTensorboardParams } from '../services/types';
-
-import { deletePathList, getPathList, isEqual, isNumber, setPathList } from './data';
+import { deletePathList, getPathList, isNumber, setPathList } from './data';
 import { isMetricsWorkload } from './step';
 
 /* Conversions to Tasks */
@@ -54,11 +52,13 @@ export const activeCommandStates = [
 
 export const activeRunStates: Array<
   'STATE_ACTIVE' | 'STATE_STOPPING_COMPLETED' | 'STATE_STOPPING_CANCELED' | 'STATE_STOPPING_ERROR'
+  | 'STATE_STOPPING_TIMEOUT'
 > = [
   'STATE_ACTIVE',
   'STATE_STOPPING_CANCELED',
   'STATE_STOPPING_COMPLETED',
   'STATE_STOPPING_ERROR',
+  'STATE_STOPPING_TIMEOUT',
 ];
 
 export const killableRunStates = [ RunState.Active, RunState.Paused, RunState.StoppingCanceled ];
@@ -80,6 +80,7 @@ export const terminalRunStates: Set<RunState> = new Set([
   RunState.Canceled,
   RunState.Completed,
   RunState.Errored,
+  RunState.TimedOut,
   RunState.Deleted,
 ]);
 
@@ -93,6 +94,8 @@ export const runStateToLabel: {[key in RunState]: string} = {
   [RunState.StoppingCanceled]: 'Canceling',
   [RunState.StoppingCompleted]: 'Completing',
   [RunState.StoppingError]: 'Erroring',
+  [RunState.StoppingTimeout]: 'Timing Out',
+  [RunState.TimedOut]: 'Timed Out',
   [RunState.Unspecified]: 'Unspecified',
 };
 
@@ -247,30 +250,6 @@ export const upgradeConfig = (config: RawJson): void => {
   delete config.batches_per_step;
 };
 
-// Checks whether tensorboard source matches a given source list.
-export const tsbMatchesSource =
-  (tensorboard: CommandTask, source: LaunchTensorboardParams): boolean => {
-    if (source.experimentIds) {
-      source.experimentIds?.sort();
-      tensorboard.misc?.experimentIds?.sort();
-
-      if (isEqual(tensorboard.misc?.experimentIds, source.experimentIds)) {
-        return true;
-      }
-    }
-
-    if (source.trialIds) {
-      source.trialIds?.sort();
-      tensorboard.misc?.trialIds?.sort();
-
-      if (isEqual(tensorboard.misc?.trialIds, source.trialIds)) {
-        return true;
-      }
-    }
-
-    return false;
-  };
-
 export const getMetricValue = (workload?: Workload, metricName?: string): number | undefined => {
   const metricsWl = workload as MetricsWorkload;
   if (!workload || !isMetricsWorkload(metricsWl) || !metricsWl.metrics) return undefined;